@@ -21,7 +21,7 @@ func StartClientAndServerAndWaitForConnection(ctx context.Context, t *testing.T,
 
 	internalCtx, cancelFn := context.WithCancel(ctx)
 
-	rd, rdOutChan, rdErrChan := Run(t, "", path.Join(projectRoot, "cmd/rportd/main.go"))
+	rd, rdOutChan, rdErrChan := Run(t, "", path.Join(projectRoot, "cmd/rportd"))
 	go func() {
 		for line := range rdErrChan {
 			if strings.Contains(line, "go: downloading") {
@@ -0,0 +1,262 @@
+package chclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/IOTech17/neo-rport/share/clientconfig"
+	"github.com/IOTech17/neo-rport/share/comm"
+)
+
+const (
+	defaultAgentlessMaxSessions = 8
+	agentlessDialTimeout        = 10 * time.Second
+	defaultAgentlessTimeout     = time.Minute
+	// maxAgentlessOutput limits stdout and stderr each, the rest is cut
+	maxAgentlessOutput = 1024 * 1024
+)
+
+// handleRunAgentless runs a command on a target without an rport client if allowed by the config. The result is
+// sent to the server as separate request when the command is finished.
+func (c *Client) handleRunAgentless(ctx context.Context, conn ssh.Conn, payload []byte) error {
+	cfg := c.configHolder.Agentless
+	if !cfg.Enabled {
+		return errors.New(`agentless management is disabled by "agentless.enabled" config`)
+	}
+
+	var req comm.AgentlessRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return fmt.Errorf("failed to decode %T: %v", req, err)
+	}
+	var run func(context.Context, string, comm.AgentlessRequest) comm.AgentlessResult
+	switch req.Protocol {
+	case comm.AgentlessProtocolSSH:
+		run = runSSHCommand
+	case comm.AgentlessProtocolWinRM:
+		run = runWinRMCommand
+	default:
+		return fmt.Errorf("unsupported agentless protocol %q", req.Protocol)
+	}
+
+	addr, err := agentlessTargetAddr(ctx, cfg, req, net.DefaultResolver, interfaceSubnets)
+	if err != nil {
+		return err
+	}
+
+	maxSessions := cfg.MaxSessions
+	if maxSessions <= 0 {
+		maxSessions = defaultAgentlessMaxSessions
+	}
+	if c.agentlessSessions.Add(1) > int32(maxSessions) {
+		c.agentlessSessions.Add(-1)
+		return fmt.Errorf("too many agentless sessions, the limit of %d is set by %q config", maxSessions, "agentless.max_sessions")
+	}
+	c.Debugf("Running agentless command %s on %s@%s", req.ID, req.Username, addr)
+	go func() {
+		defer c.agentlessSessions.Add(-1)
+
+		result := run(ctx, addr, req)
+		if result.Error != "" {
+			c.Infof("Agentless command %s on %s failed: %s", req.ID, addr, result.Error)
+		}
+		err := comm.SendRequestAndGetResponse(conn, comm.RequestTypeAgentlessResult, result, nil, c.Logger)
+		if err != nil {
+			c.Errorf("Failed to send result of agentless command %s: %v", req.ID, err)
+		}
+	}()
+	return nil
+}
+
+// agentlessTargetAddr resolves the host of the target, all its addresses must be allowed by the config. The first
+// address is used.
+func agentlessTargetAddr(ctx context.Context, cfg clientconfig.AgentlessConfig, req comm.AgentlessRequest, resolver *net.Resolver, localSubnets func() ([]*net.IPNet, error)) (string, error) {
+	return allowedTargetAddr(ctx, cfg.Targets, "agentless.targets", req.Host, req.Port, resolver, localSubnets)
+}
+
+// allowedTargetAddr resolves the host, all its addresses must be in the target subnets, or in the local subnets if
+// none are given. The first address is used.
+func allowedTargetAddr(ctx context.Context, targets []string, targetsConfig, host string, port int, resolver *net.Resolver, localSubnets func() ([]*net.IPNet, error)) (string, error) {
+	if port < 1 || port > 65535 {
+		return "", fmt.Errorf("invalid port %d", port)
+	}
+	allowed, err := parseAgentlessTargets(targets)
+	if err != nil {
+		return "", err
+	}
+	if len(allowed) == 0 {
+		allowed, err = localSubnets()
+		if err != nil {
+			return "", fmt.Errorf("failed to get the local subnets: %v", err)
+		}
+	}
+
+	var ips []net.IP
+	if ip := net.ParseIP(host); ip != nil {
+		ips = []net.IP{ip}
+	} else {
+		addrs, err := resolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve %s: %v", host, err)
+		}
+		for _, addr := range addrs {
+			ips = append(ips, addr.IP)
+		}
+	}
+	if len(ips) == 0 {
+		return "", fmt.Errorf("no address found for %s", host)
+	}
+	for _, ip := range ips {
+		if !ipAllowed(ip, allowed) {
+			return "", fmt.Errorf("target %s is not allowed by %q config", ip, targetsConfig)
+		}
+	}
+	return net.JoinHostPort(ips[0].String(), strconv.Itoa(port)), nil
+}
+
+func parseAgentlessTargets(cidrs []string) ([]*net.IPNet, error) {
+	subnets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, subnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid target subnet %q: %v", cidr, err)
+		}
+		subnets = append(subnets, subnet)
+	}
+	return subnets, nil
+}
+
+func ipAllowed(ip net.IP, allowed []*net.IPNet) bool {
+	for _, subnet := range allowed {
+		if subnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// runSSHCommand connects to the target and runs the command. A host key not matching the one requested fails the
+// connection before the credentials are sent. Without a requested host key the connection is closed once the key is
+// known, the target is only probed.
+func runSSHCommand(ctx context.Context, addr string, req comm.AgentlessRequest) comm.AgentlessResult {
+	result := comm.AgentlessResult{ID: req.ID, ExitCode: -1}
+
+	timeout := req.Timeout
+	if timeout <= 0 {
+		timeout = defaultAgentlessTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var auth []ssh.AuthMethod
+	if req.PrivateKey != "" {
+		signer, err := ssh.ParsePrivateKey([]byte(req.PrivateKey))
+		if err != nil {
+			result.Error = fmt.Sprintf("invalid private key: %v", err)
+			return result
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if req.Password != "" {
+		auth = append(auth, ssh.Password(req.Password))
+	}
+
+	config := &ssh.ClientConfig{
+		User: req.Username,
+		Auth: auth,
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			result.HostKey = ssh.FingerprintSHA256(key)
+			return checkHostKey("host key", result.HostKey, req.HostKey)
+		},
+		Timeout: agentlessDialTimeout,
+	}
+
+	dialer := &net.Dialer{Timeout: agentlessDialTimeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	// unblocks the handshake and the command on timeout
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			netConn.Close()
+		case <-done:
+		}
+	}()
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(netConn, addr, config)
+	if err != nil {
+		netConn.Close()
+		result.Error = err.Error()
+		return result
+	}
+	client := ssh.NewClient(sshConn, chans, reqs)
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer session.Close()
+
+	stdout := &limitedBuffer{max: maxAgentlessOutput}
+	stderr := &limitedBuffer{max: maxAgentlessOutput}
+	session.Stdout = stdout
+	session.Stderr = stderr
+	err = session.Run(req.Command)
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+
+	var exitErr *ssh.ExitError
+	switch {
+	case err == nil:
+		result.ExitCode = 0
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitStatus()
+	case ctx.Err() != nil:
+		result.Error = fmt.Sprintf("timeout after %s", timeout)
+	default:
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// checkHostKey fails if the host key presented by the target is not the pinned one, or if none is pinned.
+func checkHostKey(kind, hostKey, pinned string) error {
+	if pinned == "" {
+		return fmt.Errorf("%s %s is not pinned", kind, hostKey)
+	}
+	if hostKey != pinned {
+		return fmt.Errorf("%s %s does not match the expected %s", kind, hostKey, pinned)
+	}
+	return nil
+}
+
+// limitedBuffer keeps the first max bytes written and drops the rest.
+type limitedBuffer struct {
+	bytes.Buffer
+	max int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if free := b.max - b.Len(); free > 0 {
+		if len(p) > free {
+			b.Buffer.Write(p[:free])
+		} else {
+			b.Buffer.Write(p)
+		}
+	}
+	return len(p), nil
+}
@@ -0,0 +1,215 @@
+package chclient
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/IOTech17/neo-rport/share/clientconfig"
+	"github.com/IOTech17/neo-rport/share/comm"
+)
+
+func TestAgentlessTargetAddr(t *testing.T) {
+	localSubnets := func() ([]*net.IPNet, error) {
+		_, subnet, _ := net.ParseCIDR("192.168.1.0/24")
+		return []*net.IPNet{subnet}, nil
+	}
+	testCases := []struct {
+		name    string
+		targets []string
+		host    string
+		port    int
+		want    string
+		wantErr string
+	}{
+		{
+			name: "local subnet",
+			host: "192.168.1.10",
+			port: 22,
+			want: "192.168.1.10:22",
+		},
+		{
+			name:    "not in local subnet",
+			host:    "10.0.0.1",
+			port:    22,
+			wantErr: `target 10.0.0.1 is not allowed by "agentless.targets" config`,
+		},
+		{
+			name:    "configured targets",
+			targets: []string{"10.0.0.0/8"},
+			host:    "10.0.0.1",
+			port:    2222,
+			want:    "10.0.0.1:2222",
+		},
+		{
+			name:    "configured targets replace local subnets",
+			targets: []string{"10.0.0.0/8"},
+			host:    "192.168.1.10",
+			port:    22,
+			wantErr: `target 192.168.1.10 is not allowed by "agentless.targets" config`,
+		},
+		{
+			name:    "resolved host",
+			targets: []string{"127.0.0.0/8", "::1/128"},
+			host:    "localhost",
+			port:    22,
+		},
+		{
+			name:    "invalid port",
+			host:    "192.168.1.10",
+			wantErr: "invalid port 0",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := clientconfig.AgentlessConfig{Enabled: true, Targets: tc.targets}
+			req := comm.AgentlessRequest{Host: tc.host, Port: tc.port}
+			addr, err := agentlessTargetAddr(context.Background(), cfg, req, net.DefaultResolver, localSubnets)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			if tc.want != "" {
+				assert.Equal(t, tc.want, addr)
+			}
+		})
+	}
+}
+
+func TestRunSSHCommand(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	require.NoError(t, err)
+	addr := startTestSSHServer(t, signer, "secret")
+	ctx := context.Background()
+
+	hostKey := ssh.FingerprintSHA256(signer.PublicKey())
+	req := comm.AgentlessRequest{ID: "1", Username: "admin", Password: "secret", HostKey: hostKey, Command: "uptime"}
+	result := runSSHCommand(ctx, addr, req)
+	assert.Equal(t, comm.AgentlessResult{
+		ID:       "1",
+		HostKey:  hostKey,
+		Stdout:   "ran uptime\n",
+		Stderr:   "",
+		ExitCode: 0,
+	}, result)
+
+	req.Command = "fail"
+	result = runSSHCommand(ctx, addr, req)
+	assert.Equal(t, 3, result.ExitCode)
+	assert.Equal(t, "failed\n", result.Stderr)
+	assert.Empty(t, result.Error)
+
+	req.HostKey = "SHA256:other"
+	result = runSSHCommand(ctx, addr, req)
+	assert.Contains(t, result.Error, "does not match the expected SHA256:other")
+	assert.Equal(t, -1, result.ExitCode)
+
+	req.HostKey = ""
+	result = runSSHCommand(ctx, addr, req)
+	assert.Equal(t, comm.AgentlessResult{
+		ID:       "1",
+		HostKey:  hostKey,
+		ExitCode: -1,
+		Error:    "ssh: handshake failed: host key " + hostKey + " is not pinned",
+	}, result)
+
+	req.HostKey = hostKey
+	req.Password = "wrong"
+	result = runSSHCommand(ctx, addr, req)
+	assert.Contains(t, result.Error, "unable to authenticate")
+
+	req.Password = "secret"
+	req.Command = "sleep"
+	req.Timeout = 100 * time.Millisecond
+	result = runSSHCommand(ctx, addr, req)
+	assert.Equal(t, "timeout after 100ms", result.Error)
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	b := &limitedBuffer{max: 5}
+	n, err := b.Write([]byte("abc"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	n, err = b.Write([]byte("defg"))
+	require.NoError(t, err)
+	assert.Equal(t, 4, n)
+	assert.Equal(t, "abcde", b.String())
+}
+
+// startTestSSHServer answers "exec" requests: "fail" exits with 3, "sleep" never returns, others echo the command.
+func startTestSSHServer(t *testing.T, hostKey ssh.Signer, password string) string {
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if string(pass) != password {
+				return nil, ssh.ErrNoAuth
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serveTestSSHConn(conn, config)
+		}
+	}()
+	return l.Addr().String()
+}
+
+func serveTestSSHConn(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChan := range chans {
+		ch, chReqs, err := newChan.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer ch.Close()
+			for req := range chReqs {
+				if req.Type != "exec" {
+					_ = req.Reply(false, nil)
+					continue
+				}
+				_ = req.Reply(true, nil)
+				command := string(req.Payload[4:])
+				status := make([]byte, 4)
+				switch {
+				case command == "sleep":
+					time.Sleep(time.Second)
+					return
+				case strings.HasPrefix(command, "fail"):
+					_, _ = ch.Stderr().Write([]byte("failed\n"))
+					binary.BigEndian.PutUint32(status, 3)
+				default:
+					_, _ = ch.Write([]byte("ran " + command + "\n"))
+				}
+				_, _ = ch.SendRequest("exit-status", false, status)
+				return
+			}
+		}()
+	}
+}
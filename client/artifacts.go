@@ -0,0 +1,214 @@
+package chclient
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IOTech17/neo-rport/share/files"
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+// ArtifactsDir is the directory within the data directory the artifacts pushed from the server's repository are
+// cached in
+const ArtifactsDir = "artifacts"
+
+// maxCachedArtifactVersions is the number of versions cached per artifact, the least recently used are dropped
+const maxCachedArtifactVersions = 3
+
+// maxArtifactChunkSize limits the buffer of a chunk, larger chunks are downloaded as a whole file
+const maxArtifactChunkSize = 64 << 20
+
+// artifactCache keeps the content of the artifacts in one directory per artifact, one file per version named by
+// its sha256 sum.
+type artifactCache struct {
+	dir string
+	mtx sync.Mutex
+}
+
+type localChunk struct {
+	file   *os.File
+	offset int64
+}
+
+func newArtifactCache(dir string) *artifactCache {
+	return &artifactCache{
+		dir: dir,
+	}
+}
+
+// Get returns the path of the cached content of the artifact and the number of bytes downloaded. A version that
+// isn't cached yet is downloaded in chunks, the chunks the cached versions of the artifact have are copied locally.
+func (c *artifactCache) Get(ref *models.ArtifactRef, remotePath string, source SourceFileProvider) (string, int64, error) {
+	if err := ref.Validate(); err != nil {
+		return "", 0, err
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	dir := filepath.Join(c.dir, ref.Name)
+	path := filepath.Join(dir, ref.Sha256)
+	_, err := os.Stat(path)
+	if err == nil {
+		now := time.Now()
+		return path, 0, os.Chtimes(path, now, now)
+	}
+	if !os.IsNotExist(err) {
+		return "", 0, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", 0, err
+	}
+
+	remote, err := source.Open(remotePath)
+	if err != nil {
+		return "", 0, err
+	}
+	defer remote.Close()
+
+	tmp, err := os.CreateTemp(dir, ".download-")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	sum := sha256.New()
+	w := io.MultiWriter(tmp, sum)
+	var downloaded int64
+	if ra, ok := remote.(io.ReaderAt); ok && hasValidChunks(ref) {
+		downloaded, err = c.assemble(w, ref, ra, dir)
+	} else {
+		downloaded, err = io.Copy(w, remote)
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	if got := hex.EncodeToString(sum.Sum(nil)); got != ref.Sha256 {
+		return "", 0, fmt.Errorf("checksum of artifact %s@%s doesn't match, got sha256 %s, expected %s", ref.Name, ref.Version, got, ref.Sha256)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", 0, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", 0, err
+	}
+
+	return path, downloaded, c.prune(dir)
+}
+
+// assemble writes the chunks of the version, reading the ones the cached versions don't have from the remote file.
+func (c *artifactCache) assemble(w io.Writer, ref *models.ArtifactRef, remote io.ReaderAt, dir string) (int64, error) {
+	cached, err := cachedVersions(dir)
+	if err != nil {
+		return 0, err
+	}
+	local := make(map[string]localChunk)
+	for _, p := range cached {
+		f, err := os.Open(p)
+		if err != nil {
+			return 0, err
+		}
+		defer f.Close()
+		h := files.NewChunkHasher(ref.ChunkSize)
+		if _, err := io.Copy(h, f); err != nil {
+			return 0, err
+		}
+		for i, s := range h.Sums() {
+			if _, ok := local[s]; !ok {
+				local[s] = localChunk{file: f, offset: int64(i) * ref.ChunkSize}
+			}
+		}
+	}
+
+	var downloaded int64
+	buf := make([]byte, ref.ChunkSize)
+	for i, s := range ref.Chunks {
+		offset := int64(i) * ref.ChunkSize
+		n := ref.SizeBytes - offset
+		if n > ref.ChunkSize {
+			n = ref.ChunkSize
+		}
+		var src io.ReaderAt = remote
+		srcOffset := offset
+		if lc, ok := local[s]; ok {
+			src, srcOffset = lc.file, lc.offset
+		} else {
+			downloaded += n
+		}
+		read, err := src.ReadAt(buf[:n], srcOffset)
+		if int64(read) < n {
+			if err == nil || err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		if _, err := w.Write(buf[:n]); err != nil {
+			return 0, err
+		}
+	}
+	return downloaded, nil
+}
+
+// prune drops the least recently used versions beyond maxCachedArtifactVersions.
+func (c *artifactCache) prune(dir string) error {
+	cached, err := cachedVersions(dir)
+	if err != nil {
+		return err
+	}
+	for len(cached) > maxCachedArtifactVersions {
+		if err := os.Remove(cached[0]); err != nil {
+			return err
+		}
+		cached = cached[1:]
+	}
+	return nil
+}
+
+func hasValidChunks(ref *models.ArtifactRef) bool {
+	if ref.ChunkSize > maxArtifactChunkSize {
+		return false
+	}
+	return int64(len(ref.Chunks)) == (ref.SizeBytes+ref.ChunkSize-1)/ref.ChunkSize
+}
+
+// cachedVersions returns the files of the cached versions, the least recently used first.
+func cachedVersions(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	type version struct {
+		path    string
+		modTime time.Time
+	}
+	versions := make([]version, 0, len(entries))
+	for _, e := range entries {
+		// skips unfinished downloads
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, version{path: filepath.Join(dir, e.Name()), modTime: info.ModTime()})
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].modTime.Before(versions[j].modTime)
+	})
+
+	paths := make([]string, 0, len(versions))
+	for _, v := range versions {
+		paths = append(paths, v.path)
+	}
+	return paths, nil
+}
@@ -0,0 +1,133 @@
+package chclient
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/share/files"
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+const testArtifactChunkSize = 4
+
+type remoteArtifactFile struct {
+	*bytes.Reader
+	read *int64
+}
+
+func (f remoteArtifactFile) ReadAt(p []byte, off int64) (int, error) {
+	n, err := f.Reader.ReadAt(p, off)
+	*f.read += int64(n)
+	return n, err
+}
+
+func (f remoteArtifactFile) Close() error {
+	return nil
+}
+
+// remoteArtifacts serves the artifacts by path and counts the bytes read
+type remoteArtifacts struct {
+	content map[string][]byte
+	read    int64
+}
+
+func (r *remoteArtifacts) Open(path string) (io.ReadCloser, error) {
+	content, ok := r.content[path]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return remoteArtifactFile{Reader: bytes.NewReader(content), read: &r.read}, nil
+}
+
+func testArtifactRef(version string, content []byte) *models.ArtifactRef {
+	sum := sha256.Sum256(content)
+	chunks := files.NewChunkHasher(testArtifactChunkSize)
+	_, _ = chunks.Write(content)
+	return &models.ArtifactRef{
+		Name:      "agent",
+		Version:   version,
+		Sha256:    hex.EncodeToString(sum[:]),
+		SizeBytes: int64(len(content)),
+		ChunkSize: testArtifactChunkSize,
+		Chunks:    chunks.Sums(),
+	}
+}
+
+func TestArtifactCacheDownloadsMissingChunks(t *testing.T) {
+	c := newArtifactCache(t.TempDir())
+	remote := &remoteArtifacts{content: map[string][]byte{
+		"v1": []byte("aaaabbbbcc"),
+		"v2": []byte("aaaaXXXXcc"),
+	}}
+
+	v1 := testArtifactRef("1", remote.content["v1"])
+	path, downloaded, err := c.Get(v1, "v1", remote)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), downloaded)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, remote.content["v1"], content)
+
+	// only the changed chunk is downloaded
+	v2 := testArtifactRef("2", remote.content["v2"])
+	remote.read = 0
+	path, downloaded, err = c.Get(v2, "v2", remote)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), downloaded)
+	assert.Equal(t, int64(4), remote.read)
+	content, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, remote.content["v2"], content)
+
+	// cached
+	remote.read = 0
+	_, downloaded, err = c.Get(v1, "v1", remote)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), downloaded)
+	assert.Equal(t, int64(0), remote.read)
+}
+
+func TestArtifactCacheChecksumMismatch(t *testing.T) {
+	dir := t.TempDir()
+	c := newArtifactCache(dir)
+	remote := &remoteArtifacts{content: map[string][]byte{"v1": []byte("changed")}}
+
+	_, _, err := c.Get(testArtifactRef("1", []byte("content")), "v1", remote)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "checksum of artifact agent@1 doesn't match")
+
+	entries, err := os.ReadDir(filepath.Join(dir, "agent"))
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestArtifactCachePrunesLeastRecentlyUsed(t *testing.T) {
+	dir := t.TempDir()
+	c := newArtifactCache(dir)
+	remote := &remoteArtifacts{content: map[string][]byte{}}
+	var paths []string
+	for i, version := range []string{"1", "2", "3", "4"} {
+		content := bytes.Repeat([]byte(version), 6)
+		remote.content[version] = content
+		path, _, err := c.Get(testArtifactRef(version, content), version, remote)
+		require.NoError(t, err)
+		// distinct modification times
+		modTime := time.Now().Add(time.Duration(i-10) * time.Minute)
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+		paths = append(paths, path)
+	}
+
+	assert.NoFileExists(t, paths[0])
+	for _, p := range paths[1:] {
+		assert.FileExists(t, p)
+	}
+}
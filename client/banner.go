@@ -0,0 +1,32 @@
+package chclient
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+// handleServerBanner logs the banner the server sends on connect, e.g. a legal notice.
+func (c *Client) handleServerBanner(message string) error {
+	c.serverBanner = strings.TrimSpace(message)
+	c.Infof("Server banner:\n%s", c.serverBanner)
+	return nil
+}
+
+// writeBannerFile writes the banner of the server to the configured file, it removes the file if the server has no
+// banner. Errors are only logged, they must not prevent the connection.
+func (c *Client) writeBannerFile(banner string) {
+	path := c.configHolder.Client.BannerFile
+	if path == "" {
+		return
+	}
+	if banner == "" {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			c.Errorf("Failed to remove banner file: %v", err)
+		}
+		return
+	}
+	if err := os.WriteFile(path, []byte(banner+"\n"), 0644); err != nil { //nolint:gosec
+		c.Errorf("Failed to write banner file: %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+package chclient
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/share/clientconfig"
+)
+
+func TestServerBanner(t *testing.T) {
+	bannerFile := filepath.Join(t.TempDir(), "banner")
+	c := &Client{
+		Logger: testLog,
+		configHolder: &ClientConfigHolder{Config: &clientconfig.Config{
+			Client: clientconfig.ClientConfig{BannerFile: bannerFile},
+		}},
+	}
+
+	require.NoError(t, c.handleServerBanner("Authorized use only.\n"))
+	c.writeBannerFile(c.serverBanner)
+	content, err := os.ReadFile(bannerFile)
+	require.NoError(t, err)
+	assert.Equal(t, "Authorized use only.\n", string(content))
+
+	// the server removed its banner
+	c.writeBannerFile("")
+	assert.NoFileExists(t, bannerFile)
+	c.writeBannerFile("")
+}
@@ -0,0 +1,114 @@
+package chclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/IOTech17/neo-rport/share/clientconfig"
+	"github.com/IOTech17/neo-rport/share/comm"
+)
+
+const (
+	chatFromPlaceholder    = "{from}"
+	chatMessagePlaceholder = "{message}"
+	chatMaxReplyBytes      = 4096
+	chatReplyTimeout       = 10 * time.Minute
+)
+
+// handleChatMessage shows the message of an operator to the user if allowed by the config. The reply of the user is
+// sent to the server as separate request, the user might take a while to answer.
+func (c *Client) handleChatMessage(ctx context.Context, conn ssh.Conn, payload []byte) error {
+	cfg := c.configHolder.Chat
+	if !cfg.Enabled {
+		return errors.New(`chat is disabled by "chat.enabled" config`)
+	}
+
+	var msg comm.ChatMessage
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		return fmt.Errorf("failed to decode %T: %v", msg, err)
+	}
+
+	command, err := chatCommand(cfg)
+	if err != nil {
+		return err
+	}
+
+	timeout := cfg.ReplyTimeout
+	if timeout <= 0 {
+		timeout = chatReplyTimeout
+	}
+
+	c.Infof("Showing chat message %d from %s", msg.ID, msg.From)
+	go func() {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		reply, err := showChatMessage(ctx, command, msg)
+		if err != nil {
+			c.Errorf("Failed to show chat message %d: %v", msg.ID, err)
+			return
+		}
+		if reply == "" {
+			c.Debugf("Chat message %d closed without reply", msg.ID)
+			return
+		}
+
+		err = comm.SendRequestAndGetResponse(conn, comm.RequestTypeChatReply, comm.ChatReply{
+			ReplyTo:   msg.ID,
+			SessionID: msg.SessionID,
+			Text:      reply,
+		}, nil, c.Logger)
+		if err != nil {
+			c.Errorf("Failed to send reply to chat message %d: %v", msg.ID, err)
+		}
+	}()
+	return nil
+}
+
+func chatCommand(cfg clientconfig.ChatConfig) ([]string, error) {
+	if len(cfg.Command) > 0 {
+		return cfg.Command, nil
+	}
+	for _, command := range defaultChatCommands {
+		if _, err := exec.LookPath(command[0]); err == nil {
+			return command, nil
+		}
+	}
+	return nil, errors.New(`no tool to show chat messages found, set "chat.command" config`)
+}
+
+// showChatMessage runs the command and returns the reply it printed. Closing the dialog without reply is not an error.
+func showChatMessage(ctx context.Context, command []string, msg comm.ChatMessage) (string, error) {
+	replacer := strings.NewReplacer(chatFromPlaceholder, msg.From, chatMessagePlaceholder, msg.Text)
+	args := make([]string, 0, len(command)-1)
+	for _, arg := range command[1:] {
+		args = append(args, replacer.Replace(arg))
+	}
+	cmd := exec.CommandContext(ctx, command[0], args...) //nolint:gosec
+	cmd.Env = append(os.Environ(), "RPORT_CHAT_FROM="+msg.From, "RPORT_CHAT_MESSAGE="+msg.Text)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	reply := strings.TrimSpace(string(out))
+	if len(reply) > chatMaxReplyBytes {
+		reply = reply[:chatMaxReplyBytes]
+	}
+	var exitErr *exec.ExitError
+	if err != nil && (ctx.Err() != nil || (errors.As(err, &exitErr) && reply == "" && stderr.Len() == 0)) {
+		// timed out or cancelled by the user
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: %v %s", command[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return reply, nil
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+// +build !windows
+
+package chclient
+
+// defaultChatCommands are tried in order, the first one installed is used. Like screenshots, the dialogs need access
+// to the display of the user.
+var defaultChatCommands = [][]string{
+	{"zenity", "--entry", "--title", "Message from " + chatFromPlaceholder, "--text", chatMessagePlaceholder},
+	{"kdialog", "--title", "Message from " + chatFromPlaceholder, "--inputbox", chatMessagePlaceholder},
+	{
+		"osascript",
+		"-e", "on run argv",
+		"-e", `text returned of (display dialog (item 2 of argv) default answer "" with title ("Message from " & item 1 of argv))`,
+		"-e", "end run",
+		chatFromPlaceholder, chatMessagePlaceholder,
+	},
+	{"wall", "Message from " + chatFromPlaceholder + ": " + chatMessagePlaceholder},
+}
@@ -0,0 +1,45 @@
+//go:build !windows
+// +build !windows
+
+package chclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/share/clientconfig"
+	"github.com/IOTech17/neo-rport/share/comm"
+)
+
+func TestShowChatMessage(t *testing.T) {
+	ctx := context.Background()
+	msg := comm.ChatMessage{ID: 1, From: "admin", Text: "please restart"}
+
+	reply, err := showChatMessage(ctx, []string{"sh", "-c", `echo "$0 to $RPORT_CHAT_FROM: ok, $RPORT_CHAT_MESSAGE"`, "{from}"}, msg)
+	require.NoError(t, err)
+	assert.Equal(t, "admin to admin: ok, please restart", reply)
+
+	reply, err = showChatMessage(ctx, []string{"sh", "-c", "exit 1"}, msg)
+	require.NoError(t, err)
+	assert.Empty(t, reply)
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	reply, err = showChatMessage(ctx, []string{"sleep", "5"}, msg)
+	require.NoError(t, err)
+	assert.Empty(t, reply)
+
+	_, err = showChatMessage(context.Background(), []string{"sh", "-c", "echo no display >&2; exit 1"}, msg)
+	assert.EqualError(t, err, "sh: exit status 1 no display")
+}
+
+func TestHandleChatMessageDisabled(t *testing.T) {
+	c := &Client{configHolder: &ClientConfigHolder{Config: &clientconfig.Config{}}}
+
+	err := c.handleChatMessage(context.Background(), nil, []byte(`{"ID":1}`))
+	assert.EqualError(t, err, `chat is disabled by "chat.enabled" config`)
+}
@@ -0,0 +1,13 @@
+//go:build windows
+// +build windows
+
+package chclient
+
+// defaultChatCommands read the message from the environment, so it's never interpreted as PowerShell code.
+var defaultChatCommands = [][]string{
+	{
+		"powershell.exe", "-NoProfile", "-NonInteractive", "-Command",
+		"Add-Type -AssemblyName Microsoft.VisualBasic; " +
+			"[Microsoft.VisualBasic.Interaction]::InputBox($env:RPORT_CHAT_MESSAGE, 'Message from ' + $env:RPORT_CHAT_FROM)",
+	},
+}
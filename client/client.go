@@ -2,6 +2,7 @@ package chclient
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -10,9 +11,11 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	ipAddresses "github.com/IOTech17/neo-rport/client/ip_addresses"
@@ -28,12 +31,16 @@ import (
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/net/proxy"
 
+	"github.com/IOTech17/neo-rport/client/cloudmeta"
+	"github.com/IOTech17/neo-rport/client/kubernetes"
 	"github.com/IOTech17/neo-rport/client/monitoring"
+	"github.com/IOTech17/neo-rport/client/sysproxy"
 	"github.com/IOTech17/neo-rport/client/system"
 	"github.com/IOTech17/neo-rport/client/updates"
 	chshare "github.com/IOTech17/neo-rport/share"
 	"github.com/IOTech17/neo-rport/share/comm"
 	"github.com/IOTech17/neo-rport/share/files"
+	"github.com/IOTech17/neo-rport/share/fips"
 	"github.com/IOTech17/neo-rport/share/logger"
 	"github.com/IOTech17/neo-rport/share/models"
 )
@@ -48,6 +55,7 @@ const SendRequestTimeout = 30 * time.Second
 const MinSendRequestRetryWaitTime = 1 * time.Second
 const BackoffOnServerTimeoutMaxDuration = 1 * time.Second
 const MaxKeepAliveJitterMilliseconds = 5000
+const ProxyDetectionTimeout = 30 * time.Second
 
 // Client represents a client instance
 type Client struct {
@@ -64,10 +72,26 @@ type Client struct {
 	systemInfo         system.SysInfo
 	updates            *updates.Updates
 	monitor            *monitoring.Monitor
+	jobResults         *jobResults
+	executedJobs       *executedJobs
+	artifacts          *artifactCache
+	fileChanges        *fileChanges
 	ipAddressesFetcher *ipAddresses.Fetcher
 	serverCapabilities *models.Capabilities
 	filesAPI           files.FileAPI
 	watchdog           *Watchdog
+	meshTunnels        *meshTunnels
+	reverseRemotes     *reverseRemotes
+	edgeAlerts         *edgeAlerts
+	consents           *grantedConsents
+	serialConsoles     *serialConsoles
+	serverBanner       string
+	kubernetesNode     *kubernetesNode
+	cloudMetadata      *cloudMetadata
+	proxyResolver      sysproxy.Resolver
+	discoveryRunning   atomic.Bool
+	agentlessSessions  atomic.Int32
+	industrialPolls    atomic.Int32
 
 	mu sync.RWMutex
 }
@@ -82,12 +106,18 @@ type sshClientConnection struct {
 func NewClient(config *ClientConfigHolder, filesAPI files.FileAPI) (*Client, error) {
 	// Generate a session id that will not change while the client is running
 	// This allows the server to resume sessions.
-	sessionID, err := random.UUID4()
+	var sessionID string
+	var err error
+	if config.Kubernetes.Enabled {
+		sessionID, err = loadOrCreateSessionID(config.Client.DataDir)
+	} else {
+		sessionID, err = random.UUID4()
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create initial session id: %s", err)
 	}
 
-	cmdExec := system.NewCmdExecutor(logger.NewLogger("cmd executor", config.Logging.LogOutput, config.Logging.LogLevel))
+	cmdExec := system.NewCmdExecutor(logger.NewLogger("cmd executor", config.Logging.LogOutput, config.Logging.LogLevel), config.ResourceLimits)
 	logger := logger.NewLogger("client", config.Logging.LogOutput, config.Logging.LogLevel)
 
 	watchdog, err := NewWatchdog(config.Connection.WatchdogIntegration, config.Client.DataDir, logger)
@@ -105,10 +135,32 @@ func NewClient(config *ClientConfigHolder, filesAPI files.FileAPI) (*Client, err
 		cmdExec:            cmdExec,
 		systemInfo:         systemInfo,
 		updates:            updates.New(logger, config.Client.UpdatesInterval),
-		monitor:            monitoring.NewMonitor(logger, config.Monitoring, systemInfo),
+		monitor:            monitoring.NewMonitor(logger, config.Monitoring, config.ResourceLimits.MonitoringCPUBudgetPercent, systemInfo, filepath.Join(config.Client.DataDir, monitoring.BufferFile)),
+		jobResults:         newJobResults(logger, filepath.Join(config.Client.DataDir, JobResultsDir)),
+		executedJobs:       newExecutedJobs(filepath.Join(config.Client.DataDir, ExecutedJobsDir)),
+		artifacts:          newArtifactCache(filepath.Join(config.Client.DataDir, ArtifactsDir)),
+		fileChanges:        newFileChanges(),
 		ipAddressesFetcher: ipAddresses.NewFetcher(logger, config.Client.IPAPIURL, config.Client.IPRefreshMin),
 		filesAPI:           filesAPI,
 		watchdog:           watchdog,
+		consents:           newGrantedConsents(),
+		serialConsoles:     newSerialConsoles(),
+	}
+	client.meshTunnels = newMeshTunnels(logger.Fork("mesh tunnels"), &client.connStats)
+	client.reverseRemotes = newReverseRemotes(logger.Fork("reverse remotes"), &client.connStats)
+	client.edgeAlerts = newEdgeAlerts(logger.Fork("edge alerts"), filepath.Join(config.Client.DataDir, EdgeAlertsFile), systemEdgeMetrics{}, client.runEdgeRemediation)
+	if config.Client.ProxyURL == nil && (config.Client.UseSystemProxy || config.Client.ProxyPACURL != "") {
+		client.proxyResolver = sysproxy.NewResolver(config.Client.ProxyPACURL)
+	}
+	if config.Kubernetes.Enabled {
+		discoverer, err := kubernetes.NewDiscoverer(config.Kubernetes)
+		if err != nil {
+			return nil, fmt.Errorf("kubernetes: %v", err)
+		}
+		client.kubernetesNode = newKubernetesNode(logger.Fork("kubernetes"), discoverer)
+	}
+	if config.CloudMetadata.Enabled {
+		client.cloudMetadata = newCloudMetadata(logger.Fork("cloud metadata"), cloudmeta.NewReader(config.CloudMetadata), config.CloudMetadata.TagKeys)
 	}
 
 	client.sshConfig = &ssh.ClientConfig{
@@ -116,8 +168,17 @@ func NewClient(config *ClientConfigHolder, filesAPI files.FileAPI) (*Client, err
 		Auth:            []ssh.AuthMethod{ssh.Password(config.Client.AuthPass)},
 		ClientVersion:   "SSH-" + chshare.ProtocolVersion + "-client",
 		HostKeyCallback: client.verifyServer,
+		BannerCallback:  client.handleServerBanner,
 		Timeout:         AuthTimeout,
 	}
+	config.Connection.SSHPolicy.Apply(&client.sshConfig.Config)
+	for _, warning := range config.Connection.SSHPolicy.Deprecated() {
+		logger.Infof("warning: %s", warning)
+	}
+	if fips.Enabled() {
+		logger.Infof("FIPS mode enabled, BoringCrypto: %t", fips.BoringCrypto())
+		client.sshConfig.HostKeyAlgorithms = fips.HostKeyAlgorithms
+	}
 
 	logger.Infof("NewFetcher client instance with sessionID %s", sessionID)
 	return client, nil
@@ -139,6 +200,11 @@ func (c *Client) Run(ctx context.Context) (err error) {
 }
 
 func (c *Client) verifyServer(hostname string, remote net.Addr, key ssh.PublicKey) error {
+	if fips.Enabled() {
+		if err := fips.ValidateSSHKey(key); err != nil {
+			return fmt.Errorf("server key rejected: %v", err)
+		}
+	}
 	got := chshare.FingerprintKey(key)
 	if c.configHolder.Client.Fingerprint != "" && !strings.HasPrefix(got, c.configHolder.Client.Fingerprint) {
 		return fmt.Errorf("invalid fingerprint (%s)", got)
@@ -156,6 +222,12 @@ func (c *Client) Start(ctx context.Context) error {
 		go c.keepAliveLoop(ctx)
 	}
 
+	if c.kubernetesNode != nil && c.configHolder.Kubernetes.IPWatchInterval > 0 {
+		go c.ipWatchLoop(ctx, c.configHolder.Kubernetes.IPWatchInterval)
+	}
+
+	go c.edgeAlerts.Run(ctx)
+
 	//connection loop
 	go c.connectionLoop(ctx, true)
 
@@ -294,6 +366,7 @@ func (c *Client) connectionLoop(ctx context.Context, withInitialSendRequestDelay
 		c.updates.SetConn(sshClientConn.Connection)
 		c.ipAddressesFetcher.SetConn(sshClientConn.Connection)
 		c.monitor.SetConn(sshClientConn.Connection)
+		c.edgeAlerts.SetConn(sshClientConn.Connection)
 
 		// watch for shutting down due to ctx.Done
 		go func() {
@@ -308,7 +381,10 @@ func (c *Client) connectionLoop(ctx context.Context, withInitialSendRequestDelay
 		c.Logger.Infof("connection wait stopped")
 
 		c.setConn(nil)
-		c.monitor.Stop()
+		c.meshTunnels.StopAll()
+		c.reverseRemotes.StopAll()
+		c.monitor.Disconnect()
+		c.edgeAlerts.SetConn(nil)
 		c.updates.Stop()
 		c.ipAddressesFetcher.Stop()
 		cancelSwitchback()
@@ -408,9 +484,10 @@ func (c *Client) connectToMainOrFallback() (conn *sshClientConnection, isPrimary
 }
 
 func (c *Client) connect(server string) (*sshClientConnection, error) {
+	proxyURL := c.proxyFor(server)
 	via := ""
-	if c.configHolder.Client.ProxyURL != nil {
-		via = " via " + c.configHolder.Client.ProxyURL.String()
+	if proxyURL != nil {
+		via = " via " + proxyURL.Redacted()
 	}
 	c.Infof("Trying to connect to %s%s ...\n", server, via)
 	c.Infof("Will wait up to %0.2f seconds for the server to respond", DialTimeout.Seconds())
@@ -420,8 +497,8 @@ func (c *Client) connect(server string) (*sshClientConnection, error) {
 	}
 
 	//optionally proxy
-	if c.configHolder.Client.ProxyURL != nil {
-		err := c.addDialerProxySupport(d, netDialer)
+	if proxyURL != nil {
+		err := addDialerProxySupport(d, netDialer, proxyURL)
 		if err != nil {
 			return nil, err
 		}
@@ -436,6 +513,7 @@ func (c *Client) connect(server string) (*sshClientConnection, error) {
 
 	// perform SSH handshake on net.Conn
 	c.Debugf("Handshaking...")
+	c.serverBanner = ""
 	sshClientConn, chans, reqs, err := ssh.NewClientConn(conn, "", c.sshConfig)
 	if err != nil {
 		if strings.Contains(err.Error(), "unable to authenticate") {
@@ -444,6 +522,7 @@ func (c *Client) connect(server string) (*sshClientConnection, error) {
 		}
 		return nil, err
 	}
+	c.writeBannerFile(c.serverBanner)
 
 	return &sshClientConnection{
 		Connection: sshClientConn,
@@ -461,6 +540,9 @@ func (c *Client) setupDialer() (d *websocket.Dialer, netDialer *net.Dialer, err
 		Subprotocols:     []string{chshare.ProtocolVersion},
 		NetDialContext:   netDialer.DialContext,
 	}
+	if fips.Enabled() {
+		d.TLSClientConfig = fips.ApplyTLSConfig(&tls.Config{})
+	}
 	if c.configHolder.Client.BindInterface != "" {
 		laddr, err := c.localAddrForInterface(c.configHolder.Client.BindInterface)
 		if err != nil {
@@ -472,23 +554,47 @@ func (c *Client) setupDialer() (d *websocket.Dialer, netDialer *net.Dialer, err
 	return d, netDialer, err
 }
 
-func (c *Client) addDialerProxySupport(d *websocket.Dialer, netDialer *net.Dialer) (err error) {
-	if strings.HasPrefix(c.configHolder.Client.ProxyURL.Scheme, "socks") {
+// proxyFor returns the configured proxy or the one found by the system settings or PAC file, nil means direct.
+func (c *Client) proxyFor(server string) *url.URL {
+	if c.configHolder.Client.ProxyURL != nil {
+		return c.configHolder.Client.ProxyURL
+	}
+	if c.proxyResolver == nil {
+		return nil
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		c.Errorf("Could not parse server url %s: %v", server, err)
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ProxyDetectionTimeout)
+	defer cancel()
+	proxyURL, err := c.proxyResolver.ProxyFor(ctx, serverURL)
+	if err != nil {
+		c.Errorf("Could not determine proxy for %s, connecting directly: %v", server, err)
+		return nil
+	}
+	return proxyURL
+}
+
+func addDialerProxySupport(d *websocket.Dialer, netDialer *net.Dialer, proxyURL *url.URL) (err error) {
+	if strings.HasPrefix(proxyURL.Scheme, "socks") {
 		// SOCKS5 proxy
-		if c.configHolder.Client.ProxyURL.Scheme != "socks" && c.configHolder.Client.ProxyURL.Scheme != "socks5h" {
+		if proxyURL.Scheme != "socks" && proxyURL.Scheme != "socks5h" {
 			return fmt.Errorf(
 				"unsupported socks proxy type: %s:// (only socks5h:// or socks:// is supported)",
-				c.configHolder.Client.ProxyURL.Scheme)
+				proxyURL.Scheme)
 		}
 		var auth *proxy.Auth
-		if c.configHolder.Client.ProxyURL.User != nil {
-			pass, _ := c.configHolder.Client.ProxyURL.User.Password()
+		if proxyURL.User != nil {
+			pass, _ := proxyURL.User.Password()
 			auth = &proxy.Auth{
-				User:     c.configHolder.Client.ProxyURL.User.Username(),
+				User:     proxyURL.User.Username(),
 				Password: pass,
 			}
 		}
-		socksDialer, err := proxy.SOCKS5("tcp", c.configHolder.Client.ProxyURL.Host, auth, netDialer)
+		socksDialer, err := proxy.SOCKS5("tcp", proxyURL.Host, auth, netDialer)
 		if err != nil {
 			return err
 		}
@@ -496,7 +602,7 @@ func (c *Client) addDialerProxySupport(d *websocket.Dialer, netDialer *net.Diale
 	} else {
 		// CONNECT proxy
 		d.Proxy = func(*http.Request) (*url.URL, error) {
-			return c.configHolder.Client.ProxyURL, nil
+			return proxyURL, nil
 		}
 	}
 
@@ -593,9 +699,11 @@ func (c *Client) sendConnectionRequest(ctx context.Context, sshConn ssh.Conn, mi
 }
 
 // afterPutCapabilities is the place to do things dependent on server capabilities
-func (c *Client) afterPutCapabilities(ctx context.Context) {
+func (c *Client) afterPutCapabilities(ctx context.Context, conn ssh.Conn) {
+	go c.jobResults.Start(conn, c.serverCapabilities.JobResultsVersion > 0)
+
 	if c.serverCapabilities.MonitoringVersion > 0 {
-		c.monitor.Start(ctx)
+		c.monitor.Start(ctx, c.serverCapabilities.MonitoringVersion)
 	} else {
 		c.Debugf("Server has no monitoring capability, measurement not started")
 	}
@@ -607,7 +715,7 @@ func (c *Client) afterPutCapabilities(ctx context.Context) {
 	}
 }
 
-func (c *Client) handlePutCapabilitiesRequest(ctx context.Context, payload []byte) {
+func (c *Client) handlePutCapabilitiesRequest(ctx context.Context, conn ssh.Conn, payload []byte) {
 	caps := &models.Capabilities{}
 	if err := json.Unmarshal(payload, caps); err != nil {
 		c.Errorf("failed to decode %T: %v", caps, err)
@@ -615,7 +723,20 @@ func (c *Client) handlePutCapabilitiesRequest(ctx context.Context, payload []byt
 	}
 	c.Debugf("Server has capabilities: %s", string(payload))
 	c.serverCapabilities = caps
-	c.afterPutCapabilities(ctx)
+	c.afterPutCapabilities(ctx, conn)
+}
+
+func (c *Client) newUploadManager(conn ssh.Conn) *UploadManager {
+	return NewSSHUploadManager(
+		c.Logger,
+		c.filesAPI,
+		c.configHolder,
+		conn,
+		system.SysUserProvider{},
+		c.artifacts,
+		c,
+		c.fileChanges,
+	)
 }
 
 func (c *Client) handleSSHRequests(ctx context.Context, sshClientConn *sshClientConnection) {
@@ -640,21 +761,60 @@ func (c *Client) handleSSHRequests(ctx context.Context, sshClientConn *sshClient
 			c.updates.Refresh()
 			// fall through to reply success with empty resp
 		case comm.RequestTypePutCapabilities:
-			c.handlePutCapabilitiesRequest(ctx, r.Payload)
+			c.handlePutCapabilitiesRequest(ctx, sshClientConn.Connection, r.Payload)
 			// fall through to reply success with empty resp
 		case comm.RequestTypeUpload:
-			uploadManager := NewSSHUploadManager(
-				c.Logger,
-				c.filesAPI,
-				c.configHolder,
-				sshClientConn.Connection,
-				system.SysUserProvider{},
-			)
-			resp, err = uploadManager.HandleUploadRequest(r.Payload)
+			resp, err = c.newUploadManager(sshClientConn.Connection).HandleUploadRequest(r.Payload)
+			// fall through for err and resp handling
+		case comm.RequestTypeActivateFileChange:
+			resp, err = c.newUploadManager(sshClientConn.Connection).HandleActivateFileChangeRequest(r.Payload)
+			// fall through for err and resp handling
+		case comm.RequestTypeConfirmFileChange:
+			resp, err = c.newUploadManager(sshClientConn.Connection).HandleConfirmFileChangeRequest(r.Payload)
+			// fall through for err and resp handling
+		case comm.RequestTypeRollbackFileChange:
+			resp, err = c.newUploadManager(sshClientConn.Connection).HandleRollbackFileChangeRequest(r.Payload)
 			// fall through for err and resp handling
 		case comm.RequestTypeCheckTunnelAllowed:
 			resp, err = c.checkTunnelAllowed(r.Payload)
 			// fall through for err and resp handling
+		case comm.RequestTypeStartMeshTunnel:
+			if !c.configHolder.Client.AllowMeshTunnels {
+				err = errors.New(`mesh tunnels are disabled by "allow_mesh_tunnels" config`)
+				break
+			}
+			err = c.meshTunnels.Start(sshClientConn.Connection, r.Payload)
+			// fall through for err and resp handling
+		case comm.RequestTypeStopMeshTunnel:
+			err = c.meshTunnels.Stop(r.Payload)
+			// fall through for err and resp handling
+		case comm.RequestTypePutReverseRemotes:
+			err = c.reverseRemotes.Put(sshClientConn.Connection, r.Payload)
+			// fall through for err and resp handling
+		case comm.RequestTypePutEdgeRules:
+			err = c.edgeAlerts.Put(r.Payload)
+			// fall through for err and resp handling
+		case comm.RequestTypeTakeScreenshot:
+			err = c.handleTakeScreenshot(ctx, sshClientConn.Connection, r.Payload)
+			// fall through for err and resp handling
+		case comm.RequestTypeAskConsent:
+			resp, err = c.handleAskConsent(ctx, sshClientConn.Connection, r.Payload)
+			// fall through for err and resp handling
+		case comm.RequestTypeChatMessage:
+			err = c.handleChatMessage(ctx, sshClientConn.Connection, r.Payload)
+			// fall through for err and resp handling
+		case comm.RequestTypeDiscoverNetwork:
+			err = c.handleDiscoverNetwork(ctx, sshClientConn.Connection, r.Payload)
+			// fall through for err and resp handling
+		case comm.RequestTypeRunAgentless:
+			err = c.handleRunAgentless(ctx, sshClientConn.Connection, r.Payload)
+			// fall through for err and resp handling
+		case comm.RequestTypeOpenSerialConsole:
+			err = c.handleOpenSerialConsole(sshClientConn.Connection, r.Payload)
+			// fall through for err and resp handling
+		case comm.RequestTypePollIndustrial:
+			err = c.handlePollIndustrial(ctx, sshClientConn.Connection, r.Payload)
+			// fall through for err and resp handling
 		case comm.RequestTypePing:
 			// use empty reply (and NOT empty resp with success reply)
 			_ = r.Reply(true, nil)
@@ -858,6 +1018,18 @@ func (c *Client) connectionRequest(ctx context.Context) (*chshare.ConnectionRequ
 		CPUModelName:           system.UnknownValue,
 		CPUVendor:              system.UnknownValue,
 		ClientConfiguration:    c.configHolder.Config,
+		JobDeliveryVersion:     chshare.JobDeliveryVersion,
+		Capabilities:           c.configHolder.Capabilities(),
+		ProtocolRevision:       chshare.ProtocolRevision,
+		FeatureLevels:          chshare.FeatureLevels(),
+		Ephemeral:              c.configHolder.Client.Ephemeral,
+	}
+
+	if c.kubernetesNode != nil {
+		c.kubernetesNode.apply(ctx, connReq, c.configHolder.Client.UseSystemID)
+	}
+	if c.cloudMetadata != nil {
+		c.cloudMetadata.apply(ctx, connReq)
 	}
 
 	var err error
@@ -906,6 +1078,8 @@ func (c *Client) connectionRequest(ctx context.Context) (*chshare.ConnectionRequ
 	connReq.IPv4, connReq.IPv6, err = c.localIPAddresses()
 	if err != nil {
 		c.Logger.Errorf("Could not get local ips: %v", err)
+	} else if c.kubernetesNode != nil {
+		c.kubernetesNode.setReportedIPs(connReq.IPv4, connReq.IPv6)
 	}
 
 	hostname, err := c.systemInfo.Hostname()
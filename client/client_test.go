@@ -198,6 +198,10 @@ func TestConnectionRequest(t *testing.T) {
 				Labels:                 map[string]string{"lab1": "val1"},
 				Remotes:                []*models.Remote{remote1, remote2},
 				ClientConfiguration:    config.Config,
+				JobDeliveryVersion:     chshare.JobDeliveryVersion,
+				Capabilities:           []string{models.ClientCapabilityUDPTunnels},
+				ProtocolRevision:       chshare.ProtocolRevision,
+				FeatureLevels:          chshare.FeatureLevels(),
 			},
 		}, {
 			Name: "windows, no errors",
@@ -251,6 +255,10 @@ func TestConnectionRequest(t *testing.T) {
 				IPv4:                   []string{"192.0.2.1", "192.0.2.2"},
 				IPv6:                   []string{"2001:db8::1", "2001:db8::2"},
 				ClientConfiguration:    config.Config,
+				JobDeliveryVersion:     chshare.JobDeliveryVersion,
+				Capabilities:           []string{models.ClientCapabilityUDPTunnels},
+				ProtocolRevision:       chshare.ProtocolRevision,
+				FeatureLevels:          chshare.FeatureLevels(),
 			},
 		}, {
 			Name: "all errors",
@@ -288,6 +296,10 @@ func TestConnectionRequest(t *testing.T) {
 				IPv4:                   nil,
 				IPv6:                   nil,
 				ClientConfiguration:    config.Config,
+				JobDeliveryVersion:     chshare.JobDeliveryVersion,
+				Capabilities:           []string{models.ClientCapabilityUDPTunnels},
+				ProtocolRevision:       chshare.ProtocolRevision,
+				FeatureLevels:          chshare.FeatureLevels(),
 			},
 		}, {
 			Name: "uname error",
@@ -329,6 +341,10 @@ func TestConnectionRequest(t *testing.T) {
 				IPv4:                   []string{"192.0.2.1", "192.0.2.2"},
 				IPv6:                   []string{"2001:db8::1", "2001:db8::2"},
 				ClientConfiguration:    config.Config,
+				JobDeliveryVersion:     chshare.JobDeliveryVersion,
+				Capabilities:           []string{models.ClientCapabilityUDPTunnels},
+				ProtocolRevision:       chshare.ProtocolRevision,
+				FeatureLevels:          chshare.FeatureLevels(),
 			},
 		},
 	}
@@ -0,0 +1,63 @@
+package chclient
+
+import (
+	"context"
+	"sync"
+
+	"github.com/IOTech17/neo-rport/client/cloudmeta"
+	chshare "github.com/IOTech17/neo-rport/share"
+	"github.com/IOTech17/neo-rport/share/logger"
+)
+
+// cloudMetadata adds the instance metadata to the tags and labels of the connection request when running on a cloud VM.
+type cloudMetadata struct {
+	*logger.Logger
+	reader  *cloudmeta.Reader
+	tagKeys []string
+
+	mu sync.Mutex
+	// last is used if the metadata can't be read on reconnect
+	last *cloudmeta.Metadata
+}
+
+func newCloudMetadata(l *logger.Logger, reader *cloudmeta.Reader, tagKeys []string) *cloudMetadata {
+	return &cloudMetadata{
+		Logger:  l,
+		reader:  reader,
+		tagKeys: tagKeys,
+	}
+}
+
+func (c *cloudMetadata) apply(ctx context.Context, connReq *chshare.ConnectionRequest) {
+	m, err := c.reader.Read(ctx)
+	c.mu.Lock()
+	if err != nil {
+		c.Errorf("Could not read cloud metadata: %v", err)
+		m = c.last
+	} else {
+		c.last = m
+	}
+	c.mu.Unlock()
+	if m == nil {
+		return
+	}
+
+	tags := append([]string{}, connReq.Tags...)
+	for _, tag := range m.ClientTags(c.tagKeys) {
+		if !contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	connReq.Tags = tags
+
+	// labels of the config file and the kubernetes node take precedence
+	cloudLabels := m.Labels()
+	labels := make(map[string]string, len(cloudLabels)+len(connReq.Labels))
+	for k, v := range cloudLabels {
+		labels[k] = v
+	}
+	for k, v := range connReq.Labels {
+		labels[k] = v
+	}
+	connReq.Labels = labels
+}
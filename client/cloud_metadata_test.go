@@ -0,0 +1,59 @@
+package chclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/IOTech17/neo-rport/client/cloudmeta"
+	chshare "github.com/IOTech17/neo-rport/share"
+	"github.com/IOTech17/neo-rport/share/clientconfig"
+)
+
+func TestCloudMetadataApply(t *testing.T) {
+	available := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !available {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"location":"westeurope","vmId":"vm-1","vmSize":"Standard_B2s","tagsList":[{"name":"env","value":"prod"},{"name":"team","value":"ops"}]}`))
+	}))
+	defer srv.Close()
+	oldEndpoint := cloudmeta.AzureEndpoint
+	cloudmeta.AzureEndpoint = srv.URL
+	defer func() {
+		cloudmeta.AzureEndpoint = oldEndpoint
+	}()
+
+	reader := cloudmeta.NewReader(clientconfig.CloudMetadataConfig{Provider: cloudmeta.ProviderAzure, Timeout: time.Second})
+	c := newCloudMetadata(testLog, reader, []string{"team"})
+
+	connReq := &chshare.ConnectionRequest{
+		Tags:   []string{"from-config", "azure"},
+		Labels: map[string]string{"cloud.tag.env": "staging"},
+	}
+	c.apply(context.Background(), connReq)
+
+	assert.Equal(t, []string{"from-config", "azure", "westeurope", "ops"}, connReq.Tags)
+	assert.Equal(t, map[string]string{
+		"cloud.provider":      "azure",
+		"cloud.region":        "westeurope",
+		"cloud.instance_id":   "vm-1",
+		"cloud.instance_type": "Standard_B2s",
+		"cloud.tag.env":       "staging",
+		"cloud.tag.team":      "ops",
+	}, connReq.Labels)
+
+	// the last known metadata is used when reading it fails
+	available = false
+	connReq = &chshare.ConnectionRequest{}
+	c.apply(context.Background(), connReq)
+
+	assert.Equal(t, []string{"azure", "westeurope", "ops"}, connReq.Tags)
+	assert.Equal(t, "vm-1", connReq.Labels["cloud.instance_id"])
+}
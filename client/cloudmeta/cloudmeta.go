@@ -0,0 +1,342 @@
+// Package cloudmeta reads the instance metadata of the cloud VM a client runs on, e.g. the region, instance id and
+// tags, from the metadata service of AWS, Azure or GCP.
+package cloudmeta
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IOTech17/neo-rport/share/clientconfig"
+)
+
+const (
+	ProviderAuto  = "auto"
+	ProviderAWS   = "aws"
+	ProviderAzure = "azure"
+	ProviderGCP   = "gcp"
+)
+
+// Providers are the supported providers in the order they are preferred by auto detection.
+var Providers = []string{ProviderAWS, ProviderAzure, ProviderGCP}
+
+const (
+	// LabelPrefix is the prefix of the client labels holding the metadata, cloud tags are added as cloud.tag.<key>.
+	LabelPrefix    = "cloud."
+	tagLabelPrefix = LabelPrefix + "tag."
+
+	// DefaultTimeout is the timeout of each request to the metadata service.
+	DefaultTimeout = 2 * time.Second
+
+	maxResponseBytes = 1024 * 1024
+)
+
+// The endpoints of the metadata services, variables to be replaced in tests.
+var (
+	AWSEndpoint   = "http://169.254.169.254"
+	AzureEndpoint = "http://169.254.169.254"
+	GCPEndpoint   = "http://metadata.google.internal"
+)
+
+var errNotFound = errors.New("not found")
+
+// Metadata holds the details of the instance.
+type Metadata struct {
+	Provider     string
+	Region       string
+	Zone         string
+	InstanceID   string
+	InstanceType string
+	AccountID    string
+	// Tags are the tags of the instance, on GCP the network tags without values
+	Tags map[string]string
+}
+
+// Labels returns the metadata as client labels.
+func (m *Metadata) Labels() map[string]string {
+	labels := make(map[string]string, len(m.Tags)+6)
+	for k, v := range map[string]string{
+		"provider":      m.Provider,
+		"region":        m.Region,
+		"zone":          m.Zone,
+		"instance_id":   m.InstanceID,
+		"instance_type": m.InstanceType,
+		"account_id":    m.AccountID,
+	} {
+		if v != "" {
+			labels[LabelPrefix+k] = v
+		}
+	}
+	for k, v := range m.Tags {
+		labels[tagLabelPrefix+k] = v
+	}
+	return labels
+}
+
+// ClientTags returns the provider, the region and the values of the given instance tags as client tags. Tags without
+// a value are added by their key.
+func (m *Metadata) ClientTags(tagKeys []string) []string {
+	tags := []string{m.Provider}
+	if m.Region != "" {
+		tags = append(tags, m.Region)
+	}
+	for _, k := range tagKeys {
+		v, ok := m.Tags[k]
+		if !ok {
+			continue
+		}
+		if v == "" {
+			v = k
+		}
+		tags = append(tags, v)
+	}
+	return tags
+}
+
+type Reader struct {
+	cfg        clientconfig.CloudMetadataConfig
+	httpClient *http.Client
+
+	mu sync.Mutex
+	// provider is the detected provider, the others are not queried once it's known
+	provider string
+}
+
+func NewReader(cfg clientconfig.CloudMetadataConfig) *Reader {
+	r := &Reader{
+		cfg: cfg,
+		// the metadata services must not be reached through a proxy
+		httpClient: &http.Client{
+			Timeout:   cfg.Timeout,
+			Transport: &http.Transport{Proxy: nil},
+		},
+	}
+	if cfg.Provider != ProviderAuto {
+		r.provider = cfg.Provider
+	}
+	return r
+}
+
+// Read returns the metadata of the instance. With provider auto, all providers are queried in parallel the first time.
+func (r *Reader) Read(ctx context.Context) (*Metadata, error) {
+	r.mu.Lock()
+	provider := r.provider
+	r.mu.Unlock()
+
+	if provider != "" {
+		return r.read(ctx, provider)
+	}
+
+	results := make([]*Metadata, len(Providers))
+	errs := make([]error, len(Providers))
+	var wg sync.WaitGroup
+	for i, p := range Providers {
+		wg.Add(1)
+		go func(i int, p string) {
+			defer wg.Done()
+			results[i], errs[i] = r.read(ctx, p)
+		}(i, p)
+	}
+	wg.Wait()
+
+	for i, m := range results {
+		if errs[i] == nil {
+			r.mu.Lock()
+			r.provider = Providers[i]
+			r.mu.Unlock()
+			return m, nil
+		}
+	}
+	var msgs []string
+	for i, err := range errs {
+		msgs = append(msgs, fmt.Sprintf("%s: %v", Providers[i], err))
+	}
+	return nil, fmt.Errorf("no metadata service found, not running on a cloud VM? (%s)", strings.Join(msgs, ", "))
+}
+
+func (r *Reader) read(ctx context.Context, provider string) (*Metadata, error) {
+	switch provider {
+	case ProviderAWS:
+		return r.readAWS(ctx)
+	case ProviderAzure:
+		return r.readAzure(ctx)
+	case ProviderGCP:
+		return r.readGCP(ctx)
+	default:
+		return nil, fmt.Errorf("unknown provider %q", provider)
+	}
+}
+
+// readAWS uses IMDSv2. Tags are only available if access to tags in the instance metadata is allowed.
+func (r *Reader) readAWS(ctx context.Context) (*Metadata, error) {
+	token, err := r.get(ctx, http.MethodPut, AWSEndpoint+"/latest/api/token", map[string]string{
+		"X-aws-ec2-metadata-token-ttl-seconds": "60",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token: %v", err)
+	}
+	headers := map[string]string{"X-aws-ec2-metadata-token": string(token)}
+
+	body, err := r.get(ctx, http.MethodGet, AWSEndpoint+"/latest/dynamic/instance-identity/document", headers)
+	if err != nil {
+		return nil, err
+	}
+	doc := struct {
+		Region           string `json:"region"`
+		AvailabilityZone string `json:"availabilityZone"`
+		InstanceID       string `json:"instanceId"`
+		InstanceType     string `json:"instanceType"`
+		AccountID        string `json:"accountId"`
+	}{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode instance identity document: %v", err)
+	}
+	if doc.InstanceID == "" {
+		return nil, errors.New("instance identity document without instance id")
+	}
+	m := &Metadata{
+		Provider:     ProviderAWS,
+		Region:       doc.Region,
+		Zone:         doc.AvailabilityZone,
+		InstanceID:   doc.InstanceID,
+		InstanceType: doc.InstanceType,
+		AccountID:    doc.AccountID,
+		Tags:         make(map[string]string),
+	}
+
+	keys, err := r.get(ctx, http.MethodGet, AWSEndpoint+"/latest/meta-data/tags/instance", headers)
+	if errors.Is(err, errNotFound) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %v", err)
+	}
+	for _, key := range strings.Fields(string(keys)) {
+		value, err := r.get(ctx, http.MethodGet, AWSEndpoint+"/latest/meta-data/tags/instance/"+key, headers)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tag %q: %v", key, err)
+		}
+		m.Tags[key] = string(value)
+	}
+	return m, nil
+}
+
+func (r *Reader) readAzure(ctx context.Context) (*Metadata, error) {
+	body, err := r.get(ctx, http.MethodGet, AzureEndpoint+"/metadata/instance/compute?api-version=2021-02-01", map[string]string{
+		"Metadata": "true",
+	})
+	if err != nil {
+		return nil, err
+	}
+	compute := struct {
+		Location       string `json:"location"`
+		Zone           string `json:"zone"`
+		VMID           string `json:"vmId"`
+		VMSize         string `json:"vmSize"`
+		SubscriptionID string `json:"subscriptionId"`
+		TagsList       []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"tagsList"`
+	}{}
+	if err := json.Unmarshal(body, &compute); err != nil {
+		return nil, fmt.Errorf("failed to decode compute metadata: %v", err)
+	}
+	if compute.VMID == "" {
+		return nil, errors.New("compute metadata without vm id")
+	}
+	m := &Metadata{
+		Provider:     ProviderAzure,
+		Region:       compute.Location,
+		Zone:         compute.Zone,
+		InstanceID:   compute.VMID,
+		InstanceType: compute.VMSize,
+		AccountID:    compute.SubscriptionID,
+		Tags:         make(map[string]string, len(compute.TagsList)),
+	}
+	for _, tag := range compute.TagsList {
+		m.Tags[tag.Name] = tag.Value
+	}
+	return m, nil
+}
+
+// readGCP doesn't read the custom metadata, it usually holds startup scripts and ssh keys.
+func (r *Reader) readGCP(ctx context.Context) (*Metadata, error) {
+	headers := map[string]string{"Metadata-Flavor": "Google"}
+	body, err := r.get(ctx, http.MethodGet, GCPEndpoint+"/computeMetadata/v1/instance/?recursive=true", headers)
+	if err != nil {
+		return nil, err
+	}
+	instance := struct {
+		ID          json.Number `json:"id"`
+		Zone        string      `json:"zone"`
+		MachineType string      `json:"machineType"`
+		Tags        []string    `json:"tags"`
+	}{}
+	if err := json.Unmarshal(body, &instance); err != nil {
+		return nil, fmt.Errorf("failed to decode instance metadata: %v", err)
+	}
+	if instance.ID == "" {
+		return nil, errors.New("instance metadata without id")
+	}
+	project, err := r.get(ctx, http.MethodGet, GCPEndpoint+"/computeMetadata/v1/project/project-id", headers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project id: %v", err)
+	}
+
+	// zone and machine type are given as projects/<number>/zones/<zone>
+	zone := lastPathElement(instance.Zone)
+	m := &Metadata{
+		Provider:     ProviderGCP,
+		Zone:         zone,
+		InstanceID:   instance.ID.String(),
+		InstanceType: lastPathElement(instance.MachineType),
+		AccountID:    string(project),
+		Tags:         make(map[string]string, len(instance.Tags)),
+	}
+	if i := strings.LastIndex(zone, "-"); i > 0 {
+		m.Region = zone[:i]
+	}
+	for _, tag := range instance.Tags {
+		m.Tags[tag] = ""
+	}
+	return m, nil
+}
+
+func (r *Reader) get(ctx context.Context, method, url string, headers map[string]string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s from %s", resp.Status, url)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(strings.TrimSpace(string(body))), nil
+}
+
+func lastPathElement(path string) string {
+	return path[strings.LastIndex(path, "/")+1:]
+}
@@ -0,0 +1,170 @@
+package cloudmeta
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/share/clientconfig"
+)
+
+func newAWSServer(t *testing.T, withTags bool) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/latest/api/token", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPut, r.Method)
+		require.Equal(t, "60", r.Header.Get("X-aws-ec2-metadata-token-ttl-seconds"))
+		_, _ = w.Write([]byte("token-1"))
+	})
+	authorized := func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-aws-ec2-metadata-token") != "token-1" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			h(w, r)
+		}
+	}
+	mux.HandleFunc("/latest/dynamic/instance-identity/document", authorized(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"accountId":"123456789012","availabilityZone":"eu-central-1a","instanceId":"i-0abc","instanceType":"t3.micro","region":"eu-central-1"}`))
+	}))
+	if withTags {
+		mux.HandleFunc("/latest/meta-data/tags/instance", authorized(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("CostCenter\nName"))
+		}))
+		mux.HandleFunc("/latest/meta-data/tags/instance/CostCenter", authorized(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("cc-42"))
+		}))
+		mux.HandleFunc("/latest/meta-data/tags/instance/Name", authorized(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("web-1"))
+		}))
+	}
+	return httptest.NewServer(mux)
+}
+
+func useEndpoints(t *testing.T, aws, azure, gcp string) {
+	oldAWS, oldAzure, oldGCP := AWSEndpoint, AzureEndpoint, GCPEndpoint
+	AWSEndpoint, AzureEndpoint, GCPEndpoint = aws, azure, gcp
+	t.Cleanup(func() {
+		AWSEndpoint, AzureEndpoint, GCPEndpoint = oldAWS, oldAzure, oldGCP
+	})
+}
+
+func newTestReader(provider string) *Reader {
+	return NewReader(clientconfig.CloudMetadataConfig{Provider: provider, Timeout: time.Second})
+}
+
+func TestReadAWS(t *testing.T) {
+	for _, withTags := range []bool{true, false} {
+		srv := newAWSServer(t, withTags)
+		defer srv.Close()
+		useEndpoints(t, srv.URL, "", "")
+
+		m, err := newTestReader(ProviderAWS).Read(context.Background())
+		require.NoError(t, err)
+
+		expected := &Metadata{
+			Provider:     ProviderAWS,
+			Region:       "eu-central-1",
+			Zone:         "eu-central-1a",
+			InstanceID:   "i-0abc",
+			InstanceType: "t3.micro",
+			AccountID:    "123456789012",
+			Tags:         map[string]string{},
+		}
+		if withTags {
+			expected.Tags = map[string]string{"CostCenter": "cc-42", "Name": "web-1"}
+		}
+		assert.Equal(t, expected, m)
+	}
+}
+
+func TestReadAzure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "true", r.Header.Get("Metadata"))
+		require.Equal(t, "/metadata/instance/compute", r.URL.Path)
+		_, _ = w.Write([]byte(`{"location":"westeurope","zone":"1","vmId":"02aab8a4-74ef-476e-8182-f6d2ba4166a6","vmSize":"Standard_B2s","subscriptionId":"8d10da13-8125-4ba9-a717-bf7490507b3d","tagsList":[{"name":"CostCenter","value":"cc-42"}]}`))
+	}))
+	defer srv.Close()
+	useEndpoints(t, "", srv.URL, "")
+
+	m, err := newTestReader(ProviderAzure).Read(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, &Metadata{
+		Provider:     ProviderAzure,
+		Region:       "westeurope",
+		Zone:         "1",
+		InstanceID:   "02aab8a4-74ef-476e-8182-f6d2ba4166a6",
+		InstanceType: "Standard_B2s",
+		AccountID:    "8d10da13-8125-4ba9-a717-bf7490507b3d",
+		Tags:         map[string]string{"CostCenter": "cc-42"},
+	}, m)
+}
+
+func TestReadGCP(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/computeMetadata/v1/instance/", func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+		_, _ = w.Write([]byte(`{"id":4520031799277581759,"zone":"projects/123/zones/europe-west1-b","machineType":"projects/123/machineTypes/e2-medium","tags":["http-server"],"attributes":{"ssh-keys":"secret"}}`))
+	})
+	mux.HandleFunc("/computeMetadata/v1/project/project-id", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("my-project"))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	useEndpoints(t, "", "", srv.URL)
+
+	m, err := newTestReader(ProviderGCP).Read(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, &Metadata{
+		Provider:     ProviderGCP,
+		Region:       "europe-west1",
+		Zone:         "europe-west1-b",
+		InstanceID:   "4520031799277581759",
+		InstanceType: "e2-medium",
+		AccountID:    "my-project",
+		Tags:         map[string]string{"http-server": ""},
+	}, m)
+	assert.Equal(t, []string{"gcp", "europe-west1", "http-server"}, m.ClientTags([]string{"http-server", "missing"}))
+}
+
+func TestReadAuto(t *testing.T) {
+	aws := newAWSServer(t, true)
+	defer aws.Close()
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+	useEndpoints(t, aws.URL, notFound.URL, notFound.URL)
+
+	r := newTestReader(ProviderAuto)
+	m, err := r.Read(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, ProviderAWS, m.Provider)
+	assert.Equal(t, ProviderAWS, r.provider)
+
+	useEndpoints(t, notFound.URL, notFound.URL, notFound.URL)
+	_, err = newTestReader(ProviderAuto).Read(context.Background())
+	assert.EqualError(t, err, "no metadata service found, not running on a cloud VM? (aws: failed to get token: not found, azure: not found, gcp: not found)")
+}
+
+func TestLabels(t *testing.T) {
+	m := &Metadata{
+		Provider:   ProviderAWS,
+		Region:     "eu-central-1",
+		InstanceID: "i-0abc",
+		Tags:       map[string]string{"CostCenter": "cc-42"},
+	}
+
+	assert.Equal(t, map[string]string{
+		"cloud.provider":       "aws",
+		"cloud.region":         "eu-central-1",
+		"cloud.instance_id":    "i-0abc",
+		"cloud.tag.CostCenter": "cc-42",
+	}, m.Labels())
+	assert.Equal(t, []string{"aws", "eu-central-1", "cc-42"}, m.ClientTags([]string{"CostCenter"}))
+}
@@ -50,6 +50,24 @@ func (c *Client) HandleRunCmdRequest(ctx context.Context, reqPayload []byte) (*c
 		return nil, fmt.Errorf("command is not allowed: %v", job.Command)
 	}
 
+	received, err := c.executedJobs.Begin(job.JID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record the job: %s", err)
+	}
+	if received != nil {
+		c.Infof("job [jid=%q] was received before, not running it again", job.JID)
+		return received, nil
+	}
+	started := false
+	defer func() {
+		if started {
+			return
+		}
+		if err := c.executedJobs.Failed(job.JID); err != nil {
+			c.Errorf("failed to remove the record of job [jid=%q]: %v", job.JID, err)
+		}
+	}()
+
 	interpreter := system.Interpreter{
 		InterpreterNameFromInput: job.Interpreter,
 		InterpreterAliases:       c.configHolder.InterpreterAliases,
@@ -132,6 +150,15 @@ func (c *Client) HandleRunCmdRequest(ctx context.Context, reqPayload []byte) (*c
 		return nil, fmt.Errorf("failed to start a command: %s", err)
 	}
 
+	resp := &comm.RunCmdResponse{
+		Pid:       cmd.Process.Pid,
+		StartedAt: startedAt,
+	}
+	started = true
+	if err := c.executedJobs.Started(job.JID, resp); err != nil {
+		c.Errorf("failed to record the start of job [jid=%q]: %v", job.JID, err)
+	}
+
 	// observe the cmd execution in background
 	go func() {
 		defer c.rmScript(scriptPath)
@@ -183,25 +210,13 @@ func (c *Client) HandleRunCmdRequest(ctx context.Context, reqPayload []byte) (*c
 			Summary: c.ToUTF8(summary.GetSummary(), decoder),
 		}
 
-		// send the filled job to the server
-		jobBytes, err := json.Marshal(job)
-		if err != nil {
-			c.Errorf("failed to send command result for [jid=%q,pid=%d]: failed to encode job result: %s", job.JID, cmd.Process.Pid, err)
-			return
-		}
 		c.Debugf("sending job to server: %v", job)
-		_, _, err = c.getConn().SendRequest(comm.RequestTypeCmdResult, false, jobBytes)
-		if err != nil {
-			c.Errorf("failed to send command result to server[jid=%q,pid=%d]: %s", job.JID, cmd.Process.Pid, err)
-		}
+		c.jobResults.Send(c.getConn(), &job)
 
 		c.Debugf("finished to observe cmd [jid=%q,pid=%d]", job.JID, cmd.Process.Pid)
 	}()
 
-	return &comm.RunCmdResponse{
-		Pid:       cmd.Process.Pid,
-		StartedAt: startedAt,
-	}, nil
+	return resp, nil
 }
 
 func (c *Client) buildErrText(execErr error, stdOut, stdErr *CapacityBuffer) string {
@@ -158,6 +158,7 @@ func TestHandleRunCmdRequestPositiveCase(t *testing.T) {
 		sshConnection: connMock,
 		Logger:        testLog,
 		configHolder:  &configCopy,
+		jobResults:    newJobResults(testLog, t.TempDir()),
 	}
 
 	configCopy.Client.DataDir = filepath.Join(configCopy.Client.DataDir, "TestHandleRunCmdRequestPositiveCase")
@@ -270,6 +271,7 @@ func TestHandleRunCmdRequestPositiveCase(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			// given
 			c.configHolder.RemoteCommands.SendBackLimit = tc.sendBackLimit
+			c.executedJobs = newExecutedJobs(t.TempDir())
 			if tc.denyRegexp != nil {
 				c.configHolder.RemoteCommands.DenyRegexp = []*regexp.Regexp{tc.denyRegexp}
 			}
@@ -318,6 +320,8 @@ func TestNoStreamResult(t *testing.T) {
 		sshConnection: connMock,
 		Logger:        testLog,
 		configHolder:  &configCopy,
+		jobResults:    newJobResults(testLog, t.TempDir()),
+		executedJobs:  newExecutedJobs(t.TempDir()),
 	}
 
 	configCopy.Client.DataDir = filepath.Join(configCopy.Client.DataDir, "TestHandleRunCmdRequestPositiveCase")
@@ -384,6 +388,13 @@ func TestNoStreamResult(t *testing.T) {
 	assert.Equal(t, false, inputWantReply)
 	assert.JSONEq(t, wantJSON, string(inputPayload))
 	assert.Len(t, connMock.ChannelMocks, 0)
+
+	// the job sent again after the reply got lost isn't run twice
+	res, err = c.HandleRunCmdRequest(context.Background(), []byte(jobToRunJSON))
+	require.NoError(t, err)
+	assert.True(t, res.Duplicate)
+	assert.Equal(t, wantPID, res.Pid)
+	assert.True(t, nowMock.Equal(res.StartedAt))
 }
 
 func TestRemoteCommandsDisabled(t *testing.T) {
@@ -9,14 +9,17 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"strings"
 	"time"
 
 	"github.com/IOTech17/neo-rport/share/files"
 
+	"github.com/IOTech17/neo-rport/client/cloudmeta"
 	"github.com/IOTech17/neo-rport/client/system"
 	chshare "github.com/IOTech17/neo-rport/share"
 	"github.com/IOTech17/neo-rport/share/clientconfig"
+	"github.com/IOTech17/neo-rport/share/fips"
 	"github.com/IOTech17/neo-rport/share/logger"
 	"github.com/IOTech17/neo-rport/share/models"
 )
@@ -51,6 +54,9 @@ func (c *ClientConfigHolder) ParseAndValidate(skipScriptsDirValidation bool) err
 	if err := c.parseInterpreterAliases(); err != nil {
 		return err
 	}
+	if err := c.Connection.SSHPolicy.Validate(c.Client.FIPSMode || fips.BoringCrypto()); err != nil {
+		return err
+	}
 
 	if c.Connection.MaxRetryInterval < time.Second {
 		c.Connection.MaxRetryInterval = 5 * time.Minute
@@ -86,6 +92,36 @@ func (c *ClientConfigHolder) ParseAndValidate(skipScriptsDirValidation bool) err
 		return err
 	}
 
+	if err := c.parseAndValidateResourceLimits(runtime.GOOS); err != nil {
+		return fmt.Errorf("resource limits: %v", err)
+	}
+
+	if err := c.parseAndValidateNetworkDiscovery(); err != nil {
+		return fmt.Errorf("network discovery: %v", err)
+	}
+
+	if err := c.parseAndValidateAgentless(); err != nil {
+		return fmt.Errorf("agentless: %v", err)
+	}
+
+	if err := c.parseAndValidateIndustrial(); err != nil {
+		return fmt.Errorf("industrial: %v", err)
+	}
+
+	if err := c.parseAndValidateSerialConsoles(); err != nil {
+		return fmt.Errorf("serial consoles: %v", err)
+	}
+
+	if err := c.parseAndValidateCloudMetadata(); err != nil {
+		return fmt.Errorf("cloud metadata: %v", err)
+	}
+
+	switch c.Consent.OnTimeout {
+	case "", clientconfig.ConsentOnTimeoutDeny, clientconfig.ConsentOnTimeoutAllow:
+	default:
+		return fmt.Errorf("consent: invalid 'on_timeout' %q, expected %q or %q", c.Consent.OnTimeout, clientconfig.ConsentOnTimeoutDeny, clientconfig.ConsentOnTimeoutAllow)
+	}
+
 	return nil
 }
 
@@ -213,6 +249,19 @@ func (c *ClientConfigHolder) parseProxyURL() error {
 		}
 		c.Client.ProxyURL = proxyURL
 	}
+	if p := c.Client.ProxyPACURL; p != "" {
+		pacURL, err := url.Parse(p)
+		if err != nil {
+			return fmt.Errorf("invalid proxy PAC URL: %v", err)
+		}
+		switch pacURL.Scheme {
+		case "http", "https", "file", "":
+		default:
+			if !filepath.IsAbs(p) {
+				return fmt.Errorf("invalid proxy PAC URL %q: must be a http(s) or file url or an absolute path", p)
+			}
+		}
+	}
 	return nil
 }
 
@@ -316,6 +365,23 @@ func (c *ClientConfigHolder) IsFileReceptionEnabled() bool {
 	return c.FileReceptionConfig.Enabled
 }
 
+// Capabilities returns the features the client supports with the current configuration, they are sent to the server
+// on connect, so requests for the others fail fast on the server.
+func (c *ClientConfigHolder) Capabilities() []string {
+	// there are no interactive terminals yet, so models.ClientCapabilityPTY isn't reported
+	capabilities := []string{models.ClientCapabilityUDPTunnels}
+	if c.RemoteScripts.Enabled {
+		capabilities = append(capabilities, models.ClientCapabilityScripts)
+	}
+	if c.FileReceptionConfig.Enabled {
+		capabilities = append(capabilities, models.ClientCapabilityFileTransfer)
+	}
+	if c.Monitoring.Enabled {
+		capabilities = append(capabilities, models.ClientCapabilityMonitoring)
+	}
+	return capabilities
+}
+
 func (c *ClientConfigHolder) parseRemoteScripts(skipScriptsDirValidation bool) error {
 	if skipScriptsDirValidation {
 		return nil
@@ -424,6 +490,89 @@ func (c *ClientConfigHolder) parseInterpreterAliases() error {
 	return nil
 }
 
+func (c *ClientConfigHolder) parseAndValidateResourceLimits(goos string) error {
+	limits := c.ResourceLimits
+	if limits.ExecNice < 0 || limits.ExecNice > 19 {
+		return fmt.Errorf("'exec_nice' must be between 0 and 19, %d given", limits.ExecNice)
+	}
+	switch limits.ExecIOClass {
+	case "", clientconfig.IOClassBestEffort, clientconfig.IOClassIdle:
+	default:
+		return fmt.Errorf("invalid 'exec_io_class' %q, expected %q or %q", limits.ExecIOClass, clientconfig.IOClassBestEffort, clientconfig.IOClassIdle)
+	}
+	if limits.ExecMaxCPUSeconds < 0 {
+		return errors.New("'exec_max_cpu_seconds' must not be negative")
+	}
+	if limits.ExecMaxMemoryMB < 0 {
+		return errors.New("'exec_max_memory_mb' must not be negative")
+	}
+	if goos != "linux" && (limits.ExecIOClass != "" || limits.ExecMaxCPUSeconds > 0 || limits.ExecMaxMemoryMB > 0) {
+		return fmt.Errorf("'exec_io_class', 'exec_max_cpu_seconds' and 'exec_max_memory_mb' are not supported on %s", goos)
+	}
+	if limits.MonitoringCPUBudgetPercent < 0 || limits.MonitoringCPUBudgetPercent > 100 {
+		return fmt.Errorf("'monitoring_cpu_budget_percent' must be between 0 and 100, %g given", limits.MonitoringCPUBudgetPercent)
+	}
+	return nil
+}
+
+func (c *ClientConfigHolder) parseAndValidateNetworkDiscovery() error {
+	if _, err := parseDiscoverySubnets(c.NetworkDiscovery.Subnets); err != nil {
+		return err
+	}
+	for _, port := range c.NetworkDiscovery.Ports {
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("invalid port %d in 'ports'", port)
+		}
+	}
+	if c.NetworkDiscovery.MaxHosts < 0 {
+		return errors.New("'max_hosts' must not be negative")
+	}
+	return nil
+}
+
+func (c *ClientConfigHolder) parseAndValidateAgentless() error {
+	if _, err := parseAgentlessTargets(c.Agentless.Targets); err != nil {
+		return err
+	}
+	if c.Agentless.MaxSessions < 0 {
+		return errors.New("'max_sessions' must not be negative")
+	}
+	return nil
+}
+
+func (c *ClientConfigHolder) parseAndValidateIndustrial() error {
+	_, err := parseAgentlessTargets(c.Industrial.Targets)
+	return err
+}
+
+func (c *ClientConfigHolder) parseAndValidateSerialConsoles() error {
+	for _, pattern := range c.SerialConsoles.Ports {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q in 'ports': %v", pattern, err)
+		}
+	}
+	if c.SerialConsoles.Enabled && len(c.SerialConsoles.Ports) == 0 {
+		return errors.New("'ports' must not be empty if enabled")
+	}
+	return nil
+}
+
+func (c *ClientConfigHolder) parseAndValidateCloudMetadata() error {
+	if !c.CloudMetadata.Enabled {
+		return nil
+	}
+	if c.CloudMetadata.Provider == "" {
+		c.CloudMetadata.Provider = cloudmeta.ProviderAuto
+	}
+	if c.CloudMetadata.Provider != cloudmeta.ProviderAuto && !contains(cloudmeta.Providers, c.CloudMetadata.Provider) {
+		return fmt.Errorf("invalid 'provider' %q, expected %q or one of %s", c.CloudMetadata.Provider, cloudmeta.ProviderAuto, strings.Join(cloudmeta.Providers, ", "))
+	}
+	if c.CloudMetadata.Timeout <= 0 {
+		c.CloudMetadata.Timeout = cloudmeta.DefaultTimeout
+	}
+	return nil
+}
+
 func (c *ClientConfigHolder) parseAndValidateIPAPIURL() error {
 	if c.Client.IPAPIURL == "" {
 		return nil
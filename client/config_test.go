@@ -226,6 +226,55 @@ func TestConfigParseAndValidateProxyURL(t *testing.T) {
 	}
 }
 
+func TestConfigParseAndValidateProxyPACURL(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		PACURL        string
+		ExpectedError string
+	}{
+		{
+			Name:   "http",
+			PACURL: "http://wpad.example.com/proxy.pac",
+		}, {
+			Name:   "file",
+			PACURL: "file:///etc/proxy.pac",
+		}, {
+			Name:   "path",
+			PACURL: "/etc/proxy.pac",
+		}, {
+			Name:          "unsupported scheme",
+			PACURL:        "ftp://wpad.example.com/proxy.pac",
+			ExpectedError: `invalid proxy PAC URL "ftp://wpad.example.com/proxy.pac": must be a http(s) or file url or an absolute path`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			config := getDefaultValidMinConfig()
+			config.Client.ProxyPACURL = tc.PACURL
+			err := config.ParseAndValidate(true)
+
+			if tc.ExpectedError == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Equal(t, tc.ExpectedError, err.Error())
+			}
+		})
+	}
+}
+
+func TestConfigParseAndValidateSSHPolicy(t *testing.T) {
+	config := getDefaultValidMinConfig()
+	config.Connection.SSHPolicy.Ciphers = []string{"aes256-gcm@openssh.com"}
+	require.NoError(t, config.ParseAndValidate(true))
+
+	config.Connection.SSHPolicy.Ciphers = []string{"chacha20-poly1305@openssh.com"}
+	config.Client.FIPSMode = true
+	err := config.ParseAndValidate(true)
+	assert.EqualError(t, err, `'ssh_ciphers': algorithm "chacha20-poly1305@openssh.com" is not allowed in fips mode`)
+}
+
 func TestConfigParseAndValidateRemotes(t *testing.T) {
 	schemeHTTP := "http"
 
@@ -814,3 +863,93 @@ func TestConfigParseInterpreterAliases(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigParseAndValidateResourceLimits(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		GOOS          string
+		Limits        clientconfig.ResourceLimitsConfig
+		ExpectedError string
+	}{
+		{
+			Name: "unlimited",
+			GOOS: "darwin",
+		},
+		{
+			Name: "linux",
+			GOOS: "linux",
+			Limits: clientconfig.ResourceLimitsConfig{
+				ExecNice:                   10,
+				ExecIOClass:                clientconfig.IOClassIdle,
+				ExecMaxCPUSeconds:          600,
+				ExecMaxMemoryMB:            512,
+				MonitoringCPUBudgetPercent: 2,
+			},
+		},
+		{
+			Name:   "nice on windows",
+			GOOS:   "windows",
+			Limits: clientconfig.ResourceLimitsConfig{ExecNice: 19},
+		},
+		{
+			Name:          "invalid nice",
+			GOOS:          "linux",
+			Limits:        clientconfig.ResourceLimitsConfig{ExecNice: 20},
+			ExpectedError: "'exec_nice' must be between 0 and 19, 20 given",
+		},
+		{
+			Name:          "invalid io class",
+			GOOS:          "linux",
+			Limits:        clientconfig.ResourceLimitsConfig{ExecIOClass: "realtime"},
+			ExpectedError: `invalid 'exec_io_class' "realtime", expected "best-effort" or "idle"`,
+		},
+		{
+			Name:          "rlimits on windows",
+			GOOS:          "windows",
+			Limits:        clientconfig.ResourceLimitsConfig{ExecMaxMemoryMB: 512},
+			ExpectedError: "'exec_io_class', 'exec_max_cpu_seconds' and 'exec_max_memory_mb' are not supported on windows",
+		},
+		{
+			Name:          "invalid budget",
+			GOOS:          "linux",
+			Limits:        clientconfig.ResourceLimitsConfig{MonitoringCPUBudgetPercent: 120},
+			ExpectedError: "'monitoring_cpu_budget_percent' must be between 0 and 100, 120 given",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+
+			config := getDefaultValidMinConfig()
+			config.ResourceLimits = tc.Limits
+
+			err := config.parseAndValidateResourceLimits(tc.GOOS)
+
+			if tc.ExpectedError == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.ExpectedError)
+			}
+		})
+	}
+}
+
+func TestConfigCapabilities(t *testing.T) {
+	config := getDefaultValidMinConfig()
+	config.RemoteScripts.Enabled = false
+	config.FileReceptionConfig.Enabled = false
+	config.Monitoring.Enabled = false
+	assert.Equal(t, []string{models.ClientCapabilityUDPTunnels}, config.Capabilities())
+
+	config.RemoteScripts.Enabled = true
+	config.FileReceptionConfig.Enabled = true
+	config.Monitoring.Enabled = true
+	assert.ElementsMatch(t, []string{
+		models.ClientCapabilityUDPTunnels,
+		models.ClientCapabilityScripts,
+		models.ClientCapabilityFileTransfer,
+		models.ClientCapabilityMonitoring,
+	}, config.Capabilities())
+}
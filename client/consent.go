@@ -0,0 +1,184 @@
+package chclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/IOTech17/neo-rport/share/clientconfig"
+	"github.com/IOTech17/neo-rport/share/comm"
+)
+
+const (
+	consentRequesterPlaceholder = "{requester}"
+	consentMessagePlaceholder   = "{message}"
+	consentTimeout              = 30 * time.Second
+	// consentGrantLifetime is the time the server has to use a granted consent
+	consentGrantLifetime = time.Minute
+)
+
+type grantedConsent struct {
+	subject   string
+	expiresAt time.Time
+}
+
+// grantedConsents holds the consents granted by the user until the server uses them.
+type grantedConsents struct {
+	m  map[string]grantedConsent
+	mu sync.Mutex
+}
+
+func newGrantedConsents() *grantedConsents {
+	return &grantedConsents{
+		m: make(map[string]grantedConsent),
+	}
+}
+
+func (g *grantedConsents) grant(id, subject string, now time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for id, c := range g.m {
+		if now.After(c.expiresAt) {
+			delete(g.m, id)
+		}
+	}
+	g.m[id] = grantedConsent{subject: subject, expiresAt: now.Add(consentGrantLifetime)}
+}
+
+// take removes the consent, it returns false if it's not granted for the subject or expired.
+func (g *grantedConsents) take(id, subject string, now time.Time) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	c, ok := g.m[id]
+	delete(g.m, id)
+	return ok && c.subject == subject && !now.After(c.expiresAt)
+}
+
+// handleAskConsent asks the user for consent if required by the config. The result is sent to the server as
+// separate request, the answer of the user can take a while.
+func (c *Client) handleAskConsent(ctx context.Context, conn ssh.Conn, payload []byte) (*comm.AskConsentResponse, error) {
+	var req comm.AskConsentRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return nil, fmt.Errorf("failed to decode %T: %v", req, err)
+	}
+
+	cfg := c.configHolder.Consent
+	if !cfg.Required(req.Subject) {
+		return &comm.AskConsentResponse{}, nil
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = consentTimeout
+	}
+
+	c.Infof("Asking the user for consent %s to %s requested by %s", req.ID, req.Subject, req.Requester)
+	go func() {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		result := c.askConsent(ctx, cfg, req)
+		if result.Granted {
+			c.consents.grant(req.ID, req.Subject, time.Now())
+		}
+		c.Infof("Consent %s granted: %t %s", req.ID, result.Granted, result.Reason)
+
+		if err := comm.SendRequestAndGetResponse(conn, comm.RequestTypeConsentResult, result, nil, c.Logger); err != nil {
+			c.Errorf("Failed to send result of consent %s: %v", req.ID, err)
+		}
+	}()
+	return &comm.AskConsentResponse{Required: true, Timeout: timeout}, nil
+}
+
+// askConsent asks the user, the "on_timeout" config decides if the user does not answer or can't be asked.
+func (c *Client) askConsent(ctx context.Context, cfg clientconfig.ConsentConfig, req comm.AskConsentRequest) comm.ConsentResult {
+	result := comm.ConsentResult{ID: req.ID}
+
+	var reason string
+	command, err := consentCommand(cfg)
+	if err == nil {
+		result.Granted, err = runConsentCommand(ctx, command, req.Requester, consentMessage(req))
+		if err == nil {
+			if !result.Granted {
+				result.Reason = "denied by the user"
+			}
+			return result
+		}
+	}
+	if ctx.Err() != nil {
+		reason = "the user did not answer in time"
+	} else {
+		c.Errorf("Failed to ask for consent %s: %v", req.ID, err)
+		reason = fmt.Sprintf("failed to ask the user: %v", err)
+	}
+
+	result.Granted = cfg.OnTimeout == clientconfig.ConsentOnTimeoutAllow
+	if result.Granted {
+		result.Reason = reason + ", allowed by config"
+	} else {
+		result.Reason = reason
+	}
+	return result
+}
+
+func consentMessage(req comm.AskConsentRequest) string {
+	var msg string
+	switch req.Subject {
+	case comm.ConsentSubjectScreenshot:
+		msg = fmt.Sprintf("%s wants to take a screenshot of your screen.", req.Requester)
+	case comm.ConsentSubjectSession:
+		msg = fmt.Sprintf("%s wants to start a remote desktop session.", req.Requester)
+	default:
+		msg = fmt.Sprintf("%s wants to access this machine.", req.Requester)
+	}
+	if req.Description != "" {
+		msg += " " + req.Description
+	}
+	return msg + " Do you allow it?"
+}
+
+func consentCommand(cfg clientconfig.ConsentConfig) ([]string, error) {
+	if len(cfg.Command) > 0 {
+		return cfg.Command, nil
+	}
+	for _, command := range defaultConsentCommands {
+		if _, err := exec.LookPath(command[0]); err == nil {
+			return command, nil
+		}
+	}
+	return nil, errors.New(`no tool to ask for consent found, set "consent.command" config`)
+}
+
+// runConsentCommand returns true if the command exits with 0. Any other exit code without error output is a denial.
+func runConsentCommand(ctx context.Context, command []string, requester, message string) (bool, error) {
+	replacer := strings.NewReplacer(consentRequesterPlaceholder, requester, consentMessagePlaceholder, message)
+	args := make([]string, 0, len(command)-1)
+	for _, arg := range command[1:] {
+		args = append(args, replacer.Replace(arg))
+	}
+	cmd := exec.CommandContext(ctx, command[0], args...) //nolint:gosec
+	cmd.Env = append(os.Environ(), "RPORT_CONSENT_REQUESTER="+requester, "RPORT_CONSENT_MESSAGE="+message)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && stderr.Len() == 0 {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("%s: %v %s", command[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return true, nil
+}
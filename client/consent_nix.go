@@ -0,0 +1,17 @@
+//go:build !windows
+// +build !windows
+
+package chclient
+
+// defaultConsentCommands are tried in order, the first one installed is used. They exit with 0 if the user allows it.
+var defaultConsentCommands = [][]string{
+	{"zenity", "--question", "--title", "Remote access", "--text", consentMessagePlaceholder},
+	{"kdialog", "--title", "Remote access", "--yesno", consentMessagePlaceholder},
+	{
+		"osascript",
+		"-e", "on run argv",
+		"-e", `display dialog (item 1 of argv) with title "Remote access" buttons {"Deny", "Allow"} default button "Deny" cancel button "Deny"`,
+		"-e", "end run",
+		consentMessagePlaceholder,
+	},
+}
@@ -0,0 +1,98 @@
+//go:build !windows
+// +build !windows
+
+package chclient
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/share/clientconfig"
+	"github.com/IOTech17/neo-rport/share/comm"
+)
+
+func TestRunConsentCommand(t *testing.T) {
+	ctx := context.Background()
+
+	granted, err := runConsentCommand(ctx, []string{"sh", "-c", `test "$0 $RPORT_CONSENT_MESSAGE" = "admin ok?"`, "{requester}"}, "admin", "ok?")
+	require.NoError(t, err)
+	assert.True(t, granted)
+
+	granted, err = runConsentCommand(ctx, []string{"sh", "-c", "exit 1"}, "admin", "ok?")
+	require.NoError(t, err)
+	assert.False(t, granted)
+
+	_, err = runConsentCommand(ctx, []string{"sh", "-c", "echo no display >&2; exit 1"}, "admin", "ok?")
+	assert.EqualError(t, err, "sh: exit status 1 no display")
+}
+
+func TestAskConsent(t *testing.T) {
+	c := &Client{Logger: testLog}
+	req := comm.AskConsentRequest{ID: "1", Subject: comm.ConsentSubjectSession, Requester: "admin"}
+
+	testCases := []struct {
+		name     string
+		cfg      clientconfig.ConsentConfig
+		expected comm.ConsentResult
+	}{
+		{
+			name:     "granted",
+			cfg:      clientconfig.ConsentConfig{Command: []string{"true"}},
+			expected: comm.ConsentResult{ID: "1", Granted: true},
+		},
+		{
+			name:     "denied",
+			cfg:      clientconfig.ConsentConfig{Command: []string{"false"}},
+			expected: comm.ConsentResult{ID: "1", Reason: "denied by the user"},
+		},
+		{
+			name:     "timeout",
+			cfg:      clientconfig.ConsentConfig{Command: []string{"sleep", "5"}, OnTimeout: clientconfig.ConsentOnTimeoutDeny},
+			expected: comm.ConsentResult{ID: "1", Reason: "the user did not answer in time"},
+		},
+		{
+			name:     "timeout allowed",
+			cfg:      clientconfig.ConsentConfig{Command: []string{"sleep", "5"}, OnTimeout: clientconfig.ConsentOnTimeoutAllow},
+			expected: comm.ConsentResult{ID: "1", Granted: true, Reason: "the user did not answer in time, allowed by config"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			assert.Equal(t, tc.expected, c.askConsent(ctx, tc.cfg, req))
+		})
+	}
+}
+
+func TestGrantedConsents(t *testing.T) {
+	consents := newGrantedConsents()
+	now := time.Now()
+	consents.grant("1", comm.ConsentSubjectScreenshot, now)
+	consents.grant("2", comm.ConsentSubjectScreenshot, now)
+
+	assert.False(t, consents.take("1", comm.ConsentSubjectSession, now))
+	assert.False(t, consents.take("1", comm.ConsentSubjectScreenshot, now), "taken by the wrong subject")
+	assert.False(t, consents.take("2", comm.ConsentSubjectScreenshot, now.Add(2*consentGrantLifetime)))
+	assert.False(t, consents.take("3", comm.ConsentSubjectScreenshot, now))
+
+	consents.grant("4", comm.ConsentSubjectScreenshot, now)
+	assert.True(t, consents.take("4", comm.ConsentSubjectScreenshot, now))
+}
+
+func TestHandleTakeScreenshotWithoutConsent(t *testing.T) {
+	c := &Client{
+		configHolder: &ClientConfigHolder{Config: &clientconfig.Config{
+			Screenshots: clientconfig.ScreenshotsConfig{Enabled: true},
+			Consent:     clientconfig.ConsentConfig{Screenshots: true},
+		}},
+		consents: newGrantedConsents(),
+	}
+
+	err := c.handleTakeScreenshot(context.Background(), nil, []byte(`{"ID":"1","ConsentID":"2"}`))
+	assert.EqualError(t, err, "screenshots require the consent of the user")
+}
@@ -0,0 +1,13 @@
+//go:build windows
+// +build windows
+
+package chclient
+
+// defaultConsentCommands read the message from the environment, so it's never interpreted as PowerShell code.
+var defaultConsentCommands = [][]string{
+	{
+		"powershell.exe", "-NoProfile", "-NonInteractive", "-Command",
+		"Add-Type -AssemblyName System.Windows.Forms; " +
+			"if ([System.Windows.Forms.MessageBox]::Show($env:RPORT_CONSENT_MESSAGE, 'Remote access', 'YesNo', 'Question') -eq 'Yes') { exit 0 } else { exit 1 }",
+	},
+}
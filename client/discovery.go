@@ -0,0 +1,372 @@
+package chclient
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/IOTech17/neo-rport/share/clientconfig"
+	"github.com/IOTech17/neo-rport/share/comm"
+)
+
+// DefaultDiscoveryPorts are the TCP ports network discovery scans may probe, unless configured otherwise.
+var DefaultDiscoveryPorts = []int{22, 23, 80, 443, 445, 502, 3389, 5900, 5985, 5986, 8080, 9100}
+
+const (
+	defaultDiscoveryMaxHosts     = 1024
+	defaultDiscoveryProbeTimeout = 500 * time.Millisecond
+	discoveryConcurrency         = 64
+)
+
+// arpTablePath is only available on linux, on other systems devices are found by the probes only
+var arpTablePath = "/proc/net/arp"
+
+type discoveryScan struct {
+	id      string
+	subnets []*net.IPNet
+	ports   []int
+	timeout time.Duration
+}
+
+// handleDiscoverNetwork starts a scan of the local networks if allowed by the config. The devices found are sent to
+// the server as separate request when the scan is finished.
+func (c *Client) handleDiscoverNetwork(ctx context.Context, conn ssh.Conn, payload []byte) error {
+	cfg := c.configHolder.NetworkDiscovery
+	if !cfg.Enabled {
+		return errors.New(`network discovery is disabled by "network-discovery.enabled" config`)
+	}
+
+	var req comm.DiscoveryRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return fmt.Errorf("failed to decode %T: %v", req, err)
+	}
+
+	scan, err := newDiscoveryScan(cfg, req, interfaceSubnets)
+	if err != nil {
+		return err
+	}
+
+	if !c.discoveryRunning.CompareAndSwap(false, true) {
+		return errors.New("a network discovery is already running")
+	}
+	c.Infof("Starting network discovery %s of %v", scan.id, scan.subnets)
+	go func() {
+		defer c.discoveryRunning.Store(false)
+
+		result := scan.run(ctx)
+		c.Infof("Network discovery %s found %d devices", scan.id, len(result.Devices))
+		err := comm.SendRequestAndGetResponse(conn, comm.RequestTypeDiscoveryResult, result, nil, c.Logger)
+		if err != nil {
+			c.Errorf("Failed to send result of network discovery %s: %v", scan.id, err)
+		}
+	}()
+	return nil
+}
+
+// newDiscoveryScan checks the requested subnets and ports against the config, empty ones scan all that is allowed.
+func newDiscoveryScan(cfg clientconfig.NetworkDiscoveryConfig, req comm.DiscoveryRequest, localSubnets func() ([]*net.IPNet, error)) (*discoveryScan, error) {
+	allowedSubnets, err := parseDiscoverySubnets(cfg.Subnets)
+	if err != nil {
+		return nil, err
+	}
+	if len(allowedSubnets) == 0 {
+		allowedSubnets, err = localSubnets()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get the local subnets: %v", err)
+		}
+	}
+
+	subnets := allowedSubnets
+	if len(req.Subnets) > 0 {
+		subnets, err = parseDiscoverySubnets(req.Subnets)
+		if err != nil {
+			return nil, err
+		}
+		for _, subnet := range subnets {
+			if !subnetAllowed(subnet, allowedSubnets) {
+				return nil, fmt.Errorf("subnet %s is not allowed by %q config", subnet, "network-discovery.subnets")
+			}
+		}
+	}
+	if len(subnets) == 0 {
+		return nil, errors.New("no subnet to scan")
+	}
+
+	allowedPorts := cfg.Ports
+	if len(allowedPorts) == 0 {
+		allowedPorts = DefaultDiscoveryPorts
+	}
+	ports := allowedPorts
+	if len(req.Ports) > 0 {
+		ports = req.Ports
+		for _, port := range ports {
+			if !containsPort(allowedPorts, port) {
+				return nil, fmt.Errorf("port %d is not allowed by %q config", port, "network-discovery.ports")
+			}
+		}
+	}
+
+	maxHosts := cfg.MaxHosts
+	if maxHosts <= 0 {
+		maxHosts = defaultDiscoveryMaxHosts
+	}
+	var hosts uint64
+	for _, subnet := range subnets {
+		hosts += subnetHostCount(subnet)
+	}
+	if hosts > uint64(maxHosts) {
+		return nil, fmt.Errorf("scan of %d hosts exceeds the limit of %d set by %q config", hosts, maxHosts, "network-discovery.max_hosts")
+	}
+
+	timeout := cfg.ProbeTimeout
+	if timeout <= 0 {
+		timeout = defaultDiscoveryProbeTimeout
+	}
+
+	return &discoveryScan{
+		id:      req.ID,
+		subnets: subnets,
+		ports:   ports,
+		timeout: timeout,
+	}, nil
+}
+
+func parseDiscoverySubnets(cidrs []string) ([]*net.IPNet, error) {
+	subnets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, subnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid subnet %q: %v", cidr, err)
+		}
+		if subnet.IP.To4() == nil {
+			return nil, fmt.Errorf("invalid subnet %q: only IPv4 subnets can be scanned", cidr)
+		}
+		subnets = append(subnets, subnet)
+	}
+	return subnets, nil
+}
+
+// interfaceSubnets returns the IPv4 subnets of the local interfaces which are up, except loopback and link-local.
+func interfaceSubnets() ([]*net.IPNet, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	var subnets []*net.IPNet
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || ipNet.IP.To4() == nil || ipNet.IP.IsLinkLocalUnicast() {
+				continue
+			}
+			subnets = append(subnets, &net.IPNet{IP: ipNet.IP.Mask(ipNet.Mask).To4(), Mask: ipNet.Mask})
+		}
+	}
+	return subnets, nil
+}
+
+func subnetAllowed(subnet *net.IPNet, allowed []*net.IPNet) bool {
+	ones, _ := subnet.Mask.Size()
+	for _, a := range allowed {
+		allowedOnes, _ := a.Mask.Size()
+		if a.Contains(subnet.IP) && allowedOnes <= ones {
+			return true
+		}
+	}
+	return false
+}
+
+func containsPort(ports []int, port int) bool {
+	for _, p := range ports {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
+
+// subnetHostCount returns the number of hosts without network and broadcast address, except for /31 and /32.
+func subnetHostCount(subnet *net.IPNet) uint64 {
+	ones, bits := subnet.Mask.Size()
+	size := uint64(1) << uint(bits-ones)
+	if size > 2 {
+		size -= 2
+	}
+	return size
+}
+
+func subnetHosts(subnet *net.IPNet) []net.IP {
+	ones, bits := subnet.Mask.Size()
+	size := uint32(1) << uint(bits-ones)
+	first := binary.BigEndian.Uint32(subnet.IP.To4())
+	last := first + size - 1
+	if size > 2 {
+		first++
+		last--
+	}
+	hosts := make([]net.IP, 0, last-first+1)
+	for n := first; n >= first && n <= last; n++ {
+		ip := make(net.IP, net.IPv4len)
+		binary.BigEndian.PutUint32(ip, n)
+		hosts = append(hosts, ip)
+	}
+	return hosts
+}
+
+// run probes the hosts of the subnets concurrently. Hosts accepting or refusing a connection are up, hosts in the ARP
+// table of the client afterwards as well.
+func (s *discoveryScan) run(ctx context.Context) comm.DiscoveryResult {
+	result := comm.DiscoveryResult{
+		ID:        s.id,
+		StartedAt: time.Now(),
+	}
+
+	var hosts []net.IP
+	for _, subnet := range s.subnets {
+		hosts = append(hosts, subnetHosts(subnet)...)
+	}
+
+	devices := make(map[string]*comm.DiscoveredDevice)
+	var mu sync.Mutex
+	jobs := make(chan net.IP)
+	var wg sync.WaitGroup
+	for i := 0; i < discoveryConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ip := range jobs {
+				openPorts, up := s.probeHost(ctx, ip)
+				if !up {
+					continue
+				}
+				mu.Lock()
+				devices[ip.String()] = &comm.DiscoveredDevice{IP: ip.String(), OpenPorts: openPorts}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, ip := range hosts {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- ip
+	}
+	close(jobs)
+	wg.Wait()
+
+	for ip, mac := range readARPTable(arpTablePath) {
+		if !s.contains(net.ParseIP(ip)) {
+			continue
+		}
+		device := devices[ip]
+		if device == nil {
+			device = &comm.DiscoveredDevice{IP: ip, OpenPorts: []int{}}
+			devices[ip] = device
+		}
+		device.MAC = mac
+	}
+
+	result.Devices = make([]comm.DiscoveredDevice, 0, len(devices))
+	for _, device := range devices {
+		device.Hostname = s.lookupHostname(ctx, device.IP)
+		result.Devices = append(result.Devices, *device)
+	}
+	sort.Slice(result.Devices, func(i, j int) bool {
+		return bytes.Compare(net.ParseIP(result.Devices[i].IP).To4(), net.ParseIP(result.Devices[j].IP).To4()) < 0
+	})
+
+	if ctx.Err() != nil {
+		result.Error = "network discovery was interrupted, the result is incomplete"
+	}
+	result.FinishedAt = time.Now()
+	return result
+}
+
+func (s *discoveryScan) contains(ip net.IP) bool {
+	for _, subnet := range s.subnets {
+		if subnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *discoveryScan) probeHost(ctx context.Context, ip net.IP) (openPorts []int, up bool) {
+	openPorts = []int{}
+	dialer := net.Dialer{Timeout: s.timeout}
+	for _, port := range s.ports {
+		conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(ip.String(), strconv.Itoa(port)))
+		if err == nil {
+			conn.Close()
+			openPorts = append(openPorts, port)
+			up = true
+			continue
+		}
+		if isConnectionRefused(err) {
+			up = true
+		}
+	}
+	return openPorts, up
+}
+
+func isConnectionRefused(err error) bool {
+	// on windows the error is not ECONNREFUSED
+	return errors.Is(err, syscall.ECONNREFUSED) || strings.Contains(err.Error(), "actively refused")
+}
+
+func (s *discoveryScan) lookupHostname(ctx context.Context, ip string) string {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	names, err := net.DefaultResolver.LookupAddr(ctx, ip)
+	if err != nil || len(names) == 0 {
+		return ""
+	}
+	return strings.TrimSuffix(names[0], ".")
+}
+
+// readARPTable returns the mac addresses of the complete entries of the linux ARP table by ip.
+func readARPTable(path string) map[string]string {
+	entries := make(map[string]string)
+	f, err := os.Open(path)
+	if err != nil {
+		return entries
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	// skip the header
+	scanner.Scan()
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		ip, flags, mac := fields[0], fields[2], fields[3]
+		if flags == "0x0" || mac == "00:00:00:00:00:00" {
+			continue
+		}
+		entries[ip] = mac
+	}
+	return entries
+}
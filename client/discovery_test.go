@@ -0,0 +1,131 @@
+package chclient
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/share/clientconfig"
+	"github.com/IOTech17/neo-rport/share/comm"
+)
+
+func TestNewDiscoveryScan(t *testing.T) {
+	localSubnets := func() ([]*net.IPNet, error) {
+		return parseDiscoverySubnets([]string{"192.168.1.0/24"})
+	}
+	testCases := []struct {
+		name        string
+		cfg         clientconfig.NetworkDiscoveryConfig
+		req         comm.DiscoveryRequest
+		wantSubnets []string
+		wantPorts   []int
+		wantErr     string
+	}{
+		{
+			name:        "local subnets and default ports",
+			wantSubnets: []string{"192.168.1.0/24"},
+			wantPorts:   DefaultDiscoveryPorts,
+		},
+		{
+			name:        "requested part of allowed subnet",
+			cfg:         clientconfig.NetworkDiscoveryConfig{Subnets: []string{"10.0.0.0/16"}, Ports: []int{22, 161, 443}},
+			req:         comm.DiscoveryRequest{Subnets: []string{"10.0.3.0/24"}, Ports: []int{22}},
+			wantSubnets: []string{"10.0.3.0/24"},
+			wantPorts:   []int{22},
+		},
+		{
+			name:    "subnet not allowed",
+			req:     comm.DiscoveryRequest{Subnets: []string{"192.168.0.0/16"}},
+			wantErr: `subnet 192.168.0.0/16 is not allowed by "network-discovery.subnets" config`,
+		},
+		{
+			name:    "port not allowed",
+			req:     comm.DiscoveryRequest{Ports: []int{25}},
+			wantErr: `port 25 is not allowed by "network-discovery.ports" config`,
+		},
+		{
+			name:    "too many hosts",
+			cfg:     clientconfig.NetworkDiscoveryConfig{Subnets: []string{"10.0.0.0/16"}},
+			wantErr: `scan of 65534 hosts exceeds the limit of 1024 set by "network-discovery.max_hosts" config`,
+		},
+		{
+			name:    "IPv6",
+			req:     comm.DiscoveryRequest{Subnets: []string{"fd00::/120"}},
+			wantErr: `invalid subnet "fd00::/120": only IPv4 subnets can be scanned`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			scan, err := newDiscoveryScan(tc.cfg, tc.req, localSubnets)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			var subnets []string
+			for _, subnet := range scan.subnets {
+				subnets = append(subnets, subnet.String())
+			}
+			assert.Equal(t, tc.wantSubnets, subnets)
+			assert.Equal(t, tc.wantPorts, scan.ports)
+		})
+	}
+}
+
+func TestSubnetHosts(t *testing.T) {
+	subnets, err := parseDiscoverySubnets([]string{"10.0.0.0/30", "10.0.0.8/32"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []net.IP{net.ParseIP("10.0.0.1").To4(), net.ParseIP("10.0.0.2").To4()}, subnetHosts(subnets[0]))
+	assert.EqualValues(t, 2, subnetHostCount(subnets[0]))
+	assert.Equal(t, []net.IP{net.ParseIP("10.0.0.8").To4()}, subnetHosts(subnets[1]))
+	assert.EqualValues(t, 1, subnetHostCount(subnets[1]))
+}
+
+func TestDiscoveryScanRun(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	openPort := l.Addr().(*net.TCPAddr).Port
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	closedPort := closed.Addr().(*net.TCPAddr).Port
+	require.NoError(t, closed.Close())
+
+	arpTable := filepath.Join(t.TempDir(), "arp")
+	require.NoError(t, os.WriteFile(arpTable, []byte(
+		"IP address       HW type     Flags       HW address            Mask     Device\n"+
+			"127.0.0.1        0x1         0x2         aa:bb:cc:dd:ee:01     *        lo\n"+
+			"127.0.0.3        0x1         0x0         00:00:00:00:00:00     *        lo\n"+
+			"192.168.1.1      0x1         0x2         aa:bb:cc:dd:ee:02     *        eth0\n",
+	), 0600))
+	defer func(path string) { arpTablePath = path }(arpTablePath)
+	arpTablePath = arpTable
+
+	subnets, err := parseDiscoverySubnets([]string{"127.0.0.1/32"})
+	require.NoError(t, err)
+	scan := &discoveryScan{
+		id:      "scan-1",
+		subnets: subnets,
+		ports:   []int{openPort, closedPort},
+		timeout: time.Second,
+	}
+
+	result := scan.run(context.Background())
+
+	assert.Equal(t, "scan-1", result.ID)
+	assert.Empty(t, result.Error)
+	require.Len(t, result.Devices, 1)
+	assert.Equal(t, "127.0.0.1", result.Devices[0].IP)
+	assert.Equal(t, "aa:bb:cc:dd:ee:01", result.Devices[0].MAC)
+	assert.Equal(t, []int{openPort}, result.Devices[0].OpenPorts)
+	assert.False(t, result.FinishedAt.Before(result.StartedAt))
+}
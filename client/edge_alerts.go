@@ -0,0 +1,457 @@
+package chclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/shirou/gopsutil/v3/mem"
+	"github.com/shirou/gopsutil/v3/process"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/IOTech17/neo-rport/client/system"
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/edgerules"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/random"
+)
+
+// EdgeAlertsFile is the file within the data directory the edge rules, their state and the events not confirmed by
+// the server are kept in, so rules go on firing across restarts while the server is unreachable.
+const EdgeAlertsFile = "edge_alerts.json"
+
+const (
+	edgeRuleInterval = 30 * time.Second
+	// maxEdgeAlertEvents is the number of events kept while the server is unreachable, the oldest are dropped
+	maxEdgeAlertEvents  = 1000
+	edgeEventsBatchSize = 100
+	// maxEdgeRemediationOutput limits the output of remediation scripts kept with the events
+	maxEdgeRemediationOutput      = 4096
+	defaultEdgeRemediationTimeout = time.Minute
+)
+
+// edgeMetrics measures the metrics of edge rules.
+type edgeMetrics interface {
+	CPUPercent(ctx context.Context) (float64, error)
+	MemoryPercent(ctx context.Context) (float64, error)
+	DiskUsedPercent(ctx context.Context, path string) (float64, error)
+	ProcessCount(ctx context.Context, name string) (int, error)
+}
+
+// edgeRemediateFunc runs the remediation script of a rule, it returns the details recorded on the problem.
+type edgeRemediateFunc func(ctx context.Context, rule comm.EdgeRule) string
+
+type edgeRuleState struct {
+	// PendingSince is set while the condition holds but the rule doesn't fire yet
+	PendingSince *time.Time `json:"pending_since,omitempty"`
+	// ProblemID is set while the rule fires
+	ProblemID string `json:"problem_id,omitempty"`
+}
+
+type storedEdgeEvent struct {
+	comm.EdgeAlertEvent
+	Seq       uint64    `json:"seq"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+type edgeAlertsData struct {
+	Rules   []comm.EdgeRule           `json:"rules"`
+	States  map[string]*edgeRuleState `json:"states"`
+	Events  []storedEdgeEvent         `json:"events"`
+	LastSeq uint64                    `json:"last_seq"`
+}
+
+// edgeAlerts evaluates the edge rules pushed by the server, independently of the connection. Changes of their state
+// are sent to the server, or kept until the client is connected again.
+type edgeAlerts struct {
+	logger    *logger.Logger
+	path      string
+	metrics   edgeMetrics
+	remediate edgeRemediateFunc
+	now       func() time.Time
+
+	mu       sync.Mutex
+	data     edgeAlertsData
+	conn     ssh.Conn
+	flushing bool
+}
+
+func newEdgeAlerts(logger *logger.Logger, path string, metrics edgeMetrics, remediate edgeRemediateFunc) *edgeAlerts {
+	e := &edgeAlerts{
+		logger:    logger,
+		path:      path,
+		metrics:   metrics,
+		remediate: remediate,
+		now:       time.Now,
+		data:      edgeAlertsData{States: make(map[string]*edgeRuleState)},
+	}
+	if err := e.load(); err != nil {
+		e.logger.Errorf("Failed to load edge rules, waiting for the server to send them: %v", err)
+	}
+	return e
+}
+
+func (e *edgeAlerts) load() error {
+	b, err := os.ReadFile(e.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var data edgeAlertsData
+	if err := json.Unmarshal(b, &data); err != nil {
+		return fmt.Errorf("failed to decode %q: %v", e.path, err)
+	}
+	if data.States == nil {
+		data.States = make(map[string]*edgeRuleState)
+	}
+	e.data = data
+	return nil
+}
+
+// save writes the rules, states and events to the file. It must be called with the lock held.
+func (e *edgeAlerts) save() {
+	b, err := json.Marshal(e.data)
+	if err != nil {
+		e.logger.Errorf("Failed to encode edge rules: %v", err)
+		return
+	}
+	tmp := e.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		e.logger.Errorf("Failed to save edge rules: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, e.path); err != nil {
+		e.logger.Errorf("Failed to save edge rules: %v", err)
+	}
+}
+
+// Put replaces the edge rules with the ones sent by the server. Rules firing are resolved if they were removed.
+func (e *edgeAlerts) Put(payload []byte) error {
+	var rules []comm.EdgeRule
+	if err := json.Unmarshal(payload, &rules); err != nil {
+		return fmt.Errorf("failed to decode edge rules: %v", err)
+	}
+	for _, r := range rules {
+		if _, err := edgerules.ParseExpr(r.Expr); err != nil {
+			return fmt.Errorf("edge rule %q: %v", r.ID, err)
+		}
+	}
+
+	e.mu.Lock()
+	wanted := make(map[string]bool, len(rules))
+	for _, r := range rules {
+		wanted[r.ID] = true
+	}
+	for id, state := range e.data.States {
+		if wanted[id] {
+			continue
+		}
+		if state.ProblemID != "" {
+			e.addEvent(comm.EdgeAlertEvent{ProblemID: state.ProblemID, RuleID: id, Event: comm.EdgeEventResolved, Details: "edge rule removed"})
+		}
+		delete(e.data.States, id)
+	}
+	e.data.Rules = rules
+	e.save()
+	e.mu.Unlock()
+
+	e.logger.Debugf("%d edge rule(s) received", len(rules))
+	go e.flush()
+	return nil
+}
+
+// Run evaluates the rules until the context is canceled.
+func (e *edgeAlerts) Run(ctx context.Context) {
+	ticker := time.NewTicker(edgeRuleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.evaluate(ctx)
+		}
+	}
+}
+
+func (e *edgeAlerts) evaluate(ctx context.Context) {
+	e.mu.Lock()
+	rules := e.data.Rules
+	e.mu.Unlock()
+	if len(rules) == 0 {
+		return
+	}
+
+	var remediations []comm.EdgeRule
+	var problemIDs []string
+	changed := false
+	for _, rule := range rules {
+		expr, err := edgerules.ParseExpr(rule.Expr)
+		if err != nil {
+			continue
+		}
+		value, err := e.value(ctx, expr)
+		if err != nil {
+			// the state is kept until the metric can be measured again
+			e.logger.Debugf("Edge rule %q: %v", rule.ID, err)
+			continue
+		}
+
+		now := e.now()
+		e.mu.Lock()
+		state := e.data.States[rule.ID]
+		if state == nil {
+			state = &edgeRuleState{}
+			e.data.States[rule.ID] = state
+		}
+		switch {
+		case expr.Matches(value) && state.ProblemID == "":
+			if state.PendingSince == nil {
+				state.PendingSince = &now
+				changed = true
+			}
+			if now.Sub(*state.PendingSince) < rule.For {
+				break
+			}
+			state.PendingSince = nil
+			state.ProblemID, _ = random.UUID4()
+			e.addEvent(comm.EdgeAlertEvent{ProblemID: state.ProblemID, RuleID: rule.ID, Event: comm.EdgeEventFiring, Value: value})
+			e.logger.Infof("Edge rule %q is firing: %s, value %v", rule.ID, expr, value)
+			if rule.Script != "" {
+				remediations = append(remediations, rule)
+				problemIDs = append(problemIDs, state.ProblemID)
+			}
+			changed = true
+		case !expr.Matches(value) && (state.ProblemID != "" || state.PendingSince != nil):
+			if state.ProblemID != "" {
+				e.addEvent(comm.EdgeAlertEvent{ProblemID: state.ProblemID, RuleID: rule.ID, Event: comm.EdgeEventResolved, Value: value})
+				e.logger.Infof("Edge rule %q is resolved, value %v", rule.ID, value)
+			}
+			state.PendingSince = nil
+			state.ProblemID = ""
+			changed = true
+		}
+		e.mu.Unlock()
+	}
+	if !changed {
+		return
+	}
+
+	e.mu.Lock()
+	e.save()
+	e.mu.Unlock()
+	go e.flush()
+
+	for i, rule := range remediations {
+		go e.runRemediation(ctx, rule, problemIDs[i])
+	}
+}
+
+func (e *edgeAlerts) runRemediation(ctx context.Context, rule comm.EdgeRule, problemID string) {
+	details := e.remediate(ctx, rule)
+	e.logger.Infof("Edge rule %q remediated: %s", rule.ID, details)
+
+	e.mu.Lock()
+	e.addEvent(comm.EdgeAlertEvent{ProblemID: problemID, RuleID: rule.ID, Event: comm.EdgeEventRemediated, Details: details})
+	e.save()
+	e.mu.Unlock()
+	e.flush()
+}
+
+func (e *edgeAlerts) value(ctx context.Context, expr edgerules.Expr) (float64, error) {
+	switch expr.Metric {
+	case edgerules.MetricCPUUsagePercent:
+		return e.metrics.CPUPercent(ctx)
+	case edgerules.MetricMemoryUsagePercent:
+		return e.metrics.MemoryPercent(ctx)
+	case edgerules.MetricDiskUsedPercent:
+		return e.metrics.DiskUsedPercent(ctx, expr.Arg)
+	case edgerules.MetricProcessCount:
+		n, err := e.metrics.ProcessCount(ctx, expr.Arg)
+		return float64(n), err
+	}
+	return 0, fmt.Errorf("unknown metric %q", expr.Metric)
+}
+
+// addEvent keeps the event until the server confirmed it, dropping the oldest if there are too many. It must be
+// called with the lock held.
+func (e *edgeAlerts) addEvent(ev comm.EdgeAlertEvent) {
+	e.data.LastSeq++
+	e.data.Events = append(e.data.Events, storedEdgeEvent{EdgeAlertEvent: ev, Seq: e.data.LastSeq, Timestamp: e.now()})
+	if len(e.data.Events) > maxEdgeAlertEvents {
+		e.logger.Infof("Too many edge alert events, dropping the oldest")
+		e.data.Events = e.data.Events[len(e.data.Events)-maxEdgeAlertEvents:]
+	}
+}
+
+// SetConn sets the connection to the server, nil while disconnected. The events kept are sent once connected.
+func (e *edgeAlerts) SetConn(conn ssh.Conn) {
+	e.mu.Lock()
+	e.conn = conn
+	e.mu.Unlock()
+	if conn != nil {
+		go e.flush()
+	}
+}
+
+// flush sends the events to the server, they are removed once the server confirmed them.
+func (e *edgeAlerts) flush() {
+	e.mu.Lock()
+	if e.flushing {
+		e.mu.Unlock()
+		return
+	}
+	e.flushing = true
+	e.mu.Unlock()
+	defer func() {
+		e.mu.Lock()
+		e.flushing = false
+		e.mu.Unlock()
+	}()
+
+	for {
+		e.mu.Lock()
+		conn := e.conn
+		n := len(e.data.Events)
+		if n > edgeEventsBatchSize {
+			n = edgeEventsBatchSize
+		}
+		batch := append([]storedEdgeEvent{}, e.data.Events[:n]...)
+		e.mu.Unlock()
+		if conn == nil || len(batch) == 0 {
+			return
+		}
+
+		now := e.now()
+		payload := make([]comm.EdgeAlertEvent, 0, len(batch))
+		for _, ev := range batch {
+			ev.Age = now.Sub(ev.Timestamp)
+			payload = append(payload, ev.EdgeAlertEvent)
+		}
+		data, err := json.Marshal(payload)
+		if err != nil {
+			e.logger.Errorf("Failed to encode edge alert events: %v", err)
+			return
+		}
+		ok, _, err := conn.SendRequest(comm.RequestTypeEdgeAlertEvents, true, data)
+		if err != nil || !ok {
+			e.logger.Errorf("Failed to send edge alert events, ok=%t, trying again on reconnect: %v", ok, err)
+			return
+		}
+
+		// events may have been dropped meanwhile, only the ones sent are removed
+		sent := batch[len(batch)-1].Seq
+		e.mu.Lock()
+		i := 0
+		for i < len(e.data.Events) && e.data.Events[i].Seq <= sent {
+			i++
+		}
+		e.data.Events = e.data.Events[i:]
+		e.save()
+		e.mu.Unlock()
+	}
+}
+
+// runEdgeRemediation runs the remediation script of an edge rule, it requires remote scripts to be enabled like the
+// scripts run by the server.
+func (c *Client) runEdgeRemediation(ctx context.Context, rule comm.EdgeRule) string {
+	if !c.configHolder.RemoteCommands.Enabled || !c.configHolder.RemoteScripts.Enabled {
+		return "not run, remote scripts are disabled"
+	}
+
+	interpreter := system.Interpreter{
+		InterpreterNameFromInput: rule.Interpreter,
+		InterpreterAliases:       c.configHolder.InterpreterAliases,
+	}
+	scriptPath, err := system.CreateScriptFile(c.configHolder.GetScriptsDir(), rule.Script, interpreter, nil)
+	if err != nil {
+		return fmt.Sprintf("failed to create the script: %v", err)
+	}
+	defer c.rmScript(scriptPath)
+
+	timeout := rule.Timeout
+	if timeout <= 0 {
+		timeout = defaultEdgeRemediationTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := c.cmdExec.New(ctx, &system.CmdExecutorContext{
+		Interpreter: interpreter,
+		Command:     scriptPath,
+		HasShebang:  system.HasShebangLine(rule.Script),
+	})
+	output := &CapacityBuffer{capacity: maxEdgeRemediationOutput}
+	cmd.Stdout = output
+	cmd.Stderr = output
+	if err := c.cmdExec.Start(cmd); err != nil {
+		return fmt.Sprintf("failed to start the script: %v", err)
+	}
+	err = c.cmdExec.Wait(cmd)
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		return fmt.Sprintf("timeout after %s\n%s", timeout, output.String())
+	case err != nil && cmd.ProcessState == nil:
+		return fmt.Sprintf("failed to run the script: %v", err)
+	}
+	return fmt.Sprintf("exit code %d\n%s", cmd.ProcessState.ExitCode(), output.String())
+}
+
+// systemEdgeMetrics measures the metrics of edge rules on the local system.
+type systemEdgeMetrics struct{}
+
+func (systemEdgeMetrics) CPUPercent(ctx context.Context) (float64, error) {
+	percent, err := cpu.PercentWithContext(ctx, time.Second, false)
+	if err != nil {
+		return 0, err
+	}
+	if len(percent) == 0 {
+		return 0, fmt.Errorf("no cpu usage measured")
+	}
+	return percent[0], nil
+}
+
+func (systemEdgeMetrics) MemoryPercent(ctx context.Context) (float64, error) {
+	stats, err := mem.VirtualMemoryWithContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return stats.UsedPercent, nil
+}
+
+func (systemEdgeMetrics) DiskUsedPercent(ctx context.Context, path string) (float64, error) {
+	usage, err := disk.UsageWithContext(ctx, path)
+	if err != nil {
+		return 0, err
+	}
+	return usage.UsedPercent, nil
+}
+
+// ProcessCount counts the processes with the name, ignoring case and the .exe suffix.
+func (systemEdgeMetrics) ProcessCount(ctx context.Context, name string) (int, error) {
+	procs, err := process.ProcessesWithContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+	name = strings.TrimSuffix(strings.ToLower(name), ".exe")
+	count := 0
+	for _, p := range procs {
+		// processes may exit meanwhile
+		pName, err := p.NameWithContext(ctx)
+		if err != nil {
+			continue
+		}
+		if strings.TrimSuffix(strings.ToLower(pName), ".exe") == name {
+			count++
+		}
+	}
+	return count, nil
+}
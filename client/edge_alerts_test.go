@@ -0,0 +1,148 @@
+package chclient
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/test"
+)
+
+type fakeEdgeMetrics struct {
+	mu        sync.Mutex
+	diskUsed  float64
+	processes int
+}
+
+func (m *fakeEdgeMetrics) CPUPercent(context.Context) (float64, error) {
+	return 10, nil
+}
+
+func (m *fakeEdgeMetrics) MemoryPercent(context.Context) (float64, error) {
+	return 20, nil
+}
+
+func (m *fakeEdgeMetrics) DiskUsedPercent(_ context.Context, path string) (float64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.diskUsed, nil
+}
+
+func (m *fakeEdgeMetrics) ProcessCount(_ context.Context, name string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.processes, nil
+}
+
+func (m *fakeEdgeMetrics) set(diskUsed float64, processes int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.diskUsed = diskUsed
+	m.processes = processes
+}
+
+func (e *edgeAlerts) events() []storedEdgeEvent {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]storedEdgeEvent{}, e.data.Events...)
+}
+
+func TestEdgeAlerts(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), EdgeAlertsFile)
+	metrics := &fakeEdgeMetrics{processes: 1}
+	remediated := make(chan string, 1)
+	remediate := func(ctx context.Context, rule comm.EdgeRule) string {
+		remediated <- rule.ID
+		return "exit code 0\nrestarted"
+	}
+	e := newEdgeAlerts(testLog, path, metrics, remediate)
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	e.now = func() time.Time { return now }
+
+	rules, _ := json.Marshal([]comm.EdgeRule{
+		{ID: "disk-full", Expr: "disk_used_percent(/var) > 95", For: time.Minute},
+		{ID: "nginx-down", Expr: "process_count(nginx) < 1", Script: "systemctl restart nginx"},
+	})
+	require.NoError(t, e.Put(rules))
+	assert.Error(t, e.Put([]byte(`[{"ID":"x","Expr":"load > 1"}]`)))
+
+	// the disk rule fires once the condition held for a minute
+	metrics.set(97, 0)
+	e.evaluate(ctx)
+	assert.Equal(t, "nginx-down", <-remediated)
+	require.Eventually(t, func() bool { return len(e.events()) == 2 }, time.Second, 10*time.Millisecond)
+	events := e.events()
+	assert.Equal(t, comm.EdgeEventFiring, events[0].Event)
+	assert.Equal(t, "nginx-down", events[0].RuleID)
+	assert.Equal(t, 0.0, events[0].Value)
+	assert.Equal(t, comm.EdgeEventRemediated, events[1].Event)
+	assert.Equal(t, events[0].ProblemID, events[1].ProblemID)
+	assert.Equal(t, "exit code 0\nrestarted", events[1].Details)
+
+	now = now.Add(time.Minute)
+	e.evaluate(ctx)
+	events = e.events()
+	require.Len(t, events, 3, "nginx-down keeps firing without new events")
+	assert.Equal(t, "disk-full", events[2].RuleID)
+	assert.Equal(t, comm.EdgeEventFiring, events[2].Event)
+	assert.Equal(t, 97.0, events[2].Value)
+
+	// the state and the events survive a restart
+	e = newEdgeAlerts(testLog, path, metrics, remediate)
+	e.now = func() time.Time { return now }
+	assert.Len(t, e.events(), 3)
+
+	metrics.set(50, 2)
+	e.evaluate(ctx)
+	events = e.events()
+	require.Len(t, events, 5)
+	assert.Equal(t, comm.EdgeEventResolved, events[3].Event)
+	assert.Equal(t, comm.EdgeEventResolved, events[4].Event)
+	assert.Empty(t, remediated)
+
+	// the server fails to store the events
+	conn := test.NewConnMock()
+	e.mu.Lock()
+	e.conn = conn
+	e.mu.Unlock()
+	now = now.Add(time.Minute)
+	e.flush()
+	name, wantReply, payload := conn.InputSendRequest()
+	assert.Equal(t, comm.RequestTypeEdgeAlertEvents, name)
+	assert.True(t, wantReply)
+	var sent []comm.EdgeAlertEvent
+	require.NoError(t, json.Unmarshal(payload, &sent))
+	require.Len(t, sent, 5)
+	assert.Equal(t, 2*time.Minute, sent[0].Age)
+	assert.Equal(t, time.Minute, sent[4].Age)
+	assert.Len(t, e.events(), 5)
+
+	conn.ReturnOk = true
+	e.flush()
+	assert.Empty(t, e.events())
+}
+
+func TestEdgeAlertsRuleRemoved(t *testing.T) {
+	metrics := &fakeEdgeMetrics{}
+	e := newEdgeAlerts(testLog, filepath.Join(t.TempDir(), EdgeAlertsFile), metrics, nil)
+
+	rules, _ := json.Marshal([]comm.EdgeRule{{ID: "nginx-down", Expr: "process_count(nginx) < 1"}})
+	require.NoError(t, e.Put(rules))
+	e.evaluate(context.Background())
+	require.Len(t, e.events(), 1)
+
+	require.NoError(t, e.Put([]byte("[]")))
+	events := e.events()
+	require.Len(t, events, 2)
+	assert.Equal(t, comm.EdgeEventResolved, events[1].Event)
+	assert.Equal(t, events[0].ProblemID, events[1].ProblemID)
+	assert.Equal(t, "edge rule removed", events[1].Details)
+}
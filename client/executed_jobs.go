@@ -0,0 +1,104 @@
+package chclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/IOTech17/neo-rport/share/comm"
+)
+
+// ExecutedJobsDir is the directory within the data directory the received jobs are recorded in, so a job sent again
+// after the reply to the server got lost isn't run twice
+const ExecutedJobsDir = "executed_jobs"
+
+// maxExecutedJobs is the number of received jobs recorded, the oldest are dropped
+const maxExecutedJobs = 1000
+
+// executedJobs records the received jobs by id, one file per job holding the response sent to the server.
+type executedJobs struct {
+	dir string
+	mtx sync.Mutex
+}
+
+func newExecutedJobs(dir string) *executedJobs {
+	return &executedJobs{
+		dir: dir,
+	}
+}
+
+// Begin records the job before it's started. If the job was received before, the response sent for it is
+// returned and the job must not be run.
+func (e *executedJobs) Begin(jid string) (*comm.RunCmdResponse, error) {
+	if !validJobFileName(jid) {
+		return nil, fmt.Errorf("invalid job id %q", jid)
+	}
+	if err := os.MkdirAll(e.dir, 0700); err != nil {
+		return nil, err
+	}
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	path := e.path(jid)
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if os.IsExist(err) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		resp := &comm.RunCmdResponse{}
+		// empty or cut off if the client stopped before the start was recorded, the job might have run
+		_ = json.Unmarshal(data, resp)
+		resp.Duplicate = true
+		return resp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	files, err := listJSONFiles(e.dir)
+	if err != nil {
+		return nil, err
+	}
+	for len(files) > maxExecutedJobs {
+		if err := os.Remove(files[0]); err != nil {
+			return nil, err
+		}
+		files = files[1:]
+	}
+	return nil, nil
+}
+
+// Started records the response sent to the server for the job.
+func (e *executedJobs) Started(jid string, resp *comm.RunCmdResponse) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	return os.WriteFile(e.path(jid), data, 0600)
+}
+
+// Failed removes the record of a job that wasn't started, so it can be sent again.
+func (e *executedJobs) Failed(jid string) error {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	err := os.Remove(e.path(jid))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (e *executedJobs) path(jid string) string {
+	return filepath.Join(e.dir, jid+".json")
+}
@@ -0,0 +1,41 @@
+package chclient
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/share/comm"
+)
+
+func TestExecutedJobs(t *testing.T) {
+	e := newExecutedJobs(t.TempDir())
+
+	received, err := e.Begin("job-1")
+	require.NoError(t, err)
+	assert.Nil(t, received)
+
+	// stopped before the start was recorded
+	received, err = e.Begin("job-1")
+	require.NoError(t, err)
+	assert.Equal(t, &comm.RunCmdResponse{Duplicate: true}, received)
+
+	startedAt := time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, e.Started("job-1", &comm.RunCmdResponse{Pid: 123, StartedAt: startedAt}))
+	received, err = e.Begin("job-1")
+	require.NoError(t, err)
+	assert.Equal(t, &comm.RunCmdResponse{Pid: 123, StartedAt: startedAt, Duplicate: true}, received)
+
+	// a job that failed to start can be sent again
+	_, err = e.Begin("job-2")
+	require.NoError(t, err)
+	require.NoError(t, e.Failed("job-2"))
+	received, err = e.Begin("job-2")
+	require.NoError(t, err)
+	assert.Nil(t, received)
+
+	_, err = e.Begin("../job-3")
+	assert.EqualError(t, err, `invalid job id "../job-3"`)
+}
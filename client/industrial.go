@@ -0,0 +1,107 @@
+package chclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/IOTech17/neo-rport/share/comm"
+)
+
+const (
+	maxIndustrialPolls       = 16
+	industrialDialTimeout    = 10 * time.Second
+	defaultIndustrialTimeout = 30 * time.Second
+	defaultModbusPort        = 502
+	defaultOPCUAPort         = 4840
+)
+
+// handlePollIndustrial reads the points of a Modbus TCP or OPC UA device if allowed by the config. The result is
+// sent to the server as separate request when the poll is finished.
+func (c *Client) handlePollIndustrial(ctx context.Context, conn ssh.Conn, payload []byte) error {
+	cfg := c.configHolder.Industrial
+	if !cfg.Enabled {
+		return errors.New(`industrial polling is disabled by "industrial.enabled" config`)
+	}
+
+	var req comm.IndustrialPollRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return fmt.Errorf("failed to decode %T: %v", req, err)
+	}
+	if len(req.Points) == 0 {
+		return errors.New("no points to read")
+	}
+	var poll func(context.Context, string, comm.IndustrialPollRequest) comm.IndustrialPollResult
+	switch req.Protocol {
+	case comm.IndustrialProtocolModbus:
+		poll = pollModbus
+	case comm.IndustrialProtocolOPCUA:
+		poll = pollOPCUA
+	default:
+		return fmt.Errorf("unsupported industrial protocol %q", req.Protocol)
+	}
+
+	host, port, err := industrialHostPort(req.Protocol, req.Address)
+	if err != nil {
+		return err
+	}
+	addr, err := allowedTargetAddr(ctx, cfg.Targets, "industrial.targets", host, port, net.DefaultResolver, interfaceSubnets)
+	if err != nil {
+		return err
+	}
+
+	if c.industrialPolls.Add(1) > maxIndustrialPolls {
+		c.industrialPolls.Add(-1)
+		return fmt.Errorf("too many industrial polls, at most %d run at the same time", maxIndustrialPolls)
+	}
+	timeout := req.Timeout
+	if timeout <= 0 {
+		timeout = defaultIndustrialTimeout
+	}
+	go func() {
+		defer c.industrialPolls.Add(-1)
+
+		pollCtx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		result := poll(pollCtx, addr, req)
+		if result.Error != "" {
+			c.Debugf("Industrial poll %s of %s failed: %s", req.ID, addr, result.Error)
+		}
+		err := comm.SendRequestAndGetResponse(conn, comm.RequestTypeIndustrialResult, result, nil, c.Logger)
+		if err != nil {
+			c.Errorf("Failed to send result of industrial poll %s: %v", req.ID, err)
+		}
+	}()
+	return nil
+}
+
+// industrialHostPort returns the host and the port of a Modbus host:port address or an opc.tcp:// endpoint url.
+func industrialHostPort(protocol, address string) (string, int, error) {
+	host, port := address, ""
+	if protocol == comm.IndustrialProtocolOPCUA {
+		u, err := url.Parse(address)
+		if err != nil || u.Scheme != "opc.tcp" || u.Hostname() == "" {
+			return "", 0, fmt.Errorf("invalid OPC UA endpoint %q, expected opc.tcp://host[:port][/path]", address)
+		}
+		host, port = u.Hostname(), u.Port()
+		if port == "" {
+			return host, defaultOPCUAPort, nil
+		}
+	} else if h, p, err := net.SplitHostPort(address); err == nil {
+		host, port = h, p
+	} else {
+		return host, defaultModbusPort, nil
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port in %q", address)
+	}
+	return host, n, nil
+}
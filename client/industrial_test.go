@@ -0,0 +1,400 @@
+package chclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/share/comm"
+)
+
+func TestIndustrialHostPort(t *testing.T) {
+	testCases := []struct {
+		protocol string
+		address  string
+		wantHost string
+		wantPort int
+		wantErr  bool
+	}{
+		{comm.IndustrialProtocolModbus, "192.168.10.5", "192.168.10.5", 502, false},
+		{comm.IndustrialProtocolModbus, "plc.local:5020", "plc.local", 5020, false},
+		{comm.IndustrialProtocolModbus, "plc.local:x", "", 0, true},
+		{comm.IndustrialProtocolOPCUA, "opc.tcp://192.168.10.6", "192.168.10.6", 4840, false},
+		{comm.IndustrialProtocolOPCUA, "opc.tcp://[fd00::6]:4841/server", "fd00::6", 4841, false},
+		{comm.IndustrialProtocolOPCUA, "http://192.168.10.6", "", 0, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.address, func(t *testing.T) {
+			host, port, err := industrialHostPort(tc.protocol, tc.address)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantHost, host)
+			assert.Equal(t, tc.wantPort, port)
+		})
+	}
+}
+
+func TestDecodeModbusRegisters(t *testing.T) {
+	float := make([]byte, 4)
+	binary.BigEndian.PutUint32(float, math.Float32bits(-12.5))
+	testCases := []struct {
+		data     []byte
+		typ      string
+		wordSwap bool
+		want     float64
+	}{
+		{[]byte{0xff, 0xfe}, comm.ModbusUint16, false, 65534},
+		{[]byte{0xff, 0xfe}, comm.ModbusInt16, false, -2},
+		{[]byte{0x00, 0x02}, comm.ModbusBool, false, 1},
+		{[]byte{0x00, 0x01, 0x00, 0x00}, comm.ModbusUint32, false, 65536},
+		{[]byte{0x00, 0x00, 0x00, 0x01}, comm.ModbusUint32, true, 65536},
+		{[]byte{0xff, 0xff, 0xff, 0xfd}, comm.ModbusInt32, false, -3},
+		{float, comm.ModbusFloat32, false, -12.5},
+		{[]byte{float[2], float[3], float[0], float[1]}, comm.ModbusFloat32, true, -12.5},
+	}
+	for _, tc := range testCases {
+		got, err := decodeModbusRegisters(tc.data, tc.typ, tc.wordSwap)
+		require.NoError(t, err)
+		assert.Equal(t, tc.want, got, tc.typ)
+	}
+}
+
+// serveModbus answers read requests with the register address as value of every register and bit, addresses from
+// 1000 on are illegal.
+func serveModbus(t *testing.T, conn net.Conn) {
+	defer conn.Close()
+	for {
+		req := make([]byte, 12)
+		if _, err := io.ReadFull(conn, req); err != nil {
+			return
+		}
+		assert.Equal(t, byte(7), req[6], "unit id")
+		function := req[7]
+		address := binary.BigEndian.Uint16(req[8:])
+		quantity := binary.BigEndian.Uint16(req[10:])
+		var pdu []byte
+		switch {
+		case address >= 1000:
+			pdu = []byte{function | 0x80, 0x02}
+		case function == modbusReadCoils || function == modbusReadDiscreteInputs:
+			pdu = []byte{function, 1, byte(address) & 1}
+		default:
+			pdu = []byte{function, byte(quantity * 2)}
+			for i := uint16(0); i < quantity; i++ {
+				pdu = binary.BigEndian.AppendUint16(pdu, address+i)
+			}
+		}
+		resp := make([]byte, 6, 7+len(pdu))
+		copy(resp, req[:4])
+		binary.BigEndian.PutUint16(resp[4:], uint16(len(pdu)+1))
+		resp = append(resp, req[6])
+		resp = append(resp, pdu...)
+		if _, err := conn.Write(resp); err != nil {
+			return
+		}
+	}
+}
+
+func TestPollModbus(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err == nil {
+			serveModbus(t, conn)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result := pollModbus(ctx, l.Addr().String(), comm.IndustrialPollRequest{
+		ID:       "poll-1",
+		Protocol: comm.IndustrialProtocolModbus,
+		UnitID:   7,
+		Points: []comm.IndustrialPoint{
+			{Name: "temperature", Register: comm.ModbusHoldingReg, Address: 40, Type: comm.ModbusInt16},
+			{Name: "counter", Register: comm.ModbusInputReg, Address: 1, Type: comm.ModbusUint32},
+			{Name: "running", Register: comm.ModbusCoil, Address: 3},
+			{Name: "missing", Register: comm.ModbusHoldingReg, Address: 1000},
+		},
+	})
+
+	assert.Equal(t, "poll-1", result.ID)
+	assert.Empty(t, result.Error)
+	assert.Equal(t, map[string]float64{"temperature": 40, "counter": 1<<16 + 2, "running": 1}, result.Values)
+	assert.Equal(t, map[string]string{"missing": "modbus exception 2: illegal data address"}, result.Errors)
+}
+
+func TestPollModbusConnectionRefused(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	result := pollModbus(context.Background(), addr, comm.IndustrialPollRequest{
+		ID:     "poll-1",
+		Points: []comm.IndustrialPoint{{Name: "temperature", Register: comm.ModbusHoldingReg}},
+	})
+	assert.NotEmpty(t, result.Error)
+	assert.Nil(t, result.Values)
+}
+
+func TestParseUANodeID(t *testing.T) {
+	id, err := parseUANodeID("i=2258")
+	require.NoError(t, err)
+	assert.Equal(t, uaNodeID{Numeric: 2258}, id)
+
+	id, err = parseUANodeID("ns=2;s=Boiler.Temperature")
+	require.NoError(t, err)
+	assert.Equal(t, uaNodeID{Namespace: 2, String: "Boiler.Temperature", IsString: true}, id)
+
+	for _, s := range []string{"", "ns=2", "ns=x;i=1", "i=x", "s=", "g=1"} {
+		_, err = parseUANodeID(s)
+		assert.Error(t, err, s)
+	}
+}
+
+// fakeOPCUAServer answers the requests of a poll in the order the client sends them.
+type fakeOPCUAServer struct {
+	t        *testing.T
+	conn     net.Conn
+	policyID string
+	// services are the type ids of the requests received
+	services []uint32
+	// activated is the body of the ActivateSession request
+	activated []byte
+}
+
+func (s *fakeOPCUAServer) readMessage() (string, []byte) {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(s.conn, header); err != nil {
+		return "", nil
+	}
+	body := make([]byte, binary.LittleEndian.Uint32(header[4:])-8)
+	if _, err := io.ReadFull(s.conn, body); err != nil {
+		return "", nil
+	}
+	return string(header[:3]), body
+}
+
+func (s *fakeOPCUAServer) write(msgType string, body []byte) {
+	msg := &uaEncoder{}
+	msg.WriteString(msgType)
+	msg.u8('F')
+	msg.u32(uint32(8 + len(body)))
+	msg.Write(body)
+	_, err := s.conn.Write(msg.Bytes())
+	assert.NoError(s.t, err)
+}
+
+func (s *fakeOPCUAServer) response(typeID uint32, serviceResult uint32) *uaEncoder {
+	e := &uaEncoder{}
+	e.typeID(typeID)
+	e.i64(uaDateTime(time.Now()))
+	e.u32(0)
+	e.u32(serviceResult)
+	// diagnostics, string table, additional header
+	e.u8(0)
+	e.i32(-1)
+	e.typeID(0)
+	e.u8(0)
+	return e
+}
+
+func (s *fakeOPCUAServer) serve() {
+	defer s.conn.Close()
+	for {
+		msgType, body := s.readMessage()
+		switch msgType {
+		case "HEL":
+			ack := &uaEncoder{}
+			for _, v := range []uint32{0, uaBufferSize, uaBufferSize, 0, 0} {
+				ack.u32(v)
+			}
+			s.write("ACK", ack.Bytes())
+		case "OPN":
+			resp := &uaEncoder{}
+			resp.u32(0)
+			resp.str(uaSecurityPolicyNone)
+			resp.byteString(nil)
+			resp.byteString(nil)
+			resp.u32(1)
+			resp.u32(binary.LittleEndian.Uint32(body[len(body)-len(s.opnTail(body)):]))
+			resp.Write(s.response(uaIDOpenSecureChannelResponse, 0).Bytes())
+			// server protocol version, channel id, token id, created at, lifetime, server nonce
+			resp.u32(0)
+			resp.u32(42)
+			resp.u32(1)
+			resp.i64(uaDateTime(time.Now()))
+			resp.u32(3600000)
+			resp.byteString(nil)
+			s.write("OPN", resp.Bytes())
+		case "MSG":
+			d := &uaDecoder{b: body}
+			assert.EqualValues(s.t, 42, d.u32(), "channel id")
+			d.u32()
+			d.u32()
+			requestID := d.u32()
+			_, typeID := d.nodeID()
+			s.services = append(s.services, typeID)
+
+			resp := &uaEncoder{}
+			resp.u32(42)
+			resp.u32(1)
+			resp.u32(uint32(len(s.services) + 1))
+			resp.u32(requestID)
+			switch typeID {
+			case uaIDCreateSessionRequest:
+				resp.Write(s.createSessionResponse().Bytes())
+			case uaIDActivateSessionRequest:
+				s.activated = d.b
+				r := s.response(uaIDActivateSessionResponse, 0)
+				r.byteString(nil)
+				r.i32(-1)
+				r.i32(-1)
+				resp.Write(r.Bytes())
+			case uaIDReadRequest:
+				resp.Write(s.readResponse().Bytes())
+			case uaIDCloseSessionRequest:
+				resp.Write(s.response(uaIDCloseSessionResponse, 0).Bytes())
+			default:
+				resp.Write(s.response(uaIDServiceFault, 0x800B0000).Bytes())
+			}
+			s.write("MSG", resp.Bytes())
+		default:
+			// CLO or closed connection
+			return
+		}
+	}
+}
+
+// opnTail returns the OpenSecureChannel request starting at its request id.
+func (s *fakeOPCUAServer) opnTail(body []byte) []byte {
+	d := &uaDecoder{b: body}
+	d.u32()
+	d.str()
+	d.byteString()
+	d.byteString()
+	d.u32()
+	require.NoError(s.t, d.err)
+	return d.b
+}
+
+func (s *fakeOPCUAServer) createSessionResponse() *uaEncoder {
+	r := s.response(uaIDCreateSessionResponse, 0)
+	// session id, auth token as string node id
+	r.nodeID(uaNodeID{Namespace: 1, Numeric: 1000})
+	r.nodeID(uaNodeID{Namespace: 1, String: "token", IsString: true})
+	r.f64(uaSessionTimeoutMs)
+	r.byteString(nil)
+	r.byteString(nil)
+	// one endpoint without security, allowing username and anonymous tokens
+	r.i32(1)
+	r.str("opc.tcp://localhost:4840")
+	r.str("urn:fake")
+	r.str("")
+	r.u8(0)
+	r.u32(0)
+	r.str("")
+	r.str("")
+	r.i32(-1)
+	r.byteString(nil)
+	r.u32(uaMessageSecurityNone)
+	r.str(uaSecurityPolicyNone)
+	r.i32(2)
+	r.str("username")
+	r.u32(1)
+	r.str("")
+	r.str("")
+	r.str("")
+	r.str(s.policyID)
+	r.u32(uaUserTokenAnonymous)
+	r.str("")
+	r.str("")
+	r.str("")
+	r.str("http://opcfoundation.org/UA-Profile/Transport/uatcp-uasc-uabinary")
+	r.u8(0)
+	// server software certificates, signature, max request size
+	r.i32(-1)
+	r.str("")
+	r.byteString(nil)
+	r.u32(0)
+	return r
+}
+
+func (s *fakeOPCUAServer) readResponse() *uaEncoder {
+	r := s.response(uaIDReadResponse, 0)
+	r.i32(3)
+	// a double with source timestamp
+	r.u8(0x05)
+	r.u8(11)
+	r.f64(21.5)
+	r.i64(uaDateTime(time.Now()))
+	// a boolean
+	r.u8(0x01)
+	r.u8(1)
+	r.boolean(true)
+	// BadNodeIdUnknown
+	r.u8(0x02)
+	r.u32(0x80340000)
+	// diagnostics
+	r.i32(-1)
+	return r
+}
+
+func TestPollOPCUA(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	server := &fakeOPCUAServer{t: t, policyID: "anonymous-policy"}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		conn, err := l.Accept()
+		if err == nil {
+			server.conn = conn
+			server.serve()
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result := pollOPCUA(ctx, l.Addr().String(), comm.IndustrialPollRequest{
+		ID:       "poll-1",
+		Protocol: comm.IndustrialProtocolOPCUA,
+		Address:  "opc.tcp://" + l.Addr().String(),
+		Points: []comm.IndustrialPoint{
+			{Name: "temperature", NodeID: "ns=2;s=Boiler.Temperature"},
+			{Name: "running", NodeID: "ns=2;i=1001"},
+			{Name: "missing", NodeID: "ns=2;i=1002"},
+		},
+	})
+	<-done
+
+	assert.Equal(t, "poll-1", result.ID)
+	assert.Empty(t, result.Error)
+	assert.Equal(t, map[string]float64{"temperature": 21.5, "running": 1}, result.Values)
+	assert.Equal(t, map[string]string{"missing": "bad status 0x80340000"}, result.Errors)
+	assert.Equal(t, []uint32{uaIDCreateSessionRequest, uaIDActivateSessionRequest, uaIDReadRequest, uaIDCloseSessionRequest}, server.services)
+	assert.True(t, bytes.Contains(server.activated, []byte("anonymous-policy")), "the anonymous policy id of the server must be used")
+}
+
+func TestPollOPCUAInvalidNodeID(t *testing.T) {
+	result := pollOPCUA(context.Background(), "127.0.0.1:1", comm.IndustrialPollRequest{
+		ID:     "poll-1",
+		Points: []comm.IndustrialPoint{{Name: "temperature", NodeID: "Boiler.Temperature"}},
+	})
+	assert.Contains(t, result.Error, "invalid node id")
+}
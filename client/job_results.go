@@ -0,0 +1,197 @@
+package chclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+// JobResultsDir is the directory within the data directory job results are kept in until the server confirmed them
+const JobResultsDir = "job_results"
+
+// maxStoredJobResults is the number of job results kept while the server is unreachable, the oldest are dropped
+const maxStoredJobResults = 1000
+
+var errJobResultRejected = errors.New("server failed to store the job result")
+
+// jobResults sends the results of finished jobs to the server. Results that can't be sent are stored in a directory,
+// one file per job, and sent again once the client is connected.
+type jobResults struct {
+	logger *logger.Logger
+	dir    string
+
+	mtx sync.Mutex
+	// confirmed is set if the server replies once it stored a job result
+	confirmed bool
+	flushing  bool
+}
+
+func newJobResults(logger *logger.Logger, dir string) *jobResults {
+	return &jobResults{
+		logger: logger,
+		dir:    dir,
+	}
+}
+
+// Send sends the result of the job to the server, conn is nil while disconnected.
+func (r *jobResults) Send(conn ssh.Conn, job *models.Job) {
+	data, err := json.Marshal(job)
+	if err != nil {
+		r.logger.Errorf("%s, failed to encode job result: %v", job.LogPrefix(), err)
+		return
+	}
+	r.mtx.Lock()
+	confirmed := r.confirmed
+	r.mtx.Unlock()
+	if conn != nil {
+		err := r.send(conn, confirmed, data)
+		if err == nil {
+			return
+		}
+		r.logger.Errorf("%s, failed to send job result, it's sent again on reconnect: %v", job.LogPrefix(), err)
+	}
+	if err := r.store(job.JID, data); err != nil {
+		r.logger.Errorf("%s, failed to store job result, it's lost: %v", job.LogPrefix(), err)
+		return
+	}
+	r.logger.Infof("%s, job result stored until the server is reachable", job.LogPrefix())
+}
+
+// Start sends the stored job results to the server once connected, confirmed is set if the server replies once it
+// stored a job result. Without confirmation the results are removed once they were sent.
+func (r *jobResults) Start(conn ssh.Conn, confirmed bool) {
+	r.mtx.Lock()
+	r.confirmed = confirmed
+	if r.flushing {
+		r.mtx.Unlock()
+		return
+	}
+	r.flushing = true
+	r.mtx.Unlock()
+	defer func() {
+		r.mtx.Lock()
+		r.flushing = false
+		r.mtx.Unlock()
+	}()
+
+	files, err := listJSONFiles(r.dir)
+	if err != nil {
+		r.logger.Errorf("Failed to read stored job results: %v", err)
+		return
+	}
+	if len(files) > 0 {
+		r.logger.Infof("Sending %d stored job result(s)", len(files))
+	}
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			r.logger.Errorf("Failed to read stored job result %q: %v", f, err)
+			continue
+		}
+		err = r.send(conn, confirmed, data)
+		if err == errJobResultRejected {
+			r.logger.Errorf("Server failed to store the job result %q, trying again on reconnect", f)
+			continue
+		}
+		if err != nil {
+			r.logger.Errorf("Failed to send stored job results, trying again on reconnect: %v", err)
+			return
+		}
+		if err := os.Remove(f); err != nil {
+			r.logger.Errorf("Failed to remove sent job result %q: %v", f, err)
+		}
+	}
+}
+
+func (r *jobResults) send(conn ssh.Conn, confirmed bool, data []byte) error {
+	ok, _, err := conn.SendRequest(comm.RequestTypeCmdResult, confirmed, data)
+	if err != nil {
+		return err
+	}
+	if confirmed && !ok {
+		return errJobResultRejected
+	}
+	return nil
+}
+
+func (r *jobResults) store(jid string, data []byte) error {
+	if !validJobFileName(jid) {
+		return fmt.Errorf("invalid job id %q", jid)
+	}
+	if err := os.MkdirAll(r.dir, 0700); err != nil {
+		return err
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	files, err := listJSONFiles(r.dir)
+	if err != nil {
+		return err
+	}
+	for len(files) >= maxStoredJobResults {
+		r.logger.Infof("Too many stored job results, dropping %q", files[0])
+		if err := os.Remove(files[0]); err != nil {
+			return err
+		}
+		files = files[1:]
+	}
+
+	path := filepath.Join(r.dir, jid+".json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// listJSONFiles returns the json files in dir, the oldest first.
+func listJSONFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	type jsonFile struct {
+		path    string
+		modTime int64
+	}
+	var jsonFiles []jsonFile
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		jsonFiles = append(jsonFiles, jsonFile{path: filepath.Join(dir, e.Name()), modTime: info.ModTime().UnixNano()})
+	}
+	sort.SliceStable(jsonFiles, func(i, j int) bool {
+		return jsonFiles[i].modTime < jsonFiles[j].modTime
+	})
+
+	files := make([]string, 0, len(jsonFiles))
+	for _, f := range jsonFiles {
+		files = append(files, f.path)
+	}
+	return files, nil
+}
+
+// validJobFileName is false for job ids not usable as file name.
+func validJobFileName(jid string) bool {
+	return jid != "" && !strings.ContainsAny(jid, `/\`) && !strings.Contains(jid, "..")
+}
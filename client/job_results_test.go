@@ -0,0 +1,67 @@
+package chclient
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/models"
+	"github.com/IOTech17/neo-rport/share/test"
+)
+
+func TestJobResultsStoredWhileDisconnected(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), JobResultsDir)
+	r := newJobResults(testLog, dir)
+	job := &models.Job{JID: "5f02b216-3f8a-42be-b66c-f4c1d0ea3809", Status: models.JobStatusSuccessful}
+
+	r.Send(nil, job)
+	files, err := listJSONFiles(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	// the server fails to store it
+	conn := test.NewConnMock()
+	r.Start(conn, true)
+	name, wantReply, payload := conn.InputSendRequest()
+	assert.Equal(t, comm.RequestTypeCmdResult, name)
+	assert.True(t, wantReply)
+	sent := &models.Job{}
+	require.NoError(t, json.Unmarshal(payload, sent))
+	assert.Equal(t, job.JID, sent.JID)
+	files, err = listJSONFiles(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	conn.ReturnOk = true
+	r.Start(conn, true)
+	files, err = listJSONFiles(dir)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+
+	// once confirmed, results are sent without storing them
+	r.Send(conn, job)
+	files, err = listJSONFiles(dir)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestJobResultsWithoutConfirmation(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), JobResultsDir)
+	r := newJobResults(testLog, dir)
+	r.Send(nil, &models.Job{JID: "1"})
+	r.Send(nil, &models.Job{JID: "../2"})
+
+	// servers not confirming job results don't reply
+	conn := test.NewConnMock()
+	r.Start(conn, false)
+	_, wantReply, _ := conn.InputSendRequest()
+	assert.False(t, wantReply)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
@@ -0,0 +1,213 @@
+// Package kubernetes provides the details of the node a client runs on when it is deployed as a Kubernetes DaemonSet.
+package kubernetes
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IOTech17/neo-rport/share/clientconfig"
+)
+
+const (
+	// LabelCluster and LabelNode are added to the client labels.
+	LabelCluster = "kubernetes.cluster"
+	LabelNode    = "kubernetes.node"
+
+	nodeRoleLabelPrefix = "node-role.kubernetes.io/"
+	requestTimeout      = 10 * time.Second
+)
+
+// ServiceAccountDir is where Kubernetes mounts the credentials of the pod's service account.
+var ServiceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// NodeInfo holds the client attributes derived from the node.
+type NodeInfo struct {
+	ID     string
+	Name   string
+	Tags   []string
+	Labels map[string]string
+}
+
+type Discoverer struct {
+	cfg        clientconfig.KubernetesConfig
+	nodeName   string
+	apiURL     string
+	httpClient *http.Client
+	token      string
+}
+
+// NewDiscoverer reads the node name from the environment, it is expected to be exposed by the downward API.
+func NewDiscoverer(cfg clientconfig.KubernetesConfig) (*Discoverer, error) {
+	d := &Discoverer{
+		cfg:      cfg,
+		nodeName: os.Getenv(cfg.NodeNameEnv),
+	}
+	if d.nodeName == "" {
+		return nil, fmt.Errorf("environment variable %s is not set, it must contain the node name, e.g. from the downward API field spec.nodeName", cfg.NodeNameEnv)
+	}
+
+	if cfg.ReadNodeLabels {
+		if err := d.initAPIClient(); err != nil {
+			return nil, fmt.Errorf("failed to init kubernetes api client: %v", err)
+		}
+	}
+	return d, nil
+}
+
+func (d *Discoverer) initAPIClient() error {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return fmt.Errorf("KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT are not set, not running in a pod?")
+	}
+
+	token, err := os.ReadFile(filepath.Join(ServiceAccountDir, "token"))
+	if err != nil {
+		return err
+	}
+	caCert, err := os.ReadFile(filepath.Join(ServiceAccountDir, "ca.crt"))
+	if err != nil {
+		return err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caCert) {
+		return fmt.Errorf("no certificates found in %s", filepath.Join(ServiceAccountDir, "ca.crt"))
+	}
+
+	d.apiURL = "https://" + net.JoinHostPort(host, port)
+	d.token = strings.TrimSpace(string(token))
+	d.httpClient = &http.Client{
+		Timeout: requestTimeout,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+		},
+	}
+	return nil
+}
+
+// Discover collects the node labels and maps them to the client id, name, tags and labels.
+// Labels are read from the API server and the file given by labels_file, the file wins on conflicts.
+func (d *Discoverer) Discover(ctx context.Context) (*NodeInfo, error) {
+	nodeLabels := make(map[string]string)
+	if d.httpClient != nil {
+		labels, err := d.fetchNodeLabels(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read labels of node %s: %v", d.nodeName, err)
+		}
+		for k, v := range labels {
+			nodeLabels[k] = v
+		}
+	}
+	if d.cfg.LabelsFile != "" {
+		labels, err := ReadLabelsFile(d.cfg.LabelsFile)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range labels {
+			nodeLabels[k] = v
+		}
+	}
+
+	info := &NodeInfo{
+		ID:     d.nodeName,
+		Name:   d.nodeName,
+		Tags:   d.tags(nodeLabels),
+		Labels: nodeLabels,
+	}
+	info.Labels[LabelNode] = d.nodeName
+	if d.cfg.ClusterName != "" {
+		info.ID = d.cfg.ClusterName + "-" + d.nodeName
+		info.Labels[LabelCluster] = d.cfg.ClusterName
+	}
+	return info, nil
+}
+
+// tags returns the node roles and the values of the labels given by tag_labels.
+func (d *Discoverer) tags(labels map[string]string) []string {
+	var tags []string
+	for k := range labels {
+		if role := strings.TrimPrefix(k, nodeRoleLabelPrefix); role != k && role != "" {
+			tags = append(tags, role)
+		}
+	}
+	sort.Strings(tags)
+	for _, k := range d.cfg.TagLabels {
+		if v := labels[k]; v != "" {
+			tags = append(tags, v)
+		}
+	}
+	return tags
+}
+
+func (d *Discoverer) fetchNodeLabels(ctx context.Context) (map[string]string, error) {
+	reqURL := d.apiURL + "/api/v1/nodes/" + url.PathEscape(d.nodeName)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+d.token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s from %s, does the service account have the permission to get nodes?", resp.Status, reqURL)
+	}
+
+	node := struct {
+		Metadata struct {
+			Labels map[string]string `json:"labels"`
+		} `json:"metadata"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&node); err != nil {
+		return nil, fmt.Errorf("failed to decode node: %v", err)
+	}
+	return node.Metadata.Labels, nil
+}
+
+// ReadLabelsFile reads labels in the format used by downward API volumes, one key="value" pair per line.
+func ReadLabelsFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	labels := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		key, quoted, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected key=\"value\"", path, lineNo)
+		}
+		value, err := strconv.Unquote(quoted)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid value %s: %v", path, lineNo, quoted, err)
+		}
+		labels[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
@@ -0,0 +1,103 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/share/clientconfig"
+)
+
+func TestReadLabelsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "labels")
+	require.NoError(t, os.WriteFile(path, []byte("app=\"rport\"\ntopology.kubernetes.io/zone=\"eu-central-1a\"\n\nnote=\"with \\\"quotes\\\"\"\n"), 0600))
+
+	labels, err := ReadLabelsFile(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"app":                         "rport",
+		"topology.kubernetes.io/zone": "eu-central-1a",
+		"note":                        `with "quotes"`,
+	}, labels)
+
+	require.NoError(t, os.WriteFile(path, []byte("app=rport\n"), 0600))
+	_, err = ReadLabelsFile(path)
+	assert.EqualError(t, err, path+":1: invalid value rport: invalid syntax")
+}
+
+func TestNewDiscovererRequiresNodeName(t *testing.T) {
+	t.Setenv("TEST_NODE_NAME", "")
+
+	_, err := NewDiscoverer(clientconfig.KubernetesConfig{NodeNameEnv: "TEST_NODE_NAME"})
+
+	assert.EqualError(t, err, "environment variable TEST_NODE_NAME is not set, it must contain the node name, e.g. from the downward API field spec.nodeName")
+}
+
+func TestDiscover(t *testing.T) {
+	var gotPath, gotAuth string
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		_, _ = w.Write([]byte(`{"metadata": {"name": "node-1", "labels": {
+			"node-role.kubernetes.io/worker": "",
+			"node.kubernetes.io/instance-type": "m5.large",
+			"topology.kubernetes.io/zone": "eu-central-1a"
+		}}}`))
+	}))
+	defer server.Close()
+
+	saDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(saDir, "token"), []byte("test-token\n"), 0600))
+	caCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(filepath.Join(saDir, "ca.crt"), caCert, 0600))
+	defer func(dir string) { ServiceAccountDir = dir }(ServiceAccountDir)
+	ServiceAccountDir = saDir
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	host, port, err := net.SplitHostPort(serverURL.Host)
+	require.NoError(t, err)
+	t.Setenv("KUBERNETES_SERVICE_HOST", host)
+	t.Setenv("KUBERNETES_SERVICE_PORT", port)
+	t.Setenv("NODE_NAME", "node-1")
+
+	labelsFile := filepath.Join(t.TempDir(), "labels")
+	require.NoError(t, os.WriteFile(labelsFile, []byte("topology.kubernetes.io/zone=\"eu-central-1b\"\n"), 0600))
+
+	d, err := NewDiscoverer(clientconfig.KubernetesConfig{
+		ClusterName:    "prod",
+		NodeNameEnv:    "NODE_NAME",
+		ReadNodeLabels: true,
+		LabelsFile:     labelsFile,
+		TagLabels:      []string{"node.kubernetes.io/instance-type", "missing"},
+	})
+	require.NoError(t, err)
+
+	info, err := d.Discover(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, "/api/v1/nodes/node-1", gotPath)
+	assert.Equal(t, "Bearer test-token", gotAuth)
+	assert.Equal(t, &NodeInfo{
+		ID:   "prod-node-1",
+		Name: "node-1",
+		Tags: []string{"worker", "m5.large"},
+		Labels: map[string]string{
+			"node-role.kubernetes.io/worker":   "",
+			"node.kubernetes.io/instance-type": "m5.large",
+			"topology.kubernetes.io/zone":      "eu-central-1b",
+			LabelNode:                          "node-1",
+			LabelCluster:                       "prod",
+		},
+	}, info)
+}
@@ -0,0 +1,158 @@
+package chclient
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IOTech17/neo-rport/client/kubernetes"
+	chshare "github.com/IOTech17/neo-rport/share"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/random"
+)
+
+const sessionIDFile = "session_id"
+
+// kubernetesNode adds the details of the node to the connection request when running as a DaemonSet.
+type kubernetesNode struct {
+	*logger.Logger
+	discoverer *kubernetes.Discoverer
+
+	mu sync.Mutex
+	// last is used if the node details can't be read on reconnect
+	last *kubernetes.NodeInfo
+	// reportedIPs are the local addresses sent with the last connection request
+	reportedIPs string
+}
+
+func newKubernetesNode(l *logger.Logger, discoverer *kubernetes.Discoverer) *kubernetesNode {
+	return &kubernetesNode{
+		Logger:     l,
+		discoverer: discoverer,
+	}
+}
+
+func (k *kubernetesNode) apply(ctx context.Context, connReq *chshare.ConnectionRequest, useSystemID bool) {
+	info, err := k.discoverer.Discover(ctx)
+	k.mu.Lock()
+	if err != nil {
+		k.Errorf("Could not get node details: %v", err)
+		info = k.last
+	} else {
+		k.last = info
+	}
+	k.mu.Unlock()
+	if info == nil {
+		return
+	}
+
+	if connReq.ID == "" && !useSystemID {
+		connReq.ID = info.ID
+	}
+	if connReq.Name == "" {
+		connReq.Name = info.Name
+	}
+
+	tags := append([]string{}, connReq.Tags...)
+	for _, tag := range info.Tags {
+		if !contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	connReq.Tags = tags
+
+	// labels of the config file take precedence
+	labels := make(map[string]string, len(info.Labels)+len(connReq.Labels))
+	for k, v := range info.Labels {
+		labels[k] = v
+	}
+	for k, v := range connReq.Labels {
+		labels[k] = v
+	}
+	connReq.Labels = labels
+}
+
+func (k *kubernetesNode) setReportedIPs(ipv4, ipv6 []string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.reportedIPs = joinSortedIPs(ipv4, ipv6)
+}
+
+func (k *kubernetesNode) ipsChanged(ipv4, ipv6 []string) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.reportedIPs != joinSortedIPs(ipv4, ipv6)
+}
+
+func joinSortedIPs(ipv4, ipv6 []string) string {
+	ips := append(append([]string{}, ipv4...), ipv6...)
+	sort.Strings(ips)
+	return strings.Join(ips, ",")
+}
+
+// ipWatchLoop reconnects when the local addresses change, so the server doesn't keep showing the old ones and a
+// connection bound to a vanished address doesn't have to wait for the keepalive timeout.
+func (c *Client) ipWatchLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if c.getConn() == nil {
+			continue
+		}
+		ipv4, ipv6, err := c.localIPAddresses()
+		if err != nil {
+			c.Errorf("Could not get local ips: %v", err)
+			continue
+		}
+		if !c.kubernetesNode.ipsChanged(ipv4, ipv6) {
+			continue
+		}
+		c.Infof("Local ip addresses changed to %v %v, reconnecting", ipv4, ipv6)
+		if err := c.CloseConnection(); err != nil {
+			c.Errorf("Failed to close connection: %v", err)
+		}
+	}
+}
+
+// loadOrCreateSessionID keeps the session id across restarts, so a restarted pod resumes its session instead of being
+// rejected as long as the server still considers the previous connection alive.
+func loadOrCreateSessionID(dataDir string) (string, error) {
+	path := filepath.Join(dataDir, sessionIDFile)
+	content, err := os.ReadFile(path)
+	if err == nil {
+		if id := strings.TrimSpace(string(content)); id != "" {
+			return id, nil
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	id, err := random.UUID4()
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(id), 0600); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
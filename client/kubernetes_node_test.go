@@ -0,0 +1,69 @@
+package chclient
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/client/kubernetes"
+	chshare "github.com/IOTech17/neo-rport/share"
+	"github.com/IOTech17/neo-rport/share/clientconfig"
+)
+
+func TestKubernetesNodeApply(t *testing.T) {
+	labelsFile := filepath.Join(t.TempDir(), "labels")
+	require.NoError(t, os.WriteFile(labelsFile, []byte("node-role.kubernetes.io/edge=\"\"\nenv=\"prod\"\n"), 0600))
+	t.Setenv("NODE_NAME", "node-1")
+
+	discoverer, err := kubernetes.NewDiscoverer(clientconfig.KubernetesConfig{NodeNameEnv: "NODE_NAME", LabelsFile: labelsFile})
+	require.NoError(t, err)
+	k := newKubernetesNode(testLog, discoverer)
+
+	connReq := &chshare.ConnectionRequest{
+		Tags:   []string{"from-config", "edge"},
+		Labels: map[string]string{"env": "staging"},
+	}
+	k.apply(context.Background(), connReq, false)
+
+	assert.Equal(t, "node-1", connReq.ID)
+	assert.Equal(t, "node-1", connReq.Name)
+	assert.Equal(t, []string{"from-config", "edge"}, connReq.Tags)
+	assert.Equal(t, map[string]string{
+		"node-role.kubernetes.io/edge": "",
+		"env":                          "staging",
+		kubernetes.LabelNode:           "node-1",
+	}, connReq.Labels)
+
+	// the last known details are used when reading them fails
+	require.NoError(t, os.Remove(labelsFile))
+	connReq = &chshare.ConnectionRequest{ID: "fixed-id", Name: "fixed-name"}
+	k.apply(context.Background(), connReq, false)
+
+	assert.Equal(t, "fixed-id", connReq.ID)
+	assert.Equal(t, "fixed-name", connReq.Name)
+	assert.Equal(t, []string{"edge"}, connReq.Tags)
+}
+
+func TestKubernetesNodeIPsChanged(t *testing.T) {
+	k := &kubernetesNode{}
+	k.setReportedIPs([]string{"10.0.0.2", "10.0.0.1"}, nil)
+
+	assert.False(t, k.ipsChanged([]string{"10.0.0.1", "10.0.0.2"}, nil))
+	assert.True(t, k.ipsChanged([]string{"10.0.0.1", "10.0.0.3"}, nil))
+}
+
+func TestLoadOrCreateSessionID(t *testing.T) {
+	dataDir := t.TempDir()
+
+	id, err := loadOrCreateSessionID(dataDir)
+	require.NoError(t, err)
+	assert.NotEmpty(t, id)
+
+	again, err := loadOrCreateSessionID(dataDir)
+	require.NoError(t, err)
+	assert.Equal(t, id, again)
+}
@@ -0,0 +1,134 @@
+package chclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/jpillora/sizestr"
+	"golang.org/x/crypto/ssh"
+
+	chshare "github.com/IOTech17/neo-rport/share"
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/logger"
+)
+
+// meshTunnels holds the listeners opened on behalf of the server for client-to-client tunnels.
+// Accepted connections are either relayed through the server to the target client or, if the
+// server found a direct path, dialed directly.
+type meshTunnels struct {
+	*logger.Logger
+	connStats *chshare.ConnStats
+
+	mu        sync.Mutex
+	listeners map[string]net.Listener
+}
+
+func newMeshTunnels(l *logger.Logger, connStats *chshare.ConnStats) *meshTunnels {
+	return &meshTunnels{
+		Logger:    l,
+		connStats: connStats,
+		listeners: make(map[string]net.Listener),
+	}
+}
+
+func (m *meshTunnels) Start(conn ssh.Conn, payload []byte) error {
+	var req comm.StartMeshTunnelRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return fmt.Errorf("failed to decode %T: %v", req, err)
+	}
+	if req.ID == "" {
+		return errors.New("mesh tunnel id is required")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.listeners[req.ID]; ok {
+		return fmt.Errorf("mesh tunnel %s already started", req.ID)
+	}
+
+	l, err := net.Listen("tcp", req.Local)
+	if err != nil {
+		return err
+	}
+	m.listeners[req.ID] = l
+
+	if req.DirectAddr != "" {
+		m.Infof("mesh tunnel %s: listening on %s, connecting directly to %s", req.ID, req.Local, req.DirectAddr)
+	} else {
+		m.Infof("mesh tunnel %s: listening on %s, relaying through the server", req.ID, req.Local)
+	}
+	go m.accept(l, conn, req)
+
+	return nil
+}
+
+func (m *meshTunnels) Stop(payload []byte) error {
+	var req comm.StopMeshTunnelRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return fmt.Errorf("failed to decode %T: %v", req, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	l, ok := m.listeners[req.ID]
+	if !ok {
+		return fmt.Errorf("mesh tunnel %s not found", req.ID)
+	}
+	delete(m.listeners, req.ID)
+	m.Infof("mesh tunnel %s: stopped", req.ID)
+	return l.Close()
+}
+
+// StopAll closes all listeners, mesh tunnels don't survive a reconnect.
+func (m *meshTunnels) StopAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, l := range m.listeners {
+		if err := l.Close(); err != nil {
+			m.Debugf("mesh tunnel %s: failed to close listener: %v", id, err)
+		}
+		delete(m.listeners, id)
+	}
+}
+
+func (m *meshTunnels) accept(l net.Listener, conn ssh.Conn, req comm.StartMeshTunnelRequest) {
+	for {
+		src, err := l.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				m.Errorf("mesh tunnel %s: failed to accept connection: %v", req.ID, err)
+			}
+			return
+		}
+		go m.handle(src, conn, req)
+	}
+}
+
+func (m *meshTunnels) handle(src net.Conn, conn ssh.Conn, req comm.StartMeshTunnelRequest) {
+	l := m.Fork("mesh#%s conn#%d", req.ID, m.connStats.New())
+
+	if req.DirectAddr != "" {
+		chshare.HandleTCPStream(l, m.connStats, src, req.DirectAddr)
+		return
+	}
+
+	dst, reqs, err := conn.OpenChannel(comm.ChannelMeshTunnel, []byte(req.ID))
+	if err != nil {
+		l.Errorf("Could not open relay channel: %v", err)
+		src.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	m.connStats.Open()
+	l.Debugf("%s: Open", m.connStats)
+	s, r := chshare.Pipe(src, dst)
+	m.connStats.Close()
+	l.Debugf("%s: Close (sent %s received %s)", m.connStats, sizestr.ToString(s), sizestr.ToString(r))
+}
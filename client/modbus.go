@@ -0,0 +1,204 @@
+package chclient
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net"
+
+	"github.com/IOTech17/neo-rport/share/comm"
+)
+
+const (
+	modbusReadCoils            = 0x01
+	modbusReadDiscreteInputs   = 0x02
+	modbusReadHoldingRegisters = 0x03
+	modbusReadInputRegisters   = 0x04
+
+	// modbusMaxADU is the max size of a Modbus TCP frame
+	modbusMaxADU = 260
+)
+
+var modbusExceptions = map[byte]string{
+	0x01: "illegal function",
+	0x02: "illegal data address",
+	0x03: "illegal data value",
+	0x04: "server device failure",
+	0x06: "server device busy",
+	0x0A: "gateway path unavailable",
+	0x0B: "gateway target device failed to respond",
+}
+
+// modbusConn reads registers with Modbus TCP, one request at a time.
+type modbusConn struct {
+	conn          net.Conn
+	unitID        byte
+	transactionID uint16
+}
+
+// pollModbus reads the points one by one. A point failing, e.g. with an illegal address, doesn't fail the others.
+func pollModbus(ctx context.Context, addr string, req comm.IndustrialPollRequest) comm.IndustrialPollResult {
+	result := comm.IndustrialPollResult{ID: req.ID}
+
+	dialer := &net.Dialer{Timeout: industrialDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	mc := &modbusConn{conn: conn, unitID: req.UnitID}
+	result.Values = make(map[string]float64, len(req.Points))
+	for _, p := range req.Points {
+		v, err := mc.readPoint(p)
+		if err == nil {
+			result.Values[p.Name] = v
+			continue
+		}
+		if _, ok := err.(modbusException); !ok {
+			// the connection is broken or timed out, the rest would fail the same
+			result.Values = nil
+			result.Error = err.Error()
+			return result
+		}
+		if result.Errors == nil {
+			result.Errors = make(map[string]string)
+		}
+		result.Errors[p.Name] = err.Error()
+	}
+	return result
+}
+
+type modbusException byte
+
+func (e modbusException) Error() string {
+	if msg, ok := modbusExceptions[byte(e)]; ok {
+		return fmt.Sprintf("modbus exception %d: %s", byte(e), msg)
+	}
+	return fmt.Sprintf("modbus exception %d", byte(e))
+}
+
+func (c *modbusConn) readPoint(p comm.IndustrialPoint) (float64, error) {
+	switch p.Register {
+	case comm.ModbusCoil, comm.ModbusDiscreteInput:
+		function := byte(modbusReadCoils)
+		if p.Register == comm.ModbusDiscreteInput {
+			function = modbusReadDiscreteInputs
+		}
+		data, err := c.read(function, p.Address, 1)
+		if err != nil {
+			return 0, err
+		}
+		if len(data) != 1 {
+			return 0, fmt.Errorf("invalid modbus response of %d bytes for 1 bit", len(data))
+		}
+		return float64(data[0] & 1), nil
+	case comm.ModbusHoldingReg, comm.ModbusInputReg:
+		function := byte(modbusReadHoldingRegisters)
+		if p.Register == comm.ModbusInputReg {
+			function = modbusReadInputRegisters
+		}
+		quantity := uint16(1)
+		if modbusTypeRegisters(p.Type) == 2 {
+			quantity = 2
+		}
+		data, err := c.read(function, p.Address, quantity)
+		if err != nil {
+			return 0, err
+		}
+		if len(data) != int(quantity)*2 {
+			return 0, fmt.Errorf("invalid modbus response of %d bytes for %d registers", len(data), quantity)
+		}
+		return decodeModbusRegisters(data, p.Type, p.WordSwap)
+	}
+	return 0, fmt.Errorf("invalid modbus register type %q", p.Register)
+}
+
+func modbusTypeRegisters(t string) int {
+	switch t {
+	case comm.ModbusInt32, comm.ModbusUint32, comm.ModbusFloat32:
+		return 2
+	}
+	return 1
+}
+
+// decodeModbusRegisters converts big-endian registers to the value of the type.
+func decodeModbusRegisters(data []byte, t string, wordSwap bool) (float64, error) {
+	if len(data) == 4 && wordSwap {
+		data = []byte{data[2], data[3], data[0], data[1]}
+	}
+	switch t {
+	case comm.ModbusUint16, "":
+		return float64(binary.BigEndian.Uint16(data)), nil
+	case comm.ModbusInt16:
+		return float64(int16(binary.BigEndian.Uint16(data))), nil
+	case comm.ModbusBool:
+		if binary.BigEndian.Uint16(data) != 0 {
+			return 1, nil
+		}
+		return 0, nil
+	case comm.ModbusUint32:
+		return float64(binary.BigEndian.Uint32(data)), nil
+	case comm.ModbusInt32:
+		return float64(int32(binary.BigEndian.Uint32(data))), nil
+	case comm.ModbusFloat32:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(data))), nil
+	}
+	return 0, fmt.Errorf("invalid modbus value type %q", t)
+}
+
+// read sends a read request and returns the data of the response without the byte count.
+func (c *modbusConn) read(function byte, address, quantity uint16) ([]byte, error) {
+	c.transactionID++
+	req := make([]byte, 12)
+	binary.BigEndian.PutUint16(req[0:], c.transactionID)
+	// protocol id 0 is Modbus
+	binary.BigEndian.PutUint16(req[2:], 0)
+	binary.BigEndian.PutUint16(req[4:], 6)
+	req[6] = c.unitID
+	req[7] = function
+	binary.BigEndian.PutUint16(req[8:], address)
+	binary.BigEndian.PutUint16(req[10:], quantity)
+	if _, err := c.conn.Write(req); err != nil {
+		return nil, err
+	}
+
+	for {
+		header := make([]byte, 7)
+		if _, err := io.ReadFull(c.conn, header); err != nil {
+			return nil, err
+		}
+		length := int(binary.BigEndian.Uint16(header[4:]))
+		if length < 2 || length > modbusMaxADU-6 {
+			return nil, fmt.Errorf("invalid modbus frame length %d", length)
+		}
+		pdu := make([]byte, length-1)
+		if _, err := io.ReadFull(c.conn, pdu); err != nil {
+			return nil, err
+		}
+		if binary.BigEndian.Uint16(header[0:]) != c.transactionID {
+			// a late response to an earlier request
+			continue
+		}
+
+		if pdu[0] == function|0x80 {
+			if len(pdu) < 2 {
+				return nil, fmt.Errorf("invalid modbus exception response")
+			}
+			return nil, modbusException(pdu[1])
+		}
+		if pdu[0] != function {
+			return nil, fmt.Errorf("unexpected modbus function %d in response to %d", pdu[0], function)
+		}
+		if len(pdu) < 2 || int(pdu[1]) != len(pdu)-2 {
+			return nil, fmt.Errorf("invalid modbus response byte count")
+		}
+		return pdu[2:], nil
+	}
+}
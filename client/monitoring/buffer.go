@@ -0,0 +1,153 @@
+package monitoring
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+// MeasurementBuffer keeps measurements taken while the server is unreachable in a file, holding at most size
+// measurements. When it is full the oldest measurement is dropped. New measurements are appended to the file, it is
+// rewritten only on removals and when the dropped measurements exceed a quarter of the size.
+type MeasurementBuffer struct {
+	mtx          sync.Mutex
+	path         string
+	size         int
+	measurements []models.Measurement
+	// fileEntries is the number of measurements in the file including dropped ones
+	fileEntries int
+}
+
+func NewMeasurementBuffer(path string, size int) (*MeasurementBuffer, error) {
+	b := &MeasurementBuffer{
+		path: path,
+		size: size,
+	}
+	if err := b.load(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *MeasurementBuffer) load() error {
+	data, err := os.ReadFile(b.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read measurement buffer %q: %w", b.path, err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var m models.Measurement
+		// a line cut off by a crash is skipped
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+			continue
+		}
+		b.measurements = append(b.measurements, m)
+		b.fileEntries++
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read measurement buffer %q: %w", b.path, err)
+	}
+	if len(b.measurements) > b.size {
+		b.measurements = b.measurements[len(b.measurements)-b.size:]
+		return b.rewrite()
+	}
+	return nil
+}
+
+// Add buffers a measurement, dropping the oldest one if the buffer is full.
+func (b *MeasurementBuffer) Add(m models.Measurement) error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	b.measurements = append(b.measurements, m)
+	if len(b.measurements) > b.size {
+		b.measurements = b.measurements[1:]
+	}
+	if b.fileEntries+1 > b.size+b.size/4 {
+		return b.rewrite()
+	}
+
+	line, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(b.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	b.fileEntries++
+	return nil
+}
+
+// Peek returns up to n of the oldest buffered measurements.
+func (b *MeasurementBuffer) Peek(n int) []models.Measurement {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if n > len(b.measurements) {
+		n = len(b.measurements)
+	}
+	res := make([]models.Measurement, n)
+	copy(res, b.measurements[:n])
+	return res
+}
+
+// Remove removes the n oldest measurements once they reached the server.
+func (b *MeasurementBuffer) Remove(n int) error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if n > len(b.measurements) {
+		n = len(b.measurements)
+	}
+	b.measurements = b.measurements[n:]
+	return b.rewrite()
+}
+
+func (b *MeasurementBuffer) Len() int {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	return len(b.measurements)
+}
+
+func (b *MeasurementBuffer) rewrite() error {
+	if len(b.measurements) == 0 {
+		b.fileEntries = 0
+		if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	for _, m := range b.measurements {
+		if err := enc.Encode(m); err != nil {
+			return err
+		}
+	}
+	tmp := b.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, b.path); err != nil {
+		return err
+	}
+	b.fileEntries = len(b.measurements)
+	return nil
+}
@@ -0,0 +1,58 @@
+package monitoring
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+func TestMeasurementBuffer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), BufferFile)
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	measurement := func(i int) models.Measurement {
+		return models.Measurement{Timestamp: start.Add(time.Duration(i) * time.Minute), CPUUsagePercent: float64(i)}
+	}
+	cpu := func(measurements []models.Measurement) []float64 {
+		res := []float64{}
+		for _, m := range measurements {
+			res = append(res, m.CPUUsagePercent)
+		}
+		return res
+	}
+
+	b, err := NewMeasurementBuffer(path, 4)
+	require.NoError(t, err)
+	for i := 0; i < 7; i++ {
+		require.NoError(t, b.Add(measurement(i)))
+	}
+	assert.Equal(t, 4, b.Len())
+	assert.Equal(t, []float64{3, 4, 5}, cpu(b.Peek(3)))
+
+	// the buffer survives a restart
+	b, err = NewMeasurementBuffer(path, 4)
+	require.NoError(t, err)
+	assert.Equal(t, []float64{3, 4, 5, 6}, cpu(b.Peek(10)))
+	assert.Equal(t, start.Add(3*time.Minute), b.Peek(1)[0].Timestamp.UTC())
+
+	require.NoError(t, b.Remove(2))
+	assert.Equal(t, []float64{5, 6}, cpu(b.Peek(10)))
+	require.NoError(t, b.Remove(10))
+	assert.Equal(t, 0, b.Len())
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestMeasurementBufferSkipsCorruptLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), BufferFile)
+	require.NoError(t, os.WriteFile(path, []byte("{\"cpu_usage_percent\":1}\n{\"cpu_usage_perc"), 0600))
+
+	b, err := NewMeasurementBuffer(path, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, b.Len())
+}
@@ -0,0 +1,123 @@
+package monitoring
+
+import (
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+// maxBackoff is the maximum factor the monitoring interval is stretched by if the cpu budget is exceeded
+const maxBackoff = 8
+
+// footprintMeter measures the resource usage of the client process and keeps monitoring within its cpu budget.
+type footprintMeter struct {
+	logger *logger.Logger
+	// proc is nil if the own process can't be inspected
+	proc *process.Process
+	// budget is the share of one cpu in percent monitoring may use, 0 is unlimited
+	budget  float64
+	backoff int
+
+	lastSample  time.Time
+	lastCPUTime float64
+}
+
+func newFootprintMeter(logger *logger.Logger, budget float64) *footprintMeter {
+	f := &footprintMeter{logger: logger, budget: budget, backoff: 1}
+	proc, err := process.NewProcess(int32(os.Getpid()))
+	if err != nil {
+		logger.Infof("Cannot measure resource usage of the client: %v", err)
+		return f
+	}
+	f.proc = proc
+	f.lastSample = time.Now()
+	f.lastCPUTime, _ = f.cpuTime()
+	return f
+}
+
+// cpuTime returns the cpu time in seconds used by the client process so far.
+func (f *footprintMeter) cpuTime() (float64, error) {
+	if f.proc == nil {
+		return 0, os.ErrInvalid
+	}
+	times, err := f.proc.Times()
+	if err != nil {
+		return 0, err
+	}
+	return times.User + times.System, nil
+}
+
+// interval returns the monitoring interval stretched by the current backoff.
+func (f *footprintMeter) interval(interval time.Duration) time.Duration {
+	return interval * time.Duration(f.backoff)
+}
+
+// measure runs collect and adjusts the backoff to the cpu time it took.
+func (f *footprintMeter) measure(interval time.Duration, collect func()) {
+	before, err := f.cpuTime()
+	collect()
+	if err != nil || f.budget <= 0 {
+		return
+	}
+	after, err := f.cpuTime()
+	if err != nil {
+		return
+	}
+	f.adjustBackoff(after-before, interval)
+}
+
+// adjustBackoff doubles the backoff if collecting a measurement used more than the budget of the stretched interval
+// and halves it again once it uses less than half of the budget.
+func (f *footprintMeter) adjustBackoff(cpuSeconds float64, interval time.Duration) {
+	usage := cpuSeconds / f.interval(interval).Seconds() * 100
+	previous := f.backoff
+	switch {
+	case usage > f.budget && f.backoff < maxBackoff:
+		f.backoff *= 2
+	case usage < f.budget/2 && f.backoff > 1:
+		f.backoff /= 2
+	default:
+		return
+	}
+	f.logger.Infof(
+		"Monitoring used %.2f%% cpu with a budget of %.2f%%, interval changed from %s to %s",
+		usage,
+		f.budget,
+		interval*time.Duration(previous),
+		f.interval(interval),
+	)
+}
+
+// sample returns the resource usage of the client process since the previous sample.
+func (f *footprintMeter) sample() *models.AgentFootprint {
+	now := time.Now()
+	footprint := &models.AgentFootprint{
+		Timestamp:  now.UTC(),
+		Goroutines: runtime.NumGoroutine(),
+	}
+	if f.budget > 0 {
+		footprint.MonitoringBackoff = f.backoff
+	}
+	if f.proc == nil {
+		return footprint
+	}
+
+	if cpuTime, err := f.cpuTime(); err == nil {
+		if elapsed := now.Sub(f.lastSample).Seconds(); elapsed > 0 {
+			footprint.CPUPercent = (cpuTime - f.lastCPUTime) / elapsed * 100
+		}
+		f.lastSample, f.lastCPUTime = now, cpuTime
+	}
+	if mem, err := f.proc.MemoryInfo(); err == nil {
+		footprint.MemoryRSSBytes = mem.RSS
+	}
+	if openFiles, err := f.proc.NumFDs(); err == nil {
+		footprint.OpenFiles = int(openFiles)
+	}
+	return footprint
+}
@@ -0,0 +1,56 @@
+package monitoring
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/share/logger"
+)
+
+var testLog = logger.NewLogger("monitoring", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+
+func TestFootprintMeterAdjustBackoff(t *testing.T) {
+	f := newFootprintMeter(testLog, 1)
+	interval := 10 * time.Second
+
+	// 0.5s of 10s is 5%, the interval is stretched until it's within the budget of 1%
+	f.adjustBackoff(0.5, interval)
+	assert.Equal(t, 2, f.backoff)
+	f.adjustBackoff(0.5, interval)
+	assert.Equal(t, 4, f.backoff)
+	f.adjustBackoff(0.5, interval)
+	assert.Equal(t, 8, f.backoff)
+	f.adjustBackoff(0.5, interval)
+	assert.Equal(t, maxBackoff, f.backoff)
+	assert.Equal(t, 80*time.Second, f.interval(interval))
+
+	// 0.6s of 80s is below the budget but above half of it
+	f.adjustBackoff(0.6, interval)
+	assert.Equal(t, 8, f.backoff)
+
+	f.adjustBackoff(0.01, interval)
+	assert.Equal(t, 4, f.backoff)
+	f.adjustBackoff(0.01, interval)
+	f.adjustBackoff(0.01, interval)
+	f.adjustBackoff(0.01, interval)
+	assert.Equal(t, 1, f.backoff)
+	assert.Equal(t, interval, f.interval(interval))
+}
+
+func TestFootprintMeterSample(t *testing.T) {
+	f := newFootprintMeter(testLog, 0)
+	called := false
+	f.measure(time.Second, func() { called = true })
+	assert.True(t, called)
+	assert.Equal(t, 1, f.backoff)
+
+	footprint := f.sample()
+	require.NotNil(t, footprint)
+	assert.Greater(t, footprint.Goroutines, 0)
+	assert.Greater(t, footprint.MemoryRSSBytes, uint64(0))
+	assert.Zero(t, footprint.MonitoringBackoff)
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/IOTech17/neo-rport/client/monitoring/networking"
 	"github.com/IOTech17/neo-rport/client/monitoring/processes"
 	"github.com/IOTech17/neo-rport/client/system"
+	chshare "github.com/IOTech17/neo-rport/share"
 	"github.com/IOTech17/neo-rport/share/clientconfig"
 	"github.com/IOTech17/neo-rport/share/comm"
 	"github.com/IOTech17/neo-rport/share/logger"
@@ -29,9 +30,23 @@ type Monitor struct {
 	fileSystemWatcher *fs.FileSystemWatcher
 	processHandler    *processes.ProcessHandler
 	netHandler        *networking.NetHandler
+	footprint         *footprintMeter
+	// buffer is nil if buffering is disabled
+	buffer *MeasurementBuffer
+	// backfill is set if the server accepts buffered measurements
+	backfill bool
+	flushing bool
 }
 
-func NewMonitor(logger *logger.Logger, config clientconfig.MonitoringConfig, systemInfo system.SysInfo) *Monitor {
+// BufferFile is the name of the file measurements are buffered in within the data directory
+const BufferFile = "monitoring_buffer.jsonl"
+
+// backfillBatchSize is the number of buffered measurements sent within one request
+const backfillBatchSize = 60
+
+// NewMonitor returns a monitor for the given config, cpuBudget is the share of one cpu in percent collecting the
+// measurements may use, 0 is unlimited.
+func NewMonitor(logger *logger.Logger, config clientconfig.MonitoringConfig, cpuBudget float64, systemInfo system.SysInfo, bufferPath string) *Monitor {
 	fsWatcher := fs.NewWatcher(fs.FileSystemWatcherConfig{
 		TypeInclude:                 config.FSTypeInclude,
 		PathExclude:                 config.FSPathExclude,
@@ -41,30 +56,71 @@ func NewMonitor(logger *logger.Logger, config clientconfig.MonitoringConfig, sys
 	}, logger)
 	processHandler := processes.NewProcessHandler(config, logger)
 	netHandler := networking.NewNetHandler(&config)
-	return &Monitor{logger: logger, config: config, systemInfo: systemInfo, fileSystemWatcher: fsWatcher, processHandler: processHandler, netHandler: netHandler}
+	m := &Monitor{logger: logger, config: config, systemInfo: systemInfo, fileSystemWatcher: fsWatcher, processHandler: processHandler, netHandler: netHandler}
+	if config.Enabled {
+		m.footprint = newFootprintMeter(logger, cpuBudget)
+	}
+	if config.Enabled && config.BufferSize > 0 {
+		buffer, err := NewMeasurementBuffer(bufferPath, config.BufferSize)
+		if err != nil {
+			logger.Errorf("Measurements are not buffered while the server is unreachable: %v", err)
+		} else {
+			m.buffer = buffer
+		}
+	}
+	return m
 }
 
-func (m *Monitor) Start(ctx context.Context) {
+// Start starts measuring, serverVersion is the monitoring version of the server. If measuring went on while the
+// server was unreachable, only the buffered measurements are sent.
+func (m *Monitor) Start(ctx context.Context, serverVersion int) {
 	if !m.config.Enabled {
 		return
 	}
 
-	ctx, m.stopFn = context.WithCancel(ctx)
+	m.mtx.Lock()
+	m.backfill = serverVersion >= chshare.MonitoringBackfillVersion
+	running := m.stopFn != nil
+	if !running {
+		ctx, m.stopFn = context.WithCancel(ctx)
+	}
+	m.mtx.Unlock()
 
+	go m.flushBuffer()
+	if running {
+		return
+	}
 	go m.refreshLoop(ctx)
 	m.logger.Debugf("Monitoring started")
 }
 
 func (m *Monitor) Stop() {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
 	m.conn = nil
 	if m.stopFn == nil {
 		return
 	}
 
 	m.stopFn()
+	m.stopFn = nil
 	m.logger.Debugf("Monitoring stopped")
 }
 
+// Disconnect is called when the connection to the server is lost. With buffering enabled measuring goes on and the
+// measurements are buffered until the server is reachable again.
+func (m *Monitor) Disconnect() {
+	if m.buffer == nil {
+		m.Stop()
+		return
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.conn = nil
+}
+
 func (m *Monitor) refreshLoop(ctx context.Context) {
 	for {
 		m.refreshMeasurement(ctx)
@@ -74,14 +130,20 @@ func (m *Monitor) refreshLoop(ctx context.Context) {
 		case <-ctx.Done():
 			m.logger.Errorf("Monitoring ended by context.Done")
 			return
-		case <-time.After(m.config.Interval):
+		case <-time.After(m.footprint.interval(m.config.Interval)):
 		}
 	}
 }
 
 func (m *Monitor) refreshMeasurement(ctx context.Context) {
+	var measurement *models.Measurement
+	m.footprint.measure(m.config.Interval, func() {
+		measurement = m.createMeasurement(ctx)
+	})
+	measurement.Agent = m.footprint.sample()
+
 	m.mtx.Lock()
-	m.measurement = m.createMeasurement(ctx)
+	m.measurement = measurement
 	m.mtx.Unlock()
 
 	go m.sendMeasurement()
@@ -143,6 +205,7 @@ func (m *Monitor) sendMeasurement() {
 
 	if m.conn == nil {
 		m.logger.Debugf("Cannot send measurement. SSH connection missing. m.conn = nil")
+		m.bufferMeasurement(m.measurement)
 	}
 
 	if m.conn != nil && m.measurement != nil {
@@ -155,6 +218,7 @@ func (m *Monitor) sendMeasurement() {
 		_, _, err = m.conn.SendRequest(comm.RequestTypeSaveMeasurement, false, data)
 		if err != nil {
 			m.logger.Errorf("Could not send save_measurement: %v", err)
+			m.bufferMeasurement(m.measurement)
 			return
 		}
 		m.logger.Debugf("%d bytes of monitoring measurements sent within %s", len(data), time.Since(t0))
@@ -162,6 +226,82 @@ func (m *Monitor) sendMeasurement() {
 
 }
 
+func (m *Monitor) bufferMeasurement(measurement *models.Measurement) {
+	if m.buffer == nil || measurement == nil {
+		return
+	}
+	buffered := *measurement
+	// process lists are of no use afterwards and make up most of a measurement
+	buffered.Processes = ""
+	// the footprint is only of interest while it's current
+	buffered.Agent = nil
+	if err := m.buffer.Add(buffered); err != nil {
+		m.logger.Errorf("Could not buffer measurement: %v", err)
+	}
+}
+
+// flushBuffer sends the buffered measurements to the server, they are removed once the server confirmed them.
+func (m *Monitor) flushBuffer() {
+	if m.buffer == nil {
+		return
+	}
+	m.mtx.Lock()
+	if m.flushing {
+		m.mtx.Unlock()
+		return
+	}
+	m.flushing = true
+	backfill := m.backfill
+	m.mtx.Unlock()
+	defer func() {
+		m.mtx.Lock()
+		m.flushing = false
+		m.mtx.Unlock()
+	}()
+
+	if n := m.buffer.Len(); n > 0 && !backfill {
+		m.logger.Infof("Server does not accept buffered measurements, dropping %d measurements", n)
+		if err := m.buffer.Remove(n); err != nil {
+			m.logger.Errorf("Could not clear measurement buffer: %v", err)
+		}
+		return
+	}
+
+	for {
+		batch := m.buffer.Peek(backfillBatchSize)
+		if len(batch) == 0 {
+			return
+		}
+		m.mtx.RLock()
+		conn := m.conn
+		m.mtx.RUnlock()
+		if conn == nil {
+			return
+		}
+
+		now := time.Now()
+		payload := make([]models.BufferedMeasurement, 0, len(batch))
+		for _, b := range batch {
+			payload = append(payload, models.BufferedMeasurement{Measurement: b, Age: now.Sub(b.Timestamp)})
+		}
+		data, err := json.Marshal(payload)
+		if err != nil {
+			m.logger.Errorf("Could not marshal json for save_measurements: %v", err)
+			return
+		}
+		ok, _, err := conn.SendRequest(comm.RequestTypeSaveMeasurements, true, data)
+		if err != nil || !ok {
+			m.logger.Errorf("Could not send buffered measurements, ok=%t: %v", ok, err)
+			return
+		}
+		if err := m.buffer.Remove(len(batch)); err != nil {
+			m.logger.Errorf("Could not remove sent measurements from buffer: %v", err)
+			return
+		}
+		m.logger.Debugf("%d buffered measurements sent", len(batch))
+	}
+}
+
 func (m *Monitor) SetConn(c ssh.Conn) {
 	m.logger.Debugf("SSH Connection for monitoring set.")
 	m.mtx.Lock()
@@ -0,0 +1,882 @@
+package chclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IOTech17/neo-rport/share/comm"
+)
+
+// OPC UA binary protocol over TCP, limited to what's needed to read values: security policy None, anonymous
+// sessions and the Read service.
+
+const (
+	uaSecurityPolicyNone  = "http://opcfoundation.org/UA/SecurityPolicy#None"
+	uaMessageSecurityNone = 1
+	uaUserTokenAnonymous  = 0
+	uaAttributeValue      = 13
+	uaTimestampsNeither   = 3
+	uaApplicationClient   = 1
+	uaBufferSize          = 65536
+	uaMaxMessageSize      = 16 * 1024 * 1024
+	uaSessionTimeoutMs    = 60000
+	uaStatusBad           = 0x80000000
+)
+
+// numeric ids of the binary encodings of the services
+const (
+	uaIDServiceFault                   = 397
+	uaIDOpenSecureChannelRequest       = 446
+	uaIDOpenSecureChannelResponse      = 449
+	uaIDCloseSecureChannelRequest      = 452
+	uaIDCreateSessionRequest           = 461
+	uaIDCreateSessionResponse          = 464
+	uaIDActivateSessionRequest         = 467
+	uaIDActivateSessionResponse        = 470
+	uaIDCloseSessionRequest            = 473
+	uaIDCloseSessionResponse           = 476
+	uaIDAnonymousIdentityToken         = 321
+	uaIDReadRequest                    = 631
+	uaIDReadResponse                   = 634
+	uaNodeIDTwoByte               byte = 0x00
+	uaNodeIDFourByte              byte = 0x01
+	uaNodeIDNumeric               byte = 0x02
+	uaNodeIDString                byte = 0x03
+	uaNodeIDGUID                  byte = 0x04
+	uaNodeIDByteString            byte = 0x05
+)
+
+// uaNodeID is a numeric or string node id.
+type uaNodeID struct {
+	Namespace uint16
+	Numeric   uint32
+	String    string
+	IsString  bool
+}
+
+// parseUANodeID parses the string notation of a node id, e.g. i=2258 or ns=2;s=Boiler.Temperature.
+func parseUANodeID(s string) (uaNodeID, error) {
+	var id uaNodeID
+	rest := s
+	if strings.HasPrefix(rest, "ns=") {
+		i := strings.IndexByte(rest, ';')
+		if i < 0 {
+			return id, fmt.Errorf("invalid node id %q", s)
+		}
+		ns, err := strconv.ParseUint(rest[3:i], 10, 16)
+		if err != nil {
+			return id, fmt.Errorf("invalid namespace in node id %q", s)
+		}
+		id.Namespace = uint16(ns)
+		rest = rest[i+1:]
+	}
+	switch {
+	case strings.HasPrefix(rest, "i="):
+		n, err := strconv.ParseUint(rest[2:], 10, 32)
+		if err != nil {
+			return id, fmt.Errorf("invalid numeric node id %q", s)
+		}
+		id.Numeric = uint32(n)
+	case strings.HasPrefix(rest, "s=") && len(rest) > 2:
+		id.String = rest[2:]
+		id.IsString = true
+	default:
+		return id, fmt.Errorf("invalid node id %q, expected i=<number> or s=<string> with optional ns=<index>; prefix", s)
+	}
+	return id, nil
+}
+
+type uaEncoder struct {
+	bytes.Buffer
+}
+
+func (e *uaEncoder) u8(v byte) {
+	e.WriteByte(v)
+}
+
+func (e *uaEncoder) u16(v uint16) {
+	_ = binary.Write(e, binary.LittleEndian, v)
+}
+
+func (e *uaEncoder) u32(v uint32) {
+	_ = binary.Write(e, binary.LittleEndian, v)
+}
+
+func (e *uaEncoder) i32(v int32) {
+	_ = binary.Write(e, binary.LittleEndian, v)
+}
+
+func (e *uaEncoder) i64(v int64) {
+	_ = binary.Write(e, binary.LittleEndian, v)
+}
+
+func (e *uaEncoder) f64(v float64) {
+	_ = binary.Write(e, binary.LittleEndian, v)
+}
+
+func (e *uaEncoder) boolean(v bool) {
+	if v {
+		e.u8(1)
+	} else {
+		e.u8(0)
+	}
+}
+
+// str writes an empty string as null.
+func (e *uaEncoder) str(s string) {
+	if s == "" {
+		e.i32(-1)
+		return
+	}
+	e.i32(int32(len(s)))
+	e.WriteString(s)
+}
+
+func (e *uaEncoder) byteString(b []byte) {
+	if b == nil {
+		e.i32(-1)
+		return
+	}
+	e.i32(int32(len(b)))
+	e.Write(b)
+}
+
+func (e *uaEncoder) nodeID(id uaNodeID) {
+	switch {
+	case id.IsString:
+		e.u8(uaNodeIDString)
+		e.u16(id.Namespace)
+		e.str(id.String)
+	case id.Namespace == 0 && id.Numeric <= math.MaxUint8:
+		e.u8(uaNodeIDTwoByte)
+		e.u8(byte(id.Numeric))
+	case id.Namespace <= math.MaxUint8 && id.Numeric <= math.MaxUint16:
+		e.u8(uaNodeIDFourByte)
+		e.u8(byte(id.Namespace))
+		e.u16(uint16(id.Numeric))
+	default:
+		e.u8(uaNodeIDNumeric)
+		e.u16(id.Namespace)
+		e.u32(id.Numeric)
+	}
+}
+
+func (e *uaEncoder) typeID(id uint32) {
+	e.nodeID(uaNodeID{Numeric: id})
+}
+
+// requestHeader writes a request header with the authentication token of the session, nil before it's created.
+func (e *uaEncoder) requestHeader(authToken []byte, handle uint32, timeout time.Duration) {
+	if authToken == nil {
+		e.nodeID(uaNodeID{})
+	} else {
+		e.Write(authToken)
+	}
+	e.i64(uaDateTime(time.Now()))
+	e.u32(handle)
+	// return diagnostics, audit entry id, timeout hint
+	e.u32(0)
+	e.str("")
+	e.u32(uint32(timeout.Milliseconds()))
+	// empty additional header
+	e.typeID(0)
+	e.u8(0)
+}
+
+// uaDateTime returns the 100 nanosecond intervals since 1601-01-01.
+func uaDateTime(t time.Time) int64 {
+	return t.UnixNano()/100 + 116444736000000000
+}
+
+// uaDecoder reads from a message body, the first error is kept and makes all later reads return zero values.
+type uaDecoder struct {
+	b   []byte
+	err error
+}
+
+func (d *uaDecoder) take(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(d.b) {
+		d.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	v := d.b[:n]
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *uaDecoder) u8() byte {
+	if b := d.take(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *uaDecoder) u16() uint16 {
+	if b := d.take(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (d *uaDecoder) u32() uint32 {
+	if b := d.take(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (d *uaDecoder) u64() uint64 {
+	if b := d.take(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (d *uaDecoder) byteString() []byte {
+	n := int32(d.u32())
+	if n <= 0 {
+		return nil
+	}
+	return d.take(int(n))
+}
+
+func (d *uaDecoder) str() string {
+	return string(d.byteString())
+}
+
+// array returns the length of an array, 0 if null.
+func (d *uaDecoder) array() int {
+	n := int32(d.u32())
+	if n < 0 {
+		return 0
+	}
+	if int(n) > len(d.b) {
+		// every element takes at least one byte
+		d.err = io.ErrUnexpectedEOF
+		return 0
+	}
+	return int(n)
+}
+
+// nodeID returns the encoded node id to send it back as is, and its numeric id if it's a numeric one.
+func (d *uaDecoder) nodeID() (raw []byte, numeric uint32) {
+	start := d.b
+	encoding := d.u8()
+	switch encoding & 0x3F {
+	case uaNodeIDTwoByte:
+		numeric = uint32(d.u8())
+	case uaNodeIDFourByte:
+		d.u8()
+		numeric = uint32(d.u16())
+	case uaNodeIDNumeric:
+		d.u16()
+		numeric = d.u32()
+	case uaNodeIDString, uaNodeIDByteString:
+		d.u16()
+		d.byteString()
+	case uaNodeIDGUID:
+		d.u16()
+		d.take(16)
+	default:
+		if d.err == nil {
+			d.err = fmt.Errorf("invalid node id encoding %#x", encoding)
+		}
+	}
+	// expanded node ids
+	if encoding&0x80 != 0 {
+		d.str()
+	}
+	if encoding&0x40 != 0 {
+		d.u32()
+	}
+	if d.err != nil {
+		return nil, 0
+	}
+	raw = start[:len(start)-len(d.b)]
+	// the flags of expanded node ids are not valid in the node ids of requests
+	raw = append([]byte{raw[0] & 0x3F}, raw[1:]...)
+	return raw, numeric
+}
+
+func (d *uaDecoder) localizedText() {
+	mask := d.u8()
+	if mask&0x01 != 0 {
+		d.str()
+	}
+	if mask&0x02 != 0 {
+		d.str()
+	}
+}
+
+func (d *uaDecoder) extensionObject() {
+	d.nodeID()
+	if encoding := d.u8(); encoding == 0x01 || encoding == 0x02 {
+		d.byteString()
+	}
+}
+
+func (d *uaDecoder) diagnosticInfo() {
+	mask := d.u8()
+	for _, bit := range []byte{0x01, 0x02, 0x04, 0x08} {
+		if mask&bit != 0 {
+			d.u32()
+		}
+	}
+	if mask&0x10 != 0 {
+		d.str()
+	}
+	if mask&0x20 != 0 {
+		d.u32()
+	}
+	if mask&0x40 != 0 {
+		d.diagnosticInfo()
+	}
+}
+
+// responseHeader returns the service result of the response.
+func (d *uaDecoder) responseHeader() uint32 {
+	// timestamp, request handle
+	d.u64()
+	d.u32()
+	result := d.u32()
+	d.diagnosticInfo()
+	for i, n := 0, d.array(); i < n; i++ {
+		d.str()
+	}
+	d.extensionObject()
+	return result
+}
+
+func (d *uaDecoder) applicationDescription() {
+	d.str()
+	d.str()
+	d.localizedText()
+	d.u32()
+	d.str()
+	d.str()
+	for i, n := 0, d.array(); i < n; i++ {
+		d.str()
+	}
+}
+
+// anonymousPolicyID returns the policy id of anonymous user tokens from the endpoints of a CreateSessionResponse,
+// preferring endpoints without security.
+func (d *uaDecoder) anonymousPolicyID() (string, bool) {
+	var policyID string
+	var found, foundNone bool
+	for i, n := 0, d.array(); i < n; i++ {
+		// url, server, certificate
+		d.str()
+		d.applicationDescription()
+		d.byteString()
+		securityMode := d.u32()
+		securityPolicy := d.str()
+		none := securityMode == uaMessageSecurityNone && securityPolicy == uaSecurityPolicyNone
+		for j, m := 0, d.array(); j < m; j++ {
+			id := d.str()
+			tokenType := d.u32()
+			// issued token type, issuer endpoint url, security policy
+			d.str()
+			d.str()
+			d.str()
+			if tokenType == uaUserTokenAnonymous && (!found || none && !foundNone) {
+				policyID = id
+				found = true
+				foundNone = none
+			}
+		}
+		// transport profile, security level
+		d.str()
+		d.u8()
+	}
+	return policyID, found
+}
+
+// variant returns a numeric or boolean scalar as float64. Values of other types are skipped if possible and
+// returned as error.
+func (d *uaDecoder) variant() (float64, error) {
+	encoding := d.u8()
+	typeID := encoding & 0x3F
+	if encoding&0x80 != 0 {
+		n := d.array()
+		for i := 0; i < n && d.err == nil; i++ {
+			d.skipBuiltin(typeID)
+		}
+		if encoding&0x40 != 0 {
+			for i, n := 0, d.array(); i < n; i++ {
+				d.u32()
+			}
+		}
+		return 0, errors.New("arrays are not supported")
+	}
+	switch typeID {
+	case 1:
+		return float64(d.u8() & 1), nil
+	case 2:
+		return float64(int8(d.u8())), nil
+	case 3:
+		return float64(d.u8()), nil
+	case 4:
+		return float64(int16(d.u16())), nil
+	case 5:
+		return float64(d.u16()), nil
+	case 6:
+		return float64(int32(d.u32())), nil
+	case 7:
+		return float64(d.u32()), nil
+	case 8:
+		return float64(int64(d.u64())), nil
+	case 9:
+		return float64(d.u64()), nil
+	case 10:
+		return float64(math.Float32frombits(d.u32())), nil
+	case 11:
+		return math.Float64frombits(d.u64()), nil
+	case 0:
+		return 0, errors.New("value is empty")
+	}
+	d.skipBuiltin(typeID)
+	return 0, fmt.Errorf("values of type %d are not supported", typeID)
+}
+
+func (d *uaDecoder) skipBuiltin(typeID byte) {
+	switch typeID {
+	case 1, 2, 3:
+		d.take(1)
+	case 4, 5:
+		d.take(2)
+	case 6, 7, 10, 19:
+		d.take(4)
+	case 8, 9, 11, 13:
+		d.take(8)
+	case 12, 15, 16:
+		d.byteString()
+	case 14:
+		d.take(16)
+	case 17, 18:
+		d.nodeID()
+	case 20:
+		d.u16()
+		d.str()
+	case 21:
+		d.localizedText()
+	case 22:
+		d.extensionObject()
+	default:
+		if d.err == nil {
+			d.err = fmt.Errorf("values of type %d can't be decoded", typeID)
+		}
+	}
+}
+
+// dataValue returns the value or the error of a read node.
+func (d *uaDecoder) dataValue() (float64, error) {
+	mask := d.u8()
+	var value float64
+	var valueErr error = errors.New("no value")
+	if mask&0x01 != 0 {
+		value, valueErr = d.variant()
+	}
+	if mask&0x02 != 0 {
+		if status := d.u32(); status&uaStatusBad != 0 {
+			valueErr = fmt.Errorf("bad status %#08x", status)
+		}
+	}
+	// source timestamp and picoseconds, server timestamp and picoseconds
+	if mask&0x04 != 0 {
+		d.u64()
+	}
+	if mask&0x10 != 0 {
+		d.u16()
+	}
+	if mask&0x08 != 0 {
+		d.u64()
+	}
+	if mask&0x20 != 0 {
+		d.u16()
+	}
+	return value, valueErr
+}
+
+// uaConn is a secure channel with security policy None.
+type uaConn struct {
+	conn        net.Conn
+	endpointURL string
+	channelID   uint32
+	tokenID     uint32
+	sequence    uint32
+	requestID   uint32
+	authToken   []byte
+	timeout     time.Duration
+}
+
+// pollOPCUA reads the values of the nodes in an anonymous session.
+func pollOPCUA(ctx context.Context, addr string, req comm.IndustrialPollRequest) comm.IndustrialPollResult {
+	result := comm.IndustrialPollResult{ID: req.ID}
+
+	nodes := make([]uaNodeID, 0, len(req.Points))
+	for _, p := range req.Points {
+		id, err := parseUANodeID(p.NodeID)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		nodes = append(nodes, id)
+	}
+
+	dialer := &net.Dialer{Timeout: industrialDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer conn.Close()
+	timeout := defaultIndustrialTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+		timeout = time.Until(deadline)
+	}
+
+	c := &uaConn{conn: conn, endpointURL: req.Address, timeout: timeout}
+	values, errs, err := c.read(nodes)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Values = make(map[string]float64, len(values))
+	for i, p := range req.Points {
+		if errs[i] != nil {
+			if result.Errors == nil {
+				result.Errors = make(map[string]string)
+			}
+			result.Errors[p.Name] = errs[i].Error()
+			continue
+		}
+		result.Values[p.Name] = values[i]
+	}
+	return result
+}
+
+// read opens a channel and a session, reads the nodes and closes both.
+func (c *uaConn) read(nodes []uaNodeID) ([]float64, []error, error) {
+	if err := c.hello(); err != nil {
+		return nil, nil, err
+	}
+	if err := c.openSecureChannel(); err != nil {
+		return nil, nil, err
+	}
+	defer c.closeSecureChannel()
+	if err := c.createSession(); err != nil {
+		return nil, nil, err
+	}
+	defer c.closeSession()
+
+	body := &uaEncoder{}
+	body.typeID(uaIDReadRequest)
+	body.requestHeader(c.authToken, c.requestID+1, c.timeout)
+	// max age, timestamps to return
+	body.f64(0)
+	body.u32(uaTimestampsNeither)
+	body.i32(int32(len(nodes)))
+	for _, node := range nodes {
+		body.nodeID(node)
+		body.u32(uaAttributeValue)
+		// index range, data encoding
+		body.str("")
+		body.u16(0)
+		body.str("")
+	}
+	d, err := c.call(body, uaIDReadResponse)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read failed: %v", err)
+	}
+	n := d.array()
+	if d.err == nil && n != len(nodes) {
+		return nil, nil, fmt.Errorf("read returned %d values for %d nodes", n, len(nodes))
+	}
+	values := make([]float64, n)
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		values[i], errs[i] = d.dataValue()
+	}
+	if d.err != nil {
+		return nil, nil, fmt.Errorf("invalid read response: %v", d.err)
+	}
+	return values, errs, nil
+}
+
+func (c *uaConn) hello() error {
+	body := &uaEncoder{}
+	// protocol version, receive and send buffer size, max message size, max chunk count
+	body.u32(0)
+	body.u32(uaBufferSize)
+	body.u32(uaBufferSize)
+	body.u32(uaMaxMessageSize)
+	body.u32(0)
+	body.str(c.endpointURL)
+	if err := c.write("HEL", 'F', body.Bytes()); err != nil {
+		return err
+	}
+	msgType, _, _, err := c.readMessage()
+	if err != nil {
+		return err
+	}
+	if msgType != "ACK" {
+		return fmt.Errorf("unexpected %s message in response to hello", msgType)
+	}
+	return nil
+}
+
+func (c *uaConn) openSecureChannel() error {
+	body := &uaEncoder{}
+	body.u32(0)
+	// asymmetric security header
+	body.str(uaSecurityPolicyNone)
+	body.byteString(nil)
+	body.byteString(nil)
+	c.sequence++
+	c.requestID++
+	body.u32(c.sequence)
+	body.u32(c.requestID)
+	body.typeID(uaIDOpenSecureChannelRequest)
+	body.requestHeader(nil, c.requestID, c.timeout)
+	// client protocol version, request type issue, security mode, client nonce, requested lifetime
+	body.u32(0)
+	body.u32(0)
+	body.u32(uaMessageSecurityNone)
+	body.byteString(nil)
+	body.u32(uint32(time.Hour.Milliseconds()))
+	if err := c.write("OPN", 'F', body.Bytes()); err != nil {
+		return err
+	}
+
+	msgType, _, b, err := c.readMessage()
+	if err != nil {
+		return err
+	}
+	if msgType != "OPN" {
+		return fmt.Errorf("unexpected %s message in response to open secure channel", msgType)
+	}
+	d := &uaDecoder{b: b}
+	d.u32()
+	d.str()
+	d.byteString()
+	d.byteString()
+	d.u32()
+	d.u32()
+	if err := checkUAResponse(d, uaIDOpenSecureChannelResponse); err != nil {
+		return fmt.Errorf("open secure channel failed: %v", err)
+	}
+	// server protocol version, then the security token
+	d.u32()
+	c.channelID = d.u32()
+	c.tokenID = d.u32()
+	return d.err
+}
+
+func (c *uaConn) createSession() error {
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	body := &uaEncoder{}
+	body.typeID(uaIDCreateSessionRequest)
+	body.requestHeader(nil, c.requestID+1, c.timeout)
+	// client description
+	body.str("urn:rport:client")
+	body.str("urn:rport")
+	body.u8(0x02)
+	body.str("rport")
+	body.u32(uaApplicationClient)
+	body.str("")
+	body.str("")
+	body.i32(-1)
+	// server uri, endpoint url, session name, client nonce, client certificate
+	body.str("")
+	body.str(c.endpointURL)
+	body.str("rport")
+	body.byteString(nonce)
+	body.byteString(nil)
+	body.f64(uaSessionTimeoutMs)
+	body.u32(uaMaxMessageSize)
+	d, err := c.call(body, uaIDCreateSessionResponse)
+	if err != nil {
+		return fmt.Errorf("create session failed: %v", err)
+	}
+	d.nodeID()
+	c.authToken, _ = d.nodeID()
+	// revised timeout, server nonce, server certificate
+	d.u64()
+	d.byteString()
+	d.byteString()
+	policyID, found := d.anonymousPolicyID()
+	if d.err != nil {
+		return fmt.Errorf("invalid create session response: %v", d.err)
+	}
+	if !found {
+		return errors.New("server does not allow anonymous sessions")
+	}
+
+	body = &uaEncoder{}
+	body.typeID(uaIDActivateSessionRequest)
+	body.requestHeader(c.authToken, c.requestID+1, c.timeout)
+	// client signature, software certificates, locale ids
+	body.str("")
+	body.byteString(nil)
+	body.i32(-1)
+	body.i32(-1)
+	token := &uaEncoder{}
+	token.str(policyID)
+	body.typeID(uaIDAnonymousIdentityToken)
+	body.u8(0x01)
+	body.byteString(token.Bytes())
+	// user token signature
+	body.str("")
+	body.byteString(nil)
+	if _, err := c.call(body, uaIDActivateSessionResponse); err != nil {
+		return fmt.Errorf("activate session failed: %v", err)
+	}
+	return nil
+}
+
+func (c *uaConn) closeSession() {
+	body := &uaEncoder{}
+	body.typeID(uaIDCloseSessionRequest)
+	body.requestHeader(c.authToken, c.requestID+1, c.timeout)
+	body.boolean(true)
+	_, _ = c.call(body, uaIDCloseSessionResponse)
+}
+
+func (c *uaConn) closeSecureChannel() {
+	body := &uaEncoder{}
+	body.typeID(uaIDCloseSecureChannelRequest)
+	body.requestHeader(nil, c.requestID+1, c.timeout)
+	_ = c.writeSymmetric("CLO", body.Bytes())
+}
+
+// call sends a service request and returns the decoder positioned after the response header of the expected response.
+func (c *uaConn) call(body *uaEncoder, responseID uint32) (*uaDecoder, error) {
+	if err := c.writeSymmetric("MSG", body.Bytes()); err != nil {
+		return nil, err
+	}
+	requestID := c.requestID
+
+	var response []byte
+	for {
+		msgType, chunkType, b, err := c.readMessage()
+		if err != nil {
+			return nil, err
+		}
+		if msgType != "MSG" {
+			return nil, fmt.Errorf("unexpected %s message", msgType)
+		}
+		d := &uaDecoder{b: b}
+		// channel id, token id, sequence number, request id
+		d.u32()
+		d.u32()
+		d.u32()
+		if id := d.u32(); d.err == nil && id != requestID {
+			return nil, fmt.Errorf("unexpected response to request %d", id)
+		}
+		if d.err != nil {
+			return nil, d.err
+		}
+		if chunkType == 'A' {
+			d := &uaDecoder{b: d.b}
+			status := d.u32()
+			return nil, fmt.Errorf("request aborted with status %#08x: %s", status, d.str())
+		}
+		response = append(response, d.b...)
+		if chunkType == 'F' {
+			break
+		}
+		if len(response) > uaMaxMessageSize {
+			return nil, errors.New("response exceeds max message size")
+		}
+	}
+	d := &uaDecoder{b: response}
+	if err := checkUAResponse(d, responseID); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// checkUAResponse reads the type and the header of a response, it fails on service faults and bad service results.
+func checkUAResponse(d *uaDecoder, responseID uint32) error {
+	_, typeID := d.nodeID()
+	if d.err != nil {
+		return d.err
+	}
+	if typeID != responseID && typeID != uaIDServiceFault {
+		return fmt.Errorf("unexpected response type %d", typeID)
+	}
+	result := d.responseHeader()
+	if d.err != nil {
+		return d.err
+	}
+	if result&uaStatusBad != 0 {
+		return fmt.Errorf("bad service result %#08x", result)
+	}
+	if typeID == uaIDServiceFault {
+		return errors.New("service fault")
+	}
+	return nil
+}
+
+func (c *uaConn) writeSymmetric(msgType string, body []byte) error {
+	c.sequence++
+	c.requestID++
+	msg := &uaEncoder{}
+	msg.u32(c.channelID)
+	msg.u32(c.tokenID)
+	msg.u32(c.sequence)
+	msg.u32(c.requestID)
+	msg.Write(body)
+	return c.write(msgType, 'F', msg.Bytes())
+}
+
+func (c *uaConn) write(msgType string, chunkType byte, body []byte) error {
+	msg := make([]byte, 8, 8+len(body))
+	copy(msg, msgType)
+	msg[3] = chunkType
+	binary.LittleEndian.PutUint32(msg[4:], uint32(8+len(body)))
+	msg = append(msg, body...)
+	_, err := c.conn.Write(msg)
+	return err
+}
+
+// readMessage returns the next message, an ERR message is returned as error.
+func (c *uaConn) readMessage() (msgType string, chunkType byte, body []byte, err error) {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(c.conn, header); err != nil {
+		return "", 0, nil, err
+	}
+	size := binary.LittleEndian.Uint32(header[4:])
+	if size < 8 || size > uaBufferSize {
+		return "", 0, nil, fmt.Errorf("invalid message size %d", size)
+	}
+	body = make([]byte, size-8)
+	if _, err := io.ReadFull(c.conn, body); err != nil {
+		return "", 0, nil, err
+	}
+	msgType = string(header[:3])
+	if msgType == "ERR" {
+		d := &uaDecoder{b: body}
+		status := d.u32()
+		return "", 0, nil, fmt.Errorf("server error %#08x: %s", status, d.str())
+	}
+	return msgType, header[3], body, nil
+}
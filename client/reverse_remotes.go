@@ -0,0 +1,128 @@
+package chclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/jpillora/sizestr"
+	"golang.org/x/crypto/ssh"
+
+	chshare "github.com/IOTech17/neo-rport/share"
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+type reverseRemoteListener struct {
+	net.Listener
+	remote *models.Remote
+}
+
+// reverseRemotes makes services next to the server available on local ports of the client.
+// The set of remotes is pushed by the server based on the client groups the client belongs to.
+type reverseRemotes struct {
+	*logger.Logger
+	connStats *chshare.ConnStats
+
+	mu        sync.Mutex
+	listeners map[string]*reverseRemoteListener
+}
+
+func newReverseRemotes(l *logger.Logger, connStats *chshare.ConnStats) *reverseRemotes {
+	return &reverseRemotes{
+		Logger:    l,
+		connStats: connStats,
+		listeners: make(map[string]*reverseRemoteListener),
+	}
+}
+
+// Put replaces the current reverse remotes with the given ones. Listeners of unchanged remotes are kept.
+func (rr *reverseRemotes) Put(conn ssh.Conn, payload []byte) error {
+	var remotes []*models.Remote
+	if err := json.Unmarshal(payload, &remotes); err != nil {
+		return fmt.Errorf("failed to decode reverse remotes: %v", err)
+	}
+
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	wanted := make(map[string]*models.Remote, len(remotes))
+	for _, r := range remotes {
+		wanted[r.String()] = r
+	}
+
+	for key, l := range rr.listeners {
+		if _, ok := wanted[key]; ok {
+			continue
+		}
+		rr.Infof("reverse remote %s: stopped", key)
+		_ = l.Close()
+		delete(rr.listeners, key)
+	}
+
+	var errs []string
+	for key, r := range wanted {
+		if _, ok := rr.listeners[key]; ok {
+			continue
+		}
+		l, err := net.Listen("tcp", r.Local())
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", key, err))
+			continue
+		}
+		rl := &reverseRemoteListener{Listener: l, remote: r}
+		rr.listeners[key] = rl
+		rr.Infof("reverse remote %s: listening on %s", key, r.Local())
+		go rr.accept(rl, conn)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to start reverse remotes: %v", errs)
+	}
+	return nil
+}
+
+// StopAll closes all listeners, the server pushes the remotes again after reconnecting.
+func (rr *reverseRemotes) StopAll() {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	for key, l := range rr.listeners {
+		_ = l.Close()
+		delete(rr.listeners, key)
+	}
+}
+
+func (rr *reverseRemotes) accept(l *reverseRemoteListener, conn ssh.Conn) {
+	for {
+		src, err := l.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				rr.Errorf("reverse remote %s: failed to accept connection: %v", l.remote, err)
+			}
+			return
+		}
+		go rr.handle(src, conn, l.remote)
+	}
+}
+
+func (rr *reverseRemotes) handle(src net.Conn, conn ssh.Conn, r *models.Remote) {
+	l := rr.Fork("reverse conn#%d", rr.connStats.New())
+
+	dst, reqs, err := conn.OpenChannel(comm.ChannelReverseRemote, []byte(r.Remote()))
+	if err != nil {
+		l.Errorf("Could not reach %s through the server: %v", r.Remote(), err)
+		src.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	rr.connStats.Open()
+	l.Debugf("%s: Open", rr.connStats)
+	s, rcv := chshare.Pipe(src, dst)
+	rr.connStats.Close()
+	l.Debugf("%s: Close (sent %s received %s)", rr.connStats, sizestr.ToString(s), sizestr.ToString(rcv))
+}
@@ -0,0 +1,128 @@
+package chclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/IOTech17/neo-rport/share/clientconfig"
+	"github.com/IOTech17/neo-rport/share/comm"
+)
+
+const (
+	screenshotTimeout         = 30 * time.Second
+	screenshotFilePlaceholder = "{file}"
+)
+
+// handleTakeScreenshot starts taking a screenshot if allowed by the config. The screenshot is sent on a separate
+// channel, it's usually too large for a request reply.
+func (c *Client) handleTakeScreenshot(ctx context.Context, conn ssh.Conn, payload []byte) error {
+	cfg := c.configHolder.Screenshots
+	if !cfg.Enabled {
+		return errors.New(`screenshots are disabled by "screenshots.enabled" config`)
+	}
+
+	var req comm.TakeScreenshotRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return fmt.Errorf("failed to decode %T: %v", req, err)
+	}
+	if c.configHolder.Consent.Screenshots && !c.consents.take(req.ConsentID, comm.ConsentSubjectScreenshot, time.Now()) {
+		return errors.New("screenshots require the consent of the user")
+	}
+
+	command, err := screenshotCommand(cfg)
+	if err != nil {
+		return err
+	}
+
+	c.Infof("Taking screenshot %s on request of the server", req.ID)
+	go func() {
+		ctx, cancel := context.WithTimeout(ctx, screenshotTimeout)
+		defer cancel()
+
+		result := comm.ScreenshotResult{ID: req.ID}
+		img, err := takeScreenshot(ctx, command, cfg.MaxBytes)
+		if err != nil {
+			c.Errorf("Failed to take screenshot %s: %v", req.ID, err)
+			result.Error = err.Error()
+		} else {
+			result.ContentType = http.DetectContentType(img)
+		}
+
+		if err := sendScreenshot(conn, result, img); err != nil {
+			c.Errorf("Failed to send screenshot %s: %v", req.ID, err)
+		}
+	}()
+	return nil
+}
+
+func screenshotCommand(cfg clientconfig.ScreenshotsConfig) ([]string, error) {
+	if len(cfg.Command) > 0 {
+		return cfg.Command, nil
+	}
+	for _, command := range defaultScreenshotCommands {
+		if _, err := exec.LookPath(command[0]); err == nil {
+			return command, nil
+		}
+	}
+	return nil, errors.New(`no screenshot tool found, set "screenshots.command" config`)
+}
+
+// takeScreenshot runs the command and returns the image it wrote to the file of the placeholder.
+func takeScreenshot(ctx context.Context, command []string, maxBytes int64) ([]byte, error) {
+	f, err := os.CreateTemp("", "rport-screenshot-*.png")
+	if err != nil {
+		return nil, err
+	}
+	path := f.Name()
+	f.Close()
+	defer os.Remove(path)
+
+	args := make([]string, 0, len(command)-1)
+	for _, arg := range command[1:] {
+		args = append(args, strings.ReplaceAll(arg, screenshotFilePlaceholder, path))
+	}
+	out, err := exec.CommandContext(ctx, command[0], args...).CombinedOutput() //nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v %s", command[0], err, strings.TrimSpace(string(out)))
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() == 0 {
+		return nil, fmt.Errorf("%s did not write the screenshot to %s", command[0], path)
+	}
+	if maxBytes > 0 && info.Size() > maxBytes {
+		return nil, fmt.Errorf("screenshot of %d bytes exceeds the limit of %d bytes", info.Size(), maxBytes)
+	}
+	return os.ReadFile(path)
+}
+
+func sendScreenshot(conn ssh.Conn, result comm.ScreenshotResult, img []byte) error {
+	extra, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	ch, reqs, err := conn.OpenChannel(comm.ChannelScreenshot, extra)
+	if err != nil {
+		return err
+	}
+	go ssh.DiscardRequests(reqs)
+	defer ch.Close()
+
+	_, err = ch.Write(img)
+	if err != nil {
+		return err
+	}
+	return ch.CloseWrite()
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+// +build !windows
+
+package chclient
+
+// defaultScreenshotCommands are tried in order, the first one installed is used. On Linux the client needs access to
+// the display, e.g. by DISPLAY or WAYLAND_DISPLAY in the environment of the service.
+var defaultScreenshotCommands = [][]string{
+	{"grim", screenshotFilePlaceholder},
+	{"gnome-screenshot", "-f", screenshotFilePlaceholder},
+	{"import", "-window", "root", screenshotFilePlaceholder},
+	{"scrot", "-o", screenshotFilePlaceholder},
+	{"screencapture", "-x", "-t", "png", screenshotFilePlaceholder},
+}
@@ -0,0 +1,38 @@
+//go:build !windows
+// +build !windows
+
+package chclient
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/share/clientconfig"
+)
+
+func TestTakeScreenshot(t *testing.T) {
+	ctx := context.Background()
+
+	img, err := takeScreenshot(ctx, []string{"sh", "-c", `printf 'fake png' > "$0"`, "{file}"}, 100)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("fake png"), img)
+
+	_, err = takeScreenshot(ctx, []string{"sh", "-c", `printf 'fake png' > "$0"`, "{file}"}, 4)
+	assert.EqualError(t, err, "screenshot of 8 bytes exceeds the limit of 4 bytes")
+
+	_, err = takeScreenshot(ctx, []string{"true"}, 100)
+	assert.ErrorContains(t, err, "true did not write the screenshot to")
+
+	_, err = takeScreenshot(ctx, []string{"sh", "-c", "echo no display >&2; exit 1"}, 100)
+	assert.EqualError(t, err, "sh: exit status 1 no display")
+}
+
+func TestHandleTakeScreenshotDisabled(t *testing.T) {
+	c := &Client{configHolder: &ClientConfigHolder{Config: &clientconfig.Config{}}}
+
+	err := c.handleTakeScreenshot(context.Background(), nil, []byte(`{"ID":"1"}`))
+	assert.EqualError(t, err, `screenshots are disabled by "screenshots.enabled" config`)
+}
@@ -0,0 +1,17 @@
+//go:build windows
+// +build windows
+
+package chclient
+
+// defaultScreenshotCommands capture the virtual screen of all monitors. The client must run in the session of the
+// logged-in user, the desktop is not visible to services running in session 0.
+var defaultScreenshotCommands = [][]string{
+	{
+		"powershell.exe", "-NoProfile", "-NonInteractive", "-Command",
+		"Add-Type -AssemblyName System.Windows.Forms,System.Drawing; " +
+			"$s = [System.Windows.Forms.SystemInformation]::VirtualScreen; " +
+			"$b = New-Object System.Drawing.Bitmap $s.Width, $s.Height; " +
+			"[System.Drawing.Graphics]::FromImage($b).CopyFromScreen($s.Left, $s.Top, 0, 0, $b.Size); " +
+			"$b.Save('" + screenshotFilePlaceholder + "', [System.Drawing.Imaging.ImageFormat]::Png)",
+	},
+}
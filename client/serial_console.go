@@ -0,0 +1,119 @@
+package chclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/IOTech17/neo-rport/share/comm"
+)
+
+// DefaultSerialConsolePorts are the devices console sessions may open, unless configured otherwise.
+var DefaultSerialConsolePorts = []string{"/dev/ttyUSB*", "/dev/ttyACM*", "COM*"}
+
+// serialConsoles tracks the open ports, a port is used by a single console session at a time.
+type serialConsoles struct {
+	open map[string]bool
+	mu   sync.Mutex
+}
+
+func newSerialConsoles() *serialConsoles {
+	return &serialConsoles{
+		open: make(map[string]bool),
+	}
+}
+
+func (s *serialConsoles) acquire(port string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.open[port] {
+		return false
+	}
+	s.open[port] = true
+	return true
+}
+
+func (s *serialConsoles) release(port string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.open, port)
+}
+
+// handleOpenSerialConsole opens a local serial port if allowed by the config. The port is relayed on a separate
+// channel until the server or the port closes it.
+func (c *Client) handleOpenSerialConsole(conn ssh.Conn, payload []byte) error {
+	cfg := c.configHolder.SerialConsoles
+	if !cfg.Enabled {
+		return errors.New(`serial consoles are disabled by "serial-consoles.enabled" config`)
+	}
+
+	var req comm.OpenSerialConsoleRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		return fmt.Errorf("failed to decode %T: %v", req, err)
+	}
+	if !serialPortAllowed(cfg.Ports, req.Port) {
+		return fmt.Errorf("port %s is not allowed by %q config", req.Port, "serial-consoles.ports")
+	}
+	if err := req.SerialLine.Validate(); err != nil {
+		return err
+	}
+
+	if !c.serialConsoles.acquire(req.Port) {
+		return fmt.Errorf("port %s is in use by another console session", req.Port)
+	}
+	port, err := openSerialPort(req.Port, req.SerialLine)
+	if err != nil {
+		c.serialConsoles.release(req.Port)
+		return fmt.Errorf("failed to open %s: %v", req.Port, err)
+	}
+
+	c.Infof("Opened serial console %s on %s with %d baud", req.ID, req.Port, req.BaudRate)
+	go func() {
+		defer c.serialConsoles.release(req.Port)
+		defer port.Close()
+
+		if err := relaySerialConsole(conn, req.ID, port); err != nil {
+			c.Errorf("Failed to relay serial console %s: %v", req.ID, err)
+			return
+		}
+		c.Infof("Closed serial console %s on %s", req.ID, req.Port)
+	}()
+	return nil
+}
+
+func serialPortAllowed(patterns []string, port string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, port); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// relaySerialConsole copies the bytes between the port and a console channel to the server until one side is
+// closed. The caller closes the port.
+func relaySerialConsole(conn ssh.Conn, id string, port io.ReadWriter) error {
+	ch, reqs, err := conn.OpenChannel(comm.ChannelSerialConsole, []byte(id))
+	if err != nil {
+		return err
+	}
+	go ssh.DiscardRequests(reqs)
+	defer ch.Close()
+
+	done := make(chan struct{}, 2)
+	go func() {
+		_, _ = io.Copy(ch, port)
+		done <- struct{}{}
+	}()
+	go func() {
+		_, _ = io.Copy(port, ch)
+		done <- struct{}{}
+	}()
+	<-done
+	return nil
+}
@@ -0,0 +1,56 @@
+package chclient
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/IOTech17/neo-rport/share/clientconfig"
+)
+
+func TestSerialPortAllowed(t *testing.T) {
+	for _, tc := range []struct {
+		port string
+		want bool
+	}{
+		{"/dev/ttyUSB0", true},
+		{"/dev/ttyACM12", true},
+		{"COM3", true},
+		{"/dev/ttyS0", false},
+		{"/dev/ttyUSB0/../sda", false},
+		{"/dev/sda", false},
+		{"", false},
+	} {
+		assert.Equal(t, tc.want, serialPortAllowed(DefaultSerialConsolePorts, tc.port), tc.port)
+	}
+}
+
+func TestHandleOpenSerialConsole(t *testing.T) {
+	c := &Client{
+		configHolder: &ClientConfigHolder{Config: &clientconfig.Config{
+			SerialConsoles: clientconfig.SerialConsolesConfig{
+				Ports: []string{"/dev/ttyUSB*"},
+			},
+		}},
+		serialConsoles: newSerialConsoles(),
+	}
+
+	err := c.handleOpenSerialConsole(nil, []byte(`{"ID":"1","Port":"/dev/ttyUSB0"}`))
+	assert.EqualError(t, err, `serial consoles are disabled by "serial-consoles.enabled" config`)
+
+	c.configHolder.SerialConsoles.Enabled = true
+	err = c.handleOpenSerialConsole(nil, []byte(`{"ID":"1","Port":"/dev/ttyS0","BaudRate":9600,"DataBits":8,"Parity":"none","StopBits":1}`))
+	assert.EqualError(t, err, `port /dev/ttyS0 is not allowed by "serial-consoles.ports" config`)
+
+	err = c.handleOpenSerialConsole(nil, []byte(`{"ID":"1","Port":"/dev/ttyUSB0","BaudRate":1234,"DataBits":8,"Parity":"none","StopBits":1}`))
+	assert.Error(t, err)
+
+	assert.True(t, c.serialConsoles.acquire("/dev/ttyUSB0"))
+	err = c.handleOpenSerialConsole(nil, []byte(`{"ID":"1","Port":"/dev/ttyUSB0","BaudRate":9600,"DataBits":8,"Parity":"none","StopBits":1}`))
+	assert.EqualError(t, err, "port /dev/ttyUSB0 is in use by another console session")
+	c.serialConsoles.release("/dev/ttyUSB0")
+
+	err = c.handleOpenSerialConsole(nil, []byte(`{"ID":"1","Port":"/dev/ttyUSB99","BaudRate":9600,"DataBits":8,"Parity":"none","StopBits":1}`))
+	assert.ErrorContains(t, err, "failed to open /dev/ttyUSB99")
+	assert.True(t, c.serialConsoles.acquire("/dev/ttyUSB99"), "port must be released on error")
+}
@@ -0,0 +1,93 @@
+//go:build linux
+// +build linux
+
+package chclient
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/IOTech17/neo-rport/share/comm"
+)
+
+var serialSpeeds = map[int]uint32{
+	300:    unix.B300,
+	600:    unix.B600,
+	1200:   unix.B1200,
+	2400:   unix.B2400,
+	4800:   unix.B4800,
+	9600:   unix.B9600,
+	19200:  unix.B19200,
+	38400:  unix.B38400,
+	57600:  unix.B57600,
+	115200: unix.B115200,
+	230400: unix.B230400,
+	460800: unix.B460800,
+	921600: unix.B921600,
+}
+
+var serialDataBits = map[int]uint32{
+	5: unix.CS5,
+	6: unix.CS6,
+	7: unix.CS7,
+	8: unix.CS8,
+}
+
+// openSerialPort opens the port in raw mode. It's opened non-blocking, so closing it stops a pending read.
+func openSerialPort(name string, line comm.SerialLine) (io.ReadWriteCloser, error) {
+	speed, ok := serialSpeeds[line.BaudRate]
+	if !ok {
+		return nil, fmt.Errorf("unsupported baud rate %d", line.BaudRate)
+	}
+	f, err := os.OpenFile(name, os.O_RDWR|unix.O_NOCTTY|unix.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := f.SyscallConn()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	var lineErr error
+	err = rc.Control(func(fd uintptr) {
+		lineErr = setSerialLine(int(fd), speed, line)
+	})
+	if err == nil {
+		err = lineErr
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to set the line settings: %v", err)
+	}
+	return f, nil
+}
+
+func setSerialLine(fd int, speed uint32, line comm.SerialLine) error {
+	t, err := unix.IoctlGetTermios(fd, unix.TCGETS)
+	if err != nil {
+		return err
+	}
+
+	// raw mode like cfmakeraw, without flow control
+	t.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON | unix.IXOFF | unix.IXANY
+	t.Oflag &^= unix.OPOST
+	t.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	t.Cflag &^= unix.CSIZE | unix.PARENB | unix.PARODD | unix.CSTOPB | unix.CRTSCTS | unix.CBAUD
+	t.Cflag |= unix.CREAD | unix.CLOCAL | speed | serialDataBits[line.DataBits]
+	switch line.Parity {
+	case comm.SerialParityOdd:
+		t.Cflag |= unix.PARENB | unix.PARODD
+	case comm.SerialParityEven:
+		t.Cflag |= unix.PARENB
+	}
+	if line.StopBits == 2 {
+		t.Cflag |= unix.CSTOPB
+	}
+	t.Cc[unix.VMIN] = 1
+	t.Cc[unix.VTIME] = 0
+
+	return unix.IoctlSetTermios(fd, unix.TCSETS, t)
+}
@@ -0,0 +1,56 @@
+//go:build linux
+// +build linux
+
+package chclient
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+
+	"github.com/IOTech17/neo-rport/share/comm"
+)
+
+// openPTY returns the master of a pseudo terminal and the name of its slave, a stand-in for a serial port
+func openPTY(t *testing.T) (*os.File, string) {
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("pseudo terminals not available: %v", err)
+	}
+	t.Cleanup(func() { master.Close() })
+	n, err := unix.IoctlGetInt(int(master.Fd()), unix.TIOCGPTN)
+	require.NoError(t, err)
+	require.NoError(t, unix.IoctlSetPointerInt(int(master.Fd()), unix.TIOCSPTLCK, 0))
+	return master, fmt.Sprintf("/dev/pts/%d", n)
+}
+
+func TestOpenSerialPort(t *testing.T) {
+	master, name := openPTY(t)
+
+	port, err := openSerialPort(name, comm.SerialLine{BaudRate: 115200, DataBits: 8, Parity: comm.SerialParityNone, StopBits: 1})
+	require.NoError(t, err)
+	defer port.Close()
+
+	f := port.(*os.File)
+	termios, err := unix.IoctlGetTermios(int(f.Fd()), unix.TCGETS)
+	require.NoError(t, err)
+	// pseudo terminals keep their data bits, parity and stop bits, only the speed and the mode can be checked
+	assert.EqualValues(t, unix.B115200, termios.Cflag&unix.CBAUD)
+	assert.Zero(t, termios.Lflag&(unix.ECHO|unix.ICANON))
+	assert.Zero(t, termios.Oflag&unix.OPOST)
+
+	// raw mode passes the bytes unchanged
+	_, err = master.Write([]byte("login: \r"))
+	require.NoError(t, err)
+	buf := make([]byte, 8)
+	n, err := port.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "login: \r", string(buf[:n]))
+
+	_, err = openSerialPort(name, comm.SerialLine{BaudRate: 1234, DataBits: 8})
+	assert.EqualError(t, err, "unsupported baud rate 1234")
+}
@@ -0,0 +1,16 @@
+//go:build !windows && !linux
+// +build !windows,!linux
+
+package chclient
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+
+	"github.com/IOTech17/neo-rport/share/comm"
+)
+
+func openSerialPort(string, comm.SerialLine) (io.ReadWriteCloser, error) {
+	return nil, fmt.Errorf("serial ports are not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,144 @@
+//go:build windows
+// +build windows
+
+package chclient
+
+import (
+	"io"
+	"sync/atomic"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+
+	"github.com/IOTech17/neo-rport/share/comm"
+)
+
+var (
+	kernel32         = windows.NewLazySystemDLL("kernel32.dll")
+	procGetCommState = kernel32.NewProc("GetCommState")
+	procSetCommState = kernel32.NewProc("SetCommState")
+)
+
+const (
+	dcbBinary           = 0x00000001
+	dcbParity           = 0x00000002
+	dcbDTRControlEnable = 0x00000010
+	dcbRTSControlEnable = 0x00001000
+
+	serialOneStopBit  = 0
+	serialTwoStopBits = 2
+	serialOddParity   = 1
+	serialEvenParity  = 2
+
+	// serialReadTimeoutMs lets a read without data return, so closing the port stops a pending read
+	serialReadTimeoutMs = 100
+)
+
+// dcb is the DCB structure of the Windows API holding the line settings.
+type dcb struct {
+	DCBlength  uint32
+	BaudRate   uint32
+	Flags      uint32
+	wReserved  uint16
+	XonLim     uint16
+	XoffLim    uint16
+	ByteSize   byte
+	Parity     byte
+	StopBits   byte
+	XonChar    byte
+	XoffChar   byte
+	ErrorChar  byte
+	EofChar    byte
+	EvtChar    byte
+	wReserved1 uint16
+}
+
+type serialPort struct {
+	handle windows.Handle
+	closed atomic.Bool
+}
+
+func openSerialPort(name string, line comm.SerialLine) (io.ReadWriteCloser, error) {
+	path, err := windows.UTF16PtrFromString(`\\.\` + name)
+	if err != nil {
+		return nil, err
+	}
+	h, err := windows.CreateFile(path, windows.GENERIC_READ|windows.GENERIC_WRITE, 0, nil, windows.OPEN_EXISTING, windows.FILE_ATTRIBUTE_NORMAL, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := setSerialLine(h, line); err != nil {
+		_ = windows.CloseHandle(h)
+		return nil, err
+	}
+	err = windows.SetCommTimeouts(h, &windows.CommTimeouts{
+		ReadIntervalTimeout:        windows.INFINITE,
+		ReadTotalTimeoutMultiplier: windows.INFINITE,
+		ReadTotalTimeoutConstant:   serialReadTimeoutMs,
+	})
+	if err != nil {
+		_ = windows.CloseHandle(h)
+		return nil, err
+	}
+	return &serialPort{handle: h}, nil
+}
+
+func setSerialLine(h windows.Handle, line comm.SerialLine) error {
+	d := dcb{}
+	d.DCBlength = uint32(unsafe.Sizeof(d))
+	if r, _, err := procGetCommState.Call(uintptr(h), uintptr(unsafe.Pointer(&d))); r == 0 {
+		return err
+	}
+
+	d.BaudRate = uint32(line.BaudRate)
+	d.ByteSize = byte(line.DataBits)
+	d.Flags = dcbBinary | dcbDTRControlEnable | dcbRTSControlEnable
+	d.Parity = 0
+	switch line.Parity {
+	case comm.SerialParityOdd:
+		d.Flags |= dcbParity
+		d.Parity = serialOddParity
+	case comm.SerialParityEven:
+		d.Flags |= dcbParity
+		d.Parity = serialEvenParity
+	}
+	d.StopBits = serialOneStopBit
+	if line.StopBits == 2 {
+		d.StopBits = serialTwoStopBits
+	}
+
+	if r, _, err := procSetCommState.Call(uintptr(h), uintptr(unsafe.Pointer(&d))); r == 0 {
+		return err
+	}
+	return nil
+}
+
+func (p *serialPort) Read(b []byte) (int, error) {
+	for {
+		var n uint32
+		err := windows.ReadFile(p.handle, b, &n, nil)
+		if p.closed.Load() {
+			return 0, io.EOF
+		}
+		if err != nil {
+			return 0, err
+		}
+		if n > 0 {
+			return int(n), nil
+		}
+	}
+}
+
+func (p *serialPort) Write(b []byte) (int, error) {
+	var n uint32
+	err := windows.WriteFile(p.handle, b, &n, nil)
+	return int(n), err
+}
+
+func (p *serialPort) Close() error {
+	if p.closed.Swap(true) {
+		return nil
+	}
+	_ = windows.CancelIoEx(p.handle, nil)
+	return windows.CloseHandle(p.handle)
+}
@@ -0,0 +1,74 @@
+package sysproxy
+
+import (
+	"bufio"
+	"net/url"
+	"strings"
+)
+
+// scutilProxySettings are the proxy settings of macOS as printed by "scutil --proxy".
+type scutilProxySettings struct {
+	values     map[string]string
+	exceptions []string
+}
+
+func parseScutilProxy(output string) scutilProxySettings {
+	s := scutilProxySettings{values: make(map[string]string)}
+	inExceptions := false
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "}" {
+			inExceptions = false
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		switch {
+		case inExceptions:
+			s.exceptions = append(s.exceptions, value)
+		case key == "ExceptionsList":
+			inExceptions = true
+		default:
+			s.values[key] = value
+		}
+	}
+	return s
+}
+
+func (s scutilProxySettings) enabled(key string) bool {
+	return s.values[key] == "1"
+}
+
+func (s scutilProxySettings) pacURL() string {
+	if s.enabled("ProxyAutoConfigEnable") {
+		return s.values["ProxyAutoConfigURLString"]
+	}
+	return ""
+}
+
+// proxyFor returns the static proxy configured for the target.
+func (s scutilProxySettings) proxyFor(target *url.URL) *url.URL {
+	host := target.Hostname()
+	if s.enabled("ExcludeSimpleHostnames") && !strings.Contains(host, ".") {
+		return nil
+	}
+	if bypassed(host, s.exceptions) {
+		return nil
+	}
+
+	prefix := "HTTP"
+	if target.Scheme == "https" {
+		prefix = "HTTPS"
+	}
+	if s.enabled(prefix+"Enable") && s.values[prefix+"Proxy"] != "" {
+		return &url.URL{Scheme: "http", Host: s.values[prefix+"Proxy"] + ":" + s.values[prefix+"Port"]}
+	}
+	if s.enabled("SOCKSEnable") && s.values["SOCKSProxy"] != "" {
+		return &url.URL{Scheme: "socks5h", Host: s.values["SOCKSProxy"] + ":" + s.values["SOCKSPort"]}
+	}
+	return nil
+}
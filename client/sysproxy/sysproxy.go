@@ -0,0 +1,190 @@
+// Package sysproxy finds the proxy to reach the server by the proxy settings of the operating system or a PAC file.
+package sysproxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+const (
+	pacFetchTimeout = 30 * time.Second
+	maxPACSize      = 1024 * 1024
+)
+
+// Resolver returns the proxy to use for the given target, nil means a direct connection.
+type Resolver interface {
+	ProxyFor(ctx context.Context, target *url.URL) (*url.URL, error)
+}
+
+// NewResolver returns a resolver evaluating the given PAC file, if pacURL is set, and otherwise one using the
+// system settings: environment variables like HTTPS_PROXY and NO_PROXY and the settings of Windows or macOS.
+func NewResolver(pacURL string) Resolver {
+	if pacURL != "" {
+		return &pacResolver{pacURL: pacURL}
+	}
+	return &systemResolver{}
+}
+
+type systemResolver struct{}
+
+func (r *systemResolver) ProxyFor(ctx context.Context, target *url.URL) (*url.URL, error) {
+	target = httpTarget(target)
+	proxyURL, err := httpproxy.FromEnvironment().ProxyFunc()(target)
+	if err != nil || proxyURL != nil {
+		return proxyURL, err
+	}
+	return systemProxyFor(ctx, target)
+}
+
+type pacResolver struct {
+	pacURL string
+}
+
+func (r *pacResolver) ProxyFor(ctx context.Context, target *url.URL) (*url.URL, error) {
+	target = httpTarget(target)
+	result, err := evaluatePAC(ctx, r.pacURL, target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate PAC file %s: %v", r.pacURL, err)
+	}
+	return ParsePACResult(result)
+}
+
+// httpTarget maps websocket urls to the http urls proxy settings are defined for.
+func httpTarget(target *url.URL) *url.URL {
+	res := *target
+	switch res.Scheme {
+	case "ws":
+		res.Scheme = "http"
+	case "wss":
+		res.Scheme = "https"
+	}
+	if (res.Scheme == "http" && res.Port() == "80") || (res.Scheme == "https" && res.Port() == "443") {
+		res.Host = res.Hostname()
+	}
+	return &res
+}
+
+// ParsePACResult returns the first usable proxy of the result of FindProxyForURL, e.g. "PROXY proxy:8080; DIRECT".
+// SOCKS4 isn't supported by the client, SOCKS entries are used as SOCKS5.
+func ParsePACResult(result string) (*url.URL, error) {
+	for _, entry := range strings.Split(result, ";") {
+		fields := strings.Fields(entry)
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "DIRECT":
+			return nil, nil
+		case "PROXY", "HTTP":
+			if len(fields) > 1 {
+				return &url.URL{Scheme: "http", Host: fields[1]}, nil
+			}
+		case "SOCKS", "SOCKS5":
+			if len(fields) > 1 {
+				return &url.URL{Scheme: "socks5h", Host: fields[1]}, nil
+			}
+		}
+	}
+	if strings.TrimSpace(result) == "" {
+		return nil, nil
+	}
+	return nil, fmt.Errorf("no supported proxy in %q", result)
+}
+
+// parseProxyList parses a proxy list like the ones of the Windows settings, either "host:port" for all protocols or
+// "http=host:port;https=host:port".
+func parseProxyList(list string, scheme string) *url.URL {
+	var fallback string
+	for _, entry := range strings.FieldsFunc(list, func(r rune) bool { return r == ';' || r == ' ' }) {
+		protocol, hostPort, ok := strings.Cut(entry, "=")
+		if !ok {
+			if fallback == "" {
+				fallback = entry
+			}
+			continue
+		}
+		if strings.EqualFold(protocol, scheme) {
+			return proxyURLFromHostPort(hostPort)
+		}
+	}
+	if fallback != "" {
+		return proxyURLFromHostPort(fallback)
+	}
+	return nil
+}
+
+func proxyURLFromHostPort(hostPort string) *url.URL {
+	if u, err := url.Parse(hostPort); err == nil && u.Host != "" {
+		return u
+	}
+	return &url.URL{Scheme: "http", Host: hostPort}
+}
+
+// bypassed returns true if the host matches one of the patterns, like "*.example.com", "<local>" for names without
+// dots or CIDRs like "10.0.0.0/8".
+func bypassed(host string, patterns []string) bool {
+	host = strings.ToLower(host)
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		switch {
+		case pattern == "":
+		case pattern == "<local>":
+			if !strings.Contains(host, ".") {
+				return true
+			}
+		case strings.Contains(pattern, "/"):
+			_, ipNet, err := net.ParseCIDR(pattern)
+			if ip := net.ParseIP(host); err == nil && ip != nil && ipNet.Contains(ip) {
+				return true
+			}
+		case strings.HasPrefix(pattern, "*"):
+			if strings.HasSuffix(host, strings.TrimPrefix(pattern, "*")) {
+				return true
+			}
+		case host == pattern || strings.HasSuffix(host, "."+pattern):
+			return true
+		}
+	}
+	return false
+}
+
+// fetchPAC reads the PAC file from a http(s) url, a file url or a local path.
+func fetchPAC(ctx context.Context, pacURL string) (string, error) {
+	u, err := url.Parse(pacURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		path := pacURL
+		if err == nil && u.Scheme == "file" {
+			path = u.Path
+		}
+		content, err := os.ReadFile(path)
+		return string(content), err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, pacFetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pacURL, nil)
+	if err != nil {
+		return "", err
+	}
+	// the PAC file itself is fetched directly, like browsers do
+	client := &http.Client{Transport: &http.Transport{Proxy: nil}}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxPACSize))
+	return string(content), err
+}
@@ -0,0 +1,91 @@
+//go:build darwin
+// +build darwin
+
+package sysproxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strings"
+)
+
+// systemProxyFor uses the settings of the network preferences, PAC files included.
+func systemProxyFor(ctx context.Context, target *url.URL) (*url.URL, error) {
+	out, err := exec.CommandContext(ctx, "scutil", "--proxy").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read proxy settings: %v", err)
+	}
+	settings := parseScutilProxy(string(out))
+
+	if pacURL := settings.pacURL(); pacURL != "" {
+		result, err := evaluatePAC(ctx, pacURL, target)
+		if err == nil {
+			return ParsePACResult(result)
+		}
+		// as browsers do, the static settings apply if auto configuration fails
+	}
+	return settings.proxyFor(target), nil
+}
+
+// evaluatePAC runs the PAC file by the JavaScript for Automation engine shipped with macOS.
+func evaluatePAC(ctx context.Context, pacURL string, target *url.URL) (string, error) {
+	pac, err := fetchPAC(ctx, pacURL)
+	if err != nil {
+		return "", err
+	}
+	args, err := json.Marshal([]string{target.String(), target.Hostname()})
+	if err != nil {
+		return "", err
+	}
+
+	script := pacHelpers + "\n" + pac + "\nFindProxyForURL.apply(null, " + string(args) + ");\n"
+	cmd := exec.CommandContext(ctx, "osascript", "-l", "JavaScript", "-")
+	cmd.Stdin = strings.NewReader(script)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// pacHelpers implements the functions available to PAC files.
+const pacHelpers = `
+ObjC.import('Foundation');
+function dnsResolve(host) {
+  var addr = $.NSHost.hostWithName(host).address;
+  return addr && !addr.isNil() ? ObjC.unwrap(addr) : null;
+}
+function myIpAddress() {
+  var addr = $.NSHost.currentHost.address;
+  return addr && !addr.isNil() ? ObjC.unwrap(addr) : '127.0.0.1';
+}
+function isPlainHostName(host) { return host.indexOf('.') < 0; }
+function dnsDomainIs(host, domain) {
+  return host.length >= domain.length && host.substring(host.length - domain.length) === domain;
+}
+function localHostOrDomainIs(host, hostdom) { return host === hostdom || hostdom.lastIndexOf(host + '.', 0) === 0; }
+function isResolvable(host) { return dnsResolve(host) !== null; }
+function dnsDomainLevels(host) { return host.split('.').length - 1; }
+function shExpMatch(str, shexp) {
+  var re = shexp.replace(/[.+^${}()|[\]\\]/g, '\\$&').replace(/\*/g, '.*').replace(/\?/g, '.');
+  return new RegExp('^' + re + '$').test(str);
+}
+function convertAddr(ip) {
+  var b = ip.split('.');
+  return ((b[0] << 24) | (b[1] << 16) | (b[2] << 8) | b[3]) >>> 0;
+}
+function isInNet(host, pattern, mask) {
+  var ip = /^\d+\.\d+\.\d+\.\d+$/.test(host) ? host : dnsResolve(host);
+  if (!ip) { return false; }
+  return (convertAddr(ip) & convertAddr(mask)) === (convertAddr(pattern) & convertAddr(mask));
+}
+function weekdayRange() { return true; }
+function dateRange() { return true; }
+function timeRange() { return true; }
+`
@@ -0,0 +1,19 @@
+//go:build !windows && !darwin
+// +build !windows,!darwin
+
+package sysproxy
+
+import (
+	"context"
+	"errors"
+	"net/url"
+)
+
+// systemProxyFor returns no proxy, there are no system wide settings besides the environment variables.
+func systemProxyFor(ctx context.Context, target *url.URL) (*url.URL, error) {
+	return nil, nil
+}
+
+func evaluatePAC(ctx context.Context, pacURL string, target *url.URL) (string, error) {
+	return "", errors.New("PAC files are supported on windows and macOS only")
+}
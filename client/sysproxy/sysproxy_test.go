@@ -0,0 +1,138 @@
+package sysproxy
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePACResult(t *testing.T) {
+	testCases := []struct {
+		result      string
+		expected    string
+		expectedErr string
+	}{
+		{result: "PROXY proxy.corp:8080; DIRECT", expected: "http://proxy.corp:8080"},
+		{result: "SOCKS socks.corp:1080", expected: "socks5h://socks.corp:1080"},
+		{result: "SOCKS4 old.corp:1080; PROXY proxy.corp:3128", expected: "http://proxy.corp:3128"},
+		{result: "DIRECT"},
+		{result: ""},
+		{result: "SOCKS4 old.corp:1080", expectedErr: `no supported proxy in "SOCKS4 old.corp:1080"`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.result, func(t *testing.T) {
+			proxyURL, err := ParsePACResult(tc.result)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			if tc.expected == "" {
+				assert.Nil(t, proxyURL)
+			} else {
+				assert.Equal(t, tc.expected, proxyURL.String())
+			}
+		})
+	}
+}
+
+func TestParseProxyList(t *testing.T) {
+	assert.Equal(t, "http://b:2", parseProxyList("http=a:1;https=b:2", "https").String())
+	assert.Equal(t, "http://a:1", parseProxyList("http=a:1;https=b:2", "http").String())
+	assert.Equal(t, "http://c:3", parseProxyList("c:3", "https").String())
+	assert.Equal(t, "http://c:3", parseProxyList("ftp=a:1 c:3", "https").String())
+	assert.Nil(t, parseProxyList("ftp=a:1", "https"))
+}
+
+func TestBypassed(t *testing.T) {
+	patterns := []string{"<local>", "*.corp.example.com", "10.0.0.0/8", "internal.net"}
+
+	assert.True(t, bypassed("rport", patterns))
+	assert.True(t, bypassed("server.corp.example.com", patterns))
+	assert.True(t, bypassed("10.1.2.3", patterns))
+	assert.True(t, bypassed("internal.net", patterns))
+	assert.True(t, bypassed("a.internal.net", patterns))
+	assert.False(t, bypassed("rport.example.com", patterns))
+	assert.False(t, bypassed("11.1.2.3", patterns))
+}
+
+func TestHTTPTarget(t *testing.T) {
+	testCases := map[string]string{
+		"wss://rport.example.com:443":  "https://rport.example.com",
+		"ws://rport.example.com:80":    "http://rport.example.com",
+		"wss://rport.example.com:8443": "https://rport.example.com:8443",
+		"ws://rport.example.com:443":   "http://rport.example.com:443",
+	}
+	for target, expected := range testCases {
+		u, err := url.Parse(target)
+		require.NoError(t, err)
+		assert.Equal(t, expected, httpTarget(u).String())
+	}
+}
+
+const scutilOutput = `<dictionary> {
+  ExceptionsList : <array> {
+    0 : *.local
+    1 : 169.254.0.0/16
+  }
+  ExcludeSimpleHostnames : 1
+  HTTPEnable : 1
+  HTTPPort : 3128
+  HTTPProxy : proxy.corp
+  HTTPSEnable : 1
+  HTTPSPort : 3129
+  HTTPSProxy : secure-proxy.corp
+  ProxyAutoConfigEnable : 0
+  ProxyAutoConfigURLString : http://wpad.corp/proxy.pac
+  SOCKSEnable : 0
+}
+`
+
+func TestParseScutilProxy(t *testing.T) {
+	settings := parseScutilProxy(scutilOutput)
+
+	assert.Equal(t, []string{"*.local", "169.254.0.0/16"}, settings.exceptions)
+	assert.Equal(t, "", settings.pacURL())
+	assert.Equal(t, "http://secure-proxy.corp:3129", settings.proxyFor(&url.URL{Scheme: "https", Host: "rport.example.com"}).String())
+	assert.Equal(t, "http://proxy.corp:3128", settings.proxyFor(&url.URL{Scheme: "http", Host: "rport.example.com"}).String())
+	assert.Nil(t, settings.proxyFor(&url.URL{Scheme: "https", Host: "rport"}))
+	assert.Nil(t, settings.proxyFor(&url.URL{Scheme: "https", Host: "rport.local"}))
+
+	settings.values["ProxyAutoConfigEnable"] = "1"
+	assert.Equal(t, "http://wpad.corp/proxy.pac", settings.pacURL())
+}
+
+func TestSystemResolverUsesEnvironment(t *testing.T) {
+	t.Setenv("HTTPS_PROXY", "http://env-proxy:8080")
+	t.Setenv("NO_PROXY", "internal.example.com")
+
+	r := NewResolver("")
+
+	proxyURL, err := r.ProxyFor(context.Background(), &url.URL{Scheme: "wss", Host: "rport.example.com:443"})
+	require.NoError(t, err)
+	require.NotNil(t, proxyURL)
+	assert.Equal(t, "http://env-proxy:8080", proxyURL.String())
+
+	proxyURL, err = r.ProxyFor(context.Background(), &url.URL{Scheme: "wss", Host: "internal.example.com:443"})
+	require.NoError(t, err)
+	assert.Nil(t, proxyURL)
+}
+
+func TestFetchPACFromFile(t *testing.T) {
+	const pac = `function FindProxyForURL(url, host) { return "DIRECT"; }`
+	path := filepath.Join(t.TempDir(), "proxy.pac")
+	require.NoError(t, os.WriteFile(path, []byte(pac), 0600))
+
+	content, err := fetchPAC(context.Background(), path)
+	require.NoError(t, err)
+	assert.Equal(t, pac, content)
+
+	content, err = fetchPAC(context.Background(), "file://"+path)
+	require.NoError(t, err)
+	assert.Equal(t, pac, content)
+}
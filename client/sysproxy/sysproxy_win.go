@@ -0,0 +1,163 @@
+//go:build windows
+// +build windows
+
+package sysproxy
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	winHTTPAccessTypeNoProxy    = 1
+	winHTTPAccessTypeNamedProxy = 3
+
+	winHTTPAutoProxyAutoDetect = 0x00000001
+	winHTTPAutoProxyConfigURL  = 0x00000002
+
+	winHTTPAutoDetectTypeDHCP = 0x00000001
+	winHTTPAutoDetectTypeDNSA = 0x00000002
+)
+
+var (
+	winhttp                                   = windows.NewLazySystemDLL("winhttp.dll")
+	procWinHTTPOpen                           = winhttp.NewProc("WinHttpOpen")
+	procWinHTTPCloseHandle                    = winhttp.NewProc("WinHttpCloseHandle")
+	procWinHTTPGetIEProxyConfigForCurrentUser = winhttp.NewProc("WinHttpGetIEProxyConfigForCurrentUser")
+	procWinHTTPGetDefaultProxyConfiguration   = winhttp.NewProc("WinHttpGetDefaultProxyConfiguration")
+	procWinHTTPGetProxyForURL                 = winhttp.NewProc("WinHttpGetProxyForUrl")
+
+	kernel32       = windows.NewLazySystemDLL("kernel32.dll")
+	procGlobalFree = kernel32.NewProc("GlobalFree")
+)
+
+type winHTTPCurrentUserIEProxyConfig struct {
+	autoDetect    int32
+	autoConfigURL *uint16
+	proxy         *uint16
+	proxyBypass   *uint16
+}
+
+type winHTTPAutoProxyOptions struct {
+	flags                 uint32
+	autoDetectFlags       uint32
+	autoConfigURL         *uint16
+	reserved1             uintptr
+	reserved2             uint32
+	autoLogonIfChallenged int32
+}
+
+type winHTTPProxyInfo struct {
+	accessType  uint32
+	proxy       *uint16
+	proxyBypass *uint16
+}
+
+// systemProxyFor uses the internet settings of the user, including auto detection and PAC files, and falls back to
+// the WinHTTP settings of the machine (netsh winhttp set proxy), which apply to services running as LocalSystem.
+func systemProxyFor(ctx context.Context, target *url.URL) (*url.URL, error) {
+	ieConfig := winHTTPCurrentUserIEProxyConfig{}
+	if r, _, _ := procWinHTTPGetIEProxyConfigForCurrentUser.Call(uintptr(unsafe.Pointer(&ieConfig))); r != 0 {
+		autoConfigURL := takeString(ieConfig.autoConfigURL)
+		proxyList := takeString(ieConfig.proxy)
+		bypass := takeString(ieConfig.proxyBypass)
+
+		if ieConfig.autoDetect != 0 || autoConfigURL != "" {
+			proxyURL, err := winHTTPProxyForURL(target, autoConfigURL, ieConfig.autoDetect != 0)
+			if err == nil {
+				return proxyURL, nil
+			}
+			// as browsers do, the static settings apply if auto configuration fails
+		}
+		if proxyList != "" {
+			if bypassed(target.Hostname(), strings.Split(bypass, ";")) {
+				return nil, nil
+			}
+			return parseProxyList(proxyList, target.Scheme), nil
+		}
+	}
+
+	info := winHTTPProxyInfo{}
+	if r, _, err := procWinHTTPGetDefaultProxyConfiguration.Call(uintptr(unsafe.Pointer(&info))); r == 0 {
+		return nil, fmt.Errorf("WinHttpGetDefaultProxyConfiguration: %v", err)
+	}
+	return proxyInfoToURL(info, target), nil
+}
+
+func evaluatePAC(ctx context.Context, pacURL string, target *url.URL) (string, error) {
+	u, err := url.Parse(pacURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", fmt.Errorf("only http and https PAC urls are supported on windows")
+	}
+	proxyURL, err := winHTTPProxyForURL(target, pacURL, false)
+	if err != nil {
+		return "", err
+	}
+	if proxyURL == nil {
+		return "DIRECT", nil
+	}
+	return "PROXY " + proxyURL.Host, nil
+}
+
+func winHTTPProxyForURL(target *url.URL, autoConfigURL string, autoDetect bool) (*url.URL, error) {
+	agent, err := windows.UTF16PtrFromString("rport")
+	if err != nil {
+		return nil, err
+	}
+	session, _, err := procWinHTTPOpen.Call(uintptr(unsafe.Pointer(agent)), winHTTPAccessTypeNoProxy, 0, 0, 0)
+	if session == 0 {
+		return nil, fmt.Errorf("WinHttpOpen: %v", err)
+	}
+	defer procWinHTTPCloseHandle.Call(session) //nolint:errcheck
+
+	opts := winHTTPAutoProxyOptions{autoLogonIfChallenged: 1}
+	if autoConfigURL != "" {
+		opts.flags |= winHTTPAutoProxyConfigURL
+		opts.autoConfigURL, err = windows.UTF16PtrFromString(autoConfigURL)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if autoDetect {
+		opts.flags |= winHTTPAutoProxyAutoDetect
+		opts.autoDetectFlags = winHTTPAutoDetectTypeDHCP | winHTTPAutoDetectTypeDNSA
+	}
+
+	targetStr, err := windows.UTF16PtrFromString(target.String())
+	if err != nil {
+		return nil, err
+	}
+	info := winHTTPProxyInfo{}
+	r, _, err := procWinHTTPGetProxyForURL.Call(session, uintptr(unsafe.Pointer(targetStr)), uintptr(unsafe.Pointer(&opts)), uintptr(unsafe.Pointer(&info)))
+	if r == 0 {
+		return nil, fmt.Errorf("WinHttpGetProxyForUrl: %v", err)
+	}
+	return proxyInfoToURL(info, target), nil
+}
+
+func proxyInfoToURL(info winHTTPProxyInfo, target *url.URL) *url.URL {
+	proxyList := takeString(info.proxy)
+	bypass := takeString(info.proxyBypass)
+	if info.accessType != winHTTPAccessTypeNamedProxy || proxyList == "" {
+		return nil
+	}
+	if bypassed(target.Hostname(), strings.Split(bypass, ";")) {
+		return nil
+	}
+	return parseProxyList(proxyList, target.Scheme)
+}
+
+// takeString converts and frees a string allocated by WinHTTP.
+func takeString(p *uint16) string {
+	if p == nil {
+		return ""
+	}
+	s := windows.UTF16PtrToString(p)
+	procGlobalFree.Call(uintptr(unsafe.Pointer(p))) //nolint:errcheck
+	return s
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"os/exec"
 
+	"github.com/IOTech17/neo-rport/share/clientconfig"
 	"github.com/IOTech17/neo-rport/share/logger"
 )
 
@@ -23,16 +24,25 @@ type CmdExecutor interface {
 
 type CmdExecutorImpl struct {
 	*logger.Logger
+	limits clientconfig.ResourceLimitsConfig
 }
 
-func NewCmdExecutor(l *logger.Logger) *CmdExecutorImpl {
+func NewCmdExecutor(l *logger.Logger, limits clientconfig.ResourceLimitsConfig) *CmdExecutorImpl {
 	return &CmdExecutorImpl{
 		Logger: l,
+		limits: limits,
 	}
 }
 
+// Start starts the command and applies the resource limits right after. A command is not stopped if that fails.
 func (e *CmdExecutorImpl) Start(cmd *exec.Cmd) error {
-	return cmd.Start()
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	if err := applyLimits(cmd.Process.Pid, e.limits); err != nil {
+		e.Errorf("Failed to apply resource limits to %q: %v", cmd.Path, err)
+	}
+	return nil
 }
 
 func (e *CmdExecutorImpl) Wait(cmd *exec.Cmd) error {
@@ -5,6 +5,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/IOTech17/neo-rport/share/clientconfig"
 	"github.com/IOTech17/neo-rport/share/logger"
 
 	"github.com/stretchr/testify/assert"
@@ -23,7 +24,7 @@ type cmdBuildTestCase struct {
 func TestBuildCmd(t *testing.T) {
 	var testLog = logger.NewLogger("client-system", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
 
-	cmdExecutor := NewCmdExecutor(testLog)
+	cmdExecutor := NewCmdExecutor(testLog, clientconfig.ResourceLimitsConfig{})
 	for _, tc := range getCmdBuildTestcases() {
 		t.Run(tc.name, func(t *testing.T) {
 			interpreter := Interpreter{
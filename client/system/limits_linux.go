@@ -0,0 +1,65 @@
+//go:build linux
+// +build linux
+
+package system
+
+import (
+	"fmt"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/IOTech17/neo-rport/share/clientconfig"
+)
+
+const (
+	ioprioWhoProcess      = 1
+	ioprioClassShift      = 13
+	ioprioClassBestEffort = 2
+	ioprioClassIdle       = 3
+	// ioprioLowestLevel is the lowest priority within the best-effort class
+	ioprioLowestLevel = 7
+)
+
+func applyLimits(pid int, limits clientconfig.ResourceLimitsConfig) error {
+	if err := applyNice(pid, limits.ExecNice); err != nil {
+		return err
+	}
+
+	var ioprio int
+	switch limits.ExecIOClass {
+	case clientconfig.IOClassBestEffort:
+		ioprio = ioprioClassBestEffort<<ioprioClassShift | ioprioLowestLevel
+	case clientconfig.IOClassIdle:
+		ioprio = ioprioClassIdle << ioprioClassShift
+	}
+	if ioprio != 0 {
+		if _, _, errno := unix.Syscall(unix.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(pid), uintptr(ioprio)); errno != 0 {
+			return fmt.Errorf("failed to set io class %q: %v", limits.ExecIOClass, errno)
+		}
+	}
+
+	if limits.ExecMaxCPUSeconds > 0 {
+		cpuSeconds := uint64(limits.ExecMaxCPUSeconds)
+		if err := unix.Prlimit(pid, unix.RLIMIT_CPU, &unix.Rlimit{Cur: cpuSeconds, Max: cpuSeconds}, nil); err != nil {
+			return fmt.Errorf("failed to limit cpu time: %v", err)
+		}
+	}
+	if limits.ExecMaxMemoryMB > 0 {
+		memoryBytes := uint64(limits.ExecMaxMemoryMB) * 1024 * 1024
+		if err := unix.Prlimit(pid, unix.RLIMIT_AS, &unix.Rlimit{Cur: memoryBytes, Max: memoryBytes}, nil); err != nil {
+			return fmt.Errorf("failed to limit memory: %v", err)
+		}
+	}
+	return nil
+}
+
+func applyNice(pid int, nice int) error {
+	if nice == 0 {
+		return nil
+	}
+	if err := syscall.Setpriority(syscall.PRIO_PROCESS, pid, nice); err != nil {
+		return fmt.Errorf("failed to set nice %d: %v", nice, err)
+	}
+	return nil
+}
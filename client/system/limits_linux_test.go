@@ -0,0 +1,49 @@
+//go:build linux
+// +build linux
+
+package system
+
+import (
+	"os/exec"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+
+	"github.com/IOTech17/neo-rport/share/clientconfig"
+)
+
+func TestApplyLimits(t *testing.T) {
+	cmd := exec.Command("sleep", "5")
+	require.NoError(t, cmd.Start())
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+	pid := cmd.Process.Pid
+
+	err := applyLimits(pid, clientconfig.ResourceLimitsConfig{
+		ExecNice:          10,
+		ExecIOClass:       clientconfig.IOClassIdle,
+		ExecMaxCPUSeconds: 30,
+		ExecMaxMemoryMB:   512,
+	})
+	require.NoError(t, err)
+
+	// the kernel returns 20 - nice
+	prio, err := syscall.Getpriority(syscall.PRIO_PROCESS, pid)
+	require.NoError(t, err)
+	assert.Equal(t, 10, 20-prio)
+
+	ioprio, _, errno := unix.Syscall(unix.SYS_IOPRIO_GET, ioprioWhoProcess, uintptr(pid), 0)
+	require.Zero(t, errno)
+	assert.EqualValues(t, ioprioClassIdle, ioprio>>ioprioClassShift)
+
+	var rlimit unix.Rlimit
+	require.NoError(t, unix.Prlimit(pid, unix.RLIMIT_CPU, nil, &rlimit))
+	assert.EqualValues(t, 30, rlimit.Cur)
+	require.NoError(t, unix.Prlimit(pid, unix.RLIMIT_AS, nil, &rlimit))
+	assert.EqualValues(t, 512*1024*1024, rlimit.Cur)
+}
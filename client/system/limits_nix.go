@@ -0,0 +1,22 @@
+//go:build !windows && !linux
+// +build !windows,!linux
+
+package system
+
+import (
+	"fmt"
+	"syscall"
+
+	"github.com/IOTech17/neo-rport/share/clientconfig"
+)
+
+// applyLimits sets the niceness only, the other limits are rejected on validation of the config.
+func applyLimits(pid int, limits clientconfig.ResourceLimitsConfig) error {
+	if limits.ExecNice == 0 {
+		return nil
+	}
+	if err := syscall.Setpriority(syscall.PRIO_PROCESS, pid, limits.ExecNice); err != nil {
+		return fmt.Errorf("failed to set nice %d: %v", limits.ExecNice, err)
+	}
+	return nil
+}
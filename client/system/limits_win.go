@@ -0,0 +1,33 @@
+//go:build windows
+// +build windows
+
+package system
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows"
+
+	"github.com/IOTech17/neo-rport/share/clientconfig"
+)
+
+// applyLimits maps the niceness to a priority class, the other limits are rejected on validation of the config.
+func applyLimits(pid int, limits clientconfig.ResourceLimitsConfig) error {
+	if limits.ExecNice == 0 {
+		return nil
+	}
+	priorityClass := uint32(windows.BELOW_NORMAL_PRIORITY_CLASS)
+	if limits.ExecNice >= 10 {
+		priorityClass = windows.IDLE_PRIORITY_CLASS
+	}
+
+	h, err := windows.OpenProcess(windows.PROCESS_SET_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return fmt.Errorf("failed to open process: %v", err)
+	}
+	defer windows.CloseHandle(h)
+	if err := windows.SetPriorityClass(h, priorityClass); err != nil {
+		return fmt.Errorf("failed to set priority class: %v", err)
+	}
+	return nil
+}
@@ -39,6 +39,12 @@ type UploadManager struct {
 	OptionsProvider    OptionsProvider
 	SourceFileProvider SourceFileProvider
 	SysUserLookup      system.SysUserLookup
+	// HookRunner runs the commands declared by file pushes, they fail if it's nil
+	HookRunner HookRunner
+	// artifacts caches the files pushed from the artifact repository of the server
+	artifacts *artifactCache
+	// changes holds the files of two-phase file pushes
+	changes *fileChanges
 }
 
 type SSHFileProvider struct {
@@ -54,6 +60,15 @@ func (ss *SftpSession) Read(p []byte) (n int, err error) {
 	return ss.RemoteFile.Read(p)
 }
 
+// ReadAt lets artifacts be downloaded in chunks.
+func (ss *SftpSession) ReadAt(p []byte, off int64) (n int, err error) {
+	ra, ok := ss.RemoteFile.(io.ReaderAt)
+	if !ok {
+		return 0, errors.New("remote file doesn't support reading at an offset")
+	}
+	return ra.ReadAt(p, off)
+}
+
 func (ss *SftpSession) Close() error {
 	errs := make([]string, 0, 2)
 
@@ -99,6 +114,9 @@ func NewSSHUploadManager(
 	optionsProvider OptionsProvider,
 	sshConn ssh.Conn,
 	sysUserLookup system.SysUserLookup,
+	artifacts *artifactCache,
+	hookRunner HookRunner,
+	changes *fileChanges,
 ) *UploadManager {
 	sshFileProvider := &SSHFileProvider{
 		sshConn: sshConn,
@@ -109,6 +127,9 @@ func NewSSHUploadManager(
 		OptionsProvider:    optionsProvider,
 		SourceFileProvider: sshFileProvider,
 		SysUserLookup:      sysUserLookup,
+		HookRunner:         hookRunner,
+		artifacts:          artifacts,
+		changes:            changes,
 	}
 }
 
@@ -219,11 +240,7 @@ func (um *UploadManager) fileShouldBeSynched(uploadedFile *models.UploadedFile)
 }
 
 func (um *UploadManager) handleWritingFile(uploadedFile *models.UploadedFile) (resp *models.UploadResponse, err error) {
-	copiedBytes, tempFilePath, err := um.copyFileToTempLocation(
-		uploadedFile.SourceFilePath,
-		uploadedFile.DestinationFileMode,
-		uploadedFile.Md5Checksum,
-	)
+	copiedBytes, tempFilePath, err := um.copyFileToTempLocation(uploadedFile)
 	if err != nil {
 		return nil, err
 	}
@@ -240,13 +257,40 @@ func (um *UploadManager) handleWritingFile(uploadedFile *models.UploadedFile) (r
 		msgParts = append(msgParts, fmt.Sprintf("chown of %s failed: %v", tempFilePath, err))
 	}
 
-	err = um.moveFileToDestination(tempFilePath, uploadedFile)
+	err = um.runHook("pre", uploadedFile.PreCommand, uploadedFile)
+	if err != nil {
+		um.removeTempFile(tempFilePath)
+		return nil, err
+	}
+
+	if uploadedFile.Staged {
+		err = um.stageFile(tempFilePath, uploadedFile)
+		if err != nil {
+			return nil, err
+		}
+		return &models.UploadResponse{
+			UploadResponseShort: models.UploadResponseShort{
+				ID:        uploadedFile.ID,
+				Filepath:  uploadedFile.DestinationPath,
+				SizeBytes: copiedBytes,
+			},
+			Status:  "staged",
+			Message: "file staged for " + uploadedFile.DestinationPath,
+		}, nil
+	}
+
+	err = um.writeValidatedFile(tempFilePath, uploadedFile)
 	if err != nil {
 		return nil, err
 	}
 	um.Logger.Debugf("copied remote file %s to the temp path %s", uploadedFile.SourceFilePath, tempFilePath)
 	msgParts = append(msgParts, "file successfully copied to destination "+uploadedFile.DestinationPath)
 
+	err = um.runHook("post", uploadedFile.PostCommand, uploadedFile)
+	if err != nil {
+		return nil, err
+	}
+
 	message := strings.Join(msgParts, ".")
 	um.Logger.Debugf(message)
 
@@ -371,11 +415,14 @@ func (um *UploadManager) prepareDestinationDir(destinationPath string, mode os.F
 	return nil
 }
 
-func (um *UploadManager) copyFileToTempLocation(remoteFilePath string, targetFileMode os.FileMode, expectedMd5Checksum []byte) (
+func (um *UploadManager) copyFileToTempLocation(uploadedFile *models.UploadedFile) (
 	bytesCopied int64,
 	tempFilePath string,
 	err error,
 ) {
+	remoteFilePath := uploadedFile.SourceFilePath
+	targetFileMode := uploadedFile.DestinationFileMode
+	expectedMd5Checksum := uploadedFile.Md5Checksum
 	tempFileName := filepath.Base(remoteFilePath)
 
 	if targetFileMode == 0 {
@@ -405,7 +452,7 @@ func (um *UploadManager) copyFileToTempLocation(remoteFilePath string, targetFil
 		}
 	}
 
-	remoteFile, err := um.SourceFileProvider.Open(remoteFilePath)
+	remoteFile, err := um.openSourceFile(uploadedFile)
 	if err != nil {
 		return 0, tempFilePath, err
 	}
@@ -439,6 +486,31 @@ func (um *UploadManager) copyFileToTempLocation(remoteFilePath string, targetFil
 	return copiedBytes, tempFilePath, nil
 }
 
+// openSourceFile opens the file on the server, the cached content if the file is an artifact, or the changed blocks
+// patched on the file at the destination if the server provides a delta signature.
+func (um *UploadManager) openSourceFile(uploadedFile *models.UploadedFile) (io.ReadCloser, error) {
+	remoteFilePath := uploadedFile.SourceFilePath
+	artifact := uploadedFile.Artifact
+	if artifact == nil || um.artifacts == nil {
+		if bp, ok := um.SourceFileProvider.(BlockProvider); ok && uploadedFile.DeltaSignaturePath != "" {
+			patched, err := um.openDelta(uploadedFile, bp)
+			if err == nil {
+				return patched, nil
+			}
+			um.Logger.Infof("failed to fetch the changed blocks of %s, downloading the whole file: %v", remoteFilePath, err)
+		}
+		return um.SourceFileProvider.Open(remoteFilePath)
+	}
+
+	cachedPath, downloaded, err := um.artifacts.Get(artifact, remoteFilePath, um.SourceFileProvider)
+	if err != nil {
+		return nil, err
+	}
+	um.Logger.Debugf("artifact %s@%s cached at %s, downloaded %d of %d bytes", artifact.Name, artifact.Version, cachedPath, downloaded, artifact.SizeBytes)
+
+	return os.Open(cachedPath)
+}
+
 func (um *UploadManager) getUploadedFile(reqPayload []byte) (*models.UploadedFile, error) {
 	uploadedFile := new(models.UploadedFile)
 	err := uploadedFile.FromBytes(reqPayload)
@@ -0,0 +1,122 @@
+package chclient
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/files"
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+// BlockProvider fetches ranges of a pushed file from the server.
+type BlockProvider interface {
+	OpenBlocks(id string, ranges []files.Range) (io.ReadCloser, error)
+}
+
+type fileBlocksStream struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (s *fileBlocksStream) Close() error {
+	errs := make([]string, 0, len(s.closers))
+	for _, c := range s.closers {
+		if err := c.Close(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(errs, ", "))
+}
+
+// OpenBlocks streams the ranges of the file push compressed on a file blocks channel.
+func (sfp SSHFileProvider) OpenBlocks(id string, ranges []files.Range) (io.ReadCloser, error) {
+	ch, reqs, err := sfp.sshConn.OpenChannel(comm.ChannelFileBlocks, []byte(id))
+	if err != nil {
+		return nil, err
+	}
+	go ssh.DiscardRequests(reqs)
+
+	err = json.NewEncoder(ch).Encode(comm.FileBlocksRequest{
+		Ranges:      ranges,
+		Compression: comm.FileBlocksCompressionDeflate,
+	})
+	if err == nil {
+		err = ch.CloseWrite()
+	}
+	if err != nil {
+		ch.Close()
+		return nil, err
+	}
+
+	fr := flate.NewReader(ch)
+	return &fileBlocksStream{Reader: fr, closers: []io.Closer{fr, ch}}, nil
+}
+
+// openDelta reads the pushed file from the blocks of the file at the destination the pushed file has as well, and
+// the other blocks fetched from the server.
+func (um *UploadManager) openDelta(uploadedFile *models.UploadedFile, bp BlockProvider) (io.ReadCloser, error) {
+	sigFile, err := um.SourceFileProvider.Open(uploadedFile.DeltaSignaturePath)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := files.ReadSignature(sigFile)
+	sigFile.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	matches := map[int]int64{}
+	var local io.ReaderAt = bytes.NewReader(nil)
+	closers := []io.Closer{}
+	exists, err := um.FilesAPI.Exist(uploadedFile.DestinationPath)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		destination, err := um.FilesAPI.Open(uploadedFile.DestinationPath)
+		if err != nil {
+			return nil, err
+		}
+		closers = append(closers, destination)
+		if ra, ok := destination.(io.ReaderAt); ok {
+			matches, err = sig.MatchBlocks(destination)
+			if err != nil {
+				destination.Close()
+				return nil, err
+			}
+			local = ra
+		}
+	}
+
+	ranges := sig.MissingRanges(matches)
+	var missing io.Reader = bytes.NewReader(nil)
+	if len(ranges) > 0 {
+		blocks, err := bp.OpenBlocks(uploadedFile.ID, ranges)
+		if err != nil {
+			for _, c := range closers {
+				c.Close()
+			}
+			return nil, err
+		}
+		closers = append(closers, blocks)
+		missing = blocks
+	}
+
+	var fetched int64
+	for _, r := range ranges {
+		fetched += r.Length
+	}
+	um.Logger.Debugf("fetching %d of %d bytes of %s, %d blocks found at the destination", fetched, sig.Size, uploadedFile.SourceFilePath, len(matches))
+
+	return &fileBlocksStream{Reader: files.NewPatchReader(sig, matches, local, missing), closers: closers}, nil
+}
@@ -0,0 +1,90 @@
+package chclient
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/share/files"
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+// deltaServer serves a pushed file and its signature
+type deltaServer struct {
+	content   []byte
+	signature []byte
+	requested []files.Range
+}
+
+func (s *deltaServer) Open(path string) (io.ReadCloser, error) {
+	if path == "push.sig" {
+		return io.NopCloser(bytes.NewReader(s.signature)), nil
+	}
+	return io.NopCloser(bytes.NewReader(s.content)), nil
+}
+
+func (s *deltaServer) OpenBlocks(id string, ranges []files.Range) (io.ReadCloser, error) {
+	s.requested = append(s.requested, ranges...)
+	buf := &bytes.Buffer{}
+	for _, r := range ranges {
+		buf.Write(s.content[r.Offset : r.Offset+r.Length])
+	}
+	return io.NopCloser(buf), nil
+}
+
+func newDeltaServer(t *testing.T, content []byte) *deltaServer {
+	sig, err := files.NewSignature(bytes.NewReader(content), 2048)
+	require.NoError(t, err)
+	buf := &bytes.Buffer{}
+	_, err = sig.WriteTo(buf)
+	require.NoError(t, err)
+	return &deltaServer{content: content, signature: buf.Bytes()}
+}
+
+func TestOpenDelta(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	old := make([]byte, 64*1024)
+	rnd.Read(old)
+	updated := append([]byte(nil), old...)
+	copy(updated[10000:], "changed")
+	server := newDeltaServer(t, updated)
+
+	destination := filepath.Join(t.TempDir(), "config.bin")
+	require.NoError(t, os.WriteFile(destination, old, 0600))
+	um := &UploadManager{
+		Logger:             testLog,
+		FilesAPI:           files.NewFileSystem(),
+		SourceFileProvider: server,
+	}
+	uploadedFile := &models.UploadedFile{
+		ID:                 "push-1",
+		SourceFilePath:     "push",
+		DestinationPath:    destination,
+		DeltaSignaturePath: "push.sig",
+	}
+
+	patched, err := um.openSourceFile(uploadedFile)
+	require.NoError(t, err)
+	content, err := io.ReadAll(patched)
+	require.NoError(t, err)
+	require.NoError(t, patched.Close())
+	assert.Equal(t, updated, content)
+	// only the changed block
+	assert.Equal(t, []files.Range{{Offset: 8192, Length: 2048}}, server.requested)
+
+	// without a file at the destination the whole file is fetched
+	server.requested = nil
+	uploadedFile.DestinationPath = filepath.Join(t.TempDir(), "new.bin")
+	patched, err = um.openSourceFile(uploadedFile)
+	require.NoError(t, err)
+	content, err = io.ReadAll(patched)
+	require.NoError(t, err)
+	assert.Equal(t, updated, content)
+	assert.Equal(t, []files.Range{{Offset: 0, Length: int64(len(updated))}}, server.requested)
+}
@@ -0,0 +1,160 @@
+package chclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/IOTech17/neo-rport/client/system"
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+// filePushHookTimeout limits the commands run around a file push, the server waits for them to answer the push
+const filePushHookTimeout = 2 * time.Minute
+
+const backupFileSuffix = "_rport_backup"
+
+// HookRunner runs the commands declared by a file push.
+type HookRunner interface {
+	RunFilePushHook(command, workingDir string) error
+}
+
+// RunFilePushHook runs the command with the default interpreter the same way remote commands are run, so the same
+// restrictions apply. The output is returned in the error if the command fails.
+func (c *Client) RunFilePushHook(command, workingDir string) error {
+	if !c.configHolder.RemoteCommands.Enabled {
+		return errors.New("remote commands execution is disabled")
+	}
+	if !c.isAllowed(command) {
+		return fmt.Errorf("command is not allowed: %v", command)
+	}
+
+	interpreter := system.Interpreter{
+		InterpreterAliases: c.configHolder.InterpreterAliases,
+	}
+	scriptPath, err := system.CreateScriptFile(c.configHolder.GetScriptsDir(), command, interpreter, nil)
+	if err != nil {
+		return err
+	}
+	defer c.rmScript(scriptPath)
+
+	ctx, cancel := context.WithTimeout(context.Background(), filePushHookTimeout)
+	defer cancel()
+	cmd := c.cmdExec.New(ctx, &system.CmdExecutorContext{
+		Interpreter: interpreter,
+		Command:     scriptPath,
+		WorkingDir:  workingDir,
+		HasShebang:  system.HasShebangLine(command),
+	})
+	output := &CapacityBuffer{capacity: c.configHolder.RemoteCommands.SendBackLimit}
+	cmd.Stdout = output
+	cmd.Stderr = output
+
+	c.Debugf("running file push hook: %s", command)
+	if err := c.cmdExec.Start(cmd); err != nil {
+		return fmt.Errorf("failed to start a command: %s", err)
+	}
+	err = c.cmdExec.Wait(cmd)
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timeout of %s exceeded", filePushHookTimeout)
+	}
+	if err != nil {
+		if out := strings.TrimSpace(output.String()); out != "" {
+			return fmt.Errorf("%v: %s", err, out)
+		}
+		return err
+	}
+	return nil
+}
+
+func (um *UploadManager) runHook(name, command string, uploadedFile *models.UploadedFile) error {
+	if command == "" {
+		return nil
+	}
+	if um.HookRunner == nil {
+		return fmt.Errorf("%s command failed: commands can't be run", name)
+	}
+	err := um.HookRunner.RunFilePushHook(command, filepath.Dir(uploadedFile.DestinationPath))
+	if err != nil {
+		return fmt.Errorf("%s command failed: %v", name, err)
+	}
+	um.Logger.Debugf("%s command of %s succeeded: %s", name, uploadedFile.DestinationPath, command)
+	return nil
+}
+
+// backupDestination moves the file at the destination to the upload dir, so it can be restored if the validation of
+// the pushed file fails. The returned path is empty if there is no file at the destination.
+func (um *UploadManager) backupDestination(uploadedFile *models.UploadedFile) (string, error) {
+	exists, err := um.FilesAPI.Exist(uploadedFile.DestinationPath)
+	if err != nil || !exists {
+		return "", err
+	}
+
+	backupPath := filepath.Join(um.OptionsProvider.GetUploadDir(), filepath.Base(uploadedFile.ID)+backupFileSuffix)
+	if err := um.FilesAPI.Rename(uploadedFile.DestinationPath, backupPath); err != nil {
+		return "", fmt.Errorf("failed to back up %s: %v", uploadedFile.DestinationPath, err)
+	}
+	um.Logger.Debugf("moved %s to %s until the pushed file is validated", uploadedFile.DestinationPath, backupPath)
+	return backupPath, nil
+}
+
+// rollback replaces the pushed file with the backup of the previous version, or removes it if there was none.
+func (um *UploadManager) rollback(uploadedFile *models.UploadedFile, backupPath string) error {
+	exists, err := um.FilesAPI.Exist(uploadedFile.DestinationPath)
+	if err != nil {
+		return err
+	}
+	if exists {
+		if err := um.FilesAPI.Remove(uploadedFile.DestinationPath); err != nil {
+			return err
+		}
+	}
+	if backupPath == "" {
+		return nil
+	}
+	return um.FilesAPI.Rename(backupPath, uploadedFile.DestinationPath)
+}
+
+// writeValidatedFile moves the file to the destination and runs the validation command, the previous version of the
+// file is restored if the validation fails.
+func (um *UploadManager) writeValidatedFile(tempFilePath string, uploadedFile *models.UploadedFile) error {
+	if uploadedFile.ValidateCommand == "" {
+		return um.moveFileToDestination(tempFilePath, uploadedFile)
+	}
+
+	backupPath, err := um.backupDestination(uploadedFile)
+	if err != nil {
+		um.removeTempFile(tempFilePath)
+		return err
+	}
+
+	err = um.moveFileToDestination(tempFilePath, uploadedFile)
+	if err == nil {
+		err = um.runHook("validate", uploadedFile.ValidateCommand, uploadedFile)
+	}
+	if err != nil {
+		if rbErr := um.rollback(uploadedFile, backupPath); rbErr != nil {
+			return fmt.Errorf("%v, failed to restore the previous version of %s: %v", err, uploadedFile.DestinationPath, rbErr)
+		}
+		if backupPath == "" {
+			return fmt.Errorf("%v, the pushed file was removed", err)
+		}
+		return fmt.Errorf("%v, the previous version of %s was restored", err, uploadedFile.DestinationPath)
+	}
+
+	if backupPath != "" {
+		if err := um.FilesAPI.Remove(backupPath); err != nil {
+			um.Logger.Errorf("failed to remove %s: %v", backupPath, err)
+		}
+	}
+	return nil
+}
+
+func (um *UploadManager) removeTempFile(tempFilePath string) {
+	if err := um.FilesAPI.Remove(tempFilePath); err != nil {
+		um.Logger.Errorf("failed to remove %s: %v", tempFilePath, err)
+	}
+}
@@ -0,0 +1,143 @@
+package chclient
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/share/files"
+	"github.com/IOTech17/neo-rport/share/models"
+	"github.com/IOTech17/neo-rport/share/test"
+)
+
+type hookRunnerMock struct {
+	// failing commands and the content of the destination when they run
+	failing map[string]bool
+	seen    map[string]string
+}
+
+func (h *hookRunnerMock) RunFilePushHook(command, workingDir string) error {
+	content, _ := os.ReadFile(filepath.Join(workingDir, "nginx.conf"))
+	h.seen[command] = string(content)
+	if h.failing[command] {
+		return errors.New("exit status 1: syntax error")
+	}
+	return nil
+}
+
+type contentProvider []byte
+
+func (p contentProvider) Open(path string) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(p)), nil
+}
+
+func TestHandleUploadRequestHooks(t *testing.T) {
+	testCases := []struct {
+		name        string
+		failing     string
+		noPrevious  bool
+		wantErr     string
+		wantContent string
+		wantExists  bool
+	}{
+		{
+			name:        "validated",
+			wantContent: "new",
+			wantExists:  true,
+		},
+		{
+			name:        "pre command fails",
+			failing:     "pre",
+			wantErr:     "pre command failed: exit status 1: syntax error",
+			wantContent: "old",
+			wantExists:  true,
+		},
+		{
+			name:        "validation fails",
+			failing:     "nginx -t",
+			wantErr:     "validate command failed: exit status 1: syntax error, the previous version of <dest> was restored",
+			wantContent: "old",
+			wantExists:  true,
+		},
+		{
+			name:       "validation fails without previous version",
+			failing:    "nginx -t",
+			noPrevious: true,
+			wantErr:    "validate command failed: exit status 1: syntax error, the pushed file was removed",
+		},
+		{
+			name:        "post command fails",
+			failing:     "post",
+			wantErr:     "post command failed: exit status 1: syntax error",
+			wantContent: "new",
+			wantExists:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			uploadDir := filepath.Join(dir, "upload")
+			destination := filepath.Join(dir, "nginx.conf")
+			if !tc.noPrevious {
+				require.NoError(t, os.WriteFile(destination, []byte("old"), 0600))
+			}
+
+			optionsProvMock := &UploadOptionsProviderMock{}
+			optionsProvMock.On("GetUploadDir").Return(uploadDir)
+			optionsProvMock.On("GetProtectedUploadDirs").Return([]string{})
+			optionsProvMock.On("IsFileReceptionEnabled").Return(true)
+			hooks := &hookRunnerMock{failing: map[string]bool{tc.failing: true}, seen: map[string]string{}}
+			um := &UploadManager{
+				Logger:             testLog,
+				FilesAPI:           files.NewFileSystem(),
+				OptionsProvider:    optionsProvMock,
+				SourceFileProvider: contentProvider("new"),
+				HookRunner:         hooks,
+			}
+			uploadedFile := &models.UploadedFile{
+				ID:              "push-1",
+				SourceFilePath:  "/data/push-1",
+				DestinationPath: destination,
+				ForceWrite:      true,
+				Md5Checksum:     test.Md5Hash("new"),
+				PreCommand:      "pre",
+				ValidateCommand: "nginx -t",
+				PostCommand:     "post",
+			}
+			payload, err := uploadedFile.ToBytes()
+			require.NoError(t, err)
+
+			resp, err := um.HandleUploadRequest(payload)
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+				assert.Equal(t, "success", resp.Status)
+			} else {
+				assert.EqualError(t, err, strings.ReplaceAll(tc.wantErr, "<dest>", destination))
+			}
+
+			content, err := os.ReadFile(destination)
+			if tc.wantExists {
+				require.NoError(t, err)
+				assert.Equal(t, tc.wantContent, string(content))
+			} else {
+				assert.True(t, os.IsNotExist(err))
+			}
+			if tc.failing != "pre" {
+				// the validation sees the pushed file
+				assert.Equal(t, "new", hooks.seen["nginx -t"])
+			}
+			leftovers, err := os.ReadDir(uploadDir)
+			require.NoError(t, err)
+			assert.Empty(t, leftovers)
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package chclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+// stagedFileChangeTTL is how long a staged file waits for the activation before it's discarded
+const stagedFileChangeTTL = time.Hour
+
+type fileChange struct {
+	file       *models.UploadedFile
+	stagedPath string
+	// backupPath is the previous version of the file, empty if there was none or the change isn't activated yet
+	backupPath string
+	activated  bool
+	timer      *time.Timer
+}
+
+// fileChanges holds the files of two-phase file pushes from staging until the change is confirmed or rolled back.
+// It outlives the connection, so clients losing the connection because of a change still roll it back.
+type fileChanges struct {
+	m  map[string]*fileChange
+	mu sync.Mutex
+}
+
+func newFileChanges() *fileChanges {
+	return &fileChanges{
+		m: make(map[string]*fileChange),
+	}
+}
+
+func (fc *fileChanges) put(id string, change *fileChange) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	if prev := fc.m[id]; prev != nil && prev.timer != nil {
+		prev.timer.Stop()
+	}
+	fc.m[id] = change
+}
+
+// take removes the change, only the caller getting it may act on it.
+func (fc *fileChanges) take(id string) *fileChange {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	change := fc.m[id]
+	if change == nil {
+		return nil
+	}
+	delete(fc.m, id)
+	if change.timer != nil {
+		change.timer.Stop()
+	}
+	return change
+}
+
+func (um *UploadManager) stageFile(tempFilePath string, uploadedFile *models.UploadedFile) error {
+	if um.changes == nil {
+		um.removeTempFile(tempFilePath)
+		return fmt.Errorf("client doesn't support staged file pushes")
+	}
+	change := &fileChange{file: uploadedFile, stagedPath: tempFilePath}
+	change.timer = time.AfterFunc(stagedFileChangeTTL, func() {
+		if um.changes.take(uploadedFile.ID) != nil {
+			um.Logger.Infof("discarding staged file %s of %s, it wasn't activated in time", tempFilePath, uploadedFile.DestinationPath)
+			um.removeTempFile(tempFilePath)
+		}
+	})
+	um.changes.put(uploadedFile.ID, change)
+	um.Logger.Debugf("staged %s for %s", tempFilePath, uploadedFile.DestinationPath)
+	return nil
+}
+
+func (um *UploadManager) takeFileChange(reqPayload []byte) (*comm.FileChangeRequest, *fileChange, error) {
+	req := &comm.FileChangeRequest{}
+	if err := json.Unmarshal(reqPayload, req); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode file change request: %v", err)
+	}
+	if um.changes == nil {
+		return nil, nil, fmt.Errorf("client doesn't support staged file pushes")
+	}
+	change := um.changes.take(req.ID)
+	if change == nil {
+		return nil, nil, fmt.Errorf("file change %s not found", req.ID)
+	}
+	return req, change, nil
+}
+
+// HandleActivateFileChangeRequest moves the staged file to the destination, validates and applies it. The previous
+// version is restored if that fails or if the change isn't confirmed in time.
+func (um *UploadManager) HandleActivateFileChangeRequest(reqPayload []byte) (*models.UploadResponse, error) {
+	req, change, err := um.takeFileChange(reqPayload)
+	if err != nil {
+		return nil, err
+	}
+	if change.activated {
+		um.changes.put(req.ID, change)
+		return nil, fmt.Errorf("file change %s is already activated", req.ID)
+	}
+	file := change.file
+
+	change.backupPath, err = um.backupDestination(file)
+	if err != nil {
+		um.removeTempFile(change.stagedPath)
+		return nil, err
+	}
+	change.activated = true
+
+	err = um.moveFileToDestination(change.stagedPath, file)
+	if err != nil {
+		um.removeTempFile(change.stagedPath)
+	} else {
+		err = um.runHook("validate", file.ValidateCommand, file)
+	}
+	if err == nil {
+		err = um.runHook("post", file.PostCommand, file)
+	}
+	if err != nil {
+		return nil, um.failFileChange(change, err)
+	}
+
+	change.timer = time.AfterFunc(time.Duration(req.RollbackAfterSec)*time.Second, func() {
+		if c := um.changes.take(req.ID); c != nil {
+			if _, err := um.rollbackFileChange(c, "the change was not confirmed in time"); err != nil {
+				um.Logger.Errorf("rollback of file change %s failed: %v", req.ID, err)
+			}
+		}
+	})
+	um.changes.put(req.ID, change)
+	um.Logger.Infof("activated file change %s of %s, rolling back in %ds unless confirmed", req.ID, file.DestinationPath, req.RollbackAfterSec)
+
+	return &models.UploadResponse{
+		UploadResponseShort: models.UploadResponseShort{
+			ID:       file.ID,
+			Filepath: file.DestinationPath,
+		},
+		Status:  "activated",
+		Message: "file activated at " + file.DestinationPath,
+	}, nil
+}
+
+// HandleConfirmFileChangeRequest keeps an activated file after validating it once more.
+func (um *UploadManager) HandleConfirmFileChangeRequest(reqPayload []byte) (*models.UploadResponse, error) {
+	req, change, err := um.takeFileChange(reqPayload)
+	if err != nil {
+		return nil, err
+	}
+	if !change.activated {
+		um.changes.put(req.ID, change)
+		return nil, fmt.Errorf("file change %s is not activated", req.ID)
+	}
+	file := change.file
+
+	if err := um.runHook("validate", file.ValidateCommand, file); err != nil {
+		return nil, um.failFileChange(change, err)
+	}
+	if change.backupPath != "" {
+		um.removeTempFile(change.backupPath)
+	}
+	um.Logger.Infof("confirmed file change %s of %s", req.ID, file.DestinationPath)
+
+	return &models.UploadResponse{
+		UploadResponseShort: models.UploadResponseShort{
+			ID:       file.ID,
+			Filepath: file.DestinationPath,
+		},
+		Status:  "confirmed",
+		Message: "file change confirmed at " + file.DestinationPath,
+	}, nil
+}
+
+// HandleRollbackFileChangeRequest restores the previous version of an activated file or discards a staged one.
+func (um *UploadManager) HandleRollbackFileChangeRequest(reqPayload []byte) (*models.UploadResponse, error) {
+	_, change, err := um.takeFileChange(reqPayload)
+	if err != nil {
+		return nil, err
+	}
+	file := change.file
+	done := "the staged file was discarded"
+	if !change.activated {
+		um.removeTempFile(change.stagedPath)
+		um.Logger.Infof("discarded staged file change %s of %s", file.ID, file.DestinationPath)
+	} else {
+		done, err = um.rollbackFileChange(change, "rollback requested by the server")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &models.UploadResponse{
+		UploadResponseShort: models.UploadResponseShort{
+			ID:       file.ID,
+			Filepath: file.DestinationPath,
+		},
+		Status:  "rolled_back",
+		Message: done,
+	}, nil
+}
+
+// rollbackFileChange restores the previous version of the file and runs the post command again to apply it. It
+// returns what was done.
+func (um *UploadManager) rollbackFileChange(change *fileChange, reason string) (string, error) {
+	file := change.file
+	um.Logger.Infof("rolling back file change %s of %s: %s", file.ID, file.DestinationPath, reason)
+	if err := um.rollback(file, change.backupPath); err != nil {
+		um.Logger.Errorf("failed to restore the previous version of %s: %v", file.DestinationPath, err)
+		return "", fmt.Errorf("failed to restore the previous version of %s: %v", file.DestinationPath, err)
+	}
+	if change.backupPath == "" {
+		return "the pushed file was removed", nil
+	}
+	if err := um.runHook("post", file.PostCommand, file); err != nil {
+		um.Logger.Errorf("failed to apply the previous version of %s: %v", file.DestinationPath, err)
+		return "", fmt.Errorf("the previous version of %s was restored but applying it failed: %v", file.DestinationPath, err)
+	}
+	return fmt.Sprintf("the previous version of %s was restored", file.DestinationPath), nil
+}
+
+// failFileChange rolls back the change and returns the error with the outcome of the rollback.
+func (um *UploadManager) failFileChange(change *fileChange, err error) error {
+	done, rbErr := um.rollbackFileChange(change, err.Error())
+	if rbErr != nil {
+		return fmt.Errorf("%v, %v", err, rbErr)
+	}
+	return fmt.Errorf("%v, %s", err, done)
+}
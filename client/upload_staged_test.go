@@ -0,0 +1,130 @@
+package chclient
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/files"
+	"github.com/IOTech17/neo-rport/share/models"
+	"github.com/IOTech17/neo-rport/share/test"
+)
+
+func newStagedUploadManager(t *testing.T, hooks HookRunner) (*UploadManager, string, string) {
+	dir := t.TempDir()
+	uploadDir := filepath.Join(dir, "upload")
+	destination := filepath.Join(dir, "nginx.conf")
+	require.NoError(t, os.WriteFile(destination, []byte("old"), 0600))
+
+	optionsProvMock := &UploadOptionsProviderMock{}
+	optionsProvMock.On("GetUploadDir").Return(uploadDir)
+	optionsProvMock.On("GetProtectedUploadDirs").Return([]string{})
+	optionsProvMock.On("IsFileReceptionEnabled").Return(true)
+	um := &UploadManager{
+		Logger:             testLog,
+		FilesAPI:           files.NewFileSystem(),
+		OptionsProvider:    optionsProvMock,
+		SourceFileProvider: contentProvider("new"),
+		HookRunner:         hooks,
+		changes:            newFileChanges(),
+	}
+
+	payload, err := (&models.UploadedFile{
+		ID:              "change-1",
+		SourceFilePath:  "/data/change-1",
+		DestinationPath: destination,
+		ForceWrite:      true,
+		Md5Checksum:     test.Md5Hash("new"),
+		ValidateCommand: "nginx -t",
+		PostCommand:     "reload",
+		Staged:          true,
+	}).ToBytes()
+	require.NoError(t, err)
+	resp, err := um.HandleUploadRequest(payload)
+	require.NoError(t, err)
+	assert.Equal(t, "staged", resp.Status)
+	assertContent(t, destination, "old")
+
+	return um, destination, uploadDir
+}
+
+func fileChangeRequest(t *testing.T, rollbackAfterSec int) []byte {
+	payload, err := json.Marshal(comm.FileChangeRequest{ID: "change-1", RollbackAfterSec: rollbackAfterSec})
+	require.NoError(t, err)
+	return payload
+}
+
+func assertContent(t *testing.T, path, want string) {
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, want, string(content))
+}
+
+func TestFileChangeActivateAndConfirm(t *testing.T) {
+	hooks := &hookRunnerMock{failing: map[string]bool{}, seen: map[string]string{}}
+	um, destination, uploadDir := newStagedUploadManager(t, hooks)
+
+	resp, err := um.HandleActivateFileChangeRequest(fileChangeRequest(t, 60))
+	require.NoError(t, err)
+	assert.Equal(t, "activated", resp.Status)
+	assertContent(t, destination, "new")
+	assert.Equal(t, "new", hooks.seen["reload"])
+
+	resp, err = um.HandleConfirmFileChangeRequest(fileChangeRequest(t, 60))
+	require.NoError(t, err)
+	assert.Equal(t, "confirmed", resp.Status)
+	assertContent(t, destination, "new")
+	leftovers, err := os.ReadDir(uploadDir)
+	require.NoError(t, err)
+	assert.Empty(t, leftovers)
+
+	_, err = um.HandleRollbackFileChangeRequest(fileChangeRequest(t, 60))
+	assert.EqualError(t, err, "file change change-1 not found")
+}
+
+func TestFileChangeActivationFails(t *testing.T) {
+	hooks := &hookRunnerMock{failing: map[string]bool{"nginx -t": true}, seen: map[string]string{}}
+	um, destination, _ := newStagedUploadManager(t, hooks)
+
+	_, err := um.HandleActivateFileChangeRequest(fileChangeRequest(t, 60))
+	assert.EqualError(t, err, "validate command failed: exit status 1: syntax error, the previous version of "+destination+" was restored")
+	assertContent(t, destination, "old")
+	// the restored version is applied again
+	assert.Equal(t, "old", hooks.seen["reload"])
+}
+
+func TestFileChangeRollsBackWithoutConfirmation(t *testing.T) {
+	hooks := &hookRunnerMock{failing: map[string]bool{}, seen: map[string]string{}}
+	um, destination, _ := newStagedUploadManager(t, hooks)
+
+	_, err := um.HandleActivateFileChangeRequest(fileChangeRequest(t, 0))
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		content, err := os.ReadFile(destination)
+		return err == nil && string(content) == "old"
+	}, time.Second, 10*time.Millisecond)
+	_, err = um.HandleConfirmFileChangeRequest(fileChangeRequest(t, 0))
+	assert.EqualError(t, err, "file change change-1 not found")
+}
+
+func TestFileChangeDiscardStaged(t *testing.T) {
+	hooks := &hookRunnerMock{failing: map[string]bool{}, seen: map[string]string{}}
+	um, destination, uploadDir := newStagedUploadManager(t, hooks)
+
+	resp, err := um.HandleRollbackFileChangeRequest(fileChangeRequest(t, 0))
+	require.NoError(t, err)
+	assert.Equal(t, "rolled_back", resp.Status)
+	assert.Equal(t, "the staged file was discarded", resp.Message)
+	assertContent(t, destination, "old")
+	leftovers, err := os.ReadDir(uploadDir)
+	require.NoError(t, err)
+	assert.Empty(t, leftovers)
+	assert.Empty(t, hooks.seen)
+}
@@ -0,0 +1,300 @@
+package chclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+
+	"github.com/google/uuid"
+
+	"github.com/IOTech17/neo-rport/share/comm"
+)
+
+const (
+	winrmResourceShell  = "http://schemas.microsoft.com/wbem/wsman/1/windows/shell/cmd"
+	winrmActionCreate   = "http://schemas.xmlsoap.org/ws/2004/09/transfer/Create"
+	winrmActionDelete   = "http://schemas.xmlsoap.org/ws/2004/09/transfer/Delete"
+	winrmActionCommand  = "http://schemas.microsoft.com/wbem/wsman/1/windows/shell/Command"
+	winrmActionReceive  = "http://schemas.microsoft.com/wbem/wsman/1/windows/shell/Receive"
+	winrmCommandDone    = "http://schemas.microsoft.com/wbem/wsman/1/windows/shell/CommandState/Done"
+	winrmMaxEnvelope    = 153600
+	winrmMaxResponse    = 4 * winrmMaxEnvelope
+	winrmReceiveTimeout = 20 * time.Second
+	winrmCleanupTimeout = 5 * time.Second
+)
+
+// runWinRMCommand runs the PowerShell script on the target over WinRM with HTTPS and basic auth. The certificate of
+// the target is checked by its fingerprint like the host key of ssh targets, it's usually self-signed.
+func runWinRMCommand(ctx context.Context, addr string, req comm.AgentlessRequest) comm.AgentlessResult {
+	result := comm.AgentlessResult{ID: req.ID, ExitCode: -1}
+	if req.HostKey != "" && req.Password == "" {
+		result.Error = "winrm requires a password"
+		return result
+	}
+
+	timeout := req.Timeout
+	if timeout <= 0 {
+		timeout = defaultAgentlessTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var hostKeyMu sync.Mutex
+	transport := &http.Transport{
+		DialContext:         (&net.Dialer{Timeout: agentlessDialTimeout}).DialContext,
+		TLSHandshakeTimeout: agentlessDialTimeout,
+		TLSClientConfig: &tls.Config{
+			// the chain is not verified, the fingerprint is
+			InsecureSkipVerify: true, //nolint:gosec
+			VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+				if len(rawCerts) == 0 {
+					return errors.New("no certificate presented")
+				}
+				hostKey := certFingerprintSHA256(rawCerts[0])
+				hostKeyMu.Lock()
+				result.HostKey = hostKey
+				hostKeyMu.Unlock()
+				return checkHostKey("certificate", hostKey, req.HostKey)
+			},
+		},
+	}
+	defer transport.CloseIdleConnections()
+
+	c := &winrmClient{
+		http:     &http.Client{Transport: transport},
+		url:      "https://" + addr + "/wsman",
+		username: req.Username,
+		password: req.Password,
+	}
+	stdout := &limitedBuffer{max: maxAgentlessOutput}
+	stderr := &limitedBuffer{max: maxAgentlessOutput}
+	exitCode, err := c.run(ctx, req.Command, stdout, stderr)
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+
+	hostKeyMu.Lock()
+	defer hostKeyMu.Unlock()
+	switch {
+	case err == nil:
+		result.ExitCode = exitCode
+	case ctx.Err() != nil:
+		result.Error = fmt.Sprintf("timeout after %s", timeout)
+	default:
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func certFingerprintSHA256(der []byte) string {
+	sum := sha256.Sum256(der)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// winrmClient speaks the subset of WS-Management needed to run a command in a remote shell.
+type winrmClient struct {
+	http     *http.Client
+	url      string
+	username string
+	password string
+}
+
+func (c *winrmClient) run(ctx context.Context, script string, stdout, stderr io.Writer) (int, error) {
+	env, err := c.post(ctx, winrmActionCreate, "", [][2]string{{"WINRS_NOPROFILE", "TRUE"}, {"WINRS_CODEPAGE", "65001"}},
+		`<rsp:Shell><rsp:InputStreams>stdin</rsp:InputStreams><rsp:OutputStreams>stdout stderr</rsp:OutputStreams></rsp:Shell>`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create shell: %w", err)
+	}
+	shellID := env.shellID()
+	if shellID == "" {
+		return 0, errors.New("failed to create shell: no shell id returned")
+	}
+	defer func() {
+		// the shell is closed even if the command timed out
+		ctx, cancel := context.WithTimeout(context.Background(), winrmCleanupTimeout)
+		defer cancel()
+		_, _ = c.post(ctx, winrmActionDelete, shellID, nil, "")
+	}()
+
+	env, err = c.post(ctx, winrmActionCommand, shellID, [][2]string{{"WINRS_CONSOLEMODE_STDIN", "TRUE"}, {"WINRS_SKIP_CMD_SHELL", "FALSE"}},
+		"<rsp:CommandLine><rsp:Command>powershell.exe</rsp:Command><rsp:Arguments>-NoProfile -NonInteractive -EncodedCommand "+
+			encodePowerShell(script)+"</rsp:Arguments></rsp:CommandLine>")
+	if err != nil {
+		return 0, fmt.Errorf("failed to start command: %w", err)
+	}
+	commandID := env.Body.CommandResponse.CommandID
+
+	for {
+		env, err = c.post(ctx, winrmActionReceive, shellID, nil,
+			`<rsp:Receive><rsp:DesiredStream CommandId="`+xmlEscape(commandID)+`">stdout stderr</rsp:DesiredStream></rsp:Receive>`)
+		var fault *winrmFault
+		if errors.As(err, &fault) && fault.timedOut() {
+			// no output yet, the command is still running
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to receive output: %w", err)
+		}
+
+		for _, s := range env.Body.ReceiveResponse.Streams {
+			data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s.Data))
+			if err != nil {
+				return 0, fmt.Errorf("invalid %s stream: %v", s.Name, err)
+			}
+			if s.Name == "stderr" {
+				_, _ = stderr.Write(data)
+			} else {
+				_, _ = stdout.Write(data)
+			}
+		}
+		if state := env.Body.ReceiveResponse.CommandState; state.State == winrmCommandDone {
+			return state.ExitCode, nil
+		}
+	}
+}
+
+func (c *winrmClient) post(ctx context.Context, action, shellID string, options [][2]string, body string) (*winrmEnvelope, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, strings.NewReader(c.envelope(action, shellID, options, body)))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.SetBasicAuth(c.username, c.password)
+	httpReq.Header.Set("Content-Type", "application/soap+xml;charset=UTF-8")
+
+	resp, err := c.http.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, errors.New("unauthorized, check the credentials and that basic auth is enabled for winrm on the target")
+	}
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, winrmMaxResponse))
+	if err != nil {
+		return nil, err
+	}
+	env := &winrmEnvelope{}
+	if err := xml.Unmarshal(b, env); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status %s", resp.Status)
+		}
+		return nil, fmt.Errorf("invalid response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if env.Body.Fault.Reason == "" {
+			return nil, fmt.Errorf("unexpected status %s", resp.Status)
+		}
+		return nil, &winrmFault{Subcode: env.Body.Fault.Subcode, Reason: strings.TrimSpace(env.Body.Fault.Reason)}
+	}
+	return env, nil
+}
+
+func (c *winrmClient) envelope(action, shellID string, options [][2]string, body string) string {
+	b := &strings.Builder{}
+	b.WriteString(`<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope" xmlns:a="http://schemas.xmlsoap.org/ws/2004/08/addressing" ` +
+		`xmlns:w="http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd" xmlns:rsp="http://schemas.microsoft.com/wbem/wsman/1/windows/shell">`)
+	b.WriteString(`<s:Header>`)
+	b.WriteString(`<a:To>` + xmlEscape(c.url) + `</a:To>`)
+	b.WriteString(`<w:ResourceURI s:mustUnderstand="true">` + winrmResourceShell + `</w:ResourceURI>`)
+	b.WriteString(`<a:ReplyTo><a:Address s:mustUnderstand="true">http://schemas.xmlsoap.org/ws/2004/08/addressing/role/anonymous</a:Address></a:ReplyTo>`)
+	b.WriteString(`<a:Action s:mustUnderstand="true">` + action + `</a:Action>`)
+	b.WriteString(fmt.Sprintf(`<w:MaxEnvelopeSize s:mustUnderstand="true">%d</w:MaxEnvelopeSize>`, winrmMaxEnvelope))
+	b.WriteString(`<a:MessageID>uuid:` + uuid.New().String() + `</a:MessageID>`)
+	b.WriteString(`<w:Locale xml:lang="en-US" s:mustUnderstand="false"/>`)
+	b.WriteString(fmt.Sprintf(`<w:OperationTimeout>PT%dS</w:OperationTimeout>`, int(winrmReceiveTimeout.Seconds())))
+	if shellID != "" {
+		b.WriteString(`<w:SelectorSet><w:Selector Name="ShellId">` + xmlEscape(shellID) + `</w:Selector></w:SelectorSet>`)
+	}
+	if len(options) > 0 {
+		b.WriteString(`<w:OptionSet>`)
+		for _, o := range options {
+			b.WriteString(`<w:Option Name="` + o[0] + `">` + o[1] + `</w:Option>`)
+		}
+		b.WriteString(`</w:OptionSet>`)
+	}
+	b.WriteString(`</s:Header><s:Body>` + body + `</s:Body></s:Envelope>`)
+	return b.String()
+}
+
+type winrmEnvelope struct {
+	Body struct {
+		ResourceCreated struct {
+			Selectors []struct {
+				Name  string `xml:"Name,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"ReferenceParameters>SelectorSet>Selector"`
+		} `xml:"ResourceCreated"`
+		Shell struct {
+			ShellID string `xml:"ShellId"`
+		} `xml:"Shell"`
+		CommandResponse struct {
+			CommandID string `xml:"CommandId"`
+		} `xml:"CommandResponse"`
+		ReceiveResponse struct {
+			Streams []struct {
+				Name string `xml:"Name,attr"`
+				Data string `xml:",chardata"`
+			} `xml:"Stream"`
+			CommandState struct {
+				State    string `xml:"State,attr"`
+				ExitCode int    `xml:"ExitCode"`
+			} `xml:"CommandState"`
+		} `xml:"ReceiveResponse"`
+		Fault struct {
+			Subcode string `xml:"Code>Subcode>Value"`
+			Reason  string `xml:"Reason>Text"`
+		} `xml:"Fault"`
+	} `xml:"Body"`
+}
+
+func (e *winrmEnvelope) shellID() string {
+	for _, s := range e.Body.ResourceCreated.Selectors {
+		if s.Name == "ShellId" {
+			return s.Value
+		}
+	}
+	return e.Body.Shell.ShellID
+}
+
+type winrmFault struct {
+	Subcode string
+	Reason  string
+}
+
+func (f *winrmFault) Error() string {
+	return f.Reason
+}
+
+// timedOut is returned by receive requests if the command had no output within the operation timeout.
+func (f *winrmFault) timedOut() bool {
+	return strings.HasSuffix(f.Subcode, ":TimedOut")
+}
+
+// encodePowerShell encodes the script for -EncodedCommand, base64 of UTF-16LE.
+func encodePowerShell(script string) string {
+	codes := utf16.Encode([]rune(script))
+	b := make([]byte, 2*len(codes))
+	for i, c := range codes {
+		binary.LittleEndian.PutUint16(b[2*i:], c)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func xmlEscape(s string) string {
+	b := &strings.Builder{}
+	_ = xml.EscapeText(b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,147 @@
+package chclient
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/share/comm"
+)
+
+func TestRunWinRMCommand(t *testing.T) {
+	srv := httptest.NewTLSServer(&testWinRMServer{t: t, password: "secret"})
+	defer srv.Close()
+	addr := srv.Listener.Addr().String()
+	ctx := context.Background()
+
+	hostKey := certFingerprintSHA256(srv.Certificate().Raw)
+	req := comm.AgentlessRequest{ID: "1", Username: "admin", Password: "secret", HostKey: hostKey, Command: "Get-Date"}
+	result := runWinRMCommand(ctx, addr, req)
+	assert.Equal(t, comm.AgentlessResult{
+		ID:       "1",
+		HostKey:  hostKey,
+		Stdout:   "ran Get-Date\r\n",
+		Stderr:   "warning\r\n",
+		ExitCode: 0,
+	}, result)
+
+	req.Command = "exit 5"
+	result = runWinRMCommand(ctx, addr, req)
+	assert.Equal(t, 5, result.ExitCode)
+	assert.Empty(t, result.Error)
+
+	req.HostKey = "SHA256:other"
+	result = runWinRMCommand(ctx, addr, req)
+	assert.Contains(t, result.Error, "does not match the expected SHA256:other")
+	assert.Equal(t, -1, result.ExitCode)
+
+	req.HostKey = ""
+	req.Password = ""
+	result = runWinRMCommand(ctx, addr, req)
+	assert.Equal(t, hostKey, result.HostKey)
+	assert.Contains(t, result.Error, "certificate "+hostKey+" is not pinned")
+
+	req.HostKey = hostKey
+	req.Password = "wrong"
+	result = runWinRMCommand(ctx, addr, req)
+	assert.Contains(t, result.Error, "unauthorized")
+
+	req.Password = ""
+	result = runWinRMCommand(ctx, addr, req)
+	assert.Equal(t, "winrm requires a password", result.Error)
+
+	req.Password = "secret"
+	req.Command = "Start-Sleep 10"
+	req.Timeout = 100 * time.Millisecond
+	result = runWinRMCommand(ctx, addr, req)
+	assert.Equal(t, "timeout after 100ms", result.Error)
+}
+
+func TestEncodePowerShell(t *testing.T) {
+	assert.Equal(t, "RwBlAHQALQBEAGEAdABlAA==", encodePowerShell("Get-Date"))
+}
+
+var (
+	testWinRMActionRegexp  = regexp.MustCompile(`<a:Action[^>]*>([^<]+)</a:Action>`)
+	testWinRMCommandRegexp = regexp.MustCompile(`-EncodedCommand ([^<]+)</rsp:Arguments>`)
+)
+
+// testWinRMServer runs a single command per shell: "exit N" exits with N, "Start-Sleep" never finishes, others echo
+// the command. The first receive of each command times out.
+type testWinRMServer struct {
+	t        *testing.T
+	password string
+	command  string
+	received bool
+}
+
+func (s *testWinRMServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if _, password, _ := r.BasicAuth(); password != s.password {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	require.NoError(s.t, err)
+	action := testWinRMActionRegexp.FindStringSubmatch(string(body))[1]
+
+	var resp string
+	switch action {
+	case winrmActionCreate:
+		resp = `<x:ResourceCreated><a:ReferenceParameters><w:SelectorSet><w:Selector Name="ShellId">shell-1</w:Selector></w:SelectorSet></a:ReferenceParameters></x:ResourceCreated>`
+	case winrmActionCommand:
+		s.command = decodeTestPowerShell(s.t, testWinRMCommandRegexp.FindStringSubmatch(string(body))[1])
+		s.received = false
+		resp = `<rsp:CommandResponse><rsp:CommandId>command-1</rsp:CommandId></rsp:CommandResponse>`
+	case winrmActionReceive:
+		if !s.received || strings.HasPrefix(s.command, "Start-Sleep") {
+			s.received = true
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, testWinRMEnvelope(`<s:Fault><s:Code><s:Value>s:Receiver</s:Value><s:Subcode><s:Value>w:TimedOut</s:Value></s:Subcode></s:Code>`+
+				`<s:Reason><s:Text xml:lang="en-US">The WS-Management service cannot complete the operation within the time specified in OperationTimeout.</s:Text></s:Reason></s:Fault>`))
+			return
+		}
+		exitCode := 0
+		if _, err := fmt.Sscanf(s.command, "exit %d", &exitCode); err == nil {
+			resp = fmt.Sprintf(`<rsp:ReceiveResponse><rsp:CommandState CommandId="command-1" State="%s"><rsp:ExitCode>%d</rsp:ExitCode></rsp:CommandState></rsp:ReceiveResponse>`, winrmCommandDone, exitCode)
+			break
+		}
+		resp = `<rsp:ReceiveResponse>` +
+			`<rsp:Stream Name="stdout" CommandId="command-1">` + base64.StdEncoding.EncodeToString([]byte("ran "+s.command+"\r\n")) + `</rsp:Stream>` +
+			`<rsp:Stream Name="stderr" CommandId="command-1">` + base64.StdEncoding.EncodeToString([]byte("warning\r\n")) + `</rsp:Stream>` +
+			`<rsp:Stream Name="stdout" CommandId="command-1" End="true"></rsp:Stream>` +
+			`<rsp:CommandState CommandId="command-1" State="` + winrmCommandDone + `"><rsp:ExitCode>0</rsp:ExitCode></rsp:CommandState>` +
+			`</rsp:ReceiveResponse>`
+	case winrmActionDelete:
+	default:
+		s.t.Errorf("unexpected action %s", action)
+	}
+	fmt.Fprint(w, testWinRMEnvelope(resp))
+}
+
+func testWinRMEnvelope(body string) string {
+	return `<s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope" xmlns:a="http://schemas.xmlsoap.org/ws/2004/08/addressing" ` +
+		`xmlns:x="http://schemas.xmlsoap.org/ws/2004/09/transfer" xmlns:w="http://schemas.dmtf.org/wbem/wsman/1/wsman.xsd" ` +
+		`xmlns:rsp="http://schemas.microsoft.com/wbem/wsman/1/windows/shell"><s:Header></s:Header><s:Body>` + body + `</s:Body></s:Envelope>`
+}
+
+func decodeTestPowerShell(t *testing.T, encoded string) string {
+	b, err := base64.StdEncoding.DecodeString(encoded)
+	require.NoError(t, err)
+	codes := make([]uint16, len(b)/2)
+	for i := range codes {
+		codes[i] = binary.LittleEndian.Uint16(b[2*i:])
+	}
+	return string(utf16.Decode(codes))
+}
@@ -0,0 +1,143 @@
+// rport-loadgen connects simulated clients to a rport server to validate its sizing before a rollout.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	chshare "github.com/IOTech17/neo-rport/share"
+	"github.com/IOTech17/neo-rport/share/logger"
+)
+
+var (
+	RootCmd = &cobra.Command{
+		Use:     "rport-loadgen",
+		Short:   "connect simulated clients to a rport server",
+		Long:    "Connect thousands of simulated clients to a rport server. They send synthetic measurements and answer jobs with synthetic results. The server must be started with allow_simulated_clients = true.",
+		Example: "rport-loadgen --server http://rport.example.com:8080 --auth loadgen:secret --clients 5000 --ramp-up 10m",
+		Version: chshare.BuildVersion,
+		RunE: func(*cobra.Command, []string) error {
+			return run()
+		},
+	}
+
+	serverFlag              *string
+	authFlag                *string
+	fingerprintFlag         *string
+	clientsFlag             *int
+	idPrefixFlag            *string
+	rampUpFlag              *time.Duration
+	measurementIntervalFlag *time.Duration
+	jobDurationFlag         *time.Duration
+	reconnectDelayFlag      *time.Duration
+	statsIntervalFlag       *time.Duration
+	logLevelFlag            *string
+)
+
+func init() {
+	flags := RootCmd.Flags()
+	serverFlag = flags.String("server", "", "url of the rport server the clients connect to")
+	authFlag = flags.String("auth", "", "client credentials <client-auth-id>:<password>, used by all clients, requires auth_multiuse_creds on the server")
+	fingerprintFlag = flags.String("fingerprint", "", "fingerprint of the server, not checked if empty")
+	clientsFlag = flags.Int("clients", 100, "number of simulated clients")
+	idPrefixFlag = flags.String("id-prefix", "loadgen", "prefix of the client ids, the number of the client is appended")
+	rampUpFlag = flags.Duration("ramp-up", time.Minute, "duration over which the clients are connected")
+	measurementIntervalFlag = flags.Duration("measurement-interval", time.Minute, "interval of the synthetic measurements, 0 disables them")
+	jobDurationFlag = flags.Duration("job-duration", time.Second, "time until a job result is sent")
+	reconnectDelayFlag = flags.Duration("reconnect-delay", 10*time.Second, "wait time before a client reconnects")
+	statsIntervalFlag = flags.Duration("stats-interval", 10*time.Second, "interval of printing the statistics")
+	logLevelFlag = flags.String("log-level", "error", "log level of the clients: error, info or debug")
+}
+
+func main() {
+	if err := RootCmd.Execute(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() error {
+	if *serverFlag == "" {
+		return errors.New("--server is required")
+	}
+	authID, password := chshare.ParseAuth(*authFlag)
+	if authID == "" {
+		return errors.New("--auth must be <client-auth-id>:<password>")
+	}
+	if *clientsFlag < 1 {
+		return errors.New("--clients must be at least 1")
+	}
+	server, err := websocketURL(*serverFlag)
+	if err != nil {
+		return fmt.Errorf("invalid --server: %v", err)
+	}
+	logLevel, err := logger.ParseLogLevel(*logLevelFlag)
+	if err != nil {
+		return err
+	}
+	logOutput := logger.NewLogOutput("")
+	if err := logOutput.Start(); err != nil {
+		return err
+	}
+	l := logger.NewLogger("loadgen", logOutput, logLevel)
+
+	config := &simulatorConfig{
+		Server:              server,
+		AuthID:              authID,
+		Password:            password,
+		Fingerprint:         *fingerprintFlag,
+		IDPrefix:            *idPrefixFlag,
+		MeasurementInterval: *measurementIntervalFlag,
+		JobDuration:         *jobDurationFlag,
+		ReconnectDelay:      *reconnectDelayFlag,
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	st := &stats{}
+	go printStats(ctx, st, *statsIntervalFlag)
+
+	fmt.Printf("Connecting %d simulated clients to %s within %s\n", *clientsFlag, server, *rampUpFlag)
+	step := *rampUpFlag / time.Duration(*clientsFlag)
+	wg := &sync.WaitGroup{}
+	for i := 0; i < *clientsFlag && ctx.Err() == nil; i++ {
+		c := newSimulatedClient(config, st, i, l)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Run(ctx)
+		}()
+		select {
+		case <-ctx.Done():
+		case <-time.After(step):
+		}
+	}
+
+	<-ctx.Done()
+	fmt.Println("Disconnecting the simulated clients")
+	wg.Wait()
+	fmt.Println(st)
+	return nil
+}
+
+func printStats(ctx context.Context, st *stats, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			fmt.Println(time.Now().Format(time.RFC3339), st)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"golang.org/x/crypto/ssh"
+
+	chshare "github.com/IOTech17/neo-rport/share"
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+const dialTimeout = 30 * time.Second
+
+type simulatorConfig struct {
+	Server              string
+	AuthID              string
+	Password            string
+	Fingerprint         string
+	IDPrefix            string
+	MeasurementInterval time.Duration
+	JobDuration         time.Duration
+	ReconnectDelay      time.Duration
+}
+
+// stats are the counters of all simulated clients, printed periodically.
+type stats struct {
+	connected    int64
+	failed       int64
+	jobs         int64
+	measurements int64
+}
+
+func (s *stats) String() string {
+	return fmt.Sprintf(
+		"connected=%d failed_connects=%d jobs=%d measurements=%d",
+		atomic.LoadInt64(&s.connected),
+		atomic.LoadInt64(&s.failed),
+		atomic.LoadInt64(&s.jobs),
+		atomic.LoadInt64(&s.measurements),
+	)
+}
+
+// simulatedClient is a fake client. It connects like a real client but answers jobs and sends measurements with
+// synthetic data, without running anything on the host.
+type simulatedClient struct {
+	*logger.Logger
+
+	config *simulatorConfig
+	stats  *stats
+	index  int
+	id     string
+	rand   *rand.Rand // only used by the measurement loop
+
+	connMtx sync.Mutex
+	conn    ssh.Conn
+}
+
+func newSimulatedClient(config *simulatorConfig, st *stats, index int, l *logger.Logger) *simulatedClient {
+	id := fmt.Sprintf("%s-%05d", config.IDPrefix, index)
+	return &simulatedClient{
+		Logger: l.Fork("%s", id),
+		config: config,
+		stats:  st,
+		index:  index,
+		id:     id,
+		rand:   rand.New(rand.NewSource(int64(index))),
+	}
+}
+
+// Run keeps the client connected until the context is canceled.
+func (c *simulatedClient) Run(ctx context.Context) {
+	for {
+		err := c.connect(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			atomic.AddInt64(&c.stats.failed, 1)
+			c.Errorf("Connection failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(c.config.ReconnectDelay):
+		}
+	}
+}
+
+func (c *simulatedClient) connect(ctx context.Context) error {
+	d := &websocket.Dialer{
+		ReadBufferSize:   1024,
+		WriteBufferSize:  1024,
+		HandshakeTimeout: dialTimeout,
+		Subprotocols:     []string{chshare.ProtocolVersion},
+	}
+	wsConn, _, err := d.DialContext(ctx, c.config.Server, nil)
+	if err != nil {
+		return err
+	}
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(chshare.NewWebSocketConn(wsConn), "", &ssh.ClientConfig{
+		User:            c.config.AuthID,
+		Auth:            []ssh.AuthMethod{ssh.Password(c.config.Password)},
+		ClientVersion:   "SSH-" + chshare.ProtocolVersion + "-client",
+		HostKeyCallback: c.verifyServer,
+		Timeout:         dialTimeout,
+	})
+	if err != nil {
+		return err
+	}
+	defer sshConn.Close()
+	go rejectChannels(chans)
+
+	connReq, err := chshare.EncodeConnectionRequest(c.connectionRequest())
+	if err != nil {
+		return err
+	}
+	ok, resp, err := sshConn.SendRequest("new_connection", true, connReq)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New(string(resp))
+	}
+
+	c.connMtx.Lock()
+	c.conn = sshConn
+	c.connMtx.Unlock()
+	atomic.AddInt64(&c.stats.connected, 1)
+	defer atomic.AddInt64(&c.stats.connected, -1)
+
+	connCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if c.config.MeasurementInterval > 0 {
+		go c.measurementLoop(connCtx)
+	}
+	go func() {
+		<-connCtx.Done()
+		sshConn.Close()
+	}()
+
+	for r := range reqs {
+		c.handleRequest(connCtx, r)
+	}
+	return errors.New("connection closed")
+}
+
+func (c *simulatedClient) verifyServer(_ string, _ net.Addr, key ssh.PublicKey) error {
+	got := chshare.FingerprintKey(key)
+	if c.config.Fingerprint != "" && !strings.HasPrefix(got, c.config.Fingerprint) {
+		return fmt.Errorf("invalid fingerprint (%s)", got)
+	}
+	return nil
+}
+
+func (c *simulatedClient) connectionRequest() *chshare.ConnectionRequest {
+	return &chshare.ConnectionRequest{
+		ID:                     c.id,
+		Name:                   c.id,
+		Tags:                   []string{"simulated"},
+		OS:                     "Simulated Linux",
+		OSArch:                 "amd64",
+		OSFamily:               "simulated",
+		OSKernel:               "linux",
+		OSFullName:             "Simulated Linux 1.0",
+		OSVersion:              "1.0",
+		OSVirtualizationSystem: "simulated",
+		OSVirtualizationRole:   "guest",
+		Hostname:               c.id,
+		CPUFamily:              "6",
+		CPUModel:               "85",
+		CPUModelName:           "Simulated CPU",
+		CPUVendor:              "Simulated",
+		NumCPUs:                2 << (c.index % 4),
+		MemoryTotal:            uint64(1<<30) << (c.index % 4),
+		Timezone:               "UTC (UTC+00:00)",
+		IPv4:                   []string{fmt.Sprintf("10.%d.%d.%d", c.index>>16&0xff, c.index>>8&0xff, c.index&0xff)},
+		Version:                chshare.BuildVersion,
+		JobDeliveryVersion:     chshare.JobDeliveryVersion,
+		Capabilities:           []string{models.ClientCapabilityUDPTunnels, models.ClientCapabilityMonitoring},
+		ProtocolRevision:       chshare.ProtocolRevision,
+		FeatureLevels:          chshare.FeatureLevels(),
+		Simulated:              true,
+	}
+}
+
+func (c *simulatedClient) handleRequest(ctx context.Context, r *ssh.Request) {
+	switch r.Type {
+	case comm.RequestTypePing, comm.RequestTypePutCapabilities:
+		_ = r.Reply(true, nil)
+	case comm.RequestTypeRunCmd:
+		job := &models.Job{}
+		if err := json.Unmarshal(r.Payload, job); err != nil {
+			comm.ReplyError(c.Logger, r, fmt.Errorf("invalid job: %v", err))
+			return
+		}
+		resp := &comm.RunCmdResponse{Pid: 10000 + rand.Intn(50000), StartedAt: time.Now()}
+		comm.ReplySuccessJSON(c.Logger, r, resp)
+		go c.finishJob(ctx, job, resp)
+	default:
+		if r.WantReply {
+			comm.ReplyError(c.Logger, r, fmt.Errorf("unknown request: %s", r.Type))
+		}
+	}
+}
+
+// finishJob sends a synthetic result of the job after the configured duration.
+func (c *simulatedClient) finishJob(ctx context.Context, job *models.Job, resp *comm.RunCmdResponse) {
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(c.config.JobDuration):
+	}
+
+	data, err := json.Marshal(syntheticJobResult(job, resp, time.Now()))
+	if err != nil {
+		c.Errorf("Failed to encode job result: %v", err)
+		return
+	}
+	if _, _, err := c.getConn().SendRequest(comm.RequestTypeCmdResult, false, data); err != nil {
+		c.Errorf("Failed to send job result: %v", err)
+		return
+	}
+	atomic.AddInt64(&c.stats.jobs, 1)
+}
+
+func (c *simulatedClient) measurementLoop(ctx context.Context) {
+	// spread the measurements of all clients over the interval
+	delay := time.Duration(c.rand.Int63n(int64(c.config.MeasurementInterval)))
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		timer.Reset(c.config.MeasurementInterval)
+
+		data, err := json.Marshal(syntheticMeasurement(c.rand, time.Now()))
+		if err != nil {
+			c.Errorf("Failed to encode measurement: %v", err)
+			continue
+		}
+		if _, _, err := c.getConn().SendRequest(comm.RequestTypeSaveMeasurement, false, data); err != nil {
+			c.Errorf("Failed to send measurement: %v", err)
+			continue
+		}
+		atomic.AddInt64(&c.stats.measurements, 1)
+	}
+}
+
+func (c *simulatedClient) getConn() ssh.Conn {
+	c.connMtx.Lock()
+	defer c.connMtx.Unlock()
+	return c.conn
+}
+
+func syntheticJobResult(job *models.Job, resp *comm.RunCmdResponse, now time.Time) *models.Job {
+	job.Status = models.JobStatusSuccessful
+	job.PID = &resp.Pid
+	job.StartedAt = resp.StartedAt
+	job.FinishedAt = &now
+	job.Result = &models.JobResult{
+		StdOut: fmt.Sprintf("simulated output of %q\n", job.Command),
+	}
+	return job
+}
+
+func syntheticMeasurement(r *rand.Rand, now time.Time) *models.Measurement {
+	return &models.Measurement{
+		Timestamp:          now,
+		CPUUsagePercent:    r.Float64() * 100,
+		MemoryUsagePercent: 20 + r.Float64()*60,
+		IoUsagePercent:     r.Float64() * 10,
+		Processes:          "[]",
+		Mountpoints:        "{}",
+		NetLan: &models.NetBytes{
+			In:  r.Intn(1 << 20),
+			Out: r.Intn(1 << 20),
+		},
+	}
+}
+
+func rejectChannels(chans <-chan ssh.NewChannel) {
+	for ch := range chans {
+		_ = ch.Reject(ssh.Prohibited, "simulated clients don't accept tunnels")
+	}
+}
+
+// websocketURL converts the http(s) url of the server to the url of the client listener.
+func websocketURL(server string) (string, error) {
+	if !strings.Contains(server, "://") {
+		server = "http://" + server
+	}
+	u, err := url.Parse(server)
+	if err != nil {
+		return "", err
+	}
+	u.Scheme = strings.Replace(u.Scheme, "http", "ws", 1)
+	return u.String(), nil
+}
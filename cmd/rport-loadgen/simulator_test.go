@@ -0,0 +1,63 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	chshare "github.com/IOTech17/neo-rport/share"
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+func TestWebsocketURL(t *testing.T) {
+	for server, expected := range map[string]string{
+		"http://rport.example.com:8080": "ws://rport.example.com:8080",
+		"https://rport.example.com":     "wss://rport.example.com",
+		"rport.example.com:8080":        "ws://rport.example.com:8080",
+		"wss://rport.example.com":       "wss://rport.example.com",
+	} {
+		got, err := websocketURL(server)
+		require.NoError(t, err)
+		assert.Equal(t, expected, got, server)
+	}
+}
+
+func TestSimulatedConnectionRequest(t *testing.T) {
+	l := logger.NewLogger("test", logger.LogOutput{}, logger.LogLevelError)
+	c := newSimulatedClient(&simulatorConfig{IDPrefix: "lg"}, &stats{}, 258, l)
+
+	req := c.connectionRequest()
+
+	assert.True(t, req.Simulated)
+	assert.Equal(t, "lg-00258", req.ID)
+	assert.Equal(t, []string{"10.0.1.2"}, req.IPv4)
+	assert.Equal(t, chshare.ProtocolRevision, req.ProtocolRevision)
+	assert.NotContains(t, req.Capabilities, models.ClientCapabilityScripts)
+}
+
+func TestSyntheticJobResult(t *testing.T) {
+	startedAt := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	now := startedAt.Add(time.Second)
+
+	job := syntheticJobResult(&models.Job{JID: "job-1", Command: "uptime"}, &comm.RunCmdResponse{Pid: 123, StartedAt: startedAt}, now)
+
+	assert.Equal(t, models.JobStatusSuccessful, job.Status)
+	assert.Equal(t, 123, *job.PID)
+	assert.Equal(t, startedAt, job.StartedAt)
+	assert.Equal(t, now, *job.FinishedAt)
+	assert.Equal(t, "simulated output of \"uptime\"\n", job.Result.StdOut)
+}
+
+func TestSyntheticMeasurement(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		m := syntheticMeasurement(r, time.Now())
+		assert.True(t, m.CPUUsagePercent >= 0 && m.CPUUsagePercent <= 100)
+		assert.True(t, m.MemoryUsagePercent >= 20 && m.MemoryUsagePercent <= 80)
+	}
+}
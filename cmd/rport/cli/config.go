@@ -16,6 +16,9 @@ import (
 	"github.com/IOTech17/neo-rport/share/clientconfig"
 )
 
+// EnvVarPrefix is the prefix of the environment variables overriding config file options.
+const EnvVarPrefix = "RPORT"
+
 func readAttributesFile(cfgPath string) (models.Attributes, error) {
 
 	attributes := models.Attributes{}
@@ -37,6 +40,10 @@ func DecodeConfig(cfgPath string, pFlags *pflag.FlagSet, overrideConfigWithCLIAr
 
 	config := &chclient.ClientConfigHolder{Config: &clientconfig.Config{}}
 
+	if err := chshare.BindEnvVars(viperCfg, EnvVarPrefix, config.Config); err != nil {
+		return nil, err
+	}
+
 	if overrideConfigWithCLIArgs {
 		BindPFlagsToViperConfig(pFlags, viperCfg)
 	}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	chclient "github.com/IOTech17/neo-rport/client"
+	"github.com/IOTech17/neo-rport/client/cloudmeta"
 
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
@@ -16,10 +17,13 @@ func BindPFlagsToViperConfig(pFlags *pflag.FlagSet, viperCfg *viper.Viper) {
 	_ = viperCfg.BindPFlag("client.fingerprint", pFlags.Lookup("fingerprint"))
 	_ = viperCfg.BindPFlag("client.auth", pFlags.Lookup("auth"))
 	_ = viperCfg.BindPFlag("client.proxy", pFlags.Lookup("proxy"))
+	_ = viperCfg.BindPFlag("client.use_system_proxy", pFlags.Lookup("use-system-proxy"))
+	_ = viperCfg.BindPFlag("client.proxy_pac_url", pFlags.Lookup("proxy-pac-url"))
 	_ = viperCfg.BindPFlag("client.use_system_id", pFlags.Lookup("use-system-id"))
 	_ = viperCfg.BindPFlag("client.id", pFlags.Lookup("id"))
 	_ = viperCfg.BindPFlag("client.use_hostname", pFlags.Lookup("use-hostname"))
 	_ = viperCfg.BindPFlag("client.name", pFlags.Lookup("name"))
+	_ = viperCfg.BindPFlag("client.ephemeral", pFlags.Lookup("ephemeral"))
 	_ = viperCfg.BindPFlag("client.tags", pFlags.Lookup("tag"))
 	_ = viperCfg.BindPFlag("client.allow_root", pFlags.Lookup("allow-root"))
 	_ = viperCfg.BindPFlag("client.updates_interval", pFlags.Lookup("updates-interval"))
@@ -52,6 +56,7 @@ func BindPFlagsToViperConfig(pFlags *pflag.FlagSet, viperCfg *viper.Viper) {
 	_ = viperCfg.BindPFlag("monitoring.pm_max_number_processes", pFlags.Lookup("monitoring-pm-max-number-processes"))
 	_ = viperCfg.BindPFlag("monitoring.net_lan", pFlags.Lookup("monitoring-net-lan"))
 	_ = viperCfg.BindPFlag("monitoring.net_wan", pFlags.Lookup("monitoring-net-wan"))
+	_ = viperCfg.BindPFlag("monitoring.buffer_size", pFlags.Lookup("monitoring-buffer-size"))
 
 	_ = viperCfg.BindPFlag("file-reception.protected", pFlags.Lookup("file-reception-protected"))
 	_ = viperCfg.BindPFlag("file-reception.enabled", pFlags.Lookup("file-reception-enabled"))
@@ -77,11 +82,14 @@ func SetPFlags(pFlags *pflag.FlagSet) {
 	pFlags.Int("max-retry-count", 0, "")
 	pFlags.Duration("max-retry-interval", 0, "")
 	pFlags.String("proxy", "", "")
+	pFlags.Bool("use-system-proxy", false, "")
+	pFlags.String("proxy-pac-url", "", "")
 	pFlags.StringArray("header", []string{}, "")
 	pFlags.Bool("use-system-id", true, "")
 	pFlags.String("id", "", "")
 	pFlags.Bool("use-hostname", true, "")
 	pFlags.String("name", "", "")
+	pFlags.Bool("ephemeral", false, "")
 	pFlags.StringArrayP("tag", "t", []string{}, "")
 	pFlags.String("hostname", "", "")
 	pFlags.StringP("log-file", "l", "", "")
@@ -105,6 +113,7 @@ func SetPFlags(pFlags *pflag.FlagSet) {
 	pFlags.Int("monitoring-pm-max-number-processes", 0, "")
 	pFlags.StringArray("monitoring-net-lan", []string{}, "")
 	pFlags.StringArray("monitoring-net-wan", []string{}, "")
+	pFlags.Int("monitoring-buffer-size", 0, "")
 	pFlags.StringArray("file-reception-protected", []string{}, "")
 	pFlags.Bool("file-reception-enabled", true, "")
 	pFlags.String("bind-interface", "", "")
@@ -138,7 +147,33 @@ func SetViperConfigDefaults(viperCfg *viper.Viper) {
 	viperCfg.SetDefault("monitoring.pm_enabled", true)
 	viperCfg.SetDefault("monitoring.pm_kerneltasks_enabled", true)
 	viperCfg.SetDefault("monitoring.pm_max_number_processes", 500)
+	viperCfg.SetDefault("monitoring.buffer_size", 1440)
 
 	viperCfg.SetDefault("file-reception.protected", chclient.FileReceptionGlobs)
 	viperCfg.SetDefault("file-reception.enabled", true)
+
+	viperCfg.SetDefault("screenshots.enabled", false)
+	viperCfg.SetDefault("screenshots.max_bytes", 10*1024*1024)
+	viperCfg.SetDefault("chat.enabled", false)
+	viperCfg.SetDefault("chat.reply_timeout", "10m")
+	viperCfg.SetDefault("consent.screenshots", false)
+	viperCfg.SetDefault("consent.sessions", false)
+	viperCfg.SetDefault("consent.timeout", "30s")
+	viperCfg.SetDefault("consent.on_timeout", "deny")
+	viperCfg.SetDefault("network-discovery.enabled", false)
+	viperCfg.SetDefault("network-discovery.ports", chclient.DefaultDiscoveryPorts)
+	viperCfg.SetDefault("network-discovery.max_hosts", 1024)
+	viperCfg.SetDefault("network-discovery.probe_timeout", "500ms")
+	viperCfg.SetDefault("agentless.enabled", false)
+	viperCfg.SetDefault("agentless.max_sessions", 8)
+	viperCfg.SetDefault("serial-consoles.enabled", false)
+	viperCfg.SetDefault("industrial.enabled", false)
+	viperCfg.SetDefault("serial-consoles.ports", chclient.DefaultSerialConsolePorts)
+
+	viperCfg.SetDefault("kubernetes.node_name_env", "NODE_NAME")
+	viperCfg.SetDefault("kubernetes.ip_watch_interval", time.Minute)
+
+	viperCfg.SetDefault("cloud-metadata.enabled", false)
+	viperCfg.SetDefault("cloud-metadata.provider", cloudmeta.ProviderAuto)
+	viperCfg.SetDefault("cloud-metadata.timeout", cloudmeta.DefaultTimeout)
 }
@@ -20,6 +20,7 @@ import (
 
 	"github.com/IOTech17/neo-rport/cmd/rport/cli"
 	"github.com/IOTech17/neo-rport/share/files"
+	"github.com/IOTech17/neo-rport/share/fips"
 
 	chclient "github.com/IOTech17/neo-rport/client"
 	chshare "github.com/IOTech17/neo-rport/share"
@@ -142,6 +143,9 @@ func runClient(pFlags *pflag.FlagSet) error {
 	if err != nil {
 		return fmt.Errorf("config validation failed: %v", err)
 	}
+	if config.Client.FIPSMode {
+		fips.Enable()
+	}
 
 	err = checkRootOK(config)
 	if err != nil {
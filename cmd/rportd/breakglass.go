@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/IOTech17/neo-rport/server/breakglass"
+	"github.com/IOTech17/neo-rport/share/logger"
+)
+
+var (
+	breakGlassCmd = &cobra.Command{
+		Use:   "break-glass",
+		Short: "manage the break-glass account",
+		Long:  "Create or revoke the recovery kit of the emergency administrator, usable only while the oauth identity provider is unreachable",
+	}
+	breakGlassInitCmd = &cobra.Command{
+		Use:     "init",
+		Short:   "create a recovery kit",
+		Long:    "Generate a recovery code for an administrator and print the recovery kit. Only the hash of the code is stored in the data dir, an existing kit is replaced.",
+		Example: "rportd break-glass init -u emergency-admin > kit.txt",
+		Run: func(*cobra.Command, []string) {
+			mLog := logger.NewMemLogger()
+			if err := decodeAndValidateConfig(&mLog); err != nil {
+				log.Fatalf("Invalid config: %v. See rportd --help", err)
+			}
+
+			existing, err := breakglass.LoadKit(cfg.Server.DataDir)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if existing != nil && !*breakGlassForceFlag {
+				log.Fatalf("A recovery kit for %q already exists, use --force to replace it", existing.Username)
+			}
+
+			kit, code, err := breakglass.NewKit(*breakGlassUsernameFlag)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if err := breakglass.SaveKit(cfg.Server.DataDir, kit); err != nil {
+				log.Fatal(err)
+			}
+
+			server := cfg.API.BaseURL
+			if server == "" {
+				server = cfg.API.Address
+			}
+			breakglass.PrintKit(os.Stdout, kit, code, server)
+			fmt.Fprintf(os.Stderr, "The user %q must exist and be a member of the Administrators group.\n", kit.Username)
+		},
+	}
+	breakGlassRevokeCmd = &cobra.Command{
+		Use:     "revoke",
+		Short:   "revoke the recovery kit",
+		Long:    "Delete the stored hash of the recovery code, the printed kit becomes useless",
+		Example: "rportd break-glass revoke",
+		Run: func(*cobra.Command, []string) {
+			mLog := logger.NewMemLogger()
+			if err := decodeAndValidateConfig(&mLog); err != nil {
+				log.Fatalf("Invalid config: %v. See rportd --help", err)
+			}
+
+			if err := breakglass.RemoveKit(cfg.Server.DataDir); err != nil {
+				log.Fatal(err)
+			}
+			fmt.Println("The recovery kit has been revoked.")
+		},
+	}
+
+	breakGlassUsernameFlag *string
+	breakGlassForceFlag    *bool
+)
+
+func init() {
+	// breakGlassCmd is added to RootCmd in main.go, the init of this file runs first
+	breakGlassCmd.AddCommand(breakGlassInitCmd)
+	breakGlassCmd.AddCommand(breakGlassRevokeCmd)
+
+	breakGlassUsernameFlag = breakGlassInitCmd.Flags().StringP("username", "u", "", "username of the administrator [required]")
+	err := breakGlassInitCmd.MarkFlagRequired("username")
+	if err != nil {
+		// This will return error if the flag doesn't exist, so it's ok to panic because it can only happen when changing the code
+		panic(err)
+	}
+	breakGlassForceFlag = breakGlassInitCmd.Flags().Bool("force", false, "replace an existing recovery kit")
+
+	// reset default usage func
+	breakGlassCmd.SetUsageFunc((&cobra.Command{}).UsageFunc())
+}
@@ -21,11 +21,15 @@ import (
 	"github.com/spf13/viper"
 
 	chserver "github.com/IOTech17/neo-rport/server"
+	"github.com/IOTech17/neo-rport/server/accessrequests"
+	"github.com/IOTech17/neo-rport/server/alerts"
 	"github.com/IOTech17/neo-rport/server/api/message"
 	auditlog "github.com/IOTech17/neo-rport/server/auditlog/config"
 	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/clients/versionpolicy"
 	chshare "github.com/IOTech17/neo-rport/share"
 	"github.com/IOTech17/neo-rport/share/files"
+	"github.com/IOTech17/neo-rport/share/fips"
 )
 
 const (
@@ -34,8 +38,12 @@ const (
 
 	DefaultKeepDisconnectedClients          = time.Hour
 	DefaultPurgeDisconnectedClientsInterval = 1 * time.Minute
+	DefaultEphemeralClientsGracePeriod      = 1 * time.Minute
 	DefaultCheckClientsConnectionInterval   = 5 * time.Minute
 	DefaultCheckClientsConnectionTimeout    = 30 * time.Second
+	DefaultTunnelConnectionsRetention       = 30 * 24 * time.Hour
+	DefaultSerialSessionsRetention          = 90 * 24 * time.Hour
+	DefaultIndustrialValuesRetention        = 30 * 24 * time.Hour
 	DefaultMaxRequestBytes                  = 10 * 1024       // 10 KB
 	DefaultMaxRequestBytesClient            = 512 * 1024      // 512KB
 	DefaultMaxFilePushBytes                 = int64(10 << 20) // 10M
@@ -47,6 +55,7 @@ const (
 	DefaultRunRemoteCmdTimeoutSec           = 60
 	DefaultMonitoringDataStorageDuration    = "7d"
 	DefaultPairingURL                       = "https://pairing.rport.io"
+	EnvVarPrefix                            = "RPORTD"
 )
 
 var (
@@ -79,6 +88,14 @@ var serverHelp = `
     ./rportd user
     commands for user management, run './rportd user help' for more options
 
+    ./rportd check-config -c /etc/rport/rportd.conf
+    validates the configuration file and checks the database, smtp, certificates, plugin and listen addresses without
+    starting the server
+
+    ./rportd migrate -c /etc/rport/rportd.conf --from https://old-server:3000 -u admin
+    imports client credentials, client groups, user groups, the library, clients and jobs from another server,
+    run './rportd migrate --help' for more options
+
   Options:
 
     --addr, -a, Defines the IP address and port the HTTP server listens on.
@@ -215,11 +232,19 @@ var serverHelp = `
     --allow-root, An optional arg to allow running rportd as root. There is no technical requirement to run the rport
     server under the root user. Running it as root is an unnecessary security risk.
 
+    --role, An optional arg to define which part of the server this process runs. "all" runs everything in one process.
+    "connector" holds the client connections and serves the API to the API gateways. "api" runs a stateless API gateway
+    forwarding to the connector given by "connector_api_url". Defaults to "all".
+
     --service, Manages rportd running as a service. Possible commands are "install", "uninstall", "start" and "stop".
     The only arguments compatible with --service are --service-user and --config, others will be ignored.
 
     --service-user, An optional arg specifying user to run rportd service under. Only on linux. Defaults to rport.
 
+    --skip-preflight, An optional arg to start without the checks of check-config. By default rportd doesn't start if
+    a check fails, e.g. the schema of a database is newer than supported, a certificate expired or a listen address is
+    in use, and prints the report as json to stderr.
+
     --log-level, Specify log level. Values: "error", "info", "debug" (defaults to "info")
 
     --log-file, -l, Specifies log file path. (defaults to empty string: log printed to stdout)
@@ -246,6 +271,12 @@ var serverHelp = `
 
     --version, Print version info and exit
 
+  Environment variables:
+    Every option of the config file can be set by an environment variable named RPORTD_<SECTION>_<OPTION>
+    in upper case, e.g. RPORTD_SERVER_DATA_DIR or RPORTD_API_JWT_SECRET. Dashes are replaced by underscores,
+    e.g. RPORTD_PLUS_PLUGIN_PLUGIN_PATH. Lists are given comma separated.
+    Append _FILE to read the value from a file instead, e.g. RPORTD_API_JWT_SECRET_FILE=/run/secrets/jwt.
+
   Signals:
     The rportd process is listening for SIGUSR2 to print process stats
 
@@ -257,8 +288,9 @@ var (
 	viperCfg *viper.Viper
 	cfg      = &chconfig.Config{}
 
-	svcCommand *string
-	svcUser    *string
+	svcCommand    *string
+	svcUser       *string
+	skipPreflight *bool
 )
 
 func init() {
@@ -268,6 +300,7 @@ func init() {
 		Version: chshare.BuildVersion,
 		Run:     runMain,
 	}
+	RootCmd.AddCommand(breakGlassCmd)
 
 	// lFlags are used only when starting server
 	// pFlags are used when running subcommands like user as well
@@ -311,6 +344,7 @@ func init() {
 	lFlags.Bool("equate-clientauthid-clientid", false, "")
 	lFlags.Int("run-remote-cmd-timeout-sec", 0, "")
 	lFlags.Bool("allow-root", false, "")
+	lFlags.String("role", "", "")
 	lFlags.Int64("monitoring-data-storage-days", 0, "")
 	lFlags.String("tunnel-proxy-cert-file", "", "")
 	lFlags.String("tunnel-proxy-key-file", "", "")
@@ -319,6 +353,7 @@ func init() {
 
 	cfgPath = pFlags.StringP("config", "c", "", "location of the config file")
 	svcCommand = lFlags.String("service", "", "")
+	skipPreflight = lFlags.Bool("skip-preflight", false, "")
 	if runtime.GOOS != "windows" {
 		svcUser = lFlags.String("service-user", "rport", "")
 	}
@@ -329,53 +364,84 @@ func init() {
 		return nil
 	})
 
-	viperCfg = viper.New()
-	viperCfg.SetConfigType("toml")
-
-	viperCfg.SetDefault("logging.log_level", DefaultLogLevel)
-	viperCfg.SetDefault("server.address", DefaultServerAddress)
-	viperCfg.SetDefault("server.used_ports", []string{DefaultUsedPorts})
-	viperCfg.SetDefault("server.excluded_ports", []string{DefaultExcludedPorts})
-	viperCfg.SetDefault("server.data_dir", chserver.DefaultDataDirectory)
-	viperCfg.SetDefault("server.sqlite_wal", true)
-	viperCfg.SetDefault("server.keep_disconnected_clients", DefaultKeepDisconnectedClients)
-	viperCfg.SetDefault("server.max_concurrent_ssh_handshakes", DefaultMaxConcurrentSSHConnectionHandshakes)
-	viperCfg.SetDefault("server.purge_disconnected_clients_interval", DefaultPurgeDisconnectedClientsInterval)
-	viperCfg.SetDefault("server.check_clients_connection_interval", DefaultCheckClientsConnectionInterval)
-	viperCfg.SetDefault("server.check_clients_connection_timeout", DefaultCheckClientsConnectionTimeout)
-	viperCfg.SetDefault("server.max_request_bytes_client", DefaultMaxRequestBytesClient)
-	viperCfg.SetDefault("server.check_port_timeout", DefaultCheckPortTimeout)
-	viperCfg.SetDefault("server.auth_write", true)
-	viperCfg.SetDefault("server.auth_multiuse_creds", true)
-	viperCfg.SetDefault("server.run_remote_cmd_timeout_sec", DefaultRunRemoteCmdTimeoutSec)
-	viperCfg.SetDefault("server.client_login_wait", 2)
-	viperCfg.SetDefault("server.max_failed_login", 5)
-	viperCfg.SetDefault("server.pairing_url", DefaultPairingURL)
-	viperCfg.SetDefault("server.ban_time", 3600)
-	viperCfg.SetDefault("server.jobs_max_results", 10000)
-	viperCfg.SetDefault("server.tls_min", "1.3")
-	viperCfg.SetDefault("api.user_header", "Authentication-User")
-	viperCfg.SetDefault("api.default_user_group", "Administrators")
-	viperCfg.SetDefault("api.user_login_wait", 2)
-	viperCfg.SetDefault("api.max_failed_login", 10)
-	viperCfg.SetDefault("api.ban_time", 600)
-	viperCfg.SetDefault("api.two_fa_token_ttl_seconds", 600)
-	viperCfg.SetDefault("api.two_fa_send_timeout", 10*time.Second)
-	viperCfg.SetDefault("api.two_fa_send_to_type", message.ValidationNone)
-	viperCfg.SetDefault("api.enable_audit_log", true)
-	viperCfg.SetDefault("api.totp_enabled", false)
-	viperCfg.SetDefault("api.audit_log_rotation", auditlog.RotationMonthly)
-	viperCfg.SetDefault("monitoring.data_storage_duration", DefaultMonitoringDataStorageDuration)
-	viperCfg.SetDefault("monitoring.enabled", true)
-	viperCfg.SetDefault("api.max_request_bytes", DefaultMaxRequestBytes)
-	viperCfg.SetDefault("api.max_filepush_size", DefaultMaxFilePushBytes)
-	viperCfg.SetDefault("api.enable_ws_test_endpoints", false)
-	viperCfg.SetDefault("api.totp_login_session_ttl", time.Minute*10)
-	viperCfg.SetDefault("api.totp_account_name", "RPort")
-	viperCfg.SetDefault("api.password_min_length", 14)
-	viperCfg.SetDefault("api.password_zxcvbn_minscore", 0)
-	viperCfg.SetDefault("api.tls_min", "1.3")
-	viperCfg.SetDefault("notifications.notification_script_dir", "/usr/local/lib/rport/notification_scripts")
+	viperCfg = newViperConfig()
+}
+
+// newViperConfig returns the viper config of the server with the defaults of all settings.
+func newViperConfig() *viper.Viper {
+	v := viper.New()
+	v.SetConfigType("toml")
+
+	v.SetDefault("logging.log_level", DefaultLogLevel)
+	v.SetDefault("server.address", DefaultServerAddress)
+	v.SetDefault("server.used_ports", []string{DefaultUsedPorts})
+	v.SetDefault("server.excluded_ports", []string{DefaultExcludedPorts})
+	v.SetDefault("server.data_dir", chserver.DefaultDataDirectory)
+	v.SetDefault("server.sqlite_wal", true)
+	v.SetDefault("server.keep_disconnected_clients", DefaultKeepDisconnectedClients)
+	v.SetDefault("server.max_concurrent_ssh_handshakes", DefaultMaxConcurrentSSHConnectionHandshakes)
+	v.SetDefault("server.purge_disconnected_clients_interval", DefaultPurgeDisconnectedClientsInterval)
+	v.SetDefault("server.ephemeral_clients_grace_period", DefaultEphemeralClientsGracePeriod)
+	v.SetDefault("server.check_clients_connection_interval", DefaultCheckClientsConnectionInterval)
+	v.SetDefault("server.check_clients_connection_timeout", DefaultCheckClientsConnectionTimeout)
+	v.SetDefault("server.tunnel_connections_retention", DefaultTunnelConnectionsRetention)
+	v.SetDefault("server.serial_sessions_retention", DefaultSerialSessionsRetention)
+	v.SetDefault("server.industrial_values_retention", DefaultIndustrialValuesRetention)
+	v.SetDefault("server.max_request_bytes_client", DefaultMaxRequestBytesClient)
+	v.SetDefault("server.check_port_timeout", DefaultCheckPortTimeout)
+	v.SetDefault("server.auth_write", true)
+	v.SetDefault("server.auth_multiuse_creds", true)
+	v.SetDefault("server.run_remote_cmd_timeout_sec", DefaultRunRemoteCmdTimeoutSec)
+	v.SetDefault("server.client_login_wait", 2)
+	v.SetDefault("server.max_failed_login", 5)
+	v.SetDefault("server.pairing_url", DefaultPairingURL)
+	v.SetDefault("server.ban_time", 3600)
+	v.SetDefault("server.jobs_max_results", 10000)
+	v.SetDefault("server.tls_min", "1.3")
+	v.SetDefault("server.rdp_clipboard_max_bytes", 256*1024)
+	v.SetDefault("server.rdp_file_drop_max_bytes", 100*1024*1024)
+	v.SetDefault("server.client_version_action", versionpolicy.ActionWarn)
+	v.SetDefault("api.user_header", "Authentication-User")
+	v.SetDefault("api.default_user_group", "Administrators")
+	v.SetDefault("api.user_login_wait", 2)
+	v.SetDefault("api.max_failed_login", 10)
+	v.SetDefault("api.ban_time", 600)
+	v.SetDefault("api.two_fa_token_ttl_seconds", 600)
+	v.SetDefault("api.two_fa_send_timeout", 10*time.Second)
+	v.SetDefault("api.two_fa_send_to_type", message.ValidationNone)
+	v.SetDefault("api.enable_audit_log", true)
+	v.SetDefault("api.totp_enabled", false)
+	v.SetDefault("api.audit_log_rotation", auditlog.RotationMonthly)
+	v.SetDefault("api.audit_log_anchor_s3_retention_days", 365)
+	v.SetDefault("api.request_log_sample_rate", 1)
+	v.SetDefault("api.request_log_max_body_bytes", 4096)
+	v.SetDefault("api.request_log_max_file_mb", 100)
+	v.SetDefault("api.request_log_max_backups", 5)
+	v.SetDefault("monitoring.data_storage_duration", DefaultMonitoringDataStorageDuration)
+	v.SetDefault("monitoring.enabled", true)
+	v.SetDefault("api.max_request_bytes", DefaultMaxRequestBytes)
+	v.SetDefault("api.max_filepush_size", DefaultMaxFilePushBytes)
+	v.SetDefault("api.enable_ws_test_endpoints", false)
+	v.SetDefault("api.totp_login_session_ttl", time.Minute*10)
+	v.SetDefault("api.totp_account_name", "RPort")
+	v.SetDefault("api.password_min_length", 14)
+	v.SetDefault("api.password_zxcvbn_minscore", 0)
+	v.SetDefault("api.tls_min", "1.3")
+	v.SetDefault("api.change_journal_retention", 24*time.Hour)
+	v.SetDefault("manifests.reconcile_interval", time.Minute)
+	v.SetDefault("notifications.notification_script_dir", "/usr/local/lib/rport/notification_scripts")
+	v.SetDefault("secrets-scanning.enabled", true)
+	v.SetDefault("alerting.dedup_window", "10m")
+	v.SetDefault("alerting.flap_window", "30m")
+	v.SetDefault("alerting.flap_threshold", 5)
+	v.SetDefault("alerting.dependency_mode", "suppress")
+	v.SetDefault("alerting.webhook_client_labels", alerts.DefaultWebhookClientLabels)
+	v.SetDefault("alerting.baseline_window", "24h")
+	v.SetDefault("alerting.anomaly_sensitivity", 3)
+	v.SetDefault("alerting.baseline_min_samples", 60)
+	v.SetDefault("access-requests.max_duration", accessrequests.DefaultMaxDuration)
+
+	return v
 }
 
 func bindPFlags() {
@@ -399,6 +465,7 @@ func bindPFlags() {
 	_ = viperCfg.BindPFlag("server.check_port_timeout", pFlags.Lookup("check-port-timeout"))
 	_ = viperCfg.BindPFlag("server.run_remote_cmd_timeout_sec", pFlags.Lookup("run-remote-cmd-timeout-sec"))
 	_ = viperCfg.BindPFlag("server.allow_root", pFlags.Lookup("allow-root"))
+	_ = viperCfg.BindPFlag("server.role", pFlags.Lookup("role"))
 	_ = viperCfg.BindPFlag("server.tunnel_proxy_cert_file", pFlags.Lookup("tunnel-proxy-cert-file"))
 	_ = viperCfg.BindPFlag("server.tunnel_proxy_key_file", pFlags.Lookup("tunnel-proxy-key-file"))
 	_ = viperCfg.BindPFlag("server.novnc_root", pFlags.Lookup("novnc-root"))
@@ -445,6 +512,10 @@ func decodeAndValidateConfig(mLog *logger.MemLogger) error {
 		viperCfg.SetConfigName("rportd.conf")
 	}
 
+	if err := chshare.BindEnvVars(viperCfg, EnvVarPrefix, cfg); err != nil {
+		return err
+	}
+
 	if err := chshare.DecodeViperConfig(viperCfg, cfg, nil); err != nil {
 		return err
 	}
@@ -500,6 +571,13 @@ func runMain(*cobra.Command, []string) {
 	initLogger := logger.NewLogger("server-init", cfg.Logging.LogOutput, cfg.Logging.LogLevel)
 	mLog.Flush(initLogger)
 
+	if cfg.Server.FIPSMode {
+		fips.Enable()
+	}
+	if fips.Enabled() {
+		initLogger.Infof("FIPS mode enabled, BoringCrypto: %t", fips.BoringCrypto())
+	}
+
 	filesAPI := files.NewFileSystem()
 
 	// this ctx will be used to co-ordinate shutdown of the various server go-routines
@@ -521,14 +599,24 @@ func runMain(*cobra.Command, []string) {
 		canceled.Store(true)
 	}()
 
+	if cfg.Server.IsAPIGateway() {
+		runAPIGateway(ctx)
+		return
+	}
+
+	if !*skipPreflight {
+		runPreflight(ctx, initLogger)
+	}
+
 	plusManager, err := chserver.EnablePlusIfAvailable(ctx, cfg, filesAPI)
 	if err != nil && err != chserver.ErrPlusNotEnabled {
 		log.Fatal(err)
 	}
 
 	s, err := chserver.NewServer(ctx, cfg, &chserver.ServerOpts{
-		FilesAPI:    filesAPI,
-		PlusManager: plusManager,
+		FilesAPI:        filesAPI,
+		PlusManager:     plusManager,
+		ConfigValidator: validateConfigContent,
 	})
 	if err != nil {
 		log.Fatal(err)
@@ -577,6 +665,26 @@ func runMain(*cobra.Command, []string) {
 	}
 }
 
+func runAPIGateway(ctx context.Context) {
+	g, err := chserver.NewAPIGateway(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if !service.Interactive() {
+		err = servicemanagement.RunAsService(g, *cfgPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	err = g.Run(ctx)
+	if err != nil && !strings.Contains(err.Error(), "context canceled") {
+		log.Fatal(err)
+	}
+}
+
 func WriteMemoryProfile(l *logger.Logger) {
 	l.Debugf("writing mem.rportd.prof")
 	memf, err := os.Create("/var/lib/rport/mem.rportd.prof")
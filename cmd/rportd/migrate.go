@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path"
+	"syscall"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/IOTech17/neo-rport/db/migration/client_groups"
+	clientsmigration "github.com/IOTech17/neo-rport/db/migration/clients"
+	jobsmigration "github.com/IOTech17/neo-rport/db/migration/jobs"
+	"github.com/IOTech17/neo-rport/db/migration/library"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	chserver "github.com/IOTech17/neo-rport/server"
+	"github.com/IOTech17/neo-rport/server/api/command"
+	"github.com/IOTech17/neo-rport/server/api/jobs"
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/bulk"
+	"github.com/IOTech17/neo-rport/server/cgroups"
+	"github.com/IOTech17/neo-rport/server/clients"
+	"github.com/IOTech17/neo-rport/server/migrate"
+	"github.com/IOTech17/neo-rport/server/script"
+	"github.com/IOTech17/neo-rport/share/logger"
+)
+
+const migratePasswordEnv = "RPORT_MIGRATE_PASSWORD"
+
+var (
+	migrateCmd = &cobra.Command{
+		Use:   "migrate",
+		Short: "import the data of another rport server",
+		Long: "Import client auth credentials, client groups, user groups, the command and script library, clients and their jobs from another rport or neo-rport server via its API. " +
+			"Stop rportd before migrating, the imported data is loaded on start. The password or API token of the user is read from " + migratePasswordEnv + " or prompted for.",
+		Example: "rportd migrate -c /etc/rport/rportd.conf --from https://old-server:3000 -u admin --dry-run",
+		Run: func(*cobra.Command, []string) {
+			mLog := logger.NewMemLogger()
+			if err := decodeAndValidateConfig(&mLog); err != nil {
+				log.Fatalf("Invalid config: %v. See rportd --help", err)
+			}
+			if !bulk.IsStrategy(*migrateStrategyFlag) {
+				log.Fatalf("Invalid strategy %q, expected one of %v", *migrateStrategyFlag, bulk.Strategies)
+			}
+			if err := migrate.ValidateResources(*migrateOnlyFlag); err != nil {
+				log.Fatal(err)
+			}
+
+			password, err := migratePassword()
+			if err != nil {
+				log.Fatal(err)
+			}
+			src, err := migrate.NewSource(*migrateFromFlag, *migrateUsernameFlag, password, *migrateInsecureFlag)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			l := logger.NewLogger("migrate", cfg.Logging.LogOutput, cfg.Logging.LogLevel)
+			target, closers, err := openMigrateTarget(l)
+			defer func() {
+				for _, c := range closers {
+					_ = c.Close()
+				}
+			}()
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer cancel()
+
+			opts := migrate.Options{
+				Resources: *migrateOnlyFlag,
+				Strategy:  *migrateStrategyFlag,
+				DryRun:    *migrateDryRunFlag,
+			}
+			err = migrate.Run(ctx, src, target, l, opts, printMigrateReport)
+			if err != nil {
+				log.Fatalf("Migration failed: %v", err)
+			}
+			if opts.DryRun {
+				fmt.Println("Dry run, nothing has been imported.")
+			}
+		},
+	}
+
+	migrateFromFlag     *string
+	migrateUsernameFlag *string
+	migrateOnlyFlag     *[]string
+	migrateStrategyFlag *string
+	migrateDryRunFlag   *bool
+	migrateInsecureFlag *bool
+)
+
+func init() {
+	RootCmd.AddCommand(migrateCmd)
+
+	migrateFromFlag = migrateCmd.Flags().String("from", "", "url of the server to import from, e.g. https://old-server:3000 [required]")
+	migrateUsernameFlag = migrateCmd.Flags().StringP("username", "u", "", "administrator on the server to import from [required]")
+	for _, name := range []string{"from", "username"} {
+		if err := migrateCmd.MarkFlagRequired(name); err != nil {
+			// This will return error if the flag doesn't exist, so it's ok to panic because it can only happen when changing the code
+			panic(err)
+		}
+	}
+	migrateOnlyFlag = migrateCmd.Flags().StringSlice("only", nil, fmt.Sprintf("resources to import, all by default, one of %v", migrate.Resources))
+	migrateStrategyFlag = migrateCmd.Flags().String("strategy", bulk.StrategySkip, fmt.Sprintf("how to handle records that exist already, one of %v", bulk.Strategies))
+	migrateDryRunFlag = migrateCmd.Flags().Bool("dry-run", false, "validate and show what would be imported without importing anything")
+	migrateInsecureFlag = migrateCmd.Flags().Bool("insecure", false, "don't verify the TLS certificate of the server to import from")
+
+	// reset default usage func
+	migrateCmd.SetUsageFunc((&cobra.Command{}).UsageFunc())
+}
+
+func migratePassword() (string, error) {
+	if password := os.Getenv(migratePasswordEnv); password != "" {
+		return password, nil
+	}
+	fmt.Printf("Enter password or API token of %s: ", *migrateUsernameFlag)
+	password, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Print("\n")
+	if err != nil {
+		return "", fmt.Errorf("failed to read password, set %s instead: %v", migratePasswordEnv, err)
+	}
+	return string(password), nil
+}
+
+// openMigrateTarget opens the local stores, resources the config doesn't support are left nil.
+func openMigrateTarget(l *logger.Logger) (*migrate.Target, []io.Closer, error) {
+	var closers []io.Closer
+	target := &migrate.Target{}
+	options := cfg.Server.GetSQLiteDataSourceOptions()
+
+	openSqlite := func(name string, assetNames []string, asset func(string) ([]byte, error)) (*sqlx.DB, error) {
+		db, err := sqlite.New(path.Join(cfg.Server.DataDir, name), assetNames, asset, options)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %v", name, err)
+		}
+		closers = append(closers, db)
+		return db, nil
+	}
+
+	groupsDB, err := openSqlite("client_groups.db", client_groups.AssetNames(), client_groups.Asset)
+	if err != nil {
+		return nil, closers, err
+	}
+	target.ClientGroups, err = cgroups.NewSqliteProvider(groupsDB)
+	if err != nil {
+		return nil, closers, err
+	}
+
+	libraryDB, err := openSqlite("library.db", library.AssetNames(), library.Asset)
+	if err != nil {
+		return nil, closers, err
+	}
+	target.Commands = command.NewSqliteProvider(libraryDB)
+	target.Scripts = script.NewSqliteProvider(libraryDB)
+
+	clientsDB, err := openSqlite("clients.db", clientsmigration.AssetNames(), clientsmigration.Asset)
+	if err != nil {
+		return nil, closers, err
+	}
+	target.Clients = clients.NewSqliteProvider(clientsDB, nil)
+
+	jobsDB, err := openSqlite("jobs.db", jobsmigration.AssetNames(), jobsmigration.Asset)
+	if err != nil {
+		return nil, closers, err
+	}
+	target.Jobs = jobs.NewSqliteProvider(jobsDB, l)
+
+	var authDB *sqlx.DB
+	if cfg.Database.Driver != "" {
+		authDB, err = sqlx.Connect(cfg.Database.Driver, cfg.Database.Dsn)
+		if err != nil {
+			return nil, closers, fmt.Errorf("could not connect to the database: %v", err)
+		}
+		closers = append(closers, authDB)
+	}
+
+	if cfg.Server.AuthWrite {
+		target.ClientsAuth, err = chserver.NewClientAuthProvider(cfg, authDB)
+		if err != nil {
+			return nil, closers, err
+		}
+	}
+
+	// user groups are only supported with the group details table
+	if cfg.API.AuthGroupDetailsTable != "" {
+		target.UserGroups, err = users.NewAPIServiceFromConfig(authDB, cfg)
+		if err != nil {
+			return nil, closers, err
+		}
+	}
+
+	return target, closers, nil
+}
+
+func printMigrateReport(r *migrate.Report) {
+	if r.Result == nil {
+		fmt.Printf("%-14s skipped, %s\n", r.Resource+":", r.SkipReason)
+		return
+	}
+	fmt.Printf("%-14s %d created, %d updated, %d skipped\n", r.Resource+":", len(r.Result.Created), len(r.Result.Updated), len(r.Result.Skipped))
+}
@@ -7,7 +7,6 @@ import (
 
 	"github.com/kardianos/service"
 
-	chserver "github.com/IOTech17/neo-rport/server"
 	chshare "github.com/IOTech17/neo-rport/share"
 )
 
@@ -30,7 +29,13 @@ func HandleSvcCommand(svcCommand string, configPath string, user *string) error
 	return chshare.HandleServiceCommand(svc, svcCommand)
 }
 
-func RunAsService(s *chserver.Server, configPath string) error {
+// Runner is the server process run as a service, either the full server or an API gateway.
+type Runner interface {
+	Run(ctx context.Context) error
+	Close() error
+}
+
+func RunAsService(s Runner, configPath string) error {
 	svc, err := getService(s, configPath, nil)
 	if err != nil {
 		return err
@@ -39,7 +44,7 @@ func RunAsService(s *chserver.Server, configPath string) error {
 	return svc.Run()
 }
 
-func getService(s *chserver.Server, configPath string, user *string) (service.Service, error) {
+func getService(s Runner, configPath string, user *string) (service.Service, error) {
 	absConfigPath, err := filepath.Abs(configPath)
 	if err != nil {
 		return nil, err
@@ -52,16 +57,16 @@ func getService(s *chserver.Server, configPath string, user *string) (service.Se
 }
 
 type serviceWrapper struct {
-	*chserver.Server
+	Runner
 }
 
 func (w *serviceWrapper) Start(service.Service) error {
-	if w.Server == nil {
+	if w.Runner == nil {
 		return nil
 	}
 	go func() {
 		ctx := context.Background()
-		if err := w.Server.Run(ctx); err != nil {
+		if err := w.Runner.Run(ctx); err != nil {
 			log.Println(err)
 		}
 	}()
@@ -69,5 +74,5 @@ func (w *serviceWrapper) Start(service.Service) error {
 }
 
 func (w *serviceWrapper) Stop(service.Service) error {
-	return w.Server.Close()
+	return w.Runner.Close()
 }
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/configcheck"
+	chshare "github.com/IOTech17/neo-rport/share"
+	"github.com/IOTech17/neo-rport/share/logger"
+)
+
+var (
+	checkConfigCmd = &cobra.Command{
+		Use:     "check-config",
+		Short:   "validate the config file",
+		Long:    "Validate the config file and check the database, smtp server, oauth providers, ports and listen addresses without starting the server",
+		Example: "rportd check-config -c /etc/rport/rportd.conf",
+		Run: func(*cobra.Command, []string) {
+			mLog := logger.NewMemLogger()
+			var report *configcheck.Report
+			if err := decodeAndValidateConfig(&mLog); err != nil {
+				report = configcheck.Invalid(err)
+			} else {
+				report = configcheck.Run(context.Background(), cfg, configcheck.Options{})
+			}
+
+			if *checkConfigJSONFlag {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				_ = enc.Encode(report)
+			} else {
+				printReport(report)
+			}
+			if !report.Valid {
+				os.Exit(1)
+			}
+		},
+	}
+
+	checkConfigJSONFlag *bool
+)
+
+func init() {
+	RootCmd.AddCommand(checkConfigCmd)
+
+	checkConfigJSONFlag = checkConfigCmd.Flags().Bool("json", false, "print the report as json")
+
+	// reset default usage func
+	checkConfigCmd.SetUsageFunc((&cobra.Command{}).UsageFunc())
+}
+
+func printReport(report *configcheck.Report) {
+	for _, r := range report.Results {
+		fmt.Printf("%-9s %s: %s\n", "["+r.Status+"]", r.Check, r.Message)
+		if r.Hint != "" {
+			fmt.Printf("%-9s hint: %s\n", "", r.Hint)
+		}
+	}
+	if report.Valid {
+		fmt.Println("The config is valid.")
+	} else {
+		fmt.Println("The config is invalid.")
+	}
+}
+
+// runPreflight runs the checks of check-config on start, so the server fails fast instead of starting partially.
+func runPreflight(ctx context.Context, l *logger.Logger) {
+	report := configcheck.Run(ctx, cfg, configcheck.Options{Startup: true})
+	for _, r := range report.Results {
+		switch r.Status {
+		case configcheck.StatusError:
+			l.Errorf("preflight %s: %s", r.Check, r.Message)
+		case configcheck.StatusWarning:
+			l.Infof("preflight %s: %s", r.Check, r.Message)
+		default:
+			l.Debugf("preflight %s: %s", r.Check, r.Message)
+		}
+	}
+	if report.Valid {
+		return
+	}
+
+	enc := json.NewEncoder(os.Stderr)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(report)
+	l.Errorf("preflight failed, fix the errors or start with --skip-preflight")
+	cfg.Logging.LogOutput.Shutdown()
+	os.Exit(1)
+}
+
+// validateConfigContent decodes and validates the content of a config file like on start of the server, with the
+// same defaults and environment variables.
+func validateConfigContent(content []byte) (*chconfig.Config, error) {
+	v := newViperConfig()
+	c := &chconfig.Config{}
+	if err := chshare.BindEnvVars(v, EnvVarPrefix, c); err != nil {
+		return nil, err
+	}
+	if err := chshare.DecodeViperConfig(v, c, bytes.NewReader(content)); err != nil {
+		return nil, err
+	}
+	mLog := logger.NewMemLogger()
+	if err := c.ParseAndValidate(&mLog); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
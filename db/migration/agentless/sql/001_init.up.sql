@@ -0,0 +1,20 @@
+CREATE TABLE targets (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    tags TEXT NOT NULL DEFAULT '[]',
+    protocol TEXT NOT NULL,
+    host TEXT NOT NULL,
+    port INTEGER NOT NULL,
+    username TEXT NOT NULL,
+    vault_value_id INTEGER NOT NULL,
+    jump_client_id TEXT NOT NULL,
+    host_key TEXT NOT NULL DEFAULT '',
+    monitoring INTEGER NOT NULL DEFAULT 0,
+    last_seen_at DATETIME,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_by TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX targets_jump_client_id ON targets(jump_client_id);
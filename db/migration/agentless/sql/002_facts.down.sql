@@ -0,0 +1,2 @@
+ALTER TABLE targets DROP COLUMN facts_updated_at;
+ALTER TABLE targets DROP COLUMN facts;
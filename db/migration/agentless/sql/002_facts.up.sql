@@ -0,0 +1,2 @@
+ALTER TABLE targets ADD COLUMN facts TEXT NOT NULL DEFAULT '{}';
+ALTER TABLE targets ADD COLUMN facts_updated_at DATETIME;
@@ -0,0 +1,7 @@
+CREATE TABLE group_rules (
+    id TEXT PRIMARY KEY NOT NULL,
+    group_id TEXT NOT NULL,
+    expr TEXT NOT NULL,
+    severity TEXT NOT NULL,
+    recipients TEXT NOT NULL DEFAULT '[]'
+);
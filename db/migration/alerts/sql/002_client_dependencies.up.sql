@@ -0,0 +1,4 @@
+CREATE TABLE client_dependencies (
+    client_id TEXT PRIMARY KEY NOT NULL,
+    parent_id TEXT NOT NULL
+);
@@ -0,0 +1,2 @@
+DROP TABLE problem_events;
+DROP TABLE problem_history;
@@ -0,0 +1,27 @@
+CREATE TABLE problem_history (
+    problem_id TEXT PRIMARY KEY NOT NULL,
+    source TEXT NOT NULL,
+    rule_id TEXT NOT NULL,
+    client_id TEXT NOT NULL DEFAULT '',
+    group_id TEXT NOT NULL DEFAULT '',
+    opened_at DATETIME NOT NULL,
+    notified_at DATETIME DEFAULT NULL,
+    acknowledged_at DATETIME DEFAULT NULL,
+    acknowledged_by TEXT NOT NULL DEFAULT '',
+    escalated_at DATETIME DEFAULT NULL,
+    resolved_at DATETIME DEFAULT NULL
+);
+CREATE INDEX problem_history_opened_at ON problem_history (opened_at);
+CREATE INDEX problem_history_client_id ON problem_history (client_id);
+
+CREATE TABLE problem_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    problem_id TEXT NOT NULL,
+    client_id TEXT NOT NULL DEFAULT '',
+    event TEXT NOT NULL,
+    timestamp DATETIME NOT NULL,
+    username TEXT NOT NULL DEFAULT '',
+    details TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX problem_events_problem_id ON problem_events (problem_id);
+CREATE INDEX problem_events_client_id_timestamp ON problem_events (client_id, timestamp);
@@ -0,0 +1 @@
+ALTER TABLE problem_history DROP COLUMN summary;
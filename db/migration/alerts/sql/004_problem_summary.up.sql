@@ -0,0 +1 @@
+ALTER TABLE problem_history ADD COLUMN summary TEXT NOT NULL DEFAULT '';
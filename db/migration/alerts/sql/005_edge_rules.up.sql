@@ -0,0 +1,11 @@
+CREATE TABLE edge_rules (
+    id TEXT PRIMARY KEY NOT NULL,
+    group_id TEXT NOT NULL,
+    expr TEXT NOT NULL,
+    for_sec INTEGER NOT NULL DEFAULT 0,
+    severity TEXT NOT NULL,
+    recipients TEXT NOT NULL DEFAULT '[]',
+    script TEXT NOT NULL DEFAULT '',
+    interpreter TEXT NOT NULL DEFAULT '',
+    timeout_sec INTEGER NOT NULL DEFAULT 0
+);
@@ -0,0 +1,2 @@
+DROP TABLE remediation_attempts;
+DROP TABLE remediations;
@@ -0,0 +1,19 @@
+CREATE TABLE remediations (
+    id TEXT PRIMARY KEY NOT NULL,
+    source TEXT NOT NULL,
+    rule_id TEXT NOT NULL,
+    script TEXT NOT NULL,
+    interpreter TEXT NOT NULL DEFAULT '',
+    timeout_sec INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL,
+    window_sec INTEGER NOT NULL
+);
+CREATE INDEX remediations_rule ON remediations (source, rule_id);
+
+CREATE TABLE remediation_attempts (
+    remediation_id TEXT NOT NULL,
+    client_id TEXT NOT NULL,
+    problem_id TEXT NOT NULL,
+    started_at DATETIME NOT NULL
+);
+CREATE INDEX remediation_attempts_client ON remediation_attempts (remediation_id, client_id, started_at);
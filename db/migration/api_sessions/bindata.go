@@ -4,6 +4,8 @@
 // 001_init.up.sql (195B)
 // 002_extended_session_tracking.down.sql (231B)
 // 002_extended_session_tracking.up.sql (490B)
+// 003_session_policies.down.sql (85B)
+// 003_session_policies.up.sql (400B)
 
 package api_sessions
 
@@ -151,6 +153,46 @@ func _002_extended_session_trackingUpSql() (*asset, error) {
 	return a, nil
 }
 
+var __003_session_policiesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x09\xf2\x0f\x50\x08\x71\x74\xf2\x71\x55\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x28\x2d\x4e\x2d\x8a\xcf\xc9\x4f\xcf\xcc\x8b\x4f\x49\x2d\xcb\x4c\x4e\x2d\xb6\xe6\xc2\xad\xb0\x38\xb5\xb8\x38\x33\x3f\x2f\xbe\x20\x3f\x27\x33\x39\x13\xa4\x16\x30\x00\x09\xb2\xce\xdb\x55\x00\x00\x00")
+
+func _003_session_policiesDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__003_session_policiesDownSql,
+		"003_session_policies.down.sql",
+	)
+}
+
+func _003_session_policiesDownSql() (*asset, error) {
+	bytes, err := _003_session_policiesDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "003_session_policies.down.sql", size: 85, mode: os.FileMode(0644), modTime: time.Unix(1685339919, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x52, 0xf, 0xf6, 0x7b, 0xee, 0xd1, 0x26, 0x74, 0xd2, 0x3f, 0x3, 0xcc, 0x4f, 0x87, 0x9, 0xdc, 0xe5, 0x47, 0x9c, 0x3d, 0xfe, 0x33, 0x7, 0xc1, 0x38, 0xd1, 0x3f, 0x72, 0xce, 0x3f, 0x13, 0x19}}
+	return a, nil
+}
+
+var __003_session_policiesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x84\x90\x41\x4b\xc4\x30\x10\x46\xef\xf9\x15\x73\xdc\x85\x1e\xbc\x7b\x8a\xee\x28\xc5\x6c\x95\x30\x05\x7b\x0a\xa1\x1d\x4b\xa0\x4d\x4b\xa7\x8a\xfe\x7b\x31\x52\x69\xb5\xb2\xe7\xf7\xf2\xe5\x25\xb7\x16\x35\x21\x90\xbe\x31\x08\xaf\xc2\x93\x13\x16\x09\x43\x74\xe3\xd0\x85\x3a\xb0\xc0\x41\x01\x40\x62\xd1\xf7\x0c\x84\xcf\x04\x4f\x36\x3f\x6b\x5b\xc1\x03\x56\x50\x3c\x12\x14\xa5\x31\x59\xf2\xd2\xb1\x8f\x6f\x6b\x4b\x7a\xff\xbe\x8c\x0b\xe4\x05\xe1\x3d\xda\x1f\x05\x4e\x78\xa7\x4b\x43\x70\xa5\x8e\xd7\x4a\xfd\xcd\xea\x86\x36\x44\xd7\xf0\x5b\xa8\xff\x69\xda\xde\x16\x46\xe7\x9b\x66\x62\x91\x3d\x9a\x26\x7d\xcb\x71\xde\xa3\x2f\x61\x92\xd9\x09\x73\x74\x7e\x86\x93\x26\xa4\xfc\x8c\xbf\xa4\xce\x5f\x76\xd6\xdf\x74\x58\x6a\xb3\x55\x5b\xb6\x2a\x39\x7e\xbd\xfc\x73\x00\x4c\xe1\x20\x89\x90\x01\x00\x00")
+
+func _003_session_policiesUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__003_session_policiesUpSql,
+		"003_session_policies.up.sql",
+	)
+}
+
+func _003_session_policiesUpSql() (*asset, error) {
+	bytes, err := _003_session_policiesUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "003_session_policies.up.sql", size: 400, mode: os.FileMode(0644), modTime: time.Unix(1685339919, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x71, 0xf6, 0x87, 0x86, 0x7c, 0xc1, 0xed, 0xcc, 0x9b, 0x84, 0xe2, 0x6b, 0x9f, 0xe4, 0x27, 0x80, 0xed, 0xce, 0xa2, 0x44, 0x0, 0x8a, 0xb4, 0x5d, 0x23, 0x2f, 0xb0, 0xa7, 0xac, 0x6b, 0x69, 0x8e}}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -246,6 +288,8 @@ var _bindata = map[string]func() (*asset, error){
 	"001_init.up.sql":                        _001_initUpSql,
 	"002_extended_session_tracking.down.sql": _002_extended_session_trackingDownSql,
 	"002_extended_session_tracking.up.sql":   _002_extended_session_trackingUpSql,
+	"003_session_policies.down.sql":          _003_session_policiesDownSql,
+	"003_session_policies.up.sql":            _003_session_policiesUpSql,
 }
 
 // AssetDebug is true if the assets were built with the debug flag enabled.
@@ -298,6 +342,8 @@ var _bintree = &bintree{nil, map[string]*bintree{
 	"001_init.up.sql":                        {_001_initUpSql, map[string]*bintree{}},
 	"002_extended_session_tracking.down.sql": {_002_extended_session_trackingDownSql, map[string]*bintree{}},
 	"002_extended_session_tracking.up.sql":   {_002_extended_session_trackingUpSql, map[string]*bintree{}},
+	"003_session_policies.down.sql":          {_003_session_policiesDownSql, map[string]*bintree{}},
+	"003_session_policies.up.sql":            {_003_session_policiesUpSql, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory.
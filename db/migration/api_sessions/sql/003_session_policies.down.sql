@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS user_login_devices;
+DROP TABLE IF EXISTS user_session_policies;
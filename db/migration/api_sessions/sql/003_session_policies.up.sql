@@ -0,0 +1,14 @@
+CREATE TABLE user_session_policies (
+    username TEXT PRIMARY KEY NOT NULL,
+    policy TEXT NOT NULL,
+    max_sessions INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE user_login_devices (
+    username TEXT NOT NULL,
+    ip_address TEXT NOT NULL,
+    user_agent TEXT NOT NULL,
+    first_seen_at DATETIME NOT NULL,
+    last_seen_at DATETIME NOT NULL,
+    PRIMARY KEY (username, ip_address, user_agent)
+);
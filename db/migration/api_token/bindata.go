@@ -6,6 +6,12 @@
 // 002_plural_and_name.up.sql (169B)
 // 003_init.down.sql (57B)
 // 003_init.up.sql (513B)
+// 004_derived_tokens.down.sql (148B)
+// 004_derived_tokens.up.sql (159B)
+// 005_broker_grants.down.sql (80B)
+// 005_broker_grants.up.sql (674B)
+// 006_access_requests.down.sql (38B)
+// 006_access_requests.up.sql (656B)
 
 package api_token
 
@@ -193,6 +199,126 @@ func _003_initUpSql() (*asset, error) {
 	return a, nil
 }
 
+var __004_derived_tokensDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x2c\xc8\x8c\x2f\xc9\xcf\x4e\xcd\x2b\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\x48\xce\xc9\x4c\xcd\x2b\x89\x4f\x2f\xca\x2f\x2d\x28\xb6\xe6\x22\x42\x47\x41\x6a\x51\x6e\x66\x71\x71\x66\x7e\x1e\x91\xea\x13\x8b\x40\x36\x14\x14\xa5\xa6\x65\x56\x58\x73\x01\x06\x00\x95\xd1\x7f\x02\x94\x00\x00\x00")
+
+func _004_derived_tokensDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__004_derived_tokensDownSql,
+		"004_derived_tokens.down.sql",
+	)
+}
+
+func _004_derived_tokensDownSql() (*asset, error) {
+	bytes, err := _004_derived_tokensDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "004_derived_tokens.down.sql", size: 148, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x33, 0xc, 0xdb, 0xb8, 0xa2, 0xee, 0xfa, 0xe9, 0x8f, 0xf0, 0x1f, 0x89, 0xa7, 0x2d, 0x77, 0xd6, 0xf8, 0xc3, 0x4b, 0xa6, 0xba, 0x20, 0xf, 0x56, 0x55, 0x1a, 0x1d, 0xde, 0x62, 0xdc, 0x3, 0xa7}}
+	return a, nil
+}
+
+var __004_derived_tokensUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x2c\xc8\x8c\x2f\xc9\xcf\x4e\xcd\x2b\x56\x70\x74\x71\x51\x28\x48\x2c\x4a\xcd\x2b\x89\x2f\x28\x4a\x4d\xcb\xac\x50\x08\x71\x8d\x08\x51\xf0\xf3\x0f\x51\xf0\x0b\xf5\xf1\x51\x70\x71\x75\x73\x0c\xf5\x09\x51\x50\x57\xb7\xe6\xc2\x67\x44\x6a\x51\x6e\x66\x71\x71\x66\x7e\x5e\x31\xd8\x00\xbc\x8a\x93\x73\x32\x41\xf6\xa5\x17\xe5\x97\x16\xc0\x94\x03\x06\x00\xe9\x05\xd7\x32\x9f\x00\x00\x00")
+
+func _004_derived_tokensUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__004_derived_tokensUpSql,
+		"004_derived_tokens.up.sql",
+	)
+}
+
+func _004_derived_tokensUpSql() (*asset, error) {
+	bytes, err := _004_derived_tokensUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "004_derived_tokens.up.sql", size: 159, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xb7, 0xd5, 0x56, 0x82, 0xdb, 0xba, 0xc6, 0x77, 0x44, 0x57, 0xc7, 0x93, 0x25, 0xc4, 0xf2, 0x1e, 0x4e, 0xec, 0xdf, 0x6a, 0x5c, 0x49, 0x74, 0xa6, 0xb, 0x55, 0xe3, 0xea, 0x42, 0x60, 0x1e, 0x13}}
+	return a, nil
+}
+
+var __005_broker_grantsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x09\xf2\x0f\x50\x08\x71\x74\xf2\x71\x55\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\x2a\xca\xcf\x4e\x2d\x8a\x4f\x2f\x4a\xcc\x2b\x29\xb6\xe6\x72\xf4\x09\x71\x0d\x82\x2a\x4a\x2c\xc8\x8c\x2f\xc9\xcf\x4e\xcd\x2b\x56\x00\x6b\x75\xf6\xf7\x09\xf5\xf5\x53\x48\xce\xc9\x4c\x05\xab\x05\x0c\x00\x17\x33\x04\x82\x50\x00\x00\x00")
+
+func _005_broker_grantsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__005_broker_grantsDownSql,
+		"005_broker_grants.down.sql",
+	)
+}
+
+func _005_broker_grantsDownSql() (*asset, error) {
+	bytes, err := _005_broker_grantsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "005_broker_grants.down.sql", size: 80, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x38, 0xd9, 0xfb, 0xcd, 0x81, 0x57, 0x13, 0xe2, 0xaf, 0x26, 0xd5, 0x85, 0x3e, 0x35, 0x7c, 0xc7, 0x35, 0x21, 0x33, 0x25, 0x5e, 0x19, 0xde, 0x58, 0x7f, 0x50, 0xf3, 0x47, 0x4a, 0xb5, 0x40, 0x9b}}
+	return a, nil
+}
+
+var __005_broker_grantsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x91\x41\x4f\x83\x40\x10\x46\xef\xfc\x8a\xb9\xd5\x26\x3d\x78\xe7\xb4\xca\xd4\x6c\xa4\xd4\xd0\x6d\x42\x4f\x9b\x2d\x1d\x75\x43\x05\xb2\xb3\x98\xfa\xef\x4d\xc1\x46\xc1\x4d\x8d\xe7\xf7\x78\x61\xe7\x13\xa9\xc2\x1c\x94\xb8\x4b\x11\x4c\x6b\xb5\x6f\x2a\xaa\x19\x44\x92\x40\x79\xb4\x54\x7b\x06\x85\x85\x8a\xa3\xe8\x3e\x47\xa1\xf0\x4b\x95\x4b\xc8\xd6\x0a\xb0\x90\x1b\xb5\x81\xbd\x6b\x2a\x72\xfa\xc5\x99\xb3\x7f\x13\x01\x00\xd8\x43\xff\x61\xaf\x65\xdb\x34\x85\xa7\x5c\xae\x44\xbe\x83\x47\xdc\x2d\x7a\x63\xe8\xeb\xa9\x38\x40\x4f\xe5\x6b\x6d\x4b\x6b\xea\x10\x75\x64\xb8\x99\x10\x48\x70\x29\xb6\xa9\x82\xd9\x6c\x90\x3a\x26\x57\x9b\x37\x0a\xe6\xcf\xcf\xd4\xad\xa3\x67\x7b\x0a\xf1\xd2\x91\xf1\x74\xd0\xc6\x43\x22\x14\x2a\xb9\xc2\x89\x41\xa7\xd6\x3a\xe2\x2b\x06\x7b\xe3\x3b\x0e\xff\xfe\x7b\x53\x8d\xeb\x63\xb0\xff\xf8\xe3\x6d\x47\xc3\x5e\x77\x1c\x6a\x74\x4c\x0c\x32\x53\xf8\x80\xf9\xef\xc0\x6d\x34\x8f\x2f\x53\xca\x2c\xc1\xe2\xda\x94\xfa\xc7\x19\xd6\xd9\x74\xe6\x6f\xf8\x9f\x64\x7f\xf9\x40\xed\xb2\xd6\x62\xb4\xcd\x3c\x8e\x3e\x07\x00\xdb\x01\xd0\x30\xa2\x02\x00\x00")
+
+func _005_broker_grantsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__005_broker_grantsUpSql,
+		"005_broker_grants.up.sql",
+	)
+}
+
+func _005_broker_grantsUpSql() (*asset, error) {
+	bytes, err := _005_broker_grantsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "005_broker_grants.up.sql", size: 674, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xf3, 0x69, 0xaf, 0x6f, 0xae, 0xcd, 0x6e, 0x86, 0xef, 0x96, 0x14, 0xf7, 0x99, 0xa4, 0x1, 0xf, 0x6c, 0xaa, 0xbf, 0x83, 0x82, 0x2d, 0x4c, 0xcf, 0x7f, 0xfe, 0x52, 0x13, 0x8e, 0x92, 0x2, 0xb9}}
+	return a, nil
+}
+
+var __006_access_requestsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x09\xf2\x0f\x50\x08\x71\x74\xf2\x71\x55\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x48\x4c\x4e\x4e\x2d\x2e\x8e\x2f\x4a\x2d\x2c\x4d\x2d\x2e\x29\xb6\xe6\x02\x0c\x00\x26\x66\x80\x2a\x26\x00\x00\x00")
+
+func _006_access_requestsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__006_access_requestsDownSql,
+		"006_access_requests.down.sql",
+	)
+}
+
+func _006_access_requestsDownSql() (*asset, error) {
+	bytes, err := _006_access_requestsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "006_access_requests.down.sql", size: 38, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xd7, 0x5e, 0xcf, 0x2f, 0x34, 0xdb, 0xf, 0x39, 0xf6, 0x56, 0xb3, 0x8c, 0xf3, 0x14, 0x39, 0xab, 0xd1, 0xbb, 0x4f, 0xa1, 0xd4, 0x8a, 0x4d, 0x9b, 0x5d, 0xbf, 0xd6, 0xbc, 0x25, 0x6a, 0xb6, 0xda}}
+	return a, nil
+}
+
+var __006_access_requestsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x92\xc1\x4e\xc3\x30\x0c\x86\xef\x7d\x0a\xdf\xc6\xa4\xbd\xc1\x4e\x85\x7a\x28\xa2\xeb\x50\x97\x49\xdd\x29\x0a\x8d\x41\x01\x9a\x8e\xc4\x41\xf0\xf6\x48\xa5\x13\xb4\x2b\x43\x3b\xff\x9f\xfc\x5b\xfe\x7c\x53\x62\x2a\x11\x64\x7a\x9d\x23\x88\x15\x14\x1b\x09\x58\x89\xad\xdc\x82\xae\x6b\x0a\x41\x79\x7a\x8b\x14\x38\xc0\x55\x02\x00\x60\x0d\x48\xac\x64\x07\x16\xbb\x3c\x87\xfb\x52\xac\xd3\x72\x0f\x77\xb8\x5f\x74\x44\x0c\xe4\x9d\x6e\x68\xc8\x7d\x67\xf5\xab\x25\xc7\xea\xc9\xb7\xf1\xa0\xac\x99\x42\x9e\x63\x60\xfb\x68\x6b\xcd\xb6\x75\x53\x80\x89\xbe\xcb\x54\x63\x5d\x64\x0a\x20\x0a\x89\xb7\x58\x8e\xb0\xc0\x9a\x63\x98\x5c\xc2\x93\x66\x32\x4a\x33\x64\xa9\x44\x29\xd6\x38\xae\xa0\xda\x9a\x21\x31\x0c\x1e\x3e\x47\x57\xc8\x70\x95\xee\x72\x09\xb3\x59\xdf\xd1\x36\x0d\x39\xfe\x87\xa2\x8f\x83\xf5\x14\x4e\x7b\x3c\xbd\xb7\x2f\x64\xfe\x0e\xce\x2c\x90\xcc\x97\x49\xaf\x55\x14\x19\x56\xe7\xb5\xaa\x5f\xd7\xd8\x14\xa7\xd2\x7f\xe2\xcb\xc6\x1e\xbf\x40\xf5\x1e\xa6\x66\x1f\x99\x45\x2f\x6b\xbe\x4c\xbe\x06\x00\x55\xa5\x1b\x94\x90\x02\x00\x00")
+
+func _006_access_requestsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__006_access_requestsUpSql,
+		"006_access_requests.up.sql",
+	)
+}
+
+func _006_access_requestsUpSql() (*asset, error) {
+	bytes, err := _006_access_requestsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "006_access_requests.up.sql", size: 656, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x22, 0xf5, 0x77, 0xbc, 0x9, 0xb8, 0xdf, 0x58, 0xcd, 0x5b, 0x18, 0x5c, 0xfd, 0xe4, 0x3, 0x1, 0x53, 0xcd, 0x8, 0x66, 0xfd, 0x83, 0x45, 0xfa, 0xf, 0xa4, 0xf0, 0x2d, 0xf3, 0x92, 0x24, 0xcc}}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -290,6 +416,12 @@ var _bindata = map[string]func() (*asset, error){
 	"002_plural_and_name.up.sql":   _002_plural_and_nameUpSql,
 	"003_init.down.sql":            _003_initDownSql,
 	"003_init.up.sql":              _003_initUpSql,
+	"004_derived_tokens.down.sql":  _004_derived_tokensDownSql,
+	"004_derived_tokens.up.sql":    _004_derived_tokensUpSql,
+	"005_broker_grants.down.sql":   _005_broker_grantsDownSql,
+	"005_broker_grants.up.sql":     _005_broker_grantsUpSql,
+	"006_access_requests.down.sql": _006_access_requestsDownSql,
+	"006_access_requests.up.sql":   _006_access_requestsUpSql,
 }
 
 // AssetDebug is true if the assets were built with the debug flag enabled.
@@ -344,6 +476,12 @@ var _bintree = &bintree{nil, map[string]*bintree{
 	"002_plural_and_name.up.sql":   {_002_plural_and_nameUpSql, map[string]*bintree{}},
 	"003_init.down.sql":            {_003_initDownSql, map[string]*bintree{}},
 	"003_init.up.sql":              {_003_initUpSql, map[string]*bintree{}},
+	"004_derived_tokens.down.sql":  {_004_derived_tokensDownSql, map[string]*bintree{}},
+	"004_derived_tokens.up.sql":    {_004_derived_tokensUpSql, map[string]*bintree{}},
+	"005_broker_grants.down.sql":   {_005_broker_grantsDownSql, map[string]*bintree{}},
+	"005_broker_grants.up.sql":     {_005_broker_grantsUpSql, map[string]*bintree{}},
+	"006_access_requests.down.sql": {_006_access_requestsDownSql, map[string]*bintree{}},
+	"006_access_requests.up.sql":   {_006_access_requestsUpSql, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory.
@@ -0,0 +1,3 @@
+ALTER TABLE api_tokens DROP COLUMN client_groups;
+ALTER TABLE api_tokens DROP COLUMN permissions;
+ALTER TABLE api_tokens DROP COLUMN parent_prefix;
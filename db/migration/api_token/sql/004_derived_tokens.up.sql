@@ -0,0 +1,3 @@
+ALTER TABLE api_tokens ADD parent_prefix TEXT NOT NULL DEFAULT '';
+ALTER TABLE api_tokens ADD permissions TEXT;
+ALTER TABLE api_tokens ADD client_groups TEXT;
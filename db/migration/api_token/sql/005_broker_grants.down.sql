@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS broker_grants;
+ALTER TABLE api_tokens DROP COLUMN clients;
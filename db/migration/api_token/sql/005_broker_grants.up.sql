@@ -0,0 +1,19 @@
+ALTER TABLE api_tokens ADD clients TEXT;
+
+CREATE TABLE IF NOT EXISTS broker_grants (
+    id TEXT NOT NULL PRIMARY KEY,
+    client_id TEXT NOT NULL,
+    technician TEXT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    username TEXT NOT NULL,
+    token_prefix TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL,
+    status TEXT NOT NULL,
+    revoked_at DATETIME,
+    revoked_by TEXT NOT NULL DEFAULT '',
+    last_used_at DATETIME,
+    uses INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS broker_grants_created_at ON broker_grants (created_at);
+CREATE INDEX IF NOT EXISTS broker_grants_token ON broker_grants (username, token_prefix);
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS access_requests (
+    id TEXT NOT NULL PRIMARY KEY,
+    username TEXT NOT NULL,
+    client_group_id TEXT NOT NULL,
+    justification TEXT NOT NULL,
+    duration_minutes INTEGER NOT NULL,
+    status TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    decided_at DATETIME,
+    decided_by TEXT NOT NULL DEFAULT '',
+    comment TEXT NOT NULL DEFAULT '',
+    expires_at DATETIME,
+    revoked_at DATETIME,
+    revoked_by TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS access_requests_created_at ON access_requests (created_at);
+CREATE INDEX IF NOT EXISTS access_requests_username_status ON access_requests (username, status);
@@ -2,6 +2,10 @@
 // sources:
 // 001_init.down.sql (23B)
 // 001_init.up.sql (928B)
+// 002_impersonated_by.down.sql (54B)
+// 002_impersonated_by.up.sql (71B)
+// 003_hash_chain.down.sql (91B)
+// 003_hash_chain.up.sql (125B)
 
 package auditlog
 
@@ -109,6 +113,86 @@ func _001_initUpSql() (*asset, error) {
 	return a, nil
 }
 
+var __002_impersonated_byDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x50\x4a\x2c\x4d\xc9\x2c\xc9\xc9\x4f\x57\x52\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\x50\xca\xcc\x2d\x48\x2d\x2a\xce\xcf\x4b\x2c\x49\x4d\x89\x4f\xaa\x54\xb2\xe6\x02\x0c\x00\xac\x94\x5e\x10\x36\x00\x00\x00")
+
+func _002_impersonated_byDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__002_impersonated_byDownSql,
+		"002_impersonated_by.down.sql",
+	)
+}
+
+func _002_impersonated_byDownSql() (*asset, error) {
+	bytes, err := _002_impersonated_byDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "002_impersonated_by.down.sql", size: 54, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xf5, 0xd1, 0x22, 0xf6, 0xa6, 0xc6, 0xd5, 0x31, 0x71, 0x85, 0xd9, 0xa3, 0x96, 0x62, 0xa9, 0x78, 0x3a, 0x64, 0x43, 0x1b, 0x25, 0xb2, 0x54, 0xfb, 0x58, 0x10, 0x85, 0x67, 0x33, 0x6d, 0x25, 0x87}}
+	return a, nil
+}
+
+var __002_impersonated_byUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x50\x4a\x2c\x4d\xc9\x2c\xc9\xc9\x4f\x57\x52\x70\x74\x71\x51\x50\xca\xcc\x2d\x48\x2d\x2a\xce\xcf\x4b\x2c\x49\x4d\x89\x4f\xaa\x54\x52\x08\x71\x8d\x08\x51\xf0\xf3\x0f\x51\xf0\x0b\xf5\xf1\x51\x70\x71\x75\x73\x0c\xf5\x09\x51\x50\x57\xb7\xe6\x02\x0c\x00\x4b\x08\xc1\x4c\x47\x00\x00\x00")
+
+func _002_impersonated_byUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__002_impersonated_byUpSql,
+		"002_impersonated_by.up.sql",
+	)
+}
+
+func _002_impersonated_byUpSql() (*asset, error) {
+	bytes, err := _002_impersonated_byUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "002_impersonated_by.up.sql", size: 71, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xf5, 0x9e, 0x41, 0xb4, 0x6c, 0xea, 0xe1, 0x78, 0x1f, 0x7b, 0xf7, 0x12, 0x16, 0x84, 0x67, 0xe0, 0x76, 0xa4, 0xc2, 0x3e, 0xb5, 0xec, 0x89, 0x78, 0x80, 0x4e, 0xbe, 0x7a, 0x8, 0xc5, 0x17, 0x5e}}
+	return a, nil
+}
+
+var __003_hash_chainDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x50\x4a\x2c\x4d\xc9\x2c\xc9\xc9\x4f\x57\x52\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\x50\xca\x48\x2c\xce\x50\xb2\xe6\x22\x46\x69\x41\x51\x6a\x59\x3c\x54\x3d\x60\x00\x43\xd6\x89\x94\x5b\x00\x00\x00")
+
+func _003_hash_chainDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__003_hash_chainDownSql,
+		"003_hash_chain.down.sql",
+	)
+}
+
+func _003_hash_chainDownSql() (*asset, error) {
+	bytes, err := _003_hash_chainDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "003_hash_chain.down.sql", size: 91, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x26, 0xf2, 0x49, 0x11, 0x50, 0x43, 0xa2, 0x18, 0xfd, 0x6e, 0x4e, 0x8, 0xdc, 0xd4, 0xdb, 0xe2, 0x22, 0x2c, 0xf3, 0xc7, 0x99, 0xbc, 0x95, 0xa5, 0x0, 0xb8, 0xdd, 0x98, 0x5e, 0x38, 0x49, 0x4c}}
+	return a, nil
+}
+
+var __003_hash_chainUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x50\x4a\x2c\x4d\xc9\x2c\xc9\xc9\x4f\x57\x52\x70\x74\x71\x51\x50\x2a\x28\x4a\x2d\x8b\xcf\x48\x2c\xce\x50\x52\x08\x71\x8d\x08\x51\xf0\xf3\x0f\x51\xf0\x0b\xf5\xf1\x51\x70\x71\x75\x73\x0c\xf5\x09\x51\x50\x57\xb7\xe6\xc2\x67\x00\x21\xbd\x80\x01\x00\xfa\xb8\x3b\xd7\x7d\x00\x00\x00")
+
+func _003_hash_chainUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__003_hash_chainUpSql,
+		"003_hash_chain.up.sql",
+	)
+}
+
+func _003_hash_chainUpSql() (*asset, error) {
+	bytes, err := _003_hash_chainUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "003_hash_chain.up.sql", size: 125, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x3d, 0xb0, 0x63, 0xa1, 0x61, 0x35, 0xa, 0x51, 0x2c, 0xd3, 0x1b, 0x8, 0x1f, 0xd0, 0xbb, 0x81, 0xc3, 0x9a, 0x43, 0x8e, 0x12, 0x19, 0x59, 0xcb, 0x43, 0x89, 0x4e, 0x15, 0xaa, 0x4, 0xbc, 0xd}}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -200,8 +284,12 @@ func AssetNames() []string {
 
 // _bindata is a table, holding each asset generator, mapped to its name.
 var _bindata = map[string]func() (*asset, error){
-	"001_init.down.sql": _001_initDownSql,
-	"001_init.up.sql":   _001_initUpSql,
+	"001_init.down.sql":            _001_initDownSql,
+	"001_init.up.sql":              _001_initUpSql,
+	"002_impersonated_by.down.sql": _002_impersonated_byDownSql,
+	"002_impersonated_by.up.sql":   _002_impersonated_byUpSql,
+	"003_hash_chain.down.sql":      _003_hash_chainDownSql,
+	"003_hash_chain.up.sql":        _003_hash_chainUpSql,
 }
 
 // AssetDebug is true if the assets were built with the debug flag enabled.
@@ -250,8 +338,12 @@ type bintree struct {
 }
 
 var _bintree = &bintree{nil, map[string]*bintree{
-	"001_init.down.sql": {_001_initDownSql, map[string]*bintree{}},
-	"001_init.up.sql":   {_001_initUpSql, map[string]*bintree{}},
+	"001_init.down.sql":            {_001_initDownSql, map[string]*bintree{}},
+	"001_init.up.sql":              {_001_initUpSql, map[string]*bintree{}},
+	"002_impersonated_by.down.sql": {_002_impersonated_byDownSql, map[string]*bintree{}},
+	"002_impersonated_by.up.sql":   {_002_impersonated_byUpSql, map[string]*bintree{}},
+	"003_hash_chain.down.sql":      {_003_hash_chainDownSql, map[string]*bintree{}},
+	"003_hash_chain.up.sql":        {_003_hash_chainUpSql, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory.
@@ -0,0 +1 @@
+ALTER TABLE "auditlog" ADD "impersonated_by" TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE "auditlog" DROP COLUMN "hash";
+ALTER TABLE "auditlog" DROP COLUMN "prev_hash";
@@ -0,0 +1,2 @@
+ALTER TABLE "auditlog" ADD "prev_hash" TEXT NOT NULL DEFAULT '';
+ALTER TABLE "auditlog" ADD "hash" TEXT NOT NULL DEFAULT '';
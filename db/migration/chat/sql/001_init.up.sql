@@ -0,0 +1,12 @@
+CREATE TABLE messages (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    client_id TEXT NOT NULL,
+    session_id TEXT NOT NULL,
+    direction TEXT NOT NULL,
+    author TEXT NOT NULL DEFAULT '',
+    text TEXT NOT NULL,
+    reply_to INTEGER,
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX messages_client_id_session_id ON messages(client_id, session_id);
@@ -4,6 +4,12 @@
 // 001_init.up.sql (130B)
 // 002_add_allowed_user_groups.down.sql (0)
 // 002_add_allowed_user_groups.up.sql (79B)
+// 003_add_reverse_remotes.down.sql (0)
+// 003_add_reverse_remotes.up.sql (75B)
+// 004_add_access_schedule.down.sql (0)
+// 004_add_access_schedule.up.sql (54B)
+// 005_add_maintenance_windows.down.sql (0)
+// 005_add_maintenance_windows.up.sql (58B)
 
 package client_groups
 
@@ -151,6 +157,126 @@ func _002_add_allowed_user_groupsUpSql() (*asset, error) {
 	return a, nil
 }
 
+var __003_add_reverse_remotesDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x03\x00\x00\x00\x00\x00\x00\x00\x00\x00")
+
+func _003_add_reverse_remotesDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__003_add_reverse_remotesDownSql,
+		"003_add_reverse_remotes.down.sql",
+	)
+}
+
+func _003_add_reverse_remotesDownSql() (*asset, error) {
+	bytes, err := _003_add_reverse_remotesDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "003_add_reverse_remotes.down.sql", size: 0, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xe3, 0xb0, 0xc4, 0x42, 0x98, 0xfc, 0x1c, 0x14, 0x9a, 0xfb, 0xf4, 0xc8, 0x99, 0x6f, 0xb9, 0x24, 0x27, 0xae, 0x41, 0xe4, 0x64, 0x9b, 0x93, 0x4c, 0xa4, 0x95, 0x99, 0x1b, 0x78, 0x52, 0xb8, 0x55}}
+	return a, nil
+}
+
+var __003_add_reverse_remotesUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x4a\xcc\x29\x49\x2d\x52\x28\x49\x4c\xca\x49\x55\x50\x4a\xce\xc9\x4c\xcd\x2b\x89\x4f\x2f\xca\x2f\x2d\x28\x56\x52\x48\x4c\x49\x51\x28\x4a\x2d\x4b\x2d\x2a\x4e\x8d\x2f\x4a\xcd\xcd\x2f\x49\x2d\x56\x08\x71\x8d\x08\x51\xf0\xf3\x0f\x51\xf0\x0b\xf5\xf1\x51\x70\x71\x75\x73\x0c\xf5\x09\x51\x50\x8f\x8e\x55\xb7\x06\x0c\x00\x70\xac\x5a\xb9\x4b\x00\x00\x00")
+
+func _003_add_reverse_remotesUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__003_add_reverse_remotesUpSql,
+		"003_add_reverse_remotes.up.sql",
+	)
+}
+
+func _003_add_reverse_remotesUpSql() (*asset, error) {
+	bytes, err := _003_add_reverse_remotesUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "003_add_reverse_remotes.up.sql", size: 75, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xbc, 0x5e, 0x36, 0x6, 0x2f, 0x63, 0x99, 0x10, 0x7c, 0x7c, 0xd, 0xd3, 0x81, 0x3, 0x51, 0x98, 0x5f, 0xe6, 0xef, 0x85, 0xf3, 0xe6, 0x6e, 0xfd, 0x5c, 0x39, 0x50, 0x7b, 0xce, 0x2a, 0x11, 0xfd}}
+	return a, nil
+}
+
+var __004_add_access_scheduleDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x03\x00\x00\x00\x00\x00\x00\x00\x00\x00")
+
+func _004_add_access_scheduleDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__004_add_access_scheduleDownSql,
+		"004_add_access_schedule.down.sql",
+	)
+}
+
+func _004_add_access_scheduleDownSql() (*asset, error) {
+	bytes, err := _004_add_access_scheduleDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "004_add_access_schedule.down.sql", size: 0, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xe3, 0xb0, 0xc4, 0x42, 0x98, 0xfc, 0x1c, 0x14, 0x9a, 0xfb, 0xf4, 0xc8, 0x99, 0x6f, 0xb9, 0x24, 0x27, 0xae, 0x41, 0xe4, 0x64, 0x9b, 0x93, 0x4c, 0xa4, 0x95, 0x99, 0x1b, 0x78, 0x52, 0xb8, 0x55}}
+	return a, nil
+}
+
+var __004_add_access_scheduleUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x4a\xcc\x29\x49\x2d\x52\x28\x49\x4c\xca\x49\x55\x50\x4a\xce\xc9\x4c\xcd\x2b\x89\x4f\x2f\xca\x2f\x2d\x28\x56\x52\x48\x4c\x49\x51\x48\x4c\x4e\x4e\x2d\x2e\x8e\x2f\x4e\xce\x48\x4d\x29\xcd\x49\x55\x08\x71\x8d\x08\xb1\xe6\x02\x0c\x00\x24\xb1\x0b\xcd\x36\x00\x00\x00")
+
+func _004_add_access_scheduleUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__004_add_access_scheduleUpSql,
+		"004_add_access_schedule.up.sql",
+	)
+}
+
+func _004_add_access_scheduleUpSql() (*asset, error) {
+	bytes, err := _004_add_access_scheduleUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "004_add_access_schedule.up.sql", size: 54, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xc0, 0xc7, 0x4d, 0x1f, 0x26, 0xa7, 0xbe, 0x8, 0xd0, 0xd7, 0x9a, 0xf5, 0xfa, 0x25, 0x9a, 0x49, 0xc1, 0x60, 0x7, 0xd4, 0x46, 0xd5, 0x6a, 0x0, 0x7, 0x6a, 0xe0, 0xef, 0x24, 0xf, 0x19, 0xe0}}
+	return a, nil
+}
+
+var __005_add_maintenance_windowsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x03\x00\x00\x00\x00\x00\x00\x00\x00\x00")
+
+func _005_add_maintenance_windowsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__005_add_maintenance_windowsDownSql,
+		"005_add_maintenance_windows.down.sql",
+	)
+}
+
+func _005_add_maintenance_windowsDownSql() (*asset, error) {
+	bytes, err := _005_add_maintenance_windowsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "005_add_maintenance_windows.down.sql", size: 0, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xe3, 0xb0, 0xc4, 0x42, 0x98, 0xfc, 0x1c, 0x14, 0x9a, 0xfb, 0xf4, 0xc8, 0x99, 0x6f, 0xb9, 0x24, 0x27, 0xae, 0x41, 0xe4, 0x64, 0x9b, 0x93, 0x4c, 0xa4, 0x95, 0x99, 0x1b, 0x78, 0x52, 0xb8, 0x55}}
+	return a, nil
+}
+
+var __005_add_maintenance_windowsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x4a\xcc\x29\x49\x2d\x52\x28\x49\x4c\xca\x49\x55\x50\x4a\xce\xc9\x4c\xcd\x2b\x89\x4f\x2f\xca\x2f\x2d\x28\x56\x52\x48\x4c\x49\x51\xc8\x4d\xcc\xcc\x2b\x49\xcd\x4b\xcc\x4b\x4e\x8d\x2f\xcf\xcc\x4b\xc9\x2f\x2f\x56\x08\x71\x8d\x08\xb1\xe6\x02\x0c\x00\x48\x7c\x06\x77\x3a\x00\x00\x00")
+
+func _005_add_maintenance_windowsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__005_add_maintenance_windowsUpSql,
+		"005_add_maintenance_windows.up.sql",
+	)
+}
+
+func _005_add_maintenance_windowsUpSql() (*asset, error) {
+	bytes, err := _005_add_maintenance_windowsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "005_add_maintenance_windows.up.sql", size: 58, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x4, 0x53, 0x0, 0x67, 0x8e, 0x4, 0xee, 0x12, 0x5a, 0x32, 0x70, 0x10, 0xcb, 0xd9, 0xd6, 0x20, 0x1a, 0xf0, 0xff, 0x5e, 0x1e, 0xd3, 0x16, 0x25, 0x17, 0x18, 0x29, 0xb3, 0x7a, 0x6d, 0xa2, 0xe8}}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -246,6 +372,12 @@ var _bindata = map[string]func() (*asset, error){
 	"001_init.up.sql":                      _001_initUpSql,
 	"002_add_allowed_user_groups.down.sql": _002_add_allowed_user_groupsDownSql,
 	"002_add_allowed_user_groups.up.sql":   _002_add_allowed_user_groupsUpSql,
+	"003_add_reverse_remotes.down.sql":     _003_add_reverse_remotesDownSql,
+	"003_add_reverse_remotes.up.sql":       _003_add_reverse_remotesUpSql,
+	"004_add_access_schedule.down.sql":     _004_add_access_scheduleDownSql,
+	"004_add_access_schedule.up.sql":       _004_add_access_scheduleUpSql,
+	"005_add_maintenance_windows.down.sql": _005_add_maintenance_windowsDownSql,
+	"005_add_maintenance_windows.up.sql":   _005_add_maintenance_windowsUpSql,
 }
 
 // AssetDebug is true if the assets were built with the debug flag enabled.
@@ -298,6 +430,12 @@ var _bintree = &bintree{nil, map[string]*bintree{
 	"001_init.up.sql":                      {_001_initUpSql, map[string]*bintree{}},
 	"002_add_allowed_user_groups.down.sql": {_002_add_allowed_user_groupsDownSql, map[string]*bintree{}},
 	"002_add_allowed_user_groups.up.sql":   {_002_add_allowed_user_groupsUpSql, map[string]*bintree{}},
+	"003_add_reverse_remotes.down.sql":     {_003_add_reverse_remotesDownSql, map[string]*bintree{}},
+	"003_add_reverse_remotes.up.sql":       {_003_add_reverse_remotesUpSql, map[string]*bintree{}},
+	"004_add_access_schedule.down.sql":     {_004_add_access_scheduleDownSql, map[string]*bintree{}},
+	"004_add_access_schedule.up.sql":       {_004_add_access_scheduleUpSql, map[string]*bintree{}},
+	"005_add_maintenance_windows.down.sql": {_005_add_maintenance_windowsDownSql, map[string]*bintree{}},
+	"005_add_maintenance_windows.up.sql":   {_005_add_maintenance_windowsUpSql, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory.
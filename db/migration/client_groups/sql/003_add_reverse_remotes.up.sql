@@ -0,0 +1 @@
+alter table "client_groups" add reverse_remotes TEXT NOT NULL DEFAULT '[]';
\ No newline at end of file
@@ -0,0 +1 @@
+alter table "client_groups" add access_schedule TEXT;
@@ -0,0 +1 @@
+alter table "client_groups" add maintenance_windows TEXT;
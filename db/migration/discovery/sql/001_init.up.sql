@@ -0,0 +1,26 @@
+CREATE TABLE scans (
+    id TEXT PRIMARY KEY,
+    client_id TEXT NOT NULL,
+    subnets TEXT NOT NULL DEFAULT '[]',
+    ports TEXT NOT NULL DEFAULT '[]',
+    status TEXT NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    devices_count INTEGER NOT NULL DEFAULT 0,
+    created_by TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL,
+    finished_at DATETIME
+);
+
+CREATE INDEX scans_client_id ON scans(client_id);
+
+CREATE TABLE devices (
+    client_id TEXT NOT NULL,
+    ip TEXT NOT NULL,
+    mac TEXT NOT NULL DEFAULT '',
+    hostname TEXT NOT NULL DEFAULT '',
+    open_ports TEXT NOT NULL DEFAULT '[]',
+    first_seen_at DATETIME NOT NULL,
+    last_seen_at DATETIME NOT NULL,
+    last_scan_id TEXT NOT NULL,
+    PRIMARY KEY (client_id, ip)
+);
@@ -0,0 +1,12 @@
+CREATE TABLE external_clients (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    tags TEXT NOT NULL DEFAULT '[]',
+    token_hash TEXT NOT NULL,
+    inventory TEXT NOT NULL DEFAULT '{}',
+    inventory_updated_at DATETIME,
+    last_seen_at DATETIME,
+    created_by TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL
+);
@@ -0,0 +1,11 @@
+CREATE TABLE idempotency_keys (
+    key TEXT PRIMARY KEY NOT NULL,
+    fingerprint TEXT NOT NULL,
+    expires_at DATETIME NOT NULL,
+    status INTEGER NOT NULL DEFAULT 0,
+    header TEXT NOT NULL DEFAULT '',
+    body BLOB,
+    size INTEGER NOT NULL
+);
+
+CREATE INDEX idempotency_keys_expires_at ON idempotency_keys(expires_at);
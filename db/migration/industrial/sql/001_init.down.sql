@@ -0,0 +1,2 @@
+DROP TABLE point_values;
+DROP TABLE endpoints;
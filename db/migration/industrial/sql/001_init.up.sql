@@ -0,0 +1,27 @@
+CREATE TABLE endpoints (
+    id TEXT PRIMARY KEY,
+    client_id TEXT NOT NULL,
+    name TEXT NOT NULL DEFAULT '',
+    protocol TEXT NOT NULL,
+    address TEXT NOT NULL,
+    unit_id INTEGER NOT NULL DEFAULT 0,
+    interval_sec INTEGER NOT NULL,
+    points TEXT NOT NULL DEFAULT '[]',
+    created_by TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL,
+    last_polled_at DATETIME,
+    last_error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX endpoints_client_id ON endpoints(client_id);
+
+CREATE TABLE point_values (
+    endpoint_id TEXT NOT NULL,
+    client_id TEXT NOT NULL,
+    point TEXT NOT NULL,
+    timestamp DATETIME NOT NULL,
+    value REAL NOT NULL
+);
+
+CREATE INDEX point_values_client_id_point_timestamp ON point_values(client_id, point, timestamp);
+CREATE INDEX point_values_timestamp ON point_values(timestamp);
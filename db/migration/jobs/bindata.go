@@ -6,6 +6,8 @@
 // 002_schedules.up.sql (228B)
 // 003_multi_job_schedule_id.down.sql (0)
 // 003_multi_job_schedule_id.up.sql (50B)
+// 004_schedules_maintenance_decision.down.sql (0)
+// 004_schedules_maintenance_decision.up.sql (63B)
 
 package jobs
 
@@ -193,6 +195,46 @@ func _003_multi_job_schedule_idUpSql() (*asset, error) {
 	return a, nil
 }
 
+var __004_schedules_maintenance_decisionDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x03\x00\x00\x00\x00\x00\x00\x00\x00\x00")
+
+func _004_schedules_maintenance_decisionDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__004_schedules_maintenance_decisionDownSql,
+		"004_schedules_maintenance_decision.down.sql",
+	)
+}
+
+func _004_schedules_maintenance_decisionDownSql() (*asset, error) {
+	bytes, err := _004_schedules_maintenance_decisionDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "004_schedules_maintenance_decision.down.sql", size: 0, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xe3, 0xb0, 0xc4, 0x42, 0x98, 0xfc, 0x1c, 0x14, 0x9a, 0xfb, 0xf4, 0xc8, 0x99, 0x6f, 0xb9, 0x24, 0x27, 0xae, 0x41, 0xe4, 0x64, 0x9b, 0x93, 0x4c, 0xa4, 0x95, 0x99, 0x1b, 0x78, 0x52, 0xb8, 0x55}}
+	return a, nil
+}
+
+var __004_schedules_maintenance_decisionUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x4e\xce\x48\x4d\x29\xcd\x49\x2d\x56\x70\x74\x71\x51\xc8\x49\x2c\x2e\x89\xcf\x4d\xcc\xcc\x2b\x49\xcd\x4b\xcc\x4b\x4e\x8d\x4f\x49\x4d\xce\x2c\xce\xcc\xcf\x53\x08\x71\x8d\x08\x51\xf0\x0b\xf5\xf1\xb1\xe6\x02\x0c\x00\x82\x87\x74\x07\x3f\x00\x00\x00")
+
+func _004_schedules_maintenance_decisionUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__004_schedules_maintenance_decisionUpSql,
+		"004_schedules_maintenance_decision.up.sql",
+	)
+}
+
+func _004_schedules_maintenance_decisionUpSql() (*asset, error) {
+	bytes, err := _004_schedules_maintenance_decisionUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "004_schedules_maintenance_decision.up.sql", size: 63, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xa0, 0x9c, 0xaf, 0x5c, 0xf0, 0x10, 0x4, 0x84, 0xbf, 0x14, 0x3b, 0xfe, 0xb5, 0x80, 0x19, 0x57, 0x51, 0x6f, 0x48, 0x1f, 0x1a, 0x7e, 0x10, 0x77, 0xa1, 0x46, 0xd3, 0x55, 0x84, 0x83, 0x23, 0x85}}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -284,12 +326,14 @@ func AssetNames() []string {
 
 // _bindata is a table, holding each asset generator, mapped to its name.
 var _bindata = map[string]func() (*asset, error){
-	"001_init.down.sql":                  _001_initDownSql,
-	"001_init.up.sql":                    _001_initUpSql,
-	"002_schedules.down.sql":             _002_schedulesDownSql,
-	"002_schedules.up.sql":               _002_schedulesUpSql,
-	"003_multi_job_schedule_id.down.sql": _003_multi_job_schedule_idDownSql,
-	"003_multi_job_schedule_id.up.sql":   _003_multi_job_schedule_idUpSql,
+	"001_init.down.sql":                           _001_initDownSql,
+	"001_init.up.sql":                             _001_initUpSql,
+	"002_schedules.down.sql":                      _002_schedulesDownSql,
+	"002_schedules.up.sql":                        _002_schedulesUpSql,
+	"003_multi_job_schedule_id.down.sql":          _003_multi_job_schedule_idDownSql,
+	"003_multi_job_schedule_id.up.sql":            _003_multi_job_schedule_idUpSql,
+	"004_schedules_maintenance_decision.down.sql": _004_schedules_maintenance_decisionDownSql,
+	"004_schedules_maintenance_decision.up.sql":   _004_schedules_maintenance_decisionUpSql,
 }
 
 // AssetDebug is true if the assets were built with the debug flag enabled.
@@ -338,12 +382,14 @@ type bintree struct {
 }
 
 var _bintree = &bintree{nil, map[string]*bintree{
-	"001_init.down.sql":                  {_001_initDownSql, map[string]*bintree{}},
-	"001_init.up.sql":                    {_001_initUpSql, map[string]*bintree{}},
-	"002_schedules.down.sql":             {_002_schedulesDownSql, map[string]*bintree{}},
-	"002_schedules.up.sql":               {_002_schedulesUpSql, map[string]*bintree{}},
-	"003_multi_job_schedule_id.down.sql": {_003_multi_job_schedule_idDownSql, map[string]*bintree{}},
-	"003_multi_job_schedule_id.up.sql":   {_003_multi_job_schedule_idUpSql, map[string]*bintree{}},
+	"001_init.down.sql":                           {_001_initDownSql, map[string]*bintree{}},
+	"001_init.up.sql":                             {_001_initUpSql, map[string]*bintree{}},
+	"002_schedules.down.sql":                      {_002_schedulesDownSql, map[string]*bintree{}},
+	"002_schedules.up.sql":                        {_002_schedulesUpSql, map[string]*bintree{}},
+	"003_multi_job_schedule_id.down.sql":          {_003_multi_job_schedule_idDownSql, map[string]*bintree{}},
+	"003_multi_job_schedule_id.up.sql":            {_003_multi_job_schedule_idUpSql, map[string]*bintree{}},
+	"004_schedules_maintenance_decision.down.sql": {_004_schedules_maintenance_decisionDownSql, map[string]*bintree{}},
+	"004_schedules_maintenance_decision.up.sql":   {_004_schedules_maintenance_decisionUpSql, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory.
@@ -0,0 +1 @@
+ALTER TABLE schedules ADD last_maintenance_decision TEXT NULL;
@@ -8,6 +8,12 @@
 // 003_add_fields.up.sql (352B)
 // 004_add_timeout.down.sql (0)
 // 004_add_timeout.up.sql (144B)
+// 005_output_parser.down.sql (0)
+// 005_output_parser.up.sql (110B)
+// 006_sharing.down.sql (0)
+// 006_sharing.up.sql (312B)
+// 007_artifacts.down.sql (22B)
+// 007_artifacts.up.sql (513B)
 
 package library
 
@@ -235,6 +241,126 @@ func _004_add_timeoutUpSql() (*asset, error) {
 	return a, nil
 }
 
+var __005_output_parserDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x03\x00\x00\x00\x00\x00\x00\x00\x00\x00")
+
+func _005_output_parserDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__005_output_parserDownSql,
+		"005_output_parser.down.sql",
+	)
+}
+
+func _005_output_parserDownSql() (*asset, error) {
+	bytes, err := _005_output_parserDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "005_output_parser.down.sql", size: 0, mode: os.FileMode(0644), modTime: time.Unix(1685339921, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xe3, 0xb0, 0xc4, 0x42, 0x98, 0xfc, 0x1c, 0x14, 0x9a, 0xfb, 0xf4, 0xc8, 0x99, 0x6f, 0xb9, 0x24, 0x27, 0xae, 0x41, 0xe4, 0x64, 0x9b, 0x93, 0x4c, 0xa4, 0x95, 0x99, 0x1b, 0x78, 0x52, 0xb8, 0x55}}
+	return a, nil
+}
+
+var __005_output_parserUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x50\x4a\xce\xcf\xcd\x4d\xcc\x4b\x29\x56\x52\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\x50\xca\x2f\x2d\x29\x28\x2d\x89\x2f\x48\x2c\x2a\x4e\x2d\x52\x52\x08\x71\x8d\x08\xb1\xe6\x42\xd1\x57\x9c\x5c\x94\x59\x50\x42\x84\x36\xc0\x00\xbf\xa1\x6c\x76\x6e\x00\x00\x00")
+
+func _005_output_parserUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__005_output_parserUpSql,
+		"005_output_parser.up.sql",
+	)
+}
+
+func _005_output_parserUpSql() (*asset, error) {
+	bytes, err := _005_output_parserUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "005_output_parser.up.sql", size: 110, mode: os.FileMode(0644), modTime: time.Unix(1685339921, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x28, 0xf9, 0x23, 0xe5, 0xc8, 0x93, 0x4a, 0x6a, 0xfd, 0xcc, 0xfe, 0xb, 0x42, 0x8e, 0x78, 0x34, 0xcd, 0xed, 0xf7, 0x5c, 0x28, 0xdd, 0x0, 0x7e, 0xbd, 0x2d, 0xc1, 0x6f, 0xf9, 0x9d, 0xd8, 0x86}}
+	return a, nil
+}
+
+var __006_sharingDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x03\x00\x00\x00\x00\x00\x00\x00\x00\x00")
+
+func _006_sharingDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__006_sharingDownSql,
+		"006_sharing.down.sql",
+	)
+}
+
+func _006_sharingDownSql() (*asset, error) {
+	bytes, err := _006_sharingDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "006_sharing.down.sql", size: 0, mode: os.FileMode(0644), modTime: time.Unix(1685339921, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xe3, 0xb0, 0xc4, 0x42, 0x98, 0xfc, 0x1c, 0x14, 0x9a, 0xfb, 0xf4, 0xc8, 0x99, 0x6f, 0xb9, 0x24, 0x27, 0xae, 0x41, 0xe4, 0x64, 0x9b, 0x93, 0x4c, 0xa4, 0x95, 0x99, 0x1b, 0x78, 0x52, 0xb8, 0x55}}
+	return a, nil
+}
+
+var __006_sharingUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x50\x4a\xce\xcf\xcd\x4d\xcc\x4b\x29\x56\x52\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\x50\x2a\xcb\x2c\xce\x4c\xca\xcc\xc9\x2c\xa9\x54\x52\x08\x71\x8d\x08\x51\xf0\xf3\x0f\x51\xf0\x0b\xf5\xf1\x51\x70\x71\x75\x73\x0c\xf5\x09\x51\x50\x4f\xcf\xc9\x4f\x4a\xcc\x51\xb7\xe6\x22\xc2\xb8\xe2\x8c\xc4\xa2\xd4\x94\xf8\xf4\xa2\xfc\xd2\x82\x62\x9c\x26\x46\xc7\xa2\x9b\x56\x9c\x5c\x94\x59\x50\x42\x2d\xb7\x61\x35\x8d\x04\xa7\x01\x06\x00\x7e\xdc\xe8\xb5\x38\x01\x00\x00")
+
+func _006_sharingUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__006_sharingUpSql,
+		"006_sharing.up.sql",
+	)
+}
+
+func _006_sharingUpSql() (*asset, error) {
+	bytes, err := _006_sharingUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "006_sharing.up.sql", size: 312, mode: os.FileMode(0644), modTime: time.Unix(1685339921, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xad, 0xab, 0xec, 0x5e, 0x7c, 0xd6, 0xde, 0x75, 0xa4, 0x9a, 0x43, 0xd8, 0x3a, 0xdc, 0x6a, 0x64, 0xfe, 0x25, 0xc9, 0x1, 0x86, 0xed, 0x3f, 0x61, 0xe6, 0xd4, 0x32, 0x34, 0x51, 0xd4, 0x2e, 0x72}}
+	return a, nil
+}
+
+var __007_artifactsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x09\xf2\x0f\x50\x08\x71\x74\xf2\x71\x55\x48\x2c\x2a\xc9\x4c\x4b\x4c\x2e\x29\xb6\xe6\x02\x0c\x00\x72\x73\x1b\xbd\x16\x00\x00\x00")
+
+func _007_artifactsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__007_artifactsDownSql,
+		"007_artifacts.down.sql",
+	)
+}
+
+func _007_artifactsDownSql() (*asset, error) {
+	bytes, err := _007_artifactsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "007_artifacts.down.sql", size: 22, mode: os.FileMode(0644), modTime: time.Unix(1685339921, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x79, 0x97, 0x9b, 0x45, 0x91, 0xed, 0x5a, 0x30, 0x38, 0xb1, 0x17, 0xf9, 0x47, 0x51, 0x74, 0xf7, 0x21, 0xe0, 0x71, 0x42, 0x79, 0x58, 0xb8, 0x7b, 0x41, 0xf6, 0xb0, 0xe7, 0x6b, 0xbf, 0x3d, 0x13}}
+	return a, nil
+}
+
+var __007_artifactsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x84\x90\xcf\x4a\xf3\x40\x14\xc5\xf7\xf3\x14\x87\x6c\xda\x40\xb3\xf9\xa0\xdf\xa6\xab\xd0\x5c\x25\x68\xa3\xc4\x11\x5a\x44\xa6\x93\xe9\x94\x06\xd3\x09\xcc\x1f\xa1\x3e\xbd\x98\xc4\x8a\x25\xea\x2c\xcf\xf9\xdd\x33\xf7\x9e\x24\x41\xf2\xcb\x63\x49\x02\x2e\xab\x46\xc3\x79\x1b\x94\x0f\x56\x63\xdf\x5a\x48\xeb\xeb\xbd\x54\xde\xb1\xbf\x02\x94\xd5\xd2\x6b\xf8\x2e\xe4\x6b\x6c\xca\x00\xc0\xc8\xa3\x06\xa7\x35\x87\x69\x3d\x4c\x68\x9a\x59\xa7\xbf\x6a\xeb\xea\xd6\x8c\x59\xae\x7e\xd3\xa2\x3a\x79\xed\x90\x17\x9c\xae\xa9\xbc\x04\x0e\xf2\xdf\xfc\xff\xd8\xe8\x71\x37\x1f\x93\xd5\x21\x98\x17\xf7\xdd\x41\x46\x57\xe9\xe3\x2d\xc7\xe4\xe9\x79\x32\xe4\xb6\xc1\x2a\x2d\x82\x6d\x7e\x42\x07\xb0\xbf\x78\x27\xa4\x47\x96\x72\xe2\xf9\x8a\x2e\x7f\x1c\x88\xea\x34\xb6\xcf\x7d\x99\xaf\xd2\x72\x83\x1b\xda\x60\xfa\xd1\xd0\xec\xb3\x8f\x98\xc5\x0b\xc6\x96\x25\xa5\x9c\x90\x17\x19\xad\x11\x9d\x2b\x15\xa2\xbf\x3c\xea\x42\xee\x0a\x6c\xcf\xd6\x16\x7d\xdd\xd1\x40\x20\x7d\x58\x76\x42\xbc\x60\xef\x03\x00\x1f\xac\xfe\x77\x01\x02\x00\x00")
+
+func _007_artifactsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__007_artifactsUpSql,
+		"007_artifacts.up.sql",
+	)
+}
+
+func _007_artifactsUpSql() (*asset, error) {
+	bytes, err := _007_artifactsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "007_artifacts.up.sql", size: 513, mode: os.FileMode(0644), modTime: time.Unix(1685339921, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xbc, 0xde, 0x32, 0x90, 0x3b, 0x5c, 0xd5, 0x4b, 0xd3, 0x2, 0x13, 0xc4, 0xf6, 0xa4, 0x7a, 0xb7, 0x7d, 0xc0, 0xd9, 0x86, 0x8c, 0x14, 0xeb, 0x6e, 0xc, 0xde, 0xe4, 0xf3, 0x10, 0xf3, 0x20, 0x10}}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -326,14 +452,20 @@ func AssetNames() []string {
 
 // _bindata is a table, holding each asset generator, mapped to its name.
 var _bindata = map[string]func() (*asset, error){
-	"001_init.down.sql":        _001_initDownSql,
-	"001_init.up.sql":          _001_initUpSql,
-	"002_commands.down.sql":    _002_commandsDownSql,
-	"002_commands.up.sql":      _002_commandsUpSql,
-	"003_add_fields.down.sql":  _003_add_fieldsDownSql,
-	"003_add_fields.up.sql":    _003_add_fieldsUpSql,
-	"004_add_timeout.down.sql": _004_add_timeoutDownSql,
-	"004_add_timeout.up.sql":   _004_add_timeoutUpSql,
+	"001_init.down.sql":          _001_initDownSql,
+	"001_init.up.sql":            _001_initUpSql,
+	"002_commands.down.sql":      _002_commandsDownSql,
+	"002_commands.up.sql":        _002_commandsUpSql,
+	"003_add_fields.down.sql":    _003_add_fieldsDownSql,
+	"003_add_fields.up.sql":      _003_add_fieldsUpSql,
+	"004_add_timeout.down.sql":   _004_add_timeoutDownSql,
+	"004_add_timeout.up.sql":     _004_add_timeoutUpSql,
+	"005_output_parser.down.sql": _005_output_parserDownSql,
+	"005_output_parser.up.sql":   _005_output_parserUpSql,
+	"006_sharing.down.sql":       _006_sharingDownSql,
+	"006_sharing.up.sql":         _006_sharingUpSql,
+	"007_artifacts.down.sql":     _007_artifactsDownSql,
+	"007_artifacts.up.sql":       _007_artifactsUpSql,
 }
 
 // AssetDebug is true if the assets were built with the debug flag enabled.
@@ -382,14 +514,20 @@ type bintree struct {
 }
 
 var _bintree = &bintree{nil, map[string]*bintree{
-	"001_init.down.sql":        {_001_initDownSql, map[string]*bintree{}},
-	"001_init.up.sql":          {_001_initUpSql, map[string]*bintree{}},
-	"002_commands.down.sql":    {_002_commandsDownSql, map[string]*bintree{}},
-	"002_commands.up.sql":      {_002_commandsUpSql, map[string]*bintree{}},
-	"003_add_fields.down.sql":  {_003_add_fieldsDownSql, map[string]*bintree{}},
-	"003_add_fields.up.sql":    {_003_add_fieldsUpSql, map[string]*bintree{}},
-	"004_add_timeout.down.sql": {_004_add_timeoutDownSql, map[string]*bintree{}},
-	"004_add_timeout.up.sql":   {_004_add_timeoutUpSql, map[string]*bintree{}},
+	"001_init.down.sql":          {_001_initDownSql, map[string]*bintree{}},
+	"001_init.up.sql":            {_001_initUpSql, map[string]*bintree{}},
+	"002_commands.down.sql":      {_002_commandsDownSql, map[string]*bintree{}},
+	"002_commands.up.sql":        {_002_commandsUpSql, map[string]*bintree{}},
+	"003_add_fields.down.sql":    {_003_add_fieldsDownSql, map[string]*bintree{}},
+	"003_add_fields.up.sql":      {_003_add_fieldsUpSql, map[string]*bintree{}},
+	"004_add_timeout.down.sql":   {_004_add_timeoutDownSql, map[string]*bintree{}},
+	"004_add_timeout.up.sql":     {_004_add_timeoutUpSql, map[string]*bintree{}},
+	"005_output_parser.down.sql": {_005_output_parserDownSql, map[string]*bintree{}},
+	"005_output_parser.up.sql":   {_005_output_parserUpSql, map[string]*bintree{}},
+	"006_sharing.down.sql":       {_006_sharingDownSql, map[string]*bintree{}},
+	"006_sharing.up.sql":         {_006_sharingUpSql, map[string]*bintree{}},
+	"007_artifacts.down.sql":     {_007_artifactsDownSql, map[string]*bintree{}},
+	"007_artifacts.up.sql":       {_007_artifactsUpSql, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory.
@@ -0,0 +1,2 @@
+ALTER TABLE "commands" ADD COLUMN "output_parser" TEXT;
+ALTER TABLE "scripts" ADD COLUMN "output_parser" TEXT;
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE "commands" ADD COLUMN "visibility" TEXT NOT NULL DEFAULT 'global';
+ALTER TABLE "commands" ADD COLUMN "shared_groups" TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE "scripts" ADD COLUMN "visibility" TEXT NOT NULL DEFAULT 'global';
+ALTER TABLE "scripts" ADD COLUMN "shared_groups" TEXT NOT NULL DEFAULT '[]';
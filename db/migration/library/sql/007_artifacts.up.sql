@@ -0,0 +1,21 @@
+-- ----------------------------
+-- Table structure for artifacts
+-- ----------------------------
+create table artifacts
+(
+    name TEXT not null,
+    version TEXT not null,
+    size_bytes INTEGER not null,
+    sha256 TEXT not null,
+    md5 TEXT not null,
+    chunks TEXT not null DEFAULT '[]',
+    source_url TEXT not null DEFAULT '',
+    created_at DATETIME not null,
+    created_by TEXT not null,
+    PRIMARY KEY (name, version)
+);
+
+CREATE INDEX "artifacts__sha256"
+    ON `artifacts` (
+    "sha256" ASC
+    );
@@ -0,0 +1,29 @@
+CREATE TABLE devices (
+    id TEXT PRIMARY KEY,
+    client_id TEXT NOT NULL,
+    name TEXT NOT NULL DEFAULT '',
+    port TEXT NOT NULL,
+    baud_rate INTEGER NOT NULL,
+    data_bits INTEGER NOT NULL,
+    parity TEXT NOT NULL,
+    stop_bits INTEGER NOT NULL,
+    created_by TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL
+);
+
+CREATE UNIQUE INDEX devices_client_id_port ON devices(client_id, port);
+
+CREATE TABLE sessions (
+    id TEXT PRIMARY KEY,
+    device_id TEXT NOT NULL,
+    client_id TEXT NOT NULL,
+    port TEXT NOT NULL,
+    username TEXT NOT NULL,
+    started_at DATETIME NOT NULL,
+    ended_at DATETIME,
+    bytes_sent INTEGER NOT NULL DEFAULT 0,
+    bytes_received INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX sessions_client_id_started_at ON sessions(client_id, started_at);
+CREATE INDEX sessions_ended_at ON sessions(ended_at);
@@ -0,0 +1 @@
+DROP TABLE tunnel_connections;
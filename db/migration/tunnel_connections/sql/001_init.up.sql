@@ -0,0 +1,18 @@
+CREATE TABLE tunnel_connections (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    client_id TEXT NOT NULL,
+    tunnel_id TEXT NOT NULL,
+    tunnel_local TEXT NOT NULL,
+    tunnel_remote TEXT NOT NULL,
+    protocol TEXT NOT NULL,
+    source_ip TEXT NOT NULL,
+    source_port INTEGER NOT NULL DEFAULT 0,
+    started_at DATETIME NOT NULL,
+    ended_at DATETIME,
+    interrupted BOOLEAN NOT NULL DEFAULT 0 CHECK (interrupted IN (0, 1)),
+    bytes_sent INTEGER NOT NULL DEFAULT 0,
+    bytes_received INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX tunnel_connections_client_id_started_at ON tunnel_connections (client_id, started_at);
+CREATE INDEX tunnel_connections_started_at ON tunnel_connections (started_at);
@@ -0,0 +1,2 @@
+DROP TABLE tunnel_connection_requests;
+ALTER TABLE tunnel_connections DROP COLUMN http_requests;
@@ -0,0 +1,14 @@
+ALTER TABLE tunnel_connections ADD COLUMN http_requests INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE tunnel_connection_requests (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    connection_id INTEGER NOT NULL,
+    time DATETIME NOT NULL,
+    method TEXT NOT NULL,
+    path TEXT NOT NULL,
+    proto TEXT NOT NULL,
+    status INTEGER NOT NULL,
+    duration_ms INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX tunnel_connection_requests_connection_id ON tunnel_connection_requests (connection_id);
@@ -0,0 +1,2 @@
+DROP TABLE usage_periods;
+DROP TABLE usage;
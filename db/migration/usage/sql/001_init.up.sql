@@ -0,0 +1,19 @@
+CREATE TABLE usage (
+    period TEXT NOT NULL,
+    client_id TEXT NOT NULL,
+    client_name TEXT NOT NULL DEFAULT '',
+    user_groups TEXT NOT NULL DEFAULT '[]',
+    client_groups TEXT NOT NULL DEFAULT '[]',
+    connected_seconds INTEGER NOT NULL DEFAULT 0,
+    tunnel_bytes_sent INTEGER NOT NULL DEFAULT 0,
+    tunnel_bytes_received INTEGER NOT NULL DEFAULT 0,
+    jobs INTEGER NOT NULL DEFAULT 0,
+    storage_bytes INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (period, client_id)
+);
+
+CREATE TABLE usage_periods (
+    period TEXT PRIMARY KEY NOT NULL,
+    closed_at DATETIME NOT NULL,
+    closed_by TEXT NOT NULL
+);
@@ -2,6 +2,8 @@
 // sources:
 // 001_init.down.sql (42B)
 // 001_init.up.sql (1.348kB)
+// 002_checkouts.down.sql (78B)
+// 002_checkouts.up.sql (516B)
 
 package vaults
 
@@ -69,7 +71,7 @@ func (fi bindataFileInfo) Sys() interface{} {
 	return nil
 }
 
-var __001_initDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x09\xf2\x0f\x50\x08\x71\x74\xf2\x71\x55\x48\x28\x4b\xcc\x29\x4d\x2d\x4e\xb0\xe6\x42\x16\x2c\x2e\x49\x2c\x29\x05\x09\x02\x02\x00\x00\xff\xff\x45\xff\xd6\x78\x2a\x00\x00\x00")
+var __001_initDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x73\x09\xf2\x0f\x50\x08\x71\x74\xf2\x71\x55\x48\x28\x4b\xcc\x29\x4d\x2d\x4e\xb0\xe6\x72\x41\x12\x2c\x2e\x49\x2c\x29\x05\x09\x02\x00\x45\xff\xd6\x78\x2a\x00\x00\x00")
 
 func _001_initDownSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -84,12 +86,12 @@ func _001_initDownSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "001_init.down.sql", size: 42, mode: os.FileMode(0644), modTime: time.Unix(1685339921, 0)}
+	info := bindataFileInfo{name: "001_init.down.sql", size: 42, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
 	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x90, 0x24, 0xb8, 0x5d, 0xa0, 0x86, 0xab, 0x86, 0x64, 0x40, 0xfb, 0xfa, 0x7, 0x74, 0x68, 0x6d, 0x95, 0xf7, 0x9b, 0x47, 0xcb, 0x6, 0xa1, 0x3d, 0x71, 0x4e, 0x58, 0x90, 0x75, 0x33, 0x25, 0x23}}
 	return a, nil
 }
 
-var __001_initUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\x93\x4f\x8f\x9b\x30\x10\xc5\xef\x7c\x8a\x11\x97\xec\x4a\x8b\xd4\x9e\xa3\x1e\x68\xe2\xb6\xa8\xac\xb3\x65\x8d\xba\x39\x19\x02\xd3\x16\x85\x38\xc1\xd8\x51\xf2\xed\x2b\x0c\x45\xfc\xc9\x5f\x75\x7d\x41\x32\x6f\x7e\xf3\xe4\x79\xe3\x38\xe0\x5c\x38\x96\xe3\x00\x8b\x57\x39\x42\xa9\xa4\x4e\x94\x96\x08\xbf\xb6\x12\xf6\xb1\xce\x95\x75\xad\x78\x16\x10\x97\x11\x60\xee\x67\x9f\x80\xbd\x8f\x73\x8d\xa5\x6d\x3d\x58\x00\x00\x76\x96\xda\xd0\x3d\x1e\x65\xe4\x2b\x09\x80\x2e\x18\xd0\xd0\xf7\xe1\x25\xf0\x9e\xdd\x60\x09\xdf\xc9\x12\xdc\x90\x2d\x3c\x3a\x0b\xc8\x33\xa1\xec\xa9\x06\x24\x79\x86\x42\xf1\x96\xa3\xf0\xa0\xaa\x6f\x0b\x98\x93\x2f\x6e\xe8\x33\xf8\xd0\x14\x48\x2c\x74\x26\x31\xe5\xbf\xe5\x56\xef\x6c\x60\xe4\xad\x65\x49\x8c\x15\xa6\x3c\x56\x35\x6c\x5e\xf9\xee\xb0\x06\xb2\xd5\xb1\x96\x55\x84\x13\x32\xbd\x4b\x6f\xa1\xfd\x93\x75\x69\xcd\xaf\x35\x1e\x7b\xaf\x73\xa6\x91\x79\x52\xfb\xaa\x4c\x1d\x77\x5d\xd5\x48\x66\x3d\x4e\xaf\xce\xd2\x71\xc0\xd5\x6a\x0b\x99\x48\x24\x6e\x50\x28\x30\xcd\xef\x48\x43\xf8\x62\xde\x21\x2a\x8b\x3c\x53\xc8\x4b\x2c\x34\x8a\x04\x23\xeb\x95\x30\x88\x4a\x2c\x22\xf8\x04\x1f\x8d\xe3\x9f\xdf\x48\x40\x20\x12\xf1\x06\xab\xcb\x49\x1d\x9d\xc9\x4d\x2e\x3d\x91\xe2\x01\xcb\x41\x60\x95\x09\xf1\x5d\xb1\xf5\xe8\x9c\xbc\x75\x63\x66\xac\x2d\x28\x44\xb5\x9d\x08\x1e\x46\x41\x74\x5f\x67\xe6\xee\x71\x3a\xa0\x54\x03\x3d\x57\x6f\x86\x3d\xae\x0c\xa9\xf7\x23\x6c\x01\x5a\x64\x85\x46\xde\xf6\xe2\x97\x88\x7d\x47\x4f\xa7\xdb\x0c\xd6\xb3\x54\xb1\xd2\x27\xd7\xf3\xee\xcd\x4c\x57\xbc\xa1\x8d\x96\xb2\x51\xa0\x48\x78\xf2\x07\x93\x75\x6f\x0b\x53\xec\xdd\x56\xb1\xfc\xaf\x5c\xd6\x26\xde\x2b\x98\xc3\x50\xd6\xf4\xc9\xd4\xfa\x1b\x00\x00\xff\xff\x18\x0f\x61\xdf\x44\x05\x00\x00")
+var __001_initUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xad\x53\x4d\x6f\x82\x40\x10\xbd\xf3\x2b\x26\x5c\xb4\x49\x49\xda\x73\xd3\x03\xd5\x6d\x4b\xaa\x68\x71\x49\xf5\x04\x08\xd3\x96\x88\xa8\xb0\x6b\xf4\xdf\x77\x59\x28\xe1\xc3\xcf\xb4\x7b\xd9\x64\xe6\xcd\x9b\x97\x99\x37\x9a\x06\xda\x89\xa7\x68\x1a\x50\x6f\x1e\x21\xa4\x2c\xe1\x3e\xe3\x09\xc2\xe7\x2a\x81\xad\xc7\x23\x96\x25\x4f\x16\xf7\x2c\xa2\x53\x02\x54\x7f\x1a\x10\x50\xb7\x5e\xc4\x31\x55\x95\xae\x02\xe2\xa9\x61\xa0\x42\xf5\x19\x26\x25\x2f\xc4\x02\x73\x44\xc1\xb4\x07\x03\x18\x5b\xc6\x50\xb7\x66\xf0\x46\x66\xa0\xdb\x74\x64\x98\x82\x6f\x48\x4c\x7a\x9b\x13\xf8\x51\x88\x31\x73\x4a\x1e\x86\x3b\x96\xfd\x25\x41\x9f\x3c\xeb\xf6\x80\xc2\x5d\x51\x90\xe0\x86\x87\x09\x06\xce\x57\xb2\xe2\x6b\x15\x28\x99\x96\x5c\x09\x7a\x4c\x64\x3c\x96\x93\xf5\x33\xdd\x15\xae\x06\x6c\xbe\xcf\x61\x19\xc3\x01\x18\x5f\x07\x97\xb0\xfd\xc2\xaa\x6c\x45\x6a\x81\xfb\xda\x74\x8e\x34\x92\x23\x55\xcf\xc2\xd8\x7e\x5d\x45\xb5\x60\xca\xcd\xc3\xd9\x5d\x8a\xbc\xce\xd9\x0a\xc2\x58\x0c\x61\x29\x06\x0f\xb2\xf9\x15\x6e\xb0\xc7\x72\x0e\x6e\xba\x89\x42\x86\x4e\x2a\xb6\x81\xb1\x8f\xae\x32\x21\x54\x44\x71\xe3\xc2\x23\xdc\x4b\xc5\x1f\xaf\xc4\x12\xc8\xd8\x5b\x62\x16\xec\xe4\xd6\xe9\x5c\xa4\xd2\x88\x03\xdc\x61\xda\x30\x2c\x93\x26\xbe\xca\xb6\x86\xd9\x27\xd3\xaa\xcd\xa4\xb4\x91\x09\x6e\x2e\xc7\x85\x6e\xcb\x88\xfa\xa4\x27\x63\x62\xa0\x75\x96\x6c\xa1\xc7\xea\xe5\xb2\xdb\x95\xb6\x69\xbc\xdb\x25\x01\x8f\x43\x31\x2f\xa7\xec\xe5\x9c\x62\xac\x2b\xba\x3d\xdc\xa6\x71\x9e\x29\xf3\x18\x3f\x78\x9e\x57\x5f\x66\x30\x77\x0a\xb6\xd6\x51\x16\x08\xb1\x78\xc7\xff\x46\x7f\x51\xbb\xc2\x00\x6b\xd1\xcc\x96\x7f\xf2\x65\x2e\xe2\xbf\x8c\xd9\x34\x65\xce\x2e\x4c\xf9\x03\x18\x0f\x61\xdf\x44\x05\x00\x00")
 
 func _001_initUpSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -104,11 +106,51 @@ func _001_initUpSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "001_init.up.sql", size: 1348, mode: os.FileMode(0644), modTime: time.Unix(1685339921, 0)}
+	info := bindataFileInfo{name: "001_init.up.sql", size: 1348, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
 	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x2c, 0x2f, 0x9d, 0xb8, 0xa4, 0xa2, 0x38, 0x4, 0xda, 0xc1, 0xa9, 0x5c, 0xba, 0xe7, 0xbf, 0x4b, 0x5c, 0x4e, 0xb7, 0x21, 0x1, 0x1a, 0x9e, 0xcd, 0x10, 0x11, 0x0, 0xa3, 0xbb, 0x41, 0x73, 0x72}}
 	return a, nil
 }
 
+var __002_checkoutsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x73\x09\xf2\x0f\x50\x08\x71\x74\xf2\x71\x55\x48\x48\xce\x48\x4d\xce\xce\x2f\x2d\x29\x4e\xb0\xe6\x72\xf4\x09\x71\x0d\x82\x49\x94\x25\xe6\x94\xa6\x16\x27\x28\xb8\x80\x14\x3b\xfb\xfb\x84\xfa\xfa\x21\x54\xc7\x17\xa5\x16\x96\x66\x16\xa5\xa6\x00\x75\x01\x00\x9d\xf4\xe5\xde\x4e\x00\x00\x00")
+
+func _002_checkoutsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__002_checkoutsDownSql,
+		"002_checkouts.down.sql",
+	)
+}
+
+func _002_checkoutsDownSql() (*asset, error) {
+	bytes, err := _002_checkoutsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "002_checkouts.down.sql", size: 78, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x55, 0x77, 0x46, 0x15, 0xc1, 0x99, 0xe3, 0xe, 0xf6, 0xa2, 0xf7, 0xd3, 0xd7, 0x25, 0x6, 0xbd, 0xe7, 0xb7, 0xfb, 0x6a, 0x3d, 0x5c, 0x27, 0x95, 0xa5, 0x5f, 0xf1, 0x8a, 0x3f, 0x9d, 0x6b, 0x46}}
+	return a, nil
+}
+
+var __002_checkoutsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x75\x91\xb1\x4e\xc3\x30\x10\x86\x77\x3f\xc5\x8d\x45\x62\x60\x67\x72\xeb\x03\x59\x38\x36\x8a\x2e\x52\x3b\xc5\x56\x6b\xa9\x11\x90\x40\x9c\x20\x1e\x1f\x27\xc5\x38\x8a\x94\x5b\xbc\x7c\xf7\xeb\xbf\xcf\x5c\x11\x96\x40\x7c\xaf\x10\xec\xb7\x7b\x1f\x7d\xb0\xc0\x85\x80\x83\x51\x55\xa1\xc1\x9e\xaf\xfe\xfc\xd6\x8d\x43\xdd\xfb\xaf\xb1\xe9\xfd\xc5\xc2\xde\x18\x85\x5c\x83\x36\x04\xba\x52\x0a\x04\x3e\xf1\x4a\x11\x3c\x3c\x32\x76\x28\x91\x13\xa6\xc4\xb4\x1d\x2c\xdb\x31\x88\x63\x9b\x18\xb0\x1c\xa9\x09\x9f\x63\x85\xff\xb0\xd7\x52\x16\xbc\x3c\xc1\x0b\x9e\x80\x57\x64\xa4\x8e\x91\x05\x6a\xba\xbf\x05\xcc\x25\xeb\x1c\xb3\x0e\xf8\xc3\xc6\xe0\xfb\xd6\x7d\xf8\x84\x11\x1e\x69\x7a\x57\x58\xef\x5d\xe8\xda\x5c\x69\x03\x9b\xef\xf0\x97\x7a\x12\xe1\x06\x0b\x22\xde\x48\xb2\xc0\x35\xe7\x7f\x3e\xa3\xa3\x30\x33\xd3\x6c\x71\x29\xaf\x69\x6f\x68\xe2\x52\xab\x6e\x70\x83\xcf\xa6\xb6\x8d\xb3\xbb\xec\x5c\x6a\x81\xc7\x85\xf3\x3a\xab\x32\x7a\xf9\x17\xb0\xcb\x16\xe3\xfe\x2f\xbc\x57\x02\x20\x04\x02\x00\x00")
+
+func _002_checkoutsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__002_checkoutsUpSql,
+		"002_checkouts.up.sql",
+	)
+}
+
+func _002_checkoutsUpSql() (*asset, error) {
+	bytes, err := _002_checkoutsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "002_checkouts.up.sql", size: 516, mode: os.FileMode(0644), modTime: time.Unix(1685339920, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x51, 0xe5, 0x44, 0x40, 0x31, 0xe8, 0xa, 0x77, 0x73, 0x90, 0x38, 0x12, 0x42, 0x53, 0x9, 0xd1, 0x0, 0x54, 0x6c, 0x2c, 0x1a, 0xeb, 0xcc, 0xf3, 0x1a, 0x1b, 0x63, 0xd7, 0xb1, 0xc6, 0x8f, 0x29}}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -200,8 +242,10 @@ func AssetNames() []string {
 
 // _bindata is a table, holding each asset generator, mapped to its name.
 var _bindata = map[string]func() (*asset, error){
-	"001_init.down.sql": _001_initDownSql,
-	"001_init.up.sql":   _001_initUpSql,
+	"001_init.down.sql":      _001_initDownSql,
+	"001_init.up.sql":        _001_initUpSql,
+	"002_checkouts.down.sql": _002_checkoutsDownSql,
+	"002_checkouts.up.sql":   _002_checkoutsUpSql,
 }
 
 // AssetDebug is true if the assets were built with the debug flag enabled.
@@ -250,8 +294,10 @@ type bintree struct {
 }
 
 var _bintree = &bintree{nil, map[string]*bintree{
-	"001_init.down.sql": {_001_initDownSql, map[string]*bintree{}},
-	"001_init.up.sql":   {_001_initUpSql, map[string]*bintree{}},
+	"001_init.down.sql":      {_001_initDownSql, map[string]*bintree{}},
+	"001_init.up.sql":        {_001_initUpSql, map[string]*bintree{}},
+	"002_checkouts.down.sql": {_002_checkoutsDownSql, map[string]*bintree{}},
+	"002_checkouts.up.sql":   {_002_checkoutsUpSql, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory.
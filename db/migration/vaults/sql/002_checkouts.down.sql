@@ -0,0 +1,2 @@
+DROP TABLE `checkouts`;
+ALTER TABLE `values` DROP COLUMN `checkout_required`;
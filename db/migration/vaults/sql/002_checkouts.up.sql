@@ -0,0 +1,15 @@
+ALTER TABLE `values` ADD COLUMN `checkout_required` BOOLEAN NOT NULL DEFAULT 0;
+
+CREATE TABLE `checkouts`
+(
+    `id`             INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    `value_id`       INTEGER NOT NULL,
+    `username`       TEXT    NOT NULL,
+    `reason`         TEXT    NOT NULL,
+    `checked_out_at` DATETIME NOT NULL,
+    `expires_at`     DATETIME NOT NULL,
+    `checked_in_at`  DATETIME,
+    `rotated`        BOOLEAN NOT NULL DEFAULT 0
+);
+
+CREATE INDEX `checkouts_value_id` ON `checkouts` (`value_id`);
@@ -93,3 +93,29 @@ func TestShouldFailImmediatelyWhenNonBusyError(t *testing.T) {
 	assert.EqualError(t, err, sql.ErrCorrupt.Error())
 	assert.Equal(t, 1, attempts)
 }
+
+func TestSchemaVersion(t *testing.T) {
+	dataSourceName := t.TempDir() + "/test-db.sqlite3"
+	_, _, err := SchemaVersion(dataSourceName)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	db, err := New(dataSourceName, dummy.AssetNames(), dummy.Asset, DataSourceOptions{})
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	latest, err := LatestVersion(dummy.AssetNames())
+	require.NoError(t, err)
+	version, dirty, err := SchemaVersion(dataSourceName)
+	require.NoError(t, err)
+	assert.Equal(t, latest, version)
+	assert.False(t, dirty)
+}
+
+func TestLatestVersion(t *testing.T) {
+	latest, err := LatestVersion([]string{"1_init.up.sql", "1_init.down.sql", "12_add_col.up.sql", "3_x.up.sql"})
+	require.NoError(t, err)
+	assert.Equal(t, uint(12), latest)
+
+	_, err = LatestVersion([]string{"init.sql"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,49 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/jmoiron/sqlx"
+)
+
+// LatestVersion returns the schema version the migrations in assetNames migrate to.
+func LatestVersion(assetNames []string) (uint, error) {
+	var latest uint
+	for _, name := range assetNames {
+		m, err := source.Parse(name)
+		if err != nil {
+			return 0, fmt.Errorf("invalid migration %q: %v", name, err)
+		}
+		if m.Version > latest {
+			latest = m.Version
+		}
+	}
+	return latest, nil
+}
+
+// SchemaVersion returns the schema version of an existing DB without migrating it, 0 if it wasn't migrated yet.
+// dirty is true if a migration failed half way.
+func SchemaVersion(dbPath string) (version uint, dirty bool, err error) {
+	if _, err := os.Stat(dbPath); err != nil {
+		return 0, false, err
+	}
+	db, err := sqlx.Open("sqlite3", "file:"+dbPath+"?mode=ro")
+	if err != nil {
+		return 0, false, err
+	}
+	defer db.Close()
+
+	err = db.QueryRow("SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) || (err != nil && strings.Contains(err.Error(), "no such table")) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return version, dirty, nil
+}
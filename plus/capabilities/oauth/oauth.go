@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net/http"
 	"regexp"
+	"strings"
 	"time"
 )
 
@@ -78,6 +79,8 @@ type DeviceAuthStatusErrorInfo struct {
 	ErrorCode    string `json:"error"`
 	ErrorMessage string `json:"error_description"`
 	ErrorURI     string `json:"error_uri"`
+	// Interval is the minimum seconds to wait before polling again
+	Interval int `json:"interval,omitempty"`
 }
 
 // CapabilityEx represents the functional interface provided by the OAuth
@@ -96,6 +99,15 @@ type CapabilityEx interface {
 
 // Config is the OAuth capability config, as loaded from the rportd config file
 type Config struct {
+	// Name identifies the provider when several are configured, it defaults to the provider type
+	Name string `mapstructure:"name"`
+	// Label is shown on the login page to select the provider
+	Label string `mapstructure:"label"`
+	// Domains are the email domains of the users of the provider to select it by a login hint
+	Domains []string `mapstructure:"domains"`
+	// UserGroups are the groups of users created on their first login via the provider
+	UserGroups []string `mapstructure:"user_groups"`
+
 	Provider             string `mapstructure:"provider"`
 	BaseAuthorizeURL     string `mapstructure:"authorize_url"`
 	TokenURL             string `mapstructure:"token_url"`
@@ -110,6 +122,8 @@ type Config struct {
 	// must be set when the device/cli flow is required.
 	// e.g. when using RPort CLI
 	BaseDeviceAuthorizeURL string `mapstructure:"device_authorize_url"`
+	// DevicePollInterval is the minimum seconds between polls of the device flow, if the provider allows less
+	DevicePollInterval int `mapstructure:"device_poll_interval"`
 
 	// these two fields only required when using Google's device flow
 	DeviceClientID     string `mapstructure:"device_client_id"`
@@ -123,3 +137,34 @@ type Config struct {
 
 	CompiledPermittedUserMatch *regexp.Regexp
 }
+
+// GetName returns the name of the provider or the provider type if no name is set
+func (c *Config) GetName() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.Provider
+}
+
+// GetDevicePollInterval returns the minimum interval between polls of the device flow
+func (c *Config) GetDevicePollInterval(defaultInterval time.Duration) time.Duration {
+	if c.DevicePollInterval <= 0 {
+		return defaultInterval
+	}
+	return time.Duration(c.DevicePollInterval) * time.Second
+}
+
+// MatchesHint returns true if the login hint, a username or an email address, has one of the domains of the provider
+func (c *Config) MatchesHint(hint string) bool {
+	at := strings.LastIndex(hint, "@")
+	if at < 0 {
+		return false
+	}
+	domain := hint[at+1:]
+	for _, d := range c.Domains {
+		if strings.EqualFold(d, domain) {
+			return true
+		}
+	}
+	return false
+}
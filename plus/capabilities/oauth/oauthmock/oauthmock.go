@@ -16,7 +16,10 @@ type MockCapabilityProvider struct {
 	GetUserToken                      string
 	ShouldFailGetLoginInfo            bool
 	ShouldFailGetAccessTokenForDevice bool
-	Username                          string
+	// AccessTokenForDeviceErrInfo is returned with an error by GetAccessTokenForDevice if set
+	AccessTokenForDeviceErrInfo  *oauth.DeviceAuthStatusErrorInfo
+	AccessTokenForDeviceRequests int
+	Username                     string
 }
 
 type Capability struct {
@@ -110,6 +113,11 @@ func (mp *MockCapabilityProvider) GetLoginInfoForDevice(r *http.Request) (loginI
 }
 
 func (mp *MockCapabilityProvider) GetAccessTokenForDevice(r *http.Request) (token string, username string, errInfo *oauth.DeviceAuthStatusErrorInfo, err error) {
+	mp.AccessTokenForDeviceRequests++
+	if mp.AccessTokenForDeviceErrInfo != nil {
+		errInfo := *mp.AccessTokenForDeviceErrInfo
+		return "", "", &errInfo, errors.New(errInfo.ErrorCode)
+	}
 	if mp.ShouldFailGetAccessTokenForDevice {
 		errInfo := &oauth.DeviceAuthStatusErrorInfo{
 			StatusCode:   http.StatusForbidden,
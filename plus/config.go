@@ -16,4 +16,6 @@ type PlusConfig struct {
 	PluginConfig  *PluginConfig   `mapstructure:"plus-plugin"`
 	OAuthConfig   *oauth.Config   `mapstructure:"plus-oauth"`
 	LicenseConfig *license.Config `mapstructure:"plus-license"`
+	// OAuthProviders are offered in addition to the provider of the OAuth config
+	OAuthProviders []*oauth.Config `mapstructure:"plus-oauth-providers"`
 }
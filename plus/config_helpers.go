@@ -1,5 +1,15 @@
 package rportplus
 
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/IOTech17/neo-rport/plus/capabilities/oauth"
+)
+
+var oauthProviderNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
 func IsPlusEnabled(config PlusConfig) bool {
 	return config.PluginConfig != nil &&
 		config.PluginConfig.PluginPath != ""
@@ -19,3 +29,36 @@ func IsOAuthPermittedUserList(config PlusConfig) bool {
 	}
 	return config.OAuthConfig != nil && config.OAuthConfig.PermittedUserList
 }
+
+// OAuthProviders returns the configured OAuth providers, the provider of the OAuth config first
+func OAuthProviders(config PlusConfig) []*oauth.Config {
+	if !IsPlusOAuthEnabled(config) {
+		return nil
+	}
+	return append([]*oauth.Config{config.OAuthConfig}, config.OAuthProviders...)
+}
+
+// ValidateOAuthProviders checks the additional OAuth providers can be told apart by their names
+func ValidateOAuthProviders(config PlusConfig) error {
+	if len(config.OAuthProviders) == 0 {
+		return nil
+	}
+	if config.OAuthConfig == nil {
+		return errors.New("plus-oauth-providers require plus-oauth to be configured")
+	}
+	names := make(map[string]bool)
+	for _, p := range OAuthProviders(config) {
+		if p == nil {
+			continue
+		}
+		name := p.GetName()
+		if !oauthProviderNameRegex.MatchString(name) {
+			return fmt.Errorf("invalid oauth provider name %q, only letters, digits, '-' and '_' are allowed", name)
+		}
+		if names[name] {
+			return fmt.Errorf("oauth provider name %q is not unique, set a name for each provider of the same type", name)
+		}
+		names[name] = true
+	}
+	return nil
+}
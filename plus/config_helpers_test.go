@@ -0,0 +1,70 @@
+package rportplus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/IOTech17/neo-rport/plus/capabilities/oauth"
+)
+
+func TestValidateOAuthProviders(t *testing.T) {
+	plugin := &PluginConfig{PluginPath: "/usr/local/lib/rport/rport-plus.so"}
+	github := &oauth.Config{Provider: oauth.GitHubOAuthProvider}
+
+	testCases := []struct {
+		Name        string
+		Config      PlusConfig
+		ExpectedErr string
+	}{
+		{
+			Name:   "single provider",
+			Config: PlusConfig{PluginConfig: plugin, OAuthConfig: github},
+		},
+		{
+			Name: "providers of different types",
+			Config: PlusConfig{PluginConfig: plugin, OAuthConfig: github, OAuthProviders: []*oauth.Config{
+				{Provider: oauth.MicrosoftOAuthProvider},
+			}},
+		},
+		{
+			Name: "providers of the same type",
+			Config: PlusConfig{PluginConfig: plugin, OAuthConfig: github, OAuthProviders: []*oauth.Config{
+				{Provider: oauth.GitHubOAuthProvider},
+			}},
+			ExpectedErr: `oauth provider name "github" is not unique, set a name for each provider of the same type`,
+		},
+		{
+			Name: "named providers of the same type",
+			Config: PlusConfig{PluginConfig: plugin, OAuthConfig: github, OAuthProviders: []*oauth.Config{
+				{Name: "customers", Provider: oauth.GitHubOAuthProvider},
+			}},
+		},
+		{
+			Name: "invalid name",
+			Config: PlusConfig{PluginConfig: plugin, OAuthConfig: github, OAuthProviders: []*oauth.Config{
+				{Name: "customers/eu", Provider: oauth.MicrosoftOAuthProvider},
+			}},
+			ExpectedErr: `invalid oauth provider name "customers/eu", only letters, digits, '-' and '_' are allowed`,
+		},
+		{
+			Name: "no default provider",
+			Config: PlusConfig{PluginConfig: plugin, OAuthProviders: []*oauth.Config{
+				{Provider: oauth.MicrosoftOAuthProvider},
+			}},
+			ExpectedErr: "plus-oauth-providers require plus-oauth to be configured",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			err := ValidateOAuthProviders(tc.Config)
+			if tc.ExpectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.ExpectedErr)
+			}
+		})
+	}
+}
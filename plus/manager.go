@@ -50,6 +50,7 @@ type Manager interface {
 
 	// Access specific capabilities
 	GetOAuthCapabilityEx() (capEx oauth.CapabilityEx)
+	GetOAuthProviderCapabilityEx(name string) (capEx oauth.CapabilityEx)
 	GetStatusCapabilityEx() (capEx status.CapabilityEx)
 	GetExtendedPermissionCapabilityEx() (capEx extendedpermission.CapabilityEx)
 	GetLicenseCapabilityEx() (capEx licensecap.CapabilityEx)
@@ -162,6 +163,25 @@ func (pm *ManagerProvider) GetOAuthCapabilityEx() (capEx oauth.CapabilityEx) {
 	return nil
 }
 
+// OAuthProviderCapabilityName returns the capability name of an additional OAuth provider
+func OAuthProviderCapabilityName(name string) string {
+	return PlusOAuthCapability + "/" + name
+}
+
+// GetOAuthProviderCapabilityEx returns a cast version of the OAuth capability of an additional provider
+func (pm *ManagerProvider) GetOAuthProviderCapabilityEx(name string) (capEx oauth.CapabilityEx) {
+	capEntry := pm.getCap(OAuthProviderCapabilityName(name))
+	if capEntry != nil {
+		cap, ok := capEntry.(*oauth.Capability)
+		if !ok {
+			return nil
+		}
+		return cap.GetOAuthCapabilityEx()
+	}
+
+	return nil
+}
+
 // GetStatusCapabilityEx returns a cast version of the Plus Status capability
 func (pm *ManagerProvider) GetStatusCapabilityEx() (capEx status.CapabilityEx) {
 	capEntry := pm.getCap(PlusStatusCapability)
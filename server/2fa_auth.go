@@ -12,6 +12,7 @@ import (
 
 	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
 	"github.com/IOTech17/neo-rport/server/api/message"
+	"github.com/IOTech17/neo-rport/server/branding"
 	"github.com/IOTech17/neo-rport/share/security"
 )
 
@@ -20,6 +21,7 @@ type TwoFAService struct {
 	MsgSrv      message.Service
 	UserSrv     UserService
 	SendTimeout time.Duration
+	Branding    *branding.Provider
 
 	tokensByUser map[string]*expirableToken
 	mu           sync.RWMutex
@@ -76,13 +78,15 @@ func (srv *TwoFAService) SendToken(ctx context.Context, username string, userAge
 		return "", fmt.Errorf("failed to generate 2fa token: %wv", err)
 	}
 
+	brand := srv.Branding.Get()
 	data := message.Data{
 		SendTo:        user.TwoFASendTo,
 		Token:         token,
 		TTL:           srv.TokenTTL,
-		Title:         "🔐 RRort Two-Factor Token",
+		Title:         fmt.Sprintf("🔐 %s Two-Factor Token", brand.GetProductName()),
 		RemoteAddress: remoteAddress,
 		UserAgent:     userAgent,
+		Branding:      brand,
 	}
 	if err := srv.MsgSrv.Send(ctx, data); err != nil {
 		if ctx.Err() != nil {
@@ -0,0 +1,153 @@
+package chserver
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/cgroups"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/clients/meshtunnel"
+	"github.com/IOTech17/neo-rport/server/routes"
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+const (
+	accessOverrideDefaultDuration = time.Hour
+	accessOverrideMaxDuration     = 24 * time.Hour
+)
+
+// clientAccessSchedules collects the access schedules of all groups the client belongs to by group ID.
+func clientAccessSchedules(client *clientdata.Client, groups []*cgroups.ClientGroup) map[string]*cgroups.AccessSchedule {
+	res := make(map[string]*cgroups.AccessSchedule)
+	for _, group := range groups {
+		if group.AccessSchedule != nil && client.BelongsTo(group) {
+			res[group.ID] = group.AccessSchedule
+		}
+	}
+	return res
+}
+
+type accessOverrideRequest struct {
+	DurationMinutes int    `json:"duration_minutes"`
+	Reason          string `json:"reason"`
+}
+
+type accessOverridePayload struct {
+	Token         string    `json:"token"`
+	ClientGroupID string    `json:"client_group_id"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+// handlePostClientGroupAccessOverride handles POST /client-groups/{group_id}/access-override. It returns a token
+// lifting the access schedule of the group for tunnels created with it, e.g. for emergencies.
+func (al *APIListener) handlePostClientGroupAccessOverride(w http.ResponseWriter, req *http.Request) {
+	id := mux.Vars(req)[routes.ParamGroupID]
+
+	var body accessOverrideRequest
+	if err := parseRequestBody(req.Body, &body); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	duration := time.Duration(body.DurationMinutes) * time.Minute
+	if body.DurationMinutes == 0 {
+		duration = accessOverrideDefaultDuration
+	}
+	if duration <= 0 || duration > accessOverrideMaxDuration {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("duration_minutes must be between 1 and %d", int(accessOverrideMaxDuration.Minutes())))
+		return
+	}
+
+	group, err := al.clientGroupProvider.Get(req.Context(), id)
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to find client group[id=%q].", id), err)
+		return
+	}
+	if group == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("Client Group[id=%q] not found.", id))
+		return
+	}
+	if group.AccessSchedule == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusConflict, fmt.Sprintf("Client Group[id=%q] has no access schedule.", id))
+		return
+	}
+
+	override := models.AccessOverride{
+		ClientGroupID: id,
+		ExpiresAt:     time.Now().Add(duration).Truncate(time.Second).UTC(),
+	}
+	token := cgroups.NewAccessOverrideToken([]byte(al.config.API.JWTSecret), override)
+
+	al.auditLog.Entry(auditlog.ApplicationClientGroupOverride, auditlog.ActionCreate).
+		WithHTTPRequest(req).
+		WithRequest(body).
+		WithID(id).
+		Save()
+	al.Infof("Access override of client group %q until %s created by %s: %s", id, override.ExpiresAt.Format(time.RFC3339), api.GetUser(req.Context(), al.Logger), body.Reason)
+
+	al.writeJSONResponse(w, http.StatusCreated, api.NewSuccessPayload(accessOverridePayload{
+		Token:         token,
+		ClientGroupID: override.ClientGroupID,
+		ExpiresAt:     override.ExpiresAt,
+	}))
+}
+
+// checkTunnelAccessSchedules returns an error if the access schedules of the client groups don't allow a new tunnel,
+// the override of the access_override query param is set on the remote.
+func (al *APIListener) checkTunnelAccessSchedules(req *http.Request, client *clientdata.Client, remote *models.Remote) error {
+	override, err := al.checkAccessSchedules(req, client)
+	if err != nil {
+		return err
+	}
+	remote.AccessOverride = override
+	if override != nil {
+		client.Log().Infof("Tunnel to %s created with the access override of client group %q", remote.Remote(), override.ClientGroupID)
+	}
+	return nil
+}
+
+// checkMeshTunnelAccessSchedules returns an error if the access schedules of the client groups of the source or target
+// client don't allow a new mesh tunnel, the override of the access_override query param is set on the mesh tunnel.
+func (al *APIListener) checkMeshTunnelAccessSchedules(req *http.Request, mt *meshtunnel.MeshTunnel, source, target *clientdata.Client) error {
+	for _, client := range []*clientdata.Client{source, target} {
+		override, err := al.checkAccessSchedules(req, client)
+		if err != nil {
+			return err
+		}
+		mt.AccessOverride = override
+	}
+	if mt.AccessOverride != nil {
+		source.Log().Infof("Mesh tunnel to %s on client %s created with the access override of client group %q", mt.Remote, mt.TargetClientID, mt.AccessOverride.ClientGroupID)
+	}
+	return nil
+}
+
+// checkAccessSchedules returns an error if the access schedules of the client groups don't allow a new connection to
+// the client, unless lifted by the override of the access_override query param. The override is returned if given.
+func (al *APIListener) checkAccessSchedules(req *http.Request, client *clientdata.Client) (*models.AccessOverride, error) {
+	now := time.Now()
+	var override *models.AccessOverride
+	if token := req.URL.Query().Get("access_override"); token != "" {
+		var err error
+		override, err = cgroups.ParseAccessOverrideToken([]byte(al.config.API.JWTSecret), token, now)
+		if err != nil {
+			return nil, errors2.APIError{
+				HTTPStatus: http.StatusForbidden,
+				Err:        err,
+			}
+		}
+	}
+
+	if err := cgroups.CheckAccessSchedules(client.GetAccessSchedules(), now, override); err != nil {
+		return nil, errors2.APIError{
+			HTTPStatus: http.StatusForbidden,
+			Err:        err,
+		}
+	}
+	return override, nil
+}
@@ -0,0 +1,120 @@
+package chserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/cgroups"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/clients"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/clients/meshtunnel"
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/models"
+	"github.com/IOTech17/neo-rport/share/security"
+	"github.com/IOTech17/neo-rport/share/test"
+)
+
+func TestClientGroupAccessOverride(t *testing.T) {
+	never := &cgroups.AccessSchedule{Windows: []cgroups.AccessWindow{{Days: []string{"sat"}, Start: "00:00", End: "00:01"}}}
+	routers := &cgroups.ClientGroup{ID: "routers", Params: &cgroups.ClientParams{ClientID: &cgroups.ParamValues{"router-*"}}, AccessSchedule: never}
+	al := &APIListener{
+		Logger:      testLog,
+		bannedUsers: security.NewBanList(0),
+		apiSessions: newEmptyAPISessionCache(t),
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+					JWTSecret:       "secret",
+				},
+			},
+			clientGroupProvider: staticClientGroupProvider{groups: []*cgroups.ClientGroup{routers, {ID: "servers"}}},
+		},
+		userService: users.NewAPIService(users.NewStaticProvider([]*users.User{
+			{Username: "admin", Password: "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm", Groups: []string{users.Administrators}},
+		}), false, 0, -1),
+	}
+	al.initRouter()
+
+	request := func(url, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, url, strings.NewReader(body))
+		req.SetBasicAuth("admin", "pwd")
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("/api/v1/client-groups/unknown/access-override", `{}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = request("/api/v1/client-groups/servers/access-override", `{}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = request("/api/v1/client-groups/routers/access-override", `{"duration_minutes":1441}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = request("/api/v1/client-groups/routers/access-override", `{"duration_minutes":30,"reason":"outage"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var res struct {
+		Data accessOverridePayload `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, "routers", res.Data.ClientGroupID)
+	assert.WithinDuration(t, time.Now().Add(30*time.Minute), res.Data.ExpiresAt, time.Minute)
+
+	client := &clientdata.Client{ID: "router-1", Logger: testLog}
+	client.SetAccessSchedules(clientAccessSchedules(client, []*cgroups.ClientGroup{routers, {ID: "servers"}}))
+	assert.Equal(t, map[string]*cgroups.AccessSchedule{"routers": never}, client.GetAccessSchedules())
+
+	tunnelRequest := func(token string) *http.Request {
+		return httptest.NewRequest(http.MethodPut, "/api/v1/clients/router-1/tunnels?remote=22&access_override="+url.QueryEscape(token), nil)
+	}
+
+	remote := &models.Remote{}
+	err := al.checkTunnelAccessSchedules(tunnelRequest(""), client, remote)
+	assert.EqualError(t, err, `access to the clients of group "routers" is not allowed at this time`)
+
+	err = al.checkTunnelAccessSchedules(tunnelRequest("invalid"), client, remote)
+	assert.EqualError(t, err, "invalid access override token")
+
+	err = al.checkTunnelAccessSchedules(tunnelRequest(res.Data.Token), client, remote)
+	require.NoError(t, err)
+	require.NotNil(t, remote.AccessOverride)
+	assert.Equal(t, "routers", remote.AccessOverride.ClientGroupID)
+
+	// mesh tunnels check both clients, relayed connections are refused outside the window
+	server := clients.New(t).ID("server-1").Logger(testLog).Build()
+	server.SetConnection(test.NewConnMock())
+	router := clients.New(t).ID("router-1").Logger(testLog).Build()
+	router.SetConnection(test.NewConnMock())
+	router.SetAccessSchedules(map[string]*cgroups.AccessSchedule{"routers": never})
+	mt, err := meshtunnel.New("server-1", "router-1", "127.0.0.1:2222", "127.0.0.1:22", meshtunnel.ModeRelay, "admin")
+	require.NoError(t, err)
+
+	err = al.checkMeshTunnelAccessSchedules(tunnelRequest(""), mt, server, router)
+	assert.EqualError(t, err, `access to the clients of group "routers" is not allowed at this time`)
+
+	meshTunnels := meshtunnel.NewManager()
+	require.NoError(t, meshTunnels.Add(mt))
+	cl := &ClientListener{server: &Server{
+		clientService: clients.NewClientService(nil, nil, clients.NewClientRepository([]*clientdata.Client{server, router}, &hour, testLog), testLog, nil),
+		meshTunnels:   meshTunnels,
+	}}
+	relay := &newChannelMock{channelType: comm.ChannelMeshTunnel, extraData: []byte(mt.ID)}
+	cl.handleMeshTunnelChannel(testLog, "server-1", relay)
+	assert.Equal(t, ssh.Prohibited, relay.rejected)
+
+	err = al.checkMeshTunnelAccessSchedules(tunnelRequest(res.Data.Token), mt, server, router)
+	require.NoError(t, err)
+	require.NotNil(t, mt.AccessOverride)
+	assert.Equal(t, "routers", mt.AccessOverride.ClientGroupID)
+}
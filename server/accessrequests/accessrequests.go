@@ -0,0 +1,88 @@
+// Package accessrequests implements just-in-time access. Users request temporary access to the clients of a client
+// group with a justification, an administrator approves or denies the request and approved access expires on its own.
+package accessrequests
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/IOTech17/neo-rport/share/refs"
+)
+
+type Status string
+
+const (
+	StatusPending  Status = "pending"
+	StatusApproved Status = "approved"
+	StatusDenied   Status = "denied"
+	StatusRevoked  Status = "revoked"
+	StatusExpired  Status = "expired"
+)
+
+const (
+	RefType refs.IdentifiableType = "access-request"
+
+	DefaultDuration    = time.Hour
+	DefaultMaxDuration = 8 * time.Hour
+	// ExpiryInterval is how often approved requests are checked for their expiry
+	ExpiryInterval = time.Minute
+)
+
+// Config holds the limits of access requests and who is notified about them.
+type Config struct {
+	MaxDuration  time.Duration `mapstructure:"max_duration"`
+	NotifyEmails []string      `mapstructure:"notify_emails"`
+	NotifyScript string        `mapstructure:"notify_script"`
+}
+
+func (c Config) Validate() error {
+	if c.MaxDuration < 0 {
+		return errors.New("max_duration must not be negative")
+	}
+	return nil
+}
+
+// GetMaxDuration returns the longest access that can be requested, DefaultMaxDuration if not set.
+func (c Config) GetMaxDuration() time.Duration {
+	if c.MaxDuration == 0 {
+		return DefaultMaxDuration
+	}
+	return c.MaxDuration
+}
+
+// ValidateDuration returns an error if the requested duration in minutes is not within the configured maximum.
+func (c Config) ValidateDuration(minutes int) error {
+	maxMinutes := int(c.GetMaxDuration() / time.Minute)
+	if minutes < 1 || minutes > maxMinutes {
+		return fmt.Errorf("duration_minutes must be from 1 to %d", maxMinutes)
+	}
+	return nil
+}
+
+// Request is the request of a user for access to the clients of a client group. Once approved the access is granted
+// for DurationMinutes until ExpiresAt, in addition to the access the user has by its user groups.
+type Request struct {
+	ID              string     `json:"id" db:"id"`
+	Username        string     `json:"username" db:"username"`
+	ClientGroupID   string     `json:"client_group_id" db:"client_group_id"`
+	Justification   string     `json:"justification" db:"justification"`
+	DurationMinutes int        `json:"duration_minutes" db:"duration_minutes"`
+	Status          Status     `json:"status" db:"status"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	DecidedAt       *time.Time `json:"decided_at" db:"decided_at"`
+	DecidedBy       string     `json:"decided_by" db:"decided_by"`
+	Comment         string     `json:"comment" db:"comment"`
+	ExpiresAt       *time.Time `json:"expires_at" db:"expires_at"`
+	RevokedAt       *time.Time `json:"revoked_at" db:"revoked_at"`
+	RevokedBy       string     `json:"revoked_by" db:"revoked_by"`
+}
+
+// IsActive returns true if the request grants access at the given time.
+func (r *Request) IsActive(now time.Time) bool {
+	return r.Status == StatusApproved && r.ExpiresAt != nil && r.ExpiresAt.After(now)
+}
+
+func (r *Request) Duration() time.Duration {
+	return time.Duration(r.DurationMinutes) * time.Minute
+}
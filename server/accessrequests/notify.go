@@ -0,0 +1,110 @@
+package accessrequests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/IOTech17/neo-rport/server/notifications"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/refs"
+)
+
+type Event string
+
+const (
+	EventRequested Event = "requested"
+	EventApproved  Event = "approved"
+	EventDenied    Event = "denied"
+	EventRevoked   Event = "revoked"
+)
+
+// Notification is the content sent to the notify script.
+type Notification struct {
+	Event   Event    `json:"event"`
+	Request *Request `json:"request"`
+}
+
+// Notifier informs the approvers about new requests and about the decisions on requests.
+type Notifier struct {
+	logger     *logger.Logger
+	dispatcher notifications.Dispatcher
+	emails     []string
+	script     string
+}
+
+func NewNotifier(config Config, l *logger.Logger, dispatcher notifications.Dispatcher) *Notifier {
+	return &Notifier{
+		logger:     l,
+		dispatcher: dispatcher,
+		emails:     config.NotifyEmails,
+		script:     config.NotifyScript,
+	}
+}
+
+// Notify sends the event to the configured emails and the script. Failures are only logged, they must not fail the
+// request or the decision.
+func (n *Notifier) Notify(ctx context.Context, event Event, r *Request) {
+	if n == nil {
+		return
+	}
+
+	refID := refs.NewIdentifiable(RefType, r.ID)
+	if len(n.emails) > 0 {
+		notification := notifications.NotificationData{
+			Target:      "smtp",
+			Recipients:  n.emails,
+			Subject:     fmt.Sprintf("[rport] Access request of %s to client group %s %s", r.Username, r.ClientGroupID, event),
+			Content:     message(event, r),
+			ContentType: notifications.ContentTypeTextPlain,
+		}
+		if _, err := n.dispatcher.Dispatch(ctx, refID, notification); err != nil {
+			n.logger.Errorf("Failed to send access request email: %v", err)
+		}
+	}
+	if n.script != "" {
+		content, err := json.Marshal(Notification{Event: event, Request: r})
+		if err != nil {
+			n.logger.Errorf("Failed to marshal access request notification: %v", err)
+			return
+		}
+		notification := notifications.NotificationData{
+			Target:      n.script,
+			Recipients:  n.emails,
+			Subject:     "access-request",
+			Content:     string(content),
+			ContentType: notifications.ContentTypeTextJSON,
+		}
+		if _, err := n.dispatcher.Dispatch(ctx, refID, notification); err != nil {
+			n.logger.Errorf("Failed to run access request notify script: %v", err)
+		}
+	}
+}
+
+func message(event Event, r *Request) string {
+	msg := fmt.Sprintf(`User %s requested access to the clients of client group %s for %s.
+
+Justification: %s
+Request ID: %s
+`, r.Username, r.ClientGroupID, r.Duration(), r.Justification, r.ID)
+
+	switch event {
+	case EventRequested:
+		msg += fmt.Sprintf(`
+Approve or deny the request with
+  POST /api/v1/access-requests/%s/approve
+  POST /api/v1/access-requests/%s/deny
+`, r.ID, r.ID)
+	case EventApproved:
+		msg += fmt.Sprintf("\nApproved by %s, the access expires at %s.\n", r.DecidedBy, r.ExpiresAt.UTC().Format(time.RFC1123))
+	case EventDenied:
+		msg += fmt.Sprintf("\nDenied by %s.\n", r.DecidedBy)
+	case EventRevoked:
+		msg += fmt.Sprintf("\nRevoked by %s.\n", r.RevokedBy)
+	}
+	if r.Comment != "" && event != EventRequested {
+		msg += fmt.Sprintf("Comment: %s\n", r.Comment)
+	}
+	return msg
+}
@@ -0,0 +1,79 @@
+package accessrequests
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/server/notifications"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/refs"
+)
+
+var testLog = logger.NewLogger("access-requests", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+
+type mockDispatcher struct {
+	notifications []notifications.NotificationData
+}
+
+func (d *mockDispatcher) Dispatch(ctx context.Context, refID refs.Identifiable, notification notifications.NotificationData) (refs.Identifiable, error) {
+	d.notifications = append(d.notifications, notification)
+	return refs.GenerateIdentifiable(notifications.NotificationType), nil
+}
+
+func TestNotify(t *testing.T) {
+	dispatcher := &mockDispatcher{}
+	n := NewNotifier(Config{
+		NotifyEmails: []string{"approvers@example.com"},
+		NotifyScript: "/usr/local/bin/access-request.sh",
+	}, testLog, dispatcher)
+	r := &Request{
+		ID:              "r1",
+		Username:        "jane",
+		ClientGroupID:   "routers",
+		Justification:   "INC-1234",
+		DurationMinutes: 90,
+		Status:          StatusPending,
+		CreatedAt:       time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	n.Notify(context.Background(), EventRequested, r)
+	require.Len(t, dispatcher.notifications, 2)
+
+	mail := dispatcher.notifications[0]
+	assert.Equal(t, "smtp", mail.Target)
+	assert.Equal(t, []string{"approvers@example.com"}, mail.Recipients)
+	assert.Equal(t, "[rport] Access request of jane to client group routers requested", mail.Subject)
+	assert.Contains(t, mail.Content, "for 1h30m0s")
+	assert.Contains(t, mail.Content, "Justification: INC-1234")
+	assert.Contains(t, mail.Content, "POST /api/v1/access-requests/r1/approve")
+
+	script := dispatcher.notifications[1]
+	assert.Equal(t, "/usr/local/bin/access-request.sh", script.Target)
+	assert.Equal(t, notifications.ContentTypeTextJSON, script.ContentType)
+	var content Notification
+	require.NoError(t, json.Unmarshal([]byte(script.Content), &content))
+	assert.Equal(t, EventRequested, content.Event)
+	assert.Equal(t, "routers", content.Request.ClientGroupID)
+
+	expiresAt := r.CreatedAt.Add(r.Duration())
+	r.Status, r.DecidedBy, r.Comment, r.ExpiresAt = StatusApproved, "admin", "go ahead", &expiresAt
+	n.Notify(context.Background(), EventApproved, r)
+	require.Len(t, dispatcher.notifications, 4)
+	assert.Contains(t, dispatcher.notifications[2].Content, "Approved by admin, the access expires at Sun, 01 Mar 2026 13:30:00 UTC.")
+	assert.Contains(t, dispatcher.notifications[2].Content, "Comment: go ahead")
+}
+
+func TestNotifyNotConfigured(t *testing.T) {
+	dispatcher := &mockDispatcher{}
+	n := NewNotifier(Config{}, testLog, dispatcher)
+
+	n.Notify(context.Background(), EventRequested, &Request{ID: "r1"})
+
+	assert.Empty(t, dispatcher.notifications)
+}
@@ -0,0 +1,123 @@
+package accessrequests
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type SqliteProvider struct {
+	db *sqlx.DB
+}
+
+func NewSqliteProvider(db *sqlx.DB) *SqliteProvider {
+	return &SqliteProvider{
+		db: db,
+	}
+}
+
+// List returns the requests of a user or of all users if username is empty, the latest first. An empty status
+// returns requests of any status.
+func (p *SqliteProvider) List(ctx context.Context, username string, status Status) ([]*Request, error) {
+	q := "SELECT * FROM access_requests WHERE 1 = 1"
+	var params []interface{}
+	if username != "" {
+		q += " AND username = ?"
+		params = append(params, username)
+	}
+	if status != "" {
+		q += " AND status = ?"
+		params = append(params, status)
+	}
+	q += " ORDER BY created_at DESC"
+
+	result := []*Request{}
+	if err := p.db.SelectContext(ctx, &result, q, params...); err != nil {
+		return nil, fmt.Errorf("unable to get access requests from DB: %w", err)
+	}
+	return result, nil
+}
+
+func (p *SqliteProvider) Get(ctx context.Context, id string) (*Request, error) {
+	res := &Request{}
+	err := p.db.GetContext(ctx, res, "SELECT * FROM access_requests WHERE id = ?", id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to get access request from DB: %w", err)
+	}
+	return res, nil
+}
+
+func (p *SqliteProvider) Create(ctx context.Context, r *Request) error {
+	_, err := p.db.NamedExecContext(
+		ctx,
+		`INSERT INTO access_requests (id, username, client_group_id, justification, duration_minutes, status, created_at, decided_by, comment, revoked_by)
+			VALUES (:id, :username, :client_group_id, :justification, :duration_minutes, :status, :created_at, :decided_by, :comment, :revoked_by)`,
+		r,
+	)
+	return err
+}
+
+// Decide approves or denies a pending request, an approved request expires at expiresAt. It returns false if the
+// request isn't pending anymore.
+func (p *SqliteProvider) Decide(ctx context.Context, id string, status Status, by, comment string, at time.Time, expiresAt *time.Time) (bool, error) {
+	return p.transition(
+		ctx,
+		"UPDATE access_requests SET status = ?, decided_by = ?, comment = ?, decided_at = ?, expires_at = ? WHERE id = ? AND status = ?",
+		status, by, comment, at.UTC(), expiresAt, id, StatusPending,
+	)
+}
+
+// Revoke ends the access of an approved request before it expires. It returns false if the request isn't approved.
+func (p *SqliteProvider) Revoke(ctx context.Context, id, by string, at time.Time) (bool, error) {
+	return p.transition(
+		ctx,
+		"UPDATE access_requests SET status = ?, revoked_by = ?, revoked_at = ? WHERE id = ? AND status = ?",
+		StatusRevoked, by, at.UTC(), id, StatusApproved,
+	)
+}
+
+func (p *SqliteProvider) transition(ctx context.Context, q string, params ...interface{}) (bool, error) {
+	res, err := p.db.ExecContext(ctx, q, params...)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// Expire marks the approved requests expired at the given time, it returns their number.
+func (p *SqliteProvider) Expire(ctx context.Context, now time.Time) (int64, error) {
+	res, err := p.db.ExecContext(
+		ctx,
+		"UPDATE access_requests SET status = ? WHERE status = ? AND expires_at <= ?",
+		StatusExpired, StatusApproved, now.UTC(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// GetGrantedClientGroups returns the ids of the client groups the user has access to by approved requests.
+func (p *SqliteProvider) GetGrantedClientGroups(ctx context.Context, username string, now time.Time) ([]string, error) {
+	var result []string
+	err := p.db.SelectContext(
+		ctx,
+		&result,
+		"SELECT DISTINCT client_group_id FROM access_requests WHERE username = ? AND status = ? AND expires_at > ?",
+		username, StatusApproved, now.UTC(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get granted client groups from DB: %w", err)
+	}
+	return result, nil
+}
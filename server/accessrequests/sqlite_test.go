@@ -0,0 +1,110 @@
+package accessrequests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/db/migration/api_token"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+)
+
+var DataSourceOptions = sqlite.DataSourceOptions{WALEnabled: false}
+
+func TestSqliteProvider(t *testing.T) {
+	db, err := sqlite.New(":memory:", api_token.AssetNames(), api_token.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+	p := NewSqliteProvider(db)
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, r := range []*Request{
+		{ID: "r1", Username: "jane", ClientGroupID: "routers", Justification: "INC-1", DurationMinutes: 60},
+		{ID: "r2", Username: "jane", ClientGroupID: "servers", Justification: "INC-2", DurationMinutes: 30},
+		{ID: "r3", Username: "john", ClientGroupID: "routers", Justification: "INC-3", DurationMinutes: 60},
+	} {
+		r.Status = StatusPending
+		r.CreatedAt = now.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, p.Create(ctx, r))
+	}
+
+	all, err := p.List(ctx, "", "")
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, "r3", all[0].ID)
+	own, err := p.List(ctx, "jane", StatusPending)
+	require.NoError(t, err)
+	assert.Len(t, own, 2)
+
+	missing, err := p.Get(ctx, "unknown")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	expiresAt := now.Add(time.Hour)
+	ok, err := p.Decide(ctx, "r1", StatusApproved, "admin", "ok", now, &expiresAt)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = p.Decide(ctx, "r1", StatusDenied, "admin", "", now, nil)
+	require.NoError(t, err)
+	assert.False(t, ok, "a decided request can't be decided again")
+	ok, err = p.Decide(ctx, "r2", StatusDenied, "admin", "no incident", now, nil)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	r1, err := p.Get(ctx, "r1")
+	require.NoError(t, err)
+	assert.Equal(t, StatusApproved, r1.Status)
+	assert.Equal(t, "admin", r1.DecidedBy)
+	assert.Equal(t, "ok", r1.Comment)
+	assert.True(t, r1.IsActive(now))
+	assert.False(t, r1.IsActive(expiresAt))
+
+	groups, err := p.GetGrantedClientGroups(ctx, "jane", now)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"routers"}, groups)
+	groups, err = p.GetGrantedClientGroups(ctx, "jane", expiresAt)
+	require.NoError(t, err)
+	assert.Empty(t, groups)
+
+	expired, err := p.Expire(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), expired)
+	expired, err = p.Expire(ctx, expiresAt)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), expired)
+	r1, err = p.Get(ctx, "r1")
+	require.NoError(t, err)
+	assert.Equal(t, StatusExpired, r1.Status)
+
+	ok, err = p.Decide(ctx, "r3", StatusApproved, "admin", "", now, &expiresAt)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = p.Revoke(ctx, "r3", "root", now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = p.Revoke(ctx, "r3", "root", now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.False(t, ok)
+	r3, err := p.Get(ctx, "r3")
+	require.NoError(t, err)
+	assert.Equal(t, StatusRevoked, r3.Status)
+	assert.Equal(t, "admin", r3.DecidedBy)
+	assert.Equal(t, "root", r3.RevokedBy)
+	groups, err = p.GetGrantedClientGroups(ctx, "john", now.Add(2*time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, groups)
+}
+
+func TestValidateDuration(t *testing.T) {
+	c := Config{MaxDuration: 8 * time.Hour}
+
+	assert.NoError(t, c.ValidateDuration(1))
+	assert.NoError(t, c.ValidateDuration(480))
+	assert.EqualError(t, c.ValidateDuration(0), "duration_minutes must be from 1 to 480")
+	assert.EqualError(t, c.ValidateDuration(481), "duration_minutes must be from 1 to 480")
+	assert.EqualError(t, Config{}.ValidateDuration(481), "duration_minutes must be from 1 to 480")
+}
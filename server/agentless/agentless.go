@@ -0,0 +1,90 @@
+package agentless
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Target is a device without an rport client, managed through a connected client acting as jump host. The
+// credentials are a vault value holding the password or a private key in PEM format of the user, winrm requires a
+// password.
+type Target struct {
+	ID           string `db:"id" json:"id"`
+	Name         string `db:"name" json:"name"`
+	Description  string `db:"description" json:"description"`
+	Tags         Tags   `db:"tags" json:"tags"`
+	Protocol     string `db:"protocol" json:"protocol"`
+	Host         string `db:"host" json:"host"`
+	Port         int    `db:"port" json:"port"`
+	Username     string `db:"username" json:"username"`
+	VaultValueID int    `db:"vault_value_id" json:"vault_value_id"`
+	JumpClientID string `db:"jump_client_id" json:"jump_client_id"`
+	// HostKey is the SHA256 fingerprint of the host key, or of the TLS certificate for winrm. Until it's set the
+	// target is only probed for its key, no credentials are sent.
+	HostKey        string     `db:"host_key" json:"host_key"`
+	Monitoring     bool       `db:"monitoring" json:"monitoring"`
+	Facts          Facts      `db:"facts" json:"facts"`
+	FactsUpdatedAt *time.Time `db:"facts_updated_at" json:"facts_updated_at"`
+	LastSeenAt     *time.Time `db:"last_seen_at" json:"last_seen_at"`
+	LastError      string     `db:"last_error" json:"last_error"`
+	CreatedBy      string     `db:"created_by" json:"created_by"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+}
+
+// Facts describe the system of a target, they are collected from winrm targets on request.
+type Facts struct {
+	Hostname     string `json:"hostname,omitempty"`
+	Domain       string `json:"domain,omitempty"`
+	OS           string `json:"os,omitempty"`
+	OSVersion    string `json:"os_version,omitempty"`
+	Manufacturer string `json:"manufacturer,omitempty"`
+	Model        string `json:"model,omitempty"`
+	SerialNumber string `json:"serial_number,omitempty"`
+	CPUs         int    `json:"cpus,omitempty"`
+	// MemoryTotal is in bytes
+	MemoryTotal uint64     `json:"memory_total,omitempty"`
+	LastBootAt  *time.Time `json:"last_boot_at,omitempty"`
+	IPv4        []string   `json:"ipv4,omitempty"`
+}
+
+func (f *Facts) Scan(value interface{}) error {
+	return scanJSON(value, f, "facts")
+}
+
+func (f Facts) Value() (driver.Value, error) {
+	return valueJSON(f, "facts")
+}
+
+type Tags []string
+
+func (t *Tags) Scan(value interface{}) error {
+	return scanJSON(value, t, "tags")
+}
+
+func (t Tags) Value() (driver.Value, error) {
+	if t == nil {
+		t = Tags{}
+	}
+	return valueJSON(t, "tags")
+}
+
+func scanJSON(value interface{}, dest interface{}, field string) error {
+	valueStr, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("expected to have string, got %T", value)
+	}
+	if err := json.Unmarshal([]byte(valueStr), dest); err != nil {
+		return fmt.Errorf("failed to decode '%s' field: %v", field, err)
+	}
+	return nil
+}
+
+func valueJSON(value interface{}, field string) (driver.Value, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode '%s' field: %v", field, err)
+	}
+	return string(b), nil
+}
@@ -0,0 +1,37 @@
+package agentless
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// FactsScript collects the facts of a windows target as json.
+const FactsScript = `$ErrorActionPreference = 'Stop'
+$os = Get-CimInstance Win32_OperatingSystem
+$cs = Get-CimInstance Win32_ComputerSystem
+$bios = Get-CimInstance Win32_BIOS
+$ips = @(Get-CimInstance Win32_NetworkAdapterConfiguration -Filter 'IPEnabled = True' |
+  ForEach-Object { $_.IPAddress } | Where-Object { $_ -match '^\d+\.\d+\.\d+\.\d+$' })
+[pscustomobject]@{
+  hostname = $cs.DNSHostName
+  domain = $cs.Domain
+  os = $os.Caption
+  os_version = $os.Version
+  manufacturer = $cs.Manufacturer
+  model = $cs.Model
+  serial_number = $bios.SerialNumber
+  cpus = [int]$cs.NumberOfLogicalProcessors
+  memory_total = [uint64]$cs.TotalPhysicalMemory
+  last_boot_at = $os.LastBootUpTime.ToUniversalTime().ToString('o')
+  ipv4 = $ips
+} | ConvertTo-Json -Compress`
+
+// ParseFacts reads the output of FactsScript.
+func ParseFacts(output string) (Facts, error) {
+	var facts Facts
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output)), &facts); err != nil {
+		return facts, fmt.Errorf("invalid output of the facts script: %v", err)
+	}
+	return facts, nil
+}
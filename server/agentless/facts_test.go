@@ -0,0 +1,34 @@
+package agentless
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFacts(t *testing.T) {
+	output := `{"hostname":"ws-01","domain":"corp.local","os":"Microsoft Windows Server 2022 Standard","os_version":"10.0.20348",` +
+		`"manufacturer":"Dell Inc.","model":"PowerEdge R650","serial_number":"ABC123","cpus":8,"memory_total":17179869184,` +
+		`"last_boot_at":"2026-10-01T06:30:00.0000000Z","ipv4":["192.168.1.20"]}` + "\r\n"
+	facts, err := ParseFacts(output)
+	require.NoError(t, err)
+	bootAt := time.Date(2026, 10, 1, 6, 30, 0, 0, time.UTC)
+	assert.Equal(t, Facts{
+		Hostname:     "ws-01",
+		Domain:       "corp.local",
+		OS:           "Microsoft Windows Server 2022 Standard",
+		OSVersion:    "10.0.20348",
+		Manufacturer: "Dell Inc.",
+		Model:        "PowerEdge R650",
+		SerialNumber: "ABC123",
+		CPUs:         8,
+		MemoryTotal:  17179869184,
+		LastBootAt:   &bootAt,
+		IPv4:         []string{"192.168.1.20"},
+	}, facts)
+
+	_, err = ParseFacts("")
+	assert.ErrorContains(t, err, "invalid output of the facts script")
+}
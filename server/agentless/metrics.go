@@ -0,0 +1,207 @@
+package agentless
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+const (
+	// sshMonitoringCommand takes the measures of a linux target in one ssh session. The cpu counters are read twice
+	// to get the usage of the second in between.
+	sshMonitoringCommand = "head -n1 /proc/stat; sleep 1; head -n1 /proc/stat; grep -E '^(MemTotal|MemAvailable):' /proc/meminfo; df -P -k"
+
+	// winrmMonitoringScript takes the measures of a windows target as json, the disks are the local fixed ones.
+	winrmMonitoringScript = `$ErrorActionPreference = 'Stop'
+$os = Get-CimInstance Win32_OperatingSystem
+$cpu = (Get-CimInstance Win32_Processor | Measure-Object -Property LoadPercentage -Average).Average
+$disks = @(Get-CimInstance Win32_LogicalDisk -Filter 'DriveType = 3' | ForEach-Object {
+  [pscustomobject]@{ name = $_.DeviceID; free = [uint64]$_.FreeSpace; total = [uint64]$_.Size }
+})
+[pscustomobject]@{
+  cpu = [double]$cpu
+  mem_total_kb = [uint64]$os.TotalVisibleMemorySize
+  mem_free_kb = [uint64]$os.FreePhysicalMemory
+  disks = $disks
+} | ConvertTo-Json -Compress`
+)
+
+// skippedFilesystems are not reported as mountpoints, they are not backed by a disk
+var skippedFilesystems = map[string]bool{
+	"tmpfs":    true,
+	"devtmpfs": true,
+	"overlay":  true,
+	"udev":     true,
+	"shm":      true,
+	"none":     true,
+}
+
+type cpuCounters struct {
+	total  uint64
+	idle   uint64
+	iowait uint64
+}
+
+// MonitoringCommand returns the command taking the measures of a target of the protocol.
+func MonitoringCommand(protocol string) string {
+	if protocol == comm.AgentlessProtocolWinRM {
+		return winrmMonitoringScript
+	}
+	return sshMonitoringCommand
+}
+
+// ParseMeasurement reads the output of MonitoringCommand into a measurement without client id and timestamp.
+func ParseMeasurement(protocol, output string) (models.Measurement, error) {
+	if protocol == comm.AgentlessProtocolWinRM {
+		return parseWinRMMeasurement(output)
+	}
+	return parseSSHMeasurement(output)
+}
+
+func parseWinRMMeasurement(output string) (models.Measurement, error) {
+	var (
+		m      models.Measurement
+		values struct {
+			CPU        float64 `json:"cpu"`
+			MemTotalKB uint64  `json:"mem_total_kb"`
+			MemFreeKB  uint64  `json:"mem_free_kb"`
+			Disks      []struct {
+				Name  string `json:"name"`
+				Free  uint64 `json:"free"`
+				Total uint64 `json:"total"`
+			} `json:"disks"`
+		}
+	)
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output)), &values); err != nil {
+		return m, fmt.Errorf("invalid output of the monitoring script: %v", err)
+	}
+	if values.MemTotalKB == 0 {
+		return m, errors.New("memory info not found in the output")
+	}
+	m.CPUUsagePercent = values.CPU
+	m.MemoryUsagePercent = percent(values.MemTotalKB-values.MemFreeKB, values.MemTotalKB)
+
+	if len(values.Disks) > 0 {
+		mountpoints := make(map[string]uint64, 2*len(values.Disks))
+		for _, d := range values.Disks {
+			mountpoints["free_b."+d.Name] = d.Free
+			mountpoints["total_b."+d.Name] = d.Total
+		}
+		b, err := json.Marshal(mountpoints)
+		if err != nil {
+			return m, err
+		}
+		m.Mountpoints = string(b)
+	}
+	return m, nil
+}
+
+func parseSSHMeasurement(output string) (models.Measurement, error) {
+	var (
+		m            models.Measurement
+		cpu          []cpuCounters
+		memTotal     uint64
+		memAvailable uint64
+		mountpoints  = make(map[string]uint64)
+		inDF         bool
+	)
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch {
+		case fields[0] == "cpu":
+			c, err := parseCPUCounters(fields[1:])
+			if err != nil {
+				return m, err
+			}
+			cpu = append(cpu, c)
+		case fields[0] == "MemTotal:" && len(fields) >= 2:
+			memTotal, _ = strconv.ParseUint(fields[1], 10, 64)
+		case fields[0] == "MemAvailable:" && len(fields) >= 2:
+			memAvailable, _ = strconv.ParseUint(fields[1], 10, 64)
+		case fields[0] == "Filesystem":
+			inDF = true
+		case inDF && len(fields) >= 6:
+			if skippedFilesystems[fields[0]] {
+				continue
+			}
+			total, err1 := strconv.ParseUint(fields[1], 10, 64)
+			free, err2 := strconv.ParseUint(fields[3], 10, 64)
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			mountpoint := strings.Join(fields[5:], " ")
+			mountpoints["free_b."+mountpoint] = free * 1024
+			mountpoints["total_b."+mountpoint] = total * 1024
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return m, err
+	}
+
+	if len(cpu) != 2 {
+		return m, errors.New("cpu counters not found in the output")
+	}
+	if total := cpu[1].total - cpu[0].total; cpu[1].total > cpu[0].total {
+		idle := cpu[1].idle - cpu[0].idle
+		iowait := cpu[1].iowait - cpu[0].iowait
+		m.CPUUsagePercent = percent(total-idle-iowait, total)
+		m.IoUsagePercent = percent(iowait, total)
+	}
+	if memTotal == 0 {
+		return m, errors.New("memory info not found in the output")
+	}
+	m.MemoryUsagePercent = percent(memTotal-memAvailable, memTotal)
+
+	if len(mountpoints) > 0 {
+		b, err := json.Marshal(mountpoints)
+		if err != nil {
+			return m, err
+		}
+		m.Mountpoints = string(b)
+	}
+	return m, nil
+}
+
+// parseCPUCounters reads the fields following "cpu" in /proc/stat: user nice system idle iowait irq softirq steal
+func parseCPUCounters(fields []string) (cpuCounters, error) {
+	var c cpuCounters
+	if len(fields) < 5 {
+		return c, fmt.Errorf("invalid cpu counters: %q", strings.Join(fields, " "))
+	}
+	// guest and guest_nice are included in user and nice already
+	if len(fields) > 8 {
+		fields = fields[:8]
+	}
+	for i, f := range fields {
+		v, err := strconv.ParseUint(f, 10, 64)
+		if err != nil {
+			return c, fmt.Errorf("invalid cpu counters: %v", err)
+		}
+		c.total += v
+		switch i {
+		case 3:
+			c.idle = v
+		case 4:
+			c.iowait = v
+		}
+	}
+	return c, nil
+}
+
+func percent(part, total uint64) float64 {
+	if total == 0 || part > total {
+		return 0
+	}
+	return float64(part) * 100 / float64(total)
+}
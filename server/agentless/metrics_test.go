@@ -0,0 +1,57 @@
+package agentless
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMeasurement(t *testing.T) {
+	output := `cpu  1000 0 500 8000 500 0 0 0 0 0
+cpu  1050 0 550 8850 550 0 0 0 0 0
+MemTotal:        4000000 kB
+MemAvailable:    1000000 kB
+Filesystem     1024-blocks    Used Available Capacity Mounted on
+/dev/sda1         10000000 6000000   4000000      60% /
+tmpfs               500000       0    500000       0% /dev/shm
+/dev/sdb1             2048    1024      1024      50% /mnt/usb stick
+`
+	m, err := ParseMeasurement("ssh", output)
+	require.NoError(t, err)
+	assert.InDelta(t, 10, m.CPUUsagePercent, 0.001)
+	assert.InDelta(t, 5, m.IoUsagePercent, 0.001)
+	assert.InDelta(t, 75, m.MemoryUsagePercent, 0.001)
+	assert.JSONEq(t, `{
+		"free_b./": 4096000000,
+		"total_b./": 10240000000,
+		"free_b./mnt/usb stick": 1048576,
+		"total_b./mnt/usb stick": 2097152
+	}`, m.Mountpoints)
+}
+
+func TestParseMeasurementErrors(t *testing.T) {
+	_, err := ParseMeasurement("ssh", "cpu  1 2 3 4 5\nMemTotal: 10 kB\n")
+	assert.EqualError(t, err, "cpu counters not found in the output")
+
+	_, err = ParseMeasurement("ssh", "cpu  1 2 3 4 5\ncpu  2 3 4 5 6\n")
+	assert.EqualError(t, err, "memory info not found in the output")
+
+	_, err = ParseMeasurement("ssh", "cpu  1 2\n")
+	assert.EqualError(t, err, `invalid cpu counters: "1 2"`)
+}
+
+func TestParseWinRMMeasurement(t *testing.T) {
+	output := `{"cpu":12.5,"mem_total_kb":8000000,"mem_free_kb":2000000,"disks":[{"name":"C:","free":1048576,"total":4194304}]}` + "\r\n"
+	m, err := ParseMeasurement("winrm", output)
+	require.NoError(t, err)
+	assert.InDelta(t, 12.5, m.CPUUsagePercent, 0.001)
+	assert.InDelta(t, 75, m.MemoryUsagePercent, 0.001)
+	assert.JSONEq(t, `{"free_b.C:": 1048576, "total_b.C:": 4194304}`, m.Mountpoints)
+
+	_, err = ParseMeasurement("winrm", `{"cpu":1}`)
+	assert.EqualError(t, err, "memory info not found in the output")
+
+	_, err = ParseMeasurement("winrm", "Get-CimInstance : Access denied")
+	assert.ErrorContains(t, err, "invalid output of the monitoring script")
+}
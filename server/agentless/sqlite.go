@@ -0,0 +1,109 @@
+package agentless
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type SqliteProvider struct {
+	db *sqlx.DB
+}
+
+func NewSqliteProvider(db *sqlx.DB) *SqliteProvider {
+	return &SqliteProvider{
+		db: db,
+	}
+}
+
+// Create returns false if a target with the same id exists.
+func (p *SqliteProvider) Create(ctx context.Context, t *Target) (bool, error) {
+	res, err := p.db.NamedExecContext(
+		ctx,
+		`INSERT INTO targets (id, name, description, tags, protocol, host, port, username, vault_value_id, jump_client_id, host_key, monitoring, created_by, created_at)
+			VALUES (:id, :name, :description, :tags, :protocol, :host, :port, :username, :vault_value_id, :jump_client_id, :host_key, :monitoring, :created_by, :created_at)
+			ON CONFLICT (id) DO NOTHING`,
+		t,
+	)
+	return affected(res, err)
+}
+
+// Update saves the settings of the target, it returns false if the target is not found.
+func (p *SqliteProvider) Update(ctx context.Context, t *Target) (bool, error) {
+	res, err := p.db.NamedExecContext(
+		ctx,
+		`UPDATE targets SET name = :name, description = :description, tags = :tags, protocol = :protocol, host = :host,
+			port = :port, username = :username, vault_value_id = :vault_value_id, jump_client_id = :jump_client_id,
+			host_key = :host_key, monitoring = :monitoring
+			WHERE id = :id`,
+		t,
+	)
+	return affected(res, err)
+}
+
+// Get returns nil if the target is not found.
+func (p *SqliteProvider) Get(ctx context.Context, id string) (*Target, error) {
+	t := &Target{}
+	err := p.db.GetContext(ctx, t, "SELECT * FROM targets WHERE id = ?", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// List returns all targets ordered by id.
+func (p *SqliteProvider) List(ctx context.Context) ([]*Target, error) {
+	result := []*Target{}
+	err := p.db.SelectContext(ctx, &result, "SELECT * FROM targets ORDER BY id")
+	return result, err
+}
+
+// ListMonitored returns the targets measures are taken from.
+func (p *SqliteProvider) ListMonitored(ctx context.Context) ([]*Target, error) {
+	result := []*Target{}
+	err := p.db.SelectContext(ctx, &result, "SELECT * FROM targets WHERE monitoring = 1 ORDER BY id")
+	return result, err
+}
+
+// Delete returns false if the target is not found.
+func (p *SqliteProvider) Delete(ctx context.Context, id string) (bool, error) {
+	res, err := p.db.ExecContext(ctx, "DELETE FROM targets WHERE id = ?", id)
+	return affected(res, err)
+}
+
+// SetSeen clears the last error of the target.
+func (p *SqliteProvider) SetSeen(ctx context.Context, id string, at time.Time) error {
+	_, err := p.db.ExecContext(ctx, "UPDATE targets SET last_seen_at = ?, last_error = '' WHERE id = ?", at, id)
+	return err
+}
+
+func (p *SqliteProvider) SetFacts(ctx context.Context, id string, facts Facts, at time.Time) error {
+	_, err := p.db.ExecContext(ctx, "UPDATE targets SET facts = ?, facts_updated_at = ? WHERE id = ?", facts, at, id)
+	return err
+}
+
+func (p *SqliteProvider) SetError(ctx context.Context, id, lastError string) error {
+	_, err := p.db.ExecContext(ctx, "UPDATE targets SET last_error = ? WHERE id = ?", lastError, id)
+	return err
+}
+
+func (p *SqliteProvider) Close() error {
+	return p.db.Close()
+}
+
+func affected(res sql.Result, err error) (bool, error) {
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,111 @@
+package agentless
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	agentlessmigration "github.com/IOTech17/neo-rport/db/migration/agentless"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+)
+
+var DataSourceOptions = sqlite.DataSourceOptions{WALEnabled: false}
+
+func TestSqliteProvider(t *testing.T) {
+	db, err := sqlite.New(":memory:", agentlessmigration.AssetNames(), agentlessmigration.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+	p := NewSqliteProvider(db)
+	t1 := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	t1Target := &Target{
+		ID:           "switch-1",
+		Name:         "Switch 1",
+		Tags:         Tags{"network"},
+		Protocol:     "ssh",
+		Host:         "192.168.1.2",
+		Port:         22,
+		Username:     "admin",
+		VaultValueID: 1,
+		JumpClientID: "client-1",
+		HostKey:      "SHA256:first",
+		Monitoring:   true,
+		CreatedBy:    "admin",
+		CreatedAt:    t1,
+	}
+	created, err := p.Create(ctx, t1Target)
+	require.NoError(t, err)
+	assert.True(t, created)
+	created, err = p.Create(ctx, &Target{ID: "switch-1", Protocol: "ssh", CreatedAt: t1})
+	require.NoError(t, err)
+	assert.False(t, created)
+	created, err = p.Create(ctx, &Target{ID: "router-1", Protocol: "ssh", Host: "192.168.1.1", Port: 22, CreatedAt: t1})
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	got, err := p.Get(ctx, "switch-1")
+	require.NoError(t, err)
+	assert.Equal(t, t1Target, got)
+
+	got, err = p.Get(ctx, "unknown")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	all, err := p.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, "router-1", all[0].ID)
+	assert.Equal(t, Tags{}, all[0].Tags)
+	assert.Equal(t, "switch-1", all[1].ID)
+
+	monitored, err := p.ListMonitored(ctx)
+	require.NoError(t, err)
+	require.Len(t, monitored, 1)
+	assert.Equal(t, "switch-1", monitored[0].ID)
+
+	require.NoError(t, p.SetError(ctx, "switch-1", "connection refused"))
+	got, err = p.Get(ctx, "switch-1")
+	require.NoError(t, err)
+	assert.Equal(t, "connection refused", got.LastError)
+
+	require.NoError(t, p.SetSeen(ctx, "switch-1", t1))
+	got, err = p.Get(ctx, "switch-1")
+	require.NoError(t, err)
+	assert.Equal(t, "SHA256:first", got.HostKey)
+	assert.Equal(t, &t1, got.LastSeenAt)
+	assert.Empty(t, got.LastError)
+
+	facts := Facts{Hostname: "switch-1", OS: "Windows", IPv4: []string{"192.168.1.2"}}
+	require.NoError(t, p.SetFacts(ctx, "switch-1", facts, t1))
+	got, err = p.Get(ctx, "switch-1")
+	require.NoError(t, err)
+	assert.Equal(t, facts, got.Facts)
+	assert.Equal(t, &t1, got.FactsUpdatedAt)
+
+	got.Port = 2222
+	got.Monitoring = false
+	got.HostKey = ""
+	updated, err := p.Update(ctx, got)
+	require.NoError(t, err)
+	assert.True(t, updated)
+	got, err = p.Get(ctx, "switch-1")
+	require.NoError(t, err)
+	assert.Equal(t, 2222, got.Port)
+	assert.False(t, got.Monitoring)
+	assert.Empty(t, got.HostKey)
+
+	updated, err = p.Update(ctx, &Target{ID: "unknown"})
+	require.NoError(t, err)
+	assert.False(t, updated)
+
+	deleted, err := p.Delete(ctx, "switch-1")
+	require.NoError(t, err)
+	assert.True(t, deleted)
+	deleted, err = p.Delete(ctx, "switch-1")
+	require.NoError(t, err)
+	assert.False(t, deleted)
+}
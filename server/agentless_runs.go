@@ -0,0 +1,269 @@
+package chserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IOTech17/neo-rport/server/agentless"
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/killswitch"
+	"github.com/IOTech17/neo-rport/server/vault"
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/random"
+)
+
+const (
+	// agentlessResultMargin is added to the timeout of the command for sending the result by the jump client.
+	agentlessResultMargin = 10 * time.Second
+
+	agentlessMonitoringInterval = time.Minute
+	agentlessMonitoringTimeout  = 30 * time.Second
+	// maxAgentlessMonitoringRuns limits the targets measured at the same time
+	maxAgentlessMonitoringRuns = 8
+)
+
+type pendingAgentlessRun struct {
+	clientID string
+	done     chan comm.AgentlessResult
+}
+
+// agentlessWaiters holds the commands run by jump clients on agentless targets until the result arrives.
+type agentlessWaiters struct {
+	m  map[string]*pendingAgentlessRun
+	mu sync.Mutex
+}
+
+func newAgentlessWaiters() *agentlessWaiters {
+	return &agentlessWaiters{
+		m: make(map[string]*pendingAgentlessRun),
+	}
+}
+
+func (w *agentlessWaiters) add(clientID string) (string, chan comm.AgentlessResult) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	id := random.Hex(16)
+	done := make(chan comm.AgentlessResult, 1)
+	w.m[id] = &pendingAgentlessRun{clientID: clientID, done: done}
+	return id, done
+}
+
+// take removes the pending run, it returns nil if the id is unknown or belongs to another client.
+func (w *agentlessWaiters) take(id, clientID string) *pendingAgentlessRun {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	p := w.m[id]
+	if p == nil || p.clientID != clientID {
+		return nil
+	}
+	delete(w.m, id)
+	return p
+}
+
+func (w *agentlessWaiters) del(id string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.m, id)
+}
+
+// runAgentlessCommand runs the command on the target through its jump client, with the credentials read from the
+// vault with the access of the given user. The target is marked as seen if the command could be run, whatever its
+// exit code. Without a pinned host key the target is only probed for its key, neither the credentials nor the command
+// are sent.
+func (al *APIListener) runAgentlessCommand(ctx context.Context, target *agentless.Target, user vault.UserDataProvider, command string, timeout time.Duration) (comm.AgentlessResult, error) {
+	if err := al.killSwitches.Check(killswitch.CapabilityCommands); err != nil {
+		return comm.AgentlessResult{}, err
+	}
+
+	value, found, err := al.vaultManager.GetOne(ctx, target.VaultValueID, user)
+	if err != nil {
+		return comm.AgentlessResult{}, err
+	}
+	if !found {
+		return comm.AgentlessResult{}, errors2.APIError{
+			HTTPStatus: http.StatusNotFound,
+			Message:    fmt.Sprintf("vault value with id %d not found", target.VaultValueID),
+		}
+	}
+
+	client, err := al.clientService.GetActiveByID(target.JumpClientID)
+	if err != nil {
+		return comm.AgentlessResult{}, err
+	}
+	if client == nil || !client.IsConnected() {
+		return comm.AgentlessResult{}, errors2.APIError{
+			HTTPStatus: http.StatusNotFound,
+			Message:    fmt.Sprintf("active jump client with id %s not found", target.JumpClientID),
+		}
+	}
+	if client.IsPaused() {
+		return comm.AgentlessResult{}, errors2.APIError{
+			HTTPStatus: http.StatusNotFound,
+			Message:    fmt.Sprintf("jump client with id %s is paused (reason = %s)", target.JumpClientID, client.GetPausedReason()),
+		}
+	}
+	if quarantine := client.GetQuarantine(); quarantine != nil {
+		return comm.AgentlessResult{}, errors2.APIError{
+			HTTPStatus: http.StatusForbidden,
+			Message:    fmt.Sprintf("jump client with id %s is quarantined (reason = %s)", target.JumpClientID, quarantine.Reason),
+		}
+	}
+
+	id, done := al.agentlessRuns.add(client.GetID())
+	defer al.agentlessRuns.del(id)
+
+	runReq := comm.AgentlessRequest{
+		ID:       id,
+		Protocol: target.Protocol,
+		Host:     target.Host,
+		Port:     target.Port,
+		Username: target.Username,
+		HostKey:  target.HostKey,
+		Timeout:  timeout,
+	}
+	switch {
+	case target.HostKey == "":
+	case isPrivateKey(value.Value):
+		runReq.PrivateKey = value.Value
+		runReq.Command = command
+	default:
+		runReq.Password = value.Value
+		runReq.Command = command
+	}
+	err = comm.SendRequestAndGetResponse(client.GetConnection(), comm.RequestTypeRunAgentless, runReq, nil, al.Log())
+	if err != nil {
+		if strings.Contains(err.Error(), "unknown request") {
+			err = errors.New("client does not support agentless management")
+		}
+		return comm.AgentlessResult{}, errors2.APIError{
+			HTTPStatus: http.StatusConflict,
+			Err:        fmt.Errorf("failed to run command through jump client %s: %v", client.GetID(), err),
+		}
+	}
+
+	var result comm.AgentlessResult
+	select {
+	case result = <-done:
+	case <-time.After(timeout + agentlessResultMargin):
+		return comm.AgentlessResult{}, errors2.APIError{
+			HTTPStatus: http.StatusGatewayTimeout,
+			Message:    "timeout waiting for the result of the jump client",
+		}
+	case <-ctx.Done():
+		return comm.AgentlessResult{}, ctx.Err()
+	}
+
+	if target.HostKey == "" && result.HostKey != "" {
+		notPinned := fmt.Sprintf("host key of agentless target %s is not pinned, it presented %s, set it as host_key of the target to trust it", target.ID, result.HostKey)
+		if err := al.agentlessTargets.SetError(ctx, target.ID, notPinned); err != nil {
+			al.Errorf("Failed to save the state of agentless target %s: %v", target.ID, err)
+		}
+		return comm.AgentlessResult{}, errors2.APIError{
+			HTTPStatus: http.StatusConflict,
+			Message:    notPinned,
+		}
+	}
+
+	if result.Error != "" {
+		err = al.agentlessTargets.SetError(ctx, target.ID, result.Error)
+	} else {
+		err = al.agentlessTargets.SetSeen(ctx, target.ID, time.Now().UTC())
+	}
+	if err != nil {
+		al.Errorf("Failed to save the state of agentless target %s: %v", target.ID, err)
+	}
+	return result, nil
+}
+
+// isPrivateKey tells if the credentials of a target are a private key in PEM format rather than a password.
+func isPrivateKey(credentials string) bool {
+	return strings.HasPrefix(strings.TrimSpace(credentials), "-----BEGIN")
+}
+
+// receiveAgentlessResult passes the result of a command on an agentless target to the waiting request.
+func (cl *ClientListener) receiveAgentlessResult(clientID string, payload []byte) error {
+	var result comm.AgentlessResult
+	if err := json.Unmarshal(payload, &result); err != nil {
+		return fmt.Errorf("failed to decode %T: %v", result, err)
+	}
+	p := cl.server.agentlessRuns.take(result.ID, clientID)
+	if p == nil {
+		return errors.New("agentless command not requested or expired")
+	}
+	p.done <- result
+	return nil
+}
+
+// agentlessMonitoringTask takes the measures of the agentless targets with monitoring enabled. The credentials are
+// read from the vault with the access of the user who created the target.
+type agentlessMonitoringTask struct {
+	log *logger.Logger
+	al  *APIListener
+}
+
+func (t *agentlessMonitoringTask) Run(ctx context.Context) error {
+	targets, err := t.al.agentlessTargets.ListMonitored(ctx)
+	if err != nil {
+		return err
+	}
+
+	sem := make(chan struct{}, maxAgentlessMonitoringRuns)
+	wg := sync.WaitGroup{}
+	for _, target := range targets {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(target *agentless.Target) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if err := t.measure(ctx, target); err != nil {
+				t.log.Debugf("Failed to measure agentless target %s: %v", target.ID, err)
+				if err := t.al.agentlessTargets.SetError(ctx, target.ID, err.Error()); err != nil {
+					t.log.Errorf("Failed to save the error of agentless target %s: %v", target.ID, err)
+				}
+			}
+		}(target)
+	}
+	wg.Wait()
+	return nil
+}
+
+func (t *agentlessMonitoringTask) measure(ctx context.Context, target *agentless.Target) error {
+	owner, err := t.al.userService.GetByUsername(target.CreatedBy)
+	if err != nil {
+		return err
+	}
+	if owner == nil {
+		return fmt.Errorf("user %q who created the target not found", target.CreatedBy)
+	}
+
+	result, err := t.al.runAgentlessCommand(ctx, target, owner, agentless.MonitoringCommand(target.Protocol), agentlessMonitoringTimeout)
+	if err != nil {
+		return err
+	}
+	if result.Error != "" {
+		// already saved as error of the target
+		return nil
+	}
+	if result.ExitCode != 0 {
+		return fmt.Errorf("monitoring command exited with %d: %s", result.ExitCode, result.Stderr)
+	}
+
+	m, err := agentless.ParseMeasurement(target.Protocol, result.Stdout)
+	if err != nil {
+		return err
+	}
+	m.ClientID = target.ID
+	m.Timestamp = time.Now().UTC()
+	t.al.monitoringQueue.Notify(m)
+	t.al.evaluateMeasurement(&m)
+	return nil
+}
@@ -0,0 +1,145 @@
+package alerts
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+// minBaselineStdDev keeps metrics that hardly ever change from turning every small change into an anomaly, all
+// metrics are percentages
+const minBaselineStdDev = 1.0
+
+// Baseline is the rolling mean and standard deviation of a metric of a client. They are exponentially weighted, so
+// measurements older than the baseline window fade out without being kept.
+type Baseline struct {
+	Mean    float64 `json:"mean"`
+	StdDev  float64 `json:"stddev"`
+	Samples int     `json:"samples"`
+	Last    float64 `json:"last"`
+	// Deviation is the distance of the last value from the baseline before it, in standard deviations
+	Deviation float64 `json:"deviation"`
+	// Anomalous is set if the deviation of the last value exceeds the sensitivity once the baseline is warmed up
+	Anomalous bool      `json:"anomalous"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	variance float64
+}
+
+// Baselines learns what is usual for each client from its measurements, so alerting can fire on values unusual for a
+// host instead of absolute thresholds. They are kept in memory and learned again after a restart.
+type Baselines struct {
+	window      time.Duration
+	sensitivity float64
+	minSamples  int
+
+	mu       sync.Mutex
+	byClient map[string]map[string]*Baseline
+}
+
+func NewBaselines(window time.Duration, sensitivity float64, minSamples int) *Baselines {
+	return &Baselines{
+		window:      window,
+		sensitivity: sensitivity,
+		minSamples:  minSamples,
+		byClient:    make(map[string]map[string]*Baseline),
+	}
+}
+
+// Put updates the baselines of a client with a measurement. Measurements older than the latest one are ignored.
+func (b *Baselines) Put(m models.Measurement) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	client, ok := b.byClient[m.ClientID]
+	if !ok {
+		client = make(map[string]*Baseline, len(metrics))
+		b.byClient[m.ClientID] = client
+	}
+	for _, metric := range metrics {
+		bl, ok := client[metric]
+		if !ok {
+			bl = &Baseline{}
+			client[metric] = bl
+		}
+		b.update(bl, metricValue(&m, metric), m.Timestamp)
+	}
+}
+
+func (b *Baselines) update(bl *Baseline, value float64, ts time.Time) {
+	if bl.Samples == 0 {
+		*bl = Baseline{Mean: value, Samples: 1, Last: value, UpdatedAt: ts}
+		return
+	}
+	dt := ts.Sub(bl.UpdatedAt)
+	if dt <= 0 {
+		return
+	}
+
+	bl.Deviation = (value - bl.Mean) / math.Max(bl.StdDev, minBaselineStdDev)
+	bl.Anomalous = bl.Samples >= b.minSamples && math.Abs(bl.Deviation) > b.sensitivity
+
+	// a cumulative average until the window is filled, then exponentially weighted
+	bl.Samples++
+	alpha := math.Max(1-math.Exp(-float64(dt)/float64(b.window)), 1/float64(bl.Samples))
+	diff := value - bl.Mean
+	incr := alpha * diff
+	bl.Mean += incr
+	bl.variance = (1 - alpha) * (bl.variance + diff*incr)
+	bl.StdDev = math.Sqrt(bl.variance)
+	bl.Last = value
+	bl.UpdatedAt = ts
+}
+
+// Client returns the baselines of a client by metric.
+func (b *Baselines) Client(clientID string) map[string]Baseline {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	res := make(map[string]Baseline, len(metrics))
+	for metric, bl := range b.byClient[clientID] {
+		res[metric] = *bl
+	}
+	return res
+}
+
+// Anomalies returns the metrics of a client whose latest value is unusual, nil if there are none.
+func (b *Baselines) Anomalies(clientID string) map[string]bool {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var res map[string]bool
+	for metric, bl := range b.byClient[clientID] {
+		if bl.Anomalous {
+			if res == nil {
+				res = make(map[string]bool)
+			}
+			res[metric] = true
+		}
+	}
+	return res
+}
+
+// anomalousClients returns the sorted ids of the members with an anomaly of the metric.
+func anomalousClients(members []GroupMember, metric string) []string {
+	var res []string
+	for _, m := range members {
+		if m.Connected && m.Measurement != nil && m.Anomalies[metric] {
+			res = append(res, m.ClientID)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,93 @@
+package alerts
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	alertsmigration "github.com/IOTech17/neo-rport/db/migration/alerts"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	"github.com/IOTech17/neo-rport/share/models"
+	"github.com/IOTech17/neo-rport/share/types"
+)
+
+func TestBaselines(t *testing.T) {
+	b := NewBaselines(24*time.Hour, 3, 30)
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	put := func(i int, cpu float64) {
+		b.Put(models.Measurement{ClientID: "client-1", Timestamp: start.Add(time.Duration(i) * time.Minute), CPUUsagePercent: cpu, MemoryUsagePercent: 40})
+	}
+
+	// a busy host alternating between 60 and 70 percent
+	for i := 0; i < 20; i++ {
+		put(i, 60+float64(i%2)*10)
+	}
+	put(20, 95)
+	assert.Nil(t, b.Anomalies("client-1"), "baseline is not warmed up yet")
+
+	for i := 21; i < 60; i++ {
+		put(i, 60+float64(i%2)*10)
+	}
+	bl := b.Client("client-1")[MetricCPUUsagePercent]
+	assert.Equal(t, 60, bl.Samples)
+	assert.InDelta(t, 65.5, bl.Mean, 1)
+	assert.InDelta(t, 6, bl.StdDev, 1)
+
+	put(60, 72)
+	assert.Nil(t, b.Anomalies("client-1"), "usual for this host")
+	put(61, 95)
+	assert.Equal(t, map[string]bool{MetricCPUUsagePercent: true}, b.Anomalies("client-1"))
+	bl = b.Client("client-1")[MetricCPUUsagePercent]
+	assert.Greater(t, bl.Deviation, 3.0)
+	assert.Equal(t, 95.0, bl.Last)
+
+	// memory never changes, the deviation is relative to the minimum standard deviation
+	mem := b.Client("client-1")[MetricMemoryUsagePercent]
+	assert.Equal(t, 0.0, mem.StdDev)
+	assert.False(t, mem.Anomalous)
+
+	// out of order measurements are ignored
+	put(10, 0)
+	assert.Equal(t, 95.0, b.Client("client-1")[MetricCPUUsagePercent].Last)
+	assert.Empty(t, b.Client("unknown"))
+}
+
+func TestGroupEvaluatorAnomalies(t *testing.T) {
+	db, err := sqlite.New(":memory:", alertsmigration.AssetNames(), alertsmigration.Asset, sqlite.DataSourceOptions{})
+	require.NoError(t, err)
+	p := NewGroupRuleProvider(db)
+	defer p.Close()
+	ctx := context.Background()
+	rule := GroupRule{ID: "edge-unusual", GroupID: "edge", Expr: "anomalous(cpu_usage_percent) > 0", Recipients: types.StringSlice{"ops@example.com"}}
+	require.NoError(t, p.Save(ctx, rule))
+
+	membersFunc := func(context.Context, string) ([]GroupMember, bool, error) {
+		return []GroupMember{{ClientID: "c1", Connected: true}, {ClientID: "c2", Connected: true}}, true, nil
+	}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	e := NewGroupEvaluator(p, membersFunc, &recordingDispatcher{}, testLog)
+	e.now = func() time.Time { return now }
+
+	// without baselines rules on anomalies can't be evaluated
+	require.NoError(t, e.Evaluate(ctx))
+	assert.Equal(t, "anomaly detection is disabled, baseline_window is 0", e.WithStates([]GroupRule{rule})[0].State.Error)
+
+	e.SetBaselines(NewBaselines(time.Hour, 3, 10))
+	for i := 10; i > 0; i-- {
+		ts := now.Add(-time.Duration(i) * time.Minute)
+		e.PutMeasurement(models.Measurement{ClientID: "c1", Timestamp: ts, CPUUsagePercent: 20})
+		e.PutMeasurement(models.Measurement{ClientID: "c2", Timestamp: ts, CPUUsagePercent: 80})
+	}
+	e.PutMeasurement(models.Measurement{ClientID: "c1", Timestamp: now, CPUUsagePercent: 80})
+	e.PutMeasurement(models.Measurement{ClientID: "c2", Timestamp: now, CPUUsagePercent: 80})
+	require.NoError(t, e.Evaluate(ctx))
+
+	state := e.WithStates([]GroupRule{rule})[0].State
+	require.NotNil(t, state)
+	assert.True(t, state.Firing)
+	assert.Equal(t, 1.0, *state.Value)
+	assert.Equal(t, []string{"c1"}, state.AnomalousClients)
+}
@@ -0,0 +1,114 @@
+package alerts
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"github.com/jmoiron/sqlx"
+
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+)
+
+// Handling of alerts for clients depending on a disconnected client
+const (
+	DependencyModeSuppress = "suppress"
+	DependencyModeTag      = "tag"
+	DependencyModeOff      = "off"
+)
+
+// ClientDependency declares that a client is only reachable through its parent, e.g. a device behind a site router.
+type ClientDependency struct {
+	ClientID string `json:"client_id" db:"client_id"`
+	ParentID string `json:"parent_id" db:"parent_id"`
+}
+
+type DependencyProvider struct {
+	db *sqlx.DB
+}
+
+func NewDependencyProvider(db *sqlx.DB) *DependencyProvider {
+	return &DependencyProvider{db: db}
+}
+
+func (p *DependencyProvider) List(ctx context.Context) ([]ClientDependency, error) {
+	res := []ClientDependency{}
+	err := p.db.SelectContext(ctx, &res, "SELECT * FROM client_dependencies ORDER BY parent_id, client_id")
+	return res, err
+}
+
+// GetParent returns the parent of the client, an empty string if it has none.
+func (p *DependencyProvider) GetParent(ctx context.Context, clientID string) (string, error) {
+	var parentID string
+	err := p.db.GetContext(ctx, &parentID, "SELECT parent_id FROM client_dependencies WHERE client_id = ?", clientID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return parentID, err
+}
+
+// Save sets the parent of the client, it fails if the dependency would create a cycle.
+func (p *DependencyProvider) Save(ctx context.Context, dep ClientDependency) error {
+	if dep.ClientID == dep.ParentID {
+		return errors2.APIError{Message: "a client can't depend on itself", HTTPStatus: http.StatusBadRequest}
+	}
+	ancestor := dep.ParentID
+	for ancestor != "" {
+		if ancestor == dep.ClientID {
+			return errors2.APIError{
+				Message:    fmt.Sprintf("client %q already depends on client %q", dep.ParentID, dep.ClientID),
+				HTTPStatus: http.StatusBadRequest,
+			}
+		}
+		var err error
+		ancestor, err = p.GetParent(ctx, ancestor)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := p.db.NamedExecContext(
+		ctx,
+		"INSERT OR REPLACE INTO client_dependencies (client_id, parent_id) VALUES (:client_id, :parent_id)",
+		dep,
+	)
+	return err
+}
+
+func (p *DependencyProvider) Delete(ctx context.Context, clientID string) error {
+	_, err := p.db.ExecContext(ctx, "DELETE FROM client_dependencies WHERE client_id = ?", clientID)
+	return err
+}
+
+// ConnectedFunc returns whether the client is connected, false for unknown clients.
+type ConnectedFunc func(clientID string) bool
+
+// Dependencies resolves the clients an alert is a symptom of.
+type Dependencies struct {
+	provider  *DependencyProvider
+	connected ConnectedFunc
+}
+
+func NewDependencies(provider *DependencyProvider, connected ConnectedFunc) *Dependencies {
+	return &Dependencies{provider: provider, connected: connected}
+}
+
+// DisconnectedAncestor returns the nearest disconnected ancestor of the client, an empty string if all of its
+// ancestors are connected.
+func (d *Dependencies) DisconnectedAncestor(ctx context.Context, clientID string) (string, error) {
+	// cycles are rejected on save, seen only guards against inconsistent data
+	seen := map[string]bool{clientID: true}
+	current := clientID
+	for {
+		parentID, err := d.provider.GetParent(ctx, current)
+		if err != nil || parentID == "" || seen[parentID] {
+			return "", err
+		}
+		if !d.connected(parentID) {
+			return parentID, nil
+		}
+		seen[parentID] = true
+		current = parentID
+	}
+}
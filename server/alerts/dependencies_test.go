@@ -0,0 +1,63 @@
+package alerts
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	alertsmigration "github.com/IOTech17/neo-rport/db/migration/alerts"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+)
+
+func newTestDependencyProvider(t *testing.T) *DependencyProvider {
+	db, err := sqlite.New(":memory:", alertsmigration.AssetNames(), alertsmigration.Asset, sqlite.DataSourceOptions{})
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return NewDependencyProvider(db)
+}
+
+func TestDependencyProvider(t *testing.T) {
+	p := newTestDependencyProvider(t)
+	ctx := context.Background()
+
+	require.NoError(t, p.Save(ctx, ClientDependency{ClientID: "device", ParentID: "router"}))
+	require.NoError(t, p.Save(ctx, ClientDependency{ClientID: "router", ParentID: "uplink"}))
+
+	assert.EqualError(t, p.Save(ctx, ClientDependency{ClientID: "uplink", ParentID: "device"}), `client "device" already depends on client "uplink"`)
+	assert.EqualError(t, p.Save(ctx, ClientDependency{ClientID: "device", ParentID: "device"}), "a client can't depend on itself")
+
+	all, err := p.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []ClientDependency{{ClientID: "device", ParentID: "router"}, {ClientID: "router", ParentID: "uplink"}}, all)
+
+	require.NoError(t, p.Delete(ctx, "device"))
+	parent, err := p.GetParent(ctx, "device")
+	require.NoError(t, err)
+	assert.Equal(t, "", parent)
+}
+
+func TestDisconnectedAncestor(t *testing.T) {
+	p := newTestDependencyProvider(t)
+	ctx := context.Background()
+	require.NoError(t, p.Save(ctx, ClientDependency{ClientID: "device", ParentID: "router"}))
+	require.NoError(t, p.Save(ctx, ClientDependency{ClientID: "router", ParentID: "uplink"}))
+
+	connected := map[string]bool{"device": true, "router": true, "uplink": true}
+	deps := NewDependencies(p, func(id string) bool { return connected[id] })
+
+	ancestor, err := deps.DisconnectedAncestor(ctx, "device")
+	require.NoError(t, err)
+	assert.Equal(t, "", ancestor)
+
+	connected["uplink"] = false
+	ancestor, err = deps.DisconnectedAncestor(ctx, "device")
+	require.NoError(t, err)
+	assert.Equal(t, "uplink", ancestor)
+
+	connected["router"] = false
+	ancestor, err = deps.DisconnectedAncestor(ctx, "device")
+	require.NoError(t, err)
+	assert.Equal(t, "router", ancestor)
+}
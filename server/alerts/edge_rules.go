@@ -0,0 +1,257 @@
+package alerts
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/IOTech17/neo-rport/plus/capabilities/alerting/entities/rules"
+	"github.com/IOTech17/neo-rport/plus/capabilities/alerting/entities/severity"
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/notifications"
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/edgerules"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/refs"
+	"github.com/IOTech17/neo-rport/share/types"
+)
+
+const (
+	EdgeRuleType refs.IdentifiableType = "edge-rule"
+
+	MaxEdgeRuleFor            = 24 * time.Hour
+	MaxEdgeRemediationTimeout = time.Hour
+	maxEdgeScriptSize         = 64 * 1024
+)
+
+// EdgeRule is an alerting rule pushed to the clients of a client group. The clients evaluate it on their own metrics
+// and run the remediation script, even while the server is unreachable.
+type EdgeRule struct {
+	ID         string            `json:"id" db:"id"`
+	GroupID    string            `json:"group_id" db:"group_id"`
+	Expr       string            `json:"expr" db:"expr"`
+	ForSec     int               `json:"for_sec" db:"for_sec"`
+	Severity   severity.Severity `json:"severity" db:"severity"`
+	Recipients types.StringSlice `json:"recipients" db:"recipients"`
+	// Script is run by the client once the rule starts firing
+	Script      string `json:"script" db:"script"`
+	Interpreter string `json:"interpreter" db:"interpreter"`
+	TimeoutSec  int    `json:"timeout_sec" db:"timeout_sec"`
+}
+
+func (r *EdgeRule) Validate() error {
+	if r.GroupID == "" {
+		return errors2.APIError{Message: "group_id is required", HTTPStatus: http.StatusBadRequest}
+	}
+	if _, err := edgerules.ParseExpr(r.Expr); err != nil {
+		return errors2.APIError{Message: err.Error(), HTTPStatus: http.StatusBadRequest}
+	}
+	if r.ForSec < 0 || time.Duration(r.ForSec)*time.Second > MaxEdgeRuleFor {
+		return errors2.APIError{
+			Message:    fmt.Sprintf("invalid for_sec %d, expected 0 to %d", r.ForSec, int(MaxEdgeRuleFor.Seconds())),
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+	if len(r.Script) > maxEdgeScriptSize {
+		return errors2.APIError{Message: fmt.Sprintf("script exceeds %d bytes", maxEdgeScriptSize), HTTPStatus: http.StatusBadRequest}
+	}
+	if r.Script == "" && (r.Interpreter != "" || r.TimeoutSec != 0) {
+		return errors2.APIError{Message: "interpreter and timeout_sec require a script", HTTPStatus: http.StatusBadRequest}
+	}
+	if r.TimeoutSec < 0 || time.Duration(r.TimeoutSec)*time.Second > MaxEdgeRemediationTimeout {
+		return errors2.APIError{
+			Message:    fmt.Sprintf("invalid timeout_sec %d, expected 0 to %d", r.TimeoutSec, int(MaxEdgeRemediationTimeout.Seconds())),
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+	if err := validateSeverity(&r.Severity); err != nil {
+		return err
+	}
+	if r.Recipients == nil {
+		r.Recipients = types.StringSlice{}
+	}
+	return nil
+}
+
+// ClientRule returns the rule as sent to the clients.
+func (r EdgeRule) ClientRule() comm.EdgeRule {
+	return comm.EdgeRule{
+		ID:          r.ID,
+		Expr:        r.Expr,
+		For:         time.Duration(r.ForSec) * time.Second,
+		Script:      r.Script,
+		Interpreter: r.Interpreter,
+		Timeout:     time.Duration(r.TimeoutSec) * time.Second,
+	}
+}
+
+type EdgeRuleProvider struct {
+	db *sqlx.DB
+}
+
+func NewEdgeRuleProvider(db *sqlx.DB) *EdgeRuleProvider {
+	return &EdgeRuleProvider{db: db}
+}
+
+func (p *EdgeRuleProvider) List(ctx context.Context) ([]EdgeRule, error) {
+	res := []EdgeRule{}
+	err := p.db.SelectContext(ctx, &res, "SELECT * FROM edge_rules ORDER BY id")
+	return res, err
+}
+
+func (p *EdgeRuleProvider) Get(ctx context.Context, id string) (*EdgeRule, error) {
+	res := &EdgeRule{}
+	err := p.db.GetContext(ctx, res, "SELECT * FROM edge_rules WHERE id = ?", id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return res, nil
+}
+
+func (p *EdgeRuleProvider) Save(ctx context.Context, rule EdgeRule) error {
+	_, err := p.db.NamedExecContext(
+		ctx,
+		"INSERT OR REPLACE INTO edge_rules (id, group_id, expr, for_sec, severity, recipients, script, interpreter, timeout_sec)"+
+			" VALUES (:id, :group_id, :expr, :for_sec, :severity, :recipients, :script, :interpreter, :timeout_sec)",
+		rule,
+	)
+	return err
+}
+
+func (p *EdgeRuleProvider) Delete(ctx context.Context, id string) error {
+	_, err := p.db.ExecContext(ctx, "DELETE FROM edge_rules WHERE id = ?", id)
+	return err
+}
+
+// EdgeAlerts records the events of the edge rules sent by the clients as problems and notifies the recipients of the
+// rules. Clients send events again until they are confirmed, events already recorded are skipped.
+type EdgeAlerts struct {
+	rules      *EdgeRuleProvider
+	dispatcher notifications.Dispatcher
+	history    *ProblemHistory
+	logger     *logger.Logger
+	now        func() time.Time
+}
+
+func NewEdgeAlerts(rules *EdgeRuleProvider, dispatcher notifications.Dispatcher, history *ProblemHistory, l *logger.Logger) *EdgeAlerts {
+	return &EdgeAlerts{
+		rules:      rules,
+		dispatcher: dispatcher,
+		history:    history,
+		logger:     l,
+		now:        time.Now,
+	}
+}
+
+// Receive records the events of a client.
+func (a *EdgeAlerts) Receive(ctx context.Context, clientID string, events []comm.EdgeAlertEvent) error {
+	for _, ev := range events {
+		if err := a.receive(ctx, clientID, ev); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *EdgeAlerts) receive(ctx context.Context, clientID string, ev comm.EdgeAlertEvent) error {
+	timestamp := a.now().Add(-ev.Age).UTC()
+	// the rule is nil if it was deleted meanwhile, the events are recorded without notifying
+	rule, err := a.rules.Get(ctx, ev.RuleID)
+	if err != nil {
+		return err
+	}
+
+	if ev.Event == comm.EdgeEventFiring {
+		record := ProblemRecord{
+			ProblemID: ev.ProblemID,
+			Source:    ProblemSourceEdgeRule,
+			RuleID:    ev.RuleID,
+			ClientID:  clientID,
+			Summary:   ev.RuleID,
+			OpenedAt:  timestamp,
+		}
+		if rule != nil {
+			record.GroupID = rule.GroupID
+			record.Summary = rule.Expr
+		}
+		opened, err := a.history.Open(ctx, record)
+		if err != nil || !opened {
+			return err
+		}
+		return a.notify(ctx, rule, clientID, ev, timestamp)
+	}
+
+	// events of problems of other clients or sources are dropped
+	problem, err := a.history.Get(ctx, ev.ProblemID)
+	if err != nil {
+		return err
+	}
+	if problem == nil || problem.ClientID != clientID || problem.Source != ProblemSourceEdgeRule {
+		a.logger.Infof("Dropping event %q of unknown problem %q of client %s", ev.Event, ev.ProblemID, clientID)
+		return nil
+	}
+
+	switch ev.Event {
+	case comm.EdgeEventResolved:
+		resolved, err := a.history.Record(ctx, ProblemEvent{ProblemID: ev.ProblemID, Event: ProblemEventResolved, Timestamp: timestamp, Details: ev.Details})
+		if err != nil || !resolved {
+			return err
+		}
+		return a.notify(ctx, rule, clientID, ev, timestamp)
+	case comm.EdgeEventRemediated:
+		_, err := a.history.Record(ctx, ProblemEvent{ProblemID: ev.ProblemID, Event: ProblemEventRemediated, Timestamp: timestamp, Details: ev.Details})
+		return err
+	}
+	a.logger.Infof("Dropping unknown event %q of problem %q of client %s", ev.Event, ev.ProblemID, clientID)
+	return nil
+}
+
+func (a *EdgeAlerts) notify(ctx context.Context, rule *EdgeRule, clientID string, ev comm.EdgeAlertEvent, timestamp time.Time) error {
+	if rule == nil || len(rule.Recipients) == 0 {
+		return nil
+	}
+	notification := edgeRuleNotification(*rule, clientID, ev, timestamp)
+	if _, err := a.dispatcher.Dispatch(ctx, refs.NewIdentifiable(EdgeRuleType, rule.ID), notification); err != nil {
+		// the event is recorded, sending it again wouldn't notify
+		a.logger.Errorf("Failed to notify about edge rule %q of client %s: %v", rule.ID, clientID, err)
+		return nil
+	}
+	_, err := a.history.Record(ctx, ProblemEvent{ProblemID: ev.ProblemID, Event: ProblemEventNotified, Timestamp: a.now(), Details: NotificationDetails(notification)})
+	return err
+}
+
+func edgeRuleNotification(rule EdgeRule, clientID string, ev comm.EdgeAlertEvent, timestamp time.Time) notifications.NotificationData {
+	status := rules.Resolved
+	if ev.Event == comm.EdgeEventFiring {
+		status = rules.Alerting
+	}
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "Edge rule: %s\n", rule.ID)
+	fmt.Fprintf(b, "Client: %s\n", clientID)
+	fmt.Fprintf(b, "Condition: %s\n", rule.Expr)
+	fmt.Fprintf(b, "Value: %s\n", strconv.FormatFloat(ev.Value, 'f', 2, 64))
+	if ev.Details != "" {
+		fmt.Fprintf(b, "Details: %s\n", ev.Details)
+	}
+	fmt.Fprintf(b, "Severity: %s\n", rule.Severity)
+	fmt.Fprintf(b, "Time: %s\n", timestamp.Format(time.RFC1123))
+
+	return notifications.NotificationData{
+		Target:      string(notifications.TargetMail),
+		Recipients:  rule.Recipients,
+		Subject:     fmt.Sprintf("[rport] %s: edge rule %q on client %q", status, rule.ID, clientID),
+		Content:     b.String(),
+		ContentType: notifications.ContentTypeTextPlain,
+		Severity:    NotificationSeverity(rule.Severity),
+	}
+}
@@ -0,0 +1,102 @@
+package alerts
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	alertsmigration "github.com/IOTech17/neo-rport/db/migration/alerts"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	"github.com/IOTech17/neo-rport/plus/capabilities/alerting/entities/severity"
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/types"
+)
+
+func TestEdgeRuleValidate(t *testing.T) {
+	rule := EdgeRule{GroupID: "edge", Expr: "process_count(nginx) < 1", Script: "systemctl restart nginx", TimeoutSec: 60}
+	require.NoError(t, rule.Validate())
+	assert.Equal(t, severity.Warning, rule.Severity)
+	assert.Equal(t, comm.EdgeRule{Expr: "process_count(nginx) < 1", Script: "systemctl restart nginx", Timeout: time.Minute}, rule.ClientRule())
+
+	testCases := []struct {
+		rule    EdgeRule
+		wantErr string
+	}{
+		{EdgeRule{Expr: "cpu_usage_percent > 90"}, "group_id is required"},
+		{EdgeRule{GroupID: "edge", Expr: "avg(cpu_usage_percent) > 90"}, `invalid metric "avg", expected one of cpu_usage_percent, memory_usage_percent, disk_used_percent, process_count`},
+		{EdgeRule{GroupID: "edge", Expr: "cpu_usage_percent > 90", ForSec: -1}, "invalid for_sec -1, expected 0 to 86400"},
+		{EdgeRule{GroupID: "edge", Expr: "cpu_usage_percent > 90", Interpreter: "bash"}, "interpreter and timeout_sec require a script"},
+		{EdgeRule{GroupID: "edge", Expr: "cpu_usage_percent > 90", Script: "reboot", TimeoutSec: 7200}, "invalid timeout_sec 7200, expected 0 to 3600"},
+		{EdgeRule{GroupID: "edge", Expr: "cpu_usage_percent > 90", Severity: "Critical"}, `invalid severity "Critical", expected one of Information, Warning, Average, High, Disaster`},
+	}
+	for _, tc := range testCases {
+		assert.EqualError(t, tc.rule.Validate(), tc.wantErr)
+	}
+}
+
+func TestEdgeAlerts(t *testing.T) {
+	db, err := sqlite.New(":memory:", alertsmigration.AssetNames(), alertsmigration.Asset, sqlite.DataSourceOptions{})
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+	p := NewEdgeRuleProvider(db)
+	history := NewProblemHistory(db)
+
+	rule := EdgeRule{ID: "nginx-down", GroupID: "edge", Expr: "process_count(nginx) < 1", Severity: severity.High, Recipients: types.StringSlice{"ops@example.com"}, Script: "systemctl restart nginx"}
+	require.NoError(t, p.Save(ctx, rule))
+	all, err := p.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []EdgeRule{rule}, all)
+
+	dispatcher := &recordingDispatcher{}
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	a := NewEdgeAlerts(p, dispatcher, history, testLog)
+	a.now = func() time.Time { return now }
+
+	// the client was disconnected while the rule fired
+	events := []comm.EdgeAlertEvent{
+		{ProblemID: "p1", RuleID: "nginx-down", Event: comm.EdgeEventFiring, Value: 0, Age: 10 * time.Minute},
+		{ProblemID: "p1", RuleID: "nginx-down", Event: comm.EdgeEventRemediated, Details: "exit code 0", Age: 9 * time.Minute},
+		{ProblemID: "p1", RuleID: "nginx-down", Event: comm.EdgeEventResolved, Value: 1, Age: 8 * time.Minute},
+	}
+	require.NoError(t, a.Receive(ctx, "client-1", events))
+	assert.Equal(t, []string{
+		`[rport] ALERTING: edge rule "nginx-down" on client "client-1"`,
+		`[rport] RESOLVED: edge rule "nginx-down" on client "client-1"`,
+	}, dispatcher.subjects)
+
+	problem, err := history.Get(ctx, "p1")
+	require.NoError(t, err)
+	require.NotNil(t, problem)
+	assert.Equal(t, ProblemSourceEdgeRule, problem.Source)
+	assert.Equal(t, "client-1", problem.ClientID)
+	assert.Equal(t, "edge", problem.GroupID)
+	assert.Equal(t, "process_count(nginx) < 1", problem.Summary)
+	assert.Equal(t, now.Add(-10*time.Minute), problem.OpenedAt.UTC())
+	assert.Equal(t, now.Add(-8*time.Minute), problem.ResolvedAt.UTC())
+	var got []string
+	for _, ev := range problem.Events {
+		got = append(got, ev.Event+" "+ev.Details)
+	}
+	assert.Equal(t, []string{
+		// the notifications are sent when the events arrive
+		"opened ",
+		"remediated exit code 0",
+		"resolved ",
+		"notified smtp to ops@example.com: [rport] ALERTING: edge rule \"nginx-down\" on client \"client-1\"",
+		"notified smtp to ops@example.com: [rport] RESOLVED: edge rule \"nginx-down\" on client \"client-1\"",
+	}, got)
+
+	// events sent again aren't recorded twice
+	require.NoError(t, a.Receive(ctx, "client-1", events[:1]))
+	assert.Len(t, dispatcher.subjects, 2)
+
+	// another client can't change the problem
+	require.NoError(t, a.Receive(ctx, "client-2", []comm.EdgeAlertEvent{{ProblemID: "p1", RuleID: "nginx-down", Event: comm.EdgeEventRemediated}}))
+	problem, err = history.Get(ctx, "p1")
+	require.NoError(t, err)
+	assert.Len(t, problem.Events, 5)
+}
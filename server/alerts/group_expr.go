@@ -0,0 +1,256 @@
+package alerts
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+// Aggregates over the clients of a group
+const (
+	AggregateClients             = "clients"
+	AggregateConnected           = "connected"
+	AggregateDisconnected        = "disconnected"
+	AggregateDisconnectedPercent = "disconnected_percent"
+	AggregateAvg                 = "avg"
+	AggregateMin                 = "min"
+	AggregateMax                 = "max"
+	// AggregateAnomalous counts the clients whose metric is unusual compared to their own baseline
+	AggregateAnomalous        = "anomalous"
+	AggregateAnomalousPercent = "anomalous_percent"
+	// AggregateDaysUntilFull is the minimum of the predicted days until a mountpoint of a client is full
+	AggregateDaysUntilFull = "days_until_full"
+)
+
+// Metrics of the latest measurements of the clients
+const (
+	MetricCPUUsagePercent    = "cpu_usage_percent"
+	MetricMemoryUsagePercent = "memory_usage_percent"
+	MetricIOUsagePercent     = "io_usage_percent"
+	// MetricPointPrefix is followed by the name of a point of an industrial device polled through the clients
+	MetricPointPrefix = "point."
+)
+
+var (
+	groupExprRegexp = regexp.MustCompile(`^\s*([a-z_]+)\s*(?:\(\s*([a-z_]+(?:\.[a-z0-9_]+)?)\s*\))?\s*(>=|<=|==|!=|>|<)\s*(-?[0-9]+(?:\.[0-9]+)?)\s*$`)
+
+	countAggregates  = []string{AggregateClients, AggregateConnected, AggregateDisconnected, AggregateDisconnectedPercent, AggregateDaysUntilFull}
+	metricAggregates = []string{AggregateAvg, AggregateMin, AggregateMax, AggregateAnomalous, AggregateAnomalousPercent}
+	metrics          = []string{MetricCPUUsagePercent, MetricMemoryUsagePercent, MetricIOUsagePercent}
+)
+
+// GroupExpr is a condition on an aggregate over the clients of a group, e.g. `disconnected_percent > 30` or
+// `avg(cpu_usage_percent) > 80`.
+type GroupExpr struct {
+	Aggregate string
+	Metric    string
+	Operator  string
+	Threshold float64
+}
+
+func ParseGroupExpr(expr string) (GroupExpr, error) {
+	m := groupExprRegexp.FindStringSubmatch(expr)
+	if m == nil {
+		return GroupExpr{}, fmt.Errorf("invalid expression %q, expected `<aggregate> <operator> <number>`", expr)
+	}
+	ge := GroupExpr{Aggregate: m[1], Metric: m[2], Operator: m[3]}
+
+	switch {
+	case contains(countAggregates, ge.Aggregate):
+		if ge.Metric != "" {
+			return GroupExpr{}, fmt.Errorf("aggregate %q doesn't take a metric", ge.Aggregate)
+		}
+	case contains(metricAggregates, ge.Aggregate):
+		if ge.IsPoint() {
+			if ge.Aggregate == AggregateAnomalous || ge.Aggregate == AggregateAnomalousPercent {
+				return GroupExpr{}, fmt.Errorf("aggregate %q doesn't take points", ge.Aggregate)
+			}
+			break
+		}
+		if !contains(metrics, ge.Metric) {
+			return GroupExpr{}, fmt.Errorf("invalid metric %q, expected one of %s or %s<name>", ge.Metric, strings.Join(metrics, ", "), MetricPointPrefix)
+		}
+	default:
+		return GroupExpr{}, fmt.Errorf("invalid aggregate %q, expected one of %s", ge.Aggregate,
+			strings.Join(append(append([]string{}, countAggregates...), metricAggregates...), ", "))
+	}
+
+	// the regexp only matches valid numbers
+	ge.Threshold, _ = strconv.ParseFloat(m[4], 64)
+	return ge, nil
+}
+
+// GroupMember is a client of a group with its latest measurement, nil if there is no recent one.
+type GroupMember struct {
+	ClientID    string
+	Connected   bool
+	Measurement *models.Measurement
+	// Points are the recent values of the industrial points polled through the client
+	Points map[string]float64
+	// Anomalies are the metrics of the measurement unusual for the client
+	Anomalies map[string]bool
+	// DaysUntilFull is the forecast of the mountpoint of the client getting full first, only set for rules on it
+	DaysUntilFull *float64
+}
+
+// Value returns the aggregate over the members, false if there is no value, e.g. there are no measurements.
+func (ge GroupExpr) Value(members []GroupMember) (float64, bool) {
+	var connected int
+	for _, m := range members {
+		if m.Connected {
+			connected++
+		}
+	}
+
+	switch ge.Aggregate {
+	case AggregateClients:
+		return float64(len(members)), true
+	case AggregateConnected:
+		return float64(connected), true
+	case AggregateDisconnected:
+		return float64(len(members) - connected), true
+	case AggregateDisconnectedPercent:
+		if len(members) == 0 {
+			return 0, false
+		}
+		return float64(len(members)-connected) * 100 / float64(len(members)), true
+	case AggregateDaysUntilFull:
+		var min *float64
+		for _, m := range members {
+			if m.DaysUntilFull != nil && (min == nil || *m.DaysUntilFull < *min) {
+				min = m.DaysUntilFull
+			}
+		}
+		if min == nil {
+			return 0, false
+		}
+		return *min, true
+	}
+
+	if ge.IsAnomaly() {
+		var measured int
+		for _, m := range members {
+			if m.Connected && m.Measurement != nil {
+				measured++
+			}
+		}
+		if measured == 0 {
+			return 0, false
+		}
+		anomalous := float64(len(anomalousClients(members, ge.Metric)))
+		if ge.Aggregate == AggregateAnomalousPercent {
+			return anomalous * 100 / float64(measured), true
+		}
+		return anomalous, true
+	}
+
+	var values []float64
+	for _, m := range members {
+		if ge.IsPoint() {
+			// devices are polled through the client, their values are recent only while it's connected
+			if v, ok := m.Points[strings.TrimPrefix(ge.Metric, MetricPointPrefix)]; ok && m.Connected {
+				values = append(values, v)
+			}
+			continue
+		}
+		if m.Connected && m.Measurement != nil {
+			values = append(values, metricValue(m.Measurement, ge.Metric))
+		}
+	}
+	if len(values) == 0 {
+		return 0, false
+	}
+	result := values[0]
+	for _, v := range values[1:] {
+		switch ge.Aggregate {
+		case AggregateAvg:
+			result += v
+		case AggregateMin:
+			if v < result {
+				result = v
+			}
+		case AggregateMax:
+			if v > result {
+				result = v
+			}
+		}
+	}
+	if ge.Aggregate == AggregateAvg {
+		result /= float64(len(values))
+	}
+	return result, true
+}
+
+// IsAnomaly returns true if the expression is on the anomalies of the clients rather than their values.
+func (ge GroupExpr) IsAnomaly() bool {
+	return ge.Aggregate == AggregateAnomalous || ge.Aggregate == AggregateAnomalousPercent
+}
+
+// IsPoint returns true if the metric is a point of industrial devices.
+func (ge GroupExpr) IsPoint() bool {
+	return strings.HasPrefix(ge.Metric, MetricPointPrefix)
+}
+
+// fillingClients returns the sorted ids of the members whose disk forecast fulfills the condition.
+func (ge GroupExpr) fillingClients(members []GroupMember) []string {
+	var res []string
+	for _, m := range members {
+		if m.DaysUntilFull != nil && ge.Matches(*m.DaysUntilFull) {
+			res = append(res, m.ClientID)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Matches returns true if the value fulfills the condition.
+func (ge GroupExpr) Matches(value float64) bool {
+	switch ge.Operator {
+	case ">":
+		return value > ge.Threshold
+	case ">=":
+		return value >= ge.Threshold
+	case "<":
+		return value < ge.Threshold
+	case "<=":
+		return value <= ge.Threshold
+	case "==":
+		return value == ge.Threshold
+	case "!=":
+		return value != ge.Threshold
+	}
+	return false
+}
+
+func (ge GroupExpr) String() string {
+	aggregate := ge.Aggregate
+	if ge.Metric != "" {
+		aggregate += "(" + ge.Metric + ")"
+	}
+	return fmt.Sprintf("%s %s %s", aggregate, ge.Operator, strconv.FormatFloat(ge.Threshold, 'f', -1, 64))
+}
+
+func metricValue(m *models.Measurement, metric string) float64 {
+	switch metric {
+	case MetricCPUUsagePercent:
+		return m.CPUUsagePercent
+	case MetricMemoryUsagePercent:
+		return m.MemoryUsagePercent
+	case MetricIOUsagePercent:
+		return m.IoUsagePercent
+	}
+	return 0
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package alerts
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+func TestParseGroupExpr(t *testing.T) {
+	testCases := []struct {
+		expr    string
+		want    GroupExpr
+		wantErr string
+	}{
+		{
+			expr: "disconnected_percent > 30",
+			want: GroupExpr{Aggregate: AggregateDisconnectedPercent, Operator: ">", Threshold: 30},
+		},
+		{
+			expr: " avg( cpu_usage_percent ) >= 80.5 ",
+			want: GroupExpr{Aggregate: AggregateAvg, Metric: MetricCPUUsagePercent, Operator: ">=", Threshold: 80.5},
+		},
+		{
+			expr:    "disconnected(cpu_usage_percent) > 1",
+			wantErr: `aggregate "disconnected" doesn't take a metric`,
+		},
+		{
+			expr:    "max(disk) > 1",
+			wantErr: `invalid metric "disk", expected one of cpu_usage_percent, memory_usage_percent, io_usage_percent or point.<name>`,
+		},
+		{
+			expr: "max(point.boiler_temperature_1) > 90",
+			want: GroupExpr{Aggregate: AggregateMax, Metric: "point.boiler_temperature_1", Operator: ">", Threshold: 90},
+		},
+		{
+			expr:    "anomalous(point.boiler_temperature) > 0",
+			wantErr: `aggregate "anomalous" doesn't take points`,
+		},
+		{
+			expr:    "sum(cpu_usage_percent) > 1",
+			wantErr: `invalid aggregate "sum", expected one of clients, connected, disconnected, disconnected_percent, days_until_full, avg, min, max, anomalous, anomalous_percent`,
+		},
+		{
+			expr:    "connected >",
+			wantErr: "invalid expression \"connected >\", expected `<aggregate> <operator> <number>`",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expr, func(t *testing.T) {
+			ge, err := ParseGroupExpr(tc.expr)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, ge)
+		})
+	}
+}
+
+func TestGroupExprValue(t *testing.T) {
+	days := 3.5
+	members := []GroupMember{
+		{ClientID: "1", Connected: true, Measurement: &models.Measurement{CPUUsagePercent: 90}, Anomalies: map[string]bool{MetricCPUUsagePercent: true}},
+		{ClientID: "2", Connected: true, Measurement: &models.Measurement{CPUUsagePercent: 60}, Points: map[string]float64{"pressure": 2.5}},
+		{ClientID: "3", Connected: true, DaysUntilFull: &days, Points: map[string]float64{"pressure": 1.5}},
+		{ClientID: "4", Measurement: &models.Measurement{CPUUsagePercent: 10}, Anomalies: map[string]bool{MetricCPUUsagePercent: true}, Points: map[string]float64{"pressure": 9}},
+	}
+
+	testCases := []struct {
+		expr      string
+		wantValue float64
+		wantOK    bool
+		matches   bool
+	}{
+		{expr: "clients == 4", wantValue: 4, wantOK: true, matches: true},
+		{expr: "disconnected_percent > 30", wantValue: 25, wantOK: true},
+		{expr: "avg(cpu_usage_percent) > 70", wantValue: 75, wantOK: true, matches: true},
+		{expr: "min(cpu_usage_percent) < 70", wantValue: 60, wantOK: true, matches: true},
+		{expr: "max(cpu_usage_percent) <= 80", wantValue: 90, wantOK: true},
+		{expr: "avg(memory_usage_percent) > 1", wantValue: 0, wantOK: true},
+		{expr: "anomalous(cpu_usage_percent) > 0", wantValue: 1, wantOK: true, matches: true},
+		{expr: "anomalous_percent(cpu_usage_percent) >= 50", wantValue: 50, wantOK: true, matches: true},
+		{expr: "anomalous(memory_usage_percent) > 0", wantValue: 0, wantOK: true},
+		{expr: "days_until_full < 7", wantValue: 3.5, wantOK: true, matches: true},
+		{expr: "max(point.pressure) > 3", wantValue: 2.5, wantOK: true},
+		{expr: "avg(point.pressure) == 2", wantValue: 2, wantOK: true, matches: true},
+		{expr: "min(point.flow) < 1", wantValue: 0, wantOK: false, matches: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expr, func(t *testing.T) {
+			ge, err := ParseGroupExpr(tc.expr)
+			require.NoError(t, err)
+			value, ok := ge.Value(members)
+			assert.Equal(t, tc.wantOK, ok)
+			assert.Equal(t, tc.wantValue, value)
+			assert.Equal(t, tc.matches, ge.Matches(value))
+		})
+	}
+
+	ge, err := ParseGroupExpr("avg(cpu_usage_percent) > 1")
+	require.NoError(t, err)
+	_, ok := ge.Value([]GroupMember{{ClientID: "3", Connected: true}})
+	assert.False(t, ok)
+	ge, err = ParseGroupExpr("disconnected_percent > 1")
+	require.NoError(t, err)
+	_, ok = ge.Value(nil)
+	assert.False(t, ok)
+}
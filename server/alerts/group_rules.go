@@ -0,0 +1,516 @@
+package alerts
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/IOTech17/neo-rport/plus/capabilities/alerting/entities/rules"
+	"github.com/IOTech17/neo-rport/plus/capabilities/alerting/entities/severity"
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/notifications"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/models"
+	"github.com/IOTech17/neo-rport/share/random"
+	"github.com/IOTech17/neo-rport/share/refs"
+	"github.com/IOTech17/neo-rport/share/types"
+)
+
+const (
+	GroupRuleType refs.IdentifiableType = "group-rule"
+
+	GroupRuleEvaluationInterval = time.Minute
+	// MaxMeasurementAge is the age after which a measurement of a client isn't used for group rules anymore
+	MaxMeasurementAge = 10 * time.Minute
+)
+
+var severities = []severity.Severity{severity.Information, severity.Warning, severity.Average, severity.High, severity.Disaster}
+
+// GroupRule is an alerting rule on an aggregate over all clients of a client group.
+type GroupRule struct {
+	ID         string            `json:"id" db:"id"`
+	GroupID    string            `json:"group_id" db:"group_id"`
+	Expr       string            `json:"expr" db:"expr"`
+	Severity   severity.Severity `json:"severity" db:"severity"`
+	Recipients types.StringSlice `json:"recipients" db:"recipients"`
+}
+
+func (r *GroupRule) Validate() error {
+	if r.GroupID == "" {
+		return errors2.APIError{Message: "group_id is required", HTTPStatus: http.StatusBadRequest}
+	}
+	if _, err := ParseGroupExpr(r.Expr); err != nil {
+		return errors2.APIError{Message: err.Error(), HTTPStatus: http.StatusBadRequest}
+	}
+	if err := validateSeverity(&r.Severity); err != nil {
+		return err
+	}
+	if r.Recipients == nil {
+		r.Recipients = types.StringSlice{}
+	}
+	return nil
+}
+
+// validateSeverity checks the severity of a rule, it defaults to warning.
+func validateSeverity(s *severity.Severity) error {
+	if *s == "" {
+		*s = severity.Warning
+	}
+	names := make([]string, 0, len(severities))
+	for _, valid := range severities {
+		if valid == *s {
+			return nil
+		}
+		names = append(names, string(valid))
+	}
+	return errors2.APIError{
+		Message:    fmt.Sprintf("invalid severity %q, expected one of %s", *s, strings.Join(names, ", ")),
+		HTTPStatus: http.StatusBadRequest,
+	}
+}
+
+// NotificationSeverity maps the severity of an alerting rule to the severity of its notifications.
+func NotificationSeverity(s severity.Severity) string {
+	switch s {
+	case severity.Information:
+		return notifications.SeverityInfo
+	case severity.Warning:
+		return notifications.SeverityLow
+	case severity.Average:
+		return notifications.SeverityMedium
+	case severity.High:
+		return notifications.SeverityHigh
+	case severity.Disaster:
+		return notifications.SeverityCritical
+	}
+	return ""
+}
+
+type GroupRuleProvider struct {
+	db *sqlx.DB
+}
+
+func NewGroupRuleProvider(db *sqlx.DB) *GroupRuleProvider {
+	return &GroupRuleProvider{db: db}
+}
+
+func (p *GroupRuleProvider) List(ctx context.Context) ([]GroupRule, error) {
+	var res []GroupRule
+	err := p.db.SelectContext(ctx, &res, "SELECT * FROM group_rules ORDER BY id")
+	return res, err
+}
+
+func (p *GroupRuleProvider) Get(ctx context.Context, id string) (*GroupRule, error) {
+	res := &GroupRule{}
+	err := p.db.GetContext(ctx, res, "SELECT * FROM group_rules WHERE id = ?", id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return res, nil
+}
+
+func (p *GroupRuleProvider) Save(ctx context.Context, rule GroupRule) error {
+	_, err := p.db.NamedExecContext(
+		ctx,
+		"INSERT OR REPLACE INTO group_rules (id, group_id, expr, severity, recipients) VALUES (:id, :group_id, :expr, :severity, :recipients)",
+		rule,
+	)
+	return err
+}
+
+func (p *GroupRuleProvider) Delete(ctx context.Context, id string) error {
+	_, err := p.db.ExecContext(ctx, "DELETE FROM group_rules WHERE id = ?", id)
+	return err
+}
+
+func (p *GroupRuleProvider) Close() error {
+	return p.db.Close()
+}
+
+// DiskForecastFunc returns the predicted days until the first mountpoint of a client is full, nil if none is getting
+// full.
+type DiskForecastFunc func(ctx context.Context, clientID string) (*float64, error)
+
+// diskForecastTTL is how long a disk forecast is reused, forecasts are computed from hourly measurements
+const diskForecastTTL = 15 * time.Minute
+
+type cachedForecast struct {
+	daysUntilFull *float64
+	at            time.Time
+}
+
+// GroupMembersFunc returns the clients of a group, false if the group doesn't exist.
+type GroupMembersFunc func(ctx context.Context, groupID string) (members []GroupMember, found bool, err error)
+
+// GroupRuleState is the result of the latest evaluation of a group rule.
+type GroupRuleState struct {
+	Firing      bool       `json:"firing"`
+	Value       *float64   `json:"value"`
+	Clients     int        `json:"clients"`
+	EvaluatedAt time.Time  `json:"evaluated_at"`
+	FiringSince *time.Time `json:"firing_since,omitempty"`
+	ProblemID   string     `json:"problem_id,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	// AnomalousClients are set for rules on anomalies
+	AnomalousClients []string `json:"anomalous_clients,omitempty"`
+	// FillingClients are the clients whose disk forecast fulfills the condition of a rule on days_until_full
+	FillingClients []string `json:"filling_clients,omitempty"`
+}
+
+// GroupRuleWithState is a group rule with the result of its latest evaluation, nil if it wasn't evaluated yet.
+type GroupRuleWithState struct {
+	GroupRule
+	State *GroupRuleState `json:"state"`
+}
+
+// GroupEvaluator periodically evaluates the group rules against the latest measurements and the connection state of
+// the clients and notifies the recipients of a rule when it starts or stops firing.
+type GroupEvaluator struct {
+	rules      *GroupRuleProvider
+	members    GroupMembersFunc
+	dispatcher notifications.Dispatcher
+	history    *ProblemHistory
+	baselines  *Baselines
+	forecast   DiskForecastFunc
+	logger     *logger.Logger
+	now        func() time.Time
+
+	mu           sync.Mutex
+	measurements map[string]models.Measurement
+	points       map[string]map[string]pointValue
+	states       map[string]*GroupRuleState
+	forecasts    map[string]cachedForecast
+}
+
+func NewGroupEvaluator(rules *GroupRuleProvider, members GroupMembersFunc, dispatcher notifications.Dispatcher, l *logger.Logger) *GroupEvaluator {
+	return &GroupEvaluator{
+		rules:        rules,
+		members:      members,
+		dispatcher:   dispatcher,
+		logger:       l,
+		now:          time.Now,
+		measurements: make(map[string]models.Measurement),
+		points:       make(map[string]map[string]pointValue),
+		states:       make(map[string]*GroupRuleState),
+		forecasts:    make(map[string]cachedForecast),
+	}
+}
+
+// SetHistory enables the recording of the problems raised by the group rules.
+func (e *GroupEvaluator) SetHistory(history *ProblemHistory) {
+	e.history = history
+}
+
+// SetBaselines enables rules on anomalies, the baselines are learned from the measurements put.
+func (e *GroupEvaluator) SetBaselines(baselines *Baselines) {
+	e.baselines = baselines
+}
+
+// SetDiskForecasts enables rules on days_until_full.
+func (e *GroupEvaluator) SetDiskForecasts(forecast DiskForecastFunc) {
+	e.forecast = forecast
+}
+
+// PutMeasurement keeps the latest measurement of a client.
+func (e *GroupEvaluator) PutMeasurement(m models.Measurement) {
+	if e == nil {
+		return
+	}
+	e.baselines.Put(m)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.measurements[m.ClientID] = m
+}
+
+type pointValue struct {
+	value   float64
+	expires time.Time
+}
+
+// PutPoints keeps the latest values of the industrial points polled through a client until they expire.
+func (e *GroupEvaluator) PutPoints(clientID string, values map[string]float64, expires time.Time) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	points := e.points[clientID]
+	if points == nil {
+		points = make(map[string]pointValue, len(values))
+		e.points[clientID] = points
+	}
+	for name, v := range values {
+		points[name] = pointValue{value: v, expires: expires}
+	}
+}
+
+// Run evaluates the rules until the context is canceled.
+func (e *GroupEvaluator) Run(ctx context.Context) {
+	ticker := time.NewTicker(GroupRuleEvaluationInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := e.Evaluate(ctx); err != nil {
+				e.logger.Errorf("Failed to evaluate group rules: %v", err)
+			}
+		}
+	}
+}
+
+// Evaluate evaluates all group rules once.
+func (e *GroupEvaluator) Evaluate(ctx context.Context) error {
+	all, err := e.rules.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]bool, len(all))
+	for _, rule := range all {
+		existing[rule.ID] = true
+		if err := e.evaluate(ctx, rule); err != nil {
+			e.logger.Errorf("Failed to notify about group rule %q: %v", rule.ID, err)
+		}
+	}
+
+	var orphaned []string
+	e.mu.Lock()
+	for id, state := range e.states {
+		if !existing[id] {
+			if state.ProblemID != "" {
+				orphaned = append(orphaned, state.ProblemID)
+			}
+			delete(e.states, id)
+		}
+	}
+	e.mu.Unlock()
+
+	for _, pid := range orphaned {
+		e.record(ctx, pid, ProblemEventResolved, "group rule deleted")
+	}
+	return nil
+}
+
+func (e *GroupEvaluator) evaluate(ctx context.Context, rule GroupRule) error {
+	now := e.now()
+	state := &GroupRuleState{EvaluatedAt: now}
+
+	expr, err := ParseGroupExpr(rule.Expr)
+	if err != nil {
+		state.Error = err.Error()
+	}
+	var members []GroupMember
+	if err == nil {
+		var found bool
+		members, found, err = e.members(ctx, rule.GroupID)
+		switch {
+		case err != nil:
+			state.Error = err.Error()
+		case !found:
+			state.Error = fmt.Sprintf("client group %q not found", rule.GroupID)
+		case expr.IsAnomaly() && e.baselines == nil:
+			state.Error = "anomaly detection is disabled, baseline_window is 0"
+		case expr.Aggregate == AggregateDaysUntilFull && e.forecast == nil:
+			state.Error = "disk forecasts are not available"
+		case expr.Aggregate == AggregateDaysUntilFull:
+			if err := e.forecastMembers(ctx, members, now); err != nil {
+				state.Error = err.Error()
+			}
+		}
+	}
+
+	e.mu.Lock()
+	for i := range members {
+		if m, ok := e.measurements[members[i].ClientID]; ok && now.Sub(m.Timestamp) <= MaxMeasurementAge {
+			members[i].Measurement = &m
+			if expr.IsAnomaly() {
+				members[i].Anomalies = e.baselines.Anomalies(members[i].ClientID)
+			}
+		}
+		if expr.IsPoint() {
+			members[i].Points = e.recentPoints(members[i].ClientID, now)
+		}
+	}
+	previous := e.states[rule.ID]
+	if state.Error == "" {
+		state.Clients = len(members)
+		if value, ok := expr.Value(members); ok {
+			state.Value = &value
+			state.Firing = expr.Matches(value)
+		}
+		if expr.IsAnomaly() {
+			state.AnomalousClients = anomalousClients(members, expr.Metric)
+		}
+		if expr.Aggregate == AggregateDaysUntilFull {
+			state.FillingClients = expr.fillingClients(members)
+		}
+	} else if previous != nil {
+		// keep the state if the rule can't be evaluated
+		state.Firing = previous.Firing
+	}
+	wasFiring := previous != nil && previous.Firing
+	if state.Firing {
+		state.FiringSince = &now
+		if wasFiring {
+			state.FiringSince = previous.FiringSince
+			state.ProblemID = previous.ProblemID
+		} else {
+			state.ProblemID, _ = random.UUID4()
+		}
+	}
+	e.states[rule.ID] = state
+	e.mu.Unlock()
+
+	if state.Firing == wasFiring {
+		return nil
+	}
+	problemID := state.ProblemID
+	if wasFiring {
+		problemID = previous.ProblemID
+		e.record(ctx, problemID, ProblemEventResolved, "")
+	} else {
+		e.open(ctx, rule, expr, problemID, now)
+	}
+	if len(rule.Recipients) == 0 {
+		return nil
+	}
+	notification := groupRuleNotification(rule, expr, state)
+	_, err = e.dispatcher.Dispatch(ctx, refs.NewIdentifiable(GroupRuleType, rule.ID), notification)
+	if err != nil {
+		return err
+	}
+	e.record(ctx, problemID, ProblemEventNotified, NotificationDetails(notification))
+	return nil
+}
+
+// forecastMembers sets the disk forecasts of the members, reusing forecasts computed within diskForecastTTL.
+// recentPoints returns the values of the points of the client not expired yet, the expired ones are dropped. It must
+// be called with the lock held.
+func (e *GroupEvaluator) recentPoints(clientID string, now time.Time) map[string]float64 {
+	points := e.points[clientID]
+	res := make(map[string]float64, len(points))
+	for name, p := range points {
+		if now.After(p.expires) {
+			delete(points, name)
+			continue
+		}
+		res[name] = p.value
+	}
+	return res
+}
+
+func (e *GroupEvaluator) forecastMembers(ctx context.Context, members []GroupMember, now time.Time) error {
+	for i := range members {
+		clientID := members[i].ClientID
+		e.mu.Lock()
+		cached, ok := e.forecasts[clientID]
+		e.mu.Unlock()
+		if !ok || now.Sub(cached.at) > diskForecastTTL {
+			days, err := e.forecast(ctx, clientID)
+			if err != nil {
+				return fmt.Errorf("failed to forecast the disk usage of client %q: %v", clientID, err)
+			}
+			cached = cachedForecast{daysUntilFull: days, at: now}
+			e.mu.Lock()
+			e.forecasts[clientID] = cached
+			e.mu.Unlock()
+		}
+		members[i].DaysUntilFull = cached.daysUntilFull
+	}
+	return nil
+}
+
+func (e *GroupEvaluator) open(ctx context.Context, rule GroupRule, expr GroupExpr, problemID string, now time.Time) {
+	if e.history == nil {
+		return
+	}
+	_, err := e.history.Open(ctx, ProblemRecord{
+		ProblemID: problemID,
+		Source:    ProblemSourceGroupRule,
+		RuleID:    rule.ID,
+		GroupID:   rule.GroupID,
+		Summary:   expr.String(),
+		OpenedAt:  now.UTC(),
+	})
+	if err != nil {
+		e.logger.Errorf("Failed to record problem of group rule %q: %v", rule.ID, err)
+	}
+}
+
+func (e *GroupEvaluator) record(ctx context.Context, problemID, event, details string) {
+	if e.history == nil || problemID == "" {
+		return
+	}
+	_, err := e.history.Record(ctx, ProblemEvent{ProblemID: problemID, Event: event, Timestamp: e.now(), Details: details})
+	if err != nil {
+		e.logger.Errorf("Failed to record event %q of problem %q: %v", event, problemID, err)
+	}
+}
+
+func groupRuleNotification(rule GroupRule, expr GroupExpr, state *GroupRuleState) notifications.NotificationData {
+	status := rules.Resolved
+	if state.Firing {
+		status = rules.Alerting
+	}
+	value := "unknown"
+	if state.Value != nil {
+		value = strconv.FormatFloat(*state.Value, 'f', 2, 64)
+	}
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "Group rule: %s\n", rule.ID)
+	fmt.Fprintf(b, "Client group: %s\n", rule.GroupID)
+	fmt.Fprintf(b, "Condition: %s\n", expr)
+	fmt.Fprintf(b, "Value: %s\n", value)
+	fmt.Fprintf(b, "Clients: %d\n", state.Clients)
+	if len(state.AnomalousClients) > 0 {
+		fmt.Fprintf(b, "Anomalous clients: %s\n", strings.Join(state.AnomalousClients, ", "))
+	}
+	if len(state.FillingClients) > 0 {
+		fmt.Fprintf(b, "Clients with disks getting full: %s\n", strings.Join(state.FillingClients, ", "))
+	}
+	fmt.Fprintf(b, "Severity: %s\n", rule.Severity)
+	fmt.Fprintf(b, "Time: %s\n", state.EvaluatedAt.UTC().Format(time.RFC1123))
+
+	return notifications.NotificationData{
+		Target:      string(notifications.TargetMail),
+		Recipients:  rule.Recipients,
+		Subject:     fmt.Sprintf("[rport] %s: group rule %q for client group %q", status, rule.ID, rule.GroupID),
+		Content:     b.String(),
+		ContentType: notifications.ContentTypeTextPlain,
+		Severity:    NotificationSeverity(rule.Severity),
+	}
+}
+
+// WithStates returns the rules with the results of their latest evaluation.
+func (e *GroupEvaluator) WithStates(all []GroupRule) []GroupRuleWithState {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	result := make([]GroupRuleWithState, 0, len(all))
+	for _, rule := range all {
+		var state *GroupRuleState
+		if s, ok := e.states[rule.ID]; ok {
+			copied := *s
+			state = &copied
+		}
+		result = append(result, GroupRuleWithState{GroupRule: rule, State: state})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
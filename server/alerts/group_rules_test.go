@@ -0,0 +1,144 @@
+package alerts
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	alertsmigration "github.com/IOTech17/neo-rport/db/migration/alerts"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	"github.com/IOTech17/neo-rport/plus/capabilities/alerting/entities/severity"
+	"github.com/IOTech17/neo-rport/share/models"
+	"github.com/IOTech17/neo-rport/share/types"
+)
+
+func TestGroupRuleProvider(t *testing.T) {
+	db, err := sqlite.New(":memory:", alertsmigration.AssetNames(), alertsmigration.Asset, sqlite.DataSourceOptions{})
+	require.NoError(t, err)
+	p := NewGroupRuleProvider(db)
+	defer p.Close()
+	ctx := context.Background()
+
+	rule := GroupRule{ID: "edge-down", GroupID: "edge", Expr: "disconnected_percent > 30", Severity: severity.High, Recipients: types.StringSlice{"ops@example.com"}}
+	require.NoError(t, p.Save(ctx, rule))
+
+	saved, err := p.Get(ctx, "edge-down")
+	require.NoError(t, err)
+	assert.Equal(t, &rule, saved)
+
+	all, err := p.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []GroupRule{rule}, all)
+
+	require.NoError(t, p.Delete(ctx, "edge-down"))
+	saved, err = p.Get(ctx, "edge-down")
+	require.NoError(t, err)
+	assert.Nil(t, saved)
+}
+
+func TestGroupRuleValidate(t *testing.T) {
+	rule := GroupRule{GroupID: "edge", Expr: "connected < 1"}
+	require.NoError(t, rule.Validate())
+	assert.Equal(t, severity.Warning, rule.Severity)
+	assert.Equal(t, types.StringSlice{}, rule.Recipients)
+
+	rule = GroupRule{Expr: "connected < 1"}
+	assert.EqualError(t, rule.Validate(), "group_id is required")
+
+	rule = GroupRule{GroupID: "edge", Expr: "connected < 1", Severity: "Critical"}
+	assert.EqualError(t, rule.Validate(), `invalid severity "Critical", expected one of Information, Warning, Average, High, Disaster`)
+}
+
+func TestGroupEvaluator(t *testing.T) {
+	db, err := sqlite.New(":memory:", alertsmigration.AssetNames(), alertsmigration.Asset, sqlite.DataSourceOptions{})
+	require.NoError(t, err)
+	p := NewGroupRuleProvider(db)
+	defer p.Close()
+	ctx := context.Background()
+
+	require.NoError(t, p.Save(ctx, GroupRule{ID: "edge-down", GroupID: "edge", Expr: "disconnected_percent > 30", Severity: severity.High, Recipients: types.StringSlice{"ops@example.com"}}))
+	require.NoError(t, p.Save(ctx, GroupRule{ID: "edge-cpu", GroupID: "edge", Expr: "avg(cpu_usage_percent) > 80", Recipients: types.StringSlice{}}))
+	require.NoError(t, p.Save(ctx, GroupRule{ID: "missing", GroupID: "missing", Expr: "connected < 1"}))
+
+	members := []GroupMember{{ClientID: "1", Connected: true}, {ClientID: "2", Connected: true}, {ClientID: "3", Connected: true}}
+	membersFunc := func(_ context.Context, groupID string) ([]GroupMember, bool, error) {
+		if groupID != "edge" {
+			return nil, false, nil
+		}
+		return append([]GroupMember{}, members...), true, nil
+	}
+	dispatcher := &recordingDispatcher{}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	e := NewGroupEvaluator(p, membersFunc, dispatcher, testLog)
+	e.now = func() time.Time { return now }
+
+	e.PutMeasurement(models.Measurement{ClientID: "1", Timestamp: now, CPUUsagePercent: 95})
+	e.PutMeasurement(models.Measurement{ClientID: "2", Timestamp: now.Add(-time.Hour), CPUUsagePercent: 10})
+
+	require.NoError(t, e.Evaluate(ctx))
+	assert.Empty(t, dispatcher.subjects)
+
+	all, err := p.List(ctx)
+	require.NoError(t, err)
+	states := e.WithStates(all)
+	require.Len(t, states, 3)
+	assert.Equal(t, "edge-cpu", states[0].ID)
+	assert.True(t, states[0].State.Firing)
+	assert.Equal(t, 95.0, *states[0].State.Value)
+	assert.False(t, states[1].State.Firing)
+	assert.Equal(t, `client group "missing" not found`, states[2].State.Error)
+
+	members[0].Connected = false
+	members[1].Connected = false
+	now = now.Add(time.Minute)
+	require.NoError(t, e.Evaluate(ctx))
+	assert.Equal(t, []string{`[rport] ALERTING: group rule "edge-down" for client group "edge"`}, dispatcher.subjects)
+
+	// still firing
+	now = now.Add(time.Minute)
+	require.NoError(t, e.Evaluate(ctx))
+	assert.Len(t, dispatcher.subjects, 1)
+
+	members[0].Connected = true
+	members[1].Connected = true
+	require.NoError(t, e.Evaluate(ctx))
+	assert.Equal(t, `[rport] RESOLVED: group rule "edge-down" for client group "edge"`, dispatcher.subjects[1])
+}
+
+func TestGroupEvaluatorPoints(t *testing.T) {
+	db, err := sqlite.New(":memory:", alertsmigration.AssetNames(), alertsmigration.Asset, sqlite.DataSourceOptions{})
+	require.NoError(t, err)
+	p := NewGroupRuleProvider(db)
+	defer p.Close()
+	ctx := context.Background()
+
+	require.NoError(t, p.Save(ctx, GroupRule{ID: "boiler-hot", GroupID: "plant", Expr: "max(point.boiler_temperature) > 90", Recipients: types.StringSlice{}}))
+	membersFunc := func(_ context.Context, groupID string) ([]GroupMember, bool, error) {
+		return []GroupMember{{ClientID: "1", Connected: true}, {ClientID: "2", Connected: true}}, true, nil
+	}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	e := NewGroupEvaluator(p, membersFunc, &recordingDispatcher{}, testLog)
+	e.now = func() time.Time { return now }
+
+	e.PutPoints("1", map[string]float64{"boiler_temperature": 95, "pressure": 2}, now.Add(time.Minute))
+	e.PutPoints("2", map[string]float64{"boiler_temperature": 70}, now.Add(time.Hour))
+
+	require.NoError(t, e.Evaluate(ctx))
+	all, err := p.List(ctx)
+	require.NoError(t, err)
+	states := e.WithStates(all)
+	require.Len(t, states, 1)
+	assert.True(t, states[0].State.Firing)
+	assert.Equal(t, 95.0, *states[0].State.Value)
+
+	// the value of client 1 expired
+	now = now.Add(2 * time.Minute)
+	require.NoError(t, e.Evaluate(ctx))
+	states = e.WithStates(all)
+	assert.False(t, states[0].State.Firing)
+	assert.Equal(t, 70.0, *states[0].State.Value)
+	assert.NotContains(t, e.points["1"], "boiler_temperature")
+}
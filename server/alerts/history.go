@@ -0,0 +1,384 @@
+package alerts
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/IOTech17/neo-rport/plus/capabilities/alerting/entities/rules"
+	"github.com/IOTech17/neo-rport/share/query"
+)
+
+// Sources of problems
+const (
+	ProblemSourceAlerting  = "alerting"
+	ProblemSourceGroupRule = "group-rule"
+	ProblemSourceExternal  = "external"
+	ProblemSourceEdgeRule  = "edge-rule"
+)
+
+// Events in the lifecycle of a problem
+const (
+	ProblemEventOpened       = "opened"
+	ProblemEventNotified     = "notified"
+	ProblemEventSuppressed   = "suppressed"
+	ProblemEventAcknowledged = "acknowledged"
+	ProblemEventEscalated    = "escalated"
+	ProblemEventResolved     = "resolved"
+	ProblemEventReopened     = "reopened"
+	// ProblemEventRemediated is a remediation script run for the problem
+	ProblemEventRemediated = "remediated"
+)
+
+// States of problems
+const (
+	ProblemStateOpen         = "open"
+	ProblemStateAcknowledged = "acknowledged"
+	ProblemStateResolved     = "resolved"
+)
+
+var ProblemHistorySupportedFilters = map[string]bool{
+	"problem_id":         true,
+	"source":             true,
+	"rule_id":            true,
+	"client_id":          true,
+	"group_id":           true,
+	"state":              true,
+	"acknowledged_by":    true,
+	"opened_at[gt]":      true,
+	"opened_at[lt]":      true,
+	"opened_at[since]":   true,
+	"opened_at[until]":   true,
+	"resolved_at[gt]":    true,
+	"resolved_at[lt]":    true,
+	"resolved_at[since]": true,
+	"resolved_at[until]": true,
+}
+
+var ProblemHistorySupportedSorts = map[string]bool{
+	"problem_id":  true,
+	"rule_id":     true,
+	"client_id":   true,
+	"state":       true,
+	"opened_at":   true,
+	"resolved_at": true,
+}
+
+var ProblemEventSupportedFilters = map[string]bool{
+	"problem_id":       true,
+	"event":            true,
+	"timestamp[gt]":    true,
+	"timestamp[lt]":    true,
+	"timestamp[since]": true,
+	"timestamp[until]": true,
+}
+
+var ProblemEventSupportedSorts = map[string]bool{
+	"timestamp": true,
+}
+
+const problemHistoryQuery = "SELECT * FROM (SELECT *, CASE" +
+	" WHEN resolved_at IS NOT NULL THEN '" + ProblemStateResolved + "'" +
+	" WHEN acknowledged_at IS NOT NULL THEN '" + ProblemStateAcknowledged + "'" +
+	" ELSE '" + ProblemStateOpen + "' END AS state FROM problem_history)"
+
+// problemEventUpdates are the changes of a problem caused by an event. An event not changing the problem, e.g. a
+// second acknowledgement, isn't recorded. An empty statement only requires the problem to exist.
+var problemEventUpdates = map[string]string{
+	ProblemEventNotified:     "UPDATE problem_history SET notified_at = COALESCE(notified_at, :timestamp) WHERE problem_id = :problem_id",
+	ProblemEventSuppressed:   "",
+	ProblemEventAcknowledged: "UPDATE problem_history SET acknowledged_at = :timestamp, acknowledged_by = :username WHERE problem_id = :problem_id AND acknowledged_at IS NULL AND resolved_at IS NULL",
+	ProblemEventEscalated:    "UPDATE problem_history SET escalated_at = :timestamp WHERE problem_id = :problem_id AND resolved_at IS NULL",
+	ProblemEventResolved:     "UPDATE problem_history SET resolved_at = :timestamp WHERE problem_id = :problem_id AND resolved_at IS NULL",
+	ProblemEventReopened:     "UPDATE problem_history SET resolved_at = NULL WHERE problem_id = :problem_id AND resolved_at IS NOT NULL",
+	ProblemEventRemediated:   "",
+}
+
+// ProblemRecord is the lifecycle of a problem raised by the alerting service, a group rule, an edge rule or an
+// external system.
+type ProblemRecord struct {
+	ProblemID      string         `json:"problem_id" db:"problem_id"`
+	Source         string         `json:"source" db:"source"`
+	RuleID         string         `json:"rule_id" db:"rule_id"`
+	ClientID       string         `json:"client_id" db:"client_id"`
+	GroupID        string         `json:"group_id" db:"group_id"`
+	Summary        string         `json:"summary" db:"summary"`
+	State          string         `json:"state" db:"state"`
+	OpenedAt       time.Time      `json:"opened_at" db:"opened_at"`
+	NotifiedAt     *time.Time     `json:"notified_at" db:"notified_at"`
+	AcknowledgedAt *time.Time     `json:"acknowledged_at" db:"acknowledged_at"`
+	AcknowledgedBy string         `json:"acknowledged_by" db:"acknowledged_by"`
+	EscalatedAt    *time.Time     `json:"escalated_at" db:"escalated_at"`
+	ResolvedAt     *time.Time     `json:"resolved_at" db:"resolved_at"`
+	Events         []ProblemEvent `json:"events,omitempty" db:"-"`
+}
+
+// AlertingProblemRecord returns the record of a problem of the alerting service.
+func AlertingProblemRecord(p *rules.Problem) ProblemRecord {
+	openedAt := p.CreatedAt
+	if openedAt.IsZero() {
+		openedAt = time.Now()
+	}
+	return ProblemRecord{
+		ProblemID: string(p.ID),
+		Source:    ProblemSourceAlerting,
+		RuleID:    string(p.RuleID),
+		ClientID:  p.ClientID,
+		OpenedAt:  openedAt.UTC(),
+	}
+}
+
+// ProblemEvent is an entry in the timeline of a problem.
+type ProblemEvent struct {
+	ID        int64     `json:"-" db:"id"`
+	ProblemID string    `json:"problem_id" db:"problem_id"`
+	ClientID  string    `json:"client_id" db:"client_id"`
+	Event     string    `json:"event" db:"event"`
+	Timestamp time.Time `json:"timestamp" db:"timestamp"`
+	Username  string    `json:"username,omitempty" db:"username"`
+	Details   string    `json:"details,omitempty" db:"details"`
+}
+
+// ProblemStats are the counts and mean times of a set of problems.
+type ProblemStats struct {
+	Problems     int `json:"problems"`
+	Open         int `json:"open"`
+	Acknowledged int `json:"acknowledged"`
+	Resolved     int `json:"resolved"`
+	// MTTASec is the mean time to acknowledge in seconds, nil if no problem was acknowledged
+	MTTASec *float64 `json:"mtta_sec"`
+	// MTTRSec is the mean time to resolve in seconds, nil if no problem was resolved
+	MTTRSec *float64 `json:"mttr_sec"`
+
+	ackTotal     time.Duration
+	acked        int
+	resolveTotal time.Duration
+}
+
+// RuleProblemStats are the stats of the problems of a rule.
+type RuleProblemStats struct {
+	Source string `json:"source"`
+	RuleID string `json:"rule_id"`
+	*ProblemStats
+}
+
+// ProblemReport are the stats of all problems and of the problems of each rule.
+type ProblemReport struct {
+	ProblemStats
+	Rules []RuleProblemStats `json:"rules"`
+}
+
+func (s *ProblemStats) add(r ProblemRecord) {
+	s.Problems++
+	switch r.State {
+	case ProblemStateOpen:
+		s.Open++
+	case ProblemStateAcknowledged:
+		s.Acknowledged++
+	case ProblemStateResolved:
+		s.Resolved++
+		s.resolveTotal += r.ResolvedAt.Sub(r.OpenedAt)
+	}
+	if r.AcknowledgedAt != nil {
+		s.acked++
+		s.ackTotal += r.AcknowledgedAt.Sub(r.OpenedAt)
+	}
+}
+
+func (s *ProblemStats) finish() {
+	if s.acked > 0 {
+		mtta := s.ackTotal.Seconds() / float64(s.acked)
+		s.MTTASec = &mtta
+	}
+	if s.Resolved > 0 {
+		mttr := s.resolveTotal.Seconds() / float64(s.Resolved)
+		s.MTTRSec = &mttr
+	}
+}
+
+// ProblemHistory persists the lifecycle of the problems.
+type ProblemHistory struct {
+	db        *sqlx.DB
+	converter *query.SQLConverter
+	opened    func(r ProblemRecord)
+}
+
+func NewProblemHistory(db *sqlx.DB) *ProblemHistory {
+	return &ProblemHistory{
+		db:        db,
+		converter: query.NewSQLConverter(db.DriverName()),
+	}
+}
+
+// SetOpenedHook sets a function called with each new problem, e.g. to remediate it. It must not block.
+func (h *ProblemHistory) SetOpenedHook(f func(r ProblemRecord)) {
+	h.opened = f
+}
+
+// Open records a new problem, it returns false if the problem is already known.
+func (h *ProblemHistory) Open(ctx context.Context, r ProblemRecord) (bool, error) {
+	tx, err := h.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.NamedExecContext(
+		ctx,
+		"INSERT OR IGNORE INTO problem_history (problem_id, source, rule_id, client_id, group_id, summary, opened_at)"+
+			" VALUES (:problem_id, :source, :rule_id, :client_id, :group_id, :summary, :opened_at)",
+		r,
+	)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+	err = insertProblemEvent(ctx, tx, ProblemEvent{
+		ProblemID: r.ProblemID,
+		ClientID:  r.ClientID,
+		Event:     ProblemEventOpened,
+		Timestamp: r.OpenedAt,
+	})
+	if err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	if h.opened != nil {
+		h.opened(r)
+	}
+	return true, nil
+}
+
+// Record adds an event to the timeline of a known problem and updates the problem. It returns false if the problem
+// doesn't exist or the event doesn't apply to its current state.
+func (h *ProblemHistory) Record(ctx context.Context, ev ProblemEvent) (bool, error) {
+	tx, err := h.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	err = tx.GetContext(ctx, &ev.ClientID, "SELECT client_id FROM problem_history WHERE problem_id = ?", ev.ProblemID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	if ev.Timestamp.IsZero() {
+		ev.Timestamp = time.Now()
+	}
+	ev.Timestamp = ev.Timestamp.UTC()
+
+	if update := problemEventUpdates[ev.Event]; update != "" {
+		res, err := tx.NamedExecContext(ctx, update, ev)
+		if err != nil {
+			return false, err
+		}
+		if n, err := res.RowsAffected(); err != nil || n == 0 {
+			return false, err
+		}
+	}
+	if err := insertProblemEvent(ctx, tx, ev); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func insertProblemEvent(ctx context.Context, tx *sqlx.Tx, ev ProblemEvent) error {
+	_, err := tx.NamedExecContext(
+		ctx,
+		"INSERT INTO problem_events (problem_id, client_id, event, timestamp, username, details)"+
+			" VALUES (:problem_id, :client_id, :event, :timestamp, :username, :details)",
+		ev,
+	)
+	return err
+}
+
+// Get returns the problem with its timeline, nil if it doesn't exist.
+func (h *ProblemHistory) Get(ctx context.Context, problemID string) (*ProblemRecord, error) {
+	r := &ProblemRecord{}
+	err := h.db.GetContext(ctx, r, problemHistoryQuery+" WHERE problem_id = ?", problemID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	r.Events = []ProblemEvent{}
+	err = h.db.SelectContext(ctx, &r.Events, "SELECT * FROM problem_events WHERE problem_id = ? ORDER BY timestamp, id", problemID)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// List returns the problems filtered by the options, the most recent first by default.
+func (h *ProblemHistory) List(ctx context.Context, options *query.ListOptions) ([]ProblemRecord, error) {
+	if len(options.Sorts) == 0 {
+		options.Sorts = []query.SortOption{{Column: "opened_at", IsASC: false}}
+	}
+	res := []ProblemRecord{}
+	q, params := h.converter.ConvertListOptionsToQuery(options, problemHistoryQuery)
+	err := h.db.SelectContext(ctx, &res, q, params...)
+	return res, err
+}
+
+// Count counts the problems filtered by the options.
+func (h *ProblemHistory) Count(ctx context.Context, options *query.ListOptions) (int, error) {
+	var result int
+
+	countOptions := *options
+	countOptions.Sorts = nil
+	countOptions.Pagination = nil
+	q, params := h.converter.ConvertListOptionsToQuery(&countOptions, "SELECT count(*) FROM ("+problemHistoryQuery+")")
+	err := h.db.GetContext(ctx, &result, q, params...)
+	return result, err
+}
+
+// Timeline returns the events of the problems of a client filtered by the options, the oldest first by default.
+func (h *ProblemHistory) Timeline(ctx context.Context, clientID string, options *query.ListOptions) ([]ProblemEvent, error) {
+	if len(options.Sorts) == 0 {
+		options.Sorts = []query.SortOption{{Column: "timestamp", IsASC: true}}
+	}
+	timelineOptions := *options
+	timelineOptions.Filters = append([]query.FilterOption{{Column: []string{"client_id"}, Values: []string{clientID}}}, options.Filters...)
+
+	res := []ProblemEvent{}
+	q, params := h.converter.ConvertListOptionsToQuery(&timelineOptions, "SELECT * FROM problem_events")
+	err := h.db.SelectContext(ctx, &res, q, params...)
+	return res, err
+}
+
+// Report returns the stats of the problems filtered by the options.
+func (h *ProblemHistory) Report(ctx context.Context, options *query.ListOptions) (*ProblemReport, error) {
+	reportOptions := *options
+	reportOptions.Sorts = []query.SortOption{{Column: "source", IsASC: true}, {Column: "rule_id", IsASC: true}}
+	reportOptions.Pagination = nil
+	records, err := h.List(ctx, &reportOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ProblemReport{Rules: []RuleProblemStats{}}
+	var current *RuleProblemStats
+	for _, r := range records {
+		if current == nil || current.Source != r.Source || current.RuleID != r.RuleID {
+			report.Rules = append(report.Rules, RuleProblemStats{Source: r.Source, RuleID: r.RuleID, ProblemStats: &ProblemStats{}})
+			current = &report.Rules[len(report.Rules)-1]
+		}
+		current.add(r)
+		report.add(r)
+	}
+	report.finish()
+	for _, r := range report.Rules {
+		r.finish()
+	}
+	return report, nil
+}
@@ -0,0 +1,160 @@
+package alerts
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	alertsmigration "github.com/IOTech17/neo-rport/db/migration/alerts"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	"github.com/IOTech17/neo-rport/share/query"
+)
+
+func newTestProblemHistory(t *testing.T) *ProblemHistory {
+	db, err := sqlite.New(":memory:", alertsmigration.AssetNames(), alertsmigration.Asset, sqlite.DataSourceOptions{})
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return NewProblemHistory(db)
+}
+
+func TestProblemHistoryLifecycle(t *testing.T) {
+	h := newTestProblemHistory(t)
+	ctx := context.Background()
+	opened := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	ok, err := h.Open(ctx, ProblemRecord{ProblemID: "p1", Source: ProblemSourceAlerting, RuleID: "cpu_high", ClientID: "client-1", OpenedAt: opened})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = h.Open(ctx, ProblemRecord{ProblemID: "p1", Source: ProblemSourceAlerting, RuleID: "cpu_high", ClientID: "client-1", OpenedAt: opened.Add(time.Hour)})
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	record := func(event string, after time.Duration) bool {
+		ok, err := h.Record(ctx, ProblemEvent{ProblemID: "p1", Event: event, Timestamp: opened.Add(after), Username: "admin"})
+		require.NoError(t, err)
+		return ok
+	}
+	assert.True(t, record(ProblemEventNotified, time.Second))
+	assert.True(t, record(ProblemEventAcknowledged, 2*time.Minute))
+	assert.False(t, record(ProblemEventAcknowledged, 3*time.Minute))
+	assert.True(t, record(ProblemEventEscalated, 4*time.Minute))
+	assert.True(t, record(ProblemEventResolved, 10*time.Minute))
+	assert.False(t, record(ProblemEventResolved, 11*time.Minute))
+	assert.False(t, record(ProblemEventEscalated, 12*time.Minute))
+
+	ok, err = h.Record(ctx, ProblemEvent{ProblemID: "unknown", Event: ProblemEventAcknowledged})
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	problem, err := h.Get(ctx, "p1")
+	require.NoError(t, err)
+	require.NotNil(t, problem)
+	assert.Equal(t, ProblemStateResolved, problem.State)
+	assert.Equal(t, opened, problem.OpenedAt.UTC())
+	assert.Equal(t, opened.Add(2*time.Minute), problem.AcknowledgedAt.UTC())
+	assert.Equal(t, "admin", problem.AcknowledgedBy)
+	assert.Equal(t, opened.Add(10*time.Minute), problem.ResolvedAt.UTC())
+	var events []string
+	for _, ev := range problem.Events {
+		events = append(events, ev.Event)
+		assert.Equal(t, "client-1", ev.ClientID)
+	}
+	assert.Equal(t, []string{ProblemEventOpened, ProblemEventNotified, ProblemEventAcknowledged, ProblemEventEscalated, ProblemEventResolved}, events)
+
+	assert.True(t, record(ProblemEventReopened, 20*time.Minute))
+	problem, err = h.Get(ctx, "p1")
+	require.NoError(t, err)
+	assert.Equal(t, ProblemStateAcknowledged, problem.State)
+	assert.Nil(t, problem.ResolvedAt)
+
+	missing, err := h.Get(ctx, "unknown")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestProblemHistoryListAndReport(t *testing.T) {
+	h := newTestProblemHistory(t)
+	ctx := context.Background()
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	open := func(id, ruleID, clientID string, after time.Duration) {
+		_, err := h.Open(ctx, ProblemRecord{ProblemID: id, Source: ProblemSourceAlerting, RuleID: ruleID, ClientID: clientID, OpenedAt: start.Add(after)})
+		require.NoError(t, err)
+	}
+	record := func(id, event string, after time.Duration) {
+		_, err := h.Record(ctx, ProblemEvent{ProblemID: id, Event: event, Timestamp: start.Add(after)})
+		require.NoError(t, err)
+	}
+	open("p1", "cpu_high", "client-1", 0)
+	record("p1", ProblemEventAcknowledged, time.Minute)
+	record("p1", ProblemEventResolved, 10*time.Minute)
+	open("p2", "cpu_high", "client-2", time.Hour)
+	record("p2", ProblemEventResolved, time.Hour+20*time.Minute)
+	open("p3", "disk_full", "client-1", 2*time.Hour)
+
+	all, err := h.List(ctx, &query.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, "p3", all[0].ProblemID)
+
+	options := &query.ListOptions{
+		Filters: []query.FilterOption{
+			{Column: []string{"client_id"}, Values: []string{"client-1"}},
+			{Column: []string{"state"}, Values: []string{ProblemStateResolved}},
+		},
+	}
+	filtered, err := h.List(ctx, options)
+	require.NoError(t, err)
+	require.Len(t, filtered, 1)
+	assert.Equal(t, "p1", filtered[0].ProblemID)
+	count, err := h.Count(ctx, options)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	timeline, err := h.Timeline(ctx, "client-1", &query.ListOptions{})
+	require.NoError(t, err)
+	var events []string
+	for _, ev := range timeline {
+		events = append(events, ev.ProblemID+" "+ev.Event)
+	}
+	assert.Equal(t, []string{"p1 opened", "p1 acknowledged", "p1 resolved", "p3 opened"}, events)
+
+	report, err := h.Report(ctx, &query.ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Problems)
+	assert.Equal(t, 1, report.Open)
+	assert.Equal(t, 2, report.Resolved)
+	assert.Equal(t, 15*60.0, *report.MTTRSec)
+	assert.Equal(t, 60.0, *report.MTTASec)
+	require.Len(t, report.Rules, 2)
+	assert.Equal(t, "cpu_high", report.Rules[0].RuleID)
+	assert.Equal(t, 2, report.Rules[0].Resolved)
+	assert.Equal(t, "disk_full", report.Rules[1].RuleID)
+	assert.Nil(t, report.Rules[1].MTTRSec)
+}
+
+func TestSuppressorRecordsHistory(t *testing.T) {
+	st := newSuppressorTest(t, Config{DedupWindow: 10 * time.Minute})
+	h := newTestProblemHistory(t)
+	st.suppressor.SetHistory(h)
+	ctx := context.Background()
+
+	st.notify(true, 0)
+	st.notify(true, time.Minute)
+
+	all, err := h.List(ctx, &query.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	first, err := h.Get(ctx, all[1].ProblemID)
+	require.NoError(t, err)
+	require.Len(t, first.Events, 2)
+	assert.Equal(t, ProblemEventNotified, first.Events[1].Event)
+	assert.Equal(t, "smtp to ops@example.com: firing", first.Events[1].Details)
+	second, err := h.Get(ctx, all[0].ProblemID)
+	require.NoError(t, err)
+	require.Len(t, second.Events, 2)
+	assert.Equal(t, ProblemEventSuppressed, second.Events[1].Event)
+}
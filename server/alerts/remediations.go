@@ -0,0 +1,253 @@
+package alerts
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/share/logger"
+)
+
+const (
+	DefaultRemediationMaxAttempts = 3
+	DefaultRemediationWindow      = time.Hour
+	MaxRemediationAttempts        = 100
+	MinRemediationWindow          = time.Minute
+	MaxRemediationWindow          = 7 * 24 * time.Hour
+	MaxRemediationTimeout         = time.Hour
+	maxRemediationScriptSize      = 64 * 1024
+)
+
+// Remediation is a script run by the server on the client of a problem when a problem of the rule opens. Only
+// problems of the alerting service and external problems have a client, edge rules run their own script on the client.
+type Remediation struct {
+	ID          string `json:"id" db:"id"`
+	Source      string `json:"source" db:"source"`
+	RuleID      string `json:"rule_id" db:"rule_id"`
+	Script      string `json:"script" db:"script"`
+	Interpreter string `json:"interpreter" db:"interpreter"`
+	TimeoutSec  int    `json:"timeout_sec" db:"timeout_sec"`
+	// MaxAttempts is how often the script is run on a client within the window, problems opening again and again
+	// aren't remediated in a loop
+	MaxAttempts int `json:"max_attempts" db:"max_attempts"`
+	WindowSec   int `json:"window_sec" db:"window_sec"`
+}
+
+func (r *Remediation) Validate() error {
+	if r.Source == "" {
+		r.Source = ProblemSourceAlerting
+	}
+	if r.Source != ProblemSourceAlerting && r.Source != ProblemSourceExternal {
+		return errors2.APIError{
+			Message:    fmt.Sprintf("invalid source %q, expected %q or %q", r.Source, ProblemSourceAlerting, ProblemSourceExternal),
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+	if r.RuleID == "" {
+		return errors2.APIError{Message: "rule_id is required", HTTPStatus: http.StatusBadRequest}
+	}
+	if r.Script == "" {
+		return errors2.APIError{Message: "script is required", HTTPStatus: http.StatusBadRequest}
+	}
+	if len(r.Script) > maxRemediationScriptSize {
+		return errors2.APIError{Message: fmt.Sprintf("script exceeds %d bytes", maxRemediationScriptSize), HTTPStatus: http.StatusBadRequest}
+	}
+	if r.TimeoutSec < 0 || time.Duration(r.TimeoutSec)*time.Second > MaxRemediationTimeout {
+		return errors2.APIError{
+			Message:    fmt.Sprintf("invalid timeout_sec %d, expected 0 to %d", r.TimeoutSec, int(MaxRemediationTimeout.Seconds())),
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+	if r.MaxAttempts == 0 {
+		r.MaxAttempts = DefaultRemediationMaxAttempts
+	}
+	if r.MaxAttempts < 1 || r.MaxAttempts > MaxRemediationAttempts {
+		return errors2.APIError{
+			Message:    fmt.Sprintf("invalid max_attempts %d, expected 1 to %d", r.MaxAttempts, MaxRemediationAttempts),
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+	if r.WindowSec == 0 {
+		r.WindowSec = int(DefaultRemediationWindow.Seconds())
+	}
+	if r.Window() < MinRemediationWindow || r.Window() > MaxRemediationWindow {
+		return errors2.APIError{
+			Message: fmt.Sprintf("invalid window_sec %d, expected %d to %d",
+				r.WindowSec, int(MinRemediationWindow.Seconds()), int(MaxRemediationWindow.Seconds())),
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+	return nil
+}
+
+func (r Remediation) Window() time.Duration {
+	return time.Duration(r.WindowSec) * time.Second
+}
+
+type RemediationProvider struct {
+	db *sqlx.DB
+	// attemptsMu makes counting and adding an attempt atomic
+	attemptsMu sync.Mutex
+}
+
+func NewRemediationProvider(db *sqlx.DB) *RemediationProvider {
+	return &RemediationProvider{db: db}
+}
+
+func (p *RemediationProvider) List(ctx context.Context) ([]Remediation, error) {
+	res := []Remediation{}
+	err := p.db.SelectContext(ctx, &res, "SELECT * FROM remediations ORDER BY id")
+	return res, err
+}
+
+// ListByRule returns the remediations of a rule.
+func (p *RemediationProvider) ListByRule(ctx context.Context, source, ruleID string) ([]Remediation, error) {
+	res := []Remediation{}
+	err := p.db.SelectContext(ctx, &res, "SELECT * FROM remediations WHERE source = ? AND rule_id = ? ORDER BY id", source, ruleID)
+	return res, err
+}
+
+func (p *RemediationProvider) Get(ctx context.Context, id string) (*Remediation, error) {
+	res := &Remediation{}
+	err := p.db.GetContext(ctx, res, "SELECT * FROM remediations WHERE id = ?", id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return res, nil
+}
+
+func (p *RemediationProvider) Save(ctx context.Context, r Remediation) error {
+	_, err := p.db.NamedExecContext(
+		ctx,
+		"INSERT OR REPLACE INTO remediations (id, source, rule_id, script, interpreter, timeout_sec, max_attempts, window_sec)"+
+			" VALUES (:id, :source, :rule_id, :script, :interpreter, :timeout_sec, :max_attempts, :window_sec)",
+		r,
+	)
+	return err
+}
+
+func (p *RemediationProvider) Delete(ctx context.Context, id string) error {
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM remediation_attempts WHERE remediation_id = ?", id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM remediations WHERE id = ?", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// StartAttempt records an attempt of the remediation on the client, it returns false if the client already had
+// max_attempts within the window.
+func (p *RemediationProvider) StartAttempt(ctx context.Context, r Remediation, clientID, problemID string, now time.Time) (bool, error) {
+	p.attemptsMu.Lock()
+	defer p.attemptsMu.Unlock()
+
+	since := now.Add(-r.Window()).UTC()
+	_, err := p.db.ExecContext(ctx, "DELETE FROM remediation_attempts WHERE remediation_id = ? AND started_at <= ?", r.ID, since)
+	if err != nil {
+		return false, err
+	}
+	var attempts int
+	err = p.db.GetContext(ctx, &attempts, "SELECT COUNT(*) FROM remediation_attempts WHERE remediation_id = ? AND client_id = ?", r.ID, clientID)
+	if err != nil || attempts >= r.MaxAttempts {
+		return false, err
+	}
+	_, err = p.db.ExecContext(
+		ctx,
+		"INSERT INTO remediation_attempts (remediation_id, client_id, problem_id, started_at) VALUES (?, ?, ?, ?)",
+		r.ID, clientID, problemID, now.UTC(),
+	)
+	return err == nil, err
+}
+
+// RemediationRunner runs the script of a remediation on a client and returns its outcome.
+type RemediationRunner func(ctx context.Context, clientID string, r Remediation) (string, error)
+
+// Remediator runs the remediations of the rules of new problems and records their outcome as event of the problem.
+type Remediator struct {
+	remediations *RemediationProvider
+	history      *ProblemHistory
+	run          RemediationRunner
+	logger       *logger.Logger
+	now          func() time.Time
+}
+
+func NewRemediator(remediations *RemediationProvider, history *ProblemHistory, run RemediationRunner, l *logger.Logger) *Remediator {
+	return &Remediator{
+		remediations: remediations,
+		history:      history,
+		run:          run,
+		logger:       l,
+		now:          time.Now,
+	}
+}
+
+// ProblemOpened starts the remediation of a new problem, it's the opened hook of the problem history.
+func (r *Remediator) ProblemOpened(p ProblemRecord) {
+	if p.ClientID == "" || (p.Source != ProblemSourceAlerting && p.Source != ProblemSourceExternal) {
+		return
+	}
+	go r.remediate(context.Background(), p)
+}
+
+func (r *Remediator) remediate(ctx context.Context, p ProblemRecord) {
+	remediations, err := r.remediations.ListByRule(ctx, p.Source, p.RuleID)
+	if err != nil {
+		r.logger.Errorf("Failed to get remediations of rule %q: %v", p.RuleID, err)
+		return
+	}
+	for _, rem := range remediations {
+		// problems of resolved alerts are opened and resolved at once
+		problem, err := r.history.Get(ctx, p.ProblemID)
+		if err != nil {
+			r.logger.Errorf("Failed to get problem %q: %v", p.ProblemID, err)
+			return
+		}
+		if problem == nil || problem.ResolvedAt != nil {
+			return
+		}
+		r.attempt(ctx, p, rem)
+	}
+}
+
+func (r *Remediator) attempt(ctx context.Context, p ProblemRecord, rem Remediation) {
+	started, err := r.remediations.StartAttempt(ctx, rem, p.ClientID, p.ProblemID, r.now())
+	if err != nil {
+		r.logger.Errorf("Failed to record attempt of remediation %q: %v", rem.ID, err)
+		return
+	}
+
+	var details string
+	if !started {
+		r.logger.Infof("Not running remediation %q on client %q, it ran %d times within %s", rem.ID, p.ClientID, rem.MaxAttempts, rem.Window())
+		details = fmt.Sprintf("remediation %q not run, %d attempts within %s", rem.ID, rem.MaxAttempts, rem.Window())
+	} else {
+		r.logger.Infof("Running remediation %q of problem %q on client %q", rem.ID, p.ProblemID, p.ClientID)
+		outcome, err := r.run(ctx, p.ClientID, rem)
+		if err != nil {
+			details = fmt.Sprintf("remediation %q failed: %v", rem.ID, err)
+		} else {
+			details = fmt.Sprintf("remediation %q: %s", rem.ID, outcome)
+		}
+	}
+
+	_, err = r.history.Record(ctx, ProblemEvent{ProblemID: p.ProblemID, Event: ProblemEventRemediated, Timestamp: r.now(), Details: details})
+	if err != nil {
+		r.logger.Errorf("Failed to record remediation of problem %q: %v", p.ProblemID, err)
+	}
+}
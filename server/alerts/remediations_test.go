@@ -0,0 +1,106 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemediationValidate(t *testing.T) {
+	r := Remediation{RuleID: "disk_full", Script: "journalctl --vacuum-size=100M"}
+	require.NoError(t, r.Validate())
+	assert.Equal(t, ProblemSourceAlerting, r.Source)
+	assert.Equal(t, DefaultRemediationMaxAttempts, r.MaxAttempts)
+	assert.Equal(t, DefaultRemediationWindow, r.Window())
+
+	testCases := []struct {
+		r       Remediation
+		wantErr string
+	}{
+		{Remediation{Source: ProblemSourceGroupRule, RuleID: "edge-down", Script: "reboot"}, `invalid source "group-rule", expected "alerting" or "external"`},
+		{Remediation{Script: "reboot"}, "rule_id is required"},
+		{Remediation{RuleID: "disk_full"}, "script is required"},
+		{Remediation{RuleID: "disk_full", Script: "reboot", TimeoutSec: 7200}, "invalid timeout_sec 7200, expected 0 to 3600"},
+		{Remediation{RuleID: "disk_full", Script: "reboot", MaxAttempts: 101}, "invalid max_attempts 101, expected 1 to 100"},
+		{Remediation{RuleID: "disk_full", Script: "reboot", WindowSec: 10}, "invalid window_sec 10, expected 60 to 604800"},
+	}
+	for _, tc := range testCases {
+		assert.EqualError(t, tc.r.Validate(), tc.wantErr)
+	}
+}
+
+func TestRemediator(t *testing.T) {
+	h := newTestProblemHistory(t)
+	ctx := context.Background()
+	p := NewRemediationProvider(h.db)
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	rem := Remediation{ID: "vacuum-logs", Source: ProblemSourceAlerting, RuleID: "disk_full", Script: "journalctl --vacuum-size=100M", MaxAttempts: 2, WindowSec: 3600}
+	require.NoError(t, p.Save(ctx, rem))
+	require.NoError(t, p.Save(ctx, Remediation{ID: "other", Source: ProblemSourceExternal, RuleID: "disk_full", Script: "reboot", MaxAttempts: 1, WindowSec: 60}))
+
+	runs := make(chan string, 10)
+	run := func(ctx context.Context, clientID string, r Remediation) (string, error) {
+		runs <- r.ID + " on " + clientID
+		if clientID == "client-2" {
+			return "", errors.New("client is not connected")
+		}
+		return "job 1 successful", nil
+	}
+	r := NewRemediator(p, h, run, testLog)
+	r.now = func() time.Time { return now }
+	h.SetOpenedHook(r.ProblemOpened)
+
+	remediated := func(problemID string) string {
+		var details string
+		require.Eventually(t, func() bool {
+			problem, err := h.Get(ctx, problemID)
+			require.NoError(t, err)
+			for _, ev := range problem.Events {
+				if ev.Event == ProblemEventRemediated {
+					details = ev.Details
+					return true
+				}
+			}
+			return false
+		}, time.Second, 10*time.Millisecond)
+		return details
+	}
+	open := func(problemID, clientID string) {
+		opened, err := h.Open(ctx, ProblemRecord{ProblemID: problemID, Source: ProblemSourceAlerting, RuleID: "disk_full", ClientID: clientID, OpenedAt: now})
+		require.NoError(t, err)
+		require.True(t, opened)
+	}
+
+	for i := 1; i <= 2; i++ {
+		open(fmt.Sprintf("p%d", i), "client-1")
+		assert.Equal(t, `remediation "vacuum-logs": job 1 successful`, remediated(fmt.Sprintf("p%d", i)))
+	}
+	// the problem keeps opening, the remediation doesn't help
+	open("p3", "client-1")
+	assert.Equal(t, `remediation "vacuum-logs" not run, 2 attempts within 1h0m0s`, remediated("p3"))
+
+	open("p4", "client-2")
+	assert.Equal(t, `remediation "vacuum-logs" failed: client is not connected`, remediated("p4"))
+
+	// the window passed
+	now = now.Add(time.Hour)
+	open("p5", "client-1")
+	assert.Equal(t, `remediation "vacuum-logs": job 1 successful`, remediated("p5"))
+
+	// problems without a client aren't remediated
+	_, err := h.Open(ctx, ProblemRecord{ProblemID: "p6", Source: ProblemSourceAlerting, RuleID: "disk_full", OpenedAt: now})
+	require.NoError(t, err)
+
+	close(runs)
+	var got []string
+	for run := range runs {
+		got = append(got, run)
+	}
+	assert.Equal(t, []string{"vacuum-logs on client-1", "vacuum-logs on client-1", "vacuum-logs on client-2", "vacuum-logs on client-1"}, got)
+}
@@ -0,0 +1,398 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IOTech17/neo-rport/plus/capabilities/alerting/entities/rules"
+	"github.com/IOTech17/neo-rport/server/notifications"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/refs"
+)
+
+const (
+	SuppressedType refs.IdentifiableType = "suppressed-alert"
+
+	// SuppressorCheckInterval is how often the end of flapping is checked
+	SuppressorCheckInterval = time.Minute
+)
+
+// Config holds the settings of the alert deduplication, flap suppression, client dependencies and the webhook for
+// external alerts. A zero window disables the feature.
+type Config struct {
+	DedupWindow   time.Duration `mapstructure:"dedup_window"`
+	FlapWindow    time.Duration `mapstructure:"flap_window"`
+	FlapThreshold int           `mapstructure:"flap_threshold"`
+	// DependencyMode is how alerts are handled while a client the alerting client depends on is disconnected
+	DependencyMode string `mapstructure:"dependency_mode"`
+	// WebhookClientLabels are the labels of external alerts identifying their client
+	WebhookClientLabels []string `mapstructure:"webhook_client_labels"`
+	// BaselineWindow is the time the baselines of anomaly detection are learned over, 0 disables them
+	BaselineWindow     time.Duration `mapstructure:"baseline_window"`
+	AnomalySensitivity float64       `mapstructure:"anomaly_sensitivity"`
+	BaselineMinSamples int           `mapstructure:"baseline_min_samples"`
+}
+
+func (c Config) Validate() error {
+	if c.DedupWindow < 0 {
+		return errors.New("dedup_window must not be negative")
+	}
+	if c.FlapWindow < 0 {
+		return errors.New("flap_window must not be negative")
+	}
+	if c.FlapWindow > 0 && c.FlapThreshold < 2 {
+		return errors.New("flap_threshold must be at least 2")
+	}
+	if c.BaselineWindow < 0 {
+		return errors.New("baseline_window must not be negative")
+	}
+	if c.BaselineWindow > 0 && c.AnomalySensitivity <= 0 {
+		return errors.New("anomaly_sensitivity must be positive")
+	}
+	if c.BaselineWindow > 0 && c.BaselineMinSamples < 2 {
+		return errors.New("baseline_min_samples must be at least 2")
+	}
+	switch c.DependencyMode {
+	case "", DependencyModeSuppress, DependencyModeTag, DependencyModeOff:
+	default:
+		return fmt.Errorf("invalid dependency_mode %q, expected one of %q, %q and %q", c.DependencyMode, DependencyModeSuppress, DependencyModeTag, DependencyModeOff)
+	}
+	return nil
+}
+
+// ProblemGetter returns the problems the alerting notifications refer to.
+type ProblemGetter interface {
+	GetProblem(pid rules.ProblemID) (problem *rules.Problem, err error)
+}
+
+// Suppressor passes on the notifications of the alerting service, dropping repeated notifications of the same state
+// and holding back the notifications of flapping alerts. An alert is identified by its rule, client and notification
+// target, so a new problem raised by the same rule for the same client continues the previous one. Alerts of clients
+// depending on a disconnected client are suppressed or tagged as symptoms.
+type Suppressor struct {
+	cfg      Config
+	next     notifications.Dispatcher
+	problems ProblemGetter
+	deps     *Dependencies
+	history  *ProblemHistory
+	logger   *logger.Logger
+	now      func() time.Time
+
+	mu     sync.Mutex
+	states map[alertKey]*alertState
+}
+
+type alertKey struct {
+	ruleID     rules.RuleID
+	clientID   string
+	target     string
+	recipients string
+}
+
+type alertState struct {
+	firing         bool
+	notified       bool
+	notifiedFiring bool
+	notifiedAt     time.Time
+	// changes are the times of the state changes within the flap window
+	changes  []time.Time
+	flapping bool
+	// held is the latest notification suppressed while flapping
+	held *heldNotification
+	// symptom is set if a firing notification was suppressed because of a disconnected parent
+	symptom    bool
+	lastUpdate time.Time
+}
+
+type heldNotification struct {
+	refID        refs.Identifiable
+	notification notifications.NotificationData
+	firing       bool
+}
+
+// FlappingAlert is an alert currently flapping.
+type FlappingAlert struct {
+	RuleID       rules.RuleID `json:"rule_id"`
+	ClientID     string       `json:"client_id"`
+	Target       string       `json:"target"`
+	Firing       bool         `json:"firing"`
+	StateChanges []time.Time  `json:"state_changes"`
+}
+
+func NewSuppressor(cfg Config, next notifications.Dispatcher, problems ProblemGetter, l *logger.Logger) *Suppressor {
+	return &Suppressor{
+		cfg:      cfg,
+		next:     next,
+		problems: problems,
+		logger:   l,
+		now:      time.Now,
+		states:   make(map[alertKey]*alertState),
+	}
+}
+
+// SetDependencies enables the handling of client dependencies.
+func (s *Suppressor) SetDependencies(deps *Dependencies) {
+	s.deps = deps
+}
+
+// SetHistory enables the recording of the lifecycle of the problems.
+func (s *Suppressor) SetHistory(history *ProblemHistory) {
+	s.history = history
+}
+
+func (s *Suppressor) Dispatch(ctx context.Context, refID refs.Identifiable, notification notifications.NotificationData) (refs.Identifiable, error) {
+	problem, err := s.problems.GetProblem(rules.ProblemID(refID.ID()))
+	if err != nil || problem == nil {
+		// not a notification of a known problem
+		return s.next.Dispatch(ctx, refID, notification)
+	}
+
+	key := alertKey{
+		ruleID:     problem.RuleID,
+		clientID:   problem.ClientID,
+		target:     notification.Target,
+		recipients: strings.Join(notification.Recipients, ","),
+	}
+	firing := problem.Active
+	s.recordProblem(ctx, problem)
+
+	disconnectedParent := s.disconnectedParent(ctx, problem.ClientID)
+	symptom := disconnectedParent != "" && s.cfg.DependencyMode != DependencyModeTag
+
+	s.mu.Lock()
+	send, startsFlapping := s.update(key, firing, symptom, refID, notification)
+	s.mu.Unlock()
+
+	switch {
+	case symptom:
+		s.logger.Debugf("Suppressed notification of rule %q for client %q, it depends on disconnected client %q", key.ruleID, key.clientID, disconnectedParent)
+		s.record(ctx, problem.ID, ProblemEventSuppressed, fmt.Sprintf("symptom of disconnected client %q", disconnectedParent))
+		return refs.GenerateIdentifiable(SuppressedType), nil
+	case startsFlapping:
+		s.logger.Infof("Alert of rule %q for client %q is flapping, holding back notifications", key.ruleID, key.clientID)
+		notification = annotate(notification, "flapping",
+			fmt.Sprintf("The alert changed its state %d times within %s. Further notifications are held back until it is stable for %s.",
+				s.cfg.FlapThreshold, s.cfg.FlapWindow, s.cfg.FlapWindow))
+	case !send:
+		s.logger.Debugf("Suppressed notification of rule %q for client %q", key.ruleID, key.clientID)
+		s.record(ctx, problem.ID, ProblemEventSuppressed, "duplicate or flapping")
+		return refs.GenerateIdentifiable(SuppressedType), nil
+	}
+	if disconnectedParent != "" {
+		notification = annotate(notification, "symptom of "+disconnectedParent,
+			fmt.Sprintf("The client depends on client %q which is disconnected.", disconnectedParent))
+	}
+	return s.dispatch(ctx, refID, notification)
+}
+
+func (s *Suppressor) dispatch(ctx context.Context, refID refs.Identifiable, notification notifications.NotificationData) (refs.Identifiable, error) {
+	result, err := s.next.Dispatch(ctx, refID, notification)
+	if err == nil {
+		s.record(ctx, rules.ProblemID(refID.ID()), ProblemEventNotified, NotificationDetails(notification))
+	}
+	return result, err
+}
+
+// recordProblem records the problem if it's new and its resolution.
+func (s *Suppressor) recordProblem(ctx context.Context, problem *rules.Problem) {
+	if s.history == nil {
+		return
+	}
+	if _, err := s.history.Open(ctx, AlertingProblemRecord(problem)); err != nil {
+		s.logger.Errorf("Failed to record problem %q: %v", problem.ID, err)
+	}
+	if !problem.Active {
+		resolvedAt := problem.ResolvedAt.Time
+		if resolvedAt.IsZero() {
+			resolvedAt = s.now()
+		}
+		if _, err := s.history.Record(ctx, ProblemEvent{ProblemID: string(problem.ID), Event: ProblemEventResolved, Timestamp: resolvedAt}); err != nil {
+			s.logger.Errorf("Failed to record resolution of problem %q: %v", problem.ID, err)
+		}
+	}
+}
+
+func (s *Suppressor) record(ctx context.Context, pid rules.ProblemID, event, details string) {
+	if s.history == nil {
+		return
+	}
+	_, err := s.history.Record(ctx, ProblemEvent{ProblemID: string(pid), Event: event, Timestamp: s.now(), Details: details})
+	if err != nil {
+		s.logger.Errorf("Failed to record event %q of problem %q: %v", event, pid, err)
+	}
+}
+
+func (s *Suppressor) disconnectedParent(ctx context.Context, clientID string) string {
+	if s.deps == nil || s.cfg.DependencyMode == DependencyModeOff {
+		return ""
+	}
+	parentID, err := s.deps.DisconnectedAncestor(ctx, clientID)
+	if err != nil {
+		s.logger.Errorf("Failed to get the dependencies of client %q: %v", clientID, err)
+	}
+	return parentID
+}
+
+// update records the state of the alert and returns whether the notification has to be sent and whether the alert
+// started flapping. Symptoms only update the state.
+func (s *Suppressor) update(key alertKey, firing, symptom bool, refID refs.Identifiable, notification notifications.NotificationData) (send bool, startsFlapping bool) {
+	now := s.now()
+	st, ok := s.states[key]
+	if !ok {
+		st = &alertState{firing: firing}
+		s.states[key] = st
+	}
+	st.lastUpdate = now
+
+	if ok && st.firing != firing {
+		st.changes = append(st.changes, now)
+	}
+	st.firing = firing
+	s.pruneChanges(st, now)
+
+	if symptom {
+		st.symptom = st.symptom || firing
+		return false, false
+	}
+	if st.symptom {
+		st.symptom = false
+		// the recipients were never notified about the alert resolved now
+		if !firing && !(st.notified && st.notifiedFiring) {
+			return false, false
+		}
+	}
+	if st.flapping {
+		st.held = &heldNotification{refID: refID, notification: notification, firing: firing}
+		return false, false
+	}
+	if s.cfg.FlapWindow > 0 && len(st.changes) >= s.cfg.FlapThreshold {
+		st.flapping = true
+		st.markNotified(firing, now)
+		return true, true
+	}
+	if s.cfg.DedupWindow > 0 && st.notified && st.notifiedFiring == firing && now.Sub(st.notifiedAt) < s.cfg.DedupWindow {
+		return false, false
+	}
+	st.markNotified(firing, now)
+	return true, false
+}
+
+func (st *alertState) markNotified(firing bool, now time.Time) {
+	st.notified = true
+	st.notifiedFiring = firing
+	st.notifiedAt = now
+}
+
+func (s *Suppressor) pruneChanges(st *alertState, now time.Time) {
+	i := 0
+	for i < len(st.changes) && now.Sub(st.changes[i]) > s.cfg.FlapWindow {
+		i++
+	}
+	st.changes = st.changes[i:]
+}
+
+// Run ends the flapping of stable alerts until the context is canceled.
+func (s *Suppressor) Run(ctx context.Context) {
+	ticker := time.NewTicker(SuppressorCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.EndStableFlapping(ctx)
+		}
+	}
+}
+
+// EndStableFlapping ends the flapping of alerts without state changes within the flap window. If the last held back
+// notification reports a state the recipients haven't been notified about yet, it is sent.
+func (s *Suppressor) EndStableFlapping(ctx context.Context) {
+	now := s.now()
+	var toSend []*heldNotification
+
+	s.mu.Lock()
+	for key, st := range s.states {
+		s.pruneChanges(st, now)
+		if !st.flapping {
+			if now.Sub(st.lastUpdate) > s.maxWindow() {
+				delete(s.states, key)
+			}
+			continue
+		}
+		if len(st.changes) > 0 {
+			continue
+		}
+		st.flapping = false
+		s.logger.Infof("Alert of rule %q for client %q stopped flapping", key.ruleID, key.clientID)
+		if st.held != nil && st.held.firing != st.notifiedFiring {
+			toSend = append(toSend, st.held)
+			st.markNotified(st.held.firing, now)
+		}
+		st.held = nil
+	}
+	s.mu.Unlock()
+
+	for _, held := range toSend {
+		notification := annotate(held.notification, "flapping ended", "The alert is stable again, this is its current state.")
+		if _, err := s.dispatch(ctx, held.refID, notification); err != nil {
+			s.logger.Errorf("Failed to send notification after flapping: %v", err)
+		}
+	}
+}
+
+func (s *Suppressor) maxWindow() time.Duration {
+	if s.cfg.DedupWindow > s.cfg.FlapWindow {
+		return s.cfg.DedupWindow
+	}
+	return s.cfg.FlapWindow
+}
+
+// Flapping returns the alerts currently flapping.
+func (s *Suppressor) Flapping() []FlappingAlert {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := []FlappingAlert{}
+	for key, st := range s.states {
+		if !st.flapping {
+			continue
+		}
+		result = append(result, FlappingAlert{
+			RuleID:       key.ruleID,
+			ClientID:     key.clientID,
+			Target:       key.target,
+			Firing:       st.firing,
+			StateChanges: append([]time.Time{}, st.changes...),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].RuleID != result[j].RuleID {
+			return result[i].RuleID < result[j].RuleID
+		}
+		return result[i].ClientID < result[j].ClientID
+	})
+	return result
+}
+
+// NotificationDetails describes a notification in the problem history.
+func NotificationDetails(notification notifications.NotificationData) string {
+	return fmt.Sprintf("%s to %s: %s", notification.Target, strings.Join(notification.Recipients, ", "), notification.Subject)
+}
+
+func annotate(notification notifications.NotificationData, tag, note string) notifications.NotificationData {
+	notification.Subject = "[" + tag + "] " + notification.Subject
+	switch notification.ContentType {
+	case notifications.ContentTypeTextPlain:
+		notification.Content = note + "\n\n" + notification.Content
+	case notifications.ContentTypeTextHTML:
+		notification.Content = "<p>" + html.EscapeString(note) + "</p>\n" + notification.Content
+	}
+	return notification
+}
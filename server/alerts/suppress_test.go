@@ -0,0 +1,193 @@
+package alerts
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/plus/capabilities/alerting/entities/rules"
+	"github.com/IOTech17/neo-rport/server/notifications"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/refs"
+)
+
+var testLog = logger.NewLogger("alerts", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+
+type fakeProblems map[rules.ProblemID]*rules.Problem
+
+func (p fakeProblems) GetProblem(pid rules.ProblemID) (*rules.Problem, error) {
+	return p[pid], nil
+}
+
+type recordingDispatcher struct {
+	subjects []string
+}
+
+func (d *recordingDispatcher) Dispatch(_ context.Context, _ refs.Identifiable, notification notifications.NotificationData) (refs.Identifiable, error) {
+	d.subjects = append(d.subjects, notification.Subject)
+	return refs.GenerateIdentifiable(notifications.NotificationType), nil
+}
+
+type suppressorTest struct {
+	t          *testing.T
+	suppressor *Suppressor
+	next       *recordingDispatcher
+	problems   fakeProblems
+	now        time.Time
+	count      int
+}
+
+func newSuppressorTest(t *testing.T, cfg Config) *suppressorTest {
+	st := &suppressorTest{
+		t:        t,
+		next:     &recordingDispatcher{},
+		problems: fakeProblems{},
+		now:      time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+	st.suppressor = NewSuppressor(cfg, st.next, st.problems, testLog)
+	st.suppressor.now = func() time.Time { return st.now }
+	return st
+}
+
+// notify raises a new problem of the rule for the client and dispatches its notification.
+func (st *suppressorTest) notify(firing bool, after time.Duration) {
+	st.now = st.now.Add(after)
+	st.count++
+	pid := rules.ProblemID(string(rune('a' + st.count)))
+	st.problems[pid] = &rules.Problem{ID: pid, RuleID: "cpu_high", ClientID: "client-1", Active: firing, CreatedAt: st.now}
+	subject := "resolved"
+	if firing {
+		subject = "firing"
+	}
+	_, err := st.suppressor.Dispatch(context.Background(), refs.NewIdentifiable("problem", string(pid)), notifications.NotificationData{
+		Target:      "smtp",
+		Recipients:  []string{"ops@example.com"},
+		Subject:     subject,
+		Content:     "cpu",
+		ContentType: notifications.ContentTypeTextPlain,
+	})
+	require.NoError(st.t, err)
+}
+
+func TestSuppressorDedup(t *testing.T) {
+	st := newSuppressorTest(t, Config{DedupWindow: 10 * time.Minute})
+
+	st.notify(true, 0)
+	st.notify(true, time.Minute)
+	st.notify(false, time.Minute)
+	st.notify(true, 11*time.Minute)
+	st.notify(true, 11*time.Minute)
+
+	assert.Equal(t, []string{"firing", "resolved", "firing", "firing"}, st.next.subjects)
+}
+
+func TestSuppressorFlapping(t *testing.T) {
+	st := newSuppressorTest(t, Config{FlapWindow: 30 * time.Minute, FlapThreshold: 3})
+
+	st.notify(true, 0)
+	st.notify(false, time.Minute)
+	st.notify(true, time.Minute)
+	st.notify(false, time.Minute)
+	st.notify(true, time.Minute)
+	st.notify(false, time.Minute)
+
+	assert.Equal(t, []string{"firing", "resolved", "firing", "[flapping] resolved"}, st.next.subjects)
+	flapping := st.suppressor.Flapping()
+	require.Len(t, flapping, 1)
+	assert.Equal(t, rules.RuleID("cpu_high"), flapping[0].RuleID)
+	assert.Len(t, flapping[0].StateChanges, 5)
+
+	// still changes within the window
+	st.now = st.now.Add(10 * time.Minute)
+	st.suppressor.EndStableFlapping(context.Background())
+	assert.Len(t, st.next.subjects, 4)
+
+	// the last state was already notified
+	st.now = st.now.Add(30 * time.Minute)
+	st.suppressor.EndStableFlapping(context.Background())
+	assert.Len(t, st.next.subjects, 4)
+	assert.Empty(t, st.suppressor.Flapping())
+}
+
+func TestSuppressorFlappingEndsWithNewState(t *testing.T) {
+	st := newSuppressorTest(t, Config{FlapWindow: 30 * time.Minute, FlapThreshold: 2})
+
+	st.notify(true, 0)
+	st.notify(false, time.Minute)
+	st.notify(true, time.Minute)
+	st.notify(false, time.Minute)
+	assert.Equal(t, []string{"firing", "resolved", "[flapping] firing"}, st.next.subjects)
+
+	st.now = st.now.Add(31 * time.Minute)
+	st.suppressor.EndStableFlapping(context.Background())
+	assert.Equal(t, []string{"firing", "resolved", "[flapping] firing", "[flapping ended] resolved"}, st.next.subjects)
+}
+
+func TestSuppressorPassesUnknownProblems(t *testing.T) {
+	st := newSuppressorTest(t, Config{DedupWindow: time.Hour})
+
+	for i := 0; i < 2; i++ {
+		_, err := st.suppressor.Dispatch(context.Background(), refs.NewIdentifiable("problem", "unknown"), notifications.NotificationData{
+			Target:      "smtp",
+			Subject:     "other",
+			ContentType: notifications.ContentTypeTextPlain,
+		})
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, []string{"other", "other"}, st.next.subjects)
+}
+
+func TestConfigValidate(t *testing.T) {
+	assert.NoError(t, Config{}.Validate())
+	assert.NoError(t, Config{DedupWindow: time.Minute, FlapWindow: time.Minute, FlapThreshold: 2}.Validate())
+	assert.EqualError(t, Config{DedupWindow: -time.Minute}.Validate(), "dedup_window must not be negative")
+	assert.EqualError(t, Config{FlapWindow: time.Minute, FlapThreshold: 1}.Validate(), "flap_threshold must be at least 2")
+	assert.EqualError(t, Config{DependencyMode: "drop"}.Validate(), `invalid dependency_mode "drop", expected one of "suppress", "tag" and "off"`)
+}
+
+func TestSuppressorDependencies(t *testing.T) {
+	ctx := context.Background()
+	connected := map[string]bool{"router": false}
+	newDeps := func() *Dependencies {
+		p := newTestDependencyProvider(t)
+		require.NoError(t, p.Save(ctx, ClientDependency{ClientID: "client-1", ParentID: "router"}))
+		return NewDependencies(p, func(id string) bool { return connected[id] })
+	}
+
+	t.Run("suppress", func(t *testing.T) {
+		connected["router"] = false
+		st := newSuppressorTest(t, Config{DependencyMode: DependencyModeSuppress})
+		st.suppressor.SetDependencies(newDeps())
+
+		st.notify(true, 0)
+		connected["router"] = true
+		st.notify(false, time.Minute)
+		assert.Empty(t, st.next.subjects)
+
+		st.notify(true, time.Minute)
+		assert.Equal(t, []string{"firing"}, st.next.subjects)
+	})
+
+	t.Run("tag", func(t *testing.T) {
+		connected["router"] = false
+		st := newSuppressorTest(t, Config{DependencyMode: DependencyModeTag})
+		st.suppressor.SetDependencies(newDeps())
+
+		st.notify(true, 0)
+		assert.Equal(t, []string{"[symptom of router] firing"}, st.next.subjects)
+	})
+
+	t.Run("off", func(t *testing.T) {
+		connected["router"] = false
+		st := newSuppressorTest(t, Config{DependencyMode: DependencyModeOff})
+		st.suppressor.SetDependencies(newDeps())
+
+		st.notify(true, 0)
+		assert.Equal(t, []string{"firing"}, st.next.subjects)
+	})
+}
@@ -0,0 +1,183 @@
+package alerts
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+)
+
+// Statuses of external alerts
+const (
+	WebhookStatusFiring   = "firing"
+	WebhookStatusResolved = "resolved"
+)
+
+// DefaultWebhookClientLabels are the labels of external alerts looked up to find the client of an alert
+var DefaultWebhookClientLabels = []string{"rport_client_id", "client_id", "instance", "hostname", "host"}
+
+// ClientMatcher returns the id of the client identified by the value of a label, an empty string if there is none.
+type ClientMatcher func(value string) (clientID string)
+
+// WebhookPayload is the payload of the webhook receiver of the Prometheus Alertmanager, Grafana's webhook contact
+// point sends the same format.
+type WebhookPayload struct {
+	Version  string         `json:"version"`
+	Receiver string         `json:"receiver"`
+	Status   string         `json:"status"`
+	Alerts   []WebhookAlert `json:"alerts"`
+}
+
+type WebhookAlert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// WebhookResult summarizes the alerts received by the webhook.
+type WebhookResult struct {
+	Alerts   int `json:"alerts"`
+	Opened   int `json:"opened"`
+	Resolved int `json:"resolved"`
+	// Unmatched are the problems of alerts not matching any client
+	Unmatched []string `json:"unmatched"`
+}
+
+// WebhookReceiver ingests alerts of external systems into the problem history. The client of an alert is found by
+// the first of the configured labels matching a client.
+type WebhookReceiver struct {
+	history *ProblemHistory
+	labels  []string
+	match   ClientMatcher
+	now     func() time.Time
+}
+
+func NewWebhookReceiver(history *ProblemHistory, labels []string, match ClientMatcher) *WebhookReceiver {
+	if len(labels) == 0 {
+		labels = DefaultWebhookClientLabels
+	}
+	return &WebhookReceiver{
+		history: history,
+		labels:  labels,
+		match:   match,
+		now:     time.Now,
+	}
+}
+
+func (r *WebhookReceiver) Receive(ctx context.Context, payload WebhookPayload) (*WebhookResult, error) {
+	for _, alert := range payload.Alerts {
+		if alert.Status != WebhookStatusFiring && alert.Status != WebhookStatusResolved {
+			return nil, errors2.APIError{
+				Message:    fmt.Sprintf("invalid alert status %q, expected %q or %q", alert.Status, WebhookStatusFiring, WebhookStatusResolved),
+				HTTPStatus: http.StatusBadRequest,
+			}
+		}
+	}
+
+	result := &WebhookResult{Alerts: len(payload.Alerts), Unmatched: []string{}}
+	for _, alert := range payload.Alerts {
+		record := r.record(alert)
+		if record.ClientID == "" {
+			result.Unmatched = append(result.Unmatched, record.ProblemID)
+		}
+		opened, err := r.history.Open(ctx, record)
+		if err != nil {
+			return nil, err
+		}
+		if opened {
+			result.Opened++
+		}
+		if alert.Status != WebhookStatusResolved {
+			continue
+		}
+		resolvedAt := alert.EndsAt
+		if resolvedAt.IsZero() || resolvedAt.After(r.now()) {
+			resolvedAt = r.now()
+		}
+		resolved, err := r.history.Record(ctx, ProblemEvent{
+			ProblemID: record.ProblemID,
+			Event:     ProblemEventResolved,
+			Timestamp: resolvedAt,
+			Details:   alert.GeneratorURL,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if resolved {
+			result.Resolved++
+		}
+	}
+	return result, nil
+}
+
+func (r *WebhookReceiver) record(alert WebhookAlert) ProblemRecord {
+	ruleID := alert.Labels["alertname"]
+	if ruleID == "" {
+		ruleID = "external"
+	}
+	openedAt := alert.StartsAt
+	if openedAt.IsZero() {
+		openedAt = r.now()
+	}
+	summary := alert.Annotations["summary"]
+	if summary == "" {
+		summary = alert.Annotations["description"]
+	}
+	return ProblemRecord{
+		ProblemID: externalProblemID(alert, openedAt),
+		Source:    ProblemSourceExternal,
+		RuleID:    ruleID,
+		ClientID:  r.clientID(alert.Labels),
+		Summary:   summary,
+		OpenedAt:  openedAt.UTC(),
+	}
+}
+
+func (r *WebhookReceiver) clientID(labels map[string]string) string {
+	for _, label := range r.labels {
+		value := labels[label]
+		if value == "" {
+			continue
+		}
+		if clientID := r.match(value); clientID != "" {
+			return clientID
+		}
+		// e.g. the instance label of Prometheus targets is host:port
+		if host, _, err := net.SplitHostPort(value); err == nil {
+			if clientID := r.match(host); clientID != "" {
+				return clientID
+			}
+		}
+	}
+	return ""
+}
+
+// externalProblemID identifies an occurrence of an external alert. The alert is identified by its fingerprint,
+// falling back to a hash of its labels, an occurrence by the time it started firing.
+func externalProblemID(alert WebhookAlert, startsAt time.Time) string {
+	fingerprint := alert.Fingerprint
+	if fingerprint == "" {
+		keys := make([]string, 0, len(alert.Labels))
+		for k := range alert.Labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		h := sha256.New()
+		for _, k := range keys {
+			fmt.Fprintf(h, "%s=%s\n", k, alert.Labels[k])
+		}
+		fingerprint = hex.EncodeToString(h.Sum(nil))[:16]
+	}
+	return fmt.Sprintf("ext-%s-%d", strings.ToLower(fingerprint), startsAt.Unix())
+}
@@ -0,0 +1,94 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/share/query"
+)
+
+const alertmanagerPayload = `{
+  "version": "4",
+  "groupKey": "{}:{alertname=\"DiskFull\"}",
+  "status": "{{status}}",
+  "receiver": "rport",
+  "groupLabels": {"alertname": "DiskFull"},
+  "alerts": [
+    {
+      "status": "{{status}}",
+      "labels": {"alertname": "DiskFull", "instance": "10.0.0.5:9100", "severity": "critical"},
+      "annotations": {"summary": "Disk / is 95% full"},
+      "startsAt": "2026-01-01T12:00:00Z",
+      "endsAt": "{{endsAt}}",
+      "generatorURL": "http://prometheus:9090/graph",
+      "fingerprint": "A1B2C3"
+    },
+    {
+      "status": "firing",
+      "labels": {"alertname": "Unknown", "instance": "unknown:9100"},
+      "annotations": {},
+      "startsAt": "2026-01-01T12:00:00Z",
+      "endsAt": "0001-01-01T00:00:00Z"
+    }
+  ]
+}`
+
+func parseWebhookPayload(t *testing.T, status, endsAt string) WebhookPayload {
+	var payload WebhookPayload
+	require.NoError(t, json.Unmarshal([]byte(strings.NewReplacer("{{status}}", status, "{{endsAt}}", endsAt).Replace(alertmanagerPayload)), &payload))
+	return payload
+}
+
+func TestWebhookReceiver(t *testing.T) {
+	h := newTestProblemHistory(t)
+	ctx := context.Background()
+	clients := map[string]string{"10.0.0.5": "client-1", "router": "client-2"}
+	r := NewWebhookReceiver(h, nil, func(value string) string { return clients[value] })
+	r.now = func() time.Time { return time.Date(2026, 1, 1, 13, 0, 0, 0, time.UTC) }
+
+	result, err := r.Receive(ctx, parseWebhookPayload(t, "firing", "0001-01-01T00:00:00Z"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Alerts)
+	assert.Equal(t, 2, result.Opened)
+	assert.Len(t, result.Unmatched, 1)
+
+	// repeated notification of the same alerts
+	result, err = r.Receive(ctx, parseWebhookPayload(t, "firing", "0001-01-01T00:00:00Z"))
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Opened)
+
+	result, err = r.Receive(ctx, parseWebhookPayload(t, "resolved", "2026-01-01T12:30:00Z"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Resolved)
+
+	problems, err := h.List(ctx, &query.ListOptions{Filters: []query.FilterOption{{Column: []string{"client_id"}, Values: []string{"client-1"}}}})
+	require.NoError(t, err)
+	require.Len(t, problems, 1)
+	p := problems[0]
+	assert.Equal(t, "ext-a1b2c3-1767268800", p.ProblemID)
+	assert.Equal(t, ProblemSourceExternal, p.Source)
+	assert.Equal(t, "DiskFull", p.RuleID)
+	assert.Equal(t, "Disk / is 95% full", p.Summary)
+	assert.Equal(t, ProblemStateResolved, p.State)
+	assert.Equal(t, time.Date(2026, 1, 1, 12, 30, 0, 0, time.UTC), p.ResolvedAt.UTC())
+
+	_, err = r.Receive(ctx, WebhookPayload{Alerts: []WebhookAlert{{Status: "pending"}}})
+	assert.EqualError(t, err, `invalid alert status "pending", expected "firing" or "resolved"`)
+}
+
+func TestExternalProblemIDWithoutFingerprint(t *testing.T) {
+	startsAt := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	a := WebhookAlert{Labels: map[string]string{"alertname": "A", "instance": "x"}}
+	b := WebhookAlert{Labels: map[string]string{"instance": "x", "alertname": "A"}}
+	c := WebhookAlert{Labels: map[string]string{"alertname": "A", "instance": "y"}}
+
+	assert.Equal(t, externalProblemID(a, startsAt), externalProblemID(b, startsAt))
+	assert.NotEqual(t, externalProblemID(a, startsAt), externalProblemID(c, startsAt))
+	assert.NotEqual(t, externalProblemID(a, startsAt), externalProblemID(a, startsAt.Add(time.Hour)))
+}
@@ -0,0 +1,117 @@
+package authorization
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type BrokerGrantStatus string
+
+const (
+	BrokerGrantActive  BrokerGrantStatus = "active"
+	BrokerGrantRevoked BrokerGrantStatus = "revoked"
+	BrokerGrantExpired BrokerGrantStatus = "expired"
+)
+
+// BrokerGrant is the time-boxed access of a technician to a single client, granted by an administrator. The access is
+// an API token of the administrator restricted to the client, it's deleted when the grant is revoked or expires. The
+// grants are kept for review.
+type BrokerGrant struct {
+	ID         string `json:"id" db:"id"`
+	ClientID   string `json:"client_id" db:"client_id"`
+	Technician string `json:"technician" db:"technician"`
+	Reason     string `json:"reason" db:"reason"`
+	// Username is the administrator who granted the access and owns the token
+	Username    string            `json:"username" db:"username"`
+	TokenPrefix string            `json:"token_prefix" db:"token_prefix"`
+	CreatedAt   time.Time         `json:"created_at" db:"created_at"`
+	ExpiresAt   time.Time         `json:"expires_at" db:"expires_at"`
+	Status      BrokerGrantStatus `json:"status" db:"status"`
+	RevokedAt   *time.Time        `json:"revoked_at" db:"revoked_at"`
+	RevokedBy   string            `json:"revoked_by" db:"revoked_by"`
+	LastUsedAt  *time.Time        `json:"last_used_at" db:"last_used_at"`
+	Uses        int               `json:"uses" db:"uses"`
+}
+
+type BrokerGrantProvider struct {
+	db *sqlx.DB
+}
+
+func NewBrokerGrantProvider(db *sqlx.DB) *BrokerGrantProvider {
+	return &BrokerGrantProvider{
+		db: db,
+	}
+}
+
+// List returns the grants, the latest first.
+func (p *BrokerGrantProvider) List(ctx context.Context) ([]*BrokerGrant, error) {
+	result := []*BrokerGrant{}
+	err := p.db.SelectContext(ctx, &result, "SELECT * FROM broker_grants ORDER BY created_at DESC")
+	if err != nil {
+		return nil, fmt.Errorf("unable to get broker grants from DB: %w", err)
+	}
+	return result, nil
+}
+
+func (p *BrokerGrantProvider) Get(ctx context.Context, id string) (*BrokerGrant, error) {
+	res := &BrokerGrant{}
+	err := p.db.GetContext(ctx, res, "SELECT * FROM broker_grants WHERE id = ?", id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unable to get broker grant from DB: %w", err)
+	}
+	return res, nil
+}
+
+// ListExpired returns the active grants that expired at the given time.
+func (p *BrokerGrantProvider) ListExpired(ctx context.Context, now time.Time) ([]*BrokerGrant, error) {
+	result := []*BrokerGrant{}
+	err := p.db.SelectContext(ctx, &result, "SELECT * FROM broker_grants WHERE status = ? AND expires_at <= ?", BrokerGrantActive, now.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("unable to get expired broker grants from DB: %w", err)
+	}
+	return result, nil
+}
+
+func (p *BrokerGrantProvider) Create(ctx context.Context, g *BrokerGrant) error {
+	_, err := p.db.NamedExecContext(
+		ctx,
+		`INSERT INTO broker_grants (id, client_id, technician, reason, username, token_prefix, created_at, expires_at, status, revoked_by, uses)
+			VALUES (:id, :client_id, :technician, :reason, :username, :token_prefix, :created_at, :expires_at, :status, :revoked_by, :uses)`,
+		g,
+	)
+	return err
+}
+
+// Finish sets the final status of an active grant. It returns false if the grant isn't active anymore.
+func (p *BrokerGrantProvider) Finish(ctx context.Context, id string, status BrokerGrantStatus, by string, at time.Time) (bool, error) {
+	res, err := p.db.ExecContext(
+		ctx,
+		"UPDATE broker_grants SET status = ?, revoked_at = ?, revoked_by = ? WHERE id = ? AND status = ?",
+		status, at.UTC(), by, id, BrokerGrantActive,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// RecordUse counts a request authenticated by the token of a grant.
+func (p *BrokerGrantProvider) RecordUse(ctx context.Context, username, tokenPrefix string, at time.Time) error {
+	_, err := p.db.ExecContext(
+		ctx,
+		"UPDATE broker_grants SET uses = uses + 1, last_used_at = ? WHERE username = ? AND token_prefix = ? AND status = ?",
+		at.UTC(), username, tokenPrefix, BrokerGrantActive,
+	)
+	return err
+}
@@ -0,0 +1,83 @@
+package authorization
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/db/migration/api_token"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	"github.com/IOTech17/neo-rport/share/types"
+)
+
+func TestBrokerGrantProvider(t *testing.T) {
+	db, err := sqlite.New(":memory:", api_token.AssetNames(), api_token.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+	tokens := NewSqliteProvider(db)
+	p := NewBrokerGrantProvider(db)
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	grant := &BrokerGrant{
+		ID:          "grant-1",
+		ClientID:    "client-1",
+		Technician:  "jane@field-service.example.com",
+		Reason:      "replace the router",
+		Username:    "admin",
+		TokenPrefix: "broker01",
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(time.Hour),
+		Status:      BrokerGrantActive,
+	}
+	require.NoError(t, p.Create(ctx, grant))
+	require.NoError(t, tokens.Save(ctx, &APIToken{
+		Username: "admin",
+		Prefix:   "broker01",
+		Name:     "broker",
+		Scope:    APITokenReadWrite,
+		Token:    "hash",
+		Clients:  &types.StringSlice{"client-1"},
+	}))
+
+	token, err := tokens.Get(ctx, "admin", "broker01")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"client-1"}, token.GetAllowedClients())
+	assert.True(t, token.IsNarrowed())
+
+	require.NoError(t, p.RecordUse(ctx, "admin", "broker01", now.Add(time.Minute)))
+	require.NoError(t, p.RecordUse(ctx, "admin", "broker01", now.Add(2*time.Minute)))
+
+	expired, err := p.ListExpired(ctx, now.Add(30*time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, expired)
+	expired, err = p.ListExpired(ctx, now.Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, expired, 1)
+	assert.Equal(t, 2, expired[0].Uses)
+	assert.Equal(t, now.Add(2*time.Minute), expired[0].LastUsedAt.UTC())
+
+	ok, err := p.Finish(ctx, "grant-1", BrokerGrantExpired, "", now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = p.Finish(ctx, "grant-1", BrokerGrantRevoked, "admin", now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// uses after the end are not counted
+	require.NoError(t, p.RecordUse(ctx, "admin", "broker01", now.Add(2*time.Hour)))
+
+	grants, err := p.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, grants, 1)
+	assert.Equal(t, BrokerGrantExpired, grants[0].Status)
+	assert.Equal(t, 2, grants[0].Uses)
+	assert.Equal(t, now.Add(time.Hour), grants[0].RevokedAt.UTC())
+
+	missing, err := p.Get(ctx, "unknown")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
@@ -0,0 +1,18 @@
+package authorization
+
+import "context"
+
+type tokenCtxKeyType string
+
+const tokenCtxKey tokenCtxKeyType = "api-token"
+
+// WithToken returns a copy of a given context that contains the API token the request is authenticated with.
+func WithToken(ctx context.Context, token *APIToken) context.Context {
+	return context.WithValue(ctx, tokenCtxKey, token)
+}
+
+// TokenFromContext returns the API token the request is authenticated with, nil if it's not authenticated by a token.
+func TokenFromContext(ctx context.Context) *APIToken {
+	token, _ := ctx.Value(tokenCtxKey).(*APIToken)
+	return token
+}
@@ -2,9 +2,11 @@ package authorization
 
 import (
 	"errors"
-
+	"fmt"
 	"strings"
 	"time"
+
+	"github.com/IOTech17/neo-rport/share/types"
 )
 
 type APIToken struct {
@@ -15,6 +17,99 @@ type APIToken struct {
 	ExpiresAt *time.Time    `json:"expires_at,omitempty" db:"expires_at"`
 	Scope     APITokenScope `json:"scope,omitempty" db:"scope"`
 	Token     string        `json:"token,omitempty" db:"token"`
+	// ParentPrefix is set for tokens derived from another token of the user, they are deleted along with the parent.
+	ParentPrefix string `json:"parent_prefix,omitempty" db:"parent_prefix"`
+	// Permissions, ClientGroups and Clients narrow down the token, nil means no restriction.
+	Permissions  *types.StringSlice `json:"permissions,omitempty" db:"permissions"`
+	ClientGroups *types.StringSlice `json:"client_groups,omitempty" db:"client_groups"`
+	Clients      *types.StringSlice `json:"clients,omitempty" db:"clients"`
+}
+
+// AllowsPermission returns true if the token isn't restricted to some permissions or the given one is among them.
+func (t *APIToken) AllowsPermission(permission string) bool {
+	if t == nil || t.Permissions == nil {
+		return true
+	}
+	return contains(*t.Permissions, permission)
+}
+
+// IsNarrowed returns true if the token is restricted to some permissions, client groups or clients.
+func (t *APIToken) IsNarrowed() bool {
+	return t != nil && (t.Permissions != nil || t.ClientGroups != nil || t.Clients != nil)
+}
+
+// IsClientRestricted returns true if the token is restricted to some client groups or clients.
+func (t *APIToken) IsClientRestricted() bool {
+	return t != nil && (t.ClientGroups != nil || t.Clients != nil)
+}
+
+// GetAllowedClientGroups returns the ids of the client groups the token is restricted to, nil means all clients.
+func (t *APIToken) GetAllowedClientGroups() []string {
+	if t == nil || t.ClientGroups == nil {
+		return nil
+	}
+	return *t.ClientGroups
+}
+
+// GetAllowedClients returns the ids of the clients the token is restricted to, nil means all clients.
+func (t *APIToken) GetAllowedClients() []string {
+	if t == nil || t.Clients == nil {
+		return nil
+	}
+	return *t.Clients
+}
+
+// ValidateDerived returns an error if the token grants more than the parent token it's derived from.
+func (t *APIToken) ValidateDerived(parent *APIToken) error {
+	if t.Scope != parent.Scope && parent.Scope != APITokenReadWrite {
+		return fmt.Errorf("scope %q is not allowed for a token derived from a token with scope %q", t.Scope, parent.Scope)
+	}
+	if t.Scope == APITokenClientsAuth && parent.Scope != APITokenClientsAuth {
+		return fmt.Errorf("scope %q is not allowed for a derived token", t.Scope)
+	}
+	if parent.Permissions != nil {
+		if t.Permissions == nil {
+			return errors.New("permissions must be a subset of the permissions of the parent token")
+		}
+		for _, p := range *t.Permissions {
+			if !contains(*parent.Permissions, p) {
+				return fmt.Errorf("permission %q is not granted by the parent token", p)
+			}
+		}
+	}
+	if parent.ClientGroups != nil {
+		if t.ClientGroups == nil {
+			return errors.New("client groups must be a subset of the client groups of the parent token")
+		}
+		for _, g := range *t.ClientGroups {
+			if !contains(*parent.ClientGroups, g) {
+				return fmt.Errorf("client group %q is not granted by the parent token", g)
+			}
+		}
+	}
+	if parent.Clients != nil {
+		if t.Clients == nil {
+			return errors.New("clients must be a subset of the clients of the parent token")
+		}
+		for _, id := range *t.Clients {
+			if !contains(*parent.Clients, id) {
+				return fmt.Errorf("client %q is not granted by the parent token", id)
+			}
+		}
+	}
+	if parent.ExpiresAt != nil && (t.ExpiresAt == nil || t.ExpiresAt.After(*parent.ExpiresAt)) {
+		return fmt.Errorf("a derived token must expire not later than its parent token at %s", parent.ExpiresAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
 }
 
 const APITokenPrefixLength = 8
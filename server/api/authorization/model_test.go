@@ -0,0 +1,126 @@
+package authorization
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/IOTech17/neo-rport/share/ptr"
+	"github.com/IOTech17/neo-rport/share/types"
+)
+
+func TestValidateDerived(t *testing.T) {
+	expiresAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	parent := &APIToken{
+		Scope:        APITokenReadWrite,
+		ExpiresAt:    &expiresAt,
+		Permissions:  &types.StringSlice{"tunnels", "commands"},
+		ClientGroups: &types.StringSlice{"kiosk", "office"},
+	}
+
+	testCases := []struct {
+		name        string
+		token       *APIToken
+		expectedErr string
+	}{
+		{
+			name: "narrowed",
+			token: &APIToken{
+				Scope:        APITokenReadWrite,
+				ExpiresAt:    ptr.Time(expiresAt.Add(-time.Hour)),
+				Permissions:  &types.StringSlice{"tunnels"},
+				ClientGroups: &types.StringSlice{"kiosk"},
+			},
+		},
+		{
+			name: "read only",
+			token: &APIToken{
+				Scope:        APITokenRead,
+				ExpiresAt:    &expiresAt,
+				Permissions:  &types.StringSlice{},
+				ClientGroups: &types.StringSlice{"office"},
+			},
+		},
+		{
+			name: "clients auth",
+			token: &APIToken{
+				Scope:        APITokenClientsAuth,
+				ExpiresAt:    &expiresAt,
+				Permissions:  &types.StringSlice{"tunnels"},
+				ClientGroups: &types.StringSlice{"kiosk"},
+			},
+			expectedErr: `scope "clients-auth" is not allowed for a derived token`,
+		},
+		{
+			name: "more permissions",
+			token: &APIToken{
+				Scope:        APITokenReadWrite,
+				ExpiresAt:    &expiresAt,
+				Permissions:  &types.StringSlice{"tunnels", "vault"},
+				ClientGroups: &types.StringSlice{"kiosk"},
+			},
+			expectedErr: `permission "vault" is not granted by the parent token`,
+		},
+		{
+			name: "all permissions",
+			token: &APIToken{
+				Scope:        APITokenReadWrite,
+				ExpiresAt:    &expiresAt,
+				ClientGroups: &types.StringSlice{"kiosk"},
+			},
+			expectedErr: "permissions must be a subset of the permissions of the parent token",
+		},
+		{
+			name: "other client group",
+			token: &APIToken{
+				Scope:        APITokenReadWrite,
+				ExpiresAt:    &expiresAt,
+				Permissions:  &types.StringSlice{"tunnels"},
+				ClientGroups: &types.StringSlice{"servers"},
+			},
+			expectedErr: `client group "servers" is not granted by the parent token`,
+		},
+		{
+			name: "expires later",
+			token: &APIToken{
+				Scope:        APITokenReadWrite,
+				ExpiresAt:    ptr.Time(expiresAt.Add(time.Hour)),
+				Permissions:  &types.StringSlice{"tunnels"},
+				ClientGroups: &types.StringSlice{"kiosk"},
+			},
+			expectedErr: "a derived token must expire not later than its parent token at 2030-01-01T00:00:00Z",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.token.ValidateDerived(parent)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	brokerParent := &APIToken{Scope: APITokenReadWrite, Clients: &types.StringSlice{"client-1"}}
+	assert.NoError(t, (&APIToken{Scope: APITokenRead, Clients: &types.StringSlice{"client-1"}}).ValidateDerived(brokerParent))
+	assert.EqualError(t, (&APIToken{Scope: APITokenRead}).ValidateDerived(brokerParent), "clients must be a subset of the clients of the parent token")
+	assert.EqualError(t, (&APIToken{Scope: APITokenRead, Clients: &types.StringSlice{"client-2"}}).ValidateDerived(brokerParent), `client "client-2" is not granted by the parent token`)
+
+	readOnlyParent := &APIToken{Scope: APITokenRead}
+	err := (&APIToken{Scope: APITokenReadWrite}).ValidateDerived(readOnlyParent)
+	assert.EqualError(t, err, `scope "read+write" is not allowed for a token derived from a token with scope "read"`)
+}
+
+func TestAllowsPermission(t *testing.T) {
+	var noToken *APIToken
+	assert.True(t, noToken.AllowsPermission("tunnels"))
+	assert.True(t, (&APIToken{}).AllowsPermission("tunnels"))
+
+	token := &APIToken{Permissions: &types.StringSlice{"tunnels"}}
+	assert.True(t, token.AllowsPermission("tunnels"))
+	assert.False(t, token.AllowsPermission("commands"))
+	assert.True(t, token.IsNarrowed())
+	assert.Nil(t, token.GetAllowedClientGroups())
+}
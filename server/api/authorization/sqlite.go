@@ -72,10 +72,10 @@ func (p *SqliteProvider) GetByName(ctx context.Context, username, name string) (
 func (p *SqliteProvider) Save(ctx context.Context, tokenLine *APIToken) (err error) {
 	res, err := p.db.NamedExecContext(
 		ctx,
-		`INSERT INTO api_tokens (username, prefix, name, created_at, expires_at, scope, token)
+		`INSERT INTO api_tokens (username, prefix, name, created_at, expires_at, scope, token, parent_prefix, permissions, client_groups, clients)
 			      VALUES (:username, :prefix, :name, 
 					CASE WHEN :created_at IS NOT NULL THEN :created_at ELSE CURRENT_TIMESTAMP END,
-					:expires_at, :scope, :token)
+					:expires_at, :scope, :token, :parent_prefix, :permissions, :client_groups, :clients)
 			 	ON CONFLICT(username, prefix) DO UPDATE SET
 				 expires_at=CASE WHEN :expires_at IS NOT NULL THEN EXCLUDED.expires_at ELSE api_tokens.expires_at END,
 				 name=CASE WHEN :name != "" THEN EXCLUDED.name ELSE api_tokens.name END
@@ -98,12 +98,20 @@ func (p *SqliteProvider) Save(ctx context.Context, tokenLine *APIToken) (err err
 	return nil
 }
 
+// Delete deletes the token and all tokens derived from it.
 func (p *SqliteProvider) Delete(ctx context.Context, username, prefix string) error {
 	res, err := p.db.ExecContext(
 		ctx,
-		"DELETE FROM api_tokens WHERE username = ? AND prefix = ?",
+		`WITH RECURSIVE derived(prefix) AS (
+			SELECT prefix FROM api_tokens WHERE username = ? AND prefix = ?
+			UNION
+			SELECT t.prefix FROM api_tokens t JOIN derived d ON t.parent_prefix = d.prefix WHERE t.username = ?
+		)
+		DELETE FROM api_tokens WHERE username = ? AND prefix IN (SELECT prefix FROM derived)`,
 		username,
 		prefix,
+		username,
+		username,
 	)
 	if err != nil {
 		return err
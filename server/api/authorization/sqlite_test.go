@@ -14,6 +14,7 @@ import (
 	"github.com/IOTech17/neo-rport/db/sqlite"
 	"github.com/IOTech17/neo-rport/share/ptr"
 	"github.com/IOTech17/neo-rport/share/test"
+	"github.com/IOTech17/neo-rport/share/types"
 )
 
 var DataSourceOptions = sqlite.DataSourceOptions{WALEnabled: false}
@@ -275,12 +276,66 @@ func TestDelete(t *testing.T) {
 			"expires_at": *demoData[0].ExpiresAt,
 			"scope":      "read", // needed to avoid test fail using itemToSave.Scope which is of type enum
 			"token":      demoData[0].Token,
+			// columns of derived tokens
+			"parent_prefix": "",
+			"permissions":   nil,
+			"client_groups": nil,
+			// column of broker tokens
+			"clients": nil,
 		},
 	}
 	q := "SELECT * FROM `api_tokens`"
 	test.AssertRowsEqual(t, dbProv.db, expectedRows, q, []interface{}{})
 }
 
+func TestDeleteDerived(t *testing.T) {
+	db, err := sqlite.New(":memory:", api_token.AssetNames(), api_token.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	dbProv := NewSqliteProvider(db)
+	defer dbProv.Close()
+
+	ctx := context.Background()
+
+	err = addDemoData(dbProv.db)
+	require.NoError(t, err)
+
+	child := &APIToken{
+		Username:     "username4",
+		Prefix:       "child001",
+		Name:         "derived",
+		Scope:        APITokenReadWrite,
+		Token:        "childtoken",
+		ParentPrefix: "prefix4",
+		Permissions:  &types.StringSlice{"tunnels"},
+		ClientGroups: &types.StringSlice{"kiosk"},
+	}
+	grandchild := &APIToken{
+		Username:     "username4",
+		Prefix:       "child002",
+		Name:         "derived from derived",
+		Scope:        APITokenRead,
+		Token:        "grandchildtoken",
+		ParentPrefix: "child001",
+	}
+	require.NoError(t, dbProv.Save(ctx, child))
+	require.NoError(t, dbProv.Save(ctx, grandchild))
+
+	val, err := dbProv.Get(ctx, "username4", "child001")
+	require.NoError(t, err)
+	require.NotNil(t, val)
+	assert.Equal(t, "prefix4", val.ParentPrefix)
+	assert.Equal(t, &types.StringSlice{"tunnels"}, val.Permissions)
+	assert.Equal(t, &types.StringSlice{"kiosk"}, val.ClientGroups)
+
+	err = dbProv.Delete(ctx, "username4", "prefix4")
+	require.NoError(t, err)
+
+	result, err := dbProv.GetAll(ctx, "username4")
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	assert.Equal(t, demoData[4], *result[0])
+}
+
 func addDemoData(db *sqlx.DB) error {
 	for i := range demoData {
 		_, err := db.Exec(
@@ -11,6 +11,7 @@ import (
 	"github.com/IOTech17/neo-rport/share/types"
 
 	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/api/library"
 )
 
 var (
@@ -23,17 +24,21 @@ var (
 		"updated_at": true,
 		"cmd":        true,
 		"tags":       true,
+		"visibility": true,
 	}
 	supportedFields = map[string]map[string]bool{
 		"commands": {
-			"id":         true,
-			"name":       true,
-			"created_by": true,
-			"created_at": true,
-			"updated_by": true,
-			"updated_at": true,
-			"cmd":        true,
-			"tags":       true,
+			"id":            true,
+			"name":          true,
+			"created_by":    true,
+			"created_at":    true,
+			"updated_by":    true,
+			"updated_at":    true,
+			"cmd":           true,
+			"tags":          true,
+			"output_parser": true,
+			"visibility":    true,
+			"shared_groups": true,
 		},
 	}
 	manualFiltersConfig = map[string]bool{
@@ -59,7 +64,8 @@ func NewManager(db DbProvider) *Manager {
 	}
 }
 
-func (m *Manager) List(ctx context.Context, re *http.Request) ([]Command, int, error) {
+// List returns the commands the user has access to.
+func (m *Manager) List(ctx context.Context, re *http.Request, user library.User) ([]Command, int, error) {
 	listOptions := query.GetListOptions(re)
 
 	err := query.ValidateListOptions(listOptions, supportedSortAndFilters, supportedSortAndFilters, supportedFields, &query.PaginationConfig{
@@ -70,6 +76,11 @@ func (m *Manager) List(ctx context.Context, re *http.Request) ([]Command, int, e
 		return nil, 0, err
 	}
 
+	var accessFields []string
+	if !user.IsAdmin() {
+		listOptions.Fields, accessFields = library.WithAccessFields(listOptions.Fields, "commands")
+	}
+
 	manualFilters, dbFilters := query.SplitFilters(listOptions.Filters, manualFiltersConfig)
 	pagination := listOptions.Pagination
 
@@ -83,11 +94,15 @@ func (m *Manager) List(ctx context.Context, re *http.Request) ([]Command, int, e
 
 	filtered := make([]Command, 0, len(entries))
 	for _, entry := range entries {
+		if !entry.sharing().CanAccess(user) {
+			continue
+		}
 		matches, err := query.MatchesFilters(entry, manualFilters)
 		if err != nil {
 			return nil, 0, err
 		}
 		if matches {
+			entry.removeFields(accessFields)
 			filtered = append(filtered, entry)
 		}
 	}
@@ -99,7 +114,8 @@ func (m *Manager) List(ctx context.Context, re *http.Request) ([]Command, int, e
 	return limited, totalCount, nil
 }
 
-func (m *Manager) GetOne(ctx context.Context, re *http.Request, id string) (*Command, bool, error) {
+// GetOne returns the command if the user has access to it.
+func (m *Manager) GetOne(ctx context.Context, re *http.Request, id string, user library.User) (*Command, bool, error) {
 	retrieveOptions := query.GetRetrieveOptions(re)
 
 	err := query.ValidateRetrieveOptions(retrieveOptions, supportedFields)
@@ -107,6 +123,11 @@ func (m *Manager) GetOne(ctx context.Context, re *http.Request, id string) (*Com
 		return nil, false, err
 	}
 
+	var accessFields []string
+	if !user.IsAdmin() {
+		retrieveOptions.Fields, accessFields = library.WithAccessFields(retrieveOptions.Fields, "commands")
+	}
+
 	val, found, err := m.db.GetByID(ctx, id, retrieveOptions)
 	if err != nil {
 		return nil, false, err
@@ -115,6 +136,10 @@ func (m *Manager) GetOne(ctx context.Context, re *http.Request, id string) (*Com
 	if !found {
 		return nil, false, nil
 	}
+	if !val.sharing().CanAccess(user) {
+		return nil, false, library.ErrAccessDenied
+	}
+	val.removeFields(accessFields)
 
 	return val, true, nil
 }
@@ -148,15 +173,20 @@ func (m *Manager) Create(ctx context.Context, valueToStore *InputCommand, userna
 
 	now := time.Now()
 	commandToSave := &Command{
-		Name:      valueToStore.Name,
-		CreatedBy: username,
-		CreatedAt: &now,
-		UpdatedBy: username,
-		UpdatedAt: &now,
-		Cmd:       valueToStore.Cmd,
-		Tags:      (*types.StringSlice)(&valueToStore.Tags),
-		TimoutSec: &valueToStore.TimoutSec,
+		Name:         valueToStore.Name,
+		CreatedBy:    username,
+		CreatedAt:    &now,
+		UpdatedBy:    username,
+		UpdatedAt:    &now,
+		Cmd:          valueToStore.Cmd,
+		Tags:         (*types.StringSlice)(&valueToStore.Tags),
+		TimoutSec:    &valueToStore.TimoutSec,
+		OutputParser: valueToStore.OutputParser,
 	}
+	commandToSave.setSharing(library.Sharing{
+		Visibility:   valueToStore.Visibility,
+		SharedGroups: valueToStore.SharedGroups,
+	})
 	commandToSave.ID, err = m.db.Save(ctx, commandToSave)
 	if err != nil {
 		return nil, err
@@ -165,7 +195,7 @@ func (m *Manager) Create(ctx context.Context, valueToStore *InputCommand, userna
 	return commandToSave, nil
 }
 
-func (m *Manager) Update(ctx context.Context, existingID string, valueToStore *InputCommand, username string) (*Command, error) {
+func (m *Manager) Update(ctx context.Context, existingID string, valueToStore *InputCommand, user library.User) (*Command, error) {
 	err := Validate(valueToStore)
 	if err != nil {
 		return nil, err
@@ -182,6 +212,13 @@ func (m *Manager) Update(ctx context.Context, existingID string, valueToStore *I
 			HTTPStatus: http.StatusNotFound,
 		}
 	}
+	if !existing.sharing().CanAccess(user) {
+		return nil, library.ErrAccessDenied
+	}
+	sharing, err := library.UpdatedSharing(existing.sharing(), valueToStore.Visibility, valueToStore.SharedGroups, user)
+	if err != nil {
+		return nil, err
+	}
 
 	commandsWithSameName, err := m.db.List(ctx, &query.ListOptions{
 		Filters: []query.FilterOption{
@@ -207,16 +244,18 @@ func (m *Manager) Update(ctx context.Context, existingID string, valueToStore *I
 
 	now := time.Now()
 	commandToSave := &Command{
-		ID:        existingID,
-		Name:      valueToStore.Name,
-		CreatedBy: existing.CreatedBy,
-		CreatedAt: existing.CreatedAt,
-		UpdatedBy: username,
-		UpdatedAt: &now,
-		Cmd:       valueToStore.Cmd,
-		Tags:      (*types.StringSlice)(&valueToStore.Tags),
-		TimoutSec: &valueToStore.TimoutSec,
+		ID:           existingID,
+		Name:         valueToStore.Name,
+		CreatedBy:    existing.CreatedBy,
+		CreatedAt:    existing.CreatedAt,
+		UpdatedBy:    user.GetUsername(),
+		UpdatedAt:    &now,
+		Cmd:          valueToStore.Cmd,
+		Tags:         (*types.StringSlice)(&valueToStore.Tags),
+		TimoutSec:    &valueToStore.TimoutSec,
+		OutputParser: valueToStore.OutputParser,
 	}
+	commandToSave.setSharing(sharing)
 	_, err = m.db.Save(ctx, commandToSave)
 	if err != nil {
 		return nil, err
@@ -225,8 +264,8 @@ func (m *Manager) Update(ctx context.Context, existingID string, valueToStore *I
 	return commandToSave, nil
 }
 
-func (m *Manager) Delete(ctx context.Context, id string) error {
-	_, found, err := m.db.GetByID(ctx, id, &query.RetrieveOptions{})
+func (m *Manager) Delete(ctx context.Context, id string, user library.User) error {
+	existing, found, err := m.db.GetByID(ctx, id, &query.RetrieveOptions{})
 	if err != nil {
 		return errors2.APIError{
 			Err:        err,
@@ -240,6 +279,9 @@ func (m *Manager) Delete(ctx context.Context, id string) error {
 			HTTPStatus: http.StatusNotFound,
 		}
 	}
+	if !existing.sharing().CanAccess(user) {
+		return library.ErrAccessDenied
+	}
 
 	err = m.db.Delete(ctx, id)
 	if err != nil {
@@ -13,9 +13,12 @@ import (
 	"github.com/stretchr/testify/require"
 
 	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/api/users"
 	"github.com/IOTech17/neo-rport/share/query"
 )
 
+var testAdmin = &users.User{Username: "someuser", Groups: []string{users.Administrators}}
+
 type DbProviderMock struct {
 	getByIDGiven         string
 	getByIDCommandToGive *Command
@@ -93,7 +96,7 @@ func TestManagerList(t *testing.T) {
 		URL: inputURL,
 	}
 
-	actualValues, count, err := mngr.List(context.Background(), req)
+	actualValues, count, err := mngr.List(context.Background(), req, testAdmin)
 	require.NoError(t, err)
 
 	assert.Equal(
@@ -132,7 +135,7 @@ func TestManagerList(t *testing.T) {
 	}
 	mngr = NewManager(dbProv)
 
-	_, _, err = mngr.List(context.Background(), req)
+	_, _, err = mngr.List(context.Background(), req, testAdmin)
 	require.EqualError(t, err, "list error")
 }
 
@@ -150,7 +153,7 @@ func TestListWithUnsupportedOptions(t *testing.T) {
 		URL: inputURL,
 	}
 
-	_, _, err = mngr.List(context.Background(), req)
+	_, _, err = mngr.List(context.Background(), req, testAdmin)
 	require.EqualError(t, err, `unsupported sort field 'unsupportedSortField', unsupported filter field 'filter[unsupportedFilter]', unsupported field "nope" for resource "commands"`)
 }
 
@@ -188,7 +191,7 @@ func TestGetOne(t *testing.T) {
 
 	mngr := NewManager(dbProv)
 
-	val, found, err := mngr.GetOne(context.Background(), req, "1")
+	val, found, err := mngr.GetOne(context.Background(), req, "1", testAdmin)
 	require.NoError(t, err)
 	assert.True(t, found)
 	assert.Equal(t, expectedValue, val)
@@ -199,7 +202,7 @@ func TestGetOne(t *testing.T) {
 
 	mngr = NewManager(dbProv)
 
-	_, found, err = mngr.GetOne(context.Background(), req, "1")
+	_, found, err = mngr.GetOne(context.Background(), req, "1", testAdmin)
 	require.NoError(t, err)
 	assert.False(t, found)
 
@@ -209,7 +212,7 @@ func TestGetOne(t *testing.T) {
 
 	mngr = NewManager(dbProv)
 
-	_, _, err = mngr.GetOne(context.Background(), req, "1")
+	_, _, err = mngr.GetOne(context.Background(), req, "1", testAdmin)
 	require.EqualError(t, err, "some get id error")
 }
 
@@ -264,7 +267,7 @@ func TestStore(t *testing.T) {
 		mngr := NewManager(dbProv)
 
 		inputValue.TimoutSec = timeoutSec
-		storedCommand, err := mngr.Update(context.Background(), idToUpdate, inputValue, "someuser")
+		storedCommand, err := mngr.Update(context.Background(), idToUpdate, inputValue, testAdmin)
 		require.NoError(t, err)
 
 		assert.Equal(t, idToUpdate, storedCommand.ID)
@@ -296,7 +299,7 @@ func TestStore(t *testing.T) {
 		}
 		mngr := NewManager(dbProv)
 
-		_, err := mngr.Update(context.Background(), "1", inputValue, "someuser")
+		_, err := mngr.Update(context.Background(), "1", inputValue, testAdmin)
 		require.EqualError(t, err, "another command with the same name 'some name' exists")
 	})
 
@@ -306,7 +309,7 @@ func TestStore(t *testing.T) {
 		}
 		mngr := NewManager(dbProv)
 
-		_, err := mngr.Update(context.Background(), "1", inputValue, "someuser")
+		_, err := mngr.Update(context.Background(), "1", inputValue, testAdmin)
 		require.EqualError(t, err, "cannot find entry by the provided ID")
 	})
 
@@ -331,7 +334,7 @@ func TestStore(t *testing.T) {
 		}
 		mngr := NewManager(dbProv)
 
-		_, err := mngr.Update(context.Background(), "1", inputValue, "someuser")
+		_, err := mngr.Update(context.Background(), "1", inputValue, testAdmin)
 		require.EqualError(t, err, "failed to find anything")
 	})
 
@@ -339,7 +342,7 @@ func TestStore(t *testing.T) {
 		dbProv := &DbProviderMock{}
 		mngr := NewManager(dbProv)
 
-		_, err := mngr.Update(context.Background(), "1", &InputCommand{}, "someuser")
+		_, err := mngr.Update(context.Background(), "1", &InputCommand{}, testAdmin)
 		require.EqualError(t, err, "name is required, cmd is required")
 	})
 
@@ -358,7 +361,7 @@ func TestStore(t *testing.T) {
 		}
 		mngr := NewManager(dbProv)
 
-		_, err := mngr.Update(context.Background(), "123", inputValue, "someuser")
+		_, err := mngr.Update(context.Background(), "123", inputValue, testAdmin)
 		require.EqualError(t, err, "failed to save")
 	})
 }
@@ -370,7 +373,7 @@ func TestDeleteCommand(t *testing.T) {
 		}
 		mngr := NewManager(dbProv)
 
-		err := mngr.Delete(context.Background(), "1")
+		err := mngr.Delete(context.Background(), "1", testAdmin)
 		require.NoError(t, err)
 
 		assert.Equal(t, "1", dbProv.deleteIDGiven)
@@ -383,7 +386,7 @@ func TestDeleteCommand(t *testing.T) {
 		}
 		mngr := NewManager(dbProv)
 
-		err := mngr.Delete(context.Background(), "1")
+		err := mngr.Delete(context.Background(), "1", testAdmin)
 		require.EqualError(t, err, "cannot delete")
 	})
 
@@ -393,7 +396,7 @@ func TestDeleteCommand(t *testing.T) {
 		}
 		mngr := NewManager(dbProv)
 
-		err := mngr.Delete(context.Background(), "1")
+		err := mngr.Delete(context.Background(), "1", testAdmin)
 		require.Equal(
 			t,
 			errors2.APIError{
@@ -411,7 +414,7 @@ func TestDeleteCommand(t *testing.T) {
 		}
 		mngr := NewManager(dbProv)
 
-		err := mngr.Delete(context.Background(), "1")
+		err := mngr.Delete(context.Background(), "1", testAdmin)
 		require.Equal(
 			t,
 			errors2.APIError{
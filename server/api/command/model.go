@@ -3,6 +3,8 @@ package command
 import (
 	"time"
 
+	"github.com/IOTech17/neo-rport/server/api/library"
+	"github.com/IOTech17/neo-rport/share/models"
 	"github.com/IOTech17/neo-rport/share/types"
 )
 
@@ -11,20 +13,67 @@ const DefaultTimeoutSec = 60
 // To support sparse fieldsets, the fields that can have zero value,
 // use pointers so they're omitted only when they're nil not when they're zero value
 type Command struct {
-	ID        string             `json:"id,omitempty" db:"id"`
-	Name      string             `json:"name,omitempty" db:"name"`
-	CreatedBy string             `json:"created_by,omitempty" db:"created_by"`
-	CreatedAt *time.Time         `json:"created_at,omitempty" db:"created_at"`
-	UpdatedBy string             `json:"updated_by,omitempty" db:"updated_by"`
-	UpdatedAt *time.Time         `json:"updated_at,omitempty" db:"updated_at"`
-	Cmd       string             `json:"cmd,omitempty" db:"cmd"`
-	Tags      *types.StringSlice `json:"tags,omitempty" db:"tags"`
-	TimoutSec *int               `json:"timeout_sec,omitempty" db:"timeout_sec"`
+	ID           string               `json:"id,omitempty" db:"id"`
+	Name         string               `json:"name,omitempty" db:"name"`
+	CreatedBy    string               `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt    *time.Time           `json:"created_at,omitempty" db:"created_at"`
+	UpdatedBy    string               `json:"updated_by,omitempty" db:"updated_by"`
+	UpdatedAt    *time.Time           `json:"updated_at,omitempty" db:"updated_at"`
+	Cmd          string               `json:"cmd,omitempty" db:"cmd"`
+	Tags         *types.StringSlice   `json:"tags,omitempty" db:"tags"`
+	TimoutSec    *int                 `json:"timeout_sec,omitempty" db:"timeout_sec"`
+	OutputParser *models.OutputParser `json:"output_parser,omitempty" db:"output_parser"`
+	Visibility   *string              `json:"visibility,omitempty" db:"visibility"`
+	SharedGroups *types.StringSlice   `json:"shared_groups,omitempty" db:"shared_groups"`
 }
 
 type InputCommand struct {
-	Name      string   `json:"name" db:"name"`
-	Cmd       string   `json:"cmd" db:"script"`
-	Tags      []string `json:"tags" db:"tags"`
-	TimoutSec int      `json:"timeout_sec" db:"timeout_sec"`
+	Name         string               `json:"name" db:"name"`
+	Cmd          string               `json:"cmd" db:"script"`
+	Tags         []string             `json:"tags" db:"tags"`
+	TimoutSec    int                  `json:"timeout_sec" db:"timeout_sec"`
+	OutputParser *models.OutputParser `json:"output_parser" db:"output_parser"`
+	Visibility   string               `json:"visibility" db:"visibility"`
+	SharedGroups []string             `json:"shared_groups" db:"shared_groups"`
+}
+
+func (c *Command) sharing() library.Sharing {
+	if c == nil {
+		return library.Sharing{}
+	}
+	sharing := library.Sharing{CreatedBy: c.CreatedBy}
+	if c.Visibility != nil {
+		sharing.Visibility = *c.Visibility
+	}
+	if c.SharedGroups != nil {
+		sharing.SharedGroups = *c.SharedGroups
+	}
+	return sharing
+}
+
+func (c *Command) setSharing(sharing library.Sharing) {
+	visibility := sharing.Visibility
+	if visibility == "" {
+		visibility = library.VisibilityGlobal
+	}
+	sharedGroups := types.StringSlice(sharing.SharedGroups)
+	if sharedGroups == nil {
+		sharedGroups = types.StringSlice{}
+	}
+	c.Visibility = &visibility
+	c.SharedGroups = &sharedGroups
+}
+
+// removeFields clears the fields that were only fetched to check the access
+func (c *Command) removeFields(fields []string) {
+	for _, field := range fields {
+		switch field {
+		case library.FieldCreatedBy:
+			c.CreatedBy = ""
+		case library.FieldVisibility:
+			c.Visibility = nil
+		case library.FieldSharedGroups:
+			c.SharedGroups = nil
+		}
+	}
 }
@@ -78,9 +78,9 @@ func (p *SqliteProvider) Save(ctx context.Context, s *Command) (string, error) {
 		_, err = p.db.NamedExecContext(
 			ctx,
 			"INSERT INTO `commands` "+
-				"(`id`, `name`, `created_at`, `created_by`, `updated_at`, `updated_by`, `cmd`, `tags`, `timeout_sec`)"+
+				"(`id`, `name`, `created_at`, `created_by`, `updated_at`, `updated_by`, `cmd`, `tags`, `timeout_sec`, `output_parser`, `visibility`, `shared_groups`)"+
 				" VALUES "+
-				"(:id, :name, :created_at, :created_by, :updated_at, :updated_by, :cmd, :tags, :timeout_sec)",
+				"(:id, :name, :created_at, :created_by, :updated_at, :updated_by, :cmd, :tags, :timeout_sec, :output_parser, :visibility, :shared_groups)",
 			s,
 		)
 
@@ -93,7 +93,10 @@ func (p *SqliteProvider) Save(ctx context.Context, s *Command) (string, error) {
 		"`updated_by` =  :updated_by, " +
 		"`cmd` = :cmd, " +
 		"`tags` = :tags, " +
-		"`timeout_sec` = :timeout_sec " +
+		"`timeout_sec` = :timeout_sec, " +
+		"`output_parser` = :output_parser, " +
+		"`visibility` = :visibility, " +
+		"`shared_groups` = :shared_groups " +
 		"WHERE id = :id"
 	_, err := p.db.NamedExecContext(ctx, q, s)
 
@@ -9,6 +9,7 @@ import (
 	"github.com/IOTech17/neo-rport/db/sqlite"
 	"github.com/IOTech17/neo-rport/share/ptr"
 	"github.com/IOTech17/neo-rport/share/query"
+	"github.com/IOTech17/neo-rport/share/types"
 
 	"github.com/jmoiron/sqlx"
 
@@ -21,26 +22,30 @@ import (
 var timeoutSec = DefaultTimeoutSec
 var demoData = []Command{
 	{
-		ID:        "1",
-		Name:      "some name",
-		CreatedBy: "user1",
-		CreatedAt: ptr.Time(time.Date(2001, 1, 1, 1, 0, 0, 0, time.UTC)),
-		UpdatedBy: "user2",
-		UpdatedAt: ptr.Time(time.Date(2003, 1, 1, 1, 0, 0, 0, time.UTC)),
-		Cmd:       "ls -la",
-		Tags:      ptr.StringSlice("tag1", "tag2"),
-		TimoutSec: &timeoutSec,
+		ID:           "1",
+		Name:         "some name",
+		CreatedBy:    "user1",
+		CreatedAt:    ptr.Time(time.Date(2001, 1, 1, 1, 0, 0, 0, time.UTC)),
+		UpdatedBy:    "user2",
+		UpdatedAt:    ptr.Time(time.Date(2003, 1, 1, 1, 0, 0, 0, time.UTC)),
+		Cmd:          "ls -la",
+		Tags:         ptr.StringSlice("tag1", "tag2"),
+		TimoutSec:    &timeoutSec,
+		Visibility:   ptr.String("global"),
+		SharedGroups: &types.StringSlice{},
 	},
 	{
-		ID:        "2",
-		Name:      "other name 2",
-		CreatedBy: "user1",
-		CreatedAt: ptr.Time(time.Date(2002, 1, 1, 1, 0, 0, 0, time.UTC)),
-		UpdatedBy: "user1",
-		UpdatedAt: ptr.Time(time.Date(2002, 1, 1, 2, 0, 0, 0, time.UTC)),
-		Cmd:       "pwd",
-		Tags:      ptr.StringSlice(),
-		TimoutSec: &timeoutSec,
+		ID:           "2",
+		Name:         "other name 2",
+		CreatedBy:    "user1",
+		CreatedAt:    ptr.Time(time.Date(2002, 1, 1, 1, 0, 0, 0, time.UTC)),
+		UpdatedBy:    "user1",
+		UpdatedAt:    ptr.Time(time.Date(2002, 1, 1, 2, 0, 0, 0, time.UTC)),
+		Cmd:          "pwd",
+		Tags:         ptr.StringSlice(),
+		TimoutSec:    &timeoutSec,
+		Visibility:   ptr.String("global"),
+		SharedGroups: &types.StringSlice{},
 	},
 }
 var DataSourceOptions = sqlite.DataSourceOptions{WALEnabled: false}
@@ -259,15 +264,18 @@ func TestUpdate(t *testing.T) {
 	assert.Equal(t, itemToSave.ID, id)
 	expectedRows := []map[string]interface{}{
 		{
-			"id":          "1",
-			"name":        itemToSave.Name,
-			"created_at":  *itemToSave.CreatedAt,
-			"created_by":  itemToSave.CreatedBy,
-			"updated_at":  *itemToSave.UpdatedAt,
-			"updated_by":  itemToSave.UpdatedBy,
-			"cmd":         itemToSave.Cmd,
-			"tags":        `["tag1","tag2"]`,
-			"timeout_sec": int64(timeoutSec),
+			"id":            "1",
+			"name":          itemToSave.Name,
+			"created_at":    *itemToSave.CreatedAt,
+			"created_by":    itemToSave.CreatedBy,
+			"updated_at":    *itemToSave.UpdatedAt,
+			"updated_by":    itemToSave.UpdatedBy,
+			"cmd":           itemToSave.Cmd,
+			"tags":          `["tag1","tag2"]`,
+			"timeout_sec":   int64(timeoutSec),
+			"output_parser": nil,
+			"visibility":    "global",
+			"shared_groups": "[]",
 		},
 	}
 	q := "SELECT * FROM `commands` where id = ?"
@@ -293,15 +301,18 @@ func TestDelete(t *testing.T) {
 
 	expectedRows := []map[string]interface{}{
 		{
-			"id":          "1",
-			"name":        demoData[0].Name,
-			"created_at":  *demoData[0].CreatedAt,
-			"created_by":  demoData[0].CreatedBy,
-			"updated_at":  *demoData[0].UpdatedAt,
-			"updated_by":  demoData[0].UpdatedBy,
-			"cmd":         demoData[0].Cmd,
-			"tags":        `["tag1","tag2"]`,
-			"timeout_sec": int64(timeoutSec),
+			"id":            "1",
+			"name":          demoData[0].Name,
+			"created_at":    *demoData[0].CreatedAt,
+			"created_by":    demoData[0].CreatedBy,
+			"updated_at":    *demoData[0].UpdatedAt,
+			"updated_by":    demoData[0].UpdatedBy,
+			"cmd":           demoData[0].Cmd,
+			"tags":          `["tag1","tag2"]`,
+			"timeout_sec":   int64(timeoutSec),
+			"output_parser": nil,
+			"visibility":    "global",
+			"shared_groups": "[]",
 		},
 	}
 	q := "SELECT * FROM `commands`"
@@ -1,9 +1,11 @@
 package command
 
 import (
+	"fmt"
 	"net/http"
 
 	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/api/library"
 )
 
 func Validate(iv *InputCommand) error {
@@ -22,6 +24,22 @@ func Validate(iv *InputCommand) error {
 		})
 	}
 
+	if iv.OutputParser != nil {
+		if err := iv.OutputParser.Validate(); err != nil {
+			errs = append(errs, errors2.APIError{
+				Message:    fmt.Sprintf("invalid output_parser: %v", err),
+				HTTPStatus: http.StatusBadRequest,
+			})
+		}
+	}
+
+	if err := library.ValidateSharing(iv.Visibility, iv.SharedGroups); err != nil {
+		errs = append(errs, errors2.APIError{
+			Message:    err.Error(),
+			HTTPStatus: http.StatusBadRequest,
+		})
+	}
+
 	if len(errs) == 0 {
 		return nil
 	}
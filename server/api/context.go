@@ -25,3 +25,38 @@ func GetUser(ctx context.Context, log *logger.Logger) string {
 	}
 	return user
 }
+
+const impersonatorCtxKey userCtxKeyType = "impersonator"
+
+// WithImpersonator returns a copy of a given context that contains the admin acting as the current user.
+func WithImpersonator(ctx context.Context, username string) context.Context {
+	return context.WithValue(ctx, impersonatorCtxKey, username)
+}
+
+// GetImpersonator returns the admin acting as the current user, empty if the user isn't impersonated.
+func GetImpersonator(ctx context.Context) string {
+	impersonator, _ := ctx.Value(impersonatorCtxKey).(string)
+	return impersonator
+}
+
+// API versions, the handlers are shared by all versions.
+const (
+	V1 = 1
+	V2 = 2
+)
+
+const versionCtxKey userCtxKeyType = "api_version"
+
+// WithVersion returns a copy of a given context that contains the API version of the request.
+func WithVersion(ctx context.Context, version int) context.Context {
+	return context.WithValue(ctx, versionCtxKey, version)
+}
+
+// GetVersion returns the API version of the request, V1 if the context doesn't contain it.
+func GetVersion(ctx context.Context) int {
+	version, ok := ctx.Value(versionCtxKey).(int)
+	if !ok {
+		return V1
+	}
+	return version
+}
@@ -0,0 +1,177 @@
+package jobs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+// maxDiffCells limits the size of the table used to diff two outputs, larger outputs are diffed as replaced entirely.
+const maxDiffCells = 4 * 1000 * 1000
+
+// OutputGroup is a set of clients that returned the same output.
+type OutputGroup struct {
+	Hash      string         `json:"hash"`
+	Count     int            `json:"count"`
+	StdOut    string         `json:"stdout"`
+	StdErr    string         `json:"stderr"`
+	Statuses  map[string]int `json:"statuses"`
+	ClientIDs []string       `json:"client_ids"`
+}
+
+// Aggregation summarizes the results of the jobs of a multi-client job.
+type Aggregation struct {
+	Total         int            `json:"total"`
+	UniqueOutputs int            `json:"unique_outputs"`
+	Statuses      map[string]int `json:"statuses"`
+	Outputs       []*OutputGroup `json:"outputs"`
+}
+
+// AggregateOptions control which outputs are considered identical.
+type AggregateOptions struct {
+	IgnoreWhitespace bool
+	IgnoreCase       bool
+}
+
+func (o AggregateOptions) normalize(output string) string {
+	if o.IgnoreCase {
+		output = strings.ToLower(output)
+	}
+	if o.IgnoreWhitespace {
+		output = strings.Join(strings.Fields(output), " ")
+	}
+	return output
+}
+
+// Aggregate clusters the jobs by their output, the groups are sorted by the number of clients, the largest first.
+func Aggregate(jobs []*models.Job, options AggregateOptions) *Aggregation {
+	result := &Aggregation{
+		Statuses: map[string]int{},
+		Outputs:  []*OutputGroup{},
+	}
+	byHash := map[string]*OutputGroup{}
+	for _, job := range jobs {
+		result.Total++
+		result.Statuses[job.Status]++
+
+		var stdout, stderr string
+		if job.Result != nil {
+			stdout, stderr = job.Result.StdOut, job.Result.StdErr
+		}
+		hash := OutputHash(options.normalize(stdout), options.normalize(stderr))
+		group, ok := byHash[hash]
+		if !ok {
+			group = &OutputGroup{
+				Hash:     hash,
+				StdOut:   stdout,
+				StdErr:   stderr,
+				Statuses: map[string]int{},
+			}
+			byHash[hash] = group
+			result.Outputs = append(result.Outputs, group)
+		}
+		group.Count++
+		group.Statuses[job.Status]++
+		group.ClientIDs = append(group.ClientIDs, job.ClientID)
+	}
+
+	for _, group := range result.Outputs {
+		sort.Strings(group.ClientIDs)
+	}
+	sort.SliceStable(result.Outputs, func(i, j int) bool {
+		return result.Outputs[i].Count > result.Outputs[j].Count
+	})
+	result.UniqueOutputs = len(result.Outputs)
+
+	return result
+}
+
+// OutputHash returns a short identifier of an output.
+func OutputHash(stdout, stderr string) string {
+	h := sha256.New()
+	h.Write([]byte(stdout))
+	h.Write([]byte{0})
+	h.Write([]byte(stderr))
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+const (
+	DiffEqual  = "="
+	DiffDelete = "-"
+	DiffInsert = "+"
+)
+
+// DiffLine is a line of a diff, Op is one of DiffEqual, DiffDelete for lines of the first output only and DiffInsert
+// for lines of the second output only.
+type DiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// Diff returns a line based diff of two outputs.
+func Diff(a, b string) []DiffLine {
+	linesA, linesB := splitLines(a), splitLines(b)
+	n, m := len(linesA), len(linesB)
+
+	result := make([]DiffLine, 0, n+m)
+	if n*m > maxDiffCells {
+		for _, l := range linesA {
+			result = append(result, DiffLine{Op: DiffDelete, Text: l})
+		}
+		for _, l := range linesB {
+			result = append(result, DiffLine{Op: DiffInsert, Text: l})
+		}
+		return result
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of linesA[i:] and linesB[j:]
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if linesA[i] == linesB[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case linesA[i] == linesB[j]:
+			result = append(result, DiffLine{Op: DiffEqual, Text: linesA[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			result = append(result, DiffLine{Op: DiffDelete, Text: linesA[i]})
+			i++
+		default:
+			result = append(result, DiffLine{Op: DiffInsert, Text: linesB[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		result = append(result, DiffLine{Op: DiffDelete, Text: linesA[i]})
+	}
+	for ; j < m; j++ {
+		result = append(result, DiffLine{Op: DiffInsert, Text: linesB[j]})
+	}
+	return result
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
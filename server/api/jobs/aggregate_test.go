@@ -0,0 +1,51 @@
+package jobs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/server/test/jb"
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+func TestAggregate(t *testing.T) {
+	resolvConf := &models.JobResult{StdOut: "nameserver 10.0.0.1\nsearch corp\n"}
+	list := []*models.Job{
+		jb.New(t).ClientID("c3").Status(models.JobStatusSuccessful).Result(resolvConf).Build(),
+		jb.New(t).ClientID("c1").Status(models.JobStatusSuccessful).Result(resolvConf).Build(),
+		jb.New(t).ClientID("c2").Status(models.JobStatusSuccessful).Result(&models.JobResult{StdOut: "nameserver  10.0.0.1\nsearch CORP\n"}).Build(),
+		jb.New(t).ClientID("c4").Status(models.JobStatusFailed).Result(&models.JobResult{StdErr: "permission denied\n"}).Build(),
+	}
+
+	result := Aggregate(list, AggregateOptions{})
+	assert.Equal(t, 4, result.Total)
+	assert.Equal(t, 3, result.UniqueOutputs)
+	assert.Equal(t, map[string]int{models.JobStatusSuccessful: 3, models.JobStatusFailed: 1}, result.Statuses)
+	require.Len(t, result.Outputs, 3)
+	assert.Equal(t, 2, result.Outputs[0].Count)
+	assert.Equal(t, []string{"c1", "c3"}, result.Outputs[0].ClientIDs)
+	assert.Equal(t, resolvConf.StdOut, result.Outputs[0].StdOut)
+	assert.Equal(t, OutputHash(resolvConf.StdOut, ""), result.Outputs[0].Hash)
+
+	result = Aggregate(list, AggregateOptions{IgnoreWhitespace: true, IgnoreCase: true})
+	assert.Equal(t, 2, result.UniqueOutputs)
+	assert.Equal(t, []string{"c1", "c2", "c3"}, result.Outputs[0].ClientIDs)
+	assert.Equal(t, map[string]int{models.JobStatusFailed: 1}, result.Outputs[1].Statuses)
+}
+
+func TestDiff(t *testing.T) {
+	a := "nameserver 10.0.0.1\nnameserver 10.0.0.2\nsearch corp\n"
+	b := "nameserver 10.0.0.1\nsearch corp\noptions ndots:2\n"
+
+	assert.Equal(t, []DiffLine{
+		{Op: DiffEqual, Text: "nameserver 10.0.0.1"},
+		{Op: DiffDelete, Text: "nameserver 10.0.0.2"},
+		{Op: DiffEqual, Text: "search corp"},
+		{Op: DiffInsert, Text: "options ndots:2"},
+	}, Diff(a, b))
+
+	assert.Empty(t, Diff("", ""))
+	assert.Equal(t, []DiffLine{{Op: DiffInsert, Text: "new"}}, Diff("", "new\n"))
+}
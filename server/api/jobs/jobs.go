@@ -72,9 +72,11 @@ var JobSupportedFields = map[string]map[string]bool{
 	"commands": jobFields,
 	"scripts":  jobFields,
 	"result": {
-		"stdout":  true,
-		"stderr":  true,
-		"summary": true,
+		"stdout":      true,
+		"stderr":      true,
+		"summary":     true,
+		"parsed":      true,
+		"parse_error": true,
 	},
 }
 var JobListDefaultFields = map[string][]string{
@@ -215,16 +217,19 @@ type jobSqlite struct {
 }
 
 type JobDetails struct {
-	Command     string            `json:"command"`
-	Cwd         string            `json:"cwd"`
-	IsSudo      bool              `json:"is_sudo"`
-	IsScript    bool              `json:"is_script"`
-	Interpreter string            `json:"interpreter"`
-	PID         *int              `json:"pid"`
-	TimeoutSec  int               `json:"timeout_sec"`
-	Error       string            `json:"error"`
-	Result      *models.JobResult `json:"result"`
-	ClientName  string            `json:"client_name"`
+	Command      string               `json:"command"`
+	Cwd          string               `json:"cwd"`
+	IsSudo       bool                 `json:"is_sudo"`
+	IsScript     bool                 `json:"is_script"`
+	Interpreter  string               `json:"interpreter"`
+	PID          *int                 `json:"pid"`
+	TimeoutSec   int                  `json:"timeout_sec"`
+	Error        string               `json:"error"`
+	Result       *models.JobResult    `json:"result"`
+	ClientName   string               `json:"client_name"`
+	OutputParser *models.OutputParser `json:"output_parser,omitempty"`
+	Lock         string               `json:"lock,omitempty"`
+	Artifacts    []models.JobArtifact `json:"artifacts,omitempty"`
 }
 
 func (d *JobDetails) Scan(value interface{}) error {
@@ -281,6 +286,9 @@ func (j *jobSqlite) convert() *models.Job {
 		res.Cwd = j.Details.Cwd
 		res.IsSudo = j.Details.IsSudo
 		res.IsScript = j.Details.IsScript
+		res.OutputParser = j.Details.OutputParser
+		res.Lock = j.Details.Lock
+		res.Artifacts = j.Details.Artifacts
 	}
 	if j.FinishedAt.Valid {
 		res.FinishedAt = &j.FinishedAt.Time
@@ -299,16 +307,19 @@ func convertToSqlite(job *models.Job) *jobSqlite {
 		CreatedBy: job.CreatedBy,
 		ClientID:  job.ClientID,
 		Details: &JobDetails{
-			Command:     job.Command,
-			Interpreter: job.Interpreter,
-			PID:         job.PID,
-			TimeoutSec:  job.TimeoutSec,
-			Result:      job.Result,
-			Error:       job.Error,
-			ClientName:  job.ClientName,
-			Cwd:         job.Cwd,
-			IsSudo:      job.IsSudo,
-			IsScript:    job.IsScript,
+			Command:      job.Command,
+			Interpreter:  job.Interpreter,
+			PID:          job.PID,
+			TimeoutSec:   job.TimeoutSec,
+			Result:       job.Result,
+			Error:        job.Error,
+			ClientName:   job.ClientName,
+			Cwd:          job.Cwd,
+			IsSudo:       job.IsSudo,
+			IsScript:     job.IsScript,
+			OutputParser: job.OutputParser,
+			Lock:         job.Lock,
+			Artifacts:    job.Artifacts,
 		},
 	}
 	if job.MultiJobID != nil {
@@ -0,0 +1,132 @@
+package jobs
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Lock is a named execution lock of a client. Jobs declaring the same lock run one after the other on the client.
+type Lock struct {
+	ClientID string `json:"client_id"`
+	Name     string `json:"name"`
+	// JID is the job holding the lock, empty if it's free
+	JID        string     `json:"jid"`
+	AcquiredAt *time.Time `json:"acquired_at"`
+	// ExpiresAt releases the lock if the result of the job doesn't arrive
+	ExpiresAt *time.Time `json:"expires_at"`
+	// Waiting is the number of jobs waiting for the lock
+	Waiting int `json:"waiting"`
+}
+
+type lockKey struct {
+	clientID string
+	name     string
+}
+
+type heldLock struct {
+	// token has a value while the lock is held
+	token   chan struct{}
+	holder  Lock
+	timer   *time.Timer
+	waiting int
+}
+
+// Locks are the execution locks of the clients.
+type Locks struct {
+	mtx   sync.Mutex
+	locks map[lockKey]*heldLock
+}
+
+func NewLocks() *Locks {
+	return &Locks{
+		locks: make(map[lockKey]*heldLock),
+	}
+}
+
+// Acquire waits until the lock of the client is free and holds it for the job. The lock is released by Release or
+// once the ttl expired.
+func (l *Locks) Acquire(ctx context.Context, clientID, name, jid string, ttl time.Duration) error {
+	key := lockKey{clientID: clientID, name: name}
+	l.mtx.Lock()
+	lock := l.locks[key]
+	if lock == nil {
+		lock = &heldLock{token: make(chan struct{}, 1)}
+		l.locks[key] = lock
+	}
+	lock.waiting++
+	l.mtx.Unlock()
+
+	select {
+	case lock.token <- struct{}{}:
+	case <-ctx.Done():
+		l.mtx.Lock()
+		lock.waiting--
+		l.removeUnused(key, lock)
+		l.mtx.Unlock()
+		return ctx.Err()
+	}
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	lock.waiting--
+	now := time.Now()
+	expires := now.Add(ttl)
+	lock.holder = Lock{
+		ClientID:   clientID,
+		Name:       name,
+		JID:        jid,
+		AcquiredAt: &now,
+		ExpiresAt:  &expires,
+	}
+	lock.timer = time.AfterFunc(ttl, func() {
+		l.Release(clientID, jid)
+	})
+	return nil
+}
+
+// Release frees the locks the job holds on the client.
+func (l *Locks) Release(clientID, jid string) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	for key, lock := range l.locks {
+		if key.clientID != clientID || lock.holder.JID == "" || lock.holder.JID != jid {
+			continue
+		}
+		lock.timer.Stop()
+		lock.holder = Lock{}
+		<-lock.token
+		l.removeUnused(key, lock)
+	}
+}
+
+// List returns the locks of the client that are held or waited for, ordered by name.
+func (l *Locks) List(clientID string) []Lock {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	res := []Lock{}
+	for key, lock := range l.locks {
+		if key.clientID != clientID {
+			continue
+		}
+		cur := lock.holder
+		cur.ClientID = key.clientID
+		cur.Name = key.name
+		cur.Waiting = lock.waiting
+		res = append(res, cur)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+// removeUnused must be called with mtx locked.
+func (l *Locks) removeUnused(key lockKey, lock *heldLock) {
+	if lock.waiting == 0 && lock.holder.JID == "" && l.locks[key] == lock {
+		delete(l.locks, key)
+	}
+}
@@ -0,0 +1,66 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocks(t *testing.T) {
+	locks := NewLocks()
+	ctx := context.Background()
+
+	require.NoError(t, locks.Acquire(ctx, "client-1", "package-manager", "job-1", time.Minute))
+	// other clients and other locks aren't affected
+	require.NoError(t, locks.Acquire(ctx, "client-2", "package-manager", "job-2", time.Minute))
+	require.NoError(t, locks.Acquire(ctx, "client-1", "backup", "job-3", time.Minute))
+
+	acquired := make(chan error)
+	go func() {
+		acquired <- locks.Acquire(ctx, "client-1", "package-manager", "job-4", time.Minute)
+	}()
+	require.Eventually(t, func() bool {
+		list := locks.List("client-1")
+		return len(list) == 2 && list[1].Waiting == 1
+	}, time.Second, time.Millisecond)
+	list := locks.List("client-1")
+	assert.Equal(t, "backup", list[0].Name)
+	assert.Equal(t, "job-3", list[0].JID)
+	assert.Equal(t, "package-manager", list[1].Name)
+	assert.Equal(t, "job-1", list[1].JID)
+	require.NotNil(t, list[1].ExpiresAt)
+
+	select {
+	case <-acquired:
+		t.Fatal("lock acquired while held")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	locks.Release("client-1", "job-1")
+	require.NoError(t, <-acquired)
+	list = locks.List("client-1")
+	assert.Equal(t, "job-4", list[1].JID)
+	assert.Equal(t, 0, list[1].Waiting)
+
+	locks.Release("client-1", "job-3")
+	locks.Release("client-1", "job-4")
+	assert.Empty(t, locks.List("client-1"))
+	assert.Len(t, locks.List("client-2"), 1)
+}
+
+func TestLocksExpireAndCancel(t *testing.T) {
+	locks := NewLocks()
+	require.NoError(t, locks.Acquire(context.Background(), "client-1", "package-manager", "job-1", 50*time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, locks.Acquire(ctx, "client-1", "package-manager", "job-2", time.Minute))
+	assert.Equal(t, 0, locks.List("client-1")[0].Waiting)
+
+	// the result of job-1 didn't arrive in time
+	require.NoError(t, locks.Acquire(context.Background(), "client-1", "package-manager", "job-3", time.Minute))
+	assert.Equal(t, "job-3", locks.List("client-1")[0].JID)
+}
@@ -17,6 +17,10 @@ type MultiJobRequest struct {
 	TimeoutSec          int                   `json:"timeout_sec"`
 	ExecuteConcurrently bool                  `json:"execute_concurrently"`
 	AbortOnError        *bool                 `json:"abort_on_error"` // pointer is used because it's default value is true. Otherwise it would be more difficult to check whether this field is missing or not
+	OutputParser        *models.OutputParser  `json:"output_parser"`
+	Lock                string                `json:"lock"`
+	Artifacts           []models.JobArtifact  `json:"artifacts"`
+	Canary              *CanaryRequest        `json:"canary"`
 
 	Username       string               `json:"-"`
 	IsScript       bool                 `json:"-"`
@@ -24,6 +28,23 @@ type MultiJobRequest struct {
 	ScheduleID     *string              `json:"-"`
 }
 
+// CanaryRequest runs a multi-client job on a subset of the clients first. The canary clients are either given
+// explicitly or the first Count clients of the job are used.
+type CanaryRequest struct {
+	ClientIDs         []string `json:"client_ids"`
+	Count             int      `json:"count"`
+	AutoContinue      bool     `json:"auto_continue"`
+	ConfirmTimeoutSec int      `json:"confirm_timeout_sec"`
+	// MetricCheck gates the run on the measurements of the canary clients after their jobs succeeded
+	MetricCheck *CanaryMetricCheckRequest `json:"metric_check"`
+}
+
+type CanaryMetricCheckRequest struct {
+	Query       string   `json:"query"`
+	Below       *float64 `json:"below"`
+	DurationSec int      `json:"duration_sec"`
+}
+
 func (req *MultiJobRequest) GetClientIDs() (ids []string) {
 	return req.ClientIDs
 }
@@ -130,16 +130,20 @@ type multiJobSummarySqlite struct {
 }
 
 type multiJobDetailSqlite struct {
-	ClientIDs   []string              `json:"client_ids"`
-	GroupIDs    []string              `json:"group_ids"`
-	ClientTags  *models.JobClientTags `json:"tags"`
-	Command     string                `json:"command"`
-	Interpreter string                `json:"interpreter"`
-	Cwd         string                `json:"cwd"`
-	IsSudo      bool                  `json:"is_sudo"`
-	TimeoutSec  int                   `json:"timeout_sec"`
-	Concurrent  bool                  `json:"concurrent"`
-	AbortOnErr  bool                  `json:"abort_on_err"`
+	ClientIDs    []string               `json:"client_ids"`
+	GroupIDs     []string               `json:"group_ids"`
+	ClientTags   *models.JobClientTags  `json:"tags"`
+	Command      string                 `json:"command"`
+	Interpreter  string                 `json:"interpreter"`
+	Cwd          string                 `json:"cwd"`
+	IsSudo       bool                   `json:"is_sudo"`
+	TimeoutSec   int                    `json:"timeout_sec"`
+	Concurrent   bool                   `json:"concurrent"`
+	AbortOnErr   bool                   `json:"abort_on_err"`
+	OutputParser *models.OutputParser   `json:"output_parser,omitempty"`
+	Lock         string                 `json:"lock,omitempty"`
+	Artifacts    []models.JobArtifact   `json:"artifacts,omitempty"`
+	Canary       *models.MultiJobCanary `json:"canary,omitempty"`
 }
 
 func (d *multiJobDetailSqlite) Scan(value interface{}) error {
@@ -200,6 +204,10 @@ func (j *multiJobSqlite) convert() *models.MultiJob {
 		TimeoutSec:      d.TimeoutSec,
 		Concurrent:      d.Concurrent,
 		AbortOnErr:      d.AbortOnErr,
+		OutputParser:    d.OutputParser,
+		Lock:            d.Lock,
+		Artifacts:       d.Artifacts,
+		Canary:          d.Canary,
 	}
 }
 
@@ -212,16 +220,20 @@ func convertMultiJobToSqlite(job *models.MultiJob) *multiJobSqlite {
 			ScheduleID: job.ScheduleID,
 		},
 		Details: &multiJobDetailSqlite{
-			ClientIDs:   job.ClientIDs,
-			GroupIDs:    job.GroupIDs,
-			ClientTags:  job.ClientTags,
-			Command:     job.Command,
-			Interpreter: job.Interpreter,
-			Cwd:         job.Cwd,
-			IsSudo:      job.IsSudo,
-			TimeoutSec:  job.TimeoutSec,
-			Concurrent:  job.Concurrent,
-			AbortOnErr:  job.AbortOnErr,
+			ClientIDs:    job.ClientIDs,
+			GroupIDs:     job.GroupIDs,
+			ClientTags:   job.ClientTags,
+			Command:      job.Command,
+			Interpreter:  job.Interpreter,
+			Cwd:          job.Cwd,
+			IsSudo:       job.IsSudo,
+			TimeoutSec:   job.TimeoutSec,
+			Concurrent:   job.Concurrent,
+			AbortOnErr:   job.AbortOnErr,
+			OutputParser: job.OutputParser,
+			Lock:         job.Lock,
+			Artifacts:    job.Artifacts,
+			Canary:       job.Canary,
 		},
 	}
 }
@@ -0,0 +1,136 @@
+package jobs
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/share/models"
+	"github.com/IOTech17/neo-rport/share/query"
+)
+
+// ParseOutput fills the structured result of a finished job using its output parser. A successful job whose output
+// can't be parsed or matches one of the fail_if conditions is marked as failed.
+func ParseOutput(job *models.Job) {
+	if job.OutputParser == nil || job.Result == nil || job.Status == models.JobStatusRunning {
+		return
+	}
+	job.Result.Parsed = nil
+	job.Result.ParseError = ""
+
+	parsed, err := job.OutputParser.Parse(job.Result.StdOut)
+	if err != nil {
+		job.Result.ParseError = err.Error()
+		if len(job.OutputParser.FailIf) > 0 {
+			failJob(job, fmt.Sprintf("failed to parse output: %v", err))
+		}
+		return
+	}
+	job.Result.Parsed = parsed
+
+	if c, failed := job.OutputParser.FailedCondition(parsed); failed {
+		failJob(job, fmt.Sprintf("output check failed: %s", c))
+	}
+}
+
+func failJob(job *models.Job, reason string) {
+	if job.Status == models.JobStatusSuccessful {
+		job.Status = models.JobStatusFailed
+	}
+	if job.Error != "" {
+		job.Error += "; "
+	}
+	job.Error += reason
+}
+
+// ParsedFieldPrefix selects fields of the parsed output in job filters, e.g. filter[parsed.status]=ok.
+const ParsedFieldPrefix = "parsed."
+
+// ExtractParsedFilters removes the filters on parsed output fields from the options and returns them. These filters
+// can't be applied by the db and have to be matched with MatchesParsedFilters.
+func ExtractParsedFilters(options *query.ListOptions) ([]query.FilterOption, error) {
+	var parsed, other []query.FilterOption
+	for _, fo := range options.Filters {
+		isParsed := false
+		for _, col := range fo.Column {
+			if strings.HasPrefix(col, ParsedFieldPrefix) {
+				isParsed = true
+			}
+		}
+		if !isParsed {
+			other = append(other, fo)
+			continue
+		}
+
+		for _, col := range fo.Column {
+			if !strings.HasPrefix(col, ParsedFieldPrefix) || !models.IsValidOutputField(strings.TrimPrefix(col, ParsedFieldPrefix)) {
+				return nil, errors2.APIError{
+					Message:    fmt.Sprintf("unsupported filter field '%s'", fo),
+					HTTPStatus: http.StatusBadRequest,
+				}
+			}
+		}
+		parsed = append(parsed, fo)
+	}
+	options.Filters = other
+	return parsed, nil
+}
+
+// MatchesParsedFilters returns true if the parsed output of the job matches all filters.
+func MatchesParsedFilters(job *models.Job, filters []query.FilterOption) bool {
+	for _, fo := range filters {
+		if !matchesParsedFilter(job, fo) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesParsedFilter(job *models.Job, fo query.FilterOption) bool {
+	matched := 0
+	for _, value := range fo.Values {
+		for _, col := range fo.Column {
+			if matchesParsedValue(job, strings.TrimPrefix(col, ParsedFieldPrefix), fo.Operator, value) {
+				matched++
+				break
+			}
+		}
+	}
+	if fo.ValuesLogicalOperator == query.FilterLogicalOperatorTypeAND {
+		return matched == len(fo.Values)
+	}
+	return matched > 0
+}
+
+func matchesParsedValue(job *models.Job, field string, operator query.FilterOperatorType, value string) bool {
+	if job.Result == nil {
+		return false
+	}
+	v, found := models.LookupOutputField(job.Result.Parsed, field)
+	if !found {
+		return false
+	}
+	actual := fmt.Sprint(v)
+
+	switch operator {
+	case query.FilterOperatorTypeGT:
+		return models.CompareOutputValues(actual, value) > 0
+	case query.FilterOperatorTypeLT:
+		return models.CompareOutputValues(actual, value) < 0
+	case query.FilterOperatorTypeSince:
+		return models.CompareOutputValues(actual, value) >= 0
+	case query.FilterOperatorTypeUntil:
+		return models.CompareOutputValues(actual, value) <= 0
+	}
+
+	if !strings.Contains(value, "*") {
+		return strings.EqualFold(actual, value)
+	}
+	parts := strings.Split(value, "*")
+	for i := range parts {
+		parts[i] = regexp.QuoteMeta(parts[i])
+	}
+	return regexp.MustCompile("(?i)^" + strings.Join(parts, ".*") + "$").MatchString(actual)
+}
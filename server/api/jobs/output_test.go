@@ -0,0 +1,159 @@
+package jobs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/server/test/jb"
+	"github.com/IOTech17/neo-rport/share/models"
+	"github.com/IOTech17/neo-rport/share/query"
+)
+
+func TestParseOutput(t *testing.T) {
+	parser := &models.OutputParser{
+		Type:   models.OutputParserKeyValue,
+		FailIf: []models.OutputCondition{{Field: "status", Operator: "!=", Value: "ok"}},
+	}
+	testCases := []struct {
+		name           string
+		status         string
+		stdout         string
+		wantStatus     string
+		wantParsed     map[string]interface{}
+		wantError      string
+		wantParseError string
+	}{
+		{
+			name:       "condition not met",
+			status:     models.JobStatusSuccessful,
+			stdout:     "status=ok\n",
+			wantStatus: models.JobStatusSuccessful,
+			wantParsed: map[string]interface{}{"status": "ok"},
+		},
+		{
+			name:       "condition met",
+			status:     models.JobStatusSuccessful,
+			stdout:     "status=degraded\n",
+			wantStatus: models.JobStatusFailed,
+			wantParsed: map[string]interface{}{"status": "degraded"},
+			wantError:  "output check failed: status != ok",
+		},
+		{
+			name:       "missing field",
+			status:     models.JobStatusSuccessful,
+			stdout:     "other=ok\n",
+			wantStatus: models.JobStatusFailed,
+			wantParsed: map[string]interface{}{"other": "ok"},
+			wantError:  "output check failed: status != ok",
+		},
+		{
+			name:       "running job is not parsed",
+			status:     models.JobStatusRunning,
+			stdout:     "status=ok\n",
+			wantStatus: models.JobStatusRunning,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			job := jb.New(t).Status(tc.status).Result(&models.JobResult{StdOut: tc.stdout}).Build()
+			job.Error = ""
+			job.OutputParser = parser
+
+			ParseOutput(job)
+
+			assert.Equal(t, tc.wantStatus, job.Status)
+			assert.Equal(t, tc.wantParsed, job.Result.Parsed)
+			assert.Equal(t, tc.wantError, job.Error)
+			assert.Equal(t, tc.wantParseError, job.Result.ParseError)
+		})
+	}
+
+	t.Run("invalid json", func(t *testing.T) {
+		job := jb.New(t).Status(models.JobStatusSuccessful).Result(&models.JobResult{StdOut: "not json"}).Build()
+		job.Error = ""
+		job.OutputParser = &models.OutputParser{Type: models.OutputParserJSON}
+
+		ParseOutput(job)
+
+		assert.Equal(t, models.JobStatusSuccessful, job.Status)
+		assert.Nil(t, job.Result.Parsed)
+		assert.Contains(t, job.Result.ParseError, "output is not valid json")
+	})
+}
+
+func TestParsedFilters(t *testing.T) {
+	parser := &models.OutputParser{Type: models.OutputParserJSON}
+	job1 := jb.New(t).Result(&models.JobResult{StdOut: `{"status":"ok","disks":[{"free":10}]}`}).Build()
+	job2 := jb.New(t).Result(&models.JobResult{StdOut: `{"status":"failing","disks":[{"free":80}]}`}).Build()
+	job3 := jb.New(t).Build()
+	for _, job := range []*models.Job{job1, job2} {
+		job.OutputParser = parser
+		ParseOutput(job)
+	}
+
+	testCases := []struct {
+		name    string
+		filters []query.FilterOption
+		want    []*models.Job
+	}{
+		{
+			name:    "equal",
+			filters: []query.FilterOption{{Column: []string{"parsed.status"}, Values: []string{"OK"}}},
+			want:    []*models.Job{job1},
+		},
+		{
+			name:    "wildcard",
+			filters: []query.FilterOption{{Column: []string{"parsed.status"}, Values: []string{"fail*"}}},
+			want:    []*models.Job{job2},
+		},
+		{
+			name:    "numeric in array",
+			filters: []query.FilterOption{{Column: []string{"parsed.disks.0.free"}, Operator: query.FilterOperatorTypeGT, Values: []string{"9"}}},
+			want:    []*models.Job{job1, job2},
+		},
+		{
+			name: "all filters must match",
+			filters: []query.FilterOption{
+				{Column: []string{"parsed.disks.0.free"}, Operator: query.FilterOperatorTypeLT, Values: []string{"50"}},
+				{Column: []string{"parsed.status"}, Values: []string{"failing"}},
+			},
+			want: []*models.Job{},
+		},
+		{
+			name:    "any value matches",
+			filters: []query.FilterOption{{Column: []string{"parsed.status"}, Values: []string{"ok", "failing"}}},
+			want:    []*models.Job{job1, job2},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			options := &query.ListOptions{
+				Filters: append([]query.FilterOption{{Column: []string{"client_id"}, Values: []string{"c1"}}}, tc.filters...),
+			}
+			parsedFilters, err := ExtractParsedFilters(options)
+			require.NoError(t, err)
+			assert.Equal(t, []query.FilterOption{{Column: []string{"client_id"}, Values: []string{"c1"}}}, options.Filters)
+
+			got := []*models.Job{}
+			for _, job := range []*models.Job{job1, job2, job3} {
+				if MatchesParsedFilters(job, parsedFilters) {
+					got = append(got, job)
+				}
+			}
+			assert.Equal(t, tc.want, got)
+		})
+	}
+
+	_, err := ExtractParsedFilters(&query.ListOptions{
+		Filters: []query.FilterOption{{Column: []string{"parsed.status", "client_id"}, Values: []string{"ok"}}},
+	})
+	assert.EqualError(t, err, "unsupported filter field 'filter[parsed.status|client_id]'")
+	_, err = ExtractParsedFilters(&query.ListOptions{
+		Filters: []query.FilterOption{{Column: []string{"parsed.status'"}, Values: []string{"ok"}}},
+	})
+	assert.Error(t, err)
+}
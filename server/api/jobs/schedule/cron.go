@@ -3,6 +3,7 @@ package schedule
 import (
 	"context"
 	"sync"
+	"time"
 
 	cron "github.com/robfig/cron/v3"
 )
@@ -55,3 +56,13 @@ func (c *CronImplementation) Remove(id string) {
 	c.cron.Remove(entryID)
 	delete(c.mapping, id)
 }
+
+// Due returns true if the schedule runs at the minute of the time, evaluated in the location of the time.
+func (c *CronImplementation) Due(schedule string, t time.Time) (bool, error) {
+	sch, err := c.cronParser.Parse(schedule)
+	if err != nil {
+		return false, err
+	}
+	t = t.Truncate(time.Minute)
+	return sch.Next(t.Add(-time.Second)).Equal(t), nil
+}
@@ -5,6 +5,10 @@ import (
 	"encoding/base64"
 	"fmt"
 	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -12,6 +16,8 @@ import (
 	"github.com/IOTech17/neo-rport/server/api"
 	"github.com/IOTech17/neo-rport/server/api/errors"
 	"github.com/IOTech17/neo-rport/server/api/jobs"
+	"github.com/IOTech17/neo-rport/server/cgroups"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
 	"github.com/IOTech17/neo-rport/server/validation"
 	"github.com/IOTech17/neo-rport/share/logger"
 	"github.com/IOTech17/neo-rport/share/models"
@@ -49,18 +55,31 @@ type Provider interface {
 	Get(context.Context, string) (*Schedule, error)
 	Delete(context.Context, string) error
 	CountJobsInProgress(ctx context.Context, scheduleID string, timeoutSec int) (int, error)
+	SaveMaintenanceDecision(ctx context.Context, id string, d *MaintenanceDecision) error
 }
 
 type Cron interface {
 	Validate(string) error
 	Add(string, string, func(context.Context, string)) error
 	Remove(string)
+	Due(string, time.Time) (bool, error)
 }
 
 type JobRunner interface {
 	StartMultiClientJob(ctx context.Context, multiJobRequest *jobs.MultiJobRequest) (*models.MultiJob, error)
+	GetScheduleTargets(ctx context.Context, multiJobRequest *jobs.MultiJobRequest) ([]*Target, error)
 }
 
+// Target is a client targeted by a schedule with the maintenance windows of its client groups.
+type Target struct {
+	Client             *clientdata.Client
+	MaintenanceWindows []*cgroups.AccessSchedule
+}
+
+var now = time.Now
+
+var clientTimezoneOffset = regexp.MustCompile(`UTC([+-])(\d{2}):(\d{2})`)
+
 type Manager struct {
 	*logger.Logger
 	jobRunner JobRunner
@@ -68,6 +87,15 @@ type Manager struct {
 	cron      Cron
 
 	runRemoteCmdTimeoutSec int
+
+	deferredMtx sync.Mutex
+	// deferred are the runs by schedule ID waiting for the maintenance windows of their clients
+	deferred map[string][]*deferredRun
+}
+
+type deferredRun struct {
+	timer     *time.Timer
+	clientIDs []string
 }
 
 func New(ctx context.Context, logger *logger.Logger, db *sqlx.DB, jobRunner JobRunner, runRemoteCmdTimeoutSec int) (*Manager, error) {
@@ -96,6 +124,8 @@ func NewManager(jobRunner JobRunner, db *sqlx.DB, logger *logger.Logger, runRemo
 		cron:      newCron(),
 
 		runRemoteCmdTimeoutSec: runRemoteCmdTimeoutSec,
+
+		deferred: make(map[string][]*deferredRun),
 	}
 	return m
 }
@@ -143,6 +173,19 @@ func (m *Manager) List(ctx context.Context, r *http.Request) (*api.SuccessPayloa
 	}, nil
 }
 
+// CountByCreator returns the number of schedules per user who created them.
+func (m *Manager) CountByCreator(ctx context.Context) (map[string]int, error) {
+	entries, err := m.provider.List(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]int)
+	for _, entry := range entries {
+		res[entry.CreatedBy]++
+	}
+	return res, nil
+}
+
 func (m *Manager) Get(ctx context.Context, id string) (*Schedule, error) {
 	return m.provider.Get(ctx, id)
 }
@@ -174,6 +217,21 @@ func (m *Manager) Create(ctx context.Context, s *Schedule, user string) (*Schedu
 	return s, nil
 }
 
+// Restore recreates a deleted schedule with its original id and creator.
+func (m *Manager) Restore(ctx context.Context, s *Schedule) error {
+	err := m.validate(s)
+	if err != nil {
+		return err
+	}
+
+	err = m.provider.Insert(ctx, s)
+	if err != nil {
+		return err
+	}
+
+	return m.addCron(s)
+}
+
 func (m *Manager) Update(ctx context.Context, id string, s *Schedule) (*Schedule, error) {
 	s.ID = id
 
@@ -188,6 +246,7 @@ func (m *Manager) Update(ctx context.Context, id string, s *Schedule) (*Schedule
 	}
 
 	m.cron.Remove(s.ID)
+	m.cancelDeferred(s.ID)
 	err = m.addCron(s)
 	if err != nil {
 		return nil, err
@@ -208,6 +267,7 @@ func (m *Manager) Delete(ctx context.Context, id string) error {
 	}
 
 	m.cron.Remove(id)
+	m.cancelDeferred(id)
 	return nil
 }
 
@@ -238,6 +298,43 @@ func (m *Manager) validate(s *Schedule) error {
 		}
 	}
 
+	err = validation.ValidateOutputParser(s.Details.OutputParser)
+	if err != nil {
+		return &errors.APIError{
+			Message:    "Invalid output parser.",
+			Err:        err,
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+
+	err = validation.ValidateLock(s.Details.Lock)
+	if err != nil {
+		return &errors.APIError{
+			Message:    "Invalid lock.",
+			Err:        err,
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+
+	err = validation.ValidateJobArtifacts(s.Details.Artifacts)
+	if err != nil {
+		return &errors.APIError{
+			Message:    "Invalid artifacts.",
+			Err:        err,
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+
+	switch s.Details.MaintenanceWindows {
+	case "", MaintenanceWindowsSkip, MaintenanceWindowsDefer:
+	default:
+		return &errors.APIError{
+			Message:    "Invalid maintenance windows.",
+			Err:        fmt.Errorf("maintenance_windows must be '%s' or '%s'", MaintenanceWindowsSkip, MaintenanceWindowsDefer),
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+
 	switch s.Type {
 	case TypeCommand:
 		if s.Details.Command == "" {
@@ -269,10 +366,17 @@ func (m *Manager) validate(s *Schedule) error {
 }
 
 func (m *Manager) addCron(s *Schedule) error {
+	if s.Details.ClientTimezone {
+		// the schedule is due at different times for the clients
+		return m.cron.Add(s.ID, "* * * * *", m.runInClientTimezones)
+	}
 	return m.cron.Add(s.ID, s.Schedule, m.run)
 }
 
 func (m *Manager) run(ctx context.Context, id string) {
+	// the run covers the clients of a deferred run again
+	m.cancelDeferred(id)
+
 	schedule, err := m.provider.Get(ctx, id)
 	if err != nil {
 		m.Errorf("Could not get schedule %s: %v", id, err)
@@ -283,25 +387,276 @@ func (m *Manager) run(ctx context.Context, id string) {
 		return
 	}
 
-	if !schedule.Details.Overlaps {
-		timeoutSec := schedule.Details.TimeoutSec
-		if timeoutSec <= 0 {
-			timeoutSec = m.runRemoteCmdTimeoutSec
+	if m.inProgress(ctx, schedule) {
+		return
+	}
+
+	m.Infof("Running schedule: %s", id)
+
+	m.start(ctx, schedule, newMultiJobRequest(schedule))
+}
+
+// runInClientTimezones runs a schedule with client timezones every minute on the clients it's due for in their
+// timezone.
+func (m *Manager) runInClientTimezones(ctx context.Context, id string) {
+	schedule, err := m.provider.Get(ctx, id)
+	if err != nil {
+		m.Errorf("Could not get schedule %s: %v", id, err)
+		return
+	}
+	if schedule == nil {
+		return
+	}
+
+	targets, err := m.jobRunner.GetScheduleTargets(ctx, newMultiJobRequest(schedule))
+	if err != nil {
+		m.Errorf("Could not get the clients of schedule %s: %v", id, err)
+		return
+	}
+	t := now().Truncate(time.Minute)
+	var clientIDs []string
+	for _, target := range targets {
+		due, err := m.cron.Due(schedule.Schedule, t.In(clientLocation(target.Client.GetTimezone())))
+		if err != nil {
+			m.Errorf("Invalid cron schedule of schedule %s: %v", id, err)
+			return
+		}
+		if due {
+			clientIDs = append(clientIDs, target.Client.GetID())
 		}
-		cnt, err := m.provider.CountJobsInProgress(ctx, id, timeoutSec)
+	}
+	if len(clientIDs) == 0 {
+		return
+	}
+
+	m.supersedeDeferred(id, clientIDs)
+	if m.inProgress(ctx, schedule) {
+		return
+	}
+
+	m.Infof("Running schedule %s on %d client(s) in their timezone", id, len(clientIDs))
+
+	req := newMultiJobRequest(schedule)
+	req.ClientIDs = clientIDs
+	req.GroupIDs = nil
+	req.ClientTags = nil
+	m.start(ctx, schedule, req)
+}
+
+// inProgress returns true if the schedule doesn't overlap and has jobs in progress.
+func (m *Manager) inProgress(ctx context.Context, schedule *Schedule) bool {
+	if schedule.Details.Overlaps {
+		return false
+	}
+	timeoutSec := schedule.Details.TimeoutSec
+	if timeoutSec <= 0 {
+		timeoutSec = m.runRemoteCmdTimeoutSec
+	}
+	cnt, err := m.provider.CountJobsInProgress(ctx, schedule.ID, timeoutSec)
+	if err != nil {
+		m.Errorf("Could not count jobs in progress for schedule %s: %v", schedule.ID, err)
+		return true
+	}
+	if cnt > 0 {
+		m.Infof("Skipping non-overlapping schedule %s, because it has jobs in progress.", schedule.ID)
+		return true
+	}
+	return false
+}
+
+// runDeferred runs a schedule on the clients deferred until their maintenance windows.
+func (m *Manager) runDeferred(ctx context.Context, id string, clientIDs []string) {
+	schedule, err := m.provider.Get(ctx, id)
+	if err != nil {
+		m.Errorf("Could not get schedule %s: %v", id, err)
+		return
+	}
+	if schedule == nil {
+		return
+	}
+
+	m.Infof("Running schedule %s deferred until the maintenance windows of %d client(s)", id, len(clientIDs))
+
+	req := newMultiJobRequest(schedule)
+	req.ClientIDs = clientIDs
+	req.GroupIDs = nil
+	req.ClientTags = nil
+	m.start(ctx, schedule, req)
+}
+
+func (m *Manager) start(ctx context.Context, schedule *Schedule, req *jobs.MultiJobRequest) {
+	if schedule.Details.MaintenanceWindows != "" {
+		ok, err := m.applyMaintenanceWindows(ctx, schedule, req)
 		if err != nil {
-			m.Errorf("Could not count jobs in progress for schedule %s: %v", id, err)
+			m.Errorf("Could not apply the maintenance windows of schedule %s: %v", schedule.ID, err)
 			return
 		}
-		if cnt > 0 {
-			m.Infof("Skipping non-overlapping schedule %s, because it has jobs in progress.", id)
+		if !ok {
 			return
 		}
 	}
 
-	m.Infof("Running schedule: %s", id)
+	_, err := m.jobRunner.StartMultiClientJob(ctx, req)
+	if err != nil {
+		m.Errorf("Error running schedule %s: %v", schedule.ID, err)
+		return
+	}
+}
+
+// applyMaintenanceWindows restricts the request to the clients within the maintenance windows of their client groups,
+// the others are skipped or deferred until their next window. Clients without maintenance windows are skipped. It
+// returns false if no client is left.
+func (m *Manager) applyMaintenanceWindows(ctx context.Context, schedule *Schedule, req *jobs.MultiJobRequest) (bool, error) {
+	targets, err := m.jobRunner.GetScheduleTargets(ctx, req)
+	if err != nil {
+		return false, err
+	}
+
+	t := now()
+	decision := &MaintenanceDecision{
+		Time:     t,
+		Ran:      []string{},
+		Skipped:  []string{},
+		Deferred: []string{},
+	}
+	clients := make([]*clientdata.Client, 0, len(targets))
+	for _, target := range targets {
+		id := target.Client.GetID()
+		if inMaintenanceWindow(target.MaintenanceWindows, t) {
+			clients = append(clients, target.Client)
+			decision.Ran = append(decision.Ran, id)
+			continue
+		}
+		next, ok := nextMaintenanceWindow(target.MaintenanceWindows, t)
+		if schedule.Details.MaintenanceWindows != MaintenanceWindowsDefer || !ok {
+			decision.Skipped = append(decision.Skipped, id)
+			continue
+		}
+		decision.Deferred = append(decision.Deferred, id)
+		if decision.DeferredUntil == nil || next.Before(*decision.DeferredUntil) {
+			decision.DeferredUntil = &next
+		}
+	}
+
+	if decision.DeferredUntil != nil {
+		m.deferRun(schedule.ID, decision.Deferred, decision.DeferredUntil.Sub(t))
+	}
+	m.Infof("Schedule %s restricted to maintenance windows: running on %d client(s), skipped %d, deferred %d", schedule.ID, len(decision.Ran), len(decision.Skipped), len(decision.Deferred))
+	if err := m.provider.SaveMaintenanceDecision(ctx, schedule.ID, decision); err != nil {
+		m.Errorf("Could not save the maintenance decision of schedule %s: %v", schedule.ID, err)
+	}
 
-	_, err = m.jobRunner.StartMultiClientJob(ctx, &jobs.MultiJobRequest{
+	req.OrderedClients = clients
+	return len(clients) > 0, nil
+}
+
+func (m *Manager) deferRun(id string, clientIDs []string, delay time.Duration) {
+	m.deferredMtx.Lock()
+	defer m.deferredMtx.Unlock()
+
+	run := &deferredRun{clientIDs: clientIDs}
+	run.timer = time.AfterFunc(delay, func() {
+		m.deferredMtx.Lock()
+		m.removeDeferred(id, run)
+		clientIDs := run.clientIDs
+		m.deferredMtx.Unlock()
+
+		if len(clientIDs) > 0 {
+			m.runDeferred(context.Background(), id, clientIDs)
+		}
+	})
+	m.deferred[id] = append(m.deferred[id], run)
+}
+
+// cancelDeferred stops all deferred runs of the schedule.
+func (m *Manager) cancelDeferred(id string) {
+	m.deferredMtx.Lock()
+	defer m.deferredMtx.Unlock()
+
+	for _, run := range m.deferred[id] {
+		run.timer.Stop()
+	}
+	delete(m.deferred, id)
+}
+
+// supersedeDeferred removes the clients from the deferred runs of the schedule, they're covered by a new run.
+func (m *Manager) supersedeDeferred(id string, clientIDs []string) {
+	m.deferredMtx.Lock()
+	defer m.deferredMtx.Unlock()
+
+	superseded := make(map[string]bool, len(clientIDs))
+	for _, clientID := range clientIDs {
+		superseded[clientID] = true
+	}
+	for _, run := range m.deferred[id] {
+		remaining := make([]string, 0, len(run.clientIDs))
+		for _, clientID := range run.clientIDs {
+			if !superseded[clientID] {
+				remaining = append(remaining, clientID)
+			}
+		}
+		run.clientIDs = remaining
+		if len(remaining) == 0 {
+			run.timer.Stop()
+			m.removeDeferred(id, run)
+		}
+	}
+}
+
+// removeDeferred must be called with deferredMtx locked.
+func (m *Manager) removeDeferred(id string, run *deferredRun) {
+	runs := m.deferred[id]
+	for i, r := range runs {
+		if r == run {
+			runs = append(runs[:i:i], runs[i+1:]...)
+			break
+		}
+	}
+	if len(runs) == 0 {
+		delete(m.deferred, id)
+		return
+	}
+	m.deferred[id] = runs
+}
+
+func inMaintenanceWindow(windows []*cgroups.AccessSchedule, t time.Time) bool {
+	for _, w := range windows {
+		if w.Allows(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func nextMaintenanceWindow(windows []*cgroups.AccessSchedule, t time.Time) (time.Time, bool) {
+	var res time.Time
+	for _, w := range windows {
+		next, ok := w.NextAllowed(t)
+		if ok && (res.IsZero() || next.Before(res)) {
+			res = next
+		}
+	}
+	return res, !res.IsZero()
+}
+
+// clientLocation returns the fixed zone of the timezone reported by a client, e.g. "CEST (UTC+02:00)", the server's
+// local time if it has no offset.
+func clientLocation(timezone string) *time.Location {
+	m := clientTimezoneOffset.FindStringSubmatch(timezone)
+	if m == nil {
+		return time.Local
+	}
+	hours, _ := strconv.Atoi(m[2])
+	minutes, _ := strconv.Atoi(m[3])
+	offset := hours*3600 + minutes*60
+	if m[1] == "-" {
+		offset = -offset
+	}
+	return time.FixedZone(strings.TrimSpace(strings.Split(timezone, "(")[0]), offset)
+}
+
+func newMultiJobRequest(schedule *Schedule) *jobs.MultiJobRequest {
+	return &jobs.MultiJobRequest{
 		ScheduleID:          &schedule.ID,
 		Username:            schedule.CreatedBy,
 		ClientIDs:           schedule.Details.ClientIDs,
@@ -315,10 +670,9 @@ func (m *Manager) run(ctx context.Context, id string) {
 		TimeoutSec:          schedule.Details.TimeoutSec,
 		ExecuteConcurrently: schedule.Details.ExecuteConcurrently,
 		AbortOnError:        schedule.Details.AbortOnError,
+		OutputParser:        schedule.Details.OutputParser,
+		Lock:                schedule.Details.Lock,
+		Artifacts:           schedule.Details.Artifacts,
 		IsScript:            schedule.Type == TypeScript,
-	})
-	if err != nil {
-		m.Errorf("Error running schedule %s: %v", id, err)
-		return
 	}
 }
@@ -1,9 +1,21 @@
 package schedule
 
 import (
+	"context"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	jobsmigration "github.com/IOTech17/neo-rport/db/migration/jobs"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	"github.com/IOTech17/neo-rport/server/api/jobs"
+	"github.com/IOTech17/neo-rport/server/cgroups"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/models"
 )
 
 func TestValidate(t *testing.T) {
@@ -62,6 +74,21 @@ func TestValidate(t *testing.T) {
 			},
 			ExpectedError: "",
 		},
+		{
+			Name: "invalid maintenance windows",
+			Schedule: &Schedule{
+				Base: Base{
+					Type:     TypeCommand,
+					Schedule: "* * * * *",
+				},
+				Details: Details{
+					ClientIDs:          []string{"id-1"},
+					Command:            "/bin/true",
+					MaintenanceWindows: "wait",
+				},
+			},
+			ExpectedError: "maintenance_windows must be 'skip' or 'defer'",
+		},
 		{
 			Name: "empty script",
 			Schedule: &Schedule{
@@ -120,3 +147,155 @@ func TestValidate(t *testing.T) {
 		})
 	}
 }
+
+type fakeJobRunner struct {
+	windows   map[string][]*cgroups.AccessSchedule
+	timezones map[string]string
+	requests  []*jobs.MultiJobRequest
+}
+
+func (r *fakeJobRunner) StartMultiClientJob(ctx context.Context, multiJobRequest *jobs.MultiJobRequest) (*models.MultiJob, error) {
+	r.requests = append(r.requests, multiJobRequest)
+	return &models.MultiJob{}, nil
+}
+
+func (r *fakeJobRunner) GetScheduleTargets(ctx context.Context, multiJobRequest *jobs.MultiJobRequest) ([]*Target, error) {
+	var res []*Target
+	for _, id := range multiJobRequest.ClientIDs {
+		res = append(res, &Target{
+			Client:             &clientdata.Client{ID: id, Timezone: r.timezones[id]},
+			MaintenanceWindows: r.windows[id],
+		})
+	}
+	return res, nil
+}
+
+func TestRunMaintenanceWindows(t *testing.T) {
+	db, err := sqlite.New(":memory:", jobsmigration.AssetNames(), jobsmigration.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+
+	monday := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	now = func() time.Time { return monday }
+	defer func() { now = time.Now }()
+
+	runner := &fakeJobRunner{
+		windows: map[string][]*cgroups.AccessSchedule{
+			"c1": {{Windows: []cgroups.AccessWindow{{Start: "00:00", End: "24:00"}}}},
+			"c2": {
+				{Windows: []cgroups.AccessWindow{{Days: []string{"sat"}, Start: "22:00", End: "06:00"}}},
+				{Windows: []cgroups.AccessWindow{{Days: []string{"wed"}, Start: "01:00", End: "02:00"}}},
+			},
+		},
+	}
+	manager := NewManager(runner, db, logger.NewLogger("test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug), 30)
+	s, err := manager.Create(ctx, &Schedule{
+		Base: Base{Name: "patch", Schedule: "0 12 * * *", Type: TypeCommand},
+		Details: Details{
+			ClientIDs:          []string{"c1", "c2", "c3"},
+			Command:            "/usr/bin/apt-get upgrade -y",
+			Overlaps:           true,
+			MaintenanceWindows: MaintenanceWindowsDefer,
+		},
+	}, "admin")
+	require.NoError(t, err)
+	defer manager.Delete(ctx, s.ID)
+
+	manager.run(ctx, s.ID)
+
+	require.Len(t, runner.requests, 1)
+	require.Len(t, runner.requests[0].OrderedClients, 1)
+	assert.Equal(t, "c1", runner.requests[0].OrderedClients[0].ID)
+	wednesday := time.Date(2024, 1, 3, 1, 0, 0, 0, time.UTC)
+	saved, err := manager.Get(ctx, s.ID)
+	require.NoError(t, err)
+	assert.Equal(t, &MaintenanceDecision{
+		Time:          monday,
+		Ran:           []string{"c1"},
+		Skipped:       []string{"c3"},
+		Deferred:      []string{"c2"},
+		DeferredUntil: &wednesday,
+	}, saved.LastMaintenanceDecision)
+	manager.deferredMtx.Lock()
+	assert.Contains(t, manager.deferred, s.ID)
+	manager.deferredMtx.Unlock()
+
+	now = func() time.Time { return wednesday }
+	manager.runDeferred(ctx, s.ID, []string{"c2"})
+	require.Len(t, runner.requests, 2)
+	assert.Equal(t, []string{"c2"}, runner.requests[1].ClientIDs)
+	require.Len(t, runner.requests[1].OrderedClients, 1)
+	assert.Equal(t, "c2", runner.requests[1].OrderedClients[0].ID)
+
+	// the next run supersedes a deferred one, skipped clients aren't run later
+	now = func() time.Time { return monday }
+	s.Details.MaintenanceWindows = MaintenanceWindowsSkip
+	_, err = manager.Update(ctx, s.ID, s)
+	require.NoError(t, err)
+	manager.run(ctx, s.ID)
+	require.Len(t, runner.requests, 3)
+	saved, err = manager.Get(ctx, s.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c2", "c3"}, saved.LastMaintenanceDecision.Skipped)
+	assert.Empty(t, saved.LastMaintenanceDecision.Deferred)
+	manager.deferredMtx.Lock()
+	assert.NotContains(t, manager.deferred, s.ID)
+	manager.deferredMtx.Unlock()
+}
+
+func TestRunInClientTimezones(t *testing.T) {
+	db, err := sqlite.New(":memory:", jobsmigration.AssetNames(), jobsmigration.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+
+	runner := &fakeJobRunner{
+		timezones: map[string]string{
+			"berlin":   "CEST (UTC+02:00)",
+			"new-york": "EST (UTC-05:00)",
+			"kolkata":  "IST (UTC+05:30)",
+		},
+	}
+	manager := NewManager(runner, db, logger.NewLogger("test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug), 30)
+	s, err := manager.Create(ctx, &Schedule{
+		Base: Base{Name: "nightly", Schedule: "0 2 * * *", Type: TypeCommand},
+		Details: Details{
+			ClientIDs:      []string{"berlin", "new-york", "kolkata"},
+			Command:        "/usr/bin/backup",
+			Overlaps:       true,
+			ClientTimezone: true,
+		},
+	}, "admin")
+	require.NoError(t, err)
+	defer manager.Delete(ctx, s.ID)
+
+	for _, tc := range []struct {
+		time      time.Time
+		clientIDs []string
+	}{
+		{time: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), clientIDs: []string{"berlin"}},
+		{time: time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC)},
+		{time: time.Date(2023, 12, 31, 20, 30, 0, 0, time.UTC), clientIDs: []string{"kolkata"}},
+		{time: time.Date(2024, 1, 1, 7, 0, 30, 0, time.UTC), clientIDs: []string{"new-york"}},
+	} {
+		runner.requests = nil
+		now = func() time.Time { return tc.time }
+		manager.runInClientTimezones(ctx, s.ID)
+		if tc.clientIDs == nil {
+			assert.Empty(t, runner.requests, tc.time)
+			continue
+		}
+		require.Len(t, runner.requests, 1, tc.time)
+		assert.Equal(t, tc.clientIDs, runner.requests[0].ClientIDs, tc.time)
+	}
+	now = time.Now
+}
+
+func TestClientLocation(t *testing.T) {
+	_, offset := time.Date(2024, 1, 1, 0, 0, 0, 0, clientLocation("CEST (UTC+02:00)")).Zone()
+	assert.Equal(t, 2*3600, offset)
+	_, offset = time.Date(2024, 1, 1, 0, 0, 0, 0, clientLocation("NST (UTC-03:30)")).Zone()
+	assert.Equal(t, -(3*3600 + 30*60), offset)
+	assert.Equal(t, time.Local, clientLocation("unknown"))
+}
@@ -16,10 +16,18 @@ const (
 	TypeScript  = "script"
 )
 
+// Values of Details.MaintenanceWindows, runs on clients outside of their maintenance windows are skipped or deferred
+// until the next window starts.
+const (
+	MaintenanceWindowsSkip  = "skip"
+	MaintenanceWindowsDefer = "defer"
+)
+
 type Schedule struct {
 	Base
 	Details
-	LastExecution *Execution `json:"last_execution"`
+	LastExecution           *Execution           `json:"last_execution"`
+	LastMaintenanceDecision *MaintenanceDecision `json:"last_maintenance_decision"`
 }
 
 func (s Schedule) ToDB() DBSchedule {
@@ -44,15 +52,17 @@ func (s *Schedule) GetClientTags() (clientTags *models.JobClientTags) {
 // DBSchedule is used for saving to database and has details in one json db column
 type DBSchedule struct {
 	Base
-	Details Details `db:"details"`
+	Details             Details              `db:"details"`
+	MaintenanceDecision *MaintenanceDecision `db:"last_maintenance_decision"`
 	Execution
 }
 
 func (dbs DBSchedule) ToSchedule() *Schedule {
 	return &Schedule{
-		Base:          dbs.Base,
-		Details:       dbs.Details,
-		LastExecution: dbs.Execution.ToLastExecution(),
+		Base:                    dbs.Base,
+		Details:                 dbs.Details,
+		LastExecution:           dbs.Execution.ToLastExecution(),
+		LastMaintenanceDecision: dbs.MaintenanceDecision,
 	}
 }
 
@@ -78,6 +88,16 @@ type Details struct {
 	ExecuteConcurrently bool                  `json:"execute_concurrently" db:"-"`
 	AbortOnError        *bool                 `json:"abort_on_error" db:"-"`
 	Overlaps            bool                  `json:"overlaps" db:"-"`
+	OutputParser        *models.OutputParser  `json:"output_parser,omitempty" db:"-"`
+	// MaintenanceWindows restricts the runs to the maintenance windows of the client groups of the clients, "skip" or
+	// "defer", no restriction if empty
+	MaintenanceWindows string `json:"maintenance_windows,omitempty" db:"-"`
+	// Lock is the execution lock of the clients the jobs hold while they're running
+	Lock string `json:"lock,omitempty" db:"-"`
+	// Artifacts are pushed to the clients before each run, without a version the latest is taken at the time of the run
+	Artifacts []models.JobArtifact `json:"artifacts,omitempty" db:"-"`
+	// ClientTimezone evaluates the schedule in the timezone reported by each client instead of the server's
+	ClientTimezone bool `json:"client_timezone" db:"-"`
 }
 
 func (d *Details) Scan(value interface{}) error {
@@ -103,6 +123,42 @@ func (d Details) Value() (driver.Value, error) {
 	return string(b), nil
 }
 
+// MaintenanceDecision records on which clients the last run of a schedule restricted to maintenance windows ran, the
+// clients outside of their windows were skipped or deferred.
+type MaintenanceDecision struct {
+	Time          time.Time  `json:"time"`
+	Ran           []string   `json:"ran"`
+	Skipped       []string   `json:"skipped"`
+	Deferred      []string   `json:"deferred"`
+	DeferredUntil *time.Time `json:"deferred_until"`
+}
+
+func (d *MaintenanceDecision) Scan(value interface{}) error {
+	if d == nil {
+		return errors.New("'last_maintenance_decision' cannot be nil")
+	}
+	valueStr, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("expected to have string, got %T", value)
+	}
+	err := json.Unmarshal([]byte(valueStr), d)
+	if err != nil {
+		return fmt.Errorf("failed to decode 'last_maintenance_decision' field: %v", err)
+	}
+	return nil
+}
+
+func (d *MaintenanceDecision) Value() (driver.Value, error) {
+	if d == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(d)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode 'last_maintenance_decision' field: %v", err)
+	}
+	return string(b), nil
+}
+
 // All fields must be pointers, because when there's no execution yet the values will be nil
 type Execution struct {
 	StartedAt    *time.Time `db:"last_started_at" json:"started_at"`
@@ -155,6 +155,12 @@ func (p *SQLiteProvider) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+// SaveMaintenanceDecision sets the maintenance decision of the last run of the schedule.
+func (p *SQLiteProvider) SaveMaintenanceDecision(ctx context.Context, id string, d *MaintenanceDecision) error {
+	_, err := p.db.ExecContext(ctx, "UPDATE schedules SET last_maintenance_decision = ? WHERE id = ?", d, id)
+	return err
+}
+
 // CountJobsInProgress counts jobs for scheduleID that have not finished and are not timed out
 func (p *SQLiteProvider) CountJobsInProgress(ctx context.Context, scheduleID string, timeoutSec int) (int, error) {
 	var result int
@@ -0,0 +1,46 @@
+package jobs
+
+import (
+	"context"
+	"time"
+)
+
+// CountByClient returns the number of jobs per client started within the given time range, from is inclusive.
+func (p *SqliteProvider) CountByClient(ctx context.Context, from, to time.Time) (map[string]int64, error) {
+	var rows []struct {
+		ClientID string `db:"client_id"`
+		Count    int64  `db:"count"`
+	}
+	err := p.db.SelectContext(
+		ctx,
+		&rows,
+		"SELECT client_id, COUNT(*) AS count FROM jobs WHERE datetime(started_at) >= datetime(?) AND datetime(started_at) < datetime(?) GROUP BY client_id",
+		from.UTC(),
+		to.UTC(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]int64, len(rows))
+	for _, r := range rows {
+		res[r.ClientID] = r.Count
+	}
+	return res, nil
+}
+
+// StorageByClient returns the bytes of the job details and results stored per client.
+func (p *SqliteProvider) StorageByClient(ctx context.Context) (map[string]int64, error) {
+	var rows []struct {
+		ClientID string `db:"client_id"`
+		Bytes    int64  `db:"bytes"`
+	}
+	err := p.db.SelectContext(ctx, &rows, "SELECT client_id, SUM(LENGTH(details)) AS bytes FROM jobs GROUP BY client_id")
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]int64, len(rows))
+	for _, r := range rows {
+		res[r.ClientID] = r.Bytes
+	}
+	return res, nil
+}
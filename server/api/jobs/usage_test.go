@@ -0,0 +1,46 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/db/migration/jobs"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	"github.com/IOTech17/neo-rport/server/test/jb"
+)
+
+func TestUsageByClient(t *testing.T) {
+	ctx := context.Background()
+	jobsDB, err := sqlite.New(":memory:", jobs.AssetNames(), jobs.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	p := NewSqliteProvider(jobsDB, testLog)
+	defer p.Close()
+
+	from := time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	cet := time.FixedZone("CET", 3600)
+	for _, job := range []struct {
+		clientID  string
+		startedAt time.Time
+	}{
+		{"c1", from.Add(-time.Second)},
+		{"c1", from},
+		{"c1", from.Add(time.Minute)},
+		{"c2", from.Add(30 * time.Second).In(cet)},
+		{"c2", from.Add(time.Minute).In(cet)},
+	} {
+		require.NoError(t, p.SaveJob(jb.New(t).ClientID(job.clientID).StartedAt(job.startedAt).Build()))
+	}
+
+	counts, err := p.CountByClient(ctx, from, from.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"c1": 1, "c2": 1}, counts)
+
+	storage, err := p.StorageByClient(ctx)
+	require.NoError(t, err)
+	assert.Len(t, storage, 2)
+	assert.Greater(t, storage["c1"], storage["c2"])
+}
@@ -0,0 +1,151 @@
+package library
+
+import (
+	"fmt"
+	"net/http"
+
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/share/query"
+)
+
+// Visibility of a library item, items without visibility are global.
+const (
+	VisibilityPrivate = "private"
+	VisibilityGroup   = "group"
+	VisibilityGlobal  = "global"
+)
+
+const (
+	FieldCreatedBy    = "created_by"
+	FieldVisibility   = "visibility"
+	FieldSharedGroups = "shared_groups"
+)
+
+var accessFields = []string{FieldCreatedBy, FieldVisibility, FieldSharedGroups}
+
+var ErrAccessDenied = errors2.APIError{
+	Message:    "the item is not shared with you",
+	HTTPStatus: http.StatusForbidden,
+}
+
+// User is the user accessing a library item.
+type User interface {
+	GetUsername() string
+	GetGroups() []string
+	IsAdmin() bool
+}
+
+// Sharing holds the ownership and share settings of a library item.
+type Sharing struct {
+	CreatedBy    string
+	Visibility   string
+	SharedGroups []string
+}
+
+// CanAccess returns true if the user is allowed to read and execute the item. Admins and the owner have access to
+// all items, other users to global items and to items shared with one of their groups.
+func (s Sharing) CanAccess(user User) bool {
+	if s.CanChange(user) {
+		return true
+	}
+	switch s.visibility() {
+	case VisibilityGlobal:
+		return true
+	case VisibilityGroup:
+		for _, userGroup := range user.GetGroups() {
+			for _, group := range s.SharedGroups {
+				if userGroup == group {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// CanChange returns true if the user is allowed to change the share settings of the item.
+func (s Sharing) CanChange(user User) bool {
+	return user.IsAdmin() || s.CreatedBy == user.GetUsername()
+}
+
+// ValidateSharing validates the share settings of an item to store.
+func ValidateSharing(visibility string, sharedGroups []string) error {
+	switch visibility {
+	case "", VisibilityGlobal, VisibilityPrivate:
+		if len(sharedGroups) > 0 {
+			return fmt.Errorf("shared_groups are only allowed with visibility %q", VisibilityGroup)
+		}
+	case VisibilityGroup:
+		if len(sharedGroups) == 0 {
+			return fmt.Errorf("shared_groups are required with visibility %q", VisibilityGroup)
+		}
+	default:
+		return fmt.Errorf("invalid visibility %q, supported are %q, %q and %q", visibility, VisibilityPrivate, VisibilityGroup, VisibilityGlobal)
+	}
+	return nil
+}
+
+// WithAccessFields adds the fields needed to check the access to the requested fields of the resource. It returns
+// the added fields, they have to be removed from the result.
+func WithAccessFields(fields []query.FieldsOption, resource string) ([]query.FieldsOption, []string) {
+	var result []query.FieldsOption
+	var added []string
+	for _, fo := range fields {
+		if fo.Resource != resource {
+			result = append(result, fo)
+			continue
+		}
+		requested := make(map[string]bool, len(fo.Fields))
+		for _, field := range fo.Fields {
+			requested[field] = true
+		}
+		withAccess := append([]string{}, fo.Fields...)
+		for _, field := range accessFields {
+			if !requested[field] {
+				withAccess = append(withAccess, field)
+				added = append(added, field)
+			}
+		}
+		result = append(result, query.FieldsOption{Resource: fo.Resource, Fields: withAccess})
+	}
+	return result, added
+}
+
+// UpdatedSharing returns the share settings of an updated item. The current settings are kept if no visibility is
+// given, only users allowed to change the settings can change them.
+func UpdatedSharing(current Sharing, visibility string, sharedGroups []string, user User) (Sharing, error) {
+	if visibility == "" {
+		return current, nil
+	}
+	updated := Sharing{
+		CreatedBy:    current.CreatedBy,
+		Visibility:   visibility,
+		SharedGroups: sharedGroups,
+	}
+	if !current.sameSettings(updated) && !current.CanChange(user) {
+		return current, errors2.APIError{
+			Message:    "only the owner of the item can change its share settings",
+			HTTPStatus: http.StatusForbidden,
+		}
+	}
+	return updated, nil
+}
+
+func (s Sharing) sameSettings(other Sharing) bool {
+	if s.visibility() != other.visibility() || len(s.SharedGroups) != len(other.SharedGroups) {
+		return false
+	}
+	for i := range s.SharedGroups {
+		if s.SharedGroups[i] != other.SharedGroups[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s Sharing) visibility() string {
+	if s.Visibility == "" {
+		return VisibilityGlobal
+	}
+	return s.Visibility
+}
@@ -0,0 +1,108 @@
+package library
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/share/query"
+)
+
+func TestCanAccess(t *testing.T) {
+	admin := &users.User{Username: "admin", Groups: []string{users.Administrators}}
+	owner := &users.User{Username: "owner", Groups: []string{"ops"}}
+	opsMember := &users.User{Username: "ops-member", Groups: []string{"ops"}}
+	other := &users.User{Username: "other", Groups: []string{"dev"}}
+
+	testCases := []struct {
+		name    string
+		sharing Sharing
+		allowed []*users.User
+		denied  []*users.User
+	}{
+		{
+			name:    "global",
+			sharing: Sharing{CreatedBy: "owner", Visibility: VisibilityGlobal},
+			allowed: []*users.User{admin, owner, opsMember, other},
+		},
+		{
+			name:    "no visibility",
+			sharing: Sharing{CreatedBy: "owner"},
+			allowed: []*users.User{admin, owner, opsMember, other},
+		},
+		{
+			name:    "group",
+			sharing: Sharing{CreatedBy: "owner", Visibility: VisibilityGroup, SharedGroups: []string{"ops"}},
+			allowed: []*users.User{admin, owner, opsMember},
+			denied:  []*users.User{other},
+		},
+		{
+			name:    "private",
+			sharing: Sharing{CreatedBy: "owner", Visibility: VisibilityPrivate},
+			allowed: []*users.User{admin, owner},
+			denied:  []*users.User{opsMember, other},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, user := range tc.allowed {
+				assert.True(t, tc.sharing.CanAccess(user), user.Username)
+			}
+			for _, user := range tc.denied {
+				assert.False(t, tc.sharing.CanAccess(user), user.Username)
+			}
+		})
+	}
+}
+
+func TestValidateSharing(t *testing.T) {
+	assert.NoError(t, ValidateSharing("", nil))
+	assert.NoError(t, ValidateSharing(VisibilityPrivate, nil))
+	assert.NoError(t, ValidateSharing(VisibilityGroup, []string{"ops"}))
+	assert.EqualError(t, ValidateSharing(VisibilityGroup, nil), `shared_groups are required with visibility "group"`)
+	assert.EqualError(t, ValidateSharing(VisibilityGlobal, []string{"ops"}), `shared_groups are only allowed with visibility "group"`)
+	assert.EqualError(t, ValidateSharing("team", nil), `invalid visibility "team", supported are "private", "group" and "global"`)
+}
+
+func TestUpdatedSharing(t *testing.T) {
+	current := Sharing{CreatedBy: "owner", Visibility: VisibilityGroup, SharedGroups: []string{"ops"}}
+	owner := &users.User{Username: "owner"}
+	opsMember := &users.User{Username: "ops-member", Groups: []string{"ops"}}
+
+	updated, err := UpdatedSharing(current, "", nil, opsMember)
+	require.NoError(t, err)
+	assert.Equal(t, current, updated)
+
+	updated, err = UpdatedSharing(current, VisibilityGroup, []string{"ops"}, opsMember)
+	require.NoError(t, err)
+	assert.Equal(t, current, updated)
+
+	_, err = UpdatedSharing(current, VisibilityGlobal, nil, opsMember)
+	assert.EqualError(t, err, "only the owner of the item can change its share settings")
+
+	updated, err = UpdatedSharing(current, VisibilityPrivate, nil, owner)
+	require.NoError(t, err)
+	assert.Equal(t, Sharing{CreatedBy: "owner", Visibility: VisibilityPrivate}, updated)
+}
+
+func TestWithAccessFields(t *testing.T) {
+	fields := []query.FieldsOption{
+		{Resource: "scripts", Fields: []string{"id", "created_by"}},
+		{Resource: "other", Fields: []string{"id"}},
+	}
+
+	withAccess, added := WithAccessFields(fields, "scripts")
+	assert.Equal(t, []query.FieldsOption{
+		{Resource: "scripts", Fields: []string{"id", "created_by", "visibility", "shared_groups"}},
+		{Resource: "other", Fields: []string{"id"}},
+	}, withAccess)
+	assert.Equal(t, []string{"visibility", "shared_groups"}, added)
+	assert.Equal(t, []string{"id", "created_by"}, fields[0].Fields)
+
+	withAccess, added = WithAccessFields(nil, "scripts")
+	assert.Nil(t, withAccess)
+	assert.Nil(t, added)
+}
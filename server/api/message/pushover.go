@@ -26,10 +26,10 @@ func NewPushoverService(apiToken string) *PushoverService {
 const pushoverAPISuccessStatus = 1
 
 func (s *PushoverService) Send(ctx context.Context, data Data) error {
-	body := fmt.Sprintf(`Your RPort 2fa token:<b>%s</b>
+	body := fmt.Sprintf(`Your %s 2fa token:<b>%s</b>
 requested from %s
 <i>with %s</i>
-<i>valid for %.0f seconds</i>.`, data.Token, data.RemoteAddress, data.UserAgent, data.TTL.Seconds())
+<i>valid for %.0f seconds</i>.`, data.Branding.GetProductName(), data.Token, data.RemoteAddress, data.UserAgent, data.TTL.Seconds())
 	pMsg := &pushover.Message{
 		Title:     data.Title,
 		Message:   body,
@@ -3,6 +3,8 @@ package message
 import (
 	"context"
 	"time"
+
+	"github.com/IOTech17/neo-rport/server/branding"
 )
 
 type Data struct {
@@ -12,6 +14,7 @@ type Data struct {
 	UserAgent     string
 	RemoteAddress string
 	TTL           time.Duration
+	Branding      branding.Branding
 }
 
 type Service interface {
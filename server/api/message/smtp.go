@@ -42,14 +42,14 @@ func NewSMTPService(hostPort, username, password, fromEmail string, withTLS bool
 }
 
 func (s *SMTPService) Send(ctx context.Context, data Data) error {
-	message := fmt.Sprintf(`You have requested a token for the login to your RPort server.
+	message := fmt.Sprintf(`You have requested a token for the login to your %s server.
 The token is: %s
 
 The token has been requested from %s
 with user agent %s.
-Token is valid for %.0f seconds.`, data.Token, data.RemoteAddress, data.UserAgent, data.TTL.Seconds())
+Token is valid for %.0f seconds.`, data.Branding.GetProductName(), data.Token, data.RemoteAddress, data.UserAgent, data.TTL.Seconds())
 	e := &email.Email{
-		From:    s.From,
+		From:    data.Branding.Sender(s.From),
 		To:      []string{data.SendTo},
 		Subject: data.Title,
 		Text:    []byte(message),
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	chshare "github.com/IOTech17/neo-rport/share"
+)
+
+// TrustedProxies replaces the remote address of requests from trusted proxies by the address of the client they
+// forward, the rightmost address of the X-Forwarded-For header that is not a trusted proxy. The header is removed in
+// any case, so clients can't spoof their address by sending it directly.
+func TrustedProxies(trusted []*net.IPNet) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.RemoteAddr = forwardedRemoteAddr(trusted, r.RemoteAddr, r.Header.Values("X-Forwarded-For"))
+			r.Header.Del("X-Forwarded-For")
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func forwardedRemoteAddr(trusted []*net.IPNet, remoteAddr string, forwardedFor []string) string {
+	host, port, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !chshare.IsTrustedProxy(trusted, ip) {
+		return remoteAddr
+	}
+
+	var hops []string
+	for _, v := range forwardedFor {
+		hops = append(hops, strings.Split(v, ",")...)
+	}
+	client := ""
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			// addresses left of an invalid one can't be trusted
+			break
+		}
+		client = hop.String()
+		if !chshare.IsTrustedProxy(trusted, hop) {
+			break
+		}
+	}
+	if client == "" {
+		return remoteAddr
+	}
+	return net.JoinHostPort(client, port)
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	chshare "github.com/IOTech17/neo-rport/share"
+)
+
+func TestTrustedProxies(t *testing.T) {
+	trusted, err := chshare.ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.1"})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name               string
+		remoteAddr         string
+		forwardedFor       []string
+		expectedRemoteAddr string
+	}{
+		{
+			name:               "no proxy",
+			remoteAddr:         "198.51.100.7:4000",
+			expectedRemoteAddr: "198.51.100.7:4000",
+		},
+		{
+			name:               "untrusted peer",
+			remoteAddr:         "198.51.100.7:4000",
+			forwardedFor:       []string{"203.0.113.5"},
+			expectedRemoteAddr: "198.51.100.7:4000",
+		},
+		{
+			name:               "trusted proxy",
+			remoteAddr:         "10.1.2.3:4000",
+			forwardedFor:       []string{"203.0.113.5"},
+			expectedRemoteAddr: "203.0.113.5:4000",
+		},
+		{
+			name:               "chain of proxies",
+			remoteAddr:         "10.1.2.3:4000",
+			forwardedFor:       []string{"1.1.1.1, 203.0.113.5", "192.0.2.1"},
+			expectedRemoteAddr: "203.0.113.5:4000",
+		},
+		{
+			name:               "invalid hop",
+			remoteAddr:         "10.1.2.3:4000",
+			forwardedFor:       []string{"203.0.113.5, unknown, 10.0.0.2"},
+			expectedRemoteAddr: "10.0.0.2:4000",
+		},
+		{
+			name:               "trusted proxy without header",
+			remoteAddr:         "10.1.2.3:4000",
+			expectedRemoteAddr: "10.1.2.3:4000",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var gotRemoteAddr, gotForwardedFor string
+			h := TrustedProxies(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotRemoteAddr = r.RemoteAddr
+				gotForwardedFor = r.Header.Get("X-Forwarded-For")
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tc.remoteAddr
+			for _, v := range tc.forwardedFor {
+				req.Header.Add("X-Forwarded-For", v)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tc.expectedRemoteAddr, gotRemoteAddr)
+			assert.Empty(t, gotForwardedFor)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/share/models"
 )
 
 // SuccessPayload represents a uniform format for all successful API responses.
@@ -11,6 +12,8 @@ type SuccessPayload struct {
 	Data  interface{} `json:"data"`
 	Meta  *Meta       `json:"meta,omitempty"`
 	Links Links       `json:"links,omitempty"`
+	// Warnings are problems the client should be made aware of without failing the request.
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 func NewSuccessPayload(data interface{}) SuccessPayload {
@@ -65,11 +68,13 @@ func newAPIErrorPayload(err errors2.APIError) ErrorPayload {
 func newAPIErrorPayloadItem(err errors2.APIError) ErrorPayloadItem {
 	if err.Err != nil && err.Message != "" {
 		return ErrorPayloadItem{
+			Code:   err.ErrCode,
 			Title:  err.Message,
 			Detail: err.Err.Error(),
 		}
 	}
 	return ErrorPayloadItem{
+		Code:   err.ErrCode,
 		Title:  err.Error(),
 		Detail: "",
 	}
@@ -86,14 +91,17 @@ func newAPIErrorsPayload(errors errors2.APIErrors) ErrorPayload {
 }
 
 type ExecuteInput struct {
-	Command     string `json:"command"`
-	Script      string `json:"script"`
-	Interpreter string `json:"interpreter"`
-	Cwd         string `json:"cwd"`
-	IsSudo      bool   `json:"is_sudo"`
-	TimeoutSec  int    `json:"timeout_sec"`
-	ClientID    string
-	IsScript    bool
+	Command      string               `json:"command"`
+	Script       string               `json:"script"`
+	Interpreter  string               `json:"interpreter"`
+	Cwd          string               `json:"cwd"`
+	IsSudo       bool                 `json:"is_sudo"`
+	TimeoutSec   int                  `json:"timeout_sec"`
+	OutputParser *models.OutputParser `json:"output_parser"`
+	Lock         string               `json:"lock"`
+	Artifacts    []models.JobArtifact `json:"artifacts"`
+	ClientID     string
+	IsScript     bool
 }
 
 type Meta struct {
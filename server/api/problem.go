@@ -0,0 +1,105 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ProblemContentType is the content type of problem details.
+const ProblemContentType = "application/problem+json"
+
+// problemTypePrefix makes a problem type URI of an error code.
+const problemTypePrefix = "urn:rport:error:"
+
+// Error codes of errors that don't have a more specific one.
+const (
+	ErrCodeInvalidRequest   = "ERR_CODE_INVALID_REQUEST"
+	ErrCodeUnauthorized     = "ERR_CODE_UNAUTHORIZED"
+	ErrCodeForbidden        = "ERR_CODE_FORBIDDEN"
+	ErrCodeNotFound         = "ERR_CODE_NOT_FOUND"
+	ErrCodeMethodNotAllowed = "ERR_CODE_METHOD_NOT_ALLOWED"
+	ErrCodeConflict         = "ERR_CODE_CONFLICT"
+	ErrCodeGone             = "ERR_CODE_GONE"
+	ErrCodeRequestTooLarge  = "ERR_CODE_REQUEST_TOO_LARGE"
+	ErrCodeUnprocessable    = "ERR_CODE_UNPROCESSABLE"
+	ErrCodeLocked           = "ERR_CODE_LOCKED"
+	ErrCodeTooManyRequests  = "ERR_CODE_TOO_MANY_REQUESTS"
+	ErrCodeInternal         = "ERR_CODE_INTERNAL"
+	ErrCodeNotImplemented   = "ERR_CODE_NOT_IMPLEMENTED"
+	ErrCodeBadGateway       = "ERR_CODE_BAD_GATEWAY"
+	ErrCodeUnavailable      = "ERR_CODE_UNAVAILABLE"
+	ErrCodeGatewayTimeout   = "ERR_CODE_GATEWAY_TIMEOUT"
+)
+
+// statusErrCodes are the error codes of errors that don't have a more specific one.
+var statusErrCodes = map[int]string{
+	http.StatusBadRequest:            ErrCodeInvalidRequest,
+	http.StatusUnauthorized:          ErrCodeUnauthorized,
+	http.StatusForbidden:             ErrCodeForbidden,
+	http.StatusNotFound:              ErrCodeNotFound,
+	http.StatusMethodNotAllowed:      ErrCodeMethodNotAllowed,
+	http.StatusConflict:              ErrCodeConflict,
+	http.StatusGone:                  ErrCodeGone,
+	http.StatusRequestEntityTooLarge: ErrCodeRequestTooLarge,
+	http.StatusUnprocessableEntity:   ErrCodeUnprocessable,
+	http.StatusLocked:                ErrCodeLocked,
+	http.StatusTooManyRequests:       ErrCodeTooManyRequests,
+	http.StatusInternalServerError:   ErrCodeInternal,
+	http.StatusNotImplemented:        ErrCodeNotImplemented,
+	http.StatusBadGateway:            ErrCodeBadGateway,
+	http.StatusServiceUnavailable:    ErrCodeUnavailable,
+	http.StatusGatewayTimeout:        ErrCodeGatewayTimeout,
+}
+
+// Problem is an error response in the format of RFC 7807 problem details.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Code is the machine-readable error code, it doesn't change between releases
+	Code string `json:"code"`
+	// Errors are all errors if the request failed for more than one reason, e.g. several invalid fields
+	Errors []ErrorPayloadItem `json:"errors,omitempty"`
+}
+
+// StatusErrCode returns the error code of errors with the given status that don't have a more specific one.
+func StatusErrCode(status int) string {
+	if code, ok := statusErrCodes[status]; ok {
+		return code
+	}
+	return fmt.Sprintf("ERR_CODE_HTTP_%d", status)
+}
+
+// NewProblem returns the problem details of an error payload. The first error describes the problem.
+func NewProblem(status int, payload ErrorPayload, instance string) Problem {
+	p := Problem{
+		Status:   status,
+		Instance: instance,
+	}
+	if len(payload.Errors) > 0 {
+		first := payload.Errors[0]
+		p.Code = first.Code
+		p.Title = first.Title
+		p.Detail = first.Detail
+	}
+	if p.Code == "" {
+		p.Code = StatusErrCode(status)
+	}
+	if len(payload.Errors) > 1 {
+		p.Errors = make([]ErrorPayloadItem, 0, len(payload.Errors))
+		for _, item := range payload.Errors {
+			if item.Code == "" {
+				item.Code = StatusErrCode(status)
+			}
+			p.Errors = append(p.Errors, item)
+		}
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(status)
+	}
+	p.Type = problemTypePrefix + strings.ToLower(strings.TrimPrefix(p.Code, "ERR_CODE_"))
+	return p
+}
@@ -0,0 +1,75 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type ConcurrentSessionsPolicy string
+
+const (
+	// ConcurrentSessionsAllow puts no limit on the number of sessions.
+	ConcurrentSessionsAllow ConcurrentSessionsPolicy = "allow"
+	// ConcurrentSessionsLimit rejects new logins if the user already has the maximum number of sessions.
+	ConcurrentSessionsLimit ConcurrentSessionsPolicy = "limit"
+	// ConcurrentSessionsKickOldest ends the least recently used sessions to make room for a new login.
+	ConcurrentSessionsKickOldest ConcurrentSessionsPolicy = "kick_oldest"
+)
+
+func (p ConcurrentSessionsPolicy) Validate(maxSessions int) error {
+	switch p {
+	case ConcurrentSessionsAllow:
+		return nil
+	case ConcurrentSessionsLimit, ConcurrentSessionsKickOldest:
+		if maxSessions < 1 {
+			return fmt.Errorf("policy %q requires max sessions of at least 1", p)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid concurrent sessions policy %q, must be one of %q, %q or %q",
+			p, ConcurrentSessionsAllow, ConcurrentSessionsLimit, ConcurrentSessionsKickOldest)
+	}
+}
+
+// UserPolicy overrides the concurrent sessions policy of the server config for a single user.
+type UserPolicy struct {
+	Username    string                   `json:"username" db:"username"`
+	Policy      ConcurrentSessionsPolicy `json:"policy" db:"policy"`
+	MaxSessions int                      `json:"max_sessions" db:"max_sessions"`
+}
+
+// LoginDevice is a combination of ip address and user agent a user logged in from.
+type LoginDevice struct {
+	Username    string    `db:"username"`
+	IPAddress   string    `db:"ip_address"`
+	UserAgent   string    `db:"user_agent"`
+	FirstSeenAt time.Time `db:"first_seen_at"`
+	LastSeenAt  time.Time `db:"last_seen_at"`
+}
+
+// LoginDeviceStatus tells what was unknown about a login device before it was recorded.
+type LoginDeviceStatus struct {
+	FirstLogin bool
+	NewIP      bool
+	NewDevice  bool
+}
+
+type PolicyProvider interface {
+	GetPolicy(ctx context.Context, username string) (found bool, policy UserPolicy, err error)
+	SavePolicy(ctx context.Context, policy UserPolicy) error
+	DeletePolicy(ctx context.Context, username string) error
+	// TouchLoginDevice records the device and returns whether its ip address or user agent were seen before.
+	TouchLoginDevice(ctx context.Context, device LoginDevice) (LoginDeviceStatus, error)
+	// ListLoginDevices returns the devices of the user, the most recently used first.
+	ListLoginDevices(ctx context.Context, username string) ([]LoginDevice, error)
+}
+
+// SessionsToKick returns the sessions to end so that a new session doesn't exceed maxSessions.
+// The sessions must be sorted by last access, the least recently used first.
+func SessionsToKick(sessions []APISession, maxSessions int) []APISession {
+	if len(sessions) < maxSessions {
+		return nil
+	}
+	return sessions[:len(sessions)-maxSessions+1]
+}
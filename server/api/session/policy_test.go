@@ -0,0 +1,103 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrentSessionsPolicyValidate(t *testing.T) {
+	assert.NoError(t, ConcurrentSessionsAllow.Validate(0))
+	assert.NoError(t, ConcurrentSessionsLimit.Validate(2))
+	assert.NoError(t, ConcurrentSessionsKickOldest.Validate(1))
+	assert.EqualError(t, ConcurrentSessionsLimit.Validate(0), `policy "limit" requires max sessions of at least 1`)
+	assert.EqualError(t, ConcurrentSessionsPolicy("deny").Validate(1), `invalid concurrent sessions policy "deny", must be one of "allow", "limit" or "kick_oldest"`)
+}
+
+func TestSessionsToKick(t *testing.T) {
+	sessions := []APISession{{SessionID: 1}, {SessionID: 2}, {SessionID: 3}}
+
+	assert.Equal(t, []APISession{{SessionID: 1}}, SessionsToKick(sessions, 3))
+	assert.Equal(t, []APISession{{SessionID: 1}, {SessionID: 2}, {SessionID: 3}}, SessionsToKick(sessions, 1))
+	assert.Empty(t, SessionsToKick(sessions, 4))
+	assert.Empty(t, SessionsToKick(nil, 1))
+}
+
+func TestPolicySqlite(t *testing.T) {
+	ctx := context.Background()
+	p := newInmemoryDB(t)
+
+	found, _, err := p.GetPolicy(ctx, "user1")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	policy := UserPolicy{Username: "user1", Policy: ConcurrentSessionsLimit, MaxSessions: 2}
+	require.NoError(t, p.SavePolicy(ctx, policy))
+	policy.MaxSessions = 3
+	require.NoError(t, p.SavePolicy(ctx, policy))
+
+	found, got, err := p.GetPolicy(ctx, "user1")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, policy, got)
+
+	require.NoError(t, p.DeletePolicy(ctx, "user1"))
+	found, _, err = p.GetPolicy(ctx, "user1")
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestTouchLoginDevice(t *testing.T) {
+	ctx := context.Background()
+	p := newInmemoryDB(t)
+	now := time.Now()
+
+	device := func(ip, userAgent string) LoginDevice {
+		return LoginDevice{Username: "user1", IPAddress: ip, UserAgent: userAgent, FirstSeenAt: now, LastSeenAt: now}
+	}
+
+	status, err := p.TouchLoginDevice(ctx, device("10.0.0.1", "firefox"))
+	require.NoError(t, err)
+	assert.Equal(t, LoginDeviceStatus{FirstLogin: true, NewIP: true, NewDevice: true}, status)
+
+	status, err = p.TouchLoginDevice(ctx, device("10.0.0.1", "firefox"))
+	require.NoError(t, err)
+	assert.Equal(t, LoginDeviceStatus{}, status)
+
+	status, err = p.TouchLoginDevice(ctx, device("10.0.0.2", "firefox"))
+	require.NoError(t, err)
+	assert.Equal(t, LoginDeviceStatus{NewIP: true}, status)
+
+	status, err = p.TouchLoginDevice(ctx, device("10.0.0.1", "curl"))
+	require.NoError(t, err)
+	assert.Equal(t, LoginDeviceStatus{NewDevice: true}, status)
+
+	status, err = p.TouchLoginDevice(ctx, LoginDevice{Username: "user2", IPAddress: "10.0.0.1", UserAgent: "firefox", FirstSeenAt: now, LastSeenAt: now})
+	require.NoError(t, err)
+	assert.True(t, status.FirstLogin)
+}
+
+func TestListLoginDevices(t *testing.T) {
+	ctx := context.Background()
+	p := newInmemoryDB(t)
+	now := time.Now().UTC()
+
+	for i, ip := range []string{"10.0.0.1", "10.0.0.2"} {
+		seen := now.Add(time.Duration(i) * time.Hour)
+		_, err := p.TouchLoginDevice(ctx, LoginDevice{Username: "user1", IPAddress: ip, UserAgent: "firefox", FirstSeenAt: seen, LastSeenAt: seen})
+		require.NoError(t, err)
+	}
+
+	devices, err := p.ListLoginDevices(ctx, "user1")
+	require.NoError(t, err)
+	require.Len(t, devices, 2)
+	assert.Equal(t, "10.0.0.2", devices[0].IPAddress)
+	assert.Equal(t, "10.0.0.1", devices[1].IPAddress)
+
+	devices, err = p.ListLoginDevices(ctx, "user2")
+	require.NoError(t, err)
+	assert.Empty(t, devices)
+}
@@ -0,0 +1,98 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+func (p *SqliteProvider) GetPolicy(ctx context.Context, username string) (found bool, policy UserPolicy, err error) {
+	err = p.db.GetContext(ctx, &policy, "SELECT * FROM user_session_policies WHERE username = ?", username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, UserPolicy{}, nil
+		}
+		return false, UserPolicy{}, fmt.Errorf("unable to get session policy from DB: %w", err)
+	}
+
+	return true, policy, nil
+}
+
+func (p *SqliteProvider) SavePolicy(ctx context.Context, policy UserPolicy) error {
+	_, err := p.db.NamedExecContext(
+		ctx,
+		"INSERT INTO user_session_policies (username, policy, max_sessions) VALUES (:username, :policy, :max_sessions)"+
+			" ON CONFLICT (username) DO UPDATE SET policy = excluded.policy, max_sessions = excluded.max_sessions",
+		policy,
+	)
+	if err != nil {
+		return fmt.Errorf("unable to save session policy: %w", err)
+	}
+
+	return nil
+}
+
+func (p *SqliteProvider) DeletePolicy(ctx context.Context, username string) error {
+	_, err := p.db.ExecContext(ctx, "DELETE FROM user_session_policies WHERE username = ?", username)
+	if err != nil {
+		return fmt.Errorf("unable to delete session policy: %w", err)
+	}
+
+	return nil
+}
+
+func (p *SqliteProvider) TouchLoginDevice(ctx context.Context, device LoginDevice) (status LoginDeviceStatus, err error) {
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return status, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var known, knownIP, knownUserAgent int
+	err = tx.QueryRowxContext(
+		ctx,
+		"SELECT COUNT(*), COUNT(CASE WHEN ip_address = ? THEN 1 END), COUNT(CASE WHEN user_agent = ? THEN 1 END)"+
+			" FROM user_login_devices WHERE username = ?",
+		device.IPAddress, device.UserAgent, device.Username,
+	).Scan(&known, &knownIP, &knownUserAgent)
+	if err != nil {
+		return status, fmt.Errorf("unable to get login devices: %w", err)
+	}
+
+	_, err = tx.NamedExecContext(
+		ctx,
+		"INSERT INTO user_login_devices (username, ip_address, user_agent, first_seen_at, last_seen_at)"+
+			" VALUES (:username, :ip_address, :user_agent, :first_seen_at, :last_seen_at)"+
+			" ON CONFLICT (username, ip_address, user_agent) DO UPDATE SET last_seen_at = excluded.last_seen_at",
+		device,
+	)
+	if err != nil {
+		return status, fmt.Errorf("unable to save login device: %w", err)
+	}
+
+	status = LoginDeviceStatus{
+		FirstLogin: known == 0,
+		NewIP:      knownIP == 0,
+		NewDevice:  knownUserAgent == 0,
+	}
+	return status, tx.Commit()
+}
+
+func (p *SqliteProvider) ListLoginDevices(ctx context.Context, username string) ([]LoginDevice, error) {
+	var devices []LoginDevice
+	err := p.db.SelectContext(
+		ctx,
+		&devices,
+		"SELECT * FROM user_login_devices WHERE username = ? ORDER BY last_seen_at DESC",
+		username,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list login devices: %w", err)
+	}
+
+	return devices, nil
+}
@@ -10,7 +10,18 @@ const (
 
 var AdministratorsGroup = Group{
 	Name:        Administrators,
-	Permissions: NewPermissions(AllPermissions...),
+	Permissions: NewPermissions(administratorPermissions()...),
+}
+
+// administratorPermissions are all permissions except the ones an admin must be granted explicitly by another group.
+func administratorPermissions() []string {
+	perms := make([]string, 0, len(AllPermissions))
+	for _, p := range AllPermissions {
+		if p != PermissionQuarantine {
+			perms = append(perms, p)
+		}
+	}
+	return perms
 }
 
 type (
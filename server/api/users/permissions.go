@@ -16,6 +16,8 @@ const (
 	PermissionMonitoring = "monitoring"
 	PermissionUploads    = "uploads"
 	PermissionsAuditLog  = "auditlog"
+	// PermissionQuarantine allows to lift the quarantine of a client, administrators don't get it by membership.
+	PermissionQuarantine = "quarantine"
 )
 
 var AllPermissions = []string{
@@ -27,6 +29,7 @@ var AllPermissions = []string{
 	PermissionMonitoring,
 	PermissionUploads,
 	PermissionsAuditLog,
+	PermissionQuarantine,
 }
 
 type Permissions struct {
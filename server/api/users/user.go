@@ -8,12 +8,30 @@ type User struct {
 	Groups          []string `json:"groups" db:"-"`
 	TwoFASendTo     string   `json:"two_fa_send_to" db:"two_fa_send_to"`
 	TotP            string   `json:"totp_secret,omitempty" db:"totp_secret"`
+	// AllowedClientGroups is set for requests authenticated by an API token restricted to some client groups.
+	AllowedClientGroups []string `json:"-" db:"-"`
+	// AllowedClients is set for requests authenticated by an API token restricted to some clients.
+	AllowedClients []string `json:"-" db:"-"`
+	// GrantedClientGroups are the client groups the user has temporary access to by approved access requests.
+	GrantedClientGroups []string `json:"-" db:"-"`
 }
 
 func (u User) GetGroups() []string {
 	return u.Groups
 }
 
+func (u User) GetAllowedClientGroups() []string {
+	return u.AllowedClientGroups
+}
+
+func (u User) GetAllowedClients() []string {
+	return u.AllowedClients
+}
+
+func (u User) GetGrantedClientGroups() []string {
+	return u.GrantedClientGroups
+}
+
 func (u User) GetUsername() string {
 	return u.Username
 }
@@ -0,0 +1,72 @@
+package chserver
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/routes"
+)
+
+// The v1 API stays compatible with upstream rport, so rportcli and the frontend keep working. Endpoints are only
+// added, existing ones keep their paths, parameters and payloads. An endpoint with a replacement stays available and
+// is listed in deprecatedRoutes, its responses carry the headers pointing consumers to the replacement.
+
+// deprecation describes a v1 endpoint that has a replacement.
+type deprecation struct {
+	// Since is the date the endpoint is deprecated
+	Since time.Time
+	// Sunset is the date the endpoint might be removed, zero if there are no plans to remove it
+	Sunset time.Time
+	// Replacement is the path of the endpoint to use instead
+	Replacement string
+}
+
+// deprecatedRoutes maps the path templates of deprecated endpoints to their deprecation.
+var deprecatedRoutes = map[string]deprecation{
+	routes.AllRoutesPrefix + "/me/token": {
+		Since:       time.Date(2026, time.October, 15, 0, 0, 0, 0, time.UTC),
+		Replacement: routes.AllRoutesPrefix + "/me/tokens",
+	},
+	routes.AllRoutesPrefix + "/me/token/{_}": {
+		Since:       time.Date(2026, time.October, 15, 0, 0, 0, 0, time.UTC),
+		Replacement: routes.AllRoutesPrefix + "/me/tokens/{" + routes.ParamTokenPrefix + "}",
+	},
+}
+
+// deprecationHeaders are read by API consumers, browsers need them exposed for cross-origin requests.
+var deprecationHeaders = []string{"Deprecation", "Sunset", "Link"}
+
+// wrapDeprecationMiddleware adds the Deprecation (RFC 9745), Sunset (RFC 8594) and Link headers to the responses of
+// deprecated endpoints.
+func (al *APIListener) wrapDeprecationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := mux.CurrentRoute(r)
+		if route == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		tpl, err := route.GetPathTemplate()
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		d, ok := deprecatedRoutes[tpl]
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Set("Deprecation", fmt.Sprintf("@%d", d.Since.Unix()))
+		if !d.Sunset.IsZero() {
+			h.Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+		}
+		h.Add("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", d.Replacement))
+		al.Debugf("deprecated endpoint %s %s requested by %q, replaced by %s", r.Method, tpl, r.UserAgent(), d.Replacement)
+
+		next.ServeHTTP(w, r)
+	})
+}
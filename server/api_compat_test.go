@@ -0,0 +1,185 @@
+package chserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/server/chconfig"
+)
+
+// upstreamV1Routes are the endpoints of the upstream rport API v1, they must stay available for its consumers.
+var upstreamV1Routes = []string{
+	"DELETE /api/v1/client-groups/{group_id}",
+	"DELETE /api/v1/clients-auth/{client_auth_id}",
+	"DELETE /api/v1/clients/{client_id}",
+	"DELETE /api/v1/clients/{client_id}/stored-tunnels/{tunnel_id}",
+	"DELETE /api/v1/clients/{client_id}/tunnels/{tunnel_id}",
+	"DELETE /api/v1/library/commands/{command_value_id}",
+	"DELETE /api/v1/library/scripts/{script_value_id}",
+	"DELETE /api/v1/logout",
+	"DELETE /api/v1/me/token/{_}",
+	"DELETE /api/v1/me/tokens/{prefix}",
+	"DELETE /api/v1/me/totp-secret",
+	"DELETE /api/v1/schedules/{schedule_id}",
+	"DELETE /api/v1/user-groups/{group_name}",
+	"DELETE /api/v1/users/{user_id}",
+	"DELETE /api/v1/users/{user_id}/sessions",
+	"DELETE /api/v1/users/{user_id}/sessions/{session_id}",
+	"DELETE /api/v1/users/{user_id}/totp-secret",
+	"DELETE /api/v1/vault-admin/sesame",
+	"DELETE /api/v1/vault/{vault_value_id}",
+	"GET /api/v1/auditlog",
+	"GET /api/v1/auth/ext/settings",
+	"GET /api/v1/auth/ext/settings/device",
+	"GET /api/v1/auth/provider",
+	"GET /api/v1/client-groups",
+	"GET /api/v1/client-groups/{group_id}",
+	"GET /api/v1/client-tags",
+	"GET /api/v1/clients",
+	"GET /api/v1/clients-auth",
+	"GET /api/v1/clients-auth/{client_auth_id}",
+	"GET /api/v1/clients/{client_id}",
+	"GET /api/v1/clients/{client_id}/attributes",
+	"GET /api/v1/clients/{client_id}/commands",
+	"GET /api/v1/clients/{client_id}/commands/{job_id}",
+	"GET /api/v1/clients/{client_id}/graph-metrics",
+	"GET /api/v1/clients/{client_id}/graph-metrics/{graph_name}",
+	"GET /api/v1/clients/{client_id}/metrics",
+	"GET /api/v1/clients/{client_id}/mountpoints",
+	"GET /api/v1/clients/{client_id}/processes",
+	"GET /api/v1/clients/{client_id}/stored-tunnels",
+	"GET /api/v1/commands",
+	"GET /api/v1/commands/{job_id}",
+	"GET /api/v1/commands/{job_id}/jobs",
+	"GET /api/v1/library/commands",
+	"GET /api/v1/library/commands/{command_value_id}",
+	"GET /api/v1/library/scripts",
+	"GET /api/v1/library/scripts/{script_value_id}",
+	"GET /api/v1/login",
+	"GET /api/v1/me",
+	"GET /api/v1/me/ip",
+	"GET /api/v1/me/token",
+	"GET /api/v1/me/tokens",
+	"GET /api/v1/me/totp-secret",
+	"GET /api/v1/notification-logs",
+	"GET /api/v1/notification-logs/{notification_id}",
+	"GET /api/v1/plus/status",
+	"GET /api/v1/schedules",
+	"GET /api/v1/schedules/{schedule_id}",
+	"GET /api/v1/status",
+	"GET /api/v1/tunnels",
+	"GET /api/v1/user-groups",
+	"GET /api/v1/user-groups/{group_name}",
+	"GET /api/v1/users",
+	"GET /api/v1/users/{user_id}/sessions",
+	"GET /api/v1/vault",
+	"GET /api/v1/vault-admin",
+	"GET /api/v1/vault/{vault_value_id}",
+	"GET /api/v1/ws/commands",
+	"GET /api/v1/ws/scripts",
+	"GET /api/v1/ws/uploads",
+	"POST /api/v1/client-groups",
+	"POST /api/v1/clients-auth",
+	"POST /api/v1/clients/{client_id}/acl",
+	"POST /api/v1/clients/{client_id}/commands",
+	"POST /api/v1/clients/{client_id}/scripts",
+	"POST /api/v1/clients/{client_id}/stored-tunnels",
+	"POST /api/v1/clients/{client_id}/updates-status",
+	"POST /api/v1/commands",
+	"POST /api/v1/files",
+	"POST /api/v1/library/commands",
+	"POST /api/v1/library/scripts",
+	"POST /api/v1/login",
+	"POST /api/v1/me/token",
+	"POST /api/v1/me/tokens",
+	"POST /api/v1/me/totp-secret",
+	"POST /api/v1/schedules",
+	"POST /api/v1/scripts",
+	"POST /api/v1/users",
+	"POST /api/v1/vault",
+	"POST /api/v1/vault-admin/init",
+	"POST /api/v1/vault-admin/sesame",
+	"POST /api/v1/verify-2fa",
+	"PUT /api/v1/client-groups/{group_id}",
+	"PUT /api/v1/clients/{client_id}/attributes",
+	"PUT /api/v1/clients/{client_id}/stored-tunnels/{tunnel_id}",
+	"PUT /api/v1/clients/{client_id}/tunnels",
+	"PUT /api/v1/clients/{client_id}/tunnels/{tunnel_id}/acl",
+	"PUT /api/v1/library/commands/{command_value_id}",
+	"PUT /api/v1/library/scripts/{script_value_id}",
+	"PUT /api/v1/me",
+	"PUT /api/v1/me/token/{_}",
+	"PUT /api/v1/me/tokens/{prefix}",
+	"PUT /api/v1/schedules/{schedule_id}",
+	"PUT /api/v1/user-groups/{group_name}",
+	"PUT /api/v1/users/{user_id}",
+	"PUT /api/v1/vault/{vault_value_id}",
+}
+
+func TestUpstreamV1RoutesAvailable(t *testing.T) {
+	al := APIListener{
+		insecureForTests: true,
+		Server: &Server{
+			config: &chconfig.Config{},
+		},
+	}
+	al.initRouter()
+
+	available := make(map[string]bool)
+	err := al.router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		tpl, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, _ := route.GetMethods()
+		for _, m := range methods {
+			available[m+" "+tpl] = true
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	for _, r := range upstreamV1Routes {
+		assert.True(t, available[r], "%s was removed, keep it available and add it to deprecatedRoutes instead", r)
+	}
+	for tpl, d := range deprecatedRoutes {
+		assert.False(t, d.Since.IsZero(), tpl)
+		assert.NotEmpty(t, d.Replacement, tpl)
+	}
+}
+
+func TestDeprecationHeaders(t *testing.T) {
+	al := APIListener{
+		Logger:           testLog,
+		insecureForTests: true,
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+				},
+			},
+		},
+	}
+	al.initRouter()
+
+	w := httptest.NewRecorder()
+	al.router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/me/token", nil))
+	assert.Equal(t, http.StatusGone, w.Code)
+	assert.Equal(t, "@1792022400", w.Header().Get("Deprecation"))
+	assert.Equal(t, `</api/v1/me/tokens>; rel="successor-version"`, w.Header().Get("Link"))
+	assert.Empty(t, w.Header().Get("Sunset"))
+
+	w = httptest.NewRecorder()
+	al.router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/v1/me/token/abc", nil))
+	assert.Equal(t, `</api/v1/me/tokens/{prefix}>; rel="successor-version"`, w.Header().Get("Link"))
+
+	w = httptest.NewRecorder()
+	al.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/me/ip", nil))
+	assert.Empty(t, w.Header().Get("Deprecation"))
+	assert.Empty(t, w.Header().Get("Link"))
+}
@@ -0,0 +1,81 @@
+package chserver
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/cgroups"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+)
+
+const queryParamDryRun = "dry_run"
+
+const (
+	changeDelete = "delete"
+	changeUpdate = "update"
+	changeJoin   = "join"
+	changeLeave  = "leave"
+)
+
+// affectedResource is a resource a destructive request changes.
+type affectedResource struct {
+	Type   string `json:"type"`
+	ID     string `json:"id"`
+	Change string `json:"change"`
+}
+
+type dryRunPayload struct {
+	DryRun   bool               `json:"dry_run"`
+	Affected []affectedResource `json:"affected"`
+}
+
+// parseDryRun returns true if the request has ?dry_run=true. Destructive endpoints supporting it validate the request
+// as usual, but only return the resources they would change.
+func parseDryRun(req *http.Request) (bool, error) {
+	v := req.URL.Query().Get(queryParamDryRun)
+	if v == "" {
+		return false, nil
+	}
+	dryRun, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, errors2.APIError{
+			Message:    fmt.Sprintf("invalid %s param %q", queryParamDryRun, v),
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+	return dryRun, nil
+}
+
+func (al *APIListener) writeDryRunResponse(w http.ResponseWriter, affected []affectedResource) {
+	if affected == nil {
+		affected = []affectedResource{}
+	}
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(dryRunPayload{
+		DryRun:   true,
+		Affected: affected,
+	}))
+}
+
+// clientGroupChanges returns the clients joining and leaving a group if its params change from old to updated, nil
+// stands for a group that doesn't exist.
+func clientGroupChanges(allClients []*clientdata.Client, old, updated *cgroups.ClientGroup) []affectedResource {
+	var result []affectedResource
+	for _, c := range allClients {
+		before := old != nil && c.BelongsTo(old)
+		after := updated != nil && c.BelongsTo(updated)
+		switch {
+		case after && !before:
+			result = append(result, affectedResource{Type: "client", ID: c.GetID(), Change: changeJoin})
+		case before && !after:
+			result = append(result, affectedResource{Type: "client", ID: c.GetID(), Change: changeLeave})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
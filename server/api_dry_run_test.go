@@ -0,0 +1,77 @@
+package chserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/server/cgroups"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/clients"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+)
+
+func TestDeleteClientDryRun(t *testing.T) {
+	c1 := clients.New(t).ID("client-1").ClientAuthID(cl1.ID).DisconnectedDuration(time.Minute).Logger(testLog).Build()
+	c2 := clients.New(t).ID("client-2").ClientAuthID(cl1.ID).Logger(testLog).Build()
+	clientService := clients.NewClientService(nil, nil, clients.NewClientRepository([]*clientdata.Client{c1, c2}, &hour, testLog), testLog, nil)
+	al := APIListener{
+		insecureForTests: true,
+		Server: &Server{
+			clientService: clientService,
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+				},
+			},
+			clientGroupProvider: mockClientGroupProvider{},
+		},
+	}
+	al.initRouter()
+
+	do := func(url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		al.router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, url, nil))
+		return w
+	}
+
+	w := do("/api/v1/clients/client-1?dry_run=true")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"data":{"dry_run":true,"affected":[{"type":"client","id":"client-1","change":"delete"}]}}`, w.Body.String())
+	assert.Equal(t, 2, clientService.Count())
+
+	w = do("/api/v1/clients/client-2?dry_run=true")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Client is active, should be disconnected")
+
+	w = do("/api/v1/clients/client-1?dry_run=maybe")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `invalid dry_run param \"maybe\"`)
+	assert.Equal(t, 2, clientService.Count())
+}
+
+func TestClientGroupChanges(t *testing.T) {
+	c1 := clients.New(t).ID("client-1").Logger(testLog).Build()
+	c2 := clients.New(t).ID("client-2").Logger(testLog).Build()
+	c3 := clients.New(t).ID("client-3").Logger(testLog).Build()
+	allClients := []*clientdata.Client{c3, c2, c1}
+	old := &cgroups.ClientGroup{ID: "group-1", Params: &cgroups.ClientParams{ClientID: &cgroups.ParamValues{"client-1", "client-2"}}}
+	updated := &cgroups.ClientGroup{ID: "group-1", Params: &cgroups.ClientParams{ClientID: &cgroups.ParamValues{"client-2", "client-3"}}}
+
+	assert.Equal(t, []affectedResource{
+		{Type: "client", ID: "client-1", Change: changeLeave},
+		{Type: "client", ID: "client-3", Change: changeJoin},
+	}, clientGroupChanges(allClients, old, updated))
+	assert.Equal(t, []affectedResource{
+		{Type: "client", ID: "client-2", Change: changeJoin},
+		{Type: "client", ID: "client-3", Change: changeJoin},
+	}, clientGroupChanges(allClients, nil, updated))
+	assert.Equal(t, []affectedResource{
+		{Type: "client", ID: "client-1", Change: changeLeave},
+		{Type: "client", ID: "client-2", Change: changeLeave},
+	}, clientGroupChanges(allClients, old, nil))
+}
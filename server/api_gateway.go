@@ -0,0 +1,132 @@
+package chserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+
+	"github.com/gorilla/handlers"
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/api/middleware"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/routes"
+	chshare "github.com/IOTech17/neo-rport/share"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/security"
+)
+
+// GatewaySecretHeader carries the shared secret of the API gateways to the connector.
+const GatewaySecretHeader = "X-Rport-Gateway-Secret"
+
+// APIGateway is used when running with role 'api'. It serves the UI and forwards all API requests,
+// including websockets, to the connector holding the client connections. It keeps no state of its own,
+// so it can be restarted or run multiple times without dropping client connections.
+type APIGateway struct {
+	*logger.Logger
+	config     *chconfig.Config
+	httpServer *chshare.HTTPServer
+	router     http.Handler
+}
+
+func NewAPIGateway(config *chconfig.Config) (*APIGateway, error) {
+	connectorURL, err := url.Parse(config.Server.ConnectorAPIURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid connector api url: %v", err)
+	}
+
+	var httpServerOptions []chshare.ServerOption
+	if config.API.CertFile != "" && config.API.KeyFile != "" {
+		httpServerOptions = []chshare.ServerOption{chshare.WithTLS(config.API.CertFile, config.API.KeyFile, security.TLSConfig(config.API.TLSMin))}
+	}
+	if config.API.ProxyProtocol {
+		httpServerOptions = append(httpServerOptions, chshare.WithProxyProtocol(config.Server.TrustedProxies))
+	}
+
+	l := logger.NewLogger("api-gateway", config.Logging.LogOutput, config.Logging.LogLevel)
+	g := &APIGateway{
+		Logger:     l,
+		config:     config,
+		httpServer: chshare.NewHTTPServer(int(config.API.MaxRequestBytes), l, httpServerOptions...),
+	}
+	g.router = g.newRouter(g.newProxy(connectorURL))
+
+	return g, nil
+}
+
+func (g *APIGateway) newProxy(connectorURL *url.URL) *httputil.ReverseProxy {
+	proxy := httputil.NewSingleHostReverseProxy(connectorURL)
+	director := proxy.Director
+	proxy.Director = func(req *http.Request) {
+		director(req)
+		req.Header.Del(GatewaySecretHeader)
+		if g.config.Server.GatewaySecret != "" {
+			req.Header.Set(GatewaySecretHeader, g.config.Server.GatewaySecret)
+		}
+		if req.Header.Get("X-Forwarded-Proto") == "" {
+			if req.TLS != nil {
+				req.Header.Set("X-Forwarded-Proto", "https")
+			} else {
+				req.Header.Set("X-Forwarded-Proto", "http")
+			}
+		}
+	}
+	// stream command outputs and file uploads without buffering
+	proxy.FlushInterval = -1
+	proxy.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+		g.Errorf("failed to forward %s %s to connector: %v", req.Method, req.URL.Path, err)
+		w.WriteHeader(http.StatusBadGateway)
+	}
+	return proxy
+}
+
+func (g *APIGateway) newRouter(proxy http.Handler) http.Handler {
+	r := mux.NewRouter()
+	for _, v := range apiVersions {
+		r.PathPrefix(v.Prefix).Handler(proxy)
+	}
+	// liveness is answered by the gateway itself, readiness depends on the connector
+	r.HandleFunc(routes.HealthzRoute, func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(api.NewSuccessPayload(HealthStatus{Status: HealthStatusOK}))
+	}).Methods(http.MethodGet, http.MethodHead)
+	r.Handle(routes.ReadyzRoute, proxy)
+
+	docRoot := g.config.API.DocRoot
+	if docRoot != "" {
+		r.PathPrefix("/").Handler(middleware.Rewrite404ForVueJs(http.FileServer(http.Dir(docRoot)), vueHistoryPaths))
+	} else {
+		r.PathPrefix("/").Handler(proxy)
+	}
+
+	r.Use(handlers.RecoveryHandler(
+		handlers.PrintRecoveryStack(true),
+		handlers.RecoveryLogger(middleware.NewRecoveryLogger(g.Logger)),
+	))
+	return r
+}
+
+// Run starts the gateway and blocks until it is stopped.
+func (g *APIGateway) Run(ctx context.Context) error {
+	g.Infof("API gateway listening on %s, forwarding to %s", g.config.API.Address, g.config.Server.ConnectorAPIURL)
+
+	h := http.Handler(g.router)
+	if len(g.config.Server.TrustedProxies) > 0 {
+		h = middleware.TrustedProxies(g.config.Server.TrustedProxies)(h)
+	}
+	err := g.httpServer.GoListenAndServe(ctx, g.config.API.Address, h)
+	if err != nil {
+		return err
+	}
+
+	return g.httpServer.Wait()
+}
+
+func (g *APIGateway) Close() error {
+	return g.httpServer.Close()
+}
@@ -0,0 +1,110 @@
+package chserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/server/chconfig"
+)
+
+func TestAPIGatewayForwardsToConnector(t *testing.T) {
+	var gotSecret, gotPath string
+	connector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSecret = r.Header.Get(GatewaySecretHeader)
+		gotPath = r.URL.Path
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer connector.Close()
+
+	connectorURL, err := url.Parse(connector.URL)
+	require.NoError(t, err)
+
+	g := &APIGateway{
+		Logger: testLog,
+		config: &chconfig.Config{
+			Server: chconfig.ServerConfig{
+				GatewaySecret: "the-secret",
+			},
+		},
+	}
+	router := g.newRouter(g.newProxy(connectorURL))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/clients", nil)
+	req.Header.Set(GatewaySecretHeader, "spoofed")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusTeapot, w.Code)
+	assert.Equal(t, "/api/v1/clients", gotPath)
+	assert.Equal(t, "the-secret", gotSecret)
+}
+
+func TestAPIGatewayConnectorUnavailable(t *testing.T) {
+	connectorURL, err := url.Parse("http://127.0.0.1:1")
+	require.NoError(t, err)
+
+	g := &APIGateway{
+		Logger: testLog,
+		config: &chconfig.Config{},
+	}
+	router := g.newRouter(g.newProxy(connectorURL))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/status", nil))
+
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+}
+
+func TestWrapGatewaySecretMiddleware(t *testing.T) {
+	al := &APIListener{
+		Server: &Server{
+			config: &chconfig.Config{
+				Server: chconfig.ServerConfig{
+					GatewaySecret: "the-secret",
+				},
+			},
+		},
+	}
+	handler := al.wrapGatewaySecretMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	testCases := []struct {
+		name           string
+		secret         string
+		expectedStatus int
+	}{
+		{
+			name:           "valid secret",
+			secret:         "the-secret",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "wrong secret",
+			secret:         "other",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "no secret",
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/status", nil)
+			if tc.secret != "" {
+				req.Header.Set(GatewaySecretHeader, tc.secret)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package chserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/accessrequests"
+	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/random"
+)
+
+type accessRequestRequest struct {
+	ClientGroupID   string `json:"client_group_id"`
+	Justification   string `json:"justification"`
+	DurationMinutes int    `json:"duration_minutes"`
+}
+
+type accessRequestDecision struct {
+	Comment string `json:"comment"`
+}
+
+// handlePostAccessRequest handles POST /access-requests
+func (al *APIListener) handlePostAccessRequest(w http.ResponseWriter, req *http.Request) {
+	var r accessRequestRequest
+	if err := parseRequestBody(req.Body, &r); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if r.ClientGroupID == "" || r.Justification == "" {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "client_group_id and justification are required.")
+		return
+	}
+	if r.DurationMinutes == 0 {
+		r.DurationMinutes = int(accessrequests.DefaultDuration / time.Minute)
+	}
+	if err := al.config.AccessRequests.ValidateDuration(r.DurationMinutes); err != nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	user, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if user.IsAdmin() {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "Administrators have access to all clients.")
+		return
+	}
+
+	group, err := al.clientGroupProvider.Get(req.Context(), r.ClientGroupID)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if group == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("client group %q not found", r.ClientGroupID))
+		return
+	}
+
+	pending, err := al.accessRequests.List(req.Context(), user.Username, accessrequests.StatusPending)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	for _, p := range pending {
+		if p.ClientGroupID == r.ClientGroupID {
+			al.jsonErrorResponseWithTitle(w, http.StatusConflict, fmt.Sprintf("access request %s for client group %q is already pending", p.ID, r.ClientGroupID))
+			return
+		}
+	}
+
+	id, err := random.UUID4()
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	accessRequest := &accessrequests.Request{
+		ID:              id,
+		Username:        user.Username,
+		ClientGroupID:   r.ClientGroupID,
+		Justification:   r.Justification,
+		DurationMinutes: r.DurationMinutes,
+		Status:          accessrequests.StatusPending,
+		CreatedAt:       time.Now().Truncate(time.Second).UTC(),
+	}
+	if err := al.accessRequests.Create(req.Context(), accessRequest); err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, "Failed to save the access request.", err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationAuthAccessRequest, auditlog.ActionCreate).
+		WithHTTPRequest(req).
+		WithID(id).
+		WithRequest(r).
+		Save()
+	al.accessNotifier.Notify(req.Context(), accessrequests.EventRequested, accessRequest)
+
+	al.writeJSONResponse(w, http.StatusCreated, api.NewSuccessPayload(accessRequest))
+}
+
+// handleListAccessRequests handles GET /access-requests, administrators get the requests of all users.
+func (al *APIListener) handleListAccessRequests(w http.ResponseWriter, req *http.Request) {
+	status := accessrequests.Status(req.URL.Query().Get("status"))
+	switch status {
+	case "", accessrequests.StatusPending, accessrequests.StatusApproved, accessrequests.StatusDenied,
+		accessrequests.StatusRevoked, accessrequests.StatusExpired:
+	default:
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("invalid status %q", status))
+		return
+	}
+
+	user, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	username := user.Username
+	if user.IsAdmin() {
+		username = ""
+	}
+
+	list, err := al.accessRequests.List(req.Context(), username, status)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(list))
+}
+
+// handleApproveAccessRequest handles POST /access-requests/{id}/approve
+func (al *APIListener) handleApproveAccessRequest(w http.ResponseWriter, req *http.Request) {
+	al.decideAccessRequest(w, req, accessrequests.StatusApproved)
+}
+
+// handleDenyAccessRequest handles POST /access-requests/{id}/deny
+func (al *APIListener) handleDenyAccessRequest(w http.ResponseWriter, req *http.Request) {
+	al.decideAccessRequest(w, req, accessrequests.StatusDenied)
+}
+
+func (al *APIListener) decideAccessRequest(w http.ResponseWriter, req *http.Request, status accessrequests.Status) {
+	var d accessRequestDecision
+	if err := parseRequestBody(req.Body, &d); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	accessRequest, ok := al.getAccessRequest(w, req, accessrequests.StatusPending)
+	if !ok {
+		return
+	}
+	username := api.GetUser(req.Context(), al.Logger)
+	if accessRequest.Username == username {
+		al.jsonErrorResponseWithTitle(w, http.StatusForbidden, "You can't decide on your own access request.")
+		return
+	}
+
+	now := time.Now().Truncate(time.Second).UTC()
+	var expiresAt *time.Time
+	if status == accessrequests.StatusApproved {
+		e := now.Add(accessRequest.Duration())
+		expiresAt = &e
+	}
+	decided, err := al.accessRequests.Decide(req.Context(), accessRequest.ID, status, username, d.Comment, now, expiresAt)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if !decided {
+		al.jsonErrorResponseWithTitle(w, http.StatusConflict, fmt.Sprintf("access request %q is not pending anymore", accessRequest.ID))
+		return
+	}
+	accessRequest.Status, accessRequest.DecidedBy, accessRequest.DecidedAt = status, username, &now
+	accessRequest.Comment, accessRequest.ExpiresAt = d.Comment, expiresAt
+
+	action, event := auditlog.ActionApprove, accessrequests.EventApproved
+	if status == accessrequests.StatusDenied {
+		action, event = auditlog.ActionDeny, accessrequests.EventDenied
+	}
+	al.auditLog.Entry(auditlog.ApplicationAuthAccessRequest, action).
+		WithHTTPRequest(req).
+		WithID(accessRequest.ID).
+		WithRequest(d).
+		Save()
+	al.accessNotifier.Notify(req.Context(), event, accessRequest)
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(accessRequest))
+}
+
+// handleDeleteAccessRequest handles DELETE /access-requests/{id}, it revokes an approved access before it expires.
+func (al *APIListener) handleDeleteAccessRequest(w http.ResponseWriter, req *http.Request) {
+	accessRequest, ok := al.getAccessRequest(w, req, accessrequests.StatusApproved)
+	if !ok {
+		return
+	}
+
+	username := api.GetUser(req.Context(), al.Logger)
+	now := time.Now().Truncate(time.Second).UTC()
+	revoked, err := al.accessRequests.Revoke(req.Context(), accessRequest.ID, username, now)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if !revoked {
+		al.jsonErrorResponseWithTitle(w, http.StatusConflict, fmt.Sprintf("access request %q is not approved anymore", accessRequest.ID))
+		return
+	}
+	accessRequest.Status, accessRequest.RevokedBy, accessRequest.RevokedAt = accessrequests.StatusRevoked, username, &now
+
+	al.auditLog.Entry(auditlog.ApplicationAuthAccessRequest, auditlog.ActionDelete).
+		WithHTTPRequest(req).
+		WithID(accessRequest.ID).
+		Save()
+	al.accessNotifier.Notify(req.Context(), accessrequests.EventRevoked, accessRequest)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getAccessRequest writes an error response and returns false if the request of the id doesn't exist or doesn't have
+// the given status.
+func (al *APIListener) getAccessRequest(w http.ResponseWriter, req *http.Request, status accessrequests.Status) (*accessrequests.Request, bool) {
+	id := mux.Vars(req)["id"]
+	accessRequest, err := al.accessRequests.Get(req.Context(), id)
+	if err != nil {
+		al.jsonError(w, err)
+		return nil, false
+	}
+	if accessRequest == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("access request %q not found", id))
+		return nil, false
+	}
+	if accessRequest.Status != status {
+		al.jsonErrorResponseWithTitle(w, http.StatusConflict, fmt.Sprintf("access request %q is %s", id, accessRequest.Status))
+		return nil, false
+	}
+	return accessRequest, true
+}
+
+// accessRequestsExpiryTask marks approved access requests expired once their access ended. The access itself ends at
+// the expiry in any case, the status is for review.
+type accessRequestsExpiryTask struct {
+	log *logger.Logger
+	al  *APIListener
+}
+
+func (t *accessRequestsExpiryTask) Run(ctx context.Context) error {
+	expired, err := t.al.accessRequests.Expire(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	if expired > 0 {
+		t.log.Infof("%d access request(s) expired", expired)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package chserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/db/migration/api_token"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	"github.com/IOTech17/neo-rport/server/accessrequests"
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/cgroups"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/clients"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/share/security"
+)
+
+func TestAccessRequests(t *testing.T) {
+	// password of all users is "pwd"
+	password := "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm"
+	apiUsers := []*users.User{
+		{Username: "admin", Password: password, Groups: []string{users.Administrators}},
+		{Username: "root", Password: password, Groups: []string{users.Administrators}},
+		{Username: "jane", Password: password, Groups: []string{"operators"}},
+	}
+	apiTokenDb, err := sqlite.New(":memory:", api_token.AssetNames(), api_token.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	defer apiTokenDb.Close()
+
+	router1 := clients.New(t).ID("router-1").Logger(testLog).Build()
+	server1 := clients.New(t).ID("server-1").Logger(testLog).Build()
+	routers := &cgroups.ClientGroup{
+		ID:     "routers",
+		Params: &cgroups.ClientParams{ClientID: &cgroups.ParamValues{"router-*"}},
+	}
+	al := &APIListener{
+		Logger:      testLog,
+		bannedUsers: security.NewBanList(0),
+		apiSessions: newEmptyAPISessionCache(t),
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+				},
+				AccessRequests: accessrequests.Config{MaxDuration: 4 * time.Hour},
+			},
+			clientService:       clients.NewClientService(nil, nil, clients.NewClientRepository([]*clientdata.Client{router1, server1}, nil, testLog), testLog, nil),
+			clientGroupProvider: staticClientGroupProvider{groups: []*cgroups.ClientGroup{routers}},
+		},
+		accessRequests: accessrequests.NewSqliteProvider(apiTokenDb),
+		userService:    users.NewAPIService(users.NewStaticProvider(apiUsers), false, 0, -1),
+	}
+	al.initRouter()
+
+	do := func(method, url, username, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req.SetBasicAuth(username, "pwd")
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+	var result struct {
+		Data accessrequests.Request `json:"data"`
+	}
+
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/api/v1/access-requests", "jane", `{"client_group_id":"routers"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/api/v1/access-requests", "jane", `{"client_group_id":"routers","justification":"INC-1","duration_minutes":300}`).Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPost, "/api/v1/access-requests", "jane", `{"client_group_id":"unknown","justification":"INC-1"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/api/v1/access-requests", "admin", `{"client_group_id":"routers","justification":"INC-1"}`).Code)
+
+	w := do(http.MethodPost, "/api/v1/access-requests", "jane", `{"client_group_id":"routers","justification":"INC-1","duration_minutes":90}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	id := result.Data.ID
+	assert.Equal(t, "jane", result.Data.Username)
+	assert.Equal(t, accessrequests.StatusPending, result.Data.Status)
+	assert.Equal(t, http.StatusConflict, do(http.MethodPost, "/api/v1/access-requests", "jane", `{"client_group_id":"routers","justification":"INC-1"}`).Code)
+
+	// pending requests don't grant access, only administrators decide
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/api/v1/clients/router-1", "jane", "").Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/api/v1/access-requests/"+id+"/approve", "jane", `{}`).Code)
+
+	w = do(http.MethodPost, "/api/v1/access-requests/"+id+"/approve", "admin", `{"comment":"go ahead"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, accessrequests.StatusApproved, result.Data.Status)
+	assert.Equal(t, "admin", result.Data.DecidedBy)
+	require.NotNil(t, result.Data.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(90*time.Minute), *result.Data.ExpiresAt, time.Minute)
+	assert.Equal(t, http.StatusConflict, do(http.MethodPost, "/api/v1/access-requests/"+id+"/deny", "admin", `{}`).Code)
+
+	// the access is limited to the clients of the group
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/api/v1/clients/router-1", "jane", "").Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/api/v1/clients/server-1", "jane", "").Code)
+	w = do(http.MethodGet, "/api/v1/clients", "jane", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "router-1")
+	assert.NotContains(t, w.Body.String(), "server-1")
+
+	w = do(http.MethodGet, "/api/v1/access-requests?status=approved", "jane", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), id)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/api/v1/access-requests?status=unknown", "jane", "").Code)
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/api/v1/access-requests/"+id, "root", "").Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/api/v1/clients/router-1", "jane", "").Code)
+	assert.Equal(t, http.StatusConflict, do(http.MethodDelete, "/api/v1/access-requests/"+id, "root", "").Code)
+
+	// a denied request can be requested again
+	w = do(http.MethodPost, "/api/v1/access-requests", "jane", `{"client_group_id":"routers","justification":"INC-2"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, 60, result.Data.DurationMinutes)
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/api/v1/access-requests/"+result.Data.ID+"/deny", "root", `{"comment":"use the maintenance window"}`).Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/api/v1/clients/router-1", "jane", "").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPost, "/api/v1/access-requests/unknown/approve", "root", `{}`).Code)
+
+	w = do(http.MethodGet, "/api/v1/access-requests", "admin", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Data []accessrequests.Request `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Data, 2)
+	statuses := []accessrequests.Status{list.Data[0].Status, list.Data[1].Status}
+	assert.ElementsMatch(t, []accessrequests.Status{accessrequests.StatusDenied, accessrequests.StatusRevoked}, statuses)
+}
+
+func TestAccessRequestsExpiryTask(t *testing.T) {
+	apiTokenDb, err := sqlite.New(":memory:", api_token.AssetNames(), api_token.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	defer apiTokenDb.Close()
+	ctx := context.Background()
+	al := &APIListener{accessRequests: accessrequests.NewSqliteProvider(apiTokenDb)}
+
+	now := time.Now().UTC()
+	require.NoError(t, al.accessRequests.Create(ctx, &accessrequests.Request{
+		ID: "r1", Username: "jane", ClientGroupID: "routers", Justification: "INC-1", DurationMinutes: 1, Status: accessrequests.StatusPending, CreatedAt: now.Add(-time.Hour),
+	}))
+	expiresAt := now.Add(-time.Minute)
+	_, err = al.accessRequests.Decide(ctx, "r1", accessrequests.StatusApproved, "admin", "", now.Add(-2*time.Minute), &expiresAt)
+	require.NoError(t, err)
+
+	task := &accessRequestsExpiryTask{log: testLog, al: al}
+	require.NoError(t, task.Run(ctx))
+
+	r1, err := al.accessRequests.Get(ctx, "r1")
+	require.NoError(t, err)
+	assert.Equal(t, accessrequests.StatusExpired, r1.Status)
+}
@@ -0,0 +1,468 @@
+package chserver
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/agentless"
+	"github.com/IOTech17/neo-rport/server/api"
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/monitoring"
+	"github.com/IOTech17/neo-rport/server/routes"
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/query"
+)
+
+const (
+	defaultAgentlessCommandTimeout = time.Minute
+	maxAgentlessCommandTimeout     = 10 * time.Minute
+	agentlessFactsTimeout          = time.Minute
+	defaultAgentlessSSHPort        = 22
+	// defaultAgentlessWinRMPort is the port of winrm over https
+	defaultAgentlessWinRMPort = 5986
+)
+
+type agentlessTargetRequest struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	Tags         []string `json:"tags"`
+	Protocol     string   `json:"protocol"`
+	Host         string   `json:"host"`
+	Port         int      `json:"port"`
+	Username     string   `json:"username"`
+	VaultValueID int      `json:"vault_value_id"`
+	JumpClientID string   `json:"jump_client_id"`
+	HostKey      string   `json:"host_key"`
+	Monitoring   bool     `json:"monitoring"`
+}
+
+type agentlessCommandResult struct {
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+	ExitCode int    `json:"exit_code"`
+	HostKey  string `json:"host_key"`
+}
+
+// handleListAgentlessTargets handles GET /agentless-targets
+func (al *APIListener) handleListAgentlessTargets(w http.ResponseWriter, req *http.Request) {
+	list, err := al.agentlessTargets.List(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(list))
+}
+
+// handleGetAgentlessTarget handles GET /agentless-targets/{agentless_target_id}
+func (al *APIListener) handleGetAgentlessTarget(w http.ResponseWriter, req *http.Request) {
+	target, ok := al.getAgentlessTarget(w, req)
+	if !ok {
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(target))
+}
+
+// handlePostAgentlessTarget handles POST /agentless-targets, it registers a device managed through a jump client.
+func (al *APIListener) handlePostAgentlessTarget(w http.ResponseWriter, req *http.Request) {
+	var reqBody agentlessTargetRequest
+	if err := parseRequestBody(req.Body, &reqBody); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if len(reqBody.ID) > maxExternalClientIDLength || !externalClientIDRegexp.MatchString(reqBody.ID) {
+		al.jsonErrorResponseWithDetail(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid or missing ID.",
+			fmt.Sprintf("Only letters, digits, '_', '-' and '.' are allowed, max size is %d.", maxExternalClientIDLength))
+		return
+	}
+
+	// measures are stored by client id, the id must be unique among all kinds of clients
+	existing, err := al.clientService.GetByID(reqBody.ID)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if existing != nil {
+		al.jsonErrorResponseWithDetail(w, http.StatusConflict, ErrCodeAlreadyExist, fmt.Sprintf("Client with ID %q already exist.", reqBody.ID), "")
+		return
+	}
+	external, err := al.externalClients.Get(req.Context(), reqBody.ID)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if external != nil {
+		al.jsonErrorResponseWithDetail(w, http.StatusConflict, ErrCodeAlreadyExist, fmt.Sprintf("External client with ID %q already exist.", reqBody.ID), "")
+		return
+	}
+
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	target := &agentless.Target{
+		ID:        reqBody.ID,
+		CreatedBy: curUser.Username,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := al.applyAgentlessTargetRequest(req.Context(), target, reqBody, curUser); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	created, err := al.agentlessTargets.Create(req.Context(), target)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if !created {
+		al.jsonErrorResponseWithDetail(w, http.StatusConflict, ErrCodeAlreadyExist, fmt.Sprintf("Agentless target with ID %q already exist.", reqBody.ID), "")
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientAgentless, auditlog.ActionCreate).
+		WithHTTPRequest(req).
+		WithClientID(target.JumpClientID).
+		WithID(target.ID).
+		WithRequest(reqBody).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusCreated, api.NewSuccessPayload(target))
+}
+
+// handlePutAgentlessTarget handles PUT /agentless-targets/{agentless_target_id}, the known host key is replaced by
+// the one given, an empty one is taken again from the next connection.
+func (al *APIListener) handlePutAgentlessTarget(w http.ResponseWriter, req *http.Request) {
+	target, ok := al.getAgentlessTarget(w, req)
+	if !ok {
+		return
+	}
+
+	var reqBody agentlessTargetRequest
+	if err := parseRequestBody(req.Body, &reqBody); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	if err := al.applyAgentlessTargetRequest(req.Context(), target, reqBody, curUser); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	updated, err := al.agentlessTargets.Update(req.Context(), target)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if !updated {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("agentless target with id %s not found", target.ID))
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientAgentless, auditlog.ActionUpdate).
+		WithHTTPRequest(req).
+		WithClientID(target.JumpClientID).
+		WithID(target.ID).
+		WithRequest(reqBody).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(target))
+}
+
+// handleDeleteAgentlessTarget handles DELETE /agentless-targets/{agentless_target_id}, the measures already saved
+// are removed with the monitoring retention.
+func (al *APIListener) handleDeleteAgentlessTarget(w http.ResponseWriter, req *http.Request) {
+	id := mux.Vars(req)[routes.ParamAgentlessTargetID]
+
+	deleted, err := al.agentlessTargets.Delete(req.Context(), id)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if !deleted {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("agentless target with id %s not found", id))
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientAgentless, auditlog.ActionDelete).
+		WithHTTPRequest(req).
+		WithID(id).
+		Save()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetAgentlessTargetMetrics handles GET /agentless-targets/{agentless_target_id}/metrics
+func (al *APIListener) handleGetAgentlessTargetMetrics(w http.ResponseWriter, req *http.Request) {
+	target, ok := al.getAgentlessTarget(w, req)
+	if !ok {
+		return
+	}
+
+	queryOptions := query.NewOptions(req, monitoring.ClientMetricsSortDefault, monitoring.ClientMetricsFilterDefault, monitoring.ClientMetricsFieldsDefault)
+	payload, err := al.monitoringService.ListClientMetrics(req.Context(), target.ID, queryOptions)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("metrics for agentless target with id %q not found", target.ID))
+			return
+		}
+		al.jsonError(w, err)
+		return
+	}
+	al.writeJSONResponse(w, http.StatusOK, payload)
+}
+
+// handlePostAgentlessCommand handles POST /agentless-targets/{agentless_target_id}/commands, it runs the command
+// through the jump client and waits for the result. The user needs access to the jump client and to the vault value
+// of the target.
+func (al *APIListener) handlePostAgentlessCommand(w http.ResponseWriter, req *http.Request) {
+	target, ok := al.getAgentlessTarget(w, req)
+	if !ok {
+		return
+	}
+
+	var reqBody struct {
+		Command    string `json:"command"`
+		TimeoutSec int    `json:"timeout_sec"`
+	}
+	if err := parseRequestBody(req.Body, &reqBody); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if reqBody.Command == "" {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "command is required")
+		return
+	}
+	timeout := defaultAgentlessCommandTimeout
+	if reqBody.TimeoutSec != 0 {
+		timeout = time.Duration(reqBody.TimeoutSec) * time.Second
+	}
+	if timeout <= 0 || timeout > maxAgentlessCommandTimeout {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("timeout_sec must be between 1 and %d", int(maxAgentlessCommandTimeout.Seconds())))
+		return
+	}
+
+	curUser, err := al.checkAgentlessJumpClientAccess(req.Context(), target)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientAgentless, auditlog.ActionExecuteStart).
+		WithHTTPRequest(req).
+		WithClientID(target.JumpClientID).
+		WithID(target.ID).
+		WithRequest(reqBody).
+		Save()
+
+	result, err := al.runAgentlessCommand(req.Context(), target, curUser, reqBody.Command, timeout)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if result.Error != "" {
+		al.jsonError(w, errors2.APIError{
+			HTTPStatus: http.StatusBadGateway,
+			Message:    fmt.Sprintf("failed to run command on agentless target %s: %s", target.ID, result.Error),
+		})
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(agentlessCommandResult{
+		Stdout:   result.Stdout,
+		Stderr:   result.Stderr,
+		ExitCode: result.ExitCode,
+		HostKey:  result.HostKey,
+	}))
+}
+
+// handlePostAgentlessFacts handles POST /agentless-targets/{agentless_target_id}/facts, it collects the facts of a
+// winrm target and saves them on the target.
+func (al *APIListener) handlePostAgentlessFacts(w http.ResponseWriter, req *http.Request) {
+	target, ok := al.getAgentlessTarget(w, req)
+	if !ok {
+		return
+	}
+	if target.Protocol != comm.AgentlessProtocolWinRM {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("facts are only collected from %s targets", comm.AgentlessProtocolWinRM))
+		return
+	}
+
+	curUser, err := al.checkAgentlessJumpClientAccess(req.Context(), target)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	result, err := al.runAgentlessCommand(req.Context(), target, curUser, agentless.FactsScript, agentlessFactsTimeout)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if result.Error != "" {
+		al.jsonError(w, errors2.APIError{
+			HTTPStatus: http.StatusBadGateway,
+			Message:    fmt.Sprintf("failed to collect facts of agentless target %s: %s", target.ID, result.Error),
+		})
+		return
+	}
+	if result.ExitCode != 0 {
+		al.jsonError(w, errors2.APIError{
+			HTTPStatus: http.StatusBadGateway,
+			Message:    fmt.Sprintf("facts script exited with %d: %s", result.ExitCode, result.Stderr),
+		})
+		return
+	}
+	facts, err := agentless.ParseFacts(result.Stdout)
+	if err != nil {
+		al.jsonError(w, errors2.APIError{
+			HTTPStatus: http.StatusBadGateway,
+			Err:        err,
+		})
+		return
+	}
+
+	now := time.Now().UTC()
+	if err := al.agentlessTargets.SetFacts(req.Context(), target.ID, facts, now); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	target.Facts = facts
+	target.FactsUpdatedAt = &now
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(target))
+}
+
+// checkAgentlessJumpClientAccess returns the current user if it has access to the jump client of the target.
+func (al *APIListener) checkAgentlessJumpClientAccess(ctx context.Context, target *agentless.Target) (*users.User, error) {
+	curUser, err := al.getUserModelForAuth(ctx)
+	if err != nil {
+		return nil, err
+	}
+	clientGroups, err := al.clientGroupProvider.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = al.clientService.CheckClientAccess(target.JumpClientID, curUser, clientGroups)
+	if err != nil {
+		return nil, err
+	}
+	return curUser, nil
+}
+
+func (al *APIListener) getAgentlessTarget(w http.ResponseWriter, req *http.Request) (*agentless.Target, bool) {
+	id := mux.Vars(req)[routes.ParamAgentlessTargetID]
+
+	target, err := al.agentlessTargets.Get(req.Context(), id)
+	if err != nil {
+		al.jsonError(w, err)
+		return nil, false
+	}
+	if target == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("agentless target with id %s not found", id))
+		return nil, false
+	}
+	return target, true
+}
+
+// applyAgentlessTargetRequest validates the settings and sets them on the target. The current user must have access
+// to the vault value, the monitoring reads it later with the access of the user who created the target.
+func (al *APIListener) applyAgentlessTargetRequest(ctx context.Context, target *agentless.Target, reqBody agentlessTargetRequest, curUser *users.User) error {
+	switch reqBody.Protocol {
+	case "", comm.AgentlessProtocolSSH:
+		reqBody.Protocol = comm.AgentlessProtocolSSH
+		if reqBody.Port == 0 {
+			reqBody.Port = defaultAgentlessSSHPort
+		}
+	case comm.AgentlessProtocolWinRM:
+		if reqBody.Port == 0 {
+			reqBody.Port = defaultAgentlessWinRMPort
+		}
+	default:
+		return errors2.APIError{
+			HTTPStatus: http.StatusBadRequest,
+			Message:    fmt.Sprintf("unsupported protocol %q, expected %q or %q", reqBody.Protocol, comm.AgentlessProtocolSSH, comm.AgentlessProtocolWinRM),
+		}
+	}
+	if reqBody.Port < 1 || reqBody.Port > 65535 {
+		return errors2.APIError{
+			HTTPStatus: http.StatusBadRequest,
+			Message:    fmt.Sprintf("invalid port %d", reqBody.Port),
+		}
+	}
+	for name, value := range map[string]string{
+		"host":           reqBody.Host,
+		"username":       reqBody.Username,
+		"jump_client_id": reqBody.JumpClientID,
+	} {
+		if value == "" {
+			return errors2.APIError{
+				HTTPStatus: http.StatusBadRequest,
+				Message:    fmt.Sprintf("%s is required", name),
+			}
+		}
+	}
+
+	jumpClient, err := al.clientService.GetByID(reqBody.JumpClientID)
+	if err != nil {
+		return err
+	}
+	if jumpClient == nil {
+		return errors2.APIError{
+			HTTPStatus: http.StatusBadRequest,
+			Message:    fmt.Sprintf("jump client with id %s not found", reqBody.JumpClientID),
+		}
+	}
+
+	// the credentials aren't returned, only the access of the user to the vault value is checked
+	if reqBody.VaultValueID <= 0 {
+		return errors2.APIError{
+			HTTPStatus: http.StatusBadRequest,
+			Message:    "a valid vault_value_id is required",
+		}
+	}
+	value, found, err := al.vaultManager.GetOne(ctx, reqBody.VaultValueID, curUser)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errors2.APIError{
+			HTTPStatus: http.StatusNotFound,
+			Message:    fmt.Sprintf("vault value with id %d not found", reqBody.VaultValueID),
+		}
+	}
+	if reqBody.Protocol == comm.AgentlessProtocolWinRM && isPrivateKey(value.Value) {
+		return errors2.APIError{
+			HTTPStatus: http.StatusBadRequest,
+			Message:    "winrm targets require a password, the vault value holds a private key",
+		}
+	}
+
+	target.Name = reqBody.Name
+	target.Description = reqBody.Description
+	target.Tags = reqBody.Tags
+	target.Protocol = reqBody.Protocol
+	target.Host = reqBody.Host
+	target.Port = reqBody.Port
+	target.Username = reqBody.Username
+	target.VaultValueID = reqBody.VaultValueID
+	target.JumpClientID = reqBody.JumpClientID
+	target.HostKey = reqBody.HostKey
+	target.Monitoring = reqBody.Monitoring
+	return nil
+}
@@ -0,0 +1,112 @@
+package chserver
+
+import (
+	"mime"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/artifacts"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/routes"
+)
+
+func (al *APIListener) handleListArtifacts(w http.ResponseWriter, req *http.Request) {
+	items, err := al.artifactManager.List(req.Context(), req)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, &api.SuccessPayload{
+		Data: items,
+		Meta: api.NewMeta(len(items)),
+	})
+}
+
+// handleArtifactCreate creates a version from the multipart upload of a file or pulls it from the URL of a json request.
+func (al *APIListener) handleArtifactCreate(w http.ResponseWriter, req *http.Request) {
+	curUsername := api.GetUser(req.Context(), al.Logger)
+	if curUsername == "" {
+		al.jsonErrorResponseWithTitle(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var created *artifacts.Artifact
+	var auditRequest interface{}
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		if err := req.ParseMultipartForm(uploadBufSize); err != nil {
+			al.jsonError(w, errors2.APIError{Err: err, HTTPStatus: http.StatusBadRequest})
+			return
+		}
+		defer func() { _ = req.MultipartForm.RemoveAll() }()
+
+		file, header, err := req.FormFile("upload")
+		if err != nil {
+			al.jsonError(w, errors2.APIError{Err: err, HTTPStatus: http.StatusBadRequest})
+			return
+		}
+		defer file.Close()
+
+		name, version := req.FormValue("name"), req.FormValue("version")
+		auditRequest = map[string]string{"name": name, "version": version, "filename": header.Filename}
+		created, err = al.artifactManager.Upload(req.Context(), name, version, file, curUsername)
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
+	} else {
+		var pullReq artifacts.PullRequest
+		err := parseRequestBody(req.Body, &pullReq)
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
+		auditRequest = pullReq
+		created, err = al.artifactManager.Pull(req.Context(), pullReq, curUsername)
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationLibraryArtifact, auditlog.ActionCreate).
+		WithHTTPRequest(req).
+		WithRequest(auditRequest).
+		WithResponse(created).
+		WithID(created.String()).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusCreated, api.NewSuccessPayload(created))
+}
+
+func (al *APIListener) handleReadArtifact(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	found, err := al.artifactManager.Get(req.Context(), vars[routes.ParamArtifactName], vars[routes.ParamArtifactVersion])
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(found))
+}
+
+func (al *APIListener) handleDeleteArtifact(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	name, version := vars[routes.ParamArtifactName], vars[routes.ParamArtifactVersion]
+	err := al.artifactManager.Delete(req.Context(), name, version)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationLibraryArtifact, auditlog.ActionDelete).
+		WithHTTPRequest(req).
+		WithID(name + "@" + version).
+		Save()
+
+	w.WriteHeader(http.StatusNoContent)
+}
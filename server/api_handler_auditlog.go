@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net/http"
 
+	"github.com/IOTech17/neo-rport/server/api"
 	"github.com/IOTech17/neo-rport/server/auditlog"
 )
 
@@ -26,3 +27,16 @@ func (al *APIListener) handleListAuditLog(w http.ResponseWriter, req *http.Reque
 	}
 	al.writeJSONResponse(w, http.StatusOK, result)
 }
+
+// handleVerifyAuditLog handles GET /auditlog/verify
+func (al *APIListener) handleVerifyAuditLog(w http.ResponseWriter, req *http.Request) {
+	result, err := al.auditLog.Verify(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if !result.Valid {
+		al.Errorf("SECURITY ALERT: audit log hash chain is broken at %s: %s", result.FirstInvalid.Timestamp, result.FirstInvalid.Reason)
+	}
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(result))
+}
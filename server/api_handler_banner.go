@@ -0,0 +1,18 @@
+package chserver
+
+import (
+	"net/http"
+
+	"github.com/IOTech17/neo-rport/server/api"
+)
+
+type bannerPayload struct {
+	Banner string `json:"banner"`
+}
+
+// handleGetBanner handles GET /banner, it's public so the UI can show the legal notice before the login.
+func (al *APIListener) handleGetBanner(w http.ResponseWriter, req *http.Request) {
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(bannerPayload{
+		Banner: al.config.Server.Banner,
+	}))
+}
@@ -0,0 +1,319 @@
+package chserver
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/routes"
+	chshare "github.com/IOTech17/neo-rport/share"
+	"github.com/IOTech17/neo-rport/share/security"
+)
+
+const (
+	banTypeUser = "user"
+	banTypeIP   = "ip"
+)
+
+type banPayload struct {
+	Type      string    `json:"type"`
+	Value     string    `json:"value"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// handleListBans handles GET /bans, it returns the banned users and IP addresses of the API.
+func (al *APIListener) handleListBans(w http.ResponseWriter, req *http.Request) {
+	bans := []banPayload{}
+	for username, expiry := range al.bannedUsers.List() {
+		bans = append(bans, banPayload{Type: banTypeUser, Value: username, ExpiresAt: expiry})
+	}
+	if al.bannedIPs != nil {
+		for ip, expiry := range al.bannedIPs.List() {
+			bans = append(bans, banPayload{Type: banTypeIP, Value: ip, ExpiresAt: expiry})
+		}
+	}
+	sort.Slice(bans, func(i, j int) bool {
+		if bans[i].Type != bans[j].Type {
+			return bans[i].Type > bans[j].Type
+		}
+		return bans[i].Value < bans[j].Value
+	})
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(bans))
+}
+
+// handleDeleteUserBan handles DELETE /bans/users/{user_id}
+func (al *APIListener) handleDeleteUserBan(w http.ResponseWriter, req *http.Request) {
+	username := mux.Vars(req)[routes.ParamUserID]
+	if !al.bannedUsers.Remove(username) {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("user %q is not banned", username))
+		return
+	}
+	al.auditBanRemoval(req, banTypeUser, username)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleDeleteIPBan handles DELETE /bans/ips/{ip}
+func (al *APIListener) handleDeleteIPBan(w http.ResponseWriter, req *http.Request) {
+	ip := mux.Vars(req)[routes.ParamIP]
+	if al.bannedIPs == nil || !al.bannedIPs.Remove(ip) {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("ip address %q is not banned", ip))
+		return
+	}
+	al.auditBanRemoval(req, banTypeIP, ip)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type ipBanPayload struct {
+	IP        string    `json:"ip"`
+	Listener  string    `json:"listener"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+var (
+	nftTableRegex = regexp.MustCompile(`^([a-z]+ )?[a-zA-Z0-9_]+$`)
+	nftSetRegex   = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+)
+
+// handleExportIPBans handles GET /bans/export, it exports the banned IP addresses of the API and the client listener
+// to enforce them by the firewall. The plain format is one IP address per line, e.g. for fail2ban-client banip.
+func (al *APIListener) handleExportIPBans(w http.ResponseWriter, req *http.Request) {
+	params := req.URL.Query()
+	format := params.Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "plain" && format != "nftables" {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("Invalid format %q, expected one of: json, plain, nftables.", format))
+		return
+	}
+	listener := params.Get("listener")
+	if listener != "" && listener != banListenerAPI && listener != banListenerClient {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("Invalid listener %q, expected one of: %s, %s.", listener, banListenerAPI, banListenerClient))
+		return
+	}
+	table := params.Get("nft_table")
+	if table == "" {
+		table = "inet filter"
+	}
+	set := params.Get("nft_set")
+	if set == "" {
+		set = "rport_banned"
+	}
+	if !nftTableRegex.MatchString(table) || !nftSetRegex.MatchString(set) {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "Invalid nft_table or nft_set.")
+		return
+	}
+
+	bans := al.listIPBans(listener)
+	switch format {
+	case "plain":
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusOK)
+		unique := make(map[string]bool)
+		for _, ban := range bans {
+			if !unique[ban.IP] {
+				unique[ban.IP] = true
+				fmt.Fprintln(w, ban.IP)
+			}
+		}
+	case "nftables":
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusOK)
+		writeNftablesBans(w, table, set, bans, time.Now())
+	default:
+		al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(bans))
+	}
+}
+
+// listIPBans returns the banned IP addresses of the listener or of both if empty, sorted by IP address.
+func (al *APIListener) listIPBans(listener string) []ipBanPayload {
+	bans := []ipBanPayload{}
+	add := func(l *security.MaxBadAttemptsBanList, name string) {
+		if l == nil || (listener != "" && listener != name) {
+			return
+		}
+		for ip, expiry := range l.List() {
+			bans = append(bans, ipBanPayload{IP: ip, Listener: name, ExpiresAt: expiry})
+		}
+	}
+	add(al.bannedIPs, banListenerAPI)
+	if al.Server != nil && al.Server.clientListener != nil {
+		add(al.Server.clientListener.bannedIPs, banListenerClient)
+	}
+	sort.Slice(bans, func(i, j int) bool {
+		if bans[i].IP != bans[j].IP {
+			return bans[i].IP < bans[j].IP
+		}
+		return bans[i].Listener < bans[j].Listener
+	})
+	return bans
+}
+
+// writeNftablesBans writes an nft script adding the IP addresses to the set until the bans expire, IPv6 addresses
+// are added to the set with the suffix 6. The sets must exist with the timeout flag.
+func writeNftablesBans(w io.Writer, table, set string, bans []ipBanPayload, now time.Time) {
+	var v4, v6 []string
+	seen := make(map[string]bool)
+	for _, ban := range bans {
+		seconds := int64(ban.ExpiresAt.Sub(now).Seconds())
+		ip := net.ParseIP(ban.IP)
+		if seen[ban.IP] || seconds <= 0 || ip == nil {
+			continue
+		}
+		seen[ban.IP] = true
+		element := fmt.Sprintf("%s timeout %ds", ban.IP, seconds)
+		if ip.To4() != nil {
+			v4 = append(v4, element)
+		} else {
+			v6 = append(v6, element)
+		}
+	}
+
+	fmt.Fprintf(w, "# rport IP bans, generated at %s\n", now.UTC().Format(time.RFC3339))
+	if len(v4) > 0 {
+		fmt.Fprintf(w, "add element %s %s { %s }\n", table, set, strings.Join(v4, ", "))
+	}
+	if len(v6) > 0 {
+		fmt.Fprintf(w, "add element %s %s6 { %s }\n", table, set, strings.Join(v6, ", "))
+	}
+}
+
+func (al *APIListener) auditBanRemoval(req *http.Request, banType, value string) {
+	al.auditLog.Entry(auditlog.ApplicationAuthBan, auditlog.ActionDelete).
+		WithHTTPRequest(req).
+		WithID(banType + ":" + value).
+		Save()
+}
+
+type unlockBody struct {
+	Token string `json:"token"`
+	Code  string `json:"code"`
+}
+
+// handleGetUnlock handles GET /auth/unlock, the link of the lockout notifications. It sends the 2fa token to lift the
+// lockout with, for TotP the code of the authenticator app is used.
+func (al *APIListener) handleGetUnlock(w http.ResponseWriter, req *http.Request) {
+	r, err := al.getUnlockRequest(req.URL.Query().Get("token"))
+	if err != nil {
+		al.handleBannedIPs(req, false)
+		al.jsonError(w, err)
+		return
+	}
+
+	if al.config.API.TotPEnabled {
+		al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(twoFAResponse{
+			DeliveryMethod: "totp_authenticator_app",
+		}))
+		return
+	}
+
+	sendTo, err := al.twoFASrv.SendToken(req.Context(), r.Username, req.UserAgent(), chshare.RemoteIP(req))
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(twoFAResponse{
+		SendTo:         sendTo,
+		DeliveryMethod: al.twoFASrv.MsgSrv.DeliveryMethod(),
+	}))
+}
+
+// handlePostUnlock handles POST /auth/unlock, it lifts the bans of the unlock token if the 2fa code is valid.
+func (al *APIListener) handlePostUnlock(w http.ResponseWriter, req *http.Request) {
+	var body unlockBody
+	if err := parseRequestBody(req.Body, &body); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	r, err := al.getUnlockRequest(body.Token)
+	if err == nil {
+		err = al.validateUnlockCode(r.Username, body.Code)
+	}
+	if err != nil {
+		al.handleBannedIPs(req, false)
+		al.jsonError(w, err)
+		return
+	}
+
+	al.lockouts.deleteUnlockToken(body.Token)
+	if al.bannedUsers.Remove(r.Username) {
+		al.auditBanRemoval(req, banTypeUser, r.Username)
+	}
+	if r.IP != "" && al.bannedIPs != nil && al.bannedIPs.Remove(r.IP) {
+		al.auditBanRemoval(req, banTypeIP, r.IP)
+	}
+	al.Infof("User %q lifted the lockout by an unlock token", r.Username)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (al *APIListener) getUnlockRequest(token string) (*unlockRequest, error) {
+	if !al.config.API.IsTwoFAOn() && !al.config.API.TotPEnabled {
+		return nil, errors2.APIError{
+			HTTPStatus: http.StatusConflict,
+			Message:    "2fa is disabled",
+		}
+	}
+	if token == "" {
+		return nil, errors2.APIError{
+			HTTPStatus: http.StatusBadRequest,
+			Message:    "token is required",
+		}
+	}
+	r := al.lockouts.getUnlockRequest(token)
+	if r == nil {
+		return nil, errors2.APIError{
+			HTTPStatus: http.StatusUnauthorized,
+			Message:    "unlock token is invalid or expired",
+		}
+	}
+	return r, nil
+}
+
+func (al *APIListener) validateUnlockCode(username, code string) error {
+	if code == "" {
+		return errors2.APIError{
+			HTTPStatus: http.StatusBadRequest,
+			Message:    "code is required",
+		}
+	}
+	if !al.config.API.TotPEnabled {
+		return al.twoFASrv.ValidateToken(username, code)
+	}
+
+	user, err := al.userService.GetByUsername(username)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return errors2.APIError{
+			HTTPStatus: http.StatusUnauthorized,
+			Message:    "unlock token is invalid or expired",
+		}
+	}
+	totP, err := GetUsersTotPCode(user)
+	if err != nil {
+		return err
+	}
+	if totP == nil || totP.Secret == "" || !CheckTotPCode(code, totP) {
+		return errors2.APIError{
+			HTTPStatus: http.StatusUnauthorized,
+			Message:    "invalid code",
+		}
+	}
+	return nil
+}
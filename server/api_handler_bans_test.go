@@ -0,0 +1,246 @@
+package chserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/notifications"
+	"github.com/IOTech17/neo-rport/share/refs"
+	"github.com/IOTech17/neo-rport/share/security"
+)
+
+type capturingDispatcher struct {
+	notifications []notifications.NotificationData
+}
+
+func (d *capturingDispatcher) Dispatch(_ context.Context, refID refs.Identifiable, notification notifications.NotificationData) (refs.Identifiable, error) {
+	d.notifications = append(d.notifications, notification)
+	return refID, nil
+}
+
+// noDigestsStore has no digest settings, so all notifications are sent immediately
+type noDigestsStore struct {
+	notifications.DigestStore
+}
+
+func (noDigestsStore) GetDigestSettings(context.Context, string) (*notifications.DigestSettings, error) {
+	return nil, nil
+}
+
+func TestLockoutNotificationAndUnlock(t *testing.T) {
+	totP, err := GenerateTotPSecretKey(&TotPInput{Issuer: "rport", AccountName: "jane"})
+	require.NoError(t, err)
+	jane := &users.User{Username: "jane", Password: "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm", TwoFASendTo: "jane@example.com"}
+	StoreTotPCodeInUser(jane, totP)
+
+	dispatcher := &capturingDispatcher{}
+	al := &APIListener{
+		Logger:      testLog,
+		bannedUsers: security.NewBanList(time.Hour),
+		bannedIPs:   security.NewMaxBadAttemptsBanList(1, time.Hour, testLog),
+		lockouts:    newLockouts(),
+		apiSessions: newEmptyAPISessionCache(t),
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+					TotPEnabled:     true,
+					NotifyLockouts:  true,
+					BaseURL:         "https://rport.example.com",
+				},
+			},
+		},
+		userService: users.NewAPIService(users.NewStaticProvider([]*users.User{
+			{Username: "admin", Password: "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm", Groups: []string{users.Administrators}},
+			jane,
+		}), false, 0, -1),
+		notificationDigests: notifications.NewDigestDispatcher(dispatcher, noDigestsStore{}, testLog),
+	}
+	al.initRouter()
+
+	const janeIP = "192.0.2.10"
+	request := func(method, url, remoteIP, username, password, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req.RemoteAddr = remoteIP + ":1234"
+		if username != "" {
+			req.SetBasicAuth(username, password)
+		}
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request(http.MethodGet, "/api/v1/login", janeIP, "jane", "wrong", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.True(t, al.bannedUsers.IsBanned("jane"))
+	assert.True(t, al.bannedIPs.IsBanned(janeIP))
+
+	require.Len(t, dispatcher.notifications, 1)
+	notification := dispatcher.notifications[0]
+	assert.Equal(t, []string{"jane@example.com"}, notification.Recipients)
+	assert.Equal(t, "Your RPort account is locked", notification.Subject)
+	assert.Contains(t, notification.Content, "The IP address 192.0.2.10 is banned")
+	match := regexp.MustCompile(`https://rport\.example\.com/api/v1/auth/unlock\?token=(\w+)`).FindStringSubmatch(notification.Content)
+	require.Len(t, match, 2, notification.Content)
+	unlockToken := match[1]
+
+	// the banned IP is rejected except for the unlock
+	w = request(http.MethodGet, "/api/v1/login", janeIP, "jane", "pwd", "")
+	assert.Equal(t, http.StatusLocked, w.Code)
+
+	w = request(http.MethodGet, "/api/v1/auth/unlock?token=unknown", janeIP, "", "", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = request(http.MethodGet, "/api/v1/auth/unlock?token="+unlockToken, janeIP, "", "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data":{"send_to":"","delivery_method":"totp_authenticator_app","totp_key_status":""}}`, w.Body.String())
+
+	w = request(http.MethodPost, "/api/v1/auth/unlock", janeIP, "", "", `{"token":"`+unlockToken+`","code":"000"}`)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.True(t, al.bannedUsers.IsBanned("jane"))
+
+	code, err := totp.GenerateCode(totP.Secret, time.Now())
+	require.NoError(t, err)
+	w = request(http.MethodPost, "/api/v1/auth/unlock", janeIP, "", "", `{"token":"`+unlockToken+`","code":"`+code+`"}`)
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	assert.False(t, al.bannedUsers.IsBanned("jane"))
+	assert.False(t, al.bannedIPs.IsBanned(janeIP))
+
+	// the token is used up
+	w = request(http.MethodPost, "/api/v1/auth/unlock", janeIP, "", "", `{"token":"`+unlockToken+`","code":"`+code+`"}`)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	al.bannedIPs.Remove(janeIP)
+
+	// another lockout within the hour isn't notified again
+	w = request(http.MethodGet, "/api/v1/login", janeIP, "jane", "wrong", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Len(t, dispatcher.notifications, 1)
+
+}
+
+func TestBans(t *testing.T) {
+	// password of all users is "pwd"
+	password := "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm"
+	al := &APIListener{
+		Logger:      testLog,
+		bannedUsers: security.NewBanList(time.Hour),
+		bannedIPs:   security.NewMaxBadAttemptsBanList(1, time.Hour, testLog),
+		lockouts:    newLockouts(),
+		apiSessions: newEmptyAPISessionCache(t),
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+				},
+			},
+		},
+		userService: users.NewAPIService(users.NewStaticProvider([]*users.User{
+			{Username: "admin", Password: password, Groups: []string{users.Administrators}},
+			{Username: "jane", Password: password},
+			{Username: "john", Password: password},
+		}), false, 0, -1),
+	}
+	al.initRouter()
+	al.bannedUsers.Add("jane")
+	al.bannedIPs.AddBadAttempt("192.0.2.10")
+
+	request := func(method, url, username string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, url, nil)
+		req.SetBasicAuth(username, "pwd")
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request(http.MethodGet, "/api/v1/bans", "admin")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Regexp(t, `^{"data":\[{"type":"user","value":"jane","expires_at":"[^"]+"},{"type":"ip","value":"192.0.2.10","expires_at":"[^"]+"}\]}$`, w.Body.String())
+
+	w = request(http.MethodDelete, "/api/v1/bans/users/jane", "john")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = request(http.MethodDelete, "/api/v1/bans/users/jane", "admin")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = request(http.MethodDelete, "/api/v1/bans/users/jane", "admin")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = request(http.MethodDelete, "/api/v1/bans/ips/192.0.2.10", "admin")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = request(http.MethodDelete, "/api/v1/bans/ips/192.0.2.10", "admin")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = request(http.MethodGet, "/api/v1/bans", "admin")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data":[]}`, w.Body.String())
+}
+
+func TestExportIPBans(t *testing.T) {
+	al := &APIListener{
+		Logger:      testLog,
+		bannedUsers: security.NewBanList(0),
+		bannedIPs:   security.NewMaxBadAttemptsBanList(1, time.Hour, testLog),
+		apiSessions: newEmptyAPISessionCache(t),
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+				},
+			},
+			clientListener: &ClientListener{
+				bannedIPs: security.NewMaxBadAttemptsBanList(1, time.Hour, testLog),
+			},
+		},
+		userService: users.NewAPIService(users.NewStaticProvider([]*users.User{
+			{Username: "admin", Password: "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm", Groups: []string{users.Administrators}},
+		}), false, 0, -1),
+	}
+	al.initRouter()
+	al.bannedIPs.AddBadAttempt("192.0.2.10")
+	al.bannedIPs.AddBadAttempt("2001:db8::1")
+	al.clientListener.bannedIPs.AddBadAttempt("192.0.2.10")
+	al.clientListener.bannedIPs.AddBadAttempt("192.0.2.20")
+
+	request := func(url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req.SetBasicAuth("admin", "pwd")
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("/api/v1/bans/export?format=plain")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "192.0.2.10\n192.0.2.20\n2001:db8::1\n", w.Body.String())
+
+	w = request("/api/v1/bans/export?format=plain&listener=client")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "192.0.2.10\n192.0.2.20\n", w.Body.String())
+
+	w = request("/api/v1/bans/export?format=nftables&nft_table=inet%20rport&nft_set=banned")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Regexp(t, `^# rport IP bans, generated at \S+
+add element inet rport banned { 192.0.2.10 timeout 3\d{3}s, 192.0.2.20 timeout 3\d{3}s }
+add element inet rport banned6 { 2001:db8::1 timeout 3\d{3}s }
+$`, w.Body.String())
+
+	w = request("/api/v1/bans/export?listener=api")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Regexp(t, `^{"data":\[{"ip":"192.0.2.10","listener":"api","expires_at":"[^"]+"},{"ip":"2001:db8::1","listener":"api","expires_at":"[^"]+"}\]}$`, w.Body.String())
+
+	w = request("/api/v1/bans/export?format=nftables&nft_set=banned;flush")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = request("/api/v1/bans/export?format=xml")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
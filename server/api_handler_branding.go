@@ -0,0 +1,38 @@
+package chserver
+
+import (
+	"net/http"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/branding"
+)
+
+// handleGetBranding handles GET /branding
+func (al *APIListener) handleGetBranding(w http.ResponseWriter, req *http.Request) {
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(al.branding.Get()))
+}
+
+// handleUpdateBranding handles PUT /branding, it replaces the branding used in notifications and on the login page.
+func (al *APIListener) handleUpdateBranding(w http.ResponseWriter, req *http.Request) {
+	var b branding.Branding
+	if err := parseRequestBody(req.Body, &b); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if err := b.Validate(); err != nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := al.branding.Set(b); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationBranding, auditlog.ActionUpdate).
+		WithHTTPRequest(req).
+		WithRequest(b).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(b))
+}
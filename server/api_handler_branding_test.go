@@ -0,0 +1,74 @@
+package chserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/branding"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/routes"
+	"github.com/IOTech17/neo-rport/share/security"
+)
+
+func TestBranding(t *testing.T) {
+	brand, err := branding.NewProvider(filepath.Join(t.TempDir(), "branding.json"))
+	require.NoError(t, err)
+
+	// password of all users is "pwd"
+	password := "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm"
+	al := &APIListener{
+		Logger:      testLog,
+		bannedUsers: security.NewBanList(0),
+		apiSessions: newEmptyAPISessionCache(t),
+		branding:    brand,
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+				},
+			},
+		},
+		userService: users.NewAPIService(users.NewStaticProvider([]*users.User{
+			{Username: "admin", Password: password, Groups: []string{users.Administrators}},
+			{Username: "jane", Password: password, Groups: []string{"tenant-a"}},
+		}), false, 0, -1),
+	}
+	al.initRouter()
+
+	request := func(method, url, username, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		if username != "" {
+			req.SetBasicAuth(username, "pwd")
+		}
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request(http.MethodGet, "/api/v1/branding", "admin", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data":{"product_name":"","logo_url":"","email_footer":"","sender_name":"","sender_email":""}}`, w.Body.String())
+
+	w = request(http.MethodPut, "/api/v1/branding", "jane", `{"product_name":"Acme Remote"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = request(http.MethodPut, "/api/v1/branding", "admin", `{"logo_url":"logo.png"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = request(http.MethodPut, "/api/v1/branding", "admin", `{"product_name":"Acme Remote","logo_url":"https://acme.example.com/logo.png","sender_name":"Acme Support"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "Acme Remote", brand.Get().ProductName)
+
+	w = request(http.MethodGet, routes.AllRoutesPrefix+routes.AuthRoutesPrefix+routes.AuthProviderRoute, "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	info, err := GetSuccessPayloadResponse[AuthProviderInfo](w.Body)
+	require.NoError(t, err)
+	assert.Equal(t, branding.Public{ProductName: "Acme Remote", LogoURL: "https://acme.example.com/logo.png"}, info.Branding)
+}
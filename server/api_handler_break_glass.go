@@ -0,0 +1,142 @@
+package chserver
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	rportplus "github.com/IOTech17/neo-rport/plus"
+	"github.com/IOTech17/neo-rport/server/api"
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/bearer"
+	"github.com/IOTech17/neo-rport/server/breakglass"
+	chshare "github.com/IOTech17/neo-rport/share"
+)
+
+type breakGlassLoginRequest struct {
+	Username     string `json:"username"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// handlePostBreakGlassLogin logs in the break-glass account with the recovery code of the printed kit. It's only
+// possible with OAuth configured and none of the identity providers reachable.
+func (al *APIListener) handlePostBreakGlassLogin(w http.ResponseWriter, req *http.Request) {
+	if !rportplus.IsPlusOAuthEnabled(al.config.PlusConfig) {
+		al.jsonErrorResponse(w, http.StatusForbidden, errors.New("break-glass login is only available with oauth, use the built-in login"))
+		return
+	}
+
+	var params breakGlassLoginRequest
+	if err := parseRequestBody(req.Body, &params); err != nil {
+		if !al.handleBannedIPs(req, false) {
+			return
+		}
+		al.jsonError(w, err)
+		return
+	}
+	if al.bannedUsers.IsBanned(params.Username) {
+		al.jsonErrorResponseWithTitle(w, http.StatusTooManyRequests, ErrTooManyRequests.Error())
+		return
+	}
+
+	kit, err := breakglass.LoadKit(al.config.Server.DataDir)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if kit == nil {
+		al.jsonErrorResponse(w, http.StatusForbidden, errors.New("no break-glass kit has been created"))
+		return
+	}
+
+	authorized := params.Username == kit.Username && kit.Verify(params.RecoveryCode)
+	if !al.handleBannedIPs(req, authorized) {
+		return
+	}
+	if !authorized {
+		al.rejectBreakGlassLogin(req, params.Username, "invalid username or recovery code")
+		al.banUser(req, params.Username)
+		al.jsonErrorResponseWithTitle(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var urls []string
+	for _, p := range rportplus.OAuthProviders(al.config.PlusConfig) {
+		urls = append(urls, p.TokenURL, p.BaseAuthorizeURL)
+	}
+	if reachable, ok := breakglass.ReachableURL(req.Context(), http.DefaultClient, urls); ok {
+		al.rejectBreakGlassLogin(req, params.Username, "identity provider "+reachable+" is reachable")
+		al.jsonErrorResponse(w, http.StatusForbidden, breakglass.ErrIdPReachable)
+		return
+	}
+
+	user, err := al.userService.GetByUsername(kit.Username)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if user == nil || !user.IsAdmin() {
+		al.rejectBreakGlassLogin(req, params.Username, "the user doesn't exist or isn't an administrator")
+		al.jsonError(w, errors2.APIError{
+			Message:    "the break-glass user must exist and be a member of the Administrators group",
+			HTTPStatus: http.StatusForbidden,
+		})
+		return
+	}
+
+	lifetime, err := parseTokenLifetime(req)
+	if err != nil {
+		al.jsonErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tokenStr, err := bearer.CreateAuthToken(
+		req.Context(),
+		al.apiSessions,
+		al.config.API.JWTSecret,
+		lifetime,
+		kit.Username,
+		bearer.ScopesAllExcluding2FaCheck,
+		req.UserAgent(),
+		chshare.RemoteIP(req),
+	)
+	if err != nil {
+		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationAuthBreakGlass, auditlog.ActionSuccess).
+		WithHTTPRequest(req).
+		WithUsername(kit.Username).
+		WithID(kit.Username).
+		Save()
+	al.breakGlass.Alert(req.Context(), breakglass.Event{
+		Username:  kit.Username,
+		Success:   true,
+		RemoteIP:  chshare.RemoteIP(req),
+		UserAgent: req.UserAgent(),
+		Timestamp: time.Now(),
+	})
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(loginResponse{
+		Token:    &tokenStr,
+		Features: al.features(),
+	}))
+}
+
+func (al *APIListener) rejectBreakGlassLogin(req *http.Request, username, reason string) {
+	al.auditLog.Entry(auditlog.ApplicationAuthBreakGlass, auditlog.ActionFailed).
+		WithHTTPRequest(req).
+		WithUsername(username).
+		WithID(username).
+		WithRequest(map[string]string{"reason": reason}).
+		Save()
+	al.breakGlass.Alert(req.Context(), breakglass.Event{
+		Username:  username,
+		Reason:    reason,
+		RemoteIP:  chshare.RemoteIP(req),
+		UserAgent: req.UserAgent(),
+		Timestamp: time.Now(),
+	})
+}
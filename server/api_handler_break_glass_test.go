@@ -0,0 +1,109 @@
+package chserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rportplus "github.com/IOTech17/neo-rport/plus"
+	"github.com/IOTech17/neo-rport/plus/capabilities/oauth"
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/breakglass"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/notifications"
+	"github.com/IOTech17/neo-rport/share/security"
+)
+
+func TestBreakGlassLogin(t *testing.T) {
+	dataDir := t.TempDir()
+	kit, code, err := breakglass.NewKit("emergency")
+	require.NoError(t, err)
+	require.NoError(t, breakglass.SaveKit(dataDir, kit))
+
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	defer idp.Close()
+
+	oauthConfig := &oauth.Config{
+		Provider: oauth.GitHubOAuthProvider,
+		TokenURL: idp.URL,
+	}
+	store := &fakeNotificationStore{}
+	al := APIListener{
+		Logger: testLog,
+		Server: &Server{
+			config: &chconfig.Config{
+				Server: chconfig.ServerConfig{DataDir: dataDir},
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+					JWTSecret:       "secret",
+				},
+				PlusConfig: rportplus.PlusConfig{
+					PluginConfig: &rportplus.PluginConfig{PluginPath: defaultPluginPath},
+					OAuthConfig:  oauthConfig,
+				},
+			},
+			breakGlass: breakglass.NewAlerter(breakglass.Config{
+				AlertEmails: []string{"security@example.com"},
+			}, testLog, notifications.NewDispatcher(store)),
+		},
+		bannedUsers: security.NewBanList(0),
+		userService: users.NewAPIService(users.NewStaticProvider([]*users.User{{
+			Username: "emergency",
+			Groups:   []string{users.Administrators},
+		}}), false, 0, -1),
+		apiSessions: newEmptyAPISessionCache(t),
+	}
+	al.initRouter()
+
+	login := func(username, code string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		body := `{"username":"` + username + `","recovery_code":"` + code + `"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/login/break-glass", strings.NewReader(body))
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := login("emergency", "wrong")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// the identity provider is up
+	w = login("emergency", code)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), breakglass.ErrIdPReachable.Error())
+
+	idp.Close()
+	w = login("emergency", strings.ToLower(code))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"token":"`)
+
+	require.Len(t, store.created, 3)
+	assert.Contains(t, store.created[0].Data.Content, "rejected: invalid username or recovery code")
+	assert.Contains(t, store.created[1].Data.Content, "is reachable")
+	assert.Contains(t, store.created[2].Data.Content, `The break-glass account "emergency" was used`)
+}
+
+func TestBreakGlassLoginWithoutOAuth(t *testing.T) {
+	al := APIListener{
+		Logger: testLog,
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{MaxRequestBytes: 1024 * 1024},
+			},
+		},
+		bannedUsers: security.NewBanList(0),
+		apiSessions: newEmptyAPISessionCache(t),
+	}
+	al.initRouter()
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/login/break-glass", strings.NewReader(`{}`))
+	al.router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
@@ -0,0 +1,223 @@
+package chserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/api/authorization"
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/random"
+	"github.com/IOTech17/neo-rport/share/types"
+)
+
+const (
+	DefaultBrokerGrantLifetime = time.Hour
+	MaxBrokerGrantLifetime     = 24 * time.Hour
+
+	brokerGrantsExpiryInterval = time.Minute
+)
+
+// brokerGrantPermissions are the only permissions of a broker token
+var brokerGrantPermissions = types.StringSlice{users.PermissionTunnels, users.PermissionCommands}
+
+type brokerGrantRequest struct {
+	ClientID   string     `json:"client_id"`
+	Technician string     `json:"technician"`
+	Reason     string     `json:"reason"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+type brokerGrantResponse struct {
+	*authorization.BrokerGrant
+	// Token is only returned once, the technician authenticates with the username and the token
+	Token string `json:"token"`
+}
+
+// handlePostBrokerGrant handles POST /broker-grants
+func (al *APIListener) handlePostBrokerGrant(w http.ResponseWriter, req *http.Request) {
+	var r brokerGrantRequest
+	if err := parseRequestBody(req.Body, &r); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if r.ClientID == "" || r.Technician == "" {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "client_id and technician are required.")
+		return
+	}
+
+	now := time.Now().Truncate(time.Second).UTC()
+	expiresAt := now.Add(DefaultBrokerGrantLifetime)
+	if r.ExpiresAt != nil {
+		expiresAt = r.ExpiresAt.UTC()
+	}
+	if !expiresAt.After(now) || expiresAt.Sub(now) > MaxBrokerGrantLifetime {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("expires_at must be in the future and within %s.", MaxBrokerGrantLifetime))
+		return
+	}
+
+	client, err := al.clientService.GetByID(r.ClientID)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if client == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("client with id %s not found", r.ClientID))
+		return
+	}
+
+	user, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	id, err := random.UUID4()
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	tokenClear, err := random.UUID4()
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	tokenHash, err := users.GenerateTokenHash(tokenClear)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	prefix := random.AlphaNum(authorization.APITokenPrefixLength)
+	permissions := brokerGrantPermissions
+	clients := types.StringSlice{client.GetID()}
+
+	err = al.tokenManager.Create(req.Context(), &authorization.APIToken{
+		Username:    user.Username,
+		Prefix:      prefix,
+		Name:        fmt.Sprintf("broker grant %s for %s", id, r.Technician),
+		Scope:       authorization.APITokenReadWrite,
+		CreatedAt:   &now,
+		ExpiresAt:   &expiresAt,
+		Token:       tokenHash,
+		Permissions: &permissions,
+		Clients:     &clients,
+	})
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	grant := &authorization.BrokerGrant{
+		ID:          id,
+		ClientID:    client.GetID(),
+		Technician:  r.Technician,
+		Reason:      r.Reason,
+		Username:    user.Username,
+		TokenPrefix: prefix,
+		CreatedAt:   now,
+		ExpiresAt:   expiresAt,
+		Status:      authorization.BrokerGrantActive,
+	}
+	if err := al.brokerGrants.Create(req.Context(), grant); err != nil {
+		_ = al.tokenManager.Delete(req.Context(), user.Username, prefix)
+		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, "Failed to save the broker grant.", err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationAuthBrokerGrant, auditlog.ActionCreate).
+		WithHTTPRequest(req).
+		WithID(id).
+		WithClient(client).
+		WithRequest(r).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusCreated, api.NewSuccessPayload(brokerGrantResponse{
+		BrokerGrant: grant,
+		Token:       fmt.Sprintf("%s_%s", prefix, tokenClear),
+	}))
+}
+
+// handleListBrokerGrants handles GET /broker-grants
+func (al *APIListener) handleListBrokerGrants(w http.ResponseWriter, req *http.Request) {
+	grants, err := al.brokerGrants.List(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(grants))
+}
+
+// handleDeleteBrokerGrant handles DELETE /broker-grants/{id}, it revokes the access before it expires.
+func (al *APIListener) handleDeleteBrokerGrant(w http.ResponseWriter, req *http.Request) {
+	id := mux.Vars(req)["id"]
+	grant, err := al.brokerGrants.Get(req.Context(), id)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if grant == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("broker grant %q not found", id))
+		return
+	}
+	if grant.Status != authorization.BrokerGrantActive {
+		al.jsonErrorResponseWithTitle(w, http.StatusConflict, fmt.Sprintf("broker grant %q is already %s", id, grant.Status))
+		return
+	}
+
+	username := api.GetUser(req.Context(), al.Logger)
+	if err := al.finishBrokerGrant(req.Context(), grant, authorization.BrokerGrantRevoked, username); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationAuthBrokerGrant, auditlog.ActionDelete).
+		WithHTTPRequest(req).
+		WithID(id).
+		WithClientID(grant.ClientID).
+		Save()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// finishBrokerGrant deletes the token of a grant and keeps the grant with its final status.
+func (al *APIListener) finishBrokerGrant(ctx context.Context, grant *authorization.BrokerGrant, status authorization.BrokerGrantStatus, by string) error {
+	// the token might have been deleted along with the tokens of the administrator
+	token, err := al.tokenManager.Get(ctx, grant.Username, grant.TokenPrefix)
+	if err != nil {
+		return err
+	}
+	if token != nil {
+		if err := al.tokenManager.Delete(ctx, grant.Username, grant.TokenPrefix); err != nil {
+			return err
+		}
+	}
+	_, err = al.brokerGrants.Finish(ctx, grant.ID, status, by, time.Now())
+	return err
+}
+
+// brokerGrantsExpiryTask revokes the broker grants at their expiry.
+type brokerGrantsExpiryTask struct {
+	log *logger.Logger
+	al  *APIListener
+}
+
+func (t *brokerGrantsExpiryTask) Run(ctx context.Context) error {
+	expired, err := t.al.brokerGrants.ListExpired(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	for _, grant := range expired {
+		if err := t.al.finishBrokerGrant(ctx, grant, authorization.BrokerGrantExpired, ""); err != nil {
+			return fmt.Errorf("failed to revoke expired broker grant %s: %w", grant.ID, err)
+		}
+		t.log.Infof("Broker grant %s of %s to client %s expired", grant.ID, grant.Technician, grant.ClientID)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package chserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/db/migration/api_token"
+	jobsmigration "github.com/IOTech17/neo-rport/db/migration/jobs"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	"github.com/IOTech17/neo-rport/server/api/authorization"
+	"github.com/IOTech17/neo-rport/server/api/jobs"
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/clients"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/test/jb"
+	"github.com/IOTech17/neo-rport/share/models"
+	"github.com/IOTech17/neo-rport/share/security"
+)
+
+func TestBrokerGrants(t *testing.T) {
+	user := &users.User{
+		Username: "admin",
+		Password: "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm",
+		Groups:   []string{users.Administrators},
+	}
+	apiTokenDb, err := sqlite.New(":memory:", api_token.AssetNames(), api_token.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	defer apiTokenDb.Close()
+
+	jobsDB, err := sqlite.New(":memory:", jobsmigration.AssetNames(), jobsmigration.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	jp := jobs.NewSqliteProvider(jobsDB, testLog)
+	defer jp.Close()
+	multiJob := jb.NewMulti(t).JID("multi-1").Build()
+	multiJob.CreatedBy = "admin"
+	multiJob.ClientIDs = []string{"router-1", "router-2"}
+	require.NoError(t, jp.SaveMultiJob(multiJob))
+	for _, clientID := range multiJob.ClientIDs {
+		job := jb.New(t).ClientID(clientID).MultiJobID(multiJob.JID).Status(models.JobStatusSuccessful).Result(&models.JobResult{StdOut: "output of " + clientID}).Build()
+		require.NoError(t, jp.SaveJob(job))
+	}
+
+	router1 := clients.New(t).ID("router-1").Logger(testLog).Build()
+	router2 := clients.New(t).ID("router-2").Logger(testLog).Build()
+	al := &APIListener{
+		Logger:      testLog,
+		bannedUsers: security.NewBanList(0),
+		apiSessions: newEmptyAPISessionCache(t),
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+				},
+			},
+			clientService:       clients.NewClientService(nil, nil, clients.NewClientRepository([]*clientdata.Client{router1, router2}, nil, testLog), testLog, nil),
+			clientGroupProvider: staticClientGroupProvider{},
+			jobProvider:         jp,
+		},
+		tokenManager: authorization.NewManager(authorization.NewSqliteProvider(apiTokenDb)),
+		brokerGrants: authorization.NewBrokerGrantProvider(apiTokenDb),
+		userService:  users.NewAPIService(users.NewStaticProvider([]*users.User{user}), false, 0, -1),
+	}
+	al.initRouter()
+
+	do := func(method, url, password, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req.SetBasicAuth("admin", password)
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/api/v1/broker-grants", "pwd", `{"client_id":"router-1","technician":"jane","expires_at":"`+time.Now().Add(48*time.Hour).Format(time.RFC3339)+`"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = do(http.MethodPost, "/api/v1/broker-grants", "pwd", `{"client_id":"unknown","technician":"jane"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = do(http.MethodPost, "/api/v1/broker-grants", "pwd", `{"client_id":"router-1","technician":"jane","reason":"replace modem"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Data struct {
+			authorization.BrokerGrant
+			Token string `json:"token"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	grant := created.Data
+	assert.Equal(t, "router-1", grant.ClientID)
+	assert.Equal(t, "admin", grant.Username)
+	assert.Equal(t, authorization.BrokerGrantActive, grant.Status)
+	assert.WithinDuration(t, time.Now().Add(DefaultBrokerGrantLifetime), grant.ExpiresAt, time.Minute)
+
+	// the token is restricted to the client, tunnels and commands
+	w = do(http.MethodGet, "/api/v1/clients", grant.Token, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "router-1")
+	assert.NotContains(t, w.Body.String(), "router-2")
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/api/v1/clients/router-2", grant.Token, "").Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/api/v1/clients/router-1/scripts", grant.Token, `{}`).Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/api/v1/broker-grants", grant.Token, "").Code)
+
+	// jobs of other clients, fleet-wide listings and the command library are out of reach
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/api/v1/commands", grant.Token, "").Code)
+	w = do(http.MethodGet, "/api/v1/commands/multi-1/jobs?fields[jobs]=client_id", grant.Token, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"data":[{"client_id":"router-1"}],"meta":{"count":1}}`, w.Body.String())
+	w = do(http.MethodGet, "/api/v1/commands/multi-1", grant.Token, "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), "output of router-1")
+	assert.NotContains(t, w.Body.String(), "router-2")
+	w = do(http.MethodGet, "/api/v1/commands/multi-1/diff?client_id=router-2", grant.Token, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/api/v1/commands/multi-1/canary/abort", grant.Token, "").Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/api/v1/library/commands", grant.Token, `{"name":"reboot","cmd":"reboot"}`).Code)
+	w = do(http.MethodGet, "/api/v1/commands/multi-1/jobs?fields[jobs]=client_id", "pwd", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"meta":{"count":2}`)
+
+	w = do(http.MethodGet, "/api/v1/broker-grants", "pwd", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Data []authorization.BrokerGrant `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Data, 1)
+	assert.Equal(t, "replace modem", list.Data[0].Reason)
+	assert.Equal(t, 10, list.Data[0].Uses)
+	assert.NotNil(t, list.Data[0].LastUsedAt)
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/api/v1/broker-grants/"+grant.ID, "pwd", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/api/v1/clients", grant.Token, "").Code)
+	assert.Equal(t, http.StatusConflict, do(http.MethodDelete, "/api/v1/broker-grants/"+grant.ID, "pwd", "").Code)
+
+	revoked, err := al.brokerGrants.Get(context.Background(), grant.ID)
+	require.NoError(t, err)
+	assert.Equal(t, authorization.BrokerGrantRevoked, revoked.Status)
+	assert.Equal(t, "admin", revoked.RevokedBy)
+}
+
+func TestBrokerGrantsExpiryTask(t *testing.T) {
+	apiTokenDb, err := sqlite.New(":memory:", api_token.AssetNames(), api_token.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	defer apiTokenDb.Close()
+	ctx := context.Background()
+
+	al := &APIListener{
+		tokenManager: authorization.NewManager(authorization.NewSqliteProvider(apiTokenDb)),
+		brokerGrants: authorization.NewBrokerGrantProvider(apiTokenDb),
+	}
+	now := time.Now().UTC()
+	require.NoError(t, al.tokenManager.Create(ctx, &authorization.APIToken{Username: "admin", Prefix: "broker01", Name: "broker", Token: "hash"}))
+	require.NoError(t, al.brokerGrants.Create(ctx, &authorization.BrokerGrant{
+		ID: "expired", Username: "admin", TokenPrefix: "broker01", CreatedAt: now.Add(-time.Hour), ExpiresAt: now.Add(-time.Minute), Status: authorization.BrokerGrantActive,
+	}))
+	require.NoError(t, al.brokerGrants.Create(ctx, &authorization.BrokerGrant{
+		ID: "active", Username: "admin", TokenPrefix: "broker02", CreatedAt: now, ExpiresAt: now.Add(time.Hour), Status: authorization.BrokerGrantActive,
+	}))
+
+	task := &brokerGrantsExpiryTask{log: testLog, al: al}
+	require.NoError(t, task.Run(ctx))
+
+	token, err := al.tokenManager.Get(ctx, "admin", "broker01")
+	require.NoError(t, err)
+	assert.Nil(t, token)
+	expired, err := al.brokerGrants.Get(ctx, "expired")
+	require.NoError(t, err)
+	assert.Equal(t, authorization.BrokerGrantExpired, expired.Status)
+	active, err := al.brokerGrants.Get(ctx, "active")
+	require.NoError(t, err)
+	assert.Equal(t, authorization.BrokerGrantActive, active.Status)
+}
@@ -0,0 +1,139 @@
+package chserver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/bulk"
+	"github.com/IOTech17/neo-rport/server/routes"
+	"github.com/IOTech17/neo-rport/share/enums"
+)
+
+// bulkResource returns the resource of the request and its format, it writes the error response if one is invalid.
+func (al *APIListener) bulkResource(w http.ResponseWriter, req *http.Request) (string, bulk.Resource, string, bool) {
+	name := mux.Vars(req)[routes.ParamBulkResource]
+	r, ok := al.bulkResources()[name]
+	if !ok {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("Unknown resource %q, expected one of: %s.", name, strings.Join(bulkResourceNames, ", ")))
+		return "", nil, "", false
+	}
+	if name == bulkResourceUserGroups && al.userService.GetProviderType() == enums.ProviderSourceStatic {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "server runs on a static user-password pair, please use JSON file or database for user data")
+		return "", nil, "", false
+	}
+
+	format := req.URL.Query().Get("format")
+	if format == "" {
+		format = bulk.FormatJSON
+	}
+	if !bulk.IsFormat(format) {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("Invalid format %q, expected one of: %s.", format, strings.Join(bulk.Formats, ", ")))
+		return "", nil, "", false
+	}
+	return name, r, format, true
+}
+
+// handleExport handles GET /export/{resource}
+func (al *APIListener) handleExport(w http.ResponseWriter, req *http.Request) {
+	name, r, format, ok := al.bulkResource(w, req)
+	if !ok {
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := bulk.Export(req.Context(), r, format, &buf); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	if name == bulkResourceClientsAuth {
+		// the export contains the passwords
+		al.auditLog.Entry(auditlog.ApplicationBulk, auditlog.ActionExport).
+			WithHTTPRequest(req).
+			WithID(name).
+			Save()
+	}
+
+	contentType := "application/json"
+	if format == bulk.FormatCSV {
+		contentType = "text/csv"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", "attachment; filename="+strconv.Quote(name+"."+format))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		al.Errorf("Failed to write export of %s: %v", name, err)
+	}
+}
+
+// handleImport handles POST /import/{resource}
+func (al *APIListener) handleImport(w http.ResponseWriter, req *http.Request) {
+	name, r, format, ok := al.bulkResource(w, req)
+	if !ok {
+		return
+	}
+	strategy := req.URL.Query().Get("strategy")
+	if strategy == "" {
+		strategy = bulk.StrategyFail
+	}
+	if !bulk.IsStrategy(strategy) {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("Invalid strategy %q, expected one of: %s.", strategy, strings.Join(bulk.Strategies, ", ")))
+		return
+	}
+	dryRun, err := parseDryRun(req)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if name == bulkResourceClientsAuth && !al.allowClientAuthWrite(w) {
+		return
+	}
+
+	records, err := bulk.Decode(r, format, req.Body)
+	if err != nil {
+		al.jsonErrorResponseWithDetail(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid request body.", err.Error())
+		return
+	}
+
+	res, err := bulk.Import(req.Context(), r, records, strategy, dryRun)
+	if errors.Is(err, bulk.ErrInvalid) {
+		errs := make(errors2.APIErrors, 0, len(res.Errors))
+		for _, e := range res.Errors {
+			errs = append(errs, errors2.APIError{
+				Message:    fmt.Sprintf("record %d %q: %s", e.Index, e.ID, e.Message),
+				HTTPStatus: http.StatusBadRequest,
+			})
+		}
+		al.jsonError(w, errs)
+		return
+	}
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	if !dryRun {
+		if name == bulkResourceClientGroups && len(res.Created)+len(res.Updated) > 0 {
+			go al.refreshReverseRemotes(context.Background())
+		}
+		al.Infof("imported %s: %d created, %d updated, %d skipped", name, len(res.Created), len(res.Updated), len(res.Skipped))
+		al.auditLog.Entry(auditlog.ApplicationBulk, auditlog.ActionImport).
+			WithHTTPRequest(req).
+			WithID(name).
+			WithRequest(map[string]string{"format": format, "strategy": strategy}).
+			WithResponse(res).
+			Save()
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(res))
+}
@@ -0,0 +1,115 @@
+package chserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/clientsauth"
+	"github.com/IOTech17/neo-rport/share/security"
+)
+
+func TestHandleBulkImportExport(t *testing.T) {
+	db, err := sqlx.Connect("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	pwd := "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm"
+	for _, sqlExec := range []string{
+		`CREATE TABLE "users" ("username" TEXT PRIMARY KEY, "password" TEXT, "password_expired" BOOLEAN NOT NULL CHECK (password_expired IN (0, 1)) DEFAULT 0)`,
+		`INSERT INTO "users" VALUES("admin","` + pwd + `", false)`,
+		`CREATE TABLE "groups" ("username" TEXT, "group" TEXT)`,
+		`INSERT INTO "groups" VALUES("admin","Administrators")`,
+		`CREATE TABLE "group_details" (name TEXT, permissions TEXT, tunnels_restricted TEXT, commands_restricted TEXT)`,
+		`CREATE UNIQUE INDEX "group_details_name" ON "group_details" ("name" ASC)`,
+		`INSERT INTO "group_details" VALUES("operators",'{"commands":true}',NULL,NULL)`,
+	} {
+		_, err = db.Exec(sqlExec)
+		require.NoError(t, err)
+	}
+	userProvider, err := users.NewUserDatabase(db, "users", "groups", "group_details", false, false, false, testLog)
+	require.NoError(t, err)
+
+	authProvider := clientsauth.NewDatabaseMockProvider([]*clientsauth.ClientAuth{cl1}, t)
+	al := &APIListener{
+		Logger:      testLog,
+		bannedUsers: security.NewBanList(0),
+		apiSessions: newEmptyAPISessionCache(t),
+		Server: &Server{
+			clientAuthProvider: authProvider,
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+				},
+				Server: chconfig.ServerConfig{
+					AuthWrite: true,
+				},
+			},
+			clientGroupProvider: mockClientGroupProvider{},
+		},
+		userService: users.NewAPIService(userProvider, false, 0, -1),
+	}
+	al.initRouter()
+
+	request := func(method, url, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req.SetBasicAuth("admin", "pwd")
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request(http.MethodGet, "/api/v1/export/clients-auth?format=csv", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+	assert.Equal(t, "id,password\nuser1,pswd1\n", w.Body.String())
+
+	w = request(http.MethodGet, "/api/v1/export/users", "")
+	assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+
+	w = request(http.MethodGet, "/api/v1/export/clients-auth?format=xml", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	w = request(http.MethodPost, "/api/v1/import/clients-auth?format=csv", "id,password\nuser1,pswd-new\nuser2,pswd2\n")
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `record 1 \"user1\": already exists`)
+
+	w = request(http.MethodPost, "/api/v1/import/clients-auth?format=csv&strategy=skip&dry_run=true", "id,password\nuser1,pswd-new\nuser2,pswd2\n")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"data":{"dry_run":true,"created":["user2"],"updated":[],"skipped":["user1"],"errors":[]}}`, w.Body.String())
+	ca, err := authProvider.Get("user2")
+	require.NoError(t, err)
+	assert.Nil(t, ca)
+
+	w = request(http.MethodPost, "/api/v1/import/clients-auth?strategy=overwrite", `[{"id":"user1","password":"pswd-new"},{"id":"user2","password":"pswd2"}]`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"data":{"dry_run":false,"created":["user2"],"updated":["user1"],"skipped":[],"errors":[]}}`, w.Body.String())
+	ca, err = authProvider.Get("user1")
+	require.NoError(t, err)
+	assert.Equal(t, "pswd-new", ca.Password)
+
+	w = request(http.MethodGet, "/api/v1/export/user-groups", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"name":"operators"`)
+	assert.NotContains(t, w.Body.String(), users.Administrators)
+
+	w = request(http.MethodPost, "/api/v1/import/user-groups", `[{"name":"Administrators","permissions":{}}]`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	w = request(http.MethodPost, "/api/v1/import/user-groups", `[{"name":"viewers","permissions":{"unknown":true}}]`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	w = request(http.MethodPost, "/api/v1/import/user-groups", `[{"name":"viewers","permissions":{"monitoring":true},"tunnels_restricted":{"local":["^[0-9]+$"]}}]`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	group, err := al.userService.GetGroup("viewers")
+	require.NoError(t, err)
+	assert.True(t, group.Permissions.Has(users.PermissionMonitoring))
+	assert.NotNil(t, group.TunnelsRestricted)
+}
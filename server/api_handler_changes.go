@@ -0,0 +1,92 @@
+package chserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/routes"
+)
+
+var errChangeJournalDisabled = errors2.APIError{
+	Message:    "change journal is disabled, set change_journal_retention in the [api] section",
+	HTTPStatus: http.StatusForbidden,
+}
+
+// recordChange adds the change of an administrative resource to the change journal, before is the snapshot taken
+// before the change. The change is done already, so failures are only logged.
+func (al *APIListener) recordChange(ctx context.Context, resource, id string, before json.RawMessage) {
+	err := al.changeJournal.Record(ctx, resource, id, api.GetUser(ctx, al.Logger), before)
+	if err != nil {
+		al.Errorf("Failed to record change of %s %q: %v", resource, id, err)
+	}
+}
+
+// handleListChanges handles GET /changes
+func (al *APIListener) handleListChanges(w http.ResponseWriter, req *http.Request) {
+	if al.changeJournal == nil {
+		al.jsonError(w, errChangeJournalDisabled)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(al.changeJournal.List()))
+}
+
+// handleGetChange handles GET /changes/{change_id}
+func (al *APIListener) handleGetChange(w http.ResponseWriter, req *http.Request) {
+	if al.changeJournal == nil {
+		al.jsonError(w, errChangeJournalDisabled)
+		return
+	}
+
+	id := mux.Vars(req)[routes.ParamChangeID]
+	change := al.changeJournal.Get(id)
+	if change == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("change %q not found", id))
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(change))
+}
+
+// handleRevertChange handles POST /changes/{change_id}/revert
+func (al *APIListener) handleRevertChange(w http.ResponseWriter, req *http.Request) {
+	if al.changeJournal == nil {
+		al.jsonError(w, errChangeJournalDisabled)
+		return
+	}
+
+	force := false
+	if v := req.URL.Query().Get("force"); v != "" {
+		var err error
+		force, err = strconv.ParseBool(v)
+		if err != nil {
+			al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("invalid force param %q", v))
+			return
+		}
+	}
+
+	ctx := req.Context()
+	id := mux.Vars(req)[routes.ParamChangeID]
+	revert, err := al.changeJournal.Revert(ctx, id, api.GetUser(ctx, al.Logger), force)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.Infof("Change %q of %s %q reverted by %q", id, revert.Resource, revert.ResourceID, revert.Username)
+	al.auditLog.Entry(auditlog.ApplicationChange, auditlog.ActionRevert).
+		WithHTTPRequest(req).
+		WithID(id).
+		WithResponse(revert).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(revert))
+}
@@ -0,0 +1,105 @@
+package chserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/changes"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/clients"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/share/security"
+)
+
+func TestRevertClientACLChange(t *testing.T) {
+	db, err := sqlx.Connect("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	pwd := "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm"
+	for _, sqlExec := range []string{
+		`CREATE TABLE "users" ("username" TEXT PRIMARY KEY, "password" TEXT, "password_expired" BOOLEAN NOT NULL CHECK (password_expired IN (0, 1)) DEFAULT 0)`,
+		`INSERT INTO "users" VALUES("admin","` + pwd + `", false)`,
+		`CREATE TABLE "groups" ("username" TEXT, "group" TEXT)`,
+		`INSERT INTO "groups" VALUES("admin","Administrators")`,
+		`INSERT INTO "groups" VALUES("admin","operators")`,
+		`CREATE TABLE "group_details" ("name" TEXT, "permissions" TEXT)`,
+	} {
+		_, err = db.Exec(sqlExec)
+		require.NoError(t, err)
+	}
+	userProvider, err := users.NewUserDatabase(db, "users", "groups", "group_details", false, false, false, testLog)
+	require.NoError(t, err)
+
+	c1 := clients.New(t).ID("client-1").ClientAuthID(cl1.ID).Logger(testLog).Build()
+	al := &APIListener{
+		Logger:      testLog,
+		bannedUsers: security.NewBanList(0),
+		apiSessions: newEmptyAPISessionCache(t),
+		Server: &Server{
+			clientService: clients.NewClientService(nil, nil, clients.NewClientRepository([]*clientdata.Client{c1}, &hour, testLog), testLog, nil),
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+				},
+			},
+			clientGroupProvider: mockClientGroupProvider{},
+		},
+		userService: users.NewAPIService(userProvider, false, 0, -1),
+	}
+	al.initRouter()
+
+	do := func(method, url, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req.SetBasicAuth("admin", "pwd")
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodGet, "/api/v1/changes", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "change journal is disabled")
+
+	al.changeJournal = changes.New(time.Hour)
+	al.registerChangeResources()
+
+	w = do(http.MethodPost, "/api/v1/clients/client-1/acl", `{"allowed_user_groups":["operators"]}`)
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	assert.Equal(t, []string{"operators"}, c1.GetAllowedUserGroups())
+
+	w = do(http.MethodGet, "/api/v1/changes", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"id":"1","resource":"client_acl","resource_id":"client-1","action":"update","before":{"allowed_user_groups":null},"after":{"allowed_user_groups":["operators"]},"username":"admin"`)
+
+	w = do(http.MethodPost, "/api/v1/changes/1/revert", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"id":"2"`)
+	assert.Contains(t, w.Body.String(), `"revert_of":"1"`)
+	assert.Empty(t, c1.GetAllowedUserGroups())
+
+	w = do(http.MethodGet, "/api/v1/changes/1", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"reverted_by":"admin"`)
+
+	w = do(http.MethodPost, "/api/v1/changes/1/revert", "")
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// reverting the revert redoes the change
+	w = do(http.MethodPost, "/api/v1/changes/2/revert?force=yes", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = do(http.MethodPost, "/api/v1/changes/2/revert", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, []string{"operators"}, c1.GetAllowedUserGroups())
+
+	w = do(http.MethodGet, "/api/v1/changes/42", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,110 @@
+package chserver
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/chaos"
+	"github.com/IOTech17/neo-rport/server/routes"
+)
+
+type chaosFaultRequest struct {
+	Type           string `json:"type"`
+	DelayMS        int    `json:"delay_ms"`
+	BytesPerSecond int    `json:"bytes_per_second"`
+	// DurationSec is the time the fault is active, 0 keeps it until it's deleted
+	DurationSec int `json:"duration_sec"`
+}
+
+var errChaosTestingDisabled = errors2.APIError{
+	Message:    "chaos testing is disabled, enable chaos_testing in the [server] section",
+	HTTPStatus: http.StatusForbidden,
+}
+
+// handleListChaosFaults handles GET /server/chaos
+func (al *APIListener) handleListChaosFaults(w http.ResponseWriter, req *http.Request) {
+	if al.chaos == nil {
+		al.jsonError(w, errChaosTestingDisabled)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(al.chaos.List()))
+}
+
+// handlePutChaosFault handles PUT /server/chaos/{client_id}
+func (al *APIListener) handlePutChaosFault(w http.ResponseWriter, req *http.Request) {
+	if al.chaos == nil {
+		al.jsonError(w, errChaosTestingDisabled)
+		return
+	}
+
+	var params chaosFaultRequest
+	if err := parseRequestBody(req.Body, &params); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if params.DurationSec < 0 {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "duration_sec must not be negative")
+		return
+	}
+
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	now := time.Now()
+	fault := &chaos.Fault{
+		ClientID:       mux.Vars(req)[routes.ParamClientID],
+		Type:           params.Type,
+		DelayMS:        params.DelayMS,
+		BytesPerSecond: params.BytesPerSecond,
+		CreatedBy:      curUser.Username,
+		CreatedAt:      now,
+	}
+	if params.DurationSec > 0 {
+		expiresAt := now.Add(time.Duration(params.DurationSec) * time.Second)
+		fault.ExpiresAt = &expiresAt
+	}
+	if err := al.chaos.Set(fault); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.Infof("Chaos fault %q injected into the connections of client %q by %q", fault.Type, fault.ClientID, curUser.Username)
+	al.auditLog.Entry(auditlog.ApplicationServerChaos, auditlog.ActionUpdate).
+		WithHTTPRequest(req).
+		WithClientID(fault.ClientID).
+		WithRequest(params).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(fault))
+}
+
+// handleDeleteChaosFault handles DELETE /server/chaos/{client_id}
+func (al *APIListener) handleDeleteChaosFault(w http.ResponseWriter, req *http.Request) {
+	if al.chaos == nil {
+		al.jsonError(w, errChaosTestingDisabled)
+		return
+	}
+
+	clientID := mux.Vars(req)[routes.ParamClientID]
+	if !al.chaos.Delete(clientID) {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, "no chaos fault found for the client")
+		return
+	}
+
+	al.Infof("Chaos fault of client %q removed", clientID)
+	al.auditLog.Entry(auditlog.ApplicationServerChaos, auditlog.ActionDelete).
+		WithHTTPRequest(req).
+		WithClientID(clientID).
+		Save()
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,55 @@
+package chserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/server/api/session"
+	"github.com/IOTech17/neo-rport/server/chaos"
+)
+
+func TestChaosFaults(t *testing.T) {
+	al := setupTestAPIListenerSessionPolicy(t, session.ConcurrentSessionsAllow, 0)
+
+	do := func(method, url, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req.SetBasicAuth("admin", "pwd")
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodGet, "/api/v1/server/chaos", "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "chaos testing is disabled")
+
+	al.chaos = chaos.New(true)
+
+	w = do(http.MethodPut, "/api/v1/server/chaos/client-1", `{"type":"throttle","bytes_per_second":1024,"duration_sec":60}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"created_by":"admin"`)
+
+	w = do(http.MethodPut, "/api/v1/server/chaos/client-2", `{"type":"delay"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = do(http.MethodPut, "/api/v1/server/chaos/client-2", `{"type":"drop"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, al.chaos.Drops("client-2"))
+
+	w = do(http.MethodGet, "/api/v1/server/chaos", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"client_id":"client-1","type":"throttle","bytes_per_second":1024`)
+	assert.Contains(t, w.Body.String(), `"client_id":"client-2","type":"drop","expires_at":null`)
+
+	w = do(http.MethodDelete, "/api/v1/server/chaos/client-2", "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.False(t, al.chaos.Drops("client-2"))
+
+	w = do(http.MethodDelete, "/api/v1/server/chaos/client-2", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
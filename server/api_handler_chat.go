@@ -0,0 +1,160 @@
+package chserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/chat"
+	"github.com/IOTech17/neo-rport/server/routes"
+	"github.com/IOTech17/neo-rport/share/comm"
+)
+
+// handleGetClientChat handles GET /clients/{client_id}/chat, it returns the chat messages exchanged with the user of
+// the client machine, optionally filtered by session_id.
+func (al *APIListener) handleGetClientChat(w http.ResponseWriter, req *http.Request) {
+	clientID := mux.Vars(req)[routes.ParamClientID]
+
+	messages, err := al.chat.List(req.Context(), clientID, req.URL.Query().Get("session_id"))
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(messages))
+}
+
+// handlePostClientChat handles POST /clients/{client_id}/chat, it shows the message to the user of the client machine.
+// The message continues the current chat session of the client unless another session_id is given.
+func (al *APIListener) handlePostClientChat(w http.ResponseWriter, req *http.Request) {
+	clientID := mux.Vars(req)[routes.ParamClientID]
+
+	var reqBody struct {
+		Text      string `json:"text"`
+		SessionID string `json:"session_id"`
+	}
+	if err := parseRequestBody(req.Body, &reqBody); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	reqBody.Text = strings.TrimSpace(reqBody.Text)
+	if reqBody.Text == "" || len(reqBody.Text) > chat.MaxTextLength {
+		al.jsonError(w, errors2.APIError{
+			HTTPStatus: http.StatusBadRequest,
+			Message:    fmt.Sprintf("text must not be empty and not longer than %d bytes", chat.MaxTextLength),
+		})
+		return
+	}
+
+	client, err := al.clientService.GetActiveByID(clientID)
+	if err != nil {
+		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if client == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("client with id %s not found", clientID))
+		return
+	}
+
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	now := time.Now().UTC()
+	sessionID := reqBody.SessionID
+	if sessionID == "" {
+		sessionID, err = al.chat.CurrentSessionID(req.Context(), clientID, now)
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
+		if sessionID == "" {
+			sessionID = uuid.New().String()
+		}
+	}
+
+	msg := &chat.Message{
+		ClientID:  clientID,
+		SessionID: sessionID,
+		Direction: chat.DirectionOperator,
+		Author:    curUser.Username,
+		Text:      reqBody.Text,
+		CreatedAt: now,
+	}
+	if err := al.chat.Save(req.Context(), msg); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	err = comm.SendRequestAndGetResponse(client.GetConnection(), comm.RequestTypeChatMessage, comm.ChatMessage{
+		ID:        msg.ID,
+		SessionID: msg.SessionID,
+		From:      msg.Author,
+		Text:      msg.Text,
+	}, nil, al.Log())
+	if err != nil {
+		// the message is not stored, if it could not be shown
+		if dErr := al.chat.Delete(req.Context(), msg.ID); dErr != nil {
+			al.Errorf("Failed to delete chat message %d: %v", msg.ID, dErr)
+		}
+		if strings.Contains(err.Error(), "unknown request") {
+			err = errors.New("client does not support chat")
+		}
+		al.jsonError(w, errors2.APIError{
+			HTTPStatus: http.StatusConflict,
+			Err:        err,
+		})
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientChat, auditlog.ActionCreate).
+		WithHTTPRequest(req).
+		WithClient(client).
+		WithID(msg.ID).
+		WithRequest(map[string]string{"session_id": msg.SessionID}).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusCreated, api.NewSuccessPayload(msg))
+}
+
+// saveChatReply stores the answer of the user of the client machine in the session of the message it replies to.
+func (cl *ClientListener) saveChatReply(clientID string, payload []byte) error {
+	var reply comm.ChatReply
+	if err := json.Unmarshal(payload, &reply); err != nil {
+		return fmt.Errorf("failed to decode %T: %v", reply, err)
+	}
+	text := strings.TrimSpace(reply.Text)
+	if len(text) > chat.MaxTextLength {
+		text = text[:chat.MaxTextLength]
+	}
+
+	ctx := cl.getCtx()
+	replyTo, err := cl.server.chat.Get(ctx, clientID, reply.ReplyTo)
+	if err != nil {
+		return err
+	}
+	if replyTo == nil {
+		return fmt.Errorf("chat message %d not found", reply.ReplyTo)
+	}
+
+	msg := &chat.Message{
+		ClientID:  clientID,
+		SessionID: replyTo.SessionID,
+		Direction: chat.DirectionUser,
+		Text:      text,
+		ReplyTo:   &replyTo.ID,
+		CreatedAt: time.Now().UTC(),
+	}
+	return cl.server.chat.Save(ctx, msg)
+}
@@ -0,0 +1,107 @@
+package chserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	chatmigration "github.com/IOTech17/neo-rport/db/migration/chat"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/chat"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/clients"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/security"
+	"github.com/IOTech17/neo-rport/share/test"
+)
+
+func TestClientChat(t *testing.T) {
+	db, err := sqlite.New(":memory:", chatmigration.AssetNames(), chatmigration.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	defer db.Close()
+
+	c1 := clients.New(t).ID("client-1").Logger(testLog).Build()
+	connMock := test.NewConnMock()
+	connMock.ReturnOk = true
+	c1.SetConnection(connMock)
+	al := &APIListener{
+		Logger:      testLog,
+		bannedUsers: security.NewBanList(0),
+		apiSessions: newEmptyAPISessionCache(t),
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+				},
+			},
+			clientService:       clients.NewClientService(nil, nil, clients.NewClientRepository([]*clientdata.Client{c1}, &hour, testLog), testLog, nil),
+			chat:                chat.NewSqliteProvider(db),
+			clientGroupProvider: staticClientGroupProvider{},
+		},
+		userService: users.NewAPIService(users.NewStaticProvider([]*users.User{
+			{Username: "admin", Password: "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm", Groups: []string{users.Administrators}},
+		}), false, 0, -1),
+	}
+	al.initRouter()
+
+	request := func(method, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, "/api/v1/clients/client-1/chat", strings.NewReader(body))
+		req.SetBasicAuth("admin", "pwd")
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request(http.MethodPost, `{"text":"  "}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = request(http.MethodPost, `{"text":"Can I restart the machine?"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var res struct {
+		Data *chat.Message `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, "admin", res.Data.Author)
+	assert.NotEmpty(t, res.Data.SessionID)
+
+	name, _, payload := connMock.InputSendRequest()
+	assert.Equal(t, comm.RequestTypeChatMessage, name)
+	assert.JSONEq(t, `{"ID":1,"SessionID":"`+res.Data.SessionID+`","From":"admin","Text":"Can I restart the machine?"}`, string(payload))
+
+	cl := &ClientListener{server: al.Server, ctx: context.Background()}
+	assert.EqualError(t, cl.saveChatReply("client-1", []byte(`{"ReplyTo":2,"Text":"yes"}`)), "chat message 2 not found")
+	require.NoError(t, cl.saveChatReply("client-1", []byte(`{"ReplyTo":1,"Text":" yes "}`)))
+
+	// the next message continues the session
+	w = request(http.MethodPost, `{"text":"Thanks"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	w = request(http.MethodGet, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Data []*chat.Message `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Data, 3)
+	assert.Equal(t, chat.DirectionUser, list.Data[1].Direction)
+	assert.Equal(t, "yes", list.Data[1].Text)
+	for _, m := range list.Data {
+		assert.Equal(t, res.Data.SessionID, m.SessionID)
+	}
+
+	connMock.ReturnOk = false
+	connMock.ReturnResponsePayload = []byte(`chat is disabled by "chat.enabled" config`)
+	w = request(http.MethodPost, `{"text":"Hello?"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = request(http.MethodGet, "")
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Len(t, list.Data, 3)
+}
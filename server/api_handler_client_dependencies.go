@@ -0,0 +1,82 @@
+package chserver
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/alerts"
+	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/routes"
+)
+
+type clientDependencyRequest struct {
+	ParentID string `json:"parent_id"`
+}
+
+func (al *APIListener) handleListClientDependencies(w http.ResponseWriter, req *http.Request) {
+	deps, err := al.clientDependencies.List(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(deps))
+}
+
+func (al *APIListener) handlePutClientDependency(w http.ResponseWriter, req *http.Request) {
+	clientID := mux.Vars(req)[routes.ParamClientID]
+
+	var body clientDependencyRequest
+	if err := parseRequestBody(req.Body, &body); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if body.ParentID == "" {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "Missing parent_id.")
+		return
+	}
+	for _, id := range []string{clientID, body.ParentID} {
+		client, err := al.clientService.GetByID(id)
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
+		if client == nil {
+			al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("Client with id=%q not found.", id))
+			return
+		}
+	}
+
+	dep := alerts.ClientDependency{ClientID: clientID, ParentID: body.ParentID}
+	if err := al.clientDependencies.Save(req.Context(), dep); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationAlertingDependency, auditlog.ActionUpdate).
+		WithHTTPRequest(req).
+		WithID(clientID).
+		WithRequest(body).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(dep))
+}
+
+func (al *APIListener) handleDeleteClientDependency(w http.ResponseWriter, req *http.Request) {
+	clientID := mux.Vars(req)[routes.ParamClientID]
+
+	if err := al.clientDependencies.Delete(req.Context(), clientID); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationAlertingDependency, auditlog.ActionDelete).
+		WithHTTPRequest(req).
+		WithID(clientID).
+		Save()
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,180 @@
+package chserver
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/clientsauth"
+	"github.com/IOTech17/neo-rport/server/routes"
+)
+
+type clientIdentityTransferRequest struct {
+	ClientAuthID string `json:"client_auth_id"`
+	// Password creates the client auth credentials, without it they must exist already
+	Password             string `json:"password"`
+	RevokeOldCredentials bool   `json:"revoke_old_credentials"`
+}
+
+type clientIdentityTransferResponse struct {
+	*clientdata.IdentityTransfer
+	RevokedClientAuthID string `json:"revoked_client_auth_id,omitempty"`
+}
+
+// handlePostClientIdentityTransfer handles POST /clients/{client_id}/identity-transfer
+func (al *APIListener) handlePostClientIdentityTransfer(w http.ResponseWriter, req *http.Request) {
+	cid := mux.Vars(req)[routes.ParamClientID]
+
+	var reqBody clientIdentityTransferRequest
+	if err := parseRequestBody(req.Body, &reqBody); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if reqBody.ClientAuthID == "" {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "The client auth id of the replacement is required.")
+		return
+	}
+
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	client, err := al.clientService.GetByID(cid)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if client == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("Client with id=%q not found.", cid))
+		return
+	}
+	if client.IsConnected() {
+		al.jsonErrorResponseWithTitle(w, http.StatusConflict, fmt.Sprintf("Client with id=%q is connected, only the identity of a disconnected client can be transferred.", cid))
+		return
+	}
+	if transfer := client.GetIdentityTransfer(); transfer.IsPending() {
+		al.jsonErrorResponseWithTitle(w, http.StatusConflict, fmt.Sprintf("Client with id=%q is already waiting for its replacement with client auth id %q.", cid, transfer.ClientAuthID))
+		return
+	}
+	oldClientAuthID := client.GetClientAuthID()
+	if reqBody.ClientAuthID == oldClientAuthID {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "The replacement requires a new client auth id.")
+		return
+	}
+	if bound := al.clientService.GetAllByClientID(reqBody.ClientAuthID); len(bound) > 0 {
+		al.jsonErrorResponseWithTitle(w, http.StatusConflict, fmt.Sprintf("Client Auth with ID=%q is already used by client %q.", reqBody.ClientAuthID, bound[0].GetID()))
+		return
+	}
+
+	if reqBody.RevokeOldCredentials {
+		if !al.allowClientAuthWrite(w) {
+			return
+		}
+		for _, c := range al.clientService.GetAllByClientID(oldClientAuthID) {
+			if c.GetID() != cid {
+				al.jsonErrorResponseWithTitle(w, http.StatusConflict, fmt.Sprintf("Client Auth with ID=%q can't be revoked, it's also used by client %q.", oldClientAuthID, c.GetID()))
+				return
+			}
+		}
+	}
+
+	if reqBody.Password != "" {
+		if !al.allowClientAuthWrite(w) {
+			return
+		}
+		if len(reqBody.ClientAuthID) < MinCredentialsLength || len(reqBody.Password) < MinCredentialsLength {
+			al.jsonErrorResponseWithDetail(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid client auth id or password.", fmt.Sprintf("Min size is %d.", MinCredentialsLength))
+			return
+		}
+		added, err := al.clientAuthProvider.Add(&clientsauth.ClientAuth{ID: reqBody.ClientAuthID, Password: reqBody.Password})
+		if err != nil {
+			al.jsonErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+		if !added {
+			al.jsonErrorResponseWithDetail(w, http.StatusConflict, ErrCodeAlreadyExist, fmt.Sprintf("Client Auth with ID %q already exist.", reqBody.ClientAuthID), "")
+			return
+		}
+	} else {
+		existing, err := al.clientAuthProvider.Get(reqBody.ClientAuthID)
+		if err != nil {
+			al.jsonErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+		if existing == nil {
+			al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("Client Auth with ID=%q not found, set a password to create it.", reqBody.ClientAuthID))
+			return
+		}
+	}
+
+	transfer := &clientdata.IdentityTransfer{
+		ClientAuthID: reqBody.ClientAuthID,
+		By:           curUser.Username,
+		At:           clientdata.Now().UTC(),
+	}
+	if err := al.clientService.SetIdentityTransfer(cid, transfer); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	resp := &clientIdentityTransferResponse{IdentityTransfer: transfer}
+	if reqBody.RevokeOldCredentials {
+		if err := al.clientAuthProvider.Delete(oldClientAuthID); err != nil {
+			al.jsonErrorResponse(w, http.StatusInternalServerError, err)
+			return
+		}
+		resp.RevokedClientAuthID = oldClientAuthID
+	}
+
+	al.Infof("identity of client %s transferred to client auth id %q by %s", cid, transfer.ClientAuthID, curUser.Username)
+	// the password is not logged
+	reqBody.Password = ""
+	al.auditLog.Entry(auditlog.ApplicationClientIdentity, auditlog.ActionCreate).
+		WithHTTPRequest(req).
+		WithClient(client).
+		WithRequest(reqBody).
+		WithResponse(resp).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(resp))
+}
+
+// handleDeleteClientIdentityTransfer handles DELETE /clients/{client_id}/identity-transfer
+func (al *APIListener) handleDeleteClientIdentityTransfer(w http.ResponseWriter, req *http.Request) {
+	cid := mux.Vars(req)[routes.ParamClientID]
+
+	client, err := al.clientService.GetByID(cid)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if client == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("Client with id=%q not found.", cid))
+		return
+	}
+	transfer := client.GetIdentityTransfer()
+	if !transfer.IsPending() {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("Client with id=%q is not waiting for its replacement.", cid))
+		return
+	}
+
+	if err := al.clientService.SetIdentityTransfer(cid, nil); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.Infof("identity transfer of client %s canceled", cid)
+	al.auditLog.Entry(auditlog.ApplicationClientIdentity, auditlog.ActionDelete).
+		WithHTTPRequest(req).
+		WithClient(client).
+		WithRequest(transfer).
+		Save()
+
+	w.WriteHeader(http.StatusNoContent)
+}
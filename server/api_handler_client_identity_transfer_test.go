@@ -0,0 +1,104 @@
+package chserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/clients"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/clientsauth"
+	"github.com/IOTech17/neo-rport/share/security"
+)
+
+func TestHandleClientIdentityTransfer(t *testing.T) {
+	db, err := sqlx.Connect("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	pwd := "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm"
+	for _, sqlExec := range []string{
+		`CREATE TABLE "users" ("username" TEXT PRIMARY KEY, "password" TEXT, "password_expired" BOOLEAN NOT NULL CHECK (password_expired IN (0, 1)) DEFAULT 0)`,
+		`INSERT INTO "users" VALUES("admin","` + pwd + `", false)`,
+		`CREATE TABLE "groups" ("username" TEXT, "group" TEXT)`,
+		`INSERT INTO "groups" VALUES("admin","Administrators")`,
+		`CREATE TABLE "group_details" ("name" TEXT, "permissions" TEXT)`,
+	} {
+		_, err = db.Exec(sqlExec)
+		require.NoError(t, err)
+	}
+	userProvider, err := users.NewUserDatabase(db, "users", "groups", "group_details", false, false, false, testLog)
+	require.NoError(t, err)
+
+	authProvider := clientsauth.NewDatabaseMockProvider([]*clientsauth.ClientAuth{cl1}, t)
+	c1 := clients.New(t).ID("client-1").ClientAuthID(cl1.ID).DisconnectedDuration(5 * time.Minute).Logger(testLog).Build()
+	clientService := clients.NewClientService(nil, nil, clients.NewClientRepository([]*clientdata.Client{c1}, &hour, testLog), testLog, nil)
+	al := &APIListener{
+		Logger:      testLog,
+		bannedUsers: security.NewBanList(0),
+		apiSessions: newEmptyAPISessionCache(t),
+		Server: &Server{
+			clientService:      clientService,
+			clientAuthProvider: authProvider,
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+				},
+				Server: chconfig.ServerConfig{
+					AuthWrite: true,
+				},
+			},
+			clientGroupProvider: mockClientGroupProvider{},
+		},
+		userService: users.NewAPIService(userProvider, false, 0, -1),
+	}
+	al.initRouter()
+
+	request := func(method, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, "/api/v1/clients/client-1/identity-transfer", strings.NewReader(body))
+		req.SetBasicAuth("admin", "pwd")
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request(http.MethodPost, `{}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	w = request(http.MethodPost, `{"client_auth_id":"replacement-1"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), "set a password to create it")
+
+	w = request(http.MethodPost, `{"client_auth_id":"replacement-1","password":"secret-1","revoke_old_credentials":true}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"client_auth_id":"replacement-1","by":"admin"`)
+	assert.Contains(t, w.Body.String(), `"completed_at":null,"revoked_client_auth_id":"user1"`)
+
+	assert.True(t, c1.GetIdentityTransfer().IsPending())
+	assert.Equal(t, "client-1", clientService.GetTransferredClientID("replacement-1"))
+	auth, err := authProvider.Get("replacement-1")
+	require.NoError(t, err)
+	assert.NotNil(t, auth)
+	auth, err = authProvider.Get(cl1.ID)
+	require.NoError(t, err)
+	assert.Nil(t, auth)
+
+	w = request(http.MethodPost, `{"client_auth_id":"replacement-2","password":"secret-2"}`)
+	assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+
+	w = request(http.MethodDelete, "")
+	assert.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	assert.Nil(t, c1.GetIdentityTransfer())
+	assert.Equal(t, "", clientService.GetTransferredClientID("replacement-1"))
+
+	w = request(http.MethodDelete, "")
+	assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+}
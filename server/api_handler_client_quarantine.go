@@ -0,0 +1,155 @@
+package chserver
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/routes"
+)
+
+type clientQuarantineRequest struct {
+	Reason string `json:"reason"`
+}
+
+type clientQuarantineResponse struct {
+	*clientdata.Quarantine
+	ClosedTunnels        int `json:"closed_tunnels"`
+	ClosedMeshTunnels    int `json:"closed_mesh_tunnels"`
+	ClosedReverseRemotes int `json:"closed_reverse_remotes"`
+}
+
+// handlePostClientQuarantine handles POST /clients/{client_id}/quarantine
+func (al *APIListener) handlePostClientQuarantine(w http.ResponseWriter, req *http.Request) {
+	cid := mux.Vars(req)[routes.ParamClientID]
+
+	var reqBody clientQuarantineRequest
+	if err := parseRequestBody(req.Body, &reqBody); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	reqBody.Reason = strings.TrimSpace(reqBody.Reason)
+	if reqBody.Reason == "" {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "A reason is required to quarantine a client.")
+		return
+	}
+
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	client, err := al.clientService.GetByID(cid)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if client == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("Client with id=%q not found.", cid))
+		return
+	}
+	if client.IsQuarantined() {
+		al.jsonErrorResponseWithTitle(w, http.StatusConflict, fmt.Sprintf("Client with id=%q is already quarantined.", cid))
+		return
+	}
+
+	quarantine := &clientdata.Quarantine{
+		Reason: reqBody.Reason,
+		By:     curUser.Username,
+		At:     clientdata.Now().UTC(),
+	}
+	// set first, so no new tunnels are started while the existing ones are closed
+	if err := al.clientService.SetQuarantine(cid, quarantine); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	resp := &clientQuarantineResponse{Quarantine: quarantine}
+	for _, t := range client.GetTunnels() {
+		if err := al.clientService.TerminateTunnel(client, t, true); err != nil {
+			al.Errorf("failed to close tunnel %s of quarantined client %s: %v", t.ID, cid, err)
+			continue
+		}
+		resp.ClosedTunnels++
+	}
+	for _, mt := range al.meshTunnels.DeleteByClient(cid) {
+		if err := al.stopMeshTunnel(mt); err != nil {
+			al.Errorf("failed to stop mesh tunnel %s of quarantined client %s: %v", mt.ID, cid, err)
+		}
+		resp.ClosedMeshTunnels++
+	}
+	if client.IsConnected() {
+		// the client is quarantined, so all its reverse remotes are removed
+		resp.ClosedReverseRemotes = len(client.GetReverseRemotes())
+		al.updateReverseRemotes(client, nil)
+	}
+
+	al.Infof("client %s quarantined by %s: %s", cid, curUser.Username, quarantine.Reason)
+	al.auditLog.Entry(auditlog.ApplicationClientQuarantine, auditlog.ActionCreate).
+		WithHTTPRequest(req).
+		WithClient(client).
+		WithRequest(reqBody).
+		WithResponse(resp).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(resp))
+}
+
+// handleDeleteClientQuarantine handles DELETE /clients/{client_id}/quarantine
+func (al *APIListener) handleDeleteClientQuarantine(w http.ResponseWriter, req *http.Request) {
+	cid := mux.Vars(req)[routes.ParamClientID]
+
+	client, err := al.clientService.GetByID(cid)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if client == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("Client with id=%q not found.", cid))
+		return
+	}
+	quarantine := client.GetQuarantine()
+	if quarantine == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("Client with id=%q is not quarantined.", cid))
+		return
+	}
+
+	if err := al.clientService.SetQuarantine(cid, nil); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	if client.IsConnected() {
+		groups, err := al.clientGroupProvider.GetAll(req.Context())
+		if err != nil {
+			al.Errorf("failed to restore reverse remotes of client %s: %v", cid, err)
+		} else {
+			al.updateReverseRemotes(client, groups)
+		}
+	}
+
+	al.Infof("quarantine of client %s lifted", cid)
+	al.auditLog.Entry(auditlog.ApplicationClientQuarantine, auditlog.ActionDelete).
+		WithHTTPRequest(req).
+		WithClient(client).
+		WithRequest(quarantine).
+		Save()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func quarantinedClientIDs(clients []*clientdata.Client) []string {
+	var ids []string
+	for _, c := range clients {
+		if c.IsQuarantined() {
+			ids = append(ids, c.GetID())
+		}
+	}
+	return ids
+}
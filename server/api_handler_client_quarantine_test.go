@@ -0,0 +1,137 @@
+package chserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/clients"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/clients/meshtunnel"
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/models"
+	"github.com/IOTech17/neo-rport/share/security"
+	"github.com/IOTech17/neo-rport/share/test"
+)
+
+func TestHandleClientQuarantine(t *testing.T) {
+	db, err := sqlx.Connect("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	pwd := "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm"
+	for _, sqlExec := range []string{
+		`CREATE TABLE "users" ("username" TEXT PRIMARY KEY, "password" TEXT, "password_expired" BOOLEAN NOT NULL CHECK (password_expired IN (0, 1)) DEFAULT 0)`,
+		`INSERT INTO "users" VALUES("admin","` + pwd + `", false)`,
+		`INSERT INTO "users" VALUES("responder","` + pwd + `", false)`,
+		`CREATE TABLE "groups" ("username" TEXT, "group" TEXT)`,
+		`INSERT INTO "groups" VALUES("admin","Administrators")`,
+		`INSERT INTO "groups" VALUES("responder","Administrators")`,
+		`INSERT INTO "groups" VALUES("responder","incident-response")`,
+		`CREATE TABLE "group_details" ("name" TEXT, "permissions" TEXT)`,
+		`INSERT INTO "group_details" VALUES('incident-response','{"quarantine":true}')`,
+	} {
+		_, err = db.Exec(sqlExec)
+		require.NoError(t, err)
+	}
+	userProvider, err := users.NewUserDatabase(db, "users", "groups", "group_details", false, false, false, testLog)
+	require.NoError(t, err)
+
+	connMock := test.NewConnMock()
+	c1 := clients.New(t).ID("client-1").ClientAuthID(cl1.ID).Logger(testLog).Build()
+	c1.SetConnection(connMock)
+	c1.SetTunnels(nil)
+	mirror, err := models.NewRemoteWithDefaultLocalHost("3142:apt-mirror:3142", models.LocalHost)
+	require.NoError(t, err)
+	c1.SetReverseRemotes([]*models.Remote{mirror})
+	al := &APIListener{
+		Logger:      testLog,
+		bannedUsers: security.NewBanList(0),
+		apiSessions: newEmptyAPISessionCache(t),
+		Server: &Server{
+			Logger:        testLog,
+			clientService: clients.NewClientService(nil, nil, clients.NewClientRepository([]*clientdata.Client{c1}, &hour, testLog), testLog, nil),
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+				},
+			},
+			clientGroupProvider: mockClientGroupProvider{},
+			meshTunnels:         meshtunnel.NewManager(),
+		},
+		userService: users.NewAPIService(userProvider, false, 0, -1),
+	}
+	al.initRouter()
+
+	request := func(method, url, username, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req.SetBasicAuth(username, "pwd")
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request(http.MethodPost, "/api/v1/clients/client-1/quarantine", "admin", `{"reason":" "}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	w = request(http.MethodPost, "/api/v1/clients/client-1/quarantine", "admin", `{"reason":"suspicious outbound traffic"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var result struct {
+		Data clientQuarantineResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, "suspicious outbound traffic", result.Data.Reason)
+	assert.Equal(t, "admin", result.Data.By)
+	assert.Equal(t, 0, result.Data.ClosedTunnels)
+	assert.Equal(t, 1, result.Data.ClosedReverseRemotes)
+	assert.Empty(t, c1.GetReverseRemotes())
+	require.True(t, c1.IsQuarantined())
+	// the connection is kept
+	assert.True(t, c1.IsConnected())
+
+	w = request(http.MethodPost, "/api/v1/clients/client-1/quarantine", "admin", `{"reason":"again"}`)
+	assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+
+	w = request(http.MethodPut, "/api/v1/clients/client-1/tunnels?local=0.0.0.0%3A3390&remote=0.0.0.0%3A22&check_port=0", "admin", "")
+	assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), "quarantined (reason = suspicious outbound traffic)")
+
+	_, err = al.clientService.StartClientTunnels(c1, nil)
+	assert.EqualError(t, err, clientdata.ErrQuarantined.Error())
+
+	// reverse remotes still known to the client are refused
+	c1.SetReverseRemotes([]*models.Remote{mirror})
+	reverse := &newChannelMock{channelType: comm.ChannelReverseRemote, extraData: []byte(mirror.Remote())}
+	(&ClientListener{server: al.Server}).handleReverseRemoteChannel(testLog, "client-1", reverse)
+	assert.Equal(t, ssh.Prohibited, reverse.rejected)
+
+	// being an admin is not enough to lift it
+	w = request(http.MethodDelete, "/api/v1/clients/client-1/quarantine", "admin", "")
+	assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+	assert.True(t, c1.IsQuarantined())
+
+	w = request(http.MethodDelete, "/api/v1/clients/client-1/quarantine", "responder", "")
+	assert.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	assert.False(t, c1.IsQuarantined())
+
+	w = request(http.MethodDelete, "/api/v1/clients/client-1/quarantine", "responder", "")
+	assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+}
+
+func TestQuarantinedClientIDs(t *testing.T) {
+	c1 := clients.New(t).ID("client-1").Logger(testLog).Build()
+	c2 := clients.New(t).ID("client-2").Logger(testLog).Build()
+	c2.SetQuarantine(&clientdata.Quarantine{Reason: "test"})
+
+	assert.Equal(t, []string{"client-2"}, quarantinedClientIDs([]*clientdata.Client{c1, c2}))
+	assert.Empty(t, quarantinedClientIDs([]*clientdata.Client{c1}))
+}
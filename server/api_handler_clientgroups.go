@@ -1,6 +1,7 @@
 package chserver
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -12,6 +13,7 @@ import (
 	"github.com/IOTech17/neo-rport/server/api"
 	"github.com/IOTech17/neo-rport/server/auditlog"
 	"github.com/IOTech17/neo-rport/server/cgroups"
+	"github.com/IOTech17/neo-rport/server/changes"
 	"github.com/IOTech17/neo-rport/server/routes"
 	"github.com/IOTech17/neo-rport/share/ptr"
 	"github.com/IOTech17/neo-rport/share/query"
@@ -31,10 +33,17 @@ func (al *APIListener) handlePostClientGroups(w http.ResponseWriter, req *http.R
 		return
 	}
 
+	before, err := al.changeJournal.Snapshot(req.Context(), changes.ResourceClientGroup, group.ID)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
 	if err := al.clientGroupProvider.Create(req.Context(), &group); err != nil {
 		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, "Failed to persist a new client group.", err)
 		return
 	}
+	al.recordChange(req.Context(), changes.ResourceClientGroup, group.ID, before)
 
 	al.auditLog.Entry(auditlog.ApplicationClientGroup, auditlog.ActionCreate).
 		WithHTTPRequest(req).
@@ -43,6 +52,7 @@ func (al *APIListener) handlePostClientGroups(w http.ResponseWriter, req *http.R
 		Save()
 
 	w.WriteHeader(http.StatusCreated)
+	go al.refreshReverseRemotes(context.Background())
 	al.Debugf("Client Group [id=%q] created.", group.ID)
 }
 
@@ -71,10 +81,34 @@ func (al *APIListener) handlePutClientGroup(w http.ResponseWriter, req *http.Req
 		return
 	}
 
+	dryRun, err := parseDryRun(req)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if dryRun {
+		existing, err := al.clientGroupProvider.Get(req.Context(), id)
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
+		affected := []affectedResource{{Type: "client_group", ID: id, Change: changeUpdate}}
+		affected = append(affected, clientGroupChanges(al.clientService.GetAll(), existing, &group)...)
+		al.writeDryRunResponse(w, affected)
+		return
+	}
+
+	before, err := al.changeJournal.Snapshot(req.Context(), changes.ResourceClientGroup, id)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
 	if err := al.clientGroupProvider.Update(req.Context(), &group); err != nil {
 		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, "Failed to persist client group.", err)
 		return
 	}
+	al.recordChange(req.Context(), changes.ResourceClientGroup, id, before)
 
 	al.auditLog.Entry(auditlog.ApplicationClientGroup, auditlog.ActionUpdate).
 		WithHTTPRequest(req).
@@ -83,6 +117,7 @@ func (al *APIListener) handlePutClientGroup(w http.ResponseWriter, req *http.Req
 		Save()
 
 	w.WriteHeader(http.StatusNoContent)
+	go al.refreshReverseRemotes(context.Background())
 	al.Debugf("Client Group [id=%q] updated.", group.ID)
 }
 
@@ -107,6 +142,19 @@ func validateInputClientGroup(group cgroups.ClientGroup) error {
 			return err
 		}
 	}
+	if _, err := group.ParseReverseRemotes(); err != nil {
+		return err
+	}
+	if group.AccessSchedule != nil {
+		if err := group.AccessSchedule.Validate(); err != nil {
+			return err
+		}
+	}
+	if group.MaintenanceWindows != nil {
+		if err := group.MaintenanceWindows.Validate(); err != nil {
+			return fmt.Errorf("invalid maintenance windows: %v", err)
+		}
+	}
 	return nil
 }
 
@@ -224,11 +272,40 @@ func (al *APIListener) handleDeleteClientGroup(w http.ResponseWriter, req *http.
 		return
 	}
 
-	err := al.clientGroupProvider.Delete(req.Context(), id)
+	dryRun, err := parseDryRun(req)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if dryRun {
+		existing, err := al.clientGroupProvider.Get(req.Context(), id)
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
+		if existing == nil {
+			// deleting a missing group succeeds without changes
+			al.writeDryRunResponse(w, nil)
+			return
+		}
+		affected := []affectedResource{{Type: "client_group", ID: id, Change: changeDelete}}
+		affected = append(affected, clientGroupChanges(al.clientService.GetAll(), existing, nil)...)
+		al.writeDryRunResponse(w, affected)
+		return
+	}
+
+	before, err := al.changeJournal.Snapshot(req.Context(), changes.ResourceClientGroup, id)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	err = al.clientGroupProvider.Delete(req.Context(), id)
 	if err != nil {
 		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete client group[id=%q].", id), err)
 		return
 	}
+	al.recordChange(req.Context(), changes.ResourceClientGroup, id, before)
 
 	al.auditLog.Entry(auditlog.ApplicationClientGroup, auditlog.ActionDelete).
 		WithHTTPRequest(req).
@@ -236,17 +313,21 @@ func (al *APIListener) handleDeleteClientGroup(w http.ResponseWriter, req *http.
 		Save()
 
 	w.WriteHeader(http.StatusNoContent)
+	go al.refreshReverseRemotes(context.Background())
 	al.Debugf("Client Group [id=%q] deleted.", id)
 }
 
 type ClientGroupPayload struct {
-	ID                  *string               `json:"id,omitempty"`
-	Description         *string               `json:"description,omitempty"`
-	Params              *cgroups.ClientParams `json:"params,omitempty" db:"params"`
-	AllowedUserGroups   *types.StringSlice    `json:"allowed_user_groups,omitempty"`
-	ClientIDs           *[]string             `json:"client_ids,omitempty" db:"-"`
-	NumClients          *int                  `json:"num_clients,omitempty" db:"-"`
-	NumClientsConnected *int                  `json:"num_clients_connected,omitempty" db:"-"`
+	ID                  *string                 `json:"id,omitempty"`
+	Description         *string                 `json:"description,omitempty"`
+	Params              *cgroups.ClientParams   `json:"params,omitempty" db:"params"`
+	AllowedUserGroups   *types.StringSlice      `json:"allowed_user_groups,omitempty"`
+	ReverseRemotes      *types.StringSlice      `json:"reverse_remotes,omitempty"`
+	AccessSchedule      *cgroups.AccessSchedule `json:"access_schedule,omitempty"`
+	MaintenanceWindows  *cgroups.AccessSchedule `json:"maintenance_windows,omitempty"`
+	ClientIDs           *[]string               `json:"client_ids,omitempty" db:"-"`
+	NumClients          *int                    `json:"num_clients,omitempty" db:"-"`
+	NumClientsConnected *int                    `json:"num_clients_connected,omitempty" db:"-"`
 }
 
 func (al *APIListener) convertToClientGroupsPayload(clientGroups []*cgroups.ClientGroup, requestedFields map[string]bool) ([]ClientGroupPayload, error) {
@@ -276,6 +357,12 @@ func (al *APIListener) convertToClientGroupPayload(clientGroup *cgroups.ClientGr
 			p.Params = clientGroup.Params
 		case "allowed_user_groups":
 			p.AllowedUserGroups = &clientGroup.AllowedUserGroups
+		case "reverse_remotes":
+			p.ReverseRemotes = &clientGroup.ReverseRemotes
+		case "access_schedule":
+			p.AccessSchedule = clientGroup.AccessSchedule
+		case "maintenance_windows":
+			p.MaintenanceWindows = clientGroup.MaintenanceWindows
 		case "client_ids":
 			p.ClientIDs = &clientGroup.ClientIDs
 		case "num_clients":
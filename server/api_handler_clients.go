@@ -1,11 +1,13 @@
 package chserver
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"golang.org/x/crypto/ssh"
@@ -13,10 +15,12 @@ import (
 	"github.com/IOTech17/neo-rport/server/api"
 	apierrors "github.com/IOTech17/neo-rport/server/api/errors"
 	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/changes"
 	"github.com/IOTech17/neo-rport/server/clients"
 	"github.com/IOTech17/neo-rport/server/clients/clientdata"
 	"github.com/IOTech17/neo-rport/server/clients/clienttunnel"
 	"github.com/IOTech17/neo-rport/server/ports"
+	"github.com/IOTech17/neo-rport/server/quotas"
 	"github.com/IOTech17/neo-rport/server/routes"
 	"github.com/IOTech17/neo-rport/server/validation"
 	"github.com/IOTech17/neo-rport/share/comm"
@@ -85,7 +89,21 @@ func (al *APIListener) handleGetClient(w http.ResponseWriter, req *http.Request)
 func (al *APIListener) handleDeleteClient(w http.ResponseWriter, req *http.Request) {
 	vars := mux.Vars(req)
 	clientID := vars[routes.ParamClientID]
-	err := al.clientService.DeleteOffline(clientID)
+	dryRun, err := parseDryRun(req)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if dryRun {
+		if err := al.clientService.CheckDeleteOffline(clientID); err != nil {
+			al.jsonError(w, err)
+			return
+		}
+		al.writeDryRunResponse(w, []affectedResource{{Type: "client", ID: clientID, Change: changeDelete}})
+		return
+	}
+
+	err = al.clientService.DeleteOffline(clientID)
 	if err != nil {
 		al.jsonError(w, err)
 		return
@@ -125,11 +143,18 @@ func (al *APIListener) handlePostClientACL(w http.ResponseWriter, req *http.Requ
 		return
 	}
 
+	before, err := al.changeJournal.Snapshot(req.Context(), changes.ResourceClientACL, cid)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
 	err = al.clientService.SetACL(cid, reqBody.AllowedUserGroups)
 	if err != nil {
 		al.jsonError(w, err)
 		return
 	}
+	al.recordChange(req.Context(), changes.ResourceClientACL, cid, before)
 
 	al.auditLog.Entry(auditlog.ApplicationClientACL, auditlog.ActionUpdate).
 		WithHTTPRequest(req).
@@ -228,6 +253,16 @@ func (al *APIListener) handlePutClientTunnel(w http.ResponseWriter, req *http.Re
 		return
 	}
 
+	if quarantine := client.GetQuarantine(); quarantine != nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusForbidden, fmt.Sprintf("failed to start tunnel for client with id %s due to client being quarantined (reason = %s)", clientID, quarantine.Reason))
+		return
+	}
+
+	if policy := al.config.Server.VersionPolicy; policy.DeniesTunnels(client.GetVersion()) {
+		al.jsonErrorResponseWithTitle(w, http.StatusForbidden, fmt.Sprintf("client version %s is older than the minimum version %s, new tunnels are denied", client.GetVersion(), policy.MinVersion))
+		return
+	}
+
 	localAddr := req.URL.Query().Get("local")
 	remoteAddr := req.URL.Query().Get("remote")
 
@@ -275,6 +310,12 @@ func (al *APIListener) handlePutClientTunnel(w http.ResponseWriter, req *http.Re
 		return
 	}
 
+	err = al.setDeviceAuthOptionsForRemote(req, remote)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
 	err = al.setAutoCloseIdleOptionsForRemote(req, remote)
 	if err != nil {
 		al.jsonError(w, err)
@@ -290,6 +331,11 @@ func (al *APIListener) handlePutClientTunnel(w http.ResponseWriter, req *http.Re
 		remote.ACL = &aclStr
 	}
 
+	if err := al.checkTunnelAccessSchedules(req, client, remote); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
 	allowed, err := clienttunnel.IsAllowed(remote.Remote(), client.GetConnection(), al.Log())
 	if err != nil {
 		al.jsonError(w, err)
@@ -336,6 +382,19 @@ func (al *APIListener) handlePutClientTunnel(w http.ResponseWriter, req *http.Re
 	}
 	remote.Owner = currUser.Username
 
+	if err := al.checkUserQuota(req.Context(), currUser, quotas.ResourceTunnels); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	if remote.Scheme != nil && (*remote.Scheme == "rdp" || *remote.Scheme == "vnc") {
+		description := fmt.Sprintf("It connects to %s (%s).", remote.Remote(), *remote.Scheme)
+		if _, err := al.askConsent(req, client, comm.ConsentSubjectSession, description); err != nil {
+			al.jsonError(w, err)
+			return
+		}
+	}
+
 	// start the new tunnel only
 	tunnels, err := al.clientService.StartClientTunnels(client, []*models.Remote{remote})
 	if err != nil {
@@ -400,6 +459,21 @@ func (al *APIListener) setTunnelProxyOptionsForRemote(req *http.Request, remote
 		}
 	}
 
+	if httpInspection := req.URL.Query().Get("http_inspection"); httpInspection != "" {
+		remote.HTTPInspection, err = strconv.ParseBool(httpInspection)
+		if err != nil {
+			return apierrors.NewAPIError(http.StatusBadRequest, "", "invalid http_inspection", err)
+		}
+	}
+	if remote.HTTPInspection {
+		if !isHTTPProxy || remote.Scheme == nil || (*remote.Scheme != "http" && *remote.Scheme != "https") {
+			return apierrors.NewAPIError(http.StatusBadRequest, "", "http_inspection requires http_proxy with scheme http or https", nil)
+		}
+		if al.tunnelConns == nil {
+			return apierrors.NewAPIError(http.StatusBadRequest, "", "http_inspection requires the tunnel connection log, set 'tunnel_connections_retention'", nil)
+		}
+	}
+
 	return err
 }
 
@@ -423,6 +497,86 @@ func (al *APIListener) setAuthOptionsForRemote(req *http.Request, remote *models
 	return err
 }
 
+func (al *APIListener) setDeviceAuthOptionsForRemote(req *http.Request, remote *models.Remote) error {
+	query := req.URL.Query()
+	authType := query.Get("device_auth")
+	if authType == "" {
+		return nil
+	}
+	if !remote.HTTPProxy {
+		return apierrors.NewAPIError(http.StatusBadRequest, "", "device_auth requires http_proxy to be activated on the requested tunnel", nil)
+	}
+	if remote.Scheme == nil || (*remote.Scheme != "http" && *remote.Scheme != "https") {
+		return apierrors.NewAPIError(http.StatusBadRequest, "", "device_auth requires scheme http or https", nil)
+	}
+	auth := &models.DeviceAuth{
+		Type:     authType,
+		Username: query.Get("device_auth_user"),
+	}
+	switch authType {
+	case models.DeviceAuthBasic:
+	case models.DeviceAuthForm:
+		auth.LoginPath = query.Get("device_login_path")
+		auth.UsernameField = query.Get("device_login_user_field")
+		auth.PasswordField = query.Get("device_login_password_field")
+		if auth.UsernameField == "" {
+			auth.UsernameField = "username"
+		}
+		if auth.PasswordField == "" {
+			auth.PasswordField = "password"
+		}
+		if !strings.HasPrefix(auth.LoginPath, "/") {
+			return apierrors.NewAPIError(http.StatusBadRequest, "", "device_auth form requires device_login_path starting with /", nil)
+		}
+	default:
+		return apierrors.NewAPIError(http.StatusBadRequest, "", fmt.Sprintf("invalid device_auth %q, expected %q or %q", authType, models.DeviceAuthBasic, models.DeviceAuthForm), nil)
+	}
+	if auth.Username == "" {
+		return apierrors.NewAPIError(http.StatusBadRequest, "", "device_auth requires device_auth_user", nil)
+	}
+	var err error
+	auth.VaultValueID, err = strconv.Atoi(query.Get("device_auth_vault_value_id"))
+	if err != nil || auth.VaultValueID <= 0 {
+		return apierrors.NewAPIError(http.StatusBadRequest, "", "device_auth requires a valid device_auth_vault_value_id", err)
+	}
+
+	// the password isn't returned, only the access of the user to the vault value is checked
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		return err
+	}
+	_, found, err := al.vaultManager.GetOne(req.Context(), auth.VaultValueID, curUser)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return apierrors.NewAPIError(http.StatusNotFound, "", fmt.Sprintf("vault value with id %d not found", auth.VaultValueID), nil)
+	}
+
+	remote.DeviceAuth = auth
+	return nil
+}
+
+// devicePassword reads the password of the device authentication of a tunnel from the vault, with the access of the
+// owner of the tunnel.
+func (al *APIListener) devicePassword(remote models.Remote) (string, error) {
+	owner, err := al.userService.GetByUsername(remote.Owner)
+	if err != nil {
+		return "", err
+	}
+	if owner == nil {
+		return "", fmt.Errorf("owner %q of the tunnel not found", remote.Owner)
+	}
+	value, found, err := al.vaultManager.GetOne(context.Background(), remote.DeviceAuth.VaultValueID, owner)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", fmt.Errorf("vault value with id %d not found", remote.DeviceAuth.VaultValueID)
+	}
+	return value.Value, nil
+}
+
 func (al *APIListener) setAutoCloseIdleOptionsForRemote(req *http.Request, remote *models.Remote) (err error) {
 	idleTimeoutMinutesStr := req.URL.Query().Get(idleTimeoutMinutesQueryParam)
 	skipIdleTimeout, err := strconv.ParseBool(req.URL.Query().Get(skipIdleTimeoutQueryParam))
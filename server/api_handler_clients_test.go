@@ -164,6 +164,14 @@ func TestHandleGetClient(t *testing.T) {
         "allowed_user_groups":null,
         "updates_status":null,
         "client_configuration":null,
+        "quarantine":null,
+        "identity_transfer":null,
+        "capabilities":null,
+        "protocol_revision":0,
+        "feature_levels":null,
+        "simulated":false,
+        "ephemeral":false,
+        "agent_footprint":null,
         "groups": []
     }
 }`
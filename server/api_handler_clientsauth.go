@@ -141,6 +141,20 @@ func (al *APIListener) handleDeleteClientAuth(w http.ResponseWriter, req *http.R
 		return
 	}
 
+	dryRun, err := parseDryRun(req)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if dryRun {
+		affected := []affectedResource{{Type: "client_auth", ID: clientAuthID, Change: changeDelete}}
+		for _, c := range allClients {
+			affected = append(affected, affectedResource{Type: "client", ID: c.GetID(), Change: changeDelete})
+		}
+		al.writeDryRunResponse(w, affected)
+		return
+	}
+
 	for _, s := range allClients {
 		if err := al.clientService.ForceDelete(s); err != nil {
 			al.jsonErrorResponse(w, http.StatusInternalServerError, err)
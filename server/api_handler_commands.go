@@ -10,8 +10,11 @@ import (
 	"github.com/gorilla/mux"
 
 	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/api/authorization"
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
 	"github.com/IOTech17/neo-rport/server/api/jobs"
 	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/killswitch"
 	"github.com/IOTech17/neo-rport/server/routes"
 	"github.com/IOTech17/neo-rport/server/validation"
 	"github.com/IOTech17/neo-rport/share/comm"
@@ -42,9 +45,11 @@ type jobPayload struct {
 }
 
 type jobResult struct {
-	StdOut  *string `json:"stdout,omitempty"`
-	StdErr  *string `json:"stderr,omitempty"`
-	Summary *string `json:"summary,omitempty"`
+	StdOut     *string                 `json:"stdout,omitempty"`
+	StdErr     *string                 `json:"stderr,omitempty"`
+	Summary    *string                 `json:"summary,omitempty"`
+	Parsed     *map[string]interface{} `json:"parsed,omitempty"`
+	ParseError *string                 `json:"parse_error,omitempty"`
 }
 
 func convertToJobsPayload(jobs []*models.Job, fields []query.FieldsOption) []jobPayload {
@@ -123,6 +128,12 @@ func convertToJobsPayload(jobs []*models.Job, fields []query.FieldsOption) []job
 				if requestedResultFields["summary"] {
 					(*result[i].Result).Summary = &job.Result.Summary
 				}
+				if requestedResultFields["parsed"] {
+					(*result[i].Result).Parsed = &job.Result.Parsed
+				}
+				if requestedResultFields["parse_error"] {
+					(*result[i].Result).ParseError = &job.Result.ParseError
+				}
 			}
 		}
 	}
@@ -134,6 +145,25 @@ type newJobResponse struct {
 	JID string `json:"jid"`
 }
 
+// maxJobWait limits how long a request to execute a job blocks until the job is finished.
+const maxJobWait = 5 * time.Minute
+
+// parseJobWait returns the duration of the wait query param, 0 if it's not set.
+func parseJobWait(req *http.Request) (time.Duration, error) {
+	v := req.URL.Query().Get("wait")
+	if v == "" {
+		return 0, nil
+	}
+	wait, err := time.ParseDuration(v)
+	if err != nil || wait <= 0 || wait > maxJobWait {
+		return 0, errors2.APIError{
+			HTTPStatus: http.StatusBadRequest,
+			Message:    fmt.Sprintf("Invalid wait %q, expected a duration like 30s up to %s.", v, maxJobWait),
+		}
+	}
+	return wait, nil
+}
+
 // handlePostCommand handles POST /clients/{client_id}/commands
 func (al *APIListener) handlePostCommand(w http.ResponseWriter, req *http.Request) {
 	vars := mux.Vars(req)
@@ -152,7 +182,13 @@ func (al *APIListener) handlePostCommand(w http.ResponseWriter, req *http.Reques
 	execCmdInput.ClientID = cid
 	execCmdInput.IsScript = false
 
-	resp := al.handleExecuteCommand(req.Context(), w, execCmdInput)
+	wait, err := parseJobWait(req)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	resp := al.handleExecuteCommand(req.Context(), w, execCmdInput, wait)
 
 	if resp != nil {
 		al.auditLog.Entry(auditlog.ApplicationClientCommand, auditlog.ActionExecuteStart).
@@ -176,7 +212,12 @@ func (al *APIListener) handleGetCommands(w http.ResponseWriter, req *http.Reques
 
 	options := query.NewOptions(req, nil, nil, jobs.JobListDefaultFields)
 
-	err := query.ValidateListOptions(options, jobs.JobSupportedSorts, jobs.JobSupportedFilters, jobs.JobSupportedFields, &query.PaginationConfig{
+	parsedFilters, err := jobs.ExtractParsedFilters(options)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	err = query.ValidateListOptions(options, jobs.JobSupportedSorts, jobs.JobSupportedFilters, jobs.JobSupportedFields, &query.PaginationConfig{
 		MaxLimit:     jobs.MaxLimit,
 		DefaultLimit: jobs.DefaultLimit,
 	})
@@ -186,13 +227,7 @@ func (al *APIListener) handleGetCommands(w http.ResponseWriter, req *http.Reques
 	}
 
 	options.Filters = append(options.Filters, query.FilterOption{Column: []string{"client_id"}, Values: []string{cid}})
-	result, err := al.jobProvider.List(req.Context(), options)
-	if err != nil {
-		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get client jobs: client_id=%q.", cid), err)
-		return
-	}
-
-	totalCount, err := al.jobProvider.Count(req.Context(), options)
+	result, totalCount, err := al.listJobs(req.Context(), options, parsedFilters)
 	if err != nil {
 		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get client jobs: client_id=%q.", cid), err)
 		return
@@ -205,18 +240,56 @@ func (al *APIListener) handleGetCommands(w http.ResponseWriter, req *http.Reques
 	al.writeJSONResponse(w, http.StatusOK, payload)
 }
 
+// listJobs returns a page of jobs and the total count. Filters on the parsed output are applied after loading the jobs
+// matching the other filters.
+func (al *APIListener) listJobs(ctx context.Context, options *query.ListOptions, parsedFilters []query.FilterOption) ([]*models.Job, int, error) {
+	if len(parsedFilters) == 0 {
+		result, err := al.jobProvider.List(ctx, options)
+		if err != nil {
+			return nil, 0, err
+		}
+		totalCount, err := al.jobProvider.Count(ctx, options)
+		if err != nil {
+			return nil, 0, err
+		}
+		return result, totalCount, nil
+	}
+
+	pagination := options.Pagination
+	options.Pagination = nil
+	all, err := al.jobProvider.List(ctx, options)
+	if err != nil {
+		return nil, 0, err
+	}
+	filtered := make([]*models.Job, 0, len(all))
+	for _, job := range all {
+		if jobs.MatchesParsedFilters(job, parsedFilters) {
+			filtered = append(filtered, job)
+		}
+	}
+	if pagination == nil {
+		return filtered, len(filtered), nil
+	}
+	start, end := pagination.GetStartEnd(len(filtered))
+	return filtered[start:end], len(filtered), nil
+}
+
 // handleGetMultiClientCommandJobs handles GET /commands/{job_id}/jobs
 func (al *APIListener) handleGetMultiClientCommandJobs(w http.ResponseWriter, req *http.Request) {
-	vars := mux.Vars(req)
-	multiJobID := vars[routes.ParamJobID]
-	if multiJobID == "" {
-		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("Missing %q route param.", routes.ParamJobID))
+	multiJob, allowed, ok := al.checkMultiJobAccess(w, req)
+	if !ok {
 		return
 	}
+	multiJobID := multiJob.JID
 
 	options := query.NewOptions(req, nil, nil, jobs.JobListDefaultFields)
 
-	err := query.ValidateListOptions(options, jobs.JobSupportedSorts, jobs.JobSupportedFilters, jobs.JobSupportedFields, &query.PaginationConfig{
+	parsedFilters, err := jobs.ExtractParsedFilters(options)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	err = query.ValidateListOptions(options, jobs.JobSupportedSorts, jobs.JobSupportedFilters, jobs.JobSupportedFields, &query.PaginationConfig{
 		MaxLimit:     jobs.MaxLimit,
 		DefaultLimit: jobs.DefaultLimit,
 	})
@@ -226,13 +299,23 @@ func (al *APIListener) handleGetMultiClientCommandJobs(w http.ResponseWriter, re
 	}
 
 	options.Filters = append(options.Filters, query.FilterOption{Column: []string{"multi_job_id"}, Values: []string{multiJobID}})
-	result, err := al.jobProvider.List(req.Context(), options)
-	if err != nil {
-		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get jobs: multi_job_id=%q.", multiJobID), err)
-		return
+	if authorization.TokenFromContext(req.Context()).IsClientRestricted() {
+		allJobs, err := al.listAllMultiJobJobs(req.Context(), multiJobID)
+		if err != nil {
+			al.jsonErrorResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get jobs: multi_job_id=%q.", multiJobID), err)
+			return
+		}
+		var clientIDs []string
+		for _, job := range filterJobsByClient(allJobs, allowed) {
+			clientIDs = append(clientIDs, job.ClientID)
+		}
+		if len(clientIDs) == 0 {
+			al.writeJSONResponse(w, http.StatusOK, &api.SuccessPayload{Data: []jobPayload{}, Meta: api.NewMeta(0)})
+			return
+		}
+		options.Filters = append(options.Filters, query.FilterOption{Column: []string{"client_id"}, Values: clientIDs})
 	}
-
-	totalCount, err := al.jobProvider.Count(req.Context(), options)
+	result, totalCount, err := al.listJobs(req.Context(), options, parsedFilters)
 	if err != nil {
 		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get jobs: multi_job_id=%q.", multiJobID), err)
 		return
@@ -282,6 +365,11 @@ func (al *APIListener) handlePostMultiClientCommand(w http.ResponseWriter, req *
 		al.jsonError(w, err)
 		return
 	}
+	wait, err := parseJobWait(req)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
 
 	if reqBody.Command == "" {
 		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "Command cannot be empty.")
@@ -291,6 +379,23 @@ func (al *APIListener) handlePostMultiClientCommand(w http.ResponseWriter, req *
 		al.jsonErrorResponseWithError(w, http.StatusBadRequest, "Invalid interpreter.", err)
 		return
 	}
+	if err := validation.ValidateOutputParser(reqBody.OutputParser); err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusBadRequest, "Invalid output parser.", err)
+		return
+	}
+	if err := validation.ValidateLock(reqBody.Lock); err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusBadRequest, "Invalid lock.", err)
+		return
+	}
+	if err := validation.ValidateJobArtifacts(reqBody.Artifacts); err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusBadRequest, "Invalid artifacts.", err)
+		return
+	}
+	warnings, err := al.secretScanner.Check(reqBody.Command)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
 
 	orderedClients, _, responseErr := al.getOrderedClientsWithValidation(ctx, &reqBody)
 	if responseErr != nil {
@@ -336,12 +441,26 @@ func (al *APIListener) handlePostMultiClientCommand(w http.ResponseWriter, req *
 		WithID(multiJob.JID).
 		SaveForMultipleClients(reqBody.OrderedClients)
 
-	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(resp))
-
 	al.Debugf("Multi-client Job[id=%q] created to execute remote command on clients %s, groups %s, tags %s: %q.", multiJob.JID, reqBody.ClientIDs, reqBody.GroupIDs, reqBody.GetClientTags(), reqBody.Command)
+
+	if wait > 0 {
+		al.writeFinishedMultiJob(ctx, w, multiJob.JID, len(reqBody.OrderedClients), wait, warnings)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, &api.SuccessPayload{
+		Data:     resp,
+		Warnings: warnings,
+	})
 }
 
-func (al *APIListener) handleExecuteCommand(ctx context.Context, w http.ResponseWriter, executeInput *api.ExecuteInput) *newJobResponse {
+// handleExecuteCommand executes a command or a script on a client. If wait is set, the response is delayed until the
+// job is finished or the wait is over, and contains the job instead of its id.
+func (al *APIListener) handleExecuteCommand(ctx context.Context, w http.ResponseWriter, executeInput *api.ExecuteInput, wait time.Duration) *newJobResponse {
+	if err := al.killSwitches.Check(killswitch.JobCapability(executeInput.IsScript)); err != nil {
+		al.jsonError(w, err)
+		return nil
+	}
 	if executeInput.Command == "" {
 		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "Command cannot be empty.")
 		return nil
@@ -350,11 +469,34 @@ func (al *APIListener) handleExecuteCommand(ctx context.Context, w http.Response
 		al.jsonErrorResponseWithError(w, http.StatusBadRequest, "Invalid interpreter.", err)
 		return nil
 	}
+	if err := validation.ValidateOutputParser(executeInput.OutputParser); err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusBadRequest, "Invalid output parser.", err)
+		return nil
+	}
+	if err := validation.ValidateLock(executeInput.Lock); err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusBadRequest, "Invalid lock.", err)
+		return nil
+	}
+	if err := validation.ValidateJobArtifacts(executeInput.Artifacts); err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusBadRequest, "Invalid artifacts.", err)
+		return nil
+	}
+	warnings, err := al.secretScanner.Check(executeInput.Command)
+	if err != nil {
+		al.jsonError(w, err)
+		return nil
+	}
 
 	if executeInput.TimeoutSec <= 0 {
 		executeInput.TimeoutSec = al.config.Server.RunRemoteCmdTimeoutSec
 	}
 
+	artifacts, err := al.resolveJobArtifacts(ctx, executeInput.Artifacts)
+	if err != nil {
+		al.jsonError(w, err)
+		return nil
+	}
+
 	client, err := al.clientService.GetActiveByID(executeInput.ClientID)
 	if err != nil {
 		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to find an active client with id=%q.", executeInput.ClientID), err)
@@ -370,6 +512,16 @@ func (al *APIListener) handleExecuteCommand(ctx context.Context, w http.Response
 		return nil
 	}
 
+	if quarantine := client.GetQuarantine(); quarantine != nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusForbidden, fmt.Sprintf("failed to execute command/script for client with id %s due to client being quarantined (reason = %s)", client.GetID(), quarantine.Reason))
+		return nil
+	}
+
+	if err := checkJobCapabilities(client, executeInput.IsScript); err != nil {
+		al.jsonError(w, err)
+		return nil
+	}
+
 	// send the command to the client
 	// Send a job with all possible info in order to get the full-populated job back (in client-listener) when it's done.
 	// Needed when server restarts to get all job data from client. Because on server restart job running info is lost.
@@ -379,21 +531,32 @@ func (al *APIListener) handleExecuteCommand(ctx context.Context, w http.Response
 		return nil
 	}
 	curJob := models.Job{
-		JID:         jid,
-		FinishedAt:  nil,
-		ClientID:    executeInput.ClientID,
-		ClientName:  client.GetName(),
-		Command:     executeInput.Command,
-		Interpreter: executeInput.Interpreter,
-		CreatedBy:   api.GetUser(ctx, al.Logger),
-		TimeoutSec:  executeInput.TimeoutSec,
-		Result:      nil,
-		Cwd:         executeInput.Cwd,
-		IsSudo:      executeInput.IsSudo,
-		IsScript:    executeInput.IsScript,
+		JID:          jid,
+		FinishedAt:   nil,
+		ClientID:     executeInput.ClientID,
+		ClientName:   client.GetName(),
+		Command:      executeInput.Command,
+		Interpreter:  executeInput.Interpreter,
+		CreatedBy:    api.GetUser(ctx, al.Logger),
+		TimeoutSec:   executeInput.TimeoutSec,
+		Result:       nil,
+		Cwd:          executeInput.Cwd,
+		IsSudo:       executeInput.IsSudo,
+		IsScript:     executeInput.IsScript,
+		OutputParser: executeInput.OutputParser,
+		Lock:         executeInput.Lock,
+		Artifacts:    artifacts,
+	}
+	if err := al.acquireJobLock(ctx, &curJob); err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusServiceUnavailable, "Failed to execute remote command.", err)
+		return nil
 	}
 	sshResp := &comm.RunCmdResponse{}
-	err = comm.SendRequestAndGetResponse(client.GetConnection(), comm.RequestTypeRunCmd, curJob, sshResp, al.Log())
+	err = al.pushJobArtifacts(ctx, client, &curJob)
+	if err == nil {
+		err = al.sendJob(ctx, client, &curJob, sshResp)
+	}
+	al.releaseJobLockOnError(&curJob, sshResp, err)
 	if err != nil {
 		if _, ok := err.(*comm.ClientError); ok {
 			al.jsonErrorResponseWithTitle(w, http.StatusConflict, err.Error())
@@ -417,13 +580,65 @@ func (al *APIListener) handleExecuteCommand(ctx context.Context, w http.Response
 		JID: curJob.JID,
 	}
 
-	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(resp))
-
 	al.Debugf("Job[id=%q] created to execute remote command on client with id=%q: %q.", curJob.JID, executeInput.ClientID, executeInput.Command)
 
+	if wait > 0 {
+		al.writeFinishedJob(ctx, w, curJob.ClientID, curJob.JID, wait, warnings)
+		return resp
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, &api.SuccessPayload{
+		Data:     resp,
+		Warnings: warnings,
+	})
+
 	return resp
 }
 
+// writeFinishedJob waits until the job is finished and writes it. If the job is still running when the wait is over,
+// it's written with 202 Accepted and can be polled.
+func (al *APIListener) writeFinishedJob(ctx context.Context, w http.ResponseWriter, clientID, jid string, wait time.Duration, warnings []string) {
+	job, err := al.waitForJob(ctx, clientID, jid, wait)
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to find a job[id=%q].", jid), err)
+		return
+	}
+
+	status := http.StatusOK
+	if job.Status == models.JobStatusRunning {
+		status = http.StatusAccepted
+	}
+	al.writeJSONResponse(w, status, &api.SuccessPayload{
+		Data:     job,
+		Warnings: warnings,
+	})
+}
+
+// writeFinishedMultiJob waits until the multi-client job is finished on all clients and writes it. If it's still
+// running when the wait is over or its canary run awaits confirmation, it's written with 202 Accepted.
+func (al *APIListener) writeFinishedMultiJob(ctx context.Context, w http.ResponseWriter, jid string, clientsCount int, wait time.Duration, warnings []string) {
+	job, err := al.waitForMultiJob(ctx, jid, clientsCount, wait)
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to find a multi-client job[id=%q].", jid), err)
+		return
+	}
+
+	status := http.StatusOK
+	if !multiJobFinished(job, clientsCount) {
+		status = http.StatusAccepted
+	}
+	al.writeJSONResponse(w, status, &api.SuccessPayload{
+		Data:     job,
+		Warnings: warnings,
+	})
+}
+
 // handleCommandsWS handles GET /ws/commands
 func (al *APIListener) handleCommandsWS(w http.ResponseWriter, req *http.Request) {
 	ctx := req.Context()
@@ -459,33 +674,21 @@ func (al *APIListener) handleCommandsWS(w http.ResponseWriter, req *http.Request
 
 // handleGetMultiClientCommand handles GET /commands/{job_id}
 func (al *APIListener) handleGetMultiClientCommand(w http.ResponseWriter, req *http.Request) {
-	vars := mux.Vars(req)
-	jid := vars[routes.ParamJobID]
-	if jid == "" {
-		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("Missing %q route param.", routes.ParamJobID))
+	job, allowed, ok := al.checkMultiJobAccess(w, req)
+	if !ok {
 		return
 	}
 
-	job, err := al.jobProvider.GetMultiJob(req.Context(), jid)
-	if err != nil {
-		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to find a multi-client job[id=%q].", jid), err)
-		return
-	}
-	if job == nil {
-		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("Multi-client Job[id=%q] not found.", jid))
-		return
+	job.Jobs = filterJobsByClient(job.Jobs, allowed)
+	clientIDs := make([]string, 0, len(job.ClientIDs))
+	for _, clientID := range job.ClientIDs {
+		if allowed(clientID) {
+			clientIDs = append(clientIDs, clientID)
+		}
 	}
+	job.ClientIDs = clientIDs
 
-	curUser, err := al.getUserModelForAuth(req.Context())
-	if err != nil {
-		al.jsonError(w, err)
-		return
-	}
-	if curUser.IsAdmin() || job.CreatedBy == curUser.Username {
-		al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(job))
-		return
-	}
-	al.jsonErrorResponseWithError(w, http.StatusForbidden, "forbidden", fmt.Errorf("you are not allowed to access items created by another user"))
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(job))
 }
 
 // handleGetMultiClientCommands handles GET /commands
@@ -519,3 +722,13 @@ func (al *APIListener) handleGetMultiClientCommands(w http.ResponseWriter, req *
 	}
 	al.writeJSONResponse(w, http.StatusOK, payload)
 }
+
+// handleGetClientLocks handles GET /clients/{client_id}/locks
+func (al *APIListener) handleGetClientLocks(w http.ResponseWriter, req *http.Request) {
+	clientID := mux.Vars(req)[routes.ParamClientID]
+	locks := al.jobLocks.List(clientID)
+	al.writeJSONResponse(w, http.StatusOK, &api.SuccessPayload{
+		Data: locks,
+		Meta: api.NewMeta(len(locks)),
+	})
+}
@@ -0,0 +1,101 @@
+package chserver
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/routes"
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+type compatibilityMatrixRow struct {
+	ClientVersion    string               `json:"client_version"`
+	ProtocolRevision int                  `json:"protocol_revision"`
+	Status           string               `json:"status"`
+	FeatureLevels    models.FeatureLevels `json:"feature_levels"`
+	Clients          int                  `json:"clients"`
+}
+
+type compatibilityMatrix struct {
+	ServerVersion          string                   `json:"server_version"`
+	ServerProtocolRevision int                      `json:"server_protocol_revision"`
+	ServerFeatureLevels    models.FeatureLevels     `json:"server_feature_levels"`
+	Rows                   []compatibilityMatrixRow `json:"rows"`
+}
+
+// handleGetClientCompatibility handles GET /clients/{client_id}/compatibility
+func (al *APIListener) handleGetClientCompatibility(w http.ResponseWriter, req *http.Request) {
+	clientID := mux.Vars(req)[routes.ParamClientID]
+	client, err := al.clientService.GetActiveByID(clientID)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if client == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("active client with id %q not found", clientID))
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(client.Compatibility(al.capabilities)))
+}
+
+// handleGetCompatibilityMatrix handles GET /server/compatibility. It groups the connected clients the user has access
+// to by version and negotiated feature levels, to follow rolling upgrades of mixed-version fleets.
+func (al *APIListener) handleGetCompatibilityMatrix(w http.ResponseWriter, req *http.Request) {
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	clientGroups, err := al.clientGroupProvider.GetAll(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	rows := make(map[string]*compatibilityMatrixRow)
+	for _, c := range al.clientService.GetUserClients(clientGroups, curUser) {
+		if !c.IsConnected() {
+			continue
+		}
+		compat := c.Compatibility(al.capabilities)
+		levels := make(models.FeatureLevels, len(compat.Features))
+		for _, f := range compat.Features {
+			levels[f.Feature] = f.NegotiatedLevel
+		}
+		key := fmt.Sprintf("%s/%d/%v", compat.ClientVersion, compat.ProtocolRevision, levels)
+		row, ok := rows[key]
+		if !ok {
+			row = &compatibilityMatrixRow{
+				ClientVersion:    compat.ClientVersion,
+				ProtocolRevision: compat.ProtocolRevision,
+				Status:           compat.Status,
+				FeatureLevels:    levels,
+			}
+			rows[key] = row
+		}
+		row.Clients++
+	}
+
+	matrix := compatibilityMatrix{
+		ServerVersion:          al.capabilities.ServerVersion,
+		ServerProtocolRevision: al.capabilities.ProtocolRevision,
+		ServerFeatureLevels:    al.capabilities.FeatureLevels,
+		Rows:                   make([]compatibilityMatrixRow, 0, len(rows)),
+	}
+	for _, row := range rows {
+		matrix.Rows = append(matrix.Rows, *row)
+	}
+	sort.Slice(matrix.Rows, func(i, j int) bool {
+		if matrix.Rows[i].ProtocolRevision != matrix.Rows[j].ProtocolRevision {
+			return matrix.Rows[i].ProtocolRevision < matrix.Rows[j].ProtocolRevision
+		}
+		return matrix.Rows[i].ClientVersion < matrix.Rows[j].ClientVersion
+	})
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(matrix))
+}
@@ -0,0 +1,46 @@
+package chserver
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/configcheck"
+)
+
+// maxConfigFileBytes limits the config files to validate, they usually exceed the limit of other API requests.
+const maxConfigFileBytes = 1024 * 1024
+
+// handlePostConfigValidate handles POST /server/config/validate, it validates the config file in the body like
+// `rportd check-config` without applying it. Only the static checks are run, the files and servers named in an
+// uploaded config aren't accessed.
+func (al *APIListener) handlePostConfigValidate(w http.ResponseWriter, req *http.Request) {
+	if al.configValidator == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, "Config validation is not available.")
+		return
+	}
+
+	content, err := io.ReadAll(req.Body)
+	if err != nil {
+		al.jsonError(w, errors2.APIError{
+			HTTPStatus: http.StatusBadRequest,
+			Err:        err,
+		})
+		return
+	}
+	if len(content) == 0 {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "missing config file in the request body")
+		return
+	}
+
+	var report *configcheck.Report
+	config, err := al.configValidator(content)
+	if err != nil {
+		report = configcheck.Invalid(err)
+	} else {
+		report = configcheck.Run(req.Context(), config, configcheck.Options{Static: true})
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(report))
+}
@@ -0,0 +1,95 @@
+package chserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/configcheck"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/security"
+)
+
+func TestHandlePostConfigValidate(t *testing.T) {
+	dataDir := t.TempDir()
+	al := &APIListener{
+		Logger:      testLog,
+		bannedUsers: security.NewBanList(0),
+		apiSessions: newEmptyAPISessionCache(t),
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 10,
+				},
+			},
+			clientGroupProvider: staticClientGroupProvider{},
+			configValidator: func(content []byte) (*chconfig.Config, error) {
+				if string(content) != "valid" {
+					return nil, errors.New("error parsing config file")
+				}
+				c := &chconfig.Config{
+					Server: chconfig.ServerConfig{
+						URL:          []string{"http://localhost/"},
+						DataDir:      dataDir,
+						Auth:         "abc:def",
+						UsedPortsRaw: []string{"10000-10100"},
+					},
+				}
+				mLog := logger.NewMemLogger()
+				return c, c.ParseAndValidate(&mLog)
+			},
+		},
+		userService: users.NewAPIService(users.NewStaticProvider([]*users.User{
+			{Username: "admin", Password: "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm", Groups: []string{users.Administrators}},
+		}), false, 0, -1),
+	}
+	al.initRouter()
+
+	testCases := []struct {
+		name          string
+		body          string
+		expectedValid bool
+		expectedCheck string
+	}{
+		{
+			name:          "valid",
+			body:          "valid",
+			expectedValid: true,
+			expectedCheck: "config",
+		},
+		{
+			// longer than max_request_bytes
+			name:          "invalid",
+			body:          "[server]\n  address = 1",
+			expectedValid: false,
+			expectedCheck: "config",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/server/config/validate", strings.NewReader(tc.body))
+			req.SetBasicAuth("admin", "pwd")
+			al.router.ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+			var result struct {
+				Data configcheck.Report `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+			assert.Equal(t, tc.expectedValid, result.Data.Valid)
+			assert.Equal(t, tc.expectedCheck, result.Data.Results[0].Check)
+			for _, r := range result.Data.Results {
+				assert.NotEqual(t, "data_dir", r.Check)
+			}
+		})
+	}
+}
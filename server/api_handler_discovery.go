@@ -0,0 +1,204 @@
+package chserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/discovery"
+	"github.com/IOTech17/neo-rport/server/routes"
+	"github.com/IOTech17/neo-rport/share/comm"
+)
+
+// handlePostClientDiscoveryScan handles POST /clients/{client_id}/discovery-scans, it starts a scan of the local
+// networks of the client. Empty subnets and ports scan all the client allows by its config.
+func (al *APIListener) handlePostClientDiscoveryScan(w http.ResponseWriter, req *http.Request) {
+	clientID := mux.Vars(req)[routes.ParamClientID]
+
+	var reqBody struct {
+		Subnets []string `json:"subnets"`
+		Ports   []int    `json:"ports"`
+	}
+	if err := parseRequestBody(req.Body, &reqBody); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	for _, subnet := range reqBody.Subnets {
+		if _, _, err := net.ParseCIDR(subnet); err != nil {
+			al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("invalid subnet %q", subnet))
+			return
+		}
+	}
+	for _, port := range reqBody.Ports {
+		if port < 1 || port > 65535 {
+			al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("invalid port %d", port))
+			return
+		}
+	}
+
+	client, err := al.clientService.GetActiveByID(clientID)
+	if err != nil {
+		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if client == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("client with id %s not found", clientID))
+		return
+	}
+
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	scan := &discovery.Scan{
+		ID:        uuid.New().String(),
+		ClientID:  clientID,
+		Subnets:   reqBody.Subnets,
+		Ports:     reqBody.Ports,
+		Status:    discovery.ScanStatusRunning,
+		CreatedBy: curUser.Username,
+		CreatedAt: time.Now().UTC(),
+	}
+	// saved before it's started, the result might arrive before the client answers the request
+	if err := al.discovery.SaveScan(req.Context(), scan); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	err = comm.SendRequestAndGetResponse(client.GetConnection(), comm.RequestTypeDiscoverNetwork, comm.DiscoveryRequest{
+		ID:      scan.ID,
+		Subnets: scan.Subnets,
+		Ports:   scan.Ports,
+	}, nil, al.Log())
+	if err != nil {
+		if strings.Contains(err.Error(), "unknown request") {
+			err = errors.New("client does not support network discovery")
+		}
+		now := time.Now().UTC()
+		scan.Status = discovery.ScanStatusFailed
+		scan.Error = err.Error()
+		scan.FinishedAt = &now
+		if fErr := al.discovery.FinishScan(req.Context(), scan, nil); fErr != nil {
+			al.Errorf("Failed to save network discovery %s: %v", scan.ID, fErr)
+		}
+		al.jsonError(w, errors2.APIError{
+			HTTPStatus: http.StatusConflict,
+			Err:        err,
+		})
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientDiscovery, auditlog.ActionCreate).
+		WithHTTPRequest(req).
+		WithClient(client).
+		WithID(scan.ID).
+		WithRequest(reqBody).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusAccepted, api.NewSuccessPayload(scan))
+}
+
+// handleGetClientDiscoveryScans handles GET /clients/{client_id}/discovery-scans
+func (al *APIListener) handleGetClientDiscoveryScans(w http.ResponseWriter, req *http.Request) {
+	clientID := mux.Vars(req)[routes.ParamClientID]
+
+	scans, err := al.discovery.ListScans(req.Context(), clientID)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(scans))
+}
+
+// handleGetClientDiscoveryScan handles GET /clients/{client_id}/discovery-scans/{discovery_scan_id}
+func (al *APIListener) handleGetClientDiscoveryScan(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	clientID := vars[routes.ParamClientID]
+	id := vars[routes.ParamDiscoveryScanID]
+
+	scan, err := al.discovery.GetScan(req.Context(), clientID, id)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if scan == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("network discovery %s not found", id))
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(scan))
+}
+
+// handleGetClientDiscoveredDevices handles GET /clients/{client_id}/discovered-devices, it returns the devices found
+// by all scans of the client.
+func (al *APIListener) handleGetClientDiscoveredDevices(w http.ResponseWriter, req *http.Request) {
+	clientID := mux.Vars(req)[routes.ParamClientID]
+
+	devices, err := al.discovery.ListDevices(req.Context(), clientID)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(devices))
+}
+
+// saveDiscoveryResult finishes the scan and adds the devices found to the inventory of the client.
+func (cl *ClientListener) saveDiscoveryResult(clientID string, payload []byte) error {
+	var result comm.DiscoveryResult
+	if err := json.Unmarshal(payload, &result); err != nil {
+		return fmt.Errorf("failed to decode %T: %v", result, err)
+	}
+
+	ctx := cl.getCtx()
+	scan, err := cl.server.discovery.GetScan(ctx, clientID, result.ID)
+	if err != nil {
+		return err
+	}
+	if scan == nil {
+		return fmt.Errorf("network discovery %s not found", result.ID)
+	}
+	if scan.Status != discovery.ScanStatusRunning {
+		return fmt.Errorf("network discovery %s is already %s", result.ID, scan.Status)
+	}
+
+	now := time.Now().UTC()
+	devices := make([]*discovery.Device, 0, len(result.Devices))
+	for _, d := range result.Devices {
+		if net.ParseIP(d.IP) == nil {
+			return fmt.Errorf("invalid ip %q of discovered device", d.IP)
+		}
+		devices = append(devices, &discovery.Device{
+			ClientID:    clientID,
+			IP:          d.IP,
+			MAC:         d.MAC,
+			Hostname:    d.Hostname,
+			OpenPorts:   d.OpenPorts,
+			FirstSeenAt: now,
+			LastSeenAt:  now,
+			LastScanID:  scan.ID,
+		})
+	}
+
+	scan.Status = discovery.ScanStatusFinished
+	if result.Error != "" {
+		scan.Status = discovery.ScanStatusFailed
+	}
+	scan.Error = result.Error
+	scan.DevicesCount = len(devices)
+	scan.FinishedAt = &now
+	return cl.server.discovery.FinishScan(ctx, scan, devices)
+}
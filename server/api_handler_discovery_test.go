@@ -0,0 +1,115 @@
+package chserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	discoverymigration "github.com/IOTech17/neo-rport/db/migration/discovery"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/clients"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/discovery"
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/security"
+	"github.com/IOTech17/neo-rport/share/test"
+)
+
+func TestClientDiscovery(t *testing.T) {
+	db, err := sqlite.New(":memory:", discoverymigration.AssetNames(), discoverymigration.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	defer db.Close()
+
+	c1 := clients.New(t).ID("client-1").Logger(testLog).Build()
+	connMock := test.NewConnMock()
+	connMock.ReturnOk = true
+	c1.SetConnection(connMock)
+	al := &APIListener{
+		Logger:      testLog,
+		bannedUsers: security.NewBanList(0),
+		apiSessions: newEmptyAPISessionCache(t),
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+				},
+			},
+			clientService:       clients.NewClientService(nil, nil, clients.NewClientRepository([]*clientdata.Client{c1}, &hour, testLog), testLog, nil),
+			discovery:           discovery.NewSqliteProvider(db),
+			clientGroupProvider: staticClientGroupProvider{},
+		},
+		userService: users.NewAPIService(users.NewStaticProvider([]*users.User{
+			{Username: "admin", Password: "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm", Groups: []string{users.Administrators}},
+		}), false, 0, -1),
+	}
+	al.initRouter()
+
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, "/api/v1/clients/client-1"+path, strings.NewReader(body))
+		req.SetBasicAuth("admin", "pwd")
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request(http.MethodPost, "/discovery-scans", `{"subnets":["192.168.1.0"]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = request(http.MethodPost, "/discovery-scans", `{"subnets":["192.168.1.0/24"],"ports":[22,9100]}`)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	var res struct {
+		Data *discovery.Scan `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, discovery.ScanStatusRunning, res.Data.Status)
+	assert.Equal(t, "admin", res.Data.CreatedBy)
+
+	name, _, payload := connMock.InputSendRequest()
+	assert.Equal(t, comm.RequestTypeDiscoverNetwork, name)
+	assert.JSONEq(t, `{"ID":"`+res.Data.ID+`","Subnets":["192.168.1.0/24"],"Ports":[22,9100]}`, string(payload))
+
+	cl := &ClientListener{server: al.Server, ctx: context.Background()}
+	assert.EqualError(t, cl.saveDiscoveryResult("client-2", []byte(`{"ID":"`+res.Data.ID+`"}`)), "network discovery "+res.Data.ID+" not found")
+	result := `{"ID":"` + res.Data.ID + `","Devices":[{"IP":"192.168.1.20","MAC":"aa:bb:cc:dd:ee:20","Hostname":"printer","OpenPorts":[9100]}]}`
+	require.NoError(t, cl.saveDiscoveryResult("client-1", []byte(result)))
+	assert.EqualError(t, cl.saveDiscoveryResult("client-1", []byte(result)), "network discovery "+res.Data.ID+" is already finished")
+
+	w = request(http.MethodGet, "/discovery-scans/"+res.Data.ID, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, discovery.ScanStatusFinished, res.Data.Status)
+	assert.Equal(t, 1, res.Data.DevicesCount)
+
+	w = request(http.MethodGet, "/discovered-devices", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var devices struct {
+		Data []*discovery.Device `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &devices))
+	require.Len(t, devices.Data, 1)
+	assert.Equal(t, "printer", devices.Data[0].Hostname)
+	assert.Equal(t, discovery.Ports{9100}, devices.Data[0].OpenPorts)
+
+	connMock.ReturnOk = false
+	connMock.ReturnResponsePayload = []byte(`network discovery is disabled by "network-discovery.enabled" config`)
+	w = request(http.MethodPost, "/discovery-scans", `{}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = request(http.MethodGet, "/discovery-scans", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var scans struct {
+		Data []*discovery.Scan `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &scans))
+	require.Len(t, scans.Data, 2)
+	statuses := []string{scans.Data[0].Status, scans.Data[1].Status}
+	assert.ElementsMatch(t, []string{discovery.ScanStatusFinished, discovery.ScanStatusFailed}, statuses)
+}
@@ -0,0 +1,92 @@
+package chserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/alerts"
+	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/routes"
+)
+
+func (al *APIListener) handleListEdgeRules(w http.ResponseWriter, req *http.Request) {
+	all, err := al.edgeRules.List(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(all))
+}
+
+func (al *APIListener) handlePutEdgeRule(w http.ResponseWriter, req *http.Request) {
+	ruleID := mux.Vars(req)[routes.ParamEdgeRuleID]
+	ctx := req.Context()
+
+	var rule alerts.EdgeRule
+	if err := parseRequestBody(req.Body, &rule); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	rule.ID = ruleID
+	if err := rule.Validate(); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	group, err := al.clientGroupProvider.Get(ctx, rule.GroupID)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if group == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("Client group %q not found.", rule.GroupID))
+		return
+	}
+
+	if err := al.edgeRules.Save(ctx, rule); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	go al.refreshReverseRemotes(context.Background())
+
+	al.auditLog.Entry(auditlog.ApplicationAlertingEdgeRule, auditlog.ActionUpdate).
+		WithHTTPRequest(req).
+		WithID(ruleID).
+		WithRequest(rule).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(rule))
+}
+
+func (al *APIListener) handleDeleteEdgeRule(w http.ResponseWriter, req *http.Request) {
+	ruleID := mux.Vars(req)[routes.ParamEdgeRuleID]
+	ctx := req.Context()
+
+	existing, err := al.edgeRules.Get(ctx, ruleID)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if existing == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("Edge rule %q not found.", ruleID))
+		return
+	}
+
+	if err := al.edgeRules.Delete(ctx, ruleID); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	go al.refreshReverseRemotes(context.Background())
+
+	al.auditLog.Entry(auditlog.ApplicationAlertingEdgeRule, auditlog.ActionDelete).
+		WithHTTPRequest(req).
+		WithID(ruleID).
+		Save()
+
+	w.WriteHeader(http.StatusNoContent)
+}
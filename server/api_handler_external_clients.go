@@ -0,0 +1,395 @@
+package chserver
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	rportplus "github.com/IOTech17/neo-rport/plus"
+	"github.com/IOTech17/neo-rport/plus/capabilities/alerting/transformers"
+	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/externalclients"
+	"github.com/IOTech17/neo-rport/server/monitoring"
+	"github.com/IOTech17/neo-rport/server/routes"
+	"github.com/IOTech17/neo-rport/share/models"
+	"github.com/IOTech17/neo-rport/share/query"
+	"github.com/IOTech17/neo-rport/share/security"
+)
+
+const (
+	externalClientTokenLength = 32
+	maxExternalClientIDLength = 100
+	maxIngestMeasures         = 1000
+	// measures dated a bit ahead are accepted, the clocks of small devices are rarely exact
+	maxIngestClockSkew = 5 * time.Minute
+)
+
+var externalClientIDRegexp = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+type externalClientPayload struct {
+	*externalclients.Client
+	// Token is only returned when the client is created or its token is renewed
+	Token string `json:"token,omitempty"`
+}
+
+// handleListExternalClients handles GET /external-clients
+func (al *APIListener) handleListExternalClients(w http.ResponseWriter, req *http.Request) {
+	list, err := al.externalClients.List(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(list))
+}
+
+// handleGetExternalClient handles GET /external-clients/{external_client_id}
+func (al *APIListener) handleGetExternalClient(w http.ResponseWriter, req *http.Request) {
+	id := mux.Vars(req)[routes.ParamExternalClientID]
+
+	c, err := al.externalClients.Get(req.Context(), id)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if c == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("external client with id %s not found", id))
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(c))
+}
+
+// handlePostExternalClient handles POST /external-clients, it registers a client pushing data to the ingestion
+// endpoint. The token is generated and returned only once.
+func (al *APIListener) handlePostExternalClient(w http.ResponseWriter, req *http.Request) {
+	var reqBody struct {
+		ID          string   `json:"id"`
+		Name        string   `json:"name"`
+		Description string   `json:"description"`
+		Tags        []string `json:"tags"`
+	}
+	if err := parseRequestBody(req.Body, &reqBody); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if len(reqBody.ID) > maxExternalClientIDLength || !externalClientIDRegexp.MatchString(reqBody.ID) {
+		al.jsonErrorResponseWithDetail(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid or missing ID.",
+			fmt.Sprintf("Only letters, digits, '_', '-' and '.' are allowed, max size is %d.", maxExternalClientIDLength))
+		return
+	}
+
+	// measures are stored by client id, the id must be unique among all kinds of clients
+	existing, err := al.clientService.GetByID(reqBody.ID)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if existing != nil {
+		al.jsonErrorResponseWithDetail(w, http.StatusConflict, ErrCodeAlreadyExist, fmt.Sprintf("Client with ID %q already exist.", reqBody.ID), "")
+		return
+	}
+	target, err := al.agentlessTargets.Get(req.Context(), reqBody.ID)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if target != nil {
+		al.jsonErrorResponseWithDetail(w, http.StatusConflict, ErrCodeAlreadyExist, fmt.Sprintf("Agentless target with ID %q already exist.", reqBody.ID), "")
+		return
+	}
+
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	token, err := security.NewRandomToken(externalClientTokenLength)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	c := &externalclients.Client{
+		ID:          reqBody.ID,
+		Name:        reqBody.Name,
+		Description: reqBody.Description,
+		Tags:        reqBody.Tags,
+		CreatedBy:   curUser.Username,
+		CreatedAt:   time.Now().UTC(),
+	}
+	c.SetToken(token)
+	created, err := al.externalClients.Create(req.Context(), c)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if !created {
+		al.jsonErrorResponseWithDetail(w, http.StatusConflict, ErrCodeAlreadyExist, fmt.Sprintf("External client with ID %q already exist.", reqBody.ID), "")
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientExternal, auditlog.ActionCreate).
+		WithHTTPRequest(req).
+		WithID(c.ID).
+		WithRequest(reqBody).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusCreated, api.NewSuccessPayload(externalClientPayload{Client: c, Token: token}))
+}
+
+// handlePostExternalClientToken handles POST /external-clients/{external_client_id}/token, it replaces the token of
+// the client, the previous one stops working.
+func (al *APIListener) handlePostExternalClientToken(w http.ResponseWriter, req *http.Request) {
+	id := mux.Vars(req)[routes.ParamExternalClientID]
+
+	c, err := al.externalClients.Get(req.Context(), id)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if c == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("external client with id %s not found", id))
+		return
+	}
+
+	token, err := security.NewRandomToken(externalClientTokenLength)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	c.SetToken(token)
+	if err := al.externalClients.SetTokenHash(req.Context(), id, c.TokenHash); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientExternal, auditlog.ActionUpdate).
+		WithHTTPRequest(req).
+		WithID(id).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(externalClientPayload{Client: c, Token: token}))
+}
+
+// handleDeleteExternalClient handles DELETE /external-clients/{external_client_id}, the measures already saved are
+// removed with the monitoring retention.
+func (al *APIListener) handleDeleteExternalClient(w http.ResponseWriter, req *http.Request) {
+	id := mux.Vars(req)[routes.ParamExternalClientID]
+
+	deleted, err := al.externalClients.Delete(req.Context(), id)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if !deleted {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("external client with id %s not found", id))
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientExternal, auditlog.ActionDelete).
+		WithHTTPRequest(req).
+		WithID(id).
+		Save()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetExternalClientMetrics handles GET /external-clients/{external_client_id}/metrics
+func (al *APIListener) handleGetExternalClientMetrics(w http.ResponseWriter, req *http.Request) {
+	id := mux.Vars(req)[routes.ParamExternalClientID]
+
+	c, err := al.externalClients.Get(req.Context(), id)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if c == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("external client with id %s not found", id))
+		return
+	}
+
+	queryOptions := query.NewOptions(req, monitoring.ClientMetricsSortDefault, monitoring.ClientMetricsFilterDefault, monitoring.ClientMetricsFieldsDefault)
+	payload, err := al.monitoringService.ListClientMetrics(req.Context(), id, queryOptions)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("metrics for external client with id %q not found", id))
+			return
+		}
+		al.jsonError(w, err)
+		return
+	}
+	al.writeJSONResponse(w, http.StatusOK, payload)
+}
+
+type ingestMeasure struct {
+	// Timestamp defaults to the time the measure is received
+	Timestamp          *time.Time       `json:"timestamp"`
+	CPUUsagePercent    float64          `json:"cpu_usage_percent"`
+	MemoryUsagePercent float64          `json:"memory_usage_percent"`
+	IoUsagePercent     float64          `json:"io_usage_percent"`
+	NetLan             *models.NetBytes `json:"net_lan"`
+	NetWan             *models.NetBytes `json:"net_wan"`
+	// Mountpoints are the free and total bytes by mountpoint, e.g. "free_b./": 1024, "total_b./": 4096
+	Mountpoints map[string]uint64 `json:"mountpoints"`
+}
+
+type ingestResult struct {
+	Saved   int `json:"saved"`
+	Skipped int `json:"skipped"`
+}
+
+// handlePostIngest handles POST /ingest, external clients push measures and their inventory with basic auth of
+// their id and token. Measures older than the monitoring retention are skipped. Only the latest measure is passed to
+// the alerting, the others are history.
+func (al *APIListener) handlePostIngest(w http.ResponseWriter, req *http.Request) {
+	id, token, ok := req.BasicAuth()
+	if !ok {
+		al.jsonErrorResponseWithTitle(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	c, err := al.externalClients.Get(req.Context(), id)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	authorized := c != nil && c.VerifyToken(token)
+	if !al.handleBannedIPs(req, authorized) {
+		return
+	}
+	if !authorized {
+		al.jsonErrorResponseWithTitle(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	var reqBody struct {
+		Measures  []ingestMeasure            `json:"measures"`
+		Inventory *externalclients.Inventory `json:"inventory"`
+	}
+	if err := parseRequestBody(req.Body, &reqBody); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if len(reqBody.Measures) > maxIngestMeasures {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("too many measures, max %d are allowed", maxIngestMeasures))
+		return
+	}
+	if len(reqBody.Measures) > 0 && !al.config.Monitoring.Enabled {
+		al.handleMonitoringDisabled(w, req)
+		return
+	}
+
+	now := time.Now().UTC()
+	measurements, skipped, err := al.ingestMeasurements(id, reqBody.Measures, now)
+	if err != nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if reqBody.Inventory != nil {
+		if err := al.externalClients.SaveInventory(req.Context(), id, *reqBody.Inventory, now); err != nil {
+			al.jsonError(w, err)
+			return
+		}
+	}
+	if err := al.externalClients.SetLastSeen(req.Context(), id, now); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	var latest *models.Measurement
+	for i := range measurements {
+		al.monitoringQueue.Notify(measurements[i])
+		if latest == nil || measurements[i].Timestamp.After(latest.Timestamp) {
+			latest = &measurements[i]
+		}
+	}
+	if latest != nil {
+		al.evaluateMeasurement(latest)
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(ingestResult{
+		Saved:   len(measurements),
+		Skipped: skipped,
+	}))
+}
+
+// ingestMeasurements converts the measures pushed by an external client, it fails on the first invalid one.
+func (al *APIListener) ingestMeasurements(clientID string, measures []ingestMeasure, now time.Time) (result []models.Measurement, skipped int, err error) {
+	retention := al.config.Monitoring.GetDataStorageDuration()
+	for i, m := range measures {
+		for _, p := range []struct {
+			name  string
+			value float64
+		}{
+			{"cpu_usage_percent", m.CPUUsagePercent},
+			{"memory_usage_percent", m.MemoryUsagePercent},
+			{"io_usage_percent", m.IoUsagePercent},
+		} {
+			if p.value < 0 || p.value > 100 {
+				return nil, 0, fmt.Errorf("invalid %s %v of measure %d, expected 0 to 100", p.name, p.value, i)
+			}
+		}
+
+		ts := now
+		if m.Timestamp != nil {
+			ts = m.Timestamp.UTC()
+			if ts.After(now.Add(maxIngestClockSkew)) {
+				return nil, 0, fmt.Errorf("timestamp %s of measure %d is in the future", ts.Format(time.RFC3339), i)
+			}
+			if ts.After(now) {
+				ts = now
+			}
+			if retention > 0 && now.Sub(ts) > retention {
+				skipped++
+				continue
+			}
+		}
+
+		measurement := models.Measurement{
+			ClientID:           clientID,
+			Timestamp:          ts,
+			CPUUsagePercent:    m.CPUUsagePercent,
+			MemoryUsagePercent: m.MemoryUsagePercent,
+			IoUsagePercent:     m.IoUsagePercent,
+			NetLan:             m.NetLan,
+			NetWan:             m.NetWan,
+		}
+		if len(m.Mountpoints) > 0 {
+			b, err := json.Marshal(m.Mountpoints)
+			if err != nil {
+				return nil, 0, err
+			}
+			measurement.Mountpoints = string(b)
+		}
+		result = append(result, measurement)
+	}
+	return result, skipped, nil
+}
+
+// evaluateMeasurement passes a measurement taken by the server on behalf of a client to the client groups and the
+// alerting, it's not saved.
+func (al *APIListener) evaluateMeasurement(measurement *models.Measurement) {
+	al.groupEvaluator.PutMeasurement(*measurement)
+	if !rportplus.IsPlusEnabled(al.config.PlusConfig) {
+		return
+	}
+	alertingCap := al.plusManager.GetAlertingCapabilityEx()
+	if alertingCap == nil {
+		return
+	}
+	m, err := transformers.TransformRportMeasurementToMeasure(measurement)
+	if err != nil {
+		al.Debugf("Failed to transform measurement of client %s: %v", measurement.ClientID, err)
+		return
+	}
+	if err := alertingCap.GetService().PutMeasurement(m); err != nil {
+		al.Debugf("Failed to send measurement of client %s to the alerting service: %v", measurement.ClientID, err)
+	}
+}
@@ -0,0 +1,165 @@
+package chserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	agentlessmigration "github.com/IOTech17/neo-rport/db/migration/agentless"
+	externalclientsmigration "github.com/IOTech17/neo-rport/db/migration/external_clients"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	"github.com/IOTech17/neo-rport/server/agentless"
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/clients"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/externalclients"
+	"github.com/IOTech17/neo-rport/share/models"
+	"github.com/IOTech17/neo-rport/share/security"
+)
+
+type measurementRecorder struct {
+	measurements []models.Measurement
+}
+
+func (r *measurementRecorder) Notify(m models.Measurement) bool {
+	r.measurements = append(r.measurements, m)
+	return true
+}
+
+func (r *measurementRecorder) Close() error {
+	return nil
+}
+
+func TestExternalClients(t *testing.T) {
+	db, err := sqlite.New(":memory:", externalclientsmigration.AssetNames(), externalclientsmigration.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	defer db.Close()
+	agentlessDB, err := sqlite.New(":memory:", agentlessmigration.AssetNames(), agentlessmigration.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	defer agentlessDB.Close()
+	targets := agentless.NewSqliteProvider(agentlessDB)
+	_, err = targets.Create(context.Background(), &agentless.Target{ID: "switch-1", Protocol: "ssh", CreatedAt: time.Now()})
+	require.NoError(t, err)
+
+	c1 := clients.New(t).ID("client-1").Logger(testLog).Build()
+	queue := &measurementRecorder{}
+	al := &APIListener{
+		Logger:      testLog,
+		bannedUsers: security.NewBanList(0),
+		apiSessions: newEmptyAPISessionCache(t),
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+				},
+				Monitoring: chconfig.MonitoringConfig{
+					Enabled: true,
+				},
+			},
+			clientService:    clients.NewClientService(nil, nil, clients.NewClientRepository([]*clientdata.Client{c1}, &hour, testLog), testLog, nil),
+			externalClients:  externalclients.NewSqliteProvider(db),
+			agentlessTargets: targets,
+			monitoringQueue:  queue,
+		},
+		userService: users.NewAPIService(users.NewStaticProvider([]*users.User{
+			{Username: "admin", Password: "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm", Groups: []string{users.Administrators}},
+		}), false, 0, -1),
+	}
+	al.initRouter()
+
+	request := func(method, path, body, user, password string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, "/api/v1"+path, strings.NewReader(body))
+		req.SetBasicAuth(user, password)
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request(http.MethodPost, "/external-clients", `{"id":"client-1"}`, "admin", "pwd")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = request(http.MethodPost, "/external-clients", `{"id":"switch-1"}`, "admin", "pwd")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	w = request(http.MethodPost, "/external-clients", `{"id":"sensor 1"}`, "admin", "pwd")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = request(http.MethodPost, "/external-clients", `{"id":"sensor-1","name":"Sensor 1","tags":["iot"]}`, "admin", "pwd")
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Data struct {
+			externalclients.Client
+			Token string `json:"token"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, "admin", created.Data.CreatedBy)
+	assert.Len(t, created.Data.Token, externalClientTokenLength)
+	assert.NotContains(t, w.Body.String(), "token_hash")
+	token := created.Data.Token
+
+	w = request(http.MethodPost, "/external-clients", `{"id":"sensor-1"}`, "admin", "pwd")
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = request(http.MethodPost, "/ingest", `{}`, "sensor-1", "wrong")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = request(http.MethodPost, "/ingest", `{}`, "unknown", token)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	w = request(http.MethodPost, "/ingest", `{"measures":[{"cpu_usage_percent":120}]}`, "sensor-1", token)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid cpu_usage_percent 120 of measure 0")
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	w = request(http.MethodPost, "/ingest", `{"measures":[{"timestamp":"`+future+`"}]}`, "sensor-1", token)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Empty(t, queue.measurements)
+
+	past := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+	w = request(http.MethodPost, "/ingest", `{
+		"measures": [
+			{"timestamp":"`+past.Format(time.RFC3339)+`","cpu_usage_percent":10,"mountpoints":{"free_b./":1024,"total_b./":4096}},
+			{"cpu_usage_percent":20,"memory_usage_percent":30,"net_lan":{"in":1,"out":2}}
+		],
+		"inventory": {"hostname":"sensor-1.local","firmware":"1.2.3","attributes":{"room":"basement"}}
+	}`, "sensor-1", token)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"data":{"saved":2,"skipped":0}}`, w.Body.String())
+	require.Len(t, queue.measurements, 2)
+	assert.Equal(t, "sensor-1", queue.measurements[0].ClientID)
+	assert.Equal(t, past, queue.measurements[0].Timestamp)
+	assert.Equal(t, 10.0, queue.measurements[0].CPUUsagePercent)
+	assert.JSONEq(t, `{"free_b./":1024,"total_b./":4096}`, queue.measurements[0].Mountpoints)
+	assert.Equal(t, 30.0, queue.measurements[1].MemoryUsagePercent)
+	assert.Equal(t, &models.NetBytes{In: 1, Out: 2}, queue.measurements[1].NetLan)
+
+	w = request(http.MethodGet, "/external-clients/sensor-1", "", "admin", "pwd")
+	require.Equal(t, http.StatusOK, w.Code)
+	var got struct {
+		Data *externalclients.Client `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, externalclients.Inventory{Hostname: "sensor-1.local", Firmware: "1.2.3", Attributes: map[string]string{"room": "basement"}}, got.Data.Inventory)
+	assert.NotNil(t, got.Data.InventoryUpdatedAt)
+	assert.NotNil(t, got.Data.LastSeenAt)
+
+	w = request(http.MethodPost, "/external-clients/sensor-1/token", "", "admin", "pwd")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.NotEqual(t, token, created.Data.Token)
+	assert.Equal(t, http.StatusUnauthorized, request(http.MethodPost, "/ingest", `{}`, "sensor-1", token).Code)
+	assert.Equal(t, http.StatusOK, request(http.MethodPost, "/ingest", `{}`, "sensor-1", created.Data.Token).Code)
+
+	w = request(http.MethodGet, "/external-clients", "", "admin", "pwd")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"id":"sensor-1"`)
+
+	assert.Equal(t, http.StatusNoContent, request(http.MethodDelete, "/external-clients/sensor-1", "", "admin", "pwd").Code)
+	assert.Equal(t, http.StatusNotFound, request(http.MethodDelete, "/external-clients/sensor-1", "", "admin", "pwd").Code)
+	assert.Equal(t, http.StatusUnauthorized, request(http.MethodPost, "/ingest", `{}`, "sensor-1", created.Data.Token).Code)
+}
@@ -0,0 +1,137 @@
+package chserver
+
+import (
+	"net/http"
+
+	rportplus "github.com/IOTech17/neo-rport/plus"
+	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/killswitch"
+)
+
+// Feature tells whether a subsystem is available, so UIs can hide what isn't instead of trying it.
+type Feature struct {
+	Enabled bool                   `json:"enabled"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+type featureFlag struct {
+	name   string
+	status func(al *APIListener) Feature
+}
+
+// featureFlags is the registry of the features reported by GET /server/features and on login.
+var featureFlags = []featureFlag{
+	{name: "two_fa", status: (*APIListener).twoFAFeature},
+	{name: "plus", status: (*APIListener).plusFeature},
+	{name: "oauth", status: func(al *APIListener) Feature {
+		return Feature{Enabled: rportplus.IsPlusOAuthEnabled(al.config.PlusConfig)}
+	}},
+	{name: "monitoring", status: func(al *APIListener) Feature {
+		f := Feature{Enabled: al.config.Monitoring.Enabled}
+		if f.Enabled {
+			f.Details = map[string]interface{}{
+				"data_storage_duration": al.config.Monitoring.DataStorageDuration,
+			}
+		}
+		return f
+	}},
+	{name: "file_transfer", status: func(al *APIListener) Feature {
+		// whether a client accepts files is configured on the client
+		return Feature{
+			Enabled: !al.killSwitches.Get(killswitch.CapabilityUploads).Disabled,
+			Details: map[string]interface{}{
+				"max_filepush_size": al.config.API.MaxFilePushSize,
+			},
+		}
+	}},
+	{name: "tunnel_proxy", status: func(al *APIListener) Feature {
+		f := Feature{Enabled: al.config.Server.InternalTunnelProxyConfig.Enabled}
+		if f.Enabled {
+			f.Details = map[string]interface{}{
+				"tunnel_host": al.config.Server.InternalTunnelProxyConfig.Host,
+			}
+		}
+		return f
+	}},
+	{name: "caddy_integration", status: func(al *APIListener) Feature {
+		return Feature{Enabled: al.config.Caddy.Enabled}
+	}},
+	{name: "auditlog", status: func(al *APIListener) Feature {
+		return Feature{Enabled: al.auditLog.Status().Enabled}
+	}},
+	{name: "request_log", status: func(al *APIListener) Feature {
+		return Feature{Enabled: al.requestLog != nil}
+	}},
+	{name: "tripwire", status: func(al *APIListener) Feature {
+		return Feature{Enabled: al.config.Tripwire.Enabled()}
+	}},
+	{name: "kill_switches", status: (*APIListener).killSwitchesFeature},
+}
+
+// killSwitchesFeature lists the capabilities disabled by a kill switch.
+func (al *APIListener) killSwitchesFeature() Feature {
+	disabled := make(map[string]interface{})
+	for _, sw := range al.killSwitches.List() {
+		if sw.Disabled {
+			disabled[sw.Capability] = sw.Reason
+		}
+	}
+	return Feature{
+		Enabled: true,
+		Details: map[string]interface{}{
+			"disabled": disabled,
+		},
+	}
+}
+
+func (al *APIListener) twoFAFeature() Feature {
+	if !al.config.API.IsTwoFAOn() && !al.config.API.TotPEnabled {
+		return Feature{}
+	}
+	twoFAType := "token"
+	if al.config.API.TotPEnabled {
+		twoFAType = "totp"
+	}
+	return Feature{
+		Enabled: true,
+		Details: map[string]interface{}{
+			"type":            twoFAType,
+			"delivery_method": al.twoFADeliveryMethod(),
+		},
+	}
+}
+
+func (al *APIListener) plusFeature() Feature {
+	if al.plusManager == nil || !rportplus.IsPlusEnabled(al.config.PlusConfig) {
+		return Feature{}
+	}
+	capabilities := make(map[string]interface{})
+	for _, capName := range []string{
+		rportplus.PlusOAuthCapability,
+		rportplus.PlusStatusCapability,
+		rportplus.PlusLicenseCapability,
+		rportplus.PlusExtendedPermissionCapability,
+		rportplus.PlusAlertingCapability,
+	} {
+		capabilities[capName] = al.plusManager.IsEnabledCapability(capName)
+	}
+	return Feature{
+		Enabled: true,
+		Details: map[string]interface{}{
+			"capabilities": capabilities,
+		},
+	}
+}
+
+func (al *APIListener) features() map[string]Feature {
+	features := make(map[string]Feature, len(featureFlags))
+	for _, f := range featureFlags {
+		features[f.name] = f.status(al)
+	}
+	return features
+}
+
+// handleGetFeatures handles GET /server/features
+func (al *APIListener) handleGetFeatures(w http.ResponseWriter, req *http.Request) {
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(al.features()))
+}
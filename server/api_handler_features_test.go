@@ -0,0 +1,75 @@
+package chserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/share/security"
+)
+
+func TestHandleGetFeatures(t *testing.T) {
+	al := &APIListener{
+		Logger:      testLog,
+		bannedUsers: security.NewBanList(0),
+		apiSessions: newEmptyAPISessionCache(t),
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+					MaxFilePushSize: 1024,
+				},
+				Monitoring: chconfig.MonitoringConfig{
+					Enabled:             true,
+					DataStorageDuration: "7d",
+				},
+			},
+			clientGroupProvider: staticClientGroupProvider{},
+		},
+		userService: users.NewAPIService(users.NewStaticProvider([]*users.User{
+			{Username: "user1", Password: "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm"},
+		}), false, 0, -1),
+	}
+	al.initRouter()
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/server/features", nil)
+	req.SetBasicAuth("user1", "pwd")
+	al.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var result struct {
+		Data map[string]Feature `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+
+	assert.Len(t, result.Data, len(featureFlags))
+	assert.Equal(t, Feature{}, result.Data["two_fa"])
+	assert.Equal(t, Feature{
+		Enabled: true,
+		Details: map[string]interface{}{"data_storage_duration": "7d"},
+	}, result.Data["monitoring"])
+	assert.Equal(t, Feature{
+		Enabled: true,
+		Details: map[string]interface{}{"max_filepush_size": float64(1024)},
+	}, result.Data["file_transfer"])
+	assert.Equal(t, Feature{}, result.Data["plus"])
+	assert.Equal(t, Feature{}, result.Data["auditlog"])
+	assert.Equal(t, Feature{}, result.Data["request_log"])
+
+	// basic auth is rejected with 2fa on
+	al.config.API.TotPEnabled = true
+	assert.Equal(t, Feature{
+		Enabled: true,
+		Details: map[string]interface{}{
+			"type":            "totp",
+			"delivery_method": "totp_authenticator_app",
+		},
+	}, al.features()["two_fa"])
+}
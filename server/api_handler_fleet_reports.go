@@ -0,0 +1,73 @@
+package chserver
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/clients"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/fleet"
+	"github.com/IOTech17/neo-rport/share/query"
+)
+
+// handleGetFleetComparison handles GET /reports/fleet-comparison
+func (al *APIListener) handleGetFleetComparison(w http.ResponseWriter, req *http.Request) {
+	params := req.URL.Query()
+	opts := fleet.Options{
+		Facet:          params.Get("facet"),
+		OutlierPercent: fleet.DefaultOutlierPercent,
+	}
+	if opts.Facet == "" {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, `Missing "facet" query param.`)
+		return
+	}
+	if v := params.Get("outlier_percent"); v != "" {
+		p, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("Invalid %q query param: %v.", "outlier_percent", err))
+			return
+		}
+		opts.OutlierPercent = p
+	}
+	if params.Has("value") {
+		value := params.Get("value")
+		opts.Value = &value
+	}
+
+	// the clients are selected by the filters of GET /clients
+	filters := query.ParseFilterOptions(params)
+	if errs := query.ValidateFilterOptions(filters, clients.OptionsSupportedFilters); errs != nil {
+		al.jsonError(w, errs)
+		return
+	}
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	groups, err := al.clientGroupProvider.GetAll(req.Context())
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, "Failed to get client groups.", err)
+		return
+	}
+	userClients, err := al.clientService.GetFilteredUserClients(curUser, filters, groups)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	cc := make([]*clientdata.Client, 0, len(userClients))
+	for _, c := range userClients {
+		cc = append(cc, c.Client)
+	}
+
+	report, err := fleet.Compare(cc, opts, time.Now())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(report))
+}
@@ -0,0 +1,100 @@
+package chserver
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/alerts"
+	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/routes"
+)
+
+func (al *APIListener) handleListGroupRules(w http.ResponseWriter, req *http.Request) {
+	all, err := al.groupRules.List(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(al.groupEvaluator.WithStates(all)))
+}
+
+func (al *APIListener) handlePutGroupRule(w http.ResponseWriter, req *http.Request) {
+	ruleID := mux.Vars(req)[routes.ParamGroupRuleID]
+	ctx := req.Context()
+
+	var rule alerts.GroupRule
+	if err := parseRequestBody(req.Body, &rule); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	rule.ID = ruleID
+	if err := rule.Validate(); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	group, err := al.clientGroupProvider.Get(ctx, rule.GroupID)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if group == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("Client group %q not found.", rule.GroupID))
+		return
+	}
+
+	if err := al.groupRules.Save(ctx, rule); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationAlertingGroupRule, auditlog.ActionUpdate).
+		WithHTTPRequest(req).
+		WithID(ruleID).
+		WithRequest(rule).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(rule))
+}
+
+func (al *APIListener) handleDeleteGroupRule(w http.ResponseWriter, req *http.Request) {
+	ruleID := mux.Vars(req)[routes.ParamGroupRuleID]
+	ctx := req.Context()
+
+	existing, err := al.groupRules.Get(ctx, ruleID)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if existing == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("Group rule %q not found.", ruleID))
+		return
+	}
+
+	if err := al.groupRules.Delete(ctx, ruleID); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationAlertingGroupRule, auditlog.ActionDelete).
+		WithHTTPRequest(req).
+		WithID(ruleID).
+		Save()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetClientBaselines handles GET /alerting/clients/{client_id}/baselines
+func (al *APIListener) handleGetClientBaselines(w http.ResponseWriter, req *http.Request) {
+	if al.baselines == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, "Anomaly detection is disabled.")
+		return
+	}
+	clientID := mux.Vars(req)[routes.ParamClientID]
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(al.baselines.Client(clientID)))
+}
@@ -0,0 +1,103 @@
+package chserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	rportplus "github.com/IOTech17/neo-rport/plus"
+	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+const (
+	HealthStatusOK     = "ok"
+	HealthStatusFailed = "failed"
+
+	readinessCheckTimeout = 2 * time.Second
+)
+
+type HealthCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+type HealthStatus struct {
+	Status string         `json:"status"`
+	Checks []*HealthCheck `json:"checks,omitempty"`
+}
+
+// handleHealthz is a liveness probe, the process is alive as long as it is able to answer.
+func (al *APIListener) handleHealthz(w http.ResponseWriter, req *http.Request) {
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(HealthStatus{Status: HealthStatusOK}))
+}
+
+// handleReadyz is a readiness probe, it fails if any of the dependencies needed to serve clients and users is not usable.
+// Details of failed checks are only logged, the endpoint is usually reachable without authentication.
+func (al *APIListener) handleReadyz(w http.ResponseWriter, req *http.Request) {
+	ctx, cancel := context.WithTimeout(req.Context(), readinessCheckTimeout)
+	defer cancel()
+
+	res := HealthStatus{Status: HealthStatusOK}
+	for _, c := range al.readinessChecks() {
+		check := &HealthCheck{Name: c.name, Status: HealthStatusOK}
+		if err := c.check(ctx); err != nil {
+			al.Errorf("readiness check %s failed: %v", c.name, err)
+			check.Status = HealthStatusFailed
+			res.Status = HealthStatusFailed
+		}
+		res.Checks = append(res.Checks, check)
+	}
+
+	status := http.StatusOK
+	if res.Status != HealthStatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	al.writeJSONResponse(w, status, api.NewSuccessPayload(res))
+}
+
+type readinessCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+func (al *APIListener) readinessChecks() []readinessCheck {
+	var checks []readinessCheck
+
+	if al.clientDB != nil {
+		checks = append(checks, readinessCheck{name: "clients_db", check: al.clientDB.PingContext})
+	}
+	if al.authDB != nil {
+		checks = append(checks, readinessCheck{name: "auth_db", check: al.authDB.PingContext})
+	}
+	if allowedPorts := al.config.AllowedPorts(); al.portDistributor != nil && allowedPorts != nil && allowedPorts.Cardinality() > 0 {
+		checks = append(checks, readinessCheck{name: "port_pool", check: al.checkPortPool})
+	}
+	if rportplus.IsPlusEnabled(al.config.PlusConfig) {
+		checks = append(checks, readinessCheck{name: "plus_plugin", check: al.checkPlusPlugin})
+	}
+
+	return checks
+}
+
+func (al *APIListener) checkPortPool(ctx context.Context) error {
+	count, err := al.portDistributor.CountAvailable(models.ProtocolTCP)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New("no ports available for tunnels")
+	}
+	return nil
+}
+
+func (al *APIListener) checkPlusPlugin(ctx context.Context) error {
+	if al.plusManager == nil {
+		return errors.New("plus manager not initialized")
+	}
+	if al.plusManager.GetStatusCapabilityEx() == nil {
+		return rportplus.ErrCapabilityNotAvailable(rportplus.PlusStatusCapability)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package chserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/share/security"
+)
+
+func TestHandleHealthChecks(t *testing.T) {
+	okDB, err := sqlx.Connect("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer okDB.Close()
+
+	closedDB, err := sqlx.Connect("sqlite3", ":memory:")
+	require.NoError(t, err)
+	require.NoError(t, closedDB.Close())
+
+	testCases := []struct {
+		Name           string
+		URL            string
+		ClientDB       *sqlx.DB
+		ExpectedStatus int
+		ExpectedHealth HealthStatus
+	}{
+		{
+			Name:           "liveness",
+			URL:            "/healthz",
+			ClientDB:       closedDB,
+			ExpectedStatus: http.StatusOK,
+			ExpectedHealth: HealthStatus{Status: HealthStatusOK},
+		},
+		{
+			Name:           "ready",
+			URL:            "/readyz",
+			ClientDB:       okDB,
+			ExpectedStatus: http.StatusOK,
+			ExpectedHealth: HealthStatus{
+				Status: HealthStatusOK,
+				Checks: []*HealthCheck{{Name: "clients_db", Status: HealthStatusOK}},
+			},
+		},
+		{
+			Name:           "not ready",
+			URL:            "/readyz",
+			ClientDB:       closedDB,
+			ExpectedStatus: http.StatusServiceUnavailable,
+			ExpectedHealth: HealthStatus{
+				Status: HealthStatusFailed,
+				Checks: []*HealthCheck{{Name: "clients_db", Status: HealthStatusFailed}},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			al := APIListener{
+				Logger: testLog,
+				Server: &Server{
+					clientDB: tc.ClientDB,
+					config: &chconfig.Config{
+						API: chconfig.APIConfig{
+							MaxRequestBytes: 1024 * 1024,
+						},
+					},
+				},
+			}
+			al.initRouter()
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tc.URL, nil)
+			al.router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.ExpectedStatus, w.Code)
+			expectedJSON, err := json.Marshal(api.NewSuccessPayload(tc.ExpectedHealth))
+			require.NoError(t, err)
+			assert.JSONEq(t, string(expectedJSON), w.Body.String())
+		})
+	}
+}
+
+func TestHandleHealthChecksRequireAuth(t *testing.T) {
+	al := APIListener{
+		Logger: testLog,
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes:         1024 * 1024,
+					HealthChecksRequireAuth: true,
+				},
+			},
+		},
+		bannedUsers: security.NewBanList(0),
+	}
+	al.initRouter()
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	al.router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,126 @@
+package chserver
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/bearer"
+	"github.com/IOTech17/neo-rport/server/routes"
+	chshare "github.com/IOTech17/neo-rport/share"
+)
+
+const maxImpersonationLifetime = time.Hour
+
+var errImpersonationNotAllowed = errors.New("not allowed while impersonating a user")
+
+type impersonationRequest struct {
+	Reason string `json:"reason"`
+}
+
+type impersonationResponse struct {
+	Token          string    `json:"token"`
+	Username       string    `json:"username"`
+	ImpersonatedBy string    `json:"impersonated_by"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+// handlePostUserImpersonation issues a token to act as the given user, with the permissions of the user.
+func (al *APIListener) handlePostUserImpersonation(w http.ResponseWriter, req *http.Request) {
+	userID := mux.Vars(req)[routes.ParamUserID]
+	admin := api.GetUser(req.Context(), al.Logger)
+	if userID == admin {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "you can't impersonate yourself")
+		return
+	}
+
+	var r impersonationRequest
+	if req.ContentLength != 0 {
+		if err := parseRequestBody(req.Body, &r); err != nil {
+			al.jsonError(w, err)
+			return
+		}
+	}
+
+	lifetime, err := parseTokenLifetime(req)
+	if err != nil {
+		al.jsonErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	if lifetime > maxImpersonationLifetime {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("token lifetime of an impersonation exceeds max allowed %d", maxImpersonationLifetime/time.Second))
+		return
+	}
+
+	user, err := al.userService.GetByUsername(userID)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if user == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("user %q not found", userID))
+		return
+	}
+
+	tokenStr, err := bearer.CreateImpersonationToken(
+		req.Context(),
+		al.apiSessions,
+		al.config.API.JWTSecret,
+		lifetime,
+		user.Username,
+		admin,
+		req.UserAgent(),
+		chshare.RemoteIP(req),
+	)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.Infof("User %q started to impersonate user %q", admin, user.Username)
+	al.auditLog.Entry(auditlog.ApplicationAuthUserImpersonation, auditlog.ActionCreate).
+		WithHTTPRequest(req).
+		WithID(user.Username).
+		WithRequest(r).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(impersonationResponse{
+		Token:          tokenStr,
+		Username:       user.Username,
+		ImpersonatedBy: admin,
+		ExpiresAt:      time.Now().Add(lifetime).UTC().Truncate(time.Second),
+	}))
+}
+
+// checkImpersonator returns an error if the admin who started an impersonation isn't an administrator anymore.
+func (al *APIListener) checkImpersonator(impersonatedBy string) error {
+	admin, err := al.userService.GetByUsername(impersonatedBy)
+	if err != nil {
+		return err
+	}
+	if admin == nil || !admin.IsAdmin() {
+		return errors2.APIError{
+			Message:    fmt.Sprintf("impersonation by %q is not valid anymore", impersonatedBy),
+			HTTPStatus: http.StatusUnauthorized,
+		}
+	}
+	return nil
+}
+
+// wrapNoImpersonationMiddleware protects the credentials and the 2fa settings of a user from admins acting as the user.
+func (al *APIListener) wrapNoImpersonationMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if api.GetImpersonator(r.Context()) != "" {
+			al.jsonErrorResponse(w, http.StatusForbidden, errImpersonationNotAllowed)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}
+}
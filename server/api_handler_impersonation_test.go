@@ -0,0 +1,111 @@
+package chserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	auditlogconfig "github.com/IOTech17/neo-rport/server/auditlog/config"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/share/security"
+)
+
+func TestImpersonation(t *testing.T) {
+	admin := &users.User{
+		Username: "admin",
+		Password: "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm",
+		Groups:   []string{users.Administrators},
+	}
+	operator := &users.User{
+		Username: "operator",
+		Password: "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm",
+		Groups:   []string{"operators"},
+	}
+	cfg := auditlogconfig.Config{Enable: true, Rotation: auditlogconfig.RotationMonthly}
+	auditLog, err := auditlog.New(testLog, nil, t.TempDir(), cfg, DataSourceOptions)
+	require.NoError(t, err)
+	defer auditLog.Close()
+
+	al := &APIListener{
+		Logger: testLog,
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+					JWTSecret:       "secret",
+				},
+			},
+			auditLog: auditLog,
+		},
+		bannedUsers: security.NewBanList(0),
+		userService: users.NewAPIService(users.NewStaticProvider([]*users.User{admin, operator}), false, 0, -1),
+		apiSessions: newEmptyAPISessionCache(t),
+	}
+	al.initRouter()
+
+	do := func(method, url, token, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		if token == "" {
+			req.SetBasicAuth("admin", "pwd")
+		} else {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/api/v1/users/admin/impersonation", "", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = do(http.MethodPost, "/api/v1/users/unknown/impersonation", "", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = do(http.MethodPost, "/api/v1/users/operator/impersonation?token-lifetime=7200", "", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = do(http.MethodPost, "/api/v1/users/operator/impersonation", "", `{"reason":"ticket 42"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		Data impersonationResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "operator", resp.Data.Username)
+	assert.Equal(t, "admin", resp.Data.ImpersonatedBy)
+	token := resp.Data.Token
+
+	w = do(http.MethodGet, "/api/v1/me", token, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"username":"operator"`)
+	assert.Contains(t, w.Body.String(), `"impersonated_by":"admin"`)
+
+	// the permissions of the impersonated user apply
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/api/v1/users", token, "").Code)
+
+	// credentials of the impersonated user are protected
+	w = do(http.MethodPost, "/api/v1/me/tokens", token, `{"name":"mine","scope":"read"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), errImpersonationNotAllowed.Error())
+	w = do(http.MethodPut, "/api/v1/me", token, `{"password":"new"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/auditlog?filter[application]="+auditlog.ApplicationAuthUserImpersonation, nil)
+	payload, err := auditLog.List(req, admin)
+	require.NoError(t, err)
+	entries := payload.Data.([]*auditlog.Entry)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "admin", entries[0].Username)
+	assert.Equal(t, "operator", entries[0].ID)
+	assert.Equal(t, `{"reason":"ticket 42"}`, entries[0].Request)
+
+	// the impersonation ends once the admin loses the admin rights
+	admin.Groups = nil
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/api/v1/me", token, "").Code)
+}
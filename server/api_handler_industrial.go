@@ -0,0 +1,265 @@
+package chserver
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/industrial"
+	"github.com/IOTech17/neo-rport/server/routes"
+	"github.com/IOTech17/neo-rport/share/query"
+)
+
+type industrialEndpointRequest struct {
+	Name        string            `json:"name"`
+	Protocol    string            `json:"protocol"`
+	Address     string            `json:"address"`
+	UnitID      int               `json:"unit_id"`
+	IntervalSec int               `json:"interval_sec"`
+	Points      industrial.Points `json:"points"`
+}
+
+type industrialPollResponse struct {
+	Values map[string]float64 `json:"values"`
+	Errors map[string]string  `json:"errors,omitempty"`
+	Error  string             `json:"error,omitempty"`
+}
+
+// handleListIndustrialEndpoints handles GET /clients/{client_id}/industrial-endpoints
+func (al *APIListener) handleListIndustrialEndpoints(w http.ResponseWriter, req *http.Request) {
+	clientID := mux.Vars(req)[routes.ParamClientID]
+
+	list, err := al.industrial.ListEndpoints(req.Context(), clientID)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(list))
+}
+
+// handlePostIndustrialEndpoint handles POST /clients/{client_id}/industrial-endpoints, it adds a Modbus TCP or OPC UA
+// device the client polls.
+func (al *APIListener) handlePostIndustrialEndpoint(w http.ResponseWriter, req *http.Request) {
+	clientID := mux.Vars(req)[routes.ParamClientID]
+
+	var reqBody industrialEndpointRequest
+	if err := parseRequestBody(req.Body, &reqBody); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	client, err := al.clientService.GetByID(clientID)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if client == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("client with id %s not found", clientID))
+		return
+	}
+
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	endpoint := &industrial.Endpoint{
+		ID:        uuid.New().String(),
+		ClientID:  clientID,
+		CreatedBy: curUser.Username,
+		CreatedAt: time.Now().UTC(),
+	}
+	if !al.applyIndustrialEndpointRequest(w, req, endpoint, reqBody) {
+		return
+	}
+	if err := al.industrial.CreateEndpoint(req.Context(), endpoint); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientIndustrial, auditlog.ActionCreate).
+		WithHTTPRequest(req).
+		WithClientID(clientID).
+		WithID(endpoint.ID).
+		WithRequest(reqBody).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusCreated, api.NewSuccessPayload(endpoint))
+}
+
+// handlePutIndustrialEndpoint handles PUT /clients/{client_id}/industrial-endpoints/{industrial_endpoint_id}, the
+// endpoint is polled with the new settings on the next run of the polling task. The values already read are kept.
+func (al *APIListener) handlePutIndustrialEndpoint(w http.ResponseWriter, req *http.Request) {
+	endpoint, ok := al.getIndustrialEndpoint(w, req)
+	if !ok {
+		return
+	}
+
+	var reqBody industrialEndpointRequest
+	if err := parseRequestBody(req.Body, &reqBody); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if !al.applyIndustrialEndpointRequest(w, req, endpoint, reqBody) {
+		return
+	}
+	updated, err := al.industrial.UpdateEndpoint(req.Context(), endpoint)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if !updated {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("industrial endpoint with id %s not found", endpoint.ID))
+		return
+	}
+	endpoint.LastPolledAt = nil
+	endpoint.LastError = ""
+
+	al.auditLog.Entry(auditlog.ApplicationClientIndustrial, auditlog.ActionUpdate).
+		WithHTTPRequest(req).
+		WithClientID(endpoint.ClientID).
+		WithID(endpoint.ID).
+		WithRequest(reqBody).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(endpoint))
+}
+
+// handleDeleteIndustrialEndpoint handles DELETE /clients/{client_id}/industrial-endpoints/{industrial_endpoint_id},
+// the values read from the endpoint are deleted with it.
+func (al *APIListener) handleDeleteIndustrialEndpoint(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	clientID := vars[routes.ParamClientID]
+	id := vars[routes.ParamIndustrialID]
+
+	deleted, err := al.industrial.DeleteEndpoint(req.Context(), clientID, id)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if !deleted {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("industrial endpoint with id %s not found", id))
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientIndustrial, auditlog.ActionDelete).
+		WithHTTPRequest(req).
+		WithClientID(clientID).
+		WithID(id).
+		Save()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlePollIndustrialEndpoint handles POST /clients/{client_id}/industrial-endpoints/{industrial_endpoint_id}/poll,
+// it polls the endpoint right away and returns the values read. Values are saved like the ones of scheduled polls.
+func (al *APIListener) handlePollIndustrialEndpoint(w http.ResponseWriter, req *http.Request) {
+	endpoint, ok := al.getIndustrialEndpoint(w, req)
+	if !ok {
+		return
+	}
+
+	result, err := al.pollIndustrialEndpoint(req.Context(), endpoint)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(industrialPollResponse{
+		Values: result.Values,
+		Errors: result.Errors,
+		Error:  result.Error,
+	}))
+}
+
+// handleListIndustrialValues handles GET /clients/{client_id}/industrial-values
+func (al *APIListener) handleListIndustrialValues(w http.ResponseWriter, req *http.Request) {
+	clientID := mux.Vars(req)[routes.ParamClientID]
+
+	options := query.GetListOptions(req)
+	err := query.ValidateListOptions(options, industrial.SupportedValueSorts, industrial.SupportedValueFilters, nil, industrial.ValuesPaginationConfig)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if err := industrial.ConvertTimeFilters(options); err != nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	options.Filters = append(options.Filters, query.FilterOption{
+		Column: []string{"client_id"},
+		Values: []string{clientID},
+	})
+	if len(options.Sorts) == 0 {
+		options.Sorts = []query.SortOption{industrial.DefaultValueSort}
+	}
+
+	values, err := al.industrial.ListValues(req.Context(), options)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	count, err := al.industrial.CountValues(req.Context(), options)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, &api.SuccessPayload{
+		Data: values,
+		Meta: api.NewMeta(count),
+	})
+}
+
+// getIndustrialEndpoint returns the endpoint of the route, it sends the error response if not found.
+func (al *APIListener) getIndustrialEndpoint(w http.ResponseWriter, req *http.Request) (*industrial.Endpoint, bool) {
+	vars := mux.Vars(req)
+	id := vars[routes.ParamIndustrialID]
+
+	endpoint, err := al.industrial.GetEndpoint(req.Context(), vars[routes.ParamClientID], id)
+	if err != nil {
+		al.jsonError(w, err)
+		return nil, false
+	}
+	if endpoint == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("industrial endpoint with id %s not found", id))
+		return nil, false
+	}
+	return endpoint, true
+}
+
+// applyIndustrialEndpointRequest validates the request and sets it on the endpoint, it sends the error response if
+// it's invalid or a point name is used by another endpoint of the client.
+func (al *APIListener) applyIndustrialEndpointRequest(w http.ResponseWriter, req *http.Request, endpoint *industrial.Endpoint, reqBody industrialEndpointRequest) bool {
+	endpoint.Name = reqBody.Name
+	endpoint.Protocol = reqBody.Protocol
+	endpoint.Address = reqBody.Address
+	endpoint.UnitID = reqBody.UnitID
+	endpoint.IntervalSec = reqBody.IntervalSec
+	endpoint.Points = reqBody.Points
+	if err := endpoint.Validate(); err != nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+
+	used, err := al.industrial.UsedPointNames(req.Context(), endpoint.ClientID, endpoint.ID)
+	if err != nil {
+		al.jsonError(w, err)
+		return false
+	}
+	for _, p := range endpoint.Points {
+		if used[p.Name] {
+			al.jsonErrorResponseWithDetail(w, http.StatusConflict, ErrCodeAlreadyExist, fmt.Sprintf("Point %q already exist.", p.Name),
+				"Point names are unique per client, another endpoint of the client has a point with the name.")
+			return false
+		}
+	}
+	return true
+}
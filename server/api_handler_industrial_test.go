@@ -0,0 +1,167 @@
+package chserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	industrialmigration "github.com/IOTech17/neo-rport/db/migration/industrial"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/clients"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/industrial"
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/security"
+	"github.com/IOTech17/neo-rport/share/test"
+)
+
+func TestIndustrialEndpoints(t *testing.T) {
+	db, err := sqlite.New(":memory:", industrialmigration.AssetNames(), industrialmigration.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	defer db.Close()
+
+	c1 := clients.New(t).ID("client-1").Logger(testLog).Build()
+	connMock := test.NewConnMock()
+	connMock.ReturnOk = true
+	connMock.DoneChannel = make(chan bool, 1)
+	c1.SetConnection(connMock)
+
+	admin := &users.User{Username: "admin", Password: "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm", Groups: []string{users.Administrators}}
+	al := &APIListener{
+		Logger:      testLog,
+		bannedUsers: security.NewBanList(0),
+		apiSessions: newEmptyAPISessionCache(t),
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+				},
+			},
+			clientService:       clients.NewClientService(nil, nil, clients.NewClientRepository([]*clientdata.Client{c1}, &hour, testLog), testLog, nil),
+			clientGroupProvider: staticClientGroupProvider{},
+			industrial:          industrial.NewSqliteProvider(db),
+			industrialPolls:     newIndustrialWaiters(),
+		},
+		userService: users.NewAPIService(users.NewStaticProvider([]*users.User{admin}), false, 0, -1),
+	}
+	al.initRouter()
+	cl := &ClientListener{server: al.Server}
+
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, "/api/v1/clients/client-1"+path, strings.NewReader(body))
+		req.SetBasicAuth("admin", "pwd")
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request(http.MethodPost, "/industrial-endpoints", `{"protocol":"modbus","address":"192.168.10.5","interval_sec":1,"points":[{"name":"t","register":"holding_register"}]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid interval_sec 1")
+
+	w = request(http.MethodPost, "/industrial-endpoints", `{
+		"name": "Boiler PLC",
+		"protocol": "modbus",
+		"address": "192.168.10.5",
+		"unit_id": 1,
+		"interval_sec": 60,
+		"points": [
+			{"name": "boiler_temperature", "register": "holding_register", "address": 40, "type": "int16", "scale": 0.1, "unit": "°C"},
+			{"name": "burner_on", "register": "coil", "address": 3}
+		]
+	}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Data industrial.Endpoint `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	endpoint := created.Data
+	assert.Equal(t, "admin", endpoint.CreatedBy)
+	assert.Equal(t, comm.ModbusBool, endpoint.Points[1].Type)
+
+	w = request(http.MethodPost, "/industrial-endpoints", `{"protocol":"opcua","address":"opc.tcp://192.168.10.6","interval_sec":60,"points":[{"name":"burner_on","node_id":"ns=2;i=7"}]}`)
+	assert.Equal(t, http.StatusConflict, w.Code, "point names are unique per client")
+
+	w = request(http.MethodPut, "/industrial-endpoints/"+endpoint.ID, `{"name":"Boiler","protocol":"modbus","address":"192.168.10.5","unit_id":1,"interval_sec":30,"points":[{"name":"boiler_temperature","register":"holding_register","address":40,"type":"int16","scale":0.1}]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = request(http.MethodPut, "/industrial-endpoints/unknown", `{}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = request(http.MethodGet, "/industrial-endpoints", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"interval_sec":30`)
+
+	// the client reads the register
+	go func() {
+		<-connMock.DoneChannel
+		name, _, payload := connMock.InputSendRequest()
+		assert.Equal(t, comm.RequestTypePollIndustrial, name)
+		var req comm.IndustrialPollRequest
+		assert.NoError(t, json.Unmarshal(payload, &req))
+		assert.Equal(t, "192.168.10.5", req.Address)
+		assert.Equal(t, byte(1), req.UnitID)
+		assert.Len(t, req.Points, 1)
+
+		result, _ := json.Marshal(comm.IndustrialPollResult{ID: req.ID, Values: map[string]float64{"boiler_temperature": 815}})
+		assert.EqualError(t, cl.receiveIndustrialResult("other-client", result), "industrial poll not requested or expired")
+		assert.NoError(t, cl.receiveIndustrialResult("client-1", result))
+	}()
+
+	w = request(http.MethodPost, "/industrial-endpoints/"+endpoint.ID+"/poll", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"data":{"values":{"boiler_temperature":81.5}}}`, w.Body.String(), "the scale is applied")
+	assert.Empty(t, al.industrialPolls.m)
+
+	w = request(http.MethodGet, "/industrial-values?filter[point]=boiler_temperature", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var values struct {
+		Data []industrial.PointValue `json:"data"`
+		Meta struct {
+			Count int `json:"count"`
+		} `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &values))
+	require.Len(t, values.Data, 1)
+	assert.Equal(t, 81.5, values.Data[0].Value)
+	assert.Equal(t, endpoint.ID, values.Data[0].EndpointID)
+	assert.Equal(t, 1, values.Meta.Count)
+
+	w = request(http.MethodGet, "/industrial-values?filter[timestamp][gt]=yesterday", "")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = request(http.MethodDelete, "/industrial-endpoints/"+endpoint.ID, "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = request(http.MethodDelete, "/industrial-endpoints/"+endpoint.ID, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestIndustrialPollNotSupportedByClient(t *testing.T) {
+	c1 := clients.New(t).ID("client-1").Logger(testLog).Build()
+	connMock := test.NewConnMock()
+	connMock.ReturnOk = false
+	connMock.ReturnResponsePayload = []byte("unknown request")
+	c1.SetConnection(connMock)
+
+	s := &Server{
+		Logger:          testLog,
+		clientService:   clients.NewClientService(nil, nil, clients.NewClientRepository([]*clientdata.Client{c1}, &hour, testLog), testLog, nil),
+		industrialPolls: newIndustrialWaiters(),
+	}
+	_, err := s.pollIndustrialEndpoint(context.Background(), &industrial.Endpoint{ClientID: "client-1", Protocol: comm.IndustrialProtocolModbus})
+	assert.EqualError(t, err, "failed to poll industrial endpoint through client client-1: client does not support industrial polling")
+	assert.Empty(t, s.industrialPolls.m)
+}
+
+func TestIndustrialPollError(t *testing.T) {
+	assert.Equal(t, "connection refused", industrialPollError(comm.IndustrialPollResult{Error: "connection refused"}))
+	assert.Equal(t, "a: modbus exception 2; b: bad status", industrialPollError(comm.IndustrialPollResult{Errors: map[string]string{"b": "bad status", "a": "modbus exception 2"}}))
+	assert.Equal(t, "", industrialPollError(comm.IndustrialPollResult{}))
+}
@@ -0,0 +1,162 @@
+package chserver
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/clientsauth"
+	"github.com/IOTech17/neo-rport/server/installers"
+	chshare "github.com/IOTech17/neo-rport/share"
+	"github.com/IOTech17/neo-rport/share/random"
+	"github.com/IOTech17/neo-rport/share/security"
+)
+
+const (
+	installerClientAuthIDPrefix   = "installer-"
+	installerClientAuthPassLength = 32
+
+	// ClientAuthIDHeader tells the caller which client credentials are baked into a generated installer.
+	ClientAuthIDHeader = "X-Client-Auth-Id"
+)
+
+type clientInstallerRequest struct {
+	Format string `json:"format"`
+	// OS is only used for the conf format, "windows" selects the windows paths
+	OS string `json:"os"`
+	// ClientAuthID selects existing client credentials, new ones are created if empty
+	ClientAuthID string            `json:"client_auth_id"`
+	Tags         []string          `json:"tags"`
+	Labels       map[string]string `json:"labels"`
+}
+
+// handlePostClientInstallers generates an installer with the server url, fingerprint, client credentials and the
+// given tags and labels baked in.
+func (al *APIListener) handlePostClientInstallers(w http.ResponseWriter, req *http.Request) {
+	var installerReq clientInstallerRequest
+	if err := parseRequestBody(req.Body, &installerReq); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	switch installerReq.Format {
+	case installers.FormatShell, installers.FormatPowerShell:
+		if al.config.Server.InstallerDownloadURL == "" {
+			al.jsonErrorResponseWithDetail(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Installer scripts are not available.", "Set 'installer_download_url' in the [server] section of the rportd config.")
+			return
+		}
+	case installers.FormatConfig:
+	default:
+		al.jsonErrorResponseWithErrCode(w, http.StatusBadRequest, ErrCodeInvalidRequest, fmt.Sprintf("Invalid format %q, expected one of: sh, ps1, conf.", installerReq.Format))
+		return
+	}
+	if installerReq.OS != "" && installerReq.OS != "linux" && installerReq.OS != "windows" {
+		al.jsonErrorResponseWithErrCode(w, http.StatusBadRequest, ErrCodeInvalidRequest, fmt.Sprintf("Invalid os %q, expected one of: linux, windows.", installerReq.OS))
+		return
+	}
+
+	if err := installers.ValidateLabels(installerReq.Labels); err != nil {
+		al.jsonErrorResponseWithErrCode(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+		return
+	}
+
+	clientAuth, created, ok := al.getOrCreateInstallerClientAuth(w, installerReq.ClientAuthID)
+	if !ok {
+		return
+	}
+
+	opts := al.installerOptions(clientAuth, installerReq.Tags, installerReq.Labels)
+	opts.Windows = installerReq.OS == "windows"
+
+	installer, err := installers.Generate(installerReq.Format, opts)
+	if err != nil {
+		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if created {
+		al.auditLog.Entry(auditlog.ApplicationClientAuth, auditlog.ActionCreate).
+			WithHTTPRequest(req).
+			WithID(clientAuth.ID).
+			Save()
+	}
+	al.auditLog.Entry(auditlog.ApplicationClientInstaller, auditlog.ActionCreate).
+		WithHTTPRequest(req).
+		WithID(clientAuth.ID).
+		WithRequest(map[string]interface{}{
+			"format": installerReq.Format,
+			"os":     installerReq.OS,
+			"tags":   installerReq.Tags,
+			"labels": installerReq.Labels,
+		}).
+		Save()
+
+	w.Header().Set("Content-Type", installer.ContentType)
+	w.Header().Set("Content-Disposition", "attachment; filename="+strconv.Quote(installer.Filename))
+	w.Header().Set(ClientAuthIDHeader, clientAuth.ID)
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(installer.Content); err != nil {
+		al.Errorf("Failed to write installer: %v", err)
+	}
+}
+
+// installerOptions returns the connection settings of the server for an installer with the client credentials.
+func (al *APIListener) installerOptions(clientAuth *clientsauth.ClientAuth, tags []string, labels map[string]string) installers.Options {
+	opts := installers.Options{
+		ServerURL:    al.config.Server.URL[0],
+		Fingerprint:  al.fingerprint,
+		ClientAuthID: clientAuth.ID,
+		Password:     clientAuth.Password,
+		Tags:         tags,
+		Labels:       labels,
+		DownloadURL:  al.config.Server.InstallerDownloadURL,
+		Version:      chshare.BuildVersion,
+	}
+	if len(al.config.Server.URL) > 1 {
+		opts.FallbackServers = al.config.Server.URL[1:]
+	}
+	if al.config.Server.EquateClientauthidClientid {
+		opts.ClientID = clientAuth.ID
+	}
+	return opts
+}
+
+func (al *APIListener) getOrCreateInstallerClientAuth(w http.ResponseWriter, clientAuthID string) (clientAuth *clientsauth.ClientAuth, created bool, ok bool) {
+	if clientAuthID != "" {
+		clientAuth, err := al.clientAuthProvider.Get(clientAuthID)
+		if err != nil {
+			al.jsonErrorResponse(w, http.StatusInternalServerError, err)
+			return nil, false, false
+		}
+		if clientAuth == nil {
+			al.jsonErrorResponseWithErrCode(w, http.StatusNotFound, ErrCodeClientAuthNotFound, fmt.Sprintf("Client Auth with ID=%q not found.", clientAuthID))
+			return nil, false, false
+		}
+		return clientAuth, false, true
+	}
+
+	if !al.allowClientAuthWrite(w) {
+		return nil, false, false
+	}
+	password, err := security.NewRandomToken(installerClientAuthPassLength)
+	if err != nil {
+		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
+		return nil, false, false
+	}
+	clientAuth = &clientsauth.ClientAuth{
+		ID:       installerClientAuthIDPrefix + random.AlphaNum(12),
+		Password: password,
+	}
+	added, err := al.clientAuthProvider.Add(clientAuth)
+	if err != nil {
+		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
+		return nil, false, false
+	}
+	if !added {
+		al.jsonErrorResponseWithDetail(w, http.StatusConflict, ErrCodeAlreadyExist, fmt.Sprintf("Client Auth with ID %q already exist.", clientAuth.ID), "")
+		return nil, false, false
+	}
+	al.Infof("ClientAuth %q created for an installer.", clientAuth.ID)
+	return clientAuth, true, true
+}
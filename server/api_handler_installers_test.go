@@ -0,0 +1,134 @@
+package chserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/clientsauth"
+	"github.com/IOTech17/neo-rport/share/query"
+)
+
+func TestHandlePostClientInstallers(t *testing.T) {
+	testCases := []struct {
+		Name            string
+		RequestBody     string
+		DownloadURL     string
+		ClientAuthWrite bool
+
+		ExpectedStatus       int
+		ExpectedClientAuthID string
+		ExpectedContent      []string
+		ExpectedErrTitle     string
+		ExpectedClientsAuth  int
+	}{
+		{
+			Name:                 "config with existing credentials",
+			RequestBody:          `{"format": "conf", "client_auth_id": "user1", "tags": ["edge"], "labels": {"site": "berlin"}}`,
+			ExpectedStatus:       http.StatusOK,
+			ExpectedClientAuthID: "user1",
+			ExpectedContent: []string{
+				`server = "https://rport.example.com"`,
+				`fallback_servers = ["https://fallback.example.com"]`,
+				`fingerprint = "test-fingerprint"`,
+				`auth = "user1:pswd1"`,
+				`tags = ["edge"]`,
+				`labels = { "site" = "berlin" }`,
+			},
+			ExpectedClientsAuth: 3,
+		},
+		{
+			Name:                "shell script with new credentials",
+			RequestBody:         `{"format": "sh"}`,
+			DownloadURL:         "https://downloads.example.com/rport_{version}_{os}_{arch}.{ext}",
+			ClientAuthWrite:     true,
+			ExpectedStatus:      http.StatusOK,
+			ExpectedContent:     []string{`auth = "installer-`, "https://downloads.example.com/rport_"},
+			ExpectedClientsAuth: 4,
+		},
+		{
+			Name:                "new credentials in read-only mode",
+			RequestBody:         `{"format": "conf"}`,
+			ExpectedStatus:      http.StatusMethodNotAllowed,
+			ExpectedErrTitle:    "Client authentication has been attached in read-only mode.",
+			ExpectedClientsAuth: 3,
+		},
+		{
+			Name:                "script without download url",
+			RequestBody:         `{"format": "ps1", "client_auth_id": "user1"}`,
+			ExpectedStatus:      http.StatusBadRequest,
+			ExpectedErrTitle:    "Installer scripts are not available.",
+			ExpectedClientsAuth: 3,
+		},
+		{
+			Name:                "unknown credentials",
+			RequestBody:         `{"format": "conf", "client_auth_id": "unknown"}`,
+			ExpectedStatus:      http.StatusNotFound,
+			ExpectedErrTitle:    `Client Auth with ID="unknown" not found.`,
+			ExpectedClientsAuth: 3,
+		},
+		{
+			Name:                "invalid format",
+			RequestBody:         `{"format": "msi", "client_auth_id": "user1"}`,
+			ExpectedStatus:      http.StatusBadRequest,
+			ExpectedErrTitle:    `Invalid format "msi", expected one of: sh, ps1, conf.`,
+			ExpectedClientsAuth: 3,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			provider := clientsauth.NewMockFileProvider([]*clientsauth.ClientAuth{cl1, cl2, cl3}, t)
+			al := APIListener{
+				Server: &Server{
+					config: &chconfig.Config{
+						Server: chconfig.ServerConfig{
+							URL:                  []string{"https://rport.example.com", "https://fallback.example.com"},
+							AuthWrite:            tc.ClientAuthWrite,
+							InstallerDownloadURL: tc.DownloadURL,
+						},
+						API: chconfig.APIConfig{
+							MaxRequestBytes: 1024 * 1024,
+						},
+					},
+					clientAuthProvider: provider,
+				},
+				Logger:      testLog,
+				fingerprint: "test-fingerprint",
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/client-installers", strings.NewReader(tc.RequestBody))
+			w := httptest.NewRecorder()
+			http.HandlerFunc(al.handlePostClientInstallers).ServeHTTP(w, req)
+
+			require.Equal(t, tc.ExpectedStatus, w.Code, w.Body.String())
+			if tc.ExpectedErrTitle != "" {
+				errResp := api.ErrorPayload{}
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+				require.Len(t, errResp.Errors, 1)
+				assert.Equal(t, tc.ExpectedErrTitle, errResp.Errors[0].Title)
+			} else {
+				assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+				if tc.ExpectedClientAuthID != "" {
+					assert.Equal(t, tc.ExpectedClientAuthID, w.Header().Get(ClientAuthIDHeader))
+				} else {
+					assert.True(t, strings.HasPrefix(w.Header().Get(ClientAuthIDHeader), installerClientAuthIDPrefix))
+				}
+				for _, expected := range tc.ExpectedContent {
+					assert.Contains(t, w.Body.String(), expected)
+				}
+			}
+
+			_, count, err := provider.GetFiltered(&query.ListOptions{Pagination: query.NewPagination(10, 0)})
+			require.NoError(t, err)
+			assert.Equal(t, tc.ExpectedClientsAuth, count)
+		})
+	}
+}
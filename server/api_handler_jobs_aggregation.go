@@ -0,0 +1,218 @@
+package chserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/api/authorization"
+	"github.com/IOTech17/neo-rport/server/api/jobs"
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/routes"
+	"github.com/IOTech17/neo-rport/share/models"
+	"github.com/IOTech17/neo-rport/share/query"
+)
+
+type jobsDiffPayload struct {
+	ClientID     string          `json:"client_id"`
+	BaseClientID string          `json:"base_client_id,omitempty"`
+	BaseHash     string          `json:"base_hash"`
+	Hash         string          `json:"hash"`
+	StdOut       []jobs.DiffLine `json:"stdout"`
+	StdErr       []jobs.DiffLine `json:"stderr"`
+}
+
+// handleGetMultiClientCommandAggregation handles GET /commands/{job_id}/aggregation
+func (al *APIListener) handleGetMultiClientCommandAggregation(w http.ResponseWriter, req *http.Request) {
+	multiJob, allowed, ok := al.checkMultiJobAccess(w, req)
+	if !ok {
+		return
+	}
+	multiJobID := multiJob.JID
+
+	var options jobs.AggregateOptions
+	for param, target := range map[string]*bool{
+		"ignore_whitespace": &options.IgnoreWhitespace,
+		"ignore_case":       &options.IgnoreCase,
+	} {
+		if value := req.URL.Query().Get(param); value != "" {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("Invalid %q query param: %v.", param, err))
+				return
+			}
+			*target = b
+		}
+	}
+
+	multiJobJobs, err := al.listAllMultiJobJobs(req.Context(), multiJobID)
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get jobs: multi_job_id=%q.", multiJobID), err)
+		return
+	}
+	multiJobJobs = filterJobsByClient(multiJobJobs, allowed)
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(jobs.Aggregate(multiJobJobs, options)))
+}
+
+// handleGetMultiClientCommandDiff handles GET /commands/{job_id}/diff, it compares the output of a client with
+// the one of another client or if not given, with the most common output.
+func (al *APIListener) handleGetMultiClientCommandDiff(w http.ResponseWriter, req *http.Request) {
+	multiJob, allowed, ok := al.checkMultiJobAccess(w, req)
+	if !ok {
+		return
+	}
+	multiJobID := multiJob.JID
+
+	clientID := req.URL.Query().Get("client_id")
+	if clientID == "" {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, `Missing "client_id" query param.`)
+		return
+	}
+	baseClientID := req.URL.Query().Get("base_client_id")
+
+	multiJobJobs, err := al.listAllMultiJobJobs(req.Context(), multiJobID)
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get jobs: multi_job_id=%q.", multiJobID), err)
+		return
+	}
+	multiJobJobs = filterJobsByClient(multiJobJobs, allowed)
+
+	job := findClientJob(multiJobJobs, clientID)
+	if job == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("No job of client %q found.", clientID))
+		return
+	}
+
+	var baseStdOut, baseStdErr string
+	if baseClientID != "" {
+		baseJob := findClientJob(multiJobJobs, baseClientID)
+		if baseJob == nil {
+			al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("No job of client %q found.", baseClientID))
+			return
+		}
+		baseStdOut, baseStdErr = jobOutput(baseJob)
+	} else {
+		aggregation := jobs.Aggregate(multiJobJobs, jobs.AggregateOptions{})
+		baseStdOut, baseStdErr = aggregation.Outputs[0].StdOut, aggregation.Outputs[0].StdErr
+	}
+
+	stdout, stderr := jobOutput(job)
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(jobsDiffPayload{
+		ClientID:     clientID,
+		BaseClientID: baseClientID,
+		BaseHash:     jobs.OutputHash(baseStdOut, baseStdErr),
+		Hash:         jobs.OutputHash(stdout, stderr),
+		StdOut:       jobs.Diff(baseStdOut, stdout),
+		StdErr:       jobs.Diff(baseStdErr, stderr),
+	}))
+}
+
+// checkMultiJobAccess writes an error response and returns false if the multi-client job doesn't exist or
+// the current user is not allowed to see it. The returned func tells if the jobs of a client may be accessed.
+func (al *APIListener) checkMultiJobAccess(w http.ResponseWriter, req *http.Request) (*models.MultiJob, func(clientID string) bool, bool) {
+	jid := mux.Vars(req)[routes.ParamJobID]
+	if jid == "" {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("Missing %q route param.", routes.ParamJobID))
+		return nil, nil, false
+	}
+
+	job, err := al.jobProvider.GetMultiJob(req.Context(), jid)
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to find a multi-client job[id=%q].", jid), err)
+		return nil, nil, false
+	}
+	if job == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("Multi-client Job[id=%q] not found.", jid))
+		return nil, nil, false
+	}
+
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return nil, nil, false
+	}
+	if !curUser.IsAdmin() && job.CreatedBy != curUser.Username {
+		al.jsonErrorResponseWithError(w, http.StatusForbidden, "forbidden", fmt.Errorf("you are not allowed to access items created by another user"))
+		return nil, nil, false
+	}
+
+	allowed, err := al.clientAccessFilter(req.Context(), curUser)
+	if err != nil {
+		al.jsonError(w, err)
+		return nil, nil, false
+	}
+	return job, allowed, true
+}
+
+// clientAccessFilter returns a func telling if the user may access a client. All clients are allowed unless the
+// request is authenticated by a token restricted to some clients, clients that don't exist anymore are denied to it.
+func (al *APIListener) clientAccessFilter(ctx context.Context, curUser *users.User) (func(clientID string) bool, error) {
+	if !authorization.TokenFromContext(ctx).IsClientRestricted() {
+		return func(string) bool { return true }, nil
+	}
+
+	clientGroups, err := al.clientGroupProvider.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return func(clientID string) bool {
+		existing, err := al.clientService.GetByID(clientID)
+		if err != nil || existing == nil {
+			return false
+		}
+		return al.clientService.CheckClientsAccess([]*clientdata.Client{existing}, curUser, clientGroups) == nil
+	}, nil
+}
+
+func filterJobsByClient(list []*models.Job, allowed func(clientID string) bool) []*models.Job {
+	filtered := make([]*models.Job, 0, len(list))
+	for _, job := range list {
+		if allowed(job.ClientID) {
+			filtered = append(filtered, job)
+		}
+	}
+	return filtered
+}
+
+func (al *APIListener) listAllMultiJobJobs(ctx context.Context, multiJobID string) ([]*models.Job, error) {
+	var result []*models.Job
+	for offset := 0; ; offset += jobs.MaxLimit {
+		options := &query.ListOptions{
+			Filters: []query.FilterOption{
+				{Column: []string{"multi_job_id"}, Values: []string{multiJobID}},
+			},
+			Sorts:      []query.SortOption{{Column: "jid", IsASC: true}},
+			Pagination: query.NewPagination(jobs.MaxLimit, offset),
+		}
+		page, err := al.jobProvider.List(ctx, options)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, page...)
+		if len(page) < jobs.MaxLimit {
+			return result, nil
+		}
+	}
+}
+
+func findClientJob(list []*models.Job, clientID string) *models.Job {
+	for _, job := range list {
+		if job.ClientID == clientID {
+			return job
+		}
+	}
+	return nil
+}
+
+func jobOutput(job *models.Job) (stdout, stderr string) {
+	if job.Result == nil {
+		return "", ""
+	}
+	return job.Result.StdOut, job.Result.StdErr
+}
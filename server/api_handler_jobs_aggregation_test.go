@@ -0,0 +1,89 @@
+package chserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	jobsmigration "github.com/IOTech17/neo-rport/db/migration/jobs"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/api/jobs"
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/test/jb"
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+func TestHandleMultiClientCommandAggregation(t *testing.T) {
+	jobsDB, err := sqlite.New(":memory:", jobsmigration.AssetNames(), jobsmigration.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	jp := jobs.NewSqliteProvider(jobsDB, testLog)
+	defer jp.Close()
+
+	multiJob := jb.NewMulti(t).JID("multi-1").Build()
+	multiJob.CreatedBy = "admin"
+	require.NoError(t, jp.SaveMultiJob(multiJob))
+	for clientID, stdout := range map[string]string{
+		"c1": "nameserver 10.0.0.1\n",
+		"c2": "nameserver 10.0.0.1\n",
+		"c3": "nameserver 10.0.0.9\n",
+	} {
+		job := jb.New(t).ClientID(clientID).MultiJobID(multiJob.JID).Status(models.JobStatusSuccessful).Result(&models.JobResult{StdOut: stdout}).Build()
+		require.NoError(t, jp.SaveJob(job))
+	}
+
+	al := APIListener{
+		insecureForTests: true,
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+				},
+			},
+			jobProvider: jp,
+		},
+		userService: users.NewAPIService(users.NewStaticProvider([]*users.User{
+			{Username: "admin", Groups: []string{users.Administrators}},
+			{Username: "other"},
+		}), false, 0, -1),
+		Logger: testLog,
+	}
+	al.initRouter()
+
+	do := func(username, url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req = req.WithContext(api.WithUser(req.Context(), username))
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("admin", "/api/v1/commands/multi-1/aggregation")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data":{
+		"total":3,
+		"unique_outputs":2,
+		"statuses":{"successful":3},
+		"outputs":[
+			{"hash":"`+jobs.OutputHash("nameserver 10.0.0.1\n", "")+`","count":2,"stdout":"nameserver 10.0.0.1\n","stderr":"","statuses":{"successful":2},"client_ids":["c1","c2"]},
+			{"hash":"`+jobs.OutputHash("nameserver 10.0.0.9\n", "")+`","count":1,"stdout":"nameserver 10.0.0.9\n","stderr":"","statuses":{"successful":1},"client_ids":["c3"]}
+		]
+	}}`, w.Body.String())
+
+	w = do("admin", "/api/v1/commands/multi-1/diff?client_id=c3")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"stdout":[{"op":"-","text":"nameserver 10.0.0.1"},{"op":"+","text":"nameserver 10.0.0.9"}]`)
+
+	w = do("admin", "/api/v1/commands/multi-1/diff?client_id=c1&base_client_id=c2")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"stdout":[{"op":"=","text":"nameserver 10.0.0.1"}]`)
+
+	assert.Equal(t, http.StatusNotFound, do("admin", "/api/v1/commands/multi-1/diff?client_id=c9").Code)
+	assert.Equal(t, http.StatusBadRequest, do("admin", "/api/v1/commands/multi-1/diff").Code)
+	assert.Equal(t, http.StatusNotFound, do("admin", "/api/v1/commands/multi-2/aggregation").Code)
+	assert.Equal(t, http.StatusForbidden, do("other", "/api/v1/commands/multi-1/aggregation").Code)
+}
@@ -0,0 +1,68 @@
+package chserver
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/api/authorization"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+// handlePostMultiClientCommandCanaryConfirm handles POST /commands/{job_id}/canary/confirm
+func (al *APIListener) handlePostMultiClientCommandCanaryConfirm(w http.ResponseWriter, req *http.Request) {
+	al.handleCanaryDecision(w, req, true)
+}
+
+// handlePostMultiClientCommandCanaryAbort handles POST /commands/{job_id}/canary/abort
+func (al *APIListener) handlePostMultiClientCommandCanaryAbort(w http.ResponseWriter, req *http.Request) {
+	al.handleCanaryDecision(w, req, false)
+}
+
+func (al *APIListener) handleCanaryDecision(w http.ResponseWriter, req *http.Request, confirmed bool) {
+	multiJob, allowed, ok := al.checkMultiJobAccess(w, req)
+	if !ok {
+		return
+	}
+	multiJobID := multiJob.JID
+
+	// the decision affects all clients of the job, a token restricted to some clients has to cover them all
+	if authorization.TokenFromContext(req.Context()).IsClientRestricted() && !allowsAllJobClients(multiJob, allowed) {
+		al.jsonErrorResponseWithTitle(w, http.StatusForbidden, "A token restricted to some clients can't decide on a job running on other clients.")
+		return
+	}
+
+	username := api.GetUser(req.Context(), al.Logger)
+	if !al.decideCanary(multiJobID, canaryDecision{confirmed: confirmed, username: username}) {
+		al.jsonErrorResponseWithTitle(w, http.StatusConflict, fmt.Sprintf("Multi-client Job[id=%q] is not awaiting a canary confirmation.", multiJobID))
+		return
+	}
+
+	action := auditlog.ActionConfirm
+	if !confirmed {
+		action = auditlog.ActionAbort
+	}
+	al.auditLog.Entry(auditlog.ApplicationClientCommand, action).
+		WithHTTPRequest(req).
+		WithID(multiJobID).
+		Save()
+
+	al.Infof("Canary run of Multi-client Job[id=%q]: %s by %q.", multiJobID, action, username)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// allowsAllJobClients returns true if all clients the multi-client job is meant for are allowed. Jobs for client groups
+// or tags resolve their clients at run time, so they can't be covered.
+func allowsAllJobClients(multiJob *models.MultiJob, allowed func(clientID string) bool) bool {
+	if len(multiJob.GroupIDs) > 0 || multiJob.ClientTags != nil {
+		return false
+	}
+	for _, clientID := range multiJob.ClientIDs {
+		if !allowed(clientID) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,98 @@
+package chserver
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/routes"
+)
+
+type killSwitchRequest struct {
+	Disabled bool   `json:"disabled"`
+	Reason   string `json:"reason"`
+}
+
+// handleGetKillSwitches handles GET /server/kill-switches
+func (al *APIListener) handleGetKillSwitches(w http.ResponseWriter, req *http.Request) {
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(al.killSwitches.List()))
+}
+
+// handlePutKillSwitch handles PUT /server/kill-switches/{capability}
+func (al *APIListener) handlePutKillSwitch(w http.ResponseWriter, req *http.Request) {
+	var params killSwitchRequest
+	if err := parseRequestBody(req.Body, &params); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	capability := mux.Vars(req)[routes.ParamCapability]
+	sw, err := al.killSwitches.Set(capability, params.Disabled, params.Reason, curUser.Username)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	if sw.Disabled {
+		al.Infof("Capability %q disabled by %q: %s", capability, curUser.Username, sw.Reason)
+	} else {
+		al.Infof("Capability %q enabled by %q", capability, curUser.Username)
+	}
+	al.auditLog.Entry(auditlog.ApplicationServerKillSwitch, auditlog.ActionUpdate).
+		WithHTTPRequest(req).
+		WithID(capability).
+		WithRequest(params).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(sw))
+}
+
+// wrapSuperAdminMiddleware allows only the configured super-admins, all administrators if none are configured.
+func (al *APIListener) wrapSuperAdminMiddleware(next http.Handler) http.Handler {
+	return al.wrapAdminAccessMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if al.insecureForTests || len(al.config.API.SuperAdmins) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		user, err := al.getUserModelForAuth(r.Context())
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
+		for _, username := range al.config.API.SuperAdmins {
+			if username == user.Username {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		al.jsonError(w, errors2.APIError{
+			Message:    fmt.Sprintf("user %q is not a super-admin", user.Username),
+			HTTPStatus: http.StatusForbidden,
+		})
+	}))
+}
+
+// wrapKillSwitchMiddleware rejects the request if the capability is disabled by a kill switch.
+func (al *APIListener) wrapKillSwitchMiddleware(capability string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := al.killSwitches.Check(capability); err != nil {
+				al.jsonError(w, err)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
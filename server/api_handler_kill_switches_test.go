@@ -0,0 +1,55 @@
+package chserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/server/api/session"
+	"github.com/IOTech17/neo-rport/server/killswitch"
+)
+
+func TestKillSwitches(t *testing.T) {
+	al := setupTestAPIListenerSessionPolicy(t, session.ConcurrentSessionsAllow, 0)
+	var err error
+	al.killSwitches, err = killswitch.Open(t.TempDir())
+	require.NoError(t, err)
+
+	do := func(method, url, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req.SetBasicAuth("admin", "pwd")
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPut, "/api/v1/server/kill-switches/uploads", `{"disabled":true,"reason":"incident 42"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"changed_by":"admin"`)
+
+	w = do(http.MethodPost, "/api/v1/files", "")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), "file uploads is disabled by an administrator: incident 42")
+
+	w = do(http.MethodGet, "/api/v1/server/features", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"kill_switches":{"enabled":true,"details":{"disabled":{"uploads":"incident 42"}}}`)
+
+	w = do(http.MethodPut, "/api/v1/server/kill-switches/shutdown", `{"disabled":true}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// only super-admins toggle the switches if configured
+	al.config.API.SuperAdmins = []string{"root"}
+	w = do(http.MethodPut, "/api/v1/server/kill-switches/uploads", `{"disabled":false}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.True(t, al.killSwitches.Get(killswitch.CapabilityUploads).Disabled)
+
+	al.config.API.SuperAdmins = []string{"admin"}
+	w = do(http.MethodPut, "/api/v1/server/kill-switches/uploads", `{"disabled":false}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, al.killSwitches.Get(killswitch.CapabilityUploads).Disabled)
+}
@@ -16,7 +16,13 @@ import (
 )
 
 func (al *APIListener) handleListScripts(w http.ResponseWriter, req *http.Request) {
-	items, count, err := al.scriptManager.List(req.Context(), req)
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	items, count, err := al.scriptManager.List(req.Context(), req, curUser)
 	if err != nil {
 		al.jsonError(w, err)
 		return
@@ -36,6 +42,12 @@ func (al *APIListener) handleScriptCreate(w http.ResponseWriter, req *http.Reque
 		return
 	}
 
+	warnings, err := al.secretScanner.Check(scriptInput.Script)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
 	curUsername := api.GetUser(req.Context(), al.Logger)
 	if curUsername == "" {
 		al.jsonErrorResponseWithTitle(w, http.StatusUnauthorized, "unauthorized")
@@ -57,7 +69,10 @@ func (al *APIListener) handleScriptCreate(w http.ResponseWriter, req *http.Reque
 
 	w.WriteHeader(http.StatusCreated)
 
-	al.writeJSONResponse(w, http.StatusCreated, api.NewSuccessPayload(storedValue))
+	al.writeJSONResponse(w, http.StatusCreated, &api.SuccessPayload{
+		Data:     storedValue,
+		Warnings: warnings,
+	})
 }
 
 func (al *APIListener) handleScriptUpdate(w http.ResponseWriter, req *http.Request) {
@@ -68,20 +83,26 @@ func (al *APIListener) handleScriptUpdate(w http.ResponseWriter, req *http.Reque
 		return
 	}
 
-	curUsername := api.GetUser(req.Context(), al.Logger)
-	if curUsername == "" {
-		al.jsonErrorResponseWithTitle(w, http.StatusUnauthorized, "unauthorized")
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
 		return
 	}
 
 	var scriptInput script.InputScript
-	err := parseRequestBody(req.Body, &scriptInput)
+	err = parseRequestBody(req.Body, &scriptInput)
 	if err != nil {
 		al.jsonError(w, err)
 		return
 	}
 
-	storedValue, err := al.scriptManager.Update(req.Context(), idStr, &scriptInput, curUsername)
+	warnings, err := al.secretScanner.Check(scriptInput.Script)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	storedValue, err := al.scriptManager.Update(req.Context(), idStr, &scriptInput, curUser)
 	if err != nil {
 		al.jsonError(w, err)
 		return
@@ -94,7 +115,10 @@ func (al *APIListener) handleScriptUpdate(w http.ResponseWriter, req *http.Reque
 		WithID(idStr).
 		Save()
 
-	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(storedValue))
+	al.writeJSONResponse(w, http.StatusOK, &api.SuccessPayload{
+		Data:     storedValue,
+		Warnings: warnings,
+	})
 }
 
 func (al *APIListener) handleReadScript(w http.ResponseWriter, req *http.Request) {
@@ -108,7 +132,13 @@ func (al *APIListener) handleReadScript(w http.ResponseWriter, req *http.Request
 		return
 	}
 
-	foundScript, found, err := al.scriptManager.GetOne(req.Context(), req, idStr)
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	foundScript, found, err := al.scriptManager.GetOne(req.Context(), req, idStr, curUser)
 	if err != nil {
 		al.jsonError(w, err)
 		return
@@ -132,7 +162,13 @@ func (al *APIListener) handleDeleteScript(w http.ResponseWriter, req *http.Reque
 		return
 	}
 
-	err := al.scriptManager.Delete(req.Context(), idStr)
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	err = al.scriptManager.Delete(req.Context(), idStr, curUser)
 	if err != nil {
 		al.jsonError(w, err)
 		return
@@ -147,7 +183,13 @@ func (al *APIListener) handleDeleteScript(w http.ResponseWriter, req *http.Reque
 }
 
 func (al *APIListener) handleListCommands(w http.ResponseWriter, req *http.Request) {
-	items, count, err := al.commandManager.List(req.Context(), req)
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	items, count, err := al.commandManager.List(req.Context(), req, curUser)
 	if err != nil {
 		al.jsonError(w, err)
 		return
@@ -167,6 +209,12 @@ func (al *APIListener) handleCommandCreate(w http.ResponseWriter, req *http.Requ
 		return
 	}
 
+	warnings, err := al.secretScanner.Check(commandInput.Cmd)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
 	curUsername := api.GetUser(req.Context(), al.Logger)
 	if curUsername == "" {
 		al.jsonErrorResponseWithTitle(w, http.StatusUnauthorized, "unauthorized")
@@ -186,7 +234,10 @@ func (al *APIListener) handleCommandCreate(w http.ResponseWriter, req *http.Requ
 		WithID(storedValue.ID).
 		Save()
 
-	al.writeJSONResponse(w, http.StatusCreated, api.NewSuccessPayload(storedValue))
+	al.writeJSONResponse(w, http.StatusCreated, &api.SuccessPayload{
+		Data:     storedValue,
+		Warnings: warnings,
+	})
 }
 
 func (al *APIListener) handleCommandUpdate(w http.ResponseWriter, req *http.Request) {
@@ -197,20 +248,26 @@ func (al *APIListener) handleCommandUpdate(w http.ResponseWriter, req *http.Requ
 		return
 	}
 
-	curUsername := api.GetUser(req.Context(), al.Logger)
-	if curUsername == "" {
-		al.jsonErrorResponseWithTitle(w, http.StatusUnauthorized, "unauthorized")
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
 		return
 	}
 
 	var commandInput command.InputCommand
-	err := parseRequestBody(req.Body, &commandInput)
+	err = parseRequestBody(req.Body, &commandInput)
 	if err != nil {
 		al.jsonError(w, err)
 		return
 	}
 
-	storedValue, err := al.commandManager.Update(req.Context(), idStr, &commandInput, curUsername)
+	warnings, err := al.secretScanner.Check(commandInput.Cmd)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	storedValue, err := al.commandManager.Update(req.Context(), idStr, &commandInput, curUser)
 	if err != nil {
 		al.jsonError(w, err)
 		return
@@ -223,7 +280,10 @@ func (al *APIListener) handleCommandUpdate(w http.ResponseWriter, req *http.Requ
 		WithID(idStr).
 		Save()
 
-	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(storedValue))
+	al.writeJSONResponse(w, http.StatusOK, &api.SuccessPayload{
+		Data:     storedValue,
+		Warnings: warnings,
+	})
 }
 
 func (al *APIListener) handleReadCommand(w http.ResponseWriter, req *http.Request) {
@@ -237,7 +297,13 @@ func (al *APIListener) handleReadCommand(w http.ResponseWriter, req *http.Reques
 		return
 	}
 
-	foundScript, found, err := al.commandManager.GetOne(req.Context(), req, idStr)
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	foundScript, found, err := al.commandManager.GetOne(req.Context(), req, idStr, curUser)
 	if err != nil {
 		al.jsonError(w, err)
 		return
@@ -261,7 +327,13 @@ func (al *APIListener) handleDeleteCommand(w http.ResponseWriter, req *http.Requ
 		return
 	}
 
-	err := al.commandManager.Delete(req.Context(), idStr)
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	err = al.commandManager.Delete(req.Context(), idStr, curUser)
 	if err != nil {
 		al.jsonError(w, err)
 		return
@@ -25,6 +25,8 @@ type twoFAResponse struct {
 type loginResponse struct {
 	Token *string        `json:"token"`  // null if 2fa is on
 	TwoFA *twoFAResponse `json:"two_fa"` // null if 2fa is off
+	// Features are returned with the login token only, not before the 2fa check
+	Features map[string]Feature `json:"features,omitempty"`
 }
 
 func (al *APIListener) handleGetLogin(w http.ResponseWriter, req *http.Request) {
@@ -63,7 +65,15 @@ func (al *APIListener) handleLogin(username, pwd string, newpwd string, skipPass
 		return
 	}
 
-	authorized, user, err := al.validateCredentials(username, pwd, skipPasswordValidation)
+	if al.isDecoyUser(req, username) {
+		al.bannedUsers.Add(username)
+		if al.handleBannedIPs(req, false) {
+			al.jsonErrorResponseWithTitle(w, http.StatusUnauthorized, "unauthorized")
+		}
+		return
+	}
+
+	authorized, user, err := al.validateCredentials(username, pwd, skipPasswordValidation, oauthProviderFromContext(req.Context()))
 	if err != nil {
 		al.jsonError(w, err)
 		return
@@ -74,7 +84,7 @@ func (al *APIListener) handleLogin(username, pwd string, newpwd string, skipPass
 	}
 
 	if !authorized {
-		al.bannedUsers.Add(username)
+		al.banUser(req, username)
 		al.jsonErrorResponseWithTitle(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
@@ -103,7 +113,14 @@ func (al *APIListener) handleLogin(username, pwd string, newpwd string, skipPass
 		return
 	}
 
-	if al.config.API.IsTwoFAOn() {
+	stepUp, err := al.checkLoginPolicy(req, user)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	twoFARequired := !al.config.LoginPolicy.TwoFAOnlyOnStepUp || stepUp
+
+	if al.config.API.IsTwoFAOn() && twoFARequired {
 		sendTo, err := al.twoFASrv.SendToken(req.Context(), username, req.UserAgent(), chshare.RemoteIP(req))
 		if err != nil {
 			al.jsonError(w, err)
@@ -136,7 +153,7 @@ func (al *APIListener) handleLogin(username, pwd string, newpwd string, skipPass
 		return
 	}
 
-	if al.config.API.TotPEnabled {
+	if al.config.API.TotPEnabled && twoFARequired {
 		al.twoFASrv.SetTotPLoginSession(username, al.config.API.TotPLoginSessionTimeout)
 
 		loginResp := loginResponse{
@@ -182,6 +199,11 @@ func (al *APIListener) handleLogin(username, pwd string, newpwd string, skipPass
 		return
 	}
 
+	if err := al.applySessionPolicy(req, username); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
 	// login token, normal
 	tokenStr, err := bearer.CreateAuthToken(
 		req.Context(),
@@ -198,8 +220,11 @@ func (al *APIListener) handleLogin(username, pwd string, newpwd string, skipPass
 		return
 	}
 
+	al.notifyNewLogin(req, username)
+
 	response := api.NewSuccessPayload(loginResponse{
-		Token: &tokenStr,
+		Token:    &tokenStr,
+		Features: al.features(),
 	})
 	al.writeJSONResponse(w, http.StatusOK, response)
 }
@@ -211,6 +236,11 @@ func (al *APIListener) sendJWTToken(username string, w http.ResponseWriter, req
 		return
 	}
 
+	if err := al.applySessionPolicy(req, username); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
 	// login token, after 2fa
 	tokenStr, err := bearer.CreateAuthToken(
 		req.Context(),
@@ -227,8 +257,11 @@ func (al *APIListener) sendJWTToken(username string, w http.ResponseWriter, req
 		return
 	}
 
+	al.notifyNewLogin(req, username)
+
 	response := api.NewSuccessPayload(loginResponse{
-		Token: &tokenStr,
+		Token:    &tokenStr,
+		Features: al.features(),
 	})
 	al.writeJSONResponse(w, http.StatusOK, response)
 }
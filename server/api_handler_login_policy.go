@@ -0,0 +1,65 @@
+package chserver
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/loginpolicy"
+	chshare "github.com/IOTech17/neo-rport/share"
+)
+
+// checkLoginPolicy must be called after the credentials of a login were verified. It returns an error if the login
+// is denied and whether a step-up with 2FA is required.
+func (al *APIListener) checkLoginPolicy(req *http.Request, user *users.User) (stepUp bool, err error) {
+	if al.loginPolicy == nil || al.sessionPolicies == nil {
+		return false, nil
+	}
+
+	devices, err := al.sessionPolicies.ListLoginDevices(req.Context(), user.Username)
+	if err != nil {
+		return false, err
+	}
+	previous := make([]loginpolicy.PreviousLogin, 0, len(devices))
+	for _, d := range devices {
+		previous = append(previous, loginpolicy.PreviousLogin{IP: d.IPAddress, Time: d.LastSeenAt})
+	}
+
+	decision, err := al.loginPolicy.Evaluate(loginpolicy.Login{
+		Username: user.Username,
+		Groups:   user.Groups,
+		IP:       chshare.RemoteIP(req),
+		Time:     time.Now(),
+	}, previous)
+	if err != nil {
+		al.Errorf("Failed to reload the anonymizer lists of the login policy: %v", err)
+	}
+
+	switch decision.Action {
+	case loginpolicy.ActionDeny:
+		al.Infof("Login of user %q from %s denied by the login policy: %s", user.Username, chshare.RemoteIP(req), strings.Join(decision.Reasons, ", "))
+		al.auditLog.Entry(auditlog.ApplicationAuthLoginPolicy, auditlog.ActionDeny).
+			WithHTTPRequest(req).
+			WithUsername(user.Username).
+			WithID(user.Username).
+			WithResponse(decision).
+			Save()
+		return false, errors2.APIError{
+			Message:    "login denied by the login policy, contact your administrator",
+			HTTPStatus: http.StatusForbidden,
+		}
+	case loginpolicy.ActionStepUp:
+		al.Infof("Login of user %q from %s requires a step-up: %s", user.Username, chshare.RemoteIP(req), strings.Join(decision.Reasons, ", "))
+		al.auditLog.Entry(auditlog.ApplicationAuthLoginPolicy, auditlog.ActionEscalate).
+			WithHTTPRequest(req).
+			WithUsername(user.Username).
+			WithID(user.Username).
+			WithResponse(decision).
+			Save()
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,53 @@
+package chserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/server/api/session"
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/loginpolicy"
+)
+
+func TestLoginPolicyDeniesNewCountry(t *testing.T) {
+	al := setupTestAPIListenerSessionPolicy(t, session.ConcurrentSessionsAllow, 0)
+
+	ipRanges := filepath.Join(t.TempDir(), "ranges.csv")
+	require.NoError(t, os.WriteFile(ipRanges, []byte("10.0.0.0,10.0.255.255,DE\n10.1.0.0,10.1.255.255,US\n"), 0600))
+	config := loginpolicy.Config{
+		IPRangesFile: ipRanges,
+		Rules: []loginpolicy.Rule{{
+			Groups:     []string{users.Administrators},
+			NewCountry: loginpolicy.ActionDeny,
+		}},
+	}
+	require.NoError(t, config.Validate())
+	var err error
+	al.loginPolicy, err = loginpolicy.New(config)
+	require.NoError(t, err)
+
+	loginFrom := func(ip string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/login", nil)
+		req.SetBasicAuth("admin", "pwd")
+		req.RemoteAddr = ip + ":40000"
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, loginFrom("10.0.0.1").Code)
+	assert.Equal(t, http.StatusOK, loginFrom("10.0.1.1").Code)
+
+	w := loginFrom("10.1.0.1")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "login denied by the login policy")
+
+	// the denied login isn't recorded as a previous login
+	assert.Equal(t, http.StatusForbidden, loginFrom("10.1.0.1").Code)
+}
@@ -20,6 +20,8 @@ import (
 	"github.com/IOTech17/neo-rport/server/api/users"
 	"github.com/IOTech17/neo-rport/server/bearer"
 	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/notifications"
+	"github.com/IOTech17/neo-rport/server/tripwire"
 	"github.com/IOTech17/neo-rport/share/ptr"
 	"github.com/IOTech17/neo-rport/share/random"
 	"github.com/IOTech17/neo-rport/share/security"
@@ -753,6 +755,7 @@ func TestHandleGetLogin(t *testing.T) {
 			assert.Equal(t, tc.ExpectedStatus, w.Code)
 			if tc.ExpectedStatus == http.StatusOK {
 				assert.Contains(t, w.Body.String(), `{"data":{"token":"`)
+				assert.Contains(t, w.Body.String(), `"features":{`)
 			}
 			if tc.CreateMissingUser {
 				assert.Equal(t, tc.HeaderAuthUser, mockUsersService.ChangeUser.Username)
@@ -762,3 +765,56 @@ func TestHandleGetLogin(t *testing.T) {
 		})
 	}
 }
+
+type fakeNotificationStore struct {
+	created []notifications.NotificationDetails
+}
+
+func (s *fakeNotificationStore) Create(ctx context.Context, details notifications.NotificationDetails) error {
+	s.created = append(s.created, details)
+	return nil
+}
+
+func TestLoginWithDecoyUser(t *testing.T) {
+	// the decoy exists with a valid password, it must be rejected anyway
+	decoy := &users.User{
+		Username: "svc-backup",
+		Password: "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm",
+		Groups:   []string{users.Administrators},
+	}
+	store := &fakeNotificationStore{}
+	tw := tripwire.New(tripwire.Config{
+		DecoyAPIUsers: []string{decoy.Username},
+		AlertEmails:   []string{"security@example.com"},
+	}, testLog, notifications.NewDispatcher(store))
+
+	al := APIListener{
+		Logger: testLog,
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+				},
+			},
+			tripwire: tw,
+		},
+		bannedUsers: security.NewBanList(0),
+		userService: users.NewAPIService(users.NewStaticProvider([]*users.User{decoy}), false, 0, -1),
+		apiSessions: newEmptyAPISessionCache(t),
+	}
+	al.initRouter()
+
+	for _, path := range []string{"/api/v1/login", "/api/v1/me"} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.SetBasicAuth(decoy.Username, "pwd")
+		al.router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code, path)
+	}
+
+	// the second attempt from the same ip is suppressed
+	require.Len(t, store.created, 1)
+	assert.Equal(t, []string{"security@example.com"}, store.created[0].Data.Recipients)
+	assert.Contains(t, store.created[0].Data.Content, `The decoy API user "svc-backup" was used`)
+}
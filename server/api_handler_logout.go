@@ -48,7 +48,7 @@ func (al *APIListener) handleDeleteLogout(w http.ResponseWriter, req *http.Reque
 		return
 	}
 	if !valid {
-		al.bannedUsers.Add(tokenCtx.AppClaims.Username)
+		al.banUser(req, tokenCtx.AppClaims.Username)
 		al.jsonErrorResponse(w, http.StatusBadRequest, fmt.Errorf("token is invalid or expired"))
 		return
 	}
@@ -1,6 +1,7 @@
 package chserver
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/IOTech17/neo-rport/server/api"
 	"github.com/IOTech17/neo-rport/server/api/authorization"
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
 	users "github.com/IOTech17/neo-rport/server/api/users"
 	"github.com/IOTech17/neo-rport/server/auditlog"
 	"github.com/IOTech17/neo-rport/server/routes"
@@ -17,6 +19,7 @@ import (
 	"github.com/IOTech17/neo-rport/share/logger"
 	"github.com/IOTech17/neo-rport/share/ptr"
 	"github.com/IOTech17/neo-rport/share/random"
+	"github.com/IOTech17/neo-rport/share/types"
 )
 
 // handleGetMe returns the currently logged-in user and the groups the user belongs to.
@@ -50,6 +53,7 @@ func (al *APIListener) handleGetMe(w http.ResponseWriter, req *http.Request) {
 		EffectiveUserPermissions:     eup,
 		EffectiveExtendedPermissions: eep,
 		GroupPermissionsEnabled:      al.userService.SupportsGroupPermissions(),
+		ImpersonatedBy:               api.GetImpersonator(req.Context()),
 	}
 	response := api.NewSuccessPayload(me)
 	al.writeJSONResponse(w, http.StatusOK, response)
@@ -243,6 +247,10 @@ func (al *APIListener) handleGetToken(w http.ResponseWriter, req *http.Request)
 		CreatedAt *time.Time                  `json:"created_at" db:"created_at"`
 		ExpiresAt *time.Time                  `json:"expires_at" db:"expires_at"`
 		Scope     authorization.APITokenScope `json:"scope" db:"scope"`
+		// set for derived and narrowed tokens only
+		ParentPrefix string             `json:"parent_prefix,omitempty"`
+		Permissions  *types.StringSlice `json:"permissions,omitempty"`
+		ClientGroups *types.StringSlice `json:"client_groups,omitempty"`
 	}
 
 	apitokenset, err := al.tokenManager.GetAll(req.Context(), user.Username)
@@ -255,11 +263,14 @@ func (al *APIListener) handleGetToken(w http.ResponseWriter, req *http.Request)
 	for _, at := range apitokenset {
 		apiTokenToSend = append(apiTokenToSend,
 			APITokenPayload{
-				Prefix:    at.Prefix,
-				Name:      at.Name,
-				CreatedAt: at.CreatedAt,
-				ExpiresAt: at.ExpiresAt,
-				Scope:     at.Scope,
+				Prefix:       at.Prefix,
+				Name:         at.Name,
+				CreatedAt:    at.CreatedAt,
+				ExpiresAt:    at.ExpiresAt,
+				Scope:        at.Scope,
+				ParentPrefix: at.ParentPrefix,
+				Permissions:  at.Permissions,
+				ClientGroups: at.ClientGroups,
 			})
 	}
 
@@ -278,9 +289,12 @@ func (al *APIListener) handlePostToken(w http.ResponseWriter, req *http.Request)
 		return
 	}
 	var r struct {
-		Scope     authorization.APITokenScope `json:"scope"`
-		Name      string                      `json:"name"`
-		ExpiresAt *time.Time                  `json:"expires_at"`
+		Scope        authorization.APITokenScope `json:"scope"`
+		Name         string                      `json:"name"`
+		ExpiresAt    *time.Time                  `json:"expires_at"`
+		ParentPrefix string                      `json:"parent_prefix"`
+		Permissions  *types.StringSlice          `json:"permissions"`
+		ClientGroups *types.StringSlice          `json:"client_groups"`
 	}
 	err = parseRequestBody(req.Body, &r)
 	if err != nil {
@@ -303,9 +317,23 @@ func (al *APIListener) handlePostToken(w http.ResponseWriter, req *http.Request)
 		return
 	}
 
+	if err := al.validateTokenRestrictions(req.Context(), r.Permissions, r.ClientGroups); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	parent, err := al.getParentToken(req.Context(), user.Username, r.ParentPrefix)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
 	createdAt := ptr.Time(time.Now().Truncate(time.Second).UTC())
 	if r.ExpiresAt == nil {
 		r.ExpiresAt = ptr.Time(createdAt.AddDate(1 /* year */, 0, 0)) // expiry date default is creation date + one year
+		if parent != nil && parent.ExpiresAt != nil && parent.ExpiresAt.Before(*r.ExpiresAt) {
+			r.ExpiresAt = parent.ExpiresAt
+		}
 	}
 
 	newTokenClear, err := random.UUID4()
@@ -322,13 +350,22 @@ func (al *APIListener) handlePostToken(w http.ResponseWriter, req *http.Request)
 	}
 
 	newAPIToken := &authorization.APIToken{
-		Username:  user.Username,
-		Prefix:    newPrefix,
-		Name:      r.Name,
-		Scope:     r.Scope,
-		CreatedAt: createdAt,
-		ExpiresAt: r.ExpiresAt,
-		Token:     tokenHashStr,
+		Username:     user.Username,
+		Prefix:       newPrefix,
+		Name:         r.Name,
+		Scope:        r.Scope,
+		CreatedAt:    createdAt,
+		ExpiresAt:    r.ExpiresAt,
+		Token:        tokenHashStr,
+		Permissions:  r.Permissions,
+		ClientGroups: r.ClientGroups,
+	}
+	if parent != nil {
+		if err := newAPIToken.ValidateDerived(parent); err != nil {
+			al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		newAPIToken.ParentPrefix = parent.Prefix
 	}
 	err = al.tokenManager.Create(req.Context(), newAPIToken)
 	if err != nil {
@@ -345,13 +382,82 @@ func (al *APIListener) handlePostToken(w http.ResponseWriter, req *http.Request)
 
 	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(
 		authorization.APIToken{
-			ExpiresAt: r.ExpiresAt,
-			Scope:     r.Scope,
-			Token:     fmt.Sprintf("%s_%s", newPrefix, newTokenClear),
-			Prefix:    newPrefix,
+			ExpiresAt:    r.ExpiresAt,
+			Scope:        r.Scope,
+			Token:        fmt.Sprintf("%s_%s", newPrefix, newTokenClear),
+			Prefix:       newPrefix,
+			ParentPrefix: newAPIToken.ParentPrefix,
+			Permissions:  newAPIToken.Permissions,
+			ClientGroups: newAPIToken.ClientGroups,
 		}))
 }
 
+// getParentToken returns the token a new token is derived from. It's the token the request is authenticated with,
+// if any, otherwise the token of the user with the given prefix. Nil is returned if the new token isn't derived.
+func (al *APIListener) getParentToken(ctx context.Context, username, parentPrefix string) (*authorization.APIToken, error) {
+	if current := authorization.TokenFromContext(ctx); current != nil {
+		if parentPrefix != "" && parentPrefix != current.Prefix {
+			return nil, errors2.APIError{
+				Message:    "a token can only be derived from the token the request is authenticated with",
+				HTTPStatus: http.StatusBadRequest,
+			}
+		}
+		return current, nil
+	}
+	if parentPrefix == "" {
+		return nil, nil
+	}
+
+	parent, err := al.tokenManager.Get(ctx, username, parentPrefix)
+	if err != nil {
+		return nil, err
+	}
+	if parent == nil {
+		return nil, errors2.APIError{
+			Message:    fmt.Sprintf("parent token %q not found", parentPrefix),
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+	return parent, nil
+}
+
+func (al *APIListener) validateTokenRestrictions(ctx context.Context, permissions, clientGroups *types.StringSlice) error {
+	if permissions != nil {
+		for _, p := range *permissions {
+			if !isKnownPermission(p) {
+				return errors2.APIError{
+					Message:    fmt.Sprintf("invalid permission %q", p),
+					HTTPStatus: http.StatusBadRequest,
+				}
+			}
+		}
+	}
+	if clientGroups != nil {
+		for _, id := range *clientGroups {
+			group, err := al.clientGroupProvider.Get(ctx, id)
+			if err != nil {
+				return err
+			}
+			if group == nil {
+				return errors2.APIError{
+					Message:    fmt.Sprintf("client group %q not found", id),
+					HTTPStatus: http.StatusBadRequest,
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func isKnownPermission(permission string) bool {
+	for _, p := range users.AllPermissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
 func (al *APIListener) handlePutToken(w http.ResponseWriter, req *http.Request) {
 	vars := mux.Vars(req)
 	prefix := vars[routes.ParamTokenPrefix]
@@ -1,19 +1,31 @@
 package chserver
 
 import (
+	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/IOTech17/neo-rport/db/migration/api_token"
+	"github.com/IOTech17/neo-rport/db/sqlite"
 	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/api/authorization"
 	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/cgroups"
 	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/clients"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
 	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/random"
+	"github.com/IOTech17/neo-rport/share/security"
 )
 
 // TestHandleMeStaticAuth verifies group permissions are not supported
@@ -54,6 +66,7 @@ func TestHandleMeStaticAuth(t *testing.T) {
 				"auditlog": true,
 				"commands": true,
 				"monitoring": true,
+				"quarantine": true,
 				"scheduler": true,
 				"scripts": true,
 				"tunnels": true,
@@ -140,6 +153,7 @@ func TestHandleMeDBAuth(t *testing.T) {
 				"auditlog": false,
 				"commands": false,
 				"monitoring": true,
+				"quarantine": false,
 				"scheduler": false,
 				"scripts": false,
 				"tunnels": false,
@@ -156,3 +170,138 @@ func TestHandleMeDBAuth(t *testing.T) {
 	assert.JSONEq(t, expectedJSON, w.Body.String())
 	t.Logf("response %d %s", w.Code, w.Body.String())
 }
+
+type staticClientGroupProvider struct {
+	cgroups.ClientGroupProvider
+	groups []*cgroups.ClientGroup
+}
+
+func (p staticClientGroupProvider) Get(ctx context.Context, id string) (*cgroups.ClientGroup, error) {
+	for _, g := range p.groups {
+		if g.ID == id {
+			return g, nil
+		}
+	}
+	return nil, nil
+}
+
+func (p staticClientGroupProvider) GetAll(ctx context.Context) ([]*cgroups.ClientGroup, error) {
+	return p.groups, nil
+}
+
+func TestDerivedTokens(t *testing.T) {
+	user := &users.User{
+		Username: "admin",
+		Password: "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm",
+		Groups:   []string{users.Administrators},
+	}
+	apiTokenDb, err := sqlite.New(":memory:", api_token.AssetNames(), api_token.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	defer apiTokenDb.Close()
+
+	prefixes := []string{"parent01", "child001", "child002", "groups01"}
+	oldAlphaNum := random.AlphaNum
+	random.AlphaNum = func(int) string {
+		prefix := prefixes[0]
+		prefixes = prefixes[1:]
+		return prefix
+	}
+	defer func() {
+		random.AlphaNum = oldAlphaNum
+	}()
+
+	kiosk := clients.New(t).ID("kiosk-1").Logger(testLog).Build()
+	office := clients.New(t).ID("office-1").Logger(testLog).Build()
+	al := &APIListener{
+		Logger:      testLog,
+		bannedUsers: security.NewBanList(0),
+		apiSessions: newEmptyAPISessionCache(t),
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+				},
+			},
+			clientService: clients.NewClientService(nil, nil, clients.NewClientRepository([]*clientdata.Client{kiosk, office}, nil, testLog), testLog, nil),
+			clientGroupProvider: staticClientGroupProvider{groups: []*cgroups.ClientGroup{
+				{ID: "kiosk", Params: &cgroups.ClientParams{ClientID: &cgroups.ParamValues{"kiosk-*"}}},
+				{ID: "office", Params: &cgroups.ClientParams{ClientID: &cgroups.ParamValues{"office-*"}}},
+			}},
+		},
+		tokenManager: authorization.NewManager(authorization.NewSqliteProvider(apiTokenDb)),
+		userService:  users.NewAPIService(users.NewStaticProvider([]*users.User{user}), false, 0, -1),
+	}
+	al.initRouter()
+
+	do := func(method, url, password, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req.SetBasicAuth("admin", password)
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+	createToken := func(password, body string) (string, *httptest.ResponseRecorder) {
+		w := do(http.MethodPost, "/api/v1/me/tokens", password, body)
+		var resp struct {
+			Data authorization.APIToken `json:"data"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.Data.Token, w
+	}
+
+	parentToken, w := createToken("pwd", `{"name":"parent","scope":"read+write","permissions":["tunnels","scripts"],"client_groups":["kiosk","office"]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	_, w = createToken(parentToken, `{"name":"child","scope":"read+write","permissions":["tunnels","commands"],"client_groups":["kiosk"]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `permission \"commands\" is not granted by the parent token`)
+
+	_, w = createToken(parentToken, `{"name":"child","scope":"read+write","permissions":["tunnels"],"client_groups":["unknown"]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `client group \"unknown\" not found`)
+
+	expiresAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	childToken, w := createToken(parentToken, `{"name":"child","scope":"read+write","permissions":["tunnels"],"client_groups":["kiosk"],"expires_at":"`+expiresAt+`"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"parent_prefix":"parent01"`)
+
+	w = do(http.MethodGet, "/api/v1/clients", childToken, "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "kiosk-1")
+	assert.NotContains(t, w.Body.String(), "office-1")
+
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/api/v1/clients/kiosk-1", childToken, "").Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/api/v1/clients/office-1", childToken, "").Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/api/v1/clients/office-1", parentToken, "").Code)
+
+	w = do(http.MethodPost, "/api/v1/clients/kiosk-1/scripts", childToken, `{}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), `the token is not allowed to use \"scripts\"`)
+
+	w = do(http.MethodGet, "/api/v2/clients/kiosk-1/commands", childToken, "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), `the token is not allowed to use \"commands\"`)
+
+	// routes without a declared permission are denied
+	w = do(http.MethodGet, "/api/v1/clients/kiosk-1/attributes", childToken, "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "a token restricted to some permissions or clients can't be used to access this resource")
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/api/v1/client-tags", childToken, "").Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/api/v1/me", childToken, "").Code)
+
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/api/v1/users", childToken, "").Code)
+
+	// tokens restricted to client groups only are checked against the declared routes too
+	groupsToken, w := createToken("pwd", `{"name":"groups","scope":"read+write","client_groups":["kiosk"]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/api/v1/clients/kiosk-1", groupsToken, "").Code)
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/api/v1/clients/kiosk-1/attributes", groupsToken, "").Code)
+	w = do(http.MethodGet, "/api/v1/auditlog", groupsToken, "")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "a token restricted to some clients can't be used to access this resource")
+	assert.Equal(t, http.StatusForbidden, do(http.MethodGet, "/api/v1/commands", groupsToken, "").Code)
+
+	w = do(http.MethodDelete, "/api/v1/me/tokens/parent01", "pwd", "")
+	require.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/api/v1/clients", childToken, "").Code)
+}
@@ -0,0 +1,223 @@
+package chserver
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	apierrors "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/cgroups"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/clients/clienttunnel"
+	"github.com/IOTech17/neo-rport/server/clients/meshtunnel"
+	"github.com/IOTech17/neo-rport/server/quotas"
+	"github.com/IOTech17/neo-rport/server/routes"
+)
+
+const ErrCodeMeshTunnelDirectUnavailable = "ERR_CODE_MESH_TUNNEL_DIRECT_UNAVAILABLE"
+
+type MeshTunnelRequest struct {
+	SourceClientID string `json:"source_client_id"`
+	TargetClientID string `json:"target_client_id"`
+	Local          string `json:"local"`
+	Remote         string `json:"remote"`
+	Mode           string `json:"mode"`
+}
+
+func (al *APIListener) handleGetMeshTunnels(w http.ResponseWriter, req *http.Request) {
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	clientGroups, err := al.clientGroupProvider.GetAll(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	tunnels := make([]*meshtunnel.MeshTunnel, 0)
+	for _, mt := range al.meshTunnels.List() {
+		if al.clientService.CheckClientAccess(mt.SourceClientID, curUser, clientGroups) != nil {
+			continue
+		}
+		if al.clientService.CheckClientAccess(mt.TargetClientID, curUser, clientGroups) != nil {
+			continue
+		}
+		tunnels = append(tunnels, mt)
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(tunnels))
+}
+
+func (al *APIListener) handlePostMeshTunnel(w http.ResponseWriter, req *http.Request) {
+	var meshReq MeshTunnelRequest
+	err := parseRequestBody(req.Body, &meshReq)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	mode, err := meshtunnel.ParseMode(meshReq.Mode)
+	if err != nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	mt, err := meshtunnel.New(meshReq.SourceClientID, meshReq.TargetClientID, meshReq.Local, meshReq.Remote, mode, curUser.Username)
+	if err != nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := al.checkUserQuota(req.Context(), curUser, quotas.ResourceTunnels); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	clientGroups, err := al.clientGroupProvider.GetAll(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	source, err := al.getMeshTunnelClient(mt.SourceClientID, curUser, clientGroups)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	target, err := al.getMeshTunnelClient(mt.TargetClientID, curUser, clientGroups)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	if err := al.checkMeshTunnelAccessSchedules(req, mt, source, target); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	allowed, err := clienttunnel.IsAllowed(mt.Remote, target.GetConnection(), al.Log())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if !allowed {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "Tunnel destination is not allowed by target client configuration.")
+		return
+	}
+
+	if mode != meshtunnel.ModeRelay {
+		mt.DirectAddr = al.findMeshDirectAddr(mt, source, target)
+		mt.Relayed = mt.DirectAddr == ""
+	}
+	if mode == meshtunnel.ModeDirect && mt.Relayed {
+		al.jsonErrorResponseWithErrCode(w, http.StatusConflict, ErrCodeMeshTunnelDirectUnavailable, "Target is not directly reachable from the source client.")
+		return
+	}
+
+	err = al.meshTunnels.Add(mt)
+	if err != nil {
+		al.jsonErrorResponseWithErrCode(w, http.StatusBadRequest, ErrCodeTunnelExist, err.Error())
+		return
+	}
+
+	err = al.startMeshTunnel(mt, source)
+	if err != nil {
+		al.meshTunnels.Delete(mt.ID)
+		al.jsonErrorResponseWithError(w, http.StatusConflict, "Failed to start mesh tunnel on the source client.", err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientMeshTunnel, auditlog.ActionCreate).
+		WithHTTPRequest(req).
+		WithClient(source).
+		WithID(mt.ID).
+		WithRequest(meshReq).
+		WithResponse(mt).
+		Save()
+
+	al.Debugf("Mesh tunnel %s from client %s to %s on client %s started (relayed: %t).", mt.ID, mt.SourceClientID, mt.Remote, mt.TargetClientID, mt.Relayed)
+
+	al.writeJSONResponse(w, http.StatusCreated, api.NewSuccessPayload(mt))
+}
+
+func (al *APIListener) handleDeleteMeshTunnel(w http.ResponseWriter, req *http.Request) {
+	id := mux.Vars(req)[routes.ParamMeshTunnelID]
+	mt := al.meshTunnels.Get(id)
+	if mt == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("mesh tunnel with id %s not found", id))
+		return
+	}
+
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	clientGroups, err := al.clientGroupProvider.GetAll(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	for _, clientID := range []string{mt.SourceClientID, mt.TargetClientID} {
+		err = al.clientService.CheckClientAccess(clientID, curUser, clientGroups)
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
+	}
+
+	err = al.stopMeshTunnel(mt)
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusConflict, "Failed to stop mesh tunnel on the source client.", err)
+		return
+	}
+	al.meshTunnels.Delete(mt.ID)
+
+	al.auditLog.Entry(auditlog.ApplicationClientMeshTunnel, auditlog.ActionDelete).
+		WithHTTPRequest(req).
+		WithClientID(mt.SourceClientID).
+		WithID(mt.ID).
+		Save()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getMeshTunnelClient returns an active client the current user has access to and new tunnels are allowed to.
+func (al *APIListener) getMeshTunnelClient(clientID string, curUser *users.User, clientGroups []*cgroups.ClientGroup) (*clientdata.Client, error) {
+	err := al.clientService.CheckClientAccess(clientID, curUser, clientGroups)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := al.clientService.GetActiveByID(clientID)
+	if err != nil {
+		return nil, apierrors.NewAPIError(http.StatusInternalServerError, "", "", err)
+	}
+	if client == nil || !client.IsConnected() {
+		return nil, apierrors.NewAPIError(http.StatusNotFound, "", fmt.Sprintf("active client with id %s not found", clientID), nil)
+	}
+	if client.IsPaused() {
+		return nil, apierrors.NewAPIError(http.StatusNotFound, "", fmt.Sprintf("client with id %s is paused (reason = %s)", clientID, client.GetPausedReason()), nil)
+	}
+	if quarantine := client.GetQuarantine(); quarantine != nil {
+		return nil, apierrors.NewAPIError(http.StatusForbidden, "", fmt.Sprintf("client with id %s is quarantined (reason = %s)", clientID, quarantine.Reason), nil)
+	}
+	if policy := al.config.Server.VersionPolicy; policy.DeniesTunnels(client.GetVersion()) {
+		return nil, apierrors.NewAPIError(http.StatusForbidden, "", fmt.Sprintf("version %s of client with id %s is older than the minimum version %s, new tunnels are denied", client.GetVersion(), clientID, policy.MinVersion), nil)
+	}
+	return client, nil
+}
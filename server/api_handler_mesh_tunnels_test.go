@@ -0,0 +1,40 @@
+package chserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/clients"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/clients/versionpolicy"
+	"github.com/IOTech17/neo-rport/share/test"
+)
+
+func TestGetMeshTunnelClient(t *testing.T) {
+	c1 := clients.New(t).ID("client-1").Logger(testLog).Build()
+	c1.SetConnection(test.NewConnMock())
+	admin := &users.User{Username: "admin", Groups: []string{users.Administrators}}
+	al := &APIListener{
+		Logger: testLog,
+		Server: &Server{
+			config:        &chconfig.Config{},
+			clientService: clients.NewClientService(nil, nil, clients.NewClientRepository([]*clientdata.Client{c1}, &hour, testLog), testLog, nil),
+		},
+	}
+
+	client, err := al.getMeshTunnelClient("client-1", admin, nil)
+	require.NoError(t, err)
+	assert.Equal(t, c1, client)
+
+	al.config.Server.VersionPolicy = versionpolicy.Policy{MinVersion: "0.9.0", Action: versionpolicy.ActionDenyTunnels}
+	_, err = al.getMeshTunnelClient("client-1", admin, nil)
+	assert.EqualError(t, err, "version 0.1.12 of client with id client-1 is older than the minimum version 0.9.0, new tunnels are denied")
+
+	al.config.Server.VersionPolicy.Action = versionpolicy.ActionWarn
+	_, err = al.getMeshTunnelClient("client-1", admin, nil)
+	assert.NoError(t, err)
+}
@@ -4,12 +4,16 @@ import (
 	"database/sql"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 
+	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/clients"
 	"github.com/IOTech17/neo-rport/server/monitoring"
 	"github.com/IOTech17/neo-rport/server/routes"
 	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/models"
 	"github.com/IOTech17/neo-rport/share/query"
 )
 
@@ -78,6 +82,10 @@ func (al *APIListener) handleGetClientGraphMetrics(w http.ResponseWriter, req *h
 	queryOptions := query.NewOptions(req, monitoring.ClientGraphMetricsSortDefault, monitoring.ClientGraphMetricsFilterDefault, monitoring.ClientGraphMetricsFieldsDefault)
 	requestInfo := query.ParseRequestInfo(req)
 
+	if err := client.CheckCapability(models.ClientCapabilityMonitoring); err != nil {
+		al.jsonError(w, err)
+		return
+	}
 	monitoringConfig := client.GetMonitoringConfig()
 	netLan := monitoringConfig.LanCard != nil
 	netWan := monitoringConfig.WanCard != nil
@@ -112,6 +120,10 @@ func (al *APIListener) handleGetClientGraphMetricsGraph(w http.ResponseWriter, r
 		return
 	}
 
+	if err := client.CheckCapability(models.ClientCapabilityMonitoring); err != nil {
+		al.jsonError(w, err)
+		return
+	}
 	monitoringConfig := client.GetMonitoringConfig()
 
 	payload, err := al.monitoringService.ListClientGraph(req.Context(), clientID, queryOptions, graph, monitoringConfig.LanCard, monitoringConfig.WanCard)
@@ -164,6 +176,108 @@ func (al *APIListener) handleGetClientMountpoints(w http.ResponseWriter, req *ht
 	al.writeJSONResponse(w, http.StatusOK, payload)
 }
 
+// handleGetClientDiskForecast handles GET /clients/{client_id}/disk-forecast
+func (al *APIListener) handleGetClientDiskForecast(w http.ResponseWriter, req *http.Request) {
+	clientID := mux.Vars(req)[routes.ParamClientID]
+
+	window := monitoring.DefaultForecastWindow
+	if v := req.URL.Query().Get("window"); v != "" {
+		var err error
+		if window, err = time.ParseDuration(v); err != nil || window <= 0 || window > monitoring.MaxQueryRange {
+			al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("Invalid window %q, expected a duration like 168h up to %s.", v, monitoring.MaxQueryRange))
+			return
+		}
+	}
+
+	forecasts, err := al.monitoringService.ForecastDiskUsage(req.Context(), clientID, window)
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, "Failed to forecast the disk usage.", err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(forecasts))
+}
+
+// handleQueryMeasures handles GET /measures/query
+func (al *APIListener) handleQueryMeasures(w http.ResponseWriter, req *http.Request) {
+	params := req.URL.Query()
+	mq, err := monitoring.ParseMeasureQuery(params.Get("q"))
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	var from, to time.Time
+	var step time.Duration
+	if v := params.Get("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			al.jsonErrorResponseWithError(w, http.StatusBadRequest, "Invalid from, expected RFC3339 time.", err)
+			return
+		}
+	}
+	if v := params.Get("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			al.jsonErrorResponseWithError(w, http.StatusBadRequest, "Invalid to, expected RFC3339 time.", err)
+			return
+		}
+	}
+	if v := params.Get("step"); v != "" {
+		if step, err = time.ParseDuration(v); err != nil {
+			al.jsonErrorResponseWithError(w, http.StatusBadRequest, "Invalid step, expected a duration like 5m.", err)
+			return
+		}
+	}
+	r, err := monitoring.NewQueryRange(from, to, step)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	// the clients are selected by the filters of GET /clients
+	filters := query.ParseFilterOptions(params)
+	if errs := query.ValidateFilterOptions(filters, clients.OptionsSupportedFilters); errs != nil {
+		al.jsonError(w, errs)
+		return
+	}
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	groups, err := al.clientGroupProvider.GetAll(req.Context())
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, "Failed to get client groups.", err)
+		return
+	}
+	userClients, err := al.clientService.GetFilteredUserClients(curUser, filters, groups)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	queryClients := make([]monitoring.QueryClient, 0, len(userClients))
+	for _, c := range userClients {
+		queryClients = append(queryClients, monitoring.QueryClient{
+			ID:     c.ID,
+			Tags:   c.GetTags(),
+			Labels: c.Labels,
+		})
+	}
+
+	series, err := al.monitoringService.QueryMeasures(req.Context(), mq, r, queryClients)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(monitoring.QueryResult{
+		Query:  params.Get("q"),
+		From:   r.From,
+		To:     r.To,
+		Step:   r.Step.String(),
+		Series: series,
+	}))
+}
+
 // handleMonitoringDisabled returns Not Found (404) when monitoring is disabled
 func (al *APIListener) handleMonitoringDisabled(w http.ResponseWriter, req *http.Request) {
 	al.jsonErrorResponseWithTitle(w, http.StatusNotFound, "monitoring disabled. re-enable to view monitoring statistics.")
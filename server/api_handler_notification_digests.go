@@ -0,0 +1,76 @@
+package chserver
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/notifications"
+	"github.com/IOTech17/neo-rport/server/routes"
+)
+
+func (al *APIListener) handleListNotificationDigests(w http.ResponseWriter, req *http.Request) {
+	settings, err := al.notificationDigestStore.ListDigestSettings(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if settings == nil {
+		settings = []notifications.DigestSettings{}
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(settings))
+}
+
+func (al *APIListener) handlePutNotificationDigest(w http.ResponseWriter, req *http.Request) {
+	recipient := mux.Vars(req)[routes.ParamRecipient]
+
+	var settings notifications.DigestSettings
+	if err := parseRequestBody(req.Body, &settings); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	settings.Recipient = recipient
+	if err := settings.Validate(); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	if err := al.notificationDigestStore.SaveDigestSettings(req.Context(), settings); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationNotificationDigest, auditlog.ActionUpdate).
+		WithHTTPRequest(req).
+		WithID(recipient).
+		WithRequest(settings).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(settings))
+}
+
+// handleDeleteNotificationDigest sends the pending notifications of the recipient before the settings are deleted,
+// all later notifications are sent immediately.
+func (al *APIListener) handleDeleteNotificationDigest(w http.ResponseWriter, req *http.Request) {
+	recipient := mux.Vars(req)[routes.ParamRecipient]
+	ctx := req.Context()
+
+	if err := al.notificationDigests.SendNow(ctx, recipient); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if err := al.notificationDigestStore.DeleteDigestSettings(ctx, recipient); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationNotificationDigest, auditlog.ActionDelete).
+		WithHTTPRequest(req).
+		WithID(recipient).
+		Save()
+
+	w.WriteHeader(http.StatusNoContent)
+}
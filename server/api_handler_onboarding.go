@@ -0,0 +1,327 @@
+package chserver
+
+import (
+	"bytes"
+	"crypto/md5"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/discovery"
+	"github.com/IOTech17/neo-rport/server/installers"
+	"github.com/IOTech17/neo-rport/server/routes"
+	"github.com/IOTech17/neo-rport/share/files"
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+// OnboardingSuggestion is a discovered device that isn't managed by rport yet.
+type OnboardingSuggestion struct {
+	*discovery.Device
+	OS string `json:"os"`
+	// Method the installer can be pushed with by the client that found the device, empty if it must be installed
+	// manually
+	Method          string `json:"method"`
+	InstallerFormat string `json:"installer_format"`
+}
+
+type onboardingRequest struct {
+	IP string `json:"ip"`
+	// VaultValueID is the password or private key of the user
+	VaultValueID int               `json:"vault_value_id"`
+	Username     string            `json:"username"`
+	ClientAuthID string            `json:"client_auth_id"`
+	Tags         []string          `json:"tags"`
+	Labels       map[string]string `json:"labels"`
+}
+
+type onboardingResponse struct {
+	IP           string `json:"ip"`
+	Method       string `json:"method"`
+	ClientAuthID string `json:"client_auth_id"`
+	// JobID is the job of the client that found the device, running the installer on it
+	JobID string `json:"job_id"`
+}
+
+// handleGetClientOnboardingSuggestions handles GET /clients/{client_id}/onboarding-suggestions, it suggests the
+// devices found by the client whose operating system is known and that no client runs on.
+func (al *APIListener) handleGetClientOnboardingSuggestions(w http.ResponseWriter, req *http.Request) {
+	clientID := mux.Vars(req)[routes.ParamClientID]
+
+	devices, err := al.discovery.ListDevices(req.Context(), clientID)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	managed := al.managedIPs()
+	suggestions := []*OnboardingSuggestion{}
+	for _, device := range devices {
+		if managed[device.IP] {
+			continue
+		}
+		if suggestion := newOnboardingSuggestion(device); suggestion != nil {
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(suggestions))
+}
+
+func newOnboardingSuggestion(device *discovery.Device) *OnboardingSuggestion {
+	suggestion := &OnboardingSuggestion{
+		Device: device,
+		OS:     device.GuessOS(),
+		Method: device.InstallMethod(),
+	}
+	switch suggestion.OS {
+	case discovery.OSLinux:
+		suggestion.InstallerFormat = installers.FormatShell
+	case discovery.OSWindows:
+		suggestion.InstallerFormat = installers.FormatPowerShell
+	default:
+		return nil
+	}
+	return suggestion
+}
+
+// managedIPs returns the addresses of all clients.
+func (al *APIListener) managedIPs() map[string]bool {
+	ips := make(map[string]bool)
+	for _, c := range al.clientService.GetAll() {
+		for _, ip := range c.GetIPv4() {
+			ips[ip] = true
+		}
+	}
+	return ips
+}
+
+// handlePostClientOnboarding handles POST /clients/{client_id}/onboarding, it installs the rport client on a device
+// found by the client. The client connects to the device by ssh or WinRM with the credentials from the vault and
+// runs an installer with new client credentials on it.
+func (al *APIListener) handlePostClientOnboarding(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	clientID := mux.Vars(req)[routes.ParamClientID]
+
+	var onboardingReq onboardingRequest
+	if err := parseRequestBody(req.Body, &onboardingReq); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if net.ParseIP(onboardingReq.IP) == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("invalid ip %q", onboardingReq.IP))
+		return
+	}
+	if onboardingReq.Username == "" || onboardingReq.VaultValueID <= 0 {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "username and vault_value_id are required")
+		return
+	}
+	if err := installers.ValidateLabels(onboardingReq.Labels); err != nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if al.config.Server.InstallerDownloadURL == "" {
+		al.jsonErrorResponseWithDetail(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Installer scripts are not available.", "Set 'installer_download_url' in the [server] section of the rportd config.")
+		return
+	}
+
+	client, err := al.clientService.GetActiveByID(clientID)
+	if err != nil {
+		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if client == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("client with id %s not found", clientID))
+		return
+	}
+
+	suggestion, err := al.getOnboardingSuggestion(req, client, onboardingReq.IP)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	curUser, err := al.getUserModelForAuth(ctx)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	credential, found, err := al.vaultManager.GetOne(ctx, onboardingReq.VaultValueID, curUser)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if !found {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("vault value with id %d not found", onboardingReq.VaultValueID))
+		return
+	}
+	pushOpts := installers.PushOptions{
+		Host:     suggestion.IP,
+		Username: onboardingReq.Username,
+		UseSSL:   !suggestion.OpenPorts.Contains(discovery.PortWinRM),
+	}
+	if strings.Contains(credential.Value, "PRIVATE KEY-----") {
+		pushOpts.PrivateKey = credential.Value
+	} else {
+		pushOpts.Password = credential.Value
+	}
+
+	clientAuth, created, ok := al.getOrCreateInstallerClientAuth(w, onboardingReq.ClientAuthID)
+	if !ok {
+		return
+	}
+	if created {
+		al.auditLog.Entry(auditlog.ApplicationClientAuth, auditlog.ActionCreate).
+			WithHTTPRequest(req).
+			WithID(clientAuth.ID).
+			Save()
+	}
+
+	installer, err := installers.Generate(suggestion.InstallerFormat, al.installerOptions(clientAuth, onboardingReq.Tags, onboardingReq.Labels))
+	if err != nil {
+		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	pushOpts.Installer = installer.Content
+	push, err := installers.GeneratePush(suggestion.Method, pushOpts)
+	if err != nil {
+		al.jsonError(w, errors2.APIError{
+			HTTPStatus: http.StatusBadRequest,
+			Err:        err,
+		})
+		return
+	}
+
+	jid, err := al.pushOnboardingScript(client, suggestion.Method, push, curUser.Username)
+	if err != nil {
+		al.jsonError(w, errors2.APIError{
+			HTTPStatus: http.StatusConflict,
+			Err:        err,
+		})
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientOnboarding, auditlog.ActionCreate).
+		WithHTTPRequest(req).
+		WithClient(client).
+		WithID(jid).
+		WithRequest(map[string]interface{}{
+			"ip":             suggestion.IP,
+			"method":         suggestion.Method,
+			"username":       onboardingReq.Username,
+			"vault_value_id": onboardingReq.VaultValueID,
+			"client_auth_id": clientAuth.ID,
+		}).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusAccepted, api.NewSuccessPayload(onboardingResponse{
+		IP:           suggestion.IP,
+		Method:       suggestion.Method,
+		ClientAuthID: clientAuth.ID,
+		JobID:        jid,
+	}))
+}
+
+func (al *APIListener) getOnboardingSuggestion(req *http.Request, client *clientdata.Client, ip string) (*OnboardingSuggestion, error) {
+	devices, err := al.discovery.ListDevices(req.Context(), client.GetID())
+	if err != nil {
+		return nil, err
+	}
+	var device *discovery.Device
+	for _, d := range devices {
+		if d.IP == ip {
+			device = d
+		}
+	}
+	if device == nil {
+		return nil, errors2.APIError{
+			HTTPStatus: http.StatusNotFound,
+			Message:    fmt.Sprintf("device %s was not discovered by client %s", ip, client.GetID()),
+		}
+	}
+	if al.managedIPs()[ip] {
+		return nil, errors2.APIError{
+			HTTPStatus: http.StatusConflict,
+			Message:    fmt.Sprintf("a client is already running on %s", ip),
+		}
+	}
+
+	suggestion := newOnboardingSuggestion(device)
+	if suggestion == nil || suggestion.Method == "" {
+		return nil, errors2.APIError{
+			HTTPStatus: http.StatusConflict,
+			Message:    fmt.Sprintf("no ssh or WinRM port of %s was found open, the installer can't be pushed", ip),
+		}
+	}
+	clientWindows := client.GetOSKernel() == "windows"
+	if (suggestion.Method == discovery.MethodWinRM) != clientWindows {
+		return nil, errors2.APIError{
+			HTTPStatus: http.StatusConflict,
+			Message:    fmt.Sprintf("client %s can't push installers by %s, WinRM requires a windows client, ssh a linux or macOS client", client.GetID(), suggestion.Method),
+		}
+	}
+	return suggestion, nil
+}
+
+// pushOnboardingScript pushes the script with the credentials as file to the data directory of the client, so they
+// are not stored with the job, and starts a job running it. The script deletes itself.
+func (al *APIListener) pushOnboardingScript(client *clientdata.Client, method string, push *installers.Installer, createdBy string) (string, error) {
+	dataDir := client.GetDataDir()
+	if dataDir == "" {
+		return "", fmt.Errorf("client %s did not report its data directory", client.GetID())
+	}
+
+	id := uuid.New().String()
+	if _, err := al.filesAPI.CreateDirIfNotExists(al.config.GetUploadDir(), files.DefaultMode); err != nil {
+		return "", err
+	}
+	sourcePath := al.genFilePath(id)
+	if _, err := al.filesAPI.CreateFile(sourcePath, bytes.NewReader(push.Content)); err != nil {
+		return "", err
+	}
+	md5Checksum := md5.Sum(push.Content) //nolint:gosec
+
+	var destination, command string
+	if method == discovery.MethodWinRM {
+		destination = strings.TrimRight(dataDir, `\`) + `\rport-onboarding-` + id + ".ps1"
+		command = `powershell.exe -NoProfile -NonInteractive -ExecutionPolicy Bypass -File "` + destination + `"`
+	} else {
+		destination = strings.TrimRight(dataDir, "/") + "/rport-onboarding-" + id + ".sh"
+		command = "sh '" + destination + "'"
+	}
+
+	results := al.sendFileToClients(&UploadRequest{
+		Clients: []*clientdata.Client{client},
+		UploadedFile: &models.UploadedFile{
+			ID:                  id,
+			SourceFilePath:      sourcePath,
+			DestinationPath:     destination,
+			DestinationFileMode: 0700,
+			ForceWrite:          true,
+			Md5Checksum:         md5Checksum[:],
+		},
+	})
+	if len(results) == 0 {
+		return "", fmt.Errorf("failed to push the installer to client %s", client.GetID())
+	}
+	if results[0].err != nil {
+		return "", fmt.Errorf("failed to push the installer to client %s: %v", client.GetID(), results[0].err)
+	}
+
+	jid, err := generateNewJobID()
+	if err != nil {
+		return "", err
+	}
+	err = al.createAndRunJob(nil, nil, jid, command, "", createdBy, "", al.config.Server.RunRemoteCmdTimeoutSec, false, false, nil, "", nil, client)
+	if err != nil {
+		return "", fmt.Errorf("failed to run the installer on client %s: %v", client.GetID(), err)
+	}
+	return jid, nil
+}
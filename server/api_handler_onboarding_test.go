@@ -0,0 +1,84 @@
+package chserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	discoverymigration "github.com/IOTech17/neo-rport/db/migration/discovery"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/clients"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/discovery"
+	"github.com/IOTech17/neo-rport/share/security"
+)
+
+func TestClientOnboardingSuggestions(t *testing.T) {
+	db, err := sqlite.New(":memory:", discoverymigration.AssetNames(), discoverymigration.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	defer db.Close()
+
+	c1 := clients.New(t).ID("client-1").Logger(testLog).Build()
+	c2 := clients.New(t).ID("client-2").Logger(testLog).Build()
+	c2.IPv4 = []string{"192.168.1.12"}
+	provider := discovery.NewSqliteProvider(db)
+	scan := &discovery.Scan{ID: "scan-1", ClientID: "client-1", Status: discovery.ScanStatusFinished, CreatedAt: time.Now()}
+	require.NoError(t, provider.SaveScan(context.Background(), scan))
+	require.NoError(t, provider.FinishScan(context.Background(), scan, []*discovery.Device{
+		{ClientID: "client-1", IP: "192.168.1.10", OpenPorts: discovery.Ports{22}, LastScanID: "scan-1"},
+		{ClientID: "client-1", IP: "192.168.1.11", OpenPorts: discovery.Ports{445, 5985}, LastScanID: "scan-1"},
+		{ClientID: "client-1", IP: "192.168.1.12", OpenPorts: discovery.Ports{22}, LastScanID: "scan-1"},
+		{ClientID: "client-1", IP: "192.168.1.13", OpenPorts: discovery.Ports{3389}, LastScanID: "scan-1"},
+		{ClientID: "client-1", IP: "192.168.1.20", OpenPorts: discovery.Ports{9100}, LastScanID: "scan-1"},
+	}))
+
+	al := &APIListener{
+		Logger:      testLog,
+		bannedUsers: security.NewBanList(0),
+		apiSessions: newEmptyAPISessionCache(t),
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+				},
+			},
+			clientService:       clients.NewClientService(nil, nil, clients.NewClientRepository([]*clientdata.Client{c1, c2}, &hour, testLog), testLog, nil),
+			discovery:           provider,
+			clientGroupProvider: staticClientGroupProvider{},
+		},
+		userService: users.NewAPIService(users.NewStaticProvider([]*users.User{
+			{Username: "admin", Password: "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm", Groups: []string{users.Administrators}},
+		}), false, 0, -1),
+	}
+	al.initRouter()
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/clients/client-1/onboarding-suggestions", nil)
+	req.SetBasicAuth("admin", "pwd")
+	al.router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var res struct {
+		Data []*OnboardingSuggestion `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	require.Len(t, res.Data, 3)
+	assert.Equal(t, "192.168.1.10", res.Data[0].IP)
+	assert.Equal(t, discovery.OSLinux, res.Data[0].OS)
+	assert.Equal(t, discovery.MethodSSH, res.Data[0].Method)
+	assert.Equal(t, "sh", res.Data[0].InstallerFormat)
+	assert.Equal(t, "192.168.1.11", res.Data[1].IP)
+	assert.Equal(t, discovery.OSWindows, res.Data[1].OS)
+	assert.Equal(t, discovery.MethodWinRM, res.Data[1].Method)
+	assert.Equal(t, "ps1", res.Data[1].InstallerFormat)
+	assert.Equal(t, "192.168.1.13", res.Data[2].IP)
+	assert.Equal(t, "", res.Data[2].Method)
+}
@@ -2,8 +2,11 @@ package chserver
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
 	rportplus "github.com/IOTech17/neo-rport/plus"
+	"github.com/IOTech17/neo-rport/plus/capabilities/oauth"
 	"github.com/IOTech17/neo-rport/plus/capabilities/status"
 	"github.com/IOTech17/neo-rport/server/api"
 )
@@ -18,11 +21,12 @@ func (al *APIListener) handleOAuthAuthorizationCode(w http.ResponseWriter, r *ht
 		return
 	}
 
-	capEx := plusManager.GetOAuthCapabilityEx()
-	if capEx == nil {
-		al.jsonErrorResponse(w, http.StatusForbidden, rportplus.ErrCapabilityNotAvailable(rportplus.PlusOAuthCapability))
+	provider, err := al.oauthProviderFromRoute(r)
+	if err != nil {
+		al.jsonError(w, err)
 		return
 	}
+	capEx := provider.capEx
 
 	// if IDToken in use then possible that a permitted username
 	// will be returned after the auth code exchange. if the user
@@ -45,7 +49,7 @@ func (al *APIListener) handleOAuthAuthorizationCode(w http.ResponseWriter, r *ht
 
 	// pass the username to the existing login logic to create the user (if required) and
 	// get an rport-plus bearer token.
-	al.handleLogin(username, "", "", true /* skipPasswordValidation */, w, r)
+	al.handleLogin(username, "", "", true /* skipPasswordValidation */, w, r.WithContext(withOAuthProvider(r.Context(), provider.config)))
 }
 
 // handleGetDeviceAuth will return an RPort JWT token if the user has completed authorization
@@ -60,11 +64,29 @@ func (al *APIListener) handleGetDeviceAuth(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	capEx := plusManager.GetOAuthCapabilityEx()
-	if capEx == nil {
-		al.jsonErrorResponse(w, http.StatusForbidden, rportplus.ErrCapabilityNotAvailable(rportplus.PlusOAuthCapability))
+	provider, err := al.oauthProviderFromRoute(r)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	capEx := provider.capEx
+
+	if err := devicePollFormToQuery(r); err != nil {
+		al.jsonErrorResponse(w, http.StatusBadRequest, err)
 		return
 	}
+	deviceCode := r.URL.Query().Get("device_code")
+	pollKey := provider.config.GetName() + "/" + deviceCode
+	if deviceCode != "" {
+		if ok, interval := al.devicePolls.Poll(pollKey, provider.config.GetDevicePollInterval(DefaultDevicePollInterval)); !ok {
+			al.writeDeviceAuthError(w, &oauth.DeviceAuthStatusErrorInfo{
+				StatusCode:   http.StatusBadRequest,
+				ErrorCode:    deviceErrSlowDown,
+				ErrorMessage: "polled too fast, wait for the interval before polling again",
+			}, interval)
+			return
+		}
+	}
 
 	token, username, errInfo, err := capEx.GetAccessTokenForDevice(r)
 	if err != nil {
@@ -72,10 +94,21 @@ func (al *APIListener) handleGetDeviceAuth(w http.ResponseWriter, r *http.Reques
 			// error handling for the OAuth device flow is a little strange.
 			// pending and slow_down errors aren't really errors. Also
 			// the different providers seems to sometimes report errors via
-			// the statusCode and sometimes not. Do the best that we can
-			// here.
-			response := api.NewSuccessPayload(errInfo)
-			al.writeJSONResponse(w, errInfo.StatusCode, response)
+			// the statusCode and sometimes not. The pending and slow_down
+			// errors are returned with status 400 as of RFC 8628 together
+			// with the interval to wait.
+			var interval time.Duration
+			switch errInfo.ErrorCode {
+			case deviceErrAuthorizationPending:
+				errInfo.StatusCode = http.StatusBadRequest
+				interval = al.devicePolls.Interval(pollKey)
+			case deviceErrSlowDown:
+				errInfo.StatusCode = http.StatusBadRequest
+				interval = al.devicePolls.SlowDown(pollKey)
+			default:
+				al.devicePolls.Done(pollKey)
+			}
+			al.writeDeviceAuthError(w, errInfo, interval)
 		} else {
 			// i think internal server error is ok as the providers really
 			// should have returned the relevant errInfo.
@@ -84,6 +117,8 @@ func (al *APIListener) handleGetDeviceAuth(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	al.devicePolls.Done(pollKey)
+
 	// if no previous err and an empty username then attempt to get
 	// a permitted username from the oauth/identity provider
 	if username == "" {
@@ -96,7 +131,38 @@ func (al *APIListener) handleGetDeviceAuth(w http.ResponseWriter, r *http.Reques
 
 	// pass the username to the existing login logic to create the user (if required) and
 	// get an rport JWT bearer token.
-	al.handleLogin(username, "", "", true /* skipPasswordValidation */, w, r)
+	al.handleLogin(username, "", "", true /* skipPasswordValidation */, w, r.WithContext(withOAuthProvider(r.Context(), provider.config)))
+}
+
+// writeDeviceAuthError writes the error of a device flow poll, a retryable error includes the interval to wait.
+func (al *APIListener) writeDeviceAuthError(w http.ResponseWriter, errInfo *oauth.DeviceAuthStatusErrorInfo, interval time.Duration) {
+	if interval > 0 {
+		errInfo.Interval = int(interval.Seconds())
+		w.Header().Set("Retry-After", strconv.Itoa(errInfo.Interval))
+	}
+	statusCode := errInfo.StatusCode
+	if statusCode < http.StatusBadRequest {
+		statusCode = http.StatusBadRequest
+	}
+	al.writeJSONResponse(w, statusCode, api.NewSuccessPayload(errInfo))
+}
+
+// devicePollFormToQuery adds the form values of a POST poll to the query, the OAuth capability reads the query.
+func devicePollFormToQuery(r *http.Request) error {
+	if r.Method != http.MethodPost {
+		return nil
+	}
+	if err := r.ParseForm(); err != nil {
+		return err
+	}
+	query := r.URL.Query()
+	for key, values := range r.PostForm {
+		if query.Get(key) == "" && len(values) > 0 {
+			query.Set(key, values[0])
+		}
+	}
+	r.URL.RawQuery = query.Encode()
+	return nil
 }
 
 // handlePlusStatus makes a request to the plugin for it's status/version info
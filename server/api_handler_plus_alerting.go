@@ -16,6 +16,7 @@ import (
 	"github.com/IOTech17/neo-rport/plus/capabilities/alerting/entities/rules"
 	"github.com/IOTech17/neo-rport/plus/capabilities/alerting/entities/templates"
 	"github.com/IOTech17/neo-rport/server/api"
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
 	"github.com/IOTech17/neo-rport/server/routes"
 	"github.com/IOTech17/neo-rport/share/query"
 )
@@ -106,8 +107,7 @@ func (al *APIListener) handleSaveRuleSet(w http.ResponseWriter, r *http.Request)
 	errs, err := as.SaveRuleSet(rs)
 	if err != nil {
 		if errs != nil {
-			errPayload := makeValidationErrorPayload(errs)
-			al.writeJSONResponse(w, http.StatusBadRequest, errPayload)
+			al.jsonError(w, makeValidationAPIErrors(errs))
 			return
 		}
 		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
@@ -123,20 +123,16 @@ func (al *APIListener) handleSaveRuleSet(w http.ResponseWriter, r *http.Request)
 	al.Debugf("saved ruleset = %v", rs)
 }
 
-func makeValidationErrorPayload(errs validations.ErrorList) *api.ErrorPayload {
-	validationErrs := []api.ErrorPayloadItem{}
+func makeValidationAPIErrors(errs validations.ErrorList) errors2.APIErrors {
+	validationErrs := make(errors2.APIErrors, 0, len(errs))
 	for _, validationErr := range errs {
-		vErr := api.ErrorPayloadItem{
-			Code:   "",
-			Title:  "error during rule set validation",
-			Detail: fmt.Sprintf("%s: %s", validationErr.Prefix, validationErr.Err.Error()),
-		}
-		validationErrs = append(validationErrs, vErr)
-	}
-	errPayload := &api.ErrorPayload{
-		Errors: validationErrs,
+		validationErrs = append(validationErrs, errors2.APIError{
+			Message:    "error during rule set validation",
+			Err:        fmt.Errorf("%s: %w", validationErr.Prefix, validationErr.Err),
+			HTTPStatus: http.StatusBadRequest,
+		})
 	}
-	return errPayload
+	return validationErrs
 }
 
 func (al *APIListener) handleSaveTemplate(w http.ResponseWriter, r *http.Request) {
@@ -181,8 +177,7 @@ func (al *APIListener) handleSaveTemplate(w http.ResponseWriter, r *http.Request
 	errs, err := as.SaveTemplate(template)
 	if err != nil {
 		if errs != nil {
-			errPayload := makeValidationErrorPayload(errs)
-			al.writeJSONResponse(w, http.StatusBadRequest, errPayload)
+			al.jsonError(w, makeValidationAPIErrors(errs))
 			return
 		}
 		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
@@ -322,6 +317,7 @@ func (al *APIListener) handleUpdateProblem(w http.ResponseWriter, r *http.Reques
 			return
 		}
 	}
+	al.recordProblemUpdate(r, as, problemID, problemUpdateRequest.Active)
 	al.Debugf("updated problem = %v", problemUpdateRequest)
 }
 
@@ -391,3 +387,12 @@ func (al *APIListener) handleGetLatestProblems(w http.ResponseWriter, req *http.
 
 	al.writeJSONResponse(w, http.StatusOK, response)
 }
+
+func (al *APIListener) handleGetFlappingAlerts(w http.ResponseWriter, r *http.Request) {
+	flapping := []alerts.FlappingAlert{}
+	if al.alertSuppressor != nil {
+		flapping = al.alertSuppressor.Flapping()
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(flapping))
+}
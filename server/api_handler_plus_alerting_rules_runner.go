@@ -48,8 +48,7 @@ func (al *APIListener) handleTestRules(w http.ResponseWriter, r *http.Request) {
 
 	if err != nil {
 		if errs != nil {
-			errPayload := makeValidationErrorPayload(errs)
-			al.writeJSONResponse(w, http.StatusBadRequest, errPayload)
+			al.jsonError(w, makeValidationAPIErrors(errs))
 			return
 		}
 		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
@@ -11,12 +11,15 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	alertsmigration "github.com/IOTech17/neo-rport/db/migration/alerts"
+	"github.com/IOTech17/neo-rport/db/sqlite"
 	rportplus "github.com/IOTech17/neo-rport/plus"
 	alertingcap "github.com/IOTech17/neo-rport/plus/capabilities/alerting"
 	"github.com/IOTech17/neo-rport/plus/capabilities/alerting/alertingmock"
 	"github.com/IOTech17/neo-rport/plus/capabilities/alerting/entities/rules"
 	"github.com/IOTech17/neo-rport/plus/capabilities/alerting/entities/rundata"
 	"github.com/IOTech17/neo-rport/plus/capabilities/alerting/entities/templates"
+	"github.com/IOTech17/neo-rport/server/alerts"
 	"github.com/IOTech17/neo-rport/server/api/authorization"
 	"github.com/IOTech17/neo-rport/server/api/users"
 	"github.com/IOTech17/neo-rport/server/chconfig"
@@ -115,6 +118,10 @@ func setupTestAPIListenerForAlerting(
 		plusConfig = &rportplus.PlusConfig{}
 	}
 
+	alertsDB, err := sqlite.New(":memory:", alertsmigration.AssetNames(), alertsmigration.Asset, sqlite.DataSourceOptions{})
+	require.NoError(t, err)
+	t.Cleanup(func() { alertsDB.Close() })
+
 	al = &APIListener{
 		insecureForTests: true,
 		Server: &Server{
@@ -124,7 +131,8 @@ func setupTestAPIListenerForAlerting(
 				},
 				PlusConfig: *plusConfig,
 			},
-			plusManager: plusManager,
+			plusManager:    plusManager,
+			problemHistory: alerts.NewProblemHistory(alertsDB),
 		},
 		Logger:       plusLog,
 		tokenManager: mockTokenManager,
@@ -447,6 +455,13 @@ func TestShouldSaveProblemResolved(t *testing.T) {
 
 	assert.Equal(t, rules.ProblemID("p1"), savedProblem.ID)
 	assert.Equal(t, false, savedProblem.Active)
+
+	record, err := al.problemHistory.Get(req.Context(), "p1")
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.Equal(t, alerts.ProblemStateResolved, record.State)
+	require.Len(t, record.Events, 2)
+	assert.Equal(t, alerts.ProblemEventResolved, record.Events[1].Event)
 }
 
 func getProblemsInfo(t *testing.T, w *httptest.ResponseRecorder) (problemsInfo ProblemsResponse) {
@@ -5,7 +5,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -447,8 +449,249 @@ func setupTestAPIListenerForOAuth(
 		bannedUsers:  security.NewBanList(0),
 		userService:  mockUsersService,
 		apiSessions:  newEmptyAPISessionCache(t),
+		devicePolls:  newDevicePolls(),
 	}
 	al.initRouter()
 
 	return al, mockUsersService
 }
+
+type plusManagerForMockOAuthProviders struct {
+	caps map[string]rportplus.Capability
+
+	rportplus.ManagerProvider
+}
+
+func (pm *plusManagerForMockOAuthProviders) RegisterCapability(capName string, newCap rportplus.Capability) (cap rportplus.Capability, err error) {
+	newCap.InitProvider(nil)
+	pm.caps[capName] = newCap
+	return newCap, nil
+}
+
+func (pm *plusManagerForMockOAuthProviders) GetOAuthCapabilityEx() (capEx oauth.CapabilityEx) {
+	return pm.caps[rportplus.PlusOAuthCapability].(*oauthmock.Capability).GetOAuthCapabilityEx()
+}
+
+func (pm *plusManagerForMockOAuthProviders) GetOAuthProviderCapabilityEx(name string) (capEx oauth.CapabilityEx) {
+	cap, ok := pm.caps[rportplus.OAuthProviderCapabilityName(name)]
+	if !ok {
+		return nil
+	}
+	return cap.(*oauthmock.Capability).GetOAuthCapabilityEx()
+}
+
+func TestMultipleOAuthProviders(t *testing.T) {
+	plusLog := logger.NewLogger("rport-plus", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+	staff := &oauth.Config{
+		Provider:          oauth.GitHubOAuthProvider,
+		PermittedUserList: true,
+	}
+	customers := &oauth.Config{
+		Name:       "customers",
+		Label:      "Customer login",
+		Provider:   oauth.MicrosoftOAuthProvider,
+		Domains:    []string{"customer.example.com"},
+		UserGroups: []string{"tenant-a"},
+	}
+	plusConfig := &rportplus.PlusConfig{
+		PluginConfig: &rportplus.PluginConfig{
+			PluginPath: defaultPluginPath,
+		},
+		OAuthProviders: []*oauth.Config{customers},
+	}
+
+	plusManager := &plusManagerForMockOAuthProviders{caps: make(map[string]rportplus.Capability)}
+	plusManager.InitPlusManager(plusConfig, nil, plusLog)
+	_, err := plusManager.RegisterCapability(rportplus.PlusOAuthCapability, &oauthmock.Capability{Config: staff, Logger: plusLog})
+	require.NoError(t, err)
+	_, err = plusManager.RegisterCapability(rportplus.OAuthProviderCapabilityName("customers"), &oauthmock.Capability{
+		Config:   customers,
+		Logger:   plusLog,
+		Provider: &oauthmock.MockCapabilityProvider{Username: "jane@customer.example.com"},
+	})
+	require.NoError(t, err)
+
+	al, mockUsersService := setupTestAPIListenerForOAuth(t, plusManager, plusConfig, staff)
+
+	get := func(url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		al.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		return w
+	}
+
+	t.Run("list providers", func(t *testing.T) {
+		w := get(routes.AllRoutesPrefix + routes.AuthRoutesPrefix + routes.AuthProvidersRoute)
+		require.Equal(t, http.StatusOK, w.Code)
+		var res struct {
+			Data []OAuthProviderInfo `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&res))
+		assert.Equal(t, []OAuthProviderInfo{
+			{
+				Name:              "github",
+				AuthProvider:      "github",
+				Label:             "github",
+				Default:           true,
+				SettingsURI:       "/api/v1/auth/ext/settings?provider=github",
+				DeviceSettingsURI: "/api/v1/auth/ext/settings/device?provider=github",
+			},
+			{
+				Name:              "customers",
+				AuthProvider:      "microsoft",
+				Label:             "Customer login",
+				SettingsURI:       "/api/v1/auth/ext/settings?provider=customers",
+				DeviceSettingsURI: "/api/v1/auth/ext/settings/device?provider=customers",
+			},
+		}, res.Data)
+
+		w = get(routes.V2RoutesPrefix + routes.AuthRoutesPrefix + routes.AuthProvidersRoute)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&res))
+		assert.Equal(t, "/api/v2/auth/ext/settings?provider=customers", res.Data[1].SettingsURI)
+		assert.Equal(t, "/api/v2/auth/ext/settings/device?provider=customers", res.Data[1].DeviceSettingsURI)
+	})
+
+	t.Run("settings", func(t *testing.T) {
+		testCases := []struct {
+			URL              string
+			ExpectedStatus   int
+			ExpectedName     string
+			ExpectedLoginURI string
+		}{
+			{
+				URL:              "/api/v1/auth/ext/settings",
+				ExpectedStatus:   http.StatusOK,
+				ExpectedName:     "github",
+				ExpectedLoginURI: "/mock_login_uri",
+			},
+			{
+				URL:              "/api/v1/auth/ext/settings?provider=customers",
+				ExpectedStatus:   http.StatusOK,
+				ExpectedName:     "customers",
+				ExpectedLoginURI: "/api/v1/oauth/customers/login",
+			},
+			{
+				URL:              "/api/v1/auth/ext/settings?hint=jane@Customer.example.com",
+				ExpectedStatus:   http.StatusOK,
+				ExpectedName:     "customers",
+				ExpectedLoginURI: "/api/v1/oauth/customers/login",
+			},
+			{
+				URL:              "/api/v1/auth/ext/settings?hint=john@staff.example.com",
+				ExpectedStatus:   http.StatusOK,
+				ExpectedName:     "github",
+				ExpectedLoginURI: "/mock_login_uri",
+			},
+			{
+				URL:              "/api/v2/auth/ext/settings?provider=customers",
+				ExpectedStatus:   http.StatusOK,
+				ExpectedName:     "customers",
+				ExpectedLoginURI: "/api/v2/oauth/customers/login",
+			},
+			{
+				URL:            "/api/v1/auth/ext/settings?provider=unknown",
+				ExpectedStatus: http.StatusNotFound,
+			},
+		}
+		for _, tc := range testCases {
+			w := get(tc.URL)
+			require.Equal(t, tc.ExpectedStatus, w.Code, tc.URL)
+			if tc.ExpectedStatus != http.StatusOK {
+				continue
+			}
+			var settings AuthSettingsResponse
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&settings))
+			assert.Equal(t, tc.ExpectedName, settings.Data.Name, tc.URL)
+			assert.Equal(t, tc.ExpectedLoginURI, settings.Data.LoginInfo.LoginURI, tc.URL)
+		}
+	})
+
+	t.Run("login creates user with the groups of the provider", func(t *testing.T) {
+		w := get("/api/v1/oauth/customers/login")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NotNil(t, mockUsersService.ChangeUser)
+		assert.Equal(t, "jane@customer.example.com", mockUsersService.ChangeUser.Username)
+		assert.Equal(t, []string{"tenant-a"}, mockUsersService.ChangeUser.Groups)
+
+		assert.Equal(t, http.StatusNotFound, get("/api/v1/oauth/unknown/login").Code)
+	})
+}
+
+func TestDeviceAuthPolling(t *testing.T) {
+	plusManager, plusConfig, oauthConfig, plusLog := setupPlusOAuth()
+	oauthConfig.DevicePollInterval = 10
+
+	mockOAuthCapability := &oauthmock.Capability{
+		Config: oauthConfig,
+		Logger: plusLog,
+	}
+	_, err := plusManager.RegisterCapability(plusMockOAuthCapability, mockOAuthCapability)
+	require.NoError(t, err)
+
+	al, _ := setupTestAPIListenerForOAuth(t, plusManager, plusConfig, oauthConfig)
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	al.devicePolls.now = func() time.Time { return now }
+
+	// the configured interval is longer than the interval of the provider
+	w := httptest.NewRecorder()
+	al.router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1"+routes.AuthRoutesPrefix+routes.AuthDeviceSettingsRoute, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var settings DeviceAuthSettingsResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&settings))
+	assert.Equal(t, 10, settings.Data.LoginInfo.DeviceAuthInfo.Interval)
+
+	poll := func(method string) (*httptest.ResponseRecorder, *oauth.DeviceAuthStatusErrorInfo) {
+		w := httptest.NewRecorder()
+		var req *http.Request
+		if method == http.MethodPost {
+			req = httptest.NewRequest(method, "/api/v1"+oauth.DefaultDeviceLoginURI, strings.NewReader("device_code=mock-device-code"))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		} else {
+			req = httptest.NewRequest(method, "/api/v1"+oauth.DefaultDeviceLoginURI+"?device_code=mock-device-code", nil)
+		}
+		al.router.ServeHTTP(w, req)
+		if w.Code == http.StatusOK {
+			return w, nil
+		}
+		errInfo, err := GetSuccessPayloadResponse[oauth.DeviceAuthStatusErrorInfo](w.Body)
+		require.NoError(t, err)
+		return w, errInfo
+	}
+
+	// the provider reports pending with status 200
+	mockOAuthCapability.Provider.AccessTokenForDeviceErrInfo = &oauth.DeviceAuthStatusErrorInfo{
+		StatusCode: http.StatusOK,
+		ErrorCode:  "authorization_pending",
+	}
+	w, errInfo := poll(http.MethodGet)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "authorization_pending", errInfo.ErrorCode)
+	assert.Equal(t, 10, errInfo.Interval)
+	assert.Equal(t, "10", w.Header().Get("Retry-After"))
+	assert.Equal(t, 1, mockOAuthCapability.Provider.AccessTokenForDeviceRequests)
+
+	// polled too fast, the provider isn't asked
+	now = now.Add(3 * time.Second)
+	w, errInfo = poll(http.MethodPost)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "slow_down", errInfo.ErrorCode)
+	assert.Equal(t, 15, errInfo.Interval)
+	assert.Equal(t, 1, mockOAuthCapability.Provider.AccessTokenForDeviceRequests)
+
+	// the provider asks to slow down
+	now = now.Add(15 * time.Second)
+	mockOAuthCapability.Provider.AccessTokenForDeviceErrInfo.ErrorCode = "slow_down"
+	w, errInfo = poll(http.MethodPost)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "slow_down", errInfo.ErrorCode)
+	assert.Equal(t, 20, errInfo.Interval)
+	assert.Equal(t, 2, mockOAuthCapability.Provider.AccessTokenForDeviceRequests)
+
+	// authorized
+	now = now.Add(20 * time.Second)
+	mockOAuthCapability.Provider.AccessTokenForDeviceErrInfo = nil
+	w, _ = poll(http.MethodPost)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `{"data":{"token":"`)
+	assert.Equal(t, time.Duration(0), al.devicePolls.Interval("github/mock-device-code"))
+}
@@ -0,0 +1,281 @@
+package chserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	alertingcap "github.com/IOTech17/neo-rport/plus/capabilities/alerting"
+	"github.com/IOTech17/neo-rport/plus/capabilities/alerting/entities/rules"
+	"github.com/IOTech17/neo-rport/server/alerts"
+	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/notifications"
+	"github.com/IOTech17/neo-rport/server/routes"
+	"github.com/IOTech17/neo-rport/share/query"
+	"github.com/IOTech17/neo-rport/share/refs"
+)
+
+const escalatedProblemType refs.IdentifiableType = "escalated-problem"
+
+type problemEventRequest struct {
+	Comment string `json:"comment"`
+	// Recipients are notified about an escalation
+	Recipients []string `json:"recipients"`
+}
+
+// handleListProblemHistory handles GET /alerting/problems
+func (al *APIListener) handleListProblemHistory(w http.ResponseWriter, req *http.Request) {
+	options := query.GetListOptions(req)
+	err := query.ValidateListOptions(options, alerts.ProblemHistorySupportedSorts, alerts.ProblemHistorySupportedFilters, nil, &query.PaginationConfig{
+		MaxLimit:     500,
+		DefaultLimit: 50,
+	})
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	problems, err := al.problemHistory.List(req.Context(), options)
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, "Failed to get problems.", err)
+		return
+	}
+	totalCount, err := al.problemHistory.Count(req.Context(), options)
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, "Failed to count problems.", err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, &api.SuccessPayload{
+		Data: problems,
+		Meta: api.NewMeta(totalCount),
+	})
+}
+
+// handleGetProblemHistory handles GET /alerting/problems/{problem_id}
+func (al *APIListener) handleGetProblemHistory(w http.ResponseWriter, req *http.Request) {
+	problemID := mux.Vars(req)[routes.ParamProblemID]
+
+	problem, err := al.problemHistory.Get(req.Context(), problemID)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if problem == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("Problem with id=%q not found.", problemID))
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(problem))
+}
+
+// handleAcknowledgeProblem handles POST /alerting/problems/{problem_id}/acknowledge
+func (al *APIListener) handleAcknowledgeProblem(w http.ResponseWriter, req *http.Request) {
+	al.handleProblemEvent(w, req, alerts.ProblemEventAcknowledged, auditlog.ActionAcknowledge)
+}
+
+// handleEscalateProblem handles POST /alerting/problems/{problem_id}/escalate
+func (al *APIListener) handleEscalateProblem(w http.ResponseWriter, req *http.Request) {
+	al.handleProblemEvent(w, req, alerts.ProblemEventEscalated, auditlog.ActionEscalate)
+}
+
+func (al *APIListener) handleProblemEvent(w http.ResponseWriter, req *http.Request, event, auditAction string) {
+	ctx := req.Context()
+	problemID := mux.Vars(req)[routes.ParamProblemID]
+
+	var body problemEventRequest
+	if req.ContentLength != 0 {
+		if err := parseRequestBody(req.Body, &body); err != nil {
+			al.jsonError(w, err)
+			return
+		}
+	}
+	curUser, err := al.getUserModelForAuth(ctx)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	problem, err := al.problemHistory.Get(ctx, problemID)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if problem == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("Problem with id=%q not found.", problemID))
+		return
+	}
+
+	details := body.Comment
+	if event == alerts.ProblemEventEscalated && len(body.Recipients) > 0 {
+		details = strings.TrimSpace(fmt.Sprintf("%s (to %s)", body.Comment, strings.Join(body.Recipients, ", ")))
+	}
+	recorded, err := al.problemHistory.Record(ctx, alerts.ProblemEvent{
+		ProblemID: problemID,
+		Event:     event,
+		Timestamp: time.Now(),
+		Username:  curUser.Username,
+		Details:   details,
+	})
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if !recorded {
+		al.jsonErrorResponseWithTitle(w, http.StatusConflict, fmt.Sprintf("Problem with id=%q can't be %s, it is %s.", problemID, event, problem.State))
+		return
+	}
+
+	if event == alerts.ProblemEventEscalated && len(body.Recipients) > 0 {
+		al.notifyEscalation(req, problem, curUser.Username, body)
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationAlertingProblem, auditAction).
+		WithHTTPRequest(req).
+		WithID(problemID).
+		WithRequest(body).
+		Save()
+
+	problem, err = al.problemHistory.Get(ctx, problemID)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(problem))
+}
+
+func (al *APIListener) notifyEscalation(req *http.Request, problem *alerts.ProblemRecord, username string, body problemEventRequest) {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "Problem: %s\n", problem.ProblemID)
+	fmt.Fprintf(b, "Rule: %s\n", problem.RuleID)
+	if problem.ClientID != "" {
+		fmt.Fprintf(b, "Client: %s\n", problem.ClientID)
+	}
+	if problem.GroupID != "" {
+		fmt.Fprintf(b, "Client group: %s\n", problem.GroupID)
+	}
+	fmt.Fprintf(b, "Opened: %s\n", problem.OpenedAt.UTC().Format(time.RFC1123))
+	fmt.Fprintf(b, "Escalated by: %s\n", username)
+	if body.Comment != "" {
+		fmt.Fprintf(b, "\n%s\n", body.Comment)
+	}
+
+	notification := notifications.NotificationData{
+		Target:      string(notifications.TargetMail),
+		Recipients:  body.Recipients,
+		Subject:     fmt.Sprintf("[rport] ESCALATED: problem of rule %q", problem.RuleID),
+		Content:     b.String(),
+		ContentType: notifications.ContentTypeTextPlain,
+		Severity:    notifications.SeverityHigh,
+	}
+	ctx := req.Context()
+	if _, err := al.notificationDigests.Dispatch(ctx, refs.NewIdentifiable(escalatedProblemType, problem.ProblemID), notification); err != nil {
+		al.Errorf("Failed to notify about the escalation of problem %q: %v", problem.ProblemID, err)
+		return
+	}
+	_, err := al.problemHistory.Record(ctx, alerts.ProblemEvent{
+		ProblemID: problem.ProblemID,
+		Event:     alerts.ProblemEventNotified,
+		Timestamp: time.Now(),
+		Details:   alerts.NotificationDetails(notification),
+	})
+	if err != nil {
+		al.Errorf("Failed to record the notification of problem %q: %v", problem.ProblemID, err)
+	}
+}
+
+// recordProblemUpdate records a problem of the alerting service resolved or reopened by a user.
+func (al *APIListener) recordProblemUpdate(req *http.Request, as alertingcap.Service, problemID rules.ProblemID, active bool) {
+	ctx := req.Context()
+	problem, err := as.GetProblem(problemID)
+	if err != nil || problem == nil {
+		al.Errorf("Failed to get problem %q to record its update: %v", problemID, err)
+		return
+	}
+	if _, err := al.problemHistory.Open(ctx, alerts.AlertingProblemRecord(problem)); err != nil {
+		al.Errorf("Failed to record problem %q: %v", problemID, err)
+		return
+	}
+
+	ev := alerts.ProblemEvent{
+		ProblemID: string(problemID),
+		Event:     alerts.ProblemEventResolved,
+		Timestamp: time.Now(),
+	}
+	if active {
+		ev.Event = alerts.ProblemEventReopened
+	}
+	if curUser, err := al.getUserModelForAuth(ctx); err == nil {
+		ev.Username = curUser.Username
+	}
+	if _, err := al.problemHistory.Record(ctx, ev); err != nil {
+		al.Errorf("Failed to record event %q of problem %q: %v", ev.Event, problemID, err)
+	}
+}
+
+// handleAlertingWebhook handles POST /alerting/webhook
+func (al *APIListener) handleAlertingWebhook(w http.ResponseWriter, req *http.Request) {
+	// the senders add fields of their own, unknown fields are ignored
+	var payload alerts.WebhookPayload
+	if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusBadRequest, "Invalid JSON data.", err)
+		return
+	}
+
+	result, err := al.webhookReceiver.Receive(req.Context(), payload)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if len(result.Unmatched) > 0 {
+		al.Debugf("External alerts of receiver %q not matching any client: %v", payload.Receiver, result.Unmatched)
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(result))
+}
+
+// handleGetClientProblemTimeline handles GET /alerting/clients/{client_id}/timeline
+func (al *APIListener) handleGetClientProblemTimeline(w http.ResponseWriter, req *http.Request) {
+	clientID := mux.Vars(req)[routes.ParamClientID]
+
+	options := query.GetListOptions(req)
+	err := query.ValidateListOptions(options, alerts.ProblemEventSupportedSorts, alerts.ProblemEventSupportedFilters, nil, &query.PaginationConfig{
+		MaxLimit:     1000,
+		DefaultLimit: 100,
+	})
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	events, err := al.problemHistory.Timeline(req.Context(), clientID, options)
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, "Failed to get the problem timeline.", err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(events))
+}
+
+// handleGetProblemStats handles GET /alerting/stats
+func (al *APIListener) handleGetProblemStats(w http.ResponseWriter, req *http.Request) {
+	options := query.GetListOptions(req)
+	err := query.ValidateListOptions(options, nil, alerts.ProblemHistorySupportedFilters, nil, nil)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	report, err := al.problemHistory.Report(req.Context(), options)
+	if err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusInternalServerError, "Failed to get the problem stats.", err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(report))
+}
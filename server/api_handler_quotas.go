@@ -0,0 +1,182 @@
+package chserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/quotas"
+)
+
+type quotaStatus struct {
+	quotas.Quota
+	Usage quotas.Usage `json:"usage"`
+}
+
+// handleGetQuotas handles GET /quotas, it returns the quotas and the current usage of all tenants to administrators
+// and of the own user groups to other users.
+func (al *APIListener) handleGetQuotas(w http.ResponseWriter, req *http.Request) {
+	user, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	var groups []string
+	for _, group := range al.quotas.UserGroups() {
+		if user.IsAdmin() || hasGroup(user, group) {
+			groups = append(groups, group)
+		}
+	}
+
+	usage, err := al.getQuotaUsage(req.Context(), groups)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	res := make([]quotaStatus, 0, len(groups))
+	for _, group := range groups {
+		quota, _ := al.quotas.Get(group)
+		res = append(res, quotaStatus{
+			Quota: quota,
+			Usage: *usage[group],
+		})
+	}
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(res))
+}
+
+func hasGroup(user *users.User, group string) bool {
+	for _, g := range user.Groups {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
+
+// getQuotaUsage returns the usage of the given user groups.
+func (al *APIListener) getQuotaUsage(ctx context.Context, groups []string) (map[string]*quotas.Usage, error) {
+	res := make(map[string]*quotas.Usage, len(groups))
+	for _, group := range groups {
+		res[group] = &quotas.Usage{
+			Datapoints: al.quotas.Datapoints(group),
+		}
+	}
+	if len(groups) == 0 {
+		return res, nil
+	}
+
+	clientGroups, err := al.clientGroupProvider.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ownerGroups := al.newOwnerGroupsFunc()
+	for _, c := range al.clientService.GetAll() {
+		if !c.IsConnected() {
+			continue
+		}
+		for _, group := range al.clientQuotaGroups(c, clientGroups) {
+			if u, ok := res[group]; ok {
+				u.Clients++
+			}
+		}
+		for _, t := range c.GetTunnels() {
+			groups, err := ownerGroups(t.Owner)
+			if err != nil {
+				return nil, err
+			}
+			for _, group := range groups {
+				if u, ok := res[group]; ok {
+					u.Tunnels++
+				}
+			}
+		}
+	}
+
+	if al.meshTunnels != nil {
+		for _, mt := range al.meshTunnels.List() {
+			groups, err := ownerGroups(mt.CreatedBy)
+			if err != nil {
+				return nil, err
+			}
+			for _, group := range groups {
+				if u, ok := res[group]; ok {
+					u.Tunnels++
+				}
+			}
+		}
+	}
+
+	if al.scheduleManager != nil {
+		schedules, err := al.scheduleManager.CountByCreator(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for creator, count := range schedules {
+			groups, err := ownerGroups(creator)
+			if err != nil {
+				return nil, err
+			}
+			for _, group := range groups {
+				if u, ok := res[group]; ok {
+					u.Schedules += count
+				}
+			}
+		}
+	}
+	return res, nil
+}
+
+// newOwnerGroupsFunc returns a func returning the user groups of a user, it caches the users for one usage calculation.
+func (al *APIListener) newOwnerGroupsFunc() func(username string) ([]string, error) {
+	cache := make(map[string][]string)
+	return func(username string) ([]string, error) {
+		if username == "" {
+			return nil, nil
+		}
+		if groups, ok := cache[username]; ok {
+			return groups, nil
+		}
+		user, err := al.userService.GetByUsername(username)
+		if err != nil {
+			return nil, err
+		}
+		var groups []string
+		if user != nil {
+			groups = user.Groups
+		}
+		cache[username] = groups
+		return groups, nil
+	}
+}
+
+// checkUserQuota returns a 403 API error if one more of the resource exceeds the quota of a user group of the user.
+// Administrators are not limited.
+func (al *APIListener) checkUserQuota(ctx context.Context, user *users.User, resource quotas.Resource) error {
+	if user.IsAdmin() {
+		return nil
+	}
+	limited := al.quotas.Limited(user.Groups, resource)
+	if len(limited) == 0 {
+		return nil
+	}
+
+	usage, err := al.getQuotaUsage(ctx, limited)
+	if err != nil {
+		return err
+	}
+	current := make(map[string]int, len(usage))
+	for group, u := range usage {
+		current[group] = u.Get(resource)
+	}
+
+	err = al.quotas.Check(resource, limited, current)
+	var exceeded *quotas.ExceededError
+	if errors.As(err, &exceeded) {
+		return exceeded.APIError()
+	}
+	return err
+}
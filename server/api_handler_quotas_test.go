@@ -0,0 +1,144 @@
+package chserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	jobsmigration "github.com/IOTech17/neo-rport/db/migration/jobs"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/api/jobs/schedule"
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/clients"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/clients/clienttunnel"
+	"github.com/IOTech17/neo-rport/server/clients/meshtunnel"
+	"github.com/IOTech17/neo-rport/server/quotas"
+	"github.com/IOTech17/neo-rport/share/models"
+	"github.com/IOTech17/neo-rport/share/security"
+)
+
+func TestQuotas(t *testing.T) {
+	ctx := context.Background()
+	// password of all users is "pwd"
+	password := "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm"
+	admin := &users.User{Username: "admin", Password: password, Groups: []string{users.Administrators}}
+	jane := &users.User{Username: "jane", Password: password, Groups: []string{"tenant-a"}}
+	bob := &users.User{Username: "bob", Password: password, Groups: []string{"tenant-b"}}
+
+	c1 := clients.New(t).ID("c1").AllowedUserGroups([]string{"tenant-a"}).Logger(testLog).Build()
+	c2 := clients.New(t).ID("c2").AllowedUserGroups([]string{"tenant-a"}).Logger(testLog).Build()
+	c2.SetTunnels([]*clienttunnel.Tunnel{{ID: "1", Remote: models.Remote{Owner: "jane"}}})
+	c3 := clients.New(t).ID("c3").AllowedUserGroups([]string{"tenant-b"}).Logger(testLog).Build()
+	c4 := clients.New(t).ID("c4").AllowedUserGroups([]string{"tenant-a", "tenant-b"}).Logger(testLog).Build()
+
+	meshTunnels := meshtunnel.NewManager()
+	mt, err := meshtunnel.New("c3", "c4", "127.0.0.1:5432", "127.0.0.1:5432", meshtunnel.ModeRelay, "bob")
+	require.NoError(t, err)
+	require.NoError(t, meshTunnels.Add(mt))
+
+	jobsDB, err := sqlite.New(":memory:", jobsmigration.AssetNames(), jobsmigration.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	defer jobsDB.Close()
+	scheduleManager := schedule.NewManager(nil, jobsDB, testLog, 30)
+	_, err = scheduleManager.Create(ctx, &schedule.Schedule{
+		Base:    schedule.Base{Name: "backup", Schedule: "0 1 * * *", Type: schedule.TypeCommand},
+		Details: schedule.Details{ClientIDs: []string{"c1"}, Command: "/usr/bin/backup"},
+	}, "jane")
+	require.NoError(t, err)
+
+	al := &APIListener{
+		Logger:      testLog,
+		bannedUsers: security.NewBanList(0),
+		apiSessions: newEmptyAPISessionCache(t),
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+				},
+			},
+			clientService:       clients.NewClientService(nil, nil, clients.NewClientRepository([]*clientdata.Client{c1, c2, c3}, nil, testLog), testLog, nil),
+			clientGroupProvider: staticClientGroupProvider{},
+			scheduleManager:     scheduleManager,
+			meshTunnels:         meshTunnels,
+			quotas: quotas.New([]quotas.Quota{
+				{UserGroup: "tenant-a", MaxClients: 2, MaxTunnels: 1, MaxSchedules: 2, MaxDatapointsPerDay: 1},
+				{UserGroup: "tenant-b", MaxClients: 5},
+			}),
+		},
+		userService: users.NewAPIService(users.NewStaticProvider([]*users.User{admin, jane, bob}), false, 0, -1),
+	}
+	al.initRouter()
+
+	assertQuotaExceeded := func(t *testing.T, err error, group string) {
+		t.Helper()
+		var apiErr errors2.APIError
+		require.True(t, errors.As(err, &apiErr), err)
+		assert.Equal(t, http.StatusForbidden, apiErr.HTTPStatus)
+		assert.Equal(t, quotas.ErrCodeQuotaExceeded, apiErr.ErrCode)
+		assert.Contains(t, apiErr.Message, group)
+	}
+
+	t.Run("usage", func(t *testing.T) {
+		require.NoError(t, al.meterDatapoint(ctx, "c1"))
+		require.Error(t, al.meterDatapoint(ctx, "c2"))
+		require.NoError(t, al.meterDatapoint(ctx, "c3"))
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/quotas", nil)
+		req.SetBasicAuth("admin", "pwd")
+		al.router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"data":[
+			{"user_group":"tenant-a","max_clients":2,"max_tunnels":1,"max_schedules":2,"max_datapoints_per_day":1,
+				"usage":{"clients":2,"tunnels":1,"schedules":1,"datapoints":1}},
+			{"user_group":"tenant-b","max_clients":5,"max_tunnels":0,"max_schedules":0,"max_datapoints_per_day":0,
+				"usage":{"clients":1,"tunnels":1,"schedules":0,"datapoints":1}}
+		]}`, w.Body.String())
+
+		w = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "/api/v1/quotas", nil)
+		req.SetBasicAuth("bob", "pwd")
+		al.router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		var res struct {
+			Data []quotaStatus `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		require.Len(t, res.Data, 1)
+		assert.Equal(t, "tenant-b", res.Data[0].UserGroup)
+	})
+
+	t.Run("users", func(t *testing.T) {
+		assertQuotaExceeded(t, al.checkUserQuota(ctx, jane, quotas.ResourceTunnels), "tenant-a")
+		assert.NoError(t, al.checkUserQuota(ctx, jane, quotas.ResourceSchedules))
+		assert.NoError(t, al.checkUserQuota(ctx, bob, quotas.ResourceTunnels))
+		assert.NoError(t, al.checkUserQuota(ctx, &users.User{Username: "admin", Groups: []string{users.Administrators, "tenant-a"}}, quotas.ResourceTunnels))
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/mesh-tunnels", strings.NewReader(`{"source_client_id":"c1","target_client_id":"c2","local":"127.0.0.1:2222","remote":"127.0.0.1:22"}`))
+		req.SetBasicAuth("jane", "pwd")
+		al.router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), `quota of user group \"tenant-a\" exceeded`)
+		assert.Len(t, meshTunnels.List(), 1)
+	})
+
+	t.Run("clients", func(t *testing.T) {
+		assert.NoError(t, al.checkClientQuota(ctx, c1))
+		err := al.checkClientQuota(ctx, c4)
+		var exceeded *quotas.ExceededError
+		require.True(t, errors.As(err, &exceeded), err)
+		assert.Equal(t, "tenant-a", exceeded.UserGroup)
+		assert.Equal(t, quotas.ResourceClients, exceeded.Resource)
+	})
+}
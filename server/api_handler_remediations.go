@@ -0,0 +1,92 @@
+package chserver
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/alerts"
+	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/routes"
+	"github.com/IOTech17/neo-rport/server/validation"
+)
+
+func (al *APIListener) handleListRemediations(w http.ResponseWriter, req *http.Request) {
+	all, err := al.remediations.List(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(all))
+}
+
+func (al *APIListener) handlePutRemediation(w http.ResponseWriter, req *http.Request) {
+	remediationID := mux.Vars(req)[routes.ParamRemediationID]
+	ctx := req.Context()
+
+	var remediation alerts.Remediation
+	if err := parseRequestBody(req.Body, &remediation); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	remediation.ID = remediationID
+	if err := remediation.Validate(); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if err := validation.ValidateInterpreter(remediation.Interpreter, true); err != nil {
+		al.jsonErrorResponseWithError(w, http.StatusBadRequest, "Invalid interpreter.", err)
+		return
+	}
+	warnings, err := al.secretScanner.Check(remediation.Script)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	if err := al.remediations.Save(ctx, remediation); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationAlertingRemediation, auditlog.ActionUpdate).
+		WithHTTPRequest(req).
+		WithID(remediationID).
+		WithRequest(remediation).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusOK, &api.SuccessPayload{
+		Data:     remediation,
+		Warnings: warnings,
+	})
+}
+
+func (al *APIListener) handleDeleteRemediation(w http.ResponseWriter, req *http.Request) {
+	remediationID := mux.Vars(req)[routes.ParamRemediationID]
+	ctx := req.Context()
+
+	existing, err := al.remediations.Get(ctx, remediationID)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if existing == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("Remediation %q not found.", remediationID))
+		return
+	}
+
+	if err := al.remediations.Delete(ctx, remediationID); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationAlertingRemediation, auditlog.ActionDelete).
+		WithHTTPRequest(req).
+		WithID(remediationID).
+		Save()
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,52 @@
+package chserver
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/apilog"
+)
+
+const defaultRequestLogLimit = 100
+
+// handleGetRequestLog handles GET /request-log, it returns the latest API requests of the request log, newest first.
+func (al *APIListener) handleGetRequestLog(w http.ResponseWriter, req *http.Request) {
+	if al.requestLog == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, "Request log is disabled.")
+		return
+	}
+
+	query := req.URL.Query()
+	filter := apilog.Filter{
+		Method:   query.Get("method"),
+		Path:     query.Get("path"),
+		Username: query.Get("username"),
+		Limit:    defaultRequestLogLimit,
+	}
+	var err error
+	if v := query.Get("min_status"); v != "" {
+		filter.MinStatus, err = strconv.Atoi(v)
+		if err != nil {
+			al.jsonError(w, errors2.APIError{
+				HTTPStatus: http.StatusBadRequest,
+				Message:    fmt.Sprintf("invalid min_status: %q", v),
+			})
+			return
+		}
+	}
+	if v := query.Get("limit"); v != "" {
+		filter.Limit, err = strconv.Atoi(v)
+		if err != nil || filter.Limit <= 0 || filter.Limit > apilog.RecentEntries {
+			al.jsonError(w, errors2.APIError{
+				HTTPStatus: http.StatusBadRequest,
+				Message:    fmt.Sprintf("limit must be between 1 and %d", apilog.RecentEntries),
+			})
+			return
+		}
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(al.requestLog.Recent(filter)))
+}
@@ -0,0 +1,82 @@
+package chserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/apilog"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/share/security"
+)
+
+func TestHandleGetRequestLog(t *testing.T) {
+	newAPIListener := func(requestLog *apilog.Logger) *APIListener {
+		al := &APIListener{
+			Logger:      testLog,
+			bannedUsers: security.NewBanList(0),
+			apiSessions: newEmptyAPISessionCache(t),
+			requestLog:  requestLog,
+			Server: &Server{
+				config: &chconfig.Config{
+					API: chconfig.APIConfig{
+						MaxRequestBytes: 1024 * 1024,
+					},
+				},
+				clientGroupProvider: staticClientGroupProvider{},
+			},
+			userService: users.NewAPIService(users.NewStaticProvider([]*users.User{
+				{Username: "admin", Password: "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm", Groups: []string{users.Administrators}},
+			}), false, 0, -1),
+		}
+		al.initRouter()
+		return al
+	}
+	request := func(al *APIListener, url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req.SetBasicAuth("admin", "pwd")
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		al := newAPIListener(nil)
+		w := request(al, "/api/v1/request-log")
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		requestLog, err := apilog.New(apilog.Config{
+			File:       filepath.Join(t.TempDir(), "requests.log"),
+			SampleRate: 1,
+			MaxFileMB:  1,
+		}, testLog)
+		require.NoError(t, err)
+		defer requestLog.Close()
+		al := newAPIListener(requestLog)
+
+		w := request(al, "/api/v1/me")
+		require.Equal(t, http.StatusOK, w.Code)
+		w = request(al, "/api/v1/request-log?path=/api/v1/me")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var result struct {
+			Data []*apilog.Entry `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		require.Len(t, result.Data, 1)
+		assert.Equal(t, "/api/v1/me", result.Data[0].Path)
+		assert.Equal(t, "admin", result.Data[0].Username)
+		assert.Equal(t, http.StatusOK, result.Data[0].Status)
+
+		w = request(al, "/api/v1/request-log?limit=0")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
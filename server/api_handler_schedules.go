@@ -11,7 +11,9 @@ import (
 	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
 	"github.com/IOTech17/neo-rport/server/api/jobs/schedule"
 	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/changes"
 	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/quotas"
 )
 
 func (al *APIListener) handleListSchedules(w http.ResponseWriter, req *http.Request) {
@@ -64,11 +66,22 @@ func (al *APIListener) handlePostSchedules(w http.ResponseWriter, req *http.Requ
 		return
 	}
 
+	user, err := al.getUserModelForAuth(ctx)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if err := al.checkUserQuota(ctx, user, quotas.ResourceSchedules); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
 	storedValue, err := al.scheduleManager.Create(ctx, &scheduleInput, username)
 	if err != nil {
 		al.jsonError(w, err)
 		return
 	}
+	al.recordChange(ctx, changes.ResourceSchedule, storedValue.ID, nil)
 
 	al.auditLog.Entry(auditlog.ApplicationSchedule, auditlog.ActionCreate).
 		WithHTTPRequest(req).
@@ -95,11 +108,18 @@ func (al *APIListener) handleUpdateSchedule(w http.ResponseWriter, req *http.Req
 		return
 	}
 
+	before, err := al.changeJournal.Snapshot(ctx, changes.ResourceSchedule, idStr)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
 	storedValue, err := al.scheduleManager.Update(ctx, idStr, &scheduleInput)
 	if err != nil {
 		al.jsonError(w, err)
 		return
 	}
+	al.recordChange(ctx, changes.ResourceSchedule, idStr, before)
 
 	al.auditLog.Entry(auditlog.ApplicationSchedule, auditlog.ActionUpdate).
 		WithHTTPRequest(req).
@@ -146,11 +166,18 @@ func (al *APIListener) handleDeleteSchedule(w http.ResponseWriter, req *http.Req
 		return
 	}
 
-	err := al.scheduleManager.Delete(req.Context(), idStr)
+	before, err := al.changeJournal.Snapshot(req.Context(), changes.ResourceSchedule, idStr)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	err = al.scheduleManager.Delete(req.Context(), idStr)
 	if err != nil {
 		al.jsonError(w, err)
 		return
 	}
+	al.recordChange(req.Context(), changes.ResourceSchedule, idStr, before)
 
 	al.auditLog.Entry(auditlog.ApplicationSchedule, auditlog.ActionDelete).
 		WithHTTPRequest(req).
@@ -13,6 +13,7 @@ import (
 	"github.com/IOTech17/neo-rport/server/api/jobs"
 	"github.com/IOTech17/neo-rport/server/auditlog"
 	"github.com/IOTech17/neo-rport/server/routes"
+	"github.com/IOTech17/neo-rport/server/validation"
 	"github.com/IOTech17/neo-rport/share/ws"
 )
 
@@ -46,7 +47,13 @@ func (al *APIListener) handleExecuteScript(w http.ResponseWriter, req *http.Requ
 	execCmdInput.ClientID = cid
 	execCmdInput.IsScript = true
 
-	resp := al.handleExecuteCommand(req.Context(), w, execCmdInput)
+	wait, err := parseJobWait(req)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	resp := al.handleExecuteCommand(req.Context(), w, execCmdInput, wait)
 
 	if resp != nil {
 		al.auditLog.Entry(auditlog.ApplicationClientScript, auditlog.ActionExecuteStart).
@@ -68,6 +75,11 @@ func (al *APIListener) handlePostMultiClientScript(w http.ResponseWriter, req *h
 		al.jsonError(w, err)
 		return
 	}
+	wait, err := parseJobWait(req)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
 
 	orderedClients, _, err := al.getOrderedClientsWithValidation(ctx, inboundMsg)
 	if err != nil {
@@ -83,6 +95,12 @@ func (al *APIListener) handlePostMultiClientScript(w http.ResponseWriter, req *h
 		return
 	}
 
+	warnings, err := al.secretScanner.Check(inboundMsg.Command)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
 	curUser, err := al.getUserModelForAuth(req.Context())
 	if err != nil {
 		al.jsonError(w, err)
@@ -117,9 +135,17 @@ func (al *APIListener) handlePostMultiClientScript(w http.ResponseWriter, req *h
 		WithID(multiJob.JID).
 		SaveForMultipleClients(inboundMsg.OrderedClients)
 
-	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(resp))
-
 	al.Debugf("Multi-client Job[id=%q] created to execute remote command on clients %s, groups %s, tags %s: %q.", multiJob.JID, inboundMsg.ClientIDs, inboundMsg.GroupIDs, inboundMsg.GetClientTags(), inboundMsg.Command)
+
+	if wait > 0 {
+		al.writeFinishedMultiJob(ctx, w, multiJob.JID, len(inboundMsg.OrderedClients), wait, warnings)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, &api.SuccessPayload{
+		Data:     resp,
+		Warnings: warnings,
+	})
 }
 
 // handleScriptsWS handles GET /ws/scripts
@@ -189,5 +215,32 @@ func (al *APIListener) enrichScriptInput(
 	inboundMsg.Command = string(decodedScriptBytes)
 	inboundMsg.IsScript = true
 
+	err = validation.ValidateOutputParser(inboundMsg.OutputParser)
+	if err != nil {
+		return errors2.APIError{
+			Err:        err,
+			HTTPStatus: http.StatusBadRequest,
+			Message:    "Invalid output parser.",
+		}
+	}
+
+	err = validation.ValidateLock(inboundMsg.Lock)
+	if err != nil {
+		return errors2.APIError{
+			Err:        err,
+			HTTPStatus: http.StatusBadRequest,
+			Message:    "Invalid lock.",
+		}
+	}
+
+	err = validation.ValidateJobArtifacts(inboundMsg.Artifacts)
+	if err != nil {
+		return errors2.APIError{
+			Err:        err,
+			HTTPStatus: http.StatusBadRequest,
+			Message:    "Invalid artifacts.",
+		}
+	}
+
 	return nil
 }
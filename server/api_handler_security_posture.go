@@ -0,0 +1,22 @@
+package chserver
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/posture"
+)
+
+func (s *Server) postureInput() posture.Input {
+	return posture.Input{
+		Config:  s.config,
+		Clients: s.clientService.GetAll(),
+	}
+}
+
+func (al *APIListener) handleGetSecurityPosture(w http.ResponseWriter, req *http.Request) {
+	report := posture.Evaluate(al.postureInput(), time.Now())
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(report))
+}
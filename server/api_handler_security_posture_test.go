@@ -0,0 +1,73 @@
+package chserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/clients"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/posture"
+	"github.com/IOTech17/neo-rport/share/security"
+)
+
+func TestHandleGetSecurityPosture(t *testing.T) {
+	admin := &users.User{
+		Username: "admin",
+		Password: "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm",
+		Groups:   []string{users.Administrators},
+	}
+	user := &users.User{
+		Username: "user",
+		Password: "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm",
+	}
+	al := APIListener{
+		Logger: testLog,
+		Server: &Server{
+			config: &chconfig.Config{
+				Server: chconfig.ServerConfig{
+					ListenAddress: "0.0.0.0:8080",
+				},
+				API: chconfig.APIConfig{
+					Address:         "0.0.0.0:3000",
+					MaxRequestBytes: 1024 * 1024,
+				},
+			},
+			clientService: clients.NewClientService(nil, nil, clients.NewClientRepository([]*clientdata.Client{}, nil, testLog), testLog, nil),
+		},
+		bannedUsers: security.NewBanList(0),
+		userService: users.NewAPIService(users.NewStaticProvider([]*users.User{admin, user}), false, 0, -1),
+		apiSessions: newEmptyAPISessionCache(t),
+	}
+	al.initRouter()
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/security/posture", nil)
+	req.SetBasicAuth("user", "pwd")
+	al.router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/security/posture", nil)
+	req.SetBasicAuth("admin", "pwd")
+	al.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data posture.Report `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Less(t, resp.Data.Score, posture.MaxScore)
+	ids := make([]string, 0, len(resp.Data.Findings))
+	for _, f := range resp.Data.Findings {
+		ids = append(ids, f.ID)
+	}
+	assert.Contains(t, ids, "api_2fa_disabled")
+	assert.Contains(t, ids, "default_ports")
+}
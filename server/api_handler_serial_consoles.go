@@ -0,0 +1,393 @@
+package chserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/routes"
+	"github.com/IOTech17/neo-rport/server/serialconsoles"
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/query"
+)
+
+const (
+	defaultSerialBaudRate = 9600
+	defaultSerialDataBits = 8
+	defaultSerialStopBits = 1
+	maxSerialPortLength   = 255
+)
+
+type serialDeviceRequest struct {
+	Name     string `json:"name"`
+	Port     string `json:"port"`
+	BaudRate int    `json:"baud_rate"`
+	DataBits int    `json:"data_bits"`
+	Parity   string `json:"parity"`
+	StopBits int    `json:"stop_bits"`
+}
+
+// handleListSerialDevices handles GET /clients/{client_id}/serial-devices
+func (al *APIListener) handleListSerialDevices(w http.ResponseWriter, req *http.Request) {
+	clientID := mux.Vars(req)[routes.ParamClientID]
+
+	list, err := al.serialConsoles.ListDevices(req.Context(), clientID)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(list))
+}
+
+// handlePostSerialDevice handles POST /clients/{client_id}/serial-devices, it adds a serial port of the client with
+// its line settings.
+func (al *APIListener) handlePostSerialDevice(w http.ResponseWriter, req *http.Request) {
+	clientID := mux.Vars(req)[routes.ParamClientID]
+
+	var reqBody serialDeviceRequest
+	if err := parseRequestBody(req.Body, &reqBody); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if reqBody.Port == "" || len(reqBody.Port) > maxSerialPortLength {
+		al.jsonErrorResponseWithDetail(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid or missing port.",
+			fmt.Sprintf("The device of the serial port on the client, e.g. /dev/ttyUSB0 or COM3, max size is %d.", maxSerialPortLength))
+		return
+	}
+
+	client, err := al.clientService.GetByID(clientID)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if client == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("client with id %s not found", clientID))
+		return
+	}
+
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	device := &serialconsoles.Device{
+		ID:        uuid.New().String(),
+		ClientID:  clientID,
+		Port:      reqBody.Port,
+		CreatedBy: curUser.Username,
+		CreatedAt: time.Now().UTC(),
+	}
+	if !al.applySerialDeviceRequest(w, device, reqBody) {
+		return
+	}
+	created, err := al.serialConsoles.CreateDevice(req.Context(), device)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if !created {
+		al.jsonErrorResponseWithDetail(w, http.StatusConflict, ErrCodeAlreadyExist, fmt.Sprintf("Serial device with port %q already exist.", device.Port), "")
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientSerialConsole, auditlog.ActionCreate).
+		WithHTTPRequest(req).
+		WithClientID(clientID).
+		WithID(device.ID).
+		WithRequest(reqBody).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusCreated, api.NewSuccessPayload(device))
+}
+
+// handlePutSerialDevice handles PUT /clients/{client_id}/serial-devices/{serial_device_id}, the port can't be
+// changed. Console sessions already open keep their line settings.
+func (al *APIListener) handlePutSerialDevice(w http.ResponseWriter, req *http.Request) {
+	device, ok := al.getSerialDevice(w, req)
+	if !ok {
+		return
+	}
+
+	var reqBody serialDeviceRequest
+	if err := parseRequestBody(req.Body, &reqBody); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if reqBody.Port != "" && reqBody.Port != device.Port {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "The port of a serial device can't be changed.")
+		return
+	}
+
+	if !al.applySerialDeviceRequest(w, device, reqBody) {
+		return
+	}
+	updated, err := al.serialConsoles.UpdateDevice(req.Context(), device)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if !updated {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("serial device with id %s not found", device.ID))
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientSerialConsole, auditlog.ActionUpdate).
+		WithHTTPRequest(req).
+		WithClientID(device.ClientID).
+		WithID(device.ID).
+		WithRequest(reqBody).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(device))
+}
+
+// handleDeleteSerialDevice handles DELETE /clients/{client_id}/serial-devices/{serial_device_id}, the sessions and
+// recordings of the device are kept until the retention ends.
+func (al *APIListener) handleDeleteSerialDevice(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	clientID := vars[routes.ParamClientID]
+	id := vars[routes.ParamSerialDeviceID]
+
+	deleted, err := al.serialConsoles.DeleteDevice(req.Context(), clientID, id)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if !deleted {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("serial device with id %s not found", id))
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientSerialConsole, auditlog.ActionDelete).
+		WithHTTPRequest(req).
+		WithClientID(clientID).
+		WithID(id).
+		Save()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleSerialConsoleWS handles GET /ws/clients/{client_id}/serial-devices/{serial_device_id}/console, it opens the
+// port of the device on the client and relays it to the web socket. Binary and text messages are written to the
+// port, the output of the device is sent as binary messages.
+func (al *APIListener) handleSerialConsoleWS(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+	device, ok := al.getSerialDevice(w, req)
+	if !ok {
+		return
+	}
+	client, err := al.clientService.GetActiveByID(device.ClientID)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if client == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("active client with id %s not found", device.ClientID))
+		return
+	}
+	if client.IsPaused() {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("client with id %s is paused (reason = %s)", device.ClientID, client.GetPausedReason()))
+		return
+	}
+	if quarantine := client.GetQuarantine(); quarantine != nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusForbidden, fmt.Sprintf("client with id %s is quarantined (reason = %s)", device.ClientID, quarantine.Reason))
+		return
+	}
+	curUser, err := al.getUserModelForAuth(ctx)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	stream, err := al.openSerialConsole(ctx, client, device)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	defer stream.Close()
+
+	wsConn, err := apiUpgrader.Upgrade(w, req, nil)
+	if err != nil {
+		al.Errorf("Failed to establish WS connection: %v", err)
+		return
+	}
+
+	session := &serialconsoles.Session{
+		ID:        uuid.New().String(),
+		DeviceID:  device.ID,
+		ClientID:  device.ClientID,
+		Port:      device.Port,
+		Username:  curUser.Username,
+		StartedAt: time.Now().UTC(),
+	}
+	recording, err := serialconsoles.NewRecording(serialconsoles.RecordingPath(al.serialRecordingDir, session.ID), session.StartedAt, serialRecordingTitle(device, curUser.Username))
+	if err != nil {
+		al.Errorf("Failed to start recording of serial console session %s: %v", session.ID, err)
+		wsConn.Close()
+		return
+	}
+	if err := al.serialConsoles.InsertSession(ctx, session); err != nil {
+		al.Errorf("%v", err)
+		_ = recording.Close()
+		wsConn.Close()
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientSerialConsole, auditlog.ActionExecuteStart).
+		WithHTTPRequest(req).
+		WithClientID(device.ClientID).
+		WithID(session.ID).
+		WithRequest(map[string]string{"device_id": device.ID, "port": device.Port}).
+		Save()
+	al.Infof("Serial console session %s on %s of client %s opened by %s", session.ID, device.Port, device.ClientID, curUser.Username)
+
+	relay := &serialConsoleRelay{
+		log:       al.Logger,
+		ws:        wsConn,
+		stream:    stream,
+		recording: recording,
+	}
+	relay.run()
+
+	if err := recording.Close(); err != nil {
+		al.Errorf("%v", err)
+	}
+	// the request context is done when the browser is gone, the end of the session must be saved anyway
+	if err := al.serialConsoles.EndSession(context.Background(), session.ID, time.Now().UTC(), relay.sent, relay.received); err != nil {
+		al.Errorf("Failed to end serial console session %s: %v", session.ID, err)
+	}
+	al.Infof("Serial console session %s on %s of client %s closed", session.ID, device.Port, device.ClientID)
+}
+
+// handleListSerialSessions handles GET /clients/{client_id}/serial-sessions
+func (al *APIListener) handleListSerialSessions(w http.ResponseWriter, req *http.Request) {
+	clientID := mux.Vars(req)[routes.ParamClientID]
+
+	options := query.GetListOptions(req)
+	err := query.ValidateListOptions(options, serialconsoles.SupportedSessionSorts, serialconsoles.SupportedSessionFilters, nil, serialconsoles.SessionsPaginationConfig)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	options.Filters = append(options.Filters, query.FilterOption{
+		Column: []string{"client_id"},
+		Values: []string{clientID},
+	})
+	if len(options.Sorts) == 0 {
+		options.Sorts = []query.SortOption{serialconsoles.DefaultSessionSort}
+	}
+
+	sessions, err := al.serialConsoles.ListSessions(req.Context(), options)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	count, err := al.serialConsoles.CountSessions(req.Context(), options)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, &api.SuccessPayload{
+		Data: sessions,
+		Meta: api.NewMeta(count),
+	})
+}
+
+// handleGetSerialSessionRecording handles GET /clients/{client_id}/serial-sessions/{serial_session_id}/recording, it
+// returns the output of the device in asciicast v2 format. The recording of an open session is incomplete.
+func (al *APIListener) handleGetSerialSessionRecording(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	clientID := vars[routes.ParamClientID]
+	id := vars[routes.ParamSerialSessionID]
+
+	session, err := al.serialConsoles.GetSession(req.Context(), clientID, id)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if session == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("serial console session with id %s not found", id))
+		return
+	}
+	f, err := os.Open(serialconsoles.RecordingPath(al.serialRecordingDir, session.ID))
+	if os.IsNotExist(err) {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("recording of serial console session %s not found", id))
+		return
+	}
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	defer f.Close()
+
+	al.auditLog.Entry(auditlog.ApplicationClientSerialConsole, auditlog.ActionExport).
+		WithHTTPRequest(req).
+		WithClientID(clientID).
+		WithID(session.ID).
+		Save()
+
+	w.Header().Set("Content-Type", serialconsoles.RecordingContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", session.ID+".cast"))
+	http.ServeContent(w, req, "", session.StartedAt, f)
+}
+
+// getSerialDevice returns the device of the route, it sends the error response if not found.
+func (al *APIListener) getSerialDevice(w http.ResponseWriter, req *http.Request) (*serialconsoles.Device, bool) {
+	vars := mux.Vars(req)
+	id := vars[routes.ParamSerialDeviceID]
+
+	device, err := al.serialConsoles.GetDevice(req.Context(), vars[routes.ParamClientID], id)
+	if err != nil {
+		al.jsonError(w, err)
+		return nil, false
+	}
+	if device == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("serial device with id %s not found", id))
+		return nil, false
+	}
+	return device, true
+}
+
+// applySerialDeviceRequest sets the line settings given or the defaults 9600 8N1, it sends the error response if
+// they are invalid.
+func (al *APIListener) applySerialDeviceRequest(w http.ResponseWriter, device *serialconsoles.Device, reqBody serialDeviceRequest) bool {
+	line := comm.SerialLine{
+		BaudRate: reqBody.BaudRate,
+		DataBits: reqBody.DataBits,
+		Parity:   reqBody.Parity,
+		StopBits: reqBody.StopBits,
+	}
+	if line.BaudRate == 0 {
+		line.BaudRate = defaultSerialBaudRate
+	}
+	if line.DataBits == 0 {
+		line.DataBits = defaultSerialDataBits
+	}
+	if line.Parity == "" {
+		line.Parity = comm.SerialParityNone
+	}
+	if line.StopBits == 0 {
+		line.StopBits = defaultSerialStopBits
+	}
+	if err := line.Validate(); err != nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+
+	device.Name = reqBody.Name
+	device.BaudRate = line.BaudRate
+	device.DataBits = line.DataBits
+	device.Parity = line.Parity
+	device.StopBits = line.StopBits
+	return true
+}
@@ -0,0 +1,268 @@
+package chserver
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+
+	serialconsolesmigration "github.com/IOTech17/neo-rport/db/migration/serial_consoles"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/clients"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/killswitch"
+	"github.com/IOTech17/neo-rport/server/serialconsoles"
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/security"
+	"github.com/IOTech17/neo-rport/share/test"
+)
+
+// pipeChannel is an ssh channel backed by one end of a net.Pipe
+type pipeChannel struct {
+	net.Conn
+}
+
+func (c pipeChannel) CloseWrite() error {
+	return c.Close()
+}
+
+func (c pipeChannel) SendRequest(string, bool, []byte) (bool, error) {
+	return false, nil
+}
+
+func (c pipeChannel) Stderr() io.ReadWriter {
+	return nil
+}
+
+type newChannelMock struct {
+	// channelType defaults to comm.ChannelSerialConsole
+	channelType string
+	extraData   []byte
+	channel     ssh.Channel
+	rejected    ssh.RejectionReason
+}
+
+func (c *newChannelMock) Accept() (ssh.Channel, <-chan *ssh.Request, error) {
+	return c.channel, make(chan *ssh.Request), nil
+}
+
+func (c *newChannelMock) Reject(reason ssh.RejectionReason, message string) error {
+	c.rejected = reason
+	return nil
+}
+
+func (c *newChannelMock) ChannelType() string {
+	if c.channelType != "" {
+		return c.channelType
+	}
+	return comm.ChannelSerialConsole
+}
+
+func (c *newChannelMock) ExtraData() []byte {
+	return c.extraData
+}
+
+func TestSerialConsoles(t *testing.T) {
+	db, err := sqlite.New(":memory:", serialconsolesmigration.AssetNames(), serialconsolesmigration.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	defer db.Close()
+
+	c1 := clients.New(t).ID("client-1").Logger(testLog).Build()
+	connMock := test.NewConnMock()
+	connMock.ReturnOk = true
+	connMock.DoneChannel = make(chan bool, 1)
+	c1.SetConnection(connMock)
+
+	admin := &users.User{Username: "admin", Password: "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm", Groups: []string{users.Administrators}}
+	al := &APIListener{
+		Logger:      testLog,
+		bannedUsers: security.NewBanList(0),
+		apiSessions: newEmptyAPISessionCache(t),
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+				},
+			},
+			clientService:       clients.NewClientService(nil, nil, clients.NewClientRepository([]*clientdata.Client{c1}, &hour, testLog), testLog, nil),
+			clientGroupProvider: staticClientGroupProvider{},
+			serialConsoles:      serialconsoles.NewSqliteProvider(db),
+			serialConsoleOpens:  newSerialConsoleWaiters(),
+			serialRecordingDir:  t.TempDir(),
+		},
+		userService: users.NewAPIService(users.NewStaticProvider([]*users.User{admin}), false, 0, -1),
+	}
+	al.initRouter()
+	cl := &ClientListener{server: al.Server}
+
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, "/api/v1/clients/client-1"+path, strings.NewReader(body))
+		req.SetBasicAuth("admin", "pwd")
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, tc := range []struct {
+		body     string
+		wantCode int
+	}{
+		{`{"name":"switch"}`, http.StatusBadRequest},
+		{`{"port":"/dev/ttyUSB0","baud_rate":1234}`, http.StatusBadRequest},
+		{`{"port":"/dev/ttyUSB0","parity":"mark"}`, http.StatusBadRequest},
+		{`{"port":"/dev/ttyUSB0","stop_bits":3}`, http.StatusBadRequest},
+	} {
+		w := request(http.MethodPost, "/serial-devices", tc.body)
+		assert.Equal(t, tc.wantCode, w.Code, tc.body)
+	}
+
+	w := request(http.MethodPost, "/serial-devices", `{"name":"switch","port":"/dev/ttyUSB0"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Data serialconsoles.Device `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	device := created.Data
+	assert.Equal(t, comm.SerialLine{BaudRate: 9600, DataBits: 8, Parity: comm.SerialParityNone, StopBits: 1}, device.Line())
+	assert.Equal(t, "admin", device.CreatedBy)
+
+	w = request(http.MethodPost, "/serial-devices", `{"port":"/dev/ttyUSB0"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = request(http.MethodPut, "/serial-devices/"+device.ID, `{"port":"/dev/ttyUSB1"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = request(http.MethodPut, "/serial-devices/"+device.ID, `{"name":"switch","baud_rate":115200}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = request(http.MethodPut, "/serial-devices/unknown", `{"name":"switch"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = request(http.MethodGet, "/serial-devices", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"baud_rate":115200`)
+
+	// the client opens the port and the device prints a login prompt
+	deviceEnd, clientEnd := net.Pipe()
+	defer deviceEnd.Close()
+	go func() {
+		<-connMock.DoneChannel
+		name, _, payload := connMock.InputSendRequest()
+		assert.Equal(t, comm.RequestTypeOpenSerialConsole, name)
+		var req comm.OpenSerialConsoleRequest
+		assert.NoError(t, json.Unmarshal(payload, &req))
+		assert.Equal(t, "/dev/ttyUSB0", req.Port)
+		assert.Equal(t, 115200, req.BaudRate)
+
+		unrequested := &newChannelMock{extraData: []byte(req.ID)}
+		cl.handleSerialConsoleChannel(testLog, "other-client", unrequested)
+		assert.Equal(t, ssh.Prohibited, unrequested.rejected)
+
+		cl.handleSerialConsoleChannel(testLog, "client-1", &newChannelMock{extraData: []byte(req.ID), channel: pipeChannel{clientEnd}})
+		_, _ = deviceEnd.Write([]byte("login: "))
+	}()
+
+	s := httptest.NewServer(al.router)
+	defer s.Close()
+	ws, _, err := websocket.DefaultDialer.Dial(httpToWS(t, s.URL)+"/api/v1/ws/clients/client-1/serial-devices/"+device.ID+"/console", makeAuthHeader("admin", "pwd"))
+	require.NoError(t, err)
+	defer ws.Close()
+
+	msgType, msg, err := ws.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, websocket.BinaryMessage, msgType)
+	assert.Equal(t, "login: ", string(msg))
+
+	require.NoError(t, ws.WriteMessage(websocket.TextMessage, []byte("root\n")))
+	buf := make([]byte, 5)
+	_, err = io.ReadFull(deviceEnd, buf)
+	require.NoError(t, err)
+	assert.Equal(t, "root\n", string(buf))
+
+	// the port is closed by the client
+	require.NoError(t, deviceEnd.Close())
+	_, _, err = ws.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure), err)
+	assert.Empty(t, al.serialConsoleOpens.m)
+
+	var sessions struct {
+		Data []serialconsoles.Session `json:"data"`
+		Meta struct {
+			Count int `json:"count"`
+		} `json:"meta"`
+	}
+	require.Eventually(t, func() bool {
+		w = request(http.MethodGet, "/serial-sessions", "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &sessions))
+		return len(sessions.Data) == 1 && sessions.Data[0].EndedAt != nil
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1, sessions.Meta.Count)
+	session := sessions.Data[0]
+	assert.Equal(t, device.ID, session.DeviceID)
+	assert.Equal(t, "admin", session.Username)
+	assert.EqualValues(t, 5, session.BytesSent)
+	assert.EqualValues(t, 7, session.BytesReceived)
+
+	w = request(http.MethodGet, "/serial-sessions/"+session.ID+"/recording", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, serialconsoles.RecordingContentType, w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `"o","login: "]`)
+	assert.NotContains(t, w.Body.String(), "root", "the input must not be recorded")
+
+	w = request(http.MethodGet, "/serial-sessions/unknown/recording", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	// no console is opened to a quarantined client or while commands are disabled
+	consoleRequest := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/ws/clients/client-1/serial-devices/"+device.ID+"/console", nil)
+		req.SetBasicAuth("admin", "pwd")
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+	c1.SetQuarantine(&clientdata.Quarantine{Reason: "compromised"})
+	w = consoleRequest()
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "client with id client-1 is quarantined (reason = compromised)")
+	c1.SetQuarantine(nil)
+	al.killSwitches, err = killswitch.Open(t.TempDir())
+	require.NoError(t, err)
+	_, err = al.killSwitches.Set(killswitch.CapabilityCommands, true, "incident", "admin")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, consoleRequest().Code)
+	assert.Len(t, connMock.DoneChannel, 0, "the client must not be asked to open the port")
+
+	w = request(http.MethodDelete, "/serial-devices/"+device.ID, "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	w = request(http.MethodDelete, "/serial-devices/"+device.ID, "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestSerialConsoleNotSupportedByClient(t *testing.T) {
+	c1 := clients.New(t).ID("client-1").Logger(testLog).Build()
+	connMock := test.NewConnMock()
+	connMock.ReturnOk = false
+	connMock.ReturnResponsePayload = []byte("unknown request")
+	c1.SetConnection(connMock)
+
+	al := &APIListener{
+		Logger: testLog,
+		Server: &Server{
+			serialConsoleOpens: newSerialConsoleWaiters(),
+		},
+	}
+	_, err := al.openSerialConsole(context.Background(), c1, &serialconsoles.Device{Port: "COM3"})
+	assert.EqualError(t, err, "client does not support serial consoles")
+	assert.Empty(t, al.serialConsoleOpens.m)
+}
@@ -0,0 +1,256 @@
+package chserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/api/session"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/bearer"
+	"github.com/IOTech17/neo-rport/server/notifications"
+	"github.com/IOTech17/neo-rport/server/routes"
+	chshare "github.com/IOTech17/neo-rport/share"
+	"github.com/IOTech17/neo-rport/share/refs"
+)
+
+const loginNotificationType refs.IdentifiableType = "login"
+
+const (
+	sessionPolicySourceServer = "server"
+	sessionPolicySourceUser   = "user"
+)
+
+type sessionPolicyPayload struct {
+	Policy      session.ConcurrentSessionsPolicy `json:"policy"`
+	MaxSessions int                              `json:"max_sessions"`
+	Source      string                           `json:"source"`
+}
+
+// getSessionPolicy returns the policy of the user, falling back to the one of the server config.
+func (al *APIListener) getSessionPolicy(ctx context.Context, username string) (sessionPolicyPayload, error) {
+	if al.sessionPolicies != nil {
+		found, policy, err := al.sessionPolicies.GetPolicy(ctx, username)
+		if err != nil {
+			return sessionPolicyPayload{}, err
+		}
+		if found {
+			return sessionPolicyPayload{
+				Policy:      policy.Policy,
+				MaxSessions: policy.MaxSessions,
+				Source:      sessionPolicySourceUser,
+			}, nil
+		}
+	}
+
+	policy := al.config.API.ConcurrentSessionsPolicy
+	if policy == "" {
+		policy = session.ConcurrentSessionsAllow
+	}
+	return sessionPolicyPayload{
+		Policy:      policy,
+		MaxSessions: al.config.API.MaxConcurrentSessions,
+		Source:      sessionPolicySourceServer,
+	}, nil
+}
+
+// applySessionPolicy must be called before a new login session is created. Depending on the policy it rejects the
+// login or ends the least recently used sessions of the user.
+func (al *APIListener) applySessionPolicy(req *http.Request, username string) error {
+	ctx := req.Context()
+	policy, err := al.getSessionPolicy(ctx, username)
+	if err != nil {
+		return err
+	}
+	if policy.Policy == session.ConcurrentSessionsAllow {
+		return nil
+	}
+
+	allSessions, err := al.apiSessions.GetAllByUser(ctx, username)
+	if err != nil {
+		return err
+	}
+	// the 2fa session the login is finished with doesn't count
+	currentID := currentSessionID(req, al.config.API.JWTSecret)
+	sessions := make([]session.APISession, 0, len(allSessions))
+	for _, s := range allSessions {
+		if s.SessionID != currentID {
+			sessions = append(sessions, s)
+		}
+	}
+
+	toKick := session.SessionsToKick(sessions, policy.MaxSessions)
+	if len(toKick) == 0 {
+		return nil
+	}
+
+	if policy.Policy == session.ConcurrentSessionsLimit {
+		return errors2.APIError{
+			HTTPStatus: http.StatusConflict,
+			Message: fmt.Sprintf(
+				"maximum number of %d concurrent sessions reached, log out from another session first",
+				policy.MaxSessions,
+			),
+		}
+	}
+
+	for _, s := range toKick {
+		if err := al.apiSessions.DeleteByID(ctx, username, s.SessionID); err != nil {
+			return err
+		}
+		al.Infof("Ended session %d of user %q from %s to make room for a new login", s.SessionID, username, s.IPAddress)
+		al.auditLog.Entry(auditlog.ApplicationAuthAPISession, auditlog.ActionDelete).
+			WithHTTPRequest(req).
+			WithID(username).
+			WithResponse(s).
+			Save()
+	}
+	return nil
+}
+
+func currentSessionID(req *http.Request, jwtSecret string) int64 {
+	tokenStr, ok := bearer.GetBearerToken(req)
+	if !ok {
+		return 0
+	}
+	tokCtx, err := bearer.ParseToken(tokenStr, jwtSecret)
+	if err != nil {
+		return 0
+	}
+	return tokCtx.AppClaims.SessionID
+}
+
+// notifyNewLogin records the device of a successful login and if enabled, sends a message to the user
+// if the login comes from an ip address or a user agent not seen before.
+func (al *APIListener) notifyNewLogin(req *http.Request, username string) {
+	// the devices are also the previous logins of the login policy
+	if (!al.config.API.NotifyNewLogins && al.loginPolicy == nil) || al.sessionPolicies == nil {
+		return
+	}
+
+	ctx := req.Context()
+	now := time.Now()
+	device := session.LoginDevice{
+		Username:    username,
+		IPAddress:   chshare.RemoteIP(req),
+		UserAgent:   req.UserAgent(),
+		FirstSeenAt: now,
+		LastSeenAt:  now,
+	}
+	status, err := al.sessionPolicies.TouchLoginDevice(ctx, device)
+	if err != nil {
+		al.Errorf("Failed to record login device of user %q: %v", username, err)
+		return
+	}
+	if !al.config.API.NotifyNewLogins || status.FirstLogin || (!status.NewIP && !status.NewDevice) {
+		return
+	}
+
+	user, err := al.userService.GetByUsername(username)
+	if err != nil {
+		al.Errorf("Failed to get user %q to notify about a new login: %v", username, err)
+		return
+	}
+	if user == nil || !strings.Contains(user.TwoFASendTo, "@") {
+		al.Debugf("No email address to notify user %q about a new login", username)
+		return
+	}
+
+	notification := notifications.NotificationData{
+		Target:      "smtp",
+		Recipients:  []string{user.TwoFASendTo},
+		Subject:     fmt.Sprintf("New login to your %s account", al.branding.Get().GetProductName()),
+		Content:     newLoginMessage(username, device, status, al.config.API.Address),
+		ContentType: notifications.ContentTypeTextPlain,
+		Severity:    notifications.SeverityHigh,
+	}
+	refID := refs.NewIdentifiable(loginNotificationType, username)
+	if _, err := al.notificationDigests.Dispatch(ctx, refID, notification); err != nil {
+		al.Errorf("Failed to send new login notification to user %q: %v", username, err)
+	}
+}
+
+func newLoginMessage(username string, device session.LoginDevice, status session.LoginDeviceStatus, server string) string {
+	var unseen []string
+	if status.NewIP {
+		unseen = append(unseen, "ip address")
+	}
+	if status.NewDevice {
+		unseen = append(unseen, "device")
+	}
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "There was a login to the rport account %q from a new %s.\n\n", username, strings.Join(unseen, " and "))
+	fmt.Fprintf(b, "Server: %s\n", server)
+	fmt.Fprintf(b, "Time: %s\n", device.LastSeenAt.UTC().Format(time.RFC1123))
+	fmt.Fprintf(b, "IP address: %s\n", device.IPAddress)
+	fmt.Fprintf(b, "User agent: %s\n\n", device.UserAgent)
+	b.WriteString("If this wasn't you, change your password and ask your administrator to end the sessions of your account.\n")
+	return b.String()
+}
+
+func (al *APIListener) handleGetUserSessionPolicy(w http.ResponseWriter, req *http.Request) {
+	userID := mux.Vars(req)[routes.ParamUserID]
+
+	policy, err := al.getSessionPolicy(req.Context(), userID)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(policy))
+}
+
+func (al *APIListener) handlePutUserSessionPolicy(w http.ResponseWriter, req *http.Request) {
+	userID := mux.Vars(req)[routes.ParamUserID]
+
+	var policy session.UserPolicy
+	if err := parseRequestBody(req.Body, &policy); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	policy.Username = userID
+	if err := policy.Policy.Validate(policy.MaxSessions); err != nil {
+		al.jsonErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := al.sessionPolicies.SavePolicy(req.Context(), policy); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationAuthUserSessionPolicy, auditlog.ActionUpdate).
+		WithHTTPRequest(req).
+		WithID(userID).
+		WithRequest(policy).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(sessionPolicyPayload{
+		Policy:      policy.Policy,
+		MaxSessions: policy.MaxSessions,
+		Source:      sessionPolicySourceUser,
+	}))
+}
+
+func (al *APIListener) handleDeleteUserSessionPolicy(w http.ResponseWriter, req *http.Request) {
+	userID := mux.Vars(req)[routes.ParamUserID]
+
+	if err := al.sessionPolicies.DeletePolicy(req.Context(), userID); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationAuthUserSessionPolicy, auditlog.ActionDelete).
+		WithHTTPRequest(req).
+		WithID(userID).
+		Save()
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,122 @@
+package chserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/server/api/session"
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/share/security"
+)
+
+func setupTestAPIListenerSessionPolicy(t *testing.T, policy session.ConcurrentSessionsPolicy, maxSessions int) *APIListener {
+	t.Helper()
+
+	storage, err := session.NewSqliteProvider(":memory:", DataSourceOptions)
+	require.NoError(t, err)
+	sessionCache, err := session.NewCache(context.Background(), time.Hour, cleanupAPISessionsInterval, storage, nil)
+	require.NoError(t, err)
+
+	user := &users.User{
+		Username: "admin",
+		Password: "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm",
+		Groups:   []string{users.Administrators},
+	}
+	al := &APIListener{
+		Logger: testLog,
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes:          1024 * 1024,
+					ConcurrentSessionsPolicy: policy,
+					MaxConcurrentSessions:    maxSessions,
+				},
+			},
+		},
+		bannedUsers:     security.NewBanList(0),
+		userService:     users.NewAPIService(users.NewStaticProvider([]*users.User{user}), false, 0, -1),
+		apiSessions:     sessionCache,
+		sessionPolicies: storage,
+	}
+	al.initRouter()
+	return al
+}
+
+func login(al *APIListener, userAgent string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/login", nil)
+	req.SetBasicAuth("admin", "pwd")
+	req.Header.Set("User-Agent", userAgent)
+	al.router.ServeHTTP(w, req)
+	return w
+}
+
+func TestLoginConcurrentSessionsLimit(t *testing.T) {
+	al := setupTestAPIListenerSessionPolicy(t, session.ConcurrentSessionsLimit, 2)
+
+	assert.Equal(t, http.StatusOK, login(al, "first").Code)
+	assert.Equal(t, http.StatusOK, login(al, "second").Code)
+
+	w := login(al, "third")
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "maximum number of 2 concurrent sessions reached")
+
+	sessions, err := al.apiSessions.GetAllByUser(context.Background(), "admin")
+	require.NoError(t, err)
+	assert.Len(t, sessions, 2)
+}
+
+func TestLoginConcurrentSessionsKickOldest(t *testing.T) {
+	al := setupTestAPIListenerSessionPolicy(t, session.ConcurrentSessionsKickOldest, 2)
+
+	for _, userAgent := range []string{"first", "second", "third"} {
+		require.Equal(t, http.StatusOK, login(al, userAgent).Code)
+	}
+
+	sessions, err := al.apiSessions.GetAllByUser(context.Background(), "admin")
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, "second", sessions[0].UserAgent)
+	assert.Equal(t, "third", sessions[1].UserAgent)
+}
+
+func TestHandleUserSessionPolicy(t *testing.T) {
+	al := setupTestAPIListenerSessionPolicy(t, session.ConcurrentSessionsAllow, 0)
+
+	do := func(method, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, "/api/v1/users/admin/session-policy", strings.NewReader(body))
+		req.SetBasicAuth("admin", "pwd")
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodGet, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data":{"policy":"allow","max_sessions":0,"source":"server"}}`, w.Body.String())
+
+	w = do(http.MethodPut, `{"policy":"limit","max_sessions":0}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = do(http.MethodPut, `{"policy":"limit","max_sessions":1}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data":{"policy":"limit","max_sessions":1,"source":"user"}}`, w.Body.String())
+
+	assert.Equal(t, http.StatusOK, login(al, "first").Code)
+	assert.Equal(t, http.StatusConflict, login(al, "second").Code)
+
+	w = do(http.MethodDelete, "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	w = do(http.MethodGet, "")
+	assert.JSONEq(t, `{"data":{"policy":"allow","max_sessions":0,"source":"server"}}`, w.Body.String())
+	assert.Equal(t, http.StatusOK, login(al, "second").Code)
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/IOTech17/neo-rport/server/api"
 	chshare "github.com/IOTech17/neo-rport/share"
+	"github.com/IOTech17/neo-rport/share/fips"
 )
 
 func (al *APIListener) handleGetStatus(w http.ResponseWriter, req *http.Request) {
@@ -16,12 +17,7 @@ func (al *APIListener) handleGetStatus(w http.ResponseWriter, req *http.Request)
 		return
 	}
 
-	var twoFADelivery string
-	if al.twoFASrv.MsgSrv != nil {
-		twoFADelivery = al.twoFASrv.MsgSrv.DeliveryMethod()
-	} else if al.config.API.TotPEnabled {
-		twoFADelivery = "totp_authenticator_app"
-	}
+	clientVersions, clientsOutdated := al.clientVersionsStatus()
 
 	response := api.NewSuccessPayload(map[string]interface{}{
 		"version":                   chshare.BuildVersion,
@@ -35,7 +31,7 @@ func (al *APIListener) handleGetStatus(w http.ResponseWriter, req *http.Request)
 		"users_auth_source":         al.userService.GetProviderType(),
 		"group_permissions_enabled": al.userService.SupportsGroupPermissions(),
 		"two_fa_enabled":            al.config.API.IsTwoFAOn() || al.config.API.TotPEnabled,
-		"two_fa_delivery_method":    twoFADelivery,
+		"two_fa_delivery_method":    al.twoFADeliveryMethod(),
 		"auditlog":                  al.auditLog.Status(),
 		"auth_header":               al.config.API.AuthHeader != "",
 		"tunnel_host":               al.config.Server.InternalTunnelProxyConfig.Host,
@@ -45,7 +41,24 @@ func (al *APIListener) handleGetStatus(w http.ResponseWriter, req *http.Request)
 		"used_ports":                al.config.Server.UsedPortsRaw,
 		"monitoring_enabled":        al.config.Monitoring.Enabled,
 		"password_min_length":       al.config.API.PasswordMinLength,
+		"fips_mode":                 fips.Enabled(),
+		"boring_crypto":             fips.BoringCrypto(),
+		"client_min_version":        al.config.Server.VersionPolicy.MinVersion,
+		"client_version_action":     al.config.Server.VersionPolicy.Action,
+		"client_versions":           clientVersions,
+		"clients_outdated":          clientsOutdated,
+		"banner":                    al.config.Server.Banner,
 	})
 
 	al.writeJSONResponse(w, http.StatusOK, response)
 }
+
+func (al *APIListener) twoFADeliveryMethod() string {
+	if al.twoFASrv.MsgSrv != nil {
+		return al.twoFASrv.MsgSrv.DeliveryMethod()
+	}
+	if al.config.API.TotPEnabled {
+		return "totp_authenticator_app"
+	}
+	return ""
+}
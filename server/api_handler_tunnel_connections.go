@@ -0,0 +1,111 @@
+package chserver
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/routes"
+	"github.com/IOTech17/neo-rport/server/tunnelconns"
+	"github.com/IOTech17/neo-rport/share/query"
+)
+
+// handleGetClientTunnelConnections handles GET /clients/{client_id}/tunnel-connections
+func (al *APIListener) handleGetClientTunnelConnections(w http.ResponseWriter, req *http.Request) {
+	cid := mux.Vars(req)[routes.ParamClientID]
+	al.listTunnelConnections(w, req, &query.FilterOption{
+		Column: []string{"client_id"},
+		Values: []string{cid},
+	})
+}
+
+// handleGetTunnelConnections handles GET /tunnel-connections
+func (al *APIListener) handleGetTunnelConnections(w http.ResponseWriter, req *http.Request) {
+	al.listTunnelConnections(w, req, nil)
+}
+
+func (al *APIListener) listTunnelConnections(w http.ResponseWriter, req *http.Request, forced *query.FilterOption) {
+	if al.tunnelConns == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, "Tunnel connection log disabled. Set 'tunnel_connections_retention' to enable it.")
+		return
+	}
+
+	options := query.GetListOptions(req)
+	err := query.ValidateListOptions(options, tunnelconns.SupportedSorts, tunnelconns.SupportedFilters, nil, tunnelconns.PaginationConfig)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if err := tunnelconns.ConvertTimeFilters(options); err != nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if forced != nil {
+		options.Filters = append(options.Filters, *forced)
+	}
+	if len(options.Sorts) == 0 {
+		options.Sorts = []query.SortOption{tunnelconns.DefaultSort}
+	}
+
+	connections, err := al.tunnelConns.List(req.Context(), options)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	count, err := al.tunnelConns.Count(req.Context(), options)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, &api.SuccessPayload{
+		Data: connections,
+		Meta: api.NewMeta(count),
+	})
+}
+
+// handleGetClientTunnelConnectionRequests handles GET /clients/{client_id}/tunnel-connections/{connection_id}/requests
+func (al *APIListener) handleGetClientTunnelConnectionRequests(w http.ResponseWriter, req *http.Request) {
+	vars := mux.Vars(req)
+	cid := vars[routes.ParamClientID]
+	if al.tunnelConns == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, "Tunnel connection log disabled. Set 'tunnel_connections_retention' to enable it.")
+		return
+	}
+
+	id, err := strconv.ParseInt(vars["connection_id"], 10, 64)
+	if err != nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "Invalid connection id.")
+		return
+	}
+	options := query.GetListOptions(req)
+	err = query.ValidateListOptions(options, nil, nil, nil, tunnelconns.PaginationConfig)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	connection, err := al.tunnelConns.Get(req.Context(), id)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if connection == nil || connection.ClientID != cid {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("Tunnel connection with id=%d not found.", id))
+		return
+	}
+
+	requests, err := al.tunnelConns.ListRequests(req.Context(), id, options.Pagination)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, &api.SuccessPayload{
+		Data: requests,
+		Meta: api.NewMeta(connection.HTTPRequests),
+	})
+}
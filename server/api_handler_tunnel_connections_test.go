@@ -0,0 +1,127 @@
+package chserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/db/migration/tunnel_connections"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/clients"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/tunnelconns"
+	"github.com/IOTech17/neo-rport/share/security"
+)
+
+func TestHandleGetTunnelConnections(t *testing.T) {
+	ctx := context.Background()
+	c1 := clients.New(t).ID("c1").AllowedUserGroups([]string{"tenant-a"}).Logger(testLog).Build()
+	c2 := clients.New(t).ID("c2").AllowedUserGroups([]string{"tenant-b"}).Logger(testLog).Build()
+
+	db, err := sqlite.New(":memory:", tunnel_connections.AssetNames(), tunnel_connections.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	provider := tunnelconns.NewSqliteProvider(db)
+	defer provider.Close()
+	started := time.Date(2026, 10, 13, 10, 0, 0, 0, time.UTC)
+	for _, c := range []*tunnelconns.Connection{
+		{ClientID: "c1", TunnelID: "1", Protocol: "tcp", SourceIP: "192.0.2.10", StartedAt: started},
+		{ClientID: "c1", TunnelID: "1", Protocol: "tcp", SourceIP: "192.0.2.20", StartedAt: started.Add(time.Hour)},
+		{ClientID: "c2", TunnelID: "1", Protocol: "tcp", SourceIP: "192.0.2.10", StartedAt: started.Add(2 * time.Hour)},
+	} {
+		require.NoError(t, provider.Insert(ctx, c))
+	}
+
+	// password of all users is "pwd"
+	password := "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm"
+	al := &APIListener{
+		Logger:      testLog,
+		bannedUsers: security.NewBanList(0),
+		apiSessions: newEmptyAPISessionCache(t),
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+				},
+			},
+			clientService:       clients.NewClientService(nil, nil, clients.NewClientRepository([]*clientdata.Client{c1, c2}, nil, testLog), testLog, nil),
+			clientGroupProvider: mockClientGroupProvider{},
+			tunnelConns:         provider,
+		},
+		userService: users.NewAPIService(users.NewStaticProvider([]*users.User{
+			{Username: "admin", Password: password, Groups: []string{users.Administrators}},
+			{Username: "jane", Password: password, Groups: []string{"tenant-a"}},
+		}), false, 0, -1),
+	}
+	al.initRouter()
+
+	list := func(username, url string) (*httptest.ResponseRecorder, []*tunnelconns.Connection) {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req.SetBasicAuth(username, "pwd")
+		al.router.ServeHTTP(w, req)
+		var result struct {
+			Data []*tunnelconns.Connection `json:"data"`
+		}
+		if w.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		}
+		return w, result.Data
+	}
+	ids := func(connections []*tunnelconns.Connection) []int64 {
+		var result []int64
+		for _, c := range connections {
+			result = append(result, c.ID)
+		}
+		return result
+	}
+
+	w, connections := list("jane", "/api/v1/clients/c1/tunnel-connections")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, []int64{2, 1}, ids(connections))
+	assert.Contains(t, w.Body.String(), `"meta":{"count":2}`)
+
+	// the client filter can't be lifted
+	w, connections = list("jane", "/api/v1/clients/c1/tunnel-connections?filter[client_id]=c2&filter[started_at][until]=2026-10-13T10:30:00Z")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Empty(t, connections)
+
+	w, _ = list("jane", "/api/v1/clients/c2/tunnel-connections")
+	assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+
+	w, _ = list("jane", "/api/v1/tunnel-connections")
+	assert.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+
+	w, connections = list("admin", "/api/v1/tunnel-connections?filter[source_ip]=192.0.2.10&sort=started_at")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, []int64{1, 3}, ids(connections))
+
+	w, _ = list("admin", "/api/v1/tunnel-connections?filter[started_at][since]=tuesday")
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	require.NoError(t, provider.InsertRequest(ctx, &tunnelconns.Request{ConnectionID: 2, Time: started, Method: "GET", Path: "/", Proto: "HTTP/1.1", Status: 200}))
+	require.NoError(t, provider.InsertRequest(ctx, &tunnelconns.Request{ConnectionID: 2, Time: started, Method: "POST", Path: "/login", Proto: "HTTP/1.1", Status: 302}))
+	w, _ = list("jane", "/api/v1/clients/c1/tunnel-connections/2/requests?page[limit]=1")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), `"path":"/","proto":"HTTP/1.1","status":200`)
+	assert.NotContains(t, w.Body.String(), `/login`)
+	assert.Contains(t, w.Body.String(), `"meta":{"count":2}`)
+
+	// the connection must belong to the client
+	w, _ = list("admin", "/api/v1/clients/c1/tunnel-connections/3/requests")
+	assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+
+	w, _ = list("admin", "/api/v1/clients/c1/tunnel-connections/abc/requests")
+	assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	al.tunnelConns = nil
+	w, _ = list("admin", "/api/v1/clients/c1/tunnel-connections")
+	assert.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+}
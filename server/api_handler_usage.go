@@ -0,0 +1,119 @@
+package chserver
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/usage"
+)
+
+type usageReport struct {
+	*usage.Period
+	GroupBy usage.GroupBy    `json:"group_by"`
+	Usage   []*usage.Summary `json:"usage"`
+}
+
+// handleGetUsage handles GET /usage, it exports the usage of a billing period as JSON or CSV.
+func (al *APIListener) handleGetUsage(w http.ResponseWriter, req *http.Request) {
+	params := req.URL.Query()
+	period := params.Get("period")
+	if period == "" {
+		period = usage.PeriodOf(time.Now())
+	}
+	if _, _, err := usage.ParsePeriod(period); err != nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	groupBy, err := usage.ParseGroupBy(params.Get("group_by"))
+	if err != nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	format := params.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("Invalid format %q, expected one of: json, csv.", format))
+		return
+	}
+
+	p, err := al.usage.GetPeriod(req.Context(), period)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	records, err := al.usage.List(req.Context(), period)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	summaries := usage.Aggregate(period, records, groupBy)
+
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", "attachment; filename="+strconv.Quote(fmt.Sprintf("usage-%s-%s.csv", period, groupBy)))
+		w.WriteHeader(http.StatusOK)
+		if err := usage.WriteCSV(w, groupBy, summaries); err != nil {
+			al.Errorf("Failed to write usage: %v", err)
+		}
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(usageReport{
+		Period:  p,
+		GroupBy: groupBy,
+		Usage:   summaries,
+	}))
+}
+
+// handleListUsagePeriods handles GET /usage/periods
+func (al *APIListener) handleListUsagePeriods(w http.ResponseWriter, req *http.Request) {
+	periods, err := al.usage.ListPeriods(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(periods))
+}
+
+// handleCloseUsagePeriod handles POST /usage/periods/{period}/close, it locks the usage of an ended period for billing.
+func (al *APIListener) handleCloseUsagePeriod(w http.ResponseWriter, req *http.Request) {
+	period := mux.Vars(req)["period"]
+	_, end, err := usage.ParsePeriod(period)
+	if err != nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	now := time.Now().Truncate(time.Second).UTC()
+	if now.Before(end) {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, fmt.Sprintf("period %s can't be closed before it ends", period))
+		return
+	}
+
+	username := api.GetUser(req.Context(), al.Logger)
+	closed, err := al.usage.ClosePeriod(req.Context(), period, username, now)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if !closed {
+		al.jsonErrorResponseWithTitle(w, http.StatusConflict, fmt.Sprintf("period %s is already closed", period))
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationUsagePeriod, auditlog.ActionClose).
+		WithHTTPRequest(req).
+		WithID(period).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(&usage.Period{
+		Period:   period,
+		ClosedAt: &now,
+		ClosedBy: username,
+	}))
+}
@@ -0,0 +1,180 @@
+package chserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	jobsmigration "github.com/IOTech17/neo-rport/db/migration/jobs"
+	usagemigration "github.com/IOTech17/neo-rport/db/migration/usage"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	"github.com/IOTech17/neo-rport/server/api/jobs"
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/cgroups"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/clients"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/monitoring"
+	"github.com/IOTech17/neo-rport/server/test/jb"
+	"github.com/IOTech17/neo-rport/server/usage"
+	"github.com/IOTech17/neo-rport/share/security"
+)
+
+func TestUsage(t *testing.T) {
+	ctx := context.Background()
+	c1 := clients.New(t).ID("c1").AllowedUserGroups([]string{"tenant-a"}).Logger(testLog).Build()
+	c2 := clients.New(t).ID("c2").AllowedUserGroups([]string{"tenant-b"}).Logger(testLog).Build()
+	c3 := clients.New(t).ID("c3").DisconnectedDuration(time.Hour).Logger(testLog).Build()
+
+	jobsDB, err := sqlite.New(":memory:", jobsmigration.AssetNames(), jobsmigration.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	jobProvider := jobs.NewSqliteProvider(jobsDB, testLog)
+	defer jobProvider.Close()
+	usageDB, err := sqlite.New(":memory:", usagemigration.AssetNames(), usagemigration.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	usageProvider := usage.NewSqliteProvider(usageDB)
+	defer usageProvider.Close()
+
+	// password of all users is "pwd"
+	password := "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm"
+	al := &APIListener{
+		Logger:      testLog,
+		bannedUsers: security.NewBanList(0),
+		apiSessions: newEmptyAPISessionCache(t),
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+				},
+			},
+			clientService: clients.NewClientService(nil, nil, clients.NewClientRepository([]*clientdata.Client{c1, c2, c3}, nil, testLog), testLog, nil),
+			clientGroupProvider: staticClientGroupProvider{groups: []*cgroups.ClientGroup{{
+				ID:                "routers",
+				Params:            &cgroups.ClientParams{ClientID: &cgroups.ParamValues{"c1", "c3"}},
+				AllowedUserGroups: []string{"tenant-c"},
+			}}},
+			jobProvider:       jobProvider,
+			monitoringService: monitoring.NewService(&monitoring.DBProviderMock{Storage: map[string]int64{"c3": 1000}}, testLog),
+			usage:             usageProvider,
+		},
+		userService: users.NewAPIService(users.NewStaticProvider([]*users.User{
+			{Username: "admin", Password: password, Groups: []string{users.Administrators}},
+			{Username: "jane", Password: password, Groups: []string{"tenant-a"}},
+		}), false, 0, -1),
+	}
+	al.initRouter()
+
+	now := time.Date(2025, 10, 31, 23, 59, 0, 0, time.UTC)
+	task := newUsageMeterTask(testLog, al.Server)
+	task.now = func() time.Time { return now }
+
+	require.NoError(t, jobProvider.SaveJob(jb.New(t).ClientID("c1").StartedAt(now.Add(-30*time.Second)).Build()))
+	c1.Traffic().Add(100, 200)
+	require.NoError(t, task.Run(ctx))
+
+	// the next run is in the next period
+	now = now.Add(2 * time.Minute)
+	require.NoError(t, jobProvider.SaveJob(jb.New(t).ClientID("c1").StartedAt(now.Add(-30*time.Second)).Build()))
+	c1.Traffic().Add(10, 20)
+	require.NoError(t, task.Run(ctx))
+
+	october, err := usageProvider.List(ctx, "2025-10")
+	require.NoError(t, err)
+	require.Len(t, october, 3)
+	assert.Equal(t, "c1", october[0].ClientID)
+	assert.EqualValues(t, 120, october[0].ConnectedSeconds)
+	assert.EqualValues(t, 100, october[0].TunnelBytesSent)
+	assert.EqualValues(t, 200, october[0].TunnelBytesReceived)
+	assert.EqualValues(t, 1, october[0].Jobs)
+	// the storage of the job results
+	c1Storage := october[0].StorageBytes
+	assert.Greater(t, c1Storage, int64(0))
+	assert.Equal(t, []string{"tenant-a", "tenant-c"}, []string(october[0].UserGroups))
+	assert.Equal(t, []string{"routers"}, []string(october[0].ClientGroups))
+	assert.EqualValues(t, 120, october[1].ConnectedSeconds)
+	assert.Equal(t, "c3", october[2].ClientID)
+	assert.EqualValues(t, 0, october[2].ConnectedSeconds)
+	assert.EqualValues(t, 1000, october[2].StorageBytes)
+
+	november, err := usageProvider.List(ctx, "2025-11")
+	require.NoError(t, err)
+	require.Len(t, november, 2)
+	assert.EqualValues(t, 60, november[0].ConnectedSeconds)
+	assert.EqualValues(t, 10, november[0].TunnelBytesSent)
+	assert.EqualValues(t, 1, november[0].Jobs)
+
+	request := func(method, url, username string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, url, nil)
+		req.SetBasicAuth(username, "pwd")
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("export json", func(t *testing.T) {
+		w := request(http.MethodGet, "/api/v1/usage?period=2025-10&group_by=user_group", "admin")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var res struct {
+			Data usageReport `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		assert.Equal(t, "2025-10", res.Data.Period.Period)
+		assert.Nil(t, res.Data.ClosedAt)
+		require.Len(t, res.Data.Usage, 3)
+		assert.Equal(t, "tenant-a", res.Data.Usage[0].Group)
+		assert.Equal(t, "tenant-c", res.Data.Usage[2].Group)
+		assert.Equal(t, 2, res.Data.Usage[2].Clients)
+		assert.Equal(t, c1Storage+1000, res.Data.Usage[2].StorageBytes)
+	})
+
+	t.Run("export csv", func(t *testing.T) {
+		w := request(http.MethodGet, "/api/v1/usage?period=2025-10&group_by=client_group&format=csv", "admin")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "text/csv", w.Header().Get("Content-Type"))
+		assert.Equal(t, "period,client_group,clients,client_hours,tunnel_bytes_sent,tunnel_bytes_received,jobs,storage_bytes\n"+
+			fmt.Sprintf("2025-10,routers,2,0.03,100,200,1,%d\n", c1Storage+1000), w.Body.String())
+	})
+
+	t.Run("invalid params", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, request(http.MethodGet, "/api/v1/usage?period=10-2026", "admin").Code)
+		assert.Equal(t, http.StatusBadRequest, request(http.MethodGet, "/api/v1/usage?group_by=os", "admin").Code)
+		assert.Equal(t, http.StatusBadRequest, request(http.MethodGet, "/api/v1/usage?format=xml", "admin").Code)
+	})
+
+	t.Run("admins only", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, request(http.MethodGet, "/api/v1/usage", "jane").Code)
+		assert.Equal(t, http.StatusForbidden, request(http.MethodPost, "/api/v1/usage/periods/2025-10/close", "jane").Code)
+	})
+
+	t.Run("close period", func(t *testing.T) {
+		w := request(http.MethodPost, "/api/v1/usage/periods/2099-01/close", "admin")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = request(http.MethodPost, "/api/v1/usage/periods/2025-10/close", "admin")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		w = request(http.MethodPost, "/api/v1/usage/periods/2025-10/close", "admin")
+		assert.Equal(t, http.StatusConflict, w.Code)
+
+		err := usageProvider.Add(ctx, "2025-10", []*usage.Record{{ClientID: "c1", ConnectedSeconds: 60}})
+		assert.ErrorIs(t, err, usage.ErrPeriodClosed)
+
+		w = request(http.MethodGet, "/api/v1/usage/periods", "admin")
+		require.Equal(t, http.StatusOK, w.Code)
+		var res struct {
+			Data []*usage.Period `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		require.Len(t, res.Data, 2)
+		assert.Equal(t, "2025-11", res.Data[0].Period)
+		assert.Nil(t, res.Data[0].ClosedAt)
+		assert.Equal(t, "2025-10", res.Data[1].Period)
+		assert.Equal(t, "admin", res.Data[1].ClosedBy)
+	})
+}
@@ -29,6 +29,8 @@ type UserPayload struct {
 	EffectiveUserPermissions     map[string]bool              `json:"effective_user_permissions"`
 	EffectiveExtendedPermissions EffectiveExtendedPermissions `json:"effective_extended_permissions"`
 	GroupPermissionsEnabled      bool                         `json:"group_permissions_enabled"`
+	// ImpersonatedBy is set if an admin acts as the user.
+	ImpersonatedBy string `json:"impersonated_by,omitempty"`
 }
 
 type EffectiveExtendedPermissions struct {
@@ -257,7 +259,26 @@ func (al *APIListener) handleDeleteAllUserAPISessions(w http.ResponseWriter, req
 
 	ctx := req.Context()
 
-	err := al.apiSessions.DeleteAllByUser(ctx, userID)
+	dryRun, err := parseDryRun(req)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	if dryRun {
+		sessions, err := al.apiSessions.GetAllByUser(ctx, userID)
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
+		affected := make([]affectedResource, 0, len(sessions))
+		for _, s := range sessions {
+			affected = append(affected, affectedResource{Type: "api_session", ID: strconv.FormatInt(s.SessionID, 10), Change: changeDelete})
+		}
+		al.writeDryRunResponse(w, affected)
+		return
+	}
+
+	err = al.apiSessions.DeleteAllByUser(ctx, userID)
 	if err != nil {
 		titleMsg := fmt.Sprintf("unable to delete all sessions for user \"%s\"", userID)
 		al.jsonErrorResponseWithDetail(w, http.StatusInternalServerError, "", titleMsg, err.Error())
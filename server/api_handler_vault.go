@@ -242,3 +242,124 @@ func (al *APIListener) handleVaultDeleteValue(w http.ResponseWriter, req *http.R
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+type vaultCheckoutResponse struct {
+	vault.StoredValue
+	Checkout vault.Checkout `json:"checkout"`
+}
+
+func (al *APIListener) handleVaultCheckout(w http.ResponseWriter, req *http.Request) {
+	id, ok := al.readVaultValueID(w, req)
+	if !ok {
+		return
+	}
+
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	var checkoutReq vault.CheckoutRequest
+	err = parseRequestBody(req.Body, &checkoutReq)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	storedValue, checkout, err := al.vaultManager.CheckOut(req.Context(), id, checkoutReq, curUser)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationVaultCheckout, auditlog.ActionCreate).
+		WithHTTPRequest(req).
+		WithID(id).
+		WithClientID(storedValue.ClientID).
+		WithRequest(checkoutReq).
+		WithResponse(checkout).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusCreated, api.NewSuccessPayload(vaultCheckoutResponse{
+		StoredValue: storedValue,
+		Checkout:    checkout,
+	}))
+}
+
+func (al *APIListener) handleVaultCheckin(w http.ResponseWriter, req *http.Request) {
+	id, ok := al.readVaultValueID(w, req)
+	if !ok {
+		return
+	}
+
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	var checkinReq vault.CheckinRequest
+	if req.ContentLength != 0 {
+		err = parseRequestBody(req.Body, &checkinReq)
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
+	}
+
+	checkout, err := al.vaultManager.CheckIn(req.Context(), id, checkinReq.NewValue, curUser)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationVaultCheckout, auditlog.ActionDelete).
+		WithHTTPRequest(req).
+		WithID(id).
+		WithResponse(checkout).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(checkout))
+}
+
+func (al *APIListener) handleListVaultCheckouts(w http.ResponseWriter, req *http.Request) {
+	id, ok := al.readVaultValueID(w, req)
+	if !ok {
+		return
+	}
+
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	checkouts, err := al.vaultManager.ListCheckouts(req.Context(), id, curUser)
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(checkouts))
+}
+
+func (al *APIListener) readVaultValueID(w http.ResponseWriter, req *http.Request) (int, bool) {
+	id, err := al.readIntParam(routes.ParamVaultValueID, req)
+	if err != nil {
+		al.jsonError(w, errors2.APIError{
+			Err:        err,
+			HTTPStatus: http.StatusBadRequest,
+		})
+		return 0, false
+	}
+	if id == 0 {
+		al.jsonError(w, errors2.APIError{
+			Err:        fmt.Errorf("missing %q route param", routes.ParamVaultValueID),
+			HTTPStatus: http.StatusBadRequest,
+		})
+		return 0, false
+	}
+
+	return id, true
+}
@@ -0,0 +1,72 @@
+package chserver
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/share/comm"
+	errors2 "github.com/IOTech17/neo-rport/share/errors"
+	"github.com/IOTech17/neo-rport/share/models"
+	"github.com/IOTech17/neo-rport/share/random"
+)
+
+// resolveJobArtifacts pins the artifacts of a job to versions, so all clients of a multi-client job get the same
+// content even if a new version is uploaded while it runs.
+func (al *APIListener) resolveJobArtifacts(ctx context.Context, jobArtifacts []models.JobArtifact) ([]models.JobArtifact, error) {
+	if len(jobArtifacts) == 0 {
+		return nil, nil
+	}
+	resolved := make([]models.JobArtifact, 0, len(jobArtifacts))
+	for _, ja := range jobArtifacts {
+		a, err := al.artifactManager.Resolve(ctx, ja.Artifact)
+		if err != nil {
+			return nil, err
+		}
+		resolved = append(resolved, models.JobArtifact{Artifact: a.String(), Destination: ja.Destination})
+	}
+	return resolved, nil
+}
+
+// pushJobArtifacts pushes the artifacts of the job to the client. Files with the same content at the destination are
+// left untouched.
+func (al *APIListener) pushJobArtifacts(ctx context.Context, client *clientdata.Client, job *models.Job) error {
+	if len(job.Artifacts) == 0 {
+		return nil
+	}
+	if cfg := client.GetFileReceptionConfig(); cfg != nil && !cfg.Enabled {
+		return fmt.Errorf("failed to push artifacts: %w", errors2.ErrUploadsDisabled)
+	}
+
+	for _, ja := range job.Artifacts {
+		a, err := al.artifactManager.Resolve(ctx, ja.Artifact)
+		if err != nil {
+			return fmt.Errorf("failed to push artifact %s: %v", ja.Artifact, err)
+		}
+		md5Checksum, err := hex.DecodeString(a.Md5)
+		if err != nil {
+			return err
+		}
+		id, err := random.UUID4()
+		if err != nil {
+			return err
+		}
+
+		file := &models.UploadedFile{
+			ID:              id,
+			SourceFilePath:  al.artifactManager.Path(a),
+			DestinationPath: ja.Destination,
+			Sync:            true,
+			Md5Checksum:     md5Checksum,
+			Artifact:        a.Ref(),
+		}
+		resp := &models.UploadResponse{}
+		err = comm.SendRequestAndGetResponse(client.GetConnection(), comm.RequestTypeUpload, file, resp, al.Log())
+		if err != nil {
+			return fmt.Errorf("failed to push artifact %s: %v", ja.Artifact, err)
+		}
+		al.Debugf("%s, Artifact %s pushed to %s: %s.", job.LogPrefix(), ja.Artifact, ja.Destination, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package chserver
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/db/migration/library"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	"github.com/IOTech17/neo-rport/server/artifacts"
+	"github.com/IOTech17/neo-rport/server/clients"
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/models"
+	"github.com/IOTech17/neo-rport/share/test"
+)
+
+func TestPushJobArtifacts(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqlite.New(":memory:", library.AssetNames(), library.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	defer db.Close()
+	manager := artifacts.NewManager(artifacts.NewSqliteProvider(db), t.TempDir(), 1024, testLog)
+	a, err := manager.Upload(ctx, "agent", "1.0.0", strings.NewReader("payload"), "admin")
+	require.NoError(t, err)
+	al := &APIListener{
+		Logger:          testLog,
+		artifactManager: manager,
+	}
+
+	resolved, err := al.resolveJobArtifacts(ctx, []models.JobArtifact{{Artifact: "agent", Destination: "/opt/agent.tar.gz"}})
+	require.NoError(t, err)
+	assert.Equal(t, []models.JobArtifact{{Artifact: "agent@1.0.0", Destination: "/opt/agent.tar.gz"}}, resolved)
+	_, err = al.resolveJobArtifacts(ctx, []models.JobArtifact{{Artifact: "other", Destination: "/opt/other"}})
+	assert.EqualError(t, err, "artifact other not found")
+
+	connMock := test.NewConnMock()
+	connMock.ReturnOk = true
+	connMock.ReturnResponsePayload = []byte(`{"status":"success"}`)
+	c1 := clients.New(t).ID("client-1").Logger(testLog).Build()
+	c1.SetConnection(connMock)
+
+	err = al.pushJobArtifacts(ctx, c1, &models.Job{JID: "job-1", ClientID: "client-1", Artifacts: resolved})
+	require.NoError(t, err)
+	name, _, payload := connMock.InputSendRequest()
+	assert.Equal(t, comm.RequestTypeUpload, name)
+	pushed := &models.UploadedFile{}
+	require.NoError(t, json.Unmarshal(payload, pushed))
+	assert.Equal(t, manager.Path(a), pushed.SourceFilePath)
+	assert.Equal(t, "/opt/agent.tar.gz", pushed.DestinationPath)
+	assert.True(t, pushed.Sync)
+	assert.Equal(t, a.Ref(), pushed.Artifact)
+
+	connMock.ReturnOk = false
+	connMock.ReturnResponsePayload = []byte("uploads are disabled")
+	err = al.pushJobArtifacts(ctx, c1, &models.Job{JID: "job-2", ClientID: "client-1", Artifacts: resolved})
+	assert.EqualError(t, err, "failed to push artifact agent@1.0.0: client error: uploads are disabled")
+}
@@ -0,0 +1,328 @@
+package chserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/api/jobs"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/monitoring"
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+const (
+	defaultCanaryConfirmTimeoutSec = 60 * 60
+	// canaryResultGracePeriod is added to the job timeout while waiting for the results of the canary jobs
+	canaryResultGracePeriod = 30 * time.Second
+	maxCanaryMetricCheckSec = 24 * 60 * 60
+)
+
+// waitForCanaryMetrics waits while the measurements of a canary metric check are collected
+var waitForCanaryMetrics = time.Sleep
+
+type canaryDecision struct {
+	confirmed bool
+	username  string
+}
+
+// newMultiJobCanary validates the canary of a multi-client job request and resolves the canary clients.
+func newMultiJobCanary(req *jobs.CanaryRequest, orderedClients []*clientdata.Client) (*models.MultiJobCanary, error) {
+	if req == nil {
+		return nil, nil
+	}
+
+	var clientIDs []string
+	switch {
+	case len(req.ClientIDs) > 0 && req.Count > 0:
+		return nil, errors2.APIError{
+			Message:    "canary client_ids and count can't be used together",
+			HTTPStatus: http.StatusBadRequest,
+		}
+	case len(req.ClientIDs) > 0:
+		targets := make(map[string]bool, len(orderedClients))
+		for _, client := range orderedClients {
+			targets[client.GetID()] = true
+		}
+		seen := make(map[string]bool, len(req.ClientIDs))
+		for _, id := range req.ClientIDs {
+			if !targets[id] {
+				return nil, errors2.APIError{
+					Message:    fmt.Sprintf("canary client %q is not a target of the job", id),
+					HTTPStatus: http.StatusBadRequest,
+				}
+			}
+			if !seen[id] {
+				seen[id] = true
+				clientIDs = append(clientIDs, id)
+			}
+		}
+	case req.Count > 0:
+		for i := 0; i < req.Count && i < len(orderedClients); i++ {
+			clientIDs = append(clientIDs, orderedClients[i].GetID())
+		}
+	default:
+		return nil, errors2.APIError{
+			Message:    "canary requires client_ids or a positive count",
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+
+	if len(clientIDs) >= len(orderedClients) {
+		return nil, errors2.APIError{
+			Message:    "canary must not include all clients of the job",
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+	if req.ConfirmTimeoutSec < 0 {
+		return nil, errors2.APIError{
+			Message:    "canary confirm_timeout_sec must not be negative",
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+	confirmTimeoutSec := req.ConfirmTimeoutSec
+	if confirmTimeoutSec == 0 {
+		confirmTimeoutSec = defaultCanaryConfirmTimeoutSec
+	}
+	metricCheck, err := newCanaryMetricCheck(req.MetricCheck)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.MultiJobCanary{
+		ClientIDs:         clientIDs,
+		AutoContinue:      req.AutoContinue,
+		ConfirmTimeoutSec: confirmTimeoutSec,
+		Status:            models.CanaryStatusRunning,
+		MetricCheck:       metricCheck,
+	}, nil
+}
+
+func newCanaryMetricCheck(req *jobs.CanaryMetricCheckRequest) (*models.CanaryMetricCheck, error) {
+	if req == nil {
+		return nil, nil
+	}
+	if _, err := monitoring.ParseMeasureQuery(req.Query); err != nil {
+		return nil, err
+	}
+	if req.Below == nil {
+		return nil, errors2.APIError{
+			Message:    "canary metric_check requires below",
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+	if req.DurationSec <= 0 || req.DurationSec > maxCanaryMetricCheckSec {
+		return nil, errors2.APIError{
+			Message:    fmt.Sprintf("canary metric_check duration_sec must be between 1 and %d", maxCanaryMetricCheckSec),
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+	return &models.CanaryMetricCheck{
+		Query:       req.Query,
+		Below:       *req.Below,
+		DurationSec: req.DurationSec,
+	}, nil
+}
+
+// runCanary executes the job on the canary clients and returns the remaining clients to execute the job on,
+// or nil if the job must not continue.
+func (al *APIListener) runCanary(job *models.MultiJob, orderedClients []*clientdata.Client) []*clientdata.Client {
+	isCanary := make(map[string]bool, len(job.Canary.ClientIDs))
+	for _, id := range job.Canary.ClientIDs {
+		isCanary[id] = true
+	}
+	var canaryClients, remaining []*clientdata.Client
+	for _, client := range orderedClients {
+		if isCanary[client.GetID()] {
+			canaryClients = append(canaryClients, client)
+		} else {
+			remaining = append(remaining, client)
+		}
+	}
+
+	if !al.runCanaryJobs(job, canaryClients) {
+		al.Infof("Multi-client Job[id=%q] stopped, the canary run failed.", job.JID)
+		al.saveCanaryStatus(job, models.CanaryStatusFailed, "")
+		return nil
+	}
+
+	if job.Canary.MetricCheck != nil && !al.runCanaryMetricCheck(job, canaryClients) {
+		al.Infof("Multi-client Job[id=%q] stopped, the canary metric check failed: %s", job.JID, job.Canary.MetricCheck.Failure)
+		al.saveCanaryStatus(job, models.CanaryStatusFailed, "")
+		return nil
+	}
+
+	if job.Canary.AutoContinue {
+		al.saveCanaryStatus(job, models.CanaryStatusConfirmed, "")
+		return remaining
+	}
+
+	decisions := make(chan canaryDecision, 1)
+	al.canaryDecisions.Store(job.JID, decisions)
+	al.saveCanaryStatus(job, models.CanaryStatusAwaitingConfirmation, "")
+
+	var decision canaryDecision
+	timer := time.NewTimer(time.Duration(job.Canary.ConfirmTimeoutSec) * time.Second)
+	defer timer.Stop()
+	select {
+	case decision = <-decisions:
+	case <-timer.C:
+		// a decision might have been taken right before the timeout
+		if _, pending := al.canaryDecisions.LoadAndDelete(job.JID); pending {
+			al.Infof("Multi-client Job[id=%q] aborted, the canary run was not confirmed in time.", job.JID)
+		} else {
+			decision = <-decisions
+		}
+	}
+
+	if !decision.confirmed {
+		al.saveCanaryStatus(job, models.CanaryStatusAborted, decision.username)
+		return nil
+	}
+	al.saveCanaryStatus(job, models.CanaryStatusConfirmed, decision.username)
+	return remaining
+}
+
+// runCanaryJobs executes the job concurrently on the canary clients and returns true if all of them succeeded.
+func (al *APIListener) runCanaryJobs(job *models.MultiJob, canaryClients []*clientdata.Client) bool {
+	// buffered to not block on results that arrive after a failure
+	done := make(chan *models.Job, len(canaryClients))
+	al.jobsDoneChannel.Set(job.JID, done)
+	defer al.jobsDoneChannel.Del(job.JID)
+
+	for _, client := range canaryClients {
+		curJID, err := generateNewJobID()
+		if err != nil {
+			al.Errorf("Multi-client Job[id=%q], could not generate job id: %v", job.JID, err)
+			return false
+		}
+		err = al.createAndRunJob(
+			nil,
+			&job.JID,
+			curJID,
+			job.Command,
+			job.Interpreter,
+			job.CreatedBy,
+			job.Cwd,
+			job.TimeoutSec,
+			job.IsSudo,
+			job.IsScript,
+			job.OutputParser,
+			job.Lock,
+			job.Artifacts,
+			client,
+		)
+		if err != nil {
+			return false
+		}
+	}
+
+	timer := time.NewTimer(time.Duration(job.TimeoutSec)*time.Second + canaryResultGracePeriod)
+	defer timer.Stop()
+	for range canaryClients {
+		select {
+		case jobResult := <-done:
+			if jobResult.Status != models.JobStatusSuccessful {
+				return false
+			}
+		case <-timer.C:
+			al.Infof("Multi-client Job[id=%q], timeout on waiting for the canary results.", job.JID)
+			return false
+		}
+	}
+	return true
+}
+
+// runCanaryMetricCheck waits for the duration of the metric check and returns true if it passed on the canary clients,
+// otherwise the failure is set on the check.
+func (al *APIListener) runCanaryMetricCheck(job *models.MultiJob, canaryClients []*clientdata.Client) bool {
+	check := job.Canary.MetricCheck
+	al.saveCanaryStatus(job, models.CanaryStatusCheckingMetrics, "")
+
+	from := time.Now()
+	duration := time.Duration(check.DurationSec) * time.Second
+	waitForCanaryMetrics(duration)
+
+	check.Failure = al.checkCanaryMetrics(context.Background(), check, canaryClients, from, from.Add(duration))
+	return check.Failure == ""
+}
+
+// checkCanaryMetrics returns why the values of the query on the clients between from and to are not all below the
+// threshold, empty if they are. The whole time range is aggregated into a single value of each series.
+func (al *APIListener) checkCanaryMetrics(
+	ctx context.Context,
+	check *models.CanaryMetricCheck,
+	canaryClients []*clientdata.Client,
+	from, to time.Time,
+) string {
+	mq, err := monitoring.ParseMeasureQuery(check.Query)
+	if err != nil {
+		return err.Error()
+	}
+	r, err := monitoring.NewQueryRange(from, to, to.Sub(from))
+	if err != nil {
+		return err.Error()
+	}
+	queryClients := make([]monitoring.QueryClient, 0, len(canaryClients))
+	for _, c := range canaryClients {
+		queryClients = append(queryClients, monitoring.QueryClient{
+			ID:     c.GetID(),
+			Tags:   c.GetTags(),
+			Labels: c.Labels,
+		})
+	}
+
+	series, err := al.monitoringService.QueryMeasures(ctx, mq, r, queryClients)
+	if err != nil {
+		return fmt.Sprintf("failed to query the measurements: %v", err)
+	}
+	if len(series) == 0 {
+		return "no measurements of the canary clients"
+	}
+	for _, s := range series {
+		if len(s.Points) == 0 {
+			return fmt.Sprintf("no measurements of %s", formatQueryLabels(s.Labels))
+		}
+		for _, p := range s.Points {
+			if p.Value >= check.Below {
+				return fmt.Sprintf("%s of %s is %g, expected below %g", check.Query, formatQueryLabels(s.Labels), p.Value, check.Below)
+			}
+		}
+	}
+	return ""
+}
+
+func formatQueryLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return "the canary clients"
+	}
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (al *APIListener) saveCanaryStatus(job *models.MultiJob, status, decidedBy string) {
+	job.Canary.Status = status
+	job.Canary.DecidedBy = decidedBy
+	if err := al.jobProvider.SaveMultiJob(job); err != nil {
+		al.Errorf("Multi-client Job[id=%q], failed to save the canary status %q: %v", job.JID, status, err)
+	}
+}
+
+// decideCanary passes the decision to a canary run awaiting confirmation, it returns false if the run doesn't wait
+// for a decision.
+func (al *APIListener) decideCanary(multiJobID string, decision canaryDecision) bool {
+	v, ok := al.canaryDecisions.LoadAndDelete(multiJobID)
+	if !ok {
+		return false
+	}
+	v.(chan canaryDecision) <- decision
+	return true
+}
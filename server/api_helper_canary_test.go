@@ -0,0 +1,314 @@
+package chserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	jobsmigration "github.com/IOTech17/neo-rport/db/migration/jobs"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/api/jobs"
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/clients"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/monitoring"
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/models"
+	"github.com/IOTech17/neo-rport/share/test"
+)
+
+func TestNewMultiJobCanary(t *testing.T) {
+	orderedClients := []*clientdata.Client{
+		clients.New(t).ID("client-1").Build(),
+		clients.New(t).ID("client-2").Build(),
+		clients.New(t).ID("client-3").Build(),
+	}
+	below := 90.0
+
+	testCases := []struct {
+		name       string
+		req        *jobs.CanaryRequest
+		wantCanary *models.MultiJobCanary
+		wantErr    string
+	}{
+		{
+			name: "no canary",
+		},
+		{
+			name: "count",
+			req:  &jobs.CanaryRequest{Count: 2, AutoContinue: true},
+			wantCanary: &models.MultiJobCanary{
+				ClientIDs:         []string{"client-1", "client-2"},
+				AutoContinue:      true,
+				ConfirmTimeoutSec: defaultCanaryConfirmTimeoutSec,
+				Status:            models.CanaryStatusRunning,
+			},
+		},
+		{
+			name: "client ids",
+			req:  &jobs.CanaryRequest{ClientIDs: []string{"client-3", "client-3"}, ConfirmTimeoutSec: 10},
+			wantCanary: &models.MultiJobCanary{
+				ClientIDs:         []string{"client-3"},
+				ConfirmTimeoutSec: 10,
+				Status:            models.CanaryStatusRunning,
+			},
+		},
+		{
+			name:    "unknown client",
+			req:     &jobs.CanaryRequest{ClientIDs: []string{"client-4"}},
+			wantErr: `canary client "client-4" is not a target of the job`,
+		},
+		{
+			name:    "all clients",
+			req:     &jobs.CanaryRequest{Count: 5},
+			wantErr: "canary must not include all clients of the job",
+		},
+		{
+			name:    "empty",
+			req:     &jobs.CanaryRequest{},
+			wantErr: "canary requires client_ids or a positive count",
+		},
+		{
+			name:    "client ids and count",
+			req:     &jobs.CanaryRequest{ClientIDs: []string{"client-1"}, Count: 1},
+			wantErr: "canary client_ids and count can't be used together",
+		},
+		{
+			name:    "negative timeout",
+			req:     &jobs.CanaryRequest{Count: 1, ConfirmTimeoutSec: -1},
+			wantErr: "canary confirm_timeout_sec must not be negative",
+		},
+		{
+			name: "metric check",
+			req: &jobs.CanaryRequest{Count: 1, MetricCheck: &jobs.CanaryMetricCheckRequest{
+				Query:       "max(cpu_usage_percent) by client",
+				Below:       &below,
+				DurationSec: 300,
+			}},
+			wantCanary: &models.MultiJobCanary{
+				ClientIDs:         []string{"client-1"},
+				ConfirmTimeoutSec: defaultCanaryConfirmTimeoutSec,
+				Status:            models.CanaryStatusRunning,
+				MetricCheck: &models.CanaryMetricCheck{
+					Query:       "max(cpu_usage_percent) by client",
+					Below:       90,
+					DurationSec: 300,
+				},
+			},
+		},
+		{
+			name:    "metric check with invalid query",
+			req:     &jobs.CanaryRequest{Count: 1, MetricCheck: &jobs.CanaryMetricCheckRequest{Query: "cpu", Below: &below, DurationSec: 300}},
+			wantErr: `invalid query "cpu", expected "<function>(<metric>) [by client|tag|label:<key>]"`,
+		},
+		{
+			name:    "metric check without threshold",
+			req:     &jobs.CanaryRequest{Count: 1, MetricCheck: &jobs.CanaryMetricCheckRequest{Query: "avg(cpu_usage_percent)", DurationSec: 300}},
+			wantErr: "canary metric_check requires below",
+		},
+		{
+			name:    "metric check without duration",
+			req:     &jobs.CanaryRequest{Count: 1, MetricCheck: &jobs.CanaryMetricCheckRequest{Query: "avg(cpu_usage_percent)", Below: &below}},
+			wantErr: "canary metric_check duration_sec must be between 1 and 86400",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			canary, err := newMultiJobCanary(tc.req, orderedClients)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantCanary, canary)
+		})
+	}
+}
+
+func TestCanaryRun(t *testing.T) {
+	newClient := func(id string) *clientdata.Client {
+		connMock := test.NewConnMock()
+		connMock.ReturnOk = true
+		resp, err := json.Marshal(comm.RunCmdResponse{Pid: 1, StartedAt: time.Now()})
+		require.NoError(t, err)
+		connMock.ReturnResponsePayload = resp
+		return clients.New(t).ID(id).Connection(connMock).Logger(testLog).Build()
+	}
+	orderedClients := []*clientdata.Client{newClient("client-1"), newClient("client-2"), newClient("client-3")}
+
+	below := 90.0
+	checkCPU := &jobs.CanaryMetricCheckRequest{Query: "max(cpu_usage_percent) by client", Below: &below, DurationSec: 300}
+	measuredAt := time.Now().Add(time.Minute)
+	origWait := waitForCanaryMetrics
+	defer func() { waitForCanaryMetrics = origWait }()
+	waitForCanaryMetrics = func(time.Duration) {}
+
+	testCases := []struct {
+		name         string
+		autoContinue bool
+		canaryStatus string
+		decision     string
+		metricCheck  *jobs.CanaryMetricCheckRequest
+		metricValues []monitoring.MetricValue
+		wantStatus   string
+		wantFailure  string
+		wantJobs     int
+	}{
+		{
+			name:         "confirmed",
+			canaryStatus: models.JobStatusSuccessful,
+			decision:     "confirm",
+			wantStatus:   models.CanaryStatusConfirmed,
+			wantJobs:     3,
+		},
+		{
+			name:         "aborted",
+			canaryStatus: models.JobStatusSuccessful,
+			decision:     "abort",
+			wantStatus:   models.CanaryStatusAborted,
+			wantJobs:     1,
+		},
+		{
+			name:         "auto continue",
+			autoContinue: true,
+			canaryStatus: models.JobStatusSuccessful,
+			wantStatus:   models.CanaryStatusConfirmed,
+			wantJobs:     3,
+		},
+		{
+			name:         "canary failed",
+			autoContinue: true,
+			canaryStatus: models.JobStatusFailed,
+			wantStatus:   models.CanaryStatusFailed,
+			wantJobs:     1,
+		},
+		{
+			name:         "metric check passed",
+			autoContinue: true,
+			canaryStatus: models.JobStatusSuccessful,
+			metricCheck:  checkCPU,
+			metricValues: []monitoring.MetricValue{
+				{ClientID: "client-1", Timestamp: measuredAt, Value: 42},
+				{ClientID: "client-1", Timestamp: measuredAt.Add(time.Minute), Value: 89.9},
+			},
+			wantStatus: models.CanaryStatusConfirmed,
+			wantJobs:   3,
+		},
+		{
+			name:         "metric check failed",
+			autoContinue: true,
+			canaryStatus: models.JobStatusSuccessful,
+			metricCheck:  checkCPU,
+			metricValues: []monitoring.MetricValue{
+				{ClientID: "client-1", Timestamp: measuredAt, Value: 42},
+				{ClientID: "client-1", Timestamp: measuredAt.Add(time.Minute), Value: 97.5},
+			},
+			wantStatus:  models.CanaryStatusFailed,
+			wantFailure: "max(cpu_usage_percent) by client of client_id=client-1 is 97.5, expected below 90",
+			wantJobs:    1,
+		},
+		{
+			name:         "metric check without measurements",
+			autoContinue: true,
+			canaryStatus: models.JobStatusSuccessful,
+			metricCheck:  checkCPU,
+			wantStatus:   models.CanaryStatusFailed,
+			wantFailure:  "no measurements of client_id=client-1",
+			wantJobs:     1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			jobsDB, err := sqlite.New(":memory:", jobsmigration.AssetNames(), jobsmigration.Asset, DataSourceOptions)
+			require.NoError(t, err)
+			jp := jobs.NewSqliteProvider(jobsDB, testLog)
+			defer jp.Close()
+
+			done := make(chan bool)
+			al := APIListener{
+				insecureForTests: true,
+				Server: &Server{
+					config: &chconfig.Config{
+						Server: chconfig.ServerConfig{
+							RunRemoteCmdTimeoutSec: 60,
+						},
+						API: chconfig.APIConfig{
+							MaxRequestBytes: 1024 * 1024,
+						},
+					},
+					jobProvider:       jp,
+					monitoringService: monitoring.NewService(&monitoring.DBProviderMock{MetricValues: tc.metricValues}, testLog),
+					jobsDoneChannel: jobResultChanMap{
+						m: make(map[string]chan *models.Job),
+					},
+				},
+				userService: users.NewAPIService(users.NewStaticProvider([]*users.User{
+					{Username: "admin", Groups: []string{users.Administrators}},
+				}), false, 0, -1),
+				Logger:   testLog,
+				testDone: done,
+			}
+			al.initRouter()
+
+			ctx := api.WithUser(context.Background(), "admin")
+			multiJob, err := al.StartMultiClientJob(ctx, &jobs.MultiJobRequest{
+				ClientIDs:      []string{"client-1", "client-2", "client-3"},
+				Command:        "/bin/date",
+				Username:       "admin",
+				OrderedClients: orderedClients,
+				Canary:         &jobs.CanaryRequest{Count: 1, AutoContinue: tc.autoContinue, MetricCheck: tc.metricCheck},
+			})
+			require.NoError(t, err)
+
+			var canaryDone chan *models.Job
+			require.Eventually(t, func() bool {
+				canaryDone = al.jobsDoneChannel.Get(multiJob.JID)
+				return canaryDone != nil
+			}, time.Second, 10*time.Millisecond)
+			canaryDone <- &models.Job{Status: tc.canaryStatus}
+
+			decide := func(decision string) int {
+				w := httptest.NewRecorder()
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/commands/"+multiJob.JID+"/canary/"+decision, nil)
+				req = req.WithContext(ctx)
+				al.router.ServeHTTP(w, req)
+				return w.Code
+			}
+			if tc.decision != "" {
+				require.Eventually(t, func() bool {
+					saved, err := jp.GetMultiJob(ctx, multiJob.JID)
+					require.NoError(t, err)
+					return saved.Canary.Status == models.CanaryStatusAwaitingConfirmation
+				}, time.Second, 10*time.Millisecond)
+				assert.Equal(t, http.StatusNoContent, decide(tc.decision))
+			}
+
+			<-done
+
+			saved, err := jp.GetMultiJob(ctx, multiJob.JID)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantStatus, saved.Canary.Status)
+			assert.Equal(t, []string{"client-1"}, saved.Canary.ClientIDs)
+			if tc.decision != "" {
+				assert.Equal(t, "admin", saved.Canary.DecidedBy)
+			}
+			assert.Len(t, saved.Jobs, tc.wantJobs)
+			if tc.metricCheck != nil {
+				assert.Equal(t, tc.wantFailure, saved.Canary.MetricCheck.Failure)
+			}
+
+			// the run doesn't wait for a decision anymore
+			assert.Equal(t, http.StatusConflict, decide("confirm"))
+		})
+	}
+}
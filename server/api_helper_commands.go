@@ -9,6 +9,7 @@ import (
 
 	"github.com/IOTech17/neo-rport/server/api/jobs"
 	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/killswitch"
 	"github.com/IOTech17/neo-rport/server/validation"
 	"github.com/IOTech17/neo-rport/share/models"
 	"github.com/IOTech17/neo-rport/share/ws"
@@ -20,6 +21,10 @@ func (al *APIListener) handleCommandsExecutionWS(
 	inboundMsg *jobs.MultiJobRequest,
 	auditLogEntry *auditlog.Entry,
 ) {
+	if err := al.killSwitches.Check(killswitch.JobCapability(inboundMsg.IsScript)); err != nil {
+		uiConnTS.WriteError("", err)
+		return
+	}
 	if inboundMsg.Command == "" {
 		uiConnTS.WriteError("Command cannot be empty.", nil)
 		return
@@ -28,6 +33,33 @@ func (al *APIListener) handleCommandsExecutionWS(
 		uiConnTS.WriteError("Invalid interpreter", err)
 		return
 	}
+	if err := validation.ValidateOutputParser(inboundMsg.OutputParser); err != nil {
+		uiConnTS.WriteError("Invalid output parser", err)
+		return
+	}
+	if err := validation.ValidateLock(inboundMsg.Lock); err != nil {
+		uiConnTS.WriteError("Invalid lock", err)
+		return
+	}
+	if err := validation.ValidateJobArtifacts(inboundMsg.Artifacts); err != nil {
+		uiConnTS.WriteError("Invalid artifacts", err)
+		return
+	}
+	if inboundMsg.Canary != nil {
+		uiConnTS.WriteError("Canary runs are not supported on web sockets", nil)
+		return
+	}
+	// there is no way to pass warnings on web sockets, only blocking applies
+	if _, err := al.secretScanner.Check(inboundMsg.Command); err != nil {
+		uiConnTS.WriteError("Hard-coded secrets found", err)
+		return
+	}
+	artifacts, err := al.resolveJobArtifacts(ctx, inboundMsg.Artifacts)
+	if err != nil {
+		uiConnTS.WriteError("Invalid artifacts", err)
+		return
+	}
+	inboundMsg.Artifacts = artifacts
 
 	if inboundMsg.TimeoutSec <= 0 {
 		inboundMsg.TimeoutSec = al.config.Server.RunRemoteCmdTimeoutSec
@@ -83,17 +115,20 @@ func (al *APIListener) handleCommandsExecutionWS(
 				StartedAt: time.Now(),
 				CreatedBy: createdBy,
 			},
-			ClientIDs:   inboundMsg.ClientIDs,
-			GroupIDs:    inboundMsg.GroupIDs,
-			ClientTags:  inboundMsg.ClientTags,
-			Command:     inboundMsg.Command,
-			Cwd:         inboundMsg.Cwd,
-			Interpreter: inboundMsg.Interpreter,
-			TimeoutSec:  inboundMsg.TimeoutSec,
-			Concurrent:  inboundMsg.ExecuteConcurrently,
-			AbortOnErr:  abortOnErr,
-			IsSudo:      inboundMsg.IsSudo,
-			IsScript:    inboundMsg.IsScript,
+			ClientIDs:    inboundMsg.ClientIDs,
+			GroupIDs:     inboundMsg.GroupIDs,
+			ClientTags:   inboundMsg.ClientTags,
+			Command:      inboundMsg.Command,
+			Cwd:          inboundMsg.Cwd,
+			Interpreter:  inboundMsg.Interpreter,
+			TimeoutSec:   inboundMsg.TimeoutSec,
+			Concurrent:   inboundMsg.ExecuteConcurrently,
+			AbortOnErr:   abortOnErr,
+			IsSudo:       inboundMsg.IsSudo,
+			IsScript:     inboundMsg.IsScript,
+			OutputParser: inboundMsg.OutputParser,
+			Lock:         inboundMsg.Lock,
+			Artifacts:    inboundMsg.Artifacts,
 		}
 		if err := al.jobProvider.SaveMultiJob(multiJob); err != nil {
 			uiConnTS.WriteError("Failed to persist a new multi-client job.", err)
@@ -134,6 +169,9 @@ func (al *APIListener) handleCommandsExecutionWS(
 					multiJob.TimeoutSec,
 					multiJob.IsSudo,
 					multiJob.IsScript,
+					multiJob.OutputParser,
+					multiJob.Lock,
+					multiJob.Artifacts,
 					client,
 				)
 			} else {
@@ -148,6 +186,9 @@ func (al *APIListener) handleCommandsExecutionWS(
 					multiJob.TimeoutSec,
 					multiJob.IsSudo,
 					multiJob.IsScript,
+					multiJob.OutputParser,
+					multiJob.Lock,
+					multiJob.Artifacts,
 					client,
 				)
 
@@ -186,6 +227,9 @@ func (al *APIListener) handleCommandsExecutionWS(
 			inboundMsg.TimeoutSec,
 			inboundMsg.IsSudo,
 			inboundMsg.IsScript,
+			inboundMsg.OutputParser,
+			inboundMsg.Lock,
+			inboundMsg.Artifacts,
 			client,
 		)
 	}
@@ -7,8 +7,11 @@ import (
 	"fmt"
 	"time"
 
+	"golang.org/x/crypto/ssh"
+
 	"github.com/IOTech17/neo-rport/server/api/jobs"
 	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/killswitch"
 	"github.com/IOTech17/neo-rport/share/comm"
 	"github.com/IOTech17/neo-rport/share/models"
 	"github.com/IOTech17/neo-rport/share/query"
@@ -22,6 +25,15 @@ var generateNewJobID = func() (string, error) {
 	return random.UUID4()
 }
 
+// jobRedeliveryTimeout is how long a job is sent again after the connection to the client was lost before it replied
+var jobRedeliveryTimeout = time.Minute
+
+// jobRedeliveryInterval is how often the reconnect of the client is checked
+var jobRedeliveryInterval = time.Second
+
+// jobLockGracePeriod is how long an execution lock is held after the timeout of a job whose result doesn't arrive
+var jobLockGracePeriod = time.Minute
+
 type JobProvider interface {
 	GetByJID(clientID, jid string) (*models.Job, error)
 	List(ctx context.Context, options *query.ListOptions) ([]*models.Job, error)
@@ -35,15 +47,30 @@ type JobProvider interface {
 	CountMultiJobs(ctx context.Context, options *query.ListOptions) (int, error)
 	SaveMultiJob(multiJob *models.MultiJob) error
 	CleanupJobsMultiJobs(context.Context, int) error
+	// CountByClient returns the number of jobs per client started within the time range
+	CountByClient(ctx context.Context, from, to time.Time) (map[string]int64, error)
+	// StorageByClient returns the bytes of the jobs stored per client
+	StorageByClient(ctx context.Context) (map[string]int64, error)
 	Close() error
 }
 
+// checkJobCapabilities fails fast for scripts sent to clients that don't accept them.
+func checkJobCapabilities(client *clientdata.Client, isScript bool) error {
+	if !isScript {
+		return nil
+	}
+	return client.CheckCapability(models.ClientCapabilityScripts)
+}
+
 func (al *APIListener) createAndRunJob(
 	uiConnTS *ws.ConcurrentWebSocket,
 	multiJobID *string,
 	jid, cmd, interpreter, createdBy, cwd string,
 	timeoutSec int,
 	isSudo, isScript bool,
+	outputParser *models.OutputParser,
+	lock string,
+	artifacts []models.JobArtifact,
 	client *clientdata.Client,
 ) error {
 	curJob := models.Job{
@@ -60,6 +87,9 @@ func (al *APIListener) createAndRunJob(
 		TimeoutSec:   timeoutSec,
 		MultiJobID:   multiJobID,
 		StreamResult: uiConnTS != nil,
+		OutputParser: outputParser,
+		Lock:         lock,
+		Artifacts:    artifacts,
 	}
 	logPrefix := curJob.LogPrefix()
 
@@ -67,14 +97,23 @@ func (al *APIListener) createAndRunJob(
 	sshResp := &comm.RunCmdResponse{}
 
 	var err error
-	if !client.IsPaused() {
-		if client.Connection != nil {
-			err = comm.SendRequestAndGetResponse(client.GetConnection(), comm.RequestTypeRunCmd, curJob, sshResp, al.Log())
-		} else {
-			err = ErrClientNotConnected
+	if client.IsPaused() {
+		err = fmt.Errorf("client is paused (reason = %s)", client.PausedReason)
+	} else if quarantine := client.GetQuarantine(); quarantine != nil {
+		err = fmt.Errorf("%w (reason = %s)", clientdata.ErrQuarantined, quarantine.Reason)
+	} else if capErr := checkJobCapabilities(client, isScript); capErr != nil {
+		err = capErr
+	} else if client.Connection != nil {
+		err = al.acquireJobLock(context.Background(), &curJob)
+		if err == nil {
+			err = al.pushJobArtifacts(context.Background(), client, &curJob)
+			if err == nil {
+				err = al.sendJob(context.Background(), client, &curJob, sshResp)
+			}
+			al.releaseJobLockOnError(&curJob, sshResp, err)
 		}
 	} else {
-		err = fmt.Errorf("client is paused (reason = %s)", client.PausedReason)
+		err = ErrClientNotConnected
 	}
 
 	if err != nil {
@@ -111,7 +150,80 @@ func (al *APIListener) createAndRunJob(
 	return err
 }
 
+// acquireJobLock waits until the execution lock of the job is free on its client. It's held until the result of the
+// job arrives, or for the timeout of the job and jobLockGracePeriod if the result is lost.
+func (al *APIListener) acquireJobLock(ctx context.Context, job *models.Job) error {
+	if job.Lock == "" {
+		return nil
+	}
+	ttl := time.Duration(job.TimeoutSec)*time.Second + jobLockGracePeriod
+	al.Debugf("%s, Acquiring lock %q.", job.LogPrefix(), job.Lock)
+	if err := al.jobLocks.Acquire(ctx, job.ClientID, job.Lock, job.JID, ttl); err != nil {
+		return fmt.Errorf("failed to acquire lock %q: %w", job.Lock, err)
+	}
+	return nil
+}
+
+// releaseJobLockOnError releases the lock of a job that wasn't started, or that was received before and isn't
+// running anymore on the client.
+func (al *APIListener) releaseJobLockOnError(job *models.Job, resp *comm.RunCmdResponse, err error) {
+	if job.Lock != "" && (err != nil || resp.Duplicate) {
+		al.jobLocks.Release(job.ClientID, job.JID)
+	}
+}
+
+// sendJob sends the job to the client. If the connection is lost before the client replied, it's unknown whether the
+// job was started. Clients running a job at most once per job id get it again once they reconnected, their reply
+// tells if they received it before.
+func (al *APIListener) sendJob(ctx context.Context, client *clientdata.Client, job *models.Job, resp *comm.RunCmdResponse) error {
+	conn := client.GetConnection()
+	deadline := time.Now().Add(jobRedeliveryTimeout)
+	for {
+		err := comm.SendRequestAndGetResponse(conn, comm.RequestTypeRunCmd, job, resp, al.Log())
+		if err == nil {
+			if resp.Duplicate {
+				al.Infof("%s, Job was received by the client before, it's not run again.", job.LogPrefix())
+			}
+			return nil
+		}
+		if _, ok := err.(*comm.ClientError); ok || client.NegotiatedFeatureLevel(al.capabilities, models.FeatureJobDelivery) < 1 {
+			return err
+		}
+
+		al.Infof("%s, Connection lost before the client replied, sending the job again once it's reconnected: %v", job.LogPrefix(), err)
+		conn = al.waitForReconnect(ctx, client.GetID(), conn, deadline)
+		if conn == nil {
+			return fmt.Errorf("%v, client did not reconnect within %s", err, jobRedeliveryTimeout)
+		}
+	}
+}
+
+// waitForReconnect returns the new connection of the client or nil if it didn't reconnect before the deadline.
+func (al *APIListener) waitForReconnect(ctx context.Context, clientID string, lost ssh.Conn, deadline time.Time) ssh.Conn {
+	ticker := time.NewTicker(jobRedeliveryInterval)
+	defer ticker.Stop()
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		client, err := al.clientService.GetActiveByID(clientID)
+		if err != nil || client == nil {
+			continue
+		}
+		if conn := client.GetConnection(); conn != nil && conn != lost {
+			return conn
+		}
+	}
+	return nil
+}
+
 func (al *APIListener) StartMultiClientJob(ctx context.Context, multiJobRequest *jobs.MultiJobRequest) (*models.MultiJob, error) {
+	if err := al.killSwitches.Check(killswitch.JobCapability(multiJobRequest.IsScript)); err != nil {
+		return nil, err
+	}
+
 	jid, err := generateNewJobID()
 	if err != nil {
 		return nil, err
@@ -145,6 +257,16 @@ func (al *APIListener) StartMultiClientJob(ctx context.Context, multiJobRequest
 		return nil, fmt.Errorf("no clients for execution")
 	}
 
+	canary, err := newMultiJobCanary(multiJobRequest.Canary, multiJobRequest.OrderedClients)
+	if err != nil {
+		return nil, err
+	}
+
+	artifacts, err := al.resolveJobArtifacts(ctx, multiJobRequest.Artifacts)
+	if err != nil {
+		return nil, err
+	}
+
 	command := multiJobRequest.Command
 	if multiJobRequest.IsScript {
 		decodedScriptBytes, err := base64.StdEncoding.DecodeString(multiJobRequest.Script)
@@ -161,17 +283,21 @@ func (al *APIListener) StartMultiClientJob(ctx context.Context, multiJobRequest
 			CreatedBy:  multiJobRequest.Username,
 			ScheduleID: multiJobRequest.ScheduleID,
 		},
-		ClientIDs:   multiJobRequest.ClientIDs,
-		GroupIDs:    multiJobRequest.GroupIDs,
-		ClientTags:  multiJobRequest.ClientTags,
-		Command:     command,
-		Interpreter: multiJobRequest.Interpreter,
-		Cwd:         multiJobRequest.Cwd,
-		IsScript:    multiJobRequest.IsScript,
-		IsSudo:      multiJobRequest.IsSudo,
-		TimeoutSec:  multiJobRequest.TimeoutSec,
-		Concurrent:  multiJobRequest.ExecuteConcurrently,
-		AbortOnErr:  abortOnErr,
+		ClientIDs:    multiJobRequest.ClientIDs,
+		GroupIDs:     multiJobRequest.GroupIDs,
+		ClientTags:   multiJobRequest.ClientTags,
+		Command:      command,
+		Interpreter:  multiJobRequest.Interpreter,
+		Cwd:          multiJobRequest.Cwd,
+		IsScript:     multiJobRequest.IsScript,
+		IsSudo:       multiJobRequest.IsSudo,
+		TimeoutSec:   multiJobRequest.TimeoutSec,
+		Concurrent:   multiJobRequest.ExecuteConcurrently,
+		AbortOnErr:   abortOnErr,
+		OutputParser: multiJobRequest.OutputParser,
+		Lock:         multiJobRequest.Lock,
+		Artifacts:    artifacts,
+		Canary:       canary,
 	}
 	if err := al.jobProvider.SaveMultiJob(multiJob); err != nil {
 		return nil, err
@@ -186,6 +312,10 @@ func (al *APIListener) executeMultiClientJob(
 	job *models.MultiJob,
 	orderedClients []*clientdata.Client,
 ) {
+	if job.Canary != nil {
+		orderedClients = al.runCanary(job, orderedClients)
+	}
+
 	// for sequential execution - create a channel to get the job result
 	var curJobDoneChannel chan *models.Job
 	if !job.Concurrent {
@@ -213,6 +343,9 @@ func (al *APIListener) executeMultiClientJob(
 				job.TimeoutSec,
 				job.IsSudo,
 				job.IsScript,
+				job.OutputParser,
+				job.Lock,
+				job.Artifacts,
 				client,
 			)
 		} else {
@@ -227,6 +360,9 @@ func (al *APIListener) executeMultiClientJob(
 				job.TimeoutSec,
 				job.IsSudo,
 				job.IsScript,
+				job.OutputParser,
+				job.Lock,
+				job.Artifacts,
 				client,
 			)
 			if err != nil {
@@ -253,3 +389,52 @@ func (al *APIListener) executeMultiClientJob(
 		al.testDone <- true
 	}
 }
+
+// waitForMultiJob waits until the multi-client job is finished, its canary run awaits confirmation or the wait is
+// over and returns its latest stored state.
+func (al *APIListener) waitForMultiJob(ctx context.Context, jid string, clientsCount int, wait time.Duration) (*models.MultiJob, error) {
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for {
+		job, err := al.jobProvider.GetMultiJob(ctx, jid)
+		if err != nil {
+			return nil, err
+		}
+		if job == nil {
+			return nil, fmt.Errorf("multi-client job %s not found", jid)
+		}
+		if multiJobFinished(job, clientsCount) || (job.Canary != nil && job.Canary.Status == models.CanaryStatusAwaitingConfirmation) {
+			return job, nil
+		}
+		select {
+		case <-ctx.Done():
+			return job, ctx.Err()
+		case <-timer.C:
+			return job, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// multiJobFinished returns true if the multi-client job runs on none of its clients and won't run on further ones.
+func multiJobFinished(job *models.MultiJob, clientsCount int) bool {
+	failed := false
+	for _, j := range job.Jobs {
+		if j.Status == models.JobStatusRunning {
+			return false
+		}
+		// sequential jobs continue on the next client if a client is not connected
+		if j.Status == models.JobStatusFailed && j.Error != ErrClientNotConnected.Error() {
+			failed = true
+		}
+	}
+	if job.Canary != nil && (job.Canary.Status == models.CanaryStatusFailed || job.Canary.Status == models.CanaryStatusAborted) {
+		return true
+	}
+	if !job.Concurrent && job.AbortOnErr && failed {
+		return true
+	}
+	return len(job.Jobs) >= clientsCount
+}
@@ -0,0 +1,198 @@
+package chserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/server/api/jobs"
+	"github.com/IOTech17/neo-rport/server/clients"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/models"
+	"github.com/IOTech17/neo-rport/share/test"
+)
+
+func TestSendJobAgainAfterReconnect(t *testing.T) {
+	defer func(interval time.Duration) { jobRedeliveryInterval = interval }(jobRedeliveryInterval)
+	jobRedeliveryInterval = time.Millisecond
+
+	lost := test.NewConnMock()
+	lost.ReturnErr = errors.New("EOF")
+	reconnected := test.NewConnMock()
+	reconnected.ReturnOk = true
+	startedAt := time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)
+	payload, err := json.Marshal(comm.RunCmdResponse{Pid: 123, StartedAt: startedAt, Duplicate: true})
+	require.NoError(t, err)
+	reconnected.ReturnResponsePayload = payload
+
+	client := clients.New(t).ID("client-1").Connection(lost).Logger(testLog).Build()
+	client.JobDeliveryVersion = 1
+	al := &APIListener{
+		Server: &Server{
+			clientService: clients.NewClientService(nil, nil, clients.NewClientRepository([]*clientdata.Client{client}, nil, testLog), testLog, nil),
+		},
+		Logger: testLog,
+	}
+	job := &models.Job{JID: "job-1", ClientID: "client-1"}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		client.SetConnection(reconnected)
+	}()
+	resp := &comm.RunCmdResponse{}
+	require.NoError(t, al.sendJob(context.Background(), client, job, resp))
+	assert.Equal(t, &comm.RunCmdResponse{Pid: 123, StartedAt: startedAt, Duplicate: true}, resp)
+	_, _, sent := reconnected.InputSendRequest()
+	assert.Contains(t, string(sent), `"jid":"job-1"`)
+
+	// clients that might run a job twice don't get it again
+	client.JobDeliveryVersion = 0
+	client.SetConnection(lost)
+	err = al.sendJob(context.Background(), client, job, &comm.RunCmdResponse{})
+	assert.EqualError(t, err, "failed to send request: EOF")
+
+	// a rejected job isn't sent again
+	client.JobDeliveryVersion = 1
+	rejecting := test.NewConnMock()
+	rejecting.ReturnResponsePayload = []byte("remote commands execution is disabled")
+	client.SetConnection(rejecting)
+	err = al.sendJob(context.Background(), client, job, &comm.RunCmdResponse{})
+	assert.EqualError(t, err, "client error: remote commands execution is disabled")
+}
+
+func TestSendJobClientDoesNotReconnect(t *testing.T) {
+	defer func(timeout, interval time.Duration) {
+		jobRedeliveryTimeout, jobRedeliveryInterval = timeout, interval
+	}(jobRedeliveryTimeout, jobRedeliveryInterval)
+	jobRedeliveryTimeout = 20 * time.Millisecond
+	jobRedeliveryInterval = time.Millisecond
+
+	lost := test.NewConnMock()
+	lost.ReturnErr = errors.New("EOF")
+	client := clients.New(t).ID("client-1").Connection(lost).Logger(testLog).Build()
+	client.JobDeliveryVersion = 1
+	al := &APIListener{
+		Server: &Server{
+			clientService: clients.NewClientService(nil, nil, clients.NewClientRepository([]*clientdata.Client{client}, nil, testLog), testLog, nil),
+		},
+		Logger: testLog,
+	}
+
+	err := al.sendJob(context.Background(), client, &models.Job{JID: "job-1"}, &comm.RunCmdResponse{})
+	assert.EqualError(t, err, "failed to send request: EOF, client did not reconnect within 20ms")
+}
+
+func TestJobLocks(t *testing.T) {
+	al := &APIListener{
+		Server: &Server{
+			jobLocks: jobs.NewLocks(),
+		},
+		Logger: testLog,
+	}
+	job1 := &models.Job{JID: "job-1", ClientID: "client-1", Lock: "package-manager", TimeoutSec: 60}
+	job2 := &models.Job{JID: "job-2", ClientID: "client-1", Lock: "package-manager", TimeoutSec: 60}
+	require.NoError(t, al.acquireJobLock(context.Background(), job1))
+
+	acquired := make(chan error)
+	go func() {
+		acquired <- al.acquireJobLock(context.Background(), job2)
+	}()
+	require.Eventually(t, func() bool {
+		locks := al.jobLocks.List("client-1")
+		return len(locks) == 1 && locks[0].Waiting == 1
+	}, time.Second, time.Millisecond)
+
+	req := httptest.NewRequest(http.MethodGet, "/clients/client-1/locks", nil)
+	req = mux.SetURLVars(req, map[string]string{"client_id": "client-1"})
+	w := httptest.NewRecorder()
+	al.handleGetClientLocks(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Data []jobs.Lock `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	require.Len(t, body.Data, 1)
+	assert.Equal(t, "package-manager", body.Data[0].Name)
+	assert.Equal(t, "job-1", body.Data[0].JID)
+	assert.Equal(t, 1, body.Data[0].Waiting)
+
+	// a job received before isn't running anymore
+	al.releaseJobLockOnError(job1, &comm.RunCmdResponse{Duplicate: true}, nil)
+	require.NoError(t, <-acquired)
+
+	al.releaseJobLockOnError(job2, &comm.RunCmdResponse{}, nil)
+	assert.Equal(t, "job-2", al.jobLocks.List("client-1")[0].JID)
+	al.releaseJobLockOnError(job2, &comm.RunCmdResponse{}, errors.New("EOF"))
+	assert.Empty(t, al.jobLocks.List("client-1"))
+
+	// jobs without a lock don't wait
+	require.NoError(t, al.acquireJobLock(context.Background(), &models.Job{JID: "job-3", ClientID: "client-1"}))
+	assert.Empty(t, al.jobLocks.List("client-1"))
+}
+
+func TestMultiJobFinished(t *testing.T) {
+	running := &models.Job{Status: models.JobStatusRunning}
+	successful := &models.Job{Status: models.JobStatusSuccessful}
+	failed := &models.Job{Status: models.JobStatusFailed, Error: "exit status 1"}
+	notConnected := &models.Job{Status: models.JobStatusFailed, Error: ErrClientNotConnected.Error()}
+
+	testCases := []struct {
+		name string
+		job  *models.MultiJob
+		want bool
+	}{
+		{
+			name: "all clients done",
+			job:  &models.MultiJob{Concurrent: true, Jobs: []*models.Job{successful, failed}},
+			want: true,
+		},
+		{
+			name: "running",
+			job:  &models.MultiJob{Concurrent: true, Jobs: []*models.Job{successful, running}},
+		},
+		{
+			name: "next client not started",
+			job:  &models.MultiJob{AbortOnErr: true, Jobs: []*models.Job{successful}},
+		},
+		{
+			name: "aborted on error",
+			job:  &models.MultiJob{AbortOnErr: true, Jobs: []*models.Job{failed}},
+			want: true,
+		},
+		{
+			name: "continued after a client not connected",
+			job:  &models.MultiJob{AbortOnErr: true, Jobs: []*models.Job{notConnected}},
+		},
+		{
+			name: "canary failed",
+			job: &models.MultiJob{
+				Concurrent: true,
+				Jobs:       []*models.Job{failed},
+				Canary:     &models.MultiJobCanary{Status: models.CanaryStatusFailed},
+			},
+			want: true,
+		},
+		{
+			name: "canary awaiting confirmation",
+			job: &models.MultiJob{
+				Concurrent: true,
+				Jobs:       []*models.Job{successful},
+				Canary:     &models.MultiJobCanary{Status: models.CanaryStatusAwaitingConfirmation},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, multiJobFinished(tc.job, 2))
+		})
+	}
+}
@@ -3,8 +3,10 @@ package chserver
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/api/authorization"
 	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
 	"github.com/IOTech17/neo-rport/server/api/users"
 )
@@ -21,7 +23,28 @@ func (al *APIListener) getUserModel(ctx context.Context) (*users.User, error) {
 		return nil, err
 	}
 
-	return user, err
+	if user == nil {
+		return nil, nil
+	}
+
+	token := authorization.TokenFromContext(ctx)
+	var granted []string
+	if al.accessRequests != nil {
+		granted, err = al.accessRequests.GetGrantedClientGroups(ctx, user.Username, time.Now())
+		if err != nil {
+			return nil, err
+		}
+	}
+	if token.GetAllowedClientGroups() != nil || token.GetAllowedClients() != nil || len(granted) > 0 {
+		// copy, the user might be shared by the user provider
+		restricted := *user
+		restricted.AllowedClientGroups = token.GetAllowedClientGroups()
+		restricted.AllowedClients = token.GetAllowedClients()
+		restricted.GrantedClientGroups = granted
+		return &restricted, nil
+	}
+
+	return user, nil
 }
 
 // TODO: move to userService
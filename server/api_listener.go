@@ -22,6 +22,7 @@ import (
 	"github.com/jpillora/requestlog"
 
 	"github.com/IOTech17/neo-rport/db/migration/api_token"
+	idempotencykeysmigration "github.com/IOTech17/neo-rport/db/migration/idempotency_keys"
 	"github.com/IOTech17/neo-rport/db/migration/library"
 	"github.com/IOTech17/neo-rport/db/sqlite"
 	rportplus "github.com/IOTech17/neo-rport/plus"
@@ -31,6 +32,7 @@ import (
 	"github.com/IOTech17/neo-rport/server/notifications/channels/toLog"
 	notificationsSQLite "github.com/IOTech17/neo-rport/server/notifications/repository/sqlite"
 
+	"github.com/IOTech17/neo-rport/server/accessrequests"
 	"github.com/IOTech17/neo-rport/server/api/authorization"
 	"github.com/IOTech17/neo-rport/server/api/session"
 	"github.com/IOTech17/neo-rport/server/clients/storedtunnels"
@@ -39,11 +41,22 @@ import (
 	"github.com/IOTech17/neo-rport/server/api"
 	"github.com/IOTech17/neo-rport/server/api/command"
 	"github.com/IOTech17/neo-rport/server/api/message"
+	"github.com/IOTech17/neo-rport/server/api/middleware"
 	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/apilog"
+	"github.com/IOTech17/neo-rport/server/artifacts"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/authzhook"
 	"github.com/IOTech17/neo-rport/server/bearer"
+	"github.com/IOTech17/neo-rport/server/branding"
+	"github.com/IOTech17/neo-rport/server/changes"
+	"github.com/IOTech17/neo-rport/server/killswitch"
+	"github.com/IOTech17/neo-rport/server/loginpolicy"
+	"github.com/IOTech17/neo-rport/server/tripwire"
 	"github.com/IOTech17/neo-rport/server/vault"
 
 	extperm "github.com/IOTech17/neo-rport/plus/capabilities/extendedpermission"
+	"github.com/IOTech17/neo-rport/plus/capabilities/oauth"
 	chshare "github.com/IOTech17/neo-rport/share"
 	"github.com/IOTech17/neo-rport/share/enums"
 	"github.com/IOTech17/neo-rport/share/files"
@@ -64,28 +77,45 @@ type APIListener struct {
 
 	fingerprint       string
 	apiSessions       *session.Cache
+	sessionPolicies   session.PolicyProvider
+	loginPolicy       *loginpolicy.Engine
+	authzHook         *authzhook.Hook
+	killSwitches      *killswitch.Store
+	changeJournal     *changes.Journal
 	router            *mux.Router
 	httpServer        *chshare.HTTPServer
 	requestLogOptions *requestlog.Options
 	accessLogFile     io.WriteCloser
+	requestLog        *apilog.Logger
 	insecureForTests  bool
 	bannedUsers       *security.BanList
 	bannedIPs         *security.MaxBadAttemptsBanList
+	lockouts          *lockouts
 	twoFASrv          TwoFAService
 
 	testDone chan bool // is used only in tests to be able to wait until async task is done
 
-	userService    UserService
-	vaultManager   *vault.Manager
-	scriptManager  *script.Manager
-	tokenManager   *authorization.Manager
-	commandManager *command.Manager
-	storedTunnels  *storedtunnels.Manager
-
-	notificationsStorage   notificationsSQLite.Repository
-	notificationsProcessor notifications.Processor
-	notificationsDB        *sqlx.DB
-	notificationsCleaner   notificationsSQLite.Closeable
+	userService     UserService
+	vaultManager    *vault.Manager
+	scriptManager   *script.Manager
+	tokenManager    *authorization.Manager
+	brokerGrants    *authorization.BrokerGrantProvider
+	branding        *branding.Provider
+	devicePolls     *devicePolls
+	idempotencyKeys *idempotencyKeys
+	accessRequests  *accessrequests.SqliteProvider
+	commandManager  *command.Manager
+	storedTunnels   *storedtunnels.Manager
+
+	artifactManager *artifacts.Manager
+
+	notificationsStorage    notificationsSQLite.Repository
+	notificationsProcessor  notifications.Processor
+	notificationsDB         *sqlx.DB
+	notificationsCleaner    notificationsSQLite.Closeable
+	notificationDigestStore notifications.DigestStore
+	notificationDigests     *notifications.DigestDispatcher
+	stopDigests             context.CancelFunc
 
 	mu sync.RWMutex
 }
@@ -152,11 +182,16 @@ func NewAPIListener(
 
 	notificationConsumers := []notifications.Consumer{scriptConsumer}
 
+	brand, err := branding.NewProvider(path.Join(config.Server.DataDir, "branding.json"))
+	if err != nil {
+		return nil, err
+	}
+
 	smtpConfig, err := rmailer.ConfigFromSMTPConfig(config.SMTP)
 	if err == nil {
 		smtpLogger := notificationsLogger.Fork("smtp")
 		smtpLogger.Debugf("using smtp config: %v", smtpConfig)
-		mailConsumer := rmailer.NewConsumer(rmailer.NewRMailer(smtpConfig, smtpLogger), smtpLogger)
+		mailConsumer := rmailer.NewConsumer(rmailer.NewRMailer(smtpConfig, brand, smtpLogger), brand, smtpLogger)
 		notificationConsumers = append(notificationConsumers, mailConsumer)
 	} else {
 		notificationsLogger.Errorf("failed to bootstrap smtp notifications: %v", err)
@@ -201,6 +236,16 @@ func NewAPIListener(
 		return nil, fmt.Errorf("failed init api_token DB instance: %w", err)
 	}
 
+	idempotencyKeysDB, err := sqlite.New(
+		path.Join(config.Server.DataDir, "idempotency_keys.db"),
+		idempotencykeysmigration.AssetNames(),
+		idempotencykeysmigration.Asset,
+		config.Server.GetSQLiteDataSourceOptions(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed init idempotency keys DB instance: %w", err)
+	}
+
 	scriptLogger := logger.NewLogger("scripts", config.Logging.LogOutput, config.Logging.LogLevel)
 	scriptProvider := script.NewSqliteProvider(libraryDb)
 	scriptManager := script.NewManager(scriptProvider, scriptLogger)
@@ -208,6 +253,14 @@ func NewAPIListener(
 	commandProvider := command.NewSqliteProvider(libraryDb)
 	commandManager := command.NewManager(commandProvider)
 
+	artifactLogger := logger.NewLogger("artifacts", config.Logging.LogOutput, config.Logging.LogLevel)
+	artifactManager := artifacts.NewManager(
+		artifacts.NewSqliteProvider(libraryDb),
+		path.Join(config.Server.DataDir, "artifacts"),
+		config.API.MaxFilePushSize,
+		artifactLogger,
+	)
+
 	tokenProvider := authorization.NewSqliteProvider(apiTokenDb)
 	tokenManager := authorization.NewManager(tokenProvider)
 
@@ -232,28 +285,52 @@ func NewAPIListener(
 			chshare.WithTLS("", "", tlsConfig),
 		}
 	}
+	if config.API.ProxyProtocol {
+		HTTPServerOptions = append(HTTPServerOptions, chshare.WithProxyProtocol(config.Server.TrustedProxies))
+	}
 
 	allog := logger.NewLogger("api-listener", config.Logging.LogOutput, config.Logging.LogLevel)
+
+	digestStore := notificationsSQLite.NewDigestRepository(db)
+	notificationDigests := notifications.NewDigestDispatcher(
+		notifications.NewDispatcher(store),
+		digestStore,
+		notificationsLogger.Fork("digest"),
+	)
+	digestsCtx, stopDigests := context.WithCancel(context.Background())
+	go notificationDigests.Run(digestsCtx)
+
 	a := &APIListener{
-		Server:                 server,
-		Logger:                 allog,
-		fingerprint:            fingerprint,
-		httpServer:             chshare.NewHTTPServer(int(config.API.MaxRequestBytes), allog, HTTPServerOptions...),
-		requestLogOptions:      config.InitRequestLogOptions(),
-		bannedUsers:            security.NewBanList(time.Duration(config.API.UserLoginWait) * time.Second),
-		userService:            userService,
-		vaultManager:           vault.NewManager(vaultDBProviderFactory, &vault.Aes256PassManager{}, vaultLogger),
-		scriptManager:          scriptManager,
-		commandManager:         commandManager,
-		tokenManager:           tokenManager,
-		storedTunnels:          storedtunnels.New(server.clientDB),
-		notificationsStorage:   store,
-		notificationsProcessor: notificationProcessor,
-		notificationsDB:        db,
-		notificationsCleaner:   notificationsCleaner,
+		Server:                  server,
+		Logger:                  allog,
+		fingerprint:             fingerprint,
+		httpServer:              chshare.NewHTTPServer(int(config.API.MaxRequestBytes), allog, HTTPServerOptions...),
+		requestLogOptions:       config.InitRequestLogOptions(),
+		bannedUsers:             security.NewBanList(time.Duration(config.API.UserLoginWait) * time.Second),
+		lockouts:                newLockouts(),
+		userService:             userService,
+		vaultManager:            vault.NewManager(vaultDBProviderFactory, &vault.Aes256PassManager{}, vaultLogger),
+		scriptManager:           scriptManager,
+		commandManager:          commandManager,
+		artifactManager:         artifactManager,
+		tokenManager:            tokenManager,
+		brokerGrants:            authorization.NewBrokerGrantProvider(apiTokenDb),
+		accessRequests:          accessrequests.NewSqliteProvider(apiTokenDb),
+		branding:                brand,
+		devicePolls:             newDevicePolls(),
+		idempotencyKeys:         newIdempotencyKeys(idempotencyKeysDB),
+		storedTunnels:           storedtunnels.New(server.clientDB),
+		notificationsStorage:    store,
+		notificationsProcessor:  notificationProcessor,
+		notificationsDB:         db,
+		notificationsCleaner:    notificationsCleaner,
+		notificationDigestStore: digestStore,
+		notificationDigests:     notificationDigests,
+		stopDigests:             stopDigests,
 	}
 
 	a.errResponseLogger = allog.Fork("error-response")
+	server.clientService.SetDevicePasswordHook(a.devicePassword)
 
 	if config.API.IsTwoFAOn() {
 		var msgSrv message.Service
@@ -289,6 +366,7 @@ func NewAPIListener(
 			userService,
 			msgSrv,
 		)
+		a.twoFASrv.Branding = a.branding
 		userService.DeliverySrv = msgSrv
 		a.Logger.Infof("2FA is enabled via using %s", config.API.TwoFATokenDelivery)
 	}
@@ -300,6 +378,7 @@ func NewAPIListener(
 			userService,
 			nil,
 		)
+		a.twoFASrv.Branding = a.branding
 		a.Logger.Infof("2FA is enabled via an Authenticator app")
 	}
 
@@ -309,6 +388,9 @@ func NewAPIListener(
 			time.Duration(config.API.BanTime)*time.Second,
 			a.Logger,
 		)
+		if config.API.BanHook != "" {
+			a.bannedIPs.SetHook(newBanHook(config.API.BanHook, banListenerAPI, a.Logger))
+		}
 	}
 
 	if config.API.AccessLogFile != "" {
@@ -319,6 +401,13 @@ func NewAPIListener(
 		a.accessLogFile = accessLogFile
 	}
 
+	if config.API.RequestLog.Enabled() {
+		a.requestLog, err = apilog.New(config.API.RequestLog, allog.Fork("request-log"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open request log: %w", err)
+		}
+	}
+
 	sessionDB, err := session.NewSqliteProvider(path.Join(config.Server.DataDir, "api_sessions.db"), config.Server.GetSQLiteDataSourceOptions())
 	if err != nil {
 		return nil, err
@@ -328,6 +417,21 @@ func NewAPIListener(
 	if err != nil {
 		return nil, err
 	}
+	a.sessionPolicies = sessionDB
+
+	a.loginPolicy, err = loginpolicy.New(config.LoginPolicy)
+	if err != nil {
+		return nil, err
+	}
+	a.authzHook = authzhook.New(config.AuthzHook)
+
+	a.killSwitches, err = killswitch.Open(config.Server.DataDir)
+	if err != nil {
+		return nil, err
+	}
+
+	a.changeJournal = changes.New(config.API.ChangeJournalRetention)
+	a.registerChangeResources()
 
 	a.initRouter()
 
@@ -337,7 +441,11 @@ func NewAPIListener(
 func (al *APIListener) Start(ctx context.Context, addr string) error {
 	al.Infof("API Listening on %s...", addr)
 
-	err := al.httpServer.GoListenAndServe(ctx, addr, al.router)
+	h := http.Handler(al.router)
+	if len(al.config.Server.TrustedProxies) > 0 {
+		h = middleware.TrustedProxies(al.config.Server.TrustedProxies)(h)
+	}
+	err := al.httpServer.GoListenAndServe(ctx, addr, h)
 	if err != nil {
 		return err
 	}
@@ -362,6 +470,9 @@ func (al *APIListener) Close() error {
 	if al.accessLogFile != nil {
 		g.Go(al.accessLogFile.Close)
 	}
+	if al.requestLog != nil {
+		g.Go(al.requestLog.Close)
+	}
 
 	if al.vaultManager != nil {
 		g.Go(al.vaultManager.Close)
@@ -376,7 +487,13 @@ func (al *APIListener) Close() error {
 	if al.apiSessions != nil {
 		g.Go(al.apiSessions.Close)
 	}
+	if al.idempotencyKeys != nil {
+		g.Go(al.idempotencyKeys.Close)
+	}
 
+	if al.stopDigests != nil {
+		al.stopDigests()
+	}
 	g.Go(al.notificationsStorage.Close)
 	g.Go(al.notificationsProcessor.Close)
 	g.Go(al.notificationsDB.Close)
@@ -395,9 +512,13 @@ var ErrInvalidScopeOfThatToken = errors.New("the scope of the provided token is
 var ErrThatTokenHasExpired = errors.New("the provided token has expired")
 
 // lookupUser is used to get the user on every request in auth middleware
-func (al *APIListener) lookupUser(r *http.Request, isBearerOnly bool) (authorized bool, username string, err error) {
+// If authenticated by an API token, it is returned as well.
+func (al *APIListener) lookupUser(r *http.Request, isBearerOnly bool) (authorized bool, username string, apiToken *authorization.APIToken, err error) {
 	if !isBearerOnly {
 		if basicUser, basicPwd, basicAuthProvided := r.BasicAuth(); basicAuthProvided {
+			if al.isDecoyUser(r, basicUser) {
+				return false, basicUser, nil, nil
+			}
 			return al.handleBasicAuth(r.Context(), r.Method, r.URL.Path, basicUser, basicPwd)
 		}
 	}
@@ -405,47 +526,61 @@ func (al *APIListener) lookupUser(r *http.Request, isBearerOnly bool) (authorize
 	if bearerToken, bearerAuthProvided := bearer.GetBearerToken(r); bearerAuthProvided {
 		isAuthorized, token, err := al.checkBearerToken(r.Context(), bearerToken, r.URL.Path, r.Method)
 		if err != nil {
-			return isAuthorized, "", err
+			return isAuthorized, "", nil, err
 		}
 
-		return isAuthorized, token.AppClaims.Username, nil
+		return isAuthorized, token.AppClaims.Username, nil, nil
 	}
 
 	// case when no auth method is provided
 	if al.bannedUsers.IsBanned("") {
-		return false, "", ErrTooManyRequests
+		return false, "", nil, ErrTooManyRequests
 	}
 
-	return false, "", nil
+	return false, "", nil, nil
+}
+
+// isDecoyUser returns true and raises a security alert if the username is a decoy which must never authenticate.
+func (al *APIListener) isDecoyUser(req *http.Request, username string) bool {
+	if !al.tripwire.IsDecoyAPIUser(username) {
+		return false
+	}
+
+	al.tripwire.Trigger(req.Context(), tripwire.KindAPIUser, username, chshare.RemoteIP(req), req.UserAgent())
+	al.auditLog.Entry(auditlog.ApplicationAuthTripwire, auditlog.ActionFailed).
+		WithHTTPRequest(req).
+		WithID(username).
+		Save()
+	return true
 }
 
 // handleBasicAuth checks username and password against either user's password or token
-func (al *APIListener) handleBasicAuth(ctx context.Context, httpverb, urlpath, username, password string) (authorized bool, name string, err error) {
+func (al *APIListener) handleBasicAuth(ctx context.Context, httpverb, urlpath, username, password string) (authorized bool, name string, apiToken *authorization.APIToken, err error) {
 	if al.bannedUsers.IsBanned(username) {
-		return false, username, ErrTooManyRequests
+		return false, username, nil, ErrTooManyRequests
 	}
 
 	if username == "" {
-		return false, "", nil
+		return false, "", nil, nil
 	}
 
 	user, err := al.userService.GetByUsername(username)
 	if err != nil {
-		return false, username, fmt.Errorf("failed to get user: %v", err)
+		return false, username, nil, fmt.Errorf("failed to get user: %v", err)
 	}
 	if user == nil {
-		return false, username, nil
+		return false, username, nil, nil
 	}
 
 	if user.PasswordExpired != nil && *user.PasswordExpired {
-		return false, username, ErrThatPasswordHasExpired
+		return false, username, nil, ErrThatPasswordHasExpired
 	}
 
 	// skip basic auth with password when 2fa is enabled
 	if !al.config.API.IsTwoFAOn() && !al.config.API.TotPEnabled {
 		passwordOk := verifyPassword(user.Password, password)
 		if passwordOk {
-			return true, username, nil
+			return true, username, nil, nil
 		}
 	}
 
@@ -454,38 +589,57 @@ func (al *APIListener) handleBasicAuth(ctx context.Context, httpverb, urlpath, u
 	// TODO: this type of tokens "User tokens", meant to be used by scripts - used in place of the password at each request - should be renamed "passwords" or "long lived passwords" or "encrypted long lived passwords"
 	prefix, password, err := authorization.Extract(password)
 	if err != nil {
-		return false, username, nil
+		return false, username, nil, nil
 	}
 	userToken, err := al.tokenManager.Get(ctx, username, prefix)
 	if err != nil {
-		return false, username, err
+		return false, username, nil, err
 	}
 
 	if userToken != nil {
 		if userToken.ExpiresAt != nil {
 			if userToken.ExpiresAt.Before(time.Now()) {
-				return false, username, nil
+				return false, username, nil, nil
 			}
 		}
 		tokenOk := verifyPassword(userToken.Token, password)
 		if tokenOk {
+			valid, err := al.isParentTokenValid(ctx, userToken)
+			if err != nil || !valid {
+				return false, username, nil, err
+			}
 			switch userToken.Scope {
 			case authorization.APITokenRead:
 				if httpverb == "GET" && !strings.Contains(urlpath, "/ws") {
-					return true, username, nil
+					return true, username, userToken, nil
 				}
 			case authorization.APITokenReadWrite:
-				return true, username, nil
+				return true, username, userToken, nil
 			case authorization.APITokenClientsAuth:
 				if strings.Contains(urlpath, "clients-auth") {
-					return true, username, nil
+					return true, username, userToken, nil
 				}
 			}
-			return false, username, ErrInvalidScopeOfThatToken
+			return false, username, nil, ErrInvalidScopeOfThatToken
 		}
 	}
 
-	return false, username, nil
+	return false, username, nil, nil
+}
+
+// isParentTokenValid returns false if a token the given one is derived from has expired.
+func (al *APIListener) isParentTokenValid(ctx context.Context, token *authorization.APIToken) (bool, error) {
+	for token.ParentPrefix != "" {
+		parent, err := al.tokenManager.Get(ctx, token.Username, token.ParentPrefix)
+		if err != nil {
+			return false, err
+		}
+		if parent == nil || (parent.ExpiresAt != nil && parent.ExpiresAt.Before(time.Now())) {
+			return false, nil
+		}
+		token = parent
+	}
+	return true, nil
 }
 
 func (al *APIListener) checkBearerToken(ctx context.Context, bearerToken, uri, method string) (bool, *bearer.TokenContext, error) {
@@ -513,7 +667,8 @@ func (al *APIListener) checkBearerToken(ctx context.Context, bearerToken, uri, m
 	if err != nil {
 		return false, tokenCtx, err
 	}
-	if authorized {
+	// impersonations are not extended to keep them within the requested lifetime
+	if authorized && tokenCtx.AppClaims.ImpersonatedBy == "" {
 		// extend the token lifetime by a short amount so that in-progress activities can complete
 		if err := bearer.IncreaseSessionLifetime(ctx, al.apiSessions, apiSession); err != nil {
 			// do not return error since it should respond with 401 instead of 500, just log it
@@ -526,7 +681,9 @@ func (al *APIListener) checkBearerToken(ctx context.Context, bearerToken, uri, m
 const htpasswdBcryptPrefix = "$2y$"
 
 // validateCredentials returns true if given credentials belong to a user with access to the API.
-func (al *APIListener) validateCredentials(username, password string, skipPasswordValidation bool) (bool, *users.User, error) {
+// validateCredentials checks the password of the user unless skipped. A missing user is created on the first login
+// without password, with the user groups of the OAuth provider the user logs in with if given.
+func (al *APIListener) validateCredentials(username, password string, skipPasswordValidation bool, oauthProvider *oauth.Config) (bool, *users.User, error) {
 	if username == "" {
 		return false, nil, nil
 	}
@@ -536,15 +693,19 @@ func (al *APIListener) validateCredentials(username, password string, skipPasswo
 		return false, nil, fmt.Errorf("failed to get user: %v", err)
 	}
 
-	if al.shouldCreateMissingUser(user, skipPasswordValidation) {
+	if al.shouldCreateMissingUser(user, skipPasswordValidation, oauthProvider) {
 		pswd, err := random.UUID4()
 		if err != nil {
 			return false, nil, err
 		}
+		groups := []string{al.config.API.DefaultUserGroup}
+		if oauthProvider != nil && len(oauthProvider.UserGroups) > 0 {
+			groups = oauthProvider.UserGroups
+		}
 		user = &users.User{
 			Username: username,
 			Password: pswd,
-			Groups:   []string{al.config.API.DefaultUserGroup},
+			Groups:   groups,
 		}
 		err = al.userService.Change(user, "")
 		if err != nil {
@@ -563,14 +724,17 @@ func (al *APIListener) validateCredentials(username, password string, skipPasswo
 	return verifyPassword(user.Password, password), user, nil
 }
 
-func (al *APIListener) shouldCreateMissingUser(user *users.User, skipPasswordValidation bool) bool {
+func (al *APIListener) shouldCreateMissingUser(user *users.User, skipPasswordValidation bool, oauthProvider *oauth.Config) bool {
 	if user != nil || !skipPasswordValidation {
 		return false
 	}
-	if al.config.API.CreateMissingUsers || !rportplus.IsOAuthPermittedUserList(al.config.PlusConfig) {
+	if al.config.API.CreateMissingUsers {
 		return true
 	}
-	return false
+	if oauthProvider != nil && rportplus.IsPlusEnabled(al.config.PlusConfig) {
+		return !oauthProvider.PermittedUserList
+	}
+	return !rportplus.IsOAuthPermittedUserList(al.config.PlusConfig)
 }
 
 func verifyPassword(saved, provided string) bool {
@@ -594,6 +758,8 @@ func (al *APIListener) wsAuth(f http.Handler) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var authorized bool
 		var username string
+		var apiToken *authorization.APIToken
+		var impersonatedBy string
 		var err error
 
 		tokenStr := r.URL.Query().Get(WebSocketAccessTokenQueryParam)
@@ -601,7 +767,11 @@ func (al *APIListener) wsAuth(f http.Handler) http.HandlerFunc {
 			basicUser, basicPwd, basicAuthProvided := r.BasicAuth()
 
 			if basicAuthProvided {
-				authorized, username, err = al.handleBasicAuth(r.Context(), r.Method, r.URL.Path, basicUser, basicPwd)
+				if al.isDecoyUser(r, basicUser) {
+					username = basicUser
+				} else {
+					authorized, username, apiToken, err = al.handleBasicAuth(r.Context(), r.Method, r.URL.Path, basicUser, basicPwd)
+				}
 			} else {
 				if !al.handleBannedIPs(r, false) {
 					return
@@ -614,6 +784,7 @@ func (al *APIListener) wsAuth(f http.Handler) http.HandlerFunc {
 			authorized, token, err = al.checkBearerToken(r.Context(), tokenStr, r.URL.Path, r.Method)
 			if authorized && err == nil {
 				username = token.AppClaims.Username
+				impersonatedBy = token.AppClaims.ImpersonatedBy
 			}
 		}
 
@@ -631,12 +802,27 @@ func (al *APIListener) wsAuth(f http.Handler) http.HandlerFunc {
 		}
 
 		if !authorized || username == "" {
-			al.bannedUsers.Add(username)
+			al.banUser(r, username)
 			al.jsonErrorResponse(w, http.StatusUnauthorized, errUnauthorized)
 			return
 		}
 
 		newCtx := api.WithUser(r.Context(), username)
+		apilog.SetUsername(newCtx, username)
+		if apiToken != nil {
+			if err := checkTokenRoutePermission(r, apiToken); err != nil {
+				al.jsonError(w, err)
+				return
+			}
+			newCtx = authorization.WithToken(newCtx, apiToken)
+		}
+		if impersonatedBy != "" {
+			if err := al.checkImpersonator(impersonatedBy); err != nil {
+				al.jsonError(w, err)
+				return
+			}
+			newCtx = api.WithImpersonator(newCtx, impersonatedBy)
+		}
 		f.ServeHTTP(w, r.WithContext(newCtx))
 	}
 }
@@ -125,7 +125,7 @@ func TestValidateCredentials(t *testing.T) {
 		al.userService = users.NewAPIService(users.NewStaticProvider(tc.repoUsers), false, 0, -1)
 
 		// when
-		gotRes, _, gotErr := al.validateCredentials(tc.username, tc.password, false)
+		gotRes, _, gotErr := al.validateCredentials(tc.username, tc.password, false, nil)
 
 		// then
 		assert.NoErrorf(t, gotErr, msg)
@@ -1,11 +1,19 @@
 package chserver
 
 import (
+	"context"
 	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
 
 	rportplus "github.com/IOTech17/neo-rport/plus"
 	"github.com/IOTech17/neo-rport/plus/capabilities/oauth"
 	"github.com/IOTech17/neo-rport/server/api"
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/branding"
 	"github.com/IOTech17/neo-rport/server/routes"
 )
 
@@ -18,11 +26,16 @@ type AuthProviderInfo struct {
 	SettingsURI       string `json:"settings_uri"`
 	DeviceSettingsURI string `json:"device_settings_uri"`
 	MaxTokenLifetime  int    `json:"max_token_lifetime"`
+	// ProvidersURI lists all providers when several are configured
+	ProvidersURI string `json:"providers_uri"`
+	// Branding is shown on the login page
+	Branding branding.Public `json:"branding"`
 }
 
 // AuthSettings contains the auth info to be used by a regular web app
 // type authorization
 type AuthSettings struct {
+	Name         string           `json:"name"`
 	AuthProvider string           `json:"auth_provider"`
 	LoginInfo    *oauth.LoginInfo `json:"details"`
 }
@@ -30,21 +43,135 @@ type AuthSettings struct {
 // DeviceAuthSettings contains the auth info to be used by a CLI or
 // similarly constrained app
 type DeviceAuthSettings struct {
+	Name         string                 `json:"name"`
 	AuthProvider string                 `json:"auth_provider"`
 	LoginInfo    *oauth.DeviceLoginInfo `json:"details"`
 }
 
+// OAuthProviderInfo describes one of several providers to select on the login page
+type OAuthProviderInfo struct {
+	Name              string `json:"name"`
+	AuthProvider      string `json:"auth_provider"`
+	Label             string `json:"label"`
+	Default           bool   `json:"default"`
+	SettingsURI       string `json:"settings_uri"`
+	DeviceSettingsURI string `json:"device_settings_uri"`
+}
+
+// oauthProvider is a configured OAuth provider and the plugin capability handling its logins
+type oauthProvider struct {
+	config    *oauth.Config
+	capEx     oauth.CapabilityEx
+	isDefault bool
+}
+
+type oauthProviderCtxKeyType int
+
+const oauthProviderCtxKey oauthProviderCtxKeyType = iota
+
+func withOAuthProvider(ctx context.Context, config *oauth.Config) context.Context {
+	return context.WithValue(ctx, oauthProviderCtxKey, config)
+}
+
+// oauthProviderFromContext returns the config of the provider the user logs in with, nil if it's not an OAuth login
+func oauthProviderFromContext(ctx context.Context) *oauth.Config {
+	config, _ := ctx.Value(oauthProviderCtxKey).(*oauth.Config)
+	return config
+}
+
+func oauthProviderLoginURI(name string) string {
+	return "/oauth/" + name + "/login"
+}
+
+func oauthProviderDeviceLoginURI(name string) string {
+	return "/oauth/" + name + "/login/device"
+}
+
+// getOAuthProvider returns the provider with the given name, the provider of the plus-oauth config for an empty name.
+func (al *APIListener) getOAuthProvider(name string) (*oauthProvider, error) {
+	if !rportplus.IsPlusOAuthEnabled(al.config.PlusConfig) || al.Server.plusManager == nil {
+		return nil, errors2.APIError{
+			Err:        rportplus.ErrPlusNotAvailable,
+			HTTPStatus: http.StatusForbidden,
+		}
+	}
+	plusManager := al.Server.plusManager
+
+	for i, config := range rportplus.OAuthProviders(al.config.PlusConfig) {
+		isDefault := i == 0
+		if !(isDefault && name == "") && config.GetName() != name {
+			continue
+		}
+		p := &oauthProvider{
+			config:    config,
+			isDefault: isDefault,
+		}
+		if isDefault {
+			p.capEx = plusManager.GetOAuthCapabilityEx()
+		} else {
+			p.capEx = plusManager.GetOAuthProviderCapabilityEx(name)
+		}
+		if p.capEx == nil {
+			return nil, errors2.APIError{
+				Err:        rportplus.ErrCapabilityNotAvailable(rportplus.PlusOAuthCapability),
+				HTTPStatus: http.StatusForbidden,
+			}
+		}
+		return p, nil
+	}
+
+	return nil, errors2.APIError{
+		Message:    "unknown oauth provider: " + name,
+		HTTPStatus: http.StatusNotFound,
+	}
+}
+
+// oauthProviderName returns the provider selected by the provider query param or by the email domain of the hint
+// query param, empty for the default provider.
+func (al *APIListener) oauthProviderName(req *http.Request) string {
+	if name := req.URL.Query().Get("provider"); name != "" {
+		return name
+	}
+	if hint := req.URL.Query().Get("hint"); hint != "" {
+		for _, config := range al.config.PlusConfig.OAuthProviders {
+			if config.MatchesHint(hint) {
+				return config.GetName()
+			}
+		}
+	}
+	return ""
+}
+
+// loginURI returns the login uri of the plugin changed to the API prefix of the request and to the route of an
+// additional provider.
+func (p *oauthProvider) loginURI(prefix, pluginURI, defaultURI string, providerURI func(string) string) string {
+	if trimmed := trimAPIPrefix(pluginURI); trimmed != pluginURI {
+		pluginURI = prefix + trimmed
+	}
+	if p.isDefault {
+		return pluginURI
+	}
+	uri := providerURI(url.PathEscape(p.config.GetName()))
+	if strings.Contains(pluginURI, defaultURI) {
+		return strings.Replace(pluginURI, defaultURI, uri, 1)
+	}
+	return prefix + uri
+}
+
 func (al *APIListener) handleGetAuthProvider(w http.ResponseWriter, req *http.Request) {
 	var response api.SuccessPayload
 
 	maxTokenLifetime := al.config.API.MaxTokenLifeTimeHours
+	prefix := apiPrefix(req.Context())
 
 	if rportplus.IsPlusOAuthEnabled(al.config.PlusConfig) {
 		OAuthProvider := AuthProviderInfo{
 			AuthProvider:      al.config.PlusConfig.OAuthConfig.Provider,
-			SettingsURI:       routes.AllRoutesPrefix + routes.AuthRoutesPrefix + routes.AuthSettingsRoute,
-			DeviceSettingsURI: routes.AllRoutesPrefix + routes.AuthRoutesPrefix + routes.AuthDeviceSettingsRoute,
+			SettingsURI:       prefix + routes.AuthRoutesPrefix + routes.AuthSettingsRoute,
+			DeviceSettingsURI: prefix + routes.AuthRoutesPrefix + routes.AuthDeviceSettingsRoute,
 			MaxTokenLifetime:  maxTokenLifetime,
+			ProvidersURI:      prefix + routes.AuthRoutesPrefix + routes.AuthProvidersRoute,
+			Branding:          al.branding.Get().Public(),
 		}
 		response = api.NewSuccessPayload(OAuthProvider)
 	} else {
@@ -52,32 +179,62 @@ func (al *APIListener) handleGetAuthProvider(w http.ResponseWriter, req *http.Re
 			AuthProvider:     BuiltInAuthProviderName,
 			SettingsURI:      "",
 			MaxTokenLifetime: maxTokenLifetime,
+			Branding:         al.branding.Get().Public(),
 		}
 		response = api.NewSuccessPayload(builtInAuthProvider)
 	}
 	al.writeJSONResponse(w, http.StatusOK, response)
 }
 
-func (al *APIListener) handleGetAuthSettings(w http.ResponseWriter, req *http.Request) {
+// handleListAuthProviders handles GET /auth/providers, it lists the providers to select on the login page.
+func (al *APIListener) handleListAuthProviders(w http.ResponseWriter, req *http.Request) {
 	if !rportplus.IsPlusOAuthEnabled(al.config.PlusConfig) {
-		al.jsonErrorResponse(w, http.StatusForbidden, rportplus.ErrPlusNotAvailable)
+		al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload([]OAuthProviderInfo{{
+			Name:         BuiltInAuthProviderName,
+			AuthProvider: BuiltInAuthProviderName,
+			Label:        BuiltInAuthProviderName,
+			Default:      true,
+		}}))
 		return
 	}
 
-	plusManager := al.Server.plusManager
-	capEx := plusManager.GetOAuthCapabilityEx()
-	if capEx == nil {
-		al.jsonErrorResponse(w, http.StatusForbidden, rportplus.ErrCapabilityNotAvailable(rportplus.PlusOAuthCapability))
+	prefix := apiPrefix(req.Context())
+	var providers []OAuthProviderInfo
+	for i, config := range rportplus.OAuthProviders(al.config.PlusConfig) {
+		query := "?provider=" + url.QueryEscape(config.GetName())
+		label := config.Label
+		if label == "" {
+			label = config.GetName()
+		}
+		providers = append(providers, OAuthProviderInfo{
+			Name:              config.GetName(),
+			AuthProvider:      config.Provider,
+			Label:             label,
+			Default:           i == 0,
+			SettingsURI:       prefix + routes.AuthRoutesPrefix + routes.AuthSettingsRoute + query,
+			DeviceSettingsURI: prefix + routes.AuthRoutesPrefix + routes.AuthDeviceSettingsRoute + query,
+		})
+	}
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(providers))
+}
+
+func (al *APIListener) handleGetAuthSettings(w http.ResponseWriter, req *http.Request) {
+	provider, err := al.getOAuthProvider(al.oauthProviderName(req))
+	if err != nil {
+		al.jsonError(w, err)
 		return
 	}
 
-	loginInfo, err := capEx.GetLoginInfo()
+	loginInfo, err := provider.capEx.GetLoginInfo()
 	if err != nil {
 		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
 		return
 	}
+	loginInfo.LoginURI = provider.loginURI(apiPrefix(req.Context()), loginInfo.LoginURI, oauth.DefaultLoginURI, oauthProviderLoginURI)
+
 	settings := AuthSettings{
-		AuthProvider: al.config.PlusConfig.OAuthConfig.Provider,
+		Name:         provider.config.GetName(),
+		AuthProvider: provider.config.Provider,
 		LoginInfo:    loginInfo,
 	}
 	response := api.NewSuccessPayload(settings)
@@ -85,29 +242,42 @@ func (al *APIListener) handleGetAuthSettings(w http.ResponseWriter, req *http.Re
 }
 
 func (al *APIListener) handleGetAuthDeviceSettings(w http.ResponseWriter, req *http.Request) {
-	if !rportplus.IsPlusOAuthEnabled(al.config.PlusConfig) {
-		al.jsonErrorResponse(w, http.StatusForbidden, rportplus.ErrPlusNotAvailable)
-		return
-	}
-
-	plusManager := al.Server.plusManager
-	capEx := plusManager.GetOAuthCapabilityEx()
-	if capEx == nil {
-		al.jsonErrorResponse(w, http.StatusForbidden, rportplus.ErrCapabilityNotAvailable(rportplus.PlusOAuthCapability))
+	provider, err := al.getOAuthProvider(al.oauthProviderName(req))
+	if err != nil {
+		al.jsonError(w, err)
 		return
 	}
 
-	loginInfo, err := capEx.GetLoginInfoForDevice(req)
+	loginInfo, err := provider.capEx.GetLoginInfoForDevice(req)
 	if err != nil {
 		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
 		return
 	}
+	loginInfo.LoginURI = provider.loginURI(apiPrefix(req.Context()), loginInfo.LoginURI, oauth.DefaultDeviceLoginURI, oauthProviderDeviceLoginURI)
+	if info := loginInfo.DeviceAuthInfo; info != nil && info.DeviceCode != "" {
+		// the provider's interval unless a longer one is configured
+		interval := time.Duration(info.Interval) * time.Second
+		if minInterval := provider.config.GetDevicePollInterval(0); minInterval > interval {
+			interval = minInterval
+		}
+		if interval <= 0 {
+			interval = DefaultDevicePollInterval
+		}
+		info.Interval = int(interval.Seconds())
+		al.devicePolls.Start(provider.config.GetName()+"/"+info.DeviceCode, interval, time.Duration(info.ExpiresIn)*time.Second)
+	}
 
 	settings := DeviceAuthSettings{
-		AuthProvider: al.config.PlusConfig.OAuthConfig.Provider,
+		Name:         provider.config.GetName(),
+		AuthProvider: provider.config.Provider,
 		LoginInfo:    loginInfo,
 	}
 
 	response := api.NewSuccessPayload(settings)
 	al.writeJSONResponse(w, http.StatusOK, response)
 }
+
+// oauthProviderFromRoute returns the provider of the login route, the default provider for the default routes.
+func (al *APIListener) oauthProviderFromRoute(req *http.Request) (*oauthProvider, error) {
+	return al.getOAuthProvider(mux.Vars(req)[routes.ParamOAuthProvider])
+}
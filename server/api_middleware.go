@@ -2,6 +2,7 @@ package chserver
 
 import (
 	"context"
+	"crypto/subtle"
 	"errors"
 	"fmt"
 	"net/http"
@@ -11,8 +12,10 @@ import (
 
 	rportplus "github.com/IOTech17/neo-rport/plus"
 	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/api/authorization"
 	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
 	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/apilog"
 	"github.com/IOTech17/neo-rport/server/bearer"
 	"github.com/IOTech17/neo-rport/server/clients/clienttunnel"
 	"github.com/IOTech17/neo-rport/server/routes"
@@ -46,6 +49,14 @@ func (al *APIListener) wrapAdminAccessMiddleware(next http.Handler) http.Handler
 			return
 		}
 
+		if authorization.TokenFromContext(r.Context()).IsNarrowed() {
+			al.jsonError(w, errors2.APIError{
+				Message:    "a token restricted to some permissions or client groups can't be used to access this resource",
+				HTTPStatus: http.StatusForbidden,
+			})
+			return
+		}
+
 		if user.IsAdmin() {
 			next.ServeHTTP(w, r)
 			return
@@ -75,7 +86,7 @@ func (al *APIListener) wrapTotPEnabledMiddleware(next http.HandlerFunc) http.Han
 func (al *APIListener) wrapWithAuthMiddleware(isBearerOnly bool) mux.MiddlewareFunc {
 	return func(f http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			authorized, username, err := al.lookupUser(r, isBearerOnly)
+			authorized, username, apiToken, err := al.lookupUser(r, isBearerOnly)
 			if err != nil {
 				al.Logf(logger.LogLevelError, err.Error())
 				if errors.Is(err, ErrTooManyRequests) {
@@ -91,12 +102,25 @@ func (al *APIListener) wrapWithAuthMiddleware(isBearerOnly bool) mux.MiddlewareF
 			}
 
 			if !authorized || username == "" {
-				al.bannedUsers.Add(username)
+				al.banUser(r, username)
 				al.jsonErrorResponse(w, http.StatusUnauthorized, errors.New("unauthorized"))
 				return
 			}
 
 			newCtx := api.WithUser(r.Context(), username)
+			apilog.SetUsername(newCtx, username)
+			if apiToken != nil {
+				newCtx = authorization.WithToken(newCtx, apiToken)
+				if apiToken.Clients != nil && al.brokerGrants != nil {
+					if err := al.brokerGrants.RecordUse(newCtx, apiToken.Username, apiToken.Prefix, time.Now()); err != nil {
+						al.Errorf("Failed to record the use of broker token %s: %v", apiToken.Prefix, err)
+					}
+				}
+				if err := checkTokenRoutePermission(r, apiToken); err != nil {
+					al.jsonError(w, err)
+					return
+				}
+			}
 
 			token, hasBearerToken := bearer.GetBearerToken(r)
 			if hasBearerToken {
@@ -105,6 +129,19 @@ func (al *APIListener) wrapWithAuthMiddleware(isBearerOnly bool) mux.MiddlewareF
 					al.jsonError(w, err)
 					return
 				}
+
+				tokenCtx, err := bearer.ParseToken(token, al.config.API.JWTSecret)
+				if err != nil {
+					al.jsonError(w, err)
+					return
+				}
+				if impersonatedBy := tokenCtx.AppClaims.ImpersonatedBy; impersonatedBy != "" {
+					if err := al.checkImpersonator(impersonatedBy); err != nil {
+						al.jsonError(w, err)
+						return
+					}
+					newCtx = api.WithImpersonator(newCtx, impersonatedBy)
+				}
 			}
 
 			f.ServeHTTP(w, r.WithContext(newCtx))
@@ -260,3 +297,19 @@ func (al *APIListener) updateTokenAccess(ctx context.Context, token string, acce
 
 	return nil
 }
+
+// wrapGatewaySecretMiddleware makes sure a connector only serves requests forwarded by its API gateways.
+// Health checks are exempt, they are probed on the connector directly.
+func (al *APIListener) wrapGatewaySecretMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == routes.HealthzRoute || r.URL.Path == routes.ReadyzRoute {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get(GatewaySecretHeader)), []byte(al.config.Server.GatewaySecret)) != 1 {
+			al.jsonErrorResponseWithTitle(w, http.StatusForbidden, "requests must be sent through an API gateway")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
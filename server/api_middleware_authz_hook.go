@@ -0,0 +1,74 @@
+package chserver
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/authzhook"
+	chshare "github.com/IOTech17/neo-rport/share"
+)
+
+// wrapAuthzHookMiddleware asks the external authorization policy about authenticated requests.
+func (al *APIListener) wrapAuthzHookMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !al.authzHook.Applies(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		user, err := al.getUserModelForAuth(r.Context())
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
+
+		route := r.URL.Path
+		if current := mux.CurrentRoute(r); current != nil {
+			if tpl, err := current.GetPathTemplate(); err == nil {
+				route = tpl
+			}
+		}
+		route = trimAPIPrefix(route)
+
+		now := time.Now()
+		input := authzhook.Input{
+			User:       user.Username,
+			Groups:     user.Groups,
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			Route:      route,
+			APIVersion: api.GetVersion(r.Context()),
+			Vars:       mux.Vars(r),
+			Query:      r.URL.Query(),
+			Resource:   authzhook.ResourceFromRoute(route),
+			Action:     authzhook.ActionFromMethod(r.Method),
+			RemoteIP:   chshare.RemoteIP(r),
+			Time:       now,
+			Weekday:    now.Weekday().String(),
+			Hour:       now.Hour(),
+		}
+
+		decision, err := al.authzHook.Decide(r.Context(), input)
+		if err != nil {
+			al.Errorf("Authorization hook for %s %s of user %q: %v", r.Method, r.URL.Path, user.Username, err)
+		}
+		if !decision.Allow {
+			al.Infof("Authorization hook denied %s %s of user %q: %s", r.Method, r.URL.Path, user.Username, decision.Reason)
+			message := "access denied by the authorization policy"
+			if decision.Reason != "" {
+				message += ": " + decision.Reason
+			}
+			al.jsonError(w, errors2.APIError{
+				Message:    message,
+				HTTPStatus: http.StatusForbidden,
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,68 @@
+package chserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/server/api/session"
+	"github.com/IOTech17/neo-rport/server/authzhook"
+)
+
+func TestAuthzHookMiddleware(t *testing.T) {
+	var inputs []authzhook.Input
+	opa := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Input authzhook.Input `json:"input"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		inputs = append(inputs, body.Input)
+		if body.Input.Resource == "users" {
+			_, _ = w.Write([]byte(`{"result":{"allow":false,"reason":"session policies are managed by the security team"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"result":true}`))
+	}))
+	defer opa.Close()
+
+	al := setupTestAPIListenerSessionPolicy(t, session.ConcurrentSessionsAllow, 0)
+	config := authzhook.Config{URL: opa.URL}
+	require.NoError(t, config.Validate())
+	al.authzHook = authzhook.New(config)
+	al.initRouter()
+
+	do := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.SetBasicAuth("admin", "pwd")
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, do("/api/v1/me").Code)
+
+	w := do("/api/v1/users/admin/session-policy")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "access denied by the authorization policy: session policies are managed by the security team")
+
+	require.Len(t, inputs, 2)
+	assert.Equal(t, "admin", inputs[1].User)
+	assert.Equal(t, []string{"Administrators"}, inputs[1].Groups)
+	assert.Equal(t, "/users/{user_id}/session-policy", inputs[1].Route)
+	assert.Equal(t, map[string]string{"user_id": "admin"}, inputs[1].Vars)
+	assert.Equal(t, authzhook.ActionRead, inputs[1].Action)
+	assert.Equal(t, 1, inputs[1].APIVersion)
+
+	assert.Equal(t, http.StatusForbidden, do("/api/v2/users/admin/session-policy").Code)
+	require.Len(t, inputs, 3)
+	assert.Equal(t, "/users/{user_id}/session-policy", inputs[2].Route)
+	assert.Equal(t, 2, inputs[2].APIVersion)
+
+	// the policy engine is down
+	opa.Close()
+	assert.Equal(t, http.StatusForbidden, do("/api/v1/me").Code)
+}
@@ -0,0 +1,99 @@
+package chserver
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/IOTech17/neo-rport/server/api"
+)
+
+// usesProblemDetails returns true if error responses of the API version are RFC 7807 problem details. v1 keeps the
+// error format of upstream rport.
+func usesProblemDetails(version int) bool {
+	return version >= api.V2
+}
+
+// apiResponseWriter tells the error helpers the API version of the request, so the handlers don't need to know the
+// error format of the version. Responses are written through.
+type apiResponseWriter struct {
+	http.ResponseWriter
+	version int
+	path    string
+}
+
+func (w *apiResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack is needed by the web sockets.
+func (w *apiResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer doesn't support hijacking")
+	}
+	return h.Hijack()
+}
+
+func (w *apiResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// problemInstance returns the path of the request if errors are written as problem details, false otherwise. The
+// writer can be wrapped by the middlewares after the version middleware.
+func problemInstance(w http.ResponseWriter) (string, bool) {
+	for {
+		if aw, ok := w.(*apiResponseWriter); ok {
+			return aw.path, usesProblemDetails(aw.version)
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return "", false
+		}
+		w = u.Unwrap()
+	}
+}
+
+// writeErrorPayload writes the error in the format of the API version of the request.
+func (al *APIListener) writeErrorPayload(w http.ResponseWriter, statusCode int, errPayload api.ErrorPayload) {
+	al.writeErrorResponseLog(errPayload)
+
+	instance, ok := problemInstance(w)
+	if !ok {
+		al.writeJSONResponse(w, statusCode, errPayload)
+		return
+	}
+	b, err := json.Marshal(api.NewProblem(statusCode, errPayload, instance))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", api.ProblemContentType)
+	w.WriteHeader(statusCode)
+	if _, err := w.Write(b); err != nil {
+		al.Errorf("error writing response: %s", err)
+	}
+}
+
+// wrapMaxBytesMiddleware limits the request body like middleware.MaxBytes, with the error in the format of the API
+// version. v1 keeps the plain text error of upstream rport.
+func (al *APIListener) wrapMaxBytesMiddleware(next http.Handler, maxBytes int64) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+		if err := r.ParseForm(); err != nil {
+			title := fmt.Sprintf("Request data exceeds the limit of %d bytes: %s", maxBytes, err)
+			if _, ok := problemInstance(w); ok {
+				al.writeErrorPayload(w, http.StatusBadRequest, api.NewErrAPIPayloadFromMessage("", title, ""))
+			} else {
+				http.Error(w, title, http.StatusBadRequest)
+			}
+			return
+		}
+		next.ServeHTTP(w, r)
+	}
+}
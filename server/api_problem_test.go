@@ -0,0 +1,142 @@
+package chserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/api/session"
+)
+
+func TestProblemDetails(t *testing.T) {
+	al := &APIListener{Logger: testLog}
+
+	testCases := []struct {
+		Name                string
+		Handler             http.HandlerFunc
+		Body                string
+		ExpectedStatus      int
+		ExpectedContentType string
+		ExpectedBody        string
+	}{
+		{
+			Name: "error with code",
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				al.jsonErrorResponseWithDetail(w, http.StatusConflict, ErrCodeAlreadyExist, "client group exists", "id: linux")
+			},
+			ExpectedStatus:      http.StatusConflict,
+			ExpectedContentType: api.ProblemContentType,
+			ExpectedBody:        `{"type":"urn:rport:error:already_exist","title":"client group exists","status":409,"detail":"id: linux","instance":"/api/v2/test","code":"ERR_CODE_ALREADY_EXIST"}`,
+		},
+		{
+			Name: "error without code",
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				al.jsonErrorResponseWithTitle(w, http.StatusNotFound, "client not found")
+			},
+			ExpectedStatus:      http.StatusNotFound,
+			ExpectedContentType: api.ProblemContentType,
+			ExpectedBody:        `{"type":"urn:rport:error:not_found","title":"client not found","status":404,"instance":"/api/v2/test","code":"ERR_CODE_NOT_FOUND"}`,
+		},
+		{
+			Name: "several errors",
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				al.jsonError(w, errors2.APIErrors{
+					{Message: "pagination limit must be a number", HTTPStatus: http.StatusBadRequest},
+					{Message: "pagination offset must not be negative", HTTPStatus: http.StatusBadRequest},
+				})
+			},
+			ExpectedStatus:      http.StatusBadRequest,
+			ExpectedContentType: api.ProblemContentType,
+			ExpectedBody: `{"type":"urn:rport:error:invalid_request","title":"pagination limit must be a number","status":400,"instance":"/api/v2/test","code":"ERR_CODE_INVALID_REQUEST",` +
+				`"errors":[{"code":"ERR_CODE_INVALID_REQUEST","title":"pagination limit must be a number","detail":""},{"code":"ERR_CODE_INVALID_REQUEST","title":"pagination offset must not be negative","detail":""}]}`,
+		},
+		{
+			Name: "several errors with codes",
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				al.jsonError(w, errors2.APIErrors{
+					{Message: "client group exists", HTTPStatus: http.StatusConflict, ErrCode: ErrCodeAlreadyExist},
+					{Message: "client group is in use", HTTPStatus: http.StatusConflict},
+				})
+			},
+			ExpectedStatus:      http.StatusConflict,
+			ExpectedContentType: api.ProblemContentType,
+			ExpectedBody: `{"type":"urn:rport:error:already_exist","title":"client group exists","status":409,"instance":"/api/v2/test","code":"ERR_CODE_ALREADY_EXIST",` +
+				`"errors":[{"code":"ERR_CODE_ALREADY_EXIST","title":"client group exists","detail":""},{"code":"ERR_CODE_CONFLICT","title":"client group is in use","detail":""}]}`,
+		},
+		{
+			Name: "request too large",
+			Handler: al.wrapMaxBytesMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				t.Error("handler must not be called")
+			}), 10),
+			Body:                "a=12345678901",
+			ExpectedStatus:      http.StatusBadRequest,
+			ExpectedContentType: api.ProblemContentType,
+			ExpectedBody:        `{"type":"urn:rport:error:invalid_request","title":"Request data exceeds the limit of 10 bytes: http: request body too large","status":400,"instance":"/api/v2/test","code":"ERR_CODE_INVALID_REQUEST"}`,
+		},
+		{
+			Name: "success",
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload("ok"))
+			},
+			ExpectedStatus:      http.StatusOK,
+			ExpectedContentType: "application/json; charset=UTF-8",
+			ExpectedBody:        `{"data":"ok"}`,
+		},
+		{
+			Name: "error response that isn't an error payload",
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				al.writeJSONResponse(w, http.StatusServiceUnavailable, api.NewSuccessPayload("not ready"))
+			},
+			ExpectedStatus:      http.StatusServiceUnavailable,
+			ExpectedContentType: "application/json; charset=UTF-8",
+			ExpectedBody:        `{"data":"not ready"}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/v2/test", strings.NewReader(tc.Body))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			al.wrapAPIVersionMiddleware(api.V2)(tc.Handler).ServeHTTP(w, req)
+
+			assert.Equal(t, tc.ExpectedStatus, w.Code)
+			assert.Equal(t, tc.ExpectedContentType, w.Header().Get("Content-Type"))
+			assert.JSONEq(t, tc.ExpectedBody, w.Body.String())
+		})
+	}
+
+	// v1 keeps the plain text error
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/test", strings.NewReader("a=12345678901"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	al.wrapAPIVersionMiddleware(api.V1)(al.wrapMaxBytesMiddleware(http.NotFoundHandler(), 10)).ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "Request data exceeds the limit of 10 bytes: http: request body too large\n", w.Body.String())
+}
+
+func TestProblemDetailsByVersion(t *testing.T) {
+	al := setupTestAPIListenerSessionPolicy(t, session.ConcurrentSessionsAllow, 0)
+
+	do := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.SetBasicAuth("admin", "wrong")
+		al.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("/api/v1/me")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "application/json; charset=UTF-8", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"errors":[{"code":"","title":"unauthorized","detail":""}]}`, w.Body.String())
+
+	w = do("/api/v2/me")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, api.ProblemContentType, w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"type":"urn:rport:error:unauthorized","title":"unauthorized","status":401,"instance":"/api/v2/me","code":"ERR_CODE_UNAUTHORIZED"}`, w.Body.String())
+}
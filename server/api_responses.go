@@ -32,8 +32,7 @@ func (al *APIListener) writeJSONResponse(w http.ResponseWriter, statusCode int,
 
 func (al *APIListener) jsonErrorResponse(w http.ResponseWriter, statusCode int, err error) {
 	errPayload := api.NewErrAPIPayloadFromError(err, "", "")
-	al.writeErrorResponseLog(errPayload)
-	al.writeJSONResponse(w, statusCode, errPayload)
+	al.writeErrorPayload(w, statusCode, errPayload)
 }
 
 func (al *APIListener) jsonError(w http.ResponseWriter, err error) {
@@ -56,26 +55,22 @@ func (al *APIListener) jsonError(w http.ResponseWriter, err error) {
 	}
 
 	errPayload := api.NewErrAPIPayloadFromError(err, errCode, message)
-	al.writeErrorResponseLog(errPayload)
-	al.writeJSONResponse(w, statusCode, errPayload)
+	al.writeErrorPayload(w, statusCode, errPayload)
 }
 
 func (al *APIListener) jsonErrorResponseWithErrCode(w http.ResponseWriter, statusCode int, errCode, title string) {
 	errPayload := api.NewErrAPIPayloadFromMessage(errCode, title, "")
-	al.writeErrorResponseLog(errPayload)
-	al.writeJSONResponse(w, statusCode, errPayload)
+	al.writeErrorPayload(w, statusCode, errPayload)
 }
 
 func (al *APIListener) jsonErrorResponseWithTitle(w http.ResponseWriter, statusCode int, title string) {
 	errPayload := api.NewErrAPIPayloadFromMessage("", title, "")
-	al.writeErrorResponseLog(errPayload)
-	al.writeJSONResponse(w, statusCode, errPayload)
+	al.writeErrorPayload(w, statusCode, errPayload)
 }
 
 func (al *APIListener) jsonErrorResponseWithDetail(w http.ResponseWriter, statusCode int, errCode, title, detail string) {
 	errPayload := api.NewErrAPIPayloadFromMessage(errCode, title, detail)
-	al.writeErrorResponseLog(errPayload)
-	al.writeJSONResponse(w, statusCode, errPayload)
+	al.writeErrorPayload(w, statusCode, errPayload)
 }
 
 func (al *APIListener) jsonErrorResponseWithError(w http.ResponseWriter, statusCode int, title string, err error) {
@@ -84,6 +79,5 @@ func (al *APIListener) jsonErrorResponseWithError(w http.ResponseWriter, statusC
 		detail = err.Error()
 	}
 	errPayload := api.NewErrAPIPayloadFromMessage("", title, detail)
-	al.writeErrorResponseLog(errPayload)
-	al.writeJSONResponse(w, statusCode, errPayload)
+	al.writeErrorPayload(w, statusCode, errPayload)
 }
@@ -12,6 +12,8 @@ import (
 	"github.com/IOTech17/neo-rport/plus/capabilities/oauth"
 	"github.com/IOTech17/neo-rport/server/api/middleware"
 	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/killswitch"
 	"github.com/IOTech17/neo-rport/server/routes"
 	"github.com/IOTech17/neo-rport/share/security"
 )
@@ -31,34 +33,124 @@ var vueHistoryPaths = []string{
 
 func (al *APIListener) initRouter() {
 	r := mux.NewRouter()
-	api := r.PathPrefix(routes.AllRoutesPrefix).Subrouter()
+	for _, v := range apiVersions {
+		al.initAPIRoutes(r, v.Prefix, v.Version)
+	}
+
+	health := r.NewRoute().Subrouter()
+	if al.config.API.HealthChecksRequireAuth && !al.insecureForTests {
+		health.Use(al.wrapWithAuthMiddleware(false))
+	}
+	health.HandleFunc(routes.HealthzRoute, al.handleHealthz).Methods(http.MethodGet, http.MethodHead)
+	health.HandleFunc(routes.ReadyzRoute, al.handleReadyz).Methods(http.MethodGet, http.MethodHead)
+
+	docRoot := al.config.API.DocRoot
+	if docRoot != "" {
+		// Start a http file server with proper Vue.js HTML5 history mode (aka rewrite to /) for the following paths
+		r.PathPrefix("/").Handler(middleware.Rewrite404ForVueJs(http.FileServer(http.Dir(docRoot)), vueHistoryPaths))
+	}
+
+	if al.config.Server.Role == chconfig.RoleConnector && al.config.Server.GatewaySecret != "" {
+		r.Use(al.wrapGatewaySecretMiddleware)
+	}
+
+	if al.requestLogOptions != nil {
+		r.Use(func(next http.Handler) http.Handler { return requestlog.WrapWith(next, *al.requestLogOptions) })
+	}
+	if al.accessLogFile != nil {
+		r.Use(func(next http.Handler) http.Handler { return handlers.CombinedLoggingHandler(al.accessLogFile, next) })
+	}
+
+	if len(al.config.API.CORS) > 0 {
+		r.Use(cors.New(cors.Options{
+			AllowedOrigins:   al.config.API.CORS,
+			AllowCredentials: true,
+			AllowedMethods: []string{
+				http.MethodHead,
+				http.MethodGet,
+				http.MethodPost,
+				http.MethodPut,
+				http.MethodDelete,
+			},
+			AllowedHeaders: []string{"Authorization", "Content-Type", idempotencyKeyHeader},
+			ExposedHeaders: append([]string{idempotentReplayedHeader}, deprecationHeaders...),
+		}).Handler)
+	}
+
+	r.Use(handlers.CompressHandler)
+	r.Use(handlers.RecoveryHandler(
+		handlers.PrintRecoveryStack(true),
+		handlers.RecoveryLogger(middleware.NewRecoveryLogger(al.Logger)),
+	))
+
+	al.router = r
+}
+
+// initAPIRoutes registers the API endpoints of a version under its prefix. The versions share the handlers, the
+// version is added to the request context for the handlers and middlewares that behave differently in a version.
+func (al *APIListener) initAPIRoutes(r *mux.Router, prefix string, version int) {
+	api := r.PathPrefix(prefix).Subrouter()
+	if al.requestLog != nil {
+		api.Use(al.requestLog.Middleware)
+	}
+	// after the request log, its response writer hides the writer of the version from the error helpers
+	api.Use(al.wrapAPIVersionMiddleware(version))
+	api.Use(al.wrapDeprecationMiddleware)
 
 	secureAPI := api.NewRoute().Subrouter()
 	if !al.insecureForTests {
 		secureAPI.Use(al.wrapWithAuthMiddleware(false))
 	}
+	if al.authzHook != nil {
+		secureAPI.Use(al.wrapAuthzHookMiddleware)
+	}
 	secureAPI.HandleFunc("/status", al.handleGetStatus).Methods(http.MethodGet)
+	secureAPI.HandleFunc("/server/features", al.handleGetFeatures).Methods(http.MethodGet)
+	secureAPI.HandleFunc("/server/kill-switches", al.handleGetKillSwitches).Methods(http.MethodGet)
+	secureAPI.HandleFunc("/server/compatibility", al.handleGetCompatibilityMatrix).Methods(http.MethodGet)
+	secureAPI.Handle("/server/kill-switches/{"+routes.ParamCapability+"}", al.wrapSuperAdminMiddleware(http.HandlerFunc(al.handlePutKillSwitch))).Methods(http.MethodPut)
+	secureAPI.Handle("/server/chaos", al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handleListChaosFaults))).Methods(http.MethodGet)
+	secureAPI.Handle("/server/chaos/{"+routes.ParamClientID+"}", al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handlePutChaosFault))).Methods(http.MethodPut)
+	secureAPI.Handle("/server/chaos/{"+routes.ParamClientID+"}", al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handleDeleteChaosFault))).Methods(http.MethodDelete)
+	secureAPI.Handle("/changes", al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handleListChanges))).Methods(http.MethodGet)
+	secureAPI.Handle("/changes/{"+routes.ParamChangeID+"}", al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handleGetChange))).Methods(http.MethodGet)
+	secureAPI.Handle("/changes/{"+routes.ParamChangeID+"}/revert", al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handleRevertChange))).Methods(http.MethodPost)
 	secureAPI.HandleFunc("/me", al.handleGetMe).Methods(http.MethodGet)
-	secureAPI.HandleFunc("/me", al.handleChangeMe).Methods(http.MethodPut)
+	secureAPI.HandleFunc("/me", al.wrapNoImpersonationMiddleware(al.handleChangeMe)).Methods(http.MethodPut)
 	secureAPI.HandleFunc("/me/ip", al.handleGetIP).Methods(http.MethodGet)
 
-	secureAPI.HandleFunc("/me/token", al.handleTokenGone).Methods(http.MethodGet)
-	secureAPI.HandleFunc("/me/token", al.handleTokenGone).Methods(http.MethodPost)
-	secureAPI.HandleFunc("/me/token/{_}", al.handleTokenGone).Methods(http.MethodPut)
-	secureAPI.HandleFunc("/me/token/{_}", al.handleTokenGone).Methods(http.MethodDelete)
+	// deprecated v1 endpoints are not carried over to later versions
+	if prefix == routes.AllRoutesPrefix {
+		secureAPI.HandleFunc("/me/token", al.handleTokenGone).Methods(http.MethodGet)
+		secureAPI.HandleFunc("/me/token", al.handleTokenGone).Methods(http.MethodPost)
+		secureAPI.HandleFunc("/me/token/{_}", al.handleTokenGone).Methods(http.MethodPut)
+		secureAPI.HandleFunc("/me/token/{_}", al.handleTokenGone).Methods(http.MethodDelete)
+	}
 
 	secureAPI.HandleFunc("/me/tokens", al.handleGetToken).Methods(http.MethodGet)
-	secureAPI.HandleFunc("/me/tokens", al.handlePostToken).Methods(http.MethodPost)
-	secureAPI.HandleFunc("/me/tokens/{prefix}", al.handlePutToken).Methods(http.MethodPut)
-	secureAPI.HandleFunc("/me/tokens/{prefix}", al.handleDeleteToken).Methods(http.MethodDelete)
+	secureAPI.HandleFunc("/me/tokens", al.wrapNoImpersonationMiddleware(al.handlePostToken)).Methods(http.MethodPost)
+	secureAPI.HandleFunc("/me/tokens/{prefix}", al.wrapNoImpersonationMiddleware(al.handlePutToken)).Methods(http.MethodPut)
+	secureAPI.HandleFunc("/me/tokens/{prefix}", al.wrapNoImpersonationMiddleware(al.handleDeleteToken)).Methods(http.MethodDelete)
 
 	secureAPI.HandleFunc("/clients", al.handleGetClients).Methods(http.MethodGet)
+	measures := secureAPI.PathPrefix("/measures").Subrouter()
+	measures.Use(al.permissionsMiddleware(users.PermissionMonitoring))
+	if al.Server.config.Monitoring.Enabled {
+		measures.HandleFunc("/query", al.handleQueryMeasures).Methods(http.MethodGet)
+	} else {
+		measures.HandleFunc("/query", al.handleMonitoringDisabled).Methods(http.MethodGet)
+	}
 	clientDetails := secureAPI.PathPrefix("/clients/{client_id}").Subrouter()
 	clientDetails.Use(al.wrapClientAccessMiddleware)
 	clientDetails.HandleFunc("", al.handleGetClient).Methods(http.MethodGet)
 	clientDetails.HandleFunc("", al.handleDeleteClient).Methods(http.MethodDelete)
+	clientDetails.HandleFunc("/compatibility", al.handleGetClientCompatibility).Methods(http.MethodGet)
 	clientDetails.Handle("/acl", al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handlePostClientACL))).Methods(http.MethodPost)
-	clientDetails.Handle("/scripts", al.permissionsMiddleware(users.PermissionScripts)(http.HandlerFunc(al.handleExecuteScript))).Methods(http.MethodPost)
+	clientDetails.Handle("/quarantine", al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handlePostClientQuarantine))).Methods(http.MethodPost)
+	clientDetails.Handle("/quarantine", al.wrapAdminAccessMiddleware(al.permissionsMiddleware(users.PermissionQuarantine)(http.HandlerFunc(al.handleDeleteClientQuarantine)))).Methods(http.MethodDelete)
+	clientDetails.Handle("/identity-transfer", al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handlePostClientIdentityTransfer))).Methods(http.MethodPost)
+	clientDetails.Handle("/identity-transfer", al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handleDeleteClientIdentityTransfer))).Methods(http.MethodDelete)
+	clientDetails.Handle("/scripts", al.permissionsMiddleware(users.PermissionScripts)(al.wrapIdempotencyMiddleware(http.HandlerFunc(al.handleExecuteScript)))).Methods(http.MethodPost)
 
 	clientAttributes := clientDetails.PathPrefix("/attributes").Subrouter()
 	clientAttributes.Use(al.withActiveClient)
@@ -67,15 +159,34 @@ func (al *APIListener) initRouter() {
 
 	clientCommands := clientDetails.PathPrefix("/commands").Subrouter()
 	clientCommands.Use(al.permissionsMiddleware(users.PermissionCommands))
-	clientCommands.HandleFunc("", al.handlePostCommand).Methods(http.MethodPost)
+	clientCommands.Handle("", al.wrapIdempotencyMiddleware(http.HandlerFunc(al.handlePostCommand))).Methods(http.MethodPost)
 	clientCommands.HandleFunc("", al.handleGetCommands).Methods(http.MethodGet)
 	clientCommands.HandleFunc("/{job_id}", al.handleGetCommand).Methods(http.MethodGet)
+	clientDetails.Handle("/locks", al.permissionsMiddleware(users.PermissionCommands)(http.HandlerFunc(al.handleGetClientLocks))).Methods(http.MethodGet)
+
+	clientChat := clientDetails.PathPrefix("/chat").Subrouter()
+	clientChat.Use(al.permissionsMiddleware(users.PermissionCommands))
+	clientChat.HandleFunc("", al.handleGetClientChat).Methods(http.MethodGet)
+	clientChat.HandleFunc("", al.handlePostClientChat).Methods(http.MethodPost)
+
+	clientDiscovery := clientDetails.NewRoute().Subrouter()
+	clientDiscovery.Use(al.permissionsMiddleware(users.PermissionCommands))
+	clientDiscovery.HandleFunc("/discovery-scans", al.handlePostClientDiscoveryScan).Methods(http.MethodPost)
+	clientDiscovery.HandleFunc("/discovery-scans", al.handleGetClientDiscoveryScans).Methods(http.MethodGet)
+	clientDiscovery.HandleFunc("/discovery-scans/{"+routes.ParamDiscoveryScanID+"}", al.handleGetClientDiscoveryScan).Methods(http.MethodGet)
+	clientDiscovery.HandleFunc("/discovered-devices", al.handleGetClientDiscoveredDevices).Methods(http.MethodGet)
+	clientDiscovery.HandleFunc("/onboarding-suggestions", al.handleGetClientOnboardingSuggestions).Methods(http.MethodGet)
+	clientDiscovery.Handle("/onboarding", al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handlePostClientOnboarding))).Methods(http.MethodPost)
 
 	clientTunnels := clientDetails.NewRoute().Subrouter()
 	clientTunnels.Use(al.permissionsMiddleware(users.PermissionTunnels))
-	clientTunnels.HandleFunc("/tunnels", al.handlePutClientTunnel).Methods(http.MethodPut)
+	clientTunnels.Handle("/tunnels", al.wrapKillSwitchMiddleware(killswitch.CapabilityTunnels)(al.wrapIdempotencyMiddleware(http.HandlerFunc(al.handlePutClientTunnel)))).Methods(http.MethodPut)
 	clientTunnels.HandleFunc("/tunnels/{tunnel_id}", al.handleDeleteClientTunnel).Methods(http.MethodDelete)
 	clientTunnels.HandleFunc("/tunnels/{tunnel_id}/acl", al.handlePutClientTunnelACL).Methods(http.MethodPut)
+	clientTunnels.HandleFunc("/tunnels/{tunnel_id}/sessions", al.handleGetTunnelSessions).Methods(http.MethodGet)
+	clientTunnels.HandleFunc("/tunnels/{tunnel_id}/sessions/{session_id}/participants", al.handlePostTunnelSessionParticipant).Methods(http.MethodPost)
+	clientTunnels.HandleFunc("/tunnel-connections", al.handleGetClientTunnelConnections).Methods(http.MethodGet)
+	clientTunnels.HandleFunc("/tunnel-connections/{connection_id}/requests", al.handleGetClientTunnelConnectionRequests).Methods(http.MethodGet)
 	clientTunnels.HandleFunc("/stored-tunnels", al.handleGetStoredTunnels).Methods(http.MethodGet)
 	clientTunnels.HandleFunc("/stored-tunnels", al.handlePostStoredTunnels).Methods(http.MethodPost)
 	clientTunnels.HandleFunc("/stored-tunnels/{tunnel_id}", al.handleDeleteStoredTunnel).Methods(http.MethodDelete)
@@ -84,36 +195,77 @@ func (al *APIListener) initRouter() {
 	clientMonitoring := clientDetails.NewRoute().Subrouter()
 	clientMonitoring.Use(al.permissionsMiddleware(users.PermissionMonitoring))
 	clientMonitoring.HandleFunc("/updates-status", al.handleRefreshUpdatesStatus).Methods(http.MethodPost)
+	clientMonitoring.HandleFunc("/screenshot", al.handleGetClientScreenshot).Methods(http.MethodGet)
 	if al.Server.config.Monitoring.Enabled {
 		clientMonitoring.HandleFunc("/graph-metrics", al.handleGetClientGraphMetrics).Methods(http.MethodGet)
 		clientMonitoring.HandleFunc("/graph-metrics/{"+routes.ParamGraphName+"}", al.handleGetClientGraphMetricsGraph).Methods(http.MethodGet)
 		clientMonitoring.HandleFunc("/metrics", al.handleGetClientMetrics).Methods(http.MethodGet)
 		clientMonitoring.HandleFunc("/processes", al.handleGetClientProcesses).Methods(http.MethodGet)
 		clientMonitoring.HandleFunc("/mountpoints", al.handleGetClientMountpoints).Methods(http.MethodGet)
+		clientMonitoring.HandleFunc("/disk-forecast", al.handleGetClientDiskForecast).Methods(http.MethodGet)
 	} else {
 		clientMonitoring.HandleFunc("/graph-metrics", al.handleMonitoringDisabled).Methods(http.MethodGet)
 		clientMonitoring.HandleFunc("/graph-metrics/{"+routes.ParamGraphName+"}", al.handleMonitoringDisabled).Methods(http.MethodGet)
 		clientMonitoring.HandleFunc("/metrics", al.handleMonitoringDisabled).Methods(http.MethodGet)
 		clientMonitoring.HandleFunc("/processes", al.handleMonitoringDisabled).Methods(http.MethodGet)
 		clientMonitoring.HandleFunc("/mountpoints", al.handleMonitoringDisabled).Methods(http.MethodGet)
+		clientMonitoring.HandleFunc("/disk-forecast", al.handleMonitoringDisabled).Methods(http.MethodGet)
 	}
 
+	clientSerialDevices := clientDetails.PathPrefix("/serial-devices").Subrouter()
+	clientSerialDevices.Use(al.permissionsMiddleware(users.PermissionCommands))
+	clientSerialDevices.HandleFunc("", al.handleListSerialDevices).Methods(http.MethodGet)
+	clientSerialDevices.Handle("", al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handlePostSerialDevice))).Methods(http.MethodPost)
+	clientSerialDevices.Handle("/{"+routes.ParamSerialDeviceID+"}", al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handlePutSerialDevice))).Methods(http.MethodPut)
+	clientSerialDevices.Handle("/{"+routes.ParamSerialDeviceID+"}", al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handleDeleteSerialDevice))).Methods(http.MethodDelete)
+	clientIndustrial := clientDetails.PathPrefix("/industrial-endpoints").Subrouter()
+	clientIndustrial.Use(al.permissionsMiddleware(users.PermissionMonitoring))
+	clientIndustrial.HandleFunc("", al.handleListIndustrialEndpoints).Methods(http.MethodGet)
+	clientIndustrial.Handle("", al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handlePostIndustrialEndpoint))).Methods(http.MethodPost)
+	clientIndustrial.Handle("/{"+routes.ParamIndustrialID+"}", al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handlePutIndustrialEndpoint))).Methods(http.MethodPut)
+	clientIndustrial.Handle("/{"+routes.ParamIndustrialID+"}", al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handleDeleteIndustrialEndpoint))).Methods(http.MethodDelete)
+	clientIndustrial.HandleFunc("/{"+routes.ParamIndustrialID+"}/poll", al.handlePollIndustrialEndpoint).Methods(http.MethodPost)
+	clientDetails.Handle("/industrial-values", al.permissionsMiddleware(users.PermissionMonitoring)(http.HandlerFunc(al.handleListIndustrialValues))).Methods(http.MethodGet)
+	clientSerialSessions := clientDetails.PathPrefix("/serial-sessions").Subrouter()
+	clientSerialSessions.Use(al.permissionsMiddleware(users.PermissionsAuditLog))
+	clientSerialSessions.HandleFunc("", al.handleListSerialSessions).Methods(http.MethodGet)
+	clientSerialSessions.HandleFunc("/{"+routes.ParamSerialSessionID+"}/recording", al.handleGetSerialSessionRecording).Methods(http.MethodGet)
+
 	secureAPI.HandleFunc("/client-tags", al.handleGetClientTags).Methods(http.MethodGet)
+	secureAPI.HandleFunc("/reports/fleet-comparison", al.handleGetFleetComparison).Methods(http.MethodGet)
 
 	secureAPI.Handle("/tunnels", al.permissionsMiddleware(users.PermissionTunnels)(http.HandlerFunc(al.handleGetTunnels))).Methods(http.MethodGet)
+
+	meshTunnels := secureAPI.PathPrefix("/mesh-tunnels").Subrouter()
+	meshTunnels.Use(al.permissionsMiddleware(users.PermissionTunnels))
+	meshTunnels.HandleFunc("", al.handleGetMeshTunnels).Methods(http.MethodGet)
+	meshTunnels.Handle("", al.wrapKillSwitchMiddleware(killswitch.CapabilityTunnels)(al.wrapIdempotencyMiddleware(http.HandlerFunc(al.handlePostMeshTunnel)))).Methods(http.MethodPost)
+	meshTunnels.HandleFunc("/{"+routes.ParamMeshTunnelID+"}", al.handleDeleteMeshTunnel).Methods(http.MethodDelete)
 	secureAPI.Handle("/auditlog", al.permissionsMiddleware(users.PermissionsAuditLog)(http.HandlerFunc(al.handleListAuditLog))).Methods(http.MethodGet)
-	secureAPI.Handle("/files", al.permissionsMiddleware(users.PermissionUploads)(http.HandlerFunc(al.handleFileUploads))).Methods(http.MethodPost).Name(routes.FilesUploadRouteName)
+	secureAPI.Handle("/files", al.permissionsMiddleware(users.PermissionUploads)(al.wrapKillSwitchMiddleware(killswitch.CapabilityUploads)(http.HandlerFunc(al.handleFileUploads)))).Methods(http.MethodPost).Name(routes.FilesUploadRouteName)
+	fileChanges := secureAPI.PathPrefix("/files/changes").Subrouter()
+	fileChanges.Use(al.permissionsMiddleware(users.PermissionUploads))
+	fileChanges.HandleFunc("", al.handleListFileChanges).Methods(http.MethodGet)
+	fileChanges.HandleFunc("/{"+routes.ParamFileChangeID+"}", al.handleReadFileChange).Methods(http.MethodGet)
 
 	secureAPI.HandleFunc("/client-groups", al.handleGetClientGroups).Methods(http.MethodGet)
 	secureAPI.HandleFunc("/client-groups/{group_id}", al.handleGetClientGroup).Methods(http.MethodGet)
+	secureAPI.HandleFunc("/access-requests", al.handleListAccessRequests).Methods(http.MethodGet)
+	secureAPI.HandleFunc("/access-requests", al.handlePostAccessRequest).Methods(http.MethodPost)
+	secureAPI.HandleFunc("/quotas", al.handleGetQuotas).Methods(http.MethodGet)
 
 	adminOnly := secureAPI.NewRoute().Subrouter()
 	adminOnly.Use(al.wrapAdminAccessMiddleware)
+	adminOnly.HandleFunc("/auditlog/verify", al.handleVerifyAuditLog).Methods(http.MethodGet)
+	adminOnly.HandleFunc("/request-log", al.handleGetRequestLog).Methods(http.MethodGet)
+	adminOnly.HandleFunc("/tunnel-connections", al.handleGetTunnelConnections).Methods(http.MethodGet)
+	adminOnly.HandleFunc("/server/config/validate", al.handlePostConfigValidate).Methods(http.MethodPost).Name(routes.ConfigValidateRouteName)
 	adminOnly.HandleFunc("/client-groups", al.handlePostClientGroups).Methods(http.MethodPost)
 	adminOnly.HandleFunc("/client-groups/{group_id}", al.handlePutClientGroup).Methods(http.MethodPut)
 	adminOnly.HandleFunc("/client-groups/{group_id}", al.handleDeleteClientGroup).Methods(http.MethodDelete)
+	adminOnly.HandleFunc("/client-groups/{group_id}/access-override", al.handlePostClientGroupAccessOverride).Methods(http.MethodPost)
 	adminOnly.HandleFunc("/users", al.wrapStaticPassModeMiddleware(al.handleGetUsers)).Methods(http.MethodGet)
-	adminOnly.HandleFunc("/users", al.wrapStaticPassModeMiddleware(al.handleChangeUser)).Methods(http.MethodPost)
+	adminOnly.Handle("/users", al.wrapIdempotencyMiddleware(al.wrapStaticPassModeMiddleware(al.handleChangeUser))).Methods(http.MethodPost)
 	adminOnly.HandleFunc("/users/{user_id}", al.wrapStaticPassModeMiddleware(al.handleChangeUser)).Methods(http.MethodPut)
 	adminOnly.HandleFunc("/users/{user_id}", al.wrapStaticPassModeMiddleware(al.handleDeleteUser)).Methods(http.MethodDelete)
 	adminOnly.HandleFunc("/users/{user_id}/totp-secret", al.wrapStaticPassModeMiddleware(
@@ -123,6 +275,31 @@ func (al *APIListener) initRouter() {
 	adminOnly.HandleFunc("/users/{user_id}/sessions", al.handleGetUserAPISessions).Methods(http.MethodGet)
 	adminOnly.HandleFunc("/users/{user_id}/sessions", al.handleDeleteAllUserAPISessions).Methods(http.MethodDelete)
 	adminOnly.HandleFunc("/users/{user_id}/sessions/{session_id}", al.handleDeleteUserAPISession).Methods(http.MethodDelete)
+	adminOnly.HandleFunc("/users/{user_id}/session-policy", al.handleGetUserSessionPolicy).Methods(http.MethodGet)
+	adminOnly.HandleFunc("/users/{user_id}/session-policy", al.handlePutUserSessionPolicy).Methods(http.MethodPut)
+	adminOnly.HandleFunc("/users/{user_id}/session-policy", al.handleDeleteUserSessionPolicy).Methods(http.MethodDelete)
+	adminOnly.HandleFunc("/users/{user_id}/impersonation", al.wrapNoImpersonationMiddleware(al.handlePostUserImpersonation)).Methods(http.MethodPost)
+
+	adminOnly.HandleFunc("/security/posture", al.handleGetSecurityPosture).Methods(http.MethodGet)
+
+	adminOnly.HandleFunc("/broker-grants", al.handleListBrokerGrants).Methods(http.MethodGet)
+	adminOnly.HandleFunc("/broker-grants", al.handlePostBrokerGrant).Methods(http.MethodPost)
+	adminOnly.HandleFunc("/broker-grants/{id}", al.handleDeleteBrokerGrant).Methods(http.MethodDelete)
+
+	adminOnly.HandleFunc("/access-requests/{id}/approve", al.handleApproveAccessRequest).Methods(http.MethodPost)
+	adminOnly.HandleFunc("/access-requests/{id}/deny", al.handleDenyAccessRequest).Methods(http.MethodPost)
+	adminOnly.HandleFunc("/access-requests/{id}", al.handleDeleteAccessRequest).Methods(http.MethodDelete)
+
+	adminOnly.HandleFunc("/usage", al.handleGetUsage).Methods(http.MethodGet)
+	adminOnly.HandleFunc("/usage/periods", al.handleListUsagePeriods).Methods(http.MethodGet)
+	adminOnly.HandleFunc("/usage/periods/{period}/close", al.handleCloseUsagePeriod).Methods(http.MethodPost)
+
+	adminOnly.HandleFunc("/branding", al.handleGetBranding).Methods(http.MethodGet)
+	adminOnly.HandleFunc("/branding", al.handleUpdateBranding).Methods(http.MethodPut)
+	adminOnly.HandleFunc("/bans", al.handleListBans).Methods(http.MethodGet)
+	adminOnly.HandleFunc("/bans/export", al.handleExportIPBans).Methods(http.MethodGet)
+	adminOnly.HandleFunc("/bans/users/{"+routes.ParamUserID+"}", al.handleDeleteUserBan).Methods(http.MethodDelete)
+	adminOnly.HandleFunc("/bans/ips/{"+routes.ParamIP+"}", al.handleDeleteIPBan).Methods(http.MethodDelete)
 
 	adminOnly.HandleFunc("/user-groups", al.handleListUserGroups).Methods(http.MethodGet)
 	adminOnly.HandleFunc("/user-groups/{group_name}", al.wrapStaticPassModeMiddleware(al.handleGetUserGroup)).Methods(http.MethodGet)
@@ -133,21 +310,78 @@ func (al *APIListener) initRouter() {
 	adminOnly.HandleFunc("/clients-auth/{client_auth_id}", al.handleGetClientAuth).Methods(http.MethodGet)
 	adminOnly.HandleFunc("/clients-auth", al.handlePostClientsAuth).Methods(http.MethodPost)
 	adminOnly.HandleFunc("/clients-auth/{client_auth_id}", al.handleDeleteClientAuth).Methods(http.MethodDelete)
+	adminOnly.HandleFunc("/client-installers", al.handlePostClientInstallers).Methods(http.MethodPost)
+
+	externalClientRoute := "/external-clients/{" + routes.ParamExternalClientID + "}"
+	adminOnly.HandleFunc("/external-clients", al.handleListExternalClients).Methods(http.MethodGet)
+	adminOnly.HandleFunc("/external-clients", al.handlePostExternalClient).Methods(http.MethodPost)
+	adminOnly.HandleFunc(externalClientRoute, al.handleGetExternalClient).Methods(http.MethodGet)
+	adminOnly.HandleFunc(externalClientRoute, al.handleDeleteExternalClient).Methods(http.MethodDelete)
+	adminOnly.HandleFunc(externalClientRoute+"/token", al.handlePostExternalClientToken).Methods(http.MethodPost)
+	if al.Server.config.Monitoring.Enabled {
+		adminOnly.HandleFunc(externalClientRoute+"/metrics", al.handleGetExternalClientMetrics).Methods(http.MethodGet)
+	} else {
+		adminOnly.HandleFunc(externalClientRoute+"/metrics", al.handleMonitoringDisabled).Methods(http.MethodGet)
+	}
+
+	agentlessTargetRoute := "/agentless-targets/{" + routes.ParamAgentlessTargetID + "}"
+	adminOnly.HandleFunc("/agentless-targets", al.handleListAgentlessTargets).Methods(http.MethodGet)
+	adminOnly.HandleFunc("/agentless-targets", al.handlePostAgentlessTarget).Methods(http.MethodPost)
+	adminOnly.HandleFunc(agentlessTargetRoute, al.handleGetAgentlessTarget).Methods(http.MethodGet)
+	adminOnly.HandleFunc(agentlessTargetRoute, al.handlePutAgentlessTarget).Methods(http.MethodPut)
+	adminOnly.HandleFunc(agentlessTargetRoute, al.handleDeleteAgentlessTarget).Methods(http.MethodDelete)
+	if al.Server.config.Monitoring.Enabled {
+		adminOnly.HandleFunc(agentlessTargetRoute+"/metrics", al.handleGetAgentlessTargetMetrics).Methods(http.MethodGet)
+	} else {
+		adminOnly.HandleFunc(agentlessTargetRoute+"/metrics", al.handleMonitoringDisabled).Methods(http.MethodGet)
+	}
+
+	adminOnly.HandleFunc("/export/{"+routes.ParamBulkResource+"}", al.handleExport).Methods(http.MethodGet)
+	adminOnly.HandleFunc("/import/{"+routes.ParamBulkResource+"}", al.handleImport).Methods(http.MethodPost)
 
 	adminOnly.HandleFunc("/notification-logs", al.handleGetNotifications).Methods(http.MethodGet)
 	adminOnly.HandleFunc("/notification-logs/{notification_id}", al.handleGetNotificationDetails).Methods(http.MethodGet)
+	adminOnly.HandleFunc(routes.AlertingServiceRoutesPrefix+routes.ASGroupRulesRoute, al.handleListGroupRules).Methods(http.MethodGet)
+	adminOnly.HandleFunc(routes.AlertingServiceRoutesPrefix+routes.ASGroupRulesRoute+"/{"+routes.ParamGroupRuleID+"}", al.handlePutGroupRule).Methods(http.MethodPut)
+	adminOnly.HandleFunc(routes.AlertingServiceRoutesPrefix+routes.ASGroupRulesRoute+"/{"+routes.ParamGroupRuleID+"}", al.handleDeleteGroupRule).Methods(http.MethodDelete)
+	adminOnly.HandleFunc(routes.AlertingServiceRoutesPrefix+routes.ASEdgeRulesRoute, al.handleListEdgeRules).Methods(http.MethodGet)
+	adminOnly.HandleFunc(routes.AlertingServiceRoutesPrefix+routes.ASEdgeRulesRoute+"/{"+routes.ParamEdgeRuleID+"}", al.handlePutEdgeRule).Methods(http.MethodPut)
+	adminOnly.HandleFunc(routes.AlertingServiceRoutesPrefix+routes.ASEdgeRulesRoute+"/{"+routes.ParamEdgeRuleID+"}", al.handleDeleteEdgeRule).Methods(http.MethodDelete)
+	adminOnly.HandleFunc(routes.AlertingServiceRoutesPrefix+routes.ASRemediationsRoute, al.handleListRemediations).Methods(http.MethodGet)
+	adminOnly.HandleFunc(routes.AlertingServiceRoutesPrefix+routes.ASRemediationsRoute+"/{"+routes.ParamRemediationID+"}", al.handlePutRemediation).Methods(http.MethodPut)
+	adminOnly.HandleFunc(routes.AlertingServiceRoutesPrefix+routes.ASRemediationsRoute+"/{"+routes.ParamRemediationID+"}", al.handleDeleteRemediation).Methods(http.MethodDelete)
+	adminOnly.HandleFunc(routes.AlertingServiceRoutesPrefix+routes.ASClientDependenciesRoute, al.handleListClientDependencies).Methods(http.MethodGet)
+	adminOnly.HandleFunc(routes.AlertingServiceRoutesPrefix+routes.ASClientDependenciesRoute+"/{"+routes.ParamClientID+"}", al.handlePutClientDependency).Methods(http.MethodPut)
+	adminOnly.HandleFunc(routes.AlertingServiceRoutesPrefix+routes.ASClientDependenciesRoute+"/{"+routes.ParamClientID+"}", al.handleDeleteClientDependency).Methods(http.MethodDelete)
+	adminOnly.HandleFunc("/alerting/problems", al.handleListProblemHistory).Methods(http.MethodGet)
+	adminOnly.HandleFunc("/alerting/problems/{problem_id}", al.handleGetProblemHistory).Methods(http.MethodGet)
+	adminOnly.HandleFunc("/alerting/problems/{problem_id}/acknowledge", al.handleAcknowledgeProblem).Methods(http.MethodPost)
+	adminOnly.HandleFunc("/alerting/problems/{problem_id}/escalate", al.handleEscalateProblem).Methods(http.MethodPost)
+	adminOnly.HandleFunc("/alerting/clients/{client_id}/timeline", al.handleGetClientProblemTimeline).Methods(http.MethodGet)
+	adminOnly.HandleFunc("/alerting/clients/{client_id}/baselines", al.handleGetClientBaselines).Methods(http.MethodGet)
+	adminOnly.HandleFunc("/alerting/stats", al.handleGetProblemStats).Methods(http.MethodGet)
+	adminOnly.HandleFunc("/alerting/webhook", al.handleAlertingWebhook).Methods(http.MethodPost)
+	adminOnly.HandleFunc("/notification-digests", al.handleListNotificationDigests).Methods(http.MethodGet)
+	adminOnly.HandleFunc("/notification-digests/{recipient}", al.handlePutNotificationDigest).Methods(http.MethodPut)
+	adminOnly.HandleFunc("/notification-digests/{recipient}", al.handleDeleteNotificationDigest).Methods(http.MethodDelete)
 
 	commands := secureAPI.NewRoute().Subrouter()
 	commands.Use(al.permissionsMiddleware(users.PermissionCommands))
-	commands.HandleFunc("/commands", al.handlePostMultiClientCommand).Methods(http.MethodPost)
+	commands.Handle("/commands", al.wrapIdempotencyMiddleware(http.HandlerFunc(al.handlePostMultiClientCommand))).Methods(http.MethodPost)
 	commands.HandleFunc("/commands", al.handleGetMultiClientCommands).Methods(http.MethodGet)
 	commands.HandleFunc("/commands/{job_id}", al.handleGetMultiClientCommand).Methods(http.MethodGet)
 	commands.HandleFunc("/commands/{job_id}/jobs", al.handleGetMultiClientCommandJobs).Methods(http.MethodGet)
+	commands.HandleFunc("/commands/{job_id}/aggregation", al.handleGetMultiClientCommandAggregation).Methods(http.MethodGet)
+	commands.HandleFunc("/commands/{job_id}/diff", al.handleGetMultiClientCommandDiff).Methods(http.MethodGet)
+	commands.HandleFunc("/commands/{job_id}/canary/confirm", al.handlePostMultiClientCommandCanaryConfirm).Methods(http.MethodPost)
+	commands.HandleFunc("/commands/{job_id}/canary/abort", al.handlePostMultiClientCommandCanaryAbort).Methods(http.MethodPost)
 	commands.HandleFunc("/library/commands", al.handleListCommands).Methods(http.MethodGet)
 	commands.HandleFunc("/library/commands", al.handleCommandCreate).Methods(http.MethodPost)
 	commands.HandleFunc("/library/commands/{"+routes.ParamCommandValueID+"}", al.handleCommandUpdate).Methods(http.MethodPut)
 	commands.HandleFunc("/library/commands/{"+routes.ParamCommandValueID+"}", al.handleReadCommand).Methods(http.MethodGet)
 	commands.HandleFunc("/library/commands/{"+routes.ParamCommandValueID+"}", al.handleDeleteCommand).Methods(http.MethodDelete)
+	commands.Handle("/agentless-targets/{"+routes.ParamAgentlessTargetID+"}/commands", al.wrapKillSwitchMiddleware(killswitch.CapabilityCommands)(http.HandlerFunc(al.handlePostAgentlessCommand))).Methods(http.MethodPost)
+	commands.Handle("/agentless-targets/{"+routes.ParamAgentlessTargetID+"}/facts", al.wrapKillSwitchMiddleware(killswitch.CapabilityCommands)(http.HandlerFunc(al.handlePostAgentlessFacts))).Methods(http.MethodPost)
 
 	scripts := secureAPI.NewRoute().Subrouter()
 	scripts.Use(al.permissionsMiddleware(users.PermissionScripts))
@@ -156,7 +390,15 @@ func (al *APIListener) initRouter() {
 	scripts.HandleFunc("/library/scripts/{"+routes.ParamScriptValueID+"}", al.handleScriptUpdate).Methods(http.MethodPut)
 	scripts.HandleFunc("/library/scripts/{"+routes.ParamScriptValueID+"}", al.handleReadScript).Methods(http.MethodGet)
 	scripts.HandleFunc("/library/scripts/{"+routes.ParamScriptValueID+"}", al.handleDeleteScript).Methods(http.MethodDelete)
-	scripts.HandleFunc("/scripts", al.handlePostMultiClientScript).Methods(http.MethodPost)
+	scripts.Handle("/scripts", al.wrapIdempotencyMiddleware(http.HandlerFunc(al.handlePostMultiClientScript))).Methods(http.MethodPost)
+
+	artifactRoute := "/library/artifacts/{" + routes.ParamArtifactName + "}/{" + routes.ParamArtifactVersion + "}"
+	artifacts := secureAPI.NewRoute().Subrouter()
+	artifacts.Use(al.permissionsMiddleware(users.PermissionUploads))
+	artifacts.HandleFunc("/library/artifacts", al.handleListArtifacts).Methods(http.MethodGet)
+	artifacts.HandleFunc("/library/artifacts", al.handleArtifactCreate).Methods(http.MethodPost).Name(routes.ArtifactCreateRouteName)
+	artifacts.HandleFunc(artifactRoute, al.handleReadArtifact).Methods(http.MethodGet)
+	artifacts.HandleFunc(artifactRoute, al.handleDeleteArtifact).Methods(http.MethodDelete)
 
 	vault := secureAPI.NewRoute().Subrouter()
 	vault.Use(al.permissionsMiddleware(users.PermissionVault))
@@ -169,6 +411,9 @@ func (al *APIListener) initRouter() {
 	vault.HandleFunc("/vault/{"+routes.ParamVaultValueID+"}", al.handleReadVaultValue).Methods(http.MethodGet)
 	vault.HandleFunc("/vault/{"+routes.ParamVaultValueID+"}", al.handleVaultStoreValue).Methods(http.MethodPut)
 	vault.HandleFunc("/vault/{"+routes.ParamVaultValueID+"}", al.handleVaultDeleteValue).Methods(http.MethodDelete)
+	vault.HandleFunc("/vault/{"+routes.ParamVaultValueID+"}/checkout", al.handleVaultCheckout).Methods(http.MethodPost)
+	vault.HandleFunc("/vault/{"+routes.ParamVaultValueID+"}/checkin", al.handleVaultCheckin).Methods(http.MethodPost)
+	vault.HandleFunc("/vault/{"+routes.ParamVaultValueID+"}/checkouts", al.handleListVaultCheckouts).Methods(http.MethodGet)
 
 	schedules := secureAPI.PathPrefix("/schedules").Subrouter()
 	schedules.Use(al.permissionsMiddleware(users.PermissionScheduler))
@@ -179,20 +424,24 @@ func (al *APIListener) initRouter() {
 	schedules.HandleFunc("/{schedule_id}", al.handleDeleteSchedule).Methods(http.MethodDelete)
 
 	secureAPI.HandleFunc(routes.TotPRoutes, al.wrapTotPEnabledMiddleware(al.handleGetTotP)).Methods(http.MethodGet)
-	secureAPI.HandleFunc(routes.TotPRoutes, al.wrapTotPEnabledMiddleware(al.handlePostTotP)).Methods(http.MethodPost)
-	secureAPI.HandleFunc(routes.TotPRoutes, al.wrapTotPEnabledMiddleware(al.handleDeleteTotP)).Methods(http.MethodDelete)
+	secureAPI.HandleFunc(routes.TotPRoutes, al.wrapNoImpersonationMiddleware(al.wrapTotPEnabledMiddleware(al.handlePostTotP))).Methods(http.MethodPost)
+	secureAPI.HandleFunc(routes.TotPRoutes, al.wrapNoImpersonationMiddleware(al.wrapTotPEnabledMiddleware(al.handleDeleteTotP))).Methods(http.MethodDelete)
 
 	// all routes defined below do not have authorization middleware, auth is done in each handler separately
+	api.HandleFunc("/banner", al.handleGetBanner).Methods(http.MethodGet)
 	api.HandleFunc("/login", al.handleGetLogin).Methods(http.MethodGet)
 	api.HandleFunc("/login", al.handlePostLogin).Methods(http.MethodPost)
+	api.HandleFunc("/login/break-glass", al.handlePostBreakGlassLogin).Methods(http.MethodPost)
 	api.HandleFunc("/logout", al.handleDeleteLogout).Methods(http.MethodDelete)
+	api.HandleFunc("/ingest", al.handlePostIngest).Methods(http.MethodPost)
 	api.Handle(routes.Verify2FaRoute, al.wrapWithAuthMiddleware(true)(al.handlePostVerify2FAToken())).Methods(http.MethodPost)
 
 	// web sockets
 	// common auth middleware is not used due to JS issue https://stackoverflow.com/questions/22383089/is-it-possible-to-use-bearer-authentication-for-websocket-upgrade-requests
 	api.HandleFunc("/ws/commands", al.wsAuth(al.permissionsMiddleware(users.PermissionCommands)(http.HandlerFunc(al.handleCommandsWS)))).Methods(http.MethodGet)
 	api.HandleFunc("/ws/scripts", al.wsAuth(al.permissionsMiddleware(users.PermissionScripts)(http.HandlerFunc(al.handleScriptsWS)))).Methods(http.MethodGet)
-	api.HandleFunc("/ws/uploads", al.wsAuth(al.permissionsMiddleware(users.PermissionUploads)(http.HandlerFunc(al.handleUploadsWS)))).Methods(http.MethodGet)
+	api.HandleFunc("/ws/uploads", al.wsAuth(al.permissionsMiddleware(users.PermissionUploads)(al.wrapKillSwitchMiddleware(killswitch.CapabilityUploads)(http.HandlerFunc(al.handleUploadsWS))))).Methods(http.MethodGet)
+	api.HandleFunc("/ws/clients/{"+routes.ParamClientID+"}/serial-devices/{"+routes.ParamSerialDeviceID+"}/console", al.wsAuth(al.permissionsMiddleware(users.PermissionCommands)(al.wrapClientAccessMiddleware(al.wrapKillSwitchMiddleware(killswitch.CapabilityCommands)(http.HandlerFunc(al.handleSerialConsoleWS)))))).Methods(http.MethodGet)
 
 	if al.config.API.EnableWsTestEndpoints {
 		api.HandleFunc("/test/commands/ui", al.wsCommands)
@@ -201,15 +450,19 @@ func (al *APIListener) initRouter() {
 	}
 
 	if al.bannedIPs != nil {
-		api.Use(security.RejectBannedIPs(al.bannedIPs))
+		// a banned user must be able to lift the ban
+		api.Use(security.RejectBannedIPs(al.bannedIPs, prefix+routes.AuthRoutesPrefix+routes.AuthUnlockRoute))
 	}
 
 	// add max bytes middleware
 	_ = api.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
-		if route.GetName() == routes.FilesUploadRouteName {
-			route.HandlerFunc(middleware.MaxBytes(route.GetHandler(), al.config.API.MaxFilePushSize))
-		} else {
-			route.HandlerFunc(middleware.MaxBytes(route.GetHandler(), al.config.API.MaxRequestBytes))
+		switch route.GetName() {
+		case routes.FilesUploadRouteName, routes.ArtifactCreateRouteName:
+			route.HandlerFunc(al.wrapMaxBytesMiddleware(route.GetHandler(), al.config.API.MaxFilePushSize))
+		case routes.ConfigValidateRouteName:
+			route.HandlerFunc(al.wrapMaxBytesMiddleware(route.GetHandler(), maxConfigFileBytes))
+		default:
+			route.HandlerFunc(al.wrapMaxBytesMiddleware(route.GetHandler(), al.config.API.MaxRequestBytes))
 		}
 		return nil
 	})
@@ -219,8 +472,11 @@ func (al *APIListener) initRouter() {
 
 	authRouter := api.PathPrefix(routes.AuthRoutesPrefix).Subrouter()
 	authRouter.HandleFunc(routes.AuthProviderRoute, al.handleGetAuthProvider).Methods(http.MethodGet)
+	authRouter.HandleFunc(routes.AuthProvidersRoute, al.handleListAuthProviders).Methods(http.MethodGet)
 	authRouter.HandleFunc(routes.AuthSettingsRoute, al.handleGetAuthSettings).Methods(http.MethodGet)
 	authRouter.HandleFunc(routes.AuthDeviceSettingsRoute, al.handleGetAuthDeviceSettings).Methods(http.MethodGet)
+	authRouter.HandleFunc(routes.AuthUnlockRoute, al.handleGetUnlock).Methods(http.MethodGet)
+	authRouter.HandleFunc(routes.AuthUnlockRoute, al.handlePostUnlock).Methods(http.MethodPost)
 
 	if rportplus.IsPlusEnabled(al.config.PlusConfig) {
 		secureASRouter := secureAPI.PathPrefix(routes.AlertingServiceRoutesPrefix).Subrouter()
@@ -234,6 +490,7 @@ func (al *APIListener) initRouter() {
 		secureASRouter.Handle(routes.ASProblemsRoute, al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handleGetLatestProblems))).Methods(http.MethodGet)
 
 		secureASRouter.Handle(routes.ASProblemsRoute+"/{"+routes.ParamProblemID+"}", al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handleUpdateProblem))).Methods(http.MethodPut)
+		secureASRouter.Handle(routes.ASFlappingRoute, al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handleGetFlappingAlerts))).Methods(http.MethodGet)
 
 		secureASRouter.Handle(routes.ASTemplatesRoute, al.wrapAdminAccessMiddleware(http.HandlerFunc(al.handleGetAllTemplates))).Methods(http.MethodGet)
 		secureASRouter.Handle(routes.ASTemplatesRoute+"/{"+routes.ParamTemplateID+"}",
@@ -250,42 +507,8 @@ func (al *APIListener) initRouter() {
 
 	if rportplus.IsPlusOAuthEnabled(al.config.PlusConfig) {
 		api.HandleFunc(oauth.DefaultLoginURI, al.handleOAuthAuthorizationCode).Methods(http.MethodGet)
-		api.HandleFunc(oauth.DefaultDeviceLoginURI, al.handleGetDeviceAuth).Methods(http.MethodGet)
-	}
-
-	docRoot := al.config.API.DocRoot
-	if docRoot != "" {
-		// Start a http file server with proper Vue.js HTML5 history mode (aka rewrite to /) for the following paths
-		r.PathPrefix("/").Handler(middleware.Rewrite404ForVueJs(http.FileServer(http.Dir(docRoot)), vueHistoryPaths))
-	}
-
-	if al.requestLogOptions != nil {
-		r.Use(func(next http.Handler) http.Handler { return requestlog.WrapWith(next, *al.requestLogOptions) })
+		api.HandleFunc(oauth.DefaultDeviceLoginURI, al.handleGetDeviceAuth).Methods(http.MethodGet, http.MethodPost)
+		api.HandleFunc(oauthProviderLoginURI("{"+routes.ParamOAuthProvider+"}"), al.handleOAuthAuthorizationCode).Methods(http.MethodGet)
+		api.HandleFunc(oauthProviderDeviceLoginURI("{"+routes.ParamOAuthProvider+"}"), al.handleGetDeviceAuth).Methods(http.MethodGet, http.MethodPost)
 	}
-	if al.accessLogFile != nil {
-		r.Use(func(next http.Handler) http.Handler { return handlers.CombinedLoggingHandler(al.accessLogFile, next) })
-	}
-
-	if len(al.config.API.CORS) > 0 {
-		r.Use(cors.New(cors.Options{
-			AllowedOrigins:   al.config.API.CORS,
-			AllowCredentials: true,
-			AllowedMethods: []string{
-				http.MethodHead,
-				http.MethodGet,
-				http.MethodPost,
-				http.MethodPut,
-				http.MethodDelete,
-			},
-			AllowedHeaders: []string{"Authorization", "Content-Type"},
-		}).Handler)
-	}
-
-	r.Use(handlers.CompressHandler)
-	r.Use(handlers.RecoveryHandler(
-		handlers.PrintRecoveryStack(true),
-		handlers.RecoveryLogger(middleware.NewRecoveryLogger(al.Logger)),
-	))
-
-	al.router = r
 }
@@ -0,0 +1,155 @@
+package chserver
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/api/authorization"
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/routes"
+)
+
+// tokenRoutePermission declares the permission an API token restricted to some permissions or clients needs for a
+// route.
+type tokenRoutePermission struct {
+	// path is the path template without the API prefix, it covers the routes below it unless exact is set
+	path  string
+	exact bool
+	// method is empty for all methods
+	method string
+	// permission is empty for routes all tokens may use
+	permission string
+	// fleetWide is set for routes that reach data of all clients, tokens restricted to some clients can't use them
+	fleetWide bool
+}
+
+// tokenRoutePermissions are the routes tokens restricted to some permissions or clients may use. Routes not declared
+// here are denied to such tokens, so new routes stay closed until they are declared.
+var tokenRoutePermissions = []tokenRoutePermission{
+	{path: routes.HealthzRoute, exact: true, method: http.MethodGet},
+	{path: routes.ReadyzRoute, exact: true, method: http.MethodGet},
+	{path: "/status", exact: true, method: http.MethodGet},
+	{path: "/me", exact: true, method: http.MethodGet},
+	{path: "/me/ip", exact: true, method: http.MethodGet},
+	// derived tokens can't have more permissions than the token deriving them
+	{path: "/me/tokens"},
+	// the clients are filtered by the client groups of the token
+	{path: "/clients", exact: true, method: http.MethodGet},
+	{path: "/clients/{client_id}", exact: true, method: http.MethodGet},
+
+	{path: "/clients/{client_id}/commands", permission: users.PermissionCommands},
+	{path: "/clients/{client_id}/locks", permission: users.PermissionCommands},
+	{path: "/clients/{client_id}/chat", permission: users.PermissionCommands},
+	{path: "/clients/{client_id}/discovery-scans", permission: users.PermissionCommands},
+	{path: "/clients/{client_id}/discovered-devices", permission: users.PermissionCommands},
+	{path: "/clients/{client_id}/onboarding-suggestions", permission: users.PermissionCommands},
+	{path: "/clients/{client_id}/serial-devices", permission: users.PermissionCommands},
+	{path: "/commands", permission: users.PermissionCommands},
+	{path: "/commands", exact: true, method: http.MethodGet, permission: users.PermissionCommands, fleetWide: true},
+	{path: "/library/commands", permission: users.PermissionCommands},
+	{path: "/library/commands", method: http.MethodPost, permission: users.PermissionCommands, fleetWide: true},
+	{path: "/library/commands", method: http.MethodPut, permission: users.PermissionCommands, fleetWide: true},
+	{path: "/library/commands", method: http.MethodDelete, permission: users.PermissionCommands, fleetWide: true},
+	{path: "/agentless-targets/{" + routes.ParamAgentlessTargetID + "}/commands", permission: users.PermissionCommands},
+	{path: "/agentless-targets/{" + routes.ParamAgentlessTargetID + "}/facts", permission: users.PermissionCommands},
+	{path: "/ws/commands", permission: users.PermissionCommands},
+	{path: "/ws/clients/{client_id}/serial-devices", permission: users.PermissionCommands},
+
+	{path: "/clients/{client_id}/scripts", permission: users.PermissionScripts},
+	{path: "/scripts", permission: users.PermissionScripts},
+	{path: "/library/scripts", permission: users.PermissionScripts},
+	{path: "/library/scripts", method: http.MethodPost, permission: users.PermissionScripts, fleetWide: true},
+	{path: "/library/scripts", method: http.MethodPut, permission: users.PermissionScripts, fleetWide: true},
+	{path: "/library/scripts", method: http.MethodDelete, permission: users.PermissionScripts, fleetWide: true},
+	{path: "/ws/scripts", permission: users.PermissionScripts},
+
+	{path: "/clients/{client_id}/tunnels", permission: users.PermissionTunnels},
+	{path: "/clients/{client_id}/tunnel-connections", permission: users.PermissionTunnels},
+	{path: "/clients/{client_id}/stored-tunnels", permission: users.PermissionTunnels},
+	{path: "/tunnels", permission: users.PermissionTunnels},
+	{path: "/mesh-tunnels", permission: users.PermissionTunnels},
+
+	{path: "/measures", permission: users.PermissionMonitoring},
+	{path: "/clients/{client_id}/updates-status", permission: users.PermissionMonitoring},
+	{path: "/clients/{client_id}/screenshot", permission: users.PermissionMonitoring},
+	{path: "/clients/{client_id}/graph-metrics", permission: users.PermissionMonitoring},
+	{path: "/clients/{client_id}/metrics", permission: users.PermissionMonitoring},
+	{path: "/clients/{client_id}/processes", permission: users.PermissionMonitoring},
+	{path: "/clients/{client_id}/mountpoints", permission: users.PermissionMonitoring},
+	{path: "/clients/{client_id}/disk-forecast", permission: users.PermissionMonitoring},
+	{path: "/clients/{client_id}/industrial-endpoints", permission: users.PermissionMonitoring},
+	{path: "/clients/{client_id}/industrial-values", permission: users.PermissionMonitoring},
+
+	{path: "/files", permission: users.PermissionUploads},
+	{path: "/files/changes", permission: users.PermissionUploads, fleetWide: true},
+	{path: "/library/artifacts", permission: users.PermissionUploads},
+	{path: "/library/artifacts", method: http.MethodPost, permission: users.PermissionUploads, fleetWide: true},
+	{path: "/library/artifacts", method: http.MethodDelete, permission: users.PermissionUploads, fleetWide: true},
+	{path: "/ws/uploads", permission: users.PermissionUploads},
+
+	{path: "/auditlog", permission: users.PermissionsAuditLog, fleetWide: true},
+	{path: "/clients/{client_id}/serial-sessions", permission: users.PermissionsAuditLog},
+
+	{path: "/vault", permission: users.PermissionVault, fleetWide: true},
+	{path: "/vault-admin", permission: users.PermissionVault, fleetWide: true},
+
+	{path: "/schedules", permission: users.PermissionScheduler, fleetWide: true},
+}
+
+// tokenPermissionForRoute returns the declaration of the route with the most specific path, a method specific one wins
+// over one for all methods. It returns false if the route isn't declared.
+func tokenPermissionForRoute(method, pathTemplate string) (tokenRoutePermission, bool) {
+	var match *tokenRoutePermission
+	for i, p := range tokenRoutePermissions {
+		if p.method != "" && p.method != method {
+			continue
+		}
+		if pathTemplate != p.path && (p.exact || !strings.HasPrefix(pathTemplate, p.path+"/")) {
+			continue
+		}
+		if match == nil || len(p.path) > len(match.path) || (len(p.path) == len(match.path) && match.method == "") {
+			match = &tokenRoutePermissions[i]
+		}
+	}
+	if match == nil {
+		return tokenRoutePermission{}, false
+	}
+	return *match, true
+}
+
+// checkTokenRoutePermission returns an error if the request is authenticated by a token restricted to some
+// permissions or clients and the route isn't declared for them.
+func checkTokenRoutePermission(r *http.Request, token *authorization.APIToken) error {
+	if !token.IsNarrowed() {
+		return nil
+	}
+
+	var pathTemplate string
+	if route := mux.CurrentRoute(r); route != nil {
+		pathTemplate, _ = route.GetPathTemplate()
+	}
+	declared, ok := tokenPermissionForRoute(r.Method, trimAPIPrefix(pathTemplate))
+	if !ok {
+		return errors2.APIError{
+			Message:    "a token restricted to some permissions or clients can't be used to access this resource",
+			HTTPStatus: http.StatusForbidden,
+		}
+	}
+	if declared.permission != "" && !token.AllowsPermission(declared.permission) {
+		return errors2.APIError{
+			Message:    fmt.Sprintf("the token is not allowed to use %q", declared.permission),
+			HTTPStatus: http.StatusForbidden,
+		}
+	}
+	if declared.fleetWide && token.IsClientRestricted() {
+		return errors2.APIError{
+			Message:    "a token restricted to some clients can't be used to access this resource",
+			HTTPStatus: http.StatusForbidden,
+		}
+	}
+	return nil
+}
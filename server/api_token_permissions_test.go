@@ -0,0 +1,43 @@
+package chserver
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/IOTech17/neo-rport/server/api/users"
+)
+
+func TestTokenPermissionForRoute(t *testing.T) {
+	testCases := []struct {
+		method         string
+		path           string
+		wantPermission string
+		wantFleetWide  bool
+		wantDeclared   bool
+	}{
+		{http.MethodGet, "/clients", "", false, true},
+		{http.MethodDelete, "/clients/{client_id}", "", false, false},
+		{http.MethodGet, "/clients/{client_id}/attributes", "", false, false},
+		{http.MethodPost, "/clients/{client_id}/scripts", users.PermissionScripts, false, true},
+		{http.MethodGet, "/clients/{client_id}/commands/{job_id}", users.PermissionCommands, false, true},
+		{http.MethodGet, "/commands", users.PermissionCommands, true, true},
+		{http.MethodPost, "/commands", users.PermissionCommands, false, true},
+		{http.MethodGet, "/commands/{job_id}/jobs", users.PermissionCommands, false, true},
+		{http.MethodGet, "/commandsx", "", false, false},
+		{http.MethodGet, "/library/commands/{command_id}", users.PermissionCommands, false, true},
+		{http.MethodPut, "/library/commands/{command_id}", users.PermissionCommands, true, true},
+		{http.MethodGet, "/files/changes/{file_change_id}", users.PermissionUploads, true, true},
+		{http.MethodGet, "/vault", users.PermissionVault, true, true},
+		{http.MethodPost, "/me/tokens", "", false, true},
+		{http.MethodPut, "/me", "", false, false},
+		{http.MethodGet, "/users", "", false, false},
+	}
+	for _, tc := range testCases {
+		declaration, declared := tokenPermissionForRoute(tc.method, tc.path)
+		assert.Equal(t, tc.wantPermission, declaration.permission, tc.method+" "+tc.path)
+		assert.Equal(t, tc.wantFleetWide, declaration.fleetWide, tc.method+" "+tc.path)
+		assert.Equal(t, tc.wantDeclared, declared, tc.method+" "+tc.path)
+	}
+}
@@ -0,0 +1,52 @@
+package chserver
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/routes"
+)
+
+type apiVersion struct {
+	Prefix  string
+	Version int
+}
+
+// apiVersions are the served API versions. v1 stays compatible with upstream rport, breaking changes only land in
+// later versions.
+var apiVersions = []apiVersion{
+	{Prefix: routes.AllRoutesPrefix, Version: api.V1},
+	{Prefix: routes.V2RoutesPrefix, Version: api.V2},
+}
+
+func (al *APIListener) wrapAPIVersionMiddleware(version int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			aw := &apiResponseWriter{ResponseWriter: w, version: version, path: r.URL.Path}
+			next.ServeHTTP(aw, r.WithContext(api.WithVersion(r.Context(), version)))
+		})
+	}
+}
+
+// trimAPIPrefix returns the path without the prefix of its API version.
+func trimAPIPrefix(path string) string {
+	for _, v := range apiVersions {
+		if path == v.Prefix || strings.HasPrefix(path, v.Prefix+"/") {
+			return strings.TrimPrefix(path, v.Prefix)
+		}
+	}
+	return path
+}
+
+// apiPrefix returns the prefix of the API version of the request, so URIs sent back point to the same version.
+func apiPrefix(ctx context.Context) string {
+	version := api.GetVersion(ctx)
+	for _, v := range apiVersions {
+		if v.Version == version {
+			return v.Prefix
+		}
+	}
+	return routes.AllRoutesPrefix
+}
@@ -0,0 +1,75 @@
+package chserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+)
+
+func TestAPIVersions(t *testing.T) {
+	al := APIListener{
+		Logger:           testLog,
+		insecureForTests: true,
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 1024 * 1024,
+				},
+			},
+		},
+	}
+	al.initRouter()
+
+	testCases := []struct {
+		Method       string
+		Path         string
+		ExpectedCode int
+	}{
+		{Method: http.MethodGet, Path: "/api/v1/me/ip", ExpectedCode: http.StatusOK},
+		{Method: http.MethodGet, Path: "/api/v2/me/ip", ExpectedCode: http.StatusOK},
+		{Method: http.MethodPost, Path: "/api/v1/me/token", ExpectedCode: http.StatusGone},
+		// deprecated endpoints are not carried over
+		{Method: http.MethodPost, Path: "/api/v2/me/token", ExpectedCode: http.StatusNotFound},
+		{Method: http.MethodGet, Path: "/api/v3/me/ip", ExpectedCode: http.StatusNotFound},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Method+" "+tc.Path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			al.router.ServeHTTP(w, httptest.NewRequest(tc.Method, tc.Path, nil))
+			assert.Equal(t, tc.ExpectedCode, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestWrapAPIVersionMiddleware(t *testing.T) {
+	al := APIListener{}
+	var version int
+	h := al.wrapAPIVersionMiddleware(api.V2)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		version = api.GetVersion(r.Context())
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v2/status", nil))
+
+	assert.Equal(t, api.V2, version)
+	assert.Equal(t, api.V1, api.GetVersion(httptest.NewRequest(http.MethodGet, "/", nil).Context()))
+}
+
+func TestTrimAPIPrefix(t *testing.T) {
+	assert.Equal(t, "/clients/{client_id}", trimAPIPrefix("/api/v1/clients/{client_id}"))
+	assert.Equal(t, "/clients", trimAPIPrefix("/api/v2/clients"))
+	assert.Equal(t, "", trimAPIPrefix("/api/v2"))
+	assert.Equal(t, "/api/v20/clients", trimAPIPrefix("/api/v20/clients"))
+	assert.Equal(t, "/healthz", trimAPIPrefix("/healthz"))
+}
+
+func TestAPIPrefix(t *testing.T) {
+	assert.Equal(t, "/api/v1", apiPrefix(context.Background()))
+	assert.Equal(t, "/api/v1", apiPrefix(api.WithVersion(context.Background(), api.V1)))
+	assert.Equal(t, "/api/v2", apiPrefix(api.WithVersion(context.Background(), api.V2)))
+}
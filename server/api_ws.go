@@ -16,7 +16,7 @@ func (al *APIListener) getWsPrefix() string {
 
 func (al *APIListener) wsCommands(w http.ResponseWriter, r *http.Request) {
 	wsPrefix := al.getWsPrefix()
-	_ = homeTemplate.Execute(w, wsPrefix+r.Host+"/api/v1/ws/commands")
+	_ = homeTemplate.Execute(w, wsPrefix+r.Host+apiPrefix(r.Context())+"/ws/commands")
 }
 
 var homeTemplate = template.Must(template.New("").Parse(`
@@ -107,7 +107,7 @@ window.addEventListener("load", function(evt) {
 
 func (al *APIListener) wsScripts(w http.ResponseWriter, r *http.Request) {
 	wsPrefix := al.getWsPrefix()
-	_ = scriptsTemplate.Execute(w, wsPrefix+r.Host+"/api/v1/ws/scripts")
+	_ = scriptsTemplate.Execute(w, wsPrefix+r.Host+apiPrefix(r.Context())+"/ws/scripts")
 }
 
 var scriptsTemplate = template.Must(template.New("").Parse(`
@@ -223,7 +223,7 @@ window.addEventListener("load", function(evt) {
 
 func (al *APIListener) wsUploads(w http.ResponseWriter, r *http.Request) {
 	wsPrefix := al.getWsPrefix()
-	_ = uploadsTemplate.Execute(w, wsPrefix+r.Host+"/api/v1/ws/uploads")
+	_ = uploadsTemplate.Execute(w, wsPrefix+r.Host+apiPrefix(r.Context())+"/ws/uploads")
 }
 
 var uploadsTemplate = template.Must(template.New("").Parse(`
@@ -0,0 +1,254 @@
+// Package apilog logs API requests and responses for debugging integrations. Bodies of mutating requests are captured,
+// secrets are redacted and successful requests can be sampled.
+package apilog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/felixge/httpsnoop"
+
+	chshare "github.com/IOTech17/neo-rport/share"
+	"github.com/IOTech17/neo-rport/share/logger"
+)
+
+// RecentEntries is the number of the latest entries kept in memory for the API.
+const RecentEntries = 1000
+
+type Entry struct {
+	Time          time.Time   `json:"time"`
+	Method        string      `json:"method"`
+	Path          string      `json:"path"`
+	Query         string      `json:"query,omitempty"`
+	Status        int         `json:"status"`
+	DurationMS    int64       `json:"duration_ms"`
+	RequestBytes  int64       `json:"request_bytes"`
+	ResponseBytes int64       `json:"response_bytes"`
+	RemoteIP      string      `json:"remote_ip"`
+	Username      string      `json:"username,omitempty"`
+	RequestBody   interface{} `json:"request_body,omitempty"`
+	ResponseBody  interface{} `json:"response_body,omitempty"`
+}
+
+// Filter selects recent entries, empty fields match all.
+type Filter struct {
+	Method    string
+	Path      string
+	Username  string
+	MinStatus int
+	Limit     int
+}
+
+func (f Filter) match(e *Entry) bool {
+	return (f.Method == "" || strings.EqualFold(f.Method, e.Method)) &&
+		strings.HasPrefix(e.Path, f.Path) &&
+		(f.Username == "" || f.Username == e.Username) &&
+		e.Status >= f.MinStatus
+}
+
+type Logger struct {
+	config   Config
+	redactor *redactor
+	logger   *logger.Logger
+
+	mu     sync.Mutex
+	file   *rotatingFile
+	recent []*Entry
+	next   int
+}
+
+func New(config Config, l *logger.Logger) (*Logger, error) {
+	file, err := openRotatingFile(config.File, int64(config.MaxFileMB)*1024*1024, config.MaxBackups)
+	if err != nil {
+		return nil, err
+	}
+	return &Logger{
+		config:   config,
+		redactor: newRedactor(config.RedactKeys),
+		logger:   l,
+		file:     file,
+		recent:   make([]*Entry, 0, RecentEntries),
+	}, nil
+}
+
+type ctxKey struct{}
+
+// SetUsername records the authenticated user of the request.
+func SetUsername(ctx context.Context, username string) {
+	if u, ok := ctx.Value(ctxKey{}).(*string); ok {
+		*u = username
+	}
+}
+
+func isMutating(method string) bool {
+	return method != http.MethodGet && method != http.MethodHead && method != http.MethodOptions
+}
+
+func (l *Logger) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		username := new(string)
+		r = r.WithContext(context.WithValue(r.Context(), ctxKey{}, username))
+
+		reqCount, respCount := &capture{}, &capture{}
+		var reqBody, respBody *capture
+		if isMutating(r.Method) && l.config.MaxBodyBytes > 0 {
+			reqCount.max, respCount.max = l.config.MaxBodyBytes, l.config.MaxBodyBytes
+			reqBody, respBody = reqCount, respCount
+		}
+		if r.Body != nil {
+			r.Body = &teeBody{ReadCloser: r.Body, c: reqCount}
+		}
+
+		status := 0
+		ww := httpsnoop.Wrap(w, httpsnoop.Hooks{
+			WriteHeader: func(next httpsnoop.WriteHeaderFunc) httpsnoop.WriteHeaderFunc {
+				return func(code int) {
+					if status == 0 {
+						status = code
+					}
+					next(code)
+				}
+			},
+			Write: func(next httpsnoop.WriteFunc) httpsnoop.WriteFunc {
+				return func(b []byte) (int, error) {
+					if status == 0 {
+						status = http.StatusOK
+					}
+					n, err := next(b)
+					_, _ = respCount.Write(b[:n])
+					return n, err
+				}
+			},
+			ReadFrom: func(next httpsnoop.ReadFromFunc) httpsnoop.ReadFromFunc {
+				return func(src io.Reader) (int64, error) {
+					if status == 0 {
+						status = http.StatusOK
+					}
+					return next(io.TeeReader(src, respCount))
+				}
+			},
+			Hijack: func(next httpsnoop.HijackFunc) httpsnoop.HijackFunc {
+				return func() (net.Conn, *bufio.ReadWriter, error) {
+					status = http.StatusSwitchingProtocols
+					return next()
+				}
+			},
+		})
+
+		next.ServeHTTP(ww, r)
+
+		if status == 0 {
+			status = http.StatusOK
+		}
+		// errors are logged in any case
+		if status < http.StatusBadRequest && rand.Float64() >= l.config.SampleRate {
+			return
+		}
+
+		e := &Entry{
+			Time:          start.UTC(),
+			Method:        r.Method,
+			Path:          r.URL.Path,
+			Query:         l.redactor.values(r.URL.Query()).Encode(),
+			Status:        status,
+			DurationMS:    time.Since(start).Milliseconds(),
+			RequestBytes:  reqCount.total,
+			ResponseBytes: respCount.total,
+			RemoteIP:      chshare.RemoteIP(r),
+			Username:      *username,
+			RequestBody:   l.redactor.body(r.Header.Get("Content-Type"), reqBody),
+			ResponseBody:  l.redactor.body(ww.Header().Get("Content-Type"), respBody),
+		}
+		l.save(e)
+	})
+}
+
+func (l *Logger) save(e *Entry) {
+	line, err := json.Marshal(e)
+	if err != nil {
+		l.logger.Errorf("Failed to encode request log entry: %v", err)
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.recent) < RecentEntries {
+		l.recent = append(l.recent, e)
+	} else {
+		l.recent[l.next] = e
+	}
+	l.next = (l.next + 1) % RecentEntries
+
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		l.logger.Errorf("Failed to write request log: %v", err)
+	}
+}
+
+// Recent returns the latest entries matching the filter, newest first.
+func (l *Logger) Recent(f Filter) []*Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	result := []*Entry{}
+	for i := 1; i <= len(l.recent); i++ {
+		e := l.recent[(l.next-i+len(l.recent))%len(l.recent)]
+		if !f.match(e) {
+			continue
+		}
+		result = append(result, e)
+		if f.Limit > 0 && len(result) == f.Limit {
+			break
+		}
+	}
+	return result
+}
+
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// capture counts the bytes written and keeps up to max of them.
+type capture struct {
+	buf   bytes.Buffer
+	max   int
+	total int64
+}
+
+func (c *capture) Write(p []byte) (int, error) {
+	c.total += int64(len(p))
+	if room := c.max - c.buf.Len(); room > 0 {
+		if len(p) > room {
+			c.buf.Write(p[:room])
+		} else {
+			c.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+func (c *capture) truncated() bool {
+	return c.total > int64(c.buf.Len())
+}
+
+type teeBody struct {
+	io.ReadCloser
+	c *capture
+}
+
+func (b *teeBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	_, _ = b.c.Write(p[:n])
+	return n, err
+}
@@ -0,0 +1,186 @@
+package apilog
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/share/logger"
+)
+
+var testLog = logger.NewLogger("apilog", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+
+func newTestLogger(t *testing.T, config Config) *Logger {
+	config.File = filepath.Join(t.TempDir(), "requests.log")
+	if config.SampleRate == 0 {
+		config.SampleRate = 1
+	}
+	if config.MaxFileMB == 0 {
+		config.MaxFileMB = 1
+	}
+	l, err := New(config, testLog)
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+func TestMiddleware(t *testing.T) {
+	l := newTestLogger(t, Config{MaxBodyBytes: 1024, RedactKeys: []string{"pin"}})
+	h := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		SetUsername(r.Context(), "admin")
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Equal(t, `{"username":"admin","password":"secret","nested":[{"PIN":"1234","name":"x"}]}`, string(body))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"data":{"token":"abc","prefix":"p1"}}`))
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/me/tokens?access_token=xyz&scope=read", strings.NewReader(
+		`{"username":"admin","password":"secret","nested":[{"PIN":"1234","name":"x"}]}`,
+	))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = "192.0.2.1:4000"
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, `{"data":{"token":"abc","prefix":"p1"}}`, rec.Body.String())
+
+	entries := l.Recent(Filter{})
+	require.Len(t, entries, 1)
+	e := entries[0]
+	assert.Equal(t, http.MethodPost, e.Method)
+	assert.Equal(t, "/api/v1/me/tokens", e.Path)
+	assert.Equal(t, "access_token=%5BREDACTED%5D&scope=read", e.Query)
+	assert.Equal(t, http.StatusCreated, e.Status)
+	assert.Equal(t, "admin", e.Username)
+	assert.Equal(t, "192.0.2.1", e.RemoteIP)
+	assert.EqualValues(t, 77, e.RequestBytes)
+	assert.EqualValues(t, rec.Body.Len(), e.ResponseBytes)
+
+	logged, err := os.ReadFile(l.config.File)
+	require.NoError(t, err)
+	var fromFile map[string]interface{}
+	require.NoError(t, json.Unmarshal(logged, &fromFile))
+	assert.Equal(t, map[string]interface{}{
+		"username": "admin",
+		"password": Redacted,
+		"nested":   []interface{}{map[string]interface{}{"PIN": Redacted, "name": "x"}},
+	}, fromFile["request_body"])
+	assert.Equal(t, map[string]interface{}{
+		"data": map[string]interface{}{"token": Redacted, "prefix": "p1"},
+	}, fromFile["response_body"])
+	assert.NotContains(t, string(logged), "secret")
+}
+
+func TestMiddlewareBodies(t *testing.T) {
+	testCases := []struct {
+		name         string
+		method       string
+		contentType  string
+		body         string
+		expectedBody interface{}
+	}{
+		{
+			name:         "form",
+			method:       http.MethodPost,
+			contentType:  "application/x-www-form-urlencoded",
+			body:         "username=admin&password=secret",
+			expectedBody: "password=%5BREDACTED%5D&username=admin",
+		},
+		{
+			name:         "truncated json",
+			method:       http.MethodPut,
+			contentType:  "application/json",
+			body:         `{"password":"` + strings.Repeat("x", 100) + `"}`,
+			expectedBody: "[115 bytes of JSON exceed the limit]",
+		},
+		{
+			name:         "binary",
+			method:       http.MethodPost,
+			contentType:  "application/octet-stream",
+			body:         "password",
+			expectedBody: "[8 bytes of application/octet-stream]",
+		},
+		{
+			name:   "not mutating",
+			method: http.MethodGet,
+			body:   `{"a":1}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			l := newTestLogger(t, Config{MaxBodyBytes: 64})
+			h := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.ReadAll(r.Body)
+			}))
+			req := httptest.NewRequest(tc.method, "/api/v1/test", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", tc.contentType)
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			entries := l.Recent(Filter{})
+			require.Len(t, entries, 1)
+			assert.Equal(t, tc.expectedBody, entries[0].RequestBody)
+			assert.Nil(t, entries[0].ResponseBody)
+			assert.EqualValues(t, len(tc.body), entries[0].RequestBytes)
+		})
+	}
+}
+
+func TestMiddlewareSampling(t *testing.T) {
+	l := newTestLogger(t, Config{SampleRate: 0.000001})
+	h := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	for i := 0; i < 10; i++ {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", nil))
+	}
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+
+	entries := l.Recent(Filter{})
+	require.Len(t, entries, 1)
+	assert.Equal(t, "/fail", entries[0].Path)
+	assert.Equal(t, http.StatusInternalServerError, entries[0].Status)
+}
+
+func TestRecent(t *testing.T) {
+	l := newTestLogger(t, Config{})
+	for i := 0; i < RecentEntries+5; i++ {
+		status := http.StatusOK
+		if i%2 == 0 {
+			status = http.StatusNotFound
+		}
+		l.save(&Entry{Method: http.MethodGet, Path: "/api/v1/clients", Status: status, DurationMS: int64(i)})
+	}
+
+	all := l.Recent(Filter{})
+	require.Len(t, all, RecentEntries)
+	assert.EqualValues(t, RecentEntries+4, all[0].DurationMS)
+	assert.EqualValues(t, 5, all[RecentEntries-1].DurationMS)
+
+	errs := l.Recent(Filter{Method: "get", Path: "/api/v1/cl", MinStatus: 400, Limit: 2})
+	require.Len(t, errs, 2)
+	assert.EqualValues(t, RecentEntries+4, errs[0].DurationMS)
+	assert.EqualValues(t, RecentEntries+2, errs[1].DurationMS)
+
+	assert.Empty(t, l.Recent(Filter{Path: "/api/v1/users"}))
+
+	f, err := os.Open(l.config.File)
+	require.NoError(t, err)
+	defer f.Close()
+	lines := 0
+	for s := bufio.NewScanner(f); s.Scan(); lines++ {
+	}
+	assert.Equal(t, RecentEntries+5, lines)
+}
@@ -0,0 +1,37 @@
+package apilog
+
+import (
+	"errors"
+)
+
+type Config struct {
+	File         string   `mapstructure:"request_log_file"`
+	SampleRate   float64  `mapstructure:"request_log_sample_rate"`
+	MaxBodyBytes int      `mapstructure:"request_log_max_body_bytes"`
+	MaxFileMB    int      `mapstructure:"request_log_max_file_mb"`
+	MaxBackups   int      `mapstructure:"request_log_max_backups"`
+	RedactKeys   []string `mapstructure:"request_log_redact_keys"`
+}
+
+func (c Config) Enabled() bool {
+	return c.File != ""
+}
+
+func (c *Config) Validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	if c.SampleRate <= 0 || c.SampleRate > 1 {
+		return errors.New("api.request_log_sample_rate must be greater than 0 and at most 1")
+	}
+	if c.MaxBodyBytes < 0 {
+		return errors.New("api.request_log_max_body_bytes must not be negative")
+	}
+	if c.MaxFileMB <= 0 {
+		return errors.New("api.request_log_max_file_mb must be positive")
+	}
+	if c.MaxBackups < 0 {
+		return errors.New("api.request_log_max_backups must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package apilog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/url"
+	"strings"
+)
+
+// Redacted replaces the values of secrets.
+const Redacted = "[REDACTED]"
+
+// defaultRedactKeys are redacted in any case, keys are matched case-insensitive if they contain one of them.
+var defaultRedactKeys = []string{
+	"password",
+	"passwd",
+	"secret",
+	"token",
+	"authorization",
+	"api_key",
+	"apikey",
+	"private_key",
+	"otp",
+	"credential",
+	"cookie",
+}
+
+type redactor struct {
+	keys []string
+}
+
+func newRedactor(extraKeys []string) *redactor {
+	keys := append([]string{}, defaultRedactKeys...)
+	for _, k := range extraKeys {
+		if k = strings.ToLower(strings.TrimSpace(k)); k != "" {
+			keys = append(keys, k)
+		}
+	}
+	return &redactor{keys: keys}
+}
+
+func (r *redactor) isSecret(key string) bool {
+	key = strings.ToLower(key)
+	for _, k := range r.keys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *redactor) value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			if r.isSecret(k) {
+				v[k] = Redacted
+			} else {
+				v[k] = r.value(item)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = r.value(item)
+		}
+	}
+	return v
+}
+
+func (r *redactor) values(values url.Values) url.Values {
+	for k := range values {
+		if r.isSecret(k) {
+			values[k] = []string{Redacted}
+		}
+	}
+	return values
+}
+
+// body returns the redacted JSON or form body. Other bodies and truncated JSON can't be redacted, only their size is
+// returned.
+func (r *redactor) body(contentType string, c *capture) interface{} {
+	if c == nil || c.total == 0 {
+		return nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	data := c.buf.Bytes()
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(data))
+		if err == nil {
+			return r.values(values).Encode()
+		}
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") || (mediaType == "" && looksLikeJSON(data)):
+		if c.truncated() {
+			return fmt.Sprintf("[%d bytes of JSON exceed the limit]", c.total)
+		}
+		var v interface{}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(&v); err == nil {
+			return r.value(v)
+		}
+	}
+	if mediaType == "" {
+		mediaType = "unknown content type"
+	}
+	return fmt.Sprintf("[%d bytes of %s]", c.total, mediaType)
+}
+
+func looksLikeJSON(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && (data[0] == '{' || data[0] == '[')
+}
@@ -0,0 +1,51 @@
+package apilog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "requests.log")
+	r, err := openRotatingFile(path, 10, 2)
+	require.NoError(t, err)
+	defer r.Close()
+
+	for _, line := range []string{"one\n", "two\n", "three\n", "four\n", "five\n"} {
+		_, err := r.Write([]byte(line))
+		require.NoError(t, err)
+	}
+
+	assertContent := func(file, expected string) {
+		t.Helper()
+		content, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(content))
+	}
+	assertContent(path, "four\nfive\n")
+	assertContent(path+".1", "three\n")
+	assertContent(path+".2", "one\ntwo\n")
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestRotatingFileReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "requests.log")
+	require.NoError(t, os.WriteFile(path, []byte("12345678\n"), 0600))
+
+	r, err := openRotatingFile(path, 10, 0)
+	require.NoError(t, err)
+	defer r.Close()
+	_, err = r.Write([]byte("next\n"))
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "next\n", string(content))
+	_, err = os.Stat(path + ".1")
+	assert.True(t, os.IsNotExist(err))
+}
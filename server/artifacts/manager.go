@@ -0,0 +1,274 @@
+package artifacts
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/share/files"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/models"
+	"github.com/IOTech17/neo-rport/share/query"
+)
+
+const pullTimeout = time.Hour
+
+var supportedSortAndFilters = map[string]bool{
+	"name":       true,
+	"version":    true,
+	"size_bytes": true,
+	"sha256":     true,
+	"source_url": true,
+	"created_at": true,
+	"created_by": true,
+}
+
+type DbProvider interface {
+	// Get returns nil if the version doesn't exist, the latest version if it's empty
+	Get(ctx context.Context, name, version string) (*Artifact, error)
+	List(ctx context.Context, lo *query.ListOptions) ([]*Artifact, error)
+	Create(ctx context.Context, a *Artifact) error
+	// Delete returns the number of versions left with the same content
+	Delete(ctx context.Context, a *Artifact) (int, error)
+}
+
+// Manager stores the content of the artifacts in a directory, one file per distinct content named by its sha256 sum.
+type Manager struct {
+	db         DbProvider
+	dir        string
+	maxSize    int64
+	httpClient *http.Client
+	logger     *logger.Logger
+	// mtx prevents deleting the content of a version that's created at the same time
+	mtx sync.Mutex
+}
+
+func NewManager(db DbProvider, dir string, maxSize int64, logger *logger.Logger) *Manager {
+	return &Manager{
+		db:         db,
+		dir:        dir,
+		maxSize:    maxSize,
+		httpClient: &http.Client{Timeout: pullTimeout},
+		logger:     logger,
+	}
+}
+
+func (m *Manager) List(ctx context.Context, re *http.Request) ([]*Artifact, error) {
+	lo := query.NewOptions(re, map[string][]string{"sort": {"name", "-created_at"}}, nil, nil)
+	if err := query.ValidateListOptions(lo, supportedSortAndFilters, supportedSortAndFilters, nil, nil); err != nil {
+		return nil, err
+	}
+	return m.db.List(ctx, lo)
+}
+
+// Get returns the version of the artifact, the latest version if it's empty.
+func (m *Manager) Get(ctx context.Context, name, version string) (*Artifact, error) {
+	a, err := m.db.Get(ctx, name, version)
+	if err != nil {
+		return nil, err
+	}
+	if a == nil {
+		ref := name
+		if version != "" {
+			ref += "@" + version
+		}
+		return nil, errors2.APIError{
+			Message:    fmt.Sprintf("artifact %s not found", ref),
+			HTTPStatus: http.StatusNotFound,
+		}
+	}
+	return a, nil
+}
+
+// Resolve returns the version referenced by "<name>@<version>" or the latest version referenced by "<name>".
+func (m *Manager) Resolve(ctx context.Context, ref string) (*Artifact, error) {
+	name, version := ref, ""
+	if i := strings.Index(ref, "@"); i >= 0 {
+		name, version = ref[:i], ref[i+1:]
+		if err := validateVersion(version); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+	return m.Get(ctx, name, version)
+}
+
+// Upload creates a version from the content.
+func (m *Manager) Upload(ctx context.Context, name, version string, content io.Reader, createdBy string) (*Artifact, error) {
+	return m.create(ctx, &Artifact{Name: name, Version: version, CreatedBy: createdBy}, content, "")
+}
+
+// Pull creates a version from the download of the URL.
+func (m *Manager) Pull(ctx context.Context, req PullRequest, createdBy string) (*Artifact, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, badRequest("invalid url %q, expected an http or https URL", req.URL)
+	}
+	wantSha256 := strings.ToLower(req.Sha256)
+	if len(wantSha256) != sha256.Size*2 {
+		return nil, badRequest("sha256 of the download is required")
+	}
+	if _, err := hex.DecodeString(wantSha256); err != nil {
+		return nil, badRequest("invalid sha256 %q", req.Sha256)
+	}
+	a := &Artifact{Name: req.Name, Version: req.Version, SourceURL: req.URL, CreatedBy: createdBy}
+	if err := m.validateNew(ctx, a); err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, req.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := m.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, errors2.APIError{
+			Message:    "failed to download the artifact",
+			Err:        err,
+			HTTPStatus: http.StatusBadGateway,
+		}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors2.APIError{
+			Message:    fmt.Sprintf("failed to download the artifact, %s returned %s", req.URL, resp.Status),
+			HTTPStatus: http.StatusBadGateway,
+		}
+	}
+
+	return m.create(ctx, a, resp.Body, wantSha256)
+}
+
+// Delete deletes the version, the content is removed once no version has it anymore.
+func (m *Manager) Delete(ctx context.Context, name, version string) error {
+	a, err := m.Get(ctx, name, version)
+	if err != nil {
+		return err
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	left, err := m.db.Delete(ctx, a)
+	if err != nil {
+		return err
+	}
+	if left == 0 {
+		if err := os.Remove(m.Path(a)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Path returns the file holding the content of the version.
+func (m *Manager) Path(a *Artifact) string {
+	return filepath.Join(m.dir, a.Sha256)
+}
+
+func (m *Manager) validateNew(ctx context.Context, a *Artifact) error {
+	if err := validateName(a.Name); err != nil {
+		return err
+	}
+	if err := validateVersion(a.Version); err != nil {
+		return err
+	}
+	existing, err := m.db.Get(ctx, a.Name, a.Version)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return errors2.APIError{
+			Message:    fmt.Sprintf("artifact %s already exists, versions can't be changed", a),
+			HTTPStatus: http.StatusConflict,
+		}
+	}
+	return nil
+}
+
+func (m *Manager) create(ctx context.Context, a *Artifact, content io.Reader, wantSha256 string) (*Artifact, error) {
+	if err := m.validateNew(ctx, a); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(m.dir, 0700); err != nil {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp(m.dir, ".upload-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	sha := sha256.New()
+	md := md5.New()
+	chunks := files.NewChunkHasher(ChunkSize)
+	size, err := io.Copy(io.MultiWriter(tmp, sha, md, chunks), io.LimitReader(content, m.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if size > m.maxSize {
+		return nil, errors2.APIError{
+			Message:    fmt.Sprintf("artifact exceeds the maximum size of %d bytes", m.maxSize),
+			HTTPStatus: http.StatusRequestEntityTooLarge,
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+
+	a.SizeBytes = size
+	a.Sha256 = hex.EncodeToString(sha.Sum(nil))
+	a.Md5 = hex.EncodeToString(md.Sum(nil))
+	a.Chunks = chunks.Sums()
+	a.CreatedAt = time.Now().UTC()
+	if wantSha256 != "" && a.Sha256 != wantSha256 {
+		return nil, badRequest("checksum mismatch, the download has the sha256 %s", a.Sha256)
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	// versions with the same content share the file
+	if err := os.Rename(tmp.Name(), m.Path(a)); err != nil {
+		return nil, err
+	}
+	if err := m.db.Create(ctx, a); err != nil {
+		return nil, err
+	}
+	m.logger.Infof("Artifact %s created, %d bytes, sha256 %s.", a, a.SizeBytes, a.Sha256)
+	return a, nil
+}
+
+func validateName(name string) error {
+	if err := models.ValidateArtifactName("name", name); err != nil {
+		return errors2.APIError{Err: err, HTTPStatus: http.StatusBadRequest}
+	}
+	return nil
+}
+
+func validateVersion(version string) error {
+	if err := models.ValidateArtifactName("version", version); err != nil {
+		return errors2.APIError{Err: err, HTTPStatus: http.StatusBadRequest}
+	}
+	return nil
+}
+
+func badRequest(format string, a ...interface{}) error {
+	return errors2.APIError{
+		Err:        fmt.Errorf(format, a...),
+		HTTPStatus: http.StatusBadRequest,
+	}
+}
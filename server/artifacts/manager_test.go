@@ -0,0 +1,134 @@
+package artifacts
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/db/migration/library"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/share/logger"
+)
+
+var testLog = logger.NewLogger("artifacts", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+
+func newTestManager(t *testing.T, maxSize int64) *Manager {
+	db, err := sqlite.New(":memory:", library.AssetNames(), library.Asset, sqlite.DataSourceOptions{})
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return NewManager(NewSqliteProvider(db), t.TempDir(), maxSize, testLog)
+}
+
+func sha256Hex(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestUploadAndResolve(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t, 10*ChunkSize)
+	content := bytes.Repeat([]byte("a"), ChunkSize+10)
+
+	a, err := m.Upload(ctx, "agent", "1.0.0", bytes.NewReader(content), "admin")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(content)), a.SizeBytes)
+	assert.Equal(t, sha256Hex(content), a.Sha256)
+	assert.Equal(t, []string{sha256Hex(content[:ChunkSize]), sha256Hex(content[ChunkSize:])}, []string(a.Chunks))
+	stored, err := os.ReadFile(m.Path(a))
+	require.NoError(t, err)
+	assert.Equal(t, content, stored)
+
+	_, err = m.Upload(ctx, "agent", "1.0.0", strings.NewReader("other"), "admin")
+	assert.EqualError(t, err, "artifact agent@1.0.0 already exists, versions can't be changed")
+
+	_, err = m.Upload(ctx, "agent", "2.0.0", strings.NewReader("v2"), "admin")
+	require.NoError(t, err)
+
+	latest, err := m.Resolve(ctx, "agent")
+	require.NoError(t, err)
+	assert.Equal(t, "2.0.0", latest.Version)
+	first, err := m.Resolve(ctx, "agent@1.0.0")
+	require.NoError(t, err)
+	assert.Equal(t, a.Sha256, first.Sha256)
+
+	_, err = m.Resolve(ctx, "agent@3.0.0")
+	assert.Equal(t, http.StatusNotFound, err.(errors2.APIError).HTTPStatus)
+	_, err = m.Resolve(ctx, "../agent")
+	assert.Equal(t, http.StatusBadRequest, err.(errors2.APIError).HTTPStatus)
+
+	_, err = m.Upload(ctx, "big", "1", bytes.NewReader(make([]byte, 10*ChunkSize+1)), "admin")
+	assert.EqualError(t, err, "artifact exceeds the maximum size of 10485760 bytes")
+
+	req := httptest.NewRequest(http.MethodGet, "/library/artifacts?"+url.Values{"filter[name]": {"agent"}}.Encode(), nil)
+	list, err := m.List(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "2.0.0", list[0].Version)
+	assert.Equal(t, "1.0.0", list[1].Version)
+}
+
+func TestPull(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t, ChunkSize)
+	content := []byte("payload")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/agent.tar.gz" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(content)
+	}))
+	defer srv.Close()
+
+	a, err := m.Pull(ctx, PullRequest{Name: "agent", Version: "1", URL: srv.URL + "/agent.tar.gz", Sha256: strings.ToUpper(sha256Hex(content))}, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, srv.URL+"/agent.tar.gz", a.SourceURL)
+
+	_, err = m.Pull(ctx, PullRequest{Name: "agent", Version: "2", URL: srv.URL + "/agent.tar.gz", Sha256: sha256Hex([]byte("other"))}, "admin")
+	assert.EqualError(t, err, "checksum mismatch, the download has the sha256 "+sha256Hex(content))
+
+	_, err = m.Pull(ctx, PullRequest{Name: "agent", Version: "2", URL: srv.URL + "/missing", Sha256: sha256Hex(content)}, "admin")
+	assert.EqualError(t, err, "failed to download the artifact, "+srv.URL+"/missing returned 404 Not Found")
+
+	_, err = m.Pull(ctx, PullRequest{Name: "agent", Version: "2", URL: srv.URL + "/agent.tar.gz"}, "admin")
+	assert.EqualError(t, err, "sha256 of the download is required")
+
+	_, err = m.Pull(ctx, PullRequest{Name: "agent", Version: "2", URL: "file:///etc/passwd", Sha256: sha256Hex(content)}, "admin")
+	assert.EqualError(t, err, `invalid url "file:///etc/passwd", expected an http or https URL`)
+
+	_, err = m.Resolve(ctx, "agent@2")
+	assert.Error(t, err)
+}
+
+func TestDeleteKeepsSharedContent(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t, ChunkSize)
+
+	v1, err := m.Upload(ctx, "config", "1", strings.NewReader("same"), "admin")
+	require.NoError(t, err)
+	_, err = m.Upload(ctx, "config", "2", strings.NewReader("same"), "admin")
+	require.NoError(t, err)
+
+	require.NoError(t, m.Delete(ctx, "config", "1"))
+	assert.FileExists(t, m.Path(v1))
+	require.NoError(t, m.Delete(ctx, "config", "2"))
+	assert.NoFileExists(t, m.Path(v1))
+
+	entries, err := os.ReadDir(filepath.Dir(m.Path(v1)))
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	assert.Error(t, m.Delete(ctx, "config", "2"))
+}
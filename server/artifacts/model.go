@@ -0,0 +1,52 @@
+// Package artifacts is a versioned repository of deployment payloads. File pushes and jobs reference the artifacts,
+// so a payload is uploaded once instead of with every push.
+package artifacts
+
+import (
+	"time"
+
+	"github.com/IOTech17/neo-rport/share/models"
+	"github.com/IOTech17/neo-rport/share/types"
+)
+
+// ChunkSize is the size of the chunks clients download a new version in
+const ChunkSize = 1 << 20
+
+// Artifact is a version of an artifact, versions are immutable once created.
+type Artifact struct {
+	Name      string `json:"name" db:"name"`
+	Version   string `json:"version" db:"version"`
+	SizeBytes int64  `json:"size_bytes" db:"size_bytes"`
+	Sha256    string `json:"sha256" db:"sha256"`
+	// Md5 is the checksum the clients verify a pushed file with
+	Md5    string            `json:"-" db:"md5"`
+	Chunks types.StringSlice `json:"-" db:"chunks"`
+	// SourceURL is set if the artifact was pulled from a URL
+	SourceURL string    `json:"source_url,omitempty" db:"source_url"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	CreatedBy string    `json:"created_by" db:"created_by"`
+}
+
+// PullRequest creates an artifact version from a URL, the checksum of the download must match.
+type PullRequest struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	URL     string `json:"url"`
+	Sha256  string `json:"sha256"`
+}
+
+func (a *Artifact) Ref() *models.ArtifactRef {
+	return &models.ArtifactRef{
+		Name:      a.Name,
+		Version:   a.Version,
+		Sha256:    a.Sha256,
+		SizeBytes: a.SizeBytes,
+		ChunkSize: ChunkSize,
+		Chunks:    a.Chunks,
+	}
+}
+
+// String returns the reference of the version used by jobs, "<name>@<version>".
+func (a *Artifact) String() string {
+	return a.Name + "@" + a.Version
+}
@@ -0,0 +1,73 @@
+package artifacts
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/IOTech17/neo-rport/share/query"
+)
+
+type SqliteProvider struct {
+	db        *sqlx.DB
+	converter *query.SQLConverter
+}
+
+func NewSqliteProvider(db *sqlx.DB) *SqliteProvider {
+	return &SqliteProvider{
+		db:        db,
+		converter: query.NewSQLConverter(db.DriverName()),
+	}
+}
+
+// Get returns the version of the artifact, the latest one if the version is empty, or nil if it doesn't exist.
+func (p *SqliteProvider) Get(ctx context.Context, name, version string) (*Artifact, error) {
+	q := "SELECT * FROM `artifacts` WHERE `name` = ? AND `version` = ?"
+	params := []interface{}{name, version}
+	if version == "" {
+		q = "SELECT * FROM `artifacts` WHERE `name` = ? ORDER BY `created_at` DESC LIMIT 1"
+		params = params[:1]
+	}
+
+	res := &Artifact{}
+	err := p.db.GetContext(ctx, res, q, params...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (p *SqliteProvider) List(ctx context.Context, lo *query.ListOptions) ([]*Artifact, error) {
+	res := []*Artifact{}
+	q, params := p.converter.ConvertListOptionsToQuery(lo, "SELECT * FROM `artifacts`")
+	err := p.db.SelectContext(ctx, &res, q, params...)
+	return res, err
+}
+
+func (p *SqliteProvider) Create(ctx context.Context, a *Artifact) error {
+	_, err := p.db.NamedExecContext(
+		ctx,
+		"INSERT INTO `artifacts`"+
+			" (`name`, `version`, `size_bytes`, `sha256`, `md5`, `chunks`, `source_url`, `created_at`, `created_by`)"+
+			" VALUES "+
+			"(:name, :version, :size_bytes, :sha256, :md5, :chunks, :source_url, :created_at, :created_by)",
+		a,
+	)
+	return err
+}
+
+// Delete deletes the version and returns the number of versions left with the same content.
+func (p *SqliteProvider) Delete(ctx context.Context, a *Artifact) (int, error) {
+	_, err := p.db.ExecContext(ctx, "DELETE FROM `artifacts` WHERE `name` = ? AND `version` = ?", a.Name, a.Version)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	err = p.db.GetContext(ctx, &count, "SELECT COUNT(*) FROM `artifacts` WHERE `sha256` = ?", a.Sha256)
+	return count, err
+}
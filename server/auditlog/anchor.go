@@ -0,0 +1,160 @@
+package auditlog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	anchorDirname  = "auditlog_anchors"
+	anchorFilename = "anchor-20060102T150405.000000000Z.json"
+	anchorPattern  = "anchor-*.json"
+)
+
+// Anchor is the periodically exported state of the hash chain. Stored outside the server, it proves the entries
+// up to To existed in exactly this form when the anchor was created.
+type Anchor struct {
+	CreatedAt time.Time `json:"created_at"`
+	// From is the timestamp of the last entry of the previous anchor, entries are anchored in the order they were
+	// stored, the timestamps are informational only
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	Entries int       `json:"entries"`
+	// FirstPrevHash links the anchored entries to the entries of the previous anchor
+	FirstPrevHash string `json:"first_prev_hash"`
+	LastHash      string `json:"last_hash"`
+	MerkleRoot    string `json:"merkle_root"`
+}
+
+type anchorStore interface {
+	Put(ctx context.Context, name string, content []byte) error
+}
+
+// dirAnchorStore writes the anchors as read-only files, it's the source of the last anchor on startup.
+type dirAnchorStore struct {
+	dir string
+}
+
+func (s dirAnchorStore) Put(_ context.Context, name string, content []byte) error {
+	f, err := os.OpenFile(filepath.Join(s.dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0400)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (s dirAnchorStore) last() (*Anchor, error) {
+	files, err := filepath.Glob(filepath.Join(s.dir, anchorPattern))
+	if err != nil || len(files) == 0 {
+		return nil, err
+	}
+	// the names sort by creation time
+	sort.Strings(files)
+	content, err := os.ReadFile(files[len(files)-1])
+	if err != nil {
+		return nil, err
+	}
+	anchor := &Anchor{}
+	if err := json.Unmarshal(content, anchor); err != nil {
+		return nil, fmt.Errorf("invalid audit log anchor %s: %w", files[len(files)-1], err)
+	}
+	return anchor, nil
+}
+
+func (a *AuditLog) initAnchoring(dataDir string) error {
+	dir := a.config.AnchorDir
+	if dir == "" {
+		dir = filepath.Join(dataDir, anchorDirname)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	local := dirAnchorStore{dir: dir}
+	last, err := local.last()
+	if err != nil {
+		return err
+	}
+	if last != nil {
+		a.lastAnchor = *last
+	}
+
+	a.anchorStores = []anchorStore{local}
+	if a.config.AnchorS3.Enabled() {
+		a.anchorStores = append(a.anchorStores, newS3AnchorStore(a.config.AnchorS3))
+	}
+
+	a.anchorTicker = time.NewTicker(a.config.AnchorInterval)
+	go a.anchorLoop()
+	return nil
+}
+
+func (a *AuditLog) anchorLoop() {
+	for now := range a.anchorTicker.C {
+		if _, err := a.Anchor(context.Background(), now); err != nil {
+			a.logger.Errorf("Could not anchor audit log: %v", err)
+		}
+	}
+}
+
+// Anchor exports the hash chain of the entries stored since the last anchor. It returns nil if there are no new
+// entries.
+func (a *AuditLog) Anchor(ctx context.Context, now time.Time) (*Anchor, error) {
+	a.anchorMu.Lock()
+	defer a.anchorMu.Unlock()
+
+	anchor := &Anchor{
+		CreatedAt: now.UTC(),
+		From:      a.lastAnchor.To,
+	}
+	// the entries after the last anchored one are anchored, all entries if the last one is in a rotated file
+	var hashes []string
+	err := a.provider.Walk(ctx, func(e *Entry) error {
+		if e.Hash == "" {
+			return nil
+		}
+		if e.Hash == a.lastAnchor.LastHash {
+			hashes = nil
+			return nil
+		}
+		if len(hashes) == 0 {
+			anchor.FirstPrevHash = e.PrevHash
+		}
+		hashes = append(hashes, e.Hash)
+		anchor.To = e.Timestamp
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(hashes) == 0 {
+		return nil, nil
+	}
+	anchor.Entries = len(hashes)
+	anchor.LastHash = hashes[len(hashes)-1]
+	anchor.MerkleRoot = merkleRoot(hashes)
+
+	content, err := json.MarshalIndent(anchor, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	name := anchor.CreatedAt.Format(anchorFilename)
+	for _, store := range a.anchorStores {
+		if err := store.Put(ctx, name, content); err != nil {
+			return nil, err
+		}
+	}
+
+	a.lastAnchor = *anchor
+	a.logger.Infof("Audit log anchored: %d entries up to %s, last hash %s", anchor.Entries, anchor.To, anchor.LastHash)
+	return anchor, nil
+}
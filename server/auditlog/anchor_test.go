@@ -0,0 +1,115 @@
+package auditlog
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/server/auditlog/config"
+	"github.com/IOTech17/neo-rport/share/logger"
+)
+
+func TestAnchor(t *testing.T) {
+	ctx := context.Background()
+	auditLog, _ := newChainedAuditLog(t)
+	auditLog.logger = logger.NewLogger("auditlog", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+	auditLog.config.AnchorInterval = time.Hour
+	auditLog.config.AnchorDir = t.TempDir()
+	require.NoError(t, auditLog.initAnchoring(""))
+	defer auditLog.anchorTicker.Stop()
+
+	saveEntries(auditLog, 0, "1", "2")
+	first, err := auditLog.Anchor(ctx, time.Date(2022, 1, 2, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.NotNil(t, first)
+	assert.Equal(t, 2, first.Entries)
+	assert.Equal(t, "", first.FirstPrevHash)
+	assert.Equal(t, time.Date(2022, 1, 1, 0, 0, 1, 0, time.UTC), first.To.UTC())
+
+	none, err := auditLog.Anchor(ctx, time.Date(2022, 1, 2, 1, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Nil(t, none)
+
+	saveEntries(auditLog, 2, "3", "4", "5")
+	second, err := auditLog.Anchor(ctx, time.Date(2022, 1, 2, 2, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.NotNil(t, second)
+	assert.Equal(t, 3, second.Entries)
+	assert.Equal(t, first.LastHash, second.FirstPrevHash)
+
+	result, err := auditLog.Verify(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, result.LastHash, second.LastHash)
+
+	files, err := filepath.Glob(filepath.Join(auditLog.config.AnchorDir, anchorPattern))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	stat, err := os.Stat(files[0])
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0400), stat.Mode().Perm())
+
+	// the last anchor is restored on restart
+	restarted, _ := newChainedAuditLog(t)
+	restarted.config = auditLog.config
+	require.NoError(t, restarted.initAnchoring(""))
+	defer restarted.anchorTicker.Stop()
+	assert.Equal(t, second.LastHash, restarted.lastAnchor.LastHash)
+}
+
+func TestS3AnchorStore(t *testing.T) {
+	var gotReq *http.Request
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotReq = r
+		gotBody, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	store := newS3AnchorStore(config.S3Config{
+		Bucket:          "audit",
+		Region:          "eu-central-1",
+		Endpoint:        server.URL,
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+		RetentionDays:   10,
+	})
+	store.now = func() time.Time { return time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	content, err := json.Marshal(Anchor{Entries: 1})
+	require.NoError(t, err)
+	require.NoError(t, store.Put(context.Background(), "anchor-1.json", content))
+
+	assert.Equal(t, http.MethodPut, gotReq.Method)
+	assert.Equal(t, "/audit/anchor-1.json", gotReq.URL.Path)
+	assert.Equal(t, content, gotBody)
+	assert.Equal(t, "COMPLIANCE", gotReq.Header.Get("X-Amz-Object-Lock-Mode"))
+	assert.Equal(t, "2022-01-12T03:04:05Z", gotReq.Header.Get("X-Amz-Object-Lock-Retain-Until-Date"))
+	assert.Equal(t, "20220102T030405Z", gotReq.Header.Get("X-Amz-Date"))
+	assert.NotEmpty(t, gotReq.Header.Get("Content-MD5"))
+	auth := gotReq.Header.Get("Authorization")
+	assert.True(t, strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20220102/eu-central-1/s3/aws4_request, SignedHeaders=content-md5;content-type;host;x-amz-content-sha256;x-amz-date;x-amz-object-lock-mode;x-amz-object-lock-retain-until-date, Signature="), auth)
+
+	server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte("AccessDenied"))
+	})
+	err = store.Put(context.Background(), "anchor-2.json", content)
+	assert.EqualError(t, err, "failed to upload audit log anchor to s3: 403 Forbidden: AccessDenied")
+}
+
+func TestSigningKey(t *testing.T) {
+	// example of the AWS signature version 4 documentation
+	key := signingKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20120215", "us-east-1", "iam")
+	assert.Equal(t, "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d", hex.EncodeToString(key))
+}
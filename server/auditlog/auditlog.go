@@ -6,6 +6,7 @@ import (
 	"net"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/IOTech17/neo-rport/server/api/users"
@@ -26,6 +27,7 @@ var (
 		"timestamp[since]": true,
 		"timestamp[until]": true,
 		"username":         true,
+		"impersonated_by":  true,
 		"remote_ip":        true,
 		"application":      true,
 		"action":           true,
@@ -36,6 +38,7 @@ var (
 	supportedSorts = map[string]bool{
 		"timestamp":       true,
 		"username":        true,
+		"impersonated_by": true,
 		"remote_ip":       true,
 		"application":     true,
 		"action":          true,
@@ -54,6 +57,8 @@ type Provider interface {
 	Save(e *Entry) error
 	List(context.Context, *query.ListOptions) ([]*Entry, error)
 	Count(context.Context, *query.ListOptions) (int, error)
+	LastHash(context.Context) (string, error)
+	Walk(ctx context.Context, fn func(e *Entry) error) error
 }
 
 type AuditLog struct {
@@ -61,6 +66,14 @@ type AuditLog struct {
 	clientGetter ClientGetter
 	provider     Provider
 	config       config.Config
+
+	chainMu  sync.Mutex
+	lastHash string
+
+	anchorMu     sync.Mutex
+	lastAnchor   Anchor
+	anchorStores []anchorStore
+	anchorTicker *time.Ticker
 }
 
 type NotAllowedError struct {
@@ -90,6 +103,17 @@ func New(l *logger.Logger, cg ClientGetter, dataDir string, cfg config.Config, d
 		}
 
 		a.provider = rotation
+
+		a.lastHash, err = rotation.LastHash(context.Background())
+		if err != nil {
+			return nil, err
+		}
+
+		if cfg.AnchorInterval > 0 {
+			if err := a.initAnchoring(dataDir); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	return a, nil
@@ -117,6 +141,10 @@ func (a *AuditLog) Close() error {
 		return nil
 	}
 
+	if a.anchorTicker != nil {
+		a.anchorTicker.Stop()
+	}
+
 	return a.provider.Close()
 }
 
@@ -132,7 +160,17 @@ func (a *AuditLog) savePreparedEntry(e *Entry) error {
 		}
 	}
 
-	return a.provider.Save(e)
+	a.chainMu.Lock()
+	defer a.chainMu.Unlock()
+
+	e.PrevHash = a.lastHash
+	e.Hash = e.computeHash()
+	if err := a.provider.Save(e); err != nil {
+		return err
+	}
+	a.lastHash = e.Hash
+
+	return nil
 }
 
 func (a *AuditLog) List(r *http.Request, user *users.User) (*api.SuccessPayload, error) {
@@ -0,0 +1,137 @@
+package auditlog
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+)
+
+// computeHash returns the hash of the entry chained to PrevHash, any change of a stored entry or of the order
+// of entries changes the hashes of all entries that follow.
+func (e *Entry) computeHash() string {
+	content, _ := json.Marshal([]string{
+		e.PrevHash,
+		e.Timestamp.UTC().Format(time.RFC3339Nano),
+		e.Username,
+		e.ImpersonatedBy,
+		e.RemoteIP,
+		e.Application,
+		e.Action,
+		e.ID,
+		e.ClientID,
+		e.ClientHostName,
+		e.Request,
+		e.Response,
+	})
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyResult is the result of checking the hash chain of the current audit log file.
+type VerifyResult struct {
+	Entries int `json:"entries"`
+	// UnchainedEntries are entries stored before hash chaining was introduced
+	UnchainedEntries int           `json:"unchained_entries"`
+	Valid            bool          `json:"valid"`
+	LastHash         string        `json:"last_hash"`
+	MerkleRoot       string        `json:"merkle_root"`
+	FirstInvalid     *InvalidEntry `json:"first_invalid,omitempty"`
+}
+
+type InvalidEntry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Reason    string    `json:"reason"`
+}
+
+// Verify checks the hash chain of the current audit log file. The first chained entry of a file links to the last
+// entry of the previous file, it is checked against the anchors only.
+func (a *AuditLog) Verify(ctx context.Context) (*VerifyResult, error) {
+	if a == nil || a.provider == nil {
+		return nil, errors2.APIError{
+			Message:    "audit log is not enabled",
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+
+	result := &VerifyResult{Valid: true}
+	var hashes []string
+	prevHash := ""
+	err := a.provider.Walk(ctx, func(e *Entry) error {
+		result.Entries++
+		if result.FirstInvalid != nil {
+			return nil
+		}
+
+		var reason string
+		switch {
+		case e.Hash == "" && len(hashes) == 0:
+			result.UnchainedEntries++
+			return nil
+		case e.Hash == "":
+			reason = "entry without hash after chained entries"
+		case len(hashes) > 0 && e.PrevHash != prevHash:
+			reason = fmt.Sprintf("previous hash %q doesn't match %q, entries were removed or reordered", e.PrevHash, prevHash)
+		case e.computeHash() != e.Hash:
+			reason = "hash doesn't match the content, the entry was modified"
+		}
+		if reason != "" {
+			result.Valid = false
+			result.FirstInvalid = &InvalidEntry{
+				Timestamp: e.Timestamp,
+				Reason:    reason,
+			}
+			return nil
+		}
+
+		hashes = append(hashes, e.Hash)
+		prevHash = e.Hash
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.LastHash = prevHash
+	if result.Valid {
+		result.MerkleRoot = merkleRoot(hashes)
+	}
+	return result, nil
+}
+
+// merkleRoot returns the root of a Merkle tree over the hex encoded entry hashes as defined by RFC 6962,
+// it allows to prove that single entries are covered by an anchor.
+func merkleRoot(hashes []string) string {
+	if len(hashes) == 0 {
+		return ""
+	}
+	leaves := make([][]byte, len(hashes))
+	for i, h := range hashes {
+		leaves[i] = hashNode(0x00, []byte(h))
+	}
+	return hex.EncodeToString(merkleTreeHash(leaves))
+}
+
+func merkleTreeHash(nodes [][]byte) []byte {
+	if len(nodes) == 1 {
+		return nodes[0]
+	}
+	// split at the largest power of two smaller than the number of nodes
+	k := 1
+	for k*2 < len(nodes) {
+		k *= 2
+	}
+	left := merkleTreeHash(nodes[:k])
+	right := merkleTreeHash(nodes[k:])
+	return hashNode(0x01, append(append([]byte{}, left...), right...))
+}
+
+func hashNode(prefix byte, data []byte) []byte {
+	sum := sha256.Sum256(append([]byte{prefix}, data...))
+	return sum[:]
+}
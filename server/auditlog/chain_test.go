@@ -0,0 +1,136 @@
+package auditlog
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/db/migration/auditlog"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	"github.com/IOTech17/neo-rport/server/auditlog/config"
+)
+
+func newChainedAuditLog(t *testing.T) (*AuditLog, *SQLiteProvider) {
+	db, err := sqlite.New(":memory:", auditlog.AssetNames(), auditlog.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	provider := &SQLiteProvider{db: db}
+	t.Cleanup(func() { provider.Close() })
+
+	return &AuditLog{
+		config:   config.Config{Enable: true},
+		provider: provider,
+	}, provider
+}
+
+// saveEntries saves entries one second apart, starting at the given second of 2022-01-01
+func saveEntries(auditLog *AuditLog, second int, ids ...string) {
+	for i, id := range ids {
+		e := auditLog.Entry(ApplicationLibraryScript, ActionCreate).WithID(id)
+		e.Timestamp = time.Date(2022, 1, 1, 0, 0, second+i, 0, time.UTC)
+		e.Username = "admin"
+		e.Save()
+	}
+}
+
+func TestHashChain(t *testing.T) {
+	ctx := context.Background()
+	auditLog, provider := newChainedAuditLog(t)
+	// entries stored before hash chaining
+	err := provider.Save(&Entry{Timestamp: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), Application: ApplicationAuthUser, Action: ActionSuccess})
+	require.NoError(t, err)
+
+	saveEntries(auditLog, 0, "1", "2", "3")
+
+	result, err := auditLog.Verify(ctx)
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.Equal(t, 4, result.Entries)
+	assert.Equal(t, 1, result.UnchainedEntries)
+	assert.Nil(t, result.FirstInvalid)
+
+	lastHash, err := provider.LastHash(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, lastHash, result.LastHash)
+	assert.Len(t, result.MerkleRoot, 64)
+
+	testCases := []struct {
+		name       string
+		tamper     string
+		wantReason string
+	}{
+		{
+			name:       "modified",
+			tamper:     "UPDATE auditlog SET username = 'other' WHERE affected_id = '2'",
+			wantReason: "hash doesn't match the content, the entry was modified",
+		},
+		{
+			name:       "removed",
+			tamper:     "DELETE FROM auditlog WHERE affected_id = '2'",
+			wantReason: `previous hash "` + prevHashOf(t, provider, "3") + `" doesn't match "` + hashOf(t, provider, "1") + `", entries were removed or reordered`,
+		},
+		{
+			name:       "duplicated",
+			tamper:     "INSERT INTO auditlog SELECT * FROM auditlog WHERE affected_id = '1'",
+			wantReason: `previous hash "` + prevHashOf(t, provider, "1") + `" doesn't match "` + hashOf(t, provider, "3") + `", entries were removed or reordered`,
+		},
+		{
+			name:       "inserted without hash",
+			tamper:     "INSERT INTO auditlog (timestamp, application, action, username, remote_ip, affected_id, client_id, client_hostname, request, response) VALUES ('2022-01-01 00:00:05+00:00', 'auth', 'success', '', '', '', '', '', '', '')",
+			wantReason: "entry without hash after chained entries",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			auditLog, provider := newChainedAuditLog(t)
+			saveEntries(auditLog, 0, "1", "2", "3")
+			_, err := provider.db.Exec(tc.tamper)
+			require.NoError(t, err)
+
+			result, err := auditLog.Verify(ctx)
+			require.NoError(t, err)
+			assert.False(t, result.Valid)
+			require.NotNil(t, result.FirstInvalid)
+			assert.Equal(t, tc.wantReason, result.FirstInvalid.Reason)
+			assert.Empty(t, result.MerkleRoot)
+		})
+	}
+}
+
+func hashOf(t *testing.T, provider *SQLiteProvider, id string) string {
+	var hash string
+	require.NoError(t, provider.db.Get(&hash, "SELECT hash FROM auditlog WHERE affected_id = ?", id))
+	return hash
+}
+
+func prevHashOf(t *testing.T, provider *SQLiteProvider, id string) string {
+	var hash string
+	require.NoError(t, provider.db.Get(&hash, "SELECT prev_hash FROM auditlog WHERE affected_id = ?", id))
+	return hash
+}
+
+func TestVerifyNotEnabled(t *testing.T) {
+	auditLog, err := New(nil, nil, "", config.Config{Enable: false}, DataSourceOptions)
+	require.NoError(t, err)
+
+	_, err = auditLog.Verify(context.Background())
+	assert.EqualError(t, err, "audit log is not enabled")
+}
+
+func TestMerkleRoot(t *testing.T) {
+	assert.Equal(t, "", merkleRoot(nil))
+
+	a := hashNode(0x00, []byte("a"))
+	b := hashNode(0x00, []byte("b"))
+	c := hashNode(0x00, []byte("c"))
+	ab := hashNode(0x01, append(append([]byte{}, a...), b...))
+	abc := hashNode(0x01, append(append([]byte{}, ab...), c...))
+
+	assert.Equal(t, merkleRoot([]string{"a"}), hex.EncodeToString(a))
+	assert.Equal(t, merkleRoot([]string{"a", "b"}), hex.EncodeToString(ab))
+	assert.Equal(t, merkleRoot([]string{"a", "b", "c"}), hex.EncodeToString(abc))
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"time"
 )
@@ -12,10 +13,31 @@ const (
 	RotationYearly  = "yearly"
 )
 
+// MinAnchorInterval is the minimum interval between exports of the audit log hash chain.
+const MinAnchorInterval = time.Minute
+
 type Config struct {
-	Enable           bool   `mapstructure:"enable_audit_log"`
-	UseIPObfuscation bool   `mapstructure:"use_ip_obfuscation"`
-	Rotation         string `mapstructure:"audit_log_rotation"`
+	Enable           bool          `mapstructure:"enable_audit_log"`
+	UseIPObfuscation bool          `mapstructure:"use_ip_obfuscation"`
+	Rotation         string        `mapstructure:"audit_log_rotation"`
+	AnchorInterval   time.Duration `mapstructure:"audit_log_anchor_interval"`
+	AnchorDir        string        `mapstructure:"audit_log_anchor_dir"`
+	AnchorS3         S3Config      `mapstructure:",squash"`
+}
+
+// S3Config defines a bucket with object lock enabled the anchors are additionally stored in.
+type S3Config struct {
+	Bucket string `mapstructure:"audit_log_anchor_s3_bucket"`
+	Region string `mapstructure:"audit_log_anchor_s3_region"`
+	// Endpoint is only needed for S3 compatible stores, by default the AWS endpoint of the region is used.
+	Endpoint        string `mapstructure:"audit_log_anchor_s3_endpoint"`
+	AccessKeyID     string `mapstructure:"audit_log_anchor_s3_access_key_id"`
+	SecretAccessKey string `mapstructure:"audit_log_anchor_s3_secret_access_key"`
+	RetentionDays   int    `mapstructure:"audit_log_anchor_s3_retention_days"`
+}
+
+func (c S3Config) Enabled() bool {
+	return c.Bucket != ""
 }
 
 func (c *Config) Validate() error {
@@ -30,6 +52,22 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid api.audit_log_rotation: %q", c.Rotation)
 	}
 
+	if c.AnchorInterval != 0 && c.AnchorInterval < MinAnchorInterval {
+		return fmt.Errorf("api.audit_log_anchor_interval must be at least %s", MinAnchorInterval)
+	}
+
+	if c.AnchorS3.Enabled() {
+		if c.AnchorInterval == 0 {
+			return errors.New("api.audit_log_anchor_s3_bucket requires api.audit_log_anchor_interval to be set")
+		}
+		if c.AnchorS3.Region == "" || c.AnchorS3.AccessKeyID == "" || c.AnchorS3.SecretAccessKey == "" {
+			return errors.New("api.audit_log_anchor_s3_bucket requires the region, access key id and secret access key to be set")
+		}
+		if c.AnchorS3.RetentionDays <= 0 {
+			return errors.New("api.audit_log_anchor_s3_retention_days must be positive")
+		}
+	}
+
 	return nil
 }
 
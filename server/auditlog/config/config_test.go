@@ -3,6 +3,7 @@ package config
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -34,6 +35,46 @@ func TestConfigValidate(t *testing.T) {
 				Rotation: "invalid",
 			},
 			Want: errors.New(`invalid api.audit_log_rotation: "invalid"`),
+		}, {
+			Name: "anchor interval too short",
+			Config: Config{
+				Enable:         true,
+				Rotation:       RotationMonthly,
+				AnchorInterval: time.Second,
+			},
+			Want: errors.New("api.audit_log_anchor_interval must be at least 1m0s"),
+		}, {
+			Name: "s3 anchor",
+			Config: Config{
+				Enable:         true,
+				Rotation:       RotationMonthly,
+				AnchorInterval: time.Hour,
+				AnchorS3: S3Config{
+					Bucket:          "audit",
+					Region:          "eu-central-1",
+					AccessKeyID:     "key-id",
+					SecretAccessKey: "secret",
+					RetentionDays:   365,
+				},
+			},
+			Want: nil,
+		}, {
+			Name: "s3 anchor without interval",
+			Config: Config{
+				Enable:   true,
+				Rotation: RotationMonthly,
+				AnchorS3: S3Config{Bucket: "audit", Region: "eu-central-1", AccessKeyID: "key-id", SecretAccessKey: "secret", RetentionDays: 365},
+			},
+			Want: errors.New("api.audit_log_anchor_s3_bucket requires api.audit_log_anchor_interval to be set"),
+		}, {
+			Name: "s3 anchor without credentials",
+			Config: Config{
+				Enable:         true,
+				Rotation:       RotationMonthly,
+				AnchorInterval: time.Hour,
+				AnchorS3:       S3Config{Bucket: "audit", Region: "eu-central-1", RetentionDays: 365},
+			},
+			Want: errors.New("api.audit_log_anchor_s3_bucket requires the region, access key id and secret access key to be set"),
 		},
 	}
 
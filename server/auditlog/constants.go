@@ -8,26 +8,74 @@ const (
 	ActionExecuteDone  = "execute.done"
 	ActionSuccess      = "success"
 	ActionFailed       = "failed"
+	ActionConfirm      = "confirm"
+	ActionAbort        = "abort"
+	ActionAcknowledge  = "acknowledge"
+	ActionEscalate     = "escalate"
+	ActionApprove      = "approve"
+	ActionDeny         = "deny"
+	ActionClose        = "close"
+	ActionRevert       = "revert"
+	ActionImport       = "import"
+	ActionExport       = "export"
 )
 
 const (
-	ApplicationAuthUser        = "auth.user"
-	ApplicationAuthUserMe      = "auth.user.me"
-	ApplicationAuthUserMeToken = "auth.user.me.token" //nolint:gosec
-	ApplicationAuthUserTotP    = "auth.user.totp"
-	ApplicationAuthUserGroup   = "auth.user.group"
-	ApplicationAuthAPISession  = "auth.api.session"
-	ApplicationAuthAPISessions = "auth.api.sessions"
-	ApplicationClient          = "client"
-	ApplicationClientACL       = "client.acl"
-	ApplicationClientAuth      = "client.auth"
-	ApplicationClientGroup     = "client.group"
-	ApplicationClientTunnel    = "client.tunnel"
-	ApplicationClientCommand   = "client.command"
-	ApplicationClientScript    = "client.script"
-	ApplicationLibraryCommand  = "library.command"
-	ApplicationLibraryScript   = "library.script"
-	ApplicationVault           = "vault"
-	ApplicationSchedule        = "schedule"
-	ApplicationUploads         = "uploads"
+	ApplicationAuthUser              = "auth.user"
+	ApplicationAuthUserMe            = "auth.user.me"
+	ApplicationAuthUserMeToken       = "auth.user.me.token" //nolint:gosec
+	ApplicationAuthUserTotP          = "auth.user.totp"
+	ApplicationAuthUserGroup         = "auth.user.group"
+	ApplicationAuthUserSessionPolicy = "auth.user.session-policy"
+	ApplicationAuthUserImpersonation = "auth.user.impersonation"
+	ApplicationAuthBrokerGrant       = "auth.broker-grant"
+	ApplicationAuthAccessRequest     = "auth.access-request"
+	ApplicationAuthTripwire          = "auth.tripwire"
+	ApplicationAuthBreakGlass        = "auth.break-glass"
+	ApplicationAuthLoginPolicy       = "auth.login-policy"
+	ApplicationAuthBan               = "auth.ban"
+	ApplicationAuthAPISession        = "auth.api.session"
+	ApplicationAuthAPISessions       = "auth.api.sessions"
+	ApplicationClient                = "client"
+	ApplicationClientACL             = "client.acl"
+	ApplicationClientAuth            = "client.auth"
+	ApplicationClientInstaller       = "client.installer"
+	ApplicationClientGroup           = "client.group"
+	ApplicationClientGroupOverride   = "client.group.access-override"
+	ApplicationClientTunnel          = "client.tunnel"
+	ApplicationClientTunnelTransfer  = "client.tunnel.transfer"
+	ApplicationClientTunnelSession   = "client.tunnel.session"
+	ApplicationClientScreenshot      = "client.screenshot"
+	ApplicationClientChat            = "client.chat"
+	ApplicationClientDiscovery       = "client.discovery"
+	ApplicationClientOnboarding      = "client.onboarding"
+	ApplicationClientConsent         = "client.consent"
+	ApplicationClientQuarantine      = "client.quarantine"
+	ApplicationClientIdentity        = "client.identity"
+	ApplicationClientExternal        = "client.external"
+	ApplicationClientAgentless       = "client.agentless"
+	ApplicationClientSerialConsole   = "client.serial-console"
+	ApplicationClientIndustrial      = "client.industrial"
+	ApplicationClientMeshTunnel      = "client.tunnel.mesh"
+	ApplicationClientCommand         = "client.command"
+	ApplicationClientScript          = "client.script"
+	ApplicationLibraryCommand        = "library.command"
+	ApplicationLibraryScript         = "library.script"
+	ApplicationLibraryArtifact       = "library.artifact"
+	ApplicationVault                 = "vault"
+	ApplicationVaultCheckout         = "vault.checkout"
+	ApplicationSchedule              = "schedule"
+	ApplicationUploads               = "uploads"
+	ApplicationNotificationDigest    = "notification.digest"
+	ApplicationAlertingGroupRule     = "alerting.group-rule"
+	ApplicationAlertingEdgeRule      = "alerting.edge-rule"
+	ApplicationAlertingRemediation   = "alerting.remediation"
+	ApplicationAlertingDependency    = "alerting.client-dependency"
+	ApplicationAlertingProblem       = "alerting.problem"
+	ApplicationUsagePeriod           = "usage.period"
+	ApplicationBranding              = "branding"
+	ApplicationServerKillSwitch      = "server.kill-switch"
+	ApplicationServerChaos           = "server.chaos"
+	ApplicationChange                = "change"
+	ApplicationBulk                  = "bulk"
 )
@@ -14,6 +14,7 @@ import (
 type Entry struct {
 	Timestamp      time.Time `db:"timestamp" json:"timestamp"`
 	Username       string    `db:"username" json:"username"`
+	ImpersonatedBy string    `db:"impersonated_by" json:"impersonated_by"`
 	RemoteIP       string    `db:"remote_ip" json:"remote_ip"`
 	Application    string    `db:"application" json:"application"`
 	Action         string    `db:"action" json:"action"`
@@ -22,6 +23,8 @@ type Entry struct {
 	ClientHostName string    `db:"client_hostname" json:"client_hostname"`
 	Request        string    `db:"request" json:"request"`
 	Response       string    `db:"response" json:"response"`
+	PrevHash       string    `db:"prev_hash" json:"prev_hash"`
+	Hash           string    `db:"hash" json:"hash"`
 
 	al *AuditLog
 }
@@ -41,11 +44,21 @@ func (e *Entry) WithHTTPRequest(req *http.Request) *Entry {
 	}
 
 	e.Username = api.GetUser(req.Context(), e.al.logger)
+	e.ImpersonatedBy = api.GetImpersonator(req.Context())
 	e.RemoteIP = chshare.RemoteIP(req)
 
 	return e
 }
 
+func (e *Entry) WithUsername(username string) *Entry {
+	if e == nil {
+		return e
+	}
+
+	e.Username = username
+	return e
+}
+
 func (e *Entry) WithRequest(request interface{}) *Entry {
 	if e == nil {
 		return e
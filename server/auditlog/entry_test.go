@@ -63,9 +63,21 @@ func TestWithHTTPRequest(t *testing.T) {
 	e := emptyEntry().WithHTTPRequest(req)
 
 	assert.Equal(t, "test-user", e.Username)
+	assert.Equal(t, "", e.ImpersonatedBy)
 	assert.Equal(t, "192.0.2.1", e.RemoteIP)
 }
 
+func TestWithHTTPRequestImpersonated(t *testing.T) {
+	ctx := api.WithImpersonator(api.WithUser(context.Background(), "test-user"), "admin")
+	req := httptest.NewRequest("GET", "/", nil)
+	req = req.WithContext(ctx)
+
+	e := emptyEntry().WithHTTPRequest(req)
+
+	assert.Equal(t, "test-user", e.Username)
+	assert.Equal(t, "admin", e.ImpersonatedBy)
+}
+
 func TestWithRequest(t *testing.T) {
 	e := emptyEntry().WithRequest(map[string]interface{}{
 		"k1": "v1",
@@ -199,4 +211,15 @@ func (p *mockProvider) List(ctx context.Context, opts *query.ListOptions) ([]*En
 func (p *mockProvider) Count(ctx context.Context, opts *query.ListOptions) (int, error) {
 	return 0, nil
 }
+func (p *mockProvider) LastHash(ctx context.Context) (string, error) {
+	return "", nil
+}
+func (p *mockProvider) Walk(ctx context.Context, fn func(e *Entry) error) error {
+	for i := range p.entries {
+		if err := fn(&p.entries[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 func (p mockProvider) Close() error { return nil }
@@ -117,6 +117,16 @@ func (r *RotationProvider) Count(ctx context.Context, l *query.ListOptions) (int
 	defer r.mtx.RUnlock()
 	return r.sqlite.Count(ctx, l)
 }
+func (r *RotationProvider) LastHash(ctx context.Context) (string, error) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	return r.sqlite.LastHash(ctx)
+}
+func (r *RotationProvider) Walk(ctx context.Context, fn func(e *Entry) error) error {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+	return r.sqlite.Walk(ctx, fn)
+}
 func (r *RotationProvider) Close() error {
 	r.mtx.Lock()
 	defer r.mtx.Unlock()
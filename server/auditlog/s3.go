@@ -0,0 +1,129 @@
+package auditlog
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5" //nolint:gosec // required by S3 for object lock uploads, not used for security
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/IOTech17/neo-rport/server/auditlog/config"
+)
+
+const s3RequestTimeout = 30 * time.Second
+
+// s3AnchorStore uploads anchors to a bucket with object lock enabled. Objects are stored in compliance mode,
+// nobody can delete or overwrite them until the retention ends, not even the root account.
+type s3AnchorStore struct {
+	config     config.S3Config
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+func newS3AnchorStore(cfg config.S3Config) *s3AnchorStore {
+	return &s3AnchorStore{
+		config:     cfg,
+		httpClient: &http.Client{Timeout: s3RequestTimeout},
+		now:        time.Now,
+	}
+}
+
+func (s *s3AnchorStore) Put(ctx context.Context, name string, content []byte) error {
+	endpoint := s.config.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", s.config.Region)
+	}
+	objectURL := strings.TrimSuffix(endpoint, "/") + "/" + url.PathEscape(s.config.Bucket) + "/" + url.PathEscape(name)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+
+	now := s.now().UTC()
+	md5Sum := md5.Sum(content) //nolint:gosec
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(md5Sum[:]))
+	req.Header.Set("X-Amz-Object-Lock-Mode", "COMPLIANCE")
+	req.Header.Set("X-Amz-Object-Lock-Retain-Until-Date", now.AddDate(0, 0, s.config.RetentionDays).Format(time.RFC3339))
+	s.sign(req, content, now)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload audit log anchor to s3: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("failed to upload audit log anchor to s3: %s: %s", resp.Status, body)
+	}
+	return nil
+}
+
+// sign adds the AWS signature version 4 to the request.
+func (s *s3AnchorStore) sign(req *http.Request, payload []byte, now time.Time) {
+	payloadHash := sha256Hex(payload)
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + s.config.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+	signature := hex.EncodeToString(hmacSHA256(signingKey(s.config.SecretAccessKey, date, s.config.Region, "s3"), stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.config.AccessKeyID, scope, signedHeaders, signature,
+	))
+}
+
+func signingKey(secret, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
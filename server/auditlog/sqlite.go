@@ -2,6 +2,7 @@ package auditlog
 
 import (
 	"context"
+	"database/sql"
 	"path"
 	"time"
 
@@ -38,6 +39,7 @@ func (p *SQLiteProvider) Save(e *Entry) error {
 		`INSERT INTO auditlog (
 			timestamp,
 			username,
+			impersonated_by,
 			remote_ip,
 			application,
 			action,
@@ -45,10 +47,13 @@ func (p *SQLiteProvider) Save(e *Entry) error {
 			client_id,
 			client_hostname,
 			request,
-			response
+			response,
+			prev_hash,
+			hash
 		) VALUES (
 			:timestamp,
 			:username,
+			:impersonated_by,
 			:remote_ip,
 			:application,
 			:action,
@@ -56,7 +61,9 @@ func (p *SQLiteProvider) Save(e *Entry) error {
 			:client_id,
 			:client_hostname,
 			:request,
-			:response
+			:response,
+			:prev_hash,
+			:hash
 		)`,
 		e,
 	)
@@ -105,6 +112,36 @@ func (p *SQLiteProvider) OldestTimestamp(ctx context.Context) (time.Time, error)
 	return ts, nil
 }
 
+// LastHash returns the hash of the last stored entry, it's empty if there are no chained entries.
+func (p *SQLiteProvider) LastHash(ctx context.Context) (string, error) {
+	var hash string
+	err := p.db.GetContext(ctx, &hash, "SELECT hash FROM auditlog ORDER BY rowid DESC LIMIT 1")
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return hash, err
+}
+
+// Walk calls fn for all entries in the order they were stored.
+func (p *SQLiteProvider) Walk(ctx context.Context, fn func(e *Entry) error) error {
+	rows, err := p.db.QueryxContext(ctx, "SELECT * FROM auditlog ORDER BY rowid")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e := &Entry{}
+		if err := rows.StructScan(e); err != nil {
+			return err
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
 func (p *SQLiteProvider) Close() error {
 	return p.db.Close()
 }
@@ -22,6 +22,7 @@ func TestSqliteSave(t *testing.T) {
 	e := &Entry{
 		Timestamp:      time.Date(2021, 10, 19, 13, 57, 58, 0, time.UTC),
 		Username:       "admin",
+		ImpersonatedBy: "support",
 		RemoteIP:       "192.168.55.23",
 		Application:    ApplicationLibraryCommand,
 		Action:         ActionCreate,
@@ -30,6 +31,8 @@ func TestSqliteSave(t *testing.T) {
 		ClientHostName: "127.0.0.1",
 		Request:        `{"k1": "v1"}`,
 		Response:       `{"k1": "v1"}`,
+		PrevHash:       "8a1f0d5e",
+		Hash:           "4c2b9e7d",
 	}
 	err = dbProv.Save(e)
 	require.NoError(t, err)
@@ -38,6 +41,7 @@ func TestSqliteSave(t *testing.T) {
 		{
 			"timestamp":       e.Timestamp,
 			"username":        e.Username,
+			"impersonated_by": e.ImpersonatedBy,
 			"remote_ip":       e.RemoteIP,
 			"application":     e.Application,
 			"action":          e.Action,
@@ -46,6 +50,8 @@ func TestSqliteSave(t *testing.T) {
 			"client_hostname": e.ClientHostName,
 			"request":         e.Request,
 			"response":        e.Response,
+			"prev_hash":       e.PrevHash,
+			"hash":            e.Hash,
 		},
 	}
 	q := "SELECT * FROM auditlog"
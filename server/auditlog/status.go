@@ -6,6 +6,9 @@ type Status struct {
 }
 
 func (a *AuditLog) Status() Status {
+	if a == nil {
+		return Status{}
+	}
 	return Status{
 		Enabled:  a.config.Enable,
 		Rotation: a.config.Rotation,
@@ -0,0 +1,191 @@
+// Package authzhook asks an external policy engine like Open Policy Agent whether an API request is allowed. It can
+// only deny requests, the built-in permission checks still apply to the allowed ones.
+package authzhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const DefaultTimeout = 2 * time.Second
+
+const (
+	ActionRead   = "read"
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+type Config struct {
+	// URL of the policy decision, e.g. the data API of an OPA sidecar http://127.0.0.1:8181/v1/data/rport/authz
+	URL     string        `mapstructure:"url"`
+	Timeout time.Duration `mapstructure:"timeout"`
+	// FailOpen allows requests if the policy engine fails, by default they are denied.
+	FailOpen bool `mapstructure:"fail_open"`
+	// Methods limits the requests to ask for, all requests if empty.
+	Methods []string `mapstructure:"methods"`
+}
+
+func (c Config) Enabled() bool {
+	return c.URL != ""
+}
+
+func (c *Config) Validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	u, err := url.Parse(c.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url %q must be an absolute http or https url", c.URL)
+	}
+	if c.Timeout < 0 {
+		return errors.New("timeout must not be negative")
+	}
+	if c.Timeout == 0 {
+		c.Timeout = DefaultTimeout
+	}
+	for i, m := range c.Methods {
+		c.Methods[i] = strings.ToUpper(m)
+	}
+	return nil
+}
+
+// Input is the request context sent to the policy engine.
+type Input struct {
+	User     string            `json:"user"`
+	Groups   []string          `json:"groups"`
+	Method   string            `json:"method"`
+	Path     string            `json:"path"`
+	Route    string            `json:"route"`
+	Vars     map[string]string `json:"vars"`
+	Query    url.Values        `json:"query"`
+	Resource string            `json:"resource"`
+	Action   string            `json:"action"`
+	RemoteIP string            `json:"remote_ip"`
+	Time     time.Time         `json:"time"`
+	// Weekday and Hour are in the local time of the server, so policies don't need to parse the time.
+	Weekday string `json:"weekday"`
+	Hour    int    `json:"hour"`
+	// APIVersion is the version of the requested API, e.g. 2 for /api/v2
+	APIVersion int `json:"api_version"`
+}
+
+// Decision is either a plain boolean or an object with allow and an optional reason.
+type Decision struct {
+	Allow  bool   `json:"allow"`
+	Reason string `json:"reason,omitempty"`
+}
+
+func (d *Decision) UnmarshalJSON(b []byte) error {
+	var allow bool
+	if err := json.Unmarshal(b, &allow); err == nil {
+		*d = Decision{Allow: allow}
+		return nil
+	}
+	type decision Decision
+	return json.Unmarshal(b, (*decision)(d))
+}
+
+// ActionFromMethod maps the http method to the action on the resource.
+func ActionFromMethod(method string) string {
+	switch method {
+	case http.MethodPost:
+		return ActionCreate
+	case http.MethodPut, http.MethodPatch:
+		return ActionUpdate
+	case http.MethodDelete:
+		return ActionDelete
+	}
+	return ActionRead
+}
+
+// ResourceFromRoute returns the first segment of the route after the prefix, e.g. "clients" for
+// "/clients/{client_id}/tunnels".
+func ResourceFromRoute(route string) string {
+	resource, _, _ := strings.Cut(strings.TrimPrefix(route, "/"), "/")
+	return resource
+}
+
+type Hook struct {
+	config Config
+	client *http.Client
+}
+
+// New returns nil if no url is configured, a nil Hook allows all requests.
+func New(config Config) *Hook {
+	if !config.Enabled() {
+		return nil
+	}
+	return &Hook{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+	}
+}
+
+// Applies returns false for requests the policy engine is not asked for.
+func (h *Hook) Applies(method string) bool {
+	if h == nil {
+		return false
+	}
+	if len(h.config.Methods) == 0 {
+		return true
+	}
+	for _, m := range h.config.Methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// Decide asks the policy engine. If it fails, the request is denied unless fail_open is set, the error is returned
+// in both cases for logging. An undefined result denies the request.
+func (h *Hook) Decide(ctx context.Context, input Input) (Decision, error) {
+	decision, err := h.query(ctx, input)
+	if err != nil {
+		if h.config.FailOpen {
+			return Decision{Allow: true}, err
+		}
+		return Decision{Reason: "the authorization policy could not be evaluated"}, err
+	}
+	return decision, nil
+}
+
+func (h *Hook) query(ctx context.Context, input Input) (Decision, error) {
+	body, err := json.Marshal(map[string]Input{"input": input})
+	if err != nil {
+		return Decision{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return Decision{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return Decision{}, fmt.Errorf("failed to query the authorization policy: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Decision{}, fmt.Errorf("authorization policy returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Result *Decision `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return Decision{}, fmt.Errorf("invalid response of the authorization policy: %w", err)
+	}
+	if result.Result == nil {
+		return Decision{Reason: "the authorization policy is undefined for the request"}, nil
+	}
+	return *result.Result, nil
+}
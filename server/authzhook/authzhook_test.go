@@ -0,0 +1,111 @@
+package authzhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigValidate(t *testing.T) {
+	c := Config{}
+	assert.NoError(t, c.Validate())
+
+	c = Config{URL: "/v1/data/rport"}
+	assert.EqualError(t, c.Validate(), `url "/v1/data/rport" must be an absolute http or https url`)
+
+	c = Config{URL: "http://127.0.0.1:8181/v1/data/rport/authz", Methods: []string{"post"}}
+	require.NoError(t, c.Validate())
+	assert.Equal(t, DefaultTimeout, c.Timeout)
+	assert.Equal(t, []string{"POST"}, c.Methods)
+}
+
+func TestActionAndResource(t *testing.T) {
+	assert.Equal(t, ActionRead, ActionFromMethod(http.MethodGet))
+	assert.Equal(t, ActionCreate, ActionFromMethod(http.MethodPost))
+	assert.Equal(t, ActionUpdate, ActionFromMethod(http.MethodPatch))
+	assert.Equal(t, ActionDelete, ActionFromMethod(http.MethodDelete))
+
+	assert.Equal(t, "clients", ResourceFromRoute("/clients/{client_id}/tunnels"))
+	assert.Equal(t, "status", ResourceFromRoute("/status"))
+	assert.Equal(t, "", ResourceFromRoute(""))
+}
+
+func TestApplies(t *testing.T) {
+	var hook *Hook
+	assert.False(t, hook.Applies(http.MethodGet))
+
+	hook = New(Config{URL: "http://opa", Methods: []string{http.MethodPost}})
+	assert.True(t, hook.Applies(http.MethodPost))
+	assert.False(t, hook.Applies(http.MethodGet))
+
+	hook = New(Config{URL: "http://opa"})
+	assert.True(t, hook.Applies(http.MethodGet))
+}
+
+func TestDecide(t *testing.T) {
+	var received Input
+	response := ""
+	status := http.StatusOK
+	opa := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Input Input `json:"input"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		received = body.Input
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(response))
+	}))
+	defer opa.Close()
+
+	input := Input{
+		User:     "admin",
+		Groups:   []string{"Administrators"},
+		Method:   http.MethodPost,
+		Route:    "/clients/{client_id}/tunnels",
+		Resource: "clients",
+		Action:   ActionCreate,
+		Time:     time.Date(2026, 1, 1, 18, 30, 0, 0, time.UTC),
+		Hour:     18,
+	}
+
+	testCases := []struct {
+		name     string
+		response string
+		status   int
+		failOpen bool
+		expected Decision
+		err      bool
+	}{
+		{name: "boolean", response: `{"result":true}`, expected: Decision{Allow: true}},
+		{name: "object", response: `{"result":{"allow":false,"reason":"no tunnels after 18:00"}}`, expected: Decision{Reason: "no tunnels after 18:00"}},
+		{name: "undefined", response: `{}`, expected: Decision{Reason: "the authorization policy is undefined for the request"}},
+		{name: "error", status: http.StatusInternalServerError, expected: Decision{Reason: "the authorization policy could not be evaluated"}, err: true},
+		{name: "error fail open", status: http.StatusInternalServerError, failOpen: true, expected: Decision{Allow: true}, err: true},
+		{name: "invalid", response: `{"result":"yes"}`, expected: Decision{Reason: "the authorization policy could not be evaluated"}, err: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			response = tc.response
+			status = tc.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			hook := New(Config{URL: opa.URL, Timeout: time.Second, FailOpen: tc.failOpen})
+
+			decision, err := hook.Decide(context.Background(), input)
+			if tc.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expected, decision)
+			assert.Equal(t, input, received)
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package chserver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/security"
+)
+
+const (
+	banHookTimeout     = 30 * time.Second
+	banHookStderrLimit = 1024
+
+	banListenerAPI    = "api"
+	banListenerClient = "client"
+)
+
+// newBanHook returns a hook running the script on IP bans, e.g. to enforce the bans by the firewall. The ban is passed
+// as environment variables.
+func newBanHook(script, listener string, l *logger.Logger) security.BanHook {
+	return func(ip string, banned bool, banExpiry time.Time) {
+		ctx, cancel := context.WithTimeout(context.Background(), banHookTimeout)
+		defer cancel()
+
+		if err := runBanHook(ctx, script, banHookEnv(ip, listener, banned, banExpiry, time.Now())); err != nil {
+			l.Errorf("Failed to run ban hook %q for %s: %v", script, ip, err)
+		}
+	}
+}
+
+func banHookEnv(ip, listener string, banned bool, banExpiry, now time.Time) []string {
+	action := "ban"
+	if !banned {
+		action = "unban"
+	}
+	seconds := int64(banExpiry.Sub(now).Seconds())
+	if seconds < 0 {
+		seconds = 0
+	}
+	return []string{
+		"RPORT_BAN_ACTION=" + action,
+		"RPORT_BAN_IP=" + ip,
+		"RPORT_BAN_LISTENER=" + listener,
+		"RPORT_BAN_EXPIRY=" + banExpiry.UTC().Format(time.RFC3339),
+		fmt.Sprintf("RPORT_BAN_SECONDS=%d", seconds),
+	}
+}
+
+func runBanHook(ctx context.Context, script string, env []string) error {
+	stderr := &bytes.Buffer{}
+	cmd := exec.CommandContext(ctx, script) //nolint:gosec
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stderr = stderr
+
+	if err := cmd.Run(); err != nil {
+		if stderr.Len() > 0 {
+			return fmt.Errorf("%s %s", err, stderr.Next(banHookStderrLimit))
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package chserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBanHookEnv(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	assert.Equal(t, []string{
+		"RPORT_BAN_ACTION=ban",
+		"RPORT_BAN_IP=192.0.2.10",
+		"RPORT_BAN_LISTENER=client",
+		"RPORT_BAN_EXPIRY=2026-01-02T04:04:05Z",
+		"RPORT_BAN_SECONDS=3600",
+	}, banHookEnv("192.0.2.10", banListenerClient, true, now.Add(time.Hour), now))
+
+	assert.Equal(t, []string{
+		"RPORT_BAN_ACTION=unban",
+		"RPORT_BAN_IP=192.0.2.10",
+		"RPORT_BAN_LISTENER=api",
+		"RPORT_BAN_EXPIRY=2026-01-02T03:04:05Z",
+		"RPORT_BAN_SECONDS=0",
+	}, banHookEnv("192.0.2.10", banListenerAPI, false, now, now.Add(time.Minute)))
+}
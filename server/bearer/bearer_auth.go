@@ -25,6 +25,8 @@ type AppTokenClaims struct {
 	Username  string  `json:"username,omitempty"`
 	SessionID int64   `json:"sessionID,omitempty"`
 	Scopes    []Scope `json:"scopes,omitempty"`
+	// ImpersonatedBy is the admin acting as the user, empty for regular logins.
+	ImpersonatedBy string `json:"impersonatedBy,omitempty"`
 	jwt.StandardClaims
 }
 
@@ -44,6 +46,11 @@ var ScopesAllExcluding2FaCheck = []Scope{
 		Method:  "*",
 		Exclude: true,
 	},
+	{
+		URI:     routes.V2RoutesPrefix + routes.Verify2FaRoute,
+		Method:  "*",
+		Exclude: true,
+	},
 }
 
 var ScopesTotPCreateOnly = []Scope{
@@ -51,6 +58,10 @@ var ScopesTotPCreateOnly = []Scope{
 		URI:    routes.AllRoutesPrefix + routes.TotPRoutes,
 		Method: http.MethodPost,
 	},
+	{
+		URI:    routes.V2RoutesPrefix + routes.TotPRoutes,
+		Method: http.MethodPost,
+	},
 }
 
 var Scopes2FaCheckOnly = []Scope{
@@ -58,6 +69,10 @@ var Scopes2FaCheckOnly = []Scope{
 		URI:    routes.AllRoutesPrefix + routes.Verify2FaRoute,
 		Method: http.MethodPost,
 	},
+	{
+		URI:    routes.V2RoutesPrefix + routes.Verify2FaRoute,
+		Method: http.MethodPost,
+	},
 }
 
 type TokenContext struct {
@@ -83,6 +98,37 @@ func CreateAuthToken(
 	scopes []Scope,
 	userAgent string,
 	remoteAddress string,
+) (string, error) {
+	return createToken(ctx, sessionUpdater, JWTSecret, lifetime, username, "", scopes, userAgent, remoteAddress)
+}
+
+// CreateImpersonationToken creates a token for a new session of the user, marked as started by the given admin.
+func CreateImpersonationToken(
+	ctx context.Context,
+	sessionUpdater APISessionUpdater,
+	JWTSecret string,
+	lifetime time.Duration,
+	username string,
+	impersonatedBy string,
+	userAgent string,
+	remoteAddress string,
+) (string, error) {
+	if impersonatedBy == "" {
+		return "", errors.New("impersonating username cannot be empty")
+	}
+	return createToken(ctx, sessionUpdater, JWTSecret, lifetime, username, impersonatedBy, ScopesAllExcluding2FaCheck, userAgent, remoteAddress)
+}
+
+func createToken(
+	ctx context.Context,
+	sessionUpdater APISessionUpdater,
+	JWTSecret string,
+	lifetime time.Duration,
+	username string,
+	impersonatedBy string,
+	scopes []Scope,
+	userAgent string,
+	remoteAddress string,
 ) (string, error) {
 	if username == "" {
 		return "", errors.New("username cannot be empty")
@@ -108,7 +154,8 @@ func CreateAuthToken(
 		StandardClaims: jwt.StandardClaims{
 			Id: strconv.FormatUint(rand.Uint64(), 10),
 		},
-		Scopes: scopes,
+		Scopes:         scopes,
+		ImpersonatedBy: impersonatedBy,
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	tokenStr, err := token.SignedString([]byte(JWTSecret))
@@ -0,0 +1,164 @@
+package branding
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	email2 "github.com/IOTech17/neo-rport/share/email"
+)
+
+const (
+	DefaultProductName = "RPort"
+	// SubjectTag is the tag of notification subjects, it's replaced by the product name
+	SubjectTag = "[rport]"
+
+	maxNameLength   = 64
+	maxFooterLength = 2000
+)
+
+// Branding is the white-label identity of the server shown to users and in notifications. Empty values use the
+// defaults.
+type Branding struct {
+	ProductName string `json:"product_name"`
+	LogoURL     string `json:"logo_url"`
+	// EmailFooter is appended to mails, as HTML to HTML mails
+	EmailFooter string `json:"email_footer"`
+	SenderName  string `json:"sender_name"`
+	// SenderEmail replaces the sender email of the smtp config, the smtp server must accept it
+	SenderEmail string `json:"sender_email"`
+}
+
+// Public is the part of the branding shown before login.
+type Public struct {
+	ProductName string `json:"product_name"`
+	LogoURL     string `json:"logo_url"`
+}
+
+func (b Branding) Validate() error {
+	if err := validateName("product_name", b.ProductName); err != nil {
+		return err
+	}
+	if err := validateName("sender_name", b.SenderName); err != nil {
+		return err
+	}
+	if b.LogoURL != "" {
+		u, err := url.Parse(b.LogoURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return errors.New("logo_url must be an absolute http or https URL")
+		}
+	}
+	if len(b.EmailFooter) > maxFooterLength {
+		return fmt.Errorf("email_footer must not be longer than %d characters", maxFooterLength)
+	}
+	if b.SenderEmail != "" {
+		if err := email2.Validate(b.SenderEmail); err != nil {
+			return fmt.Errorf("sender_email: %v", err)
+		}
+	}
+	return nil
+}
+
+func validateName(field, value string) error {
+	if len(value) > maxNameLength {
+		return fmt.Errorf("%s must not be longer than %d characters", field, maxNameLength)
+	}
+	if strings.ContainsAny(value, "\r\n") {
+		return fmt.Errorf("%s must not contain line breaks", field)
+	}
+	return nil
+}
+
+// GetProductName returns the product name or the default.
+func (b Branding) GetProductName() string {
+	if b.ProductName == "" {
+		return DefaultProductName
+	}
+	return b.ProductName
+}
+
+func (b Branding) Public() Public {
+	return Public{
+		ProductName: b.GetProductName(),
+		LogoURL:     b.LogoURL,
+	}
+}
+
+// Subject replaces the tag of a notification subject by the product name.
+func (b Branding) Subject(subject string) string {
+	if b.ProductName == "" || !strings.HasPrefix(subject, SubjectTag) {
+		return subject
+	}
+	return "[" + b.ProductName + "]" + strings.TrimPrefix(subject, SubjectTag)
+}
+
+// Sender returns the sender address of mails, defaultEmail is used if no sender email is set.
+func (b Branding) Sender(defaultEmail string) string {
+	address := b.SenderEmail
+	if address == "" {
+		address = defaultEmail
+	}
+	if b.SenderName == "" {
+		return address
+	}
+	return (&mail.Address{Name: b.SenderName, Address: address}).String()
+}
+
+// Provider keeps the branding in a JSON file. A nil Provider returns the defaults.
+type Provider struct {
+	path string
+
+	mu       sync.RWMutex
+	branding Branding
+}
+
+// NewProvider loads the branding from the file at the path, a missing file is the default branding.
+func NewProvider(path string) (*Provider, error) {
+	p := &Provider{
+		path: path,
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return p, nil
+		}
+		return nil, fmt.Errorf("failed to read branding: %w", err)
+	}
+	if err := json.Unmarshal(content, &p.branding); err != nil {
+		return nil, fmt.Errorf("failed to decode branding %q: %w", path, err)
+	}
+	return p, nil
+}
+
+func (p *Provider) Get() Branding {
+	if p == nil {
+		return Branding{}
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.branding
+}
+
+// Set validates and saves the branding.
+func (p *Provider) Set(b Branding) error {
+	if err := b.Validate(); err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := os.WriteFile(p.path, content, 0600); err != nil {
+		return fmt.Errorf("failed to save branding: %w", err)
+	}
+	p.branding = b
+	return nil
+}
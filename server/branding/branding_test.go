@@ -0,0 +1,97 @@
+package branding
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Branding Branding
+		Error    string
+	}{
+		{
+			Name:     "defaults",
+			Branding: Branding{},
+		},
+		{
+			Name: "valid",
+			Branding: Branding{
+				ProductName: "Acme Remote",
+				LogoURL:     "https://acme.example.com/logo.png",
+				EmailFooter: "Acme Ltd.",
+				SenderName:  "Acme Support",
+				SenderEmail: "support@acme.example.com",
+			},
+		},
+		{
+			Name:     "line break in product name",
+			Branding: Branding{ProductName: "Acme\r\nBcc: all@example.com"},
+			Error:    "product_name must not contain line breaks",
+		},
+		{
+			Name:     "relative logo url",
+			Branding: Branding{LogoURL: "/logo.png"},
+			Error:    "logo_url must be an absolute http or https URL",
+		},
+		{
+			Name:     "javascript logo url",
+			Branding: Branding{LogoURL: "javascript:alert(1)"},
+			Error:    "logo_url must be an absolute http or https URL",
+		},
+		{
+			Name:     "invalid sender email",
+			Branding: Branding{SenderEmail: "support"},
+			Error:    "sender_email: ",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			err := tc.Branding.Validate()
+			if tc.Error == "" {
+				assert.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.Error)
+			}
+		})
+	}
+}
+
+func TestSubjectAndSender(t *testing.T) {
+	assert.Equal(t, "[rport] Client down", Branding{}.Subject("[rport] Client down"))
+	assert.Equal(t, "[Acme Remote] Client down", Branding{ProductName: "Acme Remote"}.Subject("[rport] Client down"))
+	assert.Equal(t, "New login", Branding{ProductName: "Acme Remote"}.Subject("New login"))
+
+	assert.Equal(t, "rport@example.com", Branding{}.Sender("rport@example.com"))
+	assert.Equal(t, "support@acme.example.com", Branding{SenderEmail: "support@acme.example.com"}.Sender("rport@example.com"))
+	assert.Equal(t, `"Acme Support" <rport@example.com>`, Branding{SenderName: "Acme Support"}.Sender("rport@example.com"))
+}
+
+func TestProvider(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "branding.json")
+
+	p, err := NewProvider(file)
+	require.NoError(t, err)
+	assert.Equal(t, Branding{}, p.Get())
+	assert.Equal(t, Public{ProductName: DefaultProductName}, p.Get().Public())
+
+	b := Branding{ProductName: "Acme Remote", EmailFooter: "Acme Ltd."}
+	require.NoError(t, p.Set(b))
+	assert.Error(t, p.Set(Branding{LogoURL: "logo.png"}))
+	assert.Equal(t, b, p.Get())
+
+	// reloaded after a restart
+	p, err = NewProvider(file)
+	require.NoError(t, err)
+	assert.Equal(t, b, p.Get())
+
+	var none *Provider
+	assert.Equal(t, Branding{}, none.Get())
+}
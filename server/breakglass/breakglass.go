@@ -0,0 +1,260 @@
+// Package breakglass implements an emergency administrator login for servers that authenticate users with OAuth.
+// The recovery code is generated offline by "rportd break-glass init" and printed on a recovery kit, the server
+// only stores its hash. The code is accepted only while all configured identity providers are unreachable and
+// every attempt to use it raises a security alert.
+package breakglass
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/IOTech17/neo-rport/server/notifications"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/refs"
+)
+
+const (
+	KitFileName = "break-glass.json"
+
+	RefType refs.IdentifiableType = "break-glass"
+
+	SeverityHigh = "high"
+
+	// ProbeTimeout is the time an identity provider has to answer before it is considered unreachable.
+	ProbeTimeout = 5 * time.Second
+
+	codeBytes     = 20
+	codeGroupSize = 4
+)
+
+var ErrIdPReachable = errors.New("the identity provider is reachable, log in with it")
+
+// Config defines who is alerted when the break-glass account is used.
+type Config struct {
+	AlertEmails []string `mapstructure:"alert_emails"`
+	AlertScript string   `mapstructure:"alert_script"`
+}
+
+// Kit is the sealed part of the recovery kit stored in the data dir of the server.
+type Kit struct {
+	Username  string    `json:"username"`
+	CodeHash  string    `json:"code_hash"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewKit generates a new recovery code for the given user. The code is returned once and never stored.
+func NewKit(username string) (*Kit, string, error) {
+	if username == "" {
+		return nil, "", errors.New("username is required")
+	}
+
+	b := make([]byte, codeBytes)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", fmt.Errorf("failed to generate recovery code: %w", err)
+	}
+	raw := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(raw), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to hash recovery code: %w", err)
+	}
+
+	var groups []string
+	for i := 0; i < len(raw); i += codeGroupSize {
+		groups = append(groups, raw[i:i+codeGroupSize])
+	}
+
+	return &Kit{
+		Username:  username,
+		CodeHash:  string(hash),
+		CreatedAt: time.Now().UTC(),
+	}, strings.Join(groups, "-"), nil
+}
+
+// Verify checks the code against the stored hash. Dashes, spaces and case are ignored, the code is typed from paper.
+func (k *Kit) Verify(code string) bool {
+	normalized := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return bcrypt.CompareHashAndPassword([]byte(k.CodeHash), []byte(normalized)) == nil
+}
+
+func kitPath(dataDir string) string {
+	return filepath.Join(dataDir, KitFileName)
+}
+
+// SaveKit writes the kit readable only by the owner, an existing kit is replaced.
+func SaveKit(dataDir string, kit *Kit) error {
+	b, err := json.MarshalIndent(kit, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(kitPath(dataDir), b, 0600)
+}
+
+// LoadKit returns nil if no kit was created.
+func LoadKit(dataDir string) (*Kit, error) {
+	b, err := os.ReadFile(kitPath(dataDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read break-glass kit: %w", err)
+	}
+
+	kit := &Kit{}
+	if err := json.Unmarshal(b, kit); err != nil {
+		return nil, fmt.Errorf("invalid break-glass kit %s: %w", kitPath(dataDir), err)
+	}
+	return kit, nil
+}
+
+// RemoveKit revokes the recovery code.
+func RemoveKit(dataDir string) error {
+	err := os.Remove(kitPath(dataDir))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// PrintKit writes the printable recovery kit.
+func PrintKit(w io.Writer, kit *Kit, code, server string) {
+	fmt.Fprintf(w, "RPORT BREAK-GLASS RECOVERY KIT\n\n")
+	fmt.Fprintf(w, "Server:        %s\n", server)
+	fmt.Fprintf(w, "Username:      %s\n", kit.Username)
+	fmt.Fprintf(w, "Recovery code: %s\n", code)
+	fmt.Fprintf(w, "Created:       %s\n\n", kit.CreatedAt.Format(time.RFC1123))
+	fmt.Fprintf(w, "The code is only accepted while the identity provider is unreachable.\n")
+	fmt.Fprintf(w, "Every use is audited and alerted. Create a new kit after using it.\n")
+	fmt.Fprintf(w, "Print this page, seal it in an envelope and store it in a safe. Do not store it digitally.\n")
+}
+
+// ReachableURL probes the given urls of the identity providers and returns the first one answering. Any response
+// below 500 counts as reachable, the token endpoints usually answer a plain GET with an error.
+func ReachableURL(ctx context.Context, client *http.Client, urls []string) (string, bool) {
+	for _, u := range urls {
+		if u == "" {
+			continue
+		}
+		if probe(ctx, client, u) {
+			return u, true
+		}
+	}
+	return "", false
+}
+
+func probe(ctx context.Context, client *http.Client, u string) bool {
+	ctx, cancel := context.WithTimeout(ctx, ProbeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode < http.StatusInternalServerError
+}
+
+// Event is an attempt to use the break-glass account.
+type Event struct {
+	Username  string    `json:"username"`
+	Success   bool      `json:"success"`
+	Reason    string    `json:"reason,omitempty"`
+	RemoteIP  string    `json:"remote_ip"`
+	UserAgent string    `json:"user_agent,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	Severity  string    `json:"severity"`
+}
+
+type Alerter struct {
+	logger      *logger.Logger
+	dispatcher  notifications.Dispatcher
+	alertEmails []string
+	alertScript string
+}
+
+func NewAlerter(config Config, l *logger.Logger, dispatcher notifications.Dispatcher) *Alerter {
+	return &Alerter{
+		logger:      l,
+		dispatcher:  dispatcher,
+		alertEmails: config.AlertEmails,
+		alertScript: config.AlertScript,
+	}
+}
+
+// Alert logs the event and sends it to the configured recipients. Unlike tripwire alerts nothing is suppressed,
+// the break-glass account is expected to be used rarely.
+func (a *Alerter) Alert(ctx context.Context, event Event) {
+	if a == nil {
+		return
+	}
+	event.Severity = SeverityHigh
+
+	result := "used"
+	if !event.Success {
+		result = "rejected"
+	}
+	a.logger.Errorf("SECURITY ALERT: break-glass login of %q %s from %s (%s) %s", event.Username, result, event.RemoteIP, event.UserAgent, event.Reason)
+
+	refID := refs.NewIdentifiable(RefType, event.Username)
+	if len(a.alertEmails) > 0 {
+		notification := notifications.NotificationData{
+			Target:      "smtp",
+			Recipients:  a.alertEmails,
+			Subject:     fmt.Sprintf("[rport] SECURITY ALERT: break-glass login %s", result),
+			Content:     alertMessage(event),
+			ContentType: notifications.ContentTypeTextPlain,
+			Severity:    event.Severity,
+		}
+		if _, err := a.dispatcher.Dispatch(ctx, refID, notification); err != nil {
+			a.logger.Errorf("Failed to send break-glass alert email: %v", err)
+		}
+	}
+	if a.alertScript != "" {
+		content, err := json.Marshal(event)
+		if err != nil {
+			a.logger.Errorf("Failed to marshal break-glass alert: %v", err)
+			return
+		}
+		notification := notifications.NotificationData{
+			Target:      a.alertScript,
+			Recipients:  a.alertEmails,
+			Subject:     "break-glass",
+			Content:     string(content),
+			ContentType: notifications.ContentTypeTextJSON,
+		}
+		if _, err := a.dispatcher.Dispatch(ctx, refID, notification); err != nil {
+			a.logger.Errorf("Failed to run break-glass alert script: %v", err)
+		}
+	}
+}
+
+func alertMessage(event Event) string {
+	b := &strings.Builder{}
+	if event.Success {
+		fmt.Fprintf(b, "The break-glass account %q was used to log in to the rport server.\n", event.Username)
+		b.WriteString("If this was not an announced emergency, end the sessions of the account and revoke the recovery kit.\n\n")
+	} else {
+		fmt.Fprintf(b, "A login with the break-glass account %q was rejected: %s.\n\n", event.Username, event.Reason)
+	}
+	fmt.Fprintf(b, "Severity: %s\n", event.Severity)
+	fmt.Fprintf(b, "Time: %s\n", event.Timestamp.UTC().Format(time.RFC1123))
+	fmt.Fprintf(b, "Remote IP: %s\n", event.RemoteIP)
+	fmt.Fprintf(b, "User agent: %s\n", event.UserAgent)
+	return b.String()
+}
@@ -0,0 +1,117 @@
+package breakglass
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/server/notifications"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/refs"
+)
+
+var testLog = logger.NewLogger("break-glass", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+
+type mockDispatcher struct {
+	notifications []notifications.NotificationData
+}
+
+func (d *mockDispatcher) Dispatch(ctx context.Context, refID refs.Identifiable, notification notifications.NotificationData) (refs.Identifiable, error) {
+	d.notifications = append(d.notifications, notification)
+	return refs.GenerateIdentifiable(notifications.NotificationType), nil
+}
+
+func TestKit(t *testing.T) {
+	dir := t.TempDir()
+
+	kit, err := LoadKit(dir)
+	require.NoError(t, err)
+	assert.Nil(t, kit)
+
+	kit, code, err := NewKit("emergency")
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(code, "-"), 8)
+	assert.NotContains(t, kit.CodeHash, strings.ReplaceAll(code, "-", ""))
+	require.NoError(t, SaveKit(dir, kit))
+
+	loaded, err := LoadKit(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "emergency", loaded.Username)
+	assert.True(t, loaded.Verify(code))
+	assert.True(t, loaded.Verify(strings.ToLower(strings.ReplaceAll(code, "-", " "))))
+	assert.False(t, loaded.Verify(code[1:]))
+	assert.False(t, loaded.Verify(""))
+
+	b := &bytes.Buffer{}
+	PrintKit(b, kit, code, "https://rport.example.com")
+	assert.Contains(t, b.String(), "Recovery code: "+code)
+
+	require.NoError(t, RemoveKit(dir))
+	kit, err = LoadKit(dir)
+	require.NoError(t, err)
+	assert.Nil(t, kit)
+	require.NoError(t, RemoveKit(dir))
+}
+
+func TestNewKitWithoutUsername(t *testing.T) {
+	_, _, err := NewKit("")
+	assert.EqualError(t, err, "username is required")
+}
+
+func TestReachableURL(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	defer up.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	u, ok := ReachableURL(context.Background(), http.DefaultClient, []string{down.URL, failing.URL, up.URL})
+	assert.True(t, ok)
+	assert.Equal(t, up.URL, u)
+
+	_, ok = ReachableURL(context.Background(), http.DefaultClient, []string{down.URL, failing.URL, ""})
+	assert.False(t, ok)
+}
+
+func TestAlert(t *testing.T) {
+	dispatcher := &mockDispatcher{}
+	a := NewAlerter(Config{
+		AlertEmails: []string{"sec@example.com"},
+		AlertScript: "/usr/local/bin/alert.sh",
+	}, testLog, dispatcher)
+
+	a.Alert(context.Background(), Event{
+		Username:  "emergency",
+		Success:   true,
+		RemoteIP:  "1.2.3.4",
+		UserAgent: "curl/8.0",
+		Timestamp: time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC),
+	})
+	// nothing is suppressed
+	a.Alert(context.Background(), Event{
+		Username: "emergency",
+		Reason:   "invalid recovery code",
+		RemoteIP: "1.2.3.4",
+	})
+
+	require.Len(t, dispatcher.notifications, 4)
+	assert.Equal(t, "[rport] SECURITY ALERT: break-glass login used", dispatcher.notifications[0].Subject)
+	assert.Contains(t, dispatcher.notifications[0].Content, `The break-glass account "emergency" was used`)
+	assert.Equal(t, "/usr/local/bin/alert.sh", dispatcher.notifications[1].Target)
+	assert.Contains(t, dispatcher.notifications[1].Content, `"severity":"high"`)
+	assert.Equal(t, "[rport] SECURITY ALERT: break-glass login rejected", dispatcher.notifications[2].Subject)
+	assert.Contains(t, dispatcher.notifications[2].Content, "rejected: invalid recovery code")
+}
@@ -0,0 +1,275 @@
+// Package bulk exports and imports administrative resources like client groups as JSON or CSV, e.g. to migrate them
+// to another server.
+package bulk
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+// Strategies define how records are imported that exist already.
+const (
+	// StrategyFail rejects the whole import
+	StrategyFail = "fail"
+	// StrategySkip keeps the existing records
+	StrategySkip = "skip"
+	// StrategyOverwrite replaces the existing records
+	StrategyOverwrite = "overwrite"
+)
+
+var Formats = []string{FormatJSON, FormatCSV}
+
+var Strategies = []string{StrategyFail, StrategySkip, StrategyOverwrite}
+
+func IsFormat(s string) bool {
+	return s == FormatJSON || s == FormatCSV
+}
+
+func IsStrategy(s string) bool {
+	return s == StrategyFail || s == StrategySkip || s == StrategyOverwrite
+}
+
+// ErrInvalid is returned by Import if a record is invalid or conflicts with an existing one, nothing is imported then.
+var ErrInvalid = errors.New("import rejected, no records imported")
+
+// Column is a field of the records of a resource, it's a column in CSV.
+type Column struct {
+	Name string
+	// JSON columns hold the JSON encoded value in CSV, other columns the plain string.
+	JSON bool
+}
+
+// Importer is the part of a resource needed to import records. Records are exchanged JSON encoded, using the same
+// fields as the API of the resource.
+type Importer interface {
+	// Validate validates a record to import and returns its id.
+	Validate(ctx context.Context, record json.RawMessage) (string, error)
+	Exists(ctx context.Context, id string) (bool, error)
+	// Save creates a new or replaces an existing record.
+	Save(ctx context.Context, id string, record json.RawMessage) error
+}
+
+// Resource is implemented by the resources supporting export and import.
+type Resource interface {
+	Importer
+	Columns() []Column
+	// List returns all records to export.
+	List(ctx context.Context) ([]json.RawMessage, error)
+}
+
+// RecordError is the reason a record can't be imported, Index is the position of the record starting at 1.
+type RecordError struct {
+	Index   int    `json:"index"`
+	ID      string `json:"id,omitempty"`
+	Message string `json:"message"`
+}
+
+// Result tells what an import did, or would do on a dry run.
+type Result struct {
+	DryRun  bool          `json:"dry_run"`
+	Created []string      `json:"created"`
+	Updated []string      `json:"updated"`
+	Skipped []string      `json:"skipped"`
+	Errors  []RecordError `json:"errors"`
+}
+
+// Export writes all records of the resource in the given format.
+func Export(ctx context.Context, r Resource, format string, w io.Writer) error {
+	records, err := r.List(ctx)
+	if err != nil {
+		return err
+	}
+	if records == nil {
+		records = []json.RawMessage{}
+	}
+
+	if format == FormatJSON {
+		return json.NewEncoder(w).Encode(records)
+	}
+
+	cw := csv.NewWriter(w)
+	columns := r.Columns()
+	header := make([]string, 0, len(columns))
+	for _, c := range columns {
+		header = append(header, c.Name)
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, record := range records {
+		fields := make(map[string]json.RawMessage)
+		if err := json.Unmarshal(record, &fields); err != nil {
+			return err
+		}
+		row := make([]string, 0, len(columns))
+		for _, c := range columns {
+			cell, err := toCell(c, fields[c.Name])
+			if err != nil {
+				return fmt.Errorf("invalid %s: %v", c.Name, err)
+			}
+			row = append(row, cell)
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func toCell(c Column, value json.RawMessage) (string, error) {
+	if len(value) == 0 || bytes.Equal(value, []byte("null")) {
+		return "", nil
+	}
+	if c.JSON {
+		return string(value), nil
+	}
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return "", err
+	}
+	return s, nil
+}
+
+// Decode reads the records to import in the given format. CSV requires a header with the column names, unknown
+// columns are rejected and empty cells are null.
+func Decode(r Resource, format string, body io.Reader) ([]json.RawMessage, error) {
+	if format == FormatJSON {
+		var records []json.RawMessage
+		if err := json.NewDecoder(body).Decode(&records); err != nil {
+			return nil, fmt.Errorf("expected a JSON array of records: %v", err)
+		}
+		return records, nil
+	}
+
+	cr := csv.NewReader(body)
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("missing CSV header")
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := make([]Column, 0, len(header))
+	for _, name := range header {
+		c, ok := findColumn(r.Columns(), name)
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q", name)
+		}
+		columns = append(columns, c)
+	}
+
+	var records []json.RawMessage
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		fields := make(map[string]json.RawMessage, len(row))
+		for i, cell := range row {
+			if cell == "" {
+				continue
+			}
+			c := columns[i]
+			if !c.JSON {
+				fields[c.Name], _ = json.Marshal(cell)
+				continue
+			}
+			if !json.Valid([]byte(cell)) {
+				return nil, fmt.Errorf("row %d: invalid JSON in column %q", len(records)+1, c.Name)
+			}
+			fields[c.Name] = json.RawMessage(cell)
+		}
+		record, err := json.Marshal(fields)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+}
+
+func findColumn(columns []Column, name string) (Column, bool) {
+	for _, c := range columns {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Column{}, false
+}
+
+// Import validates all records first and imports them only if all of them are valid. Records that exist already are
+// handled according to the strategy.
+func Import(ctx context.Context, r Importer, records []json.RawMessage, strategy string, dryRun bool) (*Result, error) {
+	res := &Result{
+		DryRun:  dryRun,
+		Created: []string{},
+		Updated: []string{},
+		Skipped: []string{},
+		Errors:  []RecordError{},
+	}
+
+	type item struct {
+		id     string
+		record json.RawMessage
+		exists bool
+	}
+	var items []item
+	seen := make(map[string]bool, len(records))
+	for i, record := range records {
+		id, err := r.Validate(ctx, record)
+		if err != nil {
+			res.Errors = append(res.Errors, RecordError{Index: i + 1, ID: id, Message: err.Error()})
+			continue
+		}
+		if seen[id] {
+			res.Errors = append(res.Errors, RecordError{Index: i + 1, ID: id, Message: "duplicate id"})
+			continue
+		}
+		seen[id] = true
+
+		exists, err := r.Exists(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if exists && strategy == StrategyFail {
+			res.Errors = append(res.Errors, RecordError{Index: i + 1, ID: id, Message: "already exists"})
+			continue
+		}
+		items = append(items, item{id: id, record: record, exists: exists})
+	}
+	if len(res.Errors) > 0 {
+		return res, ErrInvalid
+	}
+
+	for _, it := range items {
+		switch {
+		case it.exists && strategy == StrategySkip:
+			res.Skipped = append(res.Skipped, it.id)
+			continue
+		case it.exists:
+			res.Updated = append(res.Updated, it.id)
+		default:
+			res.Created = append(res.Created, it.id)
+		}
+		if dryRun {
+			continue
+		}
+		if err := r.Save(ctx, it.id, it.record); err != nil {
+			return res, fmt.Errorf("failed to import %q: %v", it.id, err)
+		}
+	}
+	return res, nil
+}
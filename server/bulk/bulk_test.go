@@ -0,0 +1,231 @@
+package bulk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testRecord struct {
+	ID   string   `json:"id"`
+	Note string   `json:"note,omitempty"`
+	Tags []string `json:"tags"`
+}
+
+type testResource struct {
+	records map[string]testRecord
+	order   []string
+}
+
+func newTestResource(records ...testRecord) *testResource {
+	r := &testResource{records: make(map[string]testRecord)}
+	for _, record := range records {
+		r.records[record.ID] = record
+		r.order = append(r.order, record.ID)
+	}
+	return r
+}
+
+func (r *testResource) Columns() []Column {
+	return []Column{{Name: "id"}, {Name: "note"}, {Name: "tags", JSON: true}}
+}
+
+func (r *testResource) List(ctx context.Context) ([]json.RawMessage, error) {
+	var list []json.RawMessage
+	for _, id := range r.order {
+		b, err := json.Marshal(r.records[id])
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, b)
+	}
+	return list, nil
+}
+
+func (r *testResource) Validate(ctx context.Context, record json.RawMessage) (string, error) {
+	var tr testRecord
+	if err := json.Unmarshal(record, &tr); err != nil {
+		return "", err
+	}
+	if tr.ID == "" {
+		return "", errors.New("missing id")
+	}
+	return tr.ID, nil
+}
+
+func (r *testResource) Exists(ctx context.Context, id string) (bool, error) {
+	_, ok := r.records[id]
+	return ok, nil
+}
+
+func (r *testResource) Save(ctx context.Context, id string, record json.RawMessage) error {
+	var tr testRecord
+	if err := json.Unmarshal(record, &tr); err != nil {
+		return err
+	}
+	if _, ok := r.records[id]; !ok {
+		r.order = append(r.order, id)
+	}
+	r.records[id] = tr
+	return nil
+}
+
+func TestExport(t *testing.T) {
+	r := newTestResource(
+		testRecord{ID: "a", Note: "first, with comma", Tags: []string{"x", "y"}},
+		testRecord{ID: "b"},
+	)
+
+	var buf bytes.Buffer
+	require.NoError(t, Export(context.Background(), r, FormatJSON, &buf))
+	assert.JSONEq(t, `[{"id":"a","note":"first, with comma","tags":["x","y"]},{"id":"b","tags":null}]`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, Export(context.Background(), r, FormatCSV, &buf))
+	assert.Equal(t, "id,note,tags\na,\"first, with comma\",\"[\"\"x\"\",\"\"y\"\"]\"\nb,,\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, Export(context.Background(), newTestResource(), FormatJSON, &buf))
+	assert.Equal(t, "[]\n", buf.String())
+}
+
+func TestDecode(t *testing.T) {
+	r := newTestResource()
+
+	records, err := Decode(r, FormatCSV, strings.NewReader("tags,id\n\"[\"\"x\"\"]\",a\n,b\n"))
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.JSONEq(t, `{"id":"a","tags":["x"]}`, string(records[0]))
+	assert.JSONEq(t, `{"id":"b"}`, string(records[1]))
+
+	_, err = Decode(r, FormatCSV, strings.NewReader("id,unknown\na,b\n"))
+	assert.EqualError(t, err, `unknown column "unknown"`)
+
+	_, err = Decode(r, FormatCSV, strings.NewReader("id,tags\na,[x\n"))
+	assert.EqualError(t, err, `row 1: invalid JSON in column "tags"`)
+
+	_, err = Decode(r, FormatCSV, strings.NewReader(""))
+	assert.EqualError(t, err, "missing CSV header")
+
+	records, err = Decode(r, FormatJSON, strings.NewReader(`[{"id":"a"}]`))
+	require.NoError(t, err)
+	assert.Len(t, records, 1)
+
+	_, err = Decode(r, FormatJSON, strings.NewReader(`{"id":"a"}`))
+	assert.Error(t, err)
+}
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+	records := []json.RawMessage{
+		json.RawMessage(`{"id":"a","note":"new"}`),
+		json.RawMessage(`{"id":"c"}`),
+	}
+
+	testCases := []struct {
+		Name          string
+		Strategy      string
+		DryRun        bool
+		Records       []json.RawMessage
+		ExpectedError error
+		Expected      *Result
+		ExpectedNoteA string
+		ExpectedIDs   []string
+	}{
+		{
+			Name:          "fail on existing",
+			Strategy:      StrategyFail,
+			Records:       records,
+			ExpectedError: ErrInvalid,
+			Expected: &Result{
+				Created: []string{},
+				Updated: []string{},
+				Skipped: []string{},
+				Errors:  []RecordError{{Index: 1, ID: "a", Message: "already exists"}},
+			},
+			ExpectedNoteA: "old",
+			ExpectedIDs:   []string{"a", "b"},
+		},
+		{
+			Name:     "skip existing",
+			Strategy: StrategySkip,
+			Records:  records,
+			Expected: &Result{
+				Created: []string{"c"},
+				Updated: []string{},
+				Skipped: []string{"a"},
+				Errors:  []RecordError{},
+			},
+			ExpectedNoteA: "old",
+			ExpectedIDs:   []string{"a", "b", "c"},
+		},
+		{
+			Name:     "overwrite existing",
+			Strategy: StrategyOverwrite,
+			Records:  records,
+			Expected: &Result{
+				Created: []string{"c"},
+				Updated: []string{"a"},
+				Skipped: []string{},
+				Errors:  []RecordError{},
+			},
+			ExpectedNoteA: "new",
+			ExpectedIDs:   []string{"a", "b", "c"},
+		},
+		{
+			Name:     "dry run",
+			Strategy: StrategyOverwrite,
+			DryRun:   true,
+			Records:  records,
+			Expected: &Result{
+				DryRun:  true,
+				Created: []string{"c"},
+				Updated: []string{"a"},
+				Skipped: []string{},
+				Errors:  []RecordError{},
+			},
+			ExpectedNoteA: "old",
+			ExpectedIDs:   []string{"a", "b"},
+		},
+		{
+			Name:     "invalid and duplicate records",
+			Strategy: StrategyOverwrite,
+			Records: []json.RawMessage{
+				json.RawMessage(`{"id":"c"}`),
+				json.RawMessage(`{"note":"no id"}`),
+				json.RawMessage(`{"id":"c"}`),
+			},
+			ExpectedError: ErrInvalid,
+			Expected: &Result{
+				Created: []string{},
+				Updated: []string{},
+				Skipped: []string{},
+				Errors: []RecordError{
+					{Index: 2, Message: "missing id"},
+					{Index: 3, ID: "c", Message: "duplicate id"},
+				},
+			},
+			ExpectedNoteA: "old",
+			ExpectedIDs:   []string{"a", "b"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			r := newTestResource(testRecord{ID: "a", Note: "old"}, testRecord{ID: "b"})
+
+			res, err := Import(ctx, r, tc.Records, tc.Strategy, tc.DryRun)
+
+			assert.Equal(t, tc.ExpectedError, err)
+			assert.Equal(t, tc.Expected, res)
+			assert.Equal(t, tc.ExpectedNoteA, r.records["a"].Note)
+			assert.Equal(t, tc.ExpectedIDs, r.order)
+		})
+	}
+}
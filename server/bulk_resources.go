@@ -0,0 +1,245 @@
+package chserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/bulk"
+	"github.com/IOTech17/neo-rport/server/cgroups"
+	"github.com/IOTech17/neo-rport/server/changes"
+	"github.com/IOTech17/neo-rport/server/clientsauth"
+	"github.com/IOTech17/neo-rport/share/query"
+)
+
+const (
+	bulkResourceClientsAuth  = "clients-auth"
+	bulkResourceClientGroups = "client-groups"
+	bulkResourceUserGroups   = "user-groups"
+)
+
+var bulkResourceNames = []string{bulkResourceClientsAuth, bulkResourceClientGroups, bulkResourceUserGroups}
+
+// bulkResources returns the resources supporting export and import by the name used in the API.
+func (al *APIListener) bulkResources() map[string]bulk.Resource {
+	return map[string]bulk.Resource{
+		bulkResourceClientsAuth:  bulkClientsAuth{al: al},
+		bulkResourceClientGroups: bulkClientGroups{al: al},
+		bulkResourceUserGroups:   bulkUserGroups{al: al},
+	}
+}
+
+// decodeRecord decodes a record to import, unknown fields are rejected to catch records of other resources.
+func decodeRecord(record json.RawMessage, dest interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(record))
+	dec.DisallowUnknownFields()
+	return dec.Decode(dest)
+}
+
+func encodeRecords(items ...interface{}) ([]json.RawMessage, error) {
+	records := make([]json.RawMessage, 0, len(items))
+	for _, item := range items {
+		record, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+type bulkClientsAuth struct {
+	al *APIListener
+}
+
+func (r bulkClientsAuth) Columns() []bulk.Column {
+	return []bulk.Column{{Name: "id"}, {Name: "password"}}
+}
+
+func (r bulkClientsAuth) List(ctx context.Context) ([]json.RawMessage, error) {
+	list, _, err := r.al.clientAuthProvider.GetFiltered(&query.ListOptions{Pagination: query.NewPagination(math.MaxInt32, 0)})
+	if err != nil {
+		return nil, err
+	}
+	items := make([]interface{}, 0, len(list))
+	for _, ca := range list {
+		items = append(items, ca)
+	}
+	return encodeRecords(items...)
+}
+
+func (r bulkClientsAuth) Validate(ctx context.Context, record json.RawMessage) (string, error) {
+	var ca clientsauth.ClientAuth
+	if err := decodeRecord(record, &ca); err != nil {
+		return "", err
+	}
+	if len(ca.ID) < MinCredentialsLength {
+		return ca.ID, fmt.Errorf("invalid or missing id, min size is %d", MinCredentialsLength)
+	}
+	if len(ca.Password) < MinCredentialsLength {
+		return ca.ID, fmt.Errorf("invalid or missing password, min size is %d", MinCredentialsLength)
+	}
+	return ca.ID, nil
+}
+
+func (r bulkClientsAuth) Exists(ctx context.Context, id string) (bool, error) {
+	ca, err := r.al.clientAuthProvider.Get(id)
+	return ca != nil, err
+}
+
+// Save replaces existing credentials, connected clients using them stay connected.
+func (r bulkClientsAuth) Save(ctx context.Context, id string, record json.RawMessage) error {
+	var ca clientsauth.ClientAuth
+	if err := json.Unmarshal(record, &ca); err != nil {
+		return err
+	}
+	exists, err := r.Exists(ctx, id)
+	if err != nil {
+		return err
+	}
+	if exists {
+		if err := r.al.clientAuthProvider.Delete(id); err != nil {
+			return err
+		}
+	}
+	_, err = r.al.clientAuthProvider.Add(&ca)
+	return err
+}
+
+type bulkClientGroups struct {
+	al *APIListener
+}
+
+func (r bulkClientGroups) Columns() []bulk.Column {
+	return []bulk.Column{
+		{Name: "id"},
+		{Name: "description"},
+		{Name: "params", JSON: true},
+		{Name: "allowed_user_groups", JSON: true},
+		{Name: "reverse_remotes", JSON: true},
+		{Name: "access_schedule", JSON: true},
+		{Name: "maintenance_windows", JSON: true},
+	}
+}
+
+func (r bulkClientGroups) List(ctx context.Context) ([]json.RawMessage, error) {
+	groups, err := r.al.clientGroupProvider.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]interface{}, 0, len(groups))
+	for _, g := range groups {
+		// the clients of a group follow from its params
+		g.ClientIDs = nil
+		items = append(items, g)
+	}
+	return encodeRecords(items...)
+}
+
+func (r bulkClientGroups) Validate(ctx context.Context, record json.RawMessage) (string, error) {
+	var group cgroups.ClientGroup
+	if err := decodeRecord(record, &group); err != nil {
+		return "", err
+	}
+	return group.ID, validateInputClientGroup(group)
+}
+
+func (r bulkClientGroups) Exists(ctx context.Context, id string) (bool, error) {
+	group, err := r.al.clientGroupProvider.Get(ctx, id)
+	return group != nil, err
+}
+
+func (r bulkClientGroups) Save(ctx context.Context, id string, record json.RawMessage) error {
+	var group cgroups.ClientGroup
+	if err := json.Unmarshal(record, &group); err != nil {
+		return err
+	}
+	group.ClientIDs = nil
+
+	before, err := r.al.changeJournal.Snapshot(ctx, changes.ResourceClientGroup, id)
+	if err != nil {
+		return err
+	}
+	exists, err := r.Exists(ctx, id)
+	if err != nil {
+		return err
+	}
+	if exists {
+		err = r.al.clientGroupProvider.Update(ctx, &group)
+	} else {
+		err = r.al.clientGroupProvider.Create(ctx, &group)
+	}
+	if err != nil {
+		return err
+	}
+	r.al.recordChange(ctx, changes.ResourceClientGroup, id, before)
+	return nil
+}
+
+type bulkUserGroups struct {
+	al *APIListener
+}
+
+func (r bulkUserGroups) Columns() []bulk.Column {
+	return []bulk.Column{
+		{Name: "name"},
+		{Name: "permissions", JSON: true},
+		{Name: "tunnels_restricted", JSON: true},
+		{Name: "commands_restricted", JSON: true},
+	}
+}
+
+// List leaves out the Administrators group, its permissions are fixed.
+func (r bulkUserGroups) List(ctx context.Context) ([]json.RawMessage, error) {
+	groups, err := r.al.userService.ListGroups()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]interface{}, 0, len(groups))
+	for _, g := range groups {
+		if g.Name != users.Administrators {
+			items = append(items, g)
+		}
+	}
+	return encodeRecords(items...)
+}
+
+func (r bulkUserGroups) Validate(ctx context.Context, record json.RawMessage) (string, error) {
+	var group users.Group
+	if err := decodeRecord(record, &group); err != nil {
+		return "", err
+	}
+	if group.Name == "" {
+		return "", errors.New("missing name")
+	}
+	if group.Name == users.Administrators {
+		return group.Name, errors.New("the permissions of the Administrators group can't be changed")
+	}
+	return group.Name, nil
+}
+
+func (r bulkUserGroups) Exists(ctx context.Context, id string) (bool, error) {
+	groups, err := r.al.userService.ListGroups()
+	if err != nil {
+		return false, err
+	}
+	for _, g := range groups {
+		if g.Name == id {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r bulkUserGroups) Save(ctx context.Context, id string, record json.RawMessage) error {
+	var group users.Group
+	if err := json.Unmarshal(record, &group); err != nil {
+		return err
+	}
+	_, err := r.al.userService.UpdateGroup(id, group)
+	return err
+}
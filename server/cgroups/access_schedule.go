@@ -0,0 +1,255 @@
+package cgroups
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// AccessSchedule restricts when tunnels to the clients of a group can be created and used.
+type AccessSchedule struct {
+	// Timezone is an IANA time zone name, it defaults to UTC
+	Timezone string         `json:"timezone"`
+	Windows  []AccessWindow `json:"windows"`
+}
+
+// AccessWindow is a daily time window. If End is before Start, the window ends on the next day.
+type AccessWindow struct {
+	// Days are the weekdays the window starts, "mon" to "sun", all days if empty
+	Days  []string `json:"days"`
+	Start string   `json:"start"`
+	End   string   `json:"end"`
+}
+
+func (s *AccessSchedule) Validate() error {
+	if _, err := s.location(); err != nil {
+		return fmt.Errorf("invalid access schedule timezone %q: %v", s.Timezone, err)
+	}
+	if len(s.Windows) == 0 {
+		return errors.New("access schedule requires at least one window")
+	}
+	for _, w := range s.Windows {
+		start, err := parseMinuteOfDay(w.Start)
+		if err != nil {
+			return err
+		}
+		end, err := parseMinuteOfDay(w.End)
+		if err != nil {
+			return err
+		}
+		if start == end {
+			return fmt.Errorf("access window %s-%s is empty", w.Start, w.End)
+		}
+		for _, d := range w.Days {
+			if _, ok := weekdays[d]; !ok {
+				return fmt.Errorf("invalid access window day %q, expected one of: mon, tue, wed, thu, fri, sat, sun", d)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *AccessSchedule) location() (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(s.Timezone)
+}
+
+// Allows returns true if the time is within one of the windows.
+func (s *AccessSchedule) Allows(t time.Time) bool {
+	loc, err := s.location()
+	if err != nil {
+		return false
+	}
+	return s.allowsIn(t, loc)
+}
+
+func (s *AccessSchedule) allowsIn(t time.Time, loc *time.Location) bool {
+	t = t.In(loc)
+	minute := t.Hour()*60 + t.Minute()
+	for _, w := range s.Windows {
+		start, err := parseMinuteOfDay(w.Start)
+		if err != nil {
+			continue
+		}
+		end, err := parseMinuteOfDay(w.End)
+		if err != nil {
+			continue
+		}
+		if start < end {
+			if w.startsOn(t.Weekday()) && minute >= start && minute < end {
+				return true
+			}
+			continue
+		}
+		// the window spans midnight
+		if w.startsOn(t.Weekday()) && minute >= start {
+			return true
+		}
+		if w.startsOn((t.Weekday()+6)%7) && minute < end {
+			return true
+		}
+	}
+	return false
+}
+
+// NextAllowed returns the first minute after the time within one of the windows, false if there's none within a
+// week. Outside of the windows it's the start of the next window.
+func (s *AccessSchedule) NextAllowed(t time.Time) (time.Time, bool) {
+	loc, err := s.location()
+	if err != nil {
+		return time.Time{}, false
+	}
+	next := t.Truncate(time.Minute)
+	for end := t.Add(8 * 24 * time.Hour); next.Before(end); next = next.Add(time.Minute) {
+		if next.After(t) && s.allowsIn(next, loc) {
+			return next, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (w AccessWindow) startsOn(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if weekdays[d] == day {
+			return true
+		}
+	}
+	return false
+}
+
+// parseMinuteOfDay parses "HH:MM", "24:00" is the end of the day.
+func parseMinuteOfDay(v string) (int, error) {
+	t, err := time.Parse("15:04", v)
+	if err != nil {
+		if v == "24:00" {
+			return 24 * 60, nil
+		}
+		return 0, fmt.Errorf("invalid access window time %q, expected HH:MM", v)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func (s *AccessSchedule) Scan(value interface{}) error {
+	if s == nil {
+		return errors.New("'access_schedule' cannot be nil")
+	}
+	valueStr, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("expected to have string, got %T", value)
+	}
+	err := json.Unmarshal([]byte(valueStr), s)
+	if err != nil {
+		return fmt.Errorf("failed to decode 'access_schedule' field: %v", err)
+	}
+	return nil
+}
+
+func (s *AccessSchedule) Value() (driver.Value, error) {
+	if s == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode 'access_schedule' field: %v", err)
+	}
+	return string(b), nil
+}
+
+// ErrOutsideAccessSchedule is returned if a client group doesn't allow access at the time.
+type ErrOutsideAccessSchedule struct {
+	ClientGroupID string
+}
+
+func (e ErrOutsideAccessSchedule) Error() string {
+	return fmt.Sprintf("access to the clients of group %q is not allowed at this time", e.ClientGroupID)
+}
+
+// CheckAccessSchedules returns an ErrOutsideAccessSchedule if one of the schedules by client group ID doesn't allow
+// access at the time. The override lifts the schedule of its client group until it expires.
+func CheckAccessSchedules(schedules map[string]*AccessSchedule, t time.Time, override *models.AccessOverride) error {
+	ids := make([]string, 0, len(schedules))
+	for id := range schedules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if override != nil && override.ClientGroupID == id && t.Before(override.ExpiresAt) {
+			continue
+		}
+		if !schedules[id].Allows(t) {
+			return ErrOutsideAccessSchedule{ClientGroupID: id}
+		}
+	}
+	return nil
+}
+
+// NewAccessOverrideToken returns a token signed by the secret, it lifts the access schedule of the client group until
+// it expires.
+func NewAccessOverrideToken(secret []byte, override models.AccessOverride) string {
+	payload := override.ClientGroupID + "\n" + strconv.FormatInt(override.ExpiresAt.Unix(), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + base64.RawURLEncoding.EncodeToString(sign(secret, payload))
+}
+
+// ParseAccessOverrideToken returns the override of a valid token that isn't expired at the time.
+func ParseAccessOverrideToken(secret []byte, token string, t time.Time) (*models.AccessOverride, error) {
+	invalid := errors.New("invalid access override token")
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return nil, invalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, invalid
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, sign(secret, string(payload))) {
+		return nil, invalid
+	}
+	fields := strings.Split(string(payload), "\n")
+	if len(fields) != 2 {
+		return nil, invalid
+	}
+	expires, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return nil, invalid
+	}
+	override := &models.AccessOverride{
+		ClientGroupID: fields[0],
+		ExpiresAt:     time.Unix(expires, 0).UTC(),
+	}
+	if !t.Before(override.ExpiresAt) {
+		return nil, errors.New("access override token expired")
+	}
+	return override, nil
+}
+
+func sign(secret []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("access-override\n" + payload))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,186 @@
+package cgroups
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+func TestAccessScheduleValidate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		schedule AccessSchedule
+		wantErr  string
+	}{
+		{
+			name:     "valid",
+			schedule: AccessSchedule{Timezone: "Europe/Berlin", Windows: []AccessWindow{{Days: []string{"mon", "fri"}, Start: "08:00", End: "18:00"}}},
+		},
+		{
+			name:     "end of day",
+			schedule: AccessSchedule{Windows: []AccessWindow{{Start: "20:00", End: "24:00"}}},
+		},
+		{
+			name:     "invalid timezone",
+			schedule: AccessSchedule{Timezone: "Mars/Olympus", Windows: []AccessWindow{{Start: "08:00", End: "18:00"}}},
+			wantErr:  `invalid access schedule timezone "Mars/Olympus": unknown time zone Mars/Olympus`,
+		},
+		{
+			name:     "no windows",
+			schedule: AccessSchedule{},
+			wantErr:  "access schedule requires at least one window",
+		},
+		{
+			name:     "invalid time",
+			schedule: AccessSchedule{Windows: []AccessWindow{{Start: "8am", End: "18:00"}}},
+			wantErr:  `invalid access window time "8am", expected HH:MM`,
+		},
+		{
+			name:     "empty window",
+			schedule: AccessSchedule{Windows: []AccessWindow{{Start: "08:00", End: "08:00"}}},
+			wantErr:  "access window 08:00-08:00 is empty",
+		},
+		{
+			name:     "invalid day",
+			schedule: AccessSchedule{Windows: []AccessWindow{{Days: []string{"monday"}, Start: "08:00", End: "18:00"}}},
+			wantErr:  `invalid access window day "monday", expected one of: mon, tue, wed, thu, fri, sat, sun`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.schedule.Validate()
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAccessScheduleAllows(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	schedule := AccessSchedule{
+		Timezone: "Europe/Berlin",
+		Windows: []AccessWindow{
+			{Days: []string{"mon", "tue", "wed", "thu", "fri"}, Start: "08:00", End: "18:00"},
+			// maintenance from Saturday night to Sunday morning
+			{Days: []string{"sat"}, Start: "22:00", End: "06:00"},
+		},
+	}
+
+	testCases := []struct {
+		name string
+		time time.Time
+		want bool
+	}{
+		{
+			name: "monday morning",
+			time: time.Date(2024, 1, 1, 8, 0, 0, 0, berlin),
+			want: true,
+		},
+		{
+			name: "monday evening",
+			time: time.Date(2024, 1, 1, 18, 0, 0, 0, berlin),
+			want: false,
+		},
+		{
+			name: "utc time is converted to the timezone",
+			time: time.Date(2024, 1, 1, 7, 30, 0, 0, time.UTC),
+			want: true,
+		},
+		{
+			name: "saturday afternoon",
+			time: time.Date(2024, 1, 6, 14, 0, 0, 0, berlin),
+			want: false,
+		},
+		{
+			name: "saturday night",
+			time: time.Date(2024, 1, 6, 23, 0, 0, 0, berlin),
+			want: true,
+		},
+		{
+			name: "sunday early morning",
+			time: time.Date(2024, 1, 7, 5, 59, 0, 0, berlin),
+			want: true,
+		},
+		{
+			name: "sunday morning",
+			time: time.Date(2024, 1, 7, 6, 0, 0, 0, berlin),
+			want: false,
+		},
+		{
+			name: "monday early morning",
+			time: time.Date(2024, 1, 8, 5, 0, 0, 0, berlin),
+			want: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, schedule.Allows(tc.time))
+		})
+	}
+}
+
+func TestAccessScheduleNextAllowed(t *testing.T) {
+	schedule := AccessSchedule{Windows: []AccessWindow{{Days: []string{"sat"}, Start: "22:00", End: "06:00"}}}
+
+	next, ok := schedule.NextAllowed(time.Date(2024, 1, 1, 12, 30, 15, 0, time.UTC))
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 1, 6, 22, 0, 0, 0, time.UTC), next)
+
+	next, ok = schedule.NextAllowed(time.Date(2024, 1, 6, 23, 0, 0, 0, time.UTC))
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 1, 6, 23, 1, 0, 0, time.UTC), next)
+
+	_, ok = (&AccessSchedule{Timezone: "Mars/Olympus", Windows: schedule.Windows}).NextAllowed(time.Now())
+	assert.False(t, ok)
+}
+
+func TestCheckAccessSchedules(t *testing.T) {
+	monday := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	schedules := map[string]*AccessSchedule{
+		"always":  {Windows: []AccessWindow{{Start: "00:00", End: "24:00"}}},
+		"weekend": {Windows: []AccessWindow{{Days: []string{"sat", "sun"}, Start: "00:00", End: "24:00"}}},
+	}
+
+	assert.NoError(t, CheckAccessSchedules(nil, monday, nil))
+	assert.EqualError(t, CheckAccessSchedules(schedules, monday, nil), `access to the clients of group "weekend" is not allowed at this time`)
+
+	override := &models.AccessOverride{ClientGroupID: "weekend", ExpiresAt: monday.Add(time.Hour)}
+	assert.NoError(t, CheckAccessSchedules(schedules, monday, override))
+	assert.Error(t, CheckAccessSchedules(schedules, monday.Add(time.Hour), override))
+
+	override = &models.AccessOverride{ClientGroupID: "always", ExpiresAt: monday.Add(time.Hour)}
+	assert.Error(t, CheckAccessSchedules(schedules, monday, override))
+}
+
+func TestAccessOverrideToken(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	secret := []byte("secret")
+	token := NewAccessOverrideToken(secret, models.AccessOverride{ClientGroupID: "routers", ExpiresAt: now.Add(time.Hour)})
+
+	override, err := ParseAccessOverrideToken(secret, token, now)
+	require.NoError(t, err)
+	assert.Equal(t, &models.AccessOverride{ClientGroupID: "routers", ExpiresAt: now.Add(time.Hour)}, override)
+
+	_, err = ParseAccessOverrideToken(secret, token, now.Add(time.Hour))
+	assert.EqualError(t, err, "access override token expired")
+
+	_, err = ParseAccessOverrideToken([]byte("other"), token, now)
+	assert.EqualError(t, err, "invalid access override token")
+
+	forged := NewAccessOverrideToken([]byte("other"), models.AccessOverride{ClientGroupID: "routers", ExpiresAt: now.Add(time.Hour)})
+	_, err = ParseAccessOverrideToken(secret, forged, now)
+	assert.EqualError(t, err, "invalid access override token")
+
+	_, err = ParseAccessOverrideToken(secret, "garbage", now)
+	assert.EqualError(t, err, "invalid access override token")
+}
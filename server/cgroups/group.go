@@ -8,6 +8,7 @@ import (
 	"reflect"
 	"strings"
 
+	"github.com/IOTech17/neo-rport/share/models"
 	"github.com/IOTech17/neo-rport/share/types"
 )
 
@@ -24,6 +25,9 @@ var OptionsSupportedFields = map[string]map[string]bool{
 		"description":           true,
 		"params":                true,
 		"allowed_user_groups":   true,
+		"reverse_remotes":       true,
+		"access_schedule":       true,
+		"maintenance_windows":   true,
 		"client_ids":            true,
 		"num_clients":           true,
 		"num_clients_connected": true,
@@ -46,6 +50,13 @@ type ClientGroup struct {
 	Description       string            `json:"description" db:"description"`
 	Params            *ClientParams     `json:"params" db:"params"`
 	AllowedUserGroups types.StringSlice `json:"allowed_user_groups" db:"allowed_user_groups"`
+	// ReverseRemotes are services next to the server the clients of the group can reach through their connection.
+	ReverseRemotes types.StringSlice `json:"reverse_remotes" db:"reverse_remotes"`
+	// AccessSchedule restricts when tunnels to the clients of the group can be created and used, no restriction if nil.
+	AccessSchedule *AccessSchedule `json:"access_schedule" db:"access_schedule"`
+	// MaintenanceWindows are the times schedules restricted to maintenance windows may run jobs on the clients of the
+	// group, none if nil.
+	MaintenanceWindows *AccessSchedule `json:"maintenance_windows" db:"maintenance_windows"`
 	// ClientIDs shows what clients belong to a given group. Note: it's populated separately.
 	ClientIDs []string `json:"client_ids" db:"-"`
 }
@@ -230,3 +241,25 @@ func (g *ClientGroup) OneOfUserGroupsIsAllowed(userGroups []string) bool {
 	}
 	return false
 }
+
+// ParseReverseRemotes parses the reverse remotes of the group. A reverse remote has the format
+// "[<local-host>:]<local-port>:<server-host>:<server-port>" where the local part is opened on the client
+// and the server part is dialed by the server. The local host defaults to 127.0.0.1, IPv6 hosts are enclosed in
+// brackets.
+func (g *ClientGroup) ParseReverseRemotes() ([]*models.Remote, error) {
+	res := make([]*models.Remote, 0, len(g.ReverseRemotes))
+	for _, s := range g.ReverseRemotes {
+		r, err := models.NewRemoteWithDefaultLocalHost(s, models.LocalHost)
+		if err != nil {
+			return nil, fmt.Errorf("invalid reverse remote %q: %v", s, err)
+		}
+		if r.Protocol != models.ProtocolTCP {
+			return nil, fmt.Errorf("invalid reverse remote %q: only tcp is supported", s)
+		}
+		if r.LocalPort == "" {
+			return nil, fmt.Errorf("invalid reverse remote %q: local port is required", s)
+		}
+		res = append(res, r)
+	}
+	return res, nil
+}
@@ -387,3 +387,59 @@ func TestMatchesOneOf(t *testing.T) {
 		})
 	}
 }
+
+func TestParseReverseRemotes(t *testing.T) {
+	testCases := []struct {
+		name           string
+		reverseRemotes []string
+		wantLocal      []string
+		wantRemote     []string
+		wantErr        string
+	}{
+		{
+			name: "no reverse remotes",
+		},
+		{
+			name:           "local host defaults to loopback",
+			reverseRemotes: []string{"3142:apt-mirror.local:3142", "127.0.0.2:27000:10.0.0.5:27000"},
+			wantLocal:      []string{"127.0.0.1:3142", "127.0.0.2:27000"},
+			wantRemote:     []string{"apt-mirror.local:3142", "10.0.0.5:27000"},
+		},
+		{
+			name:           "ipv6",
+			reverseRemotes: []string{"3142:[fd00::5]:3142", "[::1]:27000:[fd00::6]:27000"},
+			wantLocal:      []string{"127.0.0.1:3142", "[::1]:27000"},
+			wantRemote:     []string{"[fd00::5]:3142", "[fd00::6]:27000"},
+		},
+		{
+			name:           "missing local port",
+			reverseRemotes: []string{"apt-mirror.local:3142"},
+			wantErr:        `invalid reverse remote "apt-mirror.local:3142": local port is required`,
+		},
+		{
+			name:           "udp is not supported",
+			reverseRemotes: []string{"53:10.0.0.1:53/udp"},
+			wantErr:        `invalid reverse remote "53:10.0.0.1:53/udp": only tcp is supported`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			group := ClientGroup{ReverseRemotes: tc.reverseRemotes}
+
+			remotes, err := group.ParseReverseRemotes()
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			var gotLocal, gotRemote []string
+			for _, r := range remotes {
+				gotLocal = append(gotLocal, r.Local())
+				gotRemote = append(gotRemote, r.Remote())
+			}
+			assert.Equal(t, tc.wantLocal, gotLocal)
+			assert.Equal(t, tc.wantRemote, gotRemote)
+		})
+	}
+}
@@ -76,7 +76,7 @@ func (p *SqliteProvider) Get(ctx context.Context, id string) (*ClientGroup, erro
 func (p *SqliteProvider) Create(ctx context.Context, group *ClientGroup) error {
 	_, err := p.db.NamedExecContext(
 		ctx,
-		"INSERT INTO client_groups (id, description, params, allowed_user_groups) VALUES (:id, :description, :params, :allowed_user_groups)",
+		"INSERT INTO client_groups (id, description, params, allowed_user_groups, reverse_remotes, access_schedule, maintenance_windows) VALUES (:id, :description, :params, :allowed_user_groups, :reverse_remotes, :access_schedule, :maintenance_windows)",
 		group,
 	)
 	return err
@@ -85,7 +85,7 @@ func (p *SqliteProvider) Create(ctx context.Context, group *ClientGroup) error {
 func (p *SqliteProvider) Update(ctx context.Context, group *ClientGroup) error {
 	_, err := p.db.NamedExecContext(
 		ctx,
-		"INSERT OR REPLACE INTO client_groups (id, description, params, allowed_user_groups) VALUES (:id, :description, :params, :allowed_user_groups)",
+		"INSERT OR REPLACE INTO client_groups (id, description, params, allowed_user_groups, reverse_remotes, access_schedule, maintenance_windows) VALUES (:id, :description, :params, :allowed_user_groups, :reverse_remotes, :access_schedule, :maintenance_windows)",
 		group,
 	)
 	return err
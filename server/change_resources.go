@@ -0,0 +1,119 @@
+package chserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/api/jobs/schedule"
+	"github.com/IOTech17/neo-rport/server/cgroups"
+	"github.com/IOTech17/neo-rport/server/changes"
+)
+
+func (al *APIListener) registerChangeResources() {
+	al.changeJournal.Register(changes.ResourceClientGroup, clientGroupResource{al: al})
+	al.changeJournal.Register(changes.ResourceClientACL, clientACLResource{al: al})
+	al.changeJournal.Register(changes.ResourceSchedule, scheduleResource{al: al})
+}
+
+type clientGroupResource struct {
+	al *APIListener
+}
+
+func (r clientGroupResource) Current(ctx context.Context, id string) (interface{}, error) {
+	group, err := r.al.clientGroupProvider.Get(ctx, id)
+	if err != nil || group == nil {
+		return nil, err
+	}
+	// the clients of a group follow from its params
+	group.ClientIDs = nil
+	return group, nil
+}
+
+func (r clientGroupResource) Restore(ctx context.Context, id string, state json.RawMessage) error {
+	var group *cgroups.ClientGroup
+	if err := json.Unmarshal(state, &group); err != nil {
+		return err
+	}
+	defer func() {
+		go r.al.refreshReverseRemotes(context.Background())
+	}()
+
+	if group == nil {
+		return r.al.clientGroupProvider.Delete(ctx, id)
+	}
+	existing, err := r.al.clientGroupProvider.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return r.al.clientGroupProvider.Create(ctx, group)
+	}
+	return r.al.clientGroupProvider.Update(ctx, group)
+}
+
+type clientACLResource struct {
+	al *APIListener
+}
+
+func (r clientACLResource) Current(ctx context.Context, id string) (interface{}, error) {
+	client, err := r.al.clientService.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		return nil, errors2.APIError{
+			Message:    fmt.Sprintf("client %q not found", id),
+			HTTPStatus: http.StatusNotFound,
+		}
+	}
+	return clientACLRequest{AllowedUserGroups: client.GetAllowedUserGroups()}, nil
+}
+
+func (r clientACLResource) Restore(ctx context.Context, id string, state json.RawMessage) error {
+	var acl clientACLRequest
+	if err := json.Unmarshal(state, &acl); err != nil {
+		return err
+	}
+	return r.al.clientService.SetACL(id, acl.AllowedUserGroups)
+}
+
+type scheduleResource struct {
+	al *APIListener
+}
+
+// scheduleState leaves out the last execution, it's not changed by the users.
+type scheduleState struct {
+	schedule.Base
+	schedule.Details
+}
+
+func (r scheduleResource) Current(ctx context.Context, id string) (interface{}, error) {
+	s, err := r.al.scheduleManager.Get(ctx, id)
+	if err != nil || s == nil {
+		return nil, err
+	}
+	return scheduleState{Base: s.Base, Details: s.Details}, nil
+}
+
+func (r scheduleResource) Restore(ctx context.Context, id string, state json.RawMessage) error {
+	var s *schedule.Schedule
+	if err := json.Unmarshal(state, &s); err != nil {
+		return err
+	}
+
+	if s == nil {
+		return r.al.scheduleManager.Delete(ctx, id)
+	}
+	existing, err := r.al.scheduleManager.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return r.al.scheduleManager.Restore(ctx, s)
+	}
+	_, err = r.al.scheduleManager.Update(ctx, id, s)
+	return err
+}
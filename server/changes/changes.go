@@ -0,0 +1,280 @@
+// Package changes keeps a short-lived journal of administrative changes, e.g. to client groups, client ACLs and
+// schedules, so a recent change can be reverted quickly. The journal is kept in memory only and is lost on restart.
+package changes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+)
+
+const (
+	ResourceClientGroup = "client_group"
+	ResourceClientACL   = "client_acl"
+	ResourceSchedule    = "schedule"
+)
+
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// Resource gives the journal access to the state of a kind of resources.
+type Resource interface {
+	// Current returns the current state of the resource with the given id, nil if it doesn't exist.
+	Current(ctx context.Context, id string) (interface{}, error)
+	// Restore sets the resource with the given id to a state returned by Current before, null deletes it.
+	Restore(ctx context.Context, id string, state json.RawMessage) error
+}
+
+type Change struct {
+	ID         string          `json:"id"`
+	Resource   string          `json:"resource"`
+	ResourceID string          `json:"resource_id"`
+	Action     string          `json:"action"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	Username   string          `json:"username"`
+	Timestamp  time.Time       `json:"timestamp"`
+	// RevertOf is the id of the change this change reverted
+	RevertOf   string     `json:"revert_of,omitempty"`
+	RevertedBy string     `json:"reverted_by,omitempty"`
+	RevertedAt *time.Time `json:"reverted_at,omitempty"`
+}
+
+type Journal struct {
+	retention time.Duration
+	resources map[string]Resource
+	changes   []*Change
+	lastID    int
+	now       func() time.Time
+	mu        sync.Mutex
+}
+
+// New returns nil if the retention is 0, all methods of a nil journal are no-ops.
+func New(retention time.Duration) *Journal {
+	if retention <= 0 {
+		return nil
+	}
+	return &Journal{
+		retention: retention,
+		resources: make(map[string]Resource),
+		now:       time.Now,
+	}
+}
+
+// Register makes changes to the given kind of resources revertible.
+func (j *Journal) Register(name string, r Resource) {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.resources[name] = r
+}
+
+// Snapshot returns the current state of a resource, to be passed to Record once it's changed.
+func (j *Journal) Snapshot(ctx context.Context, resource, id string) (json.RawMessage, error) {
+	if j == nil {
+		return nil, nil
+	}
+	r, err := j.resource(resource)
+	if err != nil {
+		return nil, err
+	}
+	return current(ctx, r, id)
+}
+
+// Record adds the change of a resource from the before state to its current state.
+func (j *Journal) Record(ctx context.Context, resource, id, username string, before json.RawMessage) error {
+	if j == nil {
+		return nil
+	}
+	r, err := j.resource(resource)
+	if err != nil {
+		return err
+	}
+	after, err := current(ctx, r, id)
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.add(resource, id, username, before, after)
+	return nil
+}
+
+// List returns the changes within the retention, newest first.
+func (j *Journal) List() []*Change {
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.prune()
+	result := make([]*Change, 0, len(j.changes))
+	for i := len(j.changes) - 1; i >= 0; i-- {
+		c := *j.changes[i]
+		result = append(result, &c)
+	}
+	return result
+}
+
+// Get returns nil if the change doesn't exist or is expired.
+func (j *Journal) Get(id string) *Change {
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.prune()
+	c := j.get(id)
+	if c == nil {
+		return nil
+	}
+	result := *c
+	return &result
+}
+
+// Revert restores the state of a resource before the given change and records it as a new change. It fails if the
+// resource changed since, unless force is set.
+func (j *Journal) Revert(ctx context.Context, id, username string, force bool) (*Change, error) {
+	if j == nil {
+		return nil, errors2.APIError{
+			Message:    "change journal is disabled",
+			HTTPStatus: http.StatusForbidden,
+		}
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.prune()
+	c := j.get(id)
+	if c == nil {
+		return nil, errors2.APIError{
+			Message:    fmt.Sprintf("change %q not found", id),
+			HTTPStatus: http.StatusNotFound,
+		}
+	}
+	if c.RevertedAt != nil {
+		return nil, errors2.APIError{
+			Message:    fmt.Sprintf("change %q was reverted already by %s", id, c.RevertedBy),
+			HTTPStatus: http.StatusConflict,
+		}
+	}
+	r, ok := j.resources[c.Resource]
+	if !ok {
+		return nil, fmt.Errorf("unknown resource %q", c.Resource)
+	}
+
+	before, err := current(ctx, r, c.ResourceID)
+	if err != nil {
+		return nil, err
+	}
+	if !force && !bytes.Equal(before, c.After) {
+		return nil, errors2.APIError{
+			Message:    fmt.Sprintf("%s %q changed since change %q, use force to revert anyway", c.Resource, c.ResourceID, id),
+			HTTPStatus: http.StatusConflict,
+		}
+	}
+
+	if err := r.Restore(ctx, c.ResourceID, c.Before); err != nil {
+		return nil, err
+	}
+	after, err := current(ctx, r, c.ResourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := j.now()
+	c.RevertedBy = username
+	c.RevertedAt = &now
+	revert := j.add(c.Resource, c.ResourceID, username, before, after)
+	revert.RevertOf = c.ID
+
+	result := *revert
+	return &result, nil
+}
+
+func (j *Journal) resource(name string) (Resource, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	r, ok := j.resources[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown resource %q", name)
+	}
+	return r, nil
+}
+
+func (j *Journal) add(resource, id, username string, before, after json.RawMessage) *Change {
+	j.prune()
+	j.lastID++
+	c := &Change{
+		ID:         strconv.Itoa(j.lastID),
+		Resource:   resource,
+		ResourceID: id,
+		Action:     action(before, after),
+		Before:     before,
+		After:      after,
+		Username:   username,
+		Timestamp:  j.now(),
+	}
+	j.changes = append(j.changes, c)
+	return c
+}
+
+func (j *Journal) get(id string) *Change {
+	for _, c := range j.changes {
+		if c.ID == id {
+			return c
+		}
+	}
+	return nil
+}
+
+// prune drops the changes older than the retention, the changes are ordered by time.
+func (j *Journal) prune() {
+	deadline := j.now().Add(-j.retention)
+	i := 0
+	for i < len(j.changes) && j.changes[i].Timestamp.Before(deadline) {
+		i++
+	}
+	j.changes = j.changes[i:]
+}
+
+func current(ctx context.Context, r Resource, id string) (json.RawMessage, error) {
+	state, err := r.Current(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(state)
+}
+
+func action(before, after json.RawMessage) string {
+	switch {
+	case isNull(before):
+		return ActionCreate
+	case isNull(after):
+		return ActionDelete
+	default:
+		return ActionUpdate
+	}
+}
+
+func isNull(state json.RawMessage) bool {
+	return len(state) == 0 || string(state) == "null"
+}
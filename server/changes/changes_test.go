@@ -0,0 +1,139 @@
+package changes
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeResource map[string]string
+
+func (r fakeResource) Current(ctx context.Context, id string) (interface{}, error) {
+	v, ok := r[id]
+	if !ok {
+		return nil, nil
+	}
+	return v, nil
+}
+
+func (r fakeResource) Restore(ctx context.Context, id string, state json.RawMessage) error {
+	var v *string
+	if err := json.Unmarshal(state, &v); err != nil {
+		return err
+	}
+	if v == nil {
+		delete(r, id)
+		return nil
+	}
+	r[id] = *v
+	return nil
+}
+
+func newTestJournal() (*Journal, fakeResource) {
+	j := New(time.Hour)
+	r := fakeResource{}
+	j.Register("fake", r)
+	return j, r
+}
+
+func change(t *testing.T, j *Journal, r fakeResource, id, value string) {
+	ctx := context.Background()
+	before, err := j.Snapshot(ctx, "fake", id)
+	require.NoError(t, err)
+	if value == "" {
+		delete(r, id)
+	} else {
+		r[id] = value
+	}
+	require.NoError(t, j.Record(ctx, "fake", id, "admin", before))
+}
+
+func TestNilJournal(t *testing.T) {
+	var j *Journal
+	ctx := context.Background()
+
+	assert.Nil(t, New(0))
+	j.Register("fake", fakeResource{})
+	before, err := j.Snapshot(ctx, "fake", "1")
+	assert.NoError(t, err)
+	assert.NoError(t, j.Record(ctx, "fake", "1", "admin", before))
+	assert.Empty(t, j.List())
+	assert.Nil(t, j.Get("1"))
+	_, err = j.Revert(ctx, "1", "admin", false)
+	assert.EqualError(t, err, "change journal is disabled")
+}
+
+func TestRecord(t *testing.T) {
+	j, r := newTestJournal()
+
+	change(t, j, r, "a", "one")
+	change(t, j, r, "a", "two")
+	change(t, j, r, "a", "")
+
+	list := j.List()
+	require.Len(t, list, 3)
+	assert.Equal(t, "3", list[0].ID)
+	assert.Equal(t, ActionDelete, list[0].Action)
+	assert.Equal(t, `"two"`, string(list[0].Before))
+	assert.Equal(t, "null", string(list[0].After))
+	assert.Equal(t, ActionUpdate, list[1].Action)
+	assert.Equal(t, ActionCreate, list[2].Action)
+	assert.Equal(t, "admin", list[2].Username)
+
+	_, err := j.Snapshot(context.Background(), "unknown", "a")
+	assert.EqualError(t, err, `unknown resource "unknown"`)
+}
+
+func TestRevert(t *testing.T) {
+	j, r := newTestJournal()
+	ctx := context.Background()
+
+	change(t, j, r, "a", "one")
+	change(t, j, r, "a", "two")
+	change(t, j, r, "b", "three")
+
+	revert, err := j.Revert(ctx, "2", "bob", false)
+	require.NoError(t, err)
+	assert.Equal(t, "one", r["a"])
+	assert.Equal(t, "4", revert.ID)
+	assert.Equal(t, "2", revert.RevertOf)
+	assert.Equal(t, "bob", revert.Username)
+	assert.Equal(t, `"two"`, string(revert.Before))
+	assert.Equal(t, `"one"`, string(revert.After))
+	assert.Equal(t, "bob", j.Get("2").RevertedBy)
+
+	_, err = j.Revert(ctx, "2", "bob", false)
+	assert.EqualError(t, err, `change "2" was reverted already by bob`)
+
+	change(t, j, r, "a", "five")
+	_, err = j.Revert(ctx, "1", "bob", false)
+	assert.EqualError(t, err, `fake "a" changed since change "1", use force to revert anyway`)
+	assert.Equal(t, "five", r["a"])
+
+	_, err = j.Revert(ctx, "1", "bob", true)
+	require.NoError(t, err)
+	assert.NotContains(t, r, "a")
+
+	_, err = j.Revert(ctx, "42", "bob", false)
+	assert.EqualError(t, err, `change "42" not found`)
+}
+
+func TestRetention(t *testing.T) {
+	j, r := newTestJournal()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	j.now = func() time.Time { return now }
+
+	change(t, j, r, "a", "one")
+	now = now.Add(30 * time.Minute)
+	change(t, j, r, "a", "two")
+	now = now.Add(31 * time.Minute)
+
+	list := j.List()
+	require.Len(t, list, 1)
+	assert.Equal(t, "2", list[0].ID)
+	assert.Nil(t, j.Get("1"))
+}
@@ -0,0 +1,253 @@
+// Package chaos injects faults into the connections of selected clients, to verify in staging environments that
+// clients reconnect and send the data they buffered while the server was unreachable.
+package chaos
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+)
+
+const (
+	// FaultDrop closes the connections of the client and all reconnects until the fault expires.
+	FaultDrop = "drop"
+	// FaultDelay delays each read and write of the connection.
+	FaultDelay = "delay"
+	// FaultThrottle limits the bandwidth of the connection in both directions.
+	FaultThrottle = "throttle"
+)
+
+// Fault is a fault injected into the connection of a client.
+type Fault struct {
+	ClientID       string     `json:"client_id"`
+	Type           string     `json:"type"`
+	DelayMS        int        `json:"delay_ms,omitempty"`
+	BytesPerSecond int        `json:"bytes_per_second,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	CreatedBy      string     `json:"created_by"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+func (f *Fault) Validate() error {
+	switch f.Type {
+	case FaultDrop:
+	case FaultDelay:
+		if f.DelayMS <= 0 {
+			return validationError("delay_ms must be greater than 0")
+		}
+	case FaultThrottle:
+		if f.BytesPerSecond <= 0 {
+			return validationError("bytes_per_second must be greater than 0")
+		}
+	default:
+		return validationError(fmt.Sprintf("invalid fault type %q, expected one of %s, %s, %s", f.Type, FaultDrop, FaultDelay, FaultThrottle))
+	}
+	return nil
+}
+
+func (f *Fault) expired(now time.Time) bool {
+	return f.ExpiresAt != nil && !now.Before(*f.ExpiresAt)
+}
+
+func validationError(msg string) error {
+	return errors2.APIError{
+		Message:    msg,
+		HTTPStatus: http.StatusBadRequest,
+	}
+}
+
+// Injector holds the faults by client id and the connections they are applied to.
+type Injector struct {
+	mu     sync.Mutex
+	faults map[string]*Fault
+	conns  map[string]map[*Conn]struct{}
+	now    func() time.Time
+	sleep  func(time.Duration)
+}
+
+// New returns nil if chaos testing is disabled, all methods of a nil Injector are no-ops.
+func New(enabled bool) *Injector {
+	if !enabled {
+		return nil
+	}
+	return &Injector{
+		faults: make(map[string]*Fault),
+		conns:  make(map[string]map[*Conn]struct{}),
+		now:    time.Now,
+		sleep:  time.Sleep,
+	}
+}
+
+// List returns the active faults sorted by client id.
+func (i *Injector) List() []*Fault {
+	if i == nil {
+		return []*Fault{}
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	result := make([]*Fault, 0, len(i.faults))
+	for clientID := range i.faults {
+		if f := i.getLocked(clientID); f != nil {
+			result = append(result, f)
+		}
+	}
+	sort.Slice(result, func(a, b int) bool {
+		return result[a].ClientID < result[b].ClientID
+	})
+	return result
+}
+
+// Set injects the fault, replacing a previous fault of the client. The connections of the client are closed for
+// FaultDrop.
+func (i *Injector) Set(f *Fault) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	i.mu.Lock()
+	i.faults[f.ClientID] = f
+	var toClose []*Conn
+	if f.Type == FaultDrop {
+		for c := range i.conns[f.ClientID] {
+			toClose = append(toClose, c)
+		}
+	}
+	i.mu.Unlock()
+
+	for _, c := range toClose {
+		_ = c.Close()
+	}
+	return nil
+}
+
+// Delete removes the fault of the client, it returns false if there was none.
+func (i *Injector) Delete(clientID string) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.getLocked(clientID) == nil {
+		return false
+	}
+	delete(i.faults, clientID)
+	return true
+}
+
+// Drops returns true if the connections of the client are dropped.
+func (i *Injector) Drops(clientID string) bool {
+	f := i.get(clientID)
+	return f != nil && f.Type == FaultDrop
+}
+
+func (i *Injector) get(clientID string) *Fault {
+	if i == nil {
+		return nil
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.getLocked(clientID)
+}
+
+func (i *Injector) getLocked(clientID string) *Fault {
+	f := i.faults[clientID]
+	if f != nil && f.expired(i.now()) {
+		delete(i.faults, clientID)
+		return nil
+	}
+	return f
+}
+
+// Wrap returns the connection the faults are applied to once the client is identified.
+func (i *Injector) Wrap(conn net.Conn) net.Conn {
+	if i == nil {
+		return conn
+	}
+	return &Conn{Conn: conn, injector: i}
+}
+
+func (i *Injector) register(c *Conn) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.conns[c.clientID] == nil {
+		i.conns[c.clientID] = make(map[*Conn]struct{})
+	}
+	i.conns[c.clientID][c] = struct{}{}
+}
+
+func (i *Injector) unregister(c *Conn) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	delete(i.conns[c.clientID], c)
+	if len(i.conns[c.clientID]) == 0 {
+		delete(i.conns, c.clientID)
+	}
+}
+
+// Conn applies the faults of its client to reads and writes.
+type Conn struct {
+	net.Conn
+	injector *Injector
+
+	mu       sync.Mutex
+	clientID string
+}
+
+// Identify sets the client of the connection, the faults are applied from now on.
+func Identify(conn net.Conn, clientID string) {
+	c, ok := conn.(*Conn)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	c.clientID = clientID
+	c.mu.Unlock()
+	c.injector.register(c)
+}
+
+func (c *Conn) fault() *Fault {
+	c.mu.Lock()
+	clientID := c.clientID
+	c.mu.Unlock()
+	if clientID == "" {
+		return nil
+	}
+	return c.injector.get(clientID)
+}
+
+func (c *Conn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.apply(n)
+	return n, err
+}
+
+func (c *Conn) Write(b []byte) (int, error) {
+	c.apply(len(b))
+	return c.Conn.Write(b)
+}
+
+func (c *Conn) apply(n int) {
+	f := c.fault()
+	if f == nil {
+		return
+	}
+	switch f.Type {
+	case FaultDelay:
+		c.injector.sleep(time.Duration(f.DelayMS) * time.Millisecond)
+	case FaultThrottle:
+		c.injector.sleep(time.Duration(n) * time.Second / time.Duration(f.BytesPerSecond))
+	}
+}
+
+func (c *Conn) Close() error {
+	c.mu.Lock()
+	identified := c.clientID != ""
+	c.mu.Unlock()
+	if identified {
+		c.injector.unregister(c)
+	}
+	return c.Conn.Close()
+}
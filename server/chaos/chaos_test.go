@@ -0,0 +1,108 @@
+package chaos
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestInjector() (*Injector, *[]time.Duration) {
+	i := New(true)
+	var sleeps []time.Duration
+	i.sleep = func(d time.Duration) {
+		sleeps = append(sleeps, d)
+	}
+	return i, &sleeps
+}
+
+func TestNilInjector(t *testing.T) {
+	var i *Injector
+	conn, _ := net.Pipe()
+
+	assert.Nil(t, New(false))
+	assert.Equal(t, conn, i.Wrap(conn))
+	assert.False(t, i.Drops("client-1"))
+	assert.Empty(t, i.List())
+	Identify(conn, "client-1")
+}
+
+func TestValidate(t *testing.T) {
+	i, _ := newTestInjector()
+
+	assert.EqualError(t, i.Set(&Fault{ClientID: "client-1", Type: "explode"}), `invalid fault type "explode", expected one of drop, delay, throttle`)
+	assert.EqualError(t, i.Set(&Fault{ClientID: "client-1", Type: FaultDelay}), "delay_ms must be greater than 0")
+	assert.EqualError(t, i.Set(&Fault{ClientID: "client-1", Type: FaultThrottle}), "bytes_per_second must be greater than 0")
+	assert.Empty(t, i.List())
+}
+
+func TestDelayAndThrottle(t *testing.T) {
+	i, sleeps := newTestInjector()
+	server, client := net.Pipe()
+	conn := i.Wrap(server)
+	go func() {
+		buf := make([]byte, 100)
+		for {
+			if _, err := client.Read(buf); err != nil {
+				return
+			}
+		}
+	}()
+
+	// not applied before the client is identified
+	require.NoError(t, i.Set(&Fault{ClientID: "client-1", Type: FaultDelay, DelayMS: 200}))
+	_, err := conn.Write([]byte("hello"))
+	require.NoError(t, err)
+	assert.Empty(t, *sleeps)
+
+	Identify(conn, "client-1")
+	_, err = conn.Write([]byte("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, []time.Duration{200 * time.Millisecond}, *sleeps)
+
+	require.NoError(t, i.Set(&Fault{ClientID: "client-1", Type: FaultThrottle, BytesPerSecond: 10}))
+	_, err = conn.Write([]byte("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, []time.Duration{200 * time.Millisecond, 500 * time.Millisecond}, *sleeps)
+
+	assert.True(t, i.Delete("client-1"))
+	assert.False(t, i.Delete("client-1"))
+	_, err = conn.Write([]byte("hello"))
+	require.NoError(t, err)
+	assert.Len(t, *sleeps, 2)
+}
+
+func TestDrop(t *testing.T) {
+	i, _ := newTestInjector()
+	server, _ := net.Pipe()
+	conn := i.Wrap(server)
+	Identify(conn, "client-1")
+
+	require.NoError(t, i.Set(&Fault{ClientID: "client-1", Type: FaultDrop}))
+
+	assert.True(t, i.Drops("client-1"))
+	assert.False(t, i.Drops("client-2"))
+	_, err := conn.Write([]byte("hello"))
+	assert.ErrorIs(t, err, io.ErrClosedPipe)
+	assert.Empty(t, i.conns)
+}
+
+func TestExpiry(t *testing.T) {
+	i, _ := newTestInjector()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	i.now = func() time.Time { return now }
+	expiresAt := now.Add(time.Minute)
+
+	require.NoError(t, i.Set(&Fault{ClientID: "client-1", Type: FaultDrop, ExpiresAt: &expiresAt}))
+	require.NoError(t, i.Set(&Fault{ClientID: "client-2", Type: FaultDrop}))
+	assert.Len(t, i.List(), 2)
+
+	now = expiresAt
+	assert.False(t, i.Drops("client-1"))
+	faults := i.List()
+	require.Len(t, faults, 1)
+	assert.Equal(t, "client-2", faults[0].ClientID)
+}
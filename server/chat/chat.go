@@ -0,0 +1,29 @@
+package chat
+
+import (
+	"time"
+)
+
+const (
+	// DirectionOperator is a message sent by an operator to the client machine.
+	DirectionOperator = "operator"
+	// DirectionUser is a reply of the user of the client machine.
+	DirectionUser = "user"
+)
+
+// SessionIdleTimeout is the time after the last message, after which a new message starts a new chat session.
+const SessionIdleTimeout = time.Hour
+
+// MaxTextLength is the maximum length of the text of a message in bytes.
+const MaxTextLength = 4096
+
+type Message struct {
+	ID        int64     `db:"id" json:"id"`
+	ClientID  string    `db:"client_id" json:"client_id"`
+	SessionID string    `db:"session_id" json:"session_id"`
+	Direction string    `db:"direction" json:"direction"`
+	Author    string    `db:"author" json:"author"`
+	Text      string    `db:"text" json:"text"`
+	ReplyTo   *int64    `db:"reply_to" json:"reply_to"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,86 @@
+package chat
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type SqliteProvider struct {
+	db *sqlx.DB
+}
+
+func NewSqliteProvider(db *sqlx.DB) *SqliteProvider {
+	return &SqliteProvider{
+		db: db,
+	}
+}
+
+// Save saves the message and sets its id.
+func (p *SqliteProvider) Save(ctx context.Context, m *Message) error {
+	res, err := p.db.NamedExecContext(
+		ctx,
+		`INSERT INTO messages (client_id, session_id, direction, author, text, reply_to, created_at)
+			VALUES (:client_id, :session_id, :direction, :author, :text, :reply_to, :created_at)`,
+		m,
+	)
+	if err != nil {
+		return err
+	}
+	m.ID, err = res.LastInsertId()
+	return err
+}
+
+func (p *SqliteProvider) Delete(ctx context.Context, id int64) error {
+	_, err := p.db.ExecContext(ctx, "DELETE FROM messages WHERE id = ?", id)
+	return err
+}
+
+// Get returns nil if the message of the client is not found.
+func (p *SqliteProvider) Get(ctx context.Context, clientID string, id int64) (*Message, error) {
+	m := &Message{}
+	err := p.db.GetContext(ctx, m, "SELECT * FROM messages WHERE client_id = ? AND id = ?", clientID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// CurrentSessionID returns the session of the last message of the client, if it's not older than the idle timeout.
+func (p *SqliteProvider) CurrentSessionID(ctx context.Context, clientID string, now time.Time) (string, error) {
+	var sessionID string
+	err := p.db.GetContext(
+		ctx,
+		&sessionID,
+		"SELECT session_id FROM messages WHERE client_id = ? AND created_at > ? ORDER BY id DESC LIMIT 1",
+		clientID,
+		now.Add(-SessionIdleTimeout),
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return sessionID, err
+}
+
+// List returns the messages of the client, of all sessions if sessionID is empty, in the order they were sent.
+func (p *SqliteProvider) List(ctx context.Context, clientID, sessionID string) ([]*Message, error) {
+	query := "SELECT * FROM messages WHERE client_id = ?"
+	params := []interface{}{clientID}
+	if sessionID != "" {
+		query += " AND session_id = ?"
+		params = append(params, sessionID)
+	}
+	result := []*Message{}
+	err := p.db.SelectContext(ctx, &result, query+" ORDER BY id", params...)
+	return result, err
+}
+
+func (p *SqliteProvider) Close() error {
+	return p.db.Close()
+}
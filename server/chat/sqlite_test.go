@@ -0,0 +1,62 @@
+package chat
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	chatmigration "github.com/IOTech17/neo-rport/db/migration/chat"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+)
+
+var DataSourceOptions = sqlite.DataSourceOptions{WALEnabled: false}
+
+func TestSqliteProvider(t *testing.T) {
+	db, err := sqlite.New(":memory:", chatmigration.AssetNames(), chatmigration.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+	p := NewSqliteProvider(db)
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+
+	sessionID, err := p.CurrentSessionID(ctx, "c1", now)
+	require.NoError(t, err)
+	assert.Empty(t, sessionID)
+
+	m1 := &Message{ClientID: "c1", SessionID: "s1", Direction: DirectionOperator, Author: "admin", Text: "hello", CreatedAt: now.Add(-2 * time.Hour)}
+	require.NoError(t, p.Save(ctx, m1))
+	m2 := &Message{ClientID: "c1", SessionID: "s2", Direction: DirectionOperator, Author: "admin", Text: "restart?", CreatedAt: now.Add(-time.Minute)}
+	require.NoError(t, p.Save(ctx, m2))
+	m3 := &Message{ClientID: "c1", SessionID: "s2", Direction: DirectionUser, Text: "done", ReplyTo: &m2.ID, CreatedAt: now}
+	require.NoError(t, p.Save(ctx, m3))
+	require.NoError(t, p.Save(ctx, &Message{ClientID: "c2", SessionID: "s3", Direction: DirectionOperator, Text: "other", CreatedAt: now}))
+
+	sessionID, err = p.CurrentSessionID(ctx, "c1", now)
+	require.NoError(t, err)
+	assert.Equal(t, "s2", sessionID)
+	sessionID, err = p.CurrentSessionID(ctx, "c1", now.Add(SessionIdleTimeout+time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, sessionID)
+
+	messages, err := p.List(ctx, "c1", "")
+	require.NoError(t, err)
+	assert.Equal(t, []*Message{m1, m2, m3}, messages)
+	messages, err = p.List(ctx, "c1", "s2")
+	require.NoError(t, err)
+	assert.Equal(t, []*Message{m2, m3}, messages)
+
+	m, err := p.Get(ctx, "c1", m2.ID)
+	require.NoError(t, err)
+	assert.Equal(t, m2, m)
+	m, err = p.Get(ctx, "c2", m2.ID)
+	require.NoError(t, err)
+	assert.Nil(t, m)
+
+	require.NoError(t, p.Delete(ctx, m1.ID))
+	messages, err = p.List(ctx, "c1", "s1")
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+}
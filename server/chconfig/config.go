@@ -32,14 +32,27 @@ import (
 	"github.com/jpillora/requestlog"
 	"github.com/pkg/errors"
 
+	"github.com/IOTech17/neo-rport/server/accessrequests"
+	"github.com/IOTech17/neo-rport/server/alerts"
 	"github.com/IOTech17/neo-rport/server/api/message"
+	"github.com/IOTech17/neo-rport/server/api/session"
+	"github.com/IOTech17/neo-rport/server/apilog"
 	auditlog "github.com/IOTech17/neo-rport/server/auditlog/config"
+	"github.com/IOTech17/neo-rport/server/authzhook"
 	"github.com/IOTech17/neo-rport/server/bearer"
+	"github.com/IOTech17/neo-rport/server/breakglass"
 	"github.com/IOTech17/neo-rport/server/clients/clienttunnel"
+	"github.com/IOTech17/neo-rport/server/clients/versionpolicy"
+	"github.com/IOTech17/neo-rport/server/loginpolicy"
 	"github.com/IOTech17/neo-rport/server/ports"
+	"github.com/IOTech17/neo-rport/server/quotas"
+	"github.com/IOTech17/neo-rport/server/secretscan"
+	"github.com/IOTech17/neo-rport/server/tripwire"
 	chshare "github.com/IOTech17/neo-rport/share"
 	"github.com/IOTech17/neo-rport/share/email"
+	"github.com/IOTech17/neo-rport/share/fips"
 	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/sshpolicy"
 )
 
 type APIConfig struct {
@@ -63,6 +76,7 @@ type APIConfig struct {
 	UserLoginWait          float32  `mapstructure:"user_login_wait"`
 	MaxFailedLogin         int      `mapstructure:"max_failed_login"`
 	BanTime                int      `mapstructure:"ban_time"`
+	BanHook                string   `mapstructure:"ban_hook"`
 	MaxTokenLifeTimeHours  int      `mapstructure:"max_token_lifetime"`
 	PasswordMinLength      int      `mapstructure:"password_min_length"`
 	PasswordZxcvbnMinscore int      `mapstructure:"password_zxcvbn_minscore"`
@@ -71,6 +85,7 @@ type APIConfig struct {
 	MaxRequestBytes        int64    `mapstructure:"max_request_bytes"`
 	MaxFilePushSize        int64    `mapstructure:"max_filepush_size"`
 	CORS                   []string `mapstructure:"cors"`
+	ProxyProtocol          bool     `mapstructure:"proxy_protocol"`
 
 	TwoFATokenDelivery       string                 `mapstructure:"two_fa_token_delivery"`
 	TwoFATokenTTLSeconds     int                    `mapstructure:"two_fa_token_ttl_seconds"`
@@ -80,15 +95,36 @@ type APIConfig struct {
 	TwoFASendToRegexCompiled *regexp.Regexp
 
 	AuditLog                auditlog.Config `mapstructure:",squash"`
+	RequestLog              apilog.Config   `mapstructure:",squash"`
 	TotPEnabled             bool            `mapstructure:"totp_enabled"`
 	TotPLoginSessionTimeout time.Duration   `mapstructure:"totp_login_session_ttl"`
 	TotPAccountName         string          `mapstructure:"totp_account_name"`
+	HealthChecksRequireAuth bool            `mapstructure:"health_checks_require_auth"`
+
+	ConcurrentSessionsPolicy session.ConcurrentSessionsPolicy `mapstructure:"concurrent_sessions_policy"`
+	MaxConcurrentSessions    int                              `mapstructure:"max_concurrent_sessions"`
+	NotifyNewLogins          bool                             `mapstructure:"notify_new_logins"`
+	NotifyLockouts           bool                             `mapstructure:"notify_lockouts"`
+	// SuperAdmins are the administrators allowed to toggle the kill switches, all administrators if empty.
+	SuperAdmins []string `mapstructure:"super_admins"`
+	// ChangeJournalRetention is how long changes to client groups, ACLs and schedules can be reverted, 0 disables it.
+	ChangeJournalRetention time.Duration `mapstructure:"change_journal_retention"`
 }
 
 func (c *APIConfig) IsTwoFAOn() bool {
 	return c.TwoFATokenDelivery != ""
 }
 
+func (c *APIConfig) parseAndValidateSessionPolicy() error {
+	if c.ConcurrentSessionsPolicy == "" {
+		c.ConcurrentSessionsPolicy = session.ConcurrentSessionsAllow
+	}
+	if err := c.ConcurrentSessionsPolicy.Validate(c.MaxConcurrentSessions); err != nil {
+		return fmt.Errorf("invalid 'concurrent_sessions_policy': %v", err)
+	}
+	return nil
+}
+
 func (c *APIConfig) parseAndValidate2FASendToType() error {
 	if c.TwoFASendToType != message.ValidationNone &&
 		c.TwoFASendToType != message.ValidationEmail &&
@@ -126,6 +162,7 @@ type ServerConfig struct {
 	ListenAddress                        string                                 `mapstructure:"address"`
 	URL                                  []string                               `mapstructure:"url"`
 	PairingURL                           string                                 `mapstructure:"pairing_url"`
+	InstallerDownloadURL                 string                                 `mapstructure:"installer_download_url"`
 	KeySeed                              string                                 `mapstructure:"key_seed"`
 	Auth                                 string                                 `mapstructure:"auth"`
 	AuthFile                             string                                 `mapstructure:"auth_file"`
@@ -142,6 +179,7 @@ type ServerConfig struct {
 	KeepDisconnectedClients              time.Duration                          `mapstructure:"keep_disconnected_clients"`
 	CleanupClientsInterval               time.Duration                          `mapstructure:"cleanup_clients_interval" replaced_by:"PurgeDisconnectedClientsInterval"`
 	PurgeDisconnectedClientsInterval     time.Duration                          `mapstructure:"purge_disconnected_clients_interval"`
+	EphemeralClientsGracePeriod          time.Duration                          `mapstructure:"ephemeral_clients_grace_period"`
 	CheckClientsConnectionInterval       time.Duration                          `mapstructure:"check_clients_connection_interval"`
 	CheckClientsConnectionTimeout        time.Duration                          `mapstructure:"check_clients_connection_timeout"`
 	MaxRequestBytesClient                int64                                  `mapstructure:"max_request_bytes_client"`
@@ -151,12 +189,30 @@ type ServerConfig struct {
 	AuthMultiuseCreds                    bool                                   `mapstructure:"auth_multiuse_creds"`
 	EquateClientauthidClientid           bool                                   `mapstructure:"equate_clientauthid_clientid"`
 	AllowRoot                            bool                                   `mapstructure:"allow_root"`
+	AllowSimulatedClients                bool                                   `mapstructure:"allow_simulated_clients"`
+	ChaosTesting                         bool                                   `mapstructure:"chaos_testing"`
 	ClientLoginWait                      float32                                `mapstructure:"client_login_wait"`
 	MaxFailedLogin                       int                                    `mapstructure:"max_failed_login"`
 	BanTime                              int                                    `mapstructure:"ban_time"`
+	BanHook                              string                                 `mapstructure:"ban_hook"`
 	InternalTunnelProxyConfig            clienttunnel.InternalTunnelProxyConfig `mapstructure:",squash"`
 	JobsMaxResults                       int                                    `mapstructure:"jobs_max_results"`
 	AcmeHTTPPort                         int                                    `mapstructure:"acme_http_port"`
+	Role                                 string                                 `mapstructure:"role"`
+	ConnectorAPIURL                      string                                 `mapstructure:"connector_api_url"`
+	GatewaySecret                        string                                 `mapstructure:"gateway_secret"`
+	FIPSMode                             bool                                   `mapstructure:"fips_mode"`
+	SecurityPostureInterval              time.Duration                          `mapstructure:"security_posture_interval"`
+	TunnelConnectionsRetention           time.Duration                          `mapstructure:"tunnel_connections_retention"`
+	SerialSessionsRetention              time.Duration                          `mapstructure:"serial_sessions_retention"`
+	IndustrialValuesRetention            time.Duration                          `mapstructure:"industrial_values_retention"`
+	SSHPolicy                            sshpolicy.Policy                       `mapstructure:",squash"`
+	VersionPolicy                        versionpolicy.Policy                   `mapstructure:",squash"`
+	Banner                               string                                 `mapstructure:"banner"`
+	BannerFile                           string                                 `mapstructure:"banner_file"`
+	ProxyProtocol                        bool                                   `mapstructure:"proxy_protocol"`
+	TrustedProxiesRaw                    []string                               `mapstructure:"trusted_proxies"`
+	TrustedProxies                       []*net.IPNet
 
 	// DEPRECATED, only here for backwards compatibility
 	MaxRequestBytes       int64 `mapstructure:"max_request_bytes"`
@@ -328,20 +384,30 @@ func (n *NotificationsConfig) parseAndValidateCleanup() error {
 }
 
 type Config struct {
-	Server        ServerConfig         `mapstructure:"server"`
-	Caddy         caddy.Config         `mapstructure:"caddy-integration"`
-	Logging       LogConfig            `mapstructure:"logging"`
-	API           APIConfig            `mapstructure:"api"`
-	Database      DatabaseConfig       `mapstructure:"database"`
-	Pushover      PushoverConfig       `mapstructure:"pushover"`
-	SMTP          SMTPConfig           `mapstructure:"smtp"`
-	Monitoring    MonitoringConfig     `mapstructure:"monitoring"`
-	Notifications NotificationsConfig  `mapstructure:"notifications"`
-	PlusConfig    rportplus.PlusConfig `mapstructure:",squash"`
+	Server         ServerConfig          `mapstructure:"server"`
+	Caddy          caddy.Config          `mapstructure:"caddy-integration"`
+	Logging        LogConfig             `mapstructure:"logging"`
+	API            APIConfig             `mapstructure:"api"`
+	Database       DatabaseConfig        `mapstructure:"database"`
+	Pushover       PushoverConfig        `mapstructure:"pushover"`
+	SMTP           SMTPConfig            `mapstructure:"smtp"`
+	Monitoring     MonitoringConfig      `mapstructure:"monitoring"`
+	Notifications  NotificationsConfig   `mapstructure:"notifications"`
+	Manifests      ManifestsConfig       `mapstructure:"manifests"`
+	Tripwire       tripwire.Config       `mapstructure:"tripwire"`
+	BreakGlass     breakglass.Config     `mapstructure:"break-glass"`
+	LoginPolicy    loginpolicy.Config    `mapstructure:"login-policy"`
+	AuthzHook      authzhook.Config      `mapstructure:"authz-hook"`
+	SecretsScan    secretscan.Config     `mapstructure:"secrets-scanning"`
+	Alerting       alerts.Config         `mapstructure:"alerting"`
+	AccessRequests accessrequests.Config `mapstructure:"access-requests"`
+	Quotas         []quotas.Quota        `mapstructure:"quotas"`
+	PlusConfig     rportplus.PlusConfig  `mapstructure:",squash"`
 }
 
 var (
 	CheckClientsConnectionIntervalMinimum = time.Minute * 2
+	SecurityPostureIntervalMinimum        = time.Minute
 )
 
 func (c *Config) GetVaultDBPath() string {
@@ -391,10 +457,22 @@ func (c *Config) ParseAndValidate(mLog *logger.MemLogger) error {
 		mLog.Info("server setting 'enable_ws_test_endpoints' is deprecated and will be removed soon. Use the setting in api section instead.")
 	}
 
+	if err := c.Server.parseAndValidateRole(); err != nil {
+		return err
+	}
+
 	if err := c.Server.parseAndValidateURLs(); err != nil {
 		return err
 	}
 
+	if err := c.Server.parseAndValidateBanner(); err != nil {
+		return err
+	}
+
+	if err := c.parseAndValidateTrustedProxies(); err != nil {
+		return err
+	}
+
 	if err := c.Server.parseAndValidatePorts(); err != nil {
 		return err
 	}
@@ -421,10 +499,18 @@ func (c *Config) ParseAndValidate(mLog *logger.MemLogger) error {
 		return fmt.Errorf("expected 'Keep Lost Clients' can be in range [%v, %v], actual: %v", MinKeepDisconnectedClients, MaxKeepDisconnectedClients, c.Server.KeepDisconnectedClients)
 	}
 
+	if c.Server.EphemeralClientsGracePeriod < 0 || c.Server.EphemeralClientsGracePeriod > MaxKeepDisconnectedClients {
+		return fmt.Errorf("expected 'ephemeral_clients_grace_period' can be in range [0, %v], actual: %v", MaxKeepDisconnectedClients, c.Server.EphemeralClientsGracePeriod)
+	}
+
 	if err := c.parseAndValidateClientAuth(); err != nil {
 		return err
 	}
 
+	if err := validateBanHook(c.Server.BanHook); err != nil {
+		return err
+	}
+
 	if err := c.parseAndValidateAPI(mLog); err != nil {
 		return fmt.Errorf("API: %v", err)
 	}
@@ -441,6 +527,19 @@ func (c *Config) ParseAndValidate(mLog *logger.MemLogger) error {
 		mLog.Infof("warning: allowing too many concurrent ssh handhakes ('max_concurrent_ssh_handshakes') will slow down the server significantly and cause operational reliability issues. Please use a value less than or equal to the MAX_PROCS (%d)", maxProcs)
 	}
 
+	if c.Server.SecurityPostureInterval < 0 || (c.Server.SecurityPostureInterval > 0 && c.Server.SecurityPostureInterval < SecurityPostureIntervalMinimum) {
+		return fmt.Errorf("'security_posture_interval' must be 0 to disable it or at least %s", SecurityPostureIntervalMinimum)
+	}
+	if c.Server.TunnelConnectionsRetention < 0 {
+		return errors.New("'tunnel_connections_retention' must not be negative")
+	}
+	if c.Server.SerialSessionsRetention < 0 {
+		return errors.New("'serial_sessions_retention' must not be negative")
+	}
+	if c.Server.IndustrialValuesRetention < 0 {
+		return errors.New("'industrial_values_retention' must not be negative")
+	}
+
 	if c.Server.CheckClientsConnectionInterval < CheckClientsConnectionIntervalMinimum {
 		c.Server.CheckClientsConnectionInterval = CheckClientsConnectionIntervalMinimum
 		mLog.Errorf("'check_clients_status_interval' too fast. Using the minimum possible of %s", CheckClientsConnectionIntervalMinimum)
@@ -454,6 +553,98 @@ func (c *Config) ParseAndValidate(mLog *logger.MemLogger) error {
 		return err
 	}
 
+	if err := c.Manifests.parseAndValidate(); err != nil {
+		return err
+	}
+
+	if err := c.parseAndValidateFIPS(mLog); err != nil {
+		return err
+	}
+
+	if err := c.parseAndValidateTripwire(mLog); err != nil {
+		return err
+	}
+	if err := c.parseAndValidateBreakGlass(); err != nil {
+		return err
+	}
+	if err := c.parseAndValidateLoginPolicy(); err != nil {
+		return err
+	}
+	if err := c.AuthzHook.Validate(); err != nil {
+		return fmt.Errorf("authz-hook: %w", err)
+	}
+
+	if _, err := secretscan.New(c.SecretsScan); err != nil {
+		return fmt.Errorf("secrets-scanning.allowlist: %v", err)
+	}
+
+	if err := c.Alerting.Validate(); err != nil {
+		return fmt.Errorf("alerting: %v", err)
+	}
+
+	if err := c.parseAndValidateAccessRequests(); err != nil {
+		return err
+	}
+
+	if err := rportplus.ValidateOAuthProviders(c.PlusConfig); err != nil {
+		return err
+	}
+
+	if err := quotas.Validate(c.Quotas); err != nil {
+		return fmt.Errorf("quotas: %v", err)
+	}
+
+	if err := c.Server.SSHPolicy.Validate(c.Server.FIPSEnabled()); err != nil {
+		return err
+	}
+	for _, warning := range c.Server.SSHPolicy.Deprecated() {
+		mLog.Infof("warning: %s", warning)
+	}
+
+	if err := c.Server.VersionPolicy.Validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// FIPSEnabled returns true if the fips mode is configured or the server was built with BoringCrypto.
+func (s *ServerConfig) FIPSEnabled() bool {
+	return s.FIPSMode || fips.BoringCrypto()
+}
+
+func (c *Config) parseAndValidateFIPS(mLog *logger.MemLogger) error {
+	if !c.Server.FIPSEnabled() {
+		return nil
+	}
+	if c.API.TLSMin == "1.3" {
+		return errors.New("api 'tls_min' 1.3 can't be used in fips mode, TLS 1.3 is disabled")
+	}
+	if c.Server.InternalTunnelProxyConfig.TLSMin == "1.3" {
+		return errors.New("server 'tls_min' 1.3 can't be used in fips mode, TLS 1.3 is disabled")
+	}
+	certs := map[string][2]string{
+		"'cert_file', 'key_file'": {c.API.CertFile, c.API.KeyFile},
+		"'tunnel_proxy_cert_file', 'tunnel_proxy_key_file'": {
+			c.Server.InternalTunnelProxyConfig.CertFile,
+			c.Server.InternalTunnelProxyConfig.KeyFile,
+		},
+	}
+	for name, files := range certs {
+		if files[0] == "" || files[1] == "" {
+			continue
+		}
+		cert, err := tls.LoadX509KeyPair(files[0], files[1])
+		if err != nil {
+			return fmt.Errorf("invalid %s: %v", name, err)
+		}
+		if err := fips.ValidateCertificate(cert); err != nil {
+			return fmt.Errorf("invalid %s: %v", name, err)
+		}
+	}
+	if c.Server.KeySeed != "" {
+		mLog.Infof("warning: in fips mode the server key should be generated randomly, 'key_seed' derives it by a deterministic generator which is not FIPS approved")
+	}
 	return nil
 }
 
@@ -527,6 +718,14 @@ func (c *Config) parseAndValidateAPI(mLog *logger.MemLogger) error {
 
 		c.API.CORS = parseAndValidateCORS(mLog, c.API.CORS)
 
+		if err := c.API.parseAndValidateSessionPolicy(); err != nil {
+			return err
+		}
+
+		if err := validateBanHook(c.API.BanHook); err != nil {
+			return err
+		}
+
 	} else {
 		// API disabled
 		if c.API.DocRoot != "" {
@@ -538,6 +737,11 @@ func (c *Config) parseAndValidateAPI(mLog *logger.MemLogger) error {
 		return err
 	}
 
+	err = c.API.RequestLog.Validate()
+	if err != nil {
+		return err
+	}
+
 	err = c.validateAPIWhenCaddyIntegration()
 	if err != nil {
 		return err
@@ -633,6 +837,38 @@ func matchingPorts(address1 string, address2 string) (matching bool, err error)
 	return port1 == port2, nil
 }
 
+// ManifestsConfig enables reconciling client groups, users and schedules from manifest files.
+type ManifestsConfig struct {
+	Dir               string        `mapstructure:"dir"`
+	ReconcileInterval time.Duration `mapstructure:"reconcile_interval"`
+	Prune             bool          `mapstructure:"prune"`
+}
+
+const MinManifestsReconcileInterval = 10 * time.Second
+
+func (mc *ManifestsConfig) Enabled() bool {
+	return mc.Dir != ""
+}
+
+func (mc *ManifestsConfig) parseAndValidate() error {
+	if !mc.Enabled() {
+		return nil
+	}
+
+	info, err := os.Stat(mc.Dir)
+	if err != nil {
+		return fmt.Errorf("manifests.dir: %v", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("manifests.dir: %s is not a directory", mc.Dir)
+	}
+
+	if mc.ReconcileInterval < MinManifestsReconcileInterval {
+		return fmt.Errorf("manifests.reconcile_interval must be at least %s", MinManifestsReconcileInterval)
+	}
+	return nil
+}
+
 func (mc *MonitoringConfig) parseAndValidateMonitoring(mLog *logger.MemLogger) (err error) {
 	if !mc.Enabled {
 		return nil
@@ -821,10 +1057,95 @@ func (s *ServerConfig) parseAndValidateURLs() error {
 			return errors.Wrap(err, "server.pairingURL")
 		}
 	}
+	if len(s.InstallerDownloadURL) != 0 {
+		if err := validateHTTPorHTTPSURL(s.InstallerDownloadURL); err != nil {
+			return errors.Wrap(err, "server.installer_download_url")
+		}
+	}
 
 	return nil
 }
 
+const (
+	// RoleAll runs the client listener and the API in one process.
+	RoleAll = "all"
+	// RoleConnector holds the client connections and serves the API to the API gateways.
+	RoleConnector = "connector"
+	// RoleAPI runs a stateless API gateway forwarding to a connector, it can be restarted and scaled freely.
+	RoleAPI = "api"
+)
+
+func (s *ServerConfig) parseAndValidateRole() error {
+	switch s.Role {
+	case "":
+		s.Role = RoleAll
+	case RoleAll, RoleConnector:
+	case RoleAPI:
+		if s.ConnectorAPIURL == "" {
+			return errors.New("'connector_api_url' is required for role 'api'")
+		}
+		if err := validateHTTPorHTTPSURL(s.ConnectorAPIURL); err != nil {
+			return errors.Wrap(err, "server.connector_api_url")
+		}
+	default:
+		return fmt.Errorf("invalid 'role' %q, expected one of: %s, %s, %s", s.Role, RoleAll, RoleConnector, RoleAPI)
+	}
+	return nil
+}
+
+// BannerMaxBytes limits the banner, it's sent to every client on connect.
+const BannerMaxBytes = 16 * 1024
+
+func (s *ServerConfig) parseAndValidateBanner() error {
+	if s.BannerFile != "" {
+		if s.Banner != "" {
+			return errors.New("'banner' and 'banner_file' cannot be used together")
+		}
+		b, err := os.ReadFile(s.BannerFile)
+		if err != nil {
+			return errors.Wrap(err, "server.banner_file")
+		}
+		s.Banner = string(b)
+	}
+	s.Banner = strings.TrimSpace(s.Banner)
+	if len(s.Banner) > BannerMaxBytes {
+		return fmt.Errorf("banner of %d bytes exceeds the limit of %d bytes", len(s.Banner), BannerMaxBytes)
+	}
+	s.InternalTunnelProxyConfig.Banner = s.Banner
+	return nil
+}
+
+func (c *Config) parseAndValidateTrustedProxies() error {
+	trusted, err := chshare.ParseTrustedProxies(c.Server.TrustedProxiesRaw)
+	if err != nil {
+		return errors.Wrap(err, "server.trusted_proxies")
+	}
+	if len(trusted) == 0 && c.Server.ProxyProtocol {
+		return errors.New("'server.proxy_protocol' requires 'server.trusted_proxies'")
+	}
+	if len(trusted) == 0 && c.API.ProxyProtocol {
+		return errors.New("'api.proxy_protocol' requires 'server.trusted_proxies'")
+	}
+	c.Server.TrustedProxies = trusted
+	c.Server.InternalTunnelProxyConfig.TrustedProxies = trusted
+	return nil
+}
+
+// IsAPIGateway returns true if the process only forwards API requests to a connector.
+func (s *ServerConfig) IsAPIGateway() bool {
+	return s.Role == RoleAPI
+}
+
+func validateBanHook(hook string) error {
+	if hook == "" {
+		return nil
+	}
+	if _, err := exec.LookPath(hook); err != nil {
+		return fmt.Errorf("ban_hook: %v", err)
+	}
+	return nil
+}
+
 func validateHTTPorHTTPSURL(testURL string) error {
 	u, err := url.ParseRequestURI(testURL)
 	if err != nil {
@@ -930,3 +1251,76 @@ func validateCORSOrigin(c string) error {
 	}
 	return nil
 }
+
+func (c *Config) parseAndValidateAccessRequests() error {
+	ac := c.AccessRequests
+	if err := ac.Validate(); err != nil {
+		return fmt.Errorf("access-requests: %v", err)
+	}
+	if len(ac.NotifyEmails) > 0 && c.SMTP.Server == "" {
+		return errors.New("access-requests.notify_emails requires the [smtp] section to be configured")
+	}
+	if ac.NotifyScript != "" {
+		if _, err := exec.LookPath(ac.NotifyScript); err != nil {
+			return fmt.Errorf("access-requests.notify_script: %v", err)
+		}
+	}
+	return nil
+}
+
+func (c *Config) parseAndValidateBreakGlass() error {
+	bc := c.BreakGlass
+	if len(bc.AlertEmails) > 0 && c.SMTP.Server == "" {
+		return errors.New("break-glass.alert_emails requires the [smtp] section to be configured")
+	}
+	if bc.AlertScript != "" {
+		if _, err := exec.LookPath(bc.AlertScript); err != nil {
+			return fmt.Errorf("break-glass.alert_script: %v", err)
+		}
+	}
+	return nil
+}
+
+func (c *Config) parseAndValidateLoginPolicy() error {
+	lc := &c.LoginPolicy
+	if err := lc.Validate(); err != nil {
+		return fmt.Errorf("login-policy: %w", err)
+	}
+	if (lc.UsesStepUp() || lc.TwoFAOnlyOnStepUp) && !c.API.IsTwoFAOn() && !c.API.TotPEnabled {
+		return errors.New("login-policy: a step-up requires api.two_fa_token_delivery or api.totp_enabled")
+	}
+	return nil
+}
+
+func (c *Config) parseAndValidateTripwire(mLog *logger.MemLogger) error {
+	tc := c.Tripwire
+	if !tc.Enabled() {
+		return nil
+	}
+
+	staticClientAuthID, _, _ := strings.Cut(c.Server.Auth, ":")
+	for _, id := range tc.DecoyClientAuthIDs {
+		if id == "" || (c.Server.Auth != "" && id == staticClientAuthID) {
+			return fmt.Errorf("tripwire.decoy_client_auth_ids: invalid decoy %q, it must not be empty or a valid client auth id", id)
+		}
+	}
+	staticAPIUser, _, _ := strings.Cut(c.API.Auth, ":")
+	for _, username := range tc.DecoyAPIUsers {
+		if username == "" || (c.API.Auth != "" && username == staticAPIUser) {
+			return fmt.Errorf("tripwire.decoy_api_users: invalid decoy %q, it must not be empty or a valid user", username)
+		}
+	}
+
+	if len(tc.AlertEmails) > 0 && c.SMTP.Server == "" {
+		return errors.New("tripwire.alert_emails requires the [smtp] section to be configured")
+	}
+	if tc.AlertScript != "" {
+		if _, err := exec.LookPath(tc.AlertScript); err != nil {
+			return fmt.Errorf("tripwire.alert_script: %v", err)
+		}
+	}
+	if len(tc.AlertEmails) == 0 && tc.AlertScript == "" {
+		mLog.Infof("warning: neither tripwire.alert_emails nor tripwire.alert_script is set, usage of decoy credentials is only logged")
+	}
+	return nil
+}
@@ -1,13 +1,19 @@
 package chconfig
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/IOTech17/neo-rport/server/api/message"
 	"github.com/IOTech17/neo-rport/server/caddy"
 	"github.com/IOTech17/neo-rport/server/clients/clienttunnel"
+	"github.com/IOTech17/neo-rport/server/loginpolicy"
+	"github.com/IOTech17/neo-rport/server/tripwire"
 	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/sshpolicy"
 
 	mapset "github.com/deckarep/golang-set"
 	"github.com/stretchr/testify/assert"
@@ -38,6 +44,16 @@ func TestParseAndValidateServerConfig(t *testing.T) {
 			},
 			ExpectedError: "server.pairingURL: invalid url ftp:example.com: schema must be http or https",
 		},
+		{
+			Name: "Bad installer download URL",
+			Config: Config{
+				Server: ServerConfig{
+					InstallerDownloadURL: "ftp://example.com/rport_{os}_{arch}.{ext}",
+					URL:                  []string{"http://www.example.com"},
+				},
+			},
+			ExpectedError: "server.installer_download_url: invalid url ftp://example.com/rport_{os}_{arch}.{ext}: schema must be http or https",
+		},
 		{
 			Name: "invalid tls_min version in InternalTunnelProxyConfig",
 			Config: Config{
@@ -95,6 +111,157 @@ func TestParseAndValidateServerConfig(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "Invalid role",
+			Config: Config{
+				Server: ServerConfig{
+					URL:  []string{"http://localhost/"},
+					Role: "worker",
+				},
+			},
+			ExpectedError: `invalid 'role' "worker", expected one of: all, connector, api`,
+		},
+		{
+			Name: "Role api without connector api url",
+			Config: Config{
+				Server: ServerConfig{
+					URL:  []string{"http://localhost/"},
+					Role: RoleAPI,
+				},
+			},
+			ExpectedError: "'connector_api_url' is required for role 'api'",
+		},
+		{
+			Name: "Role api with bad connector api url",
+			Config: Config{
+				Server: ServerConfig{
+					URL:             []string{"http://localhost/"},
+					Role:            RoleAPI,
+					ConnectorAPIURL: "ftp://connector.local",
+				},
+			},
+			ExpectedError: "server.connector_api_url: invalid url ftp://connector.local: schema must be http or https",
+		},
+		{
+			Name: "TLS 1.3 in fips mode",
+			Config: Config{
+				Server: ServerConfig{
+					URL:          []string{"http://localhost/"},
+					DataDir:      "./",
+					Auth:         "abc:def",
+					UsedPortsRaw: []string{"10-20"},
+					FIPSMode:     true,
+				},
+				API: APIConfig{
+					TLSMin: "1.3",
+				},
+			},
+			ExpectedError: "api 'tls_min' 1.3 can't be used in fips mode, TLS 1.3 is disabled",
+		},
+		{
+			Name: "unsupported ssh mac",
+			Config: Config{
+				Server: ServerConfig{
+					URL:          []string{"http://localhost/"},
+					DataDir:      "./",
+					Auth:         "abc:def",
+					UsedPortsRaw: []string{"10-20"},
+					SSHPolicy:    sshpolicy.Policy{MACs: []string{"hmac-md5"}},
+				},
+			},
+			ExpectedError: `'ssh_macs': unsupported algorithm "hmac-md5", supported are: hmac-sha2-256-etm@openssh.com, hmac-sha2-256, hmac-sha1, hmac-sha1-96`,
+		},
+		{
+			Name: "fips mode",
+			Config: Config{
+				Server: ServerConfig{
+					URL:          []string{"http://localhost/"},
+					DataDir:      "./",
+					Auth:         "abc:def",
+					UsedPortsRaw: []string{"10-20"},
+					FIPSMode:     true,
+					InternalTunnelProxyConfig: clienttunnel.InternalTunnelProxyConfig{
+						CertFile: "../../testdata/certs/tunnels.rport.test.crt",
+						KeyFile:  "../../testdata/certs/tunnels.rport.test.key",
+					},
+				},
+			},
+		},
+		{
+			Name: "tripwire decoy is the static client auth",
+			Config: Config{
+				Server: ServerConfig{
+					URL:          []string{"http://localhost/"},
+					DataDir:      "./",
+					Auth:         "abc:def",
+					UsedPortsRaw: []string{"10-20"},
+				},
+				Tripwire: tripwire.Config{
+					DecoyClientAuthIDs: []string{"abc"},
+				},
+			},
+			ExpectedError: `tripwire.decoy_client_auth_ids: invalid decoy "abc", it must not be empty or a valid client auth id`,
+		},
+		{
+			Name: "tripwire alert emails without smtp",
+			Config: Config{
+				Server: ServerConfig{
+					URL:          []string{"http://localhost/"},
+					DataDir:      "./",
+					Auth:         "abc:def",
+					UsedPortsRaw: []string{"10-20"},
+				},
+				Tripwire: tripwire.Config{
+					DecoyAPIUsers: []string{"svc-backup"},
+					AlertEmails:   []string{"security@example.com"},
+				},
+			},
+			ExpectedError: "tripwire.alert_emails requires the [smtp] section to be configured",
+		},
+		{
+			Name: "tripwire",
+			Config: Config{
+				Server: ServerConfig{
+					URL:          []string{"http://localhost/"},
+					DataDir:      "./",
+					Auth:         "abc:def",
+					UsedPortsRaw: []string{"10-20"},
+				},
+				Tripwire: tripwire.Config{
+					DecoyClientAuthIDs: []string{"backup-client"},
+					DecoyAPIUsers:      []string{"svc-backup"},
+				},
+			},
+		},
+		{
+			Name: "login policy step-up without 2fa",
+			Config: Config{
+				Server: ServerConfig{
+					URL:          []string{"http://localhost/"},
+					DataDir:      "./",
+					Auth:         "abc:def",
+					UsedPortsRaw: []string{"10-20"},
+				},
+				LoginPolicy: loginpolicy.Config{
+					AnonymizerLists: []string{"tor-exits.txt"},
+					Rules:           []loginpolicy.Rule{{Anonymizer: loginpolicy.ActionStepUp}},
+				},
+			},
+			ExpectedError: "login-policy: a step-up requires api.two_fa_token_delivery or api.totp_enabled",
+		},
+		{
+			Name: "Role connector",
+			Config: Config{
+				Server: ServerConfig{
+					URL:           []string{"http://localhost/"},
+					DataDir:       "./",
+					Auth:          "abc:def",
+					UsedPortsRaw:  []string{"10-20"},
+					Role:          RoleConnector,
+					GatewaySecret: "secret",
+				},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -323,6 +490,16 @@ func TestParseAndValidateAPI(t *testing.T) {
 				},
 			},
 			ExpectedError: "API: 'auth_file' and 'auth' are both set: expected only one of them",
+		}, {
+			Name: "api enabled, invalid concurrent sessions policy",
+			Config: Config{
+				API: APIConfig{
+					Address:                  "0.0.0.0:3000",
+					Auth:                     "abc:def",
+					ConcurrentSessionsPolicy: "kick_oldest",
+				},
+			},
+			ExpectedError: "API: invalid 'concurrent_sessions_policy': policy \"kick_oldest\" requires max sessions of at least 1",
 		}, {
 			Name: "api enabled, auth and auth_user_table",
 			Config: Config{
@@ -857,3 +1034,100 @@ func TestParseAndValidateCORS(t *testing.T) {
 	}
 	assert.Equal(t, expected, result)
 }
+
+func TestParseAndValidateBanner(t *testing.T) {
+	bannerFile := filepath.Join(t.TempDir(), "banner.txt")
+	require.NoError(t, os.WriteFile(bannerFile, []byte("Authorized use only.\n"), 0600))
+
+	testCases := []struct {
+		name           string
+		server         ServerConfig
+		expectedBanner string
+		expectedErr    string
+	}{
+		{
+			name:           "banner",
+			server:         ServerConfig{Banner: " Authorized use only.\n"},
+			expectedBanner: "Authorized use only.",
+		},
+		{
+			name:           "banner file",
+			server:         ServerConfig{BannerFile: bannerFile},
+			expectedBanner: "Authorized use only.",
+		},
+		{
+			name:        "both",
+			server:      ServerConfig{Banner: "notice", BannerFile: bannerFile},
+			expectedErr: "'banner' and 'banner_file' cannot be used together",
+		},
+		{
+			name:        "too long",
+			server:      ServerConfig{Banner: strings.Repeat("x", BannerMaxBytes+1)},
+			expectedErr: "banner of 16385 bytes exceeds the limit of 16384 bytes",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.server.parseAndValidateBanner()
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedBanner, tc.server.Banner)
+			assert.Equal(t, tc.expectedBanner, tc.server.InternalTunnelProxyConfig.Banner)
+		})
+	}
+}
+
+func TestParseAndValidateTrustedProxies(t *testing.T) {
+	testCases := []struct {
+		name            string
+		config          Config
+		expectedTrusted []string
+		expectedErr     string
+	}{
+		{
+			name: "none",
+		},
+		{
+			name: "ips and ranges",
+			config: Config{
+				Server: ServerConfig{ProxyProtocol: true, TrustedProxiesRaw: []string{"10.0.0.0/8", "192.0.2.1", "2001:db8::/32"}},
+				API:    APIConfig{ProxyProtocol: true},
+			},
+			expectedTrusted: []string{"10.0.0.0/8", "192.0.2.1/32", "2001:db8::/32"},
+		},
+		{
+			name:        "invalid",
+			config:      Config{Server: ServerConfig{TrustedProxiesRaw: []string{"10.0.0.300"}}},
+			expectedErr: `server.trusted_proxies: invalid IP address "10.0.0.300"`,
+		},
+		{
+			name:        "server proxy protocol without trusted proxies",
+			config:      Config{Server: ServerConfig{ProxyProtocol: true}},
+			expectedErr: "'server.proxy_protocol' requires 'server.trusted_proxies'",
+		},
+		{
+			name:        "api proxy protocol without trusted proxies",
+			config:      Config{API: APIConfig{ProxyProtocol: true}},
+			expectedErr: "'api.proxy_protocol' requires 'server.trusted_proxies'",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.config.parseAndValidateTrustedProxies()
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			var trusted []string
+			for _, n := range tc.config.Server.TrustedProxies {
+				trusted = append(trusted, n.String())
+			}
+			assert.Equal(t, tc.expectedTrusted, trusted)
+			assert.Equal(t, tc.config.Server.TrustedProxies, tc.config.Server.InternalTunnelProxyConfig.TrustedProxies)
+		})
+	}
+}
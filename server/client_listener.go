@@ -25,11 +25,14 @@ import (
 	rportplus "github.com/IOTech17/neo-rport/plus"
 	alertingcap "github.com/IOTech17/neo-rport/plus/capabilities/alerting"
 	"github.com/IOTech17/neo-rport/plus/capabilities/alerting/transformers"
+	"github.com/IOTech17/neo-rport/server/api/jobs"
 	"github.com/IOTech17/neo-rport/server/api/middleware"
 	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/chaos"
 	"github.com/IOTech17/neo-rport/server/chconfig"
 	"github.com/IOTech17/neo-rport/server/clients"
 	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/tripwire"
 	chshare "github.com/IOTech17/neo-rport/share"
 	"github.com/IOTech17/neo-rport/share/comm"
 	"github.com/IOTech17/neo-rport/share/logger"
@@ -102,10 +105,15 @@ func NewClientListener(server *Server, privateKey ssh.Signer) (*ClientListener,
 	// semaphore to limit number of active pending SSH connections
 	inprogressSSHHandshakes := make(chan struct{}, config.Server.MaxConcurrentSSHConnectionHandshakes)
 
+	var httpServerOptions []chshare.ServerOption
+	if config.Server.ProxyProtocol {
+		httpServerOptions = append(httpServerOptions, chshare.WithProxyProtocol(config.Server.TrustedProxies))
+	}
+
 	clog := logger.NewLogger("client-listener", config.Logging.LogOutput, config.Logging.LogLevel)
 	cl := &ClientListener{
 		server:                  server,
-		httpServer:              chshare.NewHTTPServer(int(config.Server.MaxRequestBytesClient), clog),
+		httpServer:              chshare.NewHTTPServer(int(config.Server.MaxRequestBytesClient), clog, httpServerOptions...),
 		requestLogOptions:       config.InitRequestLogOptions(),
 		bannedClientAuths:       security.NewBanList(time.Duration(config.Server.ClientLoginWait) * time.Second),
 		inprogressSSHHandshakes: inprogressSSHHandshakes,
@@ -118,6 +126,9 @@ func NewClientListener(server *Server, privateKey ssh.Signer) (*ClientListener,
 			time.Duration(config.Server.BanTime)*time.Second,
 			cl.logger,
 		)
+		if config.Server.BanHook != "" {
+			cl.bannedIPs.SetHook(newBanHook(config.Server.BanHook, banListenerClient, cl.logger))
+		}
 	}
 
 	// create ssh config
@@ -125,7 +136,13 @@ func NewClientListener(server *Server, privateKey ssh.Signer) (*ClientListener,
 		ServerVersion:    "SSH-" + chshare.ProtocolVersion + "-server",
 		PasswordCallback: cl.authUser,
 	}
+	if banner := config.Server.Banner; banner != "" {
+		cl.sshConfig.BannerCallback = func(ssh.ConnMetadata) string {
+			return banner + "\n"
+		}
+	}
 
+	config.Server.SSHPolicy.Apply(&cl.sshConfig.Config)
 	cl.sshConfig.AddHostKey(privateKey)
 
 	// setup reverse proxy
@@ -162,12 +179,21 @@ func (cl *ClientListener) authUser(c ssh.ConnMetadata, password []byte) (*ssh.Pe
 		return nil, ErrTooManyRequests
 	}
 
+	ip := cl.getIP(c.RemoteAddr())
+	if cl.server.tripwire.IsDecoyClientAuth(clientAuthID) {
+		cl.server.tripwire.Trigger(context.Background(), tripwire.KindClientAuth, clientAuthID, ip, string(c.ClientVersion()))
+		cl.bannedClientAuths.Add(clientAuthID)
+		if cl.bannedIPs != nil {
+			cl.bannedIPs.AddBadAttempt(ip)
+		}
+		return nil, fmt.Errorf("invalid authentication for client auth id: %s", clientAuthID)
+	}
+
 	clientAuth, err := cl.server.clientAuthProvider.Get(clientAuthID)
 	if err != nil {
 		return nil, err
 	}
 
-	ip := cl.getIP(c.RemoteAddr())
 	// constant time compare is used for security reasons
 	if clientAuth == nil || subtle.ConstantTimeCompare([]byte(clientAuth.Password), password) != 1 {
 		cl.log().Debugf("Login failed for client auth id: %s", clientAuthID)
@@ -211,6 +237,9 @@ func (cl *ClientListener) Start(ctx context.Context, listenAddr string) error {
 		h = security.RejectBannedIPs(cl.bannedIPs)(h)
 	}
 	h = requestlog.WrapWith(h, *cl.requestLogOptions)
+	if len(cl.server.config.Server.TrustedProxies) > 0 {
+		h = middleware.TrustedProxies(cl.server.config.Server.TrustedProxies)(h)
+	}
 
 	return cl.httpServer.GoListenAndServe(ctx, listenAddr, h)
 }
@@ -261,7 +290,7 @@ func (cl *ClientListener) nextClientIndex() int32 {
 }
 
 func (cl *ClientListener) acceptSSHConnection(w http.ResponseWriter, req *http.Request) (sshConn *ssh.ServerConn, chans <-chan ssh.NewChannel, reqs <-chan *ssh.Request,
-	clog *logger.DynamicLogger, err error) {
+	clog *logger.DynamicLogger, conn net.Conn, err error) {
 
 	// throttle concurrent connections
 	// add to pending connections. will block if the chan is full
@@ -281,9 +310,16 @@ func (cl *ClientListener) acceptSSHConnection(w http.ResponseWriter, req *http.R
 	wsConn, err := upgrader.Upgrade(w, req, nil)
 	if err != nil {
 		clog.Debugf("Failed to upgrade (%s)", err)
-		return nil, nil, nil, nil, err
+		return nil, nil, nil, nil, nil, err
+	}
+	conn = chshare.NewWebSocketConn(wsConn)
+	if req.RemoteAddr != conn.RemoteAddr().String() {
+		// the address was forwarded by a trusted proxy
+		if addr, err := net.ResolveTCPAddr("tcp", req.RemoteAddr); err == nil {
+			conn = chshare.WithRemoteAddr(conn, addr)
+		}
 	}
-	conn := chshare.NewWebSocketConn(wsConn)
+	conn = cl.server.chaos.Wrap(conn)
 	// perform SSH handshake on net.Conn
 	clog.Debugf("SSH Handshaking...")
 	sshConn, chans, reqs, err = ssh.NewServerConn(conn, cl.sshConfig)
@@ -293,11 +329,11 @@ func (cl *ClientListener) acceptSSHConnection(w http.ResponseWriter, req *http.R
 		} else {
 			clog.Debugf("Failed to handshake (%s) from %s", err, conn.RemoteAddr().String())
 		}
-		return nil, nil, nil, nil, err
+		return nil, nil, nil, nil, nil, err
 	}
 	clog.Debugf("SSH Handshake finished after %s", time.Since(ts))
 
-	return sshConn, chans, reqs, clog, err
+	return sshConn, chans, reqs, clog, conn, err
 }
 
 func (cl *ClientListener) receiveClientConnectionRequest(sshConn *ssh.ServerConn, reqs <-chan *ssh.Request, clog *logger.DynamicLogger) (connRequest *chshare.ConnectionRequest, r *ssh.Request, err error) {
@@ -347,7 +383,7 @@ func (cl *ClientListener) handleWebsocket(w http.ResponseWriter, req *http.Reque
 	// keep the time from the initial client connection attempt
 	ts1 := time.Now()
 
-	sshConn, chans, reqs, clientLog, err := cl.acceptSSHConnection(w, req)
+	sshConn, chans, reqs, clientLog, conn, err := cl.acceptSSHConnection(w, req)
 	if err != nil {
 		return
 	}
@@ -365,6 +401,14 @@ func (cl *ClientListener) handleWebsocket(w http.ResponseWriter, req *http.Reque
 	clientLog.Debugf("client version: %s", connRequest.Version)
 
 	checkVersions(clientLog, connRequest.Version)
+	if connRequest.Simulated && !cl.server.config.Server.AllowSimulatedClients {
+		cl.replyConnectionError(r, errors.New("simulated clients are not allowed, enable allow_simulated_clients"))
+		return
+	}
+	if cl.server.config.Server.VersionPolicy.DeniesTunnels(connRequest.Version) && len(connRequest.Remotes) > 0 {
+		clientLog.Infof("Not starting %d tunnels requested by client version %s, older than the minimum version", len(connRequest.Remotes), connRequest.Version)
+		connRequest.Remotes = nil
+	}
 
 	// get the current client auth id
 	clientAuthID := sshConn.User()
@@ -374,6 +418,16 @@ func (cl *ClientListener) handleWebsocket(w http.ResponseWriter, req *http.Reque
 		cl.replyConnectionError(r, fmt.Errorf("could not get clientID: %s", err))
 		return
 	}
+	if transferredID := cl.getClientService().GetTransferredClientID(clientAuthID); transferredID != "" && transferredID != clientID {
+		clientLog.Infof("Client %s connects as client %s, its identity was transferred to client auth id %q", clientID, transferredID, clientAuthID)
+		clientID = transferredID
+	}
+	if cl.server.chaos.Drops(clientID) {
+		clientLog.Infof("Dropping connection of client %s, injected by chaos testing", clientID)
+		_ = sshConn.Close()
+		return
+	}
+	chaos.Identify(conn, clientID)
 
 	ctx, cancel := context.WithCancel(cl.getCtx())
 	defer cancel()
@@ -385,18 +439,42 @@ func (cl *ClientListener) handleWebsocket(w http.ResponseWriter, req *http.Reque
 	}
 	clientLog.Debugf("Client service started for %s (%s) within %s", client.GetID(), client.GetName(), time.Since(ts1))
 
+	if err := cl.server.checkClientQuota(ctx, client); err != nil {
+		clientLog.Infof("Rejecting client %s (%s): %v", client.GetID(), client.GetName(), err)
+		cl.replyConnectionError(r, err)
+		_ = sshConn.Close()
+		if err := cl.getClientService().Terminate(client); err != nil {
+			cl.log().Errorf("could not terminate client: %s", err)
+		}
+		return
+	}
+
 	ts2 := time.Now()
 
 	cl.replyConnectionSuccess(r, connRequest.Remotes)
 	cl.sendCapabilities(sshConn)
+
+	clientGroups, err := cl.server.clientGroupProvider.GetAll(ctx)
+	if err != nil {
+		clientLog.Errorf("failed to get client groups: %v", err)
+	} else {
+		client.SetAccessSchedules(clientAccessSchedules(client, clientGroups))
+		cl.server.updateReverseRemotes(client, clientGroups)
+		if edgeRules, err := cl.server.edgeRules.List(ctx); err != nil {
+			clientLog.Errorf("failed to get edge rules: %v", err)
+		} else {
+			cl.server.updateEdgeRules(client, clientGroups, edgeRules)
+		}
+	}
 	// Now the client is fully connected and ready to create tunnels and execute command and scripts
+	cl.server.enforceVersionPolicy(clientLog.GetLogger(), client)
 
 	clientBanner := client.Banner()
 	clientLog.Debugf("opened %s within %s", clientBanner, time.Since(ts2))
 
 	// now run handler for other client requests and connections
 	go cl.handleSSHRequests(clientLog, clientID, reqs)
-	go cl.handleSSHChannels(clientLog.GetLogger(), chans)
+	go cl.handleSSHChannels(clientLog.GetLogger(), clientID, chans)
 
 	// wait until we're disconnected from the client
 	if err = sshConn.Wait(); err != nil {
@@ -404,6 +482,8 @@ func (cl *ClientListener) handleWebsocket(w http.ResponseWriter, req *http.Reque
 	}
 	clientLog.Debugf("close %s", clientBanner)
 
+	cl.server.terminateClientMeshTunnels(clientID)
+
 	err = cl.getClientService().Terminate(client)
 	if err != nil {
 		cl.log().Errorf("could not terminate client: %s", err)
@@ -481,6 +561,9 @@ func (cl *ClientListener) handleSSHRequests(clientLog *logger.DynamicLogger, cli
 
 		if len(r.Payload) > int(cl.server.config.Server.MaxRequestBytesClient) {
 			clientLog.Errorf("%s:request data exceeds the limit of %d bytes, actual size: %d", comm.RequestTypeSaveMeasurement, cl.server.config.Server.MaxRequestBytesClient, len(r.Payload))
+			if r.WantReply {
+				_ = r.Reply(false, nil)
+			}
 			continue
 		}
 
@@ -536,11 +619,22 @@ func (cl *ClientListener) handleSSHRequests(clientLog *logger.DynamicLogger, cli
 			}
 
 			job, err := cl.saveCmdResult(r.Payload)
+			// clients keep the result until it's confirmed
+			if r.WantReply {
+				_ = r.Reply(err == nil, nil)
+			}
 			if err != nil {
 				clientLog.Errorf("Failed to save cmd result: %s", err)
 				continue
 			}
+			if job == nil {
+				clientLog.Debugf("Command result already saved, confirmed again.")
+				continue
+			}
 			clientLog.Debugf("%s, Command result saved successfully.", job.LogPrefix())
+			if job.Lock != "" && job.Status != models.JobStatusRunning {
+				cl.server.jobLocks.Release(clientID, job.JID)
+			}
 
 			var auditLogEntry *auditlog.Entry
 			if job.IsScript {
@@ -609,7 +703,19 @@ func (cl *ClientListener) handleSSHRequests(clientLog *logger.DynamicLogger, cli
 			measurement.ClientID = clientID
 			measurement.Timestamp = time.Now().UTC()
 
+			if measurement.Agent != nil {
+				if err := clientService.SetAgentFootprint(clientID, measurement.Agent); err != nil {
+					clientLog.Debugf("Failed to save agent footprint: %s", err)
+				}
+			}
+
+			if err := cl.server.meterDatapoint(cl.getCtx(), clientID); err != nil {
+				clientLog.Debugf("Measurement not saved: %v", err)
+				continue
+			}
+
 			cl.server.monitoringQueue.Notify(measurement)
+			cl.server.groupEvaluator.PutMeasurement(measurement)
 
 			if rportplus.IsPlusEnabled(cl.server.config.PlusConfig) {
 				alertingCap := cl.server.plusManager.GetAlertingCapabilityEx()
@@ -617,6 +723,62 @@ func (cl *ClientListener) handleSSHRequests(clientLog *logger.DynamicLogger, cli
 					cl.sendMeasurementToAlertingService(alertingCap, &measurement, clientLog)
 				}
 			}
+		case comm.RequestTypeSaveMeasurements:
+			err := cl.saveBufferedMeasurements(clientID, r.Payload)
+			if err != nil {
+				clientLog.Errorf("Failed to save buffered measurements: %s", err)
+			}
+			if r.WantReply {
+				_ = r.Reply(err == nil, nil)
+			}
+		case comm.RequestTypeChatReply:
+			err := cl.saveChatReply(clientID, r.Payload)
+			if err != nil {
+				clientLog.Errorf("Failed to save chat reply: %s", err)
+			}
+			if r.WantReply {
+				_ = r.Reply(err == nil, nil)
+			}
+		case comm.RequestTypeDiscoveryResult:
+			err := cl.saveDiscoveryResult(clientID, r.Payload)
+			if err != nil {
+				clientLog.Errorf("Failed to save network discovery result: %s", err)
+			}
+			if r.WantReply {
+				_ = r.Reply(err == nil, nil)
+			}
+		case comm.RequestTypeConsentResult:
+			err := cl.receiveConsentResult(clientID, r.Payload)
+			if err != nil {
+				clientLog.Errorf("Failed to receive consent result: %s", err)
+			}
+			if r.WantReply {
+				_ = r.Reply(err == nil, nil)
+			}
+		case comm.RequestTypeAgentlessResult:
+			err := cl.receiveAgentlessResult(clientID, r.Payload)
+			if err != nil {
+				clientLog.Errorf("Failed to receive agentless result: %s", err)
+			}
+			if r.WantReply {
+				_ = r.Reply(err == nil, nil)
+			}
+		case comm.RequestTypeIndustrialResult:
+			err := cl.receiveIndustrialResult(clientID, r.Payload)
+			if err != nil {
+				clientLog.Errorf("Failed to receive industrial result: %s", err)
+			}
+			if r.WantReply {
+				_ = r.Reply(err == nil, nil)
+			}
+		case comm.RequestTypeEdgeAlertEvents:
+			err := cl.receiveEdgeAlertEvents(context.Background(), clientID, r.Payload)
+			if err != nil {
+				clientLog.Errorf("Failed to receive edge alert events: %s", err)
+			}
+			if r.WantReply {
+				_ = r.Reply(err == nil, nil)
+			}
 		case comm.RequestTypeIPAddresses:
 			clientLog.Debugf("IP addresses update received from: %s, payload: %s", clientID, r.Payload)
 			IPAddresses := &models.IPAddresses{}
@@ -639,6 +801,36 @@ func (cl *ClientListener) handleSSHRequests(clientLog *logger.DynamicLogger, cli
 	clientLog.Debugf("Client listener for %s stopped", clientID)
 }
 
+// saveBufferedMeasurements stores the measurements a client buffered while the server was unreachable, dated back by
+// their age. Measurements older than the monitoring retention are skipped, they are not passed to the alerting as
+// they describe the past.
+func (cl *ClientListener) saveBufferedMeasurements(clientID string, payload []byte) error {
+	if !cl.server.config.Monitoring.Enabled {
+		return errors.New("monitoring disabled")
+	}
+
+	var buffered []models.BufferedMeasurement
+	if err := json.Unmarshal(payload, &buffered); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	retention := cl.server.config.Monitoring.GetDataStorageDuration()
+	for _, b := range buffered {
+		if b.Age < 0 || (retention > 0 && b.Age > retention) {
+			continue
+		}
+		if err := cl.server.meterDatapoint(cl.getCtx(), clientID); err != nil {
+			return err
+		}
+		measurement := b.Measurement
+		measurement.ClientID = clientID
+		measurement.Timestamp = now.Add(-b.Age)
+		cl.server.monitoringQueue.Notify(measurement)
+	}
+	return nil
+}
+
 func (cl *ClientListener) sendMeasurementToAlertingService(
 	alertingCap alertingcap.CapabilityEx,
 	measurement *models.Measurement,
@@ -659,6 +851,7 @@ func (cl *ClientListener) sendMeasurementToAlertingService(
 	}
 }
 
+// saveCmdResult returns nil if the result was saved before.
 func (cl *ClientListener) saveCmdResult(respBytes []byte) (*models.Job, error) {
 	resp := models.Job{}
 	err := json.Unmarshal(respBytes, &resp)
@@ -666,6 +859,29 @@ func (cl *ClientListener) saveCmdResult(respBytes []byte) (*models.Job, error) {
 		return nil, fmt.Errorf("failed to decode cmd result request: %s", err)
 	}
 
+	// the output parser and the lock are taken from the stored job, clients unaware of them don't send them back
+	stored, err := cl.server.jobProvider.GetByJID(resp.ClientID, resp.JID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %s", err)
+	}
+	// a client sends a result again if the confirmation didn't reach it
+	if stored != nil && stored.Status != models.JobStatusRunning && stored.FinishedAt != nil && resp.FinishedAt != nil &&
+		stored.FinishedAt.Equal(*resp.FinishedAt) {
+		return nil, nil
+	}
+	resp.OutputParser = nil
+	if stored != nil {
+		resp.OutputParser = stored.OutputParser
+		resp.Lock = stored.Lock
+	}
+	if resp.OutputParser != nil {
+		jobs.ParseOutput(&resp)
+		respBytes, err = json.Marshal(resp)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode parsed cmd result: %s", err)
+		}
+	}
+
 	var wsJID string
 	if resp.MultiJobID != nil {
 		wsJID = *resp.MultiJobID
@@ -691,10 +907,34 @@ func (cl *ClientListener) saveCmdResult(respBytes []byte) (*models.Job, error) {
 	return &resp, nil
 }
 
-func (cl *ClientListener) handleSSHChannels(clientLog *logger.Logger, chans <-chan ssh.NewChannel) {
+func (cl *ClientListener) handleSSHChannels(clientLog *logger.Logger, clientID string, chans <-chan ssh.NewChannel) {
 	for ch := range chans {
 		ch := ch
-		extraData := string(ch.ExtraData())
+		switch ch.ChannelType() {
+		case comm.ChannelMeshTunnel:
+			go cl.handleMeshTunnelChannel(clientLog, clientID, ch)
+			continue
+		case comm.ChannelReverseRemote:
+			go cl.handleReverseRemoteChannel(clientLog, clientID, ch)
+			continue
+		case comm.ChannelScreenshot:
+			go cl.handleScreenshotChannel(clientLog, clientID, ch)
+			continue
+		case comm.ChannelFileBlocks:
+			go cl.handleFileBlocksChannel(clientLog, clientID, ch)
+			continue
+		case comm.ChannelSerialConsole:
+			go cl.handleSerialConsoleChannel(clientLog, clientID, ch)
+			continue
+		case "session", models.ChannelStdout, models.ChannelStderr:
+		default:
+			// no rport client opens other channels. The generic tcp streams inherited from chisel are rejected as well,
+			// clients must not use the server as an open proxy, server-side services are reached via reverse remotes only
+			clientLog.Infof("Rejecting unknown channel type %q", ch.ChannelType())
+			cl.rejectChannel(clientLog, ch, ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+
 		stream, reqs, err := ch.Accept()
 		if err != nil {
 			clientLog.Debugf("Failed to accept stream: %s", err)
@@ -717,10 +957,6 @@ func (cl *ClientListener) handleSSHChannels(clientLog *logger.Logger, chans <-ch
 					clientLog.Errorf("Error handling output channel %s: %v", ch.ChannelType(), err)
 				}
 			}()
-		default:
-			// handle stream type
-			connID := cl.connStats.New()
-			go chshare.HandleTCPStream(clientLog.Fork("conn#%d", connID), &cl.connStats, stream, extraData)
 		}
 	}
 }
@@ -6,10 +6,16 @@ import (
 	"os"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
 
+	jobsmigration "github.com/IOTech17/neo-rport/db/migration/jobs"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	"github.com/IOTech17/neo-rport/server/api/jobs"
+	"github.com/IOTech17/neo-rport/server/test/jb"
 	"github.com/IOTech17/neo-rport/share/logger"
 	"github.com/IOTech17/neo-rport/share/models"
 	"github.com/IOTech17/neo-rport/share/ptr"
@@ -119,3 +125,96 @@ func (c *connMock) WriteJSON(data interface{}) error {
 	c.LastWrite = data
 	return nil
 }
+
+func TestSaveCmdResultParsesOutput(t *testing.T) {
+	jobsDB, err := sqlite.New(":memory:", jobsmigration.AssetNames(), jobsmigration.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	jp := jobs.NewSqliteProvider(jobsDB, testLog)
+	defer jp.Close()
+
+	cl := &ClientListener{
+		logger: testLog,
+		server: &Server{uiJobWebSockets: ws.NewWebSocketCache(), jobProvider: jp},
+	}
+
+	job := jb.New(t).Status(models.JobStatusRunning).Result(nil).Build()
+	job.Error = ""
+	job.OutputParser = &models.OutputParser{
+		Type:   models.OutputParserKeyValue,
+		FailIf: []models.OutputCondition{{Field: "status", Operator: "!=", Value: "ok"}},
+	}
+	require.NoError(t, jp.CreateJob(job))
+
+	// the result of a client unaware of output parsers
+	finished := *job
+	finished.OutputParser = nil
+	finished.Status = models.JobStatusSuccessful
+	finished.Result = &models.JobResult{StdOut: "status=degraded\n"}
+	respBytes, err := json.Marshal(finished)
+	require.NoError(t, err)
+
+	saved, err := cl.saveCmdResult(respBytes)
+	require.NoError(t, err)
+	assert.Equal(t, models.JobStatusFailed, saved.Status)
+	assert.Equal(t, "output check failed: status != ok", saved.Error)
+
+	stored, err := jp.GetByJID(job.ClientID, job.JID)
+	require.NoError(t, err)
+	assert.Equal(t, models.JobStatusFailed, stored.Status)
+	assert.Equal(t, map[string]interface{}{"status": "degraded"}, stored.Result.Parsed)
+}
+
+func TestSaveCmdResultSentAgain(t *testing.T) {
+	jobsDB, err := sqlite.New(":memory:", jobsmigration.AssetNames(), jobsmigration.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	jp := jobs.NewSqliteProvider(jobsDB, testLog)
+	defer jp.Close()
+
+	cl := &ClientListener{
+		logger: testLog,
+		server: &Server{uiJobWebSockets: ws.NewWebSocketCache(), jobProvider: jp},
+	}
+
+	job := jb.New(t).Status(models.JobStatusRunning).Result(nil).Build()
+	require.NoError(t, jp.CreateJob(job))
+
+	finished := *job
+	finished.Status = models.JobStatusSuccessful
+	finishedAt := time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)
+	finished.FinishedAt = &finishedAt
+	respBytes, err := json.Marshal(finished)
+	require.NoError(t, err)
+
+	saved, err := cl.saveCmdResult(respBytes)
+	require.NoError(t, err)
+	assert.Equal(t, models.JobStatusSuccessful, saved.Status)
+
+	// the confirmation didn't reach the client
+	saved, err = cl.saveCmdResult(respBytes)
+	require.NoError(t, err)
+	assert.Nil(t, saved)
+
+	stored, err := jp.GetByJID(job.ClientID, job.JID)
+	require.NoError(t, err)
+	assert.Equal(t, models.JobStatusSuccessful, stored.Status)
+}
+
+func TestHandleSSHChannelsRejectsTCPStreams(t *testing.T) {
+	cl := &ClientListener{}
+	// the generic tcp streams inherited from chisel, no rport client opens them
+	streams := []*newChannelMock{
+		{channelType: "rport", extraData: []byte("10.0.0.1:22")},
+		{channelType: "direct-tcpip", extraData: []byte("10.0.0.1:22")},
+	}
+	chans := make(chan ssh.NewChannel, len(streams))
+	for _, ch := range streams {
+		chans <- ch
+	}
+	close(chans)
+
+	cl.handleSSHChannels(testLog, "client-1", chans)
+
+	for _, ch := range streams {
+		assert.Equal(t, ssh.UnknownChannelType, ch.rejected, ch.channelType)
+	}
+}
@@ -0,0 +1,83 @@
+package chserver
+
+import (
+	"time"
+
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/clients/versionpolicy"
+	"github.com/IOTech17/neo-rport/share/logger"
+)
+
+const (
+	// versionPolicyCreatedBy is set as creator of the update jobs of outdated clients
+	versionPolicyCreatedBy = "version-policy"
+	// clientUpdateInterval keeps a failing update command from running on every reconnect
+	clientUpdateInterval = time.Hour
+)
+
+// enforceVersionPolicy is called once a client is connected, it logs outdated clients and runs the update command if
+// configured.
+func (s *Server) enforceVersionPolicy(clog *logger.Logger, client *clientdata.Client) {
+	policy := s.config.Server.VersionPolicy
+	clientVersion := client.GetVersion()
+	if !policy.IsOutdated(clientVersion) {
+		return
+	}
+	clog.Infof("client %s (%s) runs version %s, older than the minimum version %s", client.GetID(), client.GetName(), clientVersion, policy.MinVersion)
+	if !policy.RunsUpdate(clientVersion) {
+		return
+	}
+
+	if last, ok := s.clientUpdates.Load(client.GetID()); ok && time.Since(last.(time.Time)) < clientUpdateInterval {
+		clog.Infof("not updating client %s, last update was started at %s", client.GetID(), last.(time.Time).Format(time.RFC3339))
+		return
+	}
+	s.clientUpdates.Store(client.GetID(), time.Now())
+
+	jid, err := generateNewJobID()
+	if err != nil {
+		clog.Errorf("could not generate job id for the update of client %s: %v", client.GetID(), err)
+		return
+	}
+	go func() {
+		err := s.apiListener.createAndRunJob(
+			nil,
+			nil,
+			jid,
+			policy.UpdateCommand,
+			policy.UpdateInterpreter,
+			versionPolicyCreatedBy,
+			"",
+			s.config.Server.RunRemoteCmdTimeoutSec,
+			false,
+			false,
+			nil,
+			"",
+			nil,
+			client,
+		)
+		if err != nil {
+			clog.Errorf("failed to start the update of client %s: %v", client.GetID(), err)
+			return
+		}
+		clog.Infof("started update job %s on client %s", jid, client.GetID())
+	}()
+}
+
+// clientVersionsStatus returns the version distribution of the clients for the status endpoint.
+func (s *Server) clientVersionsStatus() ([]versionpolicy.VersionCount, int) {
+	allClients := s.clientService.GetAll()
+	versions := make([]string, 0, len(allClients))
+	for _, c := range allClients {
+		versions = append(versions, c.GetVersion())
+	}
+
+	distribution := s.config.Server.VersionPolicy.Distribution(versions)
+	outdated := 0
+	for _, v := range distribution {
+		if v.Outdated {
+			outdated += v.Count
+		}
+	}
+	return distribution, outdated
+}
@@ -59,18 +59,26 @@ type ClientService interface {
 	Terminate(client *clientdata.Client) error
 	ForceDelete(client *clientdata.Client) error
 	DeleteOffline(clientID string) error
+	CheckDeleteOffline(clientID string) error
 
 	SetACL(clientID string, allowedUserGroups []string) error
+	SetQuarantine(clientID string, quarantine *clientdata.Quarantine) error
+	SetIdentityTransfer(clientID string, transfer *clientdata.IdentityTransfer) error
+	GetTransferredClientID(clientAuthID string) string
 	CheckClientAccess(clientID string, user User, groups []*cgroups.ClientGroup) error
 	CheckClientsAccess(clients []*clientdata.Client, user User, groups []*cgroups.ClientGroup) error
 
 	SetUpdatesStatus(clientID string, updatesStatus *models.UpdatesStatus) error
 	SetLastHeartbeat(clientID string, heartbeat time.Time) error
 	SetIPAddresses(clientID string, IPAddresses *models.IPAddresses) error
+	SetAgentFootprint(clientID string, footprint *models.AgentFootprint) error
 
 	GetRepo() *ClientRepository
 
 	SetCaddyAPI(capi caddy.API)
+	SetSessionTransferHook(hook SessionTransferHook)
+	SetConnectionLogHook(hook ConnectionLogHook)
+	SetDevicePasswordHook(hook DevicePasswordHook)
 	StartClientTunnels(client *clientdata.Client, remotes []*models.Remote) ([]*clienttunnel.Tunnel, error)
 	StartTunnel(c *clientdata.Client, r *models.Remote, acl *clienttunnel.TunnelACL) (*clienttunnel.Tunnel, error)
 	FindTunnel(c *clientdata.Client, id string) *clienttunnel.Tunnel
@@ -88,6 +96,10 @@ type ClientServiceProvider struct {
 	acme              *acme.Acme
 	alertingService   alertingcap.Service
 
+	sessionTransferHook SessionTransferHook
+	connectionLogHook   ConnectionLogHook
+	devicePasswordHook  DevicePasswordHook
+
 	licensecap licensecap.CapabilityEx
 
 	mu sync.RWMutex
@@ -121,6 +133,8 @@ var OptionsSupportedFilters = map[string]bool{
 	"allowed_user_groups":      true,
 	"groups":                   true,
 	"connection_state":         true,
+	"simulated":                true,
+	"ephemeral":                true,
 }
 
 var OptionsSupportedSorts = map[string]bool{
@@ -165,6 +179,14 @@ var OptionsSupportedFields = map[string]map[string]bool{
 		"allowed_user_groups":      true,
 		"updates_status":           true,
 		"ip_addresses":             true,
+		"agent_footprint":          true,
+		"quarantine":               true,
+		"identity_transfer":        true,
+		"capabilities":             true,
+		"protocol_revision":        true,
+		"feature_levels":           true,
+		"simulated":                true,
+		"ephemeral":                true,
 		"client_configuration":     true,
 		"groups":                   true,
 	},
@@ -392,6 +414,10 @@ func (s *ClientServiceProvider) StartClient(
 			clog.Infof("old tunnels to re-establish %d: %v", len(oldTunnels), oldTunnels)
 			req.Remotes = append(req.Remotes, oldTunnels...)
 		}
+
+		if transfer := client.GetIdentityTransfer(); transfer.IsPending() && transfer.ClientAuthID == clientAuthID {
+			completeIdentityTransfer(client, transfer, req, clog)
+		}
 	}
 
 	// check if client auth ID is already used by another client
@@ -406,7 +432,10 @@ func (s *ClientServiceProvider) StartClient(
 
 	s.UpdateClientStatus()
 
-	if !client.IsPaused() {
+	if client.IsQuarantined() && len(req.Remotes) > 0 {
+		clog.Infof("Not starting %d tunnels requested by quarantined client", len(req.Remotes))
+	}
+	if !client.IsPaused() && !client.IsQuarantined() {
 		_, err = s.startClientTunnels(client, req.Remotes, clog)
 
 		if err != nil {
@@ -426,6 +455,23 @@ func (s *ClientServiceProvider) StartClient(
 	return client, nil
 }
 
+// completeIdentityTransfer is called on the first connection of the replacement of a client. The replacement keeps
+// the tags and labels of the client unless it brings its own.
+func completeIdentityTransfer(client *clientdata.Client, transfer *clientdata.IdentityTransfer, req *chshare.ConnectionRequest, clog *logger.Logger) {
+	if len(req.Tags) == 0 {
+		req.Tags = client.GetTags()
+	}
+	if len(req.Labels) == 0 {
+		req.Labels = client.GetLabels()
+	}
+
+	completed := *transfer
+	now := clientdata.Now().UTC()
+	completed.CompletedAt = &now
+	client.SetIdentityTransfer(&completed)
+	clog.Infof("identity of client %s transferred from client auth id %q to %q", client.GetID(), client.GetClientAuthID(), transfer.ClientAuthID)
+}
+
 func getRemotes(tunnels []*clienttunnel.Tunnel) []*models.Remote {
 	r := make([]*models.Remote, 0, len(tunnels))
 	for _, t := range tunnels {
@@ -508,6 +554,21 @@ func (s *ClientServiceProvider) StartClientTunnels(client *clientdata.Client, re
 }
 
 func (s *ClientServiceProvider) startClientTunnels(client *clientdata.Client, remotes []*models.Remote, clog *logger.Logger) ([]*clienttunnel.Tunnel, error) {
+	if client.IsQuarantined() {
+		return nil, apiErrors.APIError{
+			HTTPStatus: http.StatusForbidden,
+			Err:        clientdata.ErrQuarantined,
+		}
+	}
+
+	for _, remote := range remotes {
+		if remote.Protocol == models.ProtocolUDP || remote.Protocol == models.ProtocolTCPUDP {
+			if err := client.CheckCapability(models.ClientCapabilityUDPTunnels); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	err := s.portDistributor.Refresh()
 	if err != nil {
 		return nil, err
@@ -577,6 +638,9 @@ func (s *ClientServiceProvider) checkLocalPort(protocol, port string) error {
 
 func (s *ClientServiceProvider) Terminate(client *clientdata.Client) error {
 	s.log().Infof("terminating client: %s: %s", client.GetID(), client.GetName())
+	if client.Ephemeral {
+		return s.terminateEphemeral(client)
+	}
 	keepDisconnectedClientsDuration := s.repo.GetKeepDisconnectedClients()
 	if keepDisconnectedClientsDuration != nil && *keepDisconnectedClientsDuration == 0 {
 		return s.repo.Delete(client)
@@ -598,6 +662,50 @@ func (s *ClientServiceProvider) Terminate(client *clientdata.Client) error {
 	return s.repo.Save(client)
 }
 
+// terminateEphemeral keeps an ephemeral client for the ephemeral grace period to let it reconnect, e.g. after a network
+// issue, and purges it afterwards.
+func (s *ClientServiceProvider) terminateEphemeral(client *clientdata.Client) error {
+	grace := s.repo.GetEphemeralGracePeriod()
+	if grace == 0 {
+		return s.repo.Delete(client)
+	}
+
+	client.SetDisconnectedNow()
+	disconnectedAt := client.GetDisconnectedAtValue()
+
+	existing, err := s.repo.GetByID(client.GetID())
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return nil
+	}
+
+	s.UpdateClientStatus()
+
+	if err := s.repo.Save(client); err != nil {
+		return err
+	}
+
+	time.AfterFunc(grace, func() {
+		s.purgeEphemeral(client.GetID(), disconnectedAt)
+	})
+	return nil
+}
+
+// purgeEphemeral deletes an ephemeral client unless it reconnected since it disconnected at the given time.
+func (s *ClientServiceProvider) purgeEphemeral(clientID string, disconnectedAt time.Time) {
+	client := s.repo.getClient(clientID)
+	if client == nil || client.IsConnected() || !client.GetDisconnectedAtValue().Equal(disconnectedAt) {
+		return
+	}
+
+	s.log().Infof("purging ephemeral client: %s: %s", clientID, client.GetName())
+	if err := s.repo.Delete(client); err != nil {
+		s.log().Errorf("failed to purge ephemeral client %s: %v", clientID, err)
+	}
+}
+
 // ForceDelete deletes client from repo regardless off KeepDisconnectedClients setting,
 // if client is active it will be closed
 func (s *ClientServiceProvider) ForceDelete(client *clientdata.Client) error {
@@ -614,19 +722,33 @@ func (s *ClientServiceProvider) ForceDelete(client *clientdata.Client) error {
 func (s *ClientServiceProvider) DeleteOffline(clientID string) error {
 	s.logger.Debugf("deleting offline client: %s", clientID)
 
-	existing, err := s.getExistingClientByID(clientID)
+	existing, err := s.getOfflineClient(clientID)
 	if err != nil {
 		return err
 	}
 
+	return s.repo.Delete(existing)
+}
+
+// CheckDeleteOffline returns the error DeleteOffline would return without deleting the client.
+func (s *ClientServiceProvider) CheckDeleteOffline(clientID string) error {
+	_, err := s.getOfflineClient(clientID)
+	return err
+}
+
+func (s *ClientServiceProvider) getOfflineClient(clientID string) (*clientdata.Client, error) {
+	existing, err := s.getExistingClientByID(clientID)
+	if err != nil {
+		return nil, err
+	}
+
 	if existing.IsConnected() {
-		return apiErrors.APIError{
+		return nil, apiErrors.APIError{
 			Message:    "Client is active, should be disconnected",
 			HTTPStatus: http.StatusBadRequest,
 		}
 	}
-
-	return s.repo.Delete(existing)
+	return existing, nil
 }
 
 // isClientAuthIDInUse returns true when the client with different id exists for the client auth
@@ -650,6 +772,42 @@ func (s *ClientServiceProvider) SetACL(clientID string, allowedUserGroups []stri
 	return s.repo.Save(client)
 }
 
+// SetQuarantine puts a client into quarantine or lifts it with nil. Closing the tunnels is up to the caller.
+func (s *ClientServiceProvider) SetQuarantine(clientID string, quarantine *clientdata.Quarantine) error {
+	client, err := s.getExistingClientByID(clientID)
+	if err != nil {
+		return err
+	}
+
+	client.SetQuarantine(quarantine)
+
+	return s.repo.Save(client)
+}
+
+// SetIdentityTransfer transfers the identity of a client to the replacement connecting with the client auth id of the
+// transfer, nil cancels it.
+func (s *ClientServiceProvider) SetIdentityTransfer(clientID string, transfer *clientdata.IdentityTransfer) error {
+	client, err := s.getExistingClientByID(clientID)
+	if err != nil {
+		return err
+	}
+
+	client.SetIdentityTransfer(transfer)
+
+	return s.repo.Save(client)
+}
+
+// GetTransferredClientID returns the id of the client whose identity was transferred to the given client auth id,
+// empty if there's none.
+func (s *ClientServiceProvider) GetTransferredClientID(clientAuthID string) string {
+	for _, client := range s.repo.GetAllClients() {
+		if transfer := client.GetIdentityTransfer(); transfer != nil && transfer.ClientAuthID == clientAuthID {
+			return client.GetID()
+		}
+	}
+	return ""
+}
+
 func (s *ClientServiceProvider) SetUpdatesStatus(clientID string, updatesStatus *models.UpdatesStatus) error {
 	client, err := s.getExistingClientByID(clientID)
 	if err != nil {
@@ -661,6 +819,18 @@ func (s *ClientServiceProvider) SetUpdatesStatus(clientID string, updatesStatus
 	return s.repo.Save(client)
 }
 
+// SetAgentFootprint keeps the resource usage of the client in memory only, it's refreshed with every measurement.
+func (s *ClientServiceProvider) SetAgentFootprint(clientID string, footprint *models.AgentFootprint) error {
+	client, err := s.getExistingClientByID(clientID)
+	if err != nil {
+		return err
+	}
+
+	client.SetAgentFootprint(footprint)
+
+	return nil
+}
+
 func (s *ClientServiceProvider) SetIPAddresses(clientID string, IPAddresses *models.IPAddresses) error {
 	client, err := s.getExistingClientByID(clientID)
 	if err != nil {
@@ -695,14 +865,12 @@ func (s *ClientServiceProvider) CheckClientAccess(clientID string, user User, gr
 // CheckClientsAccess returns nil if a given user has an access to all of the given
 // Otherwise, APIError with 403 is returned.
 func (s *ClientServiceProvider) CheckClientsAccess(clients []*clientdata.Client, user User, clientGroups []*cgroups.ClientGroup) error {
-	if user.IsAdmin() {
-		return nil
-	}
-
 	var clientsWithNoAccess []string
 	userGroups := user.GetGroups()
 	for _, client := range clients {
-		if client.HasAccessViaUserGroups(userGroups) || client.UserGroupHasAccessViaClientGroup(userGroups, clientGroups) {
+		if allowedByClientGroupsRestriction(client, user, clientGroups) &&
+			(user.IsAdmin() || client.HasAccessViaUserGroups(userGroups) || client.UserGroupHasAccessViaClientGroup(userGroups, clientGroups) ||
+				hasAccessViaGrantedClientGroups(client, user, clientGroups)) {
 			continue
 		}
 
@@ -787,6 +955,31 @@ func (s *ClientServiceProvider) SetCaddyAPI(capi caddy.API) {
 	s.caddyAPI = capi
 }
 
+// SessionTransferHook is called for the clipboard and file transfers of remote desktop sessions of tunnels.
+type SessionTransferHook func(r *http.Request, client *clientdata.Client, t *clienttunnel.Tunnel, transfer clienttunnel.SessionTransfer)
+
+func (s *ClientServiceProvider) SetSessionTransferHook(hook SessionTransferHook) {
+	// unguarded as set during initialization
+	s.sessionTransferHook = hook
+}
+
+// ConnectionLogHook returns the log of the connections to a tunnel of the client, remote has the address the
+// connections are accepted at.
+type ConnectionLogHook func(client *clientdata.Client, tunnelID string, remote models.Remote) clienttunnel.ConnectionLog
+
+func (s *ClientServiceProvider) SetConnectionLogHook(hook ConnectionLogHook) {
+	// unguarded as set during initialization
+	s.connectionLogHook = hook
+}
+
+// DevicePasswordHook returns the password of the device authentication of a tunnel remote.
+type DevicePasswordHook func(remote models.Remote) (string, error)
+
+func (s *ClientServiceProvider) SetDevicePasswordHook(hook DevicePasswordHook) {
+	// unguarded as set during initialization
+	s.devicePasswordHook = hook
+}
+
 func (s *ClientServiceProvider) StartTunnel(
 	client *clientdata.Client,
 	remote *models.Remote,
@@ -888,10 +1081,14 @@ func (s *ClientServiceProvider) startCaddyDownstreamProxy(
 func (s *ClientServiceProvider) startRegularTunnel(ctx context.Context, client *clientdata.Client, remote *models.Remote, acl *clienttunnel.TunnelACL) (*clienttunnel.Tunnel, error) {
 	tunnelID := client.NewTunnelID()
 
-	tunnel, err := clienttunnel.NewTunnel(client.Log(), client.GetConnection(), tunnelID, *remote, acl)
+	tunnel, err := clienttunnel.NewTunnel(client.Log(), client.GetConnection(), tunnelID, *remote, acl, client.Traffic())
 	if err != nil {
 		return nil, err
 	}
+	tunnel.SetAccessCheck(accessScheduleCheck(client, remote))
+	if s.connectionLogHook != nil {
+		tunnel.SetConnectionLog(s.connectionLogHook(client, tunnelID, *remote))
+	}
 
 	err = tunnel.Start(ctx)
 	if err != nil {
@@ -935,10 +1132,11 @@ func (s *ClientServiceProvider) startTunnelWithProxy(
 	tunnelID := client.NewTunnelID()
 
 	// original tunnel will use the reconfigured original remote
-	t, err := clienttunnel.NewTunnel(clientLogger, client.GetConnection(), tunnelID, *remote, acl)
+	t, err := clienttunnel.NewTunnel(clientLogger, client.GetConnection(), tunnelID, *remote, acl, client.Traffic())
 	if err != nil {
 		return nil, err
 	}
+	t.SetAccessCheck(accessScheduleCheck(client, remote))
 
 	// start the original tunnel before the proxy tunnel
 	err = t.Start(ctx)
@@ -948,6 +1146,24 @@ func (s *ClientServiceProvider) startTunnelWithProxy(
 
 	// create new proxy tunnel listening at the original tunnel local host addr
 	tProxy := clienttunnel.NewInternalTunnelProxy(t, clientLogger, s.tunnelProxyConfig, proxyHost, proxyPort, proxyACL, s.acme)
+	if s.connectionLogHook != nil {
+		// connections to the tunnel itself come from the proxy, so the connections to the proxy are logged instead
+		proxyRemote := *remote
+		proxyRemote.LocalHost = proxyHost
+		proxyRemote.LocalPort = proxyPort
+		tProxy.SetConnectionLog(s.connectionLogHook(client, tunnelID, proxyRemote))
+	}
+	if s.sessionTransferHook != nil {
+		tProxy.OnSessionTransfer = func(r *http.Request, transfer clienttunnel.SessionTransfer) {
+			s.sessionTransferHook(r, client, t, transfer)
+		}
+	}
+	if remote.DeviceAuth != nil && s.devicePasswordHook != nil {
+		deviceRemote := *remote
+		tProxy.DevicePassword = func() (string, error) {
+			return s.devicePasswordHook(deviceRemote)
+		}
+	}
 	clientLogger.Debugf("client %s starting tunnel proxy", clientID)
 	if err := tProxy.Start(ctx); err != nil {
 		clientLogger.Debugf("tunnel proxy could not be started, tunnel must be terminated: %v", err)
@@ -969,6 +1185,15 @@ func (s *ClientServiceProvider) startTunnelWithProxy(
 	return t, nil
 }
 
+// accessScheduleCheck rejects new connections to the tunnel outside the access schedules of the client groups, unless
+// lifted by the access override of the remote.
+func accessScheduleCheck(client *clientdata.Client, remote *models.Remote) clienttunnel.AccessCheck {
+	override := remote.AccessOverride
+	return func() error {
+		return cgroups.CheckAccessSchedules(client.GetAccessSchedules(), time.Now(), override)
+	}
+}
+
 func (s *ClientServiceProvider) cleanupOnAutoCloseDeadlineExceeded(ctx context.Context, t *clienttunnel.Tunnel, c *clientdata.Client) {
 	<-ctx.Done()
 	// DeadlineExceeded err is expected when tunnel AutoClose period is reached, otherwise skip cleanup
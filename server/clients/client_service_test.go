@@ -454,6 +454,24 @@ func TestCheckClientsAccess(t *testing.T) {
 			user:                      &users.User{Groups: []string{"group4"}},
 			wantClientIDsWithNoAccess: nil,
 		},
+		{
+			name:                      "admin user restricted to a single client",
+			clients:                   allClients,
+			user:                      &users.User{Groups: []string{users.Administrators}, AllowedClients: []string{c3.GetID()}},
+			wantClientIDsWithNoAccess: []string{c1.GetID(), c2.GetID(), c4.GetID(), c5.GetID(), c6.GetID()},
+		},
+		{
+			name:                      "user granted a client group by access request",
+			clients:                   []*clientdata.Client{c6, c7},
+			user:                      &users.User{Groups: []string{"group1"}, GrantedClientGroups: []string{"1"}},
+			wantClientIDsWithNoAccess: []string{c6.GetID()},
+		},
+		{
+			name:                      "user granted an unknown client group",
+			clients:                   []*clientdata.Client{c7},
+			user:                      &users.User{Groups: []string{"group1"}, GrantedClientGroups: []string{"unknown"}},
+			wantClientIDsWithNoAccess: []string{c7.GetID()},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -993,3 +1011,84 @@ func TestShouldStartTunnelsWithSubdomains(t *testing.T) {
 		})
 	}
 }
+
+func TestTerminateEphemeralClient(t *testing.T) {
+	oldNow := clientdata.Now
+	clientdata.Now = time.Now
+	defer func() { clientdata.Now = oldNow }()
+
+	ephemeral := &clientdata.Client{ID: "ephemeral-1", Ephemeral: true}
+	reconnecting := &clientdata.Client{ID: "ephemeral-2", Ephemeral: true}
+	persistent := &clientdata.Client{ID: "persistent"}
+	repo := NewClientRepository([]*clientdata.Client{ephemeral, reconnecting, persistent}, nil, testLog)
+	repo.SetEphemeralGracePeriod(100 * time.Millisecond)
+	cs := &ClientServiceProvider{
+		repo:   repo,
+		logger: testLog,
+	}
+
+	require.NoError(t, cs.Terminate(ephemeral))
+	require.NoError(t, cs.Terminate(reconnecting))
+	require.NoError(t, cs.Terminate(persistent))
+
+	// kept within the grace period, but not reported as disconnected
+	got, err := repo.GetByID(ephemeral.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ephemeral, got)
+	count, err := repo.CountDisconnected()
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	reconnecting.SetDisconnectedAt(nil)
+
+	assert.Eventually(t, func() bool {
+		return repo.getClient(ephemeral.ID) == nil
+	}, time.Second, 10*time.Millisecond)
+	time.Sleep(150 * time.Millisecond)
+	assert.NotNil(t, repo.getClient(reconnecting.ID))
+	assert.NotNil(t, repo.getClient(persistent.ID))
+
+	// purged immediately without grace period
+	repo.SetEphemeralGracePeriod(0)
+	require.NoError(t, cs.Terminate(reconnecting))
+	assert.Nil(t, repo.getClient(reconnecting.ID))
+}
+
+func TestStartClientIdentityTransfer(t *testing.T) {
+	connMock := test.NewConnMock()
+	connMock.ReturnRemoteAddr = &net.TCPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 2345}
+	now := time.Now()
+	cs := &ClientServiceProvider{
+		repo: NewClientRepository([]*clientdata.Client{{
+			ID:             "replaced-client",
+			ClientAuthID:   "old-client-auth",
+			DisconnectedAt: &now,
+			Tags:           []string{"rack-1"},
+			Labels:         map[string]string{"site": "berlin"},
+			IdentityTransfer: &clientdata.IdentityTransfer{
+				ClientAuthID: "new-client-auth",
+				By:           "admin",
+				At:           now,
+			},
+		}}, nil, testLog),
+		portDistributor: ports.NewPortDistributor(mapset.NewSet()),
+		logger:          testLog,
+	}
+
+	assert.Equal(t, "replaced-client", cs.GetTransferredClientID("new-client-auth"))
+	assert.Equal(t, "", cs.GetTransferredClientID("old-client-auth"))
+
+	client, err := cs.StartClient(
+		context.Background(), "new-client-auth", "replaced-client", connMock, false,
+		&chshare.ConnectionRequest{Name: "replacement", Version: "0.7.0"}, testLog)
+	require.NoError(t, err)
+
+	assert.Equal(t, "new-client-auth", client.GetClientAuthID())
+	assert.Equal(t, []string{"rack-1"}, client.GetTags())
+	assert.Equal(t, map[string]string{"site": "berlin"}, client.GetLabels())
+	transfer := client.GetIdentityTransfer()
+	require.NotNil(t, transfer)
+	assert.False(t, transfer.IsPending())
+	// the replacement keeps the identity on reconnect
+	assert.Equal(t, "replaced-client", cs.GetTransferredClientID("new-client-auth"))
+}
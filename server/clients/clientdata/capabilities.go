@@ -0,0 +1,54 @@
+package clientdata
+
+import (
+	"fmt"
+	"net/http"
+
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+var capabilityNames = map[string]string{
+	models.ClientCapabilityScripts:      "scripts",
+	models.ClientCapabilityFileTransfer: "file transfers",
+	models.ClientCapabilityPTY:          "interactive terminals",
+	models.ClientCapabilityMonitoring:   "monitoring",
+	models.ClientCapabilityUDPTunnels:   "UDP tunnels",
+}
+
+func (c *Client) GetCapabilities() []string {
+	c.flock.RLock()
+	defer c.flock.RUnlock()
+	return c.Capabilities
+}
+
+// SupportsCapability returns true if the client reported the capability on connect. Clients older than the
+// capability handshake report nothing, all capabilities are assumed for them.
+func (c *Client) SupportsCapability(capability string) bool {
+	capabilities := c.GetCapabilities()
+	if capabilities == nil {
+		return true
+	}
+	for _, cc := range capabilities {
+		if cc == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckCapability returns an error with status 409 if the client doesn't support the capability, so requests fail
+// fast instead of running into a timeout on the client.
+func (c *Client) CheckCapability(capability string) error {
+	if c.SupportsCapability(capability) {
+		return nil
+	}
+	name, ok := capabilityNames[capability]
+	if !ok {
+		name = capability
+	}
+	return errors2.APIError{
+		Message:    fmt.Sprintf("client %s does not support %s, it's disabled in its configuration or the client is outdated", c.GetID(), name),
+		HTTPStatus: http.StatusConflict,
+	}
+}
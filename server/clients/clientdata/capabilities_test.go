@@ -0,0 +1,26 @@
+package clientdata
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+func TestCheckCapability(t *testing.T) {
+	// older clients don't report capabilities
+	c := &Client{ID: "client-1"}
+	assert.NoError(t, c.CheckCapability(models.ClientCapabilityScripts))
+
+	c.Capabilities = []string{models.ClientCapabilityUDPTunnels}
+	assert.NoError(t, c.CheckCapability(models.ClientCapabilityUDPTunnels))
+
+	err := c.CheckCapability(models.ClientCapabilityScripts)
+	require.Error(t, err)
+	assert.Equal(t, "client client-1 does not support scripts, it's disabled in its configuration or the client is outdated", err.Error())
+	assert.Equal(t, http.StatusConflict, err.(errors2.APIError).HTTPStatus)
+}
@@ -79,15 +79,36 @@ type Client struct {
 	UpdatesStatus       *models.UpdatesStatus `json:"updates_status"`
 	IPAddresses         *models.IPAddresses   `json:"ext_ip_addresses"`
 	ClientConfiguration *clientconfig.Config  `json:"client_configuration"`
+	Quarantine          *Quarantine           `json:"quarantine"`
+	IdentityTransfer    *IdentityTransfer     `json:"identity_transfer"`
+	// JobDeliveryVersion is the version of receiving jobs the client supports, 0 for clients that might run a job
+	// sent again twice.
+	JobDeliveryVersion int `json:"-"`
+	// Capabilities are the features the client reported on connect, nil if it's older than the capability handshake.
+	Capabilities []string `json:"capabilities"`
+	// ProtocolRevision and FeatureLevels are the protocol the client reported on connect, see Compatibility.
+	ProtocolRevision int                  `json:"protocol_revision"`
+	FeatureLevels    models.FeatureLevels `json:"feature_levels"`
+	// Simulated clients are connected by rport-loadgen to validate the sizing of the server.
+	Simulated bool `json:"simulated"`
+	// Ephemeral clients are not persisted and removed after the ephemeral clients grace period once disconnected.
+	Ephemeral bool `json:"ephemeral"`
+	// AgentFootprint is the latest resource usage the client reported with its measurements, it's not persisted.
+	AgentFootprint *models.AgentFootprint `json:"agent_footprint"`
 
 	Connection   ssh.Conn        `json:"-"`
 	Context      context.Context `json:"-"`
 	Paused       bool            `json:"-"`
 	PausedReason string          `json:"-"`
+	// ReverseRemotes are the server-side services the client may reach, derived from its client groups.
+	ReverseRemotes []*models.Remote `json:"-"`
+	// AccessSchedules are the access schedules of its client groups by group ID.
+	AccessSchedules map[string]*cgroups.AccessSchedule `json:"-"`
 
 	Logger *logger.Logger `json:"-"`
 
-	flock sync.RWMutex
+	traffic clienttunnel.Traffic
+	flock   sync.RWMutex
 }
 
 // CalculatedClient contains additional fields and is calculated on each request
@@ -258,7 +279,7 @@ func (c *Client) GetLabels() (labels map[string]string) {
 func (c *Client) GetIPv4() (ipv4 []string) {
 	c.flock.RLock()
 	defer c.flock.RUnlock()
-	ipv4 = make([]string, 0, len(c.IPv4))
+	ipv4 = make([]string, len(c.IPv4))
 	copy(ipv4, c.IPv4)
 	return ipv4
 }
@@ -266,7 +287,7 @@ func (c *Client) GetIPv4() (ipv4 []string) {
 func (c *Client) GetIPv6() (ipv6 []string) {
 	c.flock.RLock()
 	defer c.flock.RUnlock()
-	ipv6 = make([]string, 0, len(c.IPv6))
+	ipv6 = make([]string, len(c.IPv6))
 	copy(ipv6, c.IPv6)
 	return ipv6
 }
@@ -320,6 +341,11 @@ func (c *Client) GetConnection() (conn ssh.Conn) {
 	return c.Connection
 }
 
+// Traffic returns the bytes transferred through the tunnels of the client.
+func (c *Client) Traffic() *clienttunnel.Traffic {
+	return &c.traffic
+}
+
 func (c *Client) GetPausedReason() (reason string) {
 	c.flock.RLock()
 	defer c.flock.RUnlock()
@@ -367,6 +393,18 @@ func (c *Client) GetMonitoringConfig() (monitoringConfig *clientconfig.Monitorin
 	return &c.ClientConfiguration.Monitoring
 }
 
+// GetDataDir returns the data directory of the client, empty if the client didn't report its configuration.
+func (c *Client) GetDataDir() string {
+	c.flock.RLock()
+	defer c.flock.RUnlock()
+
+	if c.ClientConfiguration == nil {
+		return ""
+	}
+
+	return c.ClientConfiguration.Client.DataDir
+}
+
 func (c *Client) GetFileReceptionConfig() (fileReceptionConfig *clientconfig.FileReceptionConfig) {
 	c.flock.RLock()
 	defer c.flock.RUnlock()
@@ -378,6 +416,17 @@ func (c *Client) GetFileReceptionConfig() (fileReceptionConfig *clientconfig.Fil
 	return &c.ClientConfiguration.FileReceptionConfig
 }
 
+func (c *Client) GetConsentConfig() *clientconfig.ConsentConfig {
+	c.flock.RLock()
+	defer c.flock.RUnlock()
+
+	if c.ClientConfiguration == nil {
+		return nil
+	}
+
+	return &c.ClientConfiguration.Consent
+}
+
 // test only
 func (c *Client) SetID(id string) {
 	c.flock.Lock()
@@ -427,6 +476,30 @@ func (c *Client) SetConnection(conn ssh.Conn) {
 	c.flock.Unlock()
 }
 
+func (c *Client) GetReverseRemotes() (remotes []*models.Remote) {
+	c.flock.RLock()
+	defer c.flock.RUnlock()
+	return c.ReverseRemotes
+}
+
+func (c *Client) SetReverseRemotes(remotes []*models.Remote) {
+	c.flock.Lock()
+	defer c.flock.Unlock()
+	c.ReverseRemotes = remotes
+}
+
+func (c *Client) GetAccessSchedules() map[string]*cgroups.AccessSchedule {
+	c.flock.RLock()
+	defer c.flock.RUnlock()
+	return c.AccessSchedules
+}
+
+func (c *Client) SetAccessSchedules(schedules map[string]*cgroups.AccessSchedule) {
+	c.flock.Lock()
+	defer c.flock.Unlock()
+	c.AccessSchedules = schedules
+}
+
 func (c *Client) SetTunnels(tunnels []*clienttunnel.Tunnel) {
 	c.flock.Lock()
 	c.Tunnels = tunnels
@@ -448,6 +521,12 @@ func (c *Client) SetUpdatesStatus(status *models.UpdatesStatus) {
 	c.flock.Unlock()
 }
 
+func (c *Client) SetAgentFootprint(footprint *models.AgentFootprint) {
+	c.flock.Lock()
+	c.AgentFootprint = footprint
+	c.flock.Unlock()
+}
+
 func (c *Client) SetIPAddresses(IPAddresses *models.IPAddresses) {
 	c.flock.Lock()
 	c.IPAddresses = IPAddresses
@@ -721,6 +800,12 @@ func NewClientFromConnRequest(ctx context.Context, existingClient *Client, clien
 	client.Labels = req.Labels
 	client.Version = req.Version
 	client.ClientConfiguration = req.ClientConfiguration
+	client.JobDeliveryVersion = req.JobDeliveryVersion
+	client.Capabilities = req.Capabilities
+	client.ProtocolRevision = req.ProtocolRevision
+	client.FeatureLevels = req.FeatureLevels
+	client.Simulated = req.Simulated
+	client.Ephemeral = req.Ephemeral
 	client.Address = clientHost
 	client.Tunnels = make([]*clienttunnel.Tunnel, 0)
 	client.DisconnectedAt = nil
@@ -0,0 +1,109 @@
+package clientdata
+
+import (
+	"fmt"
+
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+const (
+	// CompatibilityCurrent means client and server support the same levels of all features.
+	CompatibilityCurrent = "current"
+	// CompatibilityDegraded means some features run on a lower level, because either side is older.
+	CompatibilityDegraded = "degraded"
+	// CompatibilityLegacy means the client is older than the protocol revisions, its feature levels are unknown.
+	CompatibilityLegacy = "legacy"
+)
+
+type FeatureCompatibility struct {
+	Feature string `json:"feature"`
+	// ClientLevel is nil if the client didn't report it.
+	ClientLevel     *int `json:"client_level"`
+	ServerLevel     int  `json:"server_level"`
+	NegotiatedLevel int  `json:"negotiated_level"`
+	Degraded        bool `json:"degraded"`
+}
+
+type Compatibility struct {
+	ClientID               string                 `json:"client_id"`
+	ClientVersion          string                 `json:"client_version"`
+	ServerVersion          string                 `json:"server_version"`
+	ProtocolRevision       int                    `json:"protocol_revision"`
+	ServerProtocolRevision int                    `json:"server_protocol_revision"`
+	Status                 string                 `json:"status"`
+	Features               []FeatureCompatibility `json:"features"`
+	Notes                  []string               `json:"notes"`
+}
+
+// reportedFeatureLevels must be called with the lock held. Clients older than protocol revision 1 only reported the
+// job delivery version, the other features are taken as level 0.
+func (c *Client) reportedFeatureLevels() (levels models.FeatureLevels, legacy bool) {
+	if c.FeatureLevels == nil {
+		return models.FeatureLevels{models.FeatureJobDelivery: c.JobDeliveryVersion}, true
+	}
+	return c.FeatureLevels, false
+}
+
+// NegotiatedFeatureLevel returns the level of the feature both the client and the server support, the level of the
+// client if the server capabilities are unknown.
+func (c *Client) NegotiatedFeatureLevel(server *models.Capabilities, feature string) int {
+	c.flock.RLock()
+	defer c.flock.RUnlock()
+
+	levels, _ := c.reportedFeatureLevels()
+	if server == nil {
+		return levels[feature]
+	}
+	return levels.Negotiate(server.FeatureLevels)[feature]
+}
+
+// Compatibility compares the protocol the client reported on connect with the server's.
+func (c *Client) Compatibility(server *models.Capabilities) *Compatibility {
+	c.flock.RLock()
+	defer c.flock.RUnlock()
+
+	clientLevels, legacy := c.reportedFeatureLevels()
+	negotiated := clientLevels.Negotiate(server.FeatureLevels)
+
+	result := &Compatibility{
+		ClientID:               c.ID,
+		ClientVersion:          c.Version,
+		ServerVersion:          server.ServerVersion,
+		ProtocolRevision:       c.ProtocolRevision,
+		ServerProtocolRevision: server.ProtocolRevision,
+		Status:                 CompatibilityCurrent,
+		Features:               make([]FeatureCompatibility, 0, len(models.Features)),
+		Notes:                  []string{},
+	}
+	if legacy {
+		result.Status = CompatibilityLegacy
+		result.Notes = append(result.Notes, "the client is older than protocol revision 1, upgrade it to use all features")
+	} else if c.ProtocolRevision != server.ProtocolRevision {
+		result.Status = CompatibilityDegraded
+		result.Notes = append(result.Notes, fmt.Sprintf("the client uses protocol revision %d, the server %d", c.ProtocolRevision, server.ProtocolRevision))
+	}
+
+	for _, f := range models.Features {
+		fc := FeatureCompatibility{
+			Feature:         f,
+			ServerLevel:     server.FeatureLevels[f],
+			NegotiatedLevel: negotiated[f],
+		}
+		if level, ok := clientLevels[f]; ok {
+			fc.ClientLevel = &level
+			fc.Degraded = level != fc.ServerLevel
+		}
+		if fc.Degraded {
+			if result.Status == CompatibilityCurrent {
+				result.Status = CompatibilityDegraded
+			}
+			older := "client"
+			if *fc.ClientLevel > fc.ServerLevel {
+				older = "server"
+			}
+			result.Notes = append(result.Notes, fmt.Sprintf("%s runs on level %d, the %s is older", f, fc.NegotiatedLevel, older))
+		}
+		result.Features = append(result.Features, fc)
+	}
+	return result
+}
@@ -0,0 +1,52 @@
+package clientdata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+func TestCompatibility(t *testing.T) {
+	server := &models.Capabilities{
+		ServerVersion:    "1.2.0",
+		ProtocolRevision: 1,
+		FeatureLevels: models.FeatureLevels{
+			models.FeatureJobDelivery: 1,
+			models.FeatureJobResults:  1,
+			models.FeatureMonitoring:  2,
+			models.FeatureIPAddresses: 1,
+		},
+	}
+
+	current := &Client{ID: "current", Version: "1.2.0", ProtocolRevision: 1, FeatureLevels: server.FeatureLevels}
+	compat := current.Compatibility(server)
+	assert.Equal(t, CompatibilityCurrent, compat.Status)
+	assert.Empty(t, compat.Notes)
+	assert.Len(t, compat.Features, len(models.Features))
+
+	degraded := &Client{ID: "degraded", Version: "1.1.0", ProtocolRevision: 1, FeatureLevels: models.FeatureLevels{
+		models.FeatureJobDelivery: 1,
+		models.FeatureJobResults:  1,
+		models.FeatureMonitoring:  1,
+		models.FeatureIPAddresses: 1,
+	}}
+	compat = degraded.Compatibility(server)
+	assert.Equal(t, CompatibilityDegraded, compat.Status)
+	assert.Equal(t, []string{"monitoring runs on level 1, the client is older"}, compat.Notes)
+	assert.Equal(t, 1, degraded.NegotiatedFeatureLevel(server, models.FeatureMonitoring))
+
+	legacy := &Client{ID: "legacy", Version: "0.9.0", JobDeliveryVersion: 1}
+	compat = legacy.Compatibility(server)
+	assert.Equal(t, CompatibilityLegacy, compat.Status)
+	assert.Equal(t, 1, legacy.NegotiatedFeatureLevel(server, models.FeatureJobDelivery))
+	assert.Equal(t, 0, legacy.NegotiatedFeatureLevel(server, models.FeatureJobResults))
+	for _, f := range compat.Features {
+		if f.Feature == models.FeatureJobDelivery {
+			assert.Equal(t, 1, *f.ClientLevel)
+		} else {
+			assert.Nil(t, f.ClientLevel)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package clientdata
+
+import "time"
+
+// IdentityTransfer moves the identity of a client, i.e. its id and everything bound to it, to a replacement machine
+// connecting with a new credential. Once completed it's kept, so the replacement keeps the identity on reconnect.
+type IdentityTransfer struct {
+	ClientAuthID string     `json:"client_auth_id"`
+	By           string     `json:"by"`
+	At           time.Time  `json:"at"`
+	CompletedAt  *time.Time `json:"completed_at"`
+}
+
+// IsPending returns true until the replacement connected the first time.
+func (t *IdentityTransfer) IsPending() bool {
+	return t != nil && t.CompletedAt == nil
+}
+
+func (c *Client) GetIdentityTransfer() *IdentityTransfer {
+	c.flock.RLock()
+	defer c.flock.RUnlock()
+	return c.IdentityTransfer
+}
+
+// SetIdentityTransfer sets the transfer of the identity to a replacement, nil cancels it.
+func (c *Client) SetIdentityTransfer(transfer *IdentityTransfer) {
+	c.flock.Lock()
+	c.IdentityTransfer = transfer
+	c.flock.Unlock()
+}
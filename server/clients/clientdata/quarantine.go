@@ -0,0 +1,34 @@
+package clientdata
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrQuarantined is returned for tunnels, jobs and file transfers to a quarantined client.
+var ErrQuarantined = errors.New("client is quarantined")
+
+// Quarantine isolates a possibly compromised client for incident response. The connection is kept for forensics,
+// but the client gets no tunnels, jobs or files until an admin lifts the quarantine.
+type Quarantine struct {
+	Reason string    `json:"reason"`
+	By     string    `json:"by"`
+	At     time.Time `json:"at"`
+}
+
+func (c *Client) GetQuarantine() *Quarantine {
+	c.flock.RLock()
+	defer c.flock.RUnlock()
+	return c.Quarantine
+}
+
+func (c *Client) IsQuarantined() bool {
+	return c.GetQuarantine() != nil
+}
+
+// SetQuarantine puts the client into quarantine, nil lifts it.
+func (c *Client) SetQuarantine(quarantine *Quarantine) {
+	c.flock.Lock()
+	c.Quarantine = quarantine
+	c.flock.Unlock()
+}
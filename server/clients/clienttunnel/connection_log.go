@@ -0,0 +1,104 @@
+package clienttunnel
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ConnectionLog is called for each accepted connection of a tunnel with the address it comes from.
+type ConnectionLog func(protocol string, source net.Addr) LoggedConnection
+
+// LoggedConnection is an accepted connection in the connection log.
+type LoggedConnection interface {
+	// Request is called for the requests of a connection to a tunnel proxy with HTTP inspection.
+	Request(req InspectedRequest)
+	// Closed is called once when the connection is closed with the bytes sent to and received from the client.
+	Closed(sent, received int64)
+}
+
+// InspectedRequest is the request line and status of a request to a tunnel proxy, the query and bodies are left out
+// as they may contain credentials.
+type InspectedRequest struct {
+	Time     time.Time
+	Method   string
+	Path     string
+	Proto    string
+	Status   int
+	Duration time.Duration
+}
+
+type noLoggedConnection struct{}
+
+func (noLoggedConnection) Request(InspectedRequest) {}
+
+func (noLoggedConnection) Closed(int64, int64) {}
+
+func loadConnectionLog(p *atomic.Pointer[ConnectionLog]) ConnectionLog {
+	log := p.Load()
+	if log == nil {
+		return nil
+	}
+	return *log
+}
+
+// openConnection calls the connection log if there is one, the returned connection is never nil.
+func openConnection(p *atomic.Pointer[ConnectionLog], protocol string, source net.Addr) LoggedConnection {
+	log := loadConnectionLog(p)
+	if log == nil {
+		return noLoggedConnection{}
+	}
+	conn := log(protocol, source)
+	if conn == nil {
+		return noLoggedConnection{}
+	}
+	return conn
+}
+
+// loggedListener calls the connection log for the connections it accepts.
+type loggedListener struct {
+	net.Listener
+	log *atomic.Pointer[ConnectionLog]
+}
+
+func (l *loggedListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &loggedConn{
+		Conn: conn,
+		log:  openConnection(l.log, "tcp", conn.RemoteAddr()),
+	}, nil
+}
+
+// loggedConn counts the bytes of a connection, reads are sent to the client.
+type loggedConn struct {
+	// Declare 64-bit integers first for alignment when compiling Go on 32-bit ARM platforms
+	sent     int64
+	received int64
+	net.Conn
+	log  LoggedConnection
+	once sync.Once
+}
+
+func (c *loggedConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	atomic.AddInt64(&c.sent, int64(n))
+	return n, err
+}
+
+func (c *loggedConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	atomic.AddInt64(&c.received, int64(n))
+	return n, err
+}
+
+func (c *loggedConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(func() {
+		c.log.Closed(atomic.LoadInt64(&c.sent), atomic.LoadInt64(&c.received))
+	})
+	return err
+}
@@ -0,0 +1,126 @@
+package clienttunnel
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+// testLoggedConnection passes the events of a logged connection to channels.
+type testLoggedConnection struct {
+	source   string
+	requests chan InspectedRequest
+	closed   chan [2]int64
+}
+
+func newTestLoggedConnection(source net.Addr) *testLoggedConnection {
+	return &testLoggedConnection{
+		source:   source.String(),
+		requests: make(chan InspectedRequest, 10),
+		closed:   make(chan [2]int64, 10),
+	}
+}
+
+func (c *testLoggedConnection) Request(req InspectedRequest) {
+	c.requests <- req
+}
+
+func (c *testLoggedConnection) Closed(sent, received int64) {
+	c.closed <- [2]int64{sent, received}
+}
+
+func TestLoggedListener(t *testing.T) {
+	var logged *testLoggedConnection
+	log := ConnectionLog(func(protocol string, addr net.Addr) LoggedConnection {
+		assert.Equal(t, "tcp", protocol)
+		logged = newTestLoggedConnection(addr)
+		return logged
+	})
+	var p atomic.Pointer[ConnectionLog]
+	p.Store(&log)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	l = &loggedListener{Listener: l, log: &p}
+	defer l.Close()
+
+	client, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	conn, err := l.Accept()
+	require.NoError(t, err)
+	assert.Equal(t, client.LocalAddr().String(), logged.source)
+
+	_, err = client.Write([]byte("hello"))
+	require.NoError(t, err)
+	buf := make([]byte, 5)
+	_, err = io.ReadFull(conn, buf)
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("hi"))
+	require.NoError(t, err)
+
+	require.NoError(t, conn.Close())
+	// closing twice logs once
+	_ = conn.Close()
+	assert.Equal(t, [2]int64{5, 2}, <-logged.closed)
+	assert.Empty(t, logged.closed)
+	client.Close()
+}
+
+func TestOpenConnectionWithoutLog(t *testing.T) {
+	var p atomic.Pointer[ConnectionLog]
+	logged := openConnection(&p, "tcp", &net.TCPAddr{})
+	require.NotNil(t, logged)
+	logged.Closed(1, 2)
+
+	var noLog ConnectionLog
+	p.Store(&noLog)
+	openConnection(&p, "tcp", &net.TCPAddr{}).Closed(1, 2)
+}
+
+func TestTunnelTCPConnectionLog(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := l.Addr().(*net.TCPAddr).Port
+	require.NoError(t, l.Close())
+
+	opened := make(chan *testLoggedConnection, 1)
+	logger := logger.NewLogger("tcp-tunnel-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+	tunnel := newTunnelTCP(logger, nil, models.Remote{LocalHost: "127.0.0.1", LocalPort: strconv.Itoa(port)}, nil, nil)
+	tunnel.SetConnectionLog(func(protocol string, source net.Addr) LoggedConnection {
+		logged := newTestLoggedConnection(source)
+		opened <- logged
+		return logged
+	})
+	require.NoError(t, tunnel.Start(context.Background()))
+	defer tunnel.Terminate(true)
+
+	conn, err := net.Dial("tcp", tunnel.Local())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	var logged *testLoggedConnection
+	select {
+	case logged = <-opened:
+		assert.Equal(t, conn.LocalAddr().String(), logged.source)
+	case <-time.After(time.Second):
+		t.Fatal("connection not logged")
+	}
+	// without a connection to the client the connection is closed right away
+	select {
+	case c := <-logged.closed:
+		assert.Equal(t, [2]int64{0, 0}, c)
+	case <-time.After(time.Second):
+		t.Fatal("closed connection not logged")
+	}
+}
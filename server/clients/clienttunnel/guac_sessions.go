@@ -0,0 +1,112 @@
+package clienttunnel
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	GuacModeOwner   = "owner"
+	GuacModeControl = "control"
+	GuacModeView    = "view"
+)
+
+var ErrGuacSessionNotFound = errors.New("session not found")
+
+// GuacParticipant is a user connected to a remote desktop session.
+type GuacParticipant struct {
+	ID       string    `json:"id"`
+	Username string    `json:"username"`
+	RemoteIP string    `json:"remote_ip"`
+	Mode     string    `json:"mode"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// GuacSession is a remote desktop session, further participants join the guacd connection of the owner.
+type GuacSession struct {
+	ID           string             `json:"id"`
+	StartedAt    time.Time          `json:"started_at"`
+	Participants []*GuacParticipant `json:"participants"`
+
+	connectionID string
+}
+
+type guacSessions struct {
+	mu       sync.RWMutex
+	sessions map[string]*GuacSession
+}
+
+func newGuacSessions() *guacSessions {
+	return &guacSessions{
+		sessions: make(map[string]*GuacSession),
+	}
+}
+
+func (s *guacSessions) start(id, connectionID string, owner *GuacParticipant) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[id] = &GuacSession{
+		ID:           id,
+		StartedAt:    owner.JoinedAt,
+		Participants: []*GuacParticipant{owner},
+		connectionID: connectionID,
+	}
+}
+
+func (s *guacSessions) connectionID(id string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	session := s.sessions[id]
+	if session == nil {
+		return "", ErrGuacSessionNotFound
+	}
+	return session.connectionID, nil
+}
+
+func (s *guacSessions) join(id string, p *GuacParticipant) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session := s.sessions[id]
+	if session == nil {
+		return ErrGuacSessionNotFound
+	}
+	session.Participants = append(session.Participants, p)
+	return nil
+}
+
+// leave removes the participant, the session ends when the owner leaves.
+func (s *guacSessions) leave(participantID string) *GuacParticipant {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, session := range s.sessions {
+		for i, p := range session.Participants {
+			if p.ID != participantID {
+				continue
+			}
+			if p.Mode == GuacModeOwner {
+				delete(s.sessions, id)
+			} else {
+				session.Participants = append(session.Participants[:i:i], session.Participants[i+1:]...)
+			}
+			return p
+		}
+	}
+	return nil
+}
+
+func (s *guacSessions) list() []*GuacSession {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := make([]*GuacSession, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		c := *session
+		c.Participants = append([]*GuacParticipant(nil), session.Participants...)
+		result = append(result, &c)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].StartedAt.Before(result[j].StartedAt)
+	})
+	return result
+}
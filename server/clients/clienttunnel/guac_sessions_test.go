@@ -0,0 +1,58 @@
+package clienttunnel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGuacSessions(t *testing.T) {
+	sessions := newGuacSessions()
+	now := time.Now()
+	owner := &GuacParticipant{ID: "p1", Username: "admin", Mode: GuacModeOwner, JoinedAt: now}
+	sessions.start("s1", "$guacd-1", owner)
+
+	connectionID, err := sessions.connectionID("s1")
+	require.NoError(t, err)
+	assert.Equal(t, "$guacd-1", connectionID)
+	_, err = sessions.connectionID("s2")
+	assert.ErrorIs(t, err, ErrGuacSessionNotFound)
+
+	viewer := &GuacParticipant{ID: "p2", Username: "trainee", Mode: GuacModeView, JoinedAt: now}
+	require.NoError(t, sessions.join("s1", viewer))
+	assert.ErrorIs(t, sessions.join("s2", viewer), ErrGuacSessionNotFound)
+
+	assert.Equal(t, []*GuacSession{{
+		ID:           "s1",
+		StartedAt:    now,
+		Participants: []*GuacParticipant{owner, viewer},
+		connectionID: "$guacd-1",
+	}}, sessions.list())
+
+	assert.Equal(t, viewer, sessions.leave("p2"))
+	assert.Nil(t, sessions.leave("p2"))
+	assert.Equal(t, []*GuacParticipant{owner}, sessions.list()[0].Participants)
+
+	assert.Equal(t, owner, sessions.leave("p1"))
+	assert.Empty(t, sessions.list())
+}
+
+func TestInviteParticipant(t *testing.T) {
+	tc := &TunnelProxyConnectorRDP{guacTokenStore: NewGuacTokenStore(), sessions: newGuacSessions()}
+	tc.sessions.start("s1", "$guacd-1", &GuacParticipant{ID: "p1", Mode: GuacModeOwner})
+
+	_, err := tc.InviteParticipant("unknown", "trainee", GuacModeView)
+	assert.ErrorIs(t, err, ErrGuacSessionNotFound)
+
+	token, err := tc.InviteParticipant("s1", "trainee", GuacModeControl)
+	require.NoError(t, err)
+	guacToken := tc.guacTokenStore.Get(token)
+	require.NotNil(t, guacToken)
+	assert.Equal(t, &guacJoin{sessionID: "s1", participant: &GuacParticipant{Username: "trainee", Mode: GuacModeControl}}, guacToken.join)
+
+	guacToken.expiresAt = time.Now().Add(-time.Second)
+	assert.Nil(t, tc.guacTokenStore.Get(token))
+	assert.Empty(t, tc.guacTokenStore.GuacTokens)
+}
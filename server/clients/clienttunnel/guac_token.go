@@ -2,6 +2,7 @@ package clienttunnel
 
 import (
 	"sync"
+	"time"
 )
 
 // GuacToken ... used to transport guacd config parameters from request to request
@@ -21,6 +22,15 @@ type GuacToken struct {
 	height     string
 	keyboard   string
 	microphone bool
+
+	// join is set for invitations to join a running session
+	join      *guacJoin
+	expiresAt time.Time
+}
+
+type guacJoin struct {
+	sessionID   string
+	participant *GuacParticipant
 }
 
 type GuacTokenStore struct {
@@ -41,9 +51,14 @@ func (s *GuacTokenStore) Add(uuid string, token *GuacToken) {
 }
 
 func (s *GuacTokenStore) Get(uuid string) *GuacToken {
-	s.RLock()
-	defer s.RUnlock()
-	return s.GuacTokens[uuid]
+	s.Lock()
+	defer s.Unlock()
+	token := s.GuacTokens[uuid]
+	if token != nil && !token.expiresAt.IsZero() && time.Now().After(token.expiresAt) {
+		delete(s.GuacTokens, uuid)
+		return nil
+	}
+	return token
 }
 
 func (s *GuacTokenStore) Delete(uuid string) {
@@ -0,0 +1,292 @@
+package clienttunnel
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/wwt/guac"
+)
+
+const (
+	SessionTransferClipboard = "clipboard"
+	SessionTransferFile      = "file"
+
+	SessionTransferUpload   = "upload"
+	SessionTransferDownload = "download"
+)
+
+// Guacamole protocol status codes sent with the ack of rejected streams
+const (
+	guacStatusClientForbidden = "771" // 0x0303
+	guacStatusClientOverrun   = "781" // 0x030D
+)
+
+// SessionTransfer is a clipboard or file transfer of a remote desktop session.
+type SessionTransfer struct {
+	TunnelID  string `json:"tunnel_id"`
+	Type      string `json:"type"`
+	Direction string `json:"direction"`
+	Name      string `json:"name,omitempty"`
+	MimeType  string `json:"mimetype"`
+	Bytes     int64  `json:"bytes"`
+	Rejected  string `json:"rejected,omitempty"`
+	// Username is the participant of the session who sent or received the transfer
+	Username string `json:"-"`
+}
+
+type guacTransferStream struct {
+	transfer SessionTransfer
+	maxBytes int64
+	rejected bool
+	// clipboard data is held back until it's complete, so a clipboard exceeding the limit is never applied partially
+	buffered [][]byte
+}
+
+// guacTransferFilter enforces the limits of the clipboard and file streams sent in one direction of a guacd connection.
+type guacTransferFilter struct {
+	direction         string
+	clipboardMaxBytes int64
+	fileMaxBytes      int64
+	onTransfer        func(SessionTransfer)
+	streams           map[string]*guacTransferStream
+}
+
+func newGuacTransferFilter(direction string, clipboardMaxBytes, fileMaxBytes int64, onTransfer func(SessionTransfer)) *guacTransferFilter {
+	return &guacTransferFilter{
+		direction:         direction,
+		clipboardMaxBytes: clipboardMaxBytes,
+		fileMaxBytes:      fileMaxBytes,
+		onTransfer:        onTransfer,
+		streams:           make(map[string]*guacTransferStream),
+	}
+}
+
+// filter returns the instructions of data to forward and the replies to send back to the sender.
+func (f *guacTransferFilter) filter(data []byte) (forward []byte, replies []byte) {
+	var out, rep bytes.Buffer
+	for len(data) > 0 {
+		ins, n, err := nextGuacInstruction(data)
+		if err != nil {
+			// leave it to guacd to fail on invalid instructions
+			out.Write(data)
+			break
+		}
+		f.filterInstruction(ins, data[:n], &out, &rep)
+		data = data[n:]
+	}
+	return out.Bytes(), rep.Bytes()
+}
+
+func (f *guacTransferFilter) filterInstruction(ins *guac.Instruction, raw []byte, out, rep *bytes.Buffer) {
+	switch ins.Opcode {
+	case "clipboard":
+		if len(ins.Args) < 2 {
+			break
+		}
+		s := f.openStream(ins.Args[0], SessionTransfer{Type: SessionTransferClipboard, MimeType: ins.Args[1]}, f.clipboardMaxBytes)
+		if s.rejected {
+			f.reject(s, ins.Args[0], "clipboard is disabled", guacStatusClientForbidden, rep)
+			return
+		}
+		s.buffered = append(s.buffered, append([]byte(nil), raw...))
+		return
+	case "file", "put":
+		// file: stream, mimetype, filename; put: object, stream, mimetype, name
+		args := ins.Args
+		if ins.Opcode == "put" && len(args) > 0 {
+			args = args[1:]
+		}
+		if f.direction != SessionTransferUpload || len(args) < 3 {
+			break
+		}
+		s := f.openStream(args[0], SessionTransfer{Type: SessionTransferFile, MimeType: args[1], Name: args[2]}, f.fileMaxBytes)
+		if s.rejected {
+			f.reject(s, args[0], "file upload is disabled", guacStatusClientForbidden, rep)
+			return
+		}
+	case "blob":
+		if len(ins.Args) < 2 {
+			break
+		}
+		s := f.streams[ins.Args[0]]
+		if s == nil {
+			break
+		}
+		if s.rejected {
+			return
+		}
+		s.transfer.Bytes += base64DecodedLen(ins.Args[1])
+		if s.transfer.Bytes > s.maxBytes {
+			if s.buffered == nil {
+				// close the stream for the receiver, it must not wait for the rest of it
+				out.Write(guac.NewInstruction("end", ins.Args[0]).Byte())
+			}
+			f.reject(s, ins.Args[0], "transfer exceeds the limit of "+strconv.FormatInt(s.maxBytes, 10)+" bytes", guacStatusClientOverrun, rep)
+			return
+		}
+		if s.buffered != nil {
+			s.buffered = append(s.buffered, append([]byte(nil), raw...))
+			return
+		}
+	case "end":
+		if len(ins.Args) < 1 {
+			break
+		}
+		s := f.streams[ins.Args[0]]
+		if s == nil {
+			break
+		}
+		delete(f.streams, ins.Args[0])
+		if s.rejected {
+			return
+		}
+		for _, b := range s.buffered {
+			out.Write(b)
+		}
+		f.onTransfer(s.transfer)
+	}
+	out.Write(raw)
+}
+
+func (f *guacTransferFilter) openStream(index string, transfer SessionTransfer, maxBytes int64) *guacTransferStream {
+	transfer.Direction = f.direction
+	s := &guacTransferStream{
+		transfer: transfer,
+		maxBytes: maxBytes,
+		rejected: maxBytes <= 0,
+	}
+	f.streams[index] = s
+	return s
+}
+
+func (f *guacTransferFilter) reject(s *guacTransferStream, index, reason, status string, rep *bytes.Buffer) {
+	s.rejected = true
+	s.buffered = nil
+	s.transfer.Rejected = reason
+	rep.Write(guac.NewInstruction("ack", index, reason, status).Byte())
+	f.onTransfer(s.transfer)
+}
+
+func base64DecodedLen(s string) int64 {
+	n := int64(len(s)) / 4 * 3
+	for i := len(s) - 1; i >= 0 && s[i] == '='; i-- {
+		n--
+	}
+	return n
+}
+
+var errInvalidGuacInstruction = errors.New("invalid guacamole instruction")
+
+// nextGuacInstruction parses the first instruction of data, it returns the instruction and its length in bytes.
+func nextGuacInstruction(data []byte) (*guac.Instruction, int, error) {
+	var elements []string
+	i := 0
+	for {
+		dot := bytes.IndexByte(data[i:], '.')
+		if dot <= 0 {
+			return nil, 0, errInvalidGuacInstruction
+		}
+		length, err := strconv.Atoi(string(data[i : i+dot]))
+		if err != nil || length < 0 {
+			return nil, 0, errInvalidGuacInstruction
+		}
+		start := i + dot + 1
+		end := start + length
+		if end >= len(data) {
+			return nil, 0, errInvalidGuacInstruction
+		}
+		elements = append(elements, string(data[start:end]))
+		i = end + 1
+		switch data[end] {
+		case ';':
+			return guac.NewInstruction(elements[0], elements[1:]...), i, nil
+		case ',':
+		default:
+			return nil, 0, errInvalidGuacInstruction
+		}
+	}
+}
+
+// guacTransferTunnel filters the clipboard and file transfers of a guacd tunnel. Uploads are sent by the browser,
+// downloads by guacd. Replies to rejected uploads are sent to the browser together with the next instruction of guacd.
+type guacTransferTunnel struct {
+	guac.Tunnel
+	upload   *guacTransferFilter
+	download *guacTransferFilter
+
+	mu      sync.Mutex
+	replies []byte
+}
+
+func newGuacTransferTunnel(tunnel guac.Tunnel, clipboardMaxBytes, fileMaxBytes int64, onTransfer func(SessionTransfer)) *guacTransferTunnel {
+	return &guacTransferTunnel{
+		Tunnel:   tunnel,
+		upload:   newGuacTransferFilter(SessionTransferUpload, clipboardMaxBytes, fileMaxBytes, onTransfer),
+		download: newGuacTransferFilter(SessionTransferDownload, clipboardMaxBytes, 0, onTransfer),
+	}
+}
+
+func (t *guacTransferTunnel) AcquireWriter() io.Writer {
+	return &guacTransferWriter{tunnel: t, writer: t.Tunnel.AcquireWriter()}
+}
+
+func (t *guacTransferTunnel) AcquireReader() guac.InstructionReader {
+	return &guacTransferReader{tunnel: t, InstructionReader: t.Tunnel.AcquireReader()}
+}
+
+func (t *guacTransferTunnel) addReplies(replies []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.replies = append(t.replies, replies...)
+}
+
+func (t *guacTransferTunnel) takeReplies() []byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	replies := t.replies
+	t.replies = nil
+	return replies
+}
+
+type guacTransferWriter struct {
+	tunnel *guacTransferTunnel
+	writer io.Writer
+}
+
+func (w *guacTransferWriter) Write(data []byte) (int, error) {
+	forward, replies := w.tunnel.upload.filter(data)
+	if len(replies) > 0 {
+		w.tunnel.addReplies(replies)
+	}
+	if len(forward) > 0 {
+		if _, err := w.writer.Write(forward); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+type guacTransferReader struct {
+	guac.InstructionReader
+	tunnel *guacTransferTunnel
+}
+
+func (r *guacTransferReader) ReadSome() ([]byte, error) {
+	for {
+		data, err := r.InstructionReader.ReadSome()
+		if err != nil {
+			return data, err
+		}
+		// replies to guacd are dropped, it doesn't wait for the acks of its clipboard streams
+		forward, _ := r.tunnel.download.filter(data)
+		if replies := r.tunnel.takeReplies(); len(replies) > 0 {
+			forward = append(replies, forward...)
+		}
+		if len(forward) > 0 {
+			return forward, nil
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package clienttunnel
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wwt/guac"
+)
+
+// guacInstructions encodes instructions given as comma separated elements
+func guacInstructions(instructions ...string) []byte {
+	var b []byte
+	for _, ins := range instructions {
+		elements := strings.Split(ins, ",")
+		b = append(b, guac.NewInstruction(elements[0], elements[1:]...).Byte()...)
+	}
+	return b
+}
+
+func TestGuacTransferFilter(t *testing.T) {
+	testCases := []struct {
+		name              string
+		direction         string
+		clipboardMaxBytes int64
+		fileMaxBytes      int64
+		input             []string
+		expectedForward   []string
+		expectedReplies   []string
+		expectedTransfers []SessionTransfer
+	}{
+		{
+			name:              "clipboard",
+			direction:         SessionTransferUpload,
+			clipboardMaxBytes: 10,
+			input:             []string{"clipboard,0,text/plain", "mouse,1,1", "blob,0,aGVsbG8=", "end,0"},
+			expectedForward:   []string{"mouse,1,1", "clipboard,0,text/plain", "blob,0,aGVsbG8=", "end,0"},
+			expectedTransfers: []SessionTransfer{{Type: SessionTransferClipboard, Direction: SessionTransferUpload, MimeType: "text/plain", Bytes: 5}},
+		},
+		{
+			name:              "clipboard too large",
+			direction:         SessionTransferDownload,
+			clipboardMaxBytes: 4,
+			input:             []string{"clipboard,1,text/plain", "blob,1,aGVsbG8=", "end,1", "sync,123"},
+			expectedForward:   []string{"sync,123"},
+			expectedReplies:   []string{"ack,1,transfer exceeds the limit of 4 bytes,781"},
+			expectedTransfers: []SessionTransfer{{Type: SessionTransferClipboard, Direction: SessionTransferDownload, MimeType: "text/plain", Bytes: 5, Rejected: "transfer exceeds the limit of 4 bytes"}},
+		},
+		{
+			name:              "clipboard disabled",
+			direction:         SessionTransferUpload,
+			input:             []string{"clipboard,0,text/plain", "blob,0,aGVsbG8=", "end,0"},
+			expectedReplies:   []string{"ack,0,clipboard is disabled,771"},
+			expectedTransfers: []SessionTransfer{{Type: SessionTransferClipboard, Direction: SessionTransferUpload, MimeType: "text/plain", Rejected: "clipboard is disabled"}},
+		},
+		{
+			name:              "file",
+			direction:         SessionTransferUpload,
+			fileMaxBytes:      10,
+			input:             []string{"file,2,text/plain,a.txt", "blob,2,aGVsbG8=", "blob,2,aGk=", "end,2"},
+			expectedForward:   []string{"file,2,text/plain,a.txt", "blob,2,aGVsbG8=", "blob,2,aGk=", "end,2"},
+			expectedTransfers: []SessionTransfer{{Type: SessionTransferFile, Direction: SessionTransferUpload, Name: "a.txt", MimeType: "text/plain", Bytes: 7}},
+		},
+		{
+			name:              "file too large",
+			direction:         SessionTransferUpload,
+			fileMaxBytes:      6,
+			input:             []string{"file,2,text/plain,a.txt", "blob,2,aGVsbG8=", "blob,2,aGk=", "blob,2,aGk=", "end,2"},
+			expectedForward:   []string{"file,2,text/plain,a.txt", "blob,2,aGVsbG8=", "end,2"},
+			expectedReplies:   []string{"ack,2,transfer exceeds the limit of 6 bytes,781"},
+			expectedTransfers: []SessionTransfer{{Type: SessionTransferFile, Direction: SessionTransferUpload, Name: "a.txt", MimeType: "text/plain", Bytes: 7, Rejected: "transfer exceeds the limit of 6 bytes"}},
+		},
+		{
+			name:              "file upload disabled",
+			direction:         SessionTransferUpload,
+			clipboardMaxBytes: 10,
+			input:             []string{"put,0,3,text/plain,a.txt", "blob,3,aGk=", "end,3"},
+			expectedReplies:   []string{"ack,3,file upload is disabled,771"},
+			expectedTransfers: []SessionTransfer{{Type: SessionTransferFile, Direction: SessionTransferUpload, Name: "a.txt", MimeType: "text/plain", Rejected: "file upload is disabled"}},
+		},
+		{
+			name:            "file download",
+			direction:       SessionTransferDownload,
+			input:           []string{"file,2,text/plain,a.txt", "blob,2,aGk=", "end,2"},
+			expectedForward: []string{"file,2,text/plain,a.txt", "blob,2,aGk=", "end,2"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var transfers []SessionTransfer
+			f := newGuacTransferFilter(tc.direction, tc.clipboardMaxBytes, tc.fileMaxBytes, func(transfer SessionTransfer) {
+				transfers = append(transfers, transfer)
+			})
+
+			var forward, replies []byte
+			for _, ins := range tc.input {
+				fw, rep := f.filter(guacInstructions(ins))
+				forward = append(forward, fw...)
+				replies = append(replies, rep...)
+			}
+
+			assert.Equal(t, string(guacInstructions(tc.expectedForward...)), string(forward))
+			assert.Equal(t, string(guacInstructions(tc.expectedReplies...)), string(replies))
+			assert.Equal(t, tc.expectedTransfers, transfers)
+			assert.Empty(t, f.streams)
+		})
+	}
+}
+
+func TestNextGuacInstruction(t *testing.T) {
+	ins, n, err := nextGuacInstruction([]byte("4.blob,1.0,8.aGVsbG8=;3.end,1.0;"))
+	assert.NoError(t, err)
+	assert.Equal(t, 22, n)
+	assert.Equal(t, "blob", ins.Opcode)
+	assert.Equal(t, []string{"0", "aGVsbG8="}, ins.Args)
+
+	for _, invalid := range []string{"", "4.blob", "4.blob,1.0", "x.end;", "3.end:", "99.end;"} {
+		_, _, err = nextGuacInstruction([]byte(invalid))
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,25 @@
+package clienttunnel
+
+import "sync/atomic"
+
+// Traffic counts the bytes transferred through the tunnels of a client since the server started. Sent bytes are
+// sent to the client, received bytes are received from the client. A nil Traffic counts nothing.
+type Traffic struct {
+	sent     int64
+	received int64
+}
+
+func (t *Traffic) Add(sent, received int64) {
+	if t == nil {
+		return
+	}
+	atomic.AddInt64(&t.sent, sent)
+	atomic.AddInt64(&t.received, received)
+}
+
+func (t *Traffic) Get() (sent, received int64) {
+	if t == nil {
+		return 0, 0
+	}
+	return atomic.LoadInt64(&t.sent), atomic.LoadInt64(&t.received)
+}
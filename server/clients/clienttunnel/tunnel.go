@@ -2,6 +2,7 @@ package clienttunnel
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/crypto/ssh"
@@ -18,8 +19,13 @@ type TunnelProtocol interface {
 	Terminate(force bool) error
 	LastActive() time.Time
 	SetACL(*TunnelACL)
+	SetAccessCheck(AccessCheck)
+	SetConnectionLog(ConnectionLog)
 }
 
+// AccessCheck returns an error if the tunnel must not be used at the moment, it's checked for each new connection.
+type AccessCheck func() error
+
 type MultiProtocolTunnel struct {
 	Protocols []TunnelProtocol
 }
@@ -62,6 +68,18 @@ func (mt *MultiProtocolTunnel) SetACL(acl *TunnelACL) {
 	}
 }
 
+func (mt *MultiProtocolTunnel) SetAccessCheck(check AccessCheck) {
+	for _, tp := range mt.Protocols {
+		tp.SetAccessCheck(check)
+	}
+}
+
+func (mt *MultiProtocolTunnel) SetConnectionLog(log ConnectionLog) {
+	for _, tp := range mt.Protocols {
+		tp.SetConnectionLog(log)
+	}
+}
+
 // TODO(m-terel): Refactor to use separate models for representation and business logic.
 // Tunnel represents active remote proxy connection
 type Tunnel struct {
@@ -72,23 +90,25 @@ type Tunnel struct {
 	TunnelProtocol      `json:"-"`
 	InternalTunnelProxy *InternalTunnelProxy `json:"-"`
 	CreatedAt           time.Time            `json:"created_at"`
+
+	accessCheck atomic.Pointer[AccessCheck]
 }
 
-func NewTunnel(logger *logger.Logger, ssh ssh.Conn, id string, remote models.Remote, acl *TunnelACL) (*Tunnel, error) {
+func NewTunnel(logger *logger.Logger, ssh ssh.Conn, id string, remote models.Remote, acl *TunnelACL, traffic *Traffic) (*Tunnel, error) {
 	logger = logger.Fork("tunnel#%s:%s", id, remote)
 	logger.Debugf("new tunnel with remote = %#v", remote)
 
 	var tunnelProtocol TunnelProtocol
 	switch remote.Protocol {
 	case models.ProtocolUDP:
-		tunnelProtocol = newTunnelUDP(logger, ssh, remote, acl)
+		tunnelProtocol = newTunnelUDP(logger, ssh, remote, acl, traffic)
 	case models.ProtocolTCP:
-		tunnelProtocol = newTunnelTCP(logger, ssh, remote, acl)
+		tunnelProtocol = newTunnelTCP(logger, ssh, remote, acl, traffic)
 	case models.ProtocolTCPUDP:
 		tunnelProtocol = &MultiProtocolTunnel{
 			Protocols: []TunnelProtocol{
-				newTunnelTCP(logger, ssh, remote, acl),
-				newTunnelUDP(logger, ssh, remote, acl),
+				newTunnelTCP(logger, ssh, remote, acl, traffic),
+				newTunnelUDP(logger, ssh, remote, acl, traffic),
 			},
 		}
 	default:
@@ -102,3 +122,22 @@ func NewTunnel(logger *logger.Logger, ssh ssh.Conn, id string, remote models.Rem
 		CreatedAt:      time.Now(),
 	}, nil
 }
+
+// SetAccessCheck sets the access check of the tunnel and its protocols.
+func (t *Tunnel) SetAccessCheck(check AccessCheck) {
+	t.accessCheck.Store(&check)
+	t.TunnelProtocol.SetAccessCheck(check)
+}
+
+// CheckAccess returns the error of the access check, nil if there is none.
+func (t *Tunnel) CheckAccess() error {
+	return loadAccessCheck(&t.accessCheck)
+}
+
+func loadAccessCheck(p *atomic.Pointer[AccessCheck]) error {
+	check := p.Load()
+	if check == nil || *check == nil {
+		return nil
+	}
+	return (*check)()
+}
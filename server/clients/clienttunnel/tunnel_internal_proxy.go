@@ -18,6 +18,7 @@ import (
 	"github.com/rs/cors"
 
 	"github.com/IOTech17/neo-rport/server/acme"
+	"github.com/IOTech17/neo-rport/server/api/middleware"
 	chshare "github.com/IOTech17/neo-rport/share"
 	"github.com/IOTech17/neo-rport/share/logger"
 	"github.com/IOTech17/neo-rport/share/security"
@@ -41,6 +42,16 @@ type InternalTunnelProxyConfig struct {
 	GuacdAddress string   `mapstructure:"guacd_address"`
 	CORS         []string `mapstructure:"tunnel_cors"`
 	Enabled      bool
+
+	RDPClipboardMaxBytes int64  `mapstructure:"rdp_clipboard_max_bytes"`
+	RDPDrivePath         string `mapstructure:"rdp_drive_path"`
+	RDPFileDropMaxBytes  int64  `mapstructure:"rdp_file_drop_max_bytes"`
+	RDPRecordingPath     string `mapstructure:"rdp_recording_path"`
+
+	// Banner is the legal notice of the server, users must accept it before the session starts
+	Banner string `mapstructure:"-"`
+	// TrustedProxies may forward the address of the user by X-Forwarded-For
+	TrustedProxies []*net.IPNet `mapstructure:"-"`
 }
 
 func (c *InternalTunnelProxyConfig) ParseAndValidate() error {
@@ -72,11 +83,25 @@ func (c *InternalTunnelProxyConfig) ParseAndValidate() error {
 	if c.TLSMin != "" && c.TLSMin != "1.2" && c.TLSMin != "1.3" {
 		return errors.New("TLS must be either 1.2 or 1.3")
 	}
+	if c.RDPClipboardMaxBytes < 0 {
+		return errors.New("'rdp_clipboard_max_bytes' must not be negative")
+	}
+	if c.RDPFileDropMaxBytes < 0 {
+		return errors.New("'rdp_file_drop_max_bytes' must not be negative")
+	}
 	c.Enabled = true
 
 	return nil
 }
 
+// rdpFileDropMaxBytes returns 0 if dropping files into remote desktop sessions is disabled.
+func (c *InternalTunnelProxyConfig) rdpFileDropMaxBytes() int64 {
+	if c.RDPDrivePath == "" {
+		return 0
+	}
+	return c.RDPFileDropMaxBytes
+}
+
 func (c *InternalTunnelProxyConfig) validateGuacd(addr string) error {
 	if addr == "" {
 		return nil
@@ -112,9 +137,15 @@ type InternalTunnelProxy struct {
 	TunnelHost           string
 	TunnelPort           string
 	acl                  atomic.Pointer[TunnelACL]
+	connLog              atomic.Pointer[ConnectionLog]
 	proxyServer          *http.Server
 	tunnelProxyConnector TunnelProxyConnector
 	acme                 *acme.Acme
+
+	// OnSessionTransfer is called for the clipboard and file transfers of remote desktop sessions
+	OnSessionTransfer func(r *http.Request, transfer SessionTransfer)
+	// DevicePassword is called if the remote of the tunnel has device authentication
+	DevicePassword DevicePassword
 }
 
 func NewInternalTunnelProxy(tunnel *Tunnel, logger *logger.Logger, config *InternalTunnelProxyConfig, host string, port string, acl *TunnelACL, acme *acme.Acme) *InternalTunnelProxy {
@@ -133,9 +164,16 @@ func NewInternalTunnelProxy(tunnel *Tunnel, logger *logger.Logger, config *Inter
 	return tp
 }
 
+// RDPConnector returns nil if the tunnel proxy doesn't serve remote desktop sessions.
+func (tp *InternalTunnelProxy) RDPConnector() *TunnelProxyConnectorRDP {
+	rdp, _ := tp.tunnelProxyConnector.(*TunnelProxyConnectorRDP)
+	return rdp
+}
+
 func (tp *InternalTunnelProxy) Start(ctx context.Context) error {
 	router := mux.NewRouter()
 	router.Use(tp.handleACL)
+	router.Use(tp.handleAccessCheck)
 
 	router.Handle("/css/tunnel-proxy.css", http.FileServer(http.FS(tunnelProxyCSS)))
 	router.Handle("/css/semantic.css", http.FileServer(http.FS(semanticCSS)))
@@ -158,10 +196,20 @@ func (tp *InternalTunnelProxy) Start(ctx context.Context) error {
 		}).Handler)
 	}
 
+	h := http.Handler(router)
+	if len(tp.Config.TrustedProxies) > 0 {
+		h = middleware.TrustedProxies(tp.Config.TrustedProxies)(h)
+	}
+
+	if tp.Tunnel.Remote.HTTPInspection {
+		h = tp.inspectHTTP(h)
+	}
+
 	tp.proxyServer = &http.Server{
 		Addr:              tp.Addr(),
-		Handler:           router,
+		Handler:           h,
 		ReadHeaderTimeout: 5 * time.Second,
+		ConnContext:       withLoggedConnection,
 	}
 
 	go tp.listen()
@@ -178,7 +226,17 @@ func (tp *InternalTunnelProxy) listen() {
 	if tp.Config.EnableAcme {
 		tp.proxyServer.TLSConfig = tp.acme.ApplyTLSConfig(tp.proxyServer.TLSConfig)
 	}
-	err := tp.proxyServer.ListenAndServeTLS(tp.Config.CertFile, tp.Config.KeyFile)
+	l, err := net.Listen("tcp", tp.proxyServer.Addr)
+	if err != nil {
+		tp.Logger.Debugf("tunnel proxy ended with %v", err)
+		return
+	}
+	// ServeTLS doesn't close the listener if it fails before serving
+	defer l.Close()
+	if loadConnectionLog(&tp.connLog) != nil {
+		l = &loggedListener{Listener: l, log: &tp.connLog}
+	}
+	err = tp.proxyServer.ServeTLS(l, tp.Config.CertFile, tp.Config.KeyFile)
 	if err != nil && err == http.ErrServerClosed {
 		tp.Logger.Infof("tunnel proxy closed")
 		return
@@ -233,6 +291,18 @@ func (tp *InternalTunnelProxy) handleACL(next http.Handler) http.Handler {
 	})
 }
 
+// handleAccessCheck middleware rejects requests if the tunnel must not be used at the moment
+func (tp *InternalTunnelProxy) handleAccessCheck(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := tp.Tunnel.CheckAccess(); err != nil {
+			tp.Logger.Infof("Proxy Access rejected: %v", err)
+			tp.sendHTML(w, http.StatusForbidden, err.Error())
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (tp *InternalTunnelProxy) serveTemplate(w http.ResponseWriter, r *http.Request, templateContent string, templateData map[string]interface{}) {
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
@@ -266,6 +336,11 @@ func (tp *InternalTunnelProxy) noCache(next http.Handler) http.Handler {
 	})
 }
 
+// SetConnectionLog sets the log of the connections to the tunnel proxy, it must be set before the proxy is started.
+func (tp *InternalTunnelProxy) SetConnectionLog(log ConnectionLog) {
+	tp.connLog.Store(&log)
+}
+
 func (tp *InternalTunnelProxy) SetACL(acl *TunnelACL) {
 	tp.acl.Store(acl)
 }
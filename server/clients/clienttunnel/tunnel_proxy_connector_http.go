@@ -58,6 +58,13 @@ func (tc *TunnelProxyConnectorHTTP) serveHTTP(w http.ResponseWriter, r *http.Req
 			return
 		}
 	}
+	if deviceAuth := tc.tunnelProxy.Tunnel.Remote.DeviceAuth; deviceAuth != nil {
+		if err := tc.tunnelProxy.injectDeviceAuth(r, deviceAuth); err != nil {
+			tc.tunnelProxy.Logger.Errorf("Failed to log in to the device: %v", err)
+			tc.tunnelProxy.sendHTML(w, http.StatusBadGateway, "failed to log in to the device")
+			return
+		}
+	}
 	tc.reverseProxy.ServeHTTP(w, r)
 }
 
@@ -2,15 +2,23 @@ package clienttunnel
 
 import (
 	_ "embed" //to embed html templates
+	"errors"
 	"net"
 	"net/http"
+	"path"
 	"strconv"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/wwt/guac"
+
+	chshare "github.com/IOTech17/neo-rport/share"
 )
 
+// GuacJoinTokenLifetime is the time an invitation to join a session can be used
+const GuacJoinTokenLifetime = 10 * time.Minute
+
 const (
 	queryParToken      = "token"
 	queryParSecurity   = "security"
@@ -42,12 +50,15 @@ type TunnelProxyConnectorRDP struct {
 	tunnelProxy         *InternalTunnelProxy
 	guacWebsocketServer *guac.WebsocketServer
 	guacTokenStore      *GuacTokenStore
+	sessions            *guacSessions
 }
 
 func NewTunnelConnectorRDP(tp *InternalTunnelProxy) *TunnelProxyConnectorRDP {
 	tpc := &TunnelProxyConnectorRDP{tunnelProxy: tp}
 	tpc.guacWebsocketServer = guac.NewWebsocketServer(tpc.connectToGuacamole)
+	tpc.guacWebsocketServer.OnDisconnect = tpc.onGuacDisconnect
 	tpc.guacTokenStore = NewGuacTokenStore()
+	tpc.sessions = newGuacSessions()
 
 	return tpc
 }
@@ -61,6 +72,7 @@ func (tc *TunnelProxyConnectorRDP) InitRouter(router *mux.Router) *mux.Router {
 
 	router.HandleFunc("/", tc.serveIndex)
 	router.HandleFunc("/createToken", tc.serveTunnelStarter)
+	router.HandleFunc("/join", tc.serveJoin)
 
 	return router
 }
@@ -87,6 +99,7 @@ func (tc *TunnelProxyConnectorRDP) serveIndex(w http.ResponseWriter, r *http.Req
 		"isError":          guacError != "",
 		"securityOptions":  CreateOptions(keysSecurity, keysSecurity, selSecurity),
 		"keyboardOptions":  CreateOptions(keysKeyboard, valuesKeyboard, selKeyboard),
+		"banner":           tc.tunnelProxy.Config.Banner,
 	}
 
 	tc.tunnelProxy.serveTemplate(w, r, guacIndexHTML, templateData)
@@ -98,12 +111,14 @@ func (tc *TunnelProxyConnectorRDP) serveTunnelStarter(w http.ResponseWriter, r *
 	tc.guacTokenStore.Add(token, guacToken)
 
 	templateData := map[string]interface{}{
-		"token":            token,
-		queryParUsername:   guacToken.username,
-		queryParDomain:     guacToken.domain,
-		queryParSecurity:   guacToken.security,
-		queryParKeyboard:   guacToken.keyboard,
-		queryParMicrophone: guacToken.microphone,
+		"token":             token,
+		queryParUsername:    guacToken.username,
+		queryParDomain:      guacToken.domain,
+		queryParSecurity:    guacToken.security,
+		queryParKeyboard:    guacToken.keyboard,
+		queryParMicrophone:  guacToken.microphone,
+		"clipboardMaxBytes": tc.tunnelProxy.Config.RDPClipboardMaxBytes,
+		"fileDropMaxBytes":  tc.tunnelProxy.Config.rdpFileDropMaxBytes(),
 	}
 
 	tc.tunnelProxy.serveTemplate(w, r, guacStartTunnelHTML, templateData)
@@ -126,6 +141,19 @@ func parseGuacToken(r *http.Request) *GuacToken {
 // connectToGuacamole creates the tunnel to the remote machine (via guacd)
 func (tc *TunnelProxyConnectorRDP) connectToGuacamole(r *http.Request) (guac.Tunnel, error) {
 	tc.tunnelProxy.Logger.Infof("TunnelProxyConnectorRDP: connect to tunnel: %s", tc.tunnelProxy.TunnelAddr())
+
+	token := r.Form.Get(queryParToken)
+	guacToken := tc.guacTokenStore.Get(token)
+	if guacToken == nil {
+		tc.tunnelProxy.Logger.Errorf("Cannot find guac token %s", token)
+		return nil, errors.New("invalid guac token")
+	}
+	tc.guacTokenStore.Delete(token)
+
+	if guacToken.join != nil {
+		return tc.joinGuacSession(r, guacToken.join)
+	}
+
 	config := guac.NewGuacamoleConfiguration()
 
 	config.Protocol = "rdp"
@@ -135,14 +163,6 @@ func (tc *TunnelProxyConnectorRDP) connectToGuacamole(r *http.Request) (guac.Tun
 
 	var err error
 
-	token := r.Form.Get(queryParToken)
-	guacToken := tc.guacTokenStore.Get(token)
-	if guacToken == nil {
-		tc.tunnelProxy.Logger.Errorf("Cannot find guac token %s", token)
-		return nil, err
-	}
-	tc.guacTokenStore.Delete(token)
-
 	config.Parameters[queryParSecurity] = guacToken.security
 	config.Parameters[queryParUsername] = guacToken.username
 	config.Parameters[queryParPassword] = guacToken.password
@@ -176,6 +196,106 @@ func (tc *TunnelProxyConnectorRDP) connectToGuacamole(r *http.Request) (guac.Tun
 		config.Parameters["disable-sound"] = "true"
 	}
 
+	if tc.tunnelProxy.Config.RDPClipboardMaxBytes == 0 {
+		config.Parameters["disable-copy"] = "true"
+		config.Parameters["disable-paste"] = "true"
+	}
+	if tc.tunnelProxy.Config.rdpFileDropMaxBytes() > 0 {
+		// each session gets its own drive, files dropped by one technician are not visible to others
+		config.Parameters["enable-drive"] = "true"
+		config.Parameters["drive-name"] = "RPort"
+		config.Parameters["drive-path"] = path.Join(tc.tunnelProxy.Config.RDPDrivePath, uuid.New().String())
+		config.Parameters["create-drive-path"] = "true"
+	}
+
+	sessionID := uuid.New().String()
+	if tc.tunnelProxy.Config.RDPRecordingPath != "" {
+		// the recording of the owner connection includes the input of all participants who join the session
+		config.Parameters["recording-path"] = tc.tunnelProxy.Config.RDPRecordingPath
+		config.Parameters["recording-name"] = sessionID
+		config.Parameters["create-recording-path"] = "true"
+		config.Parameters["recording-include-keys"] = "true"
+	}
+
+	stream, err := tc.handshake(config)
+	if err != nil {
+		return nil, err
+	}
+
+	owner := &GuacParticipant{
+		Username: tc.tunnelProxy.Tunnel.Owner,
+		RemoteIP: chshare.RemoteIP(r),
+		Mode:     GuacModeOwner,
+		JoinedAt: time.Now(),
+	}
+	tunnel := tc.newGuacTunnel(r, stream, owner)
+	tc.sessions.start(sessionID, stream.ConnectionID, owner)
+	tc.tunnelProxy.Logger.Infof("Started session %s", sessionID)
+
+	return tunnel, nil
+}
+
+// joinGuacSession connects a further participant to the guacd connection of a running session.
+func (tc *TunnelProxyConnectorRDP) joinGuacSession(r *http.Request, join *guacJoin) (guac.Tunnel, error) {
+	connectionID, err := tc.sessions.connectionID(join.sessionID)
+	if err != nil {
+		tc.tunnelProxy.Logger.Errorf("Cannot join session %s: %v", join.sessionID, err)
+		return nil, err
+	}
+
+	config := guac.NewGuacamoleConfiguration()
+	config.Protocol = "rdp"
+	config.ConnectionID = connectionID
+	config.AudioMimetypes = []string{"audio/L16", "rate=44100", "channels=2"}
+	if join.participant.Mode == GuacModeView {
+		config.Parameters["read-only"] = "true"
+	}
+
+	stream, err := tc.handshake(config)
+	if err != nil {
+		return nil, err
+	}
+
+	p := join.participant
+	p.RemoteIP = chshare.RemoteIP(r)
+	p.JoinedAt = time.Now()
+	tunnel := tc.newGuacTunnel(r, stream, p)
+	if err := tc.sessions.join(join.sessionID, p); err != nil {
+		tunnel.Close()
+		return nil, err
+	}
+	tc.tunnelProxy.Logger.Infof("User %q joined session %s in %s mode", p.Username, join.sessionID, p.Mode)
+
+	return tunnel, nil
+}
+
+func (tc *TunnelProxyConnectorRDP) newGuacTunnel(r *http.Request, stream *guac.Stream, p *GuacParticipant) guac.Tunnel {
+	clipboardMaxBytes := tc.tunnelProxy.Config.RDPClipboardMaxBytes
+	fileMaxBytes := tc.tunnelProxy.Config.rdpFileDropMaxBytes()
+	if p.Mode == GuacModeView {
+		clipboardMaxBytes, fileMaxBytes = 0, 0
+	}
+	tunnel := newGuacTransferTunnel(guac.NewSimpleTunnel(stream), clipboardMaxBytes, fileMaxBytes, func(transfer SessionTransfer) {
+		transfer.Username = p.Username
+		tc.onSessionTransfer(r, transfer)
+	})
+	p.ID = tunnel.GetUUID()
+	return tunnel
+}
+
+func (tc *TunnelProxyConnectorRDP) onSessionTransfer(r *http.Request, transfer SessionTransfer) {
+	transfer.TunnelID = tc.tunnelProxy.Tunnel.ID
+	if transfer.Rejected != "" {
+		tc.tunnelProxy.Logger.Infof("Rejected %s %s %q of %d bytes: %s", transfer.Type, transfer.Direction, transfer.Name, transfer.Bytes, transfer.Rejected)
+	} else {
+		tc.tunnelProxy.Logger.Infof("Session %s %s %q of %d bytes", transfer.Type, transfer.Direction, transfer.Name, transfer.Bytes)
+	}
+	if tc.tunnelProxy.OnSessionTransfer != nil {
+		tc.tunnelProxy.OnSessionTransfer(r, transfer)
+	}
+}
+
+func (tc *TunnelProxyConnectorRDP) handshake(config *guac.Config) (*guac.Stream, error) {
 	tc.tunnelProxy.Logger.Debugf("Connecting to guacd")
 	addr, err := net.ResolveTCPAddr("tcp", tc.tunnelProxy.Config.GuacdAddress)
 	if err != nil {
@@ -199,7 +319,63 @@ func (tc *TunnelProxyConnectorRDP) connectToGuacamole(r *http.Request) (guac.Tun
 		return nil, err
 	}
 	tc.tunnelProxy.Logger.Debugf("Socket configured")
-	return guac.NewSimpleTunnel(stream), nil
+	return stream, nil
+}
+
+func (tc *TunnelProxyConnectorRDP) onGuacDisconnect(_ string, _ *http.Request, tunnel guac.Tunnel) {
+	p := tc.sessions.leave(tunnel.GetUUID())
+	if p == nil {
+		return
+	}
+	if p.Mode == GuacModeOwner {
+		tc.tunnelProxy.Logger.Infof("Session of %q ended", p.Username)
+	} else {
+		tc.tunnelProxy.Logger.Infof("User %q left the session", p.Username)
+	}
+}
+
+// Sessions returns the running remote desktop sessions.
+func (tc *TunnelProxyConnectorRDP) Sessions() []*GuacSession {
+	return tc.sessions.list()
+}
+
+// InviteParticipant returns the token for the user to join the session.
+func (tc *TunnelProxyConnectorRDP) InviteParticipant(sessionID, username, mode string) (string, error) {
+	if _, err := tc.sessions.connectionID(sessionID); err != nil {
+		return "", err
+	}
+	token := uuid.New().String()
+	tc.guacTokenStore.Add(token, &GuacToken{
+		expiresAt: time.Now().Add(GuacJoinTokenLifetime),
+		join: &guacJoin{
+			sessionID:   sessionID,
+			participant: &GuacParticipant{Username: username, Mode: mode},
+		},
+	})
+	return token, nil
+}
+
+func (tc *TunnelProxyConnectorRDP) serveJoin(w http.ResponseWriter, r *http.Request) {
+	token := r.Form.Get(queryParToken)
+	guacToken := tc.guacTokenStore.Get(token)
+	if guacToken == nil || guacToken.join == nil {
+		tc.tunnelProxy.sendHTML(w, http.StatusNotFound, "The invitation is invalid or expired.")
+		return
+	}
+
+	// invited users haven't seen the index page with the banner
+	templateData := map[string]interface{}{
+		"token":             token,
+		"clipboardMaxBytes": 0,
+		"fileDropMaxBytes":  0,
+		"banner":            tc.tunnelProxy.Config.Banner,
+	}
+	if guacToken.join.participant.Mode == GuacModeControl {
+		templateData["clipboardMaxBytes"] = tc.tunnelProxy.Config.RDPClipboardMaxBytes
+		templateData["fileDropMaxBytes"] = tc.tunnelProxy.Config.rdpFileDropMaxBytes()
+	}
+
+	tc.tunnelProxy.serveTemplate(w, r, guacStartTunnelHTML, templateData)
 }
 
 // handleFormValues middleware to handle parsing form values
@@ -55,6 +55,7 @@ func (tc *TunnelProxyConnectorVNC) serveIndex(w http.ResponseWriter, r *http.Req
 		"noURLPassword":   true,
 		"defaultViewOnly": false,
 		"params":          novncParamsMap,
+		"banner":          tc.tunnelProxy.Config.Banner,
 	}
 
 	tc.tunnelProxy.serveTemplate(w, r, indexHTML, templateData)
@@ -0,0 +1,67 @@
+package clienttunnel
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+// maxLoginFormBytes limits the login forms of devices read by the tunnel proxy
+const maxLoginFormBytes = 64 * 1024
+
+// DevicePassword returns the password the tunnel proxy logs in to the device with.
+type DevicePassword func() (string, error)
+
+// injectDeviceAuth adds the credentials of the device to the request. With basic authentication all requests carry
+// them, with form login only the submissions of the login form, whatever the user entered is replaced.
+func (tp *InternalTunnelProxy) injectDeviceAuth(r *http.Request, auth *models.DeviceAuth) error {
+	if auth.Type == models.DeviceAuthForm && !isLoginFormSubmission(r, auth) {
+		return nil
+	}
+	if tp.DevicePassword == nil {
+		return errors.New("device credentials are not available")
+	}
+	password, err := tp.DevicePassword()
+	if err != nil {
+		return err
+	}
+
+	if auth.Type == models.DeviceAuthBasic {
+		r.SetBasicAuth(auth.Username, password)
+		return nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxLoginFormBytes+1))
+	if err != nil {
+		return err
+	}
+	if len(body) > maxLoginFormBytes {
+		return errors.New("login form too large")
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return err
+	}
+	form.Set(auth.UsernameField, auth.Username)
+	form.Set(auth.PasswordField, password)
+	encoded := form.Encode()
+	r.Body = io.NopCloser(strings.NewReader(encoded))
+	r.ContentLength = int64(len(encoded))
+	r.Header.Set("Content-Length", strconv.Itoa(len(encoded)))
+	tp.Logger.Debugf("device credentials of %s injected into login form %s", auth.Username, auth.LoginPath)
+	return nil
+}
+
+func isLoginFormSubmission(r *http.Request, auth *models.DeviceAuth) bool {
+	if r.Method != http.MethodPost || r.URL.Path != auth.LoginPath {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "application/x-www-form-urlencoded"
+}
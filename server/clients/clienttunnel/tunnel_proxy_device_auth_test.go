@@ -0,0 +1,87 @@
+package clienttunnel
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+func TestInjectDeviceAuth(t *testing.T) {
+	tp := &InternalTunnelProxy{
+		Logger: logger.NewLogger("tunnel-proxy-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug),
+		DevicePassword: func() (string, error) {
+			return "s3cret&", nil
+		},
+	}
+
+	t.Run("basic", func(t *testing.T) {
+		auth := &models.DeviceAuth{Type: models.DeviceAuthBasic, Username: "admin"}
+		r := httptest.NewRequest(http.MethodGet, "/status", nil)
+		r.SetBasicAuth("proxy-user", "proxy-password")
+
+		require.NoError(t, tp.injectDeviceAuth(r, auth))
+
+		user, password, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "admin", user)
+		assert.Equal(t, "s3cret&", password)
+	})
+
+	formAuth := &models.DeviceAuth{
+		Type:          models.DeviceAuthForm,
+		Username:      "admin",
+		LoginPath:     "/login",
+		UsernameField: "user",
+		PasswordField: "pass",
+	}
+
+	t.Run("login form", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader("user=&pass=&remember=1"))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=UTF-8")
+
+		require.NoError(t, tp.injectDeviceAuth(r, formAuth))
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		form, err := url.ParseQuery(string(body))
+		require.NoError(t, err)
+		assert.Equal(t, url.Values{"user": {"admin"}, "pass": {"s3cret&"}, "remember": {"1"}}, form)
+		assert.EqualValues(t, len(body), r.ContentLength)
+	})
+
+	t.Run("other requests untouched", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/settings", strings.NewReader("user=x"))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		require.NoError(t, tp.injectDeviceAuth(r, formAuth))
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "user=x", string(body))
+		_, _, ok := r.BasicAuth()
+		assert.False(t, ok)
+	})
+
+	t.Run("vault locked", func(t *testing.T) {
+		locked := &InternalTunnelProxy{
+			Logger: tp.Logger,
+			DevicePassword: func() (string, error) {
+				return "", errors.New("vault is locked")
+			},
+		}
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+		assert.EqualError(t, locked.injectDeviceAuth(r, &models.DeviceAuth{Type: models.DeviceAuthBasic, Username: "admin"}), "vault is locked")
+	})
+}
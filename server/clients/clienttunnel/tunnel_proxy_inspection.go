@@ -0,0 +1,77 @@
+package clienttunnel
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/felixge/httpsnoop"
+)
+
+type loggedConnectionKey struct{}
+
+// withLoggedConnection adds the logged connection of the tunnel proxy to the context of its requests.
+func withLoggedConnection(ctx context.Context, c net.Conn) context.Context {
+	if tc, ok := c.(*tls.Conn); ok {
+		c = tc.NetConn()
+	}
+	if lc, ok := c.(*loggedConn); ok {
+		return context.WithValue(ctx, loggedConnectionKey{}, lc.log)
+	}
+	return ctx
+}
+
+// inspectHTTP passes the request line and status of the requests to the connection log.
+func (tp *InternalTunnelProxy) inspectHTTP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logged, ok := r.Context().Value(loggedConnectionKey{}).(LoggedConnection)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		req := InspectedRequest{
+			Time:   time.Now(),
+			Method: r.Method,
+			Path:   r.URL.Path,
+			Proto:  r.Proto,
+		}
+		ww := httpsnoop.Wrap(w, httpsnoop.Hooks{
+			WriteHeader: func(next httpsnoop.WriteHeaderFunc) httpsnoop.WriteHeaderFunc {
+				return func(code int) {
+					if req.Status == 0 {
+						req.Status = code
+					}
+					next(code)
+				}
+			},
+			Write: func(next httpsnoop.WriteFunc) httpsnoop.WriteFunc {
+				return func(b []byte) (int, error) {
+					if req.Status == 0 {
+						req.Status = http.StatusOK
+					}
+					return next(b)
+				}
+			},
+			Hijack: func(next httpsnoop.HijackFunc) httpsnoop.HijackFunc {
+				return func() (net.Conn, *bufio.ReadWriter, error) {
+					if req.Status == 0 {
+						req.Status = http.StatusSwitchingProtocols
+					}
+					return next()
+				}
+			},
+		})
+
+		next.ServeHTTP(ww, r)
+
+		if req.Status == 0 {
+			req.Status = http.StatusOK
+		}
+		req.Duration = time.Since(req.Time)
+		logged.Request(req)
+	})
+}
@@ -0,0 +1,90 @@
+package clienttunnel
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+func TestInternalTunnelProxyHTTPInspection(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer backend.Close()
+	backendHost, backendPort, err := net.SplitHostPort(backend.Listener.Addr().String())
+	require.NoError(t, err)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	proxyPort := l.Addr().(*net.TCPAddr).Port
+	require.NoError(t, l.Close())
+
+	scheme := "http"
+	tunnel := &Tunnel{
+		ID: "1",
+		Remote: models.Remote{
+			LocalHost:      backendHost,
+			LocalPort:      backendPort,
+			Scheme:         &scheme,
+			HTTPProxy:      true,
+			HTTPInspection: true,
+		},
+	}
+	config := &InternalTunnelProxyConfig{
+		CertFile: "../../../testdata/certs/tunnels.rport.test.crt",
+		KeyFile:  "../../../testdata/certs/tunnels.rport.test.key",
+	}
+	logger := logger.NewLogger("tunnel-proxy-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+	tp := NewInternalTunnelProxy(tunnel, logger, config, "127.0.0.1", strconv.Itoa(proxyPort), nil, nil)
+	opened := make(chan *testLoggedConnection, 1)
+	tp.SetConnectionLog(func(protocol string, source net.Addr) LoggedConnection {
+		logged := newTestLoggedConnection(source)
+		opened <- logged
+		return logged
+	})
+	require.NoError(t, tp.Start(context.Background()))
+	defer tp.Stop(context.Background())
+
+	transport := &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}} //nolint:gosec
+	client := &http.Client{Transport: transport}
+	require.Eventually(t, func() bool {
+		resp, err := client.Get("https://" + tp.Addr() + "/status?token=secret")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusTeapot
+	}, time.Second, 10*time.Millisecond)
+
+	logged := <-opened
+	select {
+	case req := <-logged.requests:
+		assert.Equal(t, http.MethodGet, req.Method)
+		assert.Equal(t, "/status", req.Path)
+		assert.Equal(t, "HTTP/1.1", req.Proto)
+		assert.Equal(t, http.StatusTeapot, req.Status)
+	case <-time.After(time.Second):
+		t.Fatal("request not logged")
+	}
+
+	transport.CloseIdleConnections()
+	select {
+	case c := <-logged.closed:
+		assert.Greater(t, c[0], int64(0))
+		assert.Greater(t, c[1], int64(0))
+	case <-time.After(time.Second):
+		t.Fatal("closed connection not logged")
+	}
+}
@@ -24,6 +24,9 @@ type tunnelTCP struct {
 	models.Remote
 	sshConn ssh.Conn
 	acl     atomic.Pointer[TunnelACL] // parsed Remote.ACL field
+	access  atomic.Pointer[AccessCheck]
+	connLog atomic.Pointer[ConnectionLog]
+	traffic *Traffic
 
 	stopFn                    func()
 	connectionIDAutoIncrement int
@@ -31,11 +34,12 @@ type tunnelTCP struct {
 	wg                        sync.WaitGroup // TODO: verify whether wait group is needed here
 }
 
-func newTunnelTCP(logger *logger.Logger, ssh ssh.Conn, remote models.Remote, acl *TunnelACL) *tunnelTCP {
+func newTunnelTCP(logger *logger.Logger, ssh ssh.Conn, remote models.Remote, acl *TunnelACL, traffic *Traffic) *tunnelTCP {
 	t := &tunnelTCP{
 		Logger:  logger,
 		Remote:  remote,
 		sshConn: ssh,
+		traffic: traffic,
 	}
 	t.SetACL(acl)
 	return t
@@ -120,9 +124,16 @@ func (t *tunnelTCP) listen(ctx context.Context, l net.Listener) {
 			}
 		}
 
+		if err := loadAccessCheck(&t.access); err != nil {
+			t.Infof("Access rejected: %v", err)
+			conn.Close()
+			continue
+		}
+
+		logged := openConnection(&t.connLog, "tcp", conn.RemoteAddr())
 		t.wg.Add(1)
 		go func() {
-			t.accept(ctx, conn)
+			logged.Closed(t.accept(ctx, conn))
 			t.wg.Done()
 			atomic.StoreInt64(&t.lastConnClose, time.Now().Unix())
 		}()
@@ -136,7 +147,8 @@ func (t *tunnelTCP) LastActive() time.Time {
 	return time.Unix(atomic.LoadInt64(&t.lastConnClose), 0)
 }
 
-func (t *tunnelTCP) accept(ctx context.Context, src io.ReadWriteCloser) {
+// accept pipes the connection to the client and returns the bytes sent and received.
+func (t *tunnelTCP) accept(ctx context.Context, src io.ReadWriteCloser) (sent, received int64) {
 	defer src.Close()
 	t.connectionIDAutoIncrement++
 	atomic.AddInt32(&t.connCount, 1)
@@ -162,13 +174,13 @@ func (t *tunnelTCP) accept(ctx context.Context, src io.ReadWriteCloser) {
 
 	if t.sshConn == nil {
 		l.Debugf("No remote connection")
-		return
+		return 0, 0
 	}
 	// ssh request to open connection to this tunnel's remote
 	dst, reqs, err := t.sshConn.OpenChannel("rport", []byte(t.Remote.Remote()))
 	if err != nil {
 		l.Errorf("Could not establish TCP tunnel: %v", err)
-		return
+		return 0, 0
 	}
 
 	l.Debugf("SSH channel open")
@@ -177,10 +189,20 @@ func (t *tunnelTCP) accept(ctx context.Context, src io.ReadWriteCloser) {
 	go ssh.DiscardRequests(reqs)
 	//then pipe
 	s, r := chshare.Pipe(src, dst)
+	t.traffic.Add(s, r)
 	l.Debugf("Close (sent %s received %s)", sizestr.ToString(s), sizestr.ToString(r))
 	close(done)
+	return s, r
 }
 
 func (t *tunnelTCP) SetACL(acl *TunnelACL) {
 	t.acl.Store(acl)
 }
+
+func (t *tunnelTCP) SetAccessCheck(check AccessCheck) {
+	t.access.Store(&check)
+}
+
+func (t *tunnelTCP) SetConnectionLog(log ConnectionLog) {
+	t.connLog.Store(&log)
+}
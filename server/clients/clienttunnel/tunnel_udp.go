@@ -17,12 +17,26 @@ import (
 
 var udpReadTimeout = time.Second
 
+// udpFlowIdleTimeout is the time without datagrams after which the connection of a source address is logged as closed
+const udpFlowIdleTimeout = time.Minute
+
+// udpFlow is the traffic of one source address, UDP has no connections, so they are logged per source address.
+type udpFlow struct {
+	sent       int64
+	received   int64
+	lastActive time.Time
+	logged     LoggedConnection
+}
+
 type tunnelUDP struct {
 	*logger.Logger
 	models.Remote
 	sshConn     ssh.Conn
 	acl         atomic.Pointer[TunnelACL] // parsed Remote.ACL field
+	access      atomic.Pointer[AccessCheck]
+	connLog     atomic.Pointer[ConnectionLog]
 	idleTimeout time.Duration
+	traffic     *Traffic
 
 	conn    *net.UDPConn
 	channel *comm.UDPChannel
@@ -31,9 +45,14 @@ type tunnelUDP struct {
 
 	mtx        sync.Mutex
 	lastActive time.Time
+
+	flowsMtx        sync.Mutex
+	flows           map[string]*udpFlow // by source address
+	flowsExpired    time.Time
+	flowIdleTimeout time.Duration
 }
 
-func newTunnelUDP(logger *logger.Logger, ssh ssh.Conn, remote models.Remote, acl *TunnelACL) *tunnelUDP {
+func newTunnelUDP(logger *logger.Logger, ssh ssh.Conn, remote models.Remote, acl *TunnelACL, traffic *Traffic) *tunnelUDP {
 	t := &tunnelUDP{
 		Logger:      logger,
 		Remote:      remote,
@@ -41,6 +60,9 @@ func newTunnelUDP(logger *logger.Logger, ssh ssh.Conn, remote models.Remote, acl
 		done:        make(chan struct{}),
 		lastActive:  time.Now(),
 		idleTimeout: time.Duration(remote.IdleTimeoutMinutes) * time.Minute,
+		traffic:     traffic,
+
+		flowIdleTimeout: udpFlowIdleTimeout,
 	}
 	t.SetACL(acl)
 	return t
@@ -92,6 +114,7 @@ func (t *tunnelUDP) start(ctx context.Context, sshChan io.ReadWriter) error {
 func (t *tunnelUDP) runInbound(ctx context.Context) error {
 	defer t.conn.Close()
 	defer close(t.done)
+	defer t.closeFlows(time.Time{})
 
 	const maxMTU = 9012
 	buff := make([]byte, maxMTU)
@@ -108,6 +131,7 @@ func (t *tunnelUDP) runInbound(ctx context.Context) error {
 		}
 
 		n, sourceAddr, err := t.conn.ReadFromUDP(buff)
+		t.expireFlows()
 		if e, ok := err.(net.Error); ok && (e.Timeout() || e.Temporary()) {
 			continue
 		}
@@ -127,11 +151,17 @@ func (t *tunnelUDP) runInbound(ctx context.Context) error {
 				continue
 			}
 		}
+		if err := loadAccessCheck(&t.access); err != nil {
+			t.Debugf("Access rejected: %v", err)
+			continue
+		}
 
 		err = t.channel.Encode(sourceAddr, buff[:n])
 		if err != nil {
 			return err
 		}
+		t.traffic.Add(int64(n), 0)
+		t.addFlowTraffic(sourceAddr, int64(n), 0, true)
 	}
 }
 
@@ -157,6 +187,8 @@ func (t *tunnelUDP) runOutbound(ctx context.Context) error {
 		if err != nil {
 			return err
 		}
+		t.traffic.Add(0, int64(len(data)))
+		t.addFlowTraffic(addr, 0, int64(len(data)), false)
 	}
 }
 
@@ -184,3 +216,64 @@ func (t *tunnelUDP) setLastActive() {
 func (t *tunnelUDP) SetACL(acl *TunnelACL) {
 	t.acl.Store(acl)
 }
+
+func (t *tunnelUDP) SetAccessCheck(check AccessCheck) {
+	t.access.Store(&check)
+}
+
+func (t *tunnelUDP) SetConnectionLog(log ConnectionLog) {
+	t.connLog.Store(&log)
+}
+
+// addFlowTraffic adds the bytes to the flow of the address, inbound datagrams start a new flow.
+func (t *tunnelUDP) addFlowTraffic(addr *net.UDPAddr, sent, received int64, inbound bool) {
+	if loadConnectionLog(&t.connLog) == nil {
+		return
+	}
+
+	t.flowsMtx.Lock()
+	defer t.flowsMtx.Unlock()
+
+	key := addr.String()
+	flow, ok := t.flows[key]
+	if !ok {
+		if !inbound {
+			return
+		}
+		if t.flows == nil {
+			t.flows = make(map[string]*udpFlow)
+		}
+		flow = &udpFlow{logged: openConnection(&t.connLog, "udp", addr)}
+		t.flows[key] = flow
+	}
+	flow.sent += sent
+	flow.received += received
+	flow.lastActive = time.Now()
+}
+
+// expireFlows closes the flows without datagrams for the flow idle timeout, it checks at most once per udpReadTimeout.
+func (t *tunnelUDP) expireFlows() {
+	now := time.Now()
+	t.flowsMtx.Lock()
+	if now.Sub(t.flowsExpired) < udpReadTimeout {
+		t.flowsMtx.Unlock()
+		return
+	}
+	t.flowsExpired = now
+	t.flowsMtx.Unlock()
+
+	t.closeFlows(now.Add(-t.flowIdleTimeout))
+}
+
+// closeFlows closes the flows last active before the given time, all flows if it's zero.
+func (t *tunnelUDP) closeFlows(before time.Time) {
+	t.flowsMtx.Lock()
+	defer t.flowsMtx.Unlock()
+
+	for key, flow := range t.flows {
+		if before.IsZero() || flow.lastActive.Before(before) {
+			flow.logged.Closed(flow.sent, flow.received)
+			delete(t.flows, key)
+		}
+	}
+}
@@ -20,7 +20,7 @@ func TestTunnelUDP(t *testing.T) {
 	udpReadTimeout = time.Millisecond
 	remote := models.Remote{}
 	logger := logger.NewLogger("udp-handler-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
-	tunnel := newTunnelUDP(logger, nil, remote, nil)
+	tunnel := newTunnelUDP(logger, nil, remote, nil, nil)
 	serverChannel, clientChannel := test.NewMockChannel()
 	channel := comm.NewUDPChannel(clientChannel)
 	err := tunnel.start(context.Background(), serverChannel)
@@ -58,7 +58,7 @@ func TestTunnelUDPWithACL(t *testing.T) {
 	logger := logger.NewLogger("udp-handler-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
 	acl, err := ParseTunnelACL("127.0.0.2")
 	require.NoError(t, err)
-	tunnel := newTunnelUDP(logger, nil, remote, acl)
+	tunnel := newTunnelUDP(logger, nil, remote, acl, nil)
 	serverChannel, clientChannel := test.NewMockChannel()
 	channel := comm.NewUDPChannel(clientChannel)
 	local1, err := net.ResolveUDPAddr("udp", "127.0.0.1:0")
@@ -108,3 +108,47 @@ func TestTunnelUDPWithACL(t *testing.T) {
 	assert.Equal(t, []byte("def"), data)
 	assert.Equal(t, conn.LocalAddr(), addr)
 }
+
+func TestTunnelUDPConnectionLog(t *testing.T) {
+	opened := make(chan *testLoggedConnection, 2)
+	logger := logger.NewLogger("udp-handler-test", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+	tunnel := newTunnelUDP(logger, nil, models.Remote{}, nil, nil)
+	tunnel.flowIdleTimeout = 50 * time.Millisecond
+	tunnel.SetConnectionLog(func(protocol string, source net.Addr) LoggedConnection {
+		assert.Equal(t, "udp", protocol)
+		logged := newTestLoggedConnection(source)
+		opened <- logged
+		return logged
+	})
+	serverChannel, clientChannel := test.NewMockChannel()
+	channel := comm.NewUDPChannel(clientChannel)
+	err := tunnel.start(context.Background(), serverChannel)
+	require.NoError(t, err)
+	conn, err := net.Dial("udp", tunnel.conn.LocalAddr().String())
+	require.NoError(t, err)
+
+	for _, msg := range []string{"abc", "de"} {
+		_, err = conn.Write([]byte(msg))
+		require.NoError(t, err)
+		_, _, err = channel.Decode()
+		require.NoError(t, err)
+	}
+	err = channel.Encode(conn.LocalAddr().(*net.UDPAddr), []byte("1234"))
+	require.NoError(t, err)
+	buffer := make([]byte, 128)
+	_, err = conn.Read(buffer)
+	require.NoError(t, err)
+
+	// one connection per source address
+	logged := <-opened
+	assert.Equal(t, conn.LocalAddr().String(), logged.source)
+	select {
+	case c := <-logged.closed:
+		assert.Equal(t, [2]int64{5, 4}, c)
+	case <-time.After(3 * time.Second):
+		t.Fatal("idle flow not closed")
+	}
+	assert.Empty(t, opened)
+
+	require.NoError(t, tunnel.Terminate(false))
+}
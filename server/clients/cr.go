@@ -22,6 +22,8 @@ type ClientRepository struct {
 	clientStore ClientStore
 
 	keepDisconnectedClients *time.Duration
+	// ephemeralGracePeriod is the duration to keep ephemeral clients after they disconnected
+	ephemeralGracePeriod time.Duration
 
 	postSaveHandlerFn func(cl *clientdata.Client)
 
@@ -35,6 +37,80 @@ type User interface {
 	GetGroups() []string
 }
 
+// ClientGroupsRestrictedUser is implemented by users who can be limited to the clients of some client groups,
+// e.g. when authenticated by a narrowed API token. The restriction applies to administrators as well.
+type ClientGroupsRestrictedUser interface {
+	User
+	// GetAllowedClientGroups returns the ids of the allowed client groups, nil means no restriction.
+	GetAllowedClientGroups() []string
+}
+
+// ClientsRestrictedUser is implemented by users who can be limited to some clients, e.g. when authenticated by a
+// connection broker token. The restriction applies to administrators as well.
+type ClientsRestrictedUser interface {
+	User
+	// GetAllowedClients returns the ids of the allowed clients, nil means no restriction.
+	GetAllowedClients() []string
+}
+
+// ElevatedUser is implemented by users who can be granted temporary access to the clients of client groups, e.g. by
+// approved access requests.
+type ElevatedUser interface {
+	User
+	// GetGrantedClientGroups returns the ids of the client groups the user has access to in addition to its user groups.
+	GetGrantedClientGroups() []string
+}
+
+// hasAccessViaGrantedClientGroups returns true if the client is member of a client group granted to the user.
+func hasAccessViaGrantedClientGroups(c *clientdata.Client, user User, clientGroups []*cgroups.ClientGroup) bool {
+	elevated, ok := user.(ElevatedUser)
+	if !ok {
+		return false
+	}
+	for _, id := range elevated.GetGrantedClientGroups() {
+		for _, group := range clientGroups {
+			if group.ID == id && c.BelongsTo(group) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// allowedByClientGroupsRestriction returns false if the user is restricted to client groups the client isn't member of
+// or to other clients.
+func allowedByClientGroupsRestriction(c *clientdata.Client, user User, clientGroups []*cgroups.ClientGroup) bool {
+	if restricted, ok := user.(ClientsRestrictedUser); ok && restricted.GetAllowedClients() != nil {
+		found := false
+		for _, id := range restricted.GetAllowedClients() {
+			if id == c.GetID() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	restricted, ok := user.(ClientGroupsRestrictedUser)
+	if !ok {
+		return true
+	}
+	allowed := restricted.GetAllowedClientGroups()
+	if allowed == nil {
+		return true
+	}
+	for _, group := range clientGroups {
+		for _, id := range allowed {
+			if group.ID == id && c.BelongsTo(group) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // NewClientRepository returns a new thread-safe in-memory cache to store client connections populated with given clients if any.
 // keepDisconnectedClients is a duration to keep disconnected clients. If a client was disconnected longer than a given
 // duration it will be treated as obsolete.
@@ -64,7 +140,7 @@ func InitClientRepository(
 	keepDisconnectedClients *time.Duration,
 	logger *logger.Logger,
 ) (*ClientRepository, error) {
-	provider := newSqliteProvider(db, keepDisconnectedClients)
+	provider := NewSqliteProvider(db, keepDisconnectedClients)
 	initialClients, err := LoadInitialClients(ctx, provider, logger)
 	if err != nil {
 		return nil, err
@@ -89,7 +165,8 @@ func (r *ClientRepository) Save(cl *clientdata.Client) error {
 
 	store := r.getStore()
 
-	if store != nil {
+	// ephemeral clients are kept in memory only
+	if store != nil && !cl.Ephemeral {
 		err := store.Save(context.Background(), cl)
 		if err != nil {
 			return fmt.Errorf("failed to save client: %w", err)
@@ -210,7 +287,7 @@ func (r *ClientRepository) DeleteObsolete() ([]*clientdata.Client, error) {
 	}
 
 	clientsToDelete := r.queryClients(func(c *clientdata.Client) (match bool) {
-		return c.Obsolete(r.GetKeepDisconnectedClients())
+		return r.obsolete(c)
 	})
 
 	for _, client := range clientsToDelete {
@@ -241,7 +318,8 @@ func (r *ClientRepository) CountDisconnected() (int, error) {
 	var n int
 	// uses copy of clients array returned by getNonObsoleteClients
 	for _, client := range availableClients {
-		if !client.IsConnected() {
+		// ephemeral clients are expected to go away, so they are not reported as disconnected
+		if !client.IsConnected() && !client.Ephemeral {
 			n++
 		}
 	}
@@ -252,7 +330,7 @@ func (r *ClientRepository) CountDisconnected() (int, error) {
 func (r *ClientRepository) GetByID(id string) (*clientdata.Client, error) {
 	client := r.getClient(id)
 
-	if client != nil && client.Obsolete(r.GetKeepDisconnectedClients()) {
+	if client != nil && r.obsolete(client) {
 		return nil, nil
 	}
 	return client, nil
@@ -334,6 +412,32 @@ func (r *ClientRepository) GetKeepDisconnectedClients() (keep *time.Duration) {
 	return r.keepDisconnectedClients
 }
 
+func (r *ClientRepository) SetEphemeralGracePeriod(grace time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ephemeralGracePeriod = grace
+}
+
+func (r *ClientRepository) GetEphemeralGracePeriod() time.Duration {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.ephemeralGracePeriod
+}
+
+// obsolete returns true if a disconnected client should be purged, ephemeral clients are kept for the ephemeral
+// grace period only, regardless of whether disconnected clients are purged at all. Clients waiting for their
+// replacement are kept until the identity transfer completed.
+func (r *ClientRepository) obsolete(c *clientdata.Client) bool {
+	if c.GetIdentityTransfer().IsPending() {
+		return false
+	}
+	if c.Ephemeral {
+		grace := r.GetEphemeralGracePeriod()
+		return c.Obsolete(&grace)
+	}
+	return c.Obsolete(r.GetKeepDisconnectedClients())
+}
+
 const DefaultInitialClientsArraySize = 64
 
 // GetAllActiveClients returns a new client array that can be used without locks (assuming not shared)
@@ -347,7 +451,7 @@ func (r *ClientRepository) GetAllActiveClients() (matchingClients []*clientdata.
 // getNonObsoleteClients returns a new client array that can be used without locks (assuming not shared)
 func (r *ClientRepository) getNonObsoleteClients() (matchingClients []*clientdata.Client) {
 	matchingClients = r.queryClients(func(c *clientdata.Client) (match bool) {
-		return !c.Obsolete(r.GetKeepDisconnectedClients())
+		return !r.obsolete(c)
 	})
 	return matchingClients
 }
@@ -358,8 +462,9 @@ func (r *ClientRepository) getNonObsoleteClientsByUser(user User, clientGroups [
 	userGroups := user.GetGroups()
 
 	matchingClients = r.queryClients(func(c *clientdata.Client) (match bool) {
-		if !c.Obsolete(r.GetKeepDisconnectedClients()) {
-			if user.IsAdmin() || c.HasAccessViaUserGroups(userGroups) || c.UserGroupHasAccessViaClientGroup(userGroups, clientGroups) {
+		if !r.obsolete(c) && allowedByClientGroupsRestriction(c, user, clientGroups) {
+			if user.IsAdmin() || c.HasAccessViaUserGroups(userGroups) || c.UserGroupHasAccessViaClientGroup(userGroups, clientGroups) ||
+				hasAccessViaGrantedClientGroups(c, user, clientGroups) {
 				return true
 			}
 		}
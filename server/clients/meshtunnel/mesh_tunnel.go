@@ -0,0 +1,158 @@
+package meshtunnel
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/IOTech17/neo-rport/share/models"
+	"github.com/IOTech17/neo-rport/share/random"
+)
+
+// Mode defines how the traffic of a mesh tunnel travels between the two clients.
+type Mode string
+
+const (
+	// ModeRelay forwards all traffic through the rport server.
+	ModeRelay Mode = "relay"
+	// ModeDirect requires the source client to reach the target address directly.
+	ModeDirect Mode = "direct"
+	// ModeAuto prefers a direct connection and falls back to relaying through the server.
+	ModeAuto Mode = "auto"
+)
+
+func ParseMode(s string) (Mode, error) {
+	switch Mode(s) {
+	case "":
+		return ModeAuto, nil
+	case ModeRelay, ModeDirect, ModeAuto:
+		return Mode(s), nil
+	}
+	return "", fmt.Errorf("invalid mode %q, expected one of: %s, %s, %s", s, ModeRelay, ModeDirect, ModeAuto)
+}
+
+// MeshTunnel connects a listener opened on the source client to a service reachable from the target client.
+type MeshTunnel struct {
+	ID             string    `json:"id"`
+	SourceClientID string    `json:"source_client_id"`
+	TargetClientID string    `json:"target_client_id"`
+	Local          string    `json:"local"`
+	Remote         string    `json:"remote"`
+	Mode           Mode      `json:"mode"`
+	Relayed        bool      `json:"relayed"`
+	DirectAddr     string    `json:"direct_addr,omitempty"`
+	CreatedBy      string    `json:"created_by"`
+	CreatedAt      time.Time `json:"created_at"`
+	// AccessOverride lifts the access schedule of a client group for the relayed connections
+	AccessOverride *models.AccessOverride `json:"-"`
+}
+
+func New(sourceClientID, targetClientID, local, remote string, mode Mode, createdBy string) (*MeshTunnel, error) {
+	if sourceClientID == "" || targetClientID == "" {
+		return nil, errors.New("source and target client ids are required")
+	}
+	if sourceClientID == targetClientID {
+		return nil, errors.New("source and target client must be different")
+	}
+	if _, _, err := net.SplitHostPort(local); err != nil {
+		return nil, fmt.Errorf("invalid local address %q: %v", local, err)
+	}
+	if _, _, err := net.SplitHostPort(remote); err != nil {
+		return nil, fmt.Errorf("invalid remote address %q: %v", remote, err)
+	}
+
+	id, err := random.UUID4()
+	if err != nil {
+		return nil, err
+	}
+
+	return &MeshTunnel{
+		ID:             id,
+		SourceClientID: sourceClientID,
+		TargetClientID: targetClientID,
+		Local:          local,
+		Remote:         remote,
+		Mode:           mode,
+		Relayed:        true,
+		CreatedBy:      createdBy,
+		CreatedAt:      time.Now().UTC(),
+	}, nil
+}
+
+// IsClientInvolved returns true if the given client is one of the two ends of the tunnel.
+func (t *MeshTunnel) IsClientInvolved(clientID string) bool {
+	return t.SourceClientID == clientID || t.TargetClientID == clientID
+}
+
+// Manager keeps track of the mesh tunnels brokered by the server. Mesh tunnels live only as long as both
+// clients are connected, so they are held in memory like regular tunnels.
+type Manager struct {
+	mu      sync.RWMutex
+	tunnels map[string]*MeshTunnel
+}
+
+func NewManager() *Manager {
+	return &Manager{
+		tunnels: make(map[string]*MeshTunnel),
+	}
+}
+
+func (m *Manager) Add(t *MeshTunnel) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.tunnels {
+		if existing.SourceClientID == t.SourceClientID && existing.Local == t.Local {
+			return fmt.Errorf("mesh tunnel %s already listens on %s", existing.ID, t.Local)
+		}
+	}
+	m.tunnels[t.ID] = t
+	return nil
+}
+
+func (m *Manager) Get(id string) *MeshTunnel {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.tunnels[id]
+}
+
+// List returns all mesh tunnels ordered by creation time.
+func (m *Manager) List() []*MeshTunnel {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	res := make([]*MeshTunnel, 0, len(m.tunnels))
+	for _, t := range m.tunnels {
+		res = append(res, t)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].CreatedAt.Before(res[j].CreatedAt)
+	})
+	return res
+}
+
+func (m *Manager) Delete(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.tunnels, id)
+}
+
+// DeleteByClient removes all mesh tunnels the given client is part of and returns them.
+func (m *Manager) DeleteByClient(clientID string) []*MeshTunnel {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var deleted []*MeshTunnel
+	for id, t := range m.tunnels {
+		if t.IsClientInvolved(clientID) {
+			deleted = append(deleted, t)
+			delete(m.tunnels, id)
+		}
+	}
+	return deleted
+}
@@ -0,0 +1,100 @@
+package meshtunnel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	testCases := []struct {
+		name           string
+		source, target string
+		local, remote  string
+		wantErr        string
+	}{
+		{
+			name:   "valid",
+			source: "a", target: "b",
+			local: "127.0.0.1:5432", remote: "127.0.0.1:5432",
+		},
+		{
+			name:   "same client",
+			source: "a", target: "a",
+			local: "127.0.0.1:5432", remote: "127.0.0.1:5432",
+			wantErr: "source and target client must be different",
+		},
+		{
+			name:   "missing client",
+			source: "a",
+			local:  "127.0.0.1:5432", remote: "127.0.0.1:5432",
+			wantErr: "source and target client ids are required",
+		},
+		{
+			name:   "invalid local",
+			source: "a", target: "b",
+			local: "5432", remote: "127.0.0.1:5432",
+			wantErr: `invalid local address "5432"`,
+		},
+		{
+			name:   "invalid remote",
+			source: "a", target: "b",
+			local: "127.0.0.1:5432", remote: "db",
+			wantErr: `invalid remote address "db"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mt, err := New(tc.source, tc.target, tc.local, tc.remote, ModeAuto, "admin")
+			if tc.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotEmpty(t, mt.ID)
+			assert.True(t, mt.Relayed)
+		})
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	mode, err := ParseMode("")
+	require.NoError(t, err)
+	assert.Equal(t, ModeAuto, mode)
+
+	mode, err = ParseMode("relay")
+	require.NoError(t, err)
+	assert.Equal(t, ModeRelay, mode)
+
+	_, err = ParseMode("p2p")
+	assert.EqualError(t, err, `invalid mode "p2p", expected one of: relay, direct, auto`)
+}
+
+func TestManager(t *testing.T) {
+	m := NewManager()
+
+	t1, err := New("a", "b", "127.0.0.1:5432", "127.0.0.1:5432", ModeRelay, "admin")
+	require.NoError(t, err)
+	t2, err := New("c", "b", "127.0.0.1:5432", "127.0.0.1:5432", ModeRelay, "admin")
+	require.NoError(t, err)
+	t3, err := New("a", "c", "127.0.0.1:5432", "127.0.0.1:22", ModeRelay, "admin")
+	require.NoError(t, err)
+
+	require.NoError(t, m.Add(t1))
+	require.NoError(t, m.Add(t2))
+	assert.Error(t, m.Add(t3), "same local address on the same source client")
+
+	assert.Equal(t, t1, m.Get(t1.ID))
+	assert.Len(t, m.List(), 2)
+
+	deleted := m.DeleteByClient("b")
+	assert.ElementsMatch(t, []*MeshTunnel{t1, t2}, deleted)
+	assert.Empty(t, m.List())
+
+	require.NoError(t, m.Add(t3))
+	m.Delete(t3.ID)
+	assert.Nil(t, m.Get(t3.ID))
+}
@@ -11,40 +11,48 @@ import (
 )
 
 type ClientPayload struct {
-	ID                     *string                 `json:"id,omitempty"`
-	Name                   *string                 `json:"name,omitempty"`
-	Address                *string                 `json:"address,omitempty"`
-	Hostname               *string                 `json:"hostname,omitempty"`
-	OS                     *string                 `json:"os,omitempty"`
-	OSFullName             *string                 `json:"os_full_name,omitempty"`
-	OSVersion              *string                 `json:"os_version,omitempty"`
-	OSArch                 *string                 `json:"os_arch,omitempty"`
-	OSFamily               *string                 `json:"os_family,omitempty"`
-	OSKernel               *string                 `json:"os_kernel,omitempty"`
-	OSVirtualizationSystem *string                 `json:"os_virtualization_system,omitempty"`
-	OSVirtualizationRole   *string                 `json:"os_virtualization_role,omitempty"`
-	NumCPUs                *int                    `json:"num_cpus,omitempty"`
-	CPUFamily              *string                 `json:"cpu_family,omitempty"`
-	CPUModel               *string                 `json:"cpu_model,omitempty"`
-	CPUModelName           *string                 `json:"cpu_model_name,omitempty"`
-	CPUVendor              *string                 `json:"cpu_vendor,omitempty"`
-	MemoryTotal            *uint64                 `json:"mem_total,omitempty"`
-	Timezone               *string                 `json:"timezone,omitempty"`
-	ClientAuthID           *string                 `json:"client_auth_id,omitempty"`
-	Version                *string                 `json:"version,omitempty"`
-	DisconnectedAt         **time.Time             `json:"disconnected_at,omitempty"`
-	LastHeartbeatAt        **time.Time             `json:"last_heartbeat_at,omitempty"`
-	ConnectionState        *string                 `json:"connection_state,omitempty"`
-	IPv4                   *[]string               `json:"ipv4,omitempty"`
-	IPv6                   *[]string               `json:"ipv6,omitempty"`
-	Tags                   *[]string               `json:"tags,omitempty"`
-	AllowedUserGroups      *[]string               `json:"allowed_user_groups,omitempty"`
-	Tunnels                *[]*clienttunnel.Tunnel `json:"tunnels,omitempty"`
-	UpdatesStatus          **models.UpdatesStatus  `json:"updates_status,omitempty"`
-	IPAddresses            **models.IPAddresses    `json:"ext_ip_addresses,omitempty"`
-	ClientConfiguration    **clientconfig.Config   `json:"client_configuration,omitempty"`
-	Groups                 *[]string               `json:"groups,omitempty"`
-	Labels                 *map[string]string      `json:"labels,omitempty"`
+	ID                     *string                       `json:"id,omitempty"`
+	Name                   *string                       `json:"name,omitempty"`
+	Address                *string                       `json:"address,omitempty"`
+	Hostname               *string                       `json:"hostname,omitempty"`
+	OS                     *string                       `json:"os,omitempty"`
+	OSFullName             *string                       `json:"os_full_name,omitempty"`
+	OSVersion              *string                       `json:"os_version,omitempty"`
+	OSArch                 *string                       `json:"os_arch,omitempty"`
+	OSFamily               *string                       `json:"os_family,omitempty"`
+	OSKernel               *string                       `json:"os_kernel,omitempty"`
+	OSVirtualizationSystem *string                       `json:"os_virtualization_system,omitempty"`
+	OSVirtualizationRole   *string                       `json:"os_virtualization_role,omitempty"`
+	NumCPUs                *int                          `json:"num_cpus,omitempty"`
+	CPUFamily              *string                       `json:"cpu_family,omitempty"`
+	CPUModel               *string                       `json:"cpu_model,omitempty"`
+	CPUModelName           *string                       `json:"cpu_model_name,omitempty"`
+	CPUVendor              *string                       `json:"cpu_vendor,omitempty"`
+	MemoryTotal            *uint64                       `json:"mem_total,omitempty"`
+	Timezone               *string                       `json:"timezone,omitempty"`
+	ClientAuthID           *string                       `json:"client_auth_id,omitempty"`
+	Version                *string                       `json:"version,omitempty"`
+	DisconnectedAt         **time.Time                   `json:"disconnected_at,omitempty"`
+	LastHeartbeatAt        **time.Time                   `json:"last_heartbeat_at,omitempty"`
+	ConnectionState        *string                       `json:"connection_state,omitempty"`
+	IPv4                   *[]string                     `json:"ipv4,omitempty"`
+	IPv6                   *[]string                     `json:"ipv6,omitempty"`
+	Tags                   *[]string                     `json:"tags,omitempty"`
+	AllowedUserGroups      *[]string                     `json:"allowed_user_groups,omitempty"`
+	Tunnels                *[]*clienttunnel.Tunnel       `json:"tunnels,omitempty"`
+	UpdatesStatus          **models.UpdatesStatus        `json:"updates_status,omitempty"`
+	IPAddresses            **models.IPAddresses          `json:"ext_ip_addresses,omitempty"`
+	ClientConfiguration    **clientconfig.Config         `json:"client_configuration,omitempty"`
+	AgentFootprint         **models.AgentFootprint       `json:"agent_footprint,omitempty"`
+	Quarantine             **clientdata.Quarantine       `json:"quarantine,omitempty"`
+	IdentityTransfer       **clientdata.IdentityTransfer `json:"identity_transfer,omitempty"`
+	Capabilities           *[]string                     `json:"capabilities,omitempty"`
+	ProtocolRevision       *int                          `json:"protocol_revision,omitempty"`
+	FeatureLevels          *models.FeatureLevels         `json:"feature_levels,omitempty"`
+	Simulated              *bool                         `json:"simulated,omitempty"`
+	Ephemeral              *bool                         `json:"ephemeral,omitempty"`
+	Groups                 *[]string                     `json:"groups,omitempty"`
+	Labels                 *map[string]string            `json:"labels,omitempty"`
 }
 
 func ConvertToClientsPayload(clientsList []*clientdata.CalculatedClient, fields []query.FieldsOption) []ClientPayload {
@@ -135,6 +143,22 @@ func ConvertToClientPayload(client *clientdata.CalculatedClient, fields []query.
 			p.IPAddresses = &client.IPAddresses
 		case "client_configuration":
 			p.ClientConfiguration = &client.ClientConfiguration
+		case "agent_footprint":
+			p.AgentFootprint = &client.AgentFootprint
+		case "quarantine":
+			p.Quarantine = &client.Quarantine
+		case "identity_transfer":
+			p.IdentityTransfer = &client.IdentityTransfer
+		case "capabilities":
+			p.Capabilities = &client.Capabilities
+		case "protocol_revision":
+			p.ProtocolRevision = &client.ProtocolRevision
+		case "feature_levels":
+			p.FeatureLevels = &client.FeatureLevels
+		case "simulated":
+			p.Simulated = &client.Simulated
+		case "ephemeral":
+			p.Ephemeral = &client.Ephemeral
 		case "groups":
 			p.Groups = &client.Groups
 		case "connection_state":
@@ -27,12 +27,16 @@ type ClientStore interface {
 	Close() error
 }
 
+// pendingIdentityTransfer matches disconnected clients waiting for their replacement, they are never obsolete.
+// It relies on the identity transfer being the last field of the details.
+const pendingIdentityTransfer = `(details LIKE '%"completed_at":null}}')`
+
 type SqliteProvider struct {
 	db                      *sqlx.DB
 	keepDisconnectedClients *time.Duration
 }
 
-func newSqliteProvider(db *sqlx.DB, keepDisconnectedClients *time.Duration) *SqliteProvider {
+func NewSqliteProvider(db *sqlx.DB, keepDisconnectedClients *time.Duration) *SqliteProvider {
 	return &SqliteProvider{db: db, keepDisconnectedClients: keepDisconnectedClients}
 }
 
@@ -41,7 +45,7 @@ func (p *SqliteProvider) GetAll(ctx context.Context, l *logger.Logger) ([]*clien
 	err := p.db.SelectContext(
 		ctx,
 		&res,
-		"SELECT * FROM clients WHERE disconnected_at IS NULL OR DATETIME(disconnected_at) >= DATETIME(?) OR ?",
+		"SELECT * FROM clients WHERE disconnected_at IS NULL OR DATETIME(disconnected_at) >= DATETIME(?) OR ? OR "+pendingIdentityTransfer,
 		p.keepDisconnectedClientsStart(),
 		p.keepDisconnectedClients == nil,
 	)
@@ -87,7 +91,7 @@ func (p *SqliteProvider) DeleteObsolete(ctx context.Context, l *logger.Logger) e
 
 		_, err = p.db.ExecContext(
 			ctx,
-			"DELETE FROM clients WHERE disconnected_at IS NOT NULL AND DATETIME(disconnected_at) < DATETIME(?) AND ?",
+			"DELETE FROM clients WHERE disconnected_at IS NOT NULL AND DATETIME(disconnected_at) < DATETIME(?) AND ? AND NOT "+pendingIdentityTransfer,
 			p.keepDisconnectedClientsStart(),
 			p.keepDisconnectedClients != nil,
 		)
@@ -159,6 +163,8 @@ func convertToSqlite(c *clientdata.Client) (res *clientSqlite) {
 			UpdatesStatus:          c.UpdatesStatus,
 			IPAddresses:            c.IPAddresses,
 			ClientConfig:           c.ClientConfiguration,
+			Quarantine:             c.Quarantine,
+			IdentityTransfer:       c.IdentityTransfer,
 		},
 	}
 	c.GetLock().RUnlock()
@@ -205,6 +211,9 @@ type clientDetails struct {
 	UpdatesStatus          *models.UpdatesStatus  `json:"updates_status"`
 	IPAddresses            *models.IPAddresses    `json:"ext_ip_addresses"`
 	ClientConfig           *chshare.Config        `json:"client_configuration"`
+	Quarantine             *clientdata.Quarantine `json:"quarantine,omitempty"`
+	// IdentityTransfer must stay the last field, see pendingIdentityTransfer
+	IdentityTransfer *clientdata.IdentityTransfer `json:"identity_transfer,omitempty"`
 }
 
 func (d *clientDetails) Scan(value interface{}) error {
@@ -266,6 +275,8 @@ func (s *clientSqlite) convert(l *logger.Logger) (res *clientdata.Client) {
 		UpdatesStatus:          d.UpdatesStatus,
 		IPAddresses:            d.IPAddresses,
 		ClientConfiguration:    d.ClientConfig,
+		Quarantine:             d.Quarantine,
+		IdentityTransfer:       d.IdentityTransfer,
 		Logger:                 l,
 	}
 	if s.DisconnectedAt.Valid {
@@ -16,7 +16,7 @@ func TestClientsSqliteProvider(t *testing.T) {
 	keepLost := hour
 	p := NewFakeClientProvider(t, &keepLost)
 	defer p.Close()
-	noObsoleteProvider := newSqliteProvider(p.db, nil)
+	noObsoleteProvider := NewSqliteProvider(p.db, nil)
 
 	// verify add clients
 	c1 := New(t).Logger(testLog).Build()                                                   // active
@@ -70,3 +70,24 @@ func TestClientsSqliteProvider(t *testing.T) {
 	require.NoError(t, err)
 	assert.ElementsMatch(t, []*clientdata.Client{c1, c2, c3, c4}, gotAll)
 }
+
+func TestClientsSqliteProviderKeepsPendingIdentityTransfer(t *testing.T) {
+	ctx := context.Background()
+	keepLost := hour
+	p := NewFakeClientProvider(t, &keepLost)
+	defer p.Close()
+
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	pending := New(t).DisconnectedDuration(keepLost + time.Minute).Logger(testLog).Build()
+	pending.IdentityTransfer = &clientdata.IdentityTransfer{ClientAuthID: "replacement", By: "admin", At: at}
+	completed := New(t).DisconnectedDuration(keepLost + time.Minute).Logger(testLog).Build()
+	completed.IdentityTransfer = &clientdata.IdentityTransfer{ClientAuthID: "replacement-2", By: "admin", At: at, CompletedAt: &at}
+	require.NoError(t, p.Save(ctx, pending))
+	require.NoError(t, p.Save(ctx, completed))
+
+	require.NoError(t, p.DeleteObsolete(ctx, testLog))
+
+	gotAll, err := p.GetAll(ctx, testLog)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []*clientdata.Client{pending}, gotAll)
+}
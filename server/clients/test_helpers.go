@@ -17,7 +17,7 @@ var DataSourceOptions = sqlite.DataSourceOptions{WALEnabled: false}
 func NewFakeClientProvider(t *testing.T, exp *time.Duration, cs ...*clientdata.Client) *SqliteProvider {
 	db, err := sqlite.New(":memory:", clients.AssetNames(), clients.Asset, DataSourceOptions)
 	require.NoError(t, err)
-	p := newSqliteProvider(db, exp)
+	p := NewSqliteProvider(db, exp)
 	for _, cur := range cs {
 		if cur != nil {
 			err = p.Save(context.Background(), cur)
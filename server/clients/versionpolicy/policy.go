@@ -0,0 +1,114 @@
+// Package versionpolicy flags and restricts clients running a version older than a configured minimum.
+package versionpolicy
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/go-version"
+)
+
+type Action string
+
+const (
+	// ActionWarn only logs outdated clients and reports them on the status endpoint
+	ActionWarn Action = "warn"
+	// ActionDenyTunnels additionally rejects new tunnels to outdated clients
+	ActionDenyTunnels Action = "deny_tunnels"
+	// ActionUpdate additionally runs the update command on outdated clients when they connect
+	ActionUpdate Action = "update"
+)
+
+// Policy is configured in the [server] section, it's disabled if no minimum version is set.
+type Policy struct {
+	MinVersion        string `mapstructure:"client_min_version"`
+	Action            Action `mapstructure:"client_version_action"`
+	UpdateCommand     string `mapstructure:"client_update_command"`
+	UpdateInterpreter string `mapstructure:"client_update_interpreter"`
+}
+
+func (p Policy) Enabled() bool {
+	return p.MinVersion != ""
+}
+
+func (p Policy) Validate() error {
+	if !p.Enabled() {
+		return nil
+	}
+	if _, err := version.NewVersion(p.MinVersion); err != nil {
+		return fmt.Errorf("invalid 'client_min_version' %q: %v", p.MinVersion, err)
+	}
+	switch p.Action {
+	case ActionWarn, ActionDenyTunnels:
+	case ActionUpdate:
+		if p.UpdateCommand == "" {
+			return fmt.Errorf("'client_update_command' is required if 'client_version_action' is %q", ActionUpdate)
+		}
+	default:
+		return fmt.Errorf("invalid 'client_version_action' %q, expected one of %q, %q or %q", p.Action, ActionWarn, ActionDenyTunnels, ActionUpdate)
+	}
+	return nil
+}
+
+// IsOutdated returns true if the client version is older than the minimum. Versions that can't be parsed are not
+// considered outdated, as they can't be compared.
+func (p Policy) IsOutdated(clientVersion string) bool {
+	if !p.Enabled() {
+		return false
+	}
+	minVersion, err := version.NewVersion(p.MinVersion)
+	if err != nil {
+		return false
+	}
+	v, err := version.NewVersion(clientVersion)
+	if err != nil {
+		return false
+	}
+	return v.LessThan(minVersion)
+}
+
+// DeniesTunnels returns true if new tunnels to a client with the version are rejected.
+func (p Policy) DeniesTunnels(clientVersion string) bool {
+	return p.Action == ActionDenyTunnels && p.IsOutdated(clientVersion)
+}
+
+// RunsUpdate returns true if the update command is run on a client with the version.
+func (p Policy) RunsUpdate(clientVersion string) bool {
+	return p.Action == ActionUpdate && p.IsOutdated(clientVersion)
+}
+
+type VersionCount struct {
+	Version  string `json:"version"`
+	Count    int    `json:"count"`
+	Outdated bool   `json:"outdated"`
+}
+
+// Distribution counts the clients by version, the newest version first. Versions that can't be parsed are sorted last.
+func (p Policy) Distribution(clientVersions []string) []VersionCount {
+	counts := map[string]int{}
+	for _, v := range clientVersions {
+		counts[v]++
+	}
+
+	res := make([]VersionCount, 0, len(counts))
+	parsed := make(map[string]*version.Version, len(counts))
+	for v, count := range counts {
+		res = append(res, VersionCount{Version: v, Count: count, Outdated: p.IsOutdated(v)})
+		if pv, err := version.NewVersion(v); err == nil {
+			parsed[v] = pv
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		a, b := parsed[res[i].Version], parsed[res[j].Version]
+		switch {
+		case a != nil && b != nil && !a.Equal(b):
+			return a.GreaterThan(b)
+		case a != nil && b == nil:
+			return true
+		case a == nil && b != nil:
+			return false
+		}
+		return res[i].Version < res[j].Version
+	})
+	return res
+}
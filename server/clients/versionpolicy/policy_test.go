@@ -0,0 +1,71 @@
+package versionpolicy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		policy  Policy
+		wantErr string
+	}{
+		{name: "disabled", policy: Policy{}},
+		{name: "warn", policy: Policy{MinVersion: "0.9.12", Action: ActionWarn}},
+		{name: "update", policy: Policy{MinVersion: "0.9.12", Action: ActionUpdate, UpdateCommand: "update.sh"}},
+		{
+			name:    "invalid version",
+			policy:  Policy{MinVersion: "latest", Action: ActionWarn},
+			wantErr: `invalid 'client_min_version' "latest": Malformed version: latest`,
+		},
+		{
+			name:    "invalid action",
+			policy:  Policy{MinVersion: "0.9.12", Action: "block"},
+			wantErr: `invalid 'client_version_action' "block", expected one of "warn", "deny_tunnels" or "update"`,
+		},
+		{
+			name:    "update without command",
+			policy:  Policy{MinVersion: "0.9.12", Action: ActionUpdate},
+			wantErr: `'client_update_command' is required if 'client_version_action' is "update"`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.policy.Validate()
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestIsOutdated(t *testing.T) {
+	p := Policy{MinVersion: "0.9.12", Action: ActionDenyTunnels}
+
+	assert.True(t, p.IsOutdated("0.9.11"))
+	assert.True(t, p.IsOutdated("0.8.3"))
+	assert.False(t, p.IsOutdated("0.9.12"))
+	assert.False(t, p.IsOutdated("0.10.0"))
+	assert.False(t, p.IsOutdated(""))
+	assert.False(t, p.IsOutdated("unknown"))
+
+	assert.True(t, p.DeniesTunnels("0.9.11"))
+	assert.False(t, p.RunsUpdate("0.9.11"))
+	assert.False(t, Policy{}.IsOutdated("0.1.0"))
+}
+
+func TestDistribution(t *testing.T) {
+	p := Policy{MinVersion: "0.9.12", Action: ActionWarn}
+
+	assert.Equal(t, []VersionCount{
+		{Version: "0.10.0", Count: 1},
+		{Version: "0.9.12", Count: 2},
+		{Version: "0.9.2", Count: 1, Outdated: true},
+		{Version: "", Count: 1},
+	}, p.Distribution([]string{"0.9.12", "0.9.2", "", "0.10.0", "0.9.12"}))
+	assert.Empty(t, p.Distribution(nil))
+}
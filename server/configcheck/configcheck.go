@@ -0,0 +1,280 @@
+// Package configcheck checks a validated server config against its environment, e.g. that the database is reachable
+// and the listen addresses are free, without starting the server.
+package configcheck
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	rportplus "github.com/IOTech17/neo-rport/plus"
+	"github.com/IOTech17/neo-rport/plus/loader"
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+)
+
+const (
+	StatusOK      = "ok"
+	StatusWarning = "warning"
+	StatusError   = "error"
+	StatusSkipped = "skipped"
+)
+
+// Timeout limits the checks connecting to other servers.
+const Timeout = 10 * time.Second
+
+type Result struct {
+	Check   string `json:"check"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	// Hint tells how to fix an error or warning
+	Hint string `json:"hint,omitempty"`
+}
+
+type Report struct {
+	// Valid is false if any check failed, warnings don't prevent the server from starting
+	Valid   bool      `json:"valid"`
+	Results []*Result `json:"results"`
+}
+
+func (r *Report) add(check, status, message, hint string) {
+	r.Results = append(r.Results, &Result{
+		Check:   check,
+		Status:  status,
+		Message: message,
+		Hint:    hint,
+	})
+	if status == StatusError {
+		r.Valid = false
+	}
+}
+
+type Options struct {
+	// ListeningAddresses are used by the caller already, e.g. by the running server, they are not checked
+	ListeningAddresses []string
+	// Startup checks on start of the server, a missing data dir is created and addresses in use are errors
+	Startup bool
+	// PluginLoader loads the rport-plus plugin, the default loader is used if nil
+	PluginLoader loader.Loader
+	// Static skips the checks accessing the files and servers named in the config, e.g. for configs uploaded to the
+	// running server
+	Static bool
+}
+
+// Invalid returns the report of a config that failed to parse or validate.
+func Invalid(err error) *Report {
+	r := &Report{}
+	r.add("config", StatusError, err.Error(), "fix the setting named in the message, see rportd.example.conf for the available settings")
+	return r
+}
+
+// Run checks the config, which must be validated by ParseAndValidate before.
+func Run(ctx context.Context, c *chconfig.Config, opts Options) *Report {
+	r := &Report{Valid: true}
+	r.add("config", StatusOK, "the config is valid", "")
+	if opts.Static {
+		checkPorts(r, c)
+		checkOAuth(r, c)
+		r.add("environment", StatusSkipped, "the data dir, databases, listen addresses, certificates, smtp server and plugin are not checked",
+			"run rportd check-config on the server to check them")
+		return r
+	}
+	checkDataDir(r, c, opts)
+	checkSchemas(r, c)
+	checkDatabase(ctx, r, c)
+	checkPorts(r, c)
+	checkListenAddresses(r, c, opts)
+	checkCertificates(r, c, time.Now())
+	if !opts.Startup {
+		// validated by ParseAndValidate on start already
+		checkSMTP(r, c)
+	}
+	checkOAuth(r, c)
+	pluginLoader := opts.PluginLoader
+	if pluginLoader == nil {
+		pluginLoader = loader.New()
+	}
+	checkPlugin(r, c, pluginLoader)
+	return r
+}
+
+func checkDataDir(r *Report, c *chconfig.Config, opts Options) {
+	if opts.Startup {
+		if err := os.MkdirAll(c.Server.DataDir, os.ModePerm); err != nil {
+			r.add("data_dir", StatusError, fmt.Sprintf("failed to create data dir %q: %v", c.Server.DataDir, err),
+				"create the directory and grant write access to the user running rportd")
+			return
+		}
+	}
+	f, err := os.CreateTemp(c.Server.DataDir, ".check-config-*")
+	if err != nil {
+		r.add("data_dir", StatusError, fmt.Sprintf("data dir %q is not writable: %v", c.Server.DataDir, err),
+			"create the directory and grant write access to the user running rportd")
+		return
+	}
+	_ = f.Close()
+	_ = os.Remove(f.Name())
+	r.add("data_dir", StatusOK, fmt.Sprintf("data dir %q is writable", c.Server.DataDir), "")
+}
+
+func checkDatabase(ctx context.Context, r *Report, c *chconfig.Config) {
+	if c.Database.Driver == "" {
+		r.add("database", StatusSkipped, "no database configured", "")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+	db, err := sqlx.ConnectContext(ctx, c.Database.Driver, c.Database.Dsn)
+	if err != nil {
+		r.add("database", StatusError, fmt.Sprintf("failed to connect to %s: %v", c.Database.DsnForLogs(), err),
+			"check db_host, db_name, db_user and db_password and that the database server accepts connections from this host")
+		return
+	}
+	defer db.Close()
+	r.add("database", StatusOK, fmt.Sprintf("connected to %s", c.Database.DsnForLogs()), "")
+
+	if c.API.AuthUserTable != "" {
+		_, err := users.NewUserDatabase(
+			db,
+			c.API.AuthUserTable,
+			c.API.AuthGroupTable,
+			c.API.AuthGroupDetailsTable,
+			c.API.IsTwoFAOn(),
+			c.API.TotPEnabled,
+			rportplus.IsPlusEnabled(c.PlusConfig),
+			nil,
+		)
+		if err != nil {
+			r.add("database_api_auth", StatusError, fmt.Sprintf("invalid api user tables: %v", err),
+				"create the tables as described in the documentation of the api authentication")
+		} else {
+			r.add("database_api_auth", StatusOK, "the api user tables exist", "")
+		}
+	}
+
+	if c.Server.AuthTable != "" {
+		_, err := db.ExecContext(ctx, fmt.Sprintf("SELECT id, password FROM %s LIMIT 0", c.Server.AuthTable))
+		if err != nil {
+			r.add("database_client_auth", StatusError, fmt.Sprintf("invalid client auth table %q: %v", c.Server.AuthTable, err),
+				"create the table with the columns id and password")
+		} else {
+			r.add("database_client_auth", StatusOK, fmt.Sprintf("the client auth table %q exists", c.Server.AuthTable), "")
+		}
+	}
+}
+
+func checkPorts(r *Report, c *chconfig.Config) {
+	n := c.AllowedPorts().Cardinality()
+	switch {
+	case n == 0:
+		r.add("ports", StatusError, "no ports are left for tunnels", "adjust used_ports and excluded_ports")
+	case n < 10:
+		r.add("ports", StatusWarning, fmt.Sprintf("only %d ports are left for tunnels", n), "adjust used_ports and excluded_ports")
+	default:
+		r.add("ports", StatusOK, fmt.Sprintf("%d ports are available for tunnels", n), "")
+	}
+}
+
+func checkListenAddresses(r *Report, c *chconfig.Config, opts Options) {
+	addresses := []struct {
+		setting string
+		address string
+	}{
+		{"server.address", c.Server.ListenAddress},
+		{"api.address", c.API.Address},
+	}
+	for _, a := range addresses {
+		if a.address == "" || isListening(opts, a.address) {
+			continue
+		}
+		_, port, err := net.SplitHostPort(a.address)
+		if err != nil || port == "0" {
+			continue
+		}
+		l, err := net.Listen("tcp", a.address)
+		if err != nil {
+			status := StatusWarning
+			if opts.Startup {
+				status = StatusError
+			}
+			r.add("listen", status, fmt.Sprintf("cannot listen on %s %q: %v", a.setting, a.address, err),
+				"stop the process using the port, e.g. a running rportd, or choose another address")
+			continue
+		}
+		_ = l.Close()
+		r.add("listen", StatusOK, fmt.Sprintf("%s %q is free", a.setting, a.address), "")
+	}
+}
+
+func isListening(opts Options, address string) bool {
+	for _, a := range opts.ListeningAddresses {
+		if a == address {
+			return true
+		}
+	}
+	return false
+}
+
+func checkSMTP(r *Report, c *chconfig.Config) {
+	if c.SMTP.Server == "" {
+		r.add("smtp", StatusSkipped, "no smtp server configured", "")
+		return
+	}
+	if err := c.SMTP.Validate(); err != nil {
+		r.add("smtp", StatusError, err.Error(),
+			"check smtp.server, smtp.secure and the credentials and that the smtp server accepts connections from this host")
+		return
+	}
+	r.add("smtp", StatusOK, fmt.Sprintf("connected to %s", c.SMTP.Server), "")
+}
+
+func checkOAuth(r *Report, c *chconfig.Config) {
+	providers := rportplus.OAuthProviders(c.PlusConfig)
+	if len(providers) == 0 {
+		r.add("oauth", StatusSkipped, "no oauth provider configured", "")
+		return
+	}
+	for _, p := range providers {
+		name := p.GetName()
+		var problems []string
+		if p.ClientID == "" {
+			problems = append(problems, "client_id is missing")
+		}
+		if p.ClientSecret == "" {
+			problems = append(problems, "client_secret is missing")
+		}
+		if p.RedirectURI == "" {
+			problems = append(problems, "redirect_uri is missing")
+		}
+		for _, u := range []struct {
+			setting string
+			value   string
+		}{
+			{"redirect_uri", p.RedirectURI},
+			{"authorize_url", p.BaseAuthorizeURL},
+			{"token_url", p.TokenURL},
+			{"device_authorize_url", p.BaseDeviceAuthorizeURL},
+		} {
+			if u.value == "" {
+				continue
+			}
+			if parsed, err := url.Parse(u.value); err != nil || parsed.Scheme == "" || parsed.Host == "" {
+				problems = append(problems, fmt.Sprintf("%s %q is not an absolute url", u.setting, u.value))
+			}
+		}
+		if len(problems) > 0 {
+			for _, problem := range problems {
+				r.add("oauth", StatusError, fmt.Sprintf("oauth provider %q: %s", name, problem),
+					"copy the settings from the app registered at the provider")
+			}
+			continue
+		}
+		r.add("oauth", StatusOK, fmt.Sprintf("oauth provider %q is configured", name), "")
+	}
+}
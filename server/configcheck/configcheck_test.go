@@ -0,0 +1,195 @@
+package configcheck
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	rportplus "github.com/IOTech17/neo-rport/plus"
+	"github.com/IOTech17/neo-rport/plus/capabilities/oauth"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/share/logger"
+)
+
+func validConfig(t *testing.T, usedPorts string) *chconfig.Config {
+	c := &chconfig.Config{
+		Server: chconfig.ServerConfig{
+			URL:          []string{"http://localhost/"},
+			DataDir:      t.TempDir(),
+			Auth:         "abc:def",
+			UsedPortsRaw: []string{usedPorts},
+		},
+	}
+	mLog := logger.NewMemLogger()
+	require.NoError(t, c.ParseAndValidate(&mLog))
+	return c
+}
+
+func results(r *Report, check string) []*Result {
+	var found []*Result
+	for _, res := range r.Results {
+		if res.Check == check {
+			found = append(found, res)
+		}
+	}
+	return found
+}
+
+func TestRunValid(t *testing.T) {
+	c := validConfig(t, "10000-10100")
+
+	r := Run(context.Background(), c, Options{})
+
+	assert.True(t, r.Valid)
+	assert.Equal(t, StatusOK, results(r, "config")[0].Status)
+	assert.Equal(t, StatusOK, results(r, "data_dir")[0].Status)
+	assert.Equal(t, &Result{Check: "ports", Status: StatusOK, Message: "101 ports are available for tunnels"}, results(r, "ports")[0])
+	assert.Equal(t, StatusSkipped, results(r, "database")[0].Status)
+	assert.Equal(t, StatusSkipped, results(r, "smtp")[0].Status)
+	assert.Equal(t, StatusSkipped, results(r, "oauth")[0].Status)
+}
+
+func TestRunStatic(t *testing.T) {
+	c := validConfig(t, "10000-10100")
+	c.Server.DataDir = filepath.Join(t.TempDir(), "missing")
+
+	r := Run(context.Background(), c, Options{Static: true})
+
+	assert.True(t, r.Valid)
+	assert.Empty(t, results(r, "data_dir"))
+	assert.Empty(t, results(r, "database"))
+	assert.Empty(t, results(r, "listen"))
+	assert.Equal(t, StatusOK, results(r, "ports")[0].Status)
+	assert.Equal(t, StatusSkipped, results(r, "oauth")[0].Status)
+	assert.Equal(t, StatusSkipped, results(r, "environment")[0].Status)
+}
+
+func TestRunInvalid(t *testing.T) {
+	r := Invalid(errors.New("invalid 'server.used_ports'"))
+
+	assert.False(t, r.Valid)
+	require.Len(t, r.Results, 1)
+	assert.Equal(t, "invalid 'server.used_ports'", r.Results[0].Message)
+	assert.Equal(t, StatusError, r.Results[0].Status)
+}
+
+func TestCheckDataDir(t *testing.T) {
+	c := validConfig(t, "10000-10100")
+	c.Server.DataDir = filepath.Join(t.TempDir(), "missing")
+
+	r := &Report{Valid: true}
+	checkDataDir(r, c, Options{})
+
+	assert.False(t, r.Valid)
+	assert.Equal(t, StatusError, r.Results[0].Status)
+	assert.Contains(t, r.Results[0].Message, "is not writable")
+
+	// the server creates the data dir on start
+	r = &Report{Valid: true}
+	checkDataDir(r, c, Options{Startup: true})
+
+	assert.True(t, r.Valid)
+	assert.DirExists(t, c.Server.DataDir)
+}
+
+func TestCheckPorts(t *testing.T) {
+	r := &Report{Valid: true}
+	checkPorts(r, validConfig(t, "10000-10004"))
+
+	assert.True(t, r.Valid)
+	assert.Equal(t, &Result{
+		Check:   "ports",
+		Status:  StatusWarning,
+		Message: "only 5 ports are left for tunnels",
+		Hint:    "adjust used_ports and excluded_ports",
+	}, r.Results[0])
+}
+
+func TestCheckListenAddresses(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	c := validConfig(t, "10000-10100")
+	c.Server.ListenAddress = l.Addr().String()
+
+	r := &Report{Valid: true}
+	checkListenAddresses(r, c, Options{})
+	assert.True(t, r.Valid)
+	require.Len(t, r.Results, 1)
+	assert.Equal(t, StatusWarning, r.Results[0].Status)
+	assert.Contains(t, r.Results[0].Message, "cannot listen on server.address")
+
+	r = &Report{Valid: true}
+	checkListenAddresses(r, c, Options{ListeningAddresses: []string{l.Addr().String()}})
+	assert.Empty(t, r.Results)
+
+	r = &Report{Valid: true}
+	checkListenAddresses(r, c, Options{Startup: true})
+	assert.False(t, r.Valid)
+	assert.Equal(t, StatusError, r.Results[0].Status)
+}
+
+func TestCheckDatabase(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "auth.db")
+	db, err := sqlx.Connect("sqlite3", dbFile)
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE TABLE clients_auth (id TEXT, password TEXT)")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	c := validConfig(t, "10000-10100")
+	c.Database = chconfig.DatabaseConfig{Driver: "sqlite3", Dsn: dbFile, Name: dbFile}
+	c.Server.AuthTable = "clients_auth"
+
+	r := &Report{Valid: true}
+	checkDatabase(context.Background(), r, c)
+	assert.True(t, r.Valid)
+	assert.Equal(t, StatusOK, results(r, "database")[0].Status)
+	assert.Equal(t, StatusOK, results(r, "database_client_auth")[0].Status)
+
+	c.Server.AuthTable = "missing"
+	r = &Report{Valid: true}
+	checkDatabase(context.Background(), r, c)
+	assert.False(t, r.Valid)
+	assert.Equal(t, StatusError, results(r, "database_client_auth")[0].Status)
+}
+
+func TestCheckOAuth(t *testing.T) {
+	c := validConfig(t, "10000-10100")
+	c.PlusConfig = rportplus.PlusConfig{
+		PluginConfig: &rportplus.PluginConfig{PluginPath: "/usr/local/lib/rport/rport-plus.so"},
+		OAuthConfig: &oauth.Config{
+			Provider:     "github",
+			ClientID:     "id",
+			ClientSecret: "secret",
+			RedirectURI:  "https://rport.example.com/oauth/callback",
+		},
+		OAuthProviders: []*oauth.Config{{
+			Name:        "azure",
+			Provider:    "microsoft",
+			ClientID:    "id",
+			RedirectURI: "/oauth/callback",
+		}},
+	}
+
+	r := &Report{Valid: true}
+	checkOAuth(r, c)
+
+	assert.False(t, r.Valid)
+	var messages []string
+	for _, res := range r.Results {
+		messages = append(messages, res.Status+": "+res.Message)
+	}
+	assert.Equal(t, []string{
+		`ok: oauth provider "github" is configured`,
+		`error: oauth provider "azure": client_secret is missing`,
+		`error: oauth provider "azure": redirect_uri "/oauth/callback" is not an absolute url`,
+	}, messages)
+}
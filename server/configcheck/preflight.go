@@ -0,0 +1,148 @@
+package configcheck
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	alertsmigration "github.com/IOTech17/neo-rport/db/migration/alerts"
+	"github.com/IOTech17/neo-rport/db/migration/api_sessions"
+	"github.com/IOTech17/neo-rport/db/migration/api_token"
+	auditlogmigration "github.com/IOTech17/neo-rport/db/migration/auditlog"
+	chatmigration "github.com/IOTech17/neo-rport/db/migration/chat"
+	"github.com/IOTech17/neo-rport/db/migration/client_groups"
+	clientsmigration "github.com/IOTech17/neo-rport/db/migration/clients"
+	discoverymigration "github.com/IOTech17/neo-rport/db/migration/discovery"
+	idempotencykeysmigration "github.com/IOTech17/neo-rport/db/migration/idempotency_keys"
+	jobsmigration "github.com/IOTech17/neo-rport/db/migration/jobs"
+	"github.com/IOTech17/neo-rport/db/migration/library"
+	monitoringmigration "github.com/IOTech17/neo-rport/db/migration/monitoring"
+	"github.com/IOTech17/neo-rport/db/migration/tunnel_connections"
+	usagemigration "github.com/IOTech17/neo-rport/db/migration/usage"
+	"github.com/IOTech17/neo-rport/db/migration/vaults"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	rportplus "github.com/IOTech17/neo-rport/plus"
+	"github.com/IOTech17/neo-rport/plus/loader"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	notificationsmigration "github.com/IOTech17/neo-rport/server/notifications/repository/sqlite"
+)
+
+// CertExpiryWarning is how long before a certificate expires a warning is reported.
+const CertExpiryWarning = 14 * 24 * time.Hour
+
+// schemas are the sqlite databases in the data dir and the migrations of their schema.
+var schemas = []struct {
+	file       string
+	assetNames func() []string
+}{
+	{"clients.db", clientsmigration.AssetNames},
+	{"client_groups.db", client_groups.AssetNames},
+	{"jobs.db", jobsmigration.AssetNames},
+	{"monitoring.db", monitoringmigration.AssetNames},
+	{"usage.db", usagemigration.AssetNames},
+	{"chat.db", chatmigration.AssetNames},
+	{"discovery.db", discoverymigration.AssetNames},
+	{"tunnel_connections.db", tunnel_connections.AssetNames},
+	{"alerts.db", alertsmigration.AssetNames},
+	{"notifications.db", notificationsmigration.AssetNames},
+	{"library.db", library.AssetNames},
+	{"api_token.db", api_token.AssetNames},
+	{"api_sessions.db", api_sessions.AssetNames},
+	{"idempotency_keys.db", idempotencykeysmigration.AssetNames},
+	{"auditlog.db", auditlogmigration.AssetNames},
+	{chconfig.DefaultVaultDBName, vaults.AssetNames},
+}
+
+// checkSchemas reports databases which were migrated by a newer rportd or where a migration failed. Older schemas
+// are migrated on start.
+func checkSchemas(r *Report, c *chconfig.Config) {
+	for _, s := range schemas {
+		dbPath := filepath.Join(c.Server.DataDir, s.file)
+		version, dirty, err := sqlite.SchemaVersion(dbPath)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			r.add("schema", StatusError, fmt.Sprintf("failed to read the schema version of %q: %v", dbPath, err),
+				"check that the file is a sqlite database readable by the user running rportd")
+			continue
+		}
+		latest, err := sqlite.LatestVersion(s.assetNames())
+		if err != nil {
+			r.add("schema", StatusError, err.Error(), "")
+			continue
+		}
+		switch {
+		case dirty:
+			r.add("schema", StatusError, fmt.Sprintf("a migration of %q to version %d failed", dbPath, version),
+				"restore the database from a backup")
+		case version > latest:
+			r.add("schema", StatusError, fmt.Sprintf("the schema version %d of %q is newer than %d supported by this rportd", version, dbPath, latest),
+				"upgrade rportd or restore a backup made before the last upgrade")
+		default:
+			r.add("schema", StatusOK, fmt.Sprintf("the schema version %d of %q is supported", version, dbPath), "")
+		}
+	}
+}
+
+func checkCertificates(r *Report, c *chconfig.Config, now time.Time) {
+	certs := []struct {
+		setting  string
+		certFile string
+		keyFile  string
+	}{
+		{"api.cert_file", c.API.CertFile, c.API.KeyFile},
+		{"server.tunnel_proxy_cert_file", c.Server.InternalTunnelProxyConfig.CertFile, c.Server.InternalTunnelProxyConfig.KeyFile},
+	}
+	for _, cert := range certs {
+		if cert.certFile == "" || cert.keyFile == "" {
+			continue
+		}
+		pair, err := tls.LoadX509KeyPair(cert.certFile, cert.keyFile)
+		if err != nil {
+			r.add("certificate", StatusError, fmt.Sprintf("invalid %s %q: %v", cert.setting, cert.certFile, err),
+				"check that the certificate and key files match and are readable by the user running rportd")
+			continue
+		}
+		leaf, err := x509.ParseCertificate(pair.Certificate[0])
+		if err != nil {
+			r.add("certificate", StatusError, fmt.Sprintf("invalid %s %q: %v", cert.setting, cert.certFile, err), "")
+			continue
+		}
+		switch {
+		case now.After(leaf.NotAfter):
+			r.add("certificate", StatusError, fmt.Sprintf("%s %q expired at %s", cert.setting, cert.certFile, leaf.NotAfter.Format(time.RFC3339)),
+				"renew the certificate")
+		case now.Before(leaf.NotBefore):
+			r.add("certificate", StatusError, fmt.Sprintf("%s %q is not valid before %s", cert.setting, cert.certFile, leaf.NotBefore.Format(time.RFC3339)),
+				"check the clock of this host")
+		case now.Add(CertExpiryWarning).After(leaf.NotAfter):
+			r.add("certificate", StatusWarning, fmt.Sprintf("%s %q expires at %s", cert.setting, cert.certFile, leaf.NotAfter.Format(time.RFC3339)),
+				"renew the certificate")
+		default:
+			r.add("certificate", StatusOK, fmt.Sprintf("%s %q is valid until %s", cert.setting, cert.certFile, leaf.NotAfter.Format(time.RFC3339)), "")
+		}
+	}
+}
+
+// checkPlugin loads the rport-plus plugin, which fails if it was built for another version of rportd.
+func checkPlugin(r *Report, c *chconfig.Config, pluginLoader loader.Loader) {
+	if !rportplus.IsPlusEnabled(c.PlusConfig) {
+		r.add("plugin", StatusSkipped, "rport-plus is not enabled", "")
+		return
+	}
+	pluginPath := c.PlusConfig.PluginConfig.PluginPath
+	if _, err := os.Stat(pluginPath); err != nil {
+		r.add("plugin", StatusError, fmt.Sprintf("plugin not found at %q: %v", pluginPath, err), "check plugin_path")
+		return
+	}
+	if _, err := pluginLoader.LoadSymbol(pluginPath, "StartPluginEx"); err != nil {
+		r.add("plugin", StatusError, fmt.Sprintf("incompatible plugin %q: %v", pluginPath, err),
+			"install the rport-plus version released with this rportd")
+		return
+	}
+	r.add("plugin", StatusOK, fmt.Sprintf("plugin %q is compatible", pluginPath), "")
+}
@@ -0,0 +1,138 @@
+package configcheck
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"plugin"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	clientsmigration "github.com/IOTech17/neo-rport/db/migration/clients"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	rportplus "github.com/IOTech17/neo-rport/plus"
+)
+
+func TestCheckSchemas(t *testing.T) {
+	c := validConfig(t, "10000-10100")
+	dbPath := filepath.Join(c.Server.DataDir, "clients.db")
+	db, err := sqlite.New(dbPath, clientsmigration.AssetNames(), clientsmigration.Asset, sqlite.DataSourceOptions{})
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	r := &Report{Valid: true}
+	checkSchemas(r, c)
+	assert.True(t, r.Valid)
+	require.Len(t, r.Results, 1)
+	assert.Equal(t, StatusOK, r.Results[0].Status)
+
+	setVersion := func(version int, dirty bool) {
+		db, err := sqlx.Connect("sqlite3", dbPath)
+		require.NoError(t, err)
+		defer db.Close()
+		_, err = db.Exec("UPDATE schema_migrations SET version = ?, dirty = ?", version, dirty)
+		require.NoError(t, err)
+	}
+
+	setVersion(100000, false)
+	r = &Report{Valid: true}
+	checkSchemas(r, c)
+	assert.False(t, r.Valid)
+	assert.Contains(t, r.Results[0].Message, "is newer than")
+
+	setVersion(1, true)
+	r = &Report{Valid: true}
+	checkSchemas(r, c)
+	assert.False(t, r.Valid)
+	assert.Contains(t, r.Results[0].Message, "to version 1 failed")
+}
+
+func writeCert(t *testing.T, dir string, notBefore, notAfter time.Time) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "rport.example.com"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certFile, keyFile
+}
+
+func TestCheckCertificates(t *testing.T) {
+	now := time.Now()
+	testCases := []struct {
+		name       string
+		notBefore  time.Time
+		notAfter   time.Time
+		wantStatus string
+	}{
+		{"valid", now.Add(-time.Hour), now.Add(365 * 24 * time.Hour), StatusOK},
+		{"expiring", now.Add(-time.Hour), now.Add(24 * time.Hour), StatusWarning},
+		{"expired", now.Add(-48 * time.Hour), now.Add(-24 * time.Hour), StatusError},
+		{"not yet valid", now.Add(time.Hour), now.Add(48 * time.Hour), StatusError},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := validConfig(t, "10000-10100")
+			c.API.CertFile, c.API.KeyFile = writeCert(t, t.TempDir(), tc.notBefore, tc.notAfter)
+
+			r := &Report{Valid: true}
+			checkCertificates(r, c, now)
+			require.Len(t, r.Results, 1)
+			assert.Equal(t, tc.wantStatus, r.Results[0].Status, r.Results[0].Message)
+		})
+	}
+}
+
+type fakeLoader struct {
+	err error
+}
+
+func (l fakeLoader) LoadSymbol(pluginPath string, name string) (plugin.Symbol, error) {
+	return nil, l.err
+}
+
+func TestCheckPlugin(t *testing.T) {
+	c := validConfig(t, "10000-10100")
+	r := &Report{Valid: true}
+	checkPlugin(r, c, fakeLoader{})
+	assert.Equal(t, StatusSkipped, r.Results[0].Status)
+
+	pluginPath := filepath.Join(t.TempDir(), "rport-plus.so")
+	c.PlusConfig = rportplus.PlusConfig{PluginConfig: &rportplus.PluginConfig{PluginPath: pluginPath}}
+	r = &Report{Valid: true}
+	checkPlugin(r, c, fakeLoader{})
+	assert.False(t, r.Valid)
+	assert.Contains(t, r.Results[0].Message, "plugin not found")
+
+	require.NoError(t, os.WriteFile(pluginPath, nil, 0600))
+	r = &Report{Valid: true}
+	checkPlugin(r, c, fakeLoader{err: errors.New("plugin was built with a different version of package")})
+	assert.False(t, r.Valid)
+	assert.Contains(t, r.Results[0].Message, "incompatible plugin")
+
+	r = &Report{Valid: true}
+	checkPlugin(r, c, fakeLoader{})
+	assert.True(t, r.Valid)
+	assert.Equal(t, StatusOK, r.Results[0].Status)
+}
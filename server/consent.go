@@ -0,0 +1,148 @@
+package chserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/random"
+)
+
+// consentResultMargin is added to the timeout of the client for sending the result of the consent.
+const consentResultMargin = 10 * time.Second
+
+type pendingConsent struct {
+	clientID string
+	done     chan comm.ConsentResult
+}
+
+// consentWaiters holds the consents asked from the users of clients until the result arrives.
+type consentWaiters struct {
+	m  map[string]*pendingConsent
+	mu sync.Mutex
+}
+
+func newConsentWaiters() *consentWaiters {
+	return &consentWaiters{
+		m: make(map[string]*pendingConsent),
+	}
+}
+
+func (w *consentWaiters) add(clientID string) (string, chan comm.ConsentResult) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	id := random.Hex(16)
+	done := make(chan comm.ConsentResult, 1)
+	w.m[id] = &pendingConsent{clientID: clientID, done: done}
+	return id, done
+}
+
+// take removes the pending consent, it returns nil if the id is unknown or belongs to another client.
+func (w *consentWaiters) take(id, clientID string) *pendingConsent {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	p := w.m[id]
+	if p == nil || p.clientID != clientID {
+		return nil
+	}
+	delete(w.m, id)
+	return p
+}
+
+func (w *consentWaiters) del(id string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.m, id)
+}
+
+// askConsent asks the local user of the client for consent to the subject, if required by the client config. It
+// returns the id of the granted consent, or an empty id if no consent is required.
+func (al *APIListener) askConsent(req *http.Request, client *clientdata.Client, subject, description string) (string, error) {
+	if cfg := client.GetConsentConfig(); cfg == nil || !cfg.Required(subject) {
+		return "", nil
+	}
+
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		return "", err
+	}
+
+	id, done := al.consents.add(client.GetID())
+	defer al.consents.del(id)
+
+	resp := &comm.AskConsentResponse{}
+	err = comm.SendRequestAndGetResponse(client.GetConnection(), comm.RequestTypeAskConsent, comm.AskConsentRequest{
+		ID:          id,
+		Subject:     subject,
+		Requester:   curUser.Username,
+		Description: description,
+	}, resp, al.Log())
+	if err != nil {
+		if strings.Contains(err.Error(), "unknown request") {
+			err = errors.New("client does not support consent prompts")
+		}
+		return "", errors2.APIError{
+			HTTPStatus: http.StatusConflict,
+			Err:        fmt.Errorf("failed to ask for consent: %v", err),
+		}
+	}
+	if !resp.Required {
+		return "", nil
+	}
+
+	var result comm.ConsentResult
+	select {
+	case result = <-done:
+	case <-time.After(resp.Timeout + consentResultMargin):
+		return "", errors2.APIError{
+			HTTPStatus: http.StatusGatewayTimeout,
+			Message:    "timeout waiting for the consent of the user of the client",
+		}
+	case <-req.Context().Done():
+		return "", req.Context().Err()
+	}
+
+	action := auditlog.ActionSuccess
+	if !result.Granted {
+		action = auditlog.ActionDeny
+	}
+	al.auditLog.Entry(auditlog.ApplicationClientConsent, action).
+		WithHTTPRequest(req).
+		WithClient(client).
+		WithID(id).
+		WithRequest(map[string]string{
+			"subject": subject,
+			"reason":  result.Reason,
+		}).
+		Save()
+
+	if !result.Granted {
+		return "", errors2.APIError{
+			HTTPStatus: http.StatusForbidden,
+			Message:    fmt.Sprintf("consent of the user of the client is required: %s", result.Reason),
+		}
+	}
+	return id, nil
+}
+
+// receiveConsentResult passes the answer of the user of the client to the waiting request.
+func (cl *ClientListener) receiveConsentResult(clientID string, payload []byte) error {
+	var result comm.ConsentResult
+	if err := json.Unmarshal(payload, &result); err != nil {
+		return fmt.Errorf("failed to decode %T: %v", result, err)
+	}
+	p := cl.server.consents.take(result.ID, clientID)
+	if p == nil {
+		return errors.New("consent not requested or expired")
+	}
+	p.done <- result
+	return nil
+}
@@ -0,0 +1,76 @@
+package chserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/clients"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/share/clientconfig"
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/security"
+	"github.com/IOTech17/neo-rport/share/test"
+)
+
+func TestScreenshotConsent(t *testing.T) {
+	c1 := clients.New(t).ID("client-1").Logger(testLog).Config(&clientconfig.Config{
+		Consent: clientconfig.ConsentConfig{Screenshots: true},
+	}).Build()
+	al := &APIListener{
+		Logger:      testLog,
+		bannedUsers: security.NewBanList(0),
+		apiSessions: newEmptyAPISessionCache(t),
+		Server: &Server{
+			config: &chconfig.Config{},
+			clientService: clients.NewClientService(
+				nil, nil, clients.NewClientRepository([]*clientdata.Client{c1}, &hour, testLog), testLog, nil,
+			),
+			clientGroupProvider: staticClientGroupProvider{},
+			screenshots:         newScreenshotWaiters(),
+			consents:            newConsentWaiters(),
+		},
+		userService: users.NewAPIService(users.NewStaticProvider([]*users.User{
+			{Username: "admin", Password: "$2y$05$ep2DdPDeLDDhwRrED9q/vuVEzRpZtB5WHCFT7YbcmH9r9oNmlsZOm", Groups: []string{users.Administrators}},
+		}), false, 0, -1),
+	}
+	al.initRouter()
+	cl := &ClientListener{server: al.Server}
+
+	connMock := test.NewConnMock()
+	connMock.ReturnOk = true
+	connMock.ReturnResponsePayload = []byte(`{"Required":true,"Timeout":1000000000}`)
+	connMock.DoneChannel = make(chan bool, 1)
+	c1.SetConnection(connMock)
+
+	// the user of the client denies the screenshot
+	go func() {
+		<-connMock.DoneChannel
+		name, _, payload := connMock.InputSendRequest()
+		assert.Equal(t, comm.RequestTypeAskConsent, name)
+		var req comm.AskConsentRequest
+		assert.NoError(t, json.Unmarshal(payload, &req))
+		assert.Equal(t, comm.ConsentSubjectScreenshot, req.Subject)
+		assert.Equal(t, "admin", req.Requester)
+
+		assert.EqualError(t, cl.receiveConsentResult("other-client", payload), "consent not requested or expired")
+		result, _ := json.Marshal(comm.ConsentResult{ID: req.ID, Reason: "denied by the user"})
+		assert.NoError(t, cl.receiveConsentResult(c1.GetID(), result))
+	}()
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/clients/client-1/screenshot", nil)
+	req.SetBasicAuth("admin", "pwd")
+	al.router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), "consent of the user of the client is required: denied by the user")
+	assert.Empty(t, al.consents.m)
+	assert.Empty(t, al.screenshots.m)
+}
@@ -0,0 +1,85 @@
+package discovery
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	ScanStatusRunning  = "running"
+	ScanStatusFinished = "finished"
+	ScanStatusFailed   = "failed"
+)
+
+// Scan is a network discovery run by a client on request of a user.
+type Scan struct {
+	ID           string     `db:"id" json:"id"`
+	ClientID     string     `db:"client_id" json:"client_id"`
+	Subnets      Subnets    `db:"subnets" json:"subnets"`
+	Ports        Ports      `db:"ports" json:"ports"`
+	Status       string     `db:"status" json:"status"`
+	Error        string     `db:"error" json:"error"`
+	DevicesCount int        `db:"devices_count" json:"devices_count"`
+	CreatedBy    string     `db:"created_by" json:"created_by"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+	FinishedAt   *time.Time `db:"finished_at" json:"finished_at"`
+}
+
+// Device is a device found in the local networks of a client, updated by each scan finding it again.
+type Device struct {
+	ClientID    string    `db:"client_id" json:"client_id"`
+	IP          string    `db:"ip" json:"ip"`
+	MAC         string    `db:"mac" json:"mac"`
+	Hostname    string    `db:"hostname" json:"hostname"`
+	OpenPorts   Ports     `db:"open_ports" json:"open_ports"`
+	FirstSeenAt time.Time `db:"first_seen_at" json:"first_seen_at"`
+	LastSeenAt  time.Time `db:"last_seen_at" json:"last_seen_at"`
+	LastScanID  string    `db:"last_scan_id" json:"last_scan_id"`
+}
+
+type Ports []int
+
+func (p *Ports) Scan(value interface{}) error {
+	return scanJSON(value, p, "ports")
+}
+
+func (p Ports) Value() (driver.Value, error) {
+	if p == nil {
+		p = Ports{}
+	}
+	return valueJSON(p, "ports")
+}
+
+type Subnets []string
+
+func (s *Subnets) Scan(value interface{}) error {
+	return scanJSON(value, s, "subnets")
+}
+
+func (s Subnets) Value() (driver.Value, error) {
+	if s == nil {
+		s = Subnets{}
+	}
+	return valueJSON(s, "subnets")
+}
+
+func scanJSON(value interface{}, dest interface{}, field string) error {
+	valueStr, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("expected to have string, got %T", value)
+	}
+	if err := json.Unmarshal([]byte(valueStr), dest); err != nil {
+		return fmt.Errorf("failed to decode '%s' field: %v", field, err)
+	}
+	return nil
+}
+
+func valueJSON(value interface{}, field string) (driver.Value, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode '%s' field: %v", field, err)
+	}
+	return string(b), nil
+}
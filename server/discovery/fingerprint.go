@@ -0,0 +1,52 @@
+package discovery
+
+const (
+	OSLinux   = "linux"
+	OSWindows = "windows"
+
+	MethodSSH   = "ssh"
+	MethodWinRM = "winrm"
+
+	PortSSH        = 22
+	PortWinRM      = 5985
+	PortWinRMHTTPS = 5986
+)
+
+// windowsPorts are only open by default on windows, hosts running an ssh server as well are still windows
+var windowsPorts = []int{135, 139, 445, 3389, PortWinRM, PortWinRMHTTPS}
+
+// GuessOS tells the operating system of the device by its open ports, empty if it can't be told. Devices with only
+// ssh open are taken as linux, which includes other unix-like systems the linux installer might not support.
+func (d *Device) GuessOS() string {
+	for _, p := range windowsPorts {
+		if d.OpenPorts.Contains(p) {
+			return OSWindows
+		}
+	}
+	if d.OpenPorts.Contains(PortSSH) {
+		return OSLinux
+	}
+	return ""
+}
+
+// InstallMethod tells how an installer is pushed to the device, empty if no suitable port is open.
+func (d *Device) InstallMethod() string {
+	switch d.GuessOS() {
+	case OSLinux:
+		return MethodSSH
+	case OSWindows:
+		if d.OpenPorts.Contains(PortWinRM) || d.OpenPorts.Contains(PortWinRMHTTPS) {
+			return MethodWinRM
+		}
+	}
+	return ""
+}
+
+func (p Ports) Contains(port int) bool {
+	for _, open := range p {
+		if open == port {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package discovery
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeviceGuessOS(t *testing.T) {
+	testCases := []struct {
+		name       string
+		ports      Ports
+		wantOS     string
+		wantMethod string
+	}{
+		{
+			name:       "linux",
+			ports:      Ports{22, 80},
+			wantOS:     OSLinux,
+			wantMethod: MethodSSH,
+		},
+		{
+			name:       "windows with winrm",
+			ports:      Ports{22, 445, 5985},
+			wantOS:     OSWindows,
+			wantMethod: MethodWinRM,
+		},
+		{
+			name:   "windows without winrm",
+			ports:  Ports{3389},
+			wantOS: OSWindows,
+		},
+		{
+			name:  "printer",
+			ports: Ports{80, 9100},
+		},
+		{
+			name: "no open ports",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			d := &Device{OpenPorts: tc.ports}
+			assert.Equal(t, tc.wantOS, d.GuessOS())
+			assert.Equal(t, tc.wantMethod, d.InstallMethod())
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"net"
+	"sort"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type SqliteProvider struct {
+	db *sqlx.DB
+}
+
+func NewSqliteProvider(db *sqlx.DB) *SqliteProvider {
+	return &SqliteProvider{
+		db: db,
+	}
+}
+
+func (p *SqliteProvider) SaveScan(ctx context.Context, s *Scan) error {
+	_, err := p.db.NamedExecContext(
+		ctx,
+		`INSERT INTO scans (id, client_id, subnets, ports, status, error, devices_count, created_by, created_at, finished_at)
+			VALUES (:id, :client_id, :subnets, :ports, :status, :error, :devices_count, :created_by, :created_at, :finished_at)`,
+		s,
+	)
+	return err
+}
+
+// GetScan returns nil if the scan of the client is not found.
+func (p *SqliteProvider) GetScan(ctx context.Context, clientID, id string) (*Scan, error) {
+	s := &Scan{}
+	err := p.db.GetContext(ctx, s, "SELECT * FROM scans WHERE client_id = ? AND id = ?", clientID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ListScans returns the scans of the client, the latest first.
+func (p *SqliteProvider) ListScans(ctx context.Context, clientID string) ([]*Scan, error) {
+	result := []*Scan{}
+	err := p.db.SelectContext(ctx, &result, "SELECT * FROM scans WHERE client_id = ? ORDER BY created_at DESC", clientID)
+	return result, err
+}
+
+// FinishScan stores the result of the scan and adds the devices to the inventory of the client. Devices found before
+// keep their first_seen_at, ARP entries without mac and hostname don't overwrite the ones known.
+func (p *SqliteProvider) FinishScan(ctx context.Context, s *Scan, devices []*Device) (err error) {
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	_, err = tx.NamedExecContext(
+		ctx,
+		"UPDATE scans SET status = :status, error = :error, devices_count = :devices_count, finished_at = :finished_at WHERE id = :id",
+		s,
+	)
+	if err != nil {
+		return err
+	}
+	for _, d := range devices {
+		_, err = tx.NamedExecContext(
+			ctx,
+			`INSERT INTO devices (client_id, ip, mac, hostname, open_ports, first_seen_at, last_seen_at, last_scan_id)
+				VALUES (:client_id, :ip, :mac, :hostname, :open_ports, :first_seen_at, :last_seen_at, :last_scan_id)
+				ON CONFLICT (client_id, ip) DO UPDATE SET
+					mac = CASE WHEN excluded.mac = '' THEN mac ELSE excluded.mac END,
+					hostname = CASE WHEN excluded.hostname = '' THEN hostname ELSE excluded.hostname END,
+					open_ports = excluded.open_ports,
+					last_seen_at = excluded.last_seen_at,
+					last_scan_id = excluded.last_scan_id`,
+			d,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ListDevices returns the devices found by the client, ordered by ip.
+func (p *SqliteProvider) ListDevices(ctx context.Context, clientID string) ([]*Device, error) {
+	result := []*Device{}
+	err := p.db.SelectContext(ctx, &result, "SELECT * FROM devices WHERE client_id = ?", clientID)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return bytes.Compare(net.ParseIP(result[i].IP).To16(), net.ParseIP(result[j].IP).To16()) < 0
+	})
+	return result, nil
+}
+
+func (p *SqliteProvider) Close() error {
+	return p.db.Close()
+}
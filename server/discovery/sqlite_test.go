@@ -0,0 +1,71 @@
+package discovery
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	discoverymigration "github.com/IOTech17/neo-rport/db/migration/discovery"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+)
+
+var DataSourceOptions = sqlite.DataSourceOptions{WALEnabled: false}
+
+func TestSqliteProvider(t *testing.T) {
+	db, err := sqlite.New(":memory:", discoverymigration.AssetNames(), discoverymigration.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+	p := NewSqliteProvider(db)
+	t1 := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+
+	s1 := &Scan{ID: "s1", ClientID: "c1", Subnets: Subnets{"192.168.1.0/24"}, Status: ScanStatusRunning, CreatedBy: "admin", CreatedAt: t1}
+	require.NoError(t, p.SaveScan(ctx, s1))
+	s1.Status = ScanStatusFinished
+	s1.DevicesCount = 2
+	s1.FinishedAt = &t1
+	require.NoError(t, p.FinishScan(ctx, s1, []*Device{
+		{ClientID: "c1", IP: "192.168.1.10", MAC: "aa:bb:cc:dd:ee:10", Hostname: "printer", OpenPorts: Ports{9100}, FirstSeenAt: t1, LastSeenAt: t1, LastScanID: "s1"},
+		{ClientID: "c1", IP: "192.168.1.9", OpenPorts: Ports{22}, FirstSeenAt: t1, LastSeenAt: t1, LastScanID: "s1"},
+	}))
+
+	s2 := &Scan{ID: "s2", ClientID: "c1", Status: ScanStatusRunning, CreatedAt: t2}
+	require.NoError(t, p.SaveScan(ctx, s2))
+	s2.Status = ScanStatusFinished
+	s2.DevicesCount = 1
+	s2.FinishedAt = &t2
+	require.NoError(t, p.FinishScan(ctx, s2, []*Device{
+		{ClientID: "c1", IP: "192.168.1.10", OpenPorts: Ports{80, 9100}, FirstSeenAt: t2, LastSeenAt: t2, LastScanID: "s2"},
+	}))
+	require.NoError(t, p.SaveScan(ctx, &Scan{ID: "s3", ClientID: "c2", Status: ScanStatusRunning, CreatedAt: t2}))
+
+	scan, err := p.GetScan(ctx, "c1", "s1")
+	require.NoError(t, err)
+	assert.Equal(t, Subnets{"192.168.1.0/24"}, scan.Subnets)
+	assert.Equal(t, Ports{}, scan.Ports)
+	assert.Equal(t, 2, scan.DevicesCount)
+	scan, err = p.GetScan(ctx, "c1", "s3")
+	require.NoError(t, err)
+	assert.Nil(t, scan)
+
+	scans, err := p.ListScans(ctx, "c1")
+	require.NoError(t, err)
+	require.Len(t, scans, 2)
+	assert.Equal(t, "s2", scans[0].ID)
+
+	devices, err := p.ListDevices(ctx, "c1")
+	require.NoError(t, err)
+	assert.Equal(t, []*Device{
+		{ClientID: "c1", IP: "192.168.1.9", OpenPorts: Ports{22}, FirstSeenAt: t1, LastSeenAt: t1, LastScanID: "s1"},
+		// mac and hostname are kept, first seen as well
+		{ClientID: "c1", IP: "192.168.1.10", MAC: "aa:bb:cc:dd:ee:10", Hostname: "printer", OpenPorts: Ports{80, 9100}, FirstSeenAt: t1, LastSeenAt: t2, LastScanID: "s2"},
+	}, devices)
+
+	devices, err = p.ListDevices(ctx, "c2")
+	require.NoError(t, err)
+	assert.Empty(t, devices)
+}
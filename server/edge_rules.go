@@ -0,0 +1,57 @@
+package chserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/IOTech17/neo-rport/server/alerts"
+	"github.com/IOTech17/neo-rport/server/cgroups"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/share/comm"
+)
+
+// clientEdgeRules collects the edge rules of all groups the client belongs to.
+func clientEdgeRules(client *clientdata.Client, groups []*cgroups.ClientGroup, rules []alerts.EdgeRule) []comm.EdgeRule {
+	belongsTo := make(map[string]bool)
+	for _, group := range groups {
+		if client.BelongsTo(group) {
+			belongsTo[group.ID] = true
+		}
+	}
+
+	res := make([]comm.EdgeRule, 0)
+	for _, rule := range rules {
+		if belongsTo[rule.GroupID] {
+			res = append(res, rule.ClientRule())
+		}
+	}
+	return res
+}
+
+// updateEdgeRules sends the edge rules of the client groups to the client. Rules not sent anymore are removed by the
+// client.
+func (s *Server) updateEdgeRules(client *clientdata.Client, groups []*cgroups.ClientGroup, rules []alerts.EdgeRule) {
+	clientRules := clientEdgeRules(client, groups, rules)
+
+	err := comm.SendRequestAndGetResponse(client.GetConnection(), comm.RequestTypePutEdgeRules, clientRules, nil, s.Logger)
+	if err != nil {
+		if strings.Contains(err.Error(), "unknown request") {
+			if len(clientRules) > 0 {
+				s.Infof("client %s does not support edge rules", client.GetID())
+			}
+			return
+		}
+		s.Errorf("failed to send edge rules to client %s: %v", client.GetID(), err)
+	}
+}
+
+// receiveEdgeAlertEvents records the events of the edge rules evaluated by the client.
+func (cl *ClientListener) receiveEdgeAlertEvents(ctx context.Context, clientID string, payload []byte) error {
+	var events []comm.EdgeAlertEvent
+	if err := json.Unmarshal(payload, &events); err != nil {
+		return fmt.Errorf("failed to decode %T: %v", events, err)
+	}
+	return cl.server.edgeAlerts.Receive(ctx, clientID, events)
+}
@@ -0,0 +1,29 @@
+package chserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/IOTech17/neo-rport/server/alerts"
+	"github.com/IOTech17/neo-rport/server/cgroups"
+	"github.com/IOTech17/neo-rport/server/clients"
+	"github.com/IOTech17/neo-rport/share/comm"
+)
+
+func TestClientEdgeRules(t *testing.T) {
+	c := clients.New(t).ID("client-1").Logger(testLog).Build()
+
+	groups := []*cgroups.ClientGroup{
+		{ID: "group-1", Params: &cgroups.ClientParams{ClientID: &cgroups.ParamValues{"client-*"}}},
+		{ID: "group-2", Params: &cgroups.ClientParams{ClientID: &cgroups.ParamValues{"client-2"}}},
+	}
+	rules := []alerts.EdgeRule{
+		{ID: "cpu-high", GroupID: "group-1", Expr: "cpu_usage_percent > 90", ForSec: 300},
+		{ID: "nginx-down", GroupID: "group-2", Expr: "process_count(nginx) < 1"},
+		{ID: "unknown-group", GroupID: "group-3", Expr: "memory_usage_percent > 90"},
+	}
+
+	assert.Equal(t, []comm.EdgeRule{rules[0].ClientRule()}, clientEdgeRules(c, groups, rules))
+	assert.Equal(t, []comm.EdgeRule{}, clientEdgeRules(c, groups[1:], rules))
+}
@@ -0,0 +1,95 @@
+package externalclients
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Client is a device without an rport client, e.g. an IoT device or a script, pushing measures and inventory to the
+// ingestion endpoint with its token.
+type Client struct {
+	ID                 string     `db:"id" json:"id"`
+	Name               string     `db:"name" json:"name"`
+	Description        string     `db:"description" json:"description"`
+	Tags               Tags       `db:"tags" json:"tags"`
+	TokenHash          string     `db:"token_hash" json:"-"`
+	Inventory          Inventory  `db:"inventory" json:"inventory"`
+	InventoryUpdatedAt *time.Time `db:"inventory_updated_at" json:"inventory_updated_at"`
+	LastSeenAt         *time.Time `db:"last_seen_at" json:"last_seen_at"`
+	CreatedBy          string     `db:"created_by" json:"created_by"`
+	CreatedAt          time.Time  `db:"created_at" json:"created_at"`
+}
+
+// SetToken stores the hash of the token, the token itself isn't kept.
+func (c *Client) SetToken(token string) {
+	c.TokenHash = HashToken(token)
+}
+
+// VerifyToken returns true if the token is the one of the client.
+func (c *Client) VerifyToken(token string) bool {
+	return subtle.ConstantTimeCompare([]byte(c.TokenHash), []byte(HashToken(token))) == 1
+}
+
+// HashToken returns the hex encoded sha256 hash of a token. The tokens are random, a salted hash isn't needed.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Inventory is what an external client reports about itself, each push replaces the previous one.
+type Inventory struct {
+	Hostname     string            `json:"hostname,omitempty"`
+	OS           string            `json:"os,omitempty"`
+	OSVersion    string            `json:"os_version,omitempty"`
+	Firmware     string            `json:"firmware,omitempty"`
+	Vendor       string            `json:"vendor,omitempty"`
+	Model        string            `json:"model,omitempty"`
+	SerialNumber string            `json:"serial_number,omitempty"`
+	IPv4         []string          `json:"ipv4,omitempty"`
+	Attributes   map[string]string `json:"attributes,omitempty"`
+}
+
+func (i *Inventory) Scan(value interface{}) error {
+	return scanJSON(value, i, "inventory")
+}
+
+func (i Inventory) Value() (driver.Value, error) {
+	return valueJSON(i, "inventory")
+}
+
+type Tags []string
+
+func (t *Tags) Scan(value interface{}) error {
+	return scanJSON(value, t, "tags")
+}
+
+func (t Tags) Value() (driver.Value, error) {
+	if t == nil {
+		t = Tags{}
+	}
+	return valueJSON(t, "tags")
+}
+
+func scanJSON(value interface{}, dest interface{}, field string) error {
+	valueStr, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("expected to have string, got %T", value)
+	}
+	if err := json.Unmarshal([]byte(valueStr), dest); err != nil {
+		return fmt.Errorf("failed to decode '%s' field: %v", field, err)
+	}
+	return nil
+}
+
+func valueJSON(value interface{}, field string) (driver.Value, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode '%s' field: %v", field, err)
+	}
+	return string(b), nil
+}
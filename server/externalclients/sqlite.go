@@ -0,0 +1,93 @@
+package externalclients
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type SqliteProvider struct {
+	db *sqlx.DB
+}
+
+func NewSqliteProvider(db *sqlx.DB) *SqliteProvider {
+	return &SqliteProvider{
+		db: db,
+	}
+}
+
+// Create returns false if a client with the same id exists.
+func (p *SqliteProvider) Create(ctx context.Context, c *Client) (bool, error) {
+	res, err := p.db.NamedExecContext(
+		ctx,
+		`INSERT INTO external_clients (id, name, description, tags, token_hash, inventory, created_by, created_at)
+			VALUES (:id, :name, :description, :tags, :token_hash, :inventory, :created_by, :created_at)
+			ON CONFLICT (id) DO NOTHING`,
+		c,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// Get returns nil if the client is not found.
+func (p *SqliteProvider) Get(ctx context.Context, id string) (*Client, error) {
+	c := &Client{}
+	err := p.db.GetContext(ctx, c, "SELECT * FROM external_clients WHERE id = ?", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// List returns all clients ordered by id.
+func (p *SqliteProvider) List(ctx context.Context) ([]*Client, error) {
+	result := []*Client{}
+	err := p.db.SelectContext(ctx, &result, "SELECT * FROM external_clients ORDER BY id")
+	return result, err
+}
+
+// Delete returns false if the client is not found.
+func (p *SqliteProvider) Delete(ctx context.Context, id string) (bool, error) {
+	res, err := p.db.ExecContext(ctx, "DELETE FROM external_clients WHERE id = ?", id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// SetTokenHash replaces the token of the client, the previous one stops working.
+func (p *SqliteProvider) SetTokenHash(ctx context.Context, id, tokenHash string) error {
+	_, err := p.db.ExecContext(ctx, "UPDATE external_clients SET token_hash = ? WHERE id = ?", tokenHash, id)
+	return err
+}
+
+// SaveInventory replaces the inventory of the client.
+func (p *SqliteProvider) SaveInventory(ctx context.Context, id string, inventory Inventory, at time.Time) error {
+	_, err := p.db.ExecContext(ctx, "UPDATE external_clients SET inventory = ?, inventory_updated_at = ? WHERE id = ?", inventory, at, id)
+	return err
+}
+
+func (p *SqliteProvider) SetLastSeen(ctx context.Context, id string, at time.Time) error {
+	_, err := p.db.ExecContext(ctx, "UPDATE external_clients SET last_seen_at = ? WHERE id = ?", at, id)
+	return err
+}
+
+func (p *SqliteProvider) Close() error {
+	return p.db.Close()
+}
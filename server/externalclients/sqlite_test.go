@@ -0,0 +1,70 @@
+package externalclients
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	externalclientsmigration "github.com/IOTech17/neo-rport/db/migration/external_clients"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+)
+
+var DataSourceOptions = sqlite.DataSourceOptions{WALEnabled: false}
+
+func TestSqliteProvider(t *testing.T) {
+	db, err := sqlite.New(":memory:", externalclientsmigration.AssetNames(), externalclientsmigration.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+	p := NewSqliteProvider(db)
+	t1 := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	c1 := &Client{ID: "sensor-1", Name: "Sensor 1", Tags: Tags{"iot"}, CreatedBy: "admin", CreatedAt: t1}
+	c1.SetToken("secret1")
+	created, err := p.Create(ctx, c1)
+	require.NoError(t, err)
+	assert.True(t, created)
+	created, err = p.Create(ctx, &Client{ID: "sensor-1", TokenHash: HashToken("other"), CreatedAt: t1})
+	require.NoError(t, err)
+	assert.False(t, created)
+	created, err = p.Create(ctx, &Client{ID: "plc-1", TokenHash: HashToken("secret2"), CreatedAt: t1})
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	got, err := p.Get(ctx, "sensor-1")
+	require.NoError(t, err)
+	assert.Equal(t, c1, got)
+	assert.True(t, got.VerifyToken("secret1"))
+	assert.False(t, got.VerifyToken("secret2"))
+
+	inventory := Inventory{Hostname: "sensor-1.local", Firmware: "1.2.3", IPv4: []string{"192.168.1.10"}, Attributes: map[string]string{"room": "basement"}}
+	require.NoError(t, p.SaveInventory(ctx, "sensor-1", inventory, t1))
+	require.NoError(t, p.SetLastSeen(ctx, "sensor-1", t1))
+	require.NoError(t, p.SetTokenHash(ctx, "sensor-1", HashToken("secret3")))
+	got, err = p.Get(ctx, "sensor-1")
+	require.NoError(t, err)
+	assert.Equal(t, inventory, got.Inventory)
+	assert.Equal(t, &t1, got.InventoryUpdatedAt)
+	assert.Equal(t, &t1, got.LastSeenAt)
+	assert.True(t, got.VerifyToken("secret3"))
+
+	all, err := p.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, "plc-1", all[0].ID)
+	assert.Equal(t, Tags{}, all[0].Tags)
+	assert.Equal(t, "sensor-1", all[1].ID)
+
+	deleted, err := p.Delete(ctx, "plc-1")
+	require.NoError(t, err)
+	assert.True(t, deleted)
+	deleted, err = p.Delete(ctx, "plc-1")
+	require.NoError(t, err)
+	assert.False(t, deleted)
+	got, err = p.Get(ctx, "plc-1")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+}
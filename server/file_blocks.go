@@ -0,0 +1,157 @@
+package chserver
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/files"
+	"github.com/IOTech17/neo-rport/share/logger"
+)
+
+// deltaMinFileSize is the size from which clients get a signature of a pushed file to fetch only the changed blocks
+const deltaMinFileSize = 64 * 1024
+
+// maxFileBlocksRequestBytes limits the ranges a client requests at once
+const maxFileBlocksRequestBytes = 16 * 1024 * 1024
+
+type pendingFilePush struct {
+	path      string
+	clientIDs map[string]bool
+}
+
+// filePushes holds the files being pushed to clients, so the clients can fetch their blocks on a file blocks channel.
+type filePushes struct {
+	m  map[string]*pendingFilePush
+	mu sync.Mutex
+}
+
+func newFilePushes() *filePushes {
+	return &filePushes{
+		m: make(map[string]*pendingFilePush),
+	}
+}
+
+func (p *filePushes) add(id, path string, clients []*clientdata.Client) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	clientIDs := make(map[string]bool, len(clients))
+	for _, c := range clients {
+		clientIDs[c.GetID()] = true
+	}
+	p.m[id] = &pendingFilePush{path: path, clientIDs: clientIDs}
+}
+
+// get returns the path of the pushed file, it's empty if the id is unknown or the file isn't pushed to the client.
+func (p *filePushes) get(id, clientID string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	push := p.m[id]
+	if push == nil || !push.clientIDs[clientID] {
+		return ""
+	}
+	return push.path
+}
+
+func (p *filePushes) del(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.m, id)
+}
+
+// writeDeltaSignature stores the signature of a pushed file next to it.
+func (al *APIListener) writeDeltaSignature(path string, size int64) (string, error) {
+	file, err := al.filesAPI.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	sig, err := files.NewSignature(file, files.DeltaBlockSize(size))
+	if err != nil {
+		return "", err
+	}
+	buf := &bytes.Buffer{}
+	if _, err := sig.WriteTo(buf); err != nil {
+		return "", err
+	}
+	sigPath := path + ".sig"
+	if _, err := al.filesAPI.CreateFile(sigPath, buf); err != nil {
+		return "", err
+	}
+	return sigPath, nil
+}
+
+func (cl *ClientListener) handleFileBlocksChannel(clientLog *logger.Logger, clientID string, ch ssh.NewChannel) {
+	id := string(ch.ExtraData())
+	path := cl.server.filePushes.get(id, clientID)
+	if path == "" {
+		clientLog.Infof("Rejecting blocks of unknown file push %q", id)
+		cl.rejectChannel(clientLog, ch, ssh.Prohibited, "file push not found")
+		return
+	}
+
+	stream, reqs, err := ch.Accept()
+	if err != nil {
+		clientLog.Debugf("Failed to accept file blocks stream: %s", err)
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	defer stream.Close()
+
+	if err := sendFileBlocks(stream, path); err != nil {
+		clientLog.Errorf("Failed to send blocks of file push %s: %v", id, err)
+	}
+}
+
+func sendFileBlocks(stream io.ReadWriter, path string) error {
+	var req comm.FileBlocksRequest
+	if err := json.NewDecoder(io.LimitReader(stream, maxFileBlocksRequestBytes)).Decode(&req); err != nil {
+		return fmt.Errorf("invalid request: %w", err)
+	}
+
+	var w io.Writer = stream
+	var fw *flate.Writer
+	switch req.Compression {
+	case "":
+	case comm.FileBlocksCompressionDeflate:
+		var err error
+		fw, err = flate.NewWriter(stream, flate.BestSpeed)
+		if err != nil {
+			return err
+		}
+		w = fw
+	default:
+		return fmt.Errorf("unsupported compression %q", req.Compression)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	for _, r := range req.Ranges {
+		if r.Offset < 0 || r.Length < 0 {
+			return fmt.Errorf("invalid range %d+%d", r.Offset, r.Length)
+		}
+		n, err := io.Copy(w, io.NewSectionReader(f, r.Offset, r.Length))
+		if err != nil {
+			return err
+		}
+		if n != r.Length {
+			return fmt.Errorf("range %d+%d exceeds the file", r.Offset, r.Length)
+		}
+	}
+	if fw != nil {
+		return fw.Close()
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package chserver
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/server/clients"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/files"
+)
+
+type fileBlocksStreamMock struct {
+	io.Reader
+	bytes.Buffer
+}
+
+func (s *fileBlocksStreamMock) Read(p []byte) (int, error) {
+	return s.Reader.Read(p)
+}
+
+func newFileBlocksStreamMock(t *testing.T, req comm.FileBlocksRequest) *fileBlocksStreamMock {
+	data, err := json.Marshal(req)
+	require.NoError(t, err)
+	return &fileBlocksStreamMock{Reader: bytes.NewReader(data)}
+}
+
+func TestSendFileBlocks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "push")
+	require.NoError(t, os.WriteFile(path, []byte("0123456789"), 0600))
+
+	stream := newFileBlocksStreamMock(t, comm.FileBlocksRequest{
+		Ranges:      []files.Range{{Offset: 0, Length: 2}, {Offset: 7, Length: 3}},
+		Compression: comm.FileBlocksCompressionDeflate,
+	})
+	require.NoError(t, sendFileBlocks(stream, path))
+	content, err := io.ReadAll(flate.NewReader(&stream.Buffer))
+	require.NoError(t, err)
+	assert.Equal(t, "01789", string(content))
+
+	stream = newFileBlocksStreamMock(t, comm.FileBlocksRequest{Ranges: []files.Range{{Offset: 4, Length: 2}}})
+	require.NoError(t, sendFileBlocks(stream, path))
+	assert.Equal(t, "45", stream.Buffer.String())
+
+	stream = newFileBlocksStreamMock(t, comm.FileBlocksRequest{Ranges: []files.Range{{Offset: 8, Length: 5}}})
+	assert.EqualError(t, sendFileBlocks(stream, path), "range 8+5 exceeds the file")
+
+	stream = newFileBlocksStreamMock(t, comm.FileBlocksRequest{Compression: "zstd"})
+	assert.EqualError(t, sendFileBlocks(stream, path), `unsupported compression "zstd"`)
+}
+
+func TestFilePushes(t *testing.T) {
+	p := newFilePushes()
+	p.add("push-1", "/data/filepush/push-1", []*clientdata.Client{clients.New(t).ID("client-1").Build()})
+
+	assert.Equal(t, "/data/filepush/push-1", p.get("push-1", "client-1"))
+	assert.Empty(t, p.get("push-1", "client-2"))
+	assert.Empty(t, p.get("push-2", "client-1"))
+
+	p.del("push-1")
+	assert.Empty(t, p.get("push-1", "client-1"))
+}
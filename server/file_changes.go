@@ -0,0 +1,390 @@
+package chserver
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/routes"
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+const (
+	defaultFileChangeMonitorSec = 5 * 60
+	maxFileChangeMonitorSec     = 24 * 60 * 60
+	// fileChangeRollbackGracePeriod is added to the monitoring time the clients wait for the confirmation, before they
+	// roll back on their own
+	fileChangeRollbackGracePeriod = 2 * time.Minute
+	// maxFinishedFileChanges limits the finished changes kept to be listed
+	maxFinishedFileChanges = 100
+)
+
+// fileChangeCheckInterval is the interval the connections of the clients are checked while a change is monitored
+var fileChangeCheckInterval = 5 * time.Second
+
+const (
+	FileChangeStatusStaging    = "staging"
+	FileChangeStatusMonitoring = "monitoring"
+	FileChangeStatusCompleted  = "completed"
+	FileChangeStatusAborted    = "aborted"
+
+	FileChangeClientStatusStaging    = "staging"
+	FileChangeClientStatusStaged     = "staged"
+	FileChangeClientStatusUnchanged  = "unchanged"
+	FileChangeClientStatusActivated  = "activated"
+	FileChangeClientStatusDegraded   = "degraded"
+	FileChangeClientStatusConfirmed  = "confirmed"
+	FileChangeClientStatusRolledBack = "rolled_back"
+	FileChangeClientStatusFailed     = "failed"
+)
+
+// FileChange is a two-phase file push. The file is staged on all clients first and only activated on all of them at
+// once if staging succeeded everywhere. Clients that disconnect while the change is monitored are rolled back.
+type FileChange struct {
+	ID              string              `json:"id"`
+	Status          string              `json:"status"`
+	DestinationPath string              `json:"destination_path"`
+	MonitorSec      int                 `json:"monitor_sec"`
+	CreatedBy       string              `json:"created_by"`
+	CreatedAt       time.Time           `json:"created_at"`
+	ActivatedAt     *time.Time          `json:"activated_at"`
+	FinishedAt      *time.Time          `json:"finished_at"`
+	Clients         []*FileChangeClient `json:"clients"`
+}
+
+type FileChangeClient struct {
+	ClientID string `json:"client_id"`
+	Status   string `json:"status"`
+	Message  string `json:"message,omitempty"`
+}
+
+// fileChanges holds the two-phase file pushes since the server started.
+type fileChanges struct {
+	m  map[string]*FileChange
+	mu sync.RWMutex
+}
+
+func newFileChanges() *fileChanges {
+	return &fileChanges{
+		m: make(map[string]*FileChange),
+	}
+}
+
+// add returns false if a change with the id exists.
+func (fc *fileChanges) add(change *FileChange) bool {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	if fc.m[change.ID] != nil {
+		return false
+	}
+	fc.m[change.ID] = change
+
+	var finished []*FileChange
+	for _, c := range fc.m {
+		if c.FinishedAt != nil {
+			finished = append(finished, c)
+		}
+	}
+	if len(finished) <= maxFinishedFileChanges {
+		return true
+	}
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].FinishedAt.Before(*finished[j].FinishedAt)
+	})
+	for _, c := range finished[:len(finished)-maxFinishedFileChanges] {
+		delete(fc.m, c.ID)
+	}
+	return true
+}
+
+// get returns a copy of the change, nil if it's unknown.
+func (fc *fileChanges) get(id string) *FileChange {
+	fc.mu.RLock()
+	defer fc.mu.RUnlock()
+	change := fc.m[id]
+	if change == nil {
+		return nil
+	}
+	return change.copy()
+}
+
+// list returns copies of the changes, the latest first.
+func (fc *fileChanges) list() []*FileChange {
+	fc.mu.RLock()
+	defer fc.mu.RUnlock()
+	changes := make([]*FileChange, 0, len(fc.m))
+	for _, c := range fc.m {
+		changes = append(changes, c.copy())
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].CreatedAt.After(changes[j].CreatedAt)
+	})
+	return changes
+}
+
+// update changes the change while holding the lock, so readers get consistent copies.
+func (fc *fileChanges) update(fn func()) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	fn()
+}
+
+func (c *FileChange) copy() *FileChange {
+	cp := *c
+	cp.Clients = make([]*FileChangeClient, 0, len(c.Clients))
+	for _, cc := range c.Clients {
+		ccCopy := *cc
+		cp.Clients = append(cp.Clients, &ccCopy)
+	}
+	return &cp
+}
+
+func newFileChange(uploadRequest *UploadRequest, createdBy string) *FileChange {
+	change := &FileChange{
+		ID:              uploadRequest.ID,
+		Status:          FileChangeStatusStaging,
+		DestinationPath: uploadRequest.DestinationPath,
+		MonitorSec:      uploadRequest.MonitorSec,
+		CreatedBy:       createdBy,
+		CreatedAt:       time.Now(),
+	}
+	for _, c := range uploadRequest.Clients {
+		change.Clients = append(change.Clients, &FileChangeClient{
+			ClientID: c.GetID(),
+			Status:   FileChangeClientStatusStaging,
+		})
+	}
+	return change
+}
+
+func parseFileChangeMonitorSec(req *http.Request, twoPhase bool) (int, error) {
+	values := req.MultipartForm.Value["monitor_sec"]
+	if len(values) > 0 && !twoPhase {
+		return 0, errors2.APIError{
+			Message:    "monitor_sec requires two_phase",
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+	if !twoPhase {
+		return 0, nil
+	}
+	if len(values) == 0 {
+		return defaultFileChangeMonitorSec, nil
+	}
+	monitorSec, err := strconv.Atoi(values[0])
+	if err != nil || monitorSec < 0 || monitorSec > maxFileChangeMonitorSec {
+		return 0, errors2.APIError{
+			Message:    fmt.Sprintf("monitor_sec must be between 0 and %d", maxFileChangeMonitorSec),
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+	return monitorSec, nil
+}
+
+// runFileChange stages the file on all clients, activates it on all of them if staging succeeded everywhere and
+// rolls back the clients that disconnect while the change is monitored.
+func (al *APIListener) runFileChange(uploadRequest *UploadRequest, change *FileChange) {
+	results := al.sendFileToClients(uploadRequest)
+
+	var staged []*clientdata.Client
+	failed := false
+	al.fileChanges.update(func() {
+		for _, res := range results {
+			cc := change.client(res.client.GetID())
+			switch {
+			case res.err != nil:
+				cc.Status = FileChangeClientStatusFailed
+				cc.Message = res.err.Error()
+				failed = true
+			case res.resp.Status == "staged":
+				cc.Status = FileChangeClientStatusStaged
+				staged = append(staged, res.client)
+			case res.resp.Status == "ignored":
+				cc.Status = FileChangeClientStatusUnchanged
+				cc.Message = res.resp.Message
+			default:
+				// clients of older versions write the file right away
+				cc.Status = FileChangeClientStatusFailed
+				cc.Message = "client doesn't support two-phase file pushes, the file was written without staging"
+				failed = true
+			}
+		}
+	})
+
+	if failed {
+		al.Infof("File change[id=%q] aborted, staging failed on some clients.", change.ID)
+		for _, res := range al.sendFileChangeRequests(comm.RequestTypeRollbackFileChange, change, staged) {
+			al.setFileChangeResult(change, res, FileChangeClientStatusRolledBack)
+		}
+		al.finishFileChange(change, FileChangeStatusAborted)
+		return
+	}
+
+	now := time.Now()
+	al.fileChanges.update(func() {
+		change.Status = FileChangeStatusMonitoring
+		change.ActivatedAt = &now
+	})
+	var activated []*clientdata.Client
+	for _, res := range al.sendFileChangeRequests(comm.RequestTypeActivateFileChange, change, staged) {
+		// clients failing the activation roll back on their own
+		al.setFileChangeResult(change, res, FileChangeClientStatusActivated)
+		if res.err == nil {
+			activated = append(activated, res.client)
+		}
+	}
+
+	healthy := al.monitorFileChange(change, activated)
+
+	var degraded []*clientdata.Client
+	for _, c := range activated {
+		if !healthy[c.GetID()] {
+			degraded = append(degraded, c)
+		}
+	}
+	for _, res := range al.sendFileChangeRequests(comm.RequestTypeConfirmFileChange, change, al.currentClients(healthy, true)) {
+		al.setFileChangeResult(change, res, FileChangeClientStatusConfirmed)
+	}
+	for _, res := range al.sendFileChangeRequests(comm.RequestTypeRollbackFileChange, change, al.currentClients(healthy, false)) {
+		al.setFileChangeResult(change, res, FileChangeClientStatusRolledBack)
+	}
+	if len(degraded) > 0 {
+		al.Infof("File change[id=%q] rolled back on %d degraded clients.", change.ID, len(degraded))
+	}
+	al.finishFileChange(change, FileChangeStatusCompleted)
+}
+
+// monitorFileChange watches the connections of the clients for the monitoring time and returns which of them stayed
+// connected. Disconnected clients can't be reached, they roll back on their own if they don't reconnect in time.
+func (al *APIListener) monitorFileChange(change *FileChange, clients []*clientdata.Client) map[string]bool {
+	healthy := make(map[string]bool, len(clients))
+	for _, c := range clients {
+		healthy[c.GetID()] = true
+	}
+	if len(clients) == 0 {
+		return healthy
+	}
+
+	deadline := time.Now().Add(time.Duration(change.MonitorSec) * time.Second)
+	ticker := time.NewTicker(fileChangeCheckInterval)
+	defer ticker.Stop()
+	for {
+		for _, c := range clients {
+			if !healthy[c.GetID()] {
+				continue
+			}
+			current, err := al.clientService.GetByID(c.GetID())
+			if err != nil || current == nil || !current.IsConnected() {
+				healthy[c.GetID()] = false
+				al.fileChanges.update(func() {
+					cc := change.client(c.GetID())
+					cc.Status = FileChangeClientStatusDegraded
+					cc.Message = "client disconnected after the activation, it rolls back on its own unless it reconnects in time"
+				})
+			}
+		}
+		if !time.Now().Before(deadline) {
+			return healthy
+		}
+		<-ticker.C
+	}
+}
+
+// currentClients returns the connected clients whose health equals the given one, a reconnected client is rolled back
+// since it was degraded
+func (al *APIListener) currentClients(healthy map[string]bool, want bool) []*clientdata.Client {
+	var result []*clientdata.Client
+	for id, h := range healthy {
+		if h != want {
+			continue
+		}
+		c, err := al.clientService.GetByID(id)
+		if err != nil || c == nil || !c.IsConnected() {
+			continue
+		}
+		result = append(result, c)
+	}
+	return result
+}
+
+func (al *APIListener) sendFileChangeRequests(reqType string, change *FileChange, clients []*clientdata.Client) []*uploadResult {
+	req := &comm.FileChangeRequest{
+		ID:               change.ID,
+		RollbackAfterSec: change.MonitorSec + int(fileChangeRollbackGracePeriod.Seconds()),
+	}
+	resChan := make(chan *uploadResult, len(clients))
+	for _, c := range clients {
+		go func(c *clientdata.Client) {
+			resp := &models.UploadResponse{}
+			err := comm.SendRequestAndGetResponse(c.GetConnection(), reqType, req, resp, al.Log())
+			resChan <- &uploadResult{resp: resp, err: err, client: c}
+		}(c)
+	}
+	results := make([]*uploadResult, 0, len(clients))
+	for range clients {
+		results = append(results, <-resChan)
+	}
+	return results
+}
+
+func (al *APIListener) setFileChangeResult(change *FileChange, res *uploadResult, successStatus string) {
+	al.fileChanges.update(func() {
+		cc := change.client(res.client.GetID())
+		if res.err != nil {
+			cc.Status = FileChangeClientStatusFailed
+			cc.Message = res.err.Error()
+			return
+		}
+		cc.Status = successStatus
+		if successStatus == FileChangeClientStatusRolledBack || cc.Message == "" {
+			cc.Message = res.resp.Message
+		}
+	})
+	if res.err != nil {
+		al.Errorf("File change[id=%q] failed on client %s: %v", change.ID, res.client.GetID(), res.err)
+	}
+}
+
+func (al *APIListener) finishFileChange(change *FileChange, status string) {
+	now := time.Now()
+	al.fileChanges.update(func() {
+		change.Status = status
+		change.FinishedAt = &now
+	})
+	al.Infof("File change[id=%q] finished: %s", change.ID, status)
+}
+
+// client returns the state of the client in the change, it's called with the lock of the registry held.
+func (c *FileChange) client(clientID string) *FileChangeClient {
+	for _, cc := range c.Clients {
+		if cc.ClientID == clientID {
+			return cc
+		}
+	}
+	cc := &FileChangeClient{ClientID: clientID}
+	c.Clients = append(c.Clients, cc)
+	return cc
+}
+
+func (al *APIListener) handleListFileChanges(w http.ResponseWriter, req *http.Request) {
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(al.fileChanges.list()))
+}
+
+func (al *APIListener) handleReadFileChange(w http.ResponseWriter, req *http.Request) {
+	id := mux.Vars(req)[routes.ParamFileChangeID]
+	change := al.fileChanges.get(id)
+	if change == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("file change %s not found", id))
+		return
+	}
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(change))
+}
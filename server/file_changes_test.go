@@ -0,0 +1,158 @@
+package chserver
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/IOTech17/neo-rport/server/clients"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/models"
+	"github.com/IOTech17/neo-rport/share/test"
+)
+
+// fileChangeConnMock answers the requests of a two-phase file push
+type fileChangeConnMock struct {
+	ssh.Conn
+	mu          sync.Mutex
+	requests    []string
+	stageStatus string
+	// onActivate is called when the change is activated
+	onActivate func()
+}
+
+func (c *fileChangeConnMock) SendRequest(name string, wantReply bool, payload []byte) (bool, []byte, error) {
+	c.mu.Lock()
+	c.requests = append(c.requests, name)
+	c.mu.Unlock()
+	status := "success"
+	switch name {
+	case comm.RequestTypeUpload:
+		status = c.stageStatus
+	case comm.RequestTypeActivateFileChange:
+		req := &comm.FileChangeRequest{}
+		if err := json.Unmarshal(payload, req); err != nil || req.RollbackAfterSec != 120 {
+			return false, []byte("invalid request"), nil
+		}
+		if c.onActivate != nil {
+			c.onActivate()
+		}
+	}
+	resp, _ := json.Marshal(models.UploadResponse{Status: status, Message: name + " done"})
+	return true, resp, nil
+}
+
+func (c *fileChangeConnMock) sent() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.requests...)
+}
+
+func TestRunFileChange(t *testing.T) {
+	fileChangeCheckInterval = time.Millisecond
+	defer func() { fileChangeCheckInterval = 5 * time.Second }()
+
+	testCases := []struct {
+		name             string
+		stageStatus      string
+		disconnect       bool
+		wantStatus       string
+		wantClientStatus map[string]string
+		wantRequests     map[string][]string
+	}{
+		{
+			name:        "confirmed on healthy clients",
+			stageStatus: "staged",
+			disconnect:  true,
+			wantStatus:  FileChangeStatusCompleted,
+			wantClientStatus: map[string]string{
+				"client-1": FileChangeClientStatusConfirmed,
+				"client-2": FileChangeClientStatusDegraded,
+			},
+			wantRequests: map[string][]string{
+				"client-1": {comm.RequestTypeUpload, comm.RequestTypeActivateFileChange, comm.RequestTypeConfirmFileChange},
+				// the disconnected client rolls back on its own
+				"client-2": {comm.RequestTypeUpload, comm.RequestTypeActivateFileChange},
+			},
+		},
+		{
+			name:        "aborted if a client doesn't stage",
+			stageStatus: "success",
+			wantStatus:  FileChangeStatusAborted,
+			wantClientStatus: map[string]string{
+				"client-1": FileChangeClientStatusFailed,
+				"client-2": FileChangeClientStatusFailed,
+			},
+			wantRequests: map[string][]string{
+				"client-1": {comm.RequestTypeUpload},
+				"client-2": {comm.RequestTypeUpload},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			c1 := clients.New(t).ID("client-1").Logger(testLog).Build()
+			c2 := clients.New(t).ID("client-2").Logger(testLog).Build()
+			conn1 := &fileChangeConnMock{stageStatus: tc.stageStatus}
+			conn2 := &fileChangeConnMock{stageStatus: tc.stageStatus}
+			if tc.disconnect {
+				conn2.onActivate = c2.SetDisconnectedNow
+			}
+			c1.SetConnection(conn1)
+			c2.SetConnection(conn2)
+
+			fileAPIMock := test.NewFileAPIMock()
+			fileAPIMock.On("Remove", "/data/filepush/change-1_rport_filepush").Return(nil)
+			al := APIListener{
+				Server: &Server{
+					clientService: clients.NewClientService(
+						nil,
+						nil,
+						clients.NewClientRepository([]*clientdata.Client{c1, c2}, &hour, testLog),
+						testLog,
+						nil,
+					),
+					filesAPI:    fileAPIMock,
+					fileChanges: newFileChanges(),
+				},
+				Logger: testLog,
+			}
+			uploadRequest := &UploadRequest{
+				Clients:    []*clientdata.Client{c1, c2},
+				TwoPhase:   true,
+				MonitorSec: 0,
+				UploadedFile: &models.UploadedFile{
+					ID:              "change-1",
+					SourceFilePath:  "/data/filepush/change-1_rport_filepush",
+					DestinationPath: "/etc/network/interfaces",
+					Staged:          true,
+				},
+			}
+			change := newFileChange(uploadRequest, "admin")
+			require.True(t, al.fileChanges.add(change))
+			assert.False(t, al.fileChanges.add(newFileChange(uploadRequest, "admin")))
+
+			al.runFileChange(uploadRequest, change)
+
+			got := al.fileChanges.get("change-1")
+			require.NotNil(t, got)
+			assert.Equal(t, tc.wantStatus, got.Status)
+			assert.NotNil(t, got.FinishedAt)
+			clientStatus := map[string]string{}
+			for _, cc := range got.Clients {
+				clientStatus[cc.ClientID] = cc.Status
+			}
+			assert.Equal(t, tc.wantClientStatus, clientStatus)
+			assert.Equal(t, tc.wantRequests["client-1"], conn1.sent())
+			assert.Equal(t, tc.wantRequests["client-2"], conn2.sent())
+		})
+	}
+}
@@ -0,0 +1,160 @@
+// Package fleet compares configuration facets of clients across the fleet.
+package fleet
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+)
+
+const (
+	// DefaultOutlierPercent is the share of clients up to which the clients with a value are outliers
+	DefaultOutlierPercent = 10.0
+
+	// LabelFacetPrefix selects the value of a client label as facet, e.g. a package version set by client attributes
+	LabelFacetPrefix = "label:"
+)
+
+// facets are the client properties that can be compared
+var facets = map[string]func(c *clientdata.Client) string{
+	"os":                       (*clientdata.Client).GetOS,
+	"os_arch":                  (*clientdata.Client).GetOSArch,
+	"os_family":                (*clientdata.Client).GetOSFamily,
+	"os_full_name":             (*clientdata.Client).GetOSFullName,
+	"os_kernel":                (*clientdata.Client).GetOSKernel,
+	"os_version":               (*clientdata.Client).GetOSVersion,
+	"os_virtualization_system": (*clientdata.Client).GetOSVirtualizationSystem,
+	"timezone":                 (*clientdata.Client).GetTimezone,
+	"version":                  (*clientdata.Client).GetVersion,
+}
+
+// Bucket is the number of clients sharing a value of the facet.
+type Bucket struct {
+	Value   string  `json:"value"`
+	Count   int     `json:"count"`
+	Percent float64 `json:"percent"`
+	Outlier bool    `json:"outlier"`
+}
+
+type Client struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Report is the distribution of the values of a facet, ordered by the number of clients. Clients holding a rare
+// value are listed as outliers, DrillDown lists the clients of a single value on request.
+type Report struct {
+	Facet          string    `json:"facet"`
+	Clients        int       `json:"clients"`
+	OutlierPercent float64   `json:"outlier_percent"`
+	Distribution   []Bucket  `json:"distribution"`
+	Outliers       []Client  `json:"outliers"`
+	DrillDown      []Client  `json:"drill_down,omitempty"`
+	GeneratedAt    time.Time `json:"generated_at"`
+}
+
+// Options select the facet of a report. If Value is set the clients with this value are listed.
+type Options struct {
+	Facet          string
+	OutlierPercent float64
+	Value          *string
+}
+
+// Facets returns the names of the supported facets besides labels.
+func Facets() []string {
+	res := make([]string, 0, len(facets))
+	for name := range facets {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func facetFunc(facet string) (func(c *clientdata.Client) string, error) {
+	if f, ok := facets[facet]; ok {
+		return f, nil
+	}
+	if key := strings.TrimPrefix(facet, LabelFacetPrefix); key != facet && key != "" {
+		return func(c *clientdata.Client) string {
+			return c.GetLabels()[key]
+		}, nil
+	}
+	return nil, errors2.APIError{
+		Message:    fmt.Sprintf("unsupported facet %q, expected one of %s or %s<key>", facet, strings.Join(Facets(), ", "), LabelFacetPrefix),
+		HTTPStatus: http.StatusBadRequest,
+	}
+}
+
+// Compare generates a report of a facet over the given clients.
+func Compare(clients []*clientdata.Client, opts Options, now time.Time) (*Report, error) {
+	valueOf, err := facetFunc(opts.Facet)
+	if err != nil {
+		return nil, err
+	}
+	if opts.OutlierPercent < 0 || opts.OutlierPercent >= 100 {
+		return nil, errors2.APIError{
+			Message:    fmt.Sprintf("invalid outlier percent %v, expected at least 0 and less than 100", opts.OutlierPercent),
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+
+	report := &Report{
+		Facet:          opts.Facet,
+		Clients:        len(clients),
+		OutlierPercent: opts.OutlierPercent,
+		Distribution:   []Bucket{},
+		Outliers:       []Client{},
+		GeneratedAt:    now,
+	}
+
+	values := make([]Client, 0, len(clients))
+	counts := map[string]int{}
+	for _, c := range clients {
+		v := Client{ID: c.GetID(), Name: c.GetName(), Value: valueOf(c)}
+		values = append(values, v)
+		counts[v.Value]++
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].ID < values[j].ID
+	})
+
+	for value, count := range counts {
+		report.Distribution = append(report.Distribution, Bucket{
+			Value:   value,
+			Count:   count,
+			Percent: float64(count) * 100 / float64(len(clients)),
+		})
+	}
+	sort.Slice(report.Distribution, func(i, j int) bool {
+		a, b := report.Distribution[i], report.Distribution[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Value < b.Value
+	})
+
+	outliers := map[string]bool{}
+	// the most common value is never an outlier, even if the fleet is scattered
+	for i := 1; i < len(report.Distribution); i++ {
+		if b := &report.Distribution[i]; b.Percent <= opts.OutlierPercent {
+			b.Outlier = true
+			outliers[b.Value] = true
+		}
+	}
+
+	for _, v := range values {
+		if outliers[v.Value] {
+			report.Outliers = append(report.Outliers, v)
+		}
+		if opts.Value != nil && v.Value == *opts.Value {
+			report.DrillDown = append(report.DrillDown, v)
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,72 @@
+package fleet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+)
+
+func testClients() []*clientdata.Client {
+	var res []*clientdata.Client
+	for i, version := range []string{"0.9.12", "0.9.12", "0.9.12", "0.9.12", "0.9.12", "0.9.12", "0.9.12", "0.9.13", "0.9.13", "0.8.0"} {
+		c := &clientdata.Client{
+			ID:      string(rune('a' + i)),
+			Name:    "client " + string(rune('a'+i)),
+			Version: version,
+		}
+		if i < 2 {
+			c.Labels = map[string]string{"openssl": "3.0.2"}
+		}
+		res = append(res, c)
+	}
+	return res
+}
+
+func TestCompare(t *testing.T) {
+	now := time.Now()
+	report, err := Compare(testClients(), Options{Facet: "version", OutlierPercent: DefaultOutlierPercent}, now)
+	require.NoError(t, err)
+
+	assert.Equal(t, 10, report.Clients)
+	assert.Equal(t, now, report.GeneratedAt)
+	assert.Equal(t, []Bucket{
+		{Value: "0.9.12", Count: 7, Percent: 70},
+		{Value: "0.9.13", Count: 2, Percent: 20},
+		{Value: "0.8.0", Count: 1, Percent: 10, Outlier: true},
+	}, report.Distribution)
+	assert.Equal(t, []Client{{ID: "j", Name: "client j", Value: "0.8.0"}}, report.Outliers)
+	assert.Nil(t, report.DrillDown)
+
+	value := "0.9.13"
+	report, err = Compare(testClients(), Options{Facet: "version", OutlierPercent: 25, Value: &value}, now)
+	require.NoError(t, err)
+	assert.Len(t, report.Outliers, 3)
+	assert.Equal(t, []Client{{ID: "h", Name: "client h", Value: "0.9.13"}, {ID: "i", Name: "client i", Value: "0.9.13"}}, report.DrillDown)
+}
+
+func TestCompareLabel(t *testing.T) {
+	report, err := Compare(testClients(), Options{Facet: "label:openssl", OutlierPercent: 30}, time.Now())
+	require.NoError(t, err)
+
+	// clients without the label have an empty value
+	assert.Equal(t, []Bucket{
+		{Value: "", Count: 8, Percent: 80},
+		{Value: "3.0.2", Count: 2, Percent: 20, Outlier: true},
+	}, report.Distribution)
+}
+
+func TestCompareInvalidOptions(t *testing.T) {
+	_, err := Compare(nil, Options{Facet: "label:"}, time.Now())
+	assert.EqualError(t, err, `unsupported facet "label:", expected one of os, os_arch, os_family, os_full_name, os_kernel, os_version, os_virtualization_system, timezone, version or label:<key>`)
+
+	_, err = Compare(nil, Options{Facet: "os", OutlierPercent: 100}, time.Now())
+	assert.EqualError(t, err, "invalid outlier percent 100, expected at least 0 and less than 100")
+
+	report, err := Compare(nil, Options{Facet: "os"}, time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, report.Distribution)
+}
@@ -0,0 +1,273 @@
+package chserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotentReplayedHeader  = "Idempotent-Replayed"
+	idempotencyKeyTTL         = 24 * time.Hour
+	idempotencyKeyMaxLength   = 255
+	maxIdempotentResponseSize = 1024 * 1024
+	// maxIdempotencyKeysSize limits the bytes of all kept keys and responses, a key reserves the maximum response
+	// size while its request is processed
+	maxIdempotencyKeysSize = 64 * 1024 * 1024
+
+	ErrCodeIdempotencyKeyInvalid = "ERR_CODE_IDEMPOTENCY_KEY_INVALID"
+	ErrCodeIdempotencyKeyInUse   = "ERR_CODE_IDEMPOTENCY_KEY_IN_USE"
+	ErrCodeIdempotencyKeyReused  = "ERR_CODE_IDEMPOTENCY_KEY_REUSED"
+)
+
+// idempotentResponse is the response kept for a request sent with an idempotency key.
+type idempotentResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// idempotencyKey is a request sent with an idempotency key and its response, the status is 0 as long as the request
+// is processed.
+type idempotencyKey struct {
+	Key         string    `db:"key"`
+	Fingerprint string    `db:"fingerprint"`
+	ExpiresAt   time.Time `db:"expires_at"`
+	Status      int       `db:"status"`
+	Header      string    `db:"header"`
+	Body        []byte    `db:"body"`
+	Size        int       `db:"size"`
+}
+
+// idempotencyKeys keeps the responses of requests sent with an Idempotency-Key header, so a client retrying a request
+// after a timeout gets the original response instead of creating a duplicate. They are stored in the data dir, so
+// they are kept on restarts of the server.
+type idempotencyKeys struct {
+	db  *sqlx.DB
+	now func() time.Time
+
+	// mu makes checking and reserving a key atomic
+	mu sync.Mutex
+}
+
+func newIdempotencyKeys(db *sqlx.DB) *idempotencyKeys {
+	return &idempotencyKeys{
+		db:  db,
+		now: time.Now,
+	}
+}
+
+// Start returns the response of a key already used with the same request. Otherwise, it reserves the key for the
+// request and returns nil, the response must be saved or the key released when the request is done.
+func (k *idempotencyKeys) Start(ctx context.Context, key, fingerprint string) (*idempotentResponse, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	tx, err := k.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := k.now().UTC()
+	if _, err := tx.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at < ?", now); err != nil {
+		return nil, err
+	}
+
+	var existing idempotencyKey
+	err = tx.GetContext(ctx, &existing, "SELECT * FROM idempotency_keys WHERE key = ?", key)
+	switch {
+	case err == nil:
+		return existing.response(fingerprint)
+	case err != sql.ErrNoRows:
+		return nil, err
+	}
+
+	var used int
+	if err := tx.GetContext(ctx, &used, "SELECT COALESCE(SUM(size), 0) FROM idempotency_keys"); err != nil {
+		return nil, err
+	}
+	size := len(key) + len(fingerprint) + maxIdempotentResponseSize
+	if used+size > maxIdempotencyKeysSize {
+		return nil, errors2.APIError{
+			HTTPStatus: http.StatusServiceUnavailable,
+			Message:    "Too many idempotency keys in use, retry later.",
+		}
+	}
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO idempotency_keys (key, fingerprint, expires_at, size) VALUES (?, ?, ?, ?)",
+		key, fingerprint, now.Add(idempotencyKeyTTL), size,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return nil, tx.Commit()
+}
+
+func (k idempotencyKey) response(fingerprint string) (*idempotentResponse, error) {
+	if k.Fingerprint != fingerprint {
+		return nil, errors2.APIError{
+			HTTPStatus: http.StatusUnprocessableEntity,
+			ErrCode:    ErrCodeIdempotencyKeyReused,
+			Message:    "Idempotency key was already used for a different request.",
+		}
+	}
+	if k.Status == 0 {
+		return nil, errors2.APIError{
+			HTTPStatus: http.StatusConflict,
+			ErrCode:    ErrCodeIdempotencyKeyInUse,
+			Message:    "A request with the same idempotency key is still being processed.",
+		}
+	}
+	res := &idempotentResponse{status: k.Status, body: k.Body}
+	if err := json.Unmarshal([]byte(k.Header), &res.header); err != nil {
+		return nil, fmt.Errorf("invalid header of idempotency key: %v", err)
+	}
+	return res, nil
+}
+
+// Save keeps the response of the request the key is reserved for.
+func (k *idempotencyKeys) Save(ctx context.Context, key string, status int, header http.Header, body []byte) error {
+	rawHeader, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	_, err = k.db.ExecContext(
+		ctx,
+		"UPDATE idempotency_keys SET status = ?, header = ?, body = ?, size = LENGTH(key) + LENGTH(fingerprint) + ? WHERE key = ?",
+		status, string(rawHeader), body, len(rawHeader)+len(body), key,
+	)
+	return err
+}
+
+// Release frees a key whose request didn't complete, so the request can be retried with it.
+func (k *idempotencyKeys) Release(ctx context.Context, key string) error {
+	_, err := k.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE key = ? AND status = 0", key)
+	return err
+}
+
+func (k *idempotencyKeys) Close() error {
+	return k.db.Close()
+}
+
+// wrapIdempotencyMiddleware replays the response of a request sent again with the same Idempotency-Key header.
+// Keys are scoped to the user and the path. Responses with a 5xx status aren't kept, the request can be retried
+// with the same key.
+func (al *APIListener) wrapIdempotencyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idempotencyKey := r.Header.Get(idempotencyKeyHeader)
+		if idempotencyKey == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(idempotencyKey) > idempotencyKeyMaxLength {
+			al.jsonErrorResponseWithErrCode(w, http.StatusBadRequest, ErrCodeIdempotencyKeyInvalid, fmt.Sprintf("Idempotency key must not be longer than %d characters.", idempotencyKeyMaxLength))
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, al.config.API.MaxRequestBytes)
+		body, err := io.ReadAll(r.Body)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			al.jsonErrorResponseWithTitle(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request data exceeds the limit of %d bytes.", maxBytesErr.Limit))
+			return
+		}
+		if err != nil {
+			al.jsonErrorResponseWithError(w, http.StatusBadRequest, "Failed to read request body.", err)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		user := api.GetUser(r.Context(), al.Logger)
+		key := fmt.Sprintf("%s\n%s\n%s\n%s", user, r.Method, r.URL.Path, idempotencyKey)
+		sum := sha256.Sum256(append([]byte(r.URL.RawQuery+"\n"), body...))
+		fingerprint := hex.EncodeToString(sum[:])
+
+		res, err := al.idempotencyKeys.Start(r.Context(), key, fingerprint)
+		if err != nil {
+			var apiErr errors2.APIError
+			if errors.As(err, &apiErr) {
+				al.jsonErrorResponseWithErrCode(w, apiErr.HTTPStatus, apiErr.ErrCode, apiErr.Message)
+				return
+			}
+			al.jsonError(w, err)
+			return
+		}
+		if res != nil {
+			al.Debugf("replaying response of %s %s with idempotency key %q of user %q", r.Method, r.URL.Path, idempotencyKey, user)
+			for name, values := range res.header {
+				w.Header()[name] = values
+			}
+			w.Header().Set(idempotentReplayedHeader, "true")
+			w.WriteHeader(res.status)
+			if _, err := w.Write(res.body); err != nil {
+				al.Errorf("error writing response: %s", err)
+			}
+			return
+		}
+		// the request might be canceled, the key must be saved or released anyway
+		defer func() {
+			if err := al.idempotencyKeys.Release(context.Background(), key); err != nil {
+				al.Errorf("failed to release idempotency key %q of user %q: %v", idempotencyKey, user, err)
+			}
+		}()
+
+		rw := &recordingResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(rw, r)
+		if rw.status == 0 || rw.status >= http.StatusInternalServerError || rw.tooLarge {
+			return
+		}
+		if err := al.idempotencyKeys.Save(context.Background(), key, rw.status, rw.header, rw.body.Bytes()); err != nil {
+			al.Errorf("failed to save response of idempotency key %q of user %q: %v", idempotencyKey, user, err)
+		}
+	})
+}
+
+// recordingResponseWriter writes the response through and keeps a copy of it.
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	status   int
+	header   http.Header
+	body     bytes.Buffer
+	tooLarge bool
+}
+
+func (w *recordingResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+		w.header = w.Header().Clone()
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.body.Len()+len(b) > maxIdempotentResponseSize {
+		w.tooLarge = true
+	} else {
+		w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,168 @@
+package chserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	idempotencykeysmigration "github.com/IOTech17/neo-rport/db/migration/idempotency_keys"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+)
+
+func newTestIdempotencyKeys(t *testing.T) *idempotencyKeys {
+	db, err := sqlite.New(":memory:", idempotencykeysmigration.AssetNames(), idempotencykeysmigration.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return newIdempotencyKeys(db)
+}
+
+func TestIdempotencyKeys(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+	keys := newTestIdempotencyKeys(t)
+	keys.now = func() time.Time { return now }
+
+	res, err := keys.Start(ctx, "key1", "fp1")
+	require.NoError(t, err)
+	assert.Nil(t, res)
+
+	// still processed
+	_, err = keys.Start(ctx, "key1", "fp1")
+	assert.EqualError(t, err, "A request with the same idempotency key is still being processed.")
+
+	require.NoError(t, keys.Save(ctx, "key1", http.StatusCreated, http.Header{"Content-Type": {"application/json"}}, []byte(`{"data":1}`)))
+	res, err = keys.Start(ctx, "key1", "fp1")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, res.status)
+	assert.Equal(t, `{"data":1}`, string(res.body))
+	assert.Equal(t, http.Header{"Content-Type": {"application/json"}}, res.header)
+
+	// different request with the same key
+	_, err = keys.Start(ctx, "key1", "fp2")
+	assert.EqualError(t, err, "Idempotency key was already used for a different request.")
+
+	// released keys can be used again
+	_, err = keys.Start(ctx, "key2", "fp1")
+	require.NoError(t, err)
+	require.NoError(t, keys.Release(ctx, "key2"))
+	res, err = keys.Start(ctx, "key2", "fp2")
+	require.NoError(t, err)
+	assert.Nil(t, res)
+
+	// saved responses aren't released
+	require.NoError(t, keys.Release(ctx, "key1"))
+	res, err = keys.Start(ctx, "key1", "fp1")
+	require.NoError(t, err)
+	assert.NotNil(t, res)
+
+	// expired keys are removed
+	now = now.Add(idempotencyKeyTTL + time.Second)
+	res, err = keys.Start(ctx, "key1", "fp2")
+	require.NoError(t, err)
+	assert.Nil(t, res)
+}
+
+func TestIdempotencyKeysSizeLimit(t *testing.T) {
+	ctx := context.Background()
+	keys := newTestIdempotencyKeys(t)
+
+	// keys reserve the maximum response size while processed
+	var started int
+	for ; started < 100; started++ {
+		_, err := keys.Start(ctx, fmt.Sprintf("key%d", started), "fp")
+		if err != nil {
+			assert.EqualError(t, err, "Too many idempotency keys in use, retry later.")
+			break
+		}
+	}
+	assert.Equal(t, 63, started)
+
+	// saved responses only take their size
+	require.NoError(t, keys.Save(ctx, "key0", http.StatusOK, http.Header{}, []byte(`{"data":1}`)))
+	_, err := keys.Start(ctx, "next", "fp")
+	require.NoError(t, err)
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	al := &APIListener{
+		Server: &Server{
+			config: &chconfig.Config{
+				API: chconfig.APIConfig{
+					MaxRequestBytes: 64,
+				},
+			},
+		},
+		Logger:          testLog,
+		idempotencyKeys: newTestIdempotencyKeys(t),
+	}
+	calls := 0
+	handler := al.wrapIdempotencyMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if strings.Contains(r.URL.Path, "fail") {
+			al.jsonErrorResponseWithTitle(w, http.StatusInternalServerError, "failed")
+			return
+		}
+		al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(map[string]int{"job": calls}))
+	}))
+
+	send := func(user, path, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(idempotencyKeyHeader, key)
+		}
+		req = req.WithContext(api.WithUser(req.Context(), user))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	first := send("admin", "/api/v1/commands", "key1", `{"command":"date"}`)
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, `{"data":{"job":1}}`, first.Body.String())
+	assert.Empty(t, first.Header().Get(idempotentReplayedHeader))
+
+	replayed := send("admin", "/api/v1/commands", "key1", `{"command":"date"}`)
+	assert.Equal(t, http.StatusOK, replayed.Code)
+	assert.Equal(t, `{"data":{"job":1}}`, replayed.Body.String())
+	assert.Equal(t, "true", replayed.Header().Get(idempotentReplayedHeader))
+	assert.Equal(t, "application/json; charset=UTF-8", replayed.Header().Get("Content-Type"))
+	assert.Equal(t, 1, calls)
+
+	reused := send("admin", "/api/v1/commands", "key1", `{"command":"uptime"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, reused.Code)
+	var payload api.ErrorPayload
+	require.NoError(t, json.Unmarshal(reused.Body.Bytes(), &payload))
+	assert.Equal(t, ErrCodeIdempotencyKeyReused, payload.Errors[0].Code)
+
+	// keys are scoped to the user and the path
+	assert.Equal(t, `{"data":{"job":2}}`, send("other", "/api/v1/commands", "key1", `{"command":"date"}`).Body.String())
+	assert.Equal(t, `{"data":{"job":3}}`, send("admin", "/api/v2/commands", "key1", `{"command":"date"}`).Body.String())
+
+	// requests without a key aren't replayed
+	assert.Equal(t, `{"data":{"job":4}}`, send("admin", "/api/v1/commands", "", `{"command":"date"}`).Body.String())
+	assert.Equal(t, `{"data":{"job":5}}`, send("admin", "/api/v1/commands", "", `{"command":"date"}`).Body.String())
+
+	// server errors can be retried with the same key
+	assert.Equal(t, http.StatusInternalServerError, send("admin", "/api/v1/fail", "key2", "").Code)
+	assert.Equal(t, http.StatusInternalServerError, send("admin", "/api/v1/fail", "key2", "").Code)
+	assert.Equal(t, 7, calls)
+
+	tooLong := send("admin", "/api/v1/commands", strings.Repeat("k", idempotencyKeyMaxLength+1), "")
+	assert.Equal(t, http.StatusBadRequest, tooLong.Code)
+	assert.Equal(t, 7, calls)
+
+	tooLarge := send("admin", "/api/v1/commands", "key3", `{"command":"`+strings.Repeat("x", 64)+`"}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, tooLarge.Code)
+	assert.Contains(t, tooLarge.Body.String(), "Request data exceeds the limit of 64 bytes.")
+	assert.Equal(t, 7, calls)
+}
@@ -0,0 +1,253 @@
+package industrial
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/query"
+)
+
+const (
+	MinInterval = 10 * time.Second
+	MaxInterval = 24 * time.Hour
+	// MaxPoints limits the points of an endpoint, Modbus reads them one by one
+	MaxPoints        = 100
+	maxAddressLength = 255
+	maxNodeIDLength  = 512
+)
+
+var pointNameRegexp = regexp.MustCompile(`^[a-z0-9_]{1,64}$`)
+
+// Endpoint is a Modbus TCP or OPC UA device in the network of a client. The client polls its points every interval.
+type Endpoint struct {
+	ID       string `db:"id" json:"id"`
+	ClientID string `db:"client_id" json:"client_id"`
+	Name     string `db:"name" json:"name"`
+	Protocol string `db:"protocol" json:"protocol"`
+	// Address is host[:port] for modbus and the endpoint url opc.tcp://host[:port][/path] for opcua
+	Address string `db:"address" json:"address"`
+	// UnitID is the Modbus unit identifier, used by gateways to serial devices
+	UnitID       int        `db:"unit_id" json:"unit_id"`
+	IntervalSec  int        `db:"interval_sec" json:"interval_sec"`
+	Points       Points     `db:"points" json:"points"`
+	CreatedBy    string     `db:"created_by" json:"created_by"`
+	CreatedAt    time.Time  `db:"created_at" json:"created_at"`
+	LastPolledAt *time.Time `db:"last_polled_at" json:"last_polled_at"`
+	LastError    string     `db:"last_error" json:"last_error"`
+}
+
+// Point is a value of a device. Values read are converted with value * scale + offset.
+type Point struct {
+	Name string `json:"name"`
+	// Register, Address, Type and WordSwap locate modbus points
+	Register string `json:"register,omitempty"`
+	Address  uint16 `json:"address,omitempty"`
+	Type     string `json:"type,omitempty"`
+	WordSwap bool   `json:"word_swap,omitempty"`
+	// NodeID locates opcua points, e.g. ns=2;s=Boiler.Temperature
+	NodeID string  `json:"node_id,omitempty"`
+	Scale  float64 `json:"scale,omitempty"`
+	Offset float64 `json:"offset,omitempty"`
+	Unit   string  `json:"unit,omitempty"`
+}
+
+// Value returns the raw value read from the device converted by scale and offset, a scale of 0 is 1.
+func (p Point) Value(raw float64) float64 {
+	scale := p.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	return raw*scale + p.Offset
+}
+
+type Points []Point
+
+func (p *Points) Scan(value interface{}) error {
+	valueStr, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("expected to have string, got %T", value)
+	}
+	if err := json.Unmarshal([]byte(valueStr), p); err != nil {
+		return fmt.Errorf("failed to decode 'points' field: %v", err)
+	}
+	return nil
+}
+
+func (p Points) Value() (driver.Value, error) {
+	if p == nil {
+		p = Points{}
+	}
+	b, err := json.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode 'points' field: %v", err)
+	}
+	return string(b), nil
+}
+
+// Interval returns how often the endpoint is polled.
+func (e *Endpoint) Interval() time.Duration {
+	return time.Duration(e.IntervalSec) * time.Second
+}
+
+// Due returns true if the endpoint was never polled or the interval passed since the last poll.
+func (e *Endpoint) Due(now time.Time) bool {
+	return e.LastPolledAt == nil || !now.Before(e.LastPolledAt.Add(e.Interval()))
+}
+
+// PollRequest returns the request for the client to read the points.
+func (e *Endpoint) PollRequest(id string, timeout time.Duration) comm.IndustrialPollRequest {
+	req := comm.IndustrialPollRequest{
+		ID:       id,
+		Protocol: e.Protocol,
+		Address:  e.Address,
+		UnitID:   byte(e.UnitID),
+		Timeout:  timeout,
+	}
+	for _, p := range e.Points {
+		req.Points = append(req.Points, comm.IndustrialPoint{
+			Name:     p.Name,
+			Register: p.Register,
+			Address:  p.Address,
+			Type:     p.Type,
+			WordSwap: p.WordSwap,
+			NodeID:   p.NodeID,
+		})
+	}
+	return req
+}
+
+// Validate checks the endpoint and its points and sets the default value type of modbus registers.
+func (e *Endpoint) Validate() error {
+	switch e.Protocol {
+	case comm.IndustrialProtocolModbus:
+		if e.UnitID < 0 || e.UnitID > 255 {
+			return fmt.Errorf("invalid unit_id %d, expected 0 to 255", e.UnitID)
+		}
+	case comm.IndustrialProtocolOPCUA:
+		if e.UnitID != 0 {
+			return errors.New("unit_id is only valid for modbus")
+		}
+	default:
+		return fmt.Errorf("invalid protocol %q, expected %s or %s", e.Protocol, comm.IndustrialProtocolModbus, comm.IndustrialProtocolOPCUA)
+	}
+	if e.Address == "" || len(e.Address) > maxAddressLength {
+		return fmt.Errorf("address is required, max size is %d", maxAddressLength)
+	}
+	if e.Interval() < MinInterval || e.Interval() > MaxInterval {
+		return fmt.Errorf("invalid interval_sec %d, expected %d to %d", e.IntervalSec, int(MinInterval.Seconds()), int(MaxInterval.Seconds()))
+	}
+	if len(e.Points) == 0 || len(e.Points) > MaxPoints {
+		return fmt.Errorf("1 to %d points are required", MaxPoints)
+	}
+
+	names := make(map[string]bool, len(e.Points))
+	for i := range e.Points {
+		p := &e.Points[i]
+		if !pointNameRegexp.MatchString(p.Name) {
+			return fmt.Errorf("invalid point name %q, expected 1 to 64 lowercase letters, digits or underscores", p.Name)
+		}
+		if names[p.Name] {
+			return fmt.Errorf("duplicate point name %q", p.Name)
+		}
+		names[p.Name] = true
+		if err := p.validate(e.Protocol); err != nil {
+			return fmt.Errorf("point %q: %v", p.Name, err)
+		}
+	}
+	return nil
+}
+
+func (p *Point) validate(protocol string) error {
+	if protocol == comm.IndustrialProtocolOPCUA {
+		if p.NodeID == "" || len(p.NodeID) > maxNodeIDLength {
+			return fmt.Errorf("node_id is required, max size is %d", maxNodeIDLength)
+		}
+		if p.Register != "" || p.Type != "" {
+			return errors.New("register and type are only valid for modbus")
+		}
+		return nil
+	}
+
+	if p.NodeID != "" {
+		return errors.New("node_id is only valid for opcua")
+	}
+	switch p.Register {
+	case comm.ModbusCoil, comm.ModbusDiscreteInput:
+		if p.Type != "" && p.Type != comm.ModbusBool {
+			return fmt.Errorf("%s values are %s", p.Register, comm.ModbusBool)
+		}
+		p.Type = comm.ModbusBool
+	case comm.ModbusHoldingReg, comm.ModbusInputReg:
+		switch p.Type {
+		case "":
+			p.Type = comm.ModbusUint16
+		case comm.ModbusBool, comm.ModbusInt16, comm.ModbusUint16, comm.ModbusInt32, comm.ModbusUint32, comm.ModbusFloat32:
+		default:
+			return fmt.Errorf("invalid type %q, expected one of %s, %s, %s, %s, %s, %s", p.Type,
+				comm.ModbusBool, comm.ModbusInt16, comm.ModbusUint16, comm.ModbusInt32, comm.ModbusUint32, comm.ModbusFloat32)
+		}
+	default:
+		return fmt.Errorf("invalid register %q, expected one of %s, %s, %s, %s", p.Register,
+			comm.ModbusCoil, comm.ModbusDiscreteInput, comm.ModbusHoldingReg, comm.ModbusInputReg)
+	}
+	return nil
+}
+
+// PointValue is a value of a point read at the time.
+type PointValue struct {
+	EndpointID string    `db:"endpoint_id" json:"endpoint_id"`
+	ClientID   string    `db:"client_id" json:"client_id"`
+	Point      string    `db:"point" json:"point"`
+	Timestamp  time.Time `db:"timestamp" json:"timestamp"`
+	Value      float64   `db:"value" json:"value"`
+}
+
+// timeFormat is how the sqlite driver stores the times, filters by time are converted to it to compare them as text.
+const timeFormat = "2006-01-02 15:04:05.999999999-07:00"
+
+var (
+	SupportedValueFilters = map[string]bool{
+		"endpoint_id":      true,
+		"point":            true,
+		"timestamp[gt]":    true,
+		"timestamp[lt]":    true,
+		"timestamp[since]": true,
+		"timestamp[until]": true,
+	}
+	SupportedValueSorts = map[string]bool{
+		"timestamp": true,
+		"point":     true,
+	}
+	ValuesPaginationConfig = &query.PaginationConfig{
+		DefaultLimit: 100,
+		MaxLimit:     1000,
+	}
+	DefaultValueSort = query.SortOption{Column: "timestamp", IsASC: false}
+)
+
+// ConvertTimeFilters converts the values of the filters by timestamp given in RFC3339 or as date to the format of
+// the database.
+func ConvertTimeFilters(options *query.ListOptions) error {
+	for i := range options.Filters {
+		f := &options.Filters[i]
+		if len(f.Column) != 1 || f.Column[0] != "timestamp" {
+			continue
+		}
+		for j, v := range f.Values {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				t, err = time.Parse("2006-01-02", v)
+			}
+			if err != nil {
+				return fmt.Errorf("invalid value %q of filter timestamp, use RFC3339 or YYYY-MM-DD", v)
+			}
+			f.Values[j] = t.UTC().Format(timeFormat)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package industrial
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/IOTech17/neo-rport/share/query"
+)
+
+type SqliteProvider struct {
+	db        *sqlx.DB
+	converter *query.SQLConverter
+}
+
+func NewSqliteProvider(db *sqlx.DB) *SqliteProvider {
+	return &SqliteProvider{
+		db:        db,
+		converter: query.NewSQLConverter(db.DriverName()),
+	}
+}
+
+func (p *SqliteProvider) CreateEndpoint(ctx context.Context, e *Endpoint) error {
+	_, err := p.db.NamedExecContext(
+		ctx,
+		`INSERT INTO endpoints (id, client_id, name, protocol, address, unit_id, interval_sec, points, created_by, created_at)
+			VALUES (:id, :client_id, :name, :protocol, :address, :unit_id, :interval_sec, :points, :created_by, :created_at)`,
+		e,
+	)
+	if err != nil {
+		return fmt.Errorf("unable to save industrial endpoint: %w", err)
+	}
+	return nil
+}
+
+// UpdateEndpoint saves the endpoint, it returns false if it's not found. The endpoint is polled with the new
+// settings on the next run of the polling task.
+func (p *SqliteProvider) UpdateEndpoint(ctx context.Context, e *Endpoint) (bool, error) {
+	res, err := p.db.NamedExecContext(
+		ctx,
+		`UPDATE endpoints SET name = :name, protocol = :protocol, address = :address, unit_id = :unit_id,
+			interval_sec = :interval_sec, points = :points, last_polled_at = NULL, last_error = ''
+			WHERE id = :id AND client_id = :client_id`,
+		e,
+	)
+	return affected(res, err)
+}
+
+// GetEndpoint returns nil if the client has no endpoint with the id.
+func (p *SqliteProvider) GetEndpoint(ctx context.Context, clientID, id string) (*Endpoint, error) {
+	e := &Endpoint{}
+	err := p.db.GetContext(ctx, e, "SELECT * FROM endpoints WHERE id = ? AND client_id = ?", id, clientID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// ListEndpoints returns the endpoints of the client ordered by name.
+func (p *SqliteProvider) ListEndpoints(ctx context.Context, clientID string) ([]*Endpoint, error) {
+	values := []*Endpoint{}
+	err := p.db.SelectContext(ctx, &values, "SELECT * FROM endpoints WHERE client_id = ? ORDER BY name, id", clientID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get industrial endpoints from DB: %w", err)
+	}
+	return values, nil
+}
+
+// ListAllEndpoints returns the endpoints of all clients.
+func (p *SqliteProvider) ListAllEndpoints(ctx context.Context) ([]*Endpoint, error) {
+	values := []*Endpoint{}
+	err := p.db.SelectContext(ctx, &values, "SELECT * FROM endpoints ORDER BY client_id, id")
+	if err != nil {
+		return nil, fmt.Errorf("unable to get industrial endpoints from DB: %w", err)
+	}
+	return values, nil
+}
+
+// DeleteEndpoint deletes the endpoint with its values, it returns false if it's not found.
+func (p *SqliteProvider) DeleteEndpoint(ctx context.Context, clientID, id string) (bool, error) {
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	deleted, err := affected(tx.ExecContext(ctx, "DELETE FROM endpoints WHERE id = ? AND client_id = ?", id, clientID))
+	if err != nil || !deleted {
+		return false, err
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM point_values WHERE endpoint_id = ?", id)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// UsedPointNames returns the names of the points of the other endpoints of the client, the names of points are
+// unique per client to be used in alert rules.
+func (p *SqliteProvider) UsedPointNames(ctx context.Context, clientID, exceptEndpointID string) (map[string]bool, error) {
+	endpoints, err := p.ListEndpoints(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	used := make(map[string]bool)
+	for _, e := range endpoints {
+		if e.ID == exceptEndpointID {
+			continue
+		}
+		for _, point := range e.Points {
+			used[point.Name] = true
+		}
+	}
+	return used, nil
+}
+
+// SavePoll saves the values read and sets the time and the error of the poll.
+func (p *SqliteProvider) SavePoll(ctx context.Context, e *Endpoint, polledAt time.Time, values []PointValue, pollErr string) error {
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	_, err = tx.ExecContext(ctx, "UPDATE endpoints SET last_polled_at = ?, last_error = ? WHERE id = ?", polledAt.UTC(), pollErr, e.ID)
+	if err != nil {
+		return err
+	}
+	for _, v := range values {
+		_, err = tx.NamedExecContext(
+			ctx,
+			`INSERT INTO point_values (endpoint_id, client_id, point, timestamp, value)
+				VALUES (:endpoint_id, :client_id, :point, :timestamp, :value)`,
+			v,
+		)
+		if err != nil {
+			return fmt.Errorf("unable to save industrial point values: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (p *SqliteProvider) ListValues(ctx context.Context, options *query.ListOptions) ([]*PointValue, error) {
+	values := []*PointValue{}
+	q, params := p.converter.ConvertListOptionsToQuery(options, "SELECT * FROM point_values")
+	err := p.db.SelectContext(ctx, &values, q, params...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get industrial point values from DB: %w", err)
+	}
+	return values, nil
+}
+
+func (p *SqliteProvider) CountValues(ctx context.Context, options *query.ListOptions) (int, error) {
+	var result int
+	countOptions := *options
+	countOptions.Pagination = nil
+	countOptions.Sorts = nil
+	q, params := p.converter.ConvertListOptionsToQuery(&countOptions, "SELECT COUNT(*) FROM point_values")
+	err := p.db.GetContext(ctx, &result, q, params...)
+	if err != nil {
+		return 0, err
+	}
+	return result, nil
+}
+
+// DeleteValuesBefore deletes the values read before the given time.
+func (p *SqliteProvider) DeleteValuesBefore(ctx context.Context, before time.Time) (int64, error) {
+	res, err := p.db.ExecContext(ctx, "DELETE FROM point_values WHERE timestamp < ?", before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (p *SqliteProvider) Close() error {
+	return p.db.Close()
+}
+
+func affected(res sql.Result, err error) (bool, error) {
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
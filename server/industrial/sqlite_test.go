@@ -0,0 +1,183 @@
+package industrial
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	industrialmigration "github.com/IOTech17/neo-rport/db/migration/industrial"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/query"
+)
+
+var DataSourceOptions = sqlite.DataSourceOptions{WALEnabled: false}
+
+func TestSqliteProvider(t *testing.T) {
+	db, err := sqlite.New(":memory:", industrialmigration.AssetNames(), industrialmigration.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+	p := NewSqliteProvider(db)
+	t1 := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	e1 := &Endpoint{
+		ID:          "endpoint-1",
+		ClientID:    "client-1",
+		Name:        "Boiler PLC",
+		Protocol:    comm.IndustrialProtocolModbus,
+		Address:     "192.168.10.5",
+		UnitID:      1,
+		IntervalSec: 60,
+		Points:      Points{{Name: "boiler_temperature", Register: comm.ModbusHoldingReg, Address: 40, Type: comm.ModbusInt16, Scale: 0.1}},
+		CreatedBy:   "admin",
+		CreatedAt:   t1,
+	}
+	require.NoError(t, p.CreateEndpoint(ctx, e1))
+	e2 := &Endpoint{
+		ID:          "endpoint-2",
+		ClientID:    "client-2",
+		Protocol:    comm.IndustrialProtocolOPCUA,
+		Address:     "opc.tcp://192.168.10.6:4840",
+		IntervalSec: 10,
+		Points:      Points{{Name: "pressure", NodeID: "ns=2;s=Pressure"}},
+		CreatedAt:   t1,
+	}
+	require.NoError(t, p.CreateEndpoint(ctx, e2))
+
+	got, err := p.GetEndpoint(ctx, "client-1", "endpoint-1")
+	require.NoError(t, err)
+	assert.Equal(t, e1, got)
+	got, err = p.GetEndpoint(ctx, "client-2", "endpoint-1")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	used, err := p.UsedPointNames(ctx, "client-1", "")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"boiler_temperature": true}, used)
+	used, err = p.UsedPointNames(ctx, "client-1", "endpoint-1")
+	require.NoError(t, err)
+	assert.Empty(t, used, "the endpoint itself")
+
+	polledAt := t1.Add(time.Minute)
+	err = p.SavePoll(ctx, e1, polledAt, []PointValue{
+		{EndpointID: e1.ID, ClientID: e1.ClientID, Point: "boiler_temperature", Timestamp: t1, Value: 81.5},
+		{EndpointID: e1.ID, ClientID: e1.ClientID, Point: "boiler_temperature", Timestamp: polledAt, Value: 82},
+	}, "")
+	require.NoError(t, err)
+	require.NoError(t, p.SavePoll(ctx, e2, polledAt, nil, "connection refused"))
+
+	all, err := p.ListAllEndpoints(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, polledAt, all[0].LastPolledAt.UTC())
+	assert.Equal(t, "connection refused", all[1].LastError)
+
+	options := &query.ListOptions{
+		Filters: []query.FilterOption{
+			{Column: []string{"client_id"}, Values: []string{"client-1"}},
+			{Column: []string{"timestamp"}, Operator: query.FilterOperatorTypeGT, Values: []string{"2026-10-15T12:00:30Z"}},
+		},
+		Sorts: []query.SortOption{DefaultValueSort},
+	}
+	require.NoError(t, ConvertTimeFilters(options))
+	values, err := p.ListValues(ctx, options)
+	require.NoError(t, err)
+	require.Len(t, values, 1)
+	assert.Equal(t, 82.0, values[0].Value)
+	count, err := p.CountValues(ctx, options)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	e1.IntervalSec = 30
+	updated, err := p.UpdateEndpoint(ctx, e1)
+	require.NoError(t, err)
+	assert.True(t, updated)
+	got, err = p.GetEndpoint(ctx, "client-1", "endpoint-1")
+	require.NoError(t, err)
+	assert.Equal(t, 30, got.IntervalSec)
+	assert.Nil(t, got.LastPolledAt, "updated endpoints are polled on the next run")
+
+	deleted, err := p.DeleteValuesBefore(ctx, polledAt)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, deleted)
+
+	ok, err := p.DeleteEndpoint(ctx, "client-1", "endpoint-1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = p.DeleteEndpoint(ctx, "client-1", "endpoint-1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	count, err = p.CountValues(ctx, &query.ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 0, count, "the values of deleted endpoints are deleted")
+}
+
+func TestEndpointValidate(t *testing.T) {
+	modbus := func() Endpoint {
+		return Endpoint{
+			Protocol:    comm.IndustrialProtocolModbus,
+			Address:     "192.168.10.5:502",
+			IntervalSec: 60,
+			Points:      Points{{Name: "temperature", Register: comm.ModbusHoldingReg, Address: 40}},
+		}
+	}
+
+	e := modbus()
+	require.NoError(t, e.Validate())
+	assert.Equal(t, comm.ModbusUint16, e.Points[0].Type)
+
+	e = modbus()
+	e.Points[0].Register = comm.ModbusCoil
+	require.NoError(t, e.Validate())
+	assert.Equal(t, comm.ModbusBool, e.Points[0].Type)
+
+	e = Endpoint{
+		Protocol:    comm.IndustrialProtocolOPCUA,
+		Address:     "opc.tcp://192.168.10.6",
+		IntervalSec: 10,
+		Points:      Points{{Name: "pressure", NodeID: "ns=2;s=Pressure"}},
+	}
+	require.NoError(t, e.Validate())
+
+	testCases := []struct {
+		name    string
+		modify  func(e *Endpoint)
+		wantErr string
+	}{
+		{"protocol", func(e *Endpoint) { e.Protocol = "bacnet" }, `invalid protocol "bacnet", expected modbus or opcua`},
+		{"unit id", func(e *Endpoint) { e.UnitID = 256 }, "invalid unit_id 256, expected 0 to 255"},
+		{"address", func(e *Endpoint) { e.Address = "" }, "address is required, max size is 255"},
+		{"interval", func(e *Endpoint) { e.IntervalSec = 5 }, "invalid interval_sec 5, expected 10 to 86400"},
+		{"no points", func(e *Endpoint) { e.Points = nil }, "1 to 100 points are required"},
+		{"point name", func(e *Endpoint) { e.Points[0].Name = "Temp" }, `invalid point name "Temp", expected 1 to 64 lowercase letters, digits or underscores`},
+		{"duplicate", func(e *Endpoint) { e.Points = append(e.Points, e.Points[0]) }, `duplicate point name "temperature"`},
+		{"register", func(e *Endpoint) { e.Points[0].Register = "" }, `point "temperature": invalid register "", expected one of coil, discrete_input, holding_register, input_register`},
+		{"type", func(e *Endpoint) { e.Points[0].Type = "float64" }, `point "temperature": invalid type "float64", expected one of bool, int16, uint16, int32, uint32, float32`},
+		{"coil type", func(e *Endpoint) { e.Points[0].Register = comm.ModbusCoil; e.Points[0].Type = comm.ModbusInt16 }, `point "temperature": coil values are bool`},
+		{"node id", func(e *Endpoint) { e.Points[0].NodeID = "i=1" }, `point "temperature": node_id is only valid for opcua`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := modbus()
+			tc.modify(&e)
+			assert.EqualError(t, e.Validate(), tc.wantErr)
+		})
+	}
+}
+
+func TestPoint(t *testing.T) {
+	assert.Equal(t, 21.5, Point{}.Value(21.5))
+	assert.Equal(t, 19.5, Point{Scale: 0.1, Offset: -2}.Value(215))
+
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	e := &Endpoint{IntervalSec: 60}
+	assert.True(t, e.Due(now))
+	polled := now.Add(-59 * time.Second)
+	e.LastPolledAt = &polled
+	assert.False(t, e.Due(now))
+	assert.True(t, e.Due(now.Add(time.Second)))
+}
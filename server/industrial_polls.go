@@ -0,0 +1,228 @@
+package chserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IOTech17/neo-rport/server/alerts"
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/industrial"
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/random"
+)
+
+const (
+	industrialPollingInterval = 10 * time.Second
+	industrialPollTimeout     = 30 * time.Second
+	// industrialResultMargin is added to the timeout of the poll for sending the result by the client.
+	industrialResultMargin = 10 * time.Second
+	// maxIndustrialPollRuns limits the endpoints polled at the same time
+	maxIndustrialPollRuns = 8
+)
+
+type pendingIndustrialPoll struct {
+	clientID string
+	done     chan comm.IndustrialPollResult
+}
+
+// industrialWaiters holds the polls of industrial endpoints run by the clients until the result arrives.
+type industrialWaiters struct {
+	m  map[string]*pendingIndustrialPoll
+	mu sync.Mutex
+}
+
+func newIndustrialWaiters() *industrialWaiters {
+	return &industrialWaiters{
+		m: make(map[string]*pendingIndustrialPoll),
+	}
+}
+
+func (w *industrialWaiters) add(clientID string) (string, chan comm.IndustrialPollResult) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	id := random.Hex(16)
+	done := make(chan comm.IndustrialPollResult, 1)
+	w.m[id] = &pendingIndustrialPoll{clientID: clientID, done: done}
+	return id, done
+}
+
+// take removes the pending poll, it returns nil if the id is unknown or belongs to another client.
+func (w *industrialWaiters) take(id, clientID string) *pendingIndustrialPoll {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	p := w.m[id]
+	if p == nil || p.clientID != clientID {
+		return nil
+	}
+	delete(w.m, id)
+	return p
+}
+
+func (w *industrialWaiters) del(id string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.m, id)
+}
+
+// pollIndustrialEndpoint reads the points of the endpoint through its client and saves the values converted by the
+// scale and the offset of the points. Errors of the poll are saved as error of the endpoint and returned in the
+// result, the returned error is set if the client couldn't be asked.
+func (s *Server) pollIndustrialEndpoint(ctx context.Context, endpoint *industrial.Endpoint) (comm.IndustrialPollResult, error) {
+	client, err := s.clientService.GetActiveByID(endpoint.ClientID)
+	if err != nil {
+		return comm.IndustrialPollResult{}, err
+	}
+	if client == nil || !client.IsConnected() {
+		return comm.IndustrialPollResult{}, errors2.APIError{
+			HTTPStatus: http.StatusNotFound,
+			Message:    fmt.Sprintf("active client with id %s not found", endpoint.ClientID),
+		}
+	}
+
+	id, done := s.industrialPolls.add(client.GetID())
+	defer s.industrialPolls.del(id)
+
+	err = comm.SendRequestAndGetResponse(client.GetConnection(), comm.RequestTypePollIndustrial, endpoint.PollRequest(id, industrialPollTimeout), nil, s.Logger)
+	if err != nil {
+		if strings.Contains(err.Error(), "unknown request") {
+			err = errors.New("client does not support industrial polling")
+		}
+		return comm.IndustrialPollResult{}, errors2.APIError{
+			HTTPStatus: http.StatusConflict,
+			Err:        fmt.Errorf("failed to poll industrial endpoint through client %s: %v", client.GetID(), err),
+		}
+	}
+
+	var result comm.IndustrialPollResult
+	select {
+	case result = <-done:
+	case <-time.After(industrialPollTimeout + industrialResultMargin):
+		return comm.IndustrialPollResult{}, errors2.APIError{
+			HTTPStatus: http.StatusGatewayTimeout,
+			Message:    "timeout waiting for the result of the client",
+		}
+	case <-ctx.Done():
+		return comm.IndustrialPollResult{}, ctx.Err()
+	}
+
+	now := time.Now().UTC()
+	values := make([]industrial.PointValue, 0, len(result.Values))
+	converted := make(map[string]float64, len(result.Values))
+	for _, p := range endpoint.Points {
+		raw, ok := result.Values[p.Name]
+		if !ok {
+			continue
+		}
+		v := p.Value(raw)
+		converted[p.Name] = v
+		values = append(values, industrial.PointValue{
+			EndpointID: endpoint.ID,
+			ClientID:   endpoint.ClientID,
+			Point:      p.Name,
+			Timestamp:  now,
+			Value:      v,
+		})
+	}
+	result.Values = converted
+
+	if err := s.industrial.SavePoll(ctx, endpoint, now, values, industrialPollError(result)); err != nil {
+		s.Errorf("Failed to save the poll of industrial endpoint %s: %v", endpoint.ID, err)
+	}
+	if len(converted) > 0 {
+		// values are kept for rules on points until the next poll is overdue
+		maxAge := 2 * endpoint.Interval()
+		if maxAge < alerts.MaxMeasurementAge {
+			maxAge = alerts.MaxMeasurementAge
+		}
+		s.groupEvaluator.PutPoints(endpoint.ClientID, converted, now.Add(maxAge))
+	}
+	return result, nil
+}
+
+// industrialPollError returns the error of the poll, or the errors of the points sorted by name.
+func industrialPollError(result comm.IndustrialPollResult) string {
+	if result.Error != "" {
+		return result.Error
+	}
+	names := make([]string, 0, len(result.Errors))
+	for name := range result.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	errs := make([]string, 0, len(names))
+	for _, name := range names {
+		errs = append(errs, fmt.Sprintf("%s: %s", name, result.Errors[name]))
+	}
+	return strings.Join(errs, "; ")
+}
+
+// receiveIndustrialResult passes the result of a poll of an industrial endpoint to the waiting request.
+func (cl *ClientListener) receiveIndustrialResult(clientID string, payload []byte) error {
+	var result comm.IndustrialPollResult
+	if err := json.Unmarshal(payload, &result); err != nil {
+		return fmt.Errorf("failed to decode %T: %v", result, err)
+	}
+	p := cl.server.industrialPolls.take(result.ID, clientID)
+	if p == nil {
+		return errors.New("industrial poll not requested or expired")
+	}
+	p.done <- result
+	return nil
+}
+
+// industrialPollingTask polls the industrial endpoints whose interval passed since their last poll. Endpoints of
+// disconnected clients are skipped.
+type industrialPollingTask struct {
+	log *logger.Logger
+	s   *Server
+}
+
+func (t *industrialPollingTask) Run(ctx context.Context) error {
+	endpoints, err := t.s.industrial.ListAllEndpoints(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	sem := make(chan struct{}, maxIndustrialPollRuns)
+	wg := sync.WaitGroup{}
+	for _, endpoint := range endpoints {
+		if !endpoint.Due(now) {
+			continue
+		}
+		client, err := t.s.clientService.GetActiveByID(endpoint.ClientID)
+		if err != nil || client == nil || !client.IsConnected() {
+			continue
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(endpoint *industrial.Endpoint) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			result, err := t.s.pollIndustrialEndpoint(ctx, endpoint)
+			if err != nil {
+				t.log.Debugf("Failed to poll industrial endpoint %s: %v", endpoint.ID, err)
+				if err := t.s.industrial.SavePoll(ctx, endpoint, time.Now(), nil, err.Error()); err != nil {
+					t.log.Errorf("Failed to save the error of industrial endpoint %s: %v", endpoint.ID, err)
+				}
+				return
+			}
+			if result.Error != "" {
+				t.log.Debugf("Industrial endpoint %s of client %s failed: %s", endpoint.ID, endpoint.ClientID, result.Error)
+			}
+		}(endpoint)
+	}
+	wg.Wait()
+	return nil
+}
@@ -0,0 +1,296 @@
+// Package installers generates client installers with the connection settings of the server baked in.
+package installers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+const (
+	FormatShell      = "sh"
+	FormatPowerShell = "ps1"
+	FormatConfig     = "conf"
+)
+
+var Formats = []string{FormatShell, FormatPowerShell, FormatConfig}
+
+// Options are the settings written to the rport.conf of the installed client.
+type Options struct {
+	ServerURL       string
+	FallbackServers []string
+	Fingerprint     string
+	ClientAuthID    string
+	Password        string
+	// ClientID is only set if the server requires the client id to equal the client auth id
+	ClientID string
+	Tags     []string
+	Labels   map[string]string
+	// DownloadURL points to the client release archive, {version}, {os}, {arch} and {ext} are replaced
+	DownloadURL string
+	Version     string
+	// Windows selects the windows paths for FormatConfig, the installer scripts imply the OS
+	Windows bool
+}
+
+type Installer struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
+type configTemplateData struct {
+	Options
+	DataDir string
+	LogFile string
+}
+
+// Generate renders the installer of the given format.
+func Generate(format string, o Options) (*Installer, error) {
+	if err := ValidateLabels(o.Labels); err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case FormatShell:
+		conf, err := renderConfig(o, false)
+		if err != nil {
+			return nil, err
+		}
+		content, err := render(shellTemplate, map[string]interface{}{
+			"Options":     o,
+			"DownloadURL": strings.ReplaceAll(o.DownloadURL, "{version}", o.Version),
+			"Config":      conf,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &Installer{Filename: "rport-installer.sh", ContentType: "text/x-shellscript", Content: content}, nil
+	case FormatPowerShell:
+		conf, err := renderConfig(o, true)
+		if err != nil {
+			return nil, err
+		}
+		content, err := render(powerShellTemplate, map[string]interface{}{
+			"Options":     o,
+			"DownloadURL": strings.ReplaceAll(o.DownloadURL, "{version}", o.Version),
+			"Config":      conf,
+		})
+		if err != nil {
+			return nil, err
+		}
+		// powershell expects windows line endings
+		content = bytes.ReplaceAll(content, []byte("\n"), []byte("\r\n"))
+		return &Installer{Filename: "rport-installer.ps1", ContentType: "text/plain", Content: content}, nil
+	case FormatConfig:
+		conf, err := renderConfig(o, o.Windows)
+		if err != nil {
+			return nil, err
+		}
+		return &Installer{Filename: "rport.conf", ContentType: "text/plain", Content: []byte(conf)}, nil
+	}
+	return nil, fmt.Errorf("invalid format %q, expected one of: %s", format, strings.Join(Formats, ", "))
+}
+
+// ValidateLabels rejects label keys the client can't read from its config file, the config reader splits keys on dots.
+func ValidateLabels(labels map[string]string) error {
+	for k := range labels {
+		if k == "" || strings.Contains(k, ".") {
+			return fmt.Errorf("invalid label %q, label names must not be empty or contain dots", k)
+		}
+	}
+	return nil
+}
+
+func renderConfig(o Options, windows bool) (string, error) {
+	data := configTemplateData{
+		Options: o,
+		DataDir: "/var/lib/rport",
+		LogFile: "/var/log/rport/rport.log",
+	}
+	if windows {
+		data.DataDir = `C:\Program Files\rport`
+		data.LogFile = `C:\Program Files\rport\rport.log`
+	}
+	content, err := render(configTemplate, data)
+	return string(content), err
+}
+
+func render(tmpl *template.Template, data interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var funcs = template.FuncMap{
+	"toml":        tomlValue,
+	"tomlTable":   tomlTable,
+	"shellQuote":  shellQuote,
+	"psQuote":     psQuote,
+	"hasContents": func(s []string) bool { return len(s) > 0 },
+}
+
+// tomlValue encodes strings and string lists, a json string is a valid toml basic string.
+func tomlValue(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func tomlTable(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, tomlValue(k)+" = "+tomlValue(m[k]))
+	}
+	return "{ " + strings.Join(pairs, ", ") + " }"
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func psQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+var configTemplate = template.Must(template.New("rport.conf").Funcs(funcs).Parse(`# rport client configuration, generated by rportd {{ .Version }}.
+# All options are explained in rport.example.conf.
+
+[client]
+  server = {{ toml .ServerURL }}
+{{- if hasContents .FallbackServers }}
+  fallback_servers = {{ toml .FallbackServers }}
+{{- end }}
+{{- if .Fingerprint }}
+  fingerprint = {{ toml .Fingerprint }}
+{{- end }}
+  auth = {{ toml (printf "%s:%s" .ClientAuthID .Password) }}
+{{- if .ClientID }}
+  id = {{ toml .ClientID }}
+{{- else }}
+  use_system_id = true
+{{- end }}
+  use_hostname = true
+{{- if hasContents .Tags }}
+  tags = {{ toml .Tags }}
+{{- end }}
+{{- if .Labels }}
+  labels = {{ tomlTable .Labels }}
+{{- end }}
+  data_dir = {{ toml .DataDir }}
+
+[logging]
+  log_file = {{ toml .LogFile }}
+  log_level = "info"
+`))
+
+var shellTemplate = template.Must(template.New("rport-installer.sh").Funcs(funcs).Parse(`#!/bin/sh
+# Installs the rport client as a service and connects it to {{ .Options.ServerURL }}.
+# Generated by rportd {{ .Options.Version }}. Run as root.
+set -e
+
+if [ "$(id -u)" -ne 0 ]; then
+  echo "This script must be run as root." >&2
+  exit 1
+fi
+if [ "$(uname -s)" != "Linux" ]; then
+  echo "This script supports Linux only, download the rport.conf for other systems." >&2
+  exit 1
+fi
+
+case "$(uname -m)" in
+  x86_64 | amd64) ARCH=x86_64 ;;
+  i386 | i686) ARCH=i386 ;;
+  aarch64 | arm64) ARCH=arm64 ;;
+  armv7*) ARCH=armv7 ;;
+  armv6*) ARCH=armv6 ;;
+  *)
+    echo "Unsupported architecture $(uname -m)." >&2
+    exit 1
+    ;;
+esac
+
+DOWNLOAD_URL=$(echo {{ shellQuote .DownloadURL }} | sed -e "s/{os}/linux/g" -e "s/{arch}/${ARCH}/g" -e "s/{ext}/tar.gz/g")
+TMP_DIR=$(mktemp -d)
+trap 'rm -rf "${TMP_DIR}"' EXIT
+
+echo "Downloading ${DOWNLOAD_URL}"
+if command -v curl >/dev/null 2>&1; then
+  curl -fsSL -o "${TMP_DIR}/rport.tar.gz" "${DOWNLOAD_URL}"
+else
+  wget -q -O "${TMP_DIR}/rport.tar.gz" "${DOWNLOAD_URL}"
+fi
+tar -xzf "${TMP_DIR}/rport.tar.gz" -C "${TMP_DIR}" rport
+install -m 0755 "${TMP_DIR}/rport" /usr/local/bin/rport
+
+if ! id rport >/dev/null 2>&1; then
+  useradd -r -d /var/lib/rport -s /bin/false -U rport
+fi
+mkdir -p /etc/rport /var/lib/rport /var/log/rport
+chown rport /var/lib/rport /var/log/rport
+
+cat >/etc/rport/rport.conf <<'RPORT_CONF'
+{{ .Config -}}
+RPORT_CONF
+chown rport /etc/rport/rport.conf
+chmod 0600 /etc/rport/rport.conf
+
+if [ -f /etc/systemd/system/rport.service ] || [ -f /etc/init.d/rport ]; then
+  rport --service stop >/dev/null 2>&1 || true
+  rport --service uninstall >/dev/null 2>&1 || true
+fi
+rport --service install --service-user rport --config /etc/rport/rport.conf
+rport --service start
+echo "rport client installed and started."
+`))
+
+var powerShellTemplate = template.Must(template.New("rport-installer.ps1").Funcs(funcs).Parse(`# Installs the rport client as a service and connects it to {{ .Options.ServerURL }}.
+# Generated by rportd {{ .Options.Version }}. Run as administrator.
+$ErrorActionPreference = 'Stop'
+
+$principal = New-Object Security.Principal.WindowsPrincipal([Security.Principal.WindowsIdentity]::GetCurrent())
+if (-not $principal.IsInRole([Security.Principal.WindowsBuiltInRole]::Administrator)) {
+    throw 'This script must be run as administrator.'
+}
+
+$installDir = 'C:\Program Files\rport'
+$downloadURL = {{ psQuote .DownloadURL }}.Replace('{os}', 'windows').Replace('{arch}', 'x86_64').Replace('{ext}', 'zip')
+$tmpDir = Join-Path ([IO.Path]::GetTempPath()) ('rport-' + [guid]::NewGuid())
+New-Item -ItemType Directory -Path $tmpDir | Out-Null
+
+try {
+    Write-Output "Downloading $downloadURL"
+    [Net.ServicePointManager]::SecurityProtocol = [Net.SecurityProtocolType]::Tls12
+    Invoke-WebRequest -Uri $downloadURL -OutFile "$tmpDir\rport.zip" -UseBasicParsing
+    Expand-Archive -Path "$tmpDir\rport.zip" -DestinationPath $tmpDir -Force
+
+    New-Item -ItemType Directory -Path $installDir -Force | Out-Null
+    if (Get-Service -Name rport -ErrorAction SilentlyContinue) {
+        & "$installDir\rport.exe" --service stop
+        & "$installDir\rport.exe" --service uninstall
+    }
+    Copy-Item "$tmpDir\rport.exe" "$installDir\rport.exe" -Force
+
+    $config = @'
+{{ .Config -}}
+'@
+    # write utf-8 without byte order mark
+    [IO.File]::WriteAllText("$installDir\rport.conf", $config)
+
+    & "$installDir\rport.exe" --service install --config "$installDir\rport.conf"
+    & "$installDir\rport.exe" --service start
+    Write-Output 'rport client installed and started.'
+} finally {
+    Remove-Item $tmpDir -Recurse -Force
+}
+`))
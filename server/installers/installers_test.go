@@ -0,0 +1,111 @@
+package installers
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	chshare "github.com/IOTech17/neo-rport/share"
+	"github.com/IOTech17/neo-rport/share/clientconfig"
+)
+
+var testOptions = Options{
+	ServerURL:       "https://rport.example.com",
+	FallbackServers: []string{"https://fallback.example.com"},
+	Fingerprint:     "36:98:56:12",
+	ClientAuthID:    "installer-abc",
+	Password:        `pa"ss'word`,
+	Tags:            []string{"edge", "it's quoted"},
+	Labels:          map[string]string{"site": "berlin", "room no": "1"},
+	DownloadURL:     "https://downloads.example.com/{version}/rport_{os}_{arch}.{ext}",
+	Version:         "1.2.3",
+}
+
+func TestGenerateConfig(t *testing.T) {
+	for _, windows := range []bool{false, true} {
+		opts := testOptions
+		opts.Windows = windows
+
+		installer, err := Generate(FormatConfig, opts)
+		require.NoError(t, err)
+		assert.Equal(t, "rport.conf", installer.Filename)
+
+		v := viper.New()
+		v.SetConfigType("toml")
+		cfg := &clientconfig.Config{}
+		require.NoError(t, chshare.DecodeViperConfig(v, cfg, bytes.NewReader(installer.Content)))
+
+		assert.Equal(t, "https://rport.example.com", cfg.Client.Server)
+		assert.Equal(t, []string{"https://fallback.example.com"}, cfg.Client.FallbackServers)
+		assert.Equal(t, "36:98:56:12", cfg.Client.Fingerprint)
+		assert.Equal(t, `installer-abc:pa"ss'word`, cfg.Client.Auth)
+		assert.True(t, cfg.Client.UseSystemID)
+		assert.Equal(t, "", cfg.Client.ID)
+		assert.Equal(t, []string{"edge", "it's quoted"}, cfg.Client.Tags)
+		assert.Equal(t, map[string]string{"site": "berlin", "room no": "1"}, cfg.Client.Labels)
+		if windows {
+			assert.Equal(t, `C:\Program Files\rport`, cfg.Client.DataDir)
+		} else {
+			assert.Equal(t, "/var/lib/rport", cfg.Client.DataDir)
+		}
+	}
+}
+
+func TestGenerateConfigWithClientID(t *testing.T) {
+	opts := testOptions
+	opts.ClientID = "installer-abc"
+
+	installer, err := Generate(FormatConfig, opts)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(installer.Content), `id = "installer-abc"`)
+	assert.NotContains(t, string(installer.Content), "use_system_id")
+}
+
+func TestGenerateShell(t *testing.T) {
+	installer, err := Generate(FormatShell, testOptions)
+	require.NoError(t, err)
+
+	script := string(installer.Content)
+	assert.Equal(t, "rport-installer.sh", installer.Filename)
+	assert.Contains(t, script, `echo 'https://downloads.example.com/1.2.3/rport_{os}_{arch}.{ext}'`)
+	assert.Contains(t, script, `auth = "installer-abc:pa\"ss'word"`+"\n  use_system_id")
+
+	if _, err := exec.LookPath("sh"); err == nil {
+		cmd := exec.Command("sh", "-n")
+		cmd.Stdin = strings.NewReader(script)
+		out, err := cmd.CombinedOutput()
+		assert.NoError(t, err, string(out))
+	}
+}
+
+func TestGeneratePowerShell(t *testing.T) {
+	installer, err := Generate(FormatPowerShell, testOptions)
+	require.NoError(t, err)
+
+	script := string(installer.Content)
+	assert.Equal(t, "rport-installer.ps1", installer.Filename)
+	assert.Contains(t, script, "$downloadURL = 'https://downloads.example.com/1.2.3/rport_{os}_{arch}.{ext}'")
+	assert.Contains(t, script, "data_dir = \"C:\\\\Program Files\\\\rport\"\r\n")
+	assert.NotContains(t, strings.ReplaceAll(script, "\r\n", ""), "\n")
+}
+
+func TestGenerateInvalidLabel(t *testing.T) {
+	opts := testOptions
+	opts.Labels = map[string]string{"kubernetes.cluster": "prod"}
+
+	_, err := Generate(FormatConfig, opts)
+
+	assert.EqualError(t, err, `invalid label "kubernetes.cluster", label names must not be empty or contain dots`)
+}
+
+func TestGenerateInvalidFormat(t *testing.T) {
+	_, err := Generate("msi", testOptions)
+
+	assert.EqualError(t, err, `invalid format "msi", expected one of: sh, ps1, conf`)
+}
@@ -0,0 +1,127 @@
+package installers
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+const (
+	PushSSH   = "ssh"
+	PushWinRM = "winrm"
+)
+
+var (
+	pushHostRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9.:-]*$`)
+	// pushUsernameRegex allows domain users of windows
+	pushUsernameRegex = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9._@\\-]*$`)
+)
+
+// PushOptions tell how a client already managed reaches a device to run an installer on it.
+type PushOptions struct {
+	Host     string
+	Username string
+	// Password or PrivateKey, only ssh supports keys
+	Password   string
+	PrivateKey string
+	// UseSSL connects to the https listener of WinRM
+	UseSSL bool
+	// Installer is the script generated for the device, FormatShell for ssh and FormatPowerShell for WinRM
+	Installer []byte
+}
+
+// GeneratePush renders a script that runs the installer on the device. The ssh script runs on linux and macOS, the
+// WinRM script on windows. All values are embedded base64 encoded, so they never need quoting. The scripts delete
+// themselves when started, they hold the credentials.
+func GeneratePush(method string, o PushOptions) (*Installer, error) {
+	if !pushHostRegex.MatchString(o.Host) {
+		return nil, fmt.Errorf("invalid host %q", o.Host)
+	}
+	if !pushUsernameRegex.MatchString(o.Username) {
+		return nil, fmt.Errorf("invalid username %q", o.Username)
+	}
+	if (o.Password == "") == (o.PrivateKey == "") {
+		return nil, errors.New("either a password or a private key is required")
+	}
+
+	data := map[string]interface{}{
+		"Host":       o.Host,
+		"Username":   o.Username,
+		"Password":   base64.StdEncoding.EncodeToString([]byte(o.Password)),
+		"PrivateKey": base64.StdEncoding.EncodeToString([]byte(o.PrivateKey)),
+		"UseKey":     o.PrivateKey != "",
+		"UseSSL":     o.UseSSL,
+		"Installer":  base64.StdEncoding.EncodeToString(o.Installer),
+	}
+
+	switch method {
+	case PushSSH:
+		content, err := render(sshPushTemplate, data)
+		if err != nil {
+			return nil, err
+		}
+		return &Installer{Filename: "rport-push.sh", ContentType: "text/x-shellscript", Content: content}, nil
+	case PushWinRM:
+		if o.PrivateKey != "" {
+			return nil, errors.New("WinRM requires a password")
+		}
+		content, err := render(winRMPushTemplate, data)
+		if err != nil {
+			return nil, err
+		}
+		content = []byte(strings.ReplaceAll(string(content), "\n", "\r\n"))
+		return &Installer{Filename: "rport-push.ps1", ContentType: "text/plain", Content: content}, nil
+	}
+	return nil, fmt.Errorf("invalid push method %q, expected %q or %q", method, PushSSH, PushWinRM)
+}
+
+var sshPushTemplate = template.Must(template.New("rport-push.sh").Funcs(funcs).Parse(`#!/bin/sh
+# Installs the rport client on {{ .Host }} by ssh.
+set -e
+rm -f "$0"
+
+TMP_DIR=$(mktemp -d)
+trap 'rm -rf "${TMP_DIR}"' EXIT
+umask 077
+
+echo '{{ .Installer }}' | base64 -d >"${TMP_DIR}/installer.sh"
+{{- if .UseKey }}
+echo '{{ .PrivateKey }}' | base64 -d >"${TMP_DIR}/key"
+set -- -i "${TMP_DIR}/key" -o IdentitiesOnly=yes -o BatchMode=yes
+{{- else }}
+echo '{{ .Password }}' | base64 -d >"${TMP_DIR}/password"
+cat >"${TMP_DIR}/askpass" <<RPORT_ASKPASS
+#!/bin/sh
+cat "${TMP_DIR}/password"
+RPORT_ASKPASS
+chmod 0700 "${TMP_DIR}/askpass"
+export SSH_ASKPASS="${TMP_DIR}/askpass" SSH_ASKPASS_REQUIRE=force DISPLAY="${DISPLAY:-none}"
+set -- -o PubkeyAuthentication=no -o NumberOfPasswordPrompts=1
+{{- end }}
+
+echo {{ shellQuote (printf "Connecting to %s@%s" .Username .Host) }}
+ssh "$@" -o StrictHostKeyChecking=accept-new -o ConnectTimeout=30 {{ shellQuote .Username }}@{{ shellQuote .Host }} \
+  'if [ "$(id -u)" -eq 0 ]; then sh -s; else sudo -n sh -s; fi' <"${TMP_DIR}/installer.sh"
+`))
+
+var winRMPushTemplate = template.Must(template.New("rport-push.ps1").Funcs(funcs).Parse(`# Installs the rport client on {{ .Host }} by WinRM.
+$ErrorActionPreference = 'Stop'
+Remove-Item -LiteralPath $PSCommandPath -Force
+
+function Decode($value) {
+    return [Text.Encoding]::UTF8.GetString([Convert]::FromBase64String($value))
+}
+
+$installer = Decode '{{ .Installer }}'
+$password = ConvertTo-SecureString (Decode '{{ .Password }}') -AsPlainText -Force
+$credential = New-Object System.Management.Automation.PSCredential({{ psQuote .Username }}, $password)
+
+Write-Output {{ psQuote (printf "Connecting to %s@%s" .Username .Host) }}
+Invoke-Command -ComputerName {{ psQuote .Host }} -Credential $credential{{ if .UseSSL }} -UseSSL{{ end }} -ArgumentList $installer -ScriptBlock {
+    param($installer)
+    Invoke-Expression $installer
+}
+`))
@@ -0,0 +1,189 @@
+// Package killswitch implements runtime switches disabling risky capabilities for the whole fleet, e.g. all command
+// execution during an incident. The switches are persisted in the data dir and survive restarts.
+package killswitch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+)
+
+const FileName = "kill-switches.json"
+
+const (
+	CapabilityCommands = "commands"
+	CapabilityScripts  = "scripts"
+	CapabilityUploads  = "uploads"
+	CapabilityTunnels  = "tunnels"
+)
+
+// Capabilities are the capabilities that can be disabled.
+var Capabilities = []string{CapabilityCommands, CapabilityScripts, CapabilityUploads, CapabilityTunnels}
+
+var descriptions = map[string]string{
+	CapabilityCommands: "command execution",
+	CapabilityScripts:  "script execution",
+	CapabilityUploads:  "file uploads",
+	CapabilityTunnels:  "tunnel creation",
+}
+
+func IsCapability(capability string) bool {
+	_, ok := descriptions[capability]
+	return ok
+}
+
+// JobCapability returns the capability needed to run a command or a script job.
+func JobCapability(isScript bool) string {
+	if isScript {
+		return CapabilityScripts
+	}
+	return CapabilityCommands
+}
+
+type Switch struct {
+	Capability string     `json:"capability"`
+	Disabled   bool       `json:"disabled"`
+	Reason     string     `json:"reason,omitempty"`
+	ChangedBy  string     `json:"changed_by,omitempty"`
+	ChangedAt  *time.Time `json:"changed_at,omitempty"`
+}
+
+type Store struct {
+	path string
+
+	mu       sync.RWMutex
+	switches map[string]Switch
+}
+
+// Open reads the switches from the data dir, all capabilities are enabled if none were stored.
+func Open(dataDir string) (*Store, error) {
+	s := &Store{
+		path:     filepath.Join(dataDir, FileName),
+		switches: make(map[string]Switch),
+	}
+
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kill switches: %w", err)
+	}
+	var switches []Switch
+	if err := json.Unmarshal(b, &switches); err != nil {
+		return nil, fmt.Errorf("invalid kill switches %s: %w", s.path, err)
+	}
+	for _, sw := range switches {
+		if IsCapability(sw.Capability) {
+			s.switches[sw.Capability] = sw
+		}
+	}
+	return s, nil
+}
+
+// List returns the switches of all capabilities.
+func (s *Store) List() []Switch {
+	result := make([]Switch, 0, len(Capabilities))
+	for _, c := range Capabilities {
+		result = append(result, s.Get(c))
+	}
+	return result
+}
+
+// Get returns the switch of the capability, it's enabled on a nil Store.
+func (s *Store) Get(capability string) Switch {
+	if s == nil {
+		return Switch{Capability: capability}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sw, ok := s.switches[capability]
+	if !ok {
+		return Switch{Capability: capability}
+	}
+	return sw
+}
+
+// Set changes the switch of the capability and persists all switches.
+func (s *Store) Set(capability string, disabled bool, reason, username string) (Switch, error) {
+	if !IsCapability(capability) {
+		return Switch{}, errors2.APIError{
+			Message:    fmt.Sprintf("unknown capability %q", capability),
+			HTTPStatus: http.StatusNotFound,
+		}
+	}
+
+	now := time.Now().UTC()
+	sw := Switch{
+		Capability: capability,
+		Disabled:   disabled,
+		ChangedBy:  username,
+		ChangedAt:  &now,
+	}
+	if disabled {
+		sw.Reason = reason
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev, hadPrev := s.switches[capability]
+	s.switches[capability] = sw
+	if err := s.save(); err != nil {
+		if hadPrev {
+			s.switches[capability] = prev
+		} else {
+			delete(s.switches, capability)
+		}
+		return Switch{}, err
+	}
+	return sw, nil
+}
+
+func (s *Store) save() error {
+	switches := make([]Switch, 0, len(s.switches))
+	for _, sw := range s.switches {
+		switches = append(switches, sw)
+	}
+	sort.Slice(switches, func(i, j int) bool {
+		return switches[i].Capability < switches[j].Capability
+	})
+	b, err := json.MarshalIndent(switches, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return fmt.Errorf("failed to save kill switches: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to save kill switches: %w", err)
+	}
+	return nil
+}
+
+// Check returns an error if the capability is disabled.
+func (s *Store) Check(capability string) error {
+	sw := s.Get(capability)
+	if !sw.Disabled {
+		return nil
+	}
+	msg := fmt.Sprintf("%s is disabled by an administrator", descriptions[capability])
+	if sw.Reason != "" {
+		msg += ": " + sw.Reason
+	}
+	return errors2.APIError{
+		Message:    msg,
+		HTTPStatus: http.StatusServiceUnavailable,
+	}
+}
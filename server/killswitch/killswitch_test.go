@@ -0,0 +1,52 @@
+package killswitch
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+)
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir)
+	require.NoError(t, err)
+
+	assert.NoError(t, s.Check(CapabilityCommands))
+	assert.Len(t, s.List(), len(Capabilities))
+
+	sw, err := s.Set(CapabilityCommands, true, "incident 42", "admin")
+	require.NoError(t, err)
+	assert.True(t, sw.Disabled)
+	assert.Equal(t, "admin", sw.ChangedBy)
+	assert.NotNil(t, sw.ChangedAt)
+
+	err = s.Check(CapabilityCommands)
+	require.Error(t, err)
+	assert.Equal(t, "command execution is disabled by an administrator: incident 42", err.Error())
+	assert.Equal(t, http.StatusServiceUnavailable, err.(errors2.APIError).HTTPStatus)
+	assert.NoError(t, s.Check(CapabilityScripts))
+
+	// persisted
+	reopened, err := Open(dir)
+	require.NoError(t, err)
+	assert.Equal(t, sw.Reason, reopened.Get(CapabilityCommands).Reason)
+	assert.Error(t, reopened.Check(CapabilityCommands))
+
+	sw, err = reopened.Set(CapabilityCommands, false, "ignored", "admin")
+	require.NoError(t, err)
+	assert.Empty(t, sw.Reason)
+	assert.NoError(t, reopened.Check(CapabilityCommands))
+
+	_, err = reopened.Set("shutdown", true, "", "admin")
+	assert.EqualError(t, err, `unknown capability "shutdown"`)
+}
+
+func TestNilStore(t *testing.T) {
+	var s *Store
+	assert.NoError(t, s.Check(CapabilityUploads))
+	assert.False(t, s.Get(CapabilityUploads).Disabled)
+}
@@ -0,0 +1,182 @@
+package chserver
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IOTech17/neo-rport/server/notifications"
+	"github.com/IOTech17/neo-rport/server/routes"
+	chshare "github.com/IOTech17/neo-rport/share"
+	"github.com/IOTech17/neo-rport/share/refs"
+	"github.com/IOTech17/neo-rport/share/security"
+)
+
+const lockoutNotificationType refs.IdentifiableType = "lockout"
+
+const (
+	// lockoutNotificationInterval is how often a user is notified about the lockouts of the account
+	lockoutNotificationInterval = time.Hour
+	unlockTokenTTL              = time.Hour
+	unlockTokenLength           = 32
+)
+
+// unlockRequest is the user and the IP address an unlock token lifts the bans of.
+type unlockRequest struct {
+	Username string
+	IP       string
+	expiry   time.Time
+}
+
+// lockouts keeps track of the lockout notifications sent and the unlock tokens they contain.
+type lockouts struct {
+	now func() time.Time
+
+	mu       sync.Mutex
+	notified map[string]time.Time
+	tokens   map[string]*unlockRequest
+}
+
+func newLockouts() *lockouts {
+	return &lockouts{
+		now:      time.Now,
+		notified: make(map[string]time.Time),
+		tokens:   make(map[string]*unlockRequest),
+	}
+}
+
+// shouldNotify returns true if no notification was sent for the key within the notification interval.
+func (l *lockouts) shouldNotify(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	for k, at := range l.notified {
+		if now.Sub(at) >= lockoutNotificationInterval {
+			delete(l.notified, k)
+		}
+	}
+	if _, ok := l.notified[key]; ok {
+		return false
+	}
+	l.notified[key] = now
+	return true
+}
+
+// newUnlockToken returns a token to lift the bans of the user and the IP address, the IP address is optional.
+func (l *lockouts) newUnlockToken(username, ip string) (string, error) {
+	token, err := security.NewRandomToken(unlockTokenLength)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate unlock token: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	for t, r := range l.tokens {
+		if now.After(r.expiry) {
+			delete(l.tokens, t)
+		}
+	}
+	l.tokens[token] = &unlockRequest{
+		Username: username,
+		IP:       ip,
+		expiry:   now.Add(unlockTokenTTL),
+	}
+	return token, nil
+}
+
+// getUnlockRequest returns nil if the token is unknown or expired.
+func (l *lockouts) getUnlockRequest(token string) *unlockRequest {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	r, ok := l.tokens[token]
+	if !ok || l.now().After(r.expiry) {
+		return nil
+	}
+	return r
+}
+
+func (l *lockouts) deleteUnlockToken(token string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.tokens, token)
+}
+
+// banUser bans a user after a failed authentication and notifies the user about it.
+func (al *APIListener) banUser(req *http.Request, username string) {
+	al.bannedUsers.Add(username)
+	al.notifyLockout(req, username)
+}
+
+// notifyLockout sends an email to a locked out user, if enabled. With 2fa on, the email contains a link to lift the
+// bans of the user and the IP address.
+func (al *APIListener) notifyLockout(req *http.Request, username string) {
+	if !al.config.API.NotifyLockouts || username == "" || al.lockouts == nil {
+		return
+	}
+
+	user, err := al.userService.GetByUsername(username)
+	if err != nil {
+		al.Errorf("Failed to get user %q to notify about a lockout: %v", username, err)
+		return
+	}
+	if user == nil || !strings.Contains(user.TwoFASendTo, "@") {
+		return
+	}
+
+	ip := chshare.RemoteIP(req)
+	ipBanned := al.bannedIPs != nil && al.bannedIPs.IsBanned(ip)
+	// a ban of the IP address is notified even if the user was notified before
+	key := username
+	if ipBanned {
+		key += "/" + ip
+	} else {
+		ip = ""
+	}
+	if !al.lockouts.shouldNotify(key) {
+		return
+	}
+
+	var unlockURL string
+	if al.config.API.IsTwoFAOn() || al.config.API.TotPEnabled {
+		token, err := al.lockouts.newUnlockToken(username, ip)
+		if err != nil {
+			al.Errorf("Failed to create unlock token for user %q: %v", username, err)
+			return
+		}
+		unlockURL = strings.TrimSuffix(al.config.API.BaseURL, "/") + apiPrefix(req.Context()) + routes.AuthRoutesPrefix +
+			routes.AuthUnlockRoute + "?token=" + url.QueryEscape(token)
+	}
+
+	notification := notifications.NotificationData{
+		Target:      "smtp",
+		Recipients:  []string{user.TwoFASendTo},
+		Subject:     fmt.Sprintf("Your %s account is locked", al.branding.Get().GetProductName()),
+		Content:     lockoutMessage(username, ip, req.UserAgent(), al.config.API.Address, al.lockouts.now(), unlockURL),
+		ContentType: notifications.ContentTypeTextPlain,
+		Severity:    notifications.SeverityCritical,
+	}
+	refID := refs.NewIdentifiable(lockoutNotificationType, username)
+	if _, err := al.notificationDigests.Dispatch(req.Context(), refID, notification); err != nil {
+		al.Errorf("Failed to send lockout notification to user %q: %v", username, err)
+	}
+}
+
+func lockoutMessage(username, bannedIP, userAgent, server string, at time.Time, unlockURL string) string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "The rport account %q is temporarily locked after a failed authentication.\n", username)
+	if bannedIP != "" {
+		fmt.Fprintf(b, "The IP address %s is banned after too many failed attempts.\n", bannedIP)
+	}
+	fmt.Fprintf(b, "\nServer: %s\n", server)
+	fmt.Fprintf(b, "Time: %s\n", at.UTC().Format(time.RFC1123))
+	fmt.Fprintf(b, "User agent: %s\n\n", userAgent)
+	if unlockURL != "" {
+		fmt.Fprintf(b, "If it was you, lift the lock with your second factor until %s:\n%s\n\n", at.Add(unlockTokenTTL).UTC().Format(time.RFC1123), unlockURL)
+	}
+	b.WriteString("If this wasn't you, change your password and inform your administrator.\n")
+	return b.String()
+}
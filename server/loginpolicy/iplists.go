@@ -0,0 +1,238 @@
+package loginpolicy
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Location is the geolocation of an ip address. Coordinates are optional.
+type Location struct {
+	Country   string
+	Latitude  float64
+	Longitude float64
+	HasCoords bool
+}
+
+type ipRange struct {
+	first    netip.Addr
+	last     netip.Addr
+	location Location
+}
+
+// IPRanges maps ip addresses to locations.
+type IPRanges struct {
+	ranges []ipRange
+}
+
+// LoadIPRanges reads a csv file with the columns first_ip,last_ip,country_code and optionally latitude,longitude.
+// This is the format of the free country database of db-ip.com, coordinates can be appended.
+func LoadIPRanges(path string) (*IPRanges, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.Comment = '#'
+
+	result := &IPRanges{}
+	for line := 1; ; line++ {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		rng, err := parseIPRange(record)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		result.ranges = append(result.ranges, rng)
+	}
+
+	sort.Slice(result.ranges, func(i, j int) bool {
+		return result.ranges[i].first.Less(result.ranges[j].first)
+	})
+	return result, nil
+}
+
+func parseIPRange(record []string) (ipRange, error) {
+	if len(record) != 3 && len(record) != 5 {
+		return ipRange{}, fmt.Errorf("expected 3 or 5 columns, got %d", len(record))
+	}
+	first, err := netip.ParseAddr(strings.TrimSpace(record[0]))
+	if err != nil {
+		return ipRange{}, err
+	}
+	last, err := netip.ParseAddr(strings.TrimSpace(record[1]))
+	if err != nil {
+		return ipRange{}, err
+	}
+	if first.Is4() != last.Is4() || last.Less(first) {
+		return ipRange{}, fmt.Errorf("invalid range %s - %s", first, last)
+	}
+
+	rng := ipRange{
+		first:    first,
+		last:     last,
+		location: Location{Country: strings.ToUpper(strings.TrimSpace(record[2]))},
+	}
+	if len(record) == 5 {
+		if rng.location.Latitude, err = strconv.ParseFloat(strings.TrimSpace(record[3]), 64); err != nil {
+			return ipRange{}, fmt.Errorf("invalid latitude: %w", err)
+		}
+		if rng.location.Longitude, err = strconv.ParseFloat(strings.TrimSpace(record[4]), 64); err != nil {
+			return ipRange{}, fmt.Errorf("invalid longitude: %w", err)
+		}
+		rng.location.HasCoords = true
+	}
+	return rng, nil
+}
+
+// Lookup returns false if the ip address is invalid or not in any range.
+func (r *IPRanges) Lookup(ip string) (Location, bool) {
+	if r == nil {
+		return Location{}, false
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return Location{}, false
+	}
+	addr = addr.Unmap()
+
+	// the first range starting after the address, the candidate is the one before
+	i := sort.Search(len(r.ranges), func(i int) bool {
+		return addr.Less(r.ranges[i].first)
+	})
+	if i == 0 {
+		return Location{}, false
+	}
+	rng := r.ranges[i-1]
+	if rng.first.Is4() != addr.Is4() || rng.last.Less(addr) {
+		return Location{}, false
+	}
+	return rng.location, true
+}
+
+// IPList is a list of ip addresses and networks read from files, e.g. the exit nodes of Tor or of VPN providers.
+// Files are read again when they change.
+type IPList struct {
+	paths []string
+
+	mu       sync.Mutex
+	modTimes []time.Time
+	addrs    map[netip.Addr]bool
+	prefixes []netip.Prefix
+}
+
+func NewIPList(paths []string) (*IPList, error) {
+	l := &IPList{paths: paths}
+	if err := l.reload(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Contains returns true if the ip address is in the list. If a file can't be read again, the previous content is used.
+func (l *IPList) Contains(ip string) (bool, error) {
+	if l == nil || len(l.paths) == 0 {
+		return false, nil
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false, nil
+	}
+	addr = addr.Unmap()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	reloadErr := l.reloadIfChanged()
+	if l.addrs[addr] {
+		return true, reloadErr
+	}
+	for _, p := range l.prefixes {
+		if p.Contains(addr) {
+			return true, reloadErr
+		}
+	}
+	return false, reloadErr
+}
+
+func (l *IPList) reloadIfChanged() error {
+	for i, path := range l.paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if !info.ModTime().Equal(l.modTimes[i]) {
+			return l.reload()
+		}
+	}
+	return nil
+}
+
+func (l *IPList) reload() error {
+	modTimes := make([]time.Time, len(l.paths))
+	addrs := make(map[netip.Addr]bool)
+	var prefixes []netip.Prefix
+	for i, path := range l.paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		modTimes[i] = info.ModTime()
+		if err := readIPList(path, addrs, &prefixes); err != nil {
+			return err
+		}
+	}
+
+	l.modTimes = modTimes
+	l.addrs = addrs
+	l.prefixes = prefixes
+	return nil
+}
+
+// readIPList reads one ip address or network per line, empty lines and lines starting with # are skipped.
+func readIPList(path string, addrs map[netip.Addr]bool, prefixes *[]netip.Prefix) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if strings.Contains(text, "/") {
+			prefix, err := netip.ParsePrefix(text)
+			if err != nil {
+				return fmt.Errorf("%s:%d: %w", path, line, err)
+			}
+			*prefixes = append(*prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(text)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		addrs[addr.Unmap()] = true
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,291 @@
+// Package loginpolicy decides about API logins by where they come from. Depending on rules per user group, logins
+// from a new country, logins implying an impossible travel since the previous login or logins through Tor or a VPN
+// are denied or require a step-up with 2FA.
+package loginpolicy
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+type Action string
+
+const (
+	ActionAllow  Action = ""
+	ActionStepUp Action = "step_up"
+	ActionDeny   Action = "deny"
+)
+
+const (
+	DefaultMaxSpeedKmh = 1000
+
+	// travelTolerance is the distance ignored for the impossible travel check, geolocation isn't exact.
+	travelTolerance = 100.0
+	earthRadiusKm   = 6371.0
+)
+
+func (a Action) Validate() error {
+	switch a {
+	case ActionAllow, ActionStepUp, ActionDeny:
+		return nil
+	}
+	return fmt.Errorf("invalid action %q, must be %q or %q", a, ActionStepUp, ActionDeny)
+}
+
+func (a Action) stricter(other Action) bool {
+	rank := map[Action]int{ActionAllow: 0, ActionStepUp: 1, ActionDeny: 2}
+	return rank[a] > rank[other]
+}
+
+// Rule applies to the users of the groups, to all users if no groups are given.
+type Rule struct {
+	Groups           []string `mapstructure:"groups"`
+	ImpossibleTravel Action   `mapstructure:"impossible_travel"`
+	NewCountry       Action   `mapstructure:"new_country"`
+	Anonymizer       Action   `mapstructure:"anonymizer"`
+}
+
+type Config struct {
+	IPRangesFile    string   `mapstructure:"ip_ranges_file"`
+	AnonymizerLists []string `mapstructure:"anonymizer_lists"`
+	MaxSpeedKmh     float64  `mapstructure:"max_speed_kmh"`
+	// TwoFAOnlyOnStepUp makes the configured 2FA optional, it's only required when a rule asks for a step-up.
+	TwoFAOnlyOnStepUp bool   `mapstructure:"two_fa_only_on_step_up"`
+	Rules             []Rule `mapstructure:"rules"`
+}
+
+func (c Config) Enabled() bool {
+	return len(c.Rules) > 0
+}
+
+// UsesStepUp returns true if any rule asks for a step-up.
+func (c Config) UsesStepUp() bool {
+	for _, r := range c.Rules {
+		if r.ImpossibleTravel == ActionStepUp || r.NewCountry == ActionStepUp || r.Anonymizer == ActionStepUp {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Config) Validate() error {
+	if !c.Enabled() {
+		if c.TwoFAOnlyOnStepUp {
+			return errors.New("two_fa_only_on_step_up requires rules")
+		}
+		return nil
+	}
+	if c.MaxSpeedKmh < 0 {
+		return errors.New("max_speed_kmh must not be negative")
+	}
+	if c.MaxSpeedKmh == 0 {
+		c.MaxSpeedKmh = DefaultMaxSpeedKmh
+	}
+
+	for i, r := range c.Rules {
+		for _, a := range []Action{r.ImpossibleTravel, r.NewCountry, r.Anonymizer} {
+			if err := a.Validate(); err != nil {
+				return fmt.Errorf("rules[%d]: %w", i, err)
+			}
+		}
+		if (r.ImpossibleTravel != ActionAllow || r.NewCountry != ActionAllow) && c.IPRangesFile == "" {
+			return fmt.Errorf("rules[%d]: impossible_travel and new_country require ip_ranges_file", i)
+		}
+		if r.Anonymizer != ActionAllow && len(c.AnonymizerLists) == 0 {
+			return fmt.Errorf("rules[%d]: anonymizer requires anonymizer_lists", i)
+		}
+	}
+	return nil
+}
+
+// Login is the login attempt to decide about.
+type Login struct {
+	Username string
+	Groups   []string
+	IP       string
+	Time     time.Time
+}
+
+// PreviousLogin is a successful login of the same user.
+type PreviousLogin struct {
+	IP   string
+	Time time.Time
+}
+
+// Decision is the strictest action of all checks that failed, with the reasons.
+type Decision struct {
+	Action  Action   `json:"action"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+func (d *Decision) add(action Action, reason string) {
+	if action == ActionAllow {
+		return
+	}
+	if action.stricter(d.Action) {
+		d.Action = action
+	}
+	d.Reasons = append(d.Reasons, reason)
+}
+
+type Engine struct {
+	config      Config
+	ipRanges    *IPRanges
+	anonymizers *IPList
+}
+
+// New returns nil if no rules are configured, a nil Engine allows all logins.
+func New(config Config) (*Engine, error) {
+	if !config.Enabled() {
+		return nil, nil
+	}
+
+	e := &Engine{config: config}
+	var err error
+	if config.IPRangesFile != "" {
+		e.ipRanges, err = LoadIPRanges(config.IPRangesFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load ip ranges: %w", err)
+		}
+	}
+	if len(config.AnonymizerLists) > 0 {
+		e.anonymizers, err = NewIPList(config.AnonymizerLists)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load anonymizer lists: %w", err)
+		}
+	}
+	return e, nil
+}
+
+// Evaluate checks the login against the rules of the groups of the user. The previous logins must be sorted by time,
+// the most recent first. An error is returned if an anonymizer list can't be read again, the decision is still
+// made with its previous content.
+func (e *Engine) Evaluate(login Login, previous []PreviousLogin) (Decision, error) {
+	if e == nil {
+		return Decision{}, nil
+	}
+
+	rule := e.ruleFor(login.Groups)
+	decision := Decision{}
+
+	var listErr error
+	if rule.Anonymizer != ActionAllow {
+		var anonymous bool
+		anonymous, listErr = e.anonymizers.Contains(login.IP)
+		if anonymous {
+			decision.add(rule.Anonymizer, fmt.Sprintf("%s is a Tor or VPN exit node", login.IP))
+		}
+	}
+
+	if rule.NewCountry == ActionAllow && rule.ImpossibleTravel == ActionAllow {
+		return decision, listErr
+	}
+	location, found := e.ipRanges.Lookup(login.IP)
+	if !found {
+		return decision, listErr
+	}
+
+	if rule.NewCountry != ActionAllow {
+		if country, isNew := e.isNewCountry(location, previous); isNew {
+			decision.add(rule.NewCountry, fmt.Sprintf("first login from country %s", country))
+		}
+	}
+	if rule.ImpossibleTravel != ActionAllow {
+		if reason, impossible := e.isImpossibleTravel(login, location, previous); impossible {
+			decision.add(rule.ImpossibleTravel, reason)
+		}
+	}
+	return decision, listErr
+}
+
+// ruleFor merges all rules matching the groups, taking the strictest action of each check.
+func (e *Engine) ruleFor(groups []string) Rule {
+	result := Rule{}
+	for _, r := range e.config.Rules {
+		if !matchesGroups(r.Groups, groups) {
+			continue
+		}
+		if r.ImpossibleTravel.stricter(result.ImpossibleTravel) {
+			result.ImpossibleTravel = r.ImpossibleTravel
+		}
+		if r.NewCountry.stricter(result.NewCountry) {
+			result.NewCountry = r.NewCountry
+		}
+		if r.Anonymizer.stricter(result.Anonymizer) {
+			result.Anonymizer = r.Anonymizer
+		}
+	}
+	return result
+}
+
+func matchesGroups(ruleGroups, userGroups []string) bool {
+	if len(ruleGroups) == 0 {
+		return true
+	}
+	for _, rg := range ruleGroups {
+		for _, ug := range userGroups {
+			if rg == ug {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isNewCountry returns false for the first login of a user and if the countries of the previous logins are unknown.
+func (e *Engine) isNewCountry(location Location, previous []PreviousLogin) (string, bool) {
+	known := false
+	for _, p := range previous {
+		prevLocation, found := e.ipRanges.Lookup(p.IP)
+		if !found {
+			continue
+		}
+		if strings.EqualFold(prevLocation.Country, location.Country) {
+			return "", false
+		}
+		known = true
+	}
+	return location.Country, known
+}
+
+// isImpossibleTravel compares the login with the most recent previous login.
+func (e *Engine) isImpossibleTravel(login Login, location Location, previous []PreviousLogin) (string, bool) {
+	if len(previous) == 0 || !location.HasCoords {
+		return "", false
+	}
+	last := previous[0]
+	if last.IP == login.IP {
+		return "", false
+	}
+	lastLocation, found := e.ipRanges.Lookup(last.IP)
+	if !found || !lastLocation.HasCoords {
+		return "", false
+	}
+
+	distance := distanceKm(lastLocation, location)
+	if distance <= travelTolerance {
+		return "", false
+	}
+	hours := login.Time.Sub(last.Time).Hours()
+	if hours > 0 && distance/hours <= e.config.MaxSpeedKmh {
+		return "", false
+	}
+	return fmt.Sprintf(
+		"impossible travel of %.0f km from %s in %s since the previous login from %s",
+		distance, lastLocation.Country, login.Time.Sub(last.Time).Round(time.Minute), last.IP,
+	), true
+}
+
+// distanceKm is the great-circle distance by the haversine formula.
+func distanceKm(a, b Location) float64 {
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	dLat := lat2 - lat1
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
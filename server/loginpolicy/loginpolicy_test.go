@@ -0,0 +1,224 @@
+package loginpolicy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testIPRanges = `# first_ip,last_ip,country,latitude,longitude
+10.0.0.0,10.0.0.255,DE,52.52,13.40
+10.0.1.0,10.0.1.255,de,48.14,11.58
+10.1.0.0,10.1.255.255,US,40.71,-74.01
+10.2.0.0,10.2.0.255,FR
+2001:db8::,2001:db8::ffff,NL,52.37,4.90
+`
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestIPRangesLookup(t *testing.T) {
+	ranges, err := LoadIPRanges(writeFile(t, "ranges.csv", testIPRanges))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		ip       string
+		expected Location
+		found    bool
+	}{
+		{ip: "10.0.0.7", expected: Location{Country: "DE", Latitude: 52.52, Longitude: 13.40, HasCoords: true}, found: true},
+		{ip: "10.0.1.255", expected: Location{Country: "DE", Latitude: 48.14, Longitude: 11.58, HasCoords: true}, found: true},
+		{ip: "::ffff:10.1.2.3", expected: Location{Country: "US", Latitude: 40.71, Longitude: -74.01, HasCoords: true}, found: true},
+		{ip: "10.2.0.1", expected: Location{Country: "FR"}, found: true},
+		{ip: "2001:db8::1", expected: Location{Country: "NL", Latitude: 52.37, Longitude: 4.90, HasCoords: true}, found: true},
+		{ip: "10.0.2.1"},
+		{ip: "9.255.255.255"},
+		{ip: "2001:db9::1"},
+		{ip: "invalid"},
+	}
+	for _, tc := range testCases {
+		location, found := ranges.Lookup(tc.ip)
+		assert.Equal(t, tc.found, found, tc.ip)
+		assert.Equal(t, tc.expected, location, tc.ip)
+	}
+}
+
+func TestLoadIPRangesInvalid(t *testing.T) {
+	_, err := LoadIPRanges(writeFile(t, "ranges.csv", "10.0.0.0,10.0.0.255\n"))
+	assert.ErrorContains(t, err, "ranges.csv:1: expected 3 or 5 columns, got 2")
+
+	_, err = LoadIPRanges(writeFile(t, "ranges.csv", "10.0.0.255,10.0.0.0,DE\n"))
+	assert.ErrorContains(t, err, "invalid range 10.0.0.255 - 10.0.0.0")
+}
+
+func TestIPListReloads(t *testing.T) {
+	path := writeFile(t, "tor.txt", "# tor exits\n192.0.2.1\n198.51.100.0/24\n")
+	list, err := NewIPList([]string{path})
+	require.NoError(t, err)
+
+	for ip, expected := range map[string]bool{"192.0.2.1": true, "198.51.100.77": true, "192.0.2.2": false} {
+		contains, err := list.Contains(ip)
+		require.NoError(t, err)
+		assert.Equal(t, expected, contains, ip)
+	}
+
+	require.NoError(t, os.WriteFile(path, []byte("192.0.2.2\n"), 0600))
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(path, later, later))
+
+	contains, err := list.Contains("192.0.2.2")
+	require.NoError(t, err)
+	assert.True(t, contains)
+	contains, err = list.Contains("192.0.2.1")
+	require.NoError(t, err)
+	assert.False(t, contains)
+}
+
+func TestConfigValidate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		config   Config
+		expected string
+	}{
+		{
+			name:   "disabled",
+			config: Config{},
+		},
+		{
+			name:     "step-up only without rules",
+			config:   Config{TwoFAOnlyOnStepUp: true},
+			expected: "two_fa_only_on_step_up requires rules",
+		},
+		{
+			name:     "invalid action",
+			config:   Config{IPRangesFile: "ranges.csv", Rules: []Rule{{NewCountry: "block"}}},
+			expected: `rules[0]: invalid action "block", must be "step_up" or "deny"`,
+		},
+		{
+			name:     "new country without ip ranges",
+			config:   Config{Rules: []Rule{{NewCountry: ActionDeny}}},
+			expected: "rules[0]: impossible_travel and new_country require ip_ranges_file",
+		},
+		{
+			name:     "anonymizer without lists",
+			config:   Config{Rules: []Rule{{Anonymizer: ActionDeny}}},
+			expected: "rules[0]: anonymizer requires anonymizer_lists",
+		},
+		{
+			name:   "valid",
+			config: Config{AnonymizerLists: []string{"tor.txt"}, Rules: []Rule{{Anonymizer: ActionStepUp}}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.config.Validate()
+			if tc.expected == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expected)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	config := Config{
+		IPRangesFile:    writeFile(t, "ranges.csv", testIPRanges),
+		AnonymizerLists: []string{writeFile(t, "tor.txt", "10.0.0.66\n")},
+		Rules: []Rule{
+			{NewCountry: ActionStepUp, ImpossibleTravel: ActionStepUp},
+			{Groups: []string{"Administrators"}, ImpossibleTravel: ActionDeny, Anonymizer: ActionDeny},
+		},
+	}
+	require.NoError(t, config.Validate())
+	engine, err := New(config)
+	require.NoError(t, err)
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	berlin := PreviousLogin{IP: "10.0.0.1", Time: now.Add(-2 * time.Hour)}
+
+	testCases := []struct {
+		name     string
+		login    Login
+		previous []PreviousLogin
+		expected Decision
+	}{
+		{
+			name:  "first login",
+			login: Login{IP: "10.1.0.1", Time: now},
+		},
+		{
+			name:     "same country, possible travel",
+			login:    Login{IP: "10.0.1.1", Time: now},
+			previous: []PreviousLogin{berlin},
+		},
+		{
+			name:     "new country and impossible travel",
+			login:    Login{IP: "10.1.0.1", Time: now},
+			previous: []PreviousLogin{berlin},
+			expected: Decision{Action: ActionStepUp, Reasons: []string{
+				"first login from country US",
+				"impossible travel of 6385 km from DE in 2h0m0s since the previous login from 10.0.0.1",
+			}},
+		},
+		{
+			name:     "stricter rule of the group",
+			login:    Login{IP: "10.1.0.1", Groups: []string{"Administrators"}, Time: now},
+			previous: []PreviousLogin{berlin},
+			expected: Decision{Action: ActionDeny, Reasons: []string{
+				"first login from country US",
+				"impossible travel of 6385 km from DE in 2h0m0s since the previous login from 10.0.0.1",
+			}},
+		},
+		{
+			name:     "travel possible after enough time",
+			login:    Login{IP: "10.1.0.1", Time: now},
+			previous: []PreviousLogin{{IP: "10.0.0.1", Time: now.Add(-10 * time.Hour)}, {IP: "10.1.0.2", Time: now.Add(-20 * time.Hour)}},
+		},
+		{
+			name:     "no coordinates",
+			login:    Login{IP: "10.2.0.1", Time: now},
+			previous: []PreviousLogin{berlin, {IP: "10.2.0.2", Time: now.Add(-48 * time.Hour)}},
+		},
+		{
+			name:     "anonymizer only for administrators",
+			login:    Login{IP: "10.0.0.66", Time: now},
+			previous: []PreviousLogin{berlin},
+		},
+		{
+			name:     "anonymizer",
+			login:    Login{IP: "10.0.0.66", Groups: []string{"Administrators"}, Time: now},
+			previous: []PreviousLogin{berlin},
+			expected: Decision{Action: ActionDeny, Reasons: []string{"10.0.0.66 is a Tor or VPN exit node"}},
+		},
+		{
+			name:     "unknown location",
+			login:    Login{IP: "192.0.2.1", Time: now},
+			previous: []PreviousLogin{berlin},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			decision, err := engine.Evaluate(tc.login, tc.previous)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, decision)
+		})
+	}
+}
+
+func TestNilEngine(t *testing.T) {
+	engine, err := New(Config{})
+	require.NoError(t, err)
+	assert.Nil(t, engine)
+
+	decision, err := engine.Evaluate(Login{IP: "10.0.0.1"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, ActionAllow, decision.Action)
+}
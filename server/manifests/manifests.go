@@ -0,0 +1,218 @@
+// Package manifests reads declarative resource definitions, e.g. mounted from a Kubernetes ConfigMap, which are
+// reconciled by the server into client groups, users and schedules.
+package manifests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	APIVersion = "rport.io/v1"
+
+	KindClientGroup = "ClientGroup"
+	KindUser        = "User"
+	KindSchedule    = "Schedule"
+)
+
+type Metadata struct {
+	Name string `json:"name"`
+}
+
+// Manifest is a single resource. The spec uses the same fields as the corresponding API resource.
+type Manifest struct {
+	APIVersion string          `json:"apiVersion"`
+	Kind       string          `json:"kind"`
+	Metadata   Metadata        `json:"metadata"`
+	Spec       json.RawMessage `json:"spec"`
+
+	// Source is the file the manifest was read from.
+	Source string `json:"-"`
+}
+
+// UserSpec defines a user. The password is only used when the user is created, PasswordFile allows to take it from
+// a mounted secret.
+type UserSpec struct {
+	Groups       []string `json:"groups"`
+	Password     string   `json:"password"`
+	PasswordFile string   `json:"password_file"`
+	TwoFASendTo  string   `json:"two_fa_send_to"`
+}
+
+func (s UserSpec) GetPassword() (string, error) {
+	if s.PasswordFile == "" {
+		return s.Password, nil
+	}
+	if s.Password != "" {
+		return "", errors.New("only one of password and password_file can be set")
+	}
+	content, err := os.ReadFile(s.PasswordFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read password file: %v", err)
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+// LoadDir reads all manifests from .yaml, .yml and .json files in the given directory. Files may contain
+// several yaml documents. Hidden entries are skipped, this includes the "..data" links of mounted ConfigMaps.
+func LoadDir(dir string) ([]*Manifest, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []*Manifest
+	seen := make(map[string]string)
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") || entry.IsDir() {
+			continue
+		}
+		switch filepath.Ext(name) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+
+		path := filepath.Join(dir, name)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		manifests, err := Parse(content)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		for _, m := range manifests {
+			m.Source = path
+			key := m.Kind + "/" + m.Metadata.Name
+			if other, ok := seen[key]; ok {
+				return nil, fmt.Errorf("%s: %s %q is already defined in %s", path, m.Kind, m.Metadata.Name, other)
+			}
+			seen[key] = path
+			res = append(res, m)
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return kindOrder(res[i].Kind) < kindOrder(res[j].Kind)
+	})
+	return res, nil
+}
+
+// Parse decodes and validates all manifests of a yaml or json document stream.
+func Parse(content []byte) ([]*Manifest, error) {
+	var res []*Manifest
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	for i := 0; ; i++ {
+		var doc map[string]interface{}
+		err := dec.Decode(&doc)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %v", i, err)
+		}
+		if doc == nil {
+			continue
+		}
+
+		// decode via json to reuse the json tags of the API resources
+		raw, err := json.Marshal(doc)
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %v", i, err)
+		}
+		m := &Manifest{}
+		if err := json.Unmarshal(raw, m); err != nil {
+			return nil, fmt.Errorf("document %d: %v", i, err)
+		}
+		if err := m.validate(); err != nil {
+			return nil, fmt.Errorf("document %d: %v", i, err)
+		}
+		res = append(res, m)
+	}
+	return res, nil
+}
+
+// DecodeSpec decodes the spec into the given resource, unknown fields are rejected to catch typos.
+func (m *Manifest) DecodeSpec(dest interface{}) error {
+	if len(m.Spec) == 0 {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(m.Spec))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dest); err != nil {
+		return fmt.Errorf("%s %q: invalid spec: %v", m.Kind, m.Metadata.Name, err)
+	}
+	return nil
+}
+
+func (m *Manifest) validate() error {
+	if m.APIVersion != APIVersion {
+		return fmt.Errorf("unsupported apiVersion %q, expected %q", m.APIVersion, APIVersion)
+	}
+	if kindOrder(m.Kind) < 0 {
+		return fmt.Errorf("unsupported kind %q, expected one of: %s, %s, %s", m.Kind, KindClientGroup, KindUser, KindSchedule)
+	}
+	if m.Metadata.Name == "" {
+		return fmt.Errorf("%s: metadata.name is required", m.Kind)
+	}
+	return nil
+}
+
+// kindOrder makes sure groups exist before schedules referencing them are applied.
+func kindOrder(kind string) int {
+	switch kind {
+	case KindClientGroup:
+		return 0
+	case KindUser:
+		return 1
+	case KindSchedule:
+		return 2
+	}
+	return -1
+}
+
+// State remembers the resources created from manifests, so pruning never deletes resources created by other means.
+type State struct {
+	ClientGroups []string `json:"client_groups"`
+	Users        []string `json:"users"`
+	// Schedules maps the manifest name to the schedule id.
+	Schedules map[string]string `json:"schedules"`
+}
+
+// LoadState reads the state from the given file, a missing file results in an empty state.
+func LoadState(path string) (*State, error) {
+	s := &State{Schedules: make(map[string]string)}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return s, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(content, s); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %v", path, err)
+	}
+	if s.Schedules == nil {
+		s.Schedules = make(map[string]string)
+	}
+	return s, nil
+}
+
+func (s *State) Save(path string) error {
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0600)
+}
@@ -0,0 +1,177 @@
+package manifests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		Name          string
+		Content       string
+		ExpectedKinds []string
+		ExpectedError string
+	}{
+		{
+			Name: "multiple yaml documents",
+			Content: `
+apiVersion: rport.io/v1
+kind: Schedule
+metadata:
+  name: uptime
+spec:
+  command: uptime
+---
+apiVersion: rport.io/v1
+kind: ClientGroup
+metadata:
+  name: web
+`,
+			ExpectedKinds: []string{KindSchedule, KindClientGroup},
+		},
+		{
+			Name:          "json",
+			Content:       `{"apiVersion": "rport.io/v1", "kind": "User", "metadata": {"name": "admin"}}`,
+			ExpectedKinds: []string{KindUser},
+		},
+		{
+			Name: "empty documents are skipped",
+			Content: `---
+---
+apiVersion: rport.io/v1
+kind: User
+metadata:
+  name: admin
+`,
+			ExpectedKinds: []string{KindUser},
+		},
+		{
+			Name: "unsupported api version",
+			Content: `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: rport
+`,
+			ExpectedError: `document 0: unsupported apiVersion "v1", expected "rport.io/v1"`,
+		},
+		{
+			Name: "unsupported kind",
+			Content: `
+apiVersion: rport.io/v1
+kind: Tunnel
+metadata:
+  name: ssh
+`,
+			ExpectedError: `document 0: unsupported kind "Tunnel", expected one of: ClientGroup, User, Schedule`,
+		},
+		{
+			Name: "missing name",
+			Content: `
+apiVersion: rport.io/v1
+kind: User
+`,
+			ExpectedError: `document 0: User: metadata.name is required`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			manifests, err := Parse([]byte(tc.Content))
+			if tc.ExpectedError != "" {
+				assert.EqualError(t, err, tc.ExpectedError)
+				return
+			}
+			require.NoError(t, err)
+
+			var kinds []string
+			for _, m := range manifests {
+				kinds = append(kinds, m.Kind)
+			}
+			assert.Equal(t, tc.ExpectedKinds, kinds)
+		})
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "schedules.yml", "apiVersion: rport.io/v1\nkind: Schedule\nmetadata:\n  name: uptime\n")
+	writeFile(t, dir, "groups.json", `{"apiVersion": "rport.io/v1", "kind": "ClientGroup", "metadata": {"name": "web"}}`)
+	writeFile(t, dir, "README.md", "not a manifest")
+	writeFile(t, dir, ".hidden.yaml", "invalid")
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "..data"), 0700))
+
+	manifests, err := LoadDir(dir)
+	require.NoError(t, err)
+
+	require.Len(t, manifests, 2)
+	assert.Equal(t, KindClientGroup, manifests[0].Kind)
+	assert.Equal(t, filepath.Join(dir, "groups.json"), manifests[0].Source)
+	assert.Equal(t, KindSchedule, manifests[1].Kind)
+}
+
+func TestLoadDirDuplicate(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "a.yaml", "apiVersion: rport.io/v1\nkind: User\nmetadata:\n  name: admin\n")
+	writeFile(t, dir, "b.yaml", "apiVersion: rport.io/v1\nkind: User\nmetadata:\n  name: admin\n")
+
+	_, err := LoadDir(dir)
+
+	assert.EqualError(t, err, filepath.Join(dir, "b.yaml")+`: User "admin" is already defined in `+filepath.Join(dir, "a.yaml"))
+}
+
+func TestDecodeSpec(t *testing.T) {
+	manifests, err := Parse([]byte(`
+apiVersion: rport.io/v1
+kind: User
+metadata:
+  name: admin
+spec:
+  groups: ["Administrators"]
+  passwort: typo
+`))
+	require.NoError(t, err)
+
+	spec := UserSpec{}
+	err = manifests[0].DecodeSpec(&spec)
+
+	assert.EqualError(t, err, `User "admin": invalid spec: json: unknown field "passwort"`)
+}
+
+func TestUserSpecGetPassword(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "password", "from-secret\n")
+
+	password, err := UserSpec{PasswordFile: filepath.Join(dir, "password")}.GetPassword()
+	require.NoError(t, err)
+	assert.Equal(t, "from-secret", password)
+
+	_, err = UserSpec{Password: "a", PasswordFile: filepath.Join(dir, "password")}.GetPassword()
+	assert.EqualError(t, err, "only one of password and password_file can be set")
+}
+
+func TestState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	state, err := LoadState(path)
+	require.NoError(t, err)
+	assert.Empty(t, state.ClientGroups)
+	assert.NotNil(t, state.Schedules)
+
+	state.ClientGroups = []string{"web"}
+	state.Schedules["uptime"] = "id-1"
+	require.NoError(t, state.Save(path))
+
+	loaded, err := LoadState(path)
+	require.NoError(t, err)
+	assert.Equal(t, state, loaded)
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0600))
+}
@@ -0,0 +1,354 @@
+package chserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/IOTech17/neo-rport/server/api/jobs/schedule"
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/cgroups"
+	"github.com/IOTech17/neo-rport/server/manifests"
+	"github.com/IOTech17/neo-rport/share/logger"
+)
+
+const manifestsStateFile = "manifests_state.json"
+
+// manifestsCreatedBy is set as creator of schedules created from manifests.
+const manifestsCreatedBy = "manifests"
+
+type ManifestUserService interface {
+	GetByUsername(username string) (*users.User, error)
+	Change(*users.User, string) error
+	Delete(string) error
+}
+
+type ManifestScheduleManager interface {
+	Get(ctx context.Context, id string) (*schedule.Schedule, error)
+	Create(ctx context.Context, s *schedule.Schedule, user string) (*schedule.Schedule, error)
+	Update(ctx context.Context, id string, s *schedule.Schedule) (*schedule.Schedule, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type scheduleSpec struct {
+	Schedule string `json:"schedule"`
+	Type     string `json:"type"`
+	schedule.Details
+}
+
+// ManifestsReconcileTask applies the manifests found in a directory, so client groups, users and schedules can be
+// managed declaratively, e.g. from Kubernetes ConfigMaps kept in git.
+type ManifestsReconcileTask struct {
+	*logger.Logger
+	dir       string
+	prune     bool
+	statePath string
+
+	clientGroups cgroups.ClientGroupProvider
+	users        ManifestUserService
+	schedules    ManifestScheduleManager
+
+	// onClientGroupsChanged is called after client groups were created, updated or deleted
+	onClientGroupsChanged func()
+}
+
+func NewManifestsReconcileTask(
+	l *logger.Logger,
+	dir string,
+	prune bool,
+	dataDir string,
+	clientGroups cgroups.ClientGroupProvider,
+	users ManifestUserService,
+	schedules ManifestScheduleManager,
+	onClientGroupsChanged func(),
+) *ManifestsReconcileTask {
+	return &ManifestsReconcileTask{
+		Logger:                l,
+		dir:                   dir,
+		prune:                 prune,
+		statePath:             filepath.Join(dataDir, manifestsStateFile),
+		clientGroups:          clientGroups,
+		users:                 users,
+		schedules:             schedules,
+		onClientGroupsChanged: onClientGroupsChanged,
+	}
+}
+
+func (t *ManifestsReconcileTask) Run(ctx context.Context) error {
+	all, err := manifests.LoadDir(t.dir)
+	if err != nil {
+		// don't touch anything based on an incomplete set of manifests
+		return fmt.Errorf("failed to load manifests: %v", err)
+	}
+
+	state, err := manifests.LoadState(t.statePath)
+	if err != nil {
+		return err
+	}
+
+	var errs []string
+	groupsChanged := false
+	wantedGroups := make(map[string]bool)
+	wantedUsers := make(map[string]bool)
+	wantedSchedules := make(map[string]bool)
+	for _, m := range all {
+		var changed bool
+		var err error
+		switch m.Kind {
+		case manifests.KindClientGroup:
+			wantedGroups[m.Metadata.Name] = true
+			changed, err = t.applyClientGroup(ctx, m)
+			groupsChanged = groupsChanged || changed
+		case manifests.KindUser:
+			wantedUsers[m.Metadata.Name] = true
+			changed, err = t.applyUser(m)
+		case manifests.KindSchedule:
+			wantedSchedules[m.Metadata.Name] = true
+			changed, err = t.applySchedule(ctx, m, state)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", m.Source, err))
+			continue
+		}
+		if changed {
+			t.Infof("%s %q applied from %s", m.Kind, m.Metadata.Name, m.Source)
+		}
+	}
+
+	if t.prune {
+		deleted, pruneErrs := t.pruneResources(ctx, state, wantedGroups, wantedUsers, wantedSchedules)
+		groupsChanged = groupsChanged || deleted
+		errs = append(errs, pruneErrs...)
+	}
+
+	state.ClientGroups = mergeManaged(state.ClientGroups, wantedGroups)
+	state.Users = mergeManaged(state.Users, wantedUsers)
+	if err := state.Save(t.statePath); err != nil {
+		errs = append(errs, fmt.Sprintf("failed to save manifests state: %v", err))
+	}
+
+	if groupsChanged && t.onClientGroupsChanged != nil {
+		t.onClientGroupsChanged()
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to apply manifests: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (t *ManifestsReconcileTask) applyClientGroup(ctx context.Context, m *manifests.Manifest) (bool, error) {
+	group := &cgroups.ClientGroup{}
+	if err := m.DecodeSpec(group); err != nil {
+		return false, err
+	}
+	if group.ID != "" && group.ID != m.Metadata.Name {
+		return false, fmt.Errorf("spec.id %q doesn't match metadata.name", group.ID)
+	}
+	group.ID = m.Metadata.Name
+	if group.Params == nil {
+		group.Params = &cgroups.ClientParams{}
+	}
+	if err := validateInputClientGroup(*group); err != nil {
+		return false, err
+	}
+
+	existing, err := t.clientGroups.Get(ctx, group.ID)
+	if err != nil {
+		return false, err
+	}
+	if existing == nil {
+		return true, t.clientGroups.Create(ctx, group)
+	}
+
+	if equalJSON(existing, group) {
+		return false, nil
+	}
+	return true, t.clientGroups.Update(ctx, group)
+}
+
+func (t *ManifestsReconcileTask) applyUser(m *manifests.Manifest) (bool, error) {
+	if t.users == nil {
+		return false, fmt.Errorf("users can't be managed by manifests with the current user provider")
+	}
+
+	spec := manifests.UserSpec{}
+	if err := m.DecodeSpec(&spec); err != nil {
+		return false, err
+	}
+	username := m.Metadata.Name
+
+	existing, err := t.users.GetByUsername(username)
+	if err != nil {
+		return false, err
+	}
+	if existing == nil {
+		password, err := spec.GetPassword()
+		if err != nil {
+			return false, err
+		}
+		return true, t.users.Change(&users.User{
+			Username:    username,
+			Password:    password,
+			Groups:      spec.Groups,
+			TwoFASendTo: spec.TwoFASendTo,
+		}, "")
+	}
+
+	// the password is only set on creation, so users can change it afterwards
+	change := &users.User{}
+	if !equalStringSets(existing.Groups, spec.Groups) {
+		change.Groups = spec.Groups
+		if change.Groups == nil {
+			change.Groups = []string{}
+		}
+	}
+	if spec.TwoFASendTo != "" && spec.TwoFASendTo != existing.TwoFASendTo {
+		change.TwoFASendTo = spec.TwoFASendTo
+	}
+	if change.Groups == nil && change.TwoFASendTo == "" {
+		return false, nil
+	}
+	return true, t.users.Change(change, username)
+}
+
+func (t *ManifestsReconcileTask) applySchedule(ctx context.Context, m *manifests.Manifest, state *manifests.State) (bool, error) {
+	spec := scheduleSpec{}
+	if err := m.DecodeSpec(&spec); err != nil {
+		return false, err
+	}
+	s := &schedule.Schedule{
+		Base: schedule.Base{
+			Name:     m.Metadata.Name,
+			Schedule: spec.Schedule,
+			Type:     spec.Type,
+		},
+		Details: spec.Details,
+	}
+
+	var existing *schedule.Schedule
+	if id, ok := state.Schedules[m.Metadata.Name]; ok {
+		var err error
+		existing, err = t.schedules.Get(ctx, id)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if existing == nil {
+		created, err := t.schedules.Create(ctx, s, manifestsCreatedBy)
+		if err != nil {
+			return false, err
+		}
+		state.Schedules[m.Metadata.Name] = created.ID
+		return true, nil
+	}
+
+	if existing.Schedule == s.Schedule && existing.Type == s.Type && equalJSON(existing.Details, s.Details) {
+		return false, nil
+	}
+	_, err := t.schedules.Update(ctx, existing.ID, s)
+	return err == nil, err
+}
+
+func (t *ManifestsReconcileTask) pruneResources(
+	ctx context.Context,
+	state *manifests.State,
+	wantedGroups, wantedUsers, wantedSchedules map[string]bool,
+) (groupsDeleted bool, errs []string) {
+	for name, id := range state.Schedules {
+		if wantedSchedules[name] {
+			continue
+		}
+		if err := t.schedules.Delete(ctx, id); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to delete schedule %q: %v", name, err))
+			continue
+		}
+		delete(state.Schedules, name)
+		t.Infof("Schedule %q deleted, it was removed from the manifests", name)
+	}
+
+	var keptUsers []string
+	for _, username := range state.Users {
+		if wantedUsers[username] || t.users == nil {
+			continue
+		}
+		if err := t.users.Delete(username); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to delete user %q: %v", username, err))
+			keptUsers = append(keptUsers, username)
+			continue
+		}
+		t.Infof("User %q deleted, it was removed from the manifests", username)
+	}
+	state.Users = keptUsers
+
+	var keptGroups []string
+	for _, id := range state.ClientGroups {
+		if wantedGroups[id] {
+			continue
+		}
+		if err := t.clientGroups.Delete(ctx, id); err != nil {
+			errs = append(errs, fmt.Sprintf("failed to delete client group %q: %v", id, err))
+			keptGroups = append(keptGroups, id)
+			continue
+		}
+		groupsDeleted = true
+		t.Infof("Client group %q deleted, it was removed from the manifests", id)
+	}
+	state.ClientGroups = keptGroups
+
+	return groupsDeleted, errs
+}
+
+// mergeManaged adds the currently wanted resources to the ones already known as managed.
+func mergeManaged(managed []string, wanted map[string]bool) []string {
+	res := make(map[string]bool, len(managed)+len(wanted))
+	for _, name := range managed {
+		res[name] = true
+	}
+	for name := range wanted {
+		res[name] = true
+	}
+
+	list := make([]string, 0, len(res))
+	for name := range res {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	return list
+}
+
+func equalJSON(a, b interface{}) bool {
+	aJSON, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	bJSON, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	var aVal, bVal interface{}
+	_ = json.Unmarshal(aJSON, &aVal)
+	_ = json.Unmarshal(bJSON, &bVal)
+	return reflect.DeepEqual(aVal, bVal)
+}
+
+func equalStringSets(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	set := make(map[string]bool, len(a))
+	for _, v := range a {
+		set[v] = true
+	}
+	for _, v := range b {
+		if !set[v] {
+			return false
+		}
+	}
+	return true
+}
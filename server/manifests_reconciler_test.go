@@ -0,0 +1,208 @@
+package chserver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	"github.com/IOTech17/neo-rport/server/api/jobs/schedule"
+	"github.com/IOTech17/neo-rport/server/api/users"
+)
+
+type mockManifestUsers struct {
+	users map[string]*users.User
+}
+
+func (m *mockManifestUsers) GetByUsername(username string) (*users.User, error) {
+	return m.users[username], nil
+}
+
+func (m *mockManifestUsers) Change(u *users.User, username string) error {
+	if username == "" {
+		m.users[u.Username] = u
+		return nil
+	}
+	existing := m.users[username]
+	if u.Groups != nil {
+		existing.Groups = u.Groups
+	}
+	if u.TwoFASendTo != "" {
+		existing.TwoFASendTo = u.TwoFASendTo
+	}
+	return nil
+}
+
+func (m *mockManifestUsers) Delete(username string) error {
+	delete(m.users, username)
+	return nil
+}
+
+type mockManifestSchedules struct {
+	schedules map[string]*schedule.Schedule
+	updates   int
+}
+
+func (m *mockManifestSchedules) Get(ctx context.Context, id string) (*schedule.Schedule, error) {
+	return m.schedules[id], nil
+}
+
+func (m *mockManifestSchedules) Create(ctx context.Context, s *schedule.Schedule, user string) (*schedule.Schedule, error) {
+	s.ID = fmt.Sprintf("id-%d", len(m.schedules)+1)
+	s.CreatedBy = user
+	m.schedules[s.ID] = s
+	return s, nil
+}
+
+func (m *mockManifestSchedules) Update(ctx context.Context, id string, s *schedule.Schedule) (*schedule.Schedule, error) {
+	s.ID = id
+	m.schedules[id] = s
+	m.updates++
+	return s, nil
+}
+
+func (m *mockManifestSchedules) Delete(ctx context.Context, id string) error {
+	delete(m.schedules, id)
+	return nil
+}
+
+const testManifests = `
+apiVersion: rport.io/v1
+kind: ClientGroup
+metadata:
+  name: web
+spec:
+  description: Web servers
+  params:
+    tag: ["web"]
+---
+apiVersion: rport.io/v1
+kind: User
+metadata:
+  name: alice
+spec:
+  groups: ["Administrators"]
+  password: secret-password
+---
+apiVersion: rport.io/v1
+kind: Schedule
+metadata:
+  name: uptime
+spec:
+  schedule: "*/5 * * * *"
+  type: command
+  command: uptime
+  group_ids: ["web"]
+`
+
+func TestManifestsReconcileTask(t *testing.T) {
+	ctx := context.Background()
+	manifestsDir := t.TempDir()
+	dataDir := t.TempDir()
+	manifestFile := filepath.Join(manifestsDir, "rport.yaml")
+	require.NoError(t, os.WriteFile(manifestFile, []byte(testManifests), 0600))
+
+	groupProvider := makeGroupsProvider(t, sqlite.DataSourceOptions{})
+	userService := &mockManifestUsers{users: map[string]*users.User{
+		"bob": {Username: "bob"},
+	}}
+	scheduleManager := &mockManifestSchedules{schedules: map[string]*schedule.Schedule{}}
+	groupsChanged := 0
+
+	task := NewManifestsReconcileTask(testLog, manifestsDir, true, dataDir, groupProvider, userService, scheduleManager, func() {
+		groupsChanged++
+	})
+
+	// create
+	require.NoError(t, task.Run(ctx))
+
+	group, err := groupProvider.Get(ctx, "web")
+	require.NoError(t, err)
+	require.NotNil(t, group)
+	assert.Equal(t, "Web servers", group.Description)
+	require.Contains(t, userService.users, "alice")
+	assert.Equal(t, []string{"Administrators"}, userService.users["alice"].Groups)
+	require.Len(t, scheduleManager.schedules, 1)
+	created := scheduleManager.schedules["id-1"]
+	assert.Equal(t, "uptime", created.Name)
+	assert.Equal(t, "uptime", created.Command)
+	assert.Equal(t, manifestsCreatedBy, created.CreatedBy)
+	assert.Equal(t, 1, groupsChanged)
+
+	// nothing changed
+	require.NoError(t, task.Run(ctx))
+	assert.Equal(t, 1, groupsChanged)
+	assert.Equal(t, 0, scheduleManager.updates)
+	assert.Len(t, scheduleManager.schedules, 1)
+
+	// update
+	updated := []byte(`
+apiVersion: rport.io/v1
+kind: ClientGroup
+metadata:
+  name: web
+spec:
+  description: All web servers
+---
+apiVersion: rport.io/v1
+kind: Schedule
+metadata:
+  name: uptime
+spec:
+  schedule: "*/10 * * * *"
+  type: command
+  command: uptime
+  group_ids: ["web"]
+`)
+	require.NoError(t, os.WriteFile(manifestFile, updated, 0600))
+	require.NoError(t, task.Run(ctx))
+
+	group, err = groupProvider.Get(ctx, "web")
+	require.NoError(t, err)
+	assert.Equal(t, "All web servers", group.Description)
+	assert.Equal(t, 2, groupsChanged)
+	assert.Equal(t, 1, scheduleManager.updates)
+	assert.Equal(t, "*/10 * * * *", scheduleManager.schedules["id-1"].Schedule)
+	// alice was removed from the manifests, bob was never managed
+	assert.NotContains(t, userService.users, "alice")
+	assert.Contains(t, userService.users, "bob")
+
+	// prune
+	require.NoError(t, os.Remove(manifestFile))
+	require.NoError(t, task.Run(ctx))
+
+	group, err = groupProvider.Get(ctx, "web")
+	require.NoError(t, err)
+	assert.Nil(t, group)
+	assert.Empty(t, scheduleManager.schedules)
+	assert.Equal(t, 3, groupsChanged)
+}
+
+func TestManifestsReconcileTaskInvalidManifests(t *testing.T) {
+	ctx := context.Background()
+	manifestsDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(manifestsDir, "group.yaml"), []byte(`
+apiVersion: rport.io/v1
+kind: ClientGroup
+metadata:
+  name: web
+spec:
+  descripton: typo
+`), 0600))
+
+	groupProvider := makeGroupsProvider(t, sqlite.DataSourceOptions{})
+	task := NewManifestsReconcileTask(testLog, manifestsDir, true, t.TempDir(), groupProvider, nil, nil, nil)
+
+	err := task.Run(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `ClientGroup "web": invalid spec: json: unknown field "descripton"`)
+
+	group, err := groupProvider.Get(ctx, "web")
+	require.NoError(t, err)
+	assert.Nil(t, group)
+}
@@ -0,0 +1,152 @@
+package chserver
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/jpillora/sizestr"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/IOTech17/neo-rport/server/cgroups"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/clients/meshtunnel"
+	chshare "github.com/IOTech17/neo-rport/share"
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+// findMeshDirectAddr returns an address of the mesh tunnel destination the source client is able to reach on
+// its own. An empty string is returned if no direct path was found and the traffic has to be relayed.
+func (s *Server) findMeshDirectAddr(mt *meshtunnel.MeshTunnel, source, target *clientdata.Client) string {
+	host, port, err := net.SplitHostPort(mt.Remote)
+	if err != nil {
+		return ""
+	}
+
+	var candidates []string
+	ip := net.ParseIP(host)
+	if host == "localhost" || (ip != nil && ip.IsLoopback()) {
+		// a service bound to the loopback of the target might still be reachable on its other addresses
+		for _, addr := range target.GetIPv4() {
+			candidates = append(candidates, net.JoinHostPort(addr, port))
+		}
+	} else {
+		candidates = append(candidates, mt.Remote)
+	}
+
+	for _, candidate := range candidates {
+		req := &comm.CheckPortRequest{
+			HostPort: candidate,
+			Timeout:  s.config.Server.CheckPortTimeout,
+		}
+		resp := &comm.CheckPortResponse{}
+		err := comm.SendRequestAndGetResponse(source.GetConnection(), comm.RequestTypeCheckPort, req, resp, s.Logger)
+		if err != nil {
+			s.Debugf("mesh tunnel %s: failed to check %s from client %s: %v", mt.ID, candidate, source.GetID(), err)
+			continue
+		}
+		if resp.Open {
+			return candidate
+		}
+	}
+	return ""
+}
+
+func (s *Server) startMeshTunnel(mt *meshtunnel.MeshTunnel, source *clientdata.Client) error {
+	req := &comm.StartMeshTunnelRequest{
+		ID:         mt.ID,
+		Local:      mt.Local,
+		DirectAddr: mt.DirectAddr,
+	}
+	return comm.SendRequestAndGetResponse(source.GetConnection(), comm.RequestTypeStartMeshTunnel, req, nil, s.Logger)
+}
+
+func (s *Server) stopMeshTunnel(mt *meshtunnel.MeshTunnel) error {
+	source, err := s.clientService.GetActiveByID(mt.SourceClientID)
+	if err != nil {
+		return err
+	}
+	if source == nil || !source.IsConnected() {
+		// the listener on the client disappears together with the connection
+		return nil
+	}
+
+	req := &comm.StopMeshTunnelRequest{
+		ID: mt.ID,
+	}
+	return comm.SendRequestAndGetResponse(source.GetConnection(), comm.RequestTypeStopMeshTunnel, req, nil, s.Logger)
+}
+
+// terminateClientMeshTunnels stops all mesh tunnels of a disconnected client on the remaining end.
+func (s *Server) terminateClientMeshTunnels(clientID string) {
+	for _, mt := range s.meshTunnels.DeleteByClient(clientID) {
+		if mt.SourceClientID == clientID {
+			continue
+		}
+		if err := s.stopMeshTunnel(mt); err != nil {
+			s.Errorf("failed to stop mesh tunnel %s on client %s: %v", mt.ID, mt.SourceClientID, err)
+		}
+	}
+}
+
+// handleMeshTunnelChannel relays a connection accepted by the source client of a mesh tunnel to the target client.
+func (cl *ClientListener) handleMeshTunnelChannel(clientLog *logger.Logger, clientID string, ch ssh.NewChannel) {
+	id := string(ch.ExtraData())
+	mt := cl.server.meshTunnels.Get(id)
+	if mt == nil || mt.SourceClientID != clientID {
+		clientLog.Infof("Rejecting unknown mesh tunnel %q", id)
+		cl.rejectChannel(clientLog, ch, ssh.Prohibited, "unknown mesh tunnel")
+		return
+	}
+
+	target, err := cl.getClientService().GetActiveByID(mt.TargetClientID)
+	if err != nil || target == nil || !target.IsConnected() {
+		cl.rejectChannel(clientLog, ch, ssh.ConnectionFailed, fmt.Sprintf("target client %s is not connected", mt.TargetClientID))
+		return
+	}
+	source, err := cl.getClientService().GetActiveByID(clientID)
+	if err != nil || source == nil {
+		cl.rejectChannel(clientLog, ch, ssh.ConnectionFailed, "client not found")
+		return
+	}
+	if source.IsQuarantined() || target.IsQuarantined() {
+		clientLog.Infof("Rejecting mesh tunnel %q, a client is quarantined", id)
+		cl.rejectChannel(clientLog, ch, ssh.Prohibited, "client is quarantined")
+		return
+	}
+	for _, client := range []*clientdata.Client{source, target} {
+		if err := cgroups.CheckAccessSchedules(client.GetAccessSchedules(), time.Now(), mt.AccessOverride); err != nil {
+			clientLog.Infof("Rejecting mesh tunnel %q: %v", id, err)
+			cl.rejectChannel(clientLog, ch, ssh.Prohibited, err.Error())
+			return
+		}
+	}
+
+	dst, dstReqs, err := target.GetConnection().OpenChannel("rport", []byte(mt.Remote+"/"+models.ProtocolTCP))
+	if err != nil {
+		cl.rejectChannel(clientLog, ch, ssh.ConnectionFailed, err.Error())
+		return
+	}
+	go ssh.DiscardRequests(dstReqs)
+
+	src, srcReqs, err := ch.Accept()
+	if err != nil {
+		clientLog.Debugf("Failed to accept mesh tunnel stream: %s", err)
+		dst.Close()
+		return
+	}
+	go ssh.DiscardRequests(srcReqs)
+
+	l := clientLog.Fork("mesh#%s", mt.ID)
+	l.Debugf("Open relay to %s on client %s", mt.Remote, mt.TargetClientID)
+	sent, received := chshare.Pipe(src, dst)
+	l.Debugf("Close relay (sent %s received %s)", sizestr.ToString(sent), sizestr.ToString(received))
+}
+
+func (cl *ClientListener) rejectChannel(clientLog *logger.Logger, ch ssh.NewChannel, reason ssh.RejectionReason, msg string) {
+	if err := ch.Reject(reason, msg); err != nil {
+		clientLog.Debugf("Failed to reject %s channel: %v", ch.ChannelType(), err)
+	}
+}
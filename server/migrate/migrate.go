@@ -0,0 +1,241 @@
+// Package migrate copies the configuration and history of another rport or neo-rport server into the local stores,
+// e.g. to consolidate several servers into one.
+package migrate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/IOTech17/neo-rport/server/api/command"
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/bulk"
+	"github.com/IOTech17/neo-rport/server/cgroups"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/clientsauth"
+	"github.com/IOTech17/neo-rport/server/script"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/models"
+	"github.com/IOTech17/neo-rport/share/query"
+)
+
+const (
+	ResourceClientsAuth  = "clients-auth"
+	ResourceClientGroups = "client-groups"
+	ResourceUserGroups   = "user-groups"
+	ResourceCommands     = "commands"
+	ResourceScripts      = "scripts"
+	ResourceClients      = "clients"
+	ResourceJobs         = "jobs"
+)
+
+// Resources are migrated in this order.
+var Resources = []string{
+	ResourceClientsAuth,
+	ResourceClientGroups,
+	ResourceUserGroups,
+	ResourceCommands,
+	ResourceScripts,
+	ResourceClients,
+	ResourceJobs,
+}
+
+type UserGroupStore interface {
+	ListGroups() ([]users.Group, error)
+	UpdateGroup(name string, g users.Group) (users.Group, error)
+}
+
+type CommandStore interface {
+	List(ctx context.Context, lo *query.ListOptions) ([]command.Command, error)
+	Save(ctx context.Context, c *command.Command) (string, error)
+}
+
+type ScriptStore interface {
+	List(ctx context.Context, lo *query.ListOptions) ([]script.Script, error)
+	Save(ctx context.Context, s *script.Script, nowDate time.Time) (string, error)
+}
+
+type ClientStore interface {
+	GetAll(ctx context.Context, l *logger.Logger) ([]*clientdata.Client, error)
+	Save(ctx context.Context, client *clientdata.Client) error
+}
+
+type JobStore interface {
+	GetByJID(clientID, jid string) (*models.Job, error)
+	SaveJob(job *models.Job) error
+}
+
+// Target are the local stores to import into. Resources with a nil store are not migrated.
+type Target struct {
+	ClientsAuth  clientsauth.Provider
+	ClientGroups cgroups.ClientGroupProvider
+	UserGroups   UserGroupStore
+	Commands     CommandStore
+	Scripts      ScriptStore
+	Clients      ClientStore
+	Jobs         JobStore
+}
+
+type Options struct {
+	// Resources to migrate, all if empty
+	Resources []string
+	// Strategy for records that exist already, see bulk.Strategies
+	Strategy string
+	DryRun   bool
+}
+
+// Report is the outcome of the migration of a resource. Result is nil if the resource was skipped.
+type Report struct {
+	Resource   string
+	Result     *bulk.Result
+	SkipReason string
+}
+
+// ValidateResources returns an error if one of the resources is unknown.
+func ValidateResources(resources []string) error {
+	for _, r := range resources {
+		if !contains(Resources, r) {
+			return fmt.Errorf("unknown resource %q, expected one of %v", r, Resources)
+		}
+	}
+	return nil
+}
+
+// Run migrates the resources from the source to the target and calls done after each resource. It stops on the first
+// error, resources migrated before are kept.
+func Run(ctx context.Context, src *Source, target *Target, l *logger.Logger, opts Options, done func(*Report)) error {
+	if err := ValidateResources(opts.Resources); err != nil {
+		return err
+	}
+	// the jobs of the clients the source knows about
+	var clientIDs []string
+
+	for _, name := range Resources {
+		if len(opts.Resources) > 0 && !contains(opts.Resources, name) {
+			continue
+		}
+		report := &Report{Resource: name}
+
+		r, reason := target.importer(name, l)
+		if r == nil {
+			report.SkipReason = reason
+			done(report)
+			continue
+		}
+
+		var records []json.RawMessage
+		var err error
+		if name == ResourceJobs {
+			if clientIDs == nil {
+				clientIDs, err = src.clientIDs(ctx)
+				if err != nil {
+					return fmt.Errorf("%s: %w", ResourceClients, err)
+				}
+			}
+			records, err = src.jobs(ctx, clientIDs)
+		} else {
+			records, err = fetchers[name](ctx, src)
+		}
+		if errors.Is(err, errNotFound) {
+			report.SkipReason = "not supported by the source"
+			done(report)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		report.Result, err = bulk.Import(ctx, r, records, opts.Strategy, opts.DryRun)
+		if errors.Is(err, bulk.ErrInvalid) {
+			for _, e := range report.Result.Errors {
+				l.Errorf("%s: record %d %q: %s", name, e.Index, e.ID, e.Message)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		done(report)
+
+		if name == ResourceClients {
+			res := report.Result
+			clientIDs = append(append(append([]string{}, res.Created...), res.Updated...), res.Skipped...)
+		}
+	}
+	return nil
+}
+
+// fetchers read the records of a resource from the source and map them to the local schema.
+var fetchers = map[string]func(ctx context.Context, src *Source) ([]json.RawMessage, error){
+	ResourceClientsAuth:  fetchClientsAuth,
+	ResourceClientGroups: fetchClientGroups,
+	ResourceUserGroups:   fetchUserGroups,
+	ResourceCommands:     fetchCommands,
+	ResourceScripts:      fetchScripts,
+	ResourceClients:      fetchClients,
+}
+
+func (src *Source) clientIDs(ctx context.Context) ([]string, error) {
+	list, err := src.List(ctx, "/clients", url.Values{"fields[clients]": {"id"}})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(list))
+	for _, record := range list {
+		var c struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(record, &c); err != nil {
+			return nil, err
+		}
+		ids = append(ids, c.ID)
+	}
+	return ids, nil
+}
+
+func (t *Target) importer(name string, l *logger.Logger) (bulk.Importer, string) {
+	const notSupported = "not supported by the local configuration"
+	switch name {
+	case ResourceClientsAuth:
+		if t.ClientsAuth == nil || !t.ClientsAuth.IsWriteable() {
+			return nil, "client auth credentials are read-only"
+		}
+		return clientsAuthResource{p: t.ClientsAuth}, ""
+	case ResourceClientGroups:
+		if t.ClientGroups != nil {
+			return clientGroupsResource{p: t.ClientGroups}, ""
+		}
+	case ResourceUserGroups:
+		if t.UserGroups != nil {
+			return userGroupsResource{s: t.UserGroups}, ""
+		}
+	case ResourceCommands:
+		if t.Commands != nil {
+			return &commandsResource{s: t.Commands}, ""
+		}
+	case ResourceScripts:
+		if t.Scripts != nil {
+			return &scriptsResource{s: t.Scripts}, ""
+		}
+	case ResourceClients:
+		if t.Clients != nil {
+			return &clientsResource{s: t.Clients, l: l}, ""
+		}
+	case ResourceJobs:
+		if t.Jobs != nil {
+			return &jobsResource{s: t.Jobs, clientIDs: make(map[string]string)}, ""
+		}
+	}
+	return nil, notSupported
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
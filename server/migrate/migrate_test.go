@@ -0,0 +1,218 @@
+package migrate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/db/migration/client_groups"
+	jobsmigration "github.com/IOTech17/neo-rport/db/migration/jobs"
+	"github.com/IOTech17/neo-rport/db/migration/library"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	"github.com/IOTech17/neo-rport/server/api/command"
+	"github.com/IOTech17/neo-rport/server/api/jobs"
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/bulk"
+	"github.com/IOTech17/neo-rport/server/cgroups"
+	"github.com/IOTech17/neo-rport/server/clients"
+	"github.com/IOTech17/neo-rport/server/clientsauth"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/query"
+)
+
+var testLog = logger.NewLogger("migrate-test", logger.LogOutput{File: nil}, logger.LogLevelDebug)
+
+type fakeUserGroups struct {
+	groups []users.Group
+}
+
+func (f *fakeUserGroups) ListGroups() ([]users.Group, error) {
+	return f.groups, nil
+}
+
+func (f *fakeUserGroups) UpdateGroup(name string, g users.Group) (users.Group, error) {
+	g.Name = name
+	f.groups = append(f.groups, g)
+	return g, nil
+}
+
+// newLegacySource serves the API of an rport server without the bulk export.
+func newLegacySource(t *testing.T) *Source {
+	responses := map[string]string{
+		"/api/v1/clients-auth":                    `{"data":[{"id":"client-1","password":"secret-1"}],"meta":{"count":1}}`,
+		"/api/v1/client-groups":                   `{"data":[{"id":"linux","description":"Linux"}],"meta":{"count":1}}`,
+		"/api/v1/client-groups/linux":             `{"data":{"id":"linux","description":"Linux","params":{"os_family":["debian"]},"client_ids":["client-1"],"legacy_field":1}}`,
+		"/api/v1/user-groups":                     `{"data":[{"name":"Administrators","permissions":{}},{"name":"ops","permissions":{"commands":true,"removed-permission":true}}]}`,
+		"/api/v1/library/commands":                `{"data":[{"id":"c1","name":"uptime","cmd":"uptime","created_by":"admin"}],"meta":{"count":1}}`,
+		"/api/v1/clients":                         `{"data":[{"id":"client-1"}],"meta":{"count":1}}`,
+		"/api/v1/clients/client-1":                `{"data":{"id":"client-1","name":"web","client_auth_id":"client-1","num_cpus":"4","tunnels":[{"id":"1","lport":"2222"}],"connection_state":"connected","disconnected_at":null}}`,
+		"/api/v1/clients/client-1/commands":       `{"data":[{"jid":"job-1"}],"meta":{"count":1}}`,
+		"/api/v1/clients/client-1/commands/job-1": `{"data":{"jid":"job-1","status":"successful","client_id":"client-1","command":"uptime","started_at":"2026-01-02T03:04:05Z","result":{"stdout":"up"}}}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		if user != "admin" || password != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		resp, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(resp))
+	}))
+	t.Cleanup(srv.Close)
+
+	src, err := NewSource(srv.URL, "admin", "token", false)
+	require.NoError(t, err)
+	return src
+}
+
+func newTestTarget(t *testing.T) *Target {
+	groupsDB, err := sqlite.New(":memory:", client_groups.AssetNames(), client_groups.Asset, sqlite.DataSourceOptions{})
+	require.NoError(t, err)
+	groups, err := cgroups.NewSqliteProvider(groupsDB)
+	require.NoError(t, err)
+	libraryDB, err := sqlite.New(":memory:", library.AssetNames(), library.Asset, sqlite.DataSourceOptions{})
+	require.NoError(t, err)
+	jobsDB, err := sqlite.New(":memory:", jobsmigration.AssetNames(), jobsmigration.Asset, sqlite.DataSourceOptions{})
+	require.NoError(t, err)
+
+	return &Target{
+		ClientsAuth:  clientsauth.NewDatabaseMockProvider(nil, t),
+		ClientGroups: groups,
+		UserGroups:   &fakeUserGroups{},
+		Commands:     command.NewSqliteProvider(libraryDB),
+		Clients:      clients.NewFakeClientProvider(t, nil),
+		Jobs:         jobs.NewSqliteProvider(jobsDB, testLog),
+	}
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	src := newLegacySource(t)
+	target := newTestTarget(t)
+
+	var reports []*Report
+	err := Run(ctx, src, target, testLog, Options{Strategy: bulk.StrategySkip}, func(r *Report) {
+		reports = append(reports, r)
+	})
+	require.NoError(t, err)
+
+	summary := make(map[string]interface{})
+	for _, r := range reports {
+		if r.Result == nil {
+			summary[r.Resource] = r.SkipReason
+		} else {
+			summary[r.Resource] = r.Result.Created
+		}
+	}
+	assert.Equal(t, map[string]interface{}{
+		ResourceClientsAuth:  []string{"client-1"},
+		ResourceClientGroups: []string{"linux"},
+		ResourceUserGroups:   []string{"ops"},
+		ResourceCommands:     []string{"uptime"},
+		ResourceScripts:      "not supported by the local configuration",
+		ResourceClients:      []string{"client-1"},
+		ResourceJobs:         []string{"job-1"},
+	}, summary)
+
+	ca, err := target.ClientsAuth.Get("client-1")
+	require.NoError(t, err)
+	assert.Equal(t, "secret-1", ca.Password)
+
+	group, err := target.ClientGroups.Get(ctx, "linux")
+	require.NoError(t, err)
+	assert.Equal(t, "Linux", group.Description)
+	assert.NotNil(t, group.Params.OSFamily)
+
+	ug := target.UserGroups.(*fakeUserGroups).groups
+	require.Len(t, ug, 1)
+	assert.Equal(t, map[string]bool{"commands": true}, filterTrue(ug[0].Permissions.All()))
+
+	cmds, err := target.Commands.List(ctx, &query.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, cmds, 1)
+	assert.NotEqual(t, "c1", cmds[0].ID)
+	assert.Equal(t, "admin", cmds[0].CreatedBy)
+
+	all, err := target.Clients.GetAll(ctx, testLog)
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assert.Equal(t, "web", all[0].GetName())
+	assert.Equal(t, "client-1", all[0].GetClientAuthID())
+	assert.Empty(t, all[0].GetTunnels())
+	assert.NotNil(t, all[0].GetDisconnectedAtValue())
+
+	job, err := target.Jobs.GetByJID("client-1", "job-1")
+	require.NoError(t, err)
+	require.NotNil(t, job)
+	assert.Equal(t, "up", job.Result.StdOut)
+
+	// a second run skips what exists already
+	reports = nil
+	err = Run(ctx, src, target, testLog, Options{Resources: []string{ResourceCommands}, Strategy: bulk.StrategySkip}, func(r *Report) {
+		reports = append(reports, r)
+	})
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.Equal(t, []string{"uptime"}, reports[0].Result.Skipped)
+}
+
+func TestRunFailsOnExisting(t *testing.T) {
+	src := newLegacySource(t)
+	target := newTestTarget(t)
+	_, err := target.ClientsAuth.Add(&clientsauth.ClientAuth{ID: "client-1", Password: "local"})
+	require.NoError(t, err)
+
+	err = Run(context.Background(), src, target, testLog, Options{Strategy: bulk.StrategyFail}, func(*Report) {})
+	assert.EqualError(t, err, "clients-auth: "+bulk.ErrInvalid.Error())
+
+	err = Run(context.Background(), src, target, testLog, Options{Resources: []string{"unknown"}}, func(*Report) {})
+	assert.EqualError(t, err, `unknown resource "unknown", expected one of [clients-auth client-groups user-groups commands scripts clients jobs]`)
+}
+
+func TestNewSourceUnauthorized(t *testing.T) {
+	src := newLegacySource(t)
+	src.password = "wrong"
+
+	_, err := src.List(context.Background(), "/clients", nil)
+	assert.EqualError(t, err, "GET /clients: unauthorized, check the username and password")
+
+	_, err = NewSource("old-server:3000", "admin", "token", false)
+	assert.Error(t, err)
+}
+
+func filterTrue(m map[string]bool) map[string]bool {
+	res := make(map[string]bool)
+	for k, v := range m {
+		if v {
+			res[k] = v
+		}
+	}
+	return res
+}
+
+func TestRunDryRun(t *testing.T) {
+	src := newLegacySource(t)
+	target := newTestTarget(t)
+
+	var reports []*Report
+	err := Run(context.Background(), src, target, testLog, Options{Strategy: bulk.StrategyOverwrite, DryRun: true}, func(r *Report) {
+		reports = append(reports, r)
+	})
+	require.NoError(t, err)
+	assert.Len(t, reports, len(Resources))
+
+	ca, err := target.ClientsAuth.Get("client-1")
+	require.NoError(t, err)
+	assert.Nil(t, ca)
+	clients, err := target.Clients.GetAll(context.Background(), testLog)
+	require.NoError(t, err)
+	assert.Empty(t, clients)
+}
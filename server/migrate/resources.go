@@ -0,0 +1,517 @@
+package migrate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/IOTech17/neo-rport/server/api/command"
+	"github.com/IOTech17/neo-rport/server/api/library"
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/cgroups"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/clientsauth"
+	"github.com/IOTech17/neo-rport/server/script"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/models"
+	"github.com/IOTech17/neo-rport/share/query"
+	"github.com/IOTech17/neo-rport/share/types"
+)
+
+// remap maps the records of the source to the local schema by decoding them into the local types and encoding them
+// again. Unknown fields are dropped, as well as fields with a type different from the local one, e.g. of an older
+// rport server. fix is applied to each item, it returns false to leave out the record.
+func remap(records []json.RawMessage, newItem func() interface{}, fix func(interface{}) bool) ([]json.RawMessage, error) {
+	mapped := make([]json.RawMessage, 0, len(records))
+	for _, record := range records {
+		item := newItem()
+		if err := decodeLenient(record, item); err != nil {
+			return nil, fmt.Errorf("unexpected record %s: %v", record, err)
+		}
+		if fix != nil && !fix(item) {
+			continue
+		}
+		b, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+		mapped = append(mapped, b)
+	}
+	return mapped, nil
+}
+
+func decodeLenient(record json.RawMessage, dest interface{}) error {
+	err := json.Unmarshal(record, dest)
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) || typeErr.Field == "" {
+		return err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(record, &fields); err != nil {
+		return err
+	}
+	field := strings.SplitN(typeErr.Field, ".", 2)[0]
+	if _, ok := fields[field]; !ok {
+		return err
+	}
+	delete(fields, field)
+	record, err = json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return decodeLenient(record, dest)
+}
+
+// exportOrList uses the bulk export of neo-rport if the source supports it and the list endpoint otherwise.
+func exportOrList(ctx context.Context, src *Source, resource, path string) ([]json.RawMessage, error) {
+	records, err := src.Export(ctx, resource)
+	if errors.Is(err, errNotFound) {
+		return src.List(ctx, path, nil)
+	}
+	return records, err
+}
+
+func fetchClientsAuth(ctx context.Context, src *Source) ([]json.RawMessage, error) {
+	records, err := exportOrList(ctx, src, ResourceClientsAuth, "/clients-auth")
+	if err != nil {
+		return nil, err
+	}
+	return remap(records, func() interface{} { return &clientsauth.ClientAuth{} }, nil)
+}
+
+func fetchClientGroups(ctx context.Context, src *Source) ([]json.RawMessage, error) {
+	records, err := src.Export(ctx, ResourceClientGroups)
+	if errors.Is(err, errNotFound) {
+		// the list returns only the id and description
+		records, err = src.List(ctx, "/client-groups", nil)
+		if err != nil {
+			return nil, err
+		}
+		for i, record := range records {
+			var g struct {
+				ID string `json:"id"`
+			}
+			if err := json.Unmarshal(record, &g); err != nil {
+				return nil, err
+			}
+			var full json.RawMessage
+			if err := src.Get(ctx, "/client-groups/"+url.PathEscape(g.ID), &full); err != nil {
+				return nil, err
+			}
+			records[i] = full
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return remap(records, func() interface{} { return &cgroups.ClientGroup{} }, func(item interface{}) bool {
+		// the clients of a group follow from its params
+		item.(*cgroups.ClientGroup).ClientIDs = nil
+		return true
+	})
+}
+
+// sourceUserGroup has the permissions as a plain map to drop the ones unknown to the local server.
+type sourceUserGroup struct {
+	Name               string          `json:"name"`
+	Permissions        map[string]bool `json:"permissions"`
+	TunnelsRestricted  json.RawMessage `json:"tunnels_restricted"`
+	CommandsRestricted json.RawMessage `json:"commands_restricted"`
+}
+
+func fetchUserGroups(ctx context.Context, src *Source) ([]json.RawMessage, error) {
+	records, err := exportOrList(ctx, src, ResourceUserGroups, "/user-groups")
+	if err != nil {
+		return nil, err
+	}
+	return remap(records, func() interface{} { return &sourceUserGroup{} }, func(item interface{}) bool {
+		g := item.(*sourceUserGroup)
+		if g.Name == users.Administrators {
+			return false
+		}
+		for p := range g.Permissions {
+			if !contains(users.AllPermissions, p) {
+				delete(g.Permissions, p)
+			}
+		}
+		return true
+	})
+}
+
+func fetchCommands(ctx context.Context, src *Source) ([]json.RawMessage, error) {
+	records, err := src.List(ctx, "/library/commands", nil)
+	if err != nil {
+		return nil, err
+	}
+	return remap(records, func() interface{} { return &command.Command{} }, nil)
+}
+
+func fetchScripts(ctx context.Context, src *Source) ([]json.RawMessage, error) {
+	records, err := src.List(ctx, "/library/scripts", nil)
+	if err != nil {
+		return nil, err
+	}
+	return remap(records, func() interface{} { return &script.Script{} }, nil)
+}
+
+func fetchClients(ctx context.Context, src *Source) ([]json.RawMessage, error) {
+	ids, err := src.clientIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]json.RawMessage, 0, len(ids))
+	for _, id := range ids {
+		var fields map[string]json.RawMessage
+		if err := src.Get(ctx, "/clients/"+url.PathEscape(id), &fields); err != nil {
+			return nil, err
+		}
+		// tunnels are not migrated, their ports might be in use locally
+		delete(fields, "tunnels")
+		record, err := json.Marshal(fields)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return remap(records, func() interface{} { return &clientdata.Client{} }, nil)
+}
+
+func (src *Source) jobs(ctx context.Context, clientIDs []string) ([]json.RawMessage, error) {
+	var records []json.RawMessage
+	for _, cid := range clientIDs {
+		path := "/clients/" + url.PathEscape(cid) + "/commands"
+		list, err := src.List(ctx, path, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range list {
+			var j struct {
+				JID string `json:"jid"`
+			}
+			if err := json.Unmarshal(item, &j); err != nil {
+				return nil, err
+			}
+			var job json.RawMessage
+			if err := src.Get(ctx, path+"/"+url.PathEscape(j.JID), &job); err != nil {
+				return nil, err
+			}
+			records = append(records, job)
+		}
+	}
+	return remap(records, func() interface{} { return &models.Job{} }, nil)
+}
+
+func decode(record json.RawMessage, dest interface{}) error {
+	if err := json.Unmarshal(record, dest); err != nil {
+		return fmt.Errorf("invalid record: %v", err)
+	}
+	return nil
+}
+
+type clientsAuthResource struct {
+	p clientsauth.Provider
+}
+
+func (r clientsAuthResource) Validate(ctx context.Context, record json.RawMessage) (string, error) {
+	var ca clientsauth.ClientAuth
+	if err := decode(record, &ca); err != nil {
+		return "", err
+	}
+	if ca.ID == "" || ca.Password == "" {
+		return ca.ID, errors.New("missing id or password")
+	}
+	return ca.ID, nil
+}
+
+func (r clientsAuthResource) Exists(ctx context.Context, id string) (bool, error) {
+	ca, err := r.p.Get(id)
+	return ca != nil, err
+}
+
+func (r clientsAuthResource) Save(ctx context.Context, id string, record json.RawMessage) error {
+	var ca clientsauth.ClientAuth
+	if err := decode(record, &ca); err != nil {
+		return err
+	}
+	exists, err := r.Exists(ctx, id)
+	if err != nil {
+		return err
+	}
+	if exists {
+		if err := r.p.Delete(id); err != nil {
+			return err
+		}
+	}
+	_, err = r.p.Add(&ca)
+	return err
+}
+
+type clientGroupsResource struct {
+	p cgroups.ClientGroupProvider
+}
+
+func (r clientGroupsResource) Validate(ctx context.Context, record json.RawMessage) (string, error) {
+	var g cgroups.ClientGroup
+	if err := decode(record, &g); err != nil {
+		return "", err
+	}
+	if g.ID == "" {
+		return "", errors.New("missing id")
+	}
+	return g.ID, nil
+}
+
+func (r clientGroupsResource) Exists(ctx context.Context, id string) (bool, error) {
+	g, err := r.p.Get(ctx, id)
+	return g != nil, err
+}
+
+func (r clientGroupsResource) Save(ctx context.Context, id string, record json.RawMessage) error {
+	var g cgroups.ClientGroup
+	if err := decode(record, &g); err != nil {
+		return err
+	}
+	exists, err := r.Exists(ctx, id)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return r.p.Update(ctx, &g)
+	}
+	return r.p.Create(ctx, &g)
+}
+
+type userGroupsResource struct {
+	s UserGroupStore
+}
+
+func (r userGroupsResource) Validate(ctx context.Context, record json.RawMessage) (string, error) {
+	var g users.Group
+	if err := decode(record, &g); err != nil {
+		return "", err
+	}
+	if g.Name == "" {
+		return "", errors.New("missing name")
+	}
+	return g.Name, nil
+}
+
+func (r userGroupsResource) Exists(ctx context.Context, id string) (bool, error) {
+	groups, err := r.s.ListGroups()
+	if err != nil {
+		return false, err
+	}
+	for _, g := range groups {
+		if g.Name == id {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r userGroupsResource) Save(ctx context.Context, id string, record json.RawMessage) error {
+	var g users.Group
+	if err := decode(record, &g); err != nil {
+		return err
+	}
+	_, err := r.s.UpdateGroup(id, g)
+	return err
+}
+
+// commandsResource identifies commands by name, the ids are generated by each server.
+type commandsResource struct {
+	s   CommandStore
+	ids map[string]string
+}
+
+func (r *commandsResource) Validate(ctx context.Context, record json.RawMessage) (string, error) {
+	var c command.Command
+	if err := decode(record, &c); err != nil {
+		return "", err
+	}
+	if c.Name == "" || c.Cmd == "" {
+		return c.Name, errors.New("missing name or cmd")
+	}
+	return c.Name, nil
+}
+
+func (r *commandsResource) Exists(ctx context.Context, name string) (bool, error) {
+	if r.ids == nil {
+		list, err := r.s.List(ctx, &query.ListOptions{})
+		if err != nil {
+			return false, err
+		}
+		r.ids = make(map[string]string, len(list))
+		for _, c := range list {
+			r.ids[c.Name] = c.ID
+		}
+	}
+	_, ok := r.ids[name]
+	return ok, nil
+}
+
+func (r *commandsResource) Save(ctx context.Context, name string, record json.RawMessage) error {
+	var c command.Command
+	if err := decode(record, &c); err != nil {
+		return err
+	}
+	c.ID = r.ids[name]
+	libraryDefaults(&c.CreatedAt, &c.UpdatedAt, &c.Tags, &c.TimoutSec, &c.Visibility, &c.SharedGroups)
+	_, err := r.s.Save(ctx, &c)
+	return err
+}
+
+// scriptsResource identifies scripts by name, the ids are generated by each server.
+type scriptsResource struct {
+	s   ScriptStore
+	ids map[string]string
+}
+
+func (r *scriptsResource) Validate(ctx context.Context, record json.RawMessage) (string, error) {
+	var s script.Script
+	if err := decode(record, &s); err != nil {
+		return "", err
+	}
+	if s.Name == "" || s.Script == "" {
+		return s.Name, errors.New("missing name or script")
+	}
+	return s.Name, nil
+}
+
+func (r *scriptsResource) Exists(ctx context.Context, name string) (bool, error) {
+	if r.ids == nil {
+		list, err := r.s.List(ctx, &query.ListOptions{})
+		if err != nil {
+			return false, err
+		}
+		r.ids = make(map[string]string, len(list))
+		for _, s := range list {
+			r.ids[s.Name] = s.ID
+		}
+	}
+	_, ok := r.ids[name]
+	return ok, nil
+}
+
+func (r *scriptsResource) Save(ctx context.Context, name string, record json.RawMessage) error {
+	var s script.Script
+	if err := decode(record, &s); err != nil {
+		return err
+	}
+	s.ID = r.ids[name]
+	libraryDefaults(&s.CreatedAt, &s.UpdatedAt, &s.Tags, &s.TimoutSec, &s.Visibility, &s.SharedGroups)
+	if s.IsSudo == nil {
+		isSudo := false
+		s.IsSudo = &isSudo
+	}
+	_, err := r.s.Save(ctx, &s, *s.UpdatedAt)
+	return err
+}
+
+// libraryDefaults sets the fields of commands and scripts that older servers don't return to the defaults of a new
+// library item.
+func libraryDefaults(createdAt, updatedAt **time.Time, tags **types.StringSlice, timeoutSec **int, visibility **string, sharedGroups **types.StringSlice) {
+	if *createdAt == nil {
+		now := time.Now()
+		*createdAt = &now
+	}
+	if *updatedAt == nil {
+		*updatedAt = *createdAt
+	}
+	if *tags == nil {
+		*tags = &types.StringSlice{}
+	}
+	if *timeoutSec == nil {
+		timeout := command.DefaultTimeoutSec
+		*timeoutSec = &timeout
+	}
+	if *visibility == nil {
+		v := library.VisibilityGlobal
+		*visibility = &v
+	}
+	if *sharedGroups == nil {
+		*sharedGroups = &types.StringSlice{}
+	}
+}
+
+// clientsResource imports the clients of the source as disconnected clients, they are connected once they connect
+// to the local server with their credentials.
+type clientsResource struct {
+	s   ClientStore
+	l   *logger.Logger
+	ids map[string]bool
+}
+
+func (r *clientsResource) Validate(ctx context.Context, record json.RawMessage) (string, error) {
+	c := &clientdata.Client{}
+	if err := decode(record, c); err != nil {
+		return "", err
+	}
+	if c.ID == "" {
+		return "", errors.New("missing id")
+	}
+	return c.ID, nil
+}
+
+func (r *clientsResource) Exists(ctx context.Context, id string) (bool, error) {
+	if r.ids == nil {
+		list, err := r.s.GetAll(ctx, r.l)
+		if err != nil {
+			return false, err
+		}
+		r.ids = make(map[string]bool, len(list))
+		for _, c := range list {
+			r.ids[c.GetID()] = true
+		}
+	}
+	return r.ids[id], nil
+}
+
+func (r *clientsResource) Save(ctx context.Context, id string, record json.RawMessage) error {
+	c := &clientdata.Client{}
+	if err := decode(record, c); err != nil {
+		return err
+	}
+	c.Logger = r.l
+	if c.DisconnectedAt == nil {
+		now := time.Now().UTC()
+		c.DisconnectedAt = &now
+	}
+	return r.s.Save(ctx, c)
+}
+
+type jobsResource struct {
+	s JobStore
+	// clientIDs by jid, filled on validation
+	clientIDs map[string]string
+}
+
+func (r *jobsResource) Validate(ctx context.Context, record json.RawMessage) (string, error) {
+	var job models.Job
+	if err := decode(record, &job); err != nil {
+		return "", err
+	}
+	if job.JID == "" || job.ClientID == "" {
+		return job.JID, errors.New("missing jid or client_id")
+	}
+	r.clientIDs[job.JID] = job.ClientID
+	return job.JID, nil
+}
+
+func (r *jobsResource) Exists(ctx context.Context, jid string) (bool, error) {
+	job, err := r.s.GetByJID(r.clientIDs[jid], jid)
+	return job != nil, err
+}
+
+func (r *jobsResource) Save(ctx context.Context, jid string, record json.RawMessage) error {
+	var job models.Job
+	if err := decode(record, &job); err != nil {
+		return err
+	}
+	return r.s.SaveJob(&job)
+}
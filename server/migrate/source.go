@@ -0,0 +1,147 @@
+package migrate
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pageLimit is the page size for list endpoints, it's accepted by all of them.
+const pageLimit = 100
+
+// errNotFound is returned if the source doesn't have an endpoint, e.g. an rport server without the bulk export.
+var errNotFound = errors.New("not found")
+
+// Source reads the data of another rport or neo-rport server via its API.
+type Source struct {
+	baseURL  string
+	username string
+	password string
+	client   *http.Client
+}
+
+// NewSource returns a source for the server at the given url. The password can also be an API token of the user.
+func NewSource(baseURL, username, password string, insecure bool) (*Source, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("invalid url %q, expected http(s)://host[:port]", baseURL)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec // requested by the user for self-signed certificates
+	}
+	return &Source{
+		baseURL:  strings.TrimSuffix(u.String(), "/") + "/api/v1",
+		username: username,
+		password: password,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   time.Minute,
+		},
+	}, nil
+}
+
+func (s *Source) get(ctx context.Context, path string, params url.Values) ([]byte, error) {
+	u := s.baseURL + path
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(s.username, s.password)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, errNotFound
+	case resp.StatusCode == http.StatusUnauthorized:
+		return nil, fmt.Errorf("GET %s: unauthorized, check the username and password", path)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("GET %s: unexpected status %d: %s", path, resp.StatusCode, body)
+	}
+	return body, nil
+}
+
+// Get reads the data of a single resource.
+func (s *Source) Get(ctx context.Context, path string, dest interface{}) error {
+	body, err := s.get(ctx, path, nil)
+	if err != nil {
+		return err
+	}
+	payload := struct {
+		Data interface{} `json:"data"`
+	}{Data: dest}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return fmt.Errorf("GET %s: invalid response: %v", path, err)
+	}
+	return nil
+}
+
+// List reads all records of a list endpoint, page by page if the endpoint supports pagination.
+func (s *Source) List(ctx context.Context, path string, params url.Values) ([]json.RawMessage, error) {
+	var all []json.RawMessage
+	for offset := 0; ; offset += pageLimit {
+		p := url.Values{}
+		for k, v := range params {
+			p[k] = v
+		}
+		p.Set("page[limit]", strconv.Itoa(pageLimit))
+		p.Set("page[offset]", strconv.Itoa(offset))
+
+		body, err := s.get(ctx, path, p)
+		if err != nil {
+			return nil, err
+		}
+		var payload struct {
+			Data []json.RawMessage `json:"data"`
+			Meta *struct {
+				Count int `json:"count"`
+			} `json:"meta"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, fmt.Errorf("GET %s: invalid response: %v", path, err)
+		}
+		all = append(all, payload.Data...)
+
+		// endpoints without meta don't paginate
+		if payload.Meta == nil || len(payload.Data) < pageLimit || len(all) >= payload.Meta.Count {
+			return all, nil
+		}
+	}
+}
+
+// Export reads all records of a resource using the bulk export of neo-rport. It returns errNotFound if the source
+// doesn't support it.
+func (s *Source) Export(ctx context.Context, resource string) ([]json.RawMessage, error) {
+	body, err := s.get(ctx, "/export/"+resource, url.Values{"format": {"json"}})
+	if err != nil {
+		return nil, err
+	}
+	var records []json.RawMessage
+	if err := json.Unmarshal(body, &records); err != nil {
+		return nil, fmt.Errorf("GET /export/%s: invalid response: %v", resource, err)
+	}
+	return records, nil
+}
@@ -16,6 +16,8 @@ type DBProviderMock struct {
 	MetricsListPayload           []*ClientMetricsPayload
 	ProcessesListPayload         []*ClientProcessesPayload
 	MountpointsListPayload       []*ClientMountpointsPayload
+	MetricValues                 []MetricValue
+	Storage                      map[string]int64
 }
 
 func (p *DBProviderMock) CountByClientID(ctx context.Context, clientID string, fo *query.ListOptions) (int, error) {
@@ -50,6 +52,18 @@ func (p *DBProviderMock) DeleteMeasurementsBefore(ctx context.Context, compare t
 	return 0, nil
 }
 
+func (p *DBProviderMock) ListMountpointsSince(ctx context.Context, clientID string, since time.Time) ([]*ClientMountpointsPayload, error) {
+	return p.MountpointsListPayload, nil
+}
+
+func (p *DBProviderMock) ListMetricValues(ctx context.Context, column string, clientIDs []string, from, to time.Time, limit int) ([]MetricValue, error) {
+	return p.MetricValues, nil
+}
+
+func (p *DBProviderMock) StorageByClient(ctx context.Context) (map[string]int64, error) {
+	return p.Storage, nil
+}
+
 func (p *DBProviderMock) Close() error {
 	return nil
 }
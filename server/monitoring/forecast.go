@@ -0,0 +1,134 @@
+package monitoring
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultForecastWindow is the time span of the measurements a disk usage trend is computed from
+	DefaultForecastWindow = 7 * 24 * time.Hour
+	// minForecastSpan is the minimum time span of the measurements of a trend
+	minForecastSpan = time.Hour
+	maxForecastDays = 100 * 365
+
+	mountpointFreePrefix  = "free_b."
+	mountpointTotalPrefix = "total_b."
+)
+
+// DiskForecast is the trend of the usage of a mountpoint and the time it's predicted to be full. DaysUntilFull and
+// FullAt are nil if the usage doesn't grow or there are not enough measurements.
+type DiskForecast struct {
+	Mountpoint  string  `json:"mountpoint"`
+	TotalBytes  uint64  `json:"total_b"`
+	FreeBytes   uint64  `json:"free_b"`
+	UsedPercent float64 `json:"used_percent"`
+	// GrowthBytesPerDay is the trend of the used bytes, negative if usage shrinks
+	GrowthBytesPerDay float64    `json:"growth_b_per_day"`
+	DaysUntilFull     *float64   `json:"days_until_full"`
+	FullAt            *time.Time `json:"full_at"`
+	Samples           int        `json:"samples"`
+	Since             time.Time  `json:"since"`
+}
+
+type diskSample struct {
+	ts    time.Time
+	used  float64
+	total float64
+	free  float64
+}
+
+func (s *monitoringService) ForecastDiskUsage(ctx context.Context, clientID string, window time.Duration) ([]DiskForecast, error) {
+	entries, err := s.DBProvider.ListMountpointsSince(ctx, clientID, time.Now().Add(-window))
+	if err != nil {
+		return nil, err
+	}
+
+	samples := map[string][]diskSample{}
+	for _, e := range entries {
+		values := map[string]float64{}
+		if err := json.Unmarshal([]byte(e.Mountpoints), &values); err != nil {
+			s.L.Debugf("skipping mountpoints of client %s at %s: %v", clientID, e.Timestamp, err)
+			continue
+		}
+		for key, total := range values {
+			if !strings.HasPrefix(key, mountpointTotalPrefix) {
+				continue
+			}
+			mountpoint := strings.TrimPrefix(key, mountpointTotalPrefix)
+			free, ok := values[mountpointFreePrefix+mountpoint]
+			if !ok || total <= 0 {
+				continue
+			}
+			samples[mountpoint] = append(samples[mountpoint], diskSample{ts: e.Timestamp, used: total - free, total: total, free: free})
+		}
+	}
+
+	forecasts := make([]DiskForecast, 0, len(samples))
+	for mountpoint, ss := range samples {
+		forecasts = append(forecasts, forecastDisk(mountpoint, ss))
+	}
+	sort.Slice(forecasts, func(i, j int) bool {
+		return forecasts[i].Mountpoint < forecasts[j].Mountpoint
+	})
+	return forecasts, nil
+}
+
+// forecastDisk fits a line through the used bytes by least squares, the samples are ordered by time.
+func forecastDisk(mountpoint string, samples []diskSample) DiskForecast {
+	first, last := samples[0], samples[len(samples)-1]
+	f := DiskForecast{
+		Mountpoint:  mountpoint,
+		TotalBytes:  uint64(last.total),
+		FreeBytes:   uint64(last.free),
+		UsedPercent: last.used * 100 / last.total,
+		Samples:     len(samples),
+		Since:       first.ts,
+	}
+	if len(samples) < 2 || last.ts.Sub(first.ts) < minForecastSpan {
+		return f
+	}
+
+	var sumX, sumY, sumXX, sumXY float64
+	for _, s := range samples {
+		x := s.ts.Sub(first.ts).Seconds()
+		sumX += x
+		sumY += s.used
+		sumXX += x * x
+		sumXY += x * s.used
+	}
+	n := float64(len(samples))
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return f
+	}
+	slope := (n*sumXY - sumX*sumY) / denominator
+	f.GrowthBytesPerDay = slope * 24 * 60 * 60
+	if slope <= 0 {
+		return f
+	}
+
+	days := last.free / slope / (24 * 60 * 60)
+	f.DaysUntilFull = &days
+	// time.Duration overflows after about 290 years
+	if days < maxForecastDays {
+		fullAt := last.ts.Add(time.Duration(days * 24 * float64(time.Hour)))
+		f.FullAt = &fullAt
+	}
+	return f
+}
+
+// MinDaysUntilFull returns the forecast of the mountpoint getting full first, nil if none is getting full.
+func MinDaysUntilFull(forecasts []DiskForecast) *float64 {
+	var res *float64
+	for _, f := range forecasts {
+		if f.DaysUntilFull != nil && (res == nil || *f.DaysUntilFull < *res) {
+			days := *f.DaysUntilFull
+			res = &days
+		}
+	}
+	return res
+}
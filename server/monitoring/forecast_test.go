@@ -0,0 +1,71 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+func TestForecastDiskUsage(t *testing.T) {
+	dbProvider, err := NewSqliteProvider(":memory:", DataSourceOptions, testLog)
+	require.NoError(t, err)
+	defer dbProvider.Close()
+	ctx := context.Background()
+	service := NewService(dbProvider, testLog)
+
+	const gb = 1000 * 1000 * 1000
+	start := time.Now().UTC().Add(-48 * time.Hour).Truncate(time.Hour)
+	for i := 0; i < 48*4; i++ {
+		ts := start.Add(time.Duration(i) * 15 * time.Minute)
+		// / grows by 1 GB per day, /home is static
+		rootFree := 50*gb - int64(ts.Sub(start).Hours()*gb/24)
+		err := dbProvider.CreateMeasurement(ctx, &models.Measurement{
+			ClientID:    "client-1",
+			Timestamp:   ts,
+			Mountpoints: fmt.Sprintf(`{"free_b./":%d,"total_b./":%d,"free_b./home":%d,"total_b./home":%d}`, rootFree, 100*gb, 20*gb, 200*gb),
+		})
+		require.NoError(t, err)
+	}
+
+	forecasts, err := service.ForecastDiskUsage(ctx, "client-1", DefaultForecastWindow)
+	require.NoError(t, err)
+	require.Len(t, forecasts, 2)
+
+	root := forecasts[0]
+	assert.Equal(t, "/", root.Mountpoint)
+	assert.Equal(t, 48, root.Samples)
+	assert.Equal(t, uint64(100*gb), root.TotalBytes)
+	assert.InDelta(t, gb, root.GrowthBytesPerDay, gb/100)
+	require.NotNil(t, root.DaysUntilFull)
+	assert.InDelta(t, 48, *root.DaysUntilFull, 0.5)
+	require.NotNil(t, root.FullAt)
+
+	home := forecasts[1]
+	assert.Equal(t, "/home", home.Mountpoint)
+	assert.Equal(t, 90.0, home.UsedPercent)
+	assert.Nil(t, home.DaysUntilFull)
+	assert.Nil(t, home.FullAt)
+
+	assert.InDelta(t, 48, *MinDaysUntilFull(forecasts), 0.5)
+	assert.Nil(t, MinDaysUntilFull(forecasts[1:]))
+
+	none, err := service.ForecastDiskUsage(ctx, "unknown", DefaultForecastWindow)
+	require.NoError(t, err)
+	assert.Empty(t, none)
+}
+
+func TestForecastDiskNeedsTimeSpan(t *testing.T) {
+	now := time.Now()
+	f := forecastDisk("/", []diskSample{
+		{ts: now.Add(-30 * time.Minute), used: 10, total: 100, free: 90},
+		{ts: now, used: 20, total: 100, free: 80},
+	})
+	assert.Nil(t, f.DaysUntilFull)
+	assert.Equal(t, 20.0, f.UsedPercent)
+}
@@ -0,0 +1,385 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/IOTech17/neo-rport/server/api/errors"
+)
+
+// Functions of measure queries
+const (
+	QueryFunctionAvg   = "avg"
+	QueryFunctionMin   = "min"
+	QueryFunctionMax   = "max"
+	QueryFunctionSum   = "sum"
+	QueryFunctionCount = "count"
+	QueryFunctionRate  = "rate"
+)
+
+// Groupings of measure queries, a label grouping is given as "label:<key>"
+const (
+	QueryGroupByClient      = "client"
+	QueryGroupByTag         = "tag"
+	QueryGroupByLabelPrefix = "label:"
+)
+
+const (
+	// MaxQueryRange is the longest time range of a measure query
+	MaxQueryRange = 31 * 24 * time.Hour
+	// MaxQueryPoints is the maximum number of points of a series
+	MaxQueryPoints = 1000
+	// MaxQueryValues limits the number of stored values read by a single query
+	MaxQueryValues = 1000000
+)
+
+const (
+	defaultQueryRange  = time.Hour
+	defaultQueryPoints = 60
+	minQueryStep       = time.Second
+)
+
+// QueryMetrics maps the metrics of measure queries to the columns of the measurements
+var QueryMetrics = map[string]string{
+	"cpu_usage_percent":    "cpu_usage_percent",
+	"memory_usage_percent": "memory_usage_percent",
+	"io_usage_percent":     "io_usage_percent",
+	"net_lan_in":           "net_lan_in",
+	"net_lan_out":          "net_lan_out",
+	"net_wan_in":           "net_wan_in",
+	"net_wan_out":          "net_wan_out",
+}
+
+var queryFunctions = map[string]bool{
+	QueryFunctionAvg:   true,
+	QueryFunctionMin:   true,
+	QueryFunctionMax:   true,
+	QueryFunctionSum:   true,
+	QueryFunctionCount: true,
+	QueryFunctionRate:  true,
+}
+
+var (
+	queryRegexp      = regexp.MustCompile(`^\s*([a-z0-9]+)\s*\(\s*([a-z_]+)\s*\)\s*(?:by\s+(\S+))?\s*$`)
+	percentileRegexp = regexp.MustCompile(`^p([1-9][0-9]?)$`)
+)
+
+// MeasureQuery is a parsed measure query of the form "<function>(<metric>) [by client|tag|label:<key>]",
+// e.g. "p95(cpu_usage_percent) by tag".
+type MeasureQuery struct {
+	Function string
+	Metric   string
+	// GroupBy is empty to aggregate all clients into a single series
+	GroupBy string
+	// Percentile is set for the functions p1 to p99
+	Percentile int
+}
+
+// QueryClient is a client a measure query is evaluated on.
+type QueryClient struct {
+	ID     string
+	Tags   []string
+	Labels map[string]string
+}
+
+// QueryRange selects the measurements of a query, they are aggregated in buckets of Step.
+type QueryRange struct {
+	From time.Time
+	To   time.Time
+	Step time.Duration
+}
+
+// MetricValue is a stored value of a metric.
+type MetricValue struct {
+	ClientID  string    `db:"client_id"`
+	Timestamp time.Time `db:"timestamp"`
+	Value     float64   `db:"value"`
+}
+
+type QueryPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
+
+// QuerySeries is the result of a query for a group of clients. Buckets without measurements have no point.
+type QuerySeries struct {
+	Labels map[string]string `json:"labels"`
+	Points []QueryPoint      `json:"points"`
+}
+
+// QueryResult holds the series of a measure query.
+type QueryResult struct {
+	Query  string        `json:"query"`
+	From   time.Time     `json:"from"`
+	To     time.Time     `json:"to"`
+	Step   string        `json:"step"`
+	Series []QuerySeries `json:"series"`
+}
+
+func ParseMeasureQuery(q string) (*MeasureQuery, error) {
+	m := queryRegexp.FindStringSubmatch(q)
+	if m == nil {
+		return nil, queryError(`invalid query %q, expected "<function>(<metric>) [by client|tag|label:<key>]"`, q)
+	}
+	mq := &MeasureQuery{
+		Function: m[1],
+		Metric:   m[2],
+		GroupBy:  m[3],
+	}
+	if pm := percentileRegexp.FindStringSubmatch(mq.Function); pm != nil {
+		fmt.Sscanf(pm[1], "%d", &mq.Percentile)
+	} else if !queryFunctions[mq.Function] {
+		return nil, queryError("unsupported function %q, expected one of avg, min, max, sum, count, rate or p1 to p99", mq.Function)
+	}
+	if _, ok := QueryMetrics[mq.Metric]; !ok {
+		metrics := make([]string, 0, len(QueryMetrics))
+		for metric := range QueryMetrics {
+			metrics = append(metrics, metric)
+		}
+		sort.Strings(metrics)
+		return nil, queryError("unsupported metric %q, expected one of %s", mq.Metric, strings.Join(metrics, ", "))
+	}
+	switch {
+	case mq.GroupBy == "", mq.GroupBy == QueryGroupByClient, mq.GroupBy == QueryGroupByTag:
+	case strings.HasPrefix(mq.GroupBy, QueryGroupByLabelPrefix) && len(mq.GroupBy) > len(QueryGroupByLabelPrefix):
+	default:
+		return nil, queryError("unsupported grouping %q, expected client, tag or label:<key>", mq.GroupBy)
+	}
+	return mq, nil
+}
+
+// NewQueryRange validates a time range, a zero to defaults to now, a zero from to an hour before to and a zero step
+// to a step giving 60 points.
+func NewQueryRange(from, to time.Time, step time.Duration) (QueryRange, error) {
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = to.Add(-defaultQueryRange)
+	}
+	if !from.Before(to) {
+		return QueryRange{}, queryError("from must be before to")
+	}
+	span := to.Sub(from)
+	if span > MaxQueryRange {
+		return QueryRange{}, queryError("time range of %s exceeds the maximum of %s", span, MaxQueryRange)
+	}
+	if step == 0 {
+		step = (span / defaultQueryPoints).Truncate(time.Second)
+		if step < minQueryStep {
+			step = minQueryStep
+		}
+	}
+	if step < minQueryStep {
+		return QueryRange{}, queryError("step must be at least %s", minQueryStep)
+	}
+	if points := int64(span / step); points > MaxQueryPoints {
+		return QueryRange{}, queryError("step %s gives %d points, the maximum is %d", step, points, MaxQueryPoints)
+	}
+	return QueryRange{From: from.UTC(), To: to.UTC(), Step: step}, nil
+}
+
+func queryError(format string, a ...interface{}) error {
+	return errors.APIError{
+		Message:    fmt.Sprintf(format, a...),
+		HTTPStatus: http.StatusBadRequest,
+	}
+}
+
+func (s *monitoringService) QueryMeasures(ctx context.Context, mq *MeasureQuery, r QueryRange, clients []QueryClient) ([]QuerySeries, error) {
+	if len(clients) == 0 {
+		return []QuerySeries{}, nil
+	}
+	ids := make([]string, 0, len(clients))
+	for _, c := range clients {
+		ids = append(ids, c.ID)
+	}
+
+	values, err := s.DBProvider.ListMetricValues(ctx, QueryMetrics[mq.Metric], ids, r.From, r.To, MaxQueryValues+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) > MaxQueryValues {
+		return nil, errors.APIError{
+			Message:    fmt.Sprintf("query matches more than %d measurements, narrow the time range or the clients", MaxQueryValues),
+			HTTPStatus: http.StatusUnprocessableEntity,
+		}
+	}
+
+	return evaluateMeasureQuery(mq, r, clients, values), nil
+}
+
+type queryGroup struct {
+	labels map[string]string
+	// buckets holds the values of each client by bucket
+	buckets map[int]map[string][]MetricValue
+}
+
+func evaluateMeasureQuery(mq *MeasureQuery, r QueryRange, clients []QueryClient, values []MetricValue) []QuerySeries {
+	groups := map[string]*queryGroup{}
+	groupsByClient := map[string][]*queryGroup{}
+	for _, c := range clients {
+		for _, labels := range mq.groupLabels(c) {
+			key := labelsKey(labels)
+			g, ok := groups[key]
+			if !ok {
+				g = &queryGroup{labels: labels, buckets: map[int]map[string][]MetricValue{}}
+				groups[key] = g
+			}
+			groupsByClient[c.ID] = append(groupsByClient[c.ID], g)
+		}
+	}
+
+	for _, v := range values {
+		if v.Timestamp.Before(r.From) || v.Timestamp.After(r.To) {
+			continue
+		}
+		bucket := int(v.Timestamp.Sub(r.From) / r.Step)
+		for _, g := range groupsByClient[v.ClientID] {
+			if g.buckets[bucket] == nil {
+				g.buckets[bucket] = map[string][]MetricValue{}
+			}
+			g.buckets[bucket][v.ClientID] = append(g.buckets[bucket][v.ClientID], v)
+		}
+	}
+
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	series := make([]QuerySeries, 0, len(keys))
+	for _, key := range keys {
+		g := groups[key]
+		buckets := make([]int, 0, len(g.buckets))
+		for b := range g.buckets {
+			buckets = append(buckets, b)
+		}
+		sort.Ints(buckets)
+
+		points := make([]QueryPoint, 0, len(buckets))
+		for _, b := range buckets {
+			value, ok := mq.aggregate(g.buckets[b])
+			if !ok {
+				continue
+			}
+			points = append(points, QueryPoint{
+				Timestamp: r.From.Add(time.Duration(b) * r.Step),
+				Value:     value,
+			})
+		}
+		series = append(series, QuerySeries{Labels: g.labels, Points: points})
+	}
+	return series
+}
+
+func (mq *MeasureQuery) groupLabels(c QueryClient) []map[string]string {
+	switch {
+	case mq.GroupBy == QueryGroupByClient:
+		return []map[string]string{{"client_id": c.ID}}
+	case mq.GroupBy == QueryGroupByTag:
+		// a client with several tags takes part in each of their series
+		labels := make([]map[string]string, 0, len(c.Tags))
+		for _, tag := range c.Tags {
+			labels = append(labels, map[string]string{"tag": tag})
+		}
+		return labels
+	case strings.HasPrefix(mq.GroupBy, QueryGroupByLabelPrefix):
+		key := strings.TrimPrefix(mq.GroupBy, QueryGroupByLabelPrefix)
+		value, ok := c.Labels[key]
+		if !ok {
+			return nil
+		}
+		return []map[string]string{{key: value}}
+	}
+	return []map[string]string{{}}
+}
+
+func labelsKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	b := &strings.Builder{}
+	for _, k := range keys {
+		fmt.Fprintf(b, "%s=%s\x00", k, labels[k])
+	}
+	return b.String()
+}
+
+// aggregate returns the value of a bucket holding the values of each client ordered by time.
+func (mq *MeasureQuery) aggregate(byClient map[string][]MetricValue) (float64, bool) {
+	if mq.Function == QueryFunctionRate {
+		// the per second rate of each client, summed over the clients
+		var rate float64
+		ok := false
+		for _, values := range byClient {
+			if len(values) < 2 {
+				continue
+			}
+			first, last := values[0], values[len(values)-1]
+			seconds := last.Timestamp.Sub(first.Timestamp).Seconds()
+			if seconds <= 0 {
+				continue
+			}
+			rate += (last.Value - first.Value) / seconds
+			ok = true
+		}
+		return rate, ok
+	}
+
+	var all []float64
+	for _, values := range byClient {
+		for _, v := range values {
+			all = append(all, v.Value)
+		}
+	}
+	if len(all) == 0 {
+		return 0, false
+	}
+
+	switch mq.Function {
+	case QueryFunctionCount:
+		return float64(len(all)), true
+	case QueryFunctionSum:
+		return sum(all), true
+	case QueryFunctionAvg:
+		return sum(all) / float64(len(all)), true
+	case QueryFunctionMin:
+		min := all[0]
+		for _, v := range all[1:] {
+			min = math.Min(min, v)
+		}
+		return min, true
+	case QueryFunctionMax:
+		max := all[0]
+		for _, v := range all[1:] {
+			max = math.Max(max, v)
+		}
+		return max, true
+	}
+
+	// nearest-rank percentile
+	sort.Float64s(all)
+	rank := int(math.Ceil(float64(mq.Percentile) / 100 * float64(len(all))))
+	if rank < 1 {
+		rank = 1
+	}
+	return all[rank-1], true
+}
+
+func sum(values []float64) float64 {
+	var s float64
+	for _, v := range values {
+		s += v
+	}
+	return s
+}
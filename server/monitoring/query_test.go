@@ -0,0 +1,128 @@
+package monitoring
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMeasureQuery(t *testing.T) {
+	testCases := []struct {
+		query   string
+		want    *MeasureQuery
+		wantErr string
+	}{
+		{
+			query: "avg(cpu_usage_percent)",
+			want:  &MeasureQuery{Function: "avg", Metric: "cpu_usage_percent"},
+		},
+		{
+			query: " p95( memory_usage_percent ) by tag ",
+			want:  &MeasureQuery{Function: "p95", Metric: "memory_usage_percent", GroupBy: "tag", Percentile: 95},
+		},
+		{
+			query: "rate(net_lan_in) by label:site",
+			want:  &MeasureQuery{Function: "rate", Metric: "net_lan_in", GroupBy: "label:site"},
+		},
+		{
+			query:   "cpu_usage_percent",
+			wantErr: `invalid query "cpu_usage_percent", expected "<function>(<metric>) [by client|tag|label:<key>]"`,
+		},
+		{
+			query:   "median(cpu_usage_percent)",
+			wantErr: `unsupported function "median", expected one of avg, min, max, sum, count, rate or p1 to p99`,
+		},
+		{
+			query:   "p100(cpu_usage_percent)",
+			wantErr: `unsupported function "p100", expected one of avg, min, max, sum, count, rate or p1 to p99`,
+		},
+		{
+			query:   "max(processes)",
+			wantErr: `unsupported metric "processes", expected one of cpu_usage_percent, io_usage_percent, memory_usage_percent, net_lan_in, net_lan_out, net_wan_in, net_wan_out`,
+		},
+		{
+			query:   "max(cpu_usage_percent) by label:",
+			wantErr: `unsupported grouping "label:", expected client, tag or label:<key>`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			mq, err := ParseMeasureQuery(tc.query)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, mq)
+		})
+	}
+}
+
+func TestNewQueryRange(t *testing.T) {
+	to := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	r, err := NewQueryRange(time.Time{}, to, 0)
+	require.NoError(t, err)
+	assert.Equal(t, QueryRange{From: to.Add(-time.Hour), To: to, Step: time.Minute}, r)
+
+	_, err = NewQueryRange(to, to, 0)
+	assert.EqualError(t, err, "from must be before to")
+	_, err = NewQueryRange(to.Add(-32*24*time.Hour), to, 0)
+	assert.EqualError(t, err, "time range of 768h0m0s exceeds the maximum of 744h0m0s")
+	_, err = NewQueryRange(to.Add(-24*time.Hour), to, time.Minute)
+	assert.EqualError(t, err, "step 1m0s gives 1440 points, the maximum is 1000")
+}
+
+func TestQueryMeasures(t *testing.T) {
+	dbProvider, err := NewSqliteProvider(":memory:", DataSourceOptions, testLog)
+	require.NoError(t, err)
+	defer dbProvider.Close()
+	ctx := context.Background()
+	require.NoError(t, createTestData(ctx, dbProvider))
+	service := NewService(dbProvider, testLog)
+
+	clients := []QueryClient{
+		{ID: "test_client_1", Tags: []string{"linux", "db"}, Labels: map[string]string{"site": "a"}},
+		{ID: "test_client_2", Tags: []string{"linux"}},
+	}
+	r := QueryRange{From: measurement1, To: measurement3.Add(time.Minute), Step: 2 * time.Minute}
+	query := func(q string) []QuerySeries {
+		mq, err := ParseMeasureQuery(q)
+		require.NoError(t, err)
+		series, err := service.QueryMeasures(ctx, mq, r, clients)
+		require.NoError(t, err)
+		return series
+	}
+
+	assert.Equal(t, []QuerySeries{{
+		Labels: map[string]string{},
+		Points: []QueryPoint{{Timestamp: measurement1, Value: 12.5}, {Timestamp: measurement3, Value: 20}},
+	}}, query("avg(cpu_usage_percent)"))
+
+	assert.Equal(t, []QuerySeries{
+		{
+			Labels: map[string]string{"tag": "db"},
+			Points: []QueryPoint{{Timestamp: measurement1, Value: 15}, {Timestamp: measurement3, Value: 20}},
+		},
+		{
+			Labels: map[string]string{"tag": "linux"},
+			Points: []QueryPoint{{Timestamp: measurement1, Value: 15}, {Timestamp: measurement3, Value: 20}},
+		},
+	}, query("p95(cpu_usage_percent) by tag"))
+
+	bySite := query("max(memory_usage_percent) by label:site")
+	require.Len(t, bySite, 1)
+	assert.Equal(t, map[string]string{"site": "a"}, bySite[0].Labels)
+
+	// test_client_2 has no measurements in the range
+	byClient := query("count(cpu_usage_percent) by client")
+	require.Len(t, byClient, 2)
+	assert.Equal(t, []QueryPoint{{Timestamp: measurement1, Value: 2}, {Timestamp: measurement3, Value: 1}}, byClient[0].Points)
+	assert.Empty(t, byClient[1].Points)
+
+	// 5 percent points per minute in the first bucket, a single value in the second
+	assert.Equal(t, []QueryPoint{{Timestamp: measurement1, Value: 5.0 / 60}}, query("rate(cpu_usage_percent)")[0].Points)
+}
@@ -24,6 +24,9 @@ type Service interface {
 	ListClientGraphMetrics(context.Context, string, *query.ListOptions, *query.RequestInfo, bool, bool) (*api.SuccessPayload, error)
 	ListClientMountpoints(context.Context, string, *query.ListOptions) (*api.SuccessPayload, error)
 	ListClientProcesses(context.Context, string, *query.ListOptions) (*api.SuccessPayload, error)
+	QueryMeasures(ctx context.Context, mq *MeasureQuery, r QueryRange, clients []QueryClient) ([]QuerySeries, error)
+	ForecastDiskUsage(ctx context.Context, clientID string, window time.Duration) ([]DiskForecast, error)
+	StorageByClient(ctx context.Context) (map[string]int64, error)
 }
 
 const layoutAPI = time.RFC3339
@@ -68,6 +71,10 @@ func (s *monitoringService) DeleteMeasurementsOlderThan(ctx context.Context, per
 	return s.DBProvider.DeleteMeasurementsBefore(ctx, compare)
 }
 
+func (s *monitoringService) StorageByClient(ctx context.Context) (map[string]int64, error) {
+	return s.DBProvider.StorageByClient(ctx)
+}
+
 func (s *monitoringService) ListClientGraphMetrics(ctx context.Context, clientID string, lo *query.ListOptions, ri *query.RequestInfo, netLan bool, netWan bool) (*api.SuccessPayload, error) {
 	span, err := s.validateAndParseGraphOptions(lo)
 	if err != nil {
@@ -26,6 +26,9 @@ type DBProvider interface {
 	ListMountpointsByClientID(context.Context, string, *query.ListOptions) ([]*ClientMountpointsPayload, error)
 	ListProcessesByClientID(context.Context, string, *query.ListOptions) ([]*ClientProcessesPayload, error)
 	CountByClientID(context.Context, string, *query.ListOptions) (int, error)
+	ListMountpointsSince(ctx context.Context, clientID string, since time.Time) ([]*ClientMountpointsPayload, error)
+	ListMetricValues(ctx context.Context, column string, clientIDs []string, from, to time.Time, limit int) ([]MetricValue, error)
+	StorageByClient(ctx context.Context) (map[string]int64, error)
 	Close() error
 }
 
@@ -33,6 +36,9 @@ type DBProvider interface {
 // clean them in chunks and this is the chunk size
 const MaxDeletedEntries = 5000
 
+// measurementFixedBytes approximates the stored size of the numeric columns of a measurement
+const measurementFixedBytes = 80
+
 type SqliteProvider struct {
 	db        *sqlx.DB
 	logger    *logger.Logger
@@ -211,6 +217,58 @@ func (p *SqliteProvider) DeleteMeasurementsBefore(ctx context.Context, compare t
 	return result.RowsAffected()
 }
 
+// ListMountpointsSince returns a measurement of the mountpoints of a client per hour ordered by time.
+func (p *SqliteProvider) ListMountpointsSince(ctx context.Context, clientID string, since time.Time) ([]*ClientMountpointsPayload, error) {
+	q := `SELECT timestamp, mountpoints FROM measurements
+		WHERE client_id = ? AND timestamp >= ? AND mountpoints IS NOT NULL AND mountpoints != ''
+		GROUP BY strftime('%Y-%m-%d %H', timestamp) ORDER BY timestamp`
+
+	val := []*ClientMountpointsPayload{}
+	err := p.db.SelectContext(ctx, &val, q, clientID, since.UTC().Format(layoutDb))
+	return val, err
+}
+
+// ListMetricValues returns the non null values of a column of the measurements of the given clients ordered by client
+// and time.
+func (p *SqliteProvider) ListMetricValues(ctx context.Context, column string, clientIDs []string, from, to time.Time, limit int) ([]MetricValue, error) {
+	q, params, err := sqlx.In(
+		"SELECT client_id, timestamp, "+column+" AS value FROM measurements WHERE client_id IN (?) AND timestamp >= ? AND timestamp <= ? AND "+column+" IS NOT NULL ORDER BY client_id, timestamp LIMIT ?",
+		clientIDs,
+		from.UTC().Format(layoutDb),
+		to.UTC().Add(time.Second).Format(layoutDb),
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	val := []MetricValue{}
+	err = p.db.SelectContext(ctx, &val, p.db.Rebind(q), params...)
+	return val, err
+}
+
+// StorageByClient returns the approximate bytes of the measurements stored per client.
+func (p *SqliteProvider) StorageByClient(ctx context.Context) (map[string]int64, error) {
+	var rows []struct {
+		ClientID string `db:"client_id"`
+		Bytes    int64  `db:"bytes"`
+	}
+	err := p.db.SelectContext(
+		ctx,
+		&rows,
+		"SELECT client_id, SUM(IFNULL(LENGTH(processes), 0) + IFNULL(LENGTH(mountpoints), 0) + ?) AS bytes FROM measurements GROUP BY client_id",
+		measurementFixedBytes,
+	)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]int64, len(rows))
+	for _, r := range rows {
+		res[r.ClientID] = r.Bytes
+	}
+	return res, nil
+}
+
 func (p *SqliteProvider) Close() error {
 	return p.db.Close()
 }
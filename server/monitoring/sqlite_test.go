@@ -111,6 +111,25 @@ func TestSqliteProvider_DeleteMeasurementsBefore(t *testing.T) {
 	require.Equal(t, int64(2), deleted)
 }
 
+func TestSqliteProvider_StorageByClient(t *testing.T) {
+	dbProvider, err := NewSqliteProvider(":memory:", DataSourceOptions, testLog)
+	require.NoError(t, err)
+	defer dbProvider.Close()
+
+	ctx := context.Background()
+
+	err = createTestData(ctx, dbProvider)
+	require.NoError(t, err)
+
+	var expected int64
+	for _, m := range testData {
+		expected += int64(len(m.Processes) + len(m.Mountpoints) + measurementFixedBytes)
+	}
+	storage, err := dbProvider.StorageByClient(ctx)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{"test_client_1": expected}, storage)
+}
+
 func TestSqliteProvider_CountByClientID(t *testing.T) {
 	dbProvider, err := NewSqliteProvider(":memory:", DataSourceOptions, testLog)
 	require.NoError(t, err)
@@ -7,31 +7,36 @@ import (
 	"fmt"
 	"text/template"
 
+	"github.com/IOTech17/neo-rport/server/branding"
 	"github.com/IOTech17/neo-rport/server/notifications"
 	"github.com/IOTech17/neo-rport/share/logger"
 )
 
 type consumer struct {
-	mailer Mailer
+	mailer   Mailer
+	branding *branding.Provider
 
 	l *logger.Logger
 }
 
 //nolint:revive
-func NewConsumer(mailer Mailer, l *logger.Logger) *consumer {
-	return &consumer{mailer: mailer, l: l}
+func NewConsumer(mailer Mailer, brand *branding.Provider, l *logger.Logger) *consumer {
+	return &consumer{mailer: mailer, branding: brand, l: l}
 }
 
 func (c consumer) Process(ctx context.Context, details notifications.NotificationDetails) (string, error) {
+	b := c.branding.Get()
 	content := details.Data.Content
 	if ContentType(details.Data.ContentType) == ContentTypeTextHTML {
 		var err error
-		content, err = WrapWithTemplate(details.Data.Content)
+		content, err = WrapWithTemplate(details.Data.Content, b)
 		if err != nil {
 			return "", fmt.Errorf("failed preparing notification to dispatch: %v", err)
 		}
+	} else if b.EmailFooter != "" {
+		content += "\n\n-- \n" + b.EmailFooter
 	}
-	err := c.mailer.Send(ctx, details.Data.Recipients, details.Data.Subject, ContentType(details.Data.ContentType), content)
+	err := c.mailer.Send(ctx, details.Data.Recipients, b.Subject(details.Data.Subject), ContentType(details.Data.ContentType), content)
 	if err != nil {
 		c.l.Errorf("unable to send smtp message: %s, %v", details.RefID, err)
 		return "", err
@@ -48,7 +53,8 @@ func (c consumer) Target() notifications.Target {
 //go:embed mailTemplate.tmpl
 var mailTemplate string
 
-func WrapWithTemplate(content string) (string, error) {
+// WrapWithTemplate renders HTML content into the mail template with the logo and the footer of the branding.
+func WrapWithTemplate(content string, b branding.Branding) (string, error) {
 	tmpl, err := template.New("mail").Parse(mailTemplate)
 	if err != nil {
 		return "", err
@@ -56,8 +62,16 @@ func WrapWithTemplate(content string) (string, error) {
 
 	var buf bytes.Buffer
 	err = tmpl.Execute(&buf, struct {
-		Body string
-	}{Body: content})
+		Body        string
+		ProductName string
+		LogoURL     string
+		Footer      string
+	}{
+		Body:        content,
+		ProductName: b.GetProductName(),
+		LogoURL:     b.LogoURL,
+		Footer:      b.EmailFooter,
+	})
 
 	return buf.String(), err
 }
@@ -5,12 +5,30 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/IOTech17/neo-rport/server/branding"
 	"github.com/IOTech17/neo-rport/server/notifications/channels/rmailer"
 )
 
 func TestNotEscapedMail(t *testing.T) {
 	test := "<b>test</b><script>alert('powned!');</script>"
-	content, err := rmailer.WrapWithTemplate(test)
+	content, err := rmailer.WrapWithTemplate(test, branding.Branding{})
 	assert.NoError(t, err)
 	assert.Contains(t, content, test)
 }
+
+func TestBrandedMail(t *testing.T) {
+	content, err := rmailer.WrapWithTemplate("<p>body</p>", branding.Branding{
+		ProductName: "Acme Remote",
+		LogoURL:     "https://acme.example.com/logo.png",
+		EmailFooter: "<a href=\"https://acme.example.com\">Acme Ltd.</a>",
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, content, "<title>Acme Remote</title>")
+	assert.Contains(t, content, `<img src="https://acme.example.com/logo.png" alt="Acme Remote"`)
+	assert.Contains(t, content, `<p><a href="https://acme.example.com">Acme Ltd.</a></p>`)
+
+	content, err = rmailer.WrapWithTemplate("<p>body</p>", branding.Branding{})
+	assert.NoError(t, err)
+	assert.Contains(t, content, "<title>RPort</title>")
+	assert.NotContains(t, content, "<img")
+}
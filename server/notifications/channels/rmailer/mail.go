@@ -10,6 +10,7 @@ import (
 
 	"github.com/wneessen/go-mail"
 
+	"github.com/IOTech17/neo-rport/server/branding"
 	"github.com/IOTech17/neo-rport/server/chconfig"
 	"github.com/IOTech17/neo-rport/share/logger"
 )
@@ -51,6 +52,7 @@ const (
 
 type rMailer struct {
 	config    Config
+	branding  *branding.Provider
 	doomQueue chan struct{}
 
 	l *logger.Logger
@@ -86,7 +88,7 @@ func (rm rMailer) Send(ctx context.Context, to []string, subject string, content
 func (rm rMailer) send(ctx context.Context, to []string, subject string, contentType ContentType, body string) error {
 	m := mail.NewMsg()
 
-	if err := m.From(rm.config.From); err != nil {
+	if err := m.From(rm.branding.Get().Sender(rm.config.From)); err != nil {
 		return fmt.Errorf("failed to set From address: %s", err)
 	}
 	if err := m.To(to...); err != nil {
@@ -155,9 +157,12 @@ type AuthUserPass struct {
 	Pass string
 }
 
-func NewRMailer(config Config, l *logger.Logger) Mailer {
+// NewRMailer returns a mailer sending from the sender of the branding, a nil branding sends from the configured
+// address.
+func NewRMailer(config Config, brand *branding.Provider, l *logger.Logger) Mailer {
 	return rMailer{
 		config:    config,
+		branding:  brand,
 		doomQueue: make(chan struct{}, MaxHangingMailSends),
 		l:         l,
 	}
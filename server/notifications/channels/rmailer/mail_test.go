@@ -41,7 +41,7 @@ func (ts *MailTestSuite) SetupSuite() {
 		TLS:      false,
 		AuthType: rmailer.AuthTypeNone,
 		NoNoop:   true,
-	}, testLog)
+	}, nil, testLog)
 
 	if err := ts.server.Start(); err != nil {
 		fmt.Println(err)
@@ -99,7 +99,7 @@ func (ts *MailTestSuite) mailerFromPort(port int) rmailer.Mailer {
 		TLS:      false,
 		AuthType: rmailer.AuthTypeNone,
 		NoNoop:   true,
-	}, testLog)
+	}, nil, testLog)
 }
 
 func (ts *MailTestSuite) neverRespondingSMTPServer(port int) {
@@ -0,0 +1,255 @@
+package notifications
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/refs"
+)
+
+// Severities of notifications in ascending order.
+const (
+	SeverityInfo     = "info"
+	SeverityLow      = "low"
+	SeverityMedium   = "medium"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+)
+
+var severityRanks = map[string]int{
+	SeverityInfo:     1,
+	SeverityLow:      2,
+	SeverityMedium:   3,
+	SeverityHigh:     4,
+	SeverityCritical: 5,
+}
+
+const (
+	DigestType refs.IdentifiableType = "notification-digest"
+
+	DefaultDigestImmediateSeverity = SeverityHigh
+	MinDigestIntervalSec           = 60
+	// DigestCheckInterval is how often pending digests are checked, digests are sent up to this late
+	DigestCheckInterval = time.Minute
+)
+
+// DigestSettings batch the mail notifications of a recipient into periodic digests. Notifications of a severity
+// of at least ImmediateSeverity are sent immediately.
+type DigestSettings struct {
+	Recipient         string `json:"recipient" db:"recipient"`
+	IntervalSec       int    `json:"interval_sec" db:"interval_sec"`
+	ImmediateSeverity string `json:"immediate_severity" db:"immediate_severity"`
+	// IncludeUnclassified adds notifications without a severity to the digest, they are sent immediately otherwise.
+	IncludeUnclassified bool `json:"include_unclassified" db:"include_unclassified"`
+}
+
+func (s *DigestSettings) Validate() error {
+	if s.IntervalSec < MinDigestIntervalSec {
+		return errors2.APIError{
+			Message:    fmt.Sprintf("interval_sec must be at least %d", MinDigestIntervalSec),
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+	if s.ImmediateSeverity == "" {
+		s.ImmediateSeverity = DefaultDigestImmediateSeverity
+	}
+	if _, ok := severityRanks[s.ImmediateSeverity]; !ok {
+		return errors2.APIError{
+			Message:    fmt.Sprintf("invalid immediate_severity %q, expected one of %s", s.ImmediateSeverity, strings.Join(severities(), ", ")),
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+	return nil
+}
+
+func (s DigestSettings) digests(severity string) bool {
+	if severity == "" {
+		return s.IncludeUnclassified
+	}
+	rank, ok := severityRanks[severity]
+	return ok && rank < severityRanks[s.ImmediateSeverity]
+}
+
+func severities() []string {
+	return []string{SeverityInfo, SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical}
+}
+
+// DigestItem is a notification waiting for the next digest of the recipient.
+type DigestItem struct {
+	ID          int64       `db:"id"`
+	Recipient   string      `db:"recipient"`
+	CreatedAt   time.Time   `db:"created_at"`
+	Severity    string      `db:"severity"`
+	Subject     string      `db:"subject"`
+	Content     string      `db:"content"`
+	ContentType ContentType `db:"content_type"`
+}
+
+type DigestStore interface {
+	GetDigestSettings(ctx context.Context, recipient string) (*DigestSettings, error)
+	ListDigestSettings(ctx context.Context) ([]DigestSettings, error)
+	SaveDigestSettings(ctx context.Context, settings DigestSettings) error
+	DeleteDigestSettings(ctx context.Context, recipient string) error
+	AddDigestItem(ctx context.Context, item DigestItem) error
+	// ListDigestItems returns the pending items of the recipient, oldest first
+	ListDigestItems(ctx context.Context, recipient string) ([]DigestItem, error)
+	DeleteDigestItems(ctx context.Context, recipient string, maxID int64) error
+}
+
+// DigestDispatcher holds back mail notifications for recipients with digest settings and sends them as a periodic
+// summary, all other notifications are passed on immediately.
+type DigestDispatcher struct {
+	next   Dispatcher
+	store  DigestStore
+	logger *logger.Logger
+	now    func() time.Time
+
+	// flushMu prevents sending the same items twice
+	flushMu sync.Mutex
+}
+
+func NewDigestDispatcher(next Dispatcher, store DigestStore, l *logger.Logger) *DigestDispatcher {
+	return &DigestDispatcher{
+		next:   next,
+		store:  store,
+		logger: l,
+		now:    time.Now,
+	}
+}
+
+func (d *DigestDispatcher) Dispatch(ctx context.Context, refID refs.Identifiable, notification NotificationData) (refs.Identifiable, error) {
+	if FigureOutTarget(notification.Target) != TargetMail {
+		return d.next.Dispatch(ctx, refID, notification)
+	}
+	if err := notification.ContentType.Valid(); err != nil {
+		return nil, err
+	}
+
+	var immediate []string
+	for _, recipient := range notification.Recipients {
+		settings, err := d.store.GetDigestSettings(ctx, recipient)
+		if err != nil {
+			d.logger.Errorf("Failed to get digest settings of %q, sending immediately: %v", recipient, err)
+		}
+		if settings == nil || !settings.digests(notification.Severity) {
+			immediate = append(immediate, recipient)
+			continue
+		}
+
+		err = d.store.AddDigestItem(ctx, DigestItem{
+			Recipient:   recipient,
+			CreatedAt:   d.now(),
+			Severity:    notification.Severity,
+			Subject:     notification.Subject,
+			Content:     notification.Content,
+			ContentType: notification.ContentType,
+		})
+		if err != nil {
+			d.logger.Errorf("Failed to add notification to the digest of %q, sending immediately: %v", recipient, err)
+			immediate = append(immediate, recipient)
+		}
+	}
+
+	if len(immediate) == 0 {
+		return refs.GenerateIdentifiable(DigestType), nil
+	}
+	notification.Recipients = immediate
+	return d.next.Dispatch(ctx, refID, notification)
+}
+
+// Run sends the due digests until the context is canceled.
+func (d *DigestDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(DigestCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := d.SendDue(ctx); err != nil {
+				d.logger.Errorf("Failed to send notification digests: %v", err)
+			}
+		}
+	}
+}
+
+// SendDue sends the digests of all recipients whose oldest pending item waits for at least the digest interval.
+func (d *DigestDispatcher) SendDue(ctx context.Context) error {
+	all, err := d.store.ListDigestSettings(ctx)
+	if err != nil {
+		return err
+	}
+	for _, settings := range all {
+		interval := time.Duration(settings.IntervalSec) * time.Second
+		if err := d.send(ctx, settings.Recipient, interval); err != nil {
+			d.logger.Errorf("Failed to send notification digest to %q: %v", settings.Recipient, err)
+		}
+	}
+	return nil
+}
+
+// SendNow sends the pending items of the recipient regardless of the interval.
+func (d *DigestDispatcher) SendNow(ctx context.Context, recipient string) error {
+	return d.send(ctx, recipient, 0)
+}
+
+func (d *DigestDispatcher) send(ctx context.Context, recipient string, minAge time.Duration) error {
+	d.flushMu.Lock()
+	defer d.flushMu.Unlock()
+
+	items, err := d.store.ListDigestItems(ctx, recipient)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 || d.now().Sub(items[0].CreatedAt) < minAge {
+		return nil
+	}
+
+	notification := digestNotification(recipient, items)
+	if _, err := d.next.Dispatch(ctx, refs.NewIdentifiable(DigestType, recipient), notification); err != nil {
+		return err
+	}
+	return d.store.DeleteDigestItems(ctx, recipient, items[len(items)-1].ID)
+}
+
+func digestNotification(recipient string, items []DigestItem) NotificationData {
+	notification := NotificationData{
+		Target:      string(TargetMail),
+		Recipients:  []string{recipient},
+		Subject:     fmt.Sprintf("[rport] Digest of %d notifications", len(items)),
+		ContentType: ContentTypeTextPlain,
+	}
+	for _, item := range items {
+		if item.ContentType == ContentTypeTextHTML {
+			notification.ContentType = ContentTypeTextHTML
+			break
+		}
+	}
+
+	var b strings.Builder
+	for _, item := range items {
+		header := item.CreatedAt.UTC().Format(time.RFC3339)
+		if item.Severity != "" {
+			header += " [" + item.Severity + "]"
+		}
+		header += " " + item.Subject
+		if notification.ContentType == ContentTypeTextPlain {
+			fmt.Fprintf(&b, "%s\n\n%s\n\n---\n\n", header, item.Content)
+			continue
+		}
+		content := item.Content
+		if item.ContentType != ContentTypeTextHTML {
+			content = "<pre>" + html.EscapeString(content) + "</pre>"
+		}
+		fmt.Fprintf(&b, "<h3>%s</h3>\n%s\n<hr>\n", html.EscapeString(header), content)
+	}
+	notification.Content = b.String()
+	return notification
+}
@@ -0,0 +1,163 @@
+package notifications_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	"github.com/IOTech17/neo-rport/server/notifications"
+	repo "github.com/IOTech17/neo-rport/server/notifications/repository/sqlite"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/refs"
+)
+
+type recordingDispatcher struct {
+	dispatched []notifications.NotificationData
+}
+
+func (d *recordingDispatcher) Dispatch(_ context.Context, _ refs.Identifiable, notification notifications.NotificationData) (refs.Identifiable, error) {
+	d.dispatched = append(d.dispatched, notification)
+	return refs.GenerateIdentifiable(notifications.NotificationType), nil
+}
+
+type DigestDispatcherTestSuite struct {
+	suite.Suite
+	next       *recordingDispatcher
+	store      *repo.DigestRepository
+	dispatcher *notifications.DigestDispatcher
+}
+
+func (suite *DigestDispatcherTestSuite) SetupTest() {
+	db, err := sqlite.New(":memory:", repo.AssetNames(), repo.Asset, sqlite.DataSourceOptions{})
+	suite.Require().NoError(err)
+	suite.store = repo.NewDigestRepository(db)
+	suite.next = &recordingDispatcher{}
+	suite.dispatcher = notifications.NewDigestDispatcher(suite.next, suite.store, logger.NewLogger("digest", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug))
+
+	suite.Require().NoError(suite.store.SaveDigestSettings(context.Background(), notifications.DigestSettings{
+		Recipient:         "ops@example.com",
+		IntervalSec:       3600,
+		ImmediateSeverity: notifications.SeverityHigh,
+	}))
+}
+
+func (suite *DigestDispatcherTestSuite) dispatch(severity string, recipients ...string) {
+	_, err := suite.dispatcher.Dispatch(context.Background(), problemIdentifiable, notifications.NotificationData{
+		Target:      "smtp",
+		Recipients:  recipients,
+		Subject:     "disk " + severity,
+		Content:     "disk almost full",
+		ContentType: notifications.ContentTypeTextPlain,
+		Severity:    severity,
+	})
+	suite.Require().NoError(err)
+}
+
+func (suite *DigestDispatcherTestSuite) TestSplitsRecipients() {
+	suite.dispatch(notifications.SeverityLow, "ops@example.com", "admin@example.com")
+	suite.dispatch(notifications.SeverityCritical, "ops@example.com")
+	suite.dispatch("", "ops@example.com")
+
+	suite.Require().Len(suite.next.dispatched, 3)
+	suite.Equal([]string{"admin@example.com"}, suite.next.dispatched[0].Recipients)
+	suite.Equal("disk critical", suite.next.dispatched[1].Subject)
+	suite.Equal([]string{"ops@example.com"}, suite.next.dispatched[2].Recipients)
+
+	items, err := suite.store.ListDigestItems(context.Background(), "ops@example.com")
+	suite.NoError(err)
+	suite.Len(items, 1)
+}
+
+func (suite *DigestDispatcherTestSuite) TestScriptsAreNotDigested() {
+	_, err := suite.dispatcher.Dispatch(context.Background(), problemIdentifiable, notifications.NotificationData{
+		Target:      "notify.sh",
+		Recipients:  []string{"ops@example.com"},
+		ContentType: notifications.ContentTypeTextPlain,
+		Severity:    notifications.SeverityLow,
+	})
+	suite.NoError(err)
+	suite.Len(suite.next.dispatched, 1)
+}
+
+func (suite *DigestDispatcherTestSuite) TestSendDue() {
+	ctx := context.Background()
+	suite.Require().NoError(suite.store.AddDigestItem(ctx, notifications.DigestItem{
+		Recipient:   "ops@example.com",
+		CreatedAt:   time.Now().Add(-2 * time.Hour),
+		Severity:    notifications.SeverityLow,
+		Subject:     "disk low",
+		Content:     "disk almost full",
+		ContentType: notifications.ContentTypeTextPlain,
+	}))
+	suite.dispatch(notifications.SeverityMedium, "ops@example.com")
+	suite.Empty(suite.next.dispatched)
+
+	suite.NoError(suite.dispatcher.SendDue(ctx))
+
+	suite.Require().Len(suite.next.dispatched, 1)
+	digest := suite.next.dispatched[0]
+	suite.Equal([]string{"ops@example.com"}, digest.Recipients)
+	suite.Equal("[rport] Digest of 2 notifications", digest.Subject)
+	suite.Equal(notifications.ContentTypeTextPlain, digest.ContentType)
+	suite.Contains(digest.Content, "[low] disk low\n\ndisk almost full")
+	suite.Contains(digest.Content, "[medium] disk medium")
+
+	// nothing left
+	suite.NoError(suite.dispatcher.SendDue(ctx))
+	suite.Len(suite.next.dispatched, 1)
+}
+
+func (suite *DigestDispatcherTestSuite) TestSendDueWaitsForInterval() {
+	suite.dispatch(notifications.SeverityLow, "ops@example.com")
+	suite.NoError(suite.dispatcher.SendDue(context.Background()))
+	suite.Empty(suite.next.dispatched)
+
+	suite.NoError(suite.dispatcher.SendNow(context.Background(), "ops@example.com"))
+	suite.Len(suite.next.dispatched, 1)
+}
+
+func (suite *DigestDispatcherTestSuite) TestHTMLDigest() {
+	ctx := context.Background()
+	suite.dispatch(notifications.SeverityLow, "ops@example.com")
+	_, err := suite.dispatcher.Dispatch(ctx, problemIdentifiable, notifications.NotificationData{
+		Target:      "smtp",
+		Recipients:  []string{"ops@example.com"},
+		Subject:     "report",
+		Content:     "<b>all good</b>",
+		ContentType: notifications.ContentTypeTextHTML,
+		Severity:    notifications.SeverityInfo,
+	})
+	suite.Require().NoError(err)
+
+	suite.NoError(suite.dispatcher.SendNow(ctx, "ops@example.com"))
+	suite.Require().Len(suite.next.dispatched, 1)
+	digest := suite.next.dispatched[0]
+	suite.Equal(notifications.ContentTypeTextHTML, digest.ContentType)
+	suite.Contains(digest.Content, "<pre>disk almost full</pre>")
+	suite.Contains(digest.Content, "<b>all good</b>")
+}
+
+func TestDigestDispatcherTestSuite(t *testing.T) {
+	suite.Run(t, new(DigestDispatcherTestSuite))
+}
+
+func TestDigestSettingsValidate(t *testing.T) {
+	settings := notifications.DigestSettings{Recipient: "ops@example.com", IntervalSec: 3600}
+	if err := settings.Validate(); err != nil || settings.ImmediateSeverity != notifications.SeverityHigh {
+		t.Fatalf("unexpected result: %v, %q", err, settings.ImmediateSeverity)
+	}
+
+	settings = notifications.DigestSettings{IntervalSec: 10}
+	if err := settings.Validate(); err == nil || err.Error() != "interval_sec must be at least 60" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	settings = notifications.DigestSettings{IntervalSec: 3600, ImmediateSeverity: "urgent"}
+	if err := settings.Validate(); err == nil || err.Error() != `invalid immediate_severity "urgent", expected one of info, low, medium, high, critical` {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
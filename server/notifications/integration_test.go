@@ -55,7 +55,7 @@ func (suite *NotificationsIntegrationTestSuite) SetupTest() {
 		TLS:      false,
 		AuthType: rmailer.AuthTypeNone,
 		NoNoop:   true,
-	}, testLog), testLog)
+	}, nil, testLog), nil, testLog)
 
 	dir, err := os.Getwd()
 	suite.NoError(err)
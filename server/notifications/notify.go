@@ -35,6 +35,9 @@ type NotificationData struct {
 	Subject     string      `json:"subject"`
 	Content     string      `json:"content"`
 	ContentType ContentType `json:"content_type"`
+	// Severity decides if the notification can wait for a digest, notifications without are always sent immediately
+	// unless the digest settings of the recipient include unclassified notifications.
+	Severity string `json:"severity,omitempty"`
 }
 
 const NotificationType refs.IdentifiableType = "notification"
@@ -2,6 +2,8 @@
 // sources:
 // 001_init.down.sql (29B)
 // 001_init.up.sql (1.394kB)
+// 002_digests.down.sql (53B)
+// 002_digests.up.sql (613B)
 
 package sqlite
 
@@ -84,7 +86,7 @@ func _001_initDownSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "001_init.down.sql", size: 29, mode: os.FileMode(0644), modTime: time.Unix(1688094241, 0)}
+	info := bindataFileInfo{name: "001_init.down.sql", size: 29, mode: os.FileMode(0644), modTime: time.Unix(1689661695, 0)}
 	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x1e, 0xdc, 0x32, 0xb, 0xf2, 0x33, 0xb6, 0x50, 0x9e, 0x36, 0x9a, 0x12, 0x8c, 0xea, 0x4e, 0x29, 0x51, 0xab, 0x6d, 0x90, 0x1f, 0x8d, 0x6c, 0x7b, 0x4e, 0x7e, 0xf, 0x26, 0x3d, 0x48, 0x1a, 0x96}}
 	return a, nil
 }
@@ -109,6 +111,46 @@ func _001_initUpSql() (*asset, error) {
 	return a, nil
 }
 
+var __002_digestsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x09\xf2\x0f\x50\x08\x71\x74\xf2\x71\x55\x48\xc9\x4c\x4f\x2d\x2e\x89\xcf\x2c\x49\xcd\x2d\xb6\xe6\xc2\x94\x28\x4e\x2d\x29\xc9\xcc\x4b\x2f\xb6\xe6\x02\x0c\x00\x5f\xc5\x29\x1d\x35\x00\x00\x00")
+
+func _002_digestsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__002_digestsDownSql,
+		"002_digests.down.sql",
+	)
+}
+
+func _002_digestsDownSql() (*asset, error) {
+	bytes, err := _002_digestsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "002_digests.down.sql", size: 53, mode: os.FileMode(0644), modTime: time.Unix(1689661695, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x20, 0x41, 0x1b, 0xb4, 0xdf, 0x68, 0xb0, 0x7a, 0x55, 0xf2, 0x93, 0xf, 0x4f, 0xe5, 0x99, 0xa0, 0xab, 0x68, 0x10, 0x27, 0xfe, 0x52, 0xb8, 0x5d, 0xe9, 0xfb, 0x60, 0xed, 0x54, 0x4e, 0x85, 0x97}}
+	return a, nil
+}
+
+var __002_digestsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x90\x41\x6b\xf2\x40\x10\x86\xef\xf9\x15\xf3\x79\xd1\x80\x07\xf9\xa0\x27\xe9\x61\x8d\xd3\x1a\x8c\x9b\xb2\xac\x45\x4f\x4b\xba\x3b\x95\x2d\xba\x95\xec\x28\xf5\xdf\x17\xac\x8d\x21\x88\xf4\x3c\x0f\xef\xbc\xef\x93\x29\x14\x1a\x41\x8b\x49\x81\xe0\xfc\x86\x22\x9b\x48\xcc\x3e\x6c\x22\x0c\x12\x00\x80\x9a\xac\xdf\x7b\x0a\x0c\x1a\x57\x1a\x64\xa9\x41\x2e\x8b\x02\x5e\x54\xbe\x10\x6a\x0d\x73\x5c\x43\x36\xc3\x6c\x0e\x83\x2b\xfa\xef\x11\xfa\xfd\x74\x78\x0e\xf0\x81\xa9\x3e\x56\x5b\x13\xc9\x42\x2e\x35\x3e\xa3\x6a\x62\x2e\xc8\x6e\x47\xce\x57\x4c\x26\xd2\x91\x6a\xcf\x27\x78\x15\x2a\x9b\x09\x35\xf8\x3f\x4a\xbb\x70\xb0\xdb\x83\x23\x73\x08\x76\x5b\xc5\xe8\xdf\x3d\x39\x98\x94\x65\x81\x42\x5e\xeb\x4d\xf1\x49\x2c\x0b\x0d\xa3\x24\x1d\x27\xc9\xad\x9d\x9e\x69\xf7\x3b\xd2\xbb\xa6\x59\x7b\x97\x58\xea\x32\x97\x99\xc2\x05\x4a\x3d\xbc\xa7\xe3\xe7\x68\x6b\xaa\x98\x9c\xa9\x18\xa6\x42\xa3\xce\x17\xd8\x21\xee\xee\x6b\x4a\xf7\x7a\x17\xfa\xf0\xf6\x41\xb6\x6b\xbe\x4b\xd9\xcf\xc0\x14\xfe\x48\x19\x3e\xed\xa9\xf9\xfe\x70\xfb\x7b\xdb\x59\x2e\xa7\xb8\x02\xef\xbe\x4c\xdb\x9b\x69\x3c\x9c\xc3\x4b\xd9\xb1\xda\x9c\xd3\x71\xf2\x3d\x00\xb9\x70\x0b\x54\x65\x02\x00\x00")
+
+func _002_digestsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__002_digestsUpSql,
+		"002_digests.up.sql",
+	)
+}
+
+func _002_digestsUpSql() (*asset, error) {
+	bytes, err := _002_digestsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "002_digests.up.sql", size: 613, mode: os.FileMode(0644), modTime: time.Unix(1689661695, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x35, 0x7f, 0xaf, 0x58, 0xce, 0x69, 0x7e, 0x82, 0xac, 0x54, 0xcc, 0xa8, 0xeb, 0x5e, 0xd0, 0x85, 0xe1, 0x46, 0xc9, 0xa6, 0xfc, 0xbb, 0x53, 0x51, 0x43, 0xd3, 0x79, 0x71, 0xb8, 0x1c, 0xd8, 0x80}}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -200,8 +242,10 @@ func AssetNames() []string {
 
 // _bindata is a table, holding each asset generator, mapped to its name.
 var _bindata = map[string]func() (*asset, error){
-	"001_init.down.sql": _001_initDownSql,
-	"001_init.up.sql":   _001_initUpSql,
+	"001_init.down.sql":    _001_initDownSql,
+	"001_init.up.sql":      _001_initUpSql,
+	"002_digests.down.sql": _002_digestsDownSql,
+	"002_digests.up.sql":   _002_digestsUpSql,
 }
 
 // AssetDebug is true if the assets were built with the debug flag enabled.
@@ -250,8 +294,10 @@ type bintree struct {
 }
 
 var _bintree = &bintree{nil, map[string]*bintree{
-	"001_init.down.sql": {_001_initDownSql, map[string]*bintree{}},
-	"001_init.up.sql":   {_001_initUpSql, map[string]*bintree{}},
+	"001_init.down.sql":    {_001_initDownSql, map[string]*bintree{}},
+	"001_init.up.sql":      {_001_initUpSql, map[string]*bintree{}},
+	"002_digests.down.sql": {_002_digestsDownSql, map[string]*bintree{}},
+	"002_digests.up.sql":   {_002_digestsUpSql, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory.
@@ -0,0 +1,75 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/IOTech17/neo-rport/server/notifications"
+)
+
+type DigestRepository struct {
+	db *sqlx.DB
+}
+
+func NewDigestRepository(db *sqlx.DB) *DigestRepository {
+	return &DigestRepository{
+		db: db,
+	}
+}
+
+func (r *DigestRepository) GetDigestSettings(ctx context.Context, recipient string) (*notifications.DigestSettings, error) {
+	settings := &notifications.DigestSettings{}
+	err := r.db.GetContext(ctx, settings, "SELECT * FROM digest_settings WHERE recipient = ?", recipient)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return settings, nil
+}
+
+func (r *DigestRepository) ListDigestSettings(ctx context.Context) ([]notifications.DigestSettings, error) {
+	all := []notifications.DigestSettings{}
+	err := r.db.SelectContext(ctx, &all, "SELECT * FROM digest_settings ORDER BY recipient")
+	return all, err
+}
+
+func (r *DigestRepository) SaveDigestSettings(ctx context.Context, settings notifications.DigestSettings) error {
+	_, err := r.db.NamedExecContext(
+		ctx,
+		`INSERT OR REPLACE INTO digest_settings (recipient, interval_sec, immediate_severity, include_unclassified)
+		VALUES (:recipient, :interval_sec, :immediate_severity, :include_unclassified)`,
+		settings,
+	)
+	return err
+}
+
+func (r *DigestRepository) DeleteDigestSettings(ctx context.Context, recipient string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM digest_settings WHERE recipient = ?", recipient)
+	return err
+}
+
+func (r *DigestRepository) AddDigestItem(ctx context.Context, item notifications.DigestItem) error {
+	_, err := r.db.NamedExecContext(
+		ctx,
+		`INSERT INTO digest_items (recipient, created_at, severity, subject, content, content_type)
+		VALUES (:recipient, :created_at, :severity, :subject, :content, :content_type)`,
+		item,
+	)
+	return err
+}
+
+func (r *DigestRepository) ListDigestItems(ctx context.Context, recipient string) ([]notifications.DigestItem, error) {
+	items := []notifications.DigestItem{}
+	err := r.db.SelectContext(ctx, &items, "SELECT * FROM digest_items WHERE recipient = ? ORDER BY id", recipient)
+	return items, err
+}
+
+func (r *DigestRepository) DeleteDigestItems(ctx context.Context, recipient string, maxID int64) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM digest_items WHERE recipient = ? AND id <= ?", recipient, maxID)
+	return err
+}
@@ -0,0 +1,87 @@
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	"github.com/IOTech17/neo-rport/server/notifications"
+	repo "github.com/IOTech17/neo-rport/server/notifications/repository/sqlite"
+)
+
+type DigestRepositoryTestSuite struct {
+	suite.Suite
+	repository *repo.DigestRepository
+}
+
+func (suite *DigestRepositoryTestSuite) SetupTest() {
+	db, err := sqlite.New(":memory:", repo.AssetNames(), repo.Asset, sqlite.DataSourceOptions{})
+	suite.NoError(err)
+
+	suite.repository = repo.NewDigestRepository(db)
+}
+
+func (suite *DigestRepositoryTestSuite) TestSettings() {
+	ctx := context.Background()
+	settings, err := suite.repository.GetDigestSettings(ctx, "ops@example.com")
+	suite.NoError(err)
+	suite.Nil(settings)
+
+	saved := notifications.DigestSettings{Recipient: "ops@example.com", IntervalSec: 3600, ImmediateSeverity: notifications.SeverityHigh}
+	suite.NoError(suite.repository.SaveDigestSettings(ctx, saved))
+	saved.IncludeUnclassified = true
+	suite.NoError(suite.repository.SaveDigestSettings(ctx, saved))
+
+	settings, err = suite.repository.GetDigestSettings(ctx, "ops@example.com")
+	suite.NoError(err)
+	suite.Equal(&saved, settings)
+
+	all, err := suite.repository.ListDigestSettings(ctx)
+	suite.NoError(err)
+	suite.Equal([]notifications.DigestSettings{saved}, all)
+
+	suite.NoError(suite.repository.DeleteDigestSettings(ctx, "ops@example.com"))
+	all, err = suite.repository.ListDigestSettings(ctx)
+	suite.NoError(err)
+	suite.Empty(all)
+}
+
+func (suite *DigestRepositoryTestSuite) TestItems() {
+	ctx := context.Background()
+	createdAt := time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC)
+	for _, subject := range []string{"first", "second"} {
+		suite.NoError(suite.repository.AddDigestItem(ctx, notifications.DigestItem{
+			Recipient:   "ops@example.com",
+			CreatedAt:   createdAt,
+			Severity:    notifications.SeverityLow,
+			Subject:     subject,
+			Content:     "content",
+			ContentType: notifications.ContentTypeTextPlain,
+		}))
+	}
+	suite.NoError(suite.repository.AddDigestItem(ctx, notifications.DigestItem{Recipient: "other@example.com", CreatedAt: createdAt}))
+
+	items, err := suite.repository.ListDigestItems(ctx, "ops@example.com")
+	suite.NoError(err)
+	suite.Len(items, 2)
+	suite.Equal("first", items[0].Subject)
+	suite.Equal(createdAt, items[0].CreatedAt.UTC())
+	suite.Equal(notifications.ContentTypeTextPlain, items[1].ContentType)
+
+	suite.NoError(suite.repository.DeleteDigestItems(ctx, "ops@example.com", items[0].ID))
+	items, err = suite.repository.ListDigestItems(ctx, "ops@example.com")
+	suite.NoError(err)
+	suite.Len(items, 1)
+	suite.Equal("second", items[0].Subject)
+
+	items, err = suite.repository.ListDigestItems(ctx, "other@example.com")
+	suite.NoError(err)
+	suite.Len(items, 1)
+}
+
+func TestDigestRepositoryTestSuite(t *testing.T) {
+	suite.Run(t, new(DigestRepositoryTestSuite))
+}
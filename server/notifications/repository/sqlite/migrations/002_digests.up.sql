@@ -0,0 +1,19 @@
+CREATE TABLE digest_settings (
+    recipient TEXT NOT NULL PRIMARY KEY CHECK (recipient != ''),
+    interval_sec INTEGER NOT NULL,
+    immediate_severity VARCHAR(20) NOT NULL,
+    include_unclassified BOOLEAN NOT NULL DEFAULT 0
+);
+
+CREATE TABLE digest_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    recipient TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    severity VARCHAR(20) NOT NULL DEFAULT "",
+    subject TEXT NOT NULL DEFAULT "",
+    content TEXT NOT NULL DEFAULT "",
+    content_type VARCHAR(50) NOT NULL DEFAULT ""
+);
+
+CREATE INDEX idx_digest_items_recipient
+    ON digest_items (recipient);
@@ -0,0 +1,130 @@
+package chserver
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultDevicePollInterval is the minimum interval of the device flow polling if no interval is configured
+	DefaultDevicePollInterval = 5 * time.Second
+	// devicePollSlowDown is the interval increase on a slow_down error, see RFC 8628 section 3.5
+	devicePollSlowDown = 5 * time.Second
+	// devicePollExpiry is the lifetime of a device code whose lifetime is unknown
+	devicePollExpiry = 30 * time.Minute
+	// maxDevicePolls limits the tracked device codes, the polling endpoint doesn't require authentication
+	maxDevicePolls = 10000
+
+	deviceErrAuthorizationPending = "authorization_pending"
+	deviceErrSlowDown             = "slow_down"
+)
+
+type devicePoll struct {
+	last     time.Time
+	interval time.Duration
+	expires  time.Time
+}
+
+// devicePolls enforces the polling interval of the OAuth device flow per device code, so clients polling too fast
+// get a slow_down without the request being passed to the OAuth provider.
+type devicePolls struct {
+	now func() time.Time
+
+	mu    sync.Mutex
+	polls map[string]*devicePoll
+}
+
+func newDevicePolls() *devicePolls {
+	return &devicePolls{
+		now:   time.Now,
+		polls: make(map[string]*devicePoll),
+	}
+}
+
+// Start tracks a device code returned to a client with the interval and the lifetime of the code.
+func (d *devicePolls) Start(code string, interval, expiresIn time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	d.removeExpired(now)
+	if len(d.polls) >= maxDevicePolls {
+		return
+	}
+	if expiresIn <= 0 {
+		expiresIn = devicePollExpiry
+	}
+	d.polls[code] = &devicePoll{
+		interval: interval,
+		expires:  now.Add(expiresIn),
+	}
+}
+
+// Poll returns true if the device code may be polled now. Otherwise the interval is increased and returned.
+func (d *devicePolls) Poll(code string, interval time.Duration) (bool, time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	p, ok := d.polls[code]
+	if !ok || now.After(p.expires) {
+		d.removeExpired(now)
+		if len(d.polls) >= maxDevicePolls {
+			return true, interval
+		}
+		p = &devicePoll{
+			interval: interval,
+			expires:  now.Add(devicePollExpiry),
+		}
+		d.polls[code] = p
+	}
+
+	if !p.last.IsZero() && now.Sub(p.last) < p.interval {
+		p.interval += devicePollSlowDown
+		p.last = now
+		return false, p.interval
+	}
+	p.last = now
+	return true, p.interval
+}
+
+// SlowDown increases the interval of the device code after the OAuth provider returned slow_down.
+func (d *devicePolls) SlowDown(code string) time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	p, ok := d.polls[code]
+	if !ok {
+		return 0
+	}
+	p.interval += devicePollSlowDown
+	return p.interval
+}
+
+// Interval returns the current interval of the device code.
+func (d *devicePolls) Interval(code string) time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	p, ok := d.polls[code]
+	if !ok {
+		return 0
+	}
+	return p.interval
+}
+
+// Done stops tracking the device code after the login finished or failed.
+func (d *devicePolls) Done(code string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.polls, code)
+}
+
+func (d *devicePolls) removeExpired(now time.Time) {
+	for code, p := range d.polls {
+		if now.After(p.expires) {
+			delete(d.polls, code)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package chserver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDevicePolls(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	polls := newDevicePolls()
+	polls.now = func() time.Time { return now }
+
+	polls.Start("github/code1", 5*time.Second, time.Minute)
+
+	ok, interval := polls.Poll("github/code1", DefaultDevicePollInterval)
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Second, interval)
+
+	// polled too fast
+	now = now.Add(2 * time.Second)
+	ok, interval = polls.Poll("github/code1", DefaultDevicePollInterval)
+	assert.False(t, ok)
+	assert.Equal(t, 10*time.Second, interval)
+
+	now = now.Add(10 * time.Second)
+	ok, _ = polls.Poll("github/code1", DefaultDevicePollInterval)
+	assert.True(t, ok)
+
+	// the provider returned slow_down
+	assert.Equal(t, 15*time.Second, polls.SlowDown("github/code1"))
+	assert.Equal(t, 15*time.Second, polls.Interval("github/code1"))
+
+	polls.Done("github/code1")
+	assert.Equal(t, time.Duration(0), polls.Interval("github/code1"))
+
+	// unknown codes are tracked with the given interval
+	ok, interval = polls.Poll("github/code2", 7*time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, 7*time.Second, interval)
+
+	// expired codes are removed
+	now = now.Add(devicePollExpiry + time.Second)
+	polls.Start("github/code3", 5*time.Second, time.Minute)
+	assert.Equal(t, time.Duration(0), polls.Interval("github/code2"))
+	assert.Equal(t, 5*time.Second, polls.Interval("github/code3"))
+}
@@ -74,6 +74,23 @@ func RegisterPlusCapabilities(plusManager rportplus.Manager, cfg *chconfig.Confi
 		}
 
 		logger.Infof("oauth capability registered")
+
+		for _, providerCfg := range cfg.PlusConfig.OAuthProviders {
+			capName := rportplus.OAuthProviderCapabilityName(providerCfg.GetName())
+			_, err := plusManager.RegisterCapability(capName, &oauth.Capability{
+				Config: providerCfg,
+				Logger: logger,
+			})
+			if err != nil {
+				return fmt.Errorf("unable to register oauth plugin capability for provider %q: %w", providerCfg.GetName(), err)
+			}
+			if v := plusManager.GetConfigValidator(capName); v != nil {
+				if err := v.ValidateConfig(); err != nil {
+					return fmt.Errorf("invalid oauth configuration of provider %q: %w", providerCfg.GetName(), err)
+				}
+			}
+			logger.Infof("oauth capability registered for provider %q", providerCfg.GetName())
+		}
 	}
 
 	// always register the plus status capability
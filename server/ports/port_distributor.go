@@ -72,6 +72,15 @@ func (d *PortDistributor) IsPortBusy(protocol string, port int) bool {
 	return !d.getPool(protocol).Contains(port)
 }
 
+// CountAvailable returns the number of allowed ports currently not in use, regardless of the cached pools.
+func (d *PortDistributor) CountAvailable(protocol string) (int, error) {
+	busyPorts, err := ListBusyPorts(protocol)
+	if err != nil {
+		return 0, err
+	}
+	return d.allowedPorts.Difference(busyPorts).Cardinality(), nil
+}
+
 func (d *PortDistributor) getPool(protocol string) mapset.Set {
 	pool := d.getPoolFromMap(protocol)
 	if protocol == models.ProtocolTCPUDP {
@@ -1,6 +1,7 @@
 package ports
 
 import (
+	"net"
 	"testing"
 
 	mapset "github.com/deckarep/golang-set"
@@ -30,3 +31,16 @@ func TestPortDistributor(t *testing.T) {
 		})
 	}
 }
+
+func TestPortDistributorCountAvailable(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	busyPort := l.Addr().(*net.TCPAddr).Port
+
+	pd := NewPortDistributor(mapset.NewSetFromSlice([]interface{}{busyPort, 1}))
+
+	count, err := pd.CountAvailable(models.ProtocolTCP)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
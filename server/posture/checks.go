@@ -0,0 +1,267 @@
+package posture
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/IOTech17/neo-rport/server/bearer"
+)
+
+const (
+	// MaxRecommendedTokenLifetime is the longest lifetime of API tokens not reported as a finding.
+	MaxRecommendedTokenLifetime = 30 * 24 * time.Hour
+	// MinJWTSecretLength is the minimal length of a configured jwt_secret not reported as a finding.
+	MinJWTSecretLength = 32
+	// MinRecommendedPasswordLength is the password_min_length not reported as a finding.
+	MinRecommendedPasswordLength = 14
+
+	defaultAPIPort    = "3000"
+	defaultServerPort = "8080"
+	maxListedTunnels  = 10
+)
+
+func apiEnabled(in Input) bool {
+	return in.Config.API.Address != ""
+}
+
+var checks = []check{
+	{
+		id:          "api_2fa_disabled",
+		severity:    SeverityHigh,
+		title:       "Two-factor authentication is disabled",
+		remediation: "Set 'two_fa_token_delivery' or 'totp_enabled = true' in the [api] section.",
+		evaluate: func(in Input) string {
+			api := in.Config.API
+			if !apiEnabled(in) || api.IsTwoFAOn() || api.TotPEnabled || api.AuthHeader != "" {
+				return ""
+			}
+			return "API users log in with a password only. A leaked or guessed password gives full access."
+		},
+	},
+	{
+		id:          "api_no_tls",
+		severity:    SeverityHigh,
+		title:       "The API is served without TLS",
+		remediation: "Set 'cert_file' and 'key_file' or 'enable_acme = true' in the [api] section, or expose the API via the caddy integration only.",
+		evaluate: func(in Input) string {
+			c := in.Config
+			if !apiEnabled(in) || c.API.CertFile != "" || c.API.EnableAcme || isLoopback(c.API.Address) {
+				return ""
+			}
+			if c.CaddyEnabled() && c.Caddy.APIReverseProxyEnabled() {
+				return ""
+			}
+			return fmt.Sprintf("The API listens on %s using plain HTTP, passwords and tokens are sent unencrypted.", c.API.Address)
+		},
+	},
+	{
+		id:          "api_weak_jwt_secret",
+		severity:    SeverityHigh,
+		title:       "The JWT secret is weak",
+		remediation: fmt.Sprintf("Use a random 'jwt_secret' of at least %d characters or remove it to let the server generate one.", MinJWTSecretLength),
+		evaluate: func(in Input) string {
+			secret := in.Config.API.JWTSecret
+			if !apiEnabled(in) || secret == "" || len(secret) >= MinJWTSecretLength {
+				return ""
+			}
+			return fmt.Sprintf("The configured 'jwt_secret' has %d characters only, tokens signed with it can be brute forced.", len(secret))
+		},
+	},
+	{
+		id:          "api_long_token_lifetime",
+		severity:    SeverityMedium,
+		title:       "API tokens can be long-lived",
+		remediation: fmt.Sprintf("Set 'max_token_lifetime' in the [api] section to %.0f hours or less.", MaxRecommendedTokenLifetime.Hours()),
+		evaluate: func(in Input) string {
+			lifetime := time.Duration(in.Config.API.MaxTokenLifeTimeHours) * time.Hour
+			if lifetime == 0 {
+				lifetime = bearer.DefaultMaxTokenLifetime
+			}
+			if !apiEnabled(in) || lifetime <= MaxRecommendedTokenLifetime {
+				return ""
+			}
+			return fmt.Sprintf("Users can request tokens valid for %.0f days, a stolen token stays usable for that long.", lifetime.Hours()/24)
+		},
+	},
+	{
+		id:          "api_static_credentials",
+		severity:    SeverityMedium,
+		title:       "A single API user is defined in the config file",
+		remediation: "Use 'auth_file' or 'auth_user_table' with hashed passwords and personal accounts instead of 'auth'.",
+		evaluate: func(in Input) string {
+			if !apiEnabled(in) || in.Config.API.Auth == "" {
+				return ""
+			}
+			return "All API users share one password stored in plain text in the config file."
+		},
+	},
+	{
+		id:          "api_brute_force_protection",
+		severity:    SeverityMedium,
+		title:       "API login brute force protection is disabled",
+		remediation: "Set 'user_login_wait' and 'max_failed_login' in the [api] section to values above 0.",
+		evaluate: func(in Input) string {
+			api := in.Config.API
+			if !apiEnabled(in) || (api.UserLoginWait > 0 && api.MaxFailedLogin > 0) {
+				return ""
+			}
+			return "Passwords can be guessed without delays or bans."
+		},
+	},
+	{
+		id:          "client_brute_force_protection",
+		severity:    SeverityMedium,
+		title:       "Client login brute force protection is disabled",
+		remediation: "Set 'client_login_wait' and 'max_failed_login' in the [server] section to values above 0.",
+		evaluate: func(in Input) string {
+			s := in.Config.Server
+			if s.ClientLoginWait > 0 && s.MaxFailedLogin > 0 {
+				return ""
+			}
+			return "Client credentials can be guessed without delays or bans."
+		},
+	},
+	{
+		id:          "client_shared_credentials",
+		severity:    SeverityMedium,
+		title:       "All clients share one credential",
+		remediation: "Use 'auth_file' or 'auth_table' in the [server] section with credentials per client.",
+		evaluate: func(in Input) string {
+			if in.Config.Server.Auth == "" {
+				return ""
+			}
+			return "The [server] 'auth' credential is used by all clients, it can't be revoked for a single client."
+		},
+	},
+	{
+		id:          "key_seed",
+		severity:    SeverityMedium,
+		title:       "The server key is derived from a seed",
+		remediation: "Remove 'key_seed' to use the generated private key stored in the data directory.",
+		evaluate: func(in Input) string {
+			if in.Config.Server.KeySeed == "" {
+				return ""
+			}
+			return "Anyone knowing the 'key_seed' can derive the private key of the server and impersonate it."
+		},
+	},
+	{
+		id:          "open_tunnels",
+		severity:    SeverityHigh,
+		title:       "Tunnels are open to everyone",
+		remediation: "Create tunnels with an ACL restricting the allowed source addresses or bind them to 127.0.0.1.",
+		evaluate: func(in Input) string {
+			var open []string
+			for _, client := range in.Clients {
+				for _, t := range client.GetTunnels() {
+					if t.ACL != nil || isLoopback(t.LocalHost) || (t.HTTPProxy && t.AuthUser != "") {
+						continue
+					}
+					open = append(open, fmt.Sprintf("%s of client %s", t.ID, client.GetID()))
+				}
+			}
+			if len(open) == 0 {
+				return ""
+			}
+			listed := open
+			if len(listed) > maxListedTunnels {
+				listed = listed[:maxListedTunnels]
+			}
+			details := fmt.Sprintf("%d tunnel(s) accept connections from any address without an ACL: %s", len(open), strings.Join(listed, ", "))
+			if len(open) > len(listed) {
+				details += fmt.Sprintf(" and %d more", len(open)-len(listed))
+			}
+			return details
+		},
+	},
+	{
+		id:          "database_unencrypted",
+		severity:    SeverityMedium,
+		title:       "The database is reached over an unencrypted network connection",
+		remediation: "Run the database on the same host and connect via a unix socket or 127.0.0.1, or use an encrypted tunnel.",
+		evaluate: func(in Input) string {
+			db := in.Config.Database
+			if db.Type != "mysql" || db.Host == "" || strings.HasPrefix(db.Host, "socket:") || isLoopback(db.Host) {
+				return ""
+			}
+			return fmt.Sprintf("The connection to %s is plain TCP, user data and credentials are sent unencrypted.", db.Host)
+		},
+	},
+	{
+		id:          "audit_log_disabled",
+		severity:    SeverityMedium,
+		title:       "The audit log is disabled",
+		remediation: "Set 'enable_audit_log = true' in the [api] section.",
+		evaluate: func(in Input) string {
+			if !apiEnabled(in) || in.Config.API.AuditLog.Enable {
+				return ""
+			}
+			return "Changes made via the API are not recorded, incidents can't be traced back."
+		},
+	},
+	{
+		id:          "api_tls_min",
+		severity:    SeverityLow,
+		title:       "The API accepts TLS 1.2",
+		remediation: "Set 'tls_min = \"1.3\"' in the [api] section if all API consumers support it.",
+		evaluate: func(in Input) string {
+			api := in.Config.API
+			if !apiEnabled(in) || api.CertFile == "" || api.TLSMin != "1.2" || in.Config.Server.FIPSEnabled() {
+				return ""
+			}
+			return "TLS 1.2 allows weaker ciphers than TLS 1.3."
+		},
+	},
+	{
+		id:          "password_policy",
+		severity:    SeverityLow,
+		title:       "The password policy is weak",
+		remediation: fmt.Sprintf("Set 'password_min_length' to %d or more and don't disable 'password_zxcvbn_minscore'.", MinRecommendedPasswordLength),
+		evaluate: func(in Input) string {
+			api := in.Config.API
+			if !apiEnabled(in) || (api.PasswordMinLength >= MinRecommendedPasswordLength && api.PasswordZxcvbnMinscore >= 0) {
+				return ""
+			}
+			return fmt.Sprintf("Passwords need %d characters and the zxcvbn check is %s.", api.PasswordMinLength, enabledStr(api.PasswordZxcvbnMinscore >= 0))
+		},
+	},
+	{
+		id:          "ws_test_endpoints",
+		severity:    SeverityLow,
+		title:       "Websocket test endpoints are enabled",
+		remediation: "Set 'enable_ws_test_endpoints = false' in the [api] section.",
+		evaluate: func(in Input) string {
+			if !apiEnabled(in) || !in.Config.API.EnableWsTestEndpoints {
+				return ""
+			}
+			return "The test UIs for commands and scripts are served, they are meant for development only."
+		},
+	},
+	{
+		id:          "default_ports",
+		severity:    SeverityLow,
+		title:       "Default ports are used",
+		remediation: fmt.Sprintf("Use other ports than %s for the [server] and %s for the [api] address.", defaultServerPort, defaultAPIPort),
+		evaluate: func(in Input) string {
+			var used []string
+			if port(in.Config.Server.ListenAddress) == defaultServerPort {
+				used = append(used, "server "+in.Config.Server.ListenAddress)
+			}
+			if apiEnabled(in) && port(in.Config.API.Address) == defaultAPIPort && !isLoopback(in.Config.API.Address) {
+				used = append(used, "api "+in.Config.API.Address)
+			}
+			if len(used) == 0 {
+				return ""
+			}
+			return fmt.Sprintf("The well known default ports make the server easy to find by scans: %s.", strings.Join(used, ", "))
+		},
+	},
+}
+
+func enabledStr(enabled bool) string {
+	if enabled {
+		return "enabled"
+	}
+	return "disabled"
+}
@@ -0,0 +1,139 @@
+// Package posture evaluates the configuration and the state of the server for common security weaknesses.
+package posture
+
+import (
+	"net"
+	"sort"
+	"time"
+
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+)
+
+type Severity string
+
+const (
+	SeverityCritical Severity = "critical"
+	SeverityHigh     Severity = "high"
+	SeverityMedium   Severity = "medium"
+	SeverityLow      Severity = "low"
+)
+
+// penalties are subtracted from the maximum score of 100 for each finding.
+var penalties = map[Severity]int{
+	SeverityCritical: 40,
+	SeverityHigh:     20,
+	SeverityMedium:   10,
+	SeverityLow:      3,
+}
+
+var severityOrder = map[Severity]int{
+	SeverityCritical: 0,
+	SeverityHigh:     1,
+	SeverityMedium:   2,
+	SeverityLow:      3,
+}
+
+const MaxScore = 100
+
+type Finding struct {
+	ID          string   `json:"id"`
+	Severity    Severity `json:"severity"`
+	Title       string   `json:"title"`
+	Details     string   `json:"details"`
+	Remediation string   `json:"remediation"`
+}
+
+type Report struct {
+	Score     int       `json:"score"`
+	Grade     string    `json:"grade"`
+	Checks    int       `json:"checks"`
+	Passed    int       `json:"passed"`
+	Findings  []Finding `json:"findings"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Input is what the checks are evaluated on.
+type Input struct {
+	Config  *chconfig.Config
+	Clients []*clientdata.Client
+}
+
+type check struct {
+	id          string
+	severity    Severity
+	title       string
+	remediation string
+	// evaluate returns the details of the finding, an empty string if the check passed
+	evaluate func(in Input) string
+}
+
+// Evaluate runs all checks, the findings are sorted by severity, the most severe first.
+func Evaluate(in Input, now time.Time) Report {
+	report := Report{
+		Score:     MaxScore,
+		Findings:  make([]Finding, 0),
+		CheckedAt: now,
+	}
+	for _, c := range checks {
+		report.Checks++
+		details := c.evaluate(in)
+		if details == "" {
+			report.Passed++
+			continue
+		}
+		report.Findings = append(report.Findings, Finding{
+			ID:          c.id,
+			Severity:    c.severity,
+			Title:       c.title,
+			Details:     details,
+			Remediation: c.remediation,
+		})
+		report.Score -= penalties[c.severity]
+	}
+	if report.Score < 0 {
+		report.Score = 0
+	}
+	report.Grade = grade(report.Score)
+
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		return severityOrder[report.Findings[i].Severity] < severityOrder[report.Findings[j].Severity]
+	})
+	return report
+}
+
+func grade(score int) string {
+	switch {
+	case score >= 90:
+		return "A"
+	case score >= 75:
+		return "B"
+	case score >= 60:
+		return "C"
+	case score >= 40:
+		return "D"
+	default:
+		return "F"
+	}
+}
+
+// isLoopback returns true if the listen address only accepts local connections.
+func isLoopback(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func port(address string) string {
+	_, p, err := net.SplitHostPort(address)
+	if err != nil {
+		return ""
+	}
+	return p
+}
@@ -0,0 +1,122 @@
+package posture
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/server/auditlog/config"
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/clients/clienttunnel"
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+func hardenedConfig() *chconfig.Config {
+	return &chconfig.Config{
+		Server: chconfig.ServerConfig{
+			ListenAddress:   "0.0.0.0:443",
+			AuthFile:        "/etc/rport/clients.json",
+			ClientLoginWait: 2,
+			MaxFailedLogin:  5,
+		},
+		API: chconfig.APIConfig{
+			Address:                "0.0.0.0:4443",
+			AuthUserTable:          "users",
+			CertFile:               "/etc/rport/api.crt",
+			KeyFile:                "/etc/rport/api.key",
+			TotPEnabled:            true,
+			UserLoginWait:          2,
+			MaxFailedLogin:         5,
+			MaxTokenLifeTimeHours:  24,
+			PasswordMinLength:      14,
+			TLSMin:                 "1.3",
+			AuditLog:               config.Config{Enable: true},
+			PasswordZxcvbnMinscore: 0,
+		},
+	}
+}
+
+func findingIDs(report Report) []string {
+	ids := make([]string, 0, len(report.Findings))
+	for _, f := range report.Findings {
+		ids = append(ids, f.ID)
+	}
+	return ids
+}
+
+func TestEvaluateHardened(t *testing.T) {
+	now := time.Now()
+	report := Evaluate(Input{Config: hardenedConfig()}, now)
+
+	assert.Empty(t, report.Findings)
+	assert.Equal(t, MaxScore, report.Score)
+	assert.Equal(t, "A", report.Grade)
+	assert.Equal(t, len(checks), report.Checks)
+	assert.Equal(t, report.Checks, report.Passed)
+	assert.Equal(t, now, report.CheckedAt)
+}
+
+func TestEvaluateFindings(t *testing.T) {
+	cfg := hardenedConfig()
+	cfg.Server.ListenAddress = "0.0.0.0:8080"
+	cfg.Server.Auth = "client:secret"
+	cfg.API.TotPEnabled = false
+	cfg.API.CertFile = ""
+	cfg.API.MaxTokenLifeTimeHours = 0
+	cfg.API.JWTSecret = "short"
+	cfg.Database = chconfig.DatabaseConfig{Type: "mysql", Host: "db.example.com:3306"}
+
+	aclStr := "10.0.0.0/8"
+	client := &clientdata.Client{
+		ID: "client-1",
+		Tunnels: []*clienttunnel.Tunnel{
+			{ID: "1", Remote: models.Remote{LocalHost: "0.0.0.0", LocalPort: "2222"}},
+			{ID: "2", Remote: models.Remote{LocalHost: "0.0.0.0", LocalPort: "2223", ACL: &aclStr}},
+			{ID: "3", Remote: models.Remote{LocalHost: "127.0.0.1", LocalPort: "2224"}},
+		},
+	}
+
+	report := Evaluate(Input{Config: cfg, Clients: []*clientdata.Client{client}}, time.Now())
+
+	assert.Equal(t, []string{
+		"api_2fa_disabled",
+		"api_no_tls",
+		"api_weak_jwt_secret",
+		"open_tunnels",
+		"api_long_token_lifetime",
+		"client_shared_credentials",
+		"database_unencrypted",
+		"default_ports",
+	}, findingIDs(report))
+	assert.Equal(t, 0, report.Score)
+	assert.Equal(t, "F", report.Grade)
+
+	var openTunnels Finding
+	for _, f := range report.Findings {
+		if f.ID == "open_tunnels" {
+			openTunnels = f
+		}
+	}
+	require.NotEmpty(t, openTunnels.Remediation)
+	assert.Equal(t, "1 tunnel(s) accept connections from any address without an ACL: 1 of client client-1", openTunnels.Details)
+}
+
+func TestEvaluateAPIDisabled(t *testing.T) {
+	cfg := hardenedConfig()
+	cfg.API = chconfig.APIConfig{}
+
+	report := Evaluate(Input{Config: cfg}, time.Now())
+
+	assert.Empty(t, report.Findings)
+}
+
+func TestGrade(t *testing.T) {
+	assert.Equal(t, "A", grade(100))
+	assert.Equal(t, "B", grade(80))
+	assert.Equal(t, "C", grade(60))
+	assert.Equal(t, "D", grade(45))
+	assert.Equal(t, "F", grade(0))
+}
@@ -0,0 +1,35 @@
+package posture
+
+import (
+	"context"
+	"time"
+
+	"github.com/IOTech17/neo-rport/share/logger"
+)
+
+// Task evaluates the posture periodically and logs the findings, so weaknesses introduced by config changes or
+// tunnels are noticed without anybody requesting the report.
+type Task struct {
+	logger *logger.Logger
+	input  func() Input
+}
+
+func NewTask(l *logger.Logger, input func() Input) *Task {
+	return &Task{
+		logger: l,
+		input:  input,
+	}
+}
+
+func (t *Task) Run(ctx context.Context) error {
+	report := Evaluate(t.input(), time.Now())
+	t.logger.Infof("Security posture score %d (%s), %d of %d checks passed", report.Score, report.Grade, report.Passed, report.Checks)
+	for _, f := range report.Findings {
+		if f.Severity == SeverityCritical || f.Severity == SeverityHigh {
+			t.logger.Errorf("Security posture finding %s [%s]: %s %s", f.ID, f.Severity, f.Details, f.Remediation)
+		} else {
+			t.logger.Infof("Security posture finding %s [%s]: %s %s", f.ID, f.Severity, f.Details, f.Remediation)
+		}
+	}
+	return nil
+}
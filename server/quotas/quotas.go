@@ -0,0 +1,230 @@
+// Package quotas limits the resources of tenants. A tenant is a user group, the clients of a tenant are the clients
+// its members have access to, tunnels and schedules count for the user groups of their owner.
+package quotas
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+)
+
+type Resource string
+
+// ErrCodeQuotaExceeded is the error code of API errors of exceeded quotas
+const ErrCodeQuotaExceeded = "ERR_CODE_QUOTA_EXCEEDED"
+
+const (
+	ResourceClients    Resource = "clients"
+	ResourceTunnels    Resource = "tunnels"
+	ResourceSchedules  Resource = "schedules"
+	ResourceDatapoints Resource = "datapoints"
+)
+
+// Quota holds the limits of a user group, 0 means unlimited.
+type Quota struct {
+	UserGroup           string `mapstructure:"user_group" json:"user_group"`
+	MaxClients          int    `mapstructure:"max_clients" json:"max_clients"`
+	MaxTunnels          int    `mapstructure:"max_tunnels" json:"max_tunnels"`
+	MaxSchedules        int    `mapstructure:"max_schedules" json:"max_schedules"`
+	MaxDatapointsPerDay int    `mapstructure:"max_datapoints_per_day" json:"max_datapoints_per_day"`
+}
+
+func (q Quota) Limit(r Resource) int {
+	switch r {
+	case ResourceClients:
+		return q.MaxClients
+	case ResourceTunnels:
+		return q.MaxTunnels
+	case ResourceSchedules:
+		return q.MaxSchedules
+	case ResourceDatapoints:
+		return q.MaxDatapointsPerDay
+	}
+	return 0
+}
+
+// Usage is the current usage of a user group. Datapoints are the monitoring measurements of the clients received since
+// midnight UTC.
+type Usage struct {
+	Clients    int `json:"clients"`
+	Tunnels    int `json:"tunnels"`
+	Schedules  int `json:"schedules"`
+	Datapoints int `json:"datapoints"`
+}
+
+func (u Usage) Get(r Resource) int {
+	switch r {
+	case ResourceClients:
+		return u.Clients
+	case ResourceTunnels:
+		return u.Tunnels
+	case ResourceSchedules:
+		return u.Schedules
+	case ResourceDatapoints:
+		return u.Datapoints
+	}
+	return 0
+}
+
+// administrators is the name of the user group with access to everything, users.Administrators can't be imported as
+// the users depend on the config
+const administrators = "Administrators"
+
+// Validate returns an error if a quota is invalid or a user group has more than one quota.
+func Validate(quotas []Quota) error {
+	seen := make(map[string]bool, len(quotas))
+	for _, q := range quotas {
+		if q.UserGroup == "" {
+			return fmt.Errorf("user_group is required")
+		}
+		if q.UserGroup == administrators {
+			return fmt.Errorf("user group %q can't be limited", administrators)
+		}
+		if seen[q.UserGroup] {
+			return fmt.Errorf("user group %q has more than one quota", q.UserGroup)
+		}
+		seen[q.UserGroup] = true
+		if q.MaxClients < 0 || q.MaxTunnels < 0 || q.MaxSchedules < 0 || q.MaxDatapointsPerDay < 0 {
+			return fmt.Errorf("limits of user group %q must not be negative", q.UserGroup)
+		}
+	}
+	return nil
+}
+
+// ExceededError is returned if an action would exceed the quota of a user group.
+type ExceededError struct {
+	UserGroup string
+	Resource  Resource
+	Limit     int
+}
+
+func (e *ExceededError) Error() string {
+	return fmt.Sprintf("quota of user group %q exceeded: the limit is %d %s", e.UserGroup, e.Limit, e.Resource)
+}
+
+// APIError returns the error as 403 API error.
+func (e *ExceededError) APIError() errors2.APIError {
+	return errors2.APIError{
+		Message:    e.Error(),
+		HTTPStatus: http.StatusForbidden,
+		ErrCode:    ErrCodeQuotaExceeded,
+	}
+}
+
+// Manager enforces the quotas and meters the monitoring datapoints, which are counted in memory per UTC day.
+type Manager struct {
+	quotas map[string]Quota
+
+	mu         sync.Mutex
+	day        string
+	datapoints map[string]int
+	now        func() time.Time
+}
+
+// New returns nil if no quotas are configured, a nil Manager doesn't limit anything.
+func New(quotas []Quota) *Manager {
+	if len(quotas) == 0 {
+		return nil
+	}
+	m := &Manager{
+		quotas:     make(map[string]Quota, len(quotas)),
+		datapoints: make(map[string]int),
+		now:        time.Now,
+	}
+	for _, q := range quotas {
+		m.quotas[q.UserGroup] = q
+	}
+	return m
+}
+
+// Get returns the quota of a user group, false if the user group isn't limited.
+func (m *Manager) Get(group string) (Quota, bool) {
+	if m == nil {
+		return Quota{}, false
+	}
+	q, ok := m.quotas[group]
+	return q, ok
+}
+
+// UserGroups returns the sorted names of the user groups with a quota.
+func (m *Manager) UserGroups() []string {
+	if m == nil {
+		return nil
+	}
+	res := make([]string, 0, len(m.quotas))
+	for group := range m.quotas {
+		res = append(res, group)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Limited returns the given user groups having a limit for the resource.
+func (m *Manager) Limited(groups []string, r Resource) []string {
+	if m == nil {
+		return nil
+	}
+	var res []string
+	for _, group := range groups {
+		if q, ok := m.quotas[group]; ok && q.Limit(r) > 0 {
+			res = append(res, group)
+		}
+	}
+	return res
+}
+
+// Check returns an ExceededError if one more of the resource exceeds the quota of one of the groups, given their
+// current usage.
+func (m *Manager) Check(r Resource, groups []string, usage map[string]int) error {
+	for _, group := range m.Limited(groups, r) {
+		limit := m.quotas[group].Limit(r)
+		if usage[group] >= limit {
+			return &ExceededError{UserGroup: group, Resource: r, Limit: limit}
+		}
+	}
+	return nil
+}
+
+// AddDatapoint counts a measurement for the groups of its client. It returns an ExceededError without counting if one
+// of the groups reached its daily limit, the measurement should be dropped then.
+func (m *Manager) AddDatapoint(groups []string) error {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rotate()
+
+	if err := m.Check(ResourceDatapoints, groups, m.datapoints); err != nil {
+		return err
+	}
+	for _, group := range groups {
+		if _, ok := m.quotas[group]; ok {
+			m.datapoints[group]++
+		}
+	}
+	return nil
+}
+
+// Datapoints returns the number of datapoints of the group today.
+func (m *Manager) Datapoints(group string) int {
+	if m == nil {
+		return 0
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rotate()
+	return m.datapoints[group]
+}
+
+func (m *Manager) rotate() {
+	day := m.now().UTC().Format("2006-01-02")
+	if day != m.day {
+		m.day = day
+		m.datapoints = make(map[string]int)
+	}
+}
@@ -0,0 +1,108 @@
+package quotas
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		quotas  []Quota
+		wantErr string
+	}{
+		{
+			name:   "valid",
+			quotas: []Quota{{UserGroup: "tenant-a", MaxClients: 10}, {UserGroup: "tenant-b", MaxTunnels: 2}},
+		},
+		{
+			name:    "missing user group",
+			quotas:  []Quota{{MaxClients: 10}},
+			wantErr: "user_group is required",
+		},
+		{
+			name:    "administrators",
+			quotas:  []Quota{{UserGroup: "Administrators", MaxClients: 10}},
+			wantErr: `user group "Administrators" can't be limited`,
+		},
+		{
+			name:    "duplicate",
+			quotas:  []Quota{{UserGroup: "tenant-a"}, {UserGroup: "tenant-a"}},
+			wantErr: `user group "tenant-a" has more than one quota`,
+		},
+		{
+			name:    "negative",
+			quotas:  []Quota{{UserGroup: "tenant-a", MaxSchedules: -1}},
+			wantErr: `limits of user group "tenant-a" must not be negative`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Validate(tc.quotas)
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	m := New([]Quota{
+		{UserGroup: "tenant-a", MaxTunnels: 2},
+		{UserGroup: "tenant-b", MaxTunnels: 5, MaxClients: 1},
+	})
+
+	assert.Equal(t, []string{"tenant-a", "tenant-b"}, m.UserGroups())
+	assert.Equal(t, []string{"tenant-b"}, m.Limited([]string{"tenant-a", "tenant-b", "other"}, ResourceClients))
+
+	assert.NoError(t, m.Check(ResourceTunnels, []string{"tenant-a", "other"}, map[string]int{"tenant-a": 1}))
+	assert.NoError(t, m.Check(ResourceSchedules, []string{"tenant-a"}, map[string]int{"tenant-a": 100}))
+
+	err := m.Check(ResourceTunnels, []string{"tenant-b", "tenant-a"}, map[string]int{"tenant-a": 2, "tenant-b": 1})
+	var exceeded *ExceededError
+	require.True(t, errors.As(err, &exceeded))
+	assert.Equal(t, "tenant-a", exceeded.UserGroup)
+	assert.EqualError(t, err, `quota of user group "tenant-a" exceeded: the limit is 2 tunnels`)
+	apiErr := exceeded.APIError()
+	assert.Equal(t, http.StatusForbidden, apiErr.HTTPStatus)
+	assert.Equal(t, ErrCodeQuotaExceeded, apiErr.ErrCode)
+}
+
+func TestAddDatapoint(t *testing.T) {
+	m := New([]Quota{
+		{UserGroup: "tenant-a", MaxDatapointsPerDay: 2},
+		{UserGroup: "tenant-b"},
+	})
+	now := time.Date(2026, 3, 1, 23, 59, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	assert.NoError(t, m.AddDatapoint([]string{"tenant-a", "tenant-b", "other"}))
+	assert.NoError(t, m.AddDatapoint([]string{"tenant-a"}))
+	assert.Error(t, m.AddDatapoint([]string{"tenant-b", "tenant-a"}))
+	assert.NoError(t, m.AddDatapoint([]string{"tenant-b"}))
+	assert.Equal(t, 2, m.Datapoints("tenant-a"))
+	assert.Equal(t, 2, m.Datapoints("tenant-b"))
+	assert.Equal(t, 0, m.Datapoints("other"))
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, 0, m.Datapoints("tenant-a"))
+	assert.NoError(t, m.AddDatapoint([]string{"tenant-a"}))
+}
+
+func TestNilManager(t *testing.T) {
+	m := New(nil)
+
+	assert.Nil(t, m)
+	assert.NoError(t, m.Check(ResourceTunnels, []string{"tenant-a"}, map[string]int{"tenant-a": 100}))
+	assert.NoError(t, m.AddDatapoint([]string{"tenant-a"}))
+	assert.Empty(t, m.UserGroups())
+	_, ok := m.Get("tenant-a")
+	assert.False(t, ok)
+}
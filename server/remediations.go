@@ -0,0 +1,108 @@
+package chserver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/IOTech17/neo-rport/server/alerts"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/killswitch"
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+const (
+	// remediationCreatedBy is set as creator of the remediation jobs
+	remediationCreatedBy = "remediation"
+	// maxRemediationOutput is how much of the output of a remediation job is recorded on the problem
+	maxRemediationOutput = 4096
+)
+
+// jobPollInterval is how often the state of a job is read while waiting for it to finish
+var jobPollInterval = time.Second
+
+// runRemediation runs the script of the remediation as a job on the client and waits for its result.
+func (al *APIListener) runRemediation(ctx context.Context, clientID string, r alerts.Remediation) (string, error) {
+	if err := al.killSwitches.Check(killswitch.JobCapability(true)); err != nil {
+		return "", err
+	}
+	client, err := al.clientService.GetActiveByID(clientID)
+	if err != nil {
+		return "", err
+	}
+	if client == nil {
+		return "", ErrClientNotConnected
+	}
+
+	jid, err := generateNewJobID()
+	if err != nil {
+		return "", err
+	}
+	timeoutSec := r.TimeoutSec
+	if timeoutSec <= 0 {
+		timeoutSec = al.config.Server.RunRemoteCmdTimeoutSec
+	}
+	err = al.createAndRunJob(nil, nil, jid, r.Script, r.Interpreter, remediationCreatedBy, "", timeoutSec, false, true, nil, "", nil, client)
+	if err != nil {
+		return "", err
+	}
+	al.auditLog.Entry(auditlog.ApplicationClientScript, auditlog.ActionExecuteStart).
+		WithUsername(remediationCreatedBy).
+		WithClientID(clientID).
+		WithRequest(r).
+		WithID(jid).
+		Save()
+
+	job, err := al.waitForJob(ctx, clientID, jid, time.Duration(timeoutSec)*time.Second+jobLockGracePeriod)
+	if err != nil {
+		return "", err
+	}
+	return remediationOutcome(job), nil
+}
+
+// waitForJob waits until the job is finished or the wait is over and returns its latest stored state.
+func (al *APIListener) waitForJob(ctx context.Context, clientID, jid string, wait time.Duration) (*models.Job, error) {
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for {
+		job, err := al.jobProvider.GetByJID(clientID, jid)
+		if err != nil {
+			return nil, err
+		}
+		if job == nil {
+			return nil, fmt.Errorf("job %s not found", jid)
+		}
+		if job.Status != models.JobStatusRunning {
+			return job, nil
+		}
+		select {
+		case <-ctx.Done():
+			return job, ctx.Err()
+		case <-timer.C:
+			return job, nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// remediationOutcome is recorded on the problem, e.g. "job 8a0e... successful" followed by the output.
+func remediationOutcome(job *models.Job) string {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "job %s %s", job.JID, job.Status)
+	if job.Error != "" {
+		fmt.Fprintf(b, ": %s", job.Error)
+	}
+	if job.Result != nil {
+		output := strings.TrimSpace(job.Result.StdOut + "\n" + job.Result.StdErr)
+		if len(output) > maxRemediationOutput {
+			output = output[:maxRemediationOutput] + "..."
+		}
+		if output != "" {
+			b.WriteString("\n" + output)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,42 @@
+package chserver
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+func TestWaitForJob(t *testing.T) {
+	defer func(interval time.Duration) { jobPollInterval = interval }(jobPollInterval)
+	jobPollInterval = time.Millisecond
+
+	jp := NewJobProviderMock()
+	jp.ReturnJob = &models.Job{JID: "job-1", Status: models.JobStatusRunning}
+	al := APIListener{Server: &Server{jobProvider: jp}}
+
+	job, err := al.waitForJob(context.Background(), "client-1", "job-1", 10*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, models.JobStatusRunning, job.Status)
+	assert.Equal(t, "client-1", jp.InputCID)
+
+	jp.ReturnJob = &models.Job{JID: "job-1", Status: models.JobStatusFailed, Error: "exit status 1", Result: &models.JobResult{StdErr: "no space left on device\n"}}
+	job, err = al.waitForJob(context.Background(), "client-1", "job-1", time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "job job-1 failed: exit status 1\nno space left on device", remediationOutcome(job))
+
+	jp.ReturnJob = nil
+	_, err = al.waitForJob(context.Background(), "client-1", "job-1", time.Minute)
+	assert.EqualError(t, err, "job job-1 not found")
+}
+
+func TestRemediationOutcomeTruncated(t *testing.T) {
+	job := &models.Job{JID: "job-1", Status: models.JobStatusSuccessful, Result: &models.JobResult{StdOut: strings.Repeat("x", 5000)}}
+	outcome := remediationOutcome(job)
+	assert.Len(t, outcome, len("job job-1 successful\n")+maxRemediationOutput+len("..."))
+}
@@ -0,0 +1,125 @@
+package chserver
+
+import (
+	"context"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/IOTech17/neo-rport/server/cgroups"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	chshare "github.com/IOTech17/neo-rport/share"
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+// clientReverseRemotes collects the reverse remotes of all groups the client belongs to.
+// If several groups use the same local address, the group sorted first wins.
+func clientReverseRemotes(client *clientdata.Client, groups []*cgroups.ClientGroup, l *logger.Logger) []*models.Remote {
+	res := make([]*models.Remote, 0)
+	seen := make(map[string]bool)
+	for _, group := range groups {
+		if len(group.ReverseRemotes) == 0 || !client.BelongsTo(group) {
+			continue
+		}
+		remotes, err := group.ParseReverseRemotes()
+		if err != nil {
+			l.Errorf("client group %s: %v", group.ID, err)
+			continue
+		}
+		for _, r := range remotes {
+			if seen[r.Local()] {
+				l.Infof("client group %s: reverse remote %s ignored for client %s, local address already in use", group.ID, r, client.GetID())
+				continue
+			}
+			seen[r.Local()] = true
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
+// updateReverseRemotes sends the reverse remotes derived from the client groups to the client. A quarantined client
+// gets none.
+func (s *Server) updateReverseRemotes(client *clientdata.Client, groups []*cgroups.ClientGroup) {
+	remotes := make([]*models.Remote, 0)
+	if !client.IsQuarantined() {
+		remotes = clientReverseRemotes(client, groups, s.Logger)
+	}
+	client.SetReverseRemotes(remotes)
+
+	err := comm.SendRequestAndGetResponse(client.GetConnection(), comm.RequestTypePutReverseRemotes, remotes, nil, s.Logger)
+	if err != nil {
+		if strings.Contains(err.Error(), "unknown request") {
+			if len(remotes) > 0 {
+				s.Infof("client %s does not support reverse remotes", client.GetID())
+			}
+			return
+		}
+		s.Errorf("failed to send reverse remotes to client %s: %v", client.GetID(), err)
+	}
+}
+
+// refreshReverseRemotes re-evaluates the reverse remotes, access schedules and edge rules of all connected clients, e.g.
+// after client groups or edge rules changed.
+func (s *Server) refreshReverseRemotes(ctx context.Context) {
+	groups, err := s.clientGroupProvider.GetAll(ctx)
+	if err != nil {
+		s.Errorf("failed to get client groups: %v", err)
+		return
+	}
+	edgeRules, err := s.edgeRules.List(ctx)
+	if err != nil {
+		s.Errorf("failed to get edge rules: %v", err)
+		return
+	}
+
+	for _, client := range s.clientService.GetAll() {
+		if !client.IsConnected() {
+			continue
+		}
+		client.SetAccessSchedules(clientAccessSchedules(client, groups))
+		s.updateReverseRemotes(client, groups)
+		s.updateEdgeRules(client, groups, edgeRules)
+	}
+}
+
+// handleReverseRemoteChannel connects a client to a server-side service, if a client group allows it.
+func (cl *ClientListener) handleReverseRemoteChannel(clientLog *logger.Logger, clientID string, ch ssh.NewChannel) {
+	target := string(ch.ExtraData())
+
+	client, err := cl.getClientService().GetActiveByID(clientID)
+	if err != nil || client == nil {
+		cl.rejectChannel(clientLog, ch, ssh.ConnectionFailed, "client not found")
+		return
+	}
+	if client.IsQuarantined() {
+		clientLog.Infof("Rejecting reverse remote to %q, client is quarantined", target)
+		cl.rejectChannel(clientLog, ch, ssh.Prohibited, "client is quarantined")
+		return
+	}
+
+	allowed := false
+	for _, r := range client.GetReverseRemotes() {
+		if r.Remote() == target {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		clientLog.Infof("Rejecting reverse remote to %q, not allowed by client groups", target)
+		cl.rejectChannel(clientLog, ch, ssh.Prohibited, "reverse remote not allowed")
+		return
+	}
+
+	stream, reqs, err := ch.Accept()
+	if err != nil {
+		clientLog.Debugf("Failed to accept reverse remote stream: %s", err)
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	connID := cl.connStats.New()
+	chshare.HandleTCPStream(clientLog.Fork("reverse#%d", connID), &cl.connStats, stream, target)
+}
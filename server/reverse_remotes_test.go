@@ -0,0 +1,49 @@
+package chserver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/IOTech17/neo-rport/server/cgroups"
+	"github.com/IOTech17/neo-rport/server/clients"
+)
+
+func TestClientReverseRemotes(t *testing.T) {
+	c := clients.New(t).ID("client-1").Logger(testLog).Build()
+
+	groups := []*cgroups.ClientGroup{
+		{
+			ID:             "group-1",
+			Params:         &cgroups.ClientParams{ClientID: &cgroups.ParamValues{"client-*"}},
+			ReverseRemotes: []string{"3142:apt-mirror:3142", "27000:license-server:27000"},
+		},
+		{
+			ID:             "group-2",
+			Params:         &cgroups.ClientParams{ClientID: &cgroups.ParamValues{"client-1"}},
+			ReverseRemotes: []string{"3142:other-mirror:3142", "8080:proxy:3128"},
+		},
+		{
+			ID:             "group-3",
+			Params:         &cgroups.ClientParams{ClientID: &cgroups.ParamValues{"client-2"}},
+			ReverseRemotes: []string{"9000:not-for-client-1:9000"},
+		},
+		{
+			ID:             "group-4",
+			Params:         &cgroups.ClientParams{ClientID: &cgroups.ParamValues{"client-1"}},
+			ReverseRemotes: []string{"invalid"},
+		},
+	}
+
+	remotes := clientReverseRemotes(c, groups, testLog)
+
+	var got []string
+	for _, r := range remotes {
+		got = append(got, r.Local()+"->"+r.Remote())
+	}
+	assert.Equal(t, []string{
+		"127.0.0.1:3142->apt-mirror:3142",
+		"127.0.0.1:27000->license-server:27000",
+		"127.0.0.1:8080->proxy:3128",
+	}, got)
+}
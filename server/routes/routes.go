@@ -1,34 +1,65 @@
 package routes
 
 const (
-	ParamClientID         = "client_id"
-	ParamClientAuthID     = "client_auth_id"
-	ParamUserID           = "user_id"
-	ParamSessionID        = "session_id"
-	ParamJobID            = "job_id"
-	ParamGroupID          = "group_id"
-	ParamTokenPrefix      = "prefix"
-	ParamVaultValueID     = "vault_value_id"
-	ParamScriptValueID    = "script_value_id"
-	ParamCommandValueID   = "command_value_id"
-	ParamGraphName        = "graph_name"
-	ParamTemplateID       = "template_id"
-	ParamProblemID        = "problem_id"
-	ParamNotificationID   = "notification_id"
-	ParamSampleDataChoice = "sample_data_choice"
+	ParamClientID          = "client_id"
+	ParamClientAuthID      = "client_auth_id"
+	ParamUserID            = "user_id"
+	ParamSessionID         = "session_id"
+	ParamJobID             = "job_id"
+	ParamGroupID           = "group_id"
+	ParamTokenPrefix       = "prefix"
+	ParamVaultValueID      = "vault_value_id"
+	ParamScriptValueID     = "script_value_id"
+	ParamCommandValueID    = "command_value_id"
+	ParamArtifactName      = "artifact_name"
+	ParamArtifactVersion   = "artifact_version"
+	ParamFileChangeID      = "file_change_id"
+	ParamDiscoveryScanID   = "discovery_scan_id"
+	ParamGraphName         = "graph_name"
+	ParamTemplateID        = "template_id"
+	ParamProblemID         = "problem_id"
+	ParamNotificationID    = "notification_id"
+	ParamRecipient         = "recipient"
+	ParamSampleDataChoice  = "sample_data_choice"
+	ParamMeshTunnelID      = "mesh_tunnel_id"
+	ParamGroupRuleID       = "group_rule_id"
+	ParamEdgeRuleID        = "edge_rule_id"
+	ParamRemediationID     = "remediation_id"
+	ParamOAuthProvider     = "provider"
+	ParamIP                = "ip"
+	ParamCapability        = "capability"
+	ParamChangeID          = "change_id"
+	ParamBulkResource      = "resource"
+	ParamExternalClientID  = "external_client_id"
+	ParamAgentlessTargetID = "agentless_target_id"
+	ParamSerialDeviceID    = "serial_device_id"
+	ParamSerialSessionID   = "serial_session_id"
+	ParamIndustrialID      = "industrial_endpoint_id"
 
 	AllRoutesPrefix             = "/api/v1"
+	V2RoutesPrefix              = "/api/v2"
 	AuthRoutesPrefix            = "/auth"
 	AuthProviderRoute           = "/provider"
+	AuthProvidersRoute          = "/providers"
 	AuthSettingsRoute           = "/ext/settings"
 	AuthDeviceSettingsRoute     = "/ext/settings/device"
+	AuthUnlockRoute             = "/unlock"
 	AlertingServiceRoutesPrefix = "/monitoring"
 	ASRuleSetRoute              = "/rules"
 	ASTemplatesRoute            = "/notification-templates"
 	ASProblemsRoute             = "/problems"
+	ASFlappingRoute             = "/flapping"
+	ASGroupRulesRoute           = "/group-rules"
+	ASEdgeRulesRoute            = "/edge-rules"
+	ASRemediationsRoute         = "/remediations"
+	ASClientDependenciesRoute   = "/client-dependencies"
 	ASRunTestRulesRoute         = "/test"
 	ASSampleDataRoute           = "/sample-data"
 	TotPRoutes                  = "/me/totp-secret"
 	Verify2FaRoute              = "/verify-2fa"
 	FilesUploadRouteName        = "files"
+	ArtifactCreateRouteName     = "artifact-create"
+	ConfigValidateRouteName     = "config-validate"
+	HealthzRoute                = "/healthz"
+	ReadyzRoute                 = "/readyz"
 )
@@ -0,0 +1,41 @@
+package chserver
+
+import (
+	"context"
+
+	"github.com/IOTech17/neo-rport/server/api/jobs"
+	"github.com/IOTech17/neo-rport/server/api/jobs/schedule"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+)
+
+// GetScheduleTargets returns the clients targeted by a scheduled job with the maintenance windows of the
+// client groups they belong to.
+func (al *APIListener) GetScheduleTargets(ctx context.Context, multiJobRequest *jobs.MultiJobRequest) ([]*schedule.Target, error) {
+	var clients []*clientdata.Client
+	var err error
+	if !hasClientTags(multiJobRequest) {
+		clients, _, err = al.getOrderedClients(ctx, multiJobRequest.ClientIDs, multiJobRequest.GroupIDs)
+	} else {
+		clients, err = al.getOrderedClientsByTag(multiJobRequest.ClientTags)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := al.clientGroupProvider.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*schedule.Target, 0, len(clients))
+	for _, client := range clients {
+		target := &schedule.Target{Client: client}
+		for _, group := range groups {
+			if group.MaintenanceWindows != nil && client.BelongsTo(group) {
+				target.MaintenanceWindows = append(target.MaintenanceWindows, group.MaintenanceWindows)
+			}
+		}
+		res = append(res, target)
+	}
+	return res, nil
+}
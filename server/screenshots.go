@@ -0,0 +1,184 @@
+package chserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/crypto/ssh"
+
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/routes"
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/random"
+)
+
+const (
+	screenshotTimeout  = 45 * time.Second
+	screenshotMaxBytes = 32 * 1024 * 1024
+)
+
+type screenshot struct {
+	ContentType string
+	Data        []byte
+	Err         error
+}
+
+type pendingScreenshot struct {
+	clientID string
+	done     chan *screenshot
+}
+
+// screenshotWaiters holds the screenshots requested from clients until they arrive on a screenshot channel.
+type screenshotWaiters struct {
+	m  map[string]*pendingScreenshot
+	mu sync.Mutex
+}
+
+func newScreenshotWaiters() *screenshotWaiters {
+	return &screenshotWaiters{
+		m: make(map[string]*pendingScreenshot),
+	}
+}
+
+func (w *screenshotWaiters) add(clientID string) (string, chan *screenshot) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	id := random.Hex(16)
+	done := make(chan *screenshot, 1)
+	w.m[id] = &pendingScreenshot{clientID: clientID, done: done}
+	return id, done
+}
+
+// take removes the pending screenshot, it returns nil if the id is unknown or belongs to another client.
+func (w *screenshotWaiters) take(id, clientID string) *pendingScreenshot {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	p := w.m[id]
+	if p == nil || p.clientID != clientID {
+		return nil
+	}
+	delete(w.m, id)
+	return p
+}
+
+func (w *screenshotWaiters) del(id string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.m, id)
+}
+
+// handleGetClientScreenshot handles GET /clients/{client_id}/screenshot, it returns a screenshot of the desktop of
+// the client. The client must allow screenshots by its config.
+func (al *APIListener) handleGetClientScreenshot(w http.ResponseWriter, req *http.Request) {
+	clientID := mux.Vars(req)[routes.ParamClientID]
+
+	client, err := al.clientService.GetActiveByID(clientID)
+	if err != nil {
+		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if client == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("client with id %s not found", clientID))
+		return
+	}
+
+	consentID, err := al.askConsent(req, client, comm.ConsentSubjectScreenshot, "")
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	id, done := al.screenshots.add(clientID)
+	defer al.screenshots.del(id)
+
+	err = comm.SendRequestAndGetResponse(client.GetConnection(), comm.RequestTypeTakeScreenshot, comm.TakeScreenshotRequest{ID: id, ConsentID: consentID}, nil, al.Log())
+	if err != nil {
+		if strings.Contains(err.Error(), "unknown request") {
+			err = errors.New("client does not support screenshots")
+		}
+		al.jsonError(w, errors2.APIError{
+			HTTPStatus: http.StatusConflict,
+			Err:        err,
+		})
+		return
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientScreenshot, auditlog.ActionCreate).
+		WithHTTPRequest(req).
+		WithClientID(clientID).
+		Save()
+
+	select {
+	case s := <-done:
+		if s.Err != nil {
+			al.jsonError(w, errors2.APIError{
+				HTTPStatus: http.StatusBadGateway,
+				Err:        s.Err,
+			})
+			return
+		}
+		w.Header().Set("Content-Type", s.ContentType)
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(s.Data)
+	case <-time.After(screenshotTimeout):
+		al.jsonErrorResponseWithTitle(w, http.StatusGatewayTimeout, "timeout waiting for the screenshot of the client")
+	case <-req.Context().Done():
+	}
+}
+
+// handleScreenshotChannel receives a screenshot requested from the client.
+func (cl *ClientListener) handleScreenshotChannel(clientLog *logger.Logger, clientID string, ch ssh.NewChannel) {
+	var result comm.ScreenshotResult
+	if err := json.Unmarshal(ch.ExtraData(), &result); err != nil {
+		cl.rejectChannel(clientLog, ch, ssh.ConnectionFailed, "invalid screenshot result")
+		return
+	}
+	p := cl.server.screenshots.take(result.ID, clientID)
+	if p == nil {
+		clientLog.Infof("Rejecting unrequested screenshot %q", result.ID)
+		cl.rejectChannel(clientLog, ch, ssh.Prohibited, "screenshot not requested")
+		return
+	}
+
+	stream, reqs, err := ch.Accept()
+	if err != nil {
+		clientLog.Debugf("Failed to accept screenshot stream: %s", err)
+		p.done <- &screenshot{Err: err}
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	defer stream.Close()
+
+	p.done <- readScreenshot(stream, result)
+}
+
+func readScreenshot(r io.Reader, result comm.ScreenshotResult) *screenshot {
+	if result.Error != "" {
+		return &screenshot{Err: errors.New(result.Error)}
+	}
+	data, err := io.ReadAll(io.LimitReader(r, screenshotMaxBytes+1))
+	if err != nil {
+		return &screenshot{Err: err}
+	}
+	if len(data) > screenshotMaxBytes {
+		return &screenshot{Err: fmt.Errorf("screenshot exceeds the limit of %d bytes", screenshotMaxBytes)}
+	}
+	contentType := result.ContentType
+	if !strings.HasPrefix(contentType, "image/") {
+		contentType = http.DetectContentType(data)
+	}
+	if !strings.HasPrefix(contentType, "image/") {
+		return &screenshot{Err: fmt.Errorf("screenshot is not an image: %s", contentType)}
+	}
+	return &screenshot{ContentType: contentType, Data: data}
+}
@@ -0,0 +1,122 @@
+package chserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/server/chconfig"
+	"github.com/IOTech17/neo-rport/server/clients"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/test"
+)
+
+// pngHeader is enough for the content type detection
+const pngHeader = "\x89PNG\r\n\x1a\n"
+
+func TestHandleGetClientScreenshot(t *testing.T) {
+	c1 := clients.New(t).Logger(testLog).Build()
+	clientService := clients.NewClientService(nil, nil, clients.NewClientRepository([]*clientdata.Client{c1}, &hour, testLog), testLog, nil)
+	al := APIListener{
+		insecureForTests: true,
+		Server: &Server{
+			clientService: clientService,
+			config:        &chconfig.Config{},
+			screenshots:   newScreenshotWaiters(),
+		},
+		Logger: testLog,
+	}
+	al.initRouter()
+
+	testCases := []struct {
+		name           string
+		result         comm.ScreenshotResult
+		data           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "screenshot",
+			result:         comm.ScreenshotResult{ContentType: "image/png"},
+			data:           pngHeader,
+			expectedStatus: http.StatusOK,
+			expectedBody:   pngHeader,
+		},
+		{
+			name:           "capture failed",
+			result:         comm.ScreenshotResult{Error: "no display"},
+			expectedStatus: http.StatusBadGateway,
+			expectedBody:   `{"errors":[{"code":"","title":"no display","detail":""}]}`,
+		},
+		{
+			name:           "not an image",
+			result:         comm.ScreenshotResult{ContentType: "text/plain"},
+			data:           "hello",
+			expectedStatus: http.StatusBadGateway,
+			expectedBody:   `{"errors":[{"code":"","title":"screenshot is not an image: text/plain; charset=utf-8","detail":""}]}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			connMock := test.NewConnMock()
+			connMock.ReturnOk = true
+			connMock.DoneChannel = make(chan bool, 1)
+			c1.SetConnection(connMock)
+
+			// the client sends the screenshot on a channel
+			go func() {
+				<-connMock.DoneChannel
+				name, _, payload := connMock.InputSendRequest()
+				assert.Equal(t, comm.RequestTypeTakeScreenshot, name)
+				var req comm.TakeScreenshotRequest
+				assert.NoError(t, json.Unmarshal(payload, &req))
+
+				assert.Nil(t, al.screenshots.take(req.ID, "other-client"))
+				p := al.screenshots.take(req.ID, c1.GetID())
+				if assert.NotNil(t, p) {
+					tc.result.ID = req.ID
+					p.done <- readScreenshot(strings.NewReader(tc.data), tc.result)
+				}
+			}()
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/clients/"+c1.GetID()+"/screenshot", nil)
+			al.router.ServeHTTP(w, req)
+
+			require.Equal(t, tc.expectedStatus, w.Code)
+			assert.Equal(t, tc.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandleGetClientScreenshotDisabled(t *testing.T) {
+	c1 := clients.New(t).Logger(testLog).Build()
+	connMock := test.NewConnMock()
+	connMock.ReturnResponsePayload = []byte(`screenshots are disabled by "screenshots.enabled" config`)
+	c1.SetConnection(connMock)
+	al := APIListener{
+		insecureForTests: true,
+		Server: &Server{
+			clientService: clients.NewClientService(nil, nil, clients.NewClientRepository([]*clientdata.Client{c1}, &hour, testLog), testLog, nil),
+			config:        &chconfig.Config{},
+			screenshots:   newScreenshotWaiters(),
+		},
+		Logger: testLog,
+	}
+	al.initRouter()
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/clients/"+c1.GetID()+"/screenshot", nil)
+	al.router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), `screenshots are disabled by \"screenshots.enabled\" config`)
+	assert.Empty(t, al.screenshots.m)
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/IOTech17/neo-rport/share/types"
 
 	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/api/library"
 )
 
 var (
@@ -27,21 +28,25 @@ var (
 		"cwd":         true,
 		"script":      true,
 		"tags":        true,
+		"visibility":  true,
 	}
 	supportedFields = map[string]map[string]bool{
 		"scripts": {
-			"id":          true,
-			"name":        true,
-			"created_by":  true,
-			"created_at":  true,
-			"updated_by":  true,
-			"updated_at":  true,
-			"interpreter": true,
-			"is_sudo":     true,
-			"cwd":         true,
-			"script":      true,
-			"tags":        true,
-			"timeout_sec": true,
+			"id":            true,
+			"name":          true,
+			"created_by":    true,
+			"created_at":    true,
+			"updated_by":    true,
+			"updated_at":    true,
+			"interpreter":   true,
+			"is_sudo":       true,
+			"cwd":           true,
+			"script":        true,
+			"tags":          true,
+			"timeout_sec":   true,
+			"output_parser": true,
+			"visibility":    true,
+			"shared_groups": true,
 		},
 	}
 	manualFiltersConfig = map[string]bool{
@@ -69,7 +74,8 @@ func NewManager(db DbProvider, logger *logger.Logger) *Manager {
 	}
 }
 
-func (m *Manager) List(ctx context.Context, re *http.Request) ([]Script, int, error) {
+// List returns the scripts the user has access to.
+func (m *Manager) List(ctx context.Context, re *http.Request, user library.User) ([]Script, int, error) {
 	listOptions := query.GetListOptions(re)
 
 	err := query.ValidateListOptions(listOptions, supportedSortAndFilters, supportedSortAndFilters, supportedFields, &query.PaginationConfig{
@@ -80,6 +86,11 @@ func (m *Manager) List(ctx context.Context, re *http.Request) ([]Script, int, er
 		return nil, 0, err
 	}
 
+	var accessFields []string
+	if !user.IsAdmin() {
+		listOptions.Fields, accessFields = library.WithAccessFields(listOptions.Fields, "scripts")
+	}
+
 	manualFilters, dbFilters := query.SplitFilters(listOptions.Filters, manualFiltersConfig)
 	pagination := listOptions.Pagination
 
@@ -93,11 +104,15 @@ func (m *Manager) List(ctx context.Context, re *http.Request) ([]Script, int, er
 
 	filtered := make([]Script, 0, len(entries))
 	for _, entry := range entries {
+		if !entry.sharing().CanAccess(user) {
+			continue
+		}
 		matches, err := query.MatchesFilters(entry, manualFilters)
 		if err != nil {
 			return nil, 0, err
 		}
 		if matches {
+			entry.removeFields(accessFields)
 			filtered = append(filtered, entry)
 		}
 	}
@@ -109,7 +124,8 @@ func (m *Manager) List(ctx context.Context, re *http.Request) ([]Script, int, er
 	return limited, totalCount, nil
 }
 
-func (m *Manager) GetOne(ctx context.Context, re *http.Request, id string) (*Script, bool, error) {
+// GetOne returns the script if the user has access to it.
+func (m *Manager) GetOne(ctx context.Context, re *http.Request, id string, user library.User) (*Script, bool, error) {
 	retrieveOptions := query.GetRetrieveOptions(re)
 
 	err := query.ValidateRetrieveOptions(retrieveOptions, supportedFields)
@@ -117,6 +133,11 @@ func (m *Manager) GetOne(ctx context.Context, re *http.Request, id string) (*Scr
 		return nil, false, err
 	}
 
+	var accessFields []string
+	if !user.IsAdmin() {
+		retrieveOptions.Fields, accessFields = library.WithAccessFields(retrieveOptions.Fields, "scripts")
+	}
+
 	val, found, err := m.db.GetByID(ctx, id, retrieveOptions)
 	if err != nil {
 		return nil, false, err
@@ -125,6 +146,10 @@ func (m *Manager) GetOne(ctx context.Context, re *http.Request, id string) (*Scr
 	if !found {
 		return nil, false, nil
 	}
+	if !val.sharing().CanAccess(user) {
+		return nil, false, library.ErrAccessDenied
+	}
+	val.removeFields(accessFields)
 
 	return val, true, nil
 }
@@ -158,18 +183,23 @@ func (m *Manager) Create(ctx context.Context, valueToStore *InputScript, usernam
 
 	now := time.Now()
 	scriptToSave := &Script{
-		Name:        valueToStore.Name,
-		CreatedBy:   username,
-		CreatedAt:   &now,
-		UpdatedBy:   username,
-		UpdatedAt:   &now,
-		Interpreter: &valueToStore.Interpreter,
-		IsSudo:      &valueToStore.IsSudo,
-		Cwd:         &valueToStore.Cwd,
-		Script:      valueToStore.Script,
-		Tags:        (*types.StringSlice)(&valueToStore.Tags),
-		TimoutSec:   &valueToStore.TimoutSec,
+		Name:         valueToStore.Name,
+		CreatedBy:    username,
+		CreatedAt:    &now,
+		UpdatedBy:    username,
+		UpdatedAt:    &now,
+		Interpreter:  &valueToStore.Interpreter,
+		IsSudo:       &valueToStore.IsSudo,
+		Cwd:          &valueToStore.Cwd,
+		Script:       valueToStore.Script,
+		Tags:         (*types.StringSlice)(&valueToStore.Tags),
+		TimoutSec:    &valueToStore.TimoutSec,
+		OutputParser: valueToStore.OutputParser,
 	}
+	scriptToSave.setSharing(library.Sharing{
+		Visibility:   valueToStore.Visibility,
+		SharedGroups: valueToStore.SharedGroups,
+	})
 	scriptToSave.ID, err = m.db.Save(ctx, scriptToSave, now)
 	if err != nil {
 		return nil, err
@@ -178,7 +208,7 @@ func (m *Manager) Create(ctx context.Context, valueToStore *InputScript, usernam
 	return scriptToSave, nil
 }
 
-func (m *Manager) Update(ctx context.Context, existingID string, valueToStore *InputScript, username string) (*Script, error) {
+func (m *Manager) Update(ctx context.Context, existingID string, valueToStore *InputScript, user library.User) (*Script, error) {
 	err := Validate(valueToStore)
 	if err != nil {
 		return nil, err
@@ -195,6 +225,13 @@ func (m *Manager) Update(ctx context.Context, existingID string, valueToStore *I
 			HTTPStatus: http.StatusNotFound,
 		}
 	}
+	if !existing.sharing().CanAccess(user) {
+		return nil, library.ErrAccessDenied
+	}
+	sharing, err := library.UpdatedSharing(existing.sharing(), valueToStore.Visibility, valueToStore.SharedGroups, user)
+	if err != nil {
+		return nil, err
+	}
 
 	scriptsWithSameName, err := m.db.List(ctx, &query.ListOptions{
 		Filters: []query.FilterOption{
@@ -220,19 +257,21 @@ func (m *Manager) Update(ctx context.Context, existingID string, valueToStore *I
 
 	now := time.Now()
 	scriptToSave := &Script{
-		ID:          existingID,
-		Name:        valueToStore.Name,
-		CreatedBy:   existing.CreatedBy,
-		CreatedAt:   existing.CreatedAt,
-		UpdatedBy:   username,
-		UpdatedAt:   &now,
-		Interpreter: &valueToStore.Interpreter,
-		IsSudo:      &valueToStore.IsSudo,
-		Cwd:         &valueToStore.Cwd,
-		Script:      valueToStore.Script,
-		TimoutSec:   &valueToStore.TimoutSec,
-		Tags:        (*types.StringSlice)(&valueToStore.Tags),
+		ID:           existingID,
+		Name:         valueToStore.Name,
+		CreatedBy:    existing.CreatedBy,
+		CreatedAt:    existing.CreatedAt,
+		UpdatedBy:    user.GetUsername(),
+		UpdatedAt:    &now,
+		Interpreter:  &valueToStore.Interpreter,
+		IsSudo:       &valueToStore.IsSudo,
+		Cwd:          &valueToStore.Cwd,
+		Script:       valueToStore.Script,
+		TimoutSec:    &valueToStore.TimoutSec,
+		OutputParser: valueToStore.OutputParser,
+		Tags:         (*types.StringSlice)(&valueToStore.Tags),
 	}
+	scriptToSave.setSharing(sharing)
 	scriptToSave.ID, err = m.db.Save(ctx, scriptToSave, now)
 	if err != nil {
 		return nil, err
@@ -241,8 +280,8 @@ func (m *Manager) Update(ctx context.Context, existingID string, valueToStore *I
 	return scriptToSave, nil
 }
 
-func (m *Manager) Delete(ctx context.Context, id string) error {
-	_, found, err := m.db.GetByID(ctx, id, &query.RetrieveOptions{})
+func (m *Manager) Delete(ctx context.Context, id string, user library.User) error {
+	existing, found, err := m.db.GetByID(ctx, id, &query.RetrieveOptions{})
 	if err != nil {
 		return errors2.APIError{
 			Err:        err,
@@ -256,6 +295,9 @@ func (m *Manager) Delete(ctx context.Context, id string) error {
 			HTTPStatus: http.StatusNotFound,
 		}
 	}
+	if !existing.sharing().CanAccess(user) {
+		return library.ErrAccessDenied
+	}
 
 	err = m.db.Delete(ctx, id)
 	if err != nil {
@@ -14,12 +14,16 @@ import (
 	"github.com/stretchr/testify/require"
 
 	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/api/users"
 	chshare "github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/ptr"
 	"github.com/IOTech17/neo-rport/share/query"
 )
 
 var testLog = chshare.NewLogger("script", chshare.LogOutput{File: os.Stdout}, chshare.LogLevelDebug)
 
+var testAdmin = &users.User{Username: "someuser", Groups: []string{users.Administrators}}
+
 type DbProviderMock struct {
 	getByIDGiven        string
 	getByIDScriptToGive *Script
@@ -100,7 +104,7 @@ func TestManagerList(t *testing.T) {
 		URL: inputURL,
 	}
 
-	actualValues, count, err := mngr.List(context.Background(), req)
+	actualValues, count, err := mngr.List(context.Background(), req, testAdmin)
 	require.NoError(t, err)
 
 	assert.Equal(
@@ -140,7 +144,7 @@ func TestManagerList(t *testing.T) {
 
 	mngr = NewManager(dbProv, testLog)
 
-	_, _, err = mngr.List(context.Background(), req)
+	_, _, err = mngr.List(context.Background(), req, testAdmin)
 	require.EqualError(t, err, "list error")
 }
 
@@ -158,10 +162,46 @@ func TestListWithUnsupportedOptions(t *testing.T) {
 		URL: inputURL,
 	}
 
-	_, _, err = mngr.List(context.Background(), req)
+	_, _, err = mngr.List(context.Background(), req, testAdmin)
 	require.EqualError(t, err, `unsupported sort field 'unsupportedSortField', unsupported filter field 'filter[unsupportedFilter]', unsupported field "nope" for resource "scripts"`)
 }
 
+func TestManagerSharing(t *testing.T) {
+	dbProv := &DbProviderMock{
+		listValuesToGive: []Script{
+			{ID: "1", Name: "global", CreatedBy: "user1", Visibility: ptr.String("global"), SharedGroups: ptr.StringSlice()},
+			{ID: "2", Name: "private", CreatedBy: "user1", Visibility: ptr.String("private"), SharedGroups: ptr.StringSlice()},
+			{ID: "3", Name: "ops", CreatedBy: "user1", Visibility: ptr.String("group"), SharedGroups: ptr.StringSlice("ops")},
+		},
+		getByIDScriptToGive: &Script{ID: "2", Name: "private", CreatedBy: "user1", Visibility: ptr.String("private")},
+		getByIDFoundToGive:  true,
+	}
+	mngr := NewManager(dbProv, testLog)
+	user := &users.User{Username: "user2", Groups: []string{"ops"}}
+
+	inputURL, err := url.Parse("/someu?fields[scripts]=id,name")
+	require.NoError(t, err)
+	req := &http.Request{URL: inputURL}
+
+	actualValues, count, err := mngr.List(context.Background(), req, user)
+	require.NoError(t, err)
+	assert.Equal(t, []query.FieldsOption{
+		{Resource: "scripts", Fields: []string{"id", "name", "created_by", "visibility", "shared_groups"}},
+	}, dbProv.listOptionInput.Fields)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, []Script{{ID: "1", Name: "global"}, {ID: "3", Name: "ops"}}, actualValues)
+
+	_, _, err = mngr.GetOne(context.Background(), req, "2", user)
+	assert.EqualError(t, err, "the item is not shared with you")
+
+	_, err = mngr.Update(context.Background(), "2", &InputScript{Name: "private", Script: "ls"}, user)
+	assert.EqualError(t, err, "the item is not shared with you")
+
+	err = mngr.Delete(context.Background(), "2", user)
+	assert.EqualError(t, err, "the item is not shared with you")
+	assert.Equal(t, "", dbProv.deleteIDGiven)
+}
+
 func TestManagerClose(t *testing.T) {
 	dbProv := &DbProviderMock{
 		listValuesToGive: []Script{},
@@ -199,7 +239,7 @@ func TestGetOne(t *testing.T) {
 
 	mngr := NewManager(dbProv, testLog)
 
-	val, found, err := mngr.GetOne(context.Background(), req, "1")
+	val, found, err := mngr.GetOne(context.Background(), req, "1", testAdmin)
 	require.NoError(t, err)
 	assert.True(t, found)
 	assert.Equal(t, expectedValue, val)
@@ -210,7 +250,7 @@ func TestGetOne(t *testing.T) {
 
 	mngr = NewManager(dbProv, testLog)
 
-	_, found, err = mngr.GetOne(context.Background(), req, "1")
+	_, found, err = mngr.GetOne(context.Background(), req, "1", testAdmin)
 	require.NoError(t, err)
 	assert.False(t, found)
 
@@ -220,7 +260,7 @@ func TestGetOne(t *testing.T) {
 
 	mngr = NewManager(dbProv, testLog)
 
-	_, _, err = mngr.GetOne(context.Background(), req, "1")
+	_, _, err = mngr.GetOne(context.Background(), req, "1", testAdmin)
 	require.EqualError(t, err, "some get id error")
 }
 
@@ -280,7 +320,7 @@ func TestStore(t *testing.T) {
 		}
 		mngr := NewManager(dbProv, testLog)
 
-		storedScript, err := mngr.Update(context.Background(), idToUpdate, inputValue, "someuser")
+		storedScript, err := mngr.Update(context.Background(), idToUpdate, inputValue, testAdmin)
 		require.NoError(t, err)
 
 		assert.Equal(t, idToUpdate, storedScript.ID)
@@ -319,7 +359,7 @@ func TestStore(t *testing.T) {
 		}
 		mngr := NewManager(dbProv, testLog)
 
-		_, err := mngr.Update(context.Background(), "1", inputValue, "someuser")
+		_, err := mngr.Update(context.Background(), "1", inputValue, testAdmin)
 		require.EqualError(t, err, "another script with the same name 'some name' exists")
 	})
 
@@ -329,7 +369,7 @@ func TestStore(t *testing.T) {
 		}
 		mngr := NewManager(dbProv, testLog)
 
-		_, err := mngr.Update(context.Background(), "1", inputValue, "someuser")
+		_, err := mngr.Update(context.Background(), "1", inputValue, testAdmin)
 		require.EqualError(t, err, "cannot find entry by the provided ID")
 	})
 
@@ -355,7 +395,7 @@ func TestStore(t *testing.T) {
 		}
 		mngr := NewManager(dbProv, testLog)
 
-		_, err := mngr.Update(context.Background(), "1", inputValue, "someuser")
+		_, err := mngr.Update(context.Background(), "1", inputValue, testAdmin)
 		require.EqualError(t, err, "failed to find anything")
 	})
 
@@ -363,7 +403,7 @@ func TestStore(t *testing.T) {
 		dbProv := &DbProviderMock{}
 		mngr := NewManager(dbProv, testLog)
 
-		_, err := mngr.Update(context.Background(), "1", &InputScript{}, "someuser")
+		_, err := mngr.Update(context.Background(), "1", &InputScript{}, testAdmin)
 		require.EqualError(t, err, "name is required, script is required")
 	})
 
@@ -375,7 +415,7 @@ func TestStore(t *testing.T) {
 		}
 		mngr := NewManager(dbProv, testLog)
 
-		_, err := mngr.Update(context.Background(), "1", inputValue, "someuser")
+		_, err := mngr.Update(context.Background(), "1", inputValue, testAdmin)
 		require.EqualError(t, err, "failed to save")
 	})
 }
@@ -387,7 +427,7 @@ func TestDeleteScript(t *testing.T) {
 		}
 		mngr := NewManager(dbProv, testLog)
 
-		err := mngr.Delete(context.Background(), "1")
+		err := mngr.Delete(context.Background(), "1", testAdmin)
 		require.NoError(t, err)
 
 		assert.Equal(t, "1", dbProv.deleteIDGiven)
@@ -400,7 +440,7 @@ func TestDeleteScript(t *testing.T) {
 		}
 		mngr := NewManager(dbProv, testLog)
 
-		err := mngr.Delete(context.Background(), "1")
+		err := mngr.Delete(context.Background(), "1", testAdmin)
 		require.EqualError(t, err, "cannot delete")
 	})
 
@@ -410,7 +450,7 @@ func TestDeleteScript(t *testing.T) {
 		}
 		mngr := NewManager(dbProv, testLog)
 
-		err := mngr.Delete(context.Background(), "1")
+		err := mngr.Delete(context.Background(), "1", testAdmin)
 		require.Equal(
 			t,
 			errors2.APIError{
@@ -428,7 +468,7 @@ func TestDeleteScript(t *testing.T) {
 		}
 		mngr := NewManager(dbProv, testLog)
 
-		err := mngr.Delete(context.Background(), "1")
+		err := mngr.Delete(context.Background(), "1", testAdmin)
 		require.Equal(
 			t,
 			errors2.APIError{
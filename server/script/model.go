@@ -3,6 +3,8 @@ package script
 import (
 	"time"
 
+	"github.com/IOTech17/neo-rport/server/api/library"
+	"github.com/IOTech17/neo-rport/share/models"
 	"github.com/IOTech17/neo-rport/share/types"
 )
 
@@ -11,26 +13,73 @@ const DefaultTimeoutSec = 60
 // To support sparse fieldsets, the fields that can have zero value,
 // use pointers so they're omitted only when they're nil not when they're zero value
 type Script struct {
-	ID          string             `json:"id,omitempty" db:"id"`
-	Name        string             `json:"name,omitempty" db:"name"`
-	CreatedBy   string             `json:"created_by,omitempty" db:"created_by"`
-	CreatedAt   *time.Time         `json:"created_at,omitempty" db:"created_at"`
-	UpdatedBy   string             `json:"updated_by,omitempty" db:"updated_by"`
-	UpdatedAt   *time.Time         `json:"updated_at,omitempty" db:"updated_at"`
-	Interpreter *string            `json:"interpreter,omitempty" db:"interpreter"`
-	IsSudo      *bool              `json:"is_sudo,omitempty" db:"is_sudo"`
-	Cwd         *string            `json:"cwd,omitempty" db:"cwd"`
-	Script      string             `json:"script,omitempty" db:"script"`
-	Tags        *types.StringSlice `json:"tags,omitempty" db:"tags"`
-	TimoutSec   *int               `json:"timeout_sec,omitempty" db:"timeout_sec"`
+	ID           string               `json:"id,omitempty" db:"id"`
+	Name         string               `json:"name,omitempty" db:"name"`
+	CreatedBy    string               `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt    *time.Time           `json:"created_at,omitempty" db:"created_at"`
+	UpdatedBy    string               `json:"updated_by,omitempty" db:"updated_by"`
+	UpdatedAt    *time.Time           `json:"updated_at,omitempty" db:"updated_at"`
+	Interpreter  *string              `json:"interpreter,omitempty" db:"interpreter"`
+	IsSudo       *bool                `json:"is_sudo,omitempty" db:"is_sudo"`
+	Cwd          *string              `json:"cwd,omitempty" db:"cwd"`
+	Script       string               `json:"script,omitempty" db:"script"`
+	Tags         *types.StringSlice   `json:"tags,omitempty" db:"tags"`
+	TimoutSec    *int                 `json:"timeout_sec,omitempty" db:"timeout_sec"`
+	OutputParser *models.OutputParser `json:"output_parser,omitempty" db:"output_parser"`
+	Visibility   *string              `json:"visibility,omitempty" db:"visibility"`
+	SharedGroups *types.StringSlice   `json:"shared_groups,omitempty" db:"shared_groups"`
 }
 
 type InputScript struct {
-	Name        string   `json:"name" db:"name"`
-	Interpreter string   `json:"interpreter" db:"interpreter"`
-	IsSudo      bool     `json:"is_sudo" db:"is_sudo"`
-	Cwd         string   `json:"cwd" db:"cwd"`
-	Script      string   `json:"script" db:"script"`
-	Tags        []string `json:"tags" db:"tags"`
-	TimoutSec   int      `json:"timeout_sec" db:"timeout_sec"`
+	Name         string               `json:"name" db:"name"`
+	Interpreter  string               `json:"interpreter" db:"interpreter"`
+	IsSudo       bool                 `json:"is_sudo" db:"is_sudo"`
+	Cwd          string               `json:"cwd" db:"cwd"`
+	Script       string               `json:"script" db:"script"`
+	Tags         []string             `json:"tags" db:"tags"`
+	TimoutSec    int                  `json:"timeout_sec" db:"timeout_sec"`
+	OutputParser *models.OutputParser `json:"output_parser" db:"output_parser"`
+	Visibility   string               `json:"visibility" db:"visibility"`
+	SharedGroups []string             `json:"shared_groups" db:"shared_groups"`
+}
+
+func (s *Script) sharing() library.Sharing {
+	if s == nil {
+		return library.Sharing{}
+	}
+	sharing := library.Sharing{CreatedBy: s.CreatedBy}
+	if s.Visibility != nil {
+		sharing.Visibility = *s.Visibility
+	}
+	if s.SharedGroups != nil {
+		sharing.SharedGroups = *s.SharedGroups
+	}
+	return sharing
+}
+
+func (s *Script) setSharing(sharing library.Sharing) {
+	visibility := sharing.Visibility
+	if visibility == "" {
+		visibility = library.VisibilityGlobal
+	}
+	sharedGroups := types.StringSlice(sharing.SharedGroups)
+	if sharedGroups == nil {
+		sharedGroups = types.StringSlice{}
+	}
+	s.Visibility = &visibility
+	s.SharedGroups = &sharedGroups
+}
+
+// removeFields clears the fields that were only fetched to check the access
+func (s *Script) removeFields(fields []string) {
+	for _, field := range fields {
+		switch field {
+		case library.FieldCreatedBy:
+			s.CreatedBy = ""
+		case library.FieldVisibility:
+			s.Visibility = nil
+		case library.FieldSharedGroups:
+			s.SharedGroups = nil
+		}
+	}
 }
@@ -80,9 +80,9 @@ func (p *SqliteProvider) Save(ctx context.Context, s *Script, nowDate time.Time)
 		_, err = p.db.NamedExecContext(
 			ctx,
 			"INSERT INTO `scripts`"+
-				" (`id`, `name`, `created_at`, `created_by`, `interpreter`, `is_sudo`, `cwd`, `script`, `updated_at`, `updated_by`, `tags`, `timeout_sec`)"+
+				" (`id`, `name`, `created_at`, `created_by`, `interpreter`, `is_sudo`, `cwd`, `script`, `updated_at`, `updated_by`, `tags`, `timeout_sec`, `output_parser`, `visibility`, `shared_groups`)"+
 				" VALUES "+
-				"(:id, :name, :created_at, :created_by, :interpreter, :is_sudo, :cwd, :script, :updated_at, :updated_by, :tags, :timeout_sec)",
+				"(:id, :name, :created_at, :created_by, :interpreter, :is_sudo, :cwd, :script, :updated_at, :updated_by, :tags, :timeout_sec, :output_parser, :visibility, :shared_groups)",
 			s,
 		)
 
@@ -98,7 +98,10 @@ func (p *SqliteProvider) Save(ctx context.Context, s *Script, nowDate time.Time)
 		"`updated_at` = :updated_at, " +
 		"`updated_by` = :updated_by, " +
 		"`tags` = :tags, " +
-		"`timeout_sec` = :timeout_sec" +
+		"`timeout_sec` = :timeout_sec, " +
+		"`output_parser` = :output_parser, " +
+		"`visibility` = :visibility, " +
+		"`shared_groups` = :shared_groups" +
 		" WHERE id = :id "
 
 	_, err := p.db.NamedExecContext(ctx, q, s)
@@ -9,6 +9,7 @@ import (
 	"github.com/IOTech17/neo-rport/db/sqlite"
 	"github.com/IOTech17/neo-rport/share/ptr"
 	"github.com/IOTech17/neo-rport/share/query"
+	"github.com/IOTech17/neo-rport/share/types"
 
 	"github.com/jmoiron/sqlx"
 
@@ -22,32 +23,36 @@ var DataSourceOptions = sqlite.DataSourceOptions{WALEnabled: false}
 var timeoutSec = DefaultTimeoutSec
 var demoData = []Script{
 	{
-		ID:          "1",
-		Name:        "some name",
-		CreatedBy:   "user1",
-		CreatedAt:   ptr.Time(time.Date(2001, 1, 1, 1, 0, 0, 0, time.UTC)),
-		UpdatedBy:   "user2",
-		UpdatedAt:   ptr.Time(time.Date(2001, 1, 1, 2, 0, 0, 0, time.UTC)),
-		Interpreter: ptr.String("bash"),
-		IsSudo:      ptr.Bool(false),
-		Cwd:         ptr.String("/bin"),
-		Script:      "ls -la",
-		Tags:        ptr.StringSlice("tag1", "tag2"),
-		TimoutSec:   &timeoutSec,
+		ID:           "1",
+		Name:         "some name",
+		CreatedBy:    "user1",
+		CreatedAt:    ptr.Time(time.Date(2001, 1, 1, 1, 0, 0, 0, time.UTC)),
+		UpdatedBy:    "user2",
+		UpdatedAt:    ptr.Time(time.Date(2001, 1, 1, 2, 0, 0, 0, time.UTC)),
+		Interpreter:  ptr.String("bash"),
+		IsSudo:       ptr.Bool(false),
+		Cwd:          ptr.String("/bin"),
+		Script:       "ls -la",
+		Tags:         ptr.StringSlice("tag1", "tag2"),
+		TimoutSec:    &timeoutSec,
+		Visibility:   ptr.String("global"),
+		SharedGroups: &types.StringSlice{},
 	},
 	{
-		ID:          "2",
-		Name:        "other name 2",
-		CreatedBy:   "user1",
-		CreatedAt:   ptr.Time(time.Date(2002, 1, 1, 1, 0, 0, 0, time.UTC)),
-		UpdatedBy:   "user1",
-		UpdatedAt:   ptr.Time(time.Date(2002, 1, 1, 1, 0, 0, 0, time.UTC)),
-		Interpreter: ptr.String("sh"),
-		IsSudo:      ptr.Bool(true),
-		Cwd:         ptr.String("/bin"),
-		Script:      "pwd",
-		Tags:        ptr.StringSlice(),
-		TimoutSec:   &timeoutSec,
+		ID:           "2",
+		Name:         "other name 2",
+		CreatedBy:    "user1",
+		CreatedAt:    ptr.Time(time.Date(2002, 1, 1, 1, 0, 0, 0, time.UTC)),
+		UpdatedBy:    "user1",
+		UpdatedAt:    ptr.Time(time.Date(2002, 1, 1, 1, 0, 0, 0, time.UTC)),
+		Interpreter:  ptr.String("sh"),
+		IsSudo:       ptr.Bool(true),
+		Cwd:          ptr.String("/bin"),
+		Script:       "pwd",
+		Tags:         ptr.StringSlice(),
+		TimoutSec:    &timeoutSec,
+		Visibility:   ptr.String("global"),
+		SharedGroups: &types.StringSlice{},
 	},
 }
 
@@ -276,18 +281,21 @@ func TestUpdate(t *testing.T) {
 
 	expectedRows := []map[string]interface{}{
 		{
-			"id":          "1",
-			"name":        itemToSave.Name,
-			"created_at":  *itemToSave.CreatedAt,
-			"created_by":  itemToSave.CreatedBy,
-			"updated_at":  *itemToSave.UpdatedAt,
-			"updated_by":  itemToSave.UpdatedBy,
-			"interpreter": *itemToSave.Interpreter,
-			"is_sudo":     int64(0),
-			"cwd":         *itemToSave.Cwd,
-			"script":      itemToSave.Script,
-			"tags":        `["tag1","tag2"]`,
-			"timeout_sec": int64(timeoutSec),
+			"id":            "1",
+			"name":          itemToSave.Name,
+			"created_at":    *itemToSave.CreatedAt,
+			"created_by":    itemToSave.CreatedBy,
+			"updated_at":    *itemToSave.UpdatedAt,
+			"updated_by":    itemToSave.UpdatedBy,
+			"interpreter":   *itemToSave.Interpreter,
+			"is_sudo":       int64(0),
+			"cwd":           *itemToSave.Cwd,
+			"script":        itemToSave.Script,
+			"tags":          `["tag1","tag2"]`,
+			"timeout_sec":   int64(timeoutSec),
+			"output_parser": nil,
+			"visibility":    "global",
+			"shared_groups": "[]",
 		},
 	}
 	q := "SELECT * FROM `scripts` where id = 1"
@@ -313,18 +321,21 @@ func TestDelete(t *testing.T) {
 
 	expectedRows := []map[string]interface{}{
 		{
-			"id":          "1",
-			"name":        demoData[0].Name,
-			"created_at":  *demoData[0].CreatedAt,
-			"created_by":  demoData[0].CreatedBy,
-			"updated_at":  *demoData[0].UpdatedAt,
-			"updated_by":  demoData[0].UpdatedBy,
-			"interpreter": *demoData[0].Interpreter,
-			"is_sudo":     int64(0),
-			"cwd":         *demoData[0].Cwd,
-			"script":      demoData[0].Script,
-			"tags":        `["tag1","tag2"]`,
-			"timeout_sec": int64(timeoutSec),
+			"id":            "1",
+			"name":          demoData[0].Name,
+			"created_at":    *demoData[0].CreatedAt,
+			"created_by":    demoData[0].CreatedBy,
+			"updated_at":    *demoData[0].UpdatedAt,
+			"updated_by":    demoData[0].UpdatedBy,
+			"interpreter":   *demoData[0].Interpreter,
+			"is_sudo":       int64(0),
+			"cwd":           *demoData[0].Cwd,
+			"script":        demoData[0].Script,
+			"tags":          `["tag1","tag2"]`,
+			"timeout_sec":   int64(timeoutSec),
+			"output_parser": nil,
+			"visibility":    "global",
+			"shared_groups": "[]",
 		},
 	}
 	q := "SELECT * FROM `scripts`"
@@ -0,0 +1,125 @@
+// Package secretscan finds credentials hard-coded into scripts and commands, so they don't end up in the library,
+// the job history and the process list of the clients.
+package secretscan
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+)
+
+// Config defines if and how scripts and commands are scanned.
+type Config struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Block rejects scripts and commands with findings instead of only warning the submitter.
+	Block bool `mapstructure:"block"`
+	// Allowlist are regular expressions for matches that are not reported, e.g. example keys used in docs.
+	Allowlist []string `mapstructure:"allowlist"`
+}
+
+type rule struct {
+	name    string
+	pattern *regexp.Regexp
+	// group is the submatch holding the secret, 0 is the whole match
+	group int
+}
+
+var rules = []rule{
+	{name: "AWS access key", pattern: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{name: "private key", pattern: regexp.MustCompile(`-----BEGIN (?:(?:RSA|DSA|EC|OPENSSH|PGP) )?PRIVATE KEY(?: BLOCK)?-----`)},
+	{name: "GitHub token", pattern: regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{name: "Slack token", pattern: regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{name: "Google API key", pattern: regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}`)},
+	{name: "credentials in URL", pattern: regexp.MustCompile(`[a-zA-Z][a-zA-Z0-9+.-]*://[^/\s:@]+:([^/\s:@]+)@`), group: 1},
+	{
+		name: "password assignment",
+		// values referencing variables or placeholders are not hard-coded
+		pattern: regexp.MustCompile(`(?i)\b[a-z0-9_]*(?:password|passwd|secret|api_?key|access_?key|token)\s*[:=]\s*["']?([^\s"'$%{][^\s"']{5,})`),
+		group:   1,
+	},
+}
+
+// Finding is a possible secret.
+type Finding struct {
+	Rule string
+	Line int
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("possible %s in line %d", f.Rule, f.Line)
+}
+
+type Scanner struct {
+	block     bool
+	allowlist []*regexp.Regexp
+}
+
+// New returns nil if scanning is disabled, all methods of a nil Scanner are no-ops.
+func New(config Config) (*Scanner, error) {
+	if !config.Enabled {
+		return nil, nil
+	}
+	s := &Scanner{
+		block: config.Block,
+	}
+	for _, pattern := range config.Allowlist {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowlist pattern %q: %v", pattern, err)
+		}
+		s.allowlist = append(s.allowlist, re)
+	}
+	return s, nil
+}
+
+// Scan returns the possible secrets in the text, lines are counted from 1.
+func (s *Scanner) Scan(text string) []Finding {
+	if s == nil {
+		return nil
+	}
+	var findings []Finding
+	for i, line := range strings.Split(text, "\n") {
+		for _, r := range rules {
+			for _, match := range r.pattern.FindAllStringSubmatch(line, -1) {
+				if s.isAllowed(match[0]) || s.isAllowed(match[r.group]) {
+					continue
+				}
+				findings = append(findings, Finding{Rule: r.name, Line: i + 1})
+				break
+			}
+		}
+	}
+	return findings
+}
+
+func (s *Scanner) isAllowed(match string) bool {
+	for _, re := range s.allowlist {
+		if re.MatchString(match) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check scans the text and returns the findings as warnings for the submitter. If blocking is configured,
+// findings are returned as a bad request error instead.
+func (s *Scanner) Check(text string) ([]string, error) {
+	findings := s.Scan(text)
+	if len(findings) == 0 {
+		return nil, nil
+	}
+	warnings := make([]string, 0, len(findings))
+	for _, f := range findings {
+		warnings = append(warnings, f.String())
+	}
+	if s.block {
+		return nil, errors2.APIError{
+			Message:    fmt.Sprintf("hard-coded secrets are not allowed: %s", strings.Join(warnings, ", ")),
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+	return warnings, nil
+}
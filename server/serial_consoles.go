@@ -0,0 +1,188 @@
+package chserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"golang.org/x/crypto/ssh"
+
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/serialconsoles"
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/random"
+)
+
+const (
+	serialConsoleOpenTimeout = 30 * time.Second
+	serialConsoleBufferSize  = 4096
+)
+
+type pendingSerialConsole struct {
+	clientID string
+	done     chan ssh.Channel
+}
+
+// serialConsoleWaiters holds the serial ports requested from clients until their console channel arrives.
+type serialConsoleWaiters struct {
+	m  map[string]*pendingSerialConsole
+	mu sync.Mutex
+}
+
+func newSerialConsoleWaiters() *serialConsoleWaiters {
+	return &serialConsoleWaiters{
+		m: make(map[string]*pendingSerialConsole),
+	}
+}
+
+func (w *serialConsoleWaiters) add(clientID string) (string, chan ssh.Channel) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	id := random.Hex(16)
+	done := make(chan ssh.Channel, 1)
+	w.m[id] = &pendingSerialConsole{clientID: clientID, done: done}
+	return id, done
+}
+
+// take removes the pending console, it returns nil if the id is unknown or belongs to another client.
+func (w *serialConsoleWaiters) take(id, clientID string) *pendingSerialConsole {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	p := w.m[id]
+	if p == nil || p.clientID != clientID {
+		return nil
+	}
+	delete(w.m, id)
+	return p
+}
+
+func (w *serialConsoleWaiters) del(id string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.m, id)
+}
+
+// openSerialConsole lets the client open the port of the device and returns the channel relaying it.
+func (al *APIListener) openSerialConsole(ctx context.Context, client *clientdata.Client, device *serialconsoles.Device) (ssh.Channel, error) {
+	id, done := al.serialConsoleOpens.add(client.GetID())
+	defer al.serialConsoleOpens.del(id)
+
+	err := comm.SendRequestAndGetResponse(client.GetConnection(), comm.RequestTypeOpenSerialConsole, comm.OpenSerialConsoleRequest{
+		ID:         id,
+		Port:       device.Port,
+		SerialLine: device.Line(),
+	}, nil, al.Log())
+	if err != nil {
+		if strings.Contains(err.Error(), "unknown request") {
+			err = errors.New("client does not support serial consoles")
+		}
+		return nil, errors2.APIError{
+			HTTPStatus: http.StatusConflict,
+			Err:        err,
+		}
+	}
+
+	select {
+	case ch := <-done:
+		return ch, nil
+	case <-time.After(serialConsoleOpenTimeout):
+		return nil, errors2.APIError{
+			HTTPStatus: http.StatusGatewayTimeout,
+			Message:    "timeout waiting for the client to open the serial port",
+		}
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// handleSerialConsoleChannel receives the channel of a serial port opened on request.
+func (cl *ClientListener) handleSerialConsoleChannel(clientLog *logger.Logger, clientID string, ch ssh.NewChannel) {
+	id := string(ch.ExtraData())
+	p := cl.server.serialConsoleOpens.take(id, clientID)
+	if p == nil {
+		clientLog.Infof("Rejecting unrequested serial console %q", id)
+		cl.rejectChannel(clientLog, ch, ssh.Prohibited, "serial console not requested")
+		return
+	}
+
+	stream, reqs, err := ch.Accept()
+	if err != nil {
+		clientLog.Debugf("Failed to accept serial console stream: %s", err)
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	p.done <- stream
+}
+
+// serialConsoleRelay copies the bytes between the browser and the serial port, it records the output of the device.
+type serialConsoleRelay struct {
+	log       *logger.Logger
+	ws        *websocket.Conn
+	stream    ssh.Channel
+	recording *serialconsoles.Recording
+	sent      int64
+	received  int64
+}
+
+// run returns when the browser or the client closed the session.
+func (r *serialConsoleRelay) run() {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.relayOutput()
+	}()
+	r.relayInput()
+	r.stream.Close()
+	<-done
+}
+
+func (r *serialConsoleRelay) relayOutput() {
+	defer func() {
+		_ = r.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "serial console closed"), time.Now().Add(time.Second))
+		r.ws.Close()
+	}()
+	buf := make([]byte, serialConsoleBufferSize)
+	for {
+		n, err := r.stream.Read(buf)
+		if n > 0 {
+			r.received += int64(n)
+			if recErr := r.recording.Output(time.Now(), buf[:n]); recErr != nil {
+				r.log.Errorf("Failed to record serial console output: %v", recErr)
+			}
+			if err := r.ws.WriteMessage(websocket.BinaryMessage, buf[:n]); err != nil {
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (r *serialConsoleRelay) relayInput() {
+	for {
+		_, data, err := r.ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		if _, err := r.stream.Write(data); err != nil {
+			return
+		}
+		r.sent += int64(len(data))
+	}
+}
+
+func serialRecordingTitle(device *serialconsoles.Device, username string) string {
+	name := device.Name
+	if name == "" {
+		name = device.Port
+	}
+	return fmt.Sprintf("%s on %s by %s", name, device.ClientID, username)
+}
@@ -0,0 +1,44 @@
+package serialconsoles
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/IOTech17/neo-rport/share/logger"
+)
+
+// CleanupInterval is how often the sessions older than the retention are deleted.
+const CleanupInterval = time.Hour
+
+type CleanupTask struct {
+	log          *logger.Logger
+	provider     *SqliteProvider
+	recordingDir string
+	retention    time.Duration
+}
+
+// NewCleanupTask returns a task to delete the sessions ended longer than the retention ago with their recordings.
+func NewCleanupTask(log *logger.Logger, provider *SqliteProvider, recordingDir string, retention time.Duration) *CleanupTask {
+	return &CleanupTask{
+		log:          log,
+		provider:     provider,
+		recordingDir: recordingDir,
+		retention:    retention,
+	}
+}
+
+func (t *CleanupTask) Run(ctx context.Context) error {
+	ids, err := t.provider.DeleteSessionsEndedBefore(ctx, time.Now().Add(-t.retention))
+	if err != nil {
+		return fmt.Errorf("failed to cleanup serial console sessions: %v", err)
+	}
+	for _, id := range ids {
+		if err := os.Remove(RecordingPath(t.recordingDir, id)); err != nil && !os.IsNotExist(err) {
+			t.log.Errorf("Failed to delete recording of serial console session %s: %v", id, err)
+		}
+	}
+	t.log.Debugf("serialconsoles.CleanupTask: %d serial console sessions deleted", len(ids))
+	return nil
+}
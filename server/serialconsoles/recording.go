@@ -0,0 +1,120 @@
+package serialconsoles
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// RecordingContentType is the content type of the asciicast v2 recordings, they can be replayed by asciinema players.
+const RecordingContentType = "application/x-asciicast"
+
+// RecordingPath returns the file of the recording of the session in the dir.
+func RecordingPath(dir, sessionID string) string {
+	return filepath.Join(dir, sessionID+".cast")
+}
+
+// Recording writes the output of the device in asciicast v2 format. The input of the user is not recorded, passwords
+// typed on the console would end up in the recording otherwise.
+type Recording struct {
+	f         *os.File
+	w         *bufio.Writer
+	startedAt time.Time
+	// pending holds the start of a multi-byte character split across reads
+	pending []byte
+	mu      sync.Mutex
+}
+
+type recordingHeader struct {
+	Version   int    `json:"version"`
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Timestamp int64  `json:"timestamp"`
+	Title     string `json:"title,omitempty"`
+}
+
+func NewRecording(path string, startedAt time.Time, title string) (*Recording, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	r := &Recording{
+		f:         f,
+		w:         bufio.NewWriter(f),
+		startedAt: startedAt,
+	}
+	header, err := json.Marshal(recordingHeader{Version: 2, Width: 80, Height: 24, Timestamp: startedAt.Unix(), Title: title})
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, err := r.w.Write(append(header, '\n')); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// Output records the data sent by the device at the given time.
+func (r *Recording) Output(at time.Time, data []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data = append(r.pending, data...)
+	complete := completeUTF8(data)
+	r.pending = append([]byte(nil), data[complete:]...)
+	if complete == 0 {
+		return nil
+	}
+	return r.writeEvent(at, data[:complete])
+}
+
+func (r *Recording) writeEvent(at time.Time, data []byte) error {
+	event, err := json.Marshal([]interface{}{at.Sub(r.startedAt).Seconds(), "o", string(data)})
+	if err != nil {
+		return err
+	}
+	_, err = r.w.Write(append(event, '\n'))
+	return err
+}
+
+// Close writes the rest of the output and closes the file.
+func (r *Recording) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var err error
+	if len(r.pending) > 0 {
+		err = r.writeEvent(time.Now(), r.pending)
+		r.pending = nil
+	}
+	if flushErr := r.w.Flush(); err == nil {
+		err = flushErr
+	}
+	if closeErr := r.f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write recording %s: %v", r.f.Name(), err)
+	}
+	return nil
+}
+
+// completeUTF8 returns the length of the data without a multi-byte character cut at its end.
+func completeUTF8(data []byte) int {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if !utf8.RuneStart(data[i]) {
+			continue
+		}
+		if utf8.FullRune(data[i:]) {
+			return len(data)
+		}
+		return i
+	}
+	return len(data)
+}
@@ -0,0 +1,54 @@
+package serialconsoles
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecording(t *testing.T) {
+	dir := t.TempDir()
+	t1 := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	p := RecordingPath(dir, "session-1")
+	assert.Equal(t, filepath.Join(dir, "session-1.cast"), p)
+
+	r, err := NewRecording(p, t1, "ttyUSB0 on client-1 by admin")
+	require.NoError(t, err)
+	require.NoError(t, r.Output(t1.Add(500*time.Millisecond), []byte("login: ")))
+	// "ü" split across two reads
+	require.NoError(t, r.Output(t1.Add(time.Second), []byte("gr\xc3")))
+	require.NoError(t, r.Output(t1.Add(2*time.Second), []byte("\xbcn\r\n")))
+	require.NoError(t, r.Close())
+
+	_, err = NewRecording(p, t1, "")
+	assert.Error(t, err, "existing recordings must not be overwritten")
+
+	b, err := os.ReadFile(p)
+	require.NoError(t, err)
+	assert.Equal(t, `{"version":2,"width":80,"height":24,"timestamp":1792065600,"title":"ttyUSB0 on client-1 by admin"}
+[0.5,"o","login: "]
+[1,"o","gr"]
+[2,"o","ün\r\n"]
+`, string(b))
+}
+
+func TestCompleteUTF8(t *testing.T) {
+	for _, tc := range []struct {
+		data []byte
+		want int
+	}{
+		{[]byte(""), 0},
+		{[]byte("abc"), 3},
+		{[]byte("a\xc3\xbc"), 3},
+		{[]byte("a\xc3"), 1},
+		{[]byte("a\xe2\x82"), 1},
+		{[]byte("a\xe2\x82\xac"), 4},
+		{[]byte("\xff\xfe"), 2},
+	} {
+		assert.Equal(t, tc.want, completeUTF8(tc.data), "%q", tc.data)
+	}
+}
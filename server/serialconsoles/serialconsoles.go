@@ -0,0 +1,64 @@
+package serialconsoles
+
+import (
+	"time"
+
+	"github.com/IOTech17/neo-rport/share/comm"
+	"github.com/IOTech17/neo-rport/share/query"
+)
+
+// Device is a serial port of a client with the line settings console sessions open it with.
+type Device struct {
+	ID       string `db:"id" json:"id"`
+	ClientID string `db:"client_id" json:"client_id"`
+	Name     string `db:"name" json:"name"`
+	// Port is the device on the client, e.g. /dev/ttyUSB0 or COM3
+	Port      string    `db:"port" json:"port"`
+	BaudRate  int       `db:"baud_rate" json:"baud_rate"`
+	DataBits  int       `db:"data_bits" json:"data_bits"`
+	Parity    string    `db:"parity" json:"parity"`
+	StopBits  int       `db:"stop_bits" json:"stop_bits"`
+	CreatedBy string    `db:"created_by" json:"created_by"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+func (d *Device) Line() comm.SerialLine {
+	return comm.SerialLine{
+		BaudRate: d.BaudRate,
+		DataBits: d.DataBits,
+		Parity:   d.Parity,
+		StopBits: d.StopBits,
+	}
+}
+
+// Session is a console session opened on a device. The output of the device is recorded.
+type Session struct {
+	ID        string     `db:"id" json:"id"`
+	DeviceID  string     `db:"device_id" json:"device_id"`
+	ClientID  string     `db:"client_id" json:"client_id"`
+	Port      string     `db:"port" json:"port"`
+	Username  string     `db:"username" json:"username"`
+	StartedAt time.Time  `db:"started_at" json:"started_at"`
+	EndedAt   *time.Time `db:"ended_at" json:"ended_at"`
+	// BytesSent were typed by the user, BytesReceived were sent by the device
+	BytesSent     int64 `db:"bytes_sent" json:"bytes_sent"`
+	BytesReceived int64 `db:"bytes_received" json:"bytes_received"`
+}
+
+var (
+	SupportedSessionFilters = map[string]bool{
+		"device_id": true,
+		"username":  true,
+		"port":      true,
+	}
+	SupportedSessionSorts = map[string]bool{
+		"started_at": true,
+		"ended_at":   true,
+		"username":   true,
+	}
+	SessionsPaginationConfig = &query.PaginationConfig{
+		DefaultLimit: 50,
+		MaxLimit:     500,
+	}
+	DefaultSessionSort = query.SortOption{Column: "started_at", IsASC: false}
+)
@@ -0,0 +1,182 @@
+package serialconsoles
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/IOTech17/neo-rport/share/query"
+)
+
+type SqliteProvider struct {
+	db        *sqlx.DB
+	converter *query.SQLConverter
+}
+
+func NewSqliteProvider(db *sqlx.DB) *SqliteProvider {
+	return &SqliteProvider{
+		db:        db,
+		converter: query.NewSQLConverter(db.DriverName()),
+	}
+}
+
+// CreateDevice returns false if the client has a device with the same port.
+func (p *SqliteProvider) CreateDevice(ctx context.Context, d *Device) (bool, error) {
+	res, err := p.db.NamedExecContext(
+		ctx,
+		`INSERT INTO devices (id, client_id, name, port, baud_rate, data_bits, parity, stop_bits, created_by, created_at)
+			VALUES (:id, :client_id, :name, :port, :baud_rate, :data_bits, :parity, :stop_bits, :created_by, :created_at)
+			ON CONFLICT (client_id, port) DO NOTHING`,
+		d,
+	)
+	return affected(res, err)
+}
+
+// UpdateDevice saves the name and the line settings, it returns false if the device is not found.
+func (p *SqliteProvider) UpdateDevice(ctx context.Context, d *Device) (bool, error) {
+	res, err := p.db.NamedExecContext(
+		ctx,
+		`UPDATE devices SET name = :name, baud_rate = :baud_rate, data_bits = :data_bits, parity = :parity, stop_bits = :stop_bits
+			WHERE id = :id AND client_id = :client_id`,
+		d,
+	)
+	return affected(res, err)
+}
+
+// GetDevice returns nil if the client has no device with the id.
+func (p *SqliteProvider) GetDevice(ctx context.Context, clientID, id string) (*Device, error) {
+	d := &Device{}
+	err := p.db.GetContext(ctx, d, "SELECT * FROM devices WHERE id = ? AND client_id = ?", id, clientID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// ListDevices returns the devices of the client ordered by port.
+func (p *SqliteProvider) ListDevices(ctx context.Context, clientID string) ([]*Device, error) {
+	values := []*Device{}
+	err := p.db.SelectContext(ctx, &values, "SELECT * FROM devices WHERE client_id = ? ORDER BY port", clientID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get serial devices from DB: %w", err)
+	}
+	return values, nil
+}
+
+// DeleteDevice returns false if the device is not found. The sessions of the device are kept.
+func (p *SqliteProvider) DeleteDevice(ctx context.Context, clientID, id string) (bool, error) {
+	res, err := p.db.ExecContext(ctx, "DELETE FROM devices WHERE id = ? AND client_id = ?", id, clientID)
+	return affected(res, err)
+}
+
+func (p *SqliteProvider) InsertSession(ctx context.Context, s *Session) error {
+	_, err := p.db.NamedExecContext(
+		ctx,
+		`INSERT INTO sessions (id, device_id, client_id, port, username, started_at)
+			VALUES (:id, :device_id, :client_id, :port, :username, :started_at)`,
+		s,
+	)
+	if err != nil {
+		return fmt.Errorf("unable to save serial console session: %w", err)
+	}
+	return nil
+}
+
+// EndSession sets the end and the bytes transferred of the session.
+func (p *SqliteProvider) EndSession(ctx context.Context, id string, endedAt time.Time, sent, received int64) error {
+	_, err := p.db.ExecContext(
+		ctx,
+		"UPDATE sessions SET ended_at = ?, bytes_sent = ?, bytes_received = ? WHERE id = ?",
+		endedAt, sent, received, id,
+	)
+	return err
+}
+
+// EndOpenSessions ends the sessions interrupted by a restart of the server.
+func (p *SqliteProvider) EndOpenSessions(ctx context.Context, endedAt time.Time) (int64, error) {
+	res, err := p.db.ExecContext(ctx, "UPDATE sessions SET ended_at = ? WHERE ended_at IS NULL", endedAt)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// GetSession returns nil if the client has no session with the id.
+func (p *SqliteProvider) GetSession(ctx context.Context, clientID, id string) (*Session, error) {
+	s := &Session{}
+	err := p.db.GetContext(ctx, s, "SELECT * FROM sessions WHERE id = ? AND client_id = ?", id, clientID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (p *SqliteProvider) ListSessions(ctx context.Context, options *query.ListOptions) ([]*Session, error) {
+	values := []*Session{}
+	q, params := p.converter.ConvertListOptionsToQuery(options, "SELECT * FROM sessions")
+	err := p.db.SelectContext(ctx, &values, q, params...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get serial console sessions from DB: %w", err)
+	}
+	return values, nil
+}
+
+func (p *SqliteProvider) CountSessions(ctx context.Context, options *query.ListOptions) (int, error) {
+	var result int
+	countOptions := *options
+	countOptions.Pagination = nil
+	countOptions.Sorts = nil
+	q, params := p.converter.ConvertListOptionsToQuery(&countOptions, "SELECT COUNT(*) FROM sessions")
+	err := p.db.GetContext(ctx, &result, q, params...)
+	if err != nil {
+		return 0, err
+	}
+	return result, nil
+}
+
+// DeleteSessionsEndedBefore deletes the sessions ended before the given time and returns their ids.
+func (p *SqliteProvider) DeleteSessionsEndedBefore(ctx context.Context, before time.Time) ([]string, error) {
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	ids := []string{}
+	err = tx.SelectContext(ctx, &ids, "SELECT id FROM sessions WHERE ended_at < ?", before.UTC())
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM sessions WHERE ended_at < ?", before.UTC())
+	if err != nil {
+		return nil, err
+	}
+	return ids, tx.Commit()
+}
+
+func (p *SqliteProvider) Close() error {
+	return p.db.Close()
+}
+
+func affected(res sql.Result, err error) (bool, error) {
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,131 @@
+package serialconsoles
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	serialconsolesmigration "github.com/IOTech17/neo-rport/db/migration/serial_consoles"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	"github.com/IOTech17/neo-rport/share/query"
+)
+
+var DataSourceOptions = sqlite.DataSourceOptions{WALEnabled: false}
+
+func TestSqliteProviderDevices(t *testing.T) {
+	db, err := sqlite.New(":memory:", serialconsolesmigration.AssetNames(), serialconsolesmigration.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+	p := NewSqliteProvider(db)
+	t1 := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	d1 := &Device{
+		ID:        "device-1",
+		ClientID:  "client-1",
+		Name:      "Switch console",
+		Port:      "/dev/ttyUSB0",
+		BaudRate:  9600,
+		DataBits:  8,
+		Parity:    "none",
+		StopBits:  1,
+		CreatedBy: "admin",
+		CreatedAt: t1,
+	}
+	created, err := p.CreateDevice(ctx, d1)
+	require.NoError(t, err)
+	assert.True(t, created)
+	created, err = p.CreateDevice(ctx, &Device{ID: "device-2", ClientID: "client-1", Port: "/dev/ttyUSB0", CreatedAt: t1})
+	require.NoError(t, err)
+	assert.False(t, created)
+	created, err = p.CreateDevice(ctx, &Device{ID: "device-3", ClientID: "client-2", Port: "/dev/ttyUSB0", CreatedAt: t1})
+	require.NoError(t, err)
+	assert.True(t, created)
+
+	got, err := p.GetDevice(ctx, "client-1", "device-1")
+	require.NoError(t, err)
+	assert.Equal(t, d1, got)
+	got, err = p.GetDevice(ctx, "client-2", "device-1")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	d1.BaudRate = 115200
+	d1.Port = "/dev/ttyUSB1"
+	updated, err := p.UpdateDevice(ctx, d1)
+	require.NoError(t, err)
+	assert.True(t, updated)
+	got, err = p.GetDevice(ctx, "client-1", "device-1")
+	require.NoError(t, err)
+	assert.Equal(t, 115200, got.BaudRate)
+	assert.Equal(t, "/dev/ttyUSB0", got.Port)
+
+	list, err := p.ListDevices(ctx, "client-1")
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "device-1", list[0].ID)
+
+	deleted, err := p.DeleteDevice(ctx, "client-2", "device-1")
+	require.NoError(t, err)
+	assert.False(t, deleted)
+	deleted, err = p.DeleteDevice(ctx, "client-1", "device-1")
+	require.NoError(t, err)
+	assert.True(t, deleted)
+	list, err = p.ListDevices(ctx, "client-1")
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
+
+func TestSqliteProviderSessions(t *testing.T) {
+	db, err := sqlite.New(":memory:", serialconsolesmigration.AssetNames(), serialconsolesmigration.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+	p := NewSqliteProvider(db)
+	t1 := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	for i, s := range []*Session{
+		{ID: "session-1", DeviceID: "device-1", ClientID: "client-1", Port: "/dev/ttyUSB0", Username: "admin", StartedAt: t1},
+		{ID: "session-2", DeviceID: "device-1", ClientID: "client-1", Port: "/dev/ttyUSB0", Username: "user1", StartedAt: t1.Add(time.Hour)},
+		{ID: "session-3", DeviceID: "device-2", ClientID: "client-2", Port: "COM3", Username: "admin", StartedAt: t1.Add(2 * time.Hour)},
+	} {
+		require.NoError(t, p.InsertSession(ctx, s), i)
+	}
+
+	require.NoError(t, p.EndSession(ctx, "session-1", t1.Add(time.Minute), 10, 200))
+	got, err := p.GetSession(ctx, "client-1", "session-1")
+	require.NoError(t, err)
+	require.NotNil(t, got.EndedAt)
+	assert.Equal(t, t1.Add(time.Minute), *got.EndedAt)
+	assert.EqualValues(t, 10, got.BytesSent)
+	assert.EqualValues(t, 200, got.BytesReceived)
+	got, err = p.GetSession(ctx, "client-2", "session-1")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	options := &query.ListOptions{
+		Filters: []query.FilterOption{{Column: []string{"client_id"}, Values: []string{"client-1"}}},
+		Sorts:   []query.SortOption{DefaultSessionSort},
+	}
+	list, err := p.ListSessions(ctx, options)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "session-2", list[0].ID)
+	assert.Nil(t, list[0].EndedAt)
+	count, err := p.CountSessions(ctx, options)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	ended, err := p.EndOpenSessions(ctx, t1.Add(3*time.Hour))
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, ended)
+
+	ids, err := p.DeleteSessionsEndedBefore(ctx, t1.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"session-1"}, ids)
+	count, err = p.CountSessions(ctx, &query.ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
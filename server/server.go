@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"path"
 	"runtime"
+	"strings"
 	"sync"
 	"time"
 
@@ -20,29 +22,58 @@ import (
 
 	"github.com/patrickmn/go-cache"
 
+	agentlessmigration "github.com/IOTech17/neo-rport/db/migration/agentless"
+	alertsmigration "github.com/IOTech17/neo-rport/db/migration/alerts"
+	chatmigration "github.com/IOTech17/neo-rport/db/migration/chat"
 	"github.com/IOTech17/neo-rport/db/migration/client_groups"
 	clientsmigration "github.com/IOTech17/neo-rport/db/migration/clients"
+	discoverymigration "github.com/IOTech17/neo-rport/db/migration/discovery"
+	externalclientsmigration "github.com/IOTech17/neo-rport/db/migration/external_clients"
+	industrialmigration "github.com/IOTech17/neo-rport/db/migration/industrial"
 	jobsmigration "github.com/IOTech17/neo-rport/db/migration/jobs"
+	serialconsolesmigration "github.com/IOTech17/neo-rport/db/migration/serial_consoles"
+	tunnelconnsmigration "github.com/IOTech17/neo-rport/db/migration/tunnel_connections"
+	usagemigration "github.com/IOTech17/neo-rport/db/migration/usage"
 	"github.com/IOTech17/neo-rport/db/sqlite"
 	rportplus "github.com/IOTech17/neo-rport/plus"
 	alertingcap "github.com/IOTech17/neo-rport/plus/capabilities/alerting"
+	"github.com/IOTech17/neo-rport/server/accessrequests"
 	"github.com/IOTech17/neo-rport/server/acme"
+	"github.com/IOTech17/neo-rport/server/agentless"
+	"github.com/IOTech17/neo-rport/server/alerts"
 	"github.com/IOTech17/neo-rport/server/api/jobs"
 	"github.com/IOTech17/neo-rport/server/api/jobs/schedule"
 	"github.com/IOTech17/neo-rport/server/api/session"
 	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/breakglass"
 	"github.com/IOTech17/neo-rport/server/caddy"
 	"github.com/IOTech17/neo-rport/server/cgroups"
+	"github.com/IOTech17/neo-rport/server/chaos"
+	"github.com/IOTech17/neo-rport/server/chat"
 	"github.com/IOTech17/neo-rport/server/chconfig"
 	"github.com/IOTech17/neo-rport/server/clients"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/clients/clienttunnel"
+	"github.com/IOTech17/neo-rport/server/clients/meshtunnel"
 	"github.com/IOTech17/neo-rport/server/clientsauth"
+	"github.com/IOTech17/neo-rport/server/discovery"
+	"github.com/IOTech17/neo-rport/server/externalclients"
+	"github.com/IOTech17/neo-rport/server/industrial"
 	"github.com/IOTech17/neo-rport/server/monitoring"
-	"github.com/IOTech17/neo-rport/server/notifications"
 	"github.com/IOTech17/neo-rport/server/ports"
+	"github.com/IOTech17/neo-rport/server/posture"
+	"github.com/IOTech17/neo-rport/server/quotas"
 	"github.com/IOTech17/neo-rport/server/scheduler"
+	"github.com/IOTech17/neo-rport/server/secretscan"
+	"github.com/IOTech17/neo-rport/server/serialconsoles"
+	"github.com/IOTech17/neo-rport/server/tripwire"
+	"github.com/IOTech17/neo-rport/server/tunnelconns"
+	"github.com/IOTech17/neo-rport/server/usage"
 	chshare "github.com/IOTech17/neo-rport/share"
 	"github.com/IOTech17/neo-rport/share/capabilities"
+	"github.com/IOTech17/neo-rport/share/enums"
 	"github.com/IOTech17/neo-rport/share/files"
+	"github.com/IOTech17/neo-rport/share/fips"
 	"github.com/IOTech17/neo-rport/share/logger"
 	"github.com/IOTech17/neo-rport/share/models"
 	"github.com/IOTech17/neo-rport/share/ws"
@@ -77,20 +108,61 @@ type Server struct {
 	uiJobWebSockets     ws.WebSocketCache // used to push job result to UI
 	uploadWebSockets    sync.Map
 	jobsDoneChannel     jobResultChanMap // used for sequential command execution to know when command is finished
+	jobLocks            *jobs.Locks      // execution locks of the clients held by running jobs
+	canaryDecisions     sync.Map         // [multiJobID, chan canaryDecision] of canary runs awaiting confirmation
+	clientUpdates       sync.Map         // [clientID, time.Time] of the last update started by the version policy
 	auditLog            *auditlog.AuditLog
 	capabilities        *models.Capabilities
 	scheduleManager     *schedule.Manager
 	filesAPI            files.FileAPI
 	plusManager         rportplus.Manager
+	configValidator     func(content []byte) (*chconfig.Config, error)
 	caddyServer         *caddy.Server
 	acme                *acme.Acme
 	alertingService     alertingcap.Service
 	monitoringQueue     monitoring.MeasurementSaver
+	meshTunnels         *meshtunnel.Manager
+	screenshots         *screenshotWaiters
+	filePushes          *filePushes
+	fileChanges         *fileChanges
+	consents            *consentWaiters
+	portDistributor     *ports.PortDistributor
+	tripwire            *tripwire.Tripwire
+	chaos               *chaos.Injector
+	breakGlass          *breakglass.Alerter
+	accessNotifier      *accessrequests.Notifier
+	quotas              *quotas.Manager
+	usage               *usage.SqliteProvider
+	chat                *chat.SqliteProvider
+	discovery           *discovery.SqliteProvider
+	externalClients     *externalclients.SqliteProvider
+	agentlessTargets    *agentless.SqliteProvider
+	agentlessRuns       *agentlessWaiters
+	serialConsoles      *serialconsoles.SqliteProvider
+	serialConsoleOpens  *serialConsoleWaiters
+	serialRecordingDir  string
+	industrial          *industrial.SqliteProvider
+	industrialPolls     *industrialWaiters
+	tunnelConns         *tunnelconns.SqliteProvider
+	tunnelConnsRecorder *tunnelconns.Recorder
+	secretScanner       *secretscan.Scanner
+	alertSuppressor     *alerts.Suppressor
+	groupRules          *alerts.GroupRuleProvider
+	clientDependencies  *alerts.DependencyProvider
+	groupEvaluator      *alerts.GroupEvaluator
+	baselines           *alerts.Baselines
+	problemHistory      *alerts.ProblemHistory
+	edgeRules           *alerts.EdgeRuleProvider
+	edgeAlerts          *alerts.EdgeAlerts
+	remediations        *alerts.RemediationProvider
+	webhookReceiver     *alerts.WebhookReceiver
 }
 
 type ServerOpts struct {
 	FilesAPI    files.FileAPI
 	PlusManager rportplus.Manager
+	// ConfigValidator decodes and validates the content of a config file like on start
+	ConfigValidator func(content []byte) (*chconfig.Config, error)
 }
 
 // NewServer creates and returns a new rport server
@@ -105,6 +177,15 @@ func NewServer(ctx context.Context, config *chconfig.Config, opts *ServerOpts) (
 		jobsDoneChannel: jobResultChanMap{
 			m: make(map[string]chan *models.Job),
 		},
+		jobLocks:           jobs.NewLocks(),
+		meshTunnels:        meshtunnel.NewManager(),
+		screenshots:        newScreenshotWaiters(),
+		filePushes:         newFilePushes(),
+		fileChanges:        newFileChanges(),
+		consents:           newConsentWaiters(),
+		agentlessRuns:      newAgentlessWaiters(),
+		serialConsoleOpens: newSerialConsoleWaiters(),
+		industrialPolls:    newIndustrialWaiters(),
 	}
 
 	s.acme = acme.New(s.Logger.Fork("acme"), config.Server.DataDir, config.Server.AcmeHTTPPort)
@@ -114,6 +195,7 @@ func NewServer(ctx context.Context, config *chconfig.Config, opts *ServerOpts) (
 
 	filesAPI := opts.FilesAPI
 	s.plusManager = opts.PlusManager
+	s.configValidator = opts.ConfigValidator
 
 	if rportplus.IsPlusEnabled(config.PlusConfig) {
 		licCap := s.plusManager.GetLicenseCapabilityEx()
@@ -139,6 +221,11 @@ func NewServer(ctx context.Context, config *chconfig.Config, opts *ServerOpts) (
 	if err != nil {
 		return nil, err
 	}
+	if fips.Enabled() {
+		if err := fips.ValidateSSHKey(privateKey.PublicKey()); err != nil {
+			return nil, fmt.Errorf("invalid server key: %v", err)
+		}
+	}
 	fingerprint := chshare.FingerprintKey(privateKey.PublicKey())
 	s.Infof("Fingerprint %s", fingerprint)
 
@@ -223,10 +310,11 @@ func NewServer(ctx context.Context, config *chconfig.Config, opts *ServerOpts) (
 		keepDisconnectedClients = &config.Server.KeepDisconnectedClients
 	}
 
+	s.portDistributor = ports.NewPortDistributor(config.AllowedPorts())
 	s.clientService, err = clients.InitClientService(
 		ctx,
 		&s.config.Server.InternalTunnelProxyConfig,
-		ports.NewPortDistributor(config.AllowedPorts()),
+		s.portDistributor,
 		s.clientDB,
 		keepDisconnectedClients,
 		s.Logger,
@@ -235,6 +323,7 @@ func NewServer(ctx context.Context, config *chconfig.Config, opts *ServerOpts) (
 	if err != nil {
 		return nil, err
 	}
+	s.clientService.GetRepo().SetEphemeralGracePeriod(config.Server.EphemeralClientsGracePeriod)
 
 	if rportplus.IsPlusEnabled(config.PlusConfig) {
 		licCapEx := s.plusManager.GetLicenseCapabilityEx()
@@ -252,6 +341,7 @@ func NewServer(ctx context.Context, config *chconfig.Config, opts *ServerOpts) (
 	if err != nil {
 		return nil, err
 	}
+	s.clientService.SetSessionTransferHook(s.auditSessionTransfer)
 
 	if config.Database.Driver != "" {
 		s.authDB, err = sqlx.Connect(config.Database.Driver, config.Database.Dsn)
@@ -261,11 +351,13 @@ func NewServer(ctx context.Context, config *chconfig.Config, opts *ServerOpts) (
 		s.Infof("DB: successfully connected to %s", config.Database.DsnForLogs())
 	}
 
-	s.clientAuthProvider, err = getClientProvider(config, s.authDB)
+	s.clientAuthProvider, err = NewClientAuthProvider(config, s.authDB)
 	if err != nil {
 		return nil, err
 	}
 
+	s.chaos = chaos.New(config.Server.ChaosTesting)
+
 	s.clientListener, err = NewClientListener(s, privateKey)
 	if err != nil {
 		return nil, err
@@ -278,6 +370,172 @@ func NewServer(ctx context.Context, config *chconfig.Config, opts *ServerOpts) (
 		return nil, err
 	}
 
+	s.tripwire = tripwire.New(
+		config.Tripwire,
+		logger.NewLogger("tripwire", config.Logging.LogOutput, config.Logging.LogLevel),
+		s.apiListener.notificationDigests,
+	)
+
+	s.breakGlass = breakglass.NewAlerter(
+		config.BreakGlass,
+		logger.NewLogger("break-glass", config.Logging.LogOutput, config.Logging.LogLevel),
+		s.apiListener.notificationDigests,
+	)
+
+	s.quotas = quotas.New(config.Quotas)
+
+	s.accessNotifier = accessrequests.NewNotifier(
+		config.AccessRequests,
+		logger.NewLogger("access-requests", config.Logging.LogOutput, config.Logging.LogLevel),
+		s.apiListener.notificationDigests,
+	)
+
+	usageDB, err := sqlite.New(
+		path.Join(config.Server.DataDir, "usage.db"),
+		usagemigration.AssetNames(),
+		usagemigration.Asset,
+		config.Server.GetSQLiteDataSourceOptions(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create usage DB instance: %v", err)
+	}
+	s.usage = usage.NewSqliteProvider(usageDB)
+
+	chatDB, err := sqlite.New(
+		path.Join(config.Server.DataDir, "chat.db"),
+		chatmigration.AssetNames(),
+		chatmigration.Asset,
+		config.Server.GetSQLiteDataSourceOptions(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chat DB instance: %v", err)
+	}
+	s.chat = chat.NewSqliteProvider(chatDB)
+
+	discoveryDB, err := sqlite.New(
+		path.Join(config.Server.DataDir, "discovery.db"),
+		discoverymigration.AssetNames(),
+		discoverymigration.Asset,
+		config.Server.GetSQLiteDataSourceOptions(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create discovery DB instance: %v", err)
+	}
+	s.discovery = discovery.NewSqliteProvider(discoveryDB)
+
+	externalClientsDB, err := sqlite.New(
+		path.Join(config.Server.DataDir, "external_clients.db"),
+		externalclientsmigration.AssetNames(),
+		externalclientsmigration.Asset,
+		config.Server.GetSQLiteDataSourceOptions(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create external clients DB instance: %v", err)
+	}
+	s.externalClients = externalclients.NewSqliteProvider(externalClientsDB)
+
+	agentlessDB, err := sqlite.New(
+		path.Join(config.Server.DataDir, "agentless.db"),
+		agentlessmigration.AssetNames(),
+		agentlessmigration.Asset,
+		config.Server.GetSQLiteDataSourceOptions(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create agentless DB instance: %v", err)
+	}
+	s.agentlessTargets = agentless.NewSqliteProvider(agentlessDB)
+
+	serialConsolesDB, err := sqlite.New(
+		path.Join(config.Server.DataDir, "serial_consoles.db"),
+		serialconsolesmigration.AssetNames(),
+		serialconsolesmigration.Asset,
+		config.Server.GetSQLiteDataSourceOptions(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create serial consoles DB instance: %v", err)
+	}
+	s.serialConsoles = serialconsoles.NewSqliteProvider(serialConsolesDB)
+	s.serialRecordingDir = path.Join(config.Server.DataDir, "serial-recordings")
+	if err := filesAPI.MakeDirAll(s.serialRecordingDir); err != nil {
+		return nil, fmt.Errorf("failed to create serial console recordings dir %q: %v", s.serialRecordingDir, err)
+	}
+
+	industrialDB, err := sqlite.New(
+		path.Join(config.Server.DataDir, "industrial.db"),
+		industrialmigration.AssetNames(),
+		industrialmigration.Asset,
+		config.Server.GetSQLiteDataSourceOptions(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create industrial DB instance: %v", err)
+	}
+	s.industrial = industrial.NewSqliteProvider(industrialDB)
+
+	if config.Server.TunnelConnectionsRetention > 0 {
+		tunnelConnsDB, err := sqlite.New(
+			path.Join(config.Server.DataDir, "tunnel_connections.db"),
+			tunnelconnsmigration.AssetNames(),
+			tunnelconnsmigration.Asset,
+			config.Server.GetSQLiteDataSourceOptions(),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create tunnel connections DB instance: %v", err)
+		}
+		s.tunnelConns = tunnelconns.NewSqliteProvider(tunnelConnsDB)
+		s.tunnelConnsRecorder = tunnelconns.NewRecorder(logger.NewLogger("tunnel-connections", config.Logging.LogOutput, config.Logging.LogLevel), s.tunnelConns)
+		s.clientService.SetConnectionLogHook(func(client *clientdata.Client, tunnelID string, remote models.Remote) clienttunnel.ConnectionLog {
+			return s.tunnelConnsRecorder.ConnectionLog(client.GetID(), tunnelID, remote)
+		})
+	}
+
+	s.secretScanner, err = secretscan.New(config.SecretsScan)
+	if err != nil {
+		return nil, err
+	}
+
+	alertsDB, err := sqlite.New(
+		path.Join(config.Server.DataDir, "alerts.db"),
+		alertsmigration.AssetNames(),
+		alertsmigration.Asset,
+		config.Server.GetSQLiteDataSourceOptions(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create alerts DB instance: %v", err)
+	}
+	s.groupRules = alerts.NewGroupRuleProvider(alertsDB)
+	s.clientDependencies = alerts.NewDependencyProvider(alertsDB)
+	s.problemHistory = alerts.NewProblemHistory(alertsDB)
+	s.remediations = alerts.NewRemediationProvider(alertsDB)
+	s.problemHistory.SetOpenedHook(alerts.NewRemediator(
+		s.remediations,
+		s.problemHistory,
+		s.apiListener.runRemediation,
+		logger.NewLogger("remediations", config.Logging.LogOutput, config.Logging.LogLevel),
+	).ProblemOpened)
+	s.webhookReceiver = alerts.NewWebhookReceiver(s.problemHistory, config.Alerting.WebhookClientLabels, s.matchClient)
+	s.groupEvaluator = alerts.NewGroupEvaluator(
+		s.groupRules,
+		s.groupMembers,
+		s.apiListener.notificationDigests,
+		logger.NewLogger("group-rules", config.Logging.LogOutput, config.Logging.LogLevel),
+	)
+	s.groupEvaluator.SetHistory(s.problemHistory)
+	if config.Monitoring.Enabled {
+		s.groupEvaluator.SetDiskForecasts(s.diskForecast)
+	}
+	if config.Alerting.BaselineWindow > 0 {
+		s.baselines = alerts.NewBaselines(config.Alerting.BaselineWindow, config.Alerting.AnomalySensitivity, config.Alerting.BaselineMinSamples)
+		s.groupEvaluator.SetBaselines(s.baselines)
+	}
+	go s.groupEvaluator.Run(ctx)
+	s.edgeRules = alerts.NewEdgeRuleProvider(alertsDB)
+	s.edgeAlerts = alerts.NewEdgeAlerts(
+		s.edgeRules,
+		s.apiListener.notificationDigests,
+		s.problemHistory,
+		logger.NewLogger("edge-rules", config.Logging.LogOutput, config.Logging.LogLevel),
+	)
+
 	s.capabilities = capabilities.NewServerCapabilities(&config.Monitoring)
 
 	s.scheduleManager, err = schedule.New(ctx, s.Logger, jobsDB, s.apiListener, config.Server.RunRemoteCmdTimeoutSec)
@@ -301,8 +559,16 @@ func NewServer(ctx context.Context, config *chconfig.Config, opts *ServerOpts) (
 	}
 
 	if s.alertingService != nil {
-		dispatcher := notifications.NewDispatcher(s.apiListener.notificationsStorage)
-		s.alertingService.Run(ctx, config.Notifications.NotificationScriptDir, dispatcher, maxAlertingWorkers)
+		s.alertSuppressor = alerts.NewSuppressor(
+			config.Alerting,
+			s.apiListener.notificationDigests,
+			s.alertingService,
+			logger.NewLogger("alerting", config.Logging.LogOutput, config.Logging.LogLevel),
+		)
+		s.alertSuppressor.SetDependencies(alerts.NewDependencies(s.clientDependencies, s.isClientConnected))
+		s.alertSuppressor.SetHistory(s.problemHistory)
+		go s.alertSuppressor.Run(ctx)
+		s.alertingService.Run(ctx, config.Notifications.NotificationScriptDir, s.alertSuppressor, maxAlertingWorkers)
 	}
 	return s, nil
 }
@@ -341,7 +607,8 @@ func (s *Server) StartPlusAlertingService(alertingCap alertingcap.CapabilityEx,
 	return as, nil
 }
 
-func getClientProvider(config *chconfig.Config, db *sqlx.DB) (clientsauth.Provider, error) {
+// NewClientAuthProvider returns the client auth credentials configured by auth_table, auth_file or auth.
+func NewClientAuthProvider(config *chconfig.Config, db *sqlx.DB) (clientsauth.Provider, error) {
 	if config.Server.AuthTable != "" {
 		return clientsauth.NewDatabaseProvider(db, config.Server.AuthTable), nil
 	}
@@ -411,6 +678,9 @@ func (s *Server) Run(ctx context.Context) error {
 		monitoringCleanupTask := monitoring.NewCleanupTask(s.Logger, s.monitoringService, cleaningPeriod)
 		go scheduler.Run(ctx, s.Logger.Fork(fmt.Sprintf("task %T", monitoringCleanupTask)), monitoringCleanupTask, cleanupMeasurementsInterval)
 		s.Infof("Task to cleanup measurements will run with interval %v", cleanupMeasurementsInterval)
+
+		agentlessTask := &agentlessMonitoringTask{log: s.Logger.Fork("agentless monitoring"), al: s.apiListener}
+		go scheduler.Run(ctx, s.Logger.Fork(fmt.Sprintf("task %T", agentlessTask)), agentlessTask, agentlessMonitoringInterval)
 	} else {
 		s.Infof("Measurement disabled")
 	}
@@ -423,6 +693,69 @@ func (s *Server) Run(ctx context.Context) error {
 	go scheduler.Run(ctx, s.Logger.Fork(fmt.Sprintf("task %T", jobsCleanupTask)), jobsCleanupTask, cleanupJobsInterval)
 	s.Infof("Task to cleanup jobs will run with interval %v", cleanupJobsInterval)
 
+	if s.config.Manifests.Enabled() {
+		var userService ManifestUserService
+		if s.apiListener.userService.GetProviderType() != enums.ProviderSourceStatic {
+			userService = s.apiListener.userService
+		}
+		manifestsTask := NewManifestsReconcileTask(
+			s.Logger.Fork("manifests"),
+			s.config.Manifests.Dir,
+			s.config.Manifests.Prune,
+			s.config.Server.DataDir,
+			s.clientGroupProvider,
+			userService,
+			s.scheduleManager,
+			func() { go s.refreshReverseRemotes(context.Background()) },
+		)
+		if err := manifestsTask.Run(ctx); err != nil {
+			s.Errorf("manifests: %v", err)
+		}
+		go scheduler.Run(ctx, s.Logger.Fork(fmt.Sprintf("task %T", manifestsTask)), manifestsTask, s.config.Manifests.ReconcileInterval)
+		s.Infof("Task to reconcile manifests from %s will run with interval %v", s.config.Manifests.Dir, s.config.Manifests.ReconcileInterval)
+	}
+
+	accessRequestsTask := &accessRequestsExpiryTask{log: s.Logger.Fork("access requests"), al: s.apiListener}
+	go scheduler.Run(ctx, s.Logger.Fork(fmt.Sprintf("task %T", accessRequestsTask)), accessRequestsTask, accessrequests.ExpiryInterval)
+
+	usageTask := newUsageMeterTask(s.Logger.Fork("usage"), s)
+	go scheduler.Run(ctx, s.Logger.Fork(fmt.Sprintf("task %T", usageTask)), usageTask, usage.SampleInterval)
+
+	if s.tunnelConns != nil {
+		go s.tunnelConnsRecorder.Run(ctx)
+		tunnelConnsCleanupTask := tunnelconns.NewCleanupTask(s.Logger, s.tunnelConns, s.config.Server.TunnelConnectionsRetention)
+		go scheduler.Run(ctx, s.Logger.Fork(fmt.Sprintf("task %T", tunnelConnsCleanupTask)), tunnelConnsCleanupTask, tunnelconns.CleanupInterval)
+		s.Infof("Period to keep tunnel connections will be %s", s.config.Server.TunnelConnectionsRetention)
+	} else {
+		s.Infof("Tunnel connection log disabled")
+	}
+
+	if interrupted, err := s.serialConsoles.EndOpenSessions(ctx, time.Now().UTC()); err != nil {
+		s.Errorf("Failed to end interrupted serial console sessions: %v", err)
+	} else if interrupted > 0 {
+		s.Infof("Ended %d serial console sessions interrupted by the last shutdown", interrupted)
+	}
+	if s.config.Server.SerialSessionsRetention > 0 {
+		serialCleanupTask := serialconsoles.NewCleanupTask(s.Logger, s.serialConsoles, s.serialRecordingDir, s.config.Server.SerialSessionsRetention)
+		go scheduler.Run(ctx, s.Logger.Fork(fmt.Sprintf("task %T", serialCleanupTask)), serialCleanupTask, serialconsoles.CleanupInterval)
+	}
+
+	industrialTask := &industrialPollingTask{log: s.Logger.Fork("industrial polling"), s: s}
+	go scheduler.Run(ctx, s.Logger.Fork(fmt.Sprintf("task %T", industrialTask)), industrialTask, industrialPollingInterval)
+	if s.config.Server.IndustrialValuesRetention > 0 {
+		industrialCleanupTask := industrial.NewCleanupTask(s.Logger, s.industrial, s.config.Server.IndustrialValuesRetention)
+		go scheduler.Run(ctx, s.Logger.Fork(fmt.Sprintf("task %T", industrialCleanupTask)), industrialCleanupTask, industrial.CleanupInterval)
+	}
+
+	brokerTask := &brokerGrantsExpiryTask{log: s.Logger.Fork("broker grants"), al: s.apiListener}
+	go scheduler.Run(ctx, s.Logger.Fork(fmt.Sprintf("task %T", brokerTask)), brokerTask, brokerGrantsExpiryInterval)
+
+	if s.config.Server.SecurityPostureInterval > 0 {
+		postureTask := posture.NewTask(s.Logger.Fork("security posture"), s.postureInput)
+		go scheduler.Run(ctx, s.Logger.Fork(fmt.Sprintf("task %T", postureTask)), postureTask, s.config.Server.SecurityPostureInterval)
+		s.Infof("Task to evaluate the security posture will run with interval %v", s.config.Server.SecurityPostureInterval)
+	}
+
 	// Only on debug mode, log the number of running go routines
 	if s.config.Logging.LogLevel == logger.LogLevelDebug {
 		go func() {
@@ -496,6 +829,17 @@ func (s *Server) Close() error {
 	wg.Go(s.jobProvider.Close)
 
 	wg.Go(s.clientGroupProvider.Close)
+	wg.Go(s.groupRules.Close)
+	wg.Go(s.usage.Close)
+	wg.Go(s.chat.Close)
+	wg.Go(s.discovery.Close)
+	wg.Go(s.externalClients.Close)
+	wg.Go(s.agentlessTargets.Close)
+	wg.Go(s.serialConsoles.Close)
+	wg.Go(s.industrial.Close)
+	if s.tunnelConns != nil {
+		wg.Go(s.tunnelConns.Close)
+	}
 	wg.Go(s.uiJobWebSockets.CloseConnections)
 
 	if s.auditLog != nil {
@@ -525,6 +869,56 @@ func (s *Server) Close() error {
 	return err
 }
 
+func (s *Server) isClientConnected(clientID string) bool {
+	client, err := s.clientService.GetByID(clientID)
+	return err == nil && client != nil && client.IsConnected()
+}
+
+// matchClient returns the id of the client with the given id, name, hostname or IP address for external alerts.
+func (s *Server) matchClient(value string) string {
+	for _, client := range s.clientService.GetAll() {
+		if client.GetID() == value || client.GetName() == value || strings.EqualFold(client.GetHostname(), value) {
+			return client.GetID()
+		}
+		for _, ip := range client.GetIPv4() {
+			if ip == value {
+				return client.GetID()
+			}
+		}
+	}
+	return ""
+}
+
+// groupMembers returns the clients of a client group for the evaluation of group rules.
+func (s *Server) groupMembers(ctx context.Context, groupID string) ([]alerts.GroupMember, bool, error) {
+	group, err := s.clientGroupProvider.Get(ctx, groupID)
+	if err != nil || group == nil {
+		return nil, false, err
+	}
+	clients, err := s.clientService.GetByGroups([]*cgroups.ClientGroup{group})
+	if err != nil {
+		return nil, true, err
+	}
+	members := make([]alerts.GroupMember, 0, len(clients))
+	for _, client := range clients {
+		// disconnected ephemeral clients are about to be purged, they must not raise group alerts
+		if client.Ephemeral && !client.IsConnected() {
+			continue
+		}
+		members = append(members, alerts.GroupMember{ClientID: client.GetID(), Connected: client.IsConnected()})
+	}
+	return members, true, nil
+}
+
+// diskForecast returns the predicted days until the first mountpoint of a client is full.
+func (s *Server) diskForecast(ctx context.Context, clientID string) (*float64, error) {
+	forecasts, err := s.monitoringService.ForecastDiskUsage(ctx, clientID, monitoring.DefaultForecastWindow)
+	if err != nil {
+		return nil, err
+	}
+	return monitoring.MinDaysUntilFull(forecasts), nil
+}
+
 // jobResultChanMap is thread safe map with [jobID, chan *models.Job] pairs.
 type jobResultChanMap struct {
 	m  map[string]chan *models.Job
@@ -559,3 +953,18 @@ func (m *jobResultChanMap) GetAllKeys() (jobIDs []string) {
 	}
 	return jobIDs
 }
+
+// auditSessionTransfer saves the clipboard and file transfers of remote desktop sessions on behalf of the participant.
+func (s *Server) auditSessionTransfer(r *http.Request, client *clientdata.Client, t *clienttunnel.Tunnel, transfer clienttunnel.SessionTransfer) {
+	action := auditlog.ActionSuccess
+	if transfer.Rejected != "" {
+		action = auditlog.ActionDeny
+	}
+	s.auditLog.Entry(auditlog.ApplicationClientTunnelTransfer, action).
+		WithHTTPRequest(r).
+		WithUsername(transfer.Username).
+		WithClient(client).
+		WithID(t.ID).
+		WithRequest(transfer).
+		Save()
+}
@@ -0,0 +1,64 @@
+package chserver
+
+import (
+	"context"
+
+	"github.com/IOTech17/neo-rport/server/cgroups"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/quotas"
+)
+
+// clientQuotaGroups returns the user groups with a quota that have access to the client.
+func (s *Server) clientQuotaGroups(client *clientdata.Client, clientGroups []*cgroups.ClientGroup) []string {
+	var res []string
+	for _, group := range s.quotas.UserGroups() {
+		userGroups := []string{group}
+		if client.HasAccessViaUserGroups(userGroups) || client.UserGroupHasAccessViaClientGroup(userGroups, clientGroups) {
+			res = append(res, group)
+		}
+	}
+	return res
+}
+
+// checkClientQuota returns an error if the connected client exceeds the number of clients of one of its user groups.
+func (s *Server) checkClientQuota(ctx context.Context, client *clientdata.Client) error {
+	if s.quotas == nil {
+		return nil
+	}
+	clientGroups, err := s.clientGroupProvider.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	limited := s.quotas.Limited(s.clientQuotaGroups(client, clientGroups), quotas.ResourceClients)
+	if len(limited) == 0 {
+		return nil
+	}
+
+	usage := make(map[string]int, len(limited))
+	for _, c := range s.clientService.GetAll() {
+		if c.GetID() == client.GetID() || !c.IsConnected() {
+			continue
+		}
+		for _, group := range s.clientQuotaGroups(c, clientGroups) {
+			usage[group]++
+		}
+	}
+	return s.quotas.Check(quotas.ResourceClients, limited, usage)
+}
+
+// meterDatapoint counts a measurement of the client, it returns an error if the measurement exceeds the daily
+// datapoints of one of the user groups of the client and must be dropped.
+func (s *Server) meterDatapoint(ctx context.Context, clientID string) error {
+	if s.quotas == nil {
+		return nil
+	}
+	client, err := s.clientService.GetByID(clientID)
+	if err != nil || client == nil {
+		return err
+	}
+	clientGroups, err := s.clientGroupProvider.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	return s.quotas.AddDatapoint(s.clientQuotaGroups(client, clientGroups))
+}
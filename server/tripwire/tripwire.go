@@ -0,0 +1,190 @@
+// Package tripwire implements decoy credentials. They are never accepted, any attempt to use them raises a
+// security alert because it means the credentials leaked from wherever they were planted.
+package tripwire
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/IOTech17/neo-rport/server/notifications"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/refs"
+)
+
+type Kind string
+
+const (
+	KindClientAuth Kind = "client_auth"
+	KindAPIUser    Kind = "api_user"
+)
+
+const (
+	RefType refs.IdentifiableType = "tripwire"
+
+	SeverityHigh = "high"
+
+	// AlertInterval is the time alerts for the same credential and source are suppressed after an alert,
+	// so an attacker retrying a decoy doesn't flood the recipients.
+	AlertInterval = 10 * time.Minute
+)
+
+// Config defines the decoy credentials and who is alerted.
+type Config struct {
+	DecoyClientAuthIDs []string `mapstructure:"decoy_client_auth_ids"`
+	DecoyAPIUsers      []string `mapstructure:"decoy_api_users"`
+	AlertEmails        []string `mapstructure:"alert_emails"`
+	AlertScript        string   `mapstructure:"alert_script"`
+}
+
+func (c Config) Enabled() bool {
+	return len(c.DecoyClientAuthIDs) > 0 || len(c.DecoyAPIUsers) > 0
+}
+
+// Event is the usage of a decoy credential.
+type Event struct {
+	Kind       Kind      `json:"kind"`
+	Credential string    `json:"credential"`
+	RemoteIP   string    `json:"remote_ip"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+	Severity   string    `json:"severity"`
+}
+
+type Tripwire struct {
+	logger        *logger.Logger
+	dispatcher    notifications.Dispatcher
+	clientAuthIDs map[string]bool
+	apiUsers      map[string]bool
+	alertEmails   []string
+	alertScript   string
+
+	mu         sync.Mutex
+	lastAlerts map[string]time.Time
+	now        func() time.Time
+}
+
+// New returns nil if no decoys are configured, all methods of a nil Tripwire are no-ops.
+func New(config Config, l *logger.Logger, dispatcher notifications.Dispatcher) *Tripwire {
+	if !config.Enabled() {
+		return nil
+	}
+	return &Tripwire{
+		logger:        l,
+		dispatcher:    dispatcher,
+		clientAuthIDs: toSet(config.DecoyClientAuthIDs),
+		apiUsers:      toSet(config.DecoyAPIUsers),
+		alertEmails:   config.AlertEmails,
+		alertScript:   config.AlertScript,
+		lastAlerts:    make(map[string]time.Time),
+		now:           time.Now,
+	}
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+func (t *Tripwire) IsDecoyClientAuth(id string) bool {
+	return t != nil && t.clientAuthIDs[id]
+}
+
+func (t *Tripwire) IsDecoyAPIUser(username string) bool {
+	return t != nil && t.apiUsers[username]
+}
+
+// Trigger logs the event and sends it to the configured recipients. Repeated events for the same credential and
+// remote ip are only logged within AlertInterval.
+func (t *Tripwire) Trigger(ctx context.Context, kind Kind, credential, remoteIP, userAgent string) {
+	if t == nil {
+		return
+	}
+
+	event := Event{
+		Kind:       kind,
+		Credential: credential,
+		RemoteIP:   remoteIP,
+		UserAgent:  userAgent,
+		Timestamp:  t.now(),
+		Severity:   SeverityHigh,
+	}
+	t.logger.Errorf("SECURITY ALERT: decoy %s %q used from %s (%s)", kind, credential, remoteIP, userAgent)
+
+	if !t.shouldAlert(event) {
+		return
+	}
+
+	refID := refs.NewIdentifiable(RefType, string(kind)+":"+credential)
+	if len(t.alertEmails) > 0 {
+		notification := notifications.NotificationData{
+			Target:      "smtp",
+			Recipients:  t.alertEmails,
+			Subject:     fmt.Sprintf("[rport] SECURITY ALERT: decoy %s %q used", kind, credential),
+			Content:     alertMessage(event),
+			ContentType: notifications.ContentTypeTextPlain,
+			Severity:    event.Severity,
+		}
+		if _, err := t.dispatcher.Dispatch(ctx, refID, notification); err != nil {
+			t.logger.Errorf("Failed to send tripwire alert email: %v", err)
+		}
+	}
+	if t.alertScript != "" {
+		content, err := json.Marshal(event)
+		if err != nil {
+			t.logger.Errorf("Failed to marshal tripwire alert: %v", err)
+			return
+		}
+		notification := notifications.NotificationData{
+			Target:      t.alertScript,
+			Recipients:  t.alertEmails,
+			Subject:     "tripwire",
+			Content:     string(content),
+			ContentType: notifications.ContentTypeTextJSON,
+		}
+		if _, err := t.dispatcher.Dispatch(ctx, refID, notification); err != nil {
+			t.logger.Errorf("Failed to run tripwire alert script: %v", err)
+		}
+	}
+}
+
+func (t *Tripwire) shouldAlert(event Event) bool {
+	key := string(event.Kind) + "\x00" + event.Credential + "\x00" + event.RemoteIP
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if last, ok := t.lastAlerts[key]; ok && event.Timestamp.Sub(last) < AlertInterval {
+		return false
+	}
+	t.lastAlerts[key] = event.Timestamp
+
+	for k, last := range t.lastAlerts {
+		if event.Timestamp.Sub(last) >= AlertInterval {
+			delete(t.lastAlerts, k)
+		}
+	}
+	return true
+}
+
+func alertMessage(event Event) string {
+	what := "client auth id"
+	if event.Kind == KindAPIUser {
+		what = "API user"
+	}
+	return fmt.Sprintf(`The decoy %s %q was used to log in to the rport server.
+The credentials are not valid for any client or user, they have most likely leaked from where they were planted.
+
+Severity: %s
+Time: %s
+Remote IP: %s
+User agent: %s
+
+Further attempts from the same IP address are suppressed for %s but still logged.
+`, what, event.Credential, event.Severity, event.Timestamp.UTC().Format(time.RFC1123), event.RemoteIP, event.UserAgent, AlertInterval)
+}
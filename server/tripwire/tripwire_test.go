@@ -0,0 +1,87 @@
+package tripwire
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/server/notifications"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/refs"
+)
+
+var testLog = logger.NewLogger("tripwire", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+
+type mockDispatcher struct {
+	notifications []notifications.NotificationData
+}
+
+func (d *mockDispatcher) Dispatch(ctx context.Context, refID refs.Identifiable, notification notifications.NotificationData) (refs.Identifiable, error) {
+	d.notifications = append(d.notifications, notification)
+	return refs.GenerateIdentifiable(notifications.NotificationType), nil
+}
+
+func TestNewWithoutDecoys(t *testing.T) {
+	tw := New(Config{AlertEmails: []string{"sec@example.com"}}, testLog, &mockDispatcher{})
+
+	assert.Nil(t, tw)
+	assert.False(t, tw.IsDecoyAPIUser("admin"))
+	assert.False(t, tw.IsDecoyClientAuth("client"))
+	tw.Trigger(context.Background(), KindAPIUser, "admin", "1.2.3.4", "curl")
+}
+
+func TestTrigger(t *testing.T) {
+	dispatcher := &mockDispatcher{}
+	tw := New(Config{
+		DecoyClientAuthIDs: []string{"backup-client"},
+		DecoyAPIUsers:      []string{"svc-backup"},
+		AlertEmails:        []string{"sec@example.com"},
+		AlertScript:        "/usr/local/bin/alert.sh",
+	}, testLog, dispatcher)
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	tw.now = func() time.Time { return now }
+
+	assert.True(t, tw.IsDecoyClientAuth("backup-client"))
+	assert.False(t, tw.IsDecoyClientAuth("svc-backup"))
+	assert.True(t, tw.IsDecoyAPIUser("svc-backup"))
+	assert.False(t, tw.IsDecoyAPIUser("admin"))
+
+	tw.Trigger(context.Background(), KindAPIUser, "svc-backup", "1.2.3.4", "curl/8.0")
+	require.Len(t, dispatcher.notifications, 2)
+
+	mail := dispatcher.notifications[0]
+	assert.Equal(t, "smtp", mail.Target)
+	assert.Equal(t, []string{"sec@example.com"}, mail.Recipients)
+	assert.Equal(t, `[rport] SECURITY ALERT: decoy api_user "svc-backup" used`, mail.Subject)
+	assert.Contains(t, mail.Content, "Remote IP: 1.2.3.4")
+
+	script := dispatcher.notifications[1]
+	assert.Equal(t, "/usr/local/bin/alert.sh", script.Target)
+	assert.Equal(t, notifications.ContentTypeTextJSON, script.ContentType)
+	var event Event
+	require.NoError(t, json.Unmarshal([]byte(script.Content), &event))
+	assert.Equal(t, Event{
+		Kind:       KindAPIUser,
+		Credential: "svc-backup",
+		RemoteIP:   "1.2.3.4",
+		UserAgent:  "curl/8.0",
+		Timestamp:  now,
+		Severity:   SeverityHigh,
+	}, event)
+
+	// repeated attempts from the same ip are suppressed
+	tw.Trigger(context.Background(), KindAPIUser, "svc-backup", "1.2.3.4", "curl/8.0")
+	assert.Len(t, dispatcher.notifications, 2)
+
+	tw.Trigger(context.Background(), KindAPIUser, "svc-backup", "5.6.7.8", "curl/8.0")
+	assert.Len(t, dispatcher.notifications, 4)
+
+	now = now.Add(AlertInterval)
+	tw.Trigger(context.Background(), KindAPIUser, "svc-backup", "1.2.3.4", "curl/8.0")
+	assert.Len(t, dispatcher.notifications, 6)
+}
@@ -0,0 +1,142 @@
+package chserver
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/IOTech17/neo-rport/server/api"
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+	"github.com/IOTech17/neo-rport/server/auditlog"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/clients/clienttunnel"
+	"github.com/IOTech17/neo-rport/server/routes"
+)
+
+type tunnelSessionInvitation struct {
+	SessionID string    `json:"session_id"`
+	Mode      string    `json:"mode"`
+	Token     string    `json:"token"`
+	JoinPath  string    `json:"join_path"`
+	JoinURL   string    `json:"join_url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// rdpConnector returns the remote desktop connector of the tunnel, it sends the error response if not found.
+func (al *APIListener) rdpConnector(w http.ResponseWriter, req *http.Request) (*clientdata.Client, *clienttunnel.Tunnel, *clienttunnel.TunnelProxyConnectorRDP) {
+	vars := mux.Vars(req)
+	clientID := vars[routes.ParamClientID]
+	client, err := al.clientService.GetActiveByID(clientID)
+	if err != nil {
+		al.jsonErrorResponse(w, http.StatusInternalServerError, err)
+		return nil, nil, nil
+	}
+	if client == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, fmt.Sprintf("client with id %s not found", clientID))
+		return nil, nil, nil
+	}
+
+	tunnel := al.clientService.FindTunnel(client, vars["tunnel_id"])
+	if tunnel == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, "tunnel not found")
+		return nil, nil, nil
+	}
+	var rdp *clienttunnel.TunnelProxyConnectorRDP
+	if tunnel.InternalTunnelProxy != nil {
+		rdp = tunnel.InternalTunnelProxy.RDPConnector()
+	}
+	if rdp == nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, "tunnel is not a rdp tunnel with https proxy")
+		return nil, nil, nil
+	}
+	return client, tunnel, rdp
+}
+
+// handleGetTunnelSessions handles GET /clients/{client_id}/tunnels/{tunnel_id}/sessions and returns the running remote
+// desktop sessions with their participants.
+func (al *APIListener) handleGetTunnelSessions(w http.ResponseWriter, req *http.Request) {
+	_, _, rdp := al.rdpConnector(w, req)
+	if rdp == nil {
+		return
+	}
+
+	al.writeJSONResponse(w, http.StatusOK, api.NewSuccessPayload(rdp.Sessions()))
+}
+
+// handlePostTunnelSessionParticipant handles POST /clients/{client_id}/tunnels/{tunnel_id}/sessions/{session_id}/participants,
+// it invites the current user to join the session in view or control mode.
+func (al *APIListener) handlePostTunnelSessionParticipant(w http.ResponseWriter, req *http.Request) {
+	client, tunnel, rdp := al.rdpConnector(w, req)
+	if rdp == nil {
+		return
+	}
+
+	var reqBody struct {
+		Mode string `json:"mode"`
+	}
+	if err := parseRequestBody(req.Body, &reqBody); err != nil {
+		al.jsonError(w, err)
+		return
+	}
+	switch reqBody.Mode {
+	case "":
+		reqBody.Mode = clienttunnel.GuacModeView
+	case clienttunnel.GuacModeView, clienttunnel.GuacModeControl:
+	default:
+		al.jsonError(w, errors2.APIError{
+			HTTPStatus: http.StatusBadRequest,
+			Message:    fmt.Sprintf("invalid mode %q, expected %q or %q", reqBody.Mode, clienttunnel.GuacModeView, clienttunnel.GuacModeControl),
+		})
+		return
+	}
+
+	curUser, err := al.getUserModelForAuth(req.Context())
+	if err != nil {
+		al.jsonError(w, err)
+		return
+	}
+
+	sessionID := mux.Vars(req)["session_id"]
+	token, err := rdp.InviteParticipant(sessionID, curUser.Username, reqBody.Mode)
+	if err != nil {
+		al.jsonErrorResponseWithTitle(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	joinPath := "/join?token=" + url.QueryEscape(token)
+	invitation := tunnelSessionInvitation{
+		SessionID: sessionID,
+		Mode:      reqBody.Mode,
+		Token:     token,
+		JoinPath:  joinPath,
+		JoinURL:   tunnelProxyURL(tunnel, al.config.Server.InternalTunnelProxyConfig.Host) + joinPath,
+		ExpiresAt: time.Now().Add(clienttunnel.GuacJoinTokenLifetime),
+	}
+
+	al.auditLog.Entry(auditlog.ApplicationClientTunnelSession, auditlog.ActionCreate).
+		WithHTTPRequest(req).
+		WithClient(client).
+		WithID(tunnel.ID).
+		WithRequest(map[string]string{
+			"session_id": sessionID,
+			"mode":       reqBody.Mode,
+		}).
+		Save()
+
+	al.writeJSONResponse(w, http.StatusCreated, api.NewSuccessPayload(invitation))
+}
+
+func tunnelProxyURL(t *clienttunnel.Tunnel, tunnelHost string) string {
+	if t.TunnelURL != "" {
+		return t.TunnelURL
+	}
+	host := tunnelHost
+	if host == "" {
+		host = t.LocalHost
+	}
+	return "https://" + net.JoinHostPort(host, t.LocalPort)
+}
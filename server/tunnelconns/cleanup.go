@@ -0,0 +1,36 @@
+package tunnelconns
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/IOTech17/neo-rport/share/logger"
+)
+
+// CleanupInterval is how often the connections older than the retention are deleted.
+const CleanupInterval = time.Hour
+
+type CleanupTask struct {
+	log       *logger.Logger
+	provider  *SqliteProvider
+	retention time.Duration
+}
+
+// NewCleanupTask returns a task to delete the connections ended longer than the retention ago.
+func NewCleanupTask(log *logger.Logger, provider *SqliteProvider, retention time.Duration) *CleanupTask {
+	return &CleanupTask{
+		log:       log,
+		provider:  provider,
+		retention: retention,
+	}
+}
+
+func (t *CleanupTask) Run(ctx context.Context) error {
+	deleted, err := t.provider.DeleteOlderThan(ctx, now().Add(-t.retention))
+	if err != nil {
+		return fmt.Errorf("failed to cleanup tunnel connections: %v", err)
+	}
+	t.log.Debugf("tunnelconns.CleanupTask: %d tunnel connections deleted", deleted)
+	return nil
+}
@@ -0,0 +1,70 @@
+package tunnelconns
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/IOTech17/neo-rport/share/query"
+)
+
+// timeFormat is how the sqlite driver stores the times, filters by time are converted to it to compare them as text.
+const timeFormat = "2006-01-02 15:04:05.999999999-07:00"
+
+var (
+	SupportedFilters = map[string]bool{
+		"client_id":          true,
+		"tunnel_id":          true,
+		"protocol":           true,
+		"source_ip":          true,
+		"started_at[gt]":     true,
+		"started_at[lt]":     true,
+		"started_at[since]":  true,
+		"started_at[until]":  true,
+		"ended_at[gt]":       true,
+		"ended_at[lt]":       true,
+		"ended_at[since]":    true,
+		"ended_at[until]":    true,
+		"bytes_sent[gt]":     true,
+		"bytes_received[gt]": true,
+	}
+	SupportedSorts = map[string]bool{
+		"started_at":     true,
+		"ended_at":       true,
+		"client_id":      true,
+		"tunnel_id":      true,
+		"source_ip":      true,
+		"bytes_sent":     true,
+		"bytes_received": true,
+	}
+	PaginationConfig = &query.PaginationConfig{
+		DefaultLimit: 50,
+		MaxLimit:     500,
+	}
+	DefaultSort = query.SortOption{Column: "started_at", IsASC: false}
+)
+
+// ConvertTimeFilters converts the values of the filters by started_at and ended_at given in RFC3339 or as date to the
+// format of the database.
+func ConvertTimeFilters(options *query.ListOptions) error {
+	for i := range options.Filters {
+		f := &options.Filters[i]
+		if len(f.Column) != 1 || (f.Column[0] != "started_at" && f.Column[0] != "ended_at") {
+			continue
+		}
+		for j, v := range f.Values {
+			t, err := parseTime(v)
+			if err != nil {
+				return fmt.Errorf("invalid value %q of filter %s, use RFC3339 or YYYY-MM-DD", v, f.Column[0])
+			}
+			f.Values[j] = t.UTC().Format(timeFormat)
+		}
+	}
+	return nil
+}
+
+func parseTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", v)
+}
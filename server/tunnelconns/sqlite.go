@@ -0,0 +1,178 @@
+package tunnelconns
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/IOTech17/neo-rport/share/query"
+)
+
+type SqliteProvider struct {
+	db        *sqlx.DB
+	converter *query.SQLConverter
+}
+
+func NewSqliteProvider(db *sqlx.DB) *SqliteProvider {
+	return &SqliteProvider{
+		db:        db,
+		converter: query.NewSQLConverter(db.DriverName()),
+	}
+}
+
+// Insert stores a new connection and sets its id.
+func (p *SqliteProvider) Insert(ctx context.Context, c *Connection) error {
+	res, err := p.db.NamedExecContext(
+		ctx,
+		`INSERT INTO tunnel_connections (client_id, tunnel_id, tunnel_local, tunnel_remote, protocol, source_ip, source_port, started_at)
+			VALUES (:client_id, :tunnel_id, :tunnel_local, :tunnel_remote, :protocol, :source_ip, :source_port, :started_at)`,
+		c,
+	)
+	if err != nil {
+		return fmt.Errorf("unable to save connection to tunnel %s of client %s: %w", c.TunnelID, c.ClientID, err)
+	}
+	c.ID, err = res.LastInsertId()
+	return err
+}
+
+// End sets the end and the bytes transferred of the connection.
+func (p *SqliteProvider) End(ctx context.Context, id int64, endedAt time.Time, sent, received int64) error {
+	_, err := p.db.ExecContext(
+		ctx,
+		"UPDATE tunnel_connections SET ended_at = ?, bytes_sent = ?, bytes_received = ? WHERE id = ?",
+		endedAt, sent, received, id,
+	)
+	return err
+}
+
+// InsertRequest stores an inspected request and counts it for its connection.
+func (p *SqliteProvider) InsertRequest(ctx context.Context, r *Request) error {
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	_, err = tx.NamedExecContext(
+		ctx,
+		`INSERT INTO tunnel_connection_requests (connection_id, time, method, path, proto, status, duration_ms)
+			VALUES (:connection_id, :time, :method, :path, :proto, :status, :duration_ms)`,
+		r,
+	)
+	if err != nil {
+		return fmt.Errorf("unable to save request of tunnel connection %d: %w", r.ConnectionID, err)
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE tunnel_connections SET http_requests = http_requests + 1 WHERE id = ?", r.ConnectionID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Get returns nil if the connection doesn't exist.
+func (p *SqliteProvider) Get(ctx context.Context, id int64) (*Connection, error) {
+	c := &Connection{}
+	err := p.db.GetContext(ctx, c, "SELECT * FROM tunnel_connections WHERE id = ?", id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ListRequests returns the inspected requests of the connection in the order they were made.
+func (p *SqliteProvider) ListRequests(ctx context.Context, connectionID int64, pagination *query.Pagination) ([]*Request, error) {
+	values := []*Request{}
+	q, params := p.converter.AppendOptionsToQuery(
+		&query.ListOptions{Pagination: pagination},
+		"SELECT * FROM tunnel_connection_requests WHERE connection_id = ? ORDER BY id",
+		[]interface{}{connectionID},
+	)
+	err := p.db.SelectContext(ctx, &values, q, params...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get requests of tunnel connection from DB: %w", err)
+	}
+	return values, nil
+}
+
+// EndOpen ends the connections still open as interrupted, the bytes transferred of them are unknown.
+func (p *SqliteProvider) EndOpen(ctx context.Context, endedAt time.Time) (int64, error) {
+	res, err := p.db.ExecContext(
+		ctx,
+		"UPDATE tunnel_connections SET ended_at = ?, interrupted = 1 WHERE ended_at IS NULL",
+		endedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (p *SqliteProvider) List(ctx context.Context, options *query.ListOptions) ([]*Connection, error) {
+	values := []*Connection{}
+
+	q := "SELECT * FROM tunnel_connections"
+	q, params := p.converter.ConvertListOptionsToQuery(options, q)
+
+	err := p.db.SelectContext(ctx, &values, q, params...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get tunnel connections from DB: %w", err)
+	}
+	return values, nil
+}
+
+func (p *SqliteProvider) Count(ctx context.Context, options *query.ListOptions) (int, error) {
+	var result int
+
+	q := "SELECT COUNT(*) FROM tunnel_connections"
+	countOptions := *options
+	countOptions.Pagination = nil
+	countOptions.Sorts = nil
+	q, params := p.converter.ConvertListOptionsToQuery(&countOptions, q)
+
+	err := p.db.GetContext(ctx, &result, q, params...)
+	if err != nil {
+		return 0, err
+	}
+	return result, nil
+}
+
+// DeleteOlderThan deletes the connections ended before the given time and their requests.
+func (p *SqliteProvider) DeleteOlderThan(ctx context.Context, before time.Time) (int64, error) {
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	_, err = tx.ExecContext(
+		ctx,
+		"DELETE FROM tunnel_connection_requests WHERE connection_id IN (SELECT id FROM tunnel_connections WHERE ended_at < ?)",
+		before.UTC(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	res, err := tx.ExecContext(ctx, "DELETE FROM tunnel_connections WHERE ended_at < ?", before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return deleted, tx.Commit()
+}
+
+func (p *SqliteProvider) Close() error {
+	return p.db.Close()
+}
@@ -0,0 +1,163 @@
+package tunnelconns
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/IOTech17/neo-rport/server/clients/clienttunnel"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+// queueSize is the number of connection events waiting to be stored, further events are dropped.
+const queueSize = 1000
+
+var now = time.Now
+
+// Connection is an accepted connection to a tunnel of a client. EndedAt is nil while the connection is open.
+type Connection struct {
+	ID            int64      `json:"id" db:"id"`
+	ClientID      string     `json:"client_id" db:"client_id"`
+	TunnelID      string     `json:"tunnel_id" db:"tunnel_id"`
+	TunnelLocal   string     `json:"tunnel_local" db:"tunnel_local"`
+	TunnelRemote  string     `json:"tunnel_remote" db:"tunnel_remote"`
+	Protocol      string     `json:"protocol" db:"protocol"`
+	SourceIP      string     `json:"source_ip" db:"source_ip"`
+	SourcePort    int        `json:"source_port" db:"source_port"`
+	StartedAt     time.Time  `json:"started_at" db:"started_at"`
+	EndedAt       *time.Time `json:"ended_at" db:"ended_at"`
+	Interrupted   bool       `json:"interrupted" db:"interrupted"`
+	BytesSent     int64      `json:"bytes_sent" db:"bytes_sent"`
+	BytesReceived int64      `json:"bytes_received" db:"bytes_received"`
+	HTTPRequests  int        `json:"http_requests" db:"http_requests"`
+}
+
+// Request is an inspected HTTP request of a connection to a tunnel proxy.
+type Request struct {
+	ID           int64     `json:"id" db:"id"`
+	ConnectionID int64     `json:"connection_id" db:"connection_id"`
+	Time         time.Time `json:"time" db:"time"`
+	Method       string    `json:"method" db:"method"`
+	Path         string    `json:"path" db:"path"`
+	Proto        string    `json:"proto" db:"proto"`
+	Status       int       `json:"status" db:"status"`
+	DurationMs   int64     `json:"duration_ms" db:"duration_ms"`
+}
+
+// Recorder stores the connections to the tunnels in the background, so accepting connections isn't slowed down by
+// the database.
+type Recorder struct {
+	logger   *logger.Logger
+	provider *SqliteProvider
+	events   chan func(ctx context.Context) error
+}
+
+func NewRecorder(logger *logger.Logger, provider *SqliteProvider) *Recorder {
+	return &Recorder{
+		logger:   logger,
+		provider: provider,
+		events:   make(chan func(ctx context.Context) error, queueSize),
+	}
+}
+
+// Run stores the connection events until ctx is done. Connections left open by a previous run are marked as
+// interrupted first.
+func (r *Recorder) Run(ctx context.Context) {
+	interrupted, err := r.provider.EndOpen(ctx, now().UTC())
+	if err != nil {
+		r.logger.Errorf("Failed to end open tunnel connections: %v", err)
+	} else if interrupted > 0 {
+		r.logger.Infof("%d tunnel connection(s) of the previous run marked as interrupted", interrupted)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-r.events:
+			if err := event(ctx); err != nil {
+				r.logger.Errorf("Failed to store tunnel connection: %v", err)
+			}
+		}
+	}
+}
+
+func (r *Recorder) enqueue(event func(ctx context.Context) error) {
+	select {
+	case r.events <- event:
+	default:
+		r.logger.Errorf("Tunnel connection log is full, connection event dropped")
+	}
+}
+
+// ConnectionLog returns the log of the connections to the tunnel of the client.
+func (r *Recorder) ConnectionLog(clientID, tunnelID string, remote models.Remote) clienttunnel.ConnectionLog {
+	local := remote.Local()
+	remoteAddr := remote.Remote()
+	return func(protocol string, source net.Addr) clienttunnel.LoggedConnection {
+		c := &Connection{
+			ClientID:     clientID,
+			TunnelID:     tunnelID,
+			TunnelLocal:  local,
+			TunnelRemote: remoteAddr,
+			Protocol:     protocol,
+			StartedAt:    now().UTC(),
+		}
+		c.SourceIP, c.SourcePort = splitAddr(source)
+		// events are stored one after the other, so the id is set before the requests and the end are stored
+		r.enqueue(func(ctx context.Context) error {
+			return r.provider.Insert(ctx, c)
+		})
+		return &loggedConnection{recorder: r, connection: c}
+	}
+}
+
+type loggedConnection struct {
+	recorder   *Recorder
+	connection *Connection
+}
+
+func (l *loggedConnection) Request(req clienttunnel.InspectedRequest) {
+	l.recorder.enqueue(func(ctx context.Context) error {
+		if l.connection.ID == 0 {
+			// the start was dropped or failed
+			return nil
+		}
+		return l.recorder.provider.InsertRequest(ctx, &Request{
+			ConnectionID: l.connection.ID,
+			Time:         req.Time.UTC(),
+			Method:       req.Method,
+			Path:         req.Path,
+			Proto:        req.Proto,
+			Status:       req.Status,
+			DurationMs:   req.Duration.Milliseconds(),
+		})
+	})
+}
+
+func (l *loggedConnection) Closed(sent, received int64) {
+	endedAt := now().UTC()
+	l.recorder.enqueue(func(ctx context.Context) error {
+		if l.connection.ID == 0 {
+			return nil
+		}
+		return l.recorder.provider.End(ctx, l.connection.ID, endedAt, sent, received)
+	})
+}
+
+func splitAddr(addr net.Addr) (string, int) {
+	switch a := addr.(type) {
+	case *net.TCPAddr:
+		return a.IP.String(), a.Port
+	case *net.UDPAddr:
+		return a.IP.String(), a.Port
+	case nil:
+		return "", 0
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String(), 0
+	}
+	return host, 0
+}
@@ -0,0 +1,162 @@
+package tunnelconns
+
+import (
+	"context"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/db/migration/tunnel_connections"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+	"github.com/IOTech17/neo-rport/server/clients/clienttunnel"
+	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/models"
+	"github.com/IOTech17/neo-rport/share/query"
+)
+
+var testLog = logger.NewLogger("tunnelconns", logger.LogOutput{File: os.Stdout}, logger.LogLevelDebug)
+
+func newTestProvider(t *testing.T) *SqliteProvider {
+	db, err := sqlite.New(":memory:", tunnel_connections.AssetNames(), tunnel_connections.Asset, sqlite.DataSourceOptions{WALEnabled: false})
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return NewSqliteProvider(db)
+}
+
+func TestRecorder(t *testing.T) {
+	p := newTestProvider(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	started := time.Date(2026, 10, 13, 10, 0, 0, 0, time.UTC)
+	now = func() time.Time { return started }
+	defer func() { now = time.Now }()
+
+	r := NewRecorder(testLog, p)
+	log := r.ConnectionLog("client-1", "1", models.Remote{LocalHost: "0.0.0.0", LocalPort: "2222", RemoteHost: "127.0.0.1", RemotePort: "22"})
+	logged := log("tcp", &net.TCPAddr{IP: net.ParseIP("192.0.2.10"), Port: 50123})
+	log("tcp", &net.TCPAddr{IP: net.ParseIP("192.0.2.11"), Port: 50124})
+	logged.Request(clienttunnel.InspectedRequest{
+		Time:     started.Add(time.Second),
+		Method:   "GET",
+		Path:     "/index.html",
+		Proto:    "HTTP/1.1",
+		Status:   200,
+		Duration: 1500 * time.Millisecond,
+	})
+	now = func() time.Time { return started.Add(time.Minute) }
+	logged.Closed(100, 2000)
+
+	go r.Run(ctx)
+	require.Eventually(t, func() bool { return len(r.events) == 0 }, time.Second, time.Millisecond)
+
+	options := &query.ListOptions{Sorts: []query.SortOption{{Column: "id", IsASC: true}}}
+	var connections []*Connection
+	require.Eventually(t, func() bool {
+		var err error
+		connections, err = p.List(ctx, options)
+		require.NoError(t, err)
+		return len(connections) == 2 && connections[0].EndedAt != nil
+	}, time.Second, time.Millisecond)
+
+	ended := started.Add(time.Minute)
+	assert.Equal(t, &Connection{
+		ID:            1,
+		ClientID:      "client-1",
+		TunnelID:      "1",
+		TunnelLocal:   "0.0.0.0:2222",
+		TunnelRemote:  "127.0.0.1:22",
+		Protocol:      "tcp",
+		SourceIP:      "192.0.2.10",
+		SourcePort:    50123,
+		StartedAt:     started,
+		EndedAt:       &ended,
+		BytesSent:     100,
+		BytesReceived: 2000,
+		HTTPRequests:  1,
+	}, connections[0])
+	assert.Nil(t, connections[1].EndedAt)
+
+	requests, err := p.ListRequests(ctx, 1, query.NewPagination(10, 0))
+	require.NoError(t, err)
+	assert.Equal(t, []*Request{{
+		ID:           1,
+		ConnectionID: 1,
+		Time:         started.Add(time.Second),
+		Method:       "GET",
+		Path:         "/index.html",
+		Proto:        "HTTP/1.1",
+		Status:       200,
+		DurationMs:   1500,
+	}}, requests)
+
+	// a restart ends the open connection as interrupted
+	interrupted, err := p.EndOpen(ctx, started.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), interrupted)
+	connections, err = p.List(ctx, options)
+	require.NoError(t, err)
+	assert.True(t, connections[1].Interrupted)
+	require.NotNil(t, connections[1].EndedAt)
+	assert.Equal(t, started.Add(time.Hour), *connections[1].EndedAt)
+}
+
+func TestSqliteProviderList(t *testing.T) {
+	p := newTestProvider(t)
+	ctx := context.Background()
+
+	day := time.Date(2026, 10, 13, 0, 0, 0, 0, time.UTC)
+	for _, c := range []*Connection{
+		{ClientID: "client-1", TunnelID: "1", Protocol: "tcp", SourceIP: "192.0.2.10", StartedAt: day.Add(-time.Hour)},
+		{ClientID: "client-1", TunnelID: "1", Protocol: "tcp", SourceIP: "192.0.2.10", StartedAt: day.Add(9 * time.Hour)},
+		{ClientID: "client-1", TunnelID: "2", Protocol: "udp", SourceIP: "192.0.2.20", StartedAt: day.Add(15 * time.Hour)},
+		{ClientID: "client-2", TunnelID: "1", Protocol: "tcp", SourceIP: "192.0.2.10", StartedAt: day.Add(10 * time.Hour)},
+	} {
+		require.NoError(t, p.Insert(ctx, c))
+		require.NoError(t, p.End(ctx, c.ID, c.StartedAt.Add(time.Minute), 1, 1))
+	}
+
+	options := &query.ListOptions{
+		Filters: []query.FilterOption{
+			{Column: []string{"client_id"}, Values: []string{"client-1"}},
+			{Column: []string{"started_at"}, Operator: query.FilterOperatorTypeSince, Values: []string{"2026-10-13"}},
+			{Column: []string{"started_at"}, Operator: query.FilterOperatorTypeLT, Values: []string{"2026-10-13T12:00:00+02:00"}},
+		},
+		Sorts:      []query.SortOption{DefaultSort},
+		Pagination: query.NewPagination(10, 0),
+	}
+	require.NoError(t, ConvertTimeFilters(options))
+
+	connections, err := p.List(ctx, options)
+	require.NoError(t, err)
+	require.Len(t, connections, 1)
+	assert.Equal(t, int64(2), connections[0].ID)
+	count, err := p.Count(ctx, options)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	err = ConvertTimeFilters(&query.ListOptions{Filters: []query.FilterOption{
+		{Column: []string{"ended_at"}, Operator: query.FilterOperatorTypeGT, Values: []string{"yesterday"}},
+	}})
+	assert.EqualError(t, err, `invalid value "yesterday" of filter ended_at, use RFC3339 or YYYY-MM-DD`)
+
+	require.NoError(t, p.InsertRequest(ctx, &Request{ConnectionID: 1, Time: day, Method: "GET", Path: "/", Proto: "HTTP/1.1", Status: 200}))
+	require.NoError(t, p.InsertRequest(ctx, &Request{ConnectionID: 3, Time: day, Method: "GET", Path: "/", Proto: "HTTP/1.1", Status: 200}))
+
+	deleted, err := p.DeleteOlderThan(ctx, day.Add(10*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), deleted)
+	c, err := p.Get(ctx, 1)
+	require.NoError(t, err)
+	assert.Nil(t, c)
+	requests, err := p.ListRequests(ctx, 1, nil)
+	require.NoError(t, err)
+	assert.Empty(t, requests)
+	requests, err = p.ListRequests(ctx, 3, nil)
+	require.NoError(t, err)
+	assert.Len(t, requests, 1)
+}
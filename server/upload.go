@@ -1,11 +1,14 @@
 package chserver
 
 import (
+	"context"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"mime/multipart"
 	"net/http"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -14,6 +17,9 @@ import (
 	errors3 "github.com/IOTech17/neo-rport/share/errors"
 
 	"github.com/IOTech17/neo-rport/server/api"
+	"github.com/IOTech17/neo-rport/server/api/authorization"
+	"github.com/IOTech17/neo-rport/server/api/users"
+	"github.com/IOTech17/neo-rport/server/artifacts"
 	"github.com/IOTech17/neo-rport/server/auditlog"
 	"github.com/IOTech17/neo-rport/server/clients"
 	"github.com/IOTech17/neo-rport/server/clients/clientdata"
@@ -37,6 +43,11 @@ type UploadRequest struct {
 	ClientTags           *models.JobClientTags
 	clientsInGroupsCount int
 	Clients              []*clientdata.Client
+	// artifact is set if the file is pushed from the artifact repository instead of being uploaded
+	artifact *artifacts.Artifact
+	// TwoPhase stages the file on all clients before activating it, see FileChange
+	TwoPhase   bool
+	MonitorSec int
 	*models.UploadedFile
 }
 
@@ -64,18 +75,26 @@ func (al *APIListener) handleFileUploads(w http.ResponseWriter, req *http.Reques
 		al.jsonErrorResponseWithTitle(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	defer uploadRequest.File.Close()
-
-	wasCreated, err := al.filesAPI.CreateDirIfNotExists(al.config.GetUploadDir(), files.DefaultMode)
-	if err != nil {
-		al.jsonError(w, err)
-		return
-	}
-	if wasCreated {
-		al.Infof("created directory %s", al.config.GetUploadDir())
+	if uploadRequest.HasHooks() {
+		if err := al.checkUploadHooksPermission(req.Context(), curUser); err != nil {
+			al.jsonError(w, err)
+			return
+		}
 	}
+	if uploadRequest.File != nil {
+		defer uploadRequest.File.Close()
 
-	uploadRequest.SourceFilePath = al.genFilePath(uploadRequest.ID)
+		wasCreated, err := al.filesAPI.CreateDirIfNotExists(al.config.GetUploadDir(), files.DefaultMode)
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
+		if wasCreated {
+			al.Infof("created directory %s", al.config.GetUploadDir())
+		}
+
+		uploadRequest.SourceFilePath = al.genFilePath(uploadRequest.ID)
+	}
 
 	err = uploadRequest.Validate()
 	if err != nil {
@@ -97,35 +116,61 @@ func (al *APIListener) handleFileUploads(w http.ResponseWriter, req *http.Reques
 		al.jsonErrorResponseWithDetail(w, http.StatusForbidden, "ACCESS_CONTROL_VIOLATION", "upload forbidden", err.Error())
 		return
 	}
-
-	copiedBytes, err := al.filesAPI.CreateFile(uploadRequest.SourceFilePath, uploadRequest.File)
-	if err != nil {
-		al.jsonError(w, err)
+	if quarantined := quarantinedClientIDs(uploadRequest.Clients); len(quarantined) > 0 {
+		al.jsonErrorResponseWithDetail(w, http.StatusForbidden, "CLIENT_QUARANTINED", "upload forbidden", fmt.Sprintf("client(s) with ID(s) %s are quarantined", strings.Join(quarantined, ", ")))
 		return
 	}
 
-	file, err := al.filesAPI.Open(uploadRequest.SourceFilePath)
-	if err != nil {
-		al.jsonError(w, err)
-		return
+	var change *FileChange
+	if uploadRequest.TwoPhase {
+		change = newFileChange(uploadRequest, curUser.Username)
+		if !al.fileChanges.add(change) {
+			al.jsonErrorResponseWithTitle(w, http.StatusConflict, fmt.Sprintf("file change %s already exists", change.ID))
+			return
+		}
 	}
 
-	md5Checksum, err := files.Md5HashFromReader(file)
-	if err != nil {
-		al.jsonError(w, err)
-		return
-	}
+	var copiedBytes int64
+	if uploadRequest.artifact != nil {
+		copiedBytes = uploadRequest.artifact.SizeBytes
+	} else {
+		copiedBytes, err = al.filesAPI.CreateFile(uploadRequest.SourceFilePath, uploadRequest.File)
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
 
-	uploadRequest.Md5Checksum = md5Checksum
+		file, err := al.filesAPI.Open(uploadRequest.SourceFilePath)
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
 
-	al.Debugf(
-		"stored file %s on server, size %d, Content-Type %s, temp location: %s, md5 checksum: %x",
-		uploadRequest.FileHeader.Filename,
-		uploadRequest.FileHeader.Size,
-		uploadRequest.FileHeader.Header.Get("Content-Type"),
-		uploadRequest.SourceFilePath,
-		md5Checksum,
-	)
+		md5Checksum, err := files.Md5HashFromReader(file)
+		if err != nil {
+			al.jsonError(w, err)
+			return
+		}
+
+		uploadRequest.Md5Checksum = md5Checksum
+
+		if copiedBytes >= deltaMinFileSize {
+			uploadRequest.DeltaSignaturePath, err = al.writeDeltaSignature(uploadRequest.SourceFilePath, copiedBytes)
+			if err != nil {
+				// clients download the whole file
+				al.Errorf("failed to create the delta signature of %s: %v", uploadRequest.SourceFilePath, err)
+			}
+		}
+
+		al.Debugf(
+			"stored file %s on server, size %d, Content-Type %s, temp location: %s, md5 checksum: %x",
+			uploadRequest.FileHeader.Filename,
+			uploadRequest.FileHeader.Size,
+			uploadRequest.FileHeader.Header.Get("Content-Type"),
+			uploadRequest.SourceFilePath,
+			md5Checksum,
+		)
+	}
 
 	uploadRep := &models.UploadResponseShort{
 		ID:        uploadRequest.ID,
@@ -139,7 +184,14 @@ func (al *APIListener) handleFileUploads(w http.ResponseWriter, req *http.Reques
 		WithID(uploadRequest.UploadedFile.ID).
 		SaveForMultipleClients(uploadRequest.Clients)
 
-	go al.sendFileToClients(uploadRequest)
+	if uploadRequest.DeltaSignaturePath != "" {
+		al.filePushes.add(uploadRequest.ID, uploadRequest.SourceFilePath, uploadRequest.Clients)
+	}
+	if uploadRequest.TwoPhase {
+		go al.runFileChange(uploadRequest, change)
+	} else {
+		go al.sendFileToClients(uploadRequest)
+	}
 
 	response := api.NewSuccessPayload(uploadRep)
 
@@ -192,7 +244,7 @@ type UploadOutput struct {
 	*models.UploadResponse
 }
 
-func (al *APIListener) sendFileToClients(uploadRequest *UploadRequest) {
+func (al *APIListener) sendFileToClients(uploadRequest *UploadRequest) []*uploadResult {
 	wg := &sync.WaitGroup{}
 	wg.Add(len(uploadRequest.Clients))
 
@@ -207,16 +259,28 @@ func (al *APIListener) sendFileToClients(uploadRequest *UploadRequest) {
 		close(resChan)
 	}()
 
-	al.consumeUploadResults(resChan, uploadRequest)
+	results := al.consumeUploadResults(resChan, uploadRequest)
 
+	if uploadRequest.artifact != nil {
+		return results
+	}
+	if uploadRequest.DeltaSignaturePath != "" {
+		al.filePushes.del(uploadRequest.ID)
+		if err := al.filesAPI.Remove(uploadRequest.DeltaSignaturePath); err != nil {
+			al.Errorf("failed to delete temp file path %s: %v", uploadRequest.DeltaSignaturePath, err)
+		}
+	}
 	err := al.filesAPI.Remove(uploadRequest.SourceFilePath)
 	if err != nil {
 		al.Errorf("failed to delete temp file path %s: %v", uploadRequest.SourceFilePath, err)
 	}
+	return results
 }
 
-func (al *APIListener) consumeUploadResults(resChan chan *uploadResult, uploadRequest *UploadRequest) {
+func (al *APIListener) consumeUploadResults(resChan chan *uploadResult, uploadRequest *UploadRequest) []*uploadResult {
+	results := make([]*uploadResult, 0, len(uploadRequest.Clients))
 	for res := range resChan {
+		results = append(results, res)
 		clientID := res.client.GetID()
 		output := &UploadOutput{
 			ClientID:       clientID,
@@ -264,11 +328,21 @@ func (al *APIListener) consumeUploadResults(resChan chan *uploadResult, uploadRe
 
 		al.notifyUploadEventListeners(output)
 	}
+	return results
 }
 
 func (al *APIListener) sendFileToClient(wg *sync.WaitGroup, file *models.UploadedFile, cl *clientdata.Client, resChan chan *uploadResult) {
 	defer wg.Done()
 
+	if cl.IsQuarantined() {
+		resChan <- &uploadResult{
+			err:    clientdata.ErrQuarantined,
+			client: cl,
+			resp:   nil,
+		}
+		return
+	}
+
 	fileReceptionConfig := cl.GetFileReceptionConfig()
 	if fileReceptionConfig != nil && !fileReceptionConfig.Enabled {
 		resChan <- &uploadResult{
@@ -278,6 +352,14 @@ func (al *APIListener) sendFileToClient(wg *sync.WaitGroup, file *models.Uploade
 		}
 		return
 	}
+	if err := cl.CheckCapability(models.ClientCapabilityFileTransfer); err != nil {
+		resChan <- &uploadResult{
+			err:    err,
+			client: cl,
+			resp:   nil,
+		}
+		return
+	}
 	resp := &models.UploadResponse{}
 	err := comm.SendRequestAndGetResponse(cl.GetConnection(), comm.RequestTypeUpload, file, resp, al.Log())
 
@@ -300,6 +382,23 @@ func (al *APIListener) notifyUploadEventListeners(msg interface{}) {
 	})
 }
 
+// checkUploadHooksPermission requires the commands permission for file pushes running commands on the clients.
+func (al *APIListener) checkUploadHooksPermission(ctx context.Context, curUser *users.User) error {
+	if al.insecureForTests {
+		return nil
+	}
+	if !authorization.TokenFromContext(ctx).AllowsPermission(users.PermissionCommands) {
+		return errors2.APIError{
+			Message:    fmt.Sprintf("the token is not allowed to use %q", users.PermissionCommands),
+			HTTPStatus: http.StatusForbidden,
+		}
+	}
+	if al.userService.SupportsGroupPermissions() {
+		return al.userService.CheckPermission(curUser, users.PermissionCommands)
+	}
+	return nil
+}
+
 func (al *APIListener) uploadRequestFromRequest(req *http.Request) (ur *UploadRequest, err error) {
 	ur = &UploadRequest{
 		UploadedFile: &models.UploadedFile{},
@@ -342,14 +441,36 @@ func (al *APIListener) uploadRequestFromRequest(req *http.Request) (ur *UploadRe
 		}
 	}
 
-	ur.File, ur.FileHeader, err = req.FormFile("upload")
-	if err != nil {
-		return nil, &errors2.APIError{
-			Err:        err,
-			HTTPStatus: http.StatusBadRequest,
+	if ref := req.MultipartForm.Value["artifact"]; len(ref) > 0 {
+		err = al.setUploadArtifact(req.Context(), ur, ref[0])
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		ur.File, ur.FileHeader, err = req.FormFile("upload")
+		if err != nil {
+			return nil, &errors2.APIError{
+				Err:        err,
+				HTTPStatus: http.StatusBadRequest,
+			}
 		}
 	}
 
+	if twoPhase := req.MultipartForm.Value["two_phase"]; len(twoPhase) > 0 {
+		ur.TwoPhase, err = strconv.ParseBool(twoPhase[0])
+		if err != nil {
+			return nil, &errors2.APIError{
+				Err:        err,
+				HTTPStatus: http.StatusBadRequest,
+			}
+		}
+	}
+	ur.MonitorSec, err = parseFileChangeMonitorSec(req, ur.TwoPhase)
+	if err != nil {
+		return nil, err
+	}
+	ur.Staged = ur.TwoPhase
+
 	if ur.UploadedFile.ID == "" {
 		id, e := random.UUID4()
 		if e != nil {
@@ -362,6 +483,24 @@ func (al *APIListener) uploadRequestFromRequest(req *http.Request) (ur *UploadRe
 	return ur, nil
 }
 
+// setUploadArtifact makes the clients download the file from the artifact repository.
+func (al *APIListener) setUploadArtifact(ctx context.Context, ur *UploadRequest, ref string) error {
+	a, err := al.artifactManager.Resolve(ctx, ref)
+	if err != nil {
+		return err
+	}
+	md5Checksum, err := hex.DecodeString(a.Md5)
+	if err != nil {
+		return err
+	}
+
+	ur.artifact = a
+	ur.SourceFilePath = al.artifactManager.Path(a)
+	ur.Md5Checksum = md5Checksum
+	ur.UploadedFile.Artifact = a.Ref()
+	return nil
+}
+
 func getClientTagsFromReqForm(req *http.Request) (clientTags *models.JobClientTags, err error) {
 	jsonTags := req.MultipartForm.Value["tags"]
 
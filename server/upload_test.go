@@ -125,6 +125,46 @@ func TestHandleFileUploads(t *testing.T) {
 				Md5Checksum:          test.Md5Hash("some content"),
 			},
 		},
+		{
+			name:       "send file with validation command",
+			wantStatus: http.StatusOK,
+			user:       "admin",
+			group:      users.Administrators,
+			wantResp: &models.UploadResponseShort{
+				ID:        "id-123",
+				Filepath:  "/etc/nginx/nginx.conf",
+				SizeBytes: 10,
+			},
+			useFsCallback: true,
+			fileName:      "file.txt",
+			fileContent:   "some content",
+			cl:            clients.New(t).ID("22114341234").Logger(testLog).Build(),
+			formParts: map[string][]string{
+				"client_id": {
+					"22114341234",
+				},
+				"dest": {
+					"/etc/nginx/nginx.conf",
+				},
+				"id": {
+					"id-123",
+				},
+				"validate_command": {
+					"nginx -t",
+				},
+				"post_command": {
+					"systemctl reload nginx",
+				},
+			},
+			wantClientInputFile: &models.UploadedFile{
+				ID:              "id-123",
+				SourceFilePath:  "/data/filepush/id-123_rport_filepush",
+				DestinationPath: "/etc/nginx/nginx.conf",
+				Md5Checksum:     test.Md5Hash("some content"),
+				ValidateCommand: "nginx -t",
+				PostCommand:     "systemctl reload nginx",
+			},
+		},
 		{
 			name:       "send file success targeting tags",
 			wantStatus: http.StatusOK,
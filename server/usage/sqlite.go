@@ -0,0 +1,136 @@
+package usage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrPeriodClosed is returned when usage is added to a closed billing period.
+var ErrPeriodClosed = errors.New("billing period is closed")
+
+type SqliteProvider struct {
+	db *sqlx.DB
+}
+
+func NewSqliteProvider(db *sqlx.DB) *SqliteProvider {
+	return &SqliteProvider{
+		db: db,
+	}
+}
+
+// Add adds the usage of the records to the usage of the clients in the period. Connected seconds, traffic and jobs
+// are summed up, the storage keeps its peak, the names and groups are replaced.
+func (p *SqliteProvider) Add(ctx context.Context, period string, records []*Record) error {
+	tx, err := p.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	closed, err := isClosed(ctx, tx, period)
+	if err != nil {
+		return err
+	}
+	if closed {
+		return ErrPeriodClosed
+	}
+
+	for _, r := range records {
+		r.Period = period
+		_, err := tx.NamedExecContext(
+			ctx,
+			`INSERT INTO usage (period, client_id, client_name, user_groups, client_groups, connected_seconds, tunnel_bytes_sent, tunnel_bytes_received, jobs, storage_bytes)
+				VALUES (:period, :client_id, :client_name, :user_groups, :client_groups, :connected_seconds, :tunnel_bytes_sent, :tunnel_bytes_received, :jobs, :storage_bytes)
+				ON CONFLICT (period, client_id) DO UPDATE SET
+					client_name = excluded.client_name,
+					user_groups = excluded.user_groups,
+					client_groups = excluded.client_groups,
+					connected_seconds = connected_seconds + excluded.connected_seconds,
+					tunnel_bytes_sent = tunnel_bytes_sent + excluded.tunnel_bytes_sent,
+					tunnel_bytes_received = tunnel_bytes_received + excluded.tunnel_bytes_received,
+					jobs = jobs + excluded.jobs,
+					storage_bytes = MAX(storage_bytes, excluded.storage_bytes)`,
+			r,
+		)
+		if err != nil {
+			return fmt.Errorf("unable to save usage of client %s: %w", r.ClientID, err)
+		}
+	}
+	return tx.Commit()
+}
+
+func isClosed(ctx context.Context, tx *sqlx.Tx, period string) (bool, error) {
+	var count int
+	if err := tx.GetContext(ctx, &count, "SELECT COUNT(*) FROM usage_periods WHERE period = ?", period); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// List returns the usage of the clients in the period.
+func (p *SqliteProvider) List(ctx context.Context, period string) ([]*Record, error) {
+	result := []*Record{}
+	err := p.db.SelectContext(ctx, &result, "SELECT * FROM usage WHERE period = ? ORDER BY client_id", period)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get usage from DB: %w", err)
+	}
+	return result, nil
+}
+
+// ListPeriods returns the periods with usage, the latest first.
+func (p *SqliteProvider) ListPeriods(ctx context.Context) ([]*Period, error) {
+	result := []*Period{}
+	err := p.db.SelectContext(
+		ctx,
+		&result,
+		`SELECT p.period, up.closed_at, IFNULL(up.closed_by, '') AS closed_by
+			FROM (SELECT DISTINCT period FROM usage UNION SELECT period FROM usage_periods) p
+			LEFT JOIN usage_periods up ON up.period = p.period
+			ORDER BY p.period DESC`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get billing periods from DB: %w", err)
+	}
+	return result, nil
+}
+
+// GetPeriod returns the period, ClosedAt is nil if it's open.
+func (p *SqliteProvider) GetPeriod(ctx context.Context, period string) (*Period, error) {
+	res := &Period{}
+	err := p.db.GetContext(ctx, res, "SELECT * FROM usage_periods WHERE period = ?", period)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &Period{Period: period}, nil
+		}
+		return nil, fmt.Errorf("unable to get billing period from DB: %w", err)
+	}
+	return res, nil
+}
+
+// ClosePeriod locks the usage of the period. It returns false if the period is already closed.
+func (p *SqliteProvider) ClosePeriod(ctx context.Context, period, by string, at time.Time) (bool, error) {
+	res, err := p.db.ExecContext(
+		ctx,
+		"INSERT INTO usage_periods (period, closed_at, closed_by) VALUES (?, ?, ?) ON CONFLICT (period) DO NOTHING",
+		period, at.UTC(), by,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (p *SqliteProvider) Close() error {
+	return p.db.Close()
+}
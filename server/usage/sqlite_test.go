@@ -0,0 +1,78 @@
+package usage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	usagemigration "github.com/IOTech17/neo-rport/db/migration/usage"
+	"github.com/IOTech17/neo-rport/db/sqlite"
+)
+
+var DataSourceOptions = sqlite.DataSourceOptions{WALEnabled: false}
+
+func TestSqliteProvider(t *testing.T) {
+	db, err := sqlite.New(":memory:", usagemigration.AssetNames(), usagemigration.Asset, DataSourceOptions)
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+	p := NewSqliteProvider(db)
+
+	require.NoError(t, p.Add(ctx, "2026-09", []*Record{
+		{ClientID: "c1", ClientName: "old", UserGroups: []string{"tenant-a"}, ConnectedSeconds: 60, TunnelBytesSent: 10, Jobs: 1, StorageBytes: 500},
+		{ClientID: "c2", ConnectedSeconds: 60},
+	}))
+	require.NoError(t, p.Add(ctx, "2026-09", []*Record{
+		{ClientID: "c1", ClientName: "router", UserGroups: []string{"tenant-b"}, ConnectedSeconds: 30, TunnelBytesSent: 5, TunnelBytesReceived: 7, Jobs: 2, StorageBytes: 200},
+	}))
+	require.NoError(t, p.Add(ctx, "2026-10", []*Record{{ClientID: "c1", ConnectedSeconds: 60}}))
+
+	records, err := p.List(ctx, "2026-09")
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, &Record{
+		Period:              "2026-09",
+		ClientID:            "c1",
+		ClientName:          "router",
+		UserGroups:          []string{"tenant-b"},
+		ConnectedSeconds:    90,
+		TunnelBytesSent:     15,
+		TunnelBytesReceived: 7,
+		Jobs:                3,
+		StorageBytes:        500,
+	}, records[0])
+
+	closedAt := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	closed, err := p.ClosePeriod(ctx, "2026-09", "admin", closedAt)
+	require.NoError(t, err)
+	assert.True(t, closed)
+	closed, err = p.ClosePeriod(ctx, "2026-09", "admin", closedAt)
+	require.NoError(t, err)
+	assert.False(t, closed)
+
+	err = p.Add(ctx, "2026-09", []*Record{{ClientID: "c1", ConnectedSeconds: 60}})
+	assert.ErrorIs(t, err, ErrPeriodClosed)
+	records, err = p.List(ctx, "2026-09")
+	require.NoError(t, err)
+	assert.EqualValues(t, 90, records[0].ConnectedSeconds)
+
+	period, err := p.GetPeriod(ctx, "2026-09")
+	require.NoError(t, err)
+	require.NotNil(t, period.ClosedAt)
+	assert.Equal(t, closedAt, period.ClosedAt.UTC())
+	assert.Equal(t, "admin", period.ClosedBy)
+	period, err = p.GetPeriod(ctx, "2026-10")
+	require.NoError(t, err)
+	assert.Nil(t, period.ClosedAt)
+
+	periods, err := p.ListPeriods(ctx)
+	require.NoError(t, err)
+	require.Len(t, periods, 2)
+	assert.Equal(t, "2026-10", periods[0].Period)
+	assert.Nil(t, periods[0].ClosedAt)
+	assert.Equal(t, "2026-09", periods[1].Period)
+	assert.NotNil(t, periods[1].ClosedAt)
+}
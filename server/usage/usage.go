@@ -0,0 +1,154 @@
+package usage
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/IOTech17/neo-rport/share/types"
+)
+
+const (
+	// SampleInterval is the interval the usage of the clients is sampled.
+	SampleInterval = time.Minute
+	// StorageSampleInterval is the interval the storage used by the clients is sampled, it requires to scan the data.
+	StorageSampleInterval = time.Hour
+
+	periodLayout = "2006-01"
+)
+
+type GroupBy string
+
+const (
+	GroupByClient      GroupBy = "client"
+	GroupByClientGroup GroupBy = "client_group"
+	GroupByUserGroup   GroupBy = "user_group"
+)
+
+// Record is the usage of a client in a billing period. The user groups and client groups are the ones of the last
+// sample, so the usage of a closed period is grouped the same way at any later time.
+type Record struct {
+	Period              string            `json:"period" db:"period"`
+	ClientID            string            `json:"client_id" db:"client_id"`
+	ClientName          string            `json:"client_name" db:"client_name"`
+	UserGroups          types.StringSlice `json:"user_groups" db:"user_groups"`
+	ClientGroups        types.StringSlice `json:"client_groups" db:"client_groups"`
+	ConnectedSeconds    int64             `json:"connected_seconds" db:"connected_seconds"`
+	TunnelBytesSent     int64             `json:"tunnel_bytes_sent" db:"tunnel_bytes_sent"`
+	TunnelBytesReceived int64             `json:"tunnel_bytes_received" db:"tunnel_bytes_received"`
+	Jobs                int64             `json:"jobs" db:"jobs"`
+	// StorageBytes is the peak of the storage used by the job results and the monitoring data of the client.
+	StorageBytes int64 `json:"storage_bytes" db:"storage_bytes"`
+}
+
+// Period is a billing period, it's closed to lock the usage for billing.
+type Period struct {
+	Period   string     `json:"period" db:"period"`
+	ClosedAt *time.Time `json:"closed_at" db:"closed_at"`
+	ClosedBy string     `json:"closed_by" db:"closed_by"`
+}
+
+// Summary is the usage of a client, a client group or a user group in a billing period.
+type Summary struct {
+	Period              string  `json:"period"`
+	Group               string  `json:"group"`
+	Clients             int     `json:"clients"`
+	ClientHours         float64 `json:"client_hours"`
+	TunnelBytesSent     int64   `json:"tunnel_bytes_sent"`
+	TunnelBytesReceived int64   `json:"tunnel_bytes_received"`
+	Jobs                int64   `json:"jobs"`
+	StorageBytes        int64   `json:"storage_bytes"`
+}
+
+// PeriodOf returns the billing period of the given time, the calendar month in UTC.
+func PeriodOf(t time.Time) string {
+	return t.UTC().Format(periodLayout)
+}
+
+// ParsePeriod returns the start and the end of a billing period formatted "YYYY-MM".
+func ParsePeriod(period string) (start, end time.Time, err error) {
+	start, err = time.Parse(periodLayout, period)
+	if err != nil {
+		return start, end, fmt.Errorf("invalid period %q, expected YYYY-MM", period)
+	}
+	return start, start.AddDate(0, 1, 0), nil
+}
+
+// ParseGroupBy returns the grouping, it defaults to clients.
+func ParseGroupBy(v string) (GroupBy, error) {
+	switch GroupBy(v) {
+	case "":
+		return GroupByClient, nil
+	case GroupByClient, GroupByClientGroup, GroupByUserGroup:
+		return GroupBy(v), nil
+	}
+	return "", fmt.Errorf("invalid group_by %q, expected one of: %s, %s, %s", v, GroupByClient, GroupByClientGroup, GroupByUserGroup)
+}
+
+// Aggregate sums up the usage of the clients by the given grouping, sorted by group. A client in several groups is
+// counted in each of them, clients without a group are left out of the client group and user group summaries.
+func Aggregate(period string, records []*Record, groupBy GroupBy) []*Summary {
+	byGroup := make(map[string]*Summary)
+	for _, r := range records {
+		var groups []string
+		switch groupBy {
+		case GroupByClientGroup:
+			groups = r.ClientGroups
+		case GroupByUserGroup:
+			groups = r.UserGroups
+		default:
+			groups = []string{r.ClientID}
+		}
+		for _, group := range groups {
+			s, ok := byGroup[group]
+			if !ok {
+				s = &Summary{Period: period, Group: group}
+				byGroup[group] = s
+			}
+			s.Clients++
+			s.ClientHours += float64(r.ConnectedSeconds) / time.Hour.Seconds()
+			s.TunnelBytesSent += r.TunnelBytesSent
+			s.TunnelBytesReceived += r.TunnelBytesReceived
+			s.Jobs += r.Jobs
+			s.StorageBytes += r.StorageBytes
+		}
+	}
+
+	res := make([]*Summary, 0, len(byGroup))
+	for _, s := range byGroup {
+		res = append(res, s)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Group < res[j].Group
+	})
+	return res
+}
+
+// WriteCSV writes the summaries with a header row, the group column is named after the grouping.
+func WriteCSV(w io.Writer, groupBy GroupBy, summaries []*Summary) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"period", string(groupBy), "clients", "client_hours", "tunnel_bytes_sent", "tunnel_bytes_received", "jobs", "storage_bytes"})
+	if err != nil {
+		return err
+	}
+	for _, s := range summaries {
+		err := cw.Write([]string{
+			s.Period,
+			s.Group,
+			strconv.Itoa(s.Clients),
+			strconv.FormatFloat(s.ClientHours, 'f', 2, 64),
+			strconv.FormatInt(s.TunnelBytesSent, 10),
+			strconv.FormatInt(s.TunnelBytesReceived, 10),
+			strconv.FormatInt(s.Jobs, 10),
+			strconv.FormatInt(s.StorageBytes, 10),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,54 @@
+package usage
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAggregate(t *testing.T) {
+	records := []*Record{
+		{ClientID: "c1", UserGroups: []string{"tenant-a"}, ClientGroups: []string{"routers"}, ConnectedSeconds: 3600, TunnelBytesSent: 100, Jobs: 2, StorageBytes: 10},
+		{ClientID: "c2", UserGroups: []string{"tenant-a", "tenant-b"}, ConnectedSeconds: 1800, TunnelBytesReceived: 50, Jobs: 1, StorageBytes: 5},
+		{ClientID: "c3", ConnectedSeconds: 900},
+	}
+
+	assert.Equal(t, []*Summary{
+		{Period: "2026-09", Group: "c1", Clients: 1, ClientHours: 1, TunnelBytesSent: 100, Jobs: 2, StorageBytes: 10},
+		{Period: "2026-09", Group: "c2", Clients: 1, ClientHours: 0.5, TunnelBytesReceived: 50, Jobs: 1, StorageBytes: 5},
+		{Period: "2026-09", Group: "c3", Clients: 1, ClientHours: 0.25},
+	}, Aggregate("2026-09", records, GroupByClient))
+
+	assert.Equal(t, []*Summary{
+		{Period: "2026-09", Group: "tenant-a", Clients: 2, ClientHours: 1.5, TunnelBytesSent: 100, TunnelBytesReceived: 50, Jobs: 3, StorageBytes: 15},
+		{Period: "2026-09", Group: "tenant-b", Clients: 1, ClientHours: 0.5, TunnelBytesReceived: 50, Jobs: 1, StorageBytes: 5},
+	}, Aggregate("2026-09", records, GroupByUserGroup))
+
+	assert.Equal(t, []*Summary{
+		{Period: "2026-09", Group: "routers", Clients: 1, ClientHours: 1, TunnelBytesSent: 100, Jobs: 2, StorageBytes: 10},
+	}, Aggregate("2026-09", records, GroupByClientGroup))
+}
+
+func TestWriteCSV(t *testing.T) {
+	var b bytes.Buffer
+	err := WriteCSV(&b, GroupByUserGroup, []*Summary{
+		{Period: "2026-09", Group: "tenant-a", Clients: 2, ClientHours: 1.5, TunnelBytesSent: 100, TunnelBytesReceived: 50, Jobs: 3, StorageBytes: 15},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "period,user_group,clients,client_hours,tunnel_bytes_sent,tunnel_bytes_received,jobs,storage_bytes\n"+
+		"2026-09,tenant-a,2,1.50,100,50,3,15\n", b.String())
+}
+
+func TestParsePeriod(t *testing.T) {
+	start, end, err := ParsePeriod("2026-12")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), end)
+	assert.Equal(t, "2026-12", PeriodOf(start.Add(time.Hour)))
+
+	_, _, err = ParsePeriod("2026-13")
+	assert.EqualError(t, err, `invalid period "2026-13", expected YYYY-MM`)
+}
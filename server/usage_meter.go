@@ -0,0 +1,179 @@
+package chserver
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/IOTech17/neo-rport/server/cgroups"
+	"github.com/IOTech17/neo-rport/server/clients/clientdata"
+	"github.com/IOTech17/neo-rport/server/usage"
+	"github.com/IOTech17/neo-rport/share/logger"
+)
+
+// usageMeterTask samples the usage of the clients for billing. Connected time and jobs are sampled per interval,
+// tunnel traffic as the difference of the counters of the clients since the last run and the storage less often.
+type usageMeterTask struct {
+	log *logger.Logger
+	s   *Server
+	now func() time.Time
+
+	lastRun     time.Time
+	lastStorage time.Time
+	// traffic is the last seen tunnel traffic per client
+	traffic map[string][2]int64
+}
+
+func newUsageMeterTask(log *logger.Logger, s *Server) *usageMeterTask {
+	return &usageMeterTask{
+		log:     log,
+		s:       s,
+		now:     time.Now,
+		traffic: make(map[string][2]int64),
+	}
+}
+
+func (t *usageMeterTask) Run(ctx context.Context) error {
+	now := t.now().UTC()
+	from := t.lastRun
+	// the server was down or the task is run the first time
+	if from.IsZero() || now.Sub(from) > 2*usage.SampleInterval {
+		from = now.Add(-usage.SampleInterval)
+	}
+
+	clientGroups, err := t.s.clientGroupProvider.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	all := t.s.clientService.GetAll()
+
+	var storage map[string]int64
+	if now.Sub(t.lastStorage) >= usage.StorageSampleInterval {
+		storage, err = t.sampleStorage(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	// a run at the start of a month splits the connected time and the jobs between the periods
+	periodStart, _, err := usage.ParsePeriod(usage.PeriodOf(now))
+	if err != nil {
+		return err
+	}
+	if from.Before(periodStart) {
+		err := t.sample(ctx, usage.PeriodOf(from), from, periodStart, all, clientGroups, nil, false)
+		if err != nil && !errors.Is(err, usage.ErrPeriodClosed) {
+			return err
+		}
+		from = periodStart
+	}
+	if err := t.sample(ctx, usage.PeriodOf(now), from, now, all, clientGroups, storage, true); err != nil {
+		return err
+	}
+
+	t.lastRun = now
+	if storage != nil {
+		t.lastStorage = now
+	}
+	return nil
+}
+
+func (t *usageMeterTask) sample(
+	ctx context.Context,
+	period string,
+	from, to time.Time,
+	all []*clientdata.Client,
+	clientGroups []*cgroups.ClientGroup,
+	storage map[string]int64,
+	withTraffic bool,
+) error {
+	jobs, err := t.s.jobProvider.CountByClient(ctx, from, to)
+	if err != nil {
+		return err
+	}
+
+	var records []*usage.Record
+	for _, c := range all {
+		id := c.GetID()
+		r := &usage.Record{
+			ClientID:     id,
+			ClientName:   c.GetName(),
+			UserGroups:   clientUserGroups(c, clientGroups),
+			ClientGroups: clientGroupIDs(c, clientGroups),
+			Jobs:         jobs[id],
+			StorageBytes: storage[id],
+		}
+		if c.IsConnected() {
+			r.ConnectedSeconds = int64(to.Sub(from).Seconds())
+		}
+		if withTraffic {
+			r.TunnelBytesSent, r.TunnelBytesReceived = t.trafficDelta(c)
+		}
+		if r.ConnectedSeconds == 0 && r.TunnelBytesSent == 0 && r.TunnelBytesReceived == 0 && r.Jobs == 0 && r.StorageBytes == 0 {
+			continue
+		}
+		records = append(records, r)
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	return t.s.usage.Add(ctx, period, records)
+}
+
+// trafficDelta returns the tunnel traffic of the client since the last run.
+func (t *usageMeterTask) trafficDelta(c *clientdata.Client) (sent, received int64) {
+	sent, received = c.Traffic().Get()
+	last := t.traffic[c.GetID()]
+	t.traffic[c.GetID()] = [2]int64{sent, received}
+	// the counters start over with a new client object
+	if sent < last[0] || received < last[1] {
+		return sent, received
+	}
+	return sent - last[0], received - last[1]
+}
+
+func (t *usageMeterTask) sampleStorage(ctx context.Context) (map[string]int64, error) {
+	storage, err := t.s.jobProvider.StorageByClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	measurements, err := t.s.monitoringService.StorageByClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for id, bytes := range measurements {
+		storage[id] += bytes
+	}
+	return storage, nil
+}
+
+// clientUserGroups returns the user groups with access to the client, directly or via client groups.
+func clientUserGroups(c *clientdata.Client, clientGroups []*cgroups.ClientGroup) []string {
+	var res []string
+	seen := make(map[string]bool)
+	add := func(groups []string) {
+		for _, g := range groups {
+			if !seen[g] {
+				seen[g] = true
+				res = append(res, g)
+			}
+		}
+	}
+	add(c.GetAllowedUserGroups())
+	for _, g := range clientGroups {
+		if c.BelongsTo(g) {
+			add(g.AllowedUserGroups)
+		}
+	}
+	return res
+}
+
+func clientGroupIDs(c *clientdata.Client, clientGroups []*cgroups.ClientGroup) []string {
+	var res []string
+	for _, g := range clientGroups {
+		if c.BelongsTo(g) {
+			res = append(res, g.ID)
+		}
+	}
+	return res
+}
@@ -0,0 +1,22 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+// ValidateJobArtifacts checks the artifacts pushed to the clients before a job runs, the artifacts are resolved when
+// the job starts.
+func ValidateJobArtifacts(artifacts []models.JobArtifact) error {
+	for _, a := range artifacts {
+		if a.Artifact == "" {
+			return errors.New("artifact is required")
+		}
+		if a.Destination == "" {
+			return fmt.Errorf("destination of artifact %s is required", a.Artifact)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+package validation
+
+import (
+	"fmt"
+	"regexp"
+)
+
+var validLockName = regexp.MustCompile(`^[a-zA-Z0-9._-]{1,100}$`)
+
+// ValidateLock checks the name of the execution lock of a job, a job without a lock is valid.
+func ValidateLock(name string) error {
+	if name == "" || validLockName.MatchString(name) {
+		return nil
+	}
+	return fmt.Errorf("invalid lock %q: up to 100 letters, digits, '.', '_' or '-'", name)
+}
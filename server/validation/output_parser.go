@@ -0,0 +1,13 @@
+package validation
+
+import (
+	"github.com/IOTech17/neo-rport/share/models"
+)
+
+// ValidateOutputParser checks the output parser of a job, a job without a parser is valid.
+func ValidateOutputParser(parser *models.OutputParser) error {
+	if parser == nil {
+		return nil
+	}
+	return parser.Validate()
+}
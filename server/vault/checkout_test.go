@@ -0,0 +1,86 @@
+package vault
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
+)
+
+func TestCheckout(t *testing.T) {
+	ctx := context.Background()
+	dbProv, err := NewSqliteProvider(configMock{}, testLog)
+	require.NoError(t, err)
+	defer dbProv.Close()
+
+	m := NewManager(
+		NewStatefulDbProviderFactory(func() (DbProvider, error) { return dbProv, nil }, &NotInitDbProvider{}),
+		&Aes256PassManager{},
+		testLog,
+	)
+	require.NoError(t, m.Init(ctx, "vaultpass"))
+
+	alice := UserDataProviderMock{UsernameToGive: "alice"}
+	bob := UserDataProviderMock{UsernameToGive: "bob"}
+
+	plain, err := m.Store(ctx, 0, &InputValue{ClientID: "client1", Key: "plain", Value: "v", Type: SecretType}, alice)
+	require.NoError(t, err)
+	_, _, err = m.CheckOut(ctx, int(plain.ID), CheckoutRequest{Reason: "test"}, alice)
+	assert.EqualError(t, err, "this value doesn't require a check-out")
+
+	stored, err := m.Store(ctx, 0, &InputValue{ClientID: "client1", Key: "root", Value: "s3cret", Type: SecretType, CheckoutRequired: true}, alice)
+	require.NoError(t, err)
+	id := int(stored.ID)
+
+	_, _, err = m.GetOne(ctx, id, alice)
+	assert.Equal(t, errors2.APIError{Message: "this value requires a check-out", HTTPStatus: 403}, err)
+
+	_, _, err = m.CheckOut(ctx, id, CheckoutRequest{}, alice)
+	assert.EqualError(t, err, "a reason is required")
+	_, _, err = m.CheckOut(ctx, id, CheckoutRequest{Reason: "maintenance", DurationMinutes: 25 * 60}, alice)
+	assert.EqualError(t, err, "duration_minutes must be between 1 and 1440")
+
+	val, checkout, err := m.CheckOut(ctx, id, CheckoutRequest{Reason: "maintenance", DurationMinutes: 30}, alice)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", val.Value)
+	assert.Equal(t, "alice", checkout.Username)
+	assert.Equal(t, checkout.CheckedOutAt.Add(30*time.Minute), checkout.ExpiresAt)
+
+	val, _, err = m.GetOne(ctx, id, alice)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", val.Value)
+
+	_, _, err = m.GetOne(ctx, id, bob)
+	assert.Error(t, err)
+	_, _, err = m.CheckOut(ctx, id, CheckoutRequest{Reason: "incident"}, bob)
+	assert.ErrorContains(t, err, "value is checked out by alice until")
+	_, err = m.CheckIn(ctx, id, "", bob)
+	assert.EqualError(t, err, "value is not checked out by you")
+	err = m.Delete(ctx, id, bob)
+	assert.ErrorContains(t, err, "value is checked out by alice until")
+
+	checkout, err = m.CheckIn(ctx, id, "n3w", alice)
+	require.NoError(t, err)
+	assert.True(t, checkout.Rotated)
+	assert.NotNil(t, checkout.CheckedInAt)
+
+	_, _, err = m.GetOne(ctx, id, alice)
+	assert.Error(t, err)
+
+	val, _, err = m.CheckOut(ctx, id, CheckoutRequest{Reason: "incident"}, bob)
+	require.NoError(t, err)
+	assert.Equal(t, "n3w", val.Value)
+
+	history, err := m.ListCheckouts(ctx, id, alice)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, "bob", history[0].Username)
+	assert.Nil(t, history[0].CheckedInAt)
+	assert.Equal(t, "alice", history[1].Username)
+	assert.Equal(t, "maintenance", history[1].Reason)
+	assert.True(t, history[1].Rotated)
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -47,6 +48,10 @@ type DbProvider interface {
 	FindByKeyAndClientID(ctx context.Context, key, clientID string) (val StoredValue, found bool, err error)
 	Save(ctx context.Context, user string, idToUpdate int64, val *InputValue, nowDate time.Time) (int64, error)
 	Delete(ctx context.Context, id int) error
+	GetActiveCheckout(ctx context.Context, valueID int, now time.Time) (c Checkout, found bool, err error)
+	SaveCheckout(ctx context.Context, c *Checkout) error
+	CheckIn(ctx context.Context, id int64, checkedInAt time.Time, rotated bool) error
+	ListCheckouts(ctx context.Context, valueID int) ([]Checkout, error)
 	io.Closer
 }
 
@@ -61,12 +66,19 @@ type DbProviderFactory interface {
 	Init() error
 }
 
+const (
+	DefaultCheckoutDuration = time.Hour
+	MaxCheckoutDuration     = 24 * time.Hour
+)
+
 type Manager struct {
 	passLock  sync.RWMutex
 	pass      string
 	dbFactory DbProviderFactory
 	pm        PassManager
 	logger    *logger.Logger
+	// checkoutLock makes check-outs exclusive
+	checkoutLock sync.Mutex
 }
 
 func NewManager(dbFactory DbProviderFactory, pm PassManager, logger *logger.Logger) *Manager {
@@ -308,16 +320,38 @@ func (m *Manager) GetOne(ctx context.Context, id int, user UserDataProvider) (St
 		return StoredValue{}, false, err
 	}
 
+	if val.CheckoutRequired {
+		checkout, found, err := db.GetActiveCheckout(ctx, id, time.Now().UTC())
+		if err != nil {
+			return StoredValue{}, false, err
+		}
+		if !found || checkout.Username != user.GetUsername() {
+			return StoredValue{}, false, errors2.APIError{
+				Message:    "this value requires a check-out",
+				HTTPStatus: http.StatusForbidden,
+			}
+		}
+	}
+
+	err = m.decrypt(&val)
+	if err != nil {
+		return StoredValue{}, false, err
+	}
+
+	return val, true, nil
+}
+
+func (m *Manager) decrypt(val *StoredValue) error {
 	m.passLock.RLock()
 	defer m.passLock.RUnlock()
 
 	decryptedValue, err := enc.Aes256DecryptByPassFromBase64String(val.Value, m.pass)
 	if err != nil {
-		return StoredValue{}, false, err
+		return err
 	}
 	val.Value = string(decryptedValue)
 
-	return val, true, nil
+	return nil
 }
 
 func (m *Manager) Store(ctx context.Context, existingID int64, valueToStore *InputValue, user UserDataProvider) (StoredValueID, error) {
@@ -355,6 +389,11 @@ func (m *Manager) Store(ctx context.Context, existingID int64, valueToStore *Inp
 		if err != nil {
 			return StoredValueID{}, err
 		}
+
+		err = m.checkNotCheckedOutByOthers(ctx, db, &val, user)
+		if err != nil {
+			return StoredValueID{}, err
+		}
 	}
 
 	if found && (existingID == 0 || storedValue.ID != int(existingID)) {
@@ -408,6 +447,11 @@ func (m *Manager) Delete(ctx context.Context, id int, user UserDataProvider) err
 		return err
 	}
 
+	err = m.checkNotCheckedOutByOthers(ctx, db, &storedValue, user)
+	if err != nil {
+		return err
+	}
+
 	err = db.Delete(ctx, id)
 	if err != nil {
 		return err
@@ -416,6 +460,197 @@ func (m *Manager) Delete(ctx context.Context, id int, user UserDataProvider) err
 	return nil
 }
 
+// CheckOut leases a value requiring a check-out exclusively to the user and returns it.
+func (m *Manager) CheckOut(ctx context.Context, id int, req CheckoutRequest, user UserDataProvider) (StoredValue, Checkout, error) {
+	err := m.checkUnlockedAndInitialized(ctx)
+	if err != nil {
+		return StoredValue{}, Checkout{}, err
+	}
+
+	if strings.TrimSpace(req.Reason) == "" {
+		return StoredValue{}, Checkout{}, errors2.APIError{
+			Message:    "a reason is required",
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+	duration := DefaultCheckoutDuration
+	if req.DurationMinutes != 0 {
+		duration = time.Duration(req.DurationMinutes) * time.Minute
+	}
+	if duration <= 0 || duration > MaxCheckoutDuration {
+		return StoredValue{}, Checkout{}, errors2.APIError{
+			Message:    fmt.Sprintf("duration_minutes must be between 1 and %.0f", MaxCheckoutDuration.Minutes()),
+			HTTPStatus: http.StatusBadRequest,
+		}
+	}
+
+	db := m.dbFactory.GetDbProvider()
+
+	val, err := m.getCheckoutValue(ctx, db, id, user)
+	if err != nil {
+		return StoredValue{}, Checkout{}, err
+	}
+
+	m.checkoutLock.Lock()
+	defer m.checkoutLock.Unlock()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	active, found, err := db.GetActiveCheckout(ctx, id, now)
+	if err != nil {
+		return StoredValue{}, Checkout{}, err
+	}
+	if found {
+		return StoredValue{}, Checkout{}, errors2.APIError{
+			Message:    fmt.Sprintf("value is checked out by %s until %s", active.Username, active.ExpiresAt.Format(time.RFC3339)),
+			HTTPStatus: http.StatusConflict,
+		}
+	}
+
+	checkout := Checkout{
+		ValueID:      id,
+		Username:     user.GetUsername(),
+		Reason:       req.Reason,
+		CheckedOutAt: now,
+		ExpiresAt:    now.Add(duration),
+	}
+	err = db.SaveCheckout(ctx, &checkout)
+	if err != nil {
+		return StoredValue{}, Checkout{}, err
+	}
+
+	err = m.decrypt(&val)
+	if err != nil {
+		return StoredValue{}, Checkout{}, err
+	}
+
+	return val, checkout, nil
+}
+
+// CheckIn ends the check-out of the user, a new value replaces the value unless empty.
+func (m *Manager) CheckIn(ctx context.Context, id int, newValue string, user UserDataProvider) (Checkout, error) {
+	err := m.checkUnlockedAndInitialized(ctx)
+	if err != nil {
+		return Checkout{}, err
+	}
+
+	db := m.dbFactory.GetDbProvider()
+
+	val, err := m.getCheckoutValue(ctx, db, id, user)
+	if err != nil {
+		return Checkout{}, err
+	}
+
+	m.checkoutLock.Lock()
+	defer m.checkoutLock.Unlock()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	checkout, found, err := db.GetActiveCheckout(ctx, id, now)
+	if err != nil {
+		return Checkout{}, err
+	}
+	if !found || checkout.Username != user.GetUsername() {
+		return Checkout{}, errors2.APIError{
+			Message:    "value is not checked out by you",
+			HTTPStatus: http.StatusConflict,
+		}
+	}
+
+	rotated := newValue != ""
+	if rotated {
+		rotatedValue := val.InputValue
+		rotatedValue.Value = newValue
+		err = Validate(&rotatedValue)
+		if err != nil {
+			return Checkout{}, err
+		}
+
+		m.passLock.RLock()
+		rotatedValue.Value, err = enc.Aes256EncryptByPassToBase64String([]byte(newValue), m.pass)
+		m.passLock.RUnlock()
+		if err != nil {
+			return Checkout{}, err
+		}
+
+		_, err = db.Save(ctx, user.GetUsername(), int64(id), &rotatedValue, now)
+		if err != nil {
+			return Checkout{}, err
+		}
+	}
+
+	err = db.CheckIn(ctx, checkout.ID, now, rotated)
+	if err != nil {
+		return Checkout{}, err
+	}
+	checkout.CheckedInAt = &now
+	checkout.Rotated = rotated
+
+	return checkout, nil
+}
+
+// ListCheckouts returns the check-out history of a value, the latest first.
+func (m *Manager) ListCheckouts(ctx context.Context, id int, user UserDataProvider) ([]Checkout, error) {
+	err := m.checkUnlockedAndInitialized(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	db := m.dbFactory.GetDbProvider()
+
+	_, err = m.getCheckoutValue(ctx, db, id, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.ListCheckouts(ctx, id)
+}
+
+func (m *Manager) getCheckoutValue(ctx context.Context, db DbProvider, id int, user UserDataProvider) (StoredValue, error) {
+	val, found, err := db.GetByID(ctx, id)
+	if err != nil {
+		return StoredValue{}, err
+	}
+	if !found {
+		return StoredValue{}, errors2.APIError{
+			Message:    "cannot find this entry by the provided id",
+			HTTPStatus: http.StatusNotFound,
+		}
+	}
+
+	err = m.checkGroupAccess(&val, user)
+	if err != nil {
+		return StoredValue{}, err
+	}
+
+	if !val.CheckoutRequired {
+		return StoredValue{}, errors2.APIError{
+			Message:    "this value doesn't require a check-out",
+			HTTPStatus: http.StatusConflict,
+		}
+	}
+
+	return val, nil
+}
+
+// checkNotCheckedOutByOthers prevents changes of values while checked out by another user.
+func (m *Manager) checkNotCheckedOutByOthers(ctx context.Context, db DbProvider, val *StoredValue, user UserDataProvider) error {
+	if !val.CheckoutRequired {
+		return nil
+	}
+
+	checkout, found, err := db.GetActiveCheckout(ctx, val.ID, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	if found && checkout.Username != user.GetUsername() {
+		return errors2.APIError{
+			Message:    fmt.Sprintf("value is checked out by %s until %s", checkout.Username, checkout.ExpiresAt.Format(time.RFC3339)),
+			HTTPStatus: http.StatusConflict,
+		}
+	}
+
+	return nil
+}
+
 func (m *Manager) checkUnlockedAndInitialized(ctx context.Context) error {
 	if m.IsLocked() {
 		return errors2.APIError{
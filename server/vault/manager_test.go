@@ -103,6 +103,22 @@ func (dpm *DbProviderMock) Delete(ctx context.Context, id int) error {
 	return dpm.DeleteErrorToGive
 }
 
+func (dpm *DbProviderMock) GetActiveCheckout(ctx context.Context, valueID int, now time.Time) (c Checkout, found bool, err error) {
+	return Checkout{}, false, nil
+}
+
+func (dpm *DbProviderMock) SaveCheckout(ctx context.Context, c *Checkout) error {
+	return nil
+}
+
+func (dpm *DbProviderMock) CheckIn(ctx context.Context, id int64, checkedInAt time.Time, rotated bool) error {
+	return nil
+}
+
+func (dpm *DbProviderMock) ListCheckouts(ctx context.Context, valueID int) ([]Checkout, error) {
+	return nil, nil
+}
+
 func (dpm *DbProviderMock) GetDbProvider() DbProvider {
 	return dpm
 }
@@ -42,6 +42,8 @@ type InputValue struct {
 	Key           string    `json:"key" db:"key"`
 	Value         string    `json:"value" db:"value"`
 	Type          ValueType `json:"type" db:"type"`
+	// CheckoutRequired values can only be read by the user holding the check-out
+	CheckoutRequired bool `json:"checkout_required" db:"checkout_required"`
 }
 
 type ValueKey struct {
@@ -50,6 +52,8 @@ type ValueKey struct {
 	CreatedBy string    `json:"created_by" db:"created_by"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	Key       string    `json:"key" db:"key"`
+
+	CheckoutRequired bool `json:"checkout_required" db:"checkout_required"`
 }
 
 type StoredValue struct {
@@ -60,3 +64,26 @@ type StoredValue struct {
 	CreatedBy string    `json:"created_by" db:"created_by"`
 	UpdatedBy *string   `json:"updated_by" db:"updated_by"`
 }
+
+// Checkout is an exclusive lease of a value, it ends when checked in or expired.
+type Checkout struct {
+	ID           int64      `json:"id" db:"id"`
+	ValueID      int        `json:"value_id" db:"value_id"`
+	Username     string     `json:"username" db:"username"`
+	Reason       string     `json:"reason" db:"reason"`
+	CheckedOutAt time.Time  `json:"checked_out_at" db:"checked_out_at"`
+	ExpiresAt    time.Time  `json:"expires_at" db:"expires_at"`
+	CheckedInAt  *time.Time `json:"checked_in_at" db:"checked_in_at"`
+	// Rotated tells the value was changed on check-in
+	Rotated bool `json:"rotated" db:"rotated"`
+}
+
+type CheckoutRequest struct {
+	Reason          string `json:"reason"`
+	DurationMinutes int    `json:"duration_minutes"`
+}
+
+type CheckinRequest struct {
+	// NewValue replaces the value if not empty
+	NewValue string `json:"new_value"`
+}
@@ -139,7 +139,7 @@ func (p *SqliteProvider) GetByID(ctx context.Context, id int) (val StoredValue,
 func (p *SqliteProvider) List(ctx context.Context, lo *query.ListOptions) ([]ValueKey, error) {
 	values := []ValueKey{}
 
-	q := "SELECT `id`, `client_id`, `created_by`, `created_at`, `key` FROM `values`"
+	q := "SELECT `id`, `client_id`, `created_by`, `created_at`, `key`, `checkout_required` FROM `values`"
 
 	q, params := p.converter.ConvertListOptionsToQuery(lo, q)
 
@@ -168,7 +168,7 @@ func (p *SqliteProvider) Save(ctx context.Context, user string, idToUpdate int64
 	if idToUpdate == 0 {
 		res, err := p.db.ExecContext(
 			ctx,
-			"INSERT INTO `values` (`client_id`, `required_group`, `created_at`, `created_by`, `updated_at`, `updated_by`, `key`, `value`, `type`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			"INSERT INTO `values` (`client_id`, `required_group`, `created_at`, `created_by`, `updated_at`, `updated_by`, `key`, `value`, `type`, `checkout_required`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
 			val.ClientID,
 			val.RequiredGroup,
 			nowDate.Format(time.RFC3339),
@@ -178,6 +178,7 @@ func (p *SqliteProvider) Save(ctx context.Context, user string, idToUpdate int64
 			val.Key,
 			val.Value,
 			val.Type,
+			val.CheckoutRequired,
 		)
 
 		if err != nil {
@@ -188,7 +189,7 @@ func (p *SqliteProvider) Save(ctx context.Context, user string, idToUpdate int64
 			return 0, err
 		}
 	} else {
-		q := "UPDATE `values` SET `client_id` = ?, `required_group` = ?, `updated_at` = ?, `updated_by` = ?, `key` = ?, `value` = ?, `type` = ? WHERE id = ?"
+		q := "UPDATE `values` SET `client_id` = ?, `required_group` = ?, `updated_at` = ?, `updated_by` = ?, `key` = ?, `value` = ?, `type` = ?, `checkout_required` = ? WHERE id = ?"
 		params := []interface{}{
 			val.ClientID,
 			val.RequiredGroup,
@@ -197,6 +198,7 @@ func (p *SqliteProvider) Save(ctx context.Context, user string, idToUpdate int64
 			val.Key,
 			val.Value,
 			val.Type,
+			val.CheckoutRequired,
 			idToUpdate,
 		}
 		_, err := p.db.ExecContext(ctx, q, params...)
@@ -227,6 +229,54 @@ func (p *SqliteProvider) Delete(ctx context.Context, id int) error {
 	return nil
 }
 
+// GetActiveCheckout returns the check-out of the value that is neither checked in nor expired at now.
+func (p *SqliteProvider) GetActiveCheckout(ctx context.Context, valueID int, now time.Time) (c Checkout, found bool, err error) {
+	err = p.db.GetContext(
+		ctx,
+		&c,
+		"SELECT * FROM `checkouts` WHERE `value_id` = ? AND `checked_in_at` IS NULL AND `expires_at` > ? ORDER BY `id` DESC LIMIT 1",
+		valueID,
+		now,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c, false, nil
+		}
+		return c, false, err
+	}
+
+	return c, true, nil
+}
+
+func (p *SqliteProvider) SaveCheckout(ctx context.Context, c *Checkout) error {
+	res, err := p.db.NamedExecContext(
+		ctx,
+		"INSERT INTO `checkouts` (`value_id`, `username`, `reason`, `checked_out_at`, `expires_at`) VALUES (:value_id, :username, :reason, :checked_out_at, :expires_at)",
+		c,
+	)
+	if err != nil {
+		return err
+	}
+	c.ID, err = res.LastInsertId()
+	return err
+}
+
+func (p *SqliteProvider) CheckIn(ctx context.Context, id int64, checkedInAt time.Time, rotated bool) error {
+	_, err := p.db.ExecContext(ctx, "UPDATE `checkouts` SET `checked_in_at` = ?, `rotated` = ? WHERE `id` = ?", checkedInAt, rotated, id)
+	return err
+}
+
+// ListCheckouts returns all check-outs of the value, the latest first.
+func (p *SqliteProvider) ListCheckouts(ctx context.Context, valueID int) ([]Checkout, error) {
+	checkouts := []Checkout{}
+	err := p.db.SelectContext(ctx, &checkouts, "SELECT * FROM `checkouts` WHERE `value_id` = ? ORDER BY `id` DESC", valueID)
+	if err != nil {
+		return nil, err
+	}
+
+	return checkouts, nil
+}
+
 func (p *SqliteProvider) handleRollback(tx *sqlx.Tx) {
 	err := tx.Rollback()
 	if err != nil {
@@ -270,6 +320,23 @@ func (nidp *NotInitDbProvider) Delete(ctx context.Context, id int) error {
 	return ErrDatabaseNotInitialised
 }
 
+func (nidp *NotInitDbProvider) GetActiveCheckout(ctx context.Context, valueID int, now time.Time) (c Checkout, found bool, err error) {
+	err = ErrDatabaseNotInitialised
+	return
+}
+
+func (nidp *NotInitDbProvider) SaveCheckout(ctx context.Context, c *Checkout) error {
+	return ErrDatabaseNotInitialised
+}
+
+func (nidp *NotInitDbProvider) CheckIn(ctx context.Context, id int64, checkedInAt time.Time, rotated bool) error {
+	return ErrDatabaseNotInitialised
+}
+
+func (nidp *NotInitDbProvider) ListCheckouts(ctx context.Context, valueID int) ([]Checkout, error) {
+	return nil, ErrDatabaseNotInitialised
+}
+
 func (nidp *NotInitDbProvider) Close() error {
 	return nil
 }
@@ -345,16 +345,17 @@ func TestCreate(t *testing.T) {
 
 	expectedRows := []map[string]interface{}{
 		{
-			"id":             int64(1),
-			"client_id":      "client123",
-			"required_group": "group123",
-			"created_at":     expectedCreatedAt,
-			"created_by":     "user123",
-			"updated_at":     expectedCreatedAt,
-			"updated_by":     "user123",
-			"key":            "key123",
-			"value":          "value123",
-			"type":           "typ123",
+			"id":                int64(1),
+			"client_id":         "client123",
+			"required_group":    "group123",
+			"created_at":        expectedCreatedAt,
+			"created_by":        "user123",
+			"updated_at":        expectedCreatedAt,
+			"updated_by":        "user123",
+			"key":               "key123",
+			"value":             "value123",
+			"type":              "typ123",
+			"checkout_required": false,
 		},
 	}
 	query := "SELECT * FROM `values`"
@@ -395,16 +396,17 @@ func TestUpdate(t *testing.T) {
 
 	expectedRows := []map[string]interface{}{
 		{
-			"id":             int64(1),
-			"client_id":      "client123",
-			"required_group": "group123",
-			"created_at":     expectedCreatedAt,
-			"created_by":     "user1",
-			"updated_at":     expectedUpdatedAt,
-			"updated_by":     "user123",
-			"key":            "key123",
-			"value":          "value123",
-			"type":           "typ123",
+			"id":                int64(1),
+			"client_id":         "client123",
+			"required_group":    "group123",
+			"created_at":        expectedCreatedAt,
+			"created_by":        "user1",
+			"updated_at":        expectedUpdatedAt,
+			"updated_by":        "user123",
+			"key":               "key123",
+			"value":             "value123",
+			"type":              "typ123",
+			"checkout_required": false,
 		},
 	}
 	query := "SELECT * FROM `values` where id = 1"
@@ -477,16 +479,17 @@ func TestDelete(t *testing.T) {
 
 	expectedRows := []map[string]interface{}{
 		{
-			"id":             int64(2),
-			"client_id":      "client2",
-			"required_group": "group1",
-			"created_at":     expectedCreatedAt,
-			"created_by":     "user1",
-			"updated_at":     expectedCreatedAt,
-			"updated_by":     nil,
-			"key":            "key2",
-			"value":          "val2",
-			"type":           "type2",
+			"id":                int64(2),
+			"client_id":         "client2",
+			"required_group":    "group1",
+			"created_at":        expectedCreatedAt,
+			"created_by":        "user1",
+			"updated_at":        expectedCreatedAt,
+			"updated_by":        nil,
+			"key":               "key2",
+			"value":             "val2",
+			"type":              "type2",
+			"checkout_required": false,
 		},
 	}
 	query := "SELECT * FROM `values`"
@@ -11,6 +11,9 @@ func NewServerCapabilities(cfg *chconfig.MonitoringConfig) *models.Capabilities
 		ServerVersion:      chshare.BuildVersion,
 		MonitoringVersion:  chshare.MonitoringVersion,
 		IPAddressesVersion: chshare.IPAddressesVersion,
+		JobResultsVersion:  chshare.JobResultsVersion,
+		ProtocolRevision:   chshare.ProtocolRevision,
+		FeatureLevels:      chshare.FeatureLevels(),
 	}
 
 	if !cfg.Enabled {
@@ -6,20 +6,32 @@ import (
 	"regexp"
 	"time"
 
+	"github.com/IOTech17/neo-rport/share/comm"
 	"github.com/IOTech17/neo-rport/share/logger"
 	"github.com/IOTech17/neo-rport/share/models"
+	"github.com/IOTech17/neo-rport/share/sshpolicy"
 )
 
 type Config struct {
-	Client                   ClientConfig        `json:"client" mapstructure:"client"`
-	Connection               ConnectionConfig    `json:"connection" mapstructure:"connection"`
-	Logging                  LogConfig           `json:"logging" mapstructure:"logging"`
-	RemoteCommands           CommandsConfig      `json:"remote_commands" mapstructure:"remote-commands"`
-	RemoteScripts            ScriptsConfig       `json:"remote_scripts" mapstructure:"remote-scripts"`
-	Monitoring               MonitoringConfig    `json:"monitoring" mapstructure:"monitoring"`
-	Tunnels                  TunnelsConfig       `json:"-"`
-	InterpreterAliasesConfig map[string]any      `json:"-" mapstructure:"interpreter-aliases"`
-	FileReceptionConfig      FileReceptionConfig `json:"file_reception" mapstructure:"file-reception"`
+	Client                   ClientConfig           `json:"client" mapstructure:"client"`
+	Connection               ConnectionConfig       `json:"connection" mapstructure:"connection"`
+	Logging                  LogConfig              `json:"logging" mapstructure:"logging"`
+	RemoteCommands           CommandsConfig         `json:"remote_commands" mapstructure:"remote-commands"`
+	RemoteScripts            ScriptsConfig          `json:"remote_scripts" mapstructure:"remote-scripts"`
+	Monitoring               MonitoringConfig       `json:"monitoring" mapstructure:"monitoring"`
+	Tunnels                  TunnelsConfig          `json:"-"`
+	InterpreterAliasesConfig map[string]any         `json:"-" mapstructure:"interpreter-aliases"`
+	FileReceptionConfig      FileReceptionConfig    `json:"file_reception" mapstructure:"file-reception"`
+	Kubernetes               KubernetesConfig       `json:"kubernetes" mapstructure:"kubernetes"`
+	CloudMetadata            CloudMetadataConfig    `json:"cloud_metadata" mapstructure:"cloud-metadata"`
+	Screenshots              ScreenshotsConfig      `json:"screenshots" mapstructure:"screenshots"`
+	Chat                     ChatConfig             `json:"chat" mapstructure:"chat"`
+	Consent                  ConsentConfig          `json:"consent" mapstructure:"consent"`
+	ResourceLimits           ResourceLimitsConfig   `json:"resource_limits" mapstructure:"resource-limits"`
+	NetworkDiscovery         NetworkDiscoveryConfig `json:"network_discovery" mapstructure:"network-discovery"`
+	Agentless                AgentlessConfig        `json:"agentless" mapstructure:"agentless"`
+	SerialConsoles           SerialConsolesConfig   `json:"serial_consoles" mapstructure:"serial-consoles"`
+	Industrial               IndustrialConfig       `json:"industrial" mapstructure:"industrial"`
 
 	InterpreterAliases          map[string]string                   `json:"interpreter_aliases"`
 	InterpreterAliasesEncodings map[string]InterpreterAliasEncoding `json:"interpreter_aliases_encodings"`
@@ -33,20 +45,26 @@ type ClientConfig struct {
 	Fingerprint              string            `json:"fingerprint" mapstructure:"fingerprint"`
 	Auth                     string            `json:"auth" mapstructure:"auth"`
 	Proxy                    string            `json:"proxy" mapstructure:"proxy"`
+	UseSystemProxy           bool              `json:"use_system_proxy" mapstructure:"use_system_proxy"`
+	ProxyPACURL              string            `json:"proxy_pac_url" mapstructure:"proxy_pac_url"`
 	ID                       string            `json:"id" mapstructure:"id"`
 	UseSystemID              bool              `json:"use_system_id" mapstructure:"use_system_id"`
 	Name                     string            `json:"name" mapstructure:"name"`
 	UseHostname              bool              `json:"use_hostname" mapstructure:"use_hostname"`
+	Ephemeral                bool              `json:"ephemeral" mapstructure:"ephemeral"`
 	Tags                     []string          `json:"tags" mapstructure:"tags"`
 	Labels                   map[string]string `json:"labels" mapstructure:"labels"`
 	Remotes                  []string          `json:"remotes" mapstructure:"remotes"`
 	TunnelAllowed            []string          `json:"tunnel_allowed" mapstructure:"tunnel_allowed"`
 	AllowRoot                bool              `json:"allow_root" mapstructure:"allow_root"`
+	AllowMeshTunnels         bool              `json:"allow_mesh_tunnels" mapstructure:"allow_mesh_tunnels"`
 	UpdatesInterval          time.Duration     `json:"updates_interval" mapstructure:"updates_interval"`
 	DataDir                  string            `json:"data_dir" mapstructure:"data_dir"`
 	BindInterface            string            `json:"bind_interface" mapstructure:"bind_interface"`
 	IPAPIURL                 string            `json:"ip_api_url" mapstructure:"ip_api_url"`
 	IPRefreshMin             time.Duration     `json:"ip_refresh_min" mapstructure:"ip_refresh_min"`
+	FIPSMode                 bool              `json:"fips_mode" mapstructure:"fips_mode"`
+	BannerFile               string            `json:"banner_file" mapstructure:"banner_file"`
 
 	ProxyURL *url.URL         `json:"proxy_url"`
 	Tunnels  []*models.Remote `json:"tunnels"`
@@ -69,6 +87,8 @@ type ConnectionConfig struct {
 	Hostname            string        `json:"hostname" mapstructure:"hostname"`
 	WatchdogIntegration bool          `json:"watchdog_integration" mapstructure:"watchdog_integration"`
 
+	SSHPolicy sshpolicy.Policy `json:"ssh_policy" mapstructure:",squash"`
+
 	HTTPHeaders http.Header `json:"http_headers"`
 }
 
@@ -104,6 +124,7 @@ type MonitoringConfig struct {
 	PMMaxNumberProcesses          uint          `json:"pm_max_number_processes" mapstructure:"pm_max_number_processes"`
 	NetLan                        []string      `json:"net_lan" mapstructure:"net_lan"`
 	NetWan                        []string      `json:"net_wan" mapstructure:"net_wan"`
+	BufferSize                    int           `json:"buffer_size" mapstructure:"buffer_size"`
 
 	LanCard *models.NetworkCard `json:"lan_card"`
 	WanCard *models.NetworkCard `json:"wan_card"`
@@ -114,6 +135,116 @@ type FileReceptionConfig struct {
 	Enabled   bool     `json:"enabled" mapstructure:"enabled"`
 }
 
+type ScreenshotsConfig struct {
+	Enabled bool `json:"enabled" mapstructure:"enabled"`
+	// Command takes the screenshot, "{file}" is replaced by the path of the PNG file to write
+	Command  []string `json:"command" mapstructure:"command"`
+	MaxBytes int64    `json:"max_bytes" mapstructure:"max_bytes"`
+}
+
+const (
+	ConsentOnTimeoutDeny  = "deny"
+	ConsentOnTimeoutAllow = "allow"
+)
+
+type ConsentConfig struct {
+	// Screenshots and Sessions require the consent of the user for screenshots and remote desktop sessions
+	Screenshots bool `json:"screenshots" mapstructure:"screenshots"`
+	Sessions    bool `json:"sessions" mapstructure:"sessions"`
+	// Command asks the user and exits with 0 if allowed, "{requester}" and "{message}" are replaced
+	Command   []string      `json:"command" mapstructure:"command"`
+	Timeout   time.Duration `json:"timeout" mapstructure:"timeout"`
+	OnTimeout string        `json:"on_timeout" mapstructure:"on_timeout"`
+}
+
+// Required tells if the consent of the user is required for the subject.
+func (c ConsentConfig) Required(subject string) bool {
+	switch subject {
+	case comm.ConsentSubjectScreenshot:
+		return c.Screenshots
+	case comm.ConsentSubjectSession:
+		return c.Sessions
+	}
+	return false
+}
+
+type ChatConfig struct {
+	Enabled bool `json:"enabled" mapstructure:"enabled"`
+	// Command shows the message and prints the reply of the user, "{from}" and "{message}" are replaced
+	Command      []string      `json:"command" mapstructure:"command"`
+	ReplyTimeout time.Duration `json:"reply_timeout" mapstructure:"reply_timeout"`
+}
+
+// NetworkDiscoveryConfig allows the server to let the client scan its local networks for devices.
+type NetworkDiscoveryConfig struct {
+	Enabled bool `json:"enabled" mapstructure:"enabled"`
+	// Subnets that may be scanned, if empty the subnets of the local interfaces
+	Subnets []string `json:"subnets" mapstructure:"subnets"`
+	// Ports that may be probed, a scan probes all of them unless the request names fewer
+	Ports        []int         `json:"ports" mapstructure:"ports"`
+	MaxHosts     int           `json:"max_hosts" mapstructure:"max_hosts"`
+	ProbeTimeout time.Duration `json:"probe_timeout" mapstructure:"probe_timeout"`
+}
+
+// AgentlessConfig allows the server to use the client as jump host to manage targets without an rport client.
+type AgentlessConfig struct {
+	Enabled bool `json:"enabled" mapstructure:"enabled"`
+	// Targets are the subnets that may be reached, if empty the subnets of the local interfaces
+	Targets []string `json:"targets" mapstructure:"targets"`
+	// MaxSessions limits the commands run on targets at the same time
+	MaxSessions int `json:"max_sessions" mapstructure:"max_sessions"`
+}
+
+// SerialConsolesConfig allows the server to open console sessions on local serial ports.
+type SerialConsolesConfig struct {
+	Enabled bool `json:"enabled" mapstructure:"enabled"`
+	// Ports are glob patterns of the devices that may be opened, e.g. /dev/ttyUSB* or COM*
+	Ports []string `json:"ports" mapstructure:"ports"`
+}
+
+// IndustrialConfig allows the server to poll Modbus TCP and OPC UA devices through the client.
+type IndustrialConfig struct {
+	Enabled bool `json:"enabled" mapstructure:"enabled"`
+	// Targets are the subnets the devices may be in, if empty the subnets of the local interfaces
+	Targets []string `json:"targets" mapstructure:"targets"`
+}
+
+const (
+	IOClassBestEffort = "best-effort"
+	IOClassIdle       = "idle"
+)
+
+// ResourceLimitsConfig limits the resources used by the commands and scripts the client runs and by monitoring.
+type ResourceLimitsConfig struct {
+	// ExecNice is the niceness of commands and scripts from 0 (unchanged) to 19 (lowest priority)
+	ExecNice int `json:"exec_nice" mapstructure:"exec_nice"`
+	// ExecIOClass is the io scheduling class of commands and scripts, only on linux
+	ExecIOClass string `json:"exec_io_class" mapstructure:"exec_io_class"`
+	// ExecMaxCPUSeconds and ExecMaxMemoryMB are the rlimits of commands and scripts, only on linux, 0 is unlimited
+	ExecMaxCPUSeconds int `json:"exec_max_cpu_seconds" mapstructure:"exec_max_cpu_seconds"`
+	ExecMaxMemoryMB   int `json:"exec_max_memory_mb" mapstructure:"exec_max_memory_mb"`
+	// MonitoringCPUBudgetPercent is the share of one cpu monitoring may use, 0 is unlimited
+	MonitoringCPUBudgetPercent float64 `json:"monitoring_cpu_budget_percent" mapstructure:"monitoring_cpu_budget_percent"`
+}
+
+type KubernetesConfig struct {
+	Enabled         bool          `json:"enabled" mapstructure:"enabled"`
+	ClusterName     string        `json:"cluster_name" mapstructure:"cluster_name"`
+	NodeNameEnv     string        `json:"node_name_env" mapstructure:"node_name_env"`
+	ReadNodeLabels  bool          `json:"read_node_labels" mapstructure:"read_node_labels"`
+	LabelsFile      string        `json:"labels_file" mapstructure:"labels_file"`
+	TagLabels       []string      `json:"tag_labels" mapstructure:"tag_labels"`
+	IPWatchInterval time.Duration `json:"ip_watch_interval" mapstructure:"ip_watch_interval"`
+}
+
+// CloudMetadataConfig adds the instance metadata of the cloud VM the client runs on to its tags and labels.
+type CloudMetadataConfig struct {
+	Enabled  bool          `json:"enabled" mapstructure:"enabled"`
+	Provider string        `json:"provider" mapstructure:"provider"`
+	Timeout  time.Duration `json:"timeout" mapstructure:"timeout"`
+	TagKeys  []string      `json:"tag_keys" mapstructure:"tag_keys"`
+}
+
 type InterpreterAliasEncoding struct {
 	InputEncoding  string `json:"input_encoding"`
 	OutputEncoding string `json:"output_encoding"`
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"time"
+
+	"github.com/IOTech17/neo-rport/share/files"
 )
 
 const (
@@ -13,20 +15,64 @@ const (
 	RequestTypeRefreshUpdatesStatus = "refresh_updates_status"
 	RequestTypePutCapabilities      = "put_capabilities"
 	RequestTypeCheckTunnelAllowed   = "check_tunnel_allowed"
+	RequestTypeStartMeshTunnel      = "start_mesh_tunnel"
+	RequestTypeStopMeshTunnel       = "stop_mesh_tunnel"
+	RequestTypePutReverseRemotes    = "put_reverse_remotes"
+	RequestTypeTakeScreenshot       = "take_screenshot"
+	RequestTypeChatMessage          = "chat_message"
+	RequestTypeAskConsent           = "ask_consent"
+	RequestTypeActivateFileChange   = "activate_file_change"
+	RequestTypeConfirmFileChange    = "confirm_file_change"
+	RequestTypeRollbackFileChange   = "rollback_file_change"
+	RequestTypeDiscoverNetwork      = "discover_network"
+	RequestTypeRunAgentless         = "run_agentless"
+	RequestTypeOpenSerialConsole    = "open_serial_console"
+	RequestTypePollIndustrial       = "poll_industrial"
+	RequestTypePutEdgeRules         = "put_edge_rules"
 
 	RequestTypeUpdateClientAttributes = "update_client_metadata"
 
 	// RequestTypeCmdResult request types sent by clients to server
-	RequestTypeCmdResult       = "cmd_result"
-	RequestTypeUpdatesStatus   = "updates_status"
-	RequestTypeSaveMeasurement = "save_measurement"
-	RequestTypeUpload          = "upload"
-	RequestTypeIPAddresses     = "ip_addresses"
+	RequestTypeCmdResult        = "cmd_result"
+	RequestTypeUpdatesStatus    = "updates_status"
+	RequestTypeSaveMeasurement  = "save_measurement"
+	RequestTypeSaveMeasurements = "save_measurements"
+	RequestTypeUpload           = "upload"
+	RequestTypeIPAddresses      = "ip_addresses"
+	RequestTypeChatReply        = "chat_reply"
+	RequestTypeConsentResult    = "consent_result"
+	RequestTypeDiscoveryResult  = "discovery_result"
+	RequestTypeAgentlessResult  = "agentless_result"
+	RequestTypeIndustrialResult = "industrial_result"
+	RequestTypeEdgeAlertEvents  = "edge_alert_events"
 
 	// RequestTypePing request types understood on both sides, client and server
 	RequestTypePing = "ping"
 )
 
+// ChannelMeshTunnel is the channel type opened by clients to relay a mesh tunnel connection through the server.
+// The extra data of the channel is the mesh tunnel id.
+const ChannelMeshTunnel = "mesh_tunnel"
+
+// ChannelReverseRemote is the channel type opened by clients to reach a server-side service of a reverse remote.
+// The extra data of the channel is the "host:port" of the service as configured in the client group.
+const ChannelReverseRemote = "reverse_remote"
+
+// ChannelScreenshot is the channel type opened by clients to send a screenshot taken on request of the server.
+// The extra data of the channel is the ScreenshotResult, the image is streamed on the channel.
+const ChannelScreenshot = "screenshot"
+
+// ChannelFileBlocks is the channel type opened by clients to fetch parts of a pushed file. The extra data of the
+// channel is the id of the file push. The client writes a FileBlocksRequest and the server streams the ranges.
+const ChannelFileBlocks = "file_blocks"
+
+// ChannelSerialConsole is the channel type opened by clients to attach a serial port opened on request of the server
+// to a console session. The extra data of the channel is the id of the OpenSerialConsoleRequest.
+const ChannelSerialConsole = "serial_console"
+
+// FileBlocksCompressionDeflate compresses the ranges of a FileBlocksRequest as a single deflate stream
+const FileBlocksCompressionDeflate = "deflate"
+
 type CheckPortRequest struct {
 	HostPort string
 	Timeout  time.Duration
@@ -48,6 +94,8 @@ type CheckPortResponse struct {
 type RunCmdResponse struct {
 	Pid       int
 	StartedAt time.Time
+	// Duplicate is set if the job was received before, it's not run again
+	Duplicate bool
 }
 
 type CheckTunnelAllowedRequest struct {
@@ -57,3 +105,291 @@ type CheckTunnelAllowedRequest struct {
 type CheckTunnelAllowedResponse struct {
 	IsAllowed bool
 }
+
+type TakeScreenshotRequest struct {
+	ID string
+	// ConsentID is the id of the consent granted by the user, if the client requires consent for screenshots
+	ConsentID string
+}
+
+type FileBlocksRequest struct {
+	Ranges []files.Range
+	// Compression is empty for the plain ranges
+	Compression string
+}
+
+// FileChangeRequest activates, confirms or rolls back a file staged by a two-phase file push.
+type FileChangeRequest struct {
+	ID string
+	// RollbackAfterSec is the time the client waits for the confirmation after the activation before it restores the
+	// previous version on its own, so clients that lost the connection because of the change roll back as well
+	RollbackAfterSec int
+}
+
+type ScreenshotResult struct {
+	ID string
+	// ContentType of the image, empty if Error is set
+	ContentType string
+	Error       string
+}
+
+// ChatMessage is a message of an operator shown to the user of the client machine.
+type ChatMessage struct {
+	ID        int64
+	SessionID string
+	From      string
+	Text      string
+}
+
+// ChatReply is the answer of the user of the client machine to a ChatMessage.
+type ChatReply struct {
+	ReplyTo   int64
+	SessionID string
+	Text      string
+}
+
+// DiscoveryRequest starts a scan of the local networks of the client. Empty subnets and ports scan all the client
+// allows.
+type DiscoveryRequest struct {
+	ID      string
+	Subnets []string
+	Ports   []int
+}
+
+// DiscoveryResult is sent by the client when a scan started by a DiscoveryRequest is finished.
+type DiscoveryResult struct {
+	ID         string
+	Devices    []DiscoveredDevice
+	Error      string
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+const (
+	AgentlessProtocolSSH   = "ssh"
+	AgentlessProtocolWinRM = "winrm"
+)
+
+// AgentlessRequest lets the client, acting as jump host, run a command on a target without an rport client. The
+// result is sent as separate request when the command is finished.
+type AgentlessRequest struct {
+	ID       string
+	Protocol string
+	// Host is a hostname or an ip, the client must allow all its addresses
+	Host     string
+	Port     int
+	Username string
+	// Password or PrivateKey in PEM format authenticates the user, winrm requires a password
+	Password   string
+	PrivateKey string
+	// HostKey is the SHA256 fingerprint the host key of the target must match. If empty, the target is only probed
+	// for its key, the connection is closed before authenticating. For winrm it's the fingerprint of the TLS
+	// certificate.
+	HostKey string
+	// Command is a PowerShell script for winrm
+	Command string
+	Timeout time.Duration
+}
+
+type AgentlessResult struct {
+	ID string
+	// HostKey is the SHA256 fingerprint of the host key of the target, empty if the connection failed before
+	HostKey  string
+	Stdout   string
+	Stderr   string
+	ExitCode int
+	// Error is set if the command couldn't be run, e.g. the target is unreachable or refused the credentials
+	Error string
+}
+
+const (
+	SerialParityNone = "none"
+	SerialParityOdd  = "odd"
+	SerialParityEven = "even"
+)
+
+// SerialLine holds the line settings of a serial port.
+type SerialLine struct {
+	BaudRate int
+	DataBits int
+	Parity   string
+	StopBits int
+}
+
+// OpenSerialConsoleRequest lets the client open a local serial port. The client then opens a ChannelSerialConsole
+// with the id and relays the bytes between the port and the channel until one of them is closed.
+type OpenSerialConsoleRequest struct {
+	ID string
+	// Port is the device, e.g. /dev/ttyUSB0 or COM3, it must be allowed by the config of the client
+	Port string
+	SerialLine
+}
+
+const (
+	IndustrialProtocolModbus = "modbus"
+	IndustrialProtocolOPCUA  = "opcua"
+)
+
+// Modbus register types
+const (
+	ModbusCoil          = "coil"
+	ModbusDiscreteInput = "discrete_input"
+	ModbusHoldingReg    = "holding_register"
+	ModbusInputReg      = "input_register"
+)
+
+// Modbus value types, the 32 bit ones span two registers
+const (
+	ModbusBool    = "bool"
+	ModbusInt16   = "int16"
+	ModbusUint16  = "uint16"
+	ModbusInt32   = "int32"
+	ModbusUint32  = "uint32"
+	ModbusFloat32 = "float32"
+)
+
+// IndustrialPoint is a value read from an industrial endpoint, either a Modbus register or an OPC UA node.
+type IndustrialPoint struct {
+	Name string
+	// Register, Address, Type and WordSwap locate a Modbus value. WordSwap reads 32 bit values low word first.
+	Register string
+	Address  uint16
+	Type     string
+	WordSwap bool
+	// NodeID locates an OPC UA value, e.g. ns=2;s=Boiler.Temperature
+	NodeID string
+}
+
+// IndustrialPollRequest lets the client read the points of a Modbus or OPC UA endpoint in its network. The result is
+// sent as separate request when the points are read.
+type IndustrialPollRequest struct {
+	ID       string
+	Protocol string
+	// Address is host:port for modbus and the endpoint url for opcua, the client must allow all addresses of the host
+	Address string
+	UnitID  byte
+	Points  []IndustrialPoint
+	Timeout time.Duration
+}
+
+type IndustrialPollResult struct {
+	ID string
+	// Values are the raw values by point name, booleans are 0 or 1
+	Values map[string]float64
+	// Errors are the points that couldn't be read, e.g. the node doesn't exist
+	Errors map[string]string
+	// Error is set if the endpoint couldn't be polled at all, e.g. it's unreachable
+	Error string
+}
+
+// Events of edge rules
+const (
+	EdgeEventFiring     = "firing"
+	EdgeEventResolved   = "resolved"
+	EdgeEventRemediated = "remediated"
+)
+
+// EdgeRule is an alerting rule evaluated by the client itself, so it fires and runs its remediation script even while
+// the server is unreachable.
+type EdgeRule struct {
+	ID string
+	// Expr is parsed by edgerules.ParseExpr
+	Expr string
+	// For is how long the condition must hold before the rule fires
+	For time.Duration
+	// Script is run once when the rule starts firing, empty for none
+	Script      string
+	Interpreter string
+	Timeout     time.Duration
+}
+
+// EdgeAlertEvent is a change of the state of an edge rule on a client. Events are kept by the client until the
+// server confirmed them, Age is the time passed since the event so the server dates it back independently of the
+// client's clock.
+type EdgeAlertEvent struct {
+	// ProblemID is created by the client when the rule starts firing and repeated by the following events
+	ProblemID string
+	RuleID    string
+	Event     string
+	// Value is the value of the metric that made the rule fire or resolve
+	Value float64
+	// Details are the exit code and output of the remediation script
+	Details string
+	Age     time.Duration
+}
+
+type DiscoveredDevice struct {
+	IP string
+	// MAC is empty if the device is not in the ARP table of the client
+	MAC       string
+	Hostname  string
+	OpenPorts []int
+}
+
+const (
+	ConsentSubjectScreenshot = "screenshot"
+	ConsentSubjectSession    = "session"
+)
+
+// AskConsentRequest asks the local user of the client machine to allow a screenshot or an interactive session.
+type AskConsentRequest struct {
+	ID        string
+	Subject   string
+	Requester string
+	// Description is shown to the user, e.g. the remote desktop tunnel about to be started
+	Description string
+}
+
+// AskConsentResponse tells if the client asks the user, the ConsentResult is then sent as separate request within
+// Timeout. Otherwise no consent is required.
+type AskConsentResponse struct {
+	Required bool
+	Timeout  time.Duration
+}
+
+type ConsentResult struct {
+	ID      string
+	Granted bool
+	// Reason is set if the consent is denied or granted without an answer of the user
+	Reason string
+}
+
+type StartMeshTunnelRequest struct {
+	ID    string
+	Local string
+	// DirectAddr is set if the client should connect to the target directly instead of relaying through the server.
+	DirectAddr string
+}
+
+type StopMeshTunnelRequest struct {
+	ID string
+}
+
+// SerialBaudRates are the baud rates supported on all platforms.
+var SerialBaudRates = []int{300, 600, 1200, 2400, 4800, 9600, 19200, 38400, 57600, 115200, 230400, 460800, 921600}
+
+// Validate checks that the line settings are supported by the clients.
+func (l SerialLine) Validate() error {
+	supported := false
+	for _, rate := range SerialBaudRates {
+		if l.BaudRate == rate {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return fmt.Errorf("unsupported baud rate %d, expected one of %v", l.BaudRate, SerialBaudRates)
+	}
+	if l.DataBits < 5 || l.DataBits > 8 {
+		return fmt.Errorf("invalid data bits %d, expected 5 to 8", l.DataBits)
+	}
+	switch l.Parity {
+	case SerialParityNone, SerialParityOdd, SerialParityEven:
+	default:
+		return fmt.Errorf("invalid parity %q, expected %s, %s or %s", l.Parity, SerialParityNone, SerialParityOdd, SerialParityEven)
+	}
+	if l.StopBits != 1 && l.StopBits != 2 {
+		return fmt.Errorf("invalid stop bits %d, expected 1 or 2", l.StopBits)
+	}
+	return nil
+}
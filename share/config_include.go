@@ -0,0 +1,213 @@
+package chshare
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// IncludeKey is the top-level config option listing further config files, e.g.
+//
+//	include = ["/etc/rport/secrets.conf", "conf.d/*.conf"]
+//
+// Relative paths are resolved against the directory of the including file, glob patterns are expanded in
+// lexical order. Included files are merged after the including file, so their values take precedence.
+const IncludeKey = "include"
+
+const maxIncludeDepth = 8
+
+// envRefRegex matches ${NAME} and ${NAME:-default}, and $${ which escapes a literal ${.
+var envRefRegex = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// expandEnvRefs replaces the environment variable references in s. A reference to an unset variable without a default
+// is an error, so a missing secret doesn't silently end up as an empty value.
+func expandEnvRefs(s string) (string, error) {
+	var err error
+	result := envRefRegex.ReplaceAllStringFunc(s, func(ref string) string {
+		if ref == "$${" {
+			return "${"
+		}
+		m := envRefRegex.FindStringSubmatch(ref)
+		if val, ok := os.LookupEnv(m[1]); ok {
+			return val
+		}
+		if m[2] != "" {
+			return m[3]
+		}
+		if err == nil {
+			err = fmt.Errorf("environment variable %q is not set", m[1])
+		}
+		return ""
+	})
+	return result, err
+}
+
+// interpolateEnv expands environment variable references in all string values. Values are expanded after parsing,
+// so the content of a variable never changes the structure of the config.
+func interpolateEnv(val interface{}, key string) (interface{}, error) {
+	switch typed := val.(type) {
+	case string:
+		expanded, err := expandEnvRefs(typed)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		return expanded, nil
+	case map[string]interface{}:
+		for k, v := range typed {
+			subKey := k
+			if key != "" {
+				subKey = key + "." + k
+			}
+			expanded, err := interpolateEnv(v, subKey)
+			if err != nil {
+				return nil, err
+			}
+			typed[k] = expanded
+		}
+		return typed, nil
+	case []interface{}:
+		for i, v := range typed {
+			expanded, err := interpolateEnv(v, fmt.Sprintf("%s[%d]", key, i))
+			if err != nil {
+				return nil, err
+			}
+			typed[i] = expanded
+		}
+		return typed, nil
+	case []map[string]interface{}:
+		for i, v := range typed {
+			if _, err := interpolateEnv(v, fmt.Sprintf("%s[%d]", key, i)); err != nil {
+				return nil, err
+			}
+		}
+		return typed, nil
+	}
+	return val, nil
+}
+
+type configLoader struct {
+	// stack holds the files currently being loaded to detect include cycles
+	stack []string
+	// settings of all files in the order they are merged
+	settings []map[string]interface{}
+}
+
+// load parses the content of a config file, expands environment variables and loads the included files. source is
+// used in error messages, baseDir to resolve relative includes.
+func (l *configLoader) load(content []byte, source, baseDir string) error {
+	parsed := viper.New()
+	parsed.SetConfigType("toml")
+	if err := parsed.ReadConfig(bytes.NewReader(content)); err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+	settings := parsed.AllSettings()
+	if _, err := interpolateEnv(settings, ""); err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+
+	includes, err := includePatterns(settings[IncludeKey])
+	if err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+	delete(settings, IncludeKey)
+	l.settings = append(l.settings, settings)
+
+	for _, pattern := range includes {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(baseDir, pattern)
+		}
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return fmt.Errorf("%s: invalid include %q: %w", source, pattern, err)
+		}
+		if len(files) == 0 && !hasGlobMeta(pattern) {
+			return fmt.Errorf("%s: included file %q does not exist", source, pattern)
+		}
+		sort.Strings(files)
+		for _, file := range files {
+			if err := l.loadFile(file); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (l *configLoader) loadFile(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	for _, f := range l.stack {
+		if f == abs {
+			return fmt.Errorf("include cycle: %s -> %s", strings.Join(l.stack, " -> "), abs)
+		}
+	}
+	if len(l.stack) > maxIncludeDepth {
+		return fmt.Errorf("%s: includes are nested deeper than %d levels", abs, maxIncludeDepth)
+	}
+
+	content, err := os.ReadFile(abs)
+	if err != nil {
+		return err
+	}
+	l.stack = append(l.stack, abs)
+	defer func() { l.stack = l.stack[:len(l.stack)-1] }()
+	return l.load(content, abs, filepath.Dir(abs))
+}
+
+func includePatterns(val interface{}) ([]string, error) {
+	switch typed := val.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{typed}, nil
+	case []interface{}:
+		patterns := make([]string, 0, len(typed))
+		for _, v := range typed {
+			s, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s must be a list of file paths", IncludeKey)
+			}
+			patterns = append(patterns, s)
+		}
+		return patterns, nil
+	}
+	return nil, fmt.Errorf("%s must be a list of file paths", IncludeKey)
+}
+
+func hasGlobMeta(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// mergeConfig merges the given content of a config file into v, with the included files and environment variables
+// expanded. path is empty if the content is not read from a file, relative includes are resolved against the working
+// directory then.
+func mergeConfig(v *viper.Viper, content []byte, path string) error {
+	l := &configLoader{}
+	source, baseDir := "config", ""
+	if path != "" {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		l.stack = []string{abs}
+		source, baseDir = abs, filepath.Dir(abs)
+	}
+	if err := l.load(content, source, baseDir); err != nil {
+		return err
+	}
+
+	for _, settings := range l.settings {
+		if err := v.MergeConfigMap(settings); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package chshare
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandEnvRefs(t *testing.T) {
+	t.Setenv("TEST_RPORT_SECRET", "s3cr\"et")
+	t.Setenv("TEST_RPORT_EMPTY", "")
+
+	testCases := []struct {
+		in      string
+		want    string
+		wantErr string
+	}{
+		{in: "plain", want: "plain"},
+		{in: "${TEST_RPORT_SECRET}", want: "s3cr\"et"},
+		{in: "pre-${TEST_RPORT_SECRET}-post", want: "pre-s3cr\"et-post"},
+		{in: "${TEST_RPORT_EMPTY:-default}", want: ""},
+		{in: "${TEST_RPORT_UNSET:-default}", want: "default"},
+		{in: "${TEST_RPORT_UNSET:-}", want: ""},
+		{in: "$${TEST_RPORT_SECRET}", want: "${TEST_RPORT_SECRET}"},
+		{in: "$2y$05$hash", want: "$2y$05$hash"},
+		{in: "${TEST_RPORT_UNSET}", wantErr: `environment variable "TEST_RPORT_UNSET" is not set`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := expandEnvRefs(tc.in)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func writeConfigFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+}
+
+func decodeConfigFile(t *testing.T, path string) (*testEnvConfig, error) {
+	v := viper.New()
+	v.SetConfigType("toml")
+	v.SetConfigFile(path)
+	cfg := &testEnvConfig{}
+	return cfg, DecodeViperConfig(v, cfg, nil)
+}
+
+func TestDecodeViperConfigIncludes(t *testing.T) {
+	t.Setenv("TEST_RPORT_SECRET", "from-env")
+	dir := t.TempDir()
+	main := filepath.Join(dir, "rportd.conf")
+	writeConfigFile(t, main, `
+include = ["conf.d/*.conf", "/nonexistent/*.conf"]
+
+[server]
+  data_dir = "/var/lib/rport"
+  secret = "main"
+`)
+	writeConfigFile(t, filepath.Join(dir, "conf.d", "10-secrets.conf"), `
+include = "../ports.conf"
+
+[server]
+  secret = "${TEST_RPORT_SECRET}"
+`)
+	writeConfigFile(t, filepath.Join(dir, "ports.conf"), `
+[server]
+  used_ports = ["${TEST_RPORT_PORTS:-20000-30000}"]
+`)
+	writeConfigFile(t, filepath.Join(dir, "conf.d", "20-tunnel.conf"), `
+[server]
+  tunnel_host = "example.com"
+`)
+
+	cfg, err := decodeConfigFile(t, main)
+	require.NoError(t, err)
+
+	assert.Equal(t, "/var/lib/rport", cfg.Server.DataDir)
+	assert.Equal(t, "from-env", cfg.Server.Secret)
+	assert.Equal(t, []string{"20000-30000"}, cfg.Server.UsedPorts)
+	assert.Equal(t, "example.com", cfg.Server.Tunnel.Host)
+}
+
+func TestDecodeViperConfigEnvVarsOverrideIncludes(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "rportd.conf")
+	writeConfigFile(t, main, `
+include = ["secrets.conf"]
+`)
+	writeConfigFile(t, filepath.Join(dir, "secrets.conf"), `
+[server]
+  secret = "included"
+`)
+	t.Setenv("TEST_RPORTD_SERVER_SECRET", "env")
+
+	v := viper.New()
+	v.SetConfigType("toml")
+	v.SetConfigFile(main)
+	cfg := &testEnvConfig{}
+	require.NoError(t, BindEnvVars(v, "TEST_RPORTD", cfg))
+	require.NoError(t, DecodeViperConfig(v, cfg, nil))
+
+	assert.Equal(t, "env", cfg.Server.Secret)
+}
+
+func TestDecodeViperConfigIncludeErrors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name: "missing file",
+			files: map[string]string{
+				"rportd.conf": `include = ["missing.conf"]`,
+			},
+			wantErr: "missing.conf\" does not exist",
+		},
+		{
+			name: "cycle",
+			files: map[string]string{
+				"rportd.conf": `include = ["a.conf"]`,
+				"a.conf":      `include = ["rportd.conf"]`,
+			},
+			wantErr: "include cycle",
+		},
+		{
+			name: "invalid include",
+			files: map[string]string{
+				"rportd.conf": `include = 1`,
+			},
+			wantErr: "include must be a list of file paths",
+		},
+		{
+			name: "unset env var",
+			files: map[string]string{
+				"rportd.conf": `include = ["a.conf"]`,
+				"a.conf": `[server]
+  secret = "${TEST_RPORT_UNSET}"`,
+			},
+			wantErr: `a.conf: server.secret: environment variable "TEST_RPORT_UNSET" is not set`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tc.files {
+				writeConfigFile(t, filepath.Join(dir, name), content)
+			}
+
+			_, err := decodeConfigFile(t, filepath.Join(dir, "rportd.conf"))
+			require.Error(t, err)
+			assert.True(t, strings.Contains(err.Error(), tc.wantErr), err.Error())
+		})
+	}
+}
+
+func TestDecodeViperConfigReaderEnvRefs(t *testing.T) {
+	t.Setenv("TEST_RPORT_SECRET", "from-env")
+	v := viper.New()
+	v.SetConfigType("toml")
+	cfg := &testEnvConfig{}
+
+	err := DecodeViperConfig(v, cfg, strings.NewReader(`
+[server]
+  secret = "${TEST_RPORT_SECRET}"
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "from-env", cfg.Server.Secret)
+}
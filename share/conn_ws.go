@@ -65,3 +65,20 @@ func (c *wsConn) SetDeadline(t time.Time) error {
 	}
 	return c.Conn.SetWriteDeadline(t)
 }
+
+type remoteAddrConn struct {
+	net.Conn
+	remoteAddr net.Addr
+}
+
+// WithRemoteAddr returns the conn with another remote address, e.g. the address forwarded by a trusted proxy.
+func WithRemoteAddr(conn net.Conn, remoteAddr net.Addr) net.Conn {
+	return &remoteAddrConn{
+		Conn:       conn,
+		remoteAddr: remoteAddr,
+	}
+}
+
+func (c *remoteAddrConn) RemoteAddr() net.Addr {
+	return c.remoteAddr
+}
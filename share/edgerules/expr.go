@@ -0,0 +1,98 @@
+package edgerules
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Metrics measured by the clients for edge rules
+const (
+	MetricCPUUsagePercent    = "cpu_usage_percent"
+	MetricMemoryUsagePercent = "memory_usage_percent"
+	// MetricDiskUsedPercent takes the path of a mountpoint, e.g. disk_used_percent(/var)
+	MetricDiskUsedPercent = "disk_used_percent"
+	// MetricProcessCount takes the name of a process, e.g. process_count(nginx)
+	MetricProcessCount = "process_count"
+)
+
+const maxArgLength = 255
+
+var (
+	exprRegexp = regexp.MustCompile(`^\s*([a-z_]+)\s*(?:\(\s*([^()]*?)\s*\))?\s*(>=|<=|==|!=|>|<)\s*(-?[0-9]+(?:\.[0-9]+)?)\s*$`)
+
+	plainMetrics = []string{MetricCPUUsagePercent, MetricMemoryUsagePercent}
+	argMetrics   = []string{MetricDiskUsedPercent, MetricProcessCount}
+)
+
+// Expr is a condition on a local metric of a client, e.g. `disk_used_percent(/var) > 95` or
+// `process_count(nginx) < 1`.
+type Expr struct {
+	Metric    string
+	Arg       string
+	Operator  string
+	Threshold float64
+}
+
+func ParseExpr(expr string) (Expr, error) {
+	m := exprRegexp.FindStringSubmatch(expr)
+	if m == nil {
+		return Expr{}, fmt.Errorf("invalid expression %q, expected `<metric> <operator> <number>`", expr)
+	}
+	e := Expr{Metric: m[1], Arg: m[2], Operator: m[3]}
+
+	switch {
+	case contains(plainMetrics, e.Metric):
+		if e.Arg != "" {
+			return Expr{}, fmt.Errorf("metric %q doesn't take an argument", e.Metric)
+		}
+	case contains(argMetrics, e.Metric):
+		if e.Arg == "" || len(e.Arg) > maxArgLength {
+			return Expr{}, fmt.Errorf("metric %q requires an argument, max size is %d", e.Metric, maxArgLength)
+		}
+	default:
+		return Expr{}, fmt.Errorf("invalid metric %q, expected one of %s", e.Metric,
+			strings.Join(append(append([]string{}, plainMetrics...), argMetrics...), ", "))
+	}
+
+	// the regexp only matches valid numbers
+	e.Threshold, _ = strconv.ParseFloat(m[4], 64)
+	return e, nil
+}
+
+// Matches returns true if the value fulfills the condition.
+func (e Expr) Matches(value float64) bool {
+	switch e.Operator {
+	case ">":
+		return value > e.Threshold
+	case ">=":
+		return value >= e.Threshold
+	case "<":
+		return value < e.Threshold
+	case "<=":
+		return value <= e.Threshold
+	case "==":
+		return value == e.Threshold
+	case "!=":
+		return value != e.Threshold
+	}
+	return false
+}
+
+func (e Expr) String() string {
+	metric := e.Metric
+	if e.Arg != "" {
+		metric += "(" + e.Arg + ")"
+	}
+	return fmt.Sprintf("%s %s %s", metric, e.Operator, strconv.FormatFloat(e.Threshold, 'f', -1, 64))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
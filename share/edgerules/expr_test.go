@@ -0,0 +1,68 @@
+package edgerules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExpr(t *testing.T) {
+	testCases := []struct {
+		expr    string
+		want    Expr
+		wantErr string
+	}{
+		{
+			expr: "cpu_usage_percent > 90",
+			want: Expr{Metric: MetricCPUUsagePercent, Operator: ">", Threshold: 90},
+		},
+		{
+			expr: " disk_used_percent( /var/lib ) >= 95.5 ",
+			want: Expr{Metric: MetricDiskUsedPercent, Arg: "/var/lib", Operator: ">=", Threshold: 95.5},
+		},
+		{
+			expr: `disk_used_percent(C:\) > 90`,
+			want: Expr{Metric: MetricDiskUsedPercent, Arg: `C:\`, Operator: ">", Threshold: 90},
+		},
+		{
+			expr: "process_count(nginx) < 1",
+			want: Expr{Metric: MetricProcessCount, Arg: "nginx", Operator: "<", Threshold: 1},
+		},
+		{
+			expr:    "memory_usage_percent(/) > 1",
+			wantErr: `metric "memory_usage_percent" doesn't take an argument`,
+		},
+		{
+			expr:    "process_count() < 1",
+			wantErr: `metric "process_count" requires an argument, max size is 255`,
+		},
+		{
+			expr:    "io_usage_percent > 1",
+			wantErr: `invalid metric "io_usage_percent", expected one of cpu_usage_percent, memory_usage_percent, disk_used_percent, process_count`,
+		},
+		{
+			expr:    "process_count(nginx) <",
+			wantErr: "invalid expression \"process_count(nginx) <\", expected `<metric> <operator> <number>`",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expr, func(t *testing.T) {
+			got, err := ParseExpr(tc.expr)
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestExprMatches(t *testing.T) {
+	e, err := ParseExpr("process_count(nginx) < 1")
+	require.NoError(t, err)
+	assert.True(t, e.Matches(0))
+	assert.False(t, e.Matches(1))
+	assert.Equal(t, "process_count(nginx) < 1", e.String())
+}
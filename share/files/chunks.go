@@ -0,0 +1,59 @@
+package files
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+)
+
+// ChunkHasher is a writer computing the sha256 sums of consecutive chunks of a fixed size, the last chunk might be
+// shorter.
+type ChunkHasher struct {
+	size    int64
+	cur     hash.Hash
+	written int64
+	sums    []string
+}
+
+func NewChunkHasher(size int64) *ChunkHasher {
+	return &ChunkHasher{
+		size: size,
+	}
+}
+
+func (c *ChunkHasher) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		if c.cur == nil {
+			c.cur = sha256.New()
+		}
+		take := c.size - c.written
+		if int64(len(p)) < take {
+			take = int64(len(p))
+		}
+		c.cur.Write(p[:take])
+		c.written += take
+		p = p[take:]
+		if c.written == c.size {
+			c.finishChunk()
+		}
+	}
+	return n, nil
+}
+
+// Sums returns the sums of the chunks written so far, including the started chunk.
+func (c *ChunkHasher) Sums() []string {
+	if c.cur != nil {
+		c.finishChunk()
+	}
+	if c.sums == nil {
+		return []string{}
+	}
+	return c.sums
+}
+
+func (c *ChunkHasher) finishChunk() {
+	c.sums = append(c.sums, hex.EncodeToString(c.cur.Sum(nil)))
+	c.cur = nil
+	c.written = 0
+}
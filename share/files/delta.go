@@ -0,0 +1,303 @@
+package files
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	signatureMagic     = "RPDS"
+	minDeltaBlockSize  = 2 * 1024
+	maxDeltaBlockSize  = 64 * 1024
+	maxSignatureBlocks = 1 << 24
+)
+
+// Signature holds the rolling and strong checksums of the blocks of a file. A receiver with an older version of the
+// file finds the blocks it already has at any offset of its version and only fetches the others, like rsync does.
+type Signature struct {
+	BlockSize int
+	Size      int64
+	Blocks    []BlockSum
+}
+
+type BlockSum struct {
+	Weak   uint32
+	Strong [md5.Size]byte
+}
+
+// Range is a part of a file.
+type Range struct {
+	Offset int64 `json:"offset"`
+	Length int64 `json:"length"`
+}
+
+// DeltaBlockSize returns the block size for a file of the size, about its square root so the signature and the
+// chance of a match stay balanced.
+func DeltaBlockSize(size int64) int {
+	bs := int(math.Sqrt(float64(size)))
+	bs = (bs + 1023) / 1024 * 1024
+	if bs < minDeltaBlockSize {
+		return minDeltaBlockSize
+	}
+	if bs > maxDeltaBlockSize {
+		return maxDeltaBlockSize
+	}
+	return bs
+}
+
+// NewSignature computes the signature of the content.
+func NewSignature(r io.Reader, blockSize int) (*Signature, error) {
+	sig := &Signature{BlockSize: blockSize}
+	buf := make([]byte, blockSize)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			sig.Blocks = append(sig.Blocks, BlockSum{Weak: newRollsum(buf[:n]).sum(), Strong: md5.Sum(buf[:n])})
+			sig.Size += int64(n)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return sig, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// WriteTo writes the binary encoding of the signature.
+func (s *Signature) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	header := make([]byte, 0, 20)
+	header = append(header, signatureMagic...)
+	header = binary.BigEndian.AppendUint32(header, uint32(s.BlockSize))
+	header = binary.BigEndian.AppendUint64(header, uint64(s.Size))
+	header = binary.BigEndian.AppendUint32(header, uint32(len(s.Blocks)))
+	if _, err := bw.Write(header); err != nil {
+		return 0, err
+	}
+	record := make([]byte, 4+md5.Size)
+	for _, b := range s.Blocks {
+		binary.BigEndian.PutUint32(record, b.Weak)
+		copy(record[4:], b.Strong[:])
+		if _, err := bw.Write(record); err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(header) + len(s.Blocks)*len(record)), bw.Flush()
+}
+
+// ReadSignature reads a signature written by WriteTo.
+func ReadSignature(r io.Reader) (*Signature, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, 20)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("failed to read signature: %w", err)
+	}
+	if string(header[:4]) != signatureMagic {
+		return nil, errors.New("invalid signature")
+	}
+	sig := &Signature{
+		BlockSize: int(binary.BigEndian.Uint32(header[4:])),
+		Size:      int64(binary.BigEndian.Uint64(header[8:])),
+	}
+	count := int64(binary.BigEndian.Uint32(header[16:]))
+	if sig.BlockSize <= 0 || sig.BlockSize > maxDeltaBlockSize || sig.Size < 0 || count > maxSignatureBlocks ||
+		count != (sig.Size+int64(sig.BlockSize)-1)/int64(sig.BlockSize) {
+		return nil, errors.New("invalid signature")
+	}
+
+	sig.Blocks = make([]BlockSum, count)
+	record := make([]byte, 4+md5.Size)
+	for i := range sig.Blocks {
+		if _, err := io.ReadFull(br, record); err != nil {
+			return nil, fmt.Errorf("failed to read signature: %w", err)
+		}
+		sig.Blocks[i].Weak = binary.BigEndian.Uint32(record)
+		copy(sig.Blocks[i].Strong[:], record[4:])
+	}
+	return sig, nil
+}
+
+// BlockLength returns the length of the block, the last block might be shorter.
+func (s *Signature) BlockLength(i int) int64 {
+	n := s.Size - int64(i)*int64(s.BlockSize)
+	if n > int64(s.BlockSize) {
+		return int64(s.BlockSize)
+	}
+	return n
+}
+
+// MatchBlocks slides over the local content and returns the offsets of the blocks of the signature found in it by
+// block index. The last block is only matched if it's a full block.
+func (s *Signature) MatchBlocks(local io.Reader) (map[int]int64, error) {
+	bs := s.BlockSize
+	index := make(map[uint32][]int)
+	for i, b := range s.Blocks {
+		if s.BlockLength(i) == int64(bs) {
+			index[b.Weak] = append(index[b.Weak], i)
+		}
+	}
+	matches := make(map[int]int64)
+	if len(index) == 0 {
+		return matches, nil
+	}
+
+	br := bufio.NewReaderSize(local, 64*1024)
+	buf := make([]byte, 0, 4*bs)
+	start := 0
+	var pos int64
+	// fill reads a full window starting at start, it returns false at the end of the content
+	fill := func() (bool, error) {
+		if cap(buf)-start < bs {
+			buf = buf[:copy(buf[:cap(buf)], buf[start:])]
+			start = 0
+		}
+		n, err := io.ReadFull(br, buf[len(buf):start+bs])
+		buf = buf[:len(buf)+n]
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	buf = buf[:0]
+	ok, err := fill()
+	if !ok {
+		return matches, err
+	}
+	rs := newRollsum(buf[start : start+bs])
+	for {
+		if candidates, found := index[rs.sum()]; found {
+			strong := md5.Sum(buf[start : start+bs])
+			matched := false
+			for _, i := range candidates {
+				if s.Blocks[i].Strong == strong {
+					if _, done := matches[i]; !done {
+						matches[i] = pos
+					}
+					matched = true
+				}
+			}
+			if matched {
+				start += bs
+				pos += int64(bs)
+				ok, err := fill()
+				if !ok {
+					return matches, err
+				}
+				rs = newRollsum(buf[start : start+bs])
+				continue
+			}
+		}
+
+		c, err := br.ReadByte()
+		if err == io.EOF {
+			return matches, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(buf) == cap(buf) {
+			buf = buf[:copy(buf, buf[start:])]
+			start = 0
+		}
+		out := buf[start]
+		buf = append(buf, c)
+		start++
+		pos++
+		rs.roll(out, c)
+	}
+}
+
+// MissingRanges returns the ranges of the blocks not matched, adjacent blocks are joined.
+func (s *Signature) MissingRanges(matches map[int]int64) []Range {
+	var ranges []Range
+	for i := range s.Blocks {
+		if _, ok := matches[i]; ok {
+			continue
+		}
+		offset := int64(i) * int64(s.BlockSize)
+		if n := len(ranges); n > 0 && ranges[n-1].Offset+ranges[n-1].Length == offset {
+			ranges[n-1].Length += s.BlockLength(i)
+			continue
+		}
+		ranges = append(ranges, Range{Offset: offset, Length: s.BlockLength(i)})
+	}
+	return ranges
+}
+
+type patchReader struct {
+	sig     *Signature
+	matches map[int]int64
+	local   io.ReaderAt
+	missing io.Reader
+	block   int
+	pending *bytes.Reader
+	buf     []byte
+}
+
+// NewPatchReader reads the content of the signature, the matched blocks from the local content and the others in
+// order from missing, the concatenation of the MissingRanges.
+func NewPatchReader(sig *Signature, matches map[int]int64, local io.ReaderAt, missing io.Reader) io.Reader {
+	return &patchReader{
+		sig:     sig,
+		matches: matches,
+		local:   local,
+		missing: missing,
+		buf:     make([]byte, sig.BlockSize),
+	}
+}
+
+func (p *patchReader) Read(b []byte) (int, error) {
+	for p.pending == nil || p.pending.Len() == 0 {
+		if p.block >= len(p.sig.Blocks) {
+			return 0, io.EOF
+		}
+		n := p.sig.BlockLength(p.block)
+		var err error
+		if offset, ok := p.matches[p.block]; ok {
+			_, err = p.local.ReadAt(p.buf[:n], offset)
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+		} else {
+			_, err = io.ReadFull(p.missing, p.buf[:n])
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read block %d: %w", p.block, err)
+		}
+		p.pending = bytes.NewReader(p.buf[:n])
+		p.block++
+	}
+	return p.pending.Read(b)
+}
+
+// rollsum is the rolling checksum of rsync, it's updated in constant time when the window moves by one byte.
+type rollsum struct {
+	a, b uint32
+	n    uint32
+}
+
+func newRollsum(window []byte) *rollsum {
+	r := &rollsum{n: uint32(len(window))}
+	for i, c := range window {
+		r.a += uint32(c)
+		r.b += uint32(len(window)-i) * uint32(c)
+	}
+	return r
+}
+
+func (r *rollsum) roll(out, in byte) {
+	r.a += uint32(in) - uint32(out)
+	r.b += r.a - r.n*uint32(out)
+}
+
+func (r *rollsum) sum() uint32 {
+	return r.a&0xffff | r.b<<16
+}
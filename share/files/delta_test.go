@@ -0,0 +1,81 @@
+package files
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func patch(t *testing.T, old, updated []byte, blockSize int) ([]byte, []Range) {
+	sig, err := NewSignature(bytes.NewReader(updated), blockSize)
+	require.NoError(t, err)
+
+	encoded := &bytes.Buffer{}
+	_, err = sig.WriteTo(encoded)
+	require.NoError(t, err)
+	sig, err = ReadSignature(encoded)
+	require.NoError(t, err)
+
+	matches, err := sig.MatchBlocks(bytes.NewReader(old))
+	require.NoError(t, err)
+	ranges := sig.MissingRanges(matches)
+	missing := &bytes.Buffer{}
+	for _, r := range ranges {
+		missing.Write(updated[r.Offset : r.Offset+r.Length])
+	}
+
+	patched, err := io.ReadAll(NewPatchReader(sig, matches, bytes.NewReader(old), missing))
+	require.NoError(t, err)
+	return patched, ranges
+}
+
+func TestDeltaFindsShiftedBlocks(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	old := make([]byte, 100*1024)
+	rnd.Read(old)
+
+	// bytes inserted at the start and a block changed in the middle
+	updated := append([]byte("inserted"), old...)
+	copy(updated[50*1024:], bytes.Repeat([]byte("x"), 100))
+
+	patched, ranges := patch(t, old, updated, 2048)
+	assert.Equal(t, updated, patched)
+	var missing int64
+	for _, r := range ranges {
+		missing += r.Length
+	}
+	// the first block, up to two changed blocks and the short last block
+	assert.LessOrEqual(t, missing, int64(4*2048))
+}
+
+func TestDeltaWithoutLocalContent(t *testing.T) {
+	updated := bytes.Repeat([]byte("abc"), 1000)
+
+	patched, ranges := patch(t, nil, updated, 2048)
+	assert.Equal(t, updated, patched)
+	assert.Equal(t, []Range{{Offset: 0, Length: 3000}}, ranges)
+
+	patched, ranges = patch(t, updated, nil, 2048)
+	assert.Empty(t, patched)
+	assert.Empty(t, ranges)
+}
+
+func TestDeltaBlockSize(t *testing.T) {
+	assert.Equal(t, 2048, DeltaBlockSize(1000))
+	assert.Equal(t, 32768, DeltaBlockSize(1<<30))
+	assert.Equal(t, 65536, DeltaBlockSize(1<<40))
+}
+
+func TestReadSignatureRejectsInconsistentHeader(t *testing.T) {
+	sig := &Signature{BlockSize: 2048, Size: 5000, Blocks: make([]BlockSum, 2)}
+	encoded := &bytes.Buffer{}
+	_, err := sig.WriteTo(encoded)
+	require.NoError(t, err)
+
+	_, err = ReadSignature(encoded)
+	assert.EqualError(t, err, "invalid signature")
+}
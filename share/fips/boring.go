@@ -0,0 +1,15 @@
+//go:build boringcrypto
+// +build boringcrypto
+
+package fips
+
+import (
+	"crypto/boring"
+	// restricts all TLS connections of the process to the FIPS approved settings
+	_ "crypto/tls/fipsonly"
+)
+
+// BoringCrypto returns true if the go crypto primitives are replaced by the BoringCrypto module.
+func BoringCrypto() bool {
+	return boring.Enabled()
+}
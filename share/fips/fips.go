@@ -0,0 +1,137 @@
+// Package fips restricts TLS and SSH to the algorithms approved by FIPS 140-2.
+//
+// The mode is enabled either at runtime by the fips_mode setting or by building with GOEXPERIMENT=boringcrypto,
+// which additionally replaces the go crypto primitives by the FIPS validated BoringCrypto module.
+package fips
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const minRSABits = 2048
+
+var enabled atomic.Bool
+
+// KeyExchanges are the approved SSH key exchange algorithms.
+var KeyExchanges = []string{
+	"ecdh-sha2-nistp256",
+	"ecdh-sha2-nistp384",
+	"ecdh-sha2-nistp521",
+	"diffie-hellman-group14-sha256",
+}
+
+// Ciphers are the approved SSH ciphers.
+var Ciphers = []string{
+	"aes128-gcm@openssh.com",
+	"aes256-gcm@openssh.com",
+	"aes128-ctr",
+	"aes192-ctr",
+	"aes256-ctr",
+}
+
+// MACs are the approved SSH message authentication codes.
+var MACs = []string{
+	"hmac-sha2-256-etm@openssh.com",
+	"hmac-sha2-256",
+}
+
+// HostKeyAlgorithms are the approved SSH host key algorithms.
+var HostKeyAlgorithms = []string{
+	ssh.KeyAlgoECDSA256,
+	ssh.KeyAlgoECDSA384,
+	ssh.KeyAlgoECDSA521,
+	ssh.KeyAlgoRSASHA512,
+	ssh.KeyAlgoRSASHA256,
+}
+
+// CipherSuites are the approved TLS 1.2 cipher suites.
+var CipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+}
+
+// CurvePreferences are the approved elliptic curves for TLS.
+var CurvePreferences = []tls.CurveID{tls.CurveP384, tls.CurveP256, tls.CurveP521}
+
+// Enable switches on the FIPS mode for the whole process. It must be called on startup before any connection is made.
+func Enable() {
+	enabled.Store(true)
+}
+
+// Enabled returns true if the FIPS mode was enabled by the configuration or the binary was built with BoringCrypto.
+func Enabled() bool {
+	return enabled.Load() || BoringCrypto()
+}
+
+// ApplyTLSConfig restricts the TLS config to the approved versions, cipher suites and curves if the FIPS mode is
+// enabled. TLS 1.3 is disabled because the cipher suites of TLS 1.3 can't be restricted.
+func ApplyTLSConfig(c *tls.Config) *tls.Config {
+	if !Enabled() {
+		return c
+	}
+	c.MinVersion = tls.VersionTLS12
+	c.MaxVersion = tls.VersionTLS12
+	c.CipherSuites = CipherSuites
+	c.CurvePreferences = CurvePreferences
+	return c
+}
+
+// ApplySSHConfig restricts the SSH algorithms to the approved ones if the FIPS mode is enabled.
+func ApplySSHConfig(c *ssh.Config) {
+	if !Enabled() {
+		return
+	}
+	c.KeyExchanges = KeyExchanges
+	c.Ciphers = Ciphers
+	c.MACs = MACs
+}
+
+// ValidateSSHKey returns an error if the key isn't an ECDSA key on a NIST curve or an RSA key of at least 2048 bits.
+func ValidateSSHKey(key ssh.PublicKey) error {
+	cryptoKey, ok := key.(ssh.CryptoPublicKey)
+	if !ok {
+		return fmt.Errorf("ssh key of type %s is not allowed in fips mode", key.Type())
+	}
+	return validateKey(cryptoKey.CryptoPublicKey())
+}
+
+// ValidateCertificate returns an error if the key of the certificate isn't allowed in FIPS mode.
+func ValidateCertificate(cert tls.Certificate) error {
+	if len(cert.Certificate) == 0 {
+		return errors.New("certificate is empty")
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return err
+	}
+	return validateKey(leaf.PublicKey)
+}
+
+func validateKey(key interface{}) error {
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256(), elliptic.P384(), elliptic.P521():
+			return nil
+		}
+		return fmt.Errorf("ecdsa key on curve %s is not allowed in fips mode", k.Curve.Params().Name)
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minRSABits {
+			return fmt.Errorf("rsa key with %d bits is not allowed in fips mode, at least %d bits are required", k.N.BitLen(), minRSABits)
+		}
+		return nil
+	default:
+		return fmt.Errorf("key of type %T is not allowed in fips mode", key)
+	}
+}
@@ -0,0 +1,138 @@
+package fips
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestApplyTLSConfig(t *testing.T) {
+	enabled.Store(false)
+	defer enabled.Store(false)
+
+	c := ApplyTLSConfig(&tls.Config{MinVersion: tls.VersionTLS13})
+	if !BoringCrypto() {
+		assert.Equal(t, uint16(tls.VersionTLS13), c.MinVersion)
+		assert.Nil(t, c.CipherSuites)
+	}
+
+	Enable()
+	c = ApplyTLSConfig(&tls.Config{MinVersion: tls.VersionTLS13})
+	assert.Equal(t, uint16(tls.VersionTLS12), c.MinVersion)
+	assert.Equal(t, uint16(tls.VersionTLS12), c.MaxVersion)
+	assert.Equal(t, CipherSuites, c.CipherSuites)
+	assert.Equal(t, CurvePreferences, c.CurvePreferences)
+}
+
+func TestValidateSSHKey(t *testing.T) {
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name        string
+		key         interface{}
+		expectedErr string
+	}{
+		{name: "ecdsa", key: &ecdsaKey.PublicKey},
+		{name: "short rsa", key: &rsaKey.PublicKey, expectedErr: "rsa key with 1024 bits is not allowed in fips mode, at least 2048 bits are required"},
+		{name: "ed25519", key: edKey.Public(), expectedErr: "key of type ed25519.PublicKey is not allowed in fips mode"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sshKey, err := ssh.NewPublicKey(tc.key)
+			require.NoError(t, err)
+
+			err = ValidateSSHKey(sshKey)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateCertificate(t *testing.T) {
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	assert.NoError(t, ValidateCertificate(selfSignedCert(t, &ecdsaKey.PublicKey, ecdsaKey)))
+	assert.EqualError(t, ValidateCertificate(selfSignedCert(t, edPub, edKey)), "key of type ed25519.PublicKey is not allowed in fips mode")
+	assert.EqualError(t, ValidateCertificate(tls.Certificate{}), "certificate is empty")
+}
+
+func TestSSHHandshakeWithApprovedAlgorithms(t *testing.T) {
+	enabled.Store(true)
+	defer enabled.Store(false)
+
+	hostKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(hostKey)
+	require.NoError(t, err)
+
+	serverConfig := &ssh.ServerConfig{NoClientAuth: true}
+	ApplySSHConfig(&serverConfig.Config)
+	serverConfig.AddHostKey(signer)
+
+	clientConfig := &ssh.ClientConfig{
+		User:              "test",
+		HostKeyAlgorithms: HostKeyAlgorithms,
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			return ValidateSSHKey(key)
+		},
+	}
+	ApplySSHConfig(&clientConfig.Config)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverConn, err := l.Accept()
+		if err != nil {
+			serverErr <- err
+			return
+		}
+		defer serverConn.Close()
+		_, _, _, err = ssh.NewServerConn(serverConn, serverConfig)
+		serverErr <- err
+	}()
+
+	conn, err := ssh.Dial("tcp", l.Addr().String(), clientConfig)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, <-serverErr)
+}
+
+func selfSignedCert(t *testing.T, pub interface{}, priv interface{}) tls.Certificate {
+	t.Helper()
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "rport.example.com"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, pub, priv)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}}
+}
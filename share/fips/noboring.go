@@ -0,0 +1,9 @@
+//go:build !boringcrypto
+// +build !boringcrypto
+
+package fips
+
+// BoringCrypto returns true if the go crypto primitives are replaced by the BoringCrypto module.
+func BoringCrypto() bool {
+	return false
+}
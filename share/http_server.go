@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/IOTech17/neo-rport/share/logger"
+	"github.com/IOTech17/neo-rport/share/proxyproto"
 )
 
 const readHeaderTimeout = 5 * time.Second
@@ -23,6 +24,13 @@ func WithTLS(certFile string, keyFile string, tlsConfig *tls.Config) ServerOptio
 	}
 }
 
+// WithProxyProtocol reads the PROXY protocol header of connections from the trusted proxies.
+func WithProxyProtocol(trusted []*net.IPNet) ServerOption {
+	return func(s *HTTPServer) {
+		s.proxyProtocolTrusted = trusted
+	}
+}
+
 // HTTPServer extends net/http Server and
 // adds graceful shutdowns
 type HTTPServer struct {
@@ -34,6 +42,8 @@ type HTTPServer struct {
 	certFile  string
 	keyFile   string
 	logger    *logger.Logger
+
+	proxyProtocolTrusted []*net.IPNet
 }
 
 // NewHTTPServer creates a new HTTPServer
@@ -64,6 +74,9 @@ func (h *HTTPServer) GoListenAndServe(ctx context.Context, addr string, handler
 	if err != nil {
 		return err
 	}
+	if len(h.proxyProtocolTrusted) > 0 {
+		l = proxyproto.NewListener(l, h.proxyProtocolTrusted)
+	}
 	h.isRunning = true
 	h.ctx = ctx
 	h.Handler = handler
@@ -1,6 +1,7 @@
 package chshare
 
 import (
+	"fmt"
 	"net"
 	"net/http"
 	"strings"
@@ -65,3 +66,38 @@ func isPrivate(ip net.IP) bool {
 	//   The IANA has assigned the FC00::/7 prefix to "Unique Local Unicast".
 	return len(ip) == net.IPv6len && ip[0]&0xfe == 0xfc
 }
+
+// ParseTrustedProxies parses IP addresses and CIDR ranges of proxies.
+func ParseTrustedProxies(values []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(values))
+	for _, v := range values {
+		if !strings.Contains(v, "/") {
+			ip := net.ParseIP(v)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address %q", v)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IP range %q", v)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// IsTrustedProxy tells if the ip is in one of the ranges.
+func IsTrustedProxy(trusted []*net.IPNet, ip net.IP) bool {
+	for _, n := range trusted {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// AgentFootprint is the resource usage of the rport client process itself, commands and scripts it runs are not
+// included.
+type AgentFootprint struct {
+	Timestamp      time.Time `json:"timestamp"`
+	CPUPercent     float64   `json:"cpu_percent"`
+	MemoryRSSBytes uint64    `json:"memory_rss_bytes"`
+	Goroutines     int       `json:"goroutines"`
+	// OpenFiles is 0 if the OS doesn't tell
+	OpenFiles int `json:"open_files,omitempty"`
+	// MonitoringBackoff is the factor the monitoring interval is stretched by to stay within the cpu budget
+	MonitoringBackoff int `json:"monitoring_backoff,omitempty"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+var (
+	artifactNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]{0,99}$`)
+	sha256Regexp       = regexp.MustCompile(`^[a-f0-9]{64}$`)
+)
+
+// ArtifactRef is the version of an artifact of the server's repository a file is pushed from. Clients cache the
+// artifacts and download a new version in chunks, only the chunks their cached versions don't have.
+type ArtifactRef struct {
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	Sha256    string `json:"sha256"`
+	SizeBytes int64  `json:"size_bytes"`
+	ChunkSize int64  `json:"chunk_size"`
+	// Chunks are the sha256 sums of the chunks
+	Chunks []string `json:"chunks"`
+}
+
+func (r *ArtifactRef) Validate() error {
+	if err := ValidateArtifactName("name", r.Name); err != nil {
+		return err
+	}
+	if !sha256Regexp.MatchString(r.Sha256) {
+		return fmt.Errorf("invalid artifact sha256 %q", r.Sha256)
+	}
+	if r.ChunkSize <= 0 {
+		return errors.New("artifact chunk size must be positive")
+	}
+	return nil
+}
+
+// ValidateArtifactName checks the name or the version of an artifact, they are used as file names.
+func ValidateArtifactName(field, value string) error {
+	if !artifactNameRegexp.MatchString(value) {
+		return fmt.Errorf("invalid artifact %s %q, it must start with a letter or digit followed by up to 99 letters, digits, '.', '_' or '-'", field, value)
+	}
+	return nil
+}
+
+// JobArtifact is an artifact pushed to the client before the job runs. Artifact is "<name>@<version>", the version
+// of the latest upload is used if it's omitted when the job is created.
+type JobArtifact struct {
+	Artifact    string `json:"artifact"`
+	Destination string `json:"destination"`
+}
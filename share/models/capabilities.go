@@ -4,4 +4,42 @@ type Capabilities struct {
 	ServerVersion      string
 	MonitoringVersion  int
 	IPAddressesVersion int
+	JobResultsVersion  int
+	ProtocolRevision   int
+	FeatureLevels      FeatureLevels
 }
+
+// Features evolving with levels. Client and server report the levels they support, the lower one applies.
+const (
+	FeatureJobDelivery = "job_delivery"
+	FeatureJobResults  = "job_results"
+	FeatureMonitoring  = "monitoring"
+	FeatureIPAddresses = "ip_addresses"
+)
+
+// Features are all features with levels.
+var Features = []string{FeatureJobDelivery, FeatureJobResults, FeatureMonitoring, FeatureIPAddresses}
+
+// FeatureLevels are the supported levels by feature, 0 if a feature isn't supported.
+type FeatureLevels map[string]int
+
+// Negotiate returns the lower level of each feature, a missing feature is level 0.
+func (l FeatureLevels) Negotiate(other FeatureLevels) FeatureLevels {
+	negotiated := make(FeatureLevels, len(Features))
+	for _, f := range Features {
+		negotiated[f] = l[f]
+		if other[f] < negotiated[f] {
+			negotiated[f] = other[f]
+		}
+	}
+	return negotiated
+}
+
+// Capabilities a client reports on connect, depending on its version and configuration.
+const (
+	ClientCapabilityScripts      = "scripts"
+	ClientCapabilityFileTransfer = "file_transfer"
+	ClientCapabilityPTY          = "pty"
+	ClientCapabilityMonitoring   = "monitoring"
+	ClientCapabilityUDPTunnels   = "udp_tunnels"
+)
@@ -22,6 +22,9 @@ const (
 	fileWriteForcedKey             = "force"
 	fileSyncdKey                   = "sync"
 	IDKey                          = "id"
+	preCommandKey                  = "pre_command"
+	validateCommandKey             = "validate_command"
+	postCommandKey                 = "post_command"
 )
 
 type UploadedFile struct {
@@ -34,6 +37,25 @@ type UploadedFile struct {
 	ForceWrite           bool
 	Sync                 bool
 	Md5Checksum          []byte
+	// Artifact is set if the file is pushed from the artifact repository
+	Artifact *ArtifactRef `json:",omitempty"`
+	// DeltaSignaturePath is the signature of the source file on the server, clients having an older version of the
+	// file at the destination only fetch the blocks that changed
+	DeltaSignaturePath string `json:",omitempty"`
+	// PreCommand runs on the client before the file is moved to the destination, the push is aborted if it fails
+	PreCommand string `json:",omitempty"`
+	// ValidateCommand runs on the client after the file is moved to the destination, the previous version of the file
+	// is restored if it fails
+	ValidateCommand string `json:",omitempty"`
+	// PostCommand runs on the client after the file was validated
+	PostCommand string `json:",omitempty"`
+	// Staged files are kept by the client until they are activated by a comm.FileChangeRequest
+	Staged bool `json:",omitempty"`
+}
+
+// HasHooks returns true if commands should run on the client around the file push.
+func (uf UploadedFile) HasHooks() bool {
+	return uf.PreCommand != "" || uf.ValidateCommand != "" || uf.PostCommand != ""
 }
 
 func (uf UploadedFile) Validate() error {
@@ -117,6 +139,18 @@ func (uf *UploadedFile) FromMultipartRequest(req *http.Request) error {
 		uf.ID = req.MultipartForm.Value[IDKey][0]
 	}
 
+	if len(req.MultipartForm.Value[preCommandKey]) > 0 {
+		uf.PreCommand = req.MultipartForm.Value[preCommandKey][0]
+	}
+
+	if len(req.MultipartForm.Value[validateCommandKey]) > 0 {
+		uf.ValidateCommand = req.MultipartForm.Value[validateCommandKey][0]
+	}
+
+	if len(req.MultipartForm.Value[postCommandKey]) > 0 {
+		uf.PostCommand = req.MultipartForm.Value[postCommandKey][0]
+	}
+
 	return nil
 }
 
@@ -246,6 +246,29 @@ func TestUploadedFileFromMultipartRequest(t *testing.T) {
 				Sync:                 true,
 			},
 		},
+		{
+			name: "hooks",
+			formParts: map[string][]string{
+				"dest": {
+					"/etc/nginx/nginx.conf",
+				},
+				"pre_command": {
+					"cp /etc/nginx/nginx.conf /tmp",
+				},
+				"validate_command": {
+					"nginx -t",
+				},
+				"post_command": {
+					"systemctl reload nginx",
+				},
+			},
+			wantUploadedFile: &UploadedFile{
+				DestinationPath: "/etc/nginx/nginx.conf",
+				PreCommand:      "cp /etc/nginx/nginx.conf /tmp",
+				ValidateCommand: "nginx -t",
+				PostCommand:     "systemctl reload nginx",
+			},
+		},
 		{
 			name: "invalid_file_mode",
 			formParts: map[string][]string{
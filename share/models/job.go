@@ -36,12 +36,21 @@ type Job struct {
 	IsSudo       bool       `json:"is_sudo"`
 	IsScript     bool       `json:"is_script"`
 	StreamResult bool       `json:"stream_result"`
+	// OutputParser is applied by the server when the result is received
+	OutputParser *OutputParser `json:"output_parser,omitempty"`
+	// Lock is the execution lock of the client the job holds while it's running
+	Lock string `json:"lock,omitempty"`
+	// Artifacts are pushed to the client before the job is sent
+	Artifacts []JobArtifact `json:"artifacts,omitempty"`
 }
 
 type JobResult struct {
 	StdOut  string `json:"stdout"`
 	StdErr  string `json:"stderr"`
 	Summary string `json:"summary"`
+	// Parsed holds the structured output if the job has an output parser
+	Parsed     map[string]interface{} `json:"parsed,omitempty"`
+	ParseError string                 `json:"parse_error,omitempty"`
 }
 
 type JobClientTags struct {
@@ -52,18 +61,55 @@ type JobClientTags struct {
 // TODO: check that ClientTags is populated where required
 type MultiJob struct {
 	MultiJobSummary
-	ClientIDs   []string       `json:"client_ids"`
-	GroupIDs    []string       `json:"group_ids"`
-	ClientTags  *JobClientTags `json:"tags"`
-	Command     string         `json:"command"`
-	Cwd         string         `json:"cwd"`
-	Interpreter string         `json:"interpreter"`
-	TimeoutSec  int            `json:"timeout_sec"`
-	Concurrent  bool           `json:"concurrent"`
-	AbortOnErr  bool           `json:"abort_on_err"`
-	Jobs        []*Job         `json:"jobs"`
-	IsSudo      bool           `json:"is_sudo"`
-	IsScript    bool           `json:"is_script"`
+	ClientIDs    []string       `json:"client_ids"`
+	GroupIDs     []string       `json:"group_ids"`
+	ClientTags   *JobClientTags `json:"tags"`
+	Command      string         `json:"command"`
+	Cwd          string         `json:"cwd"`
+	Interpreter  string         `json:"interpreter"`
+	TimeoutSec   int            `json:"timeout_sec"`
+	Concurrent   bool           `json:"concurrent"`
+	AbortOnErr   bool           `json:"abort_on_err"`
+	Jobs         []*Job         `json:"jobs"`
+	IsSudo       bool           `json:"is_sudo"`
+	IsScript     bool           `json:"is_script"`
+	OutputParser *OutputParser  `json:"output_parser,omitempty"`
+	Lock         string         `json:"lock,omitempty"`
+	Artifacts    []JobArtifact  `json:"artifacts,omitempty"`
+	// Canary is set if the job runs on a canary subset of the clients first
+	Canary *MultiJobCanary `json:"canary,omitempty"`
+}
+
+const (
+	CanaryStatusRunning              = "running"
+	CanaryStatusCheckingMetrics      = "checking_metrics"
+	CanaryStatusAwaitingConfirmation = "awaiting_confirmation"
+	CanaryStatusConfirmed            = "confirmed"
+	CanaryStatusAborted              = "aborted"
+	CanaryStatusFailed               = "failed"
+)
+
+// MultiJobCanary describes the canary run of a multi-client job. The remaining clients are only executed on once
+// all canary jobs succeeded and the run was confirmed, either by an operator or automatically.
+type MultiJobCanary struct {
+	ClientIDs         []string `json:"client_ids"`
+	AutoContinue      bool     `json:"auto_continue"`
+	ConfirmTimeoutSec int      `json:"confirm_timeout_sec"`
+	Status            string   `json:"status"`
+	DecidedBy         string   `json:"decided_by,omitempty"`
+	// MetricCheck must pass on the canary clients before the run continues
+	MetricCheck *CanaryMetricCheck `json:"metric_check,omitempty"`
+}
+
+// CanaryMetricCheck is evaluated once all canary jobs succeeded. The measure query, e.g.
+// "max(cpu_usage_percent) by client", aggregates the measurements of the canary clients over the duration, each
+// resulting value must be below the threshold.
+type CanaryMetricCheck struct {
+	Query       string  `json:"query"`
+	Below       float64 `json:"below"`
+	DurationSec int     `json:"duration_sec"`
+	// Failure tells why the check didn't pass
+	Failure string `json:"failure,omitempty"`
 }
 
 type MultiJobSummary struct {
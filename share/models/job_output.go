@@ -0,0 +1,259 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	OutputParserJSON     = "json"
+	OutputParserKeyValue = "key_value"
+	OutputParserRegex    = "regex"
+
+	// OutputRowsField holds the rows of tabular output, it's filled by the regex parser and by the json parser if
+	// the output is an array.
+	OutputRowsField = "rows"
+
+	defaultKeyValueSeparator = "="
+)
+
+var outputFieldRegex = regexp.MustCompile(`^\w+(\.\w+)*$`)
+
+// OutputParser turns the stdout of a job into structured data stored in the job result.
+type OutputParser struct {
+	Type string `json:"type"`
+	// Separator of keys and values used by the key_value parser, defaults to "="
+	Separator string `json:"separator,omitempty"`
+	// Pattern with named groups used by the regex parser, each matching line becomes a row
+	Pattern string `json:"pattern,omitempty"`
+	// FailIf marks a job as failed if any of the conditions is true for the parsed output
+	FailIf []OutputCondition `json:"fail_if,omitempty"`
+}
+
+// OutputCondition compares a parsed field with a value. Field is a dot separated path, e.g. "rows.0.status".
+// Values are compared as numbers if both are numeric, otherwise as strings.
+type OutputCondition struct {
+	Field    string `json:"field"`
+	Operator string `json:"operator"`
+	Value    string `json:"value"`
+}
+
+func (c OutputCondition) String() string {
+	return fmt.Sprintf("%s %s %s", c.Field, c.Operator, c.Value)
+}
+
+// IsValidOutputField returns true if the field is a dot separated path of word characters.
+func IsValidOutputField(field string) bool {
+	return outputFieldRegex.MatchString(field)
+}
+
+func (p *OutputParser) Validate() error {
+	switch p.Type {
+	case OutputParserJSON, OutputParserKeyValue:
+	case OutputParserRegex:
+		if p.Pattern == "" {
+			return errors.New("pattern is required for the regex output parser")
+		}
+		re, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern: %v", err)
+		}
+		if !hasNamedGroup(re) {
+			return errors.New("pattern must contain at least one named group, e.g. (?P<name>\\w+)")
+		}
+	default:
+		return fmt.Errorf("unknown output parser type %q, supported are %q, %q and %q", p.Type, OutputParserJSON, OutputParserKeyValue, OutputParserRegex)
+	}
+
+	for _, c := range p.FailIf {
+		if !IsValidOutputField(c.Field) {
+			return fmt.Errorf("invalid field %q in fail_if condition", c.Field)
+		}
+		if _, ok := conditionOperators[c.Operator]; !ok {
+			return fmt.Errorf("invalid operator %q in fail_if condition", c.Operator)
+		}
+	}
+	return nil
+}
+
+// Parse converts the output into a map of fields.
+func (p *OutputParser) Parse(output string) (map[string]interface{}, error) {
+	switch p.Type {
+	case OutputParserJSON:
+		return parseJSONOutput(output)
+	case OutputParserKeyValue:
+		return p.parseKeyValueOutput(output), nil
+	case OutputParserRegex:
+		return p.parseRegexOutput(output)
+	}
+	return nil, fmt.Errorf("unknown output parser type %q", p.Type)
+}
+
+// FailedCondition returns the first fail_if condition that is true for the given parsed output.
+func (p *OutputParser) FailedCondition(parsed map[string]interface{}) (OutputCondition, bool) {
+	for _, c := range p.FailIf {
+		if c.isTrue(parsed) {
+			return c, true
+		}
+	}
+	return OutputCondition{}, false
+}
+
+func (p *OutputParser) Scan(value interface{}) error {
+	if p == nil {
+		return errors.New("'output_parser' cannot be nil")
+	}
+	valueStr, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("expected to have string, got %T", value)
+	}
+	err := json.Unmarshal([]byte(valueStr), p)
+	if err != nil {
+		return fmt.Errorf("failed to decode 'output_parser' field: %v", err)
+	}
+	return nil
+}
+
+func (p *OutputParser) Value() (driver.Value, error) {
+	if p == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode 'output_parser' field: %v", err)
+	}
+	return string(b), nil
+}
+
+func parseJSONOutput(output string) (map[string]interface{}, error) {
+	var parsed interface{}
+	if err := json.Unmarshal([]byte(output), &parsed); err != nil {
+		return nil, fmt.Errorf("output is not valid json: %v", err)
+	}
+	switch v := parsed.(type) {
+	case map[string]interface{}:
+		return v, nil
+	case []interface{}:
+		return map[string]interface{}{OutputRowsField: v}, nil
+	}
+	return nil, errors.New("output must be a json object or array")
+}
+
+func (p *OutputParser) parseKeyValueOutput(output string) map[string]interface{} {
+	sep := p.Separator
+	if sep == "" {
+		sep = defaultKeyValueSeparator
+	}
+	result := map[string]interface{}{}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, found := strings.Cut(line, sep)
+		if !found {
+			continue
+		}
+		result[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return result
+}
+
+func (p *OutputParser) parseRegexOutput(output string) (map[string]interface{}, error) {
+	re, err := regexp.Compile(p.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %v", err)
+	}
+	names := re.SubexpNames()
+	rows := []interface{}{}
+	for _, line := range strings.Split(output, "\n") {
+		matches := re.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if matches == nil {
+			continue
+		}
+		row := map[string]interface{}{}
+		for i, name := range names {
+			if name != "" {
+				row[name] = matches[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+	return map[string]interface{}{OutputRowsField: rows}, nil
+}
+
+func hasNamedGroup(re *regexp.Regexp) bool {
+	for _, name := range re.SubexpNames() {
+		if name != "" {
+			return true
+		}
+	}
+	return false
+}
+
+var conditionOperators = map[string]func(cmp int) bool{
+	"==": func(cmp int) bool { return cmp == 0 },
+	"!=": func(cmp int) bool { return cmp != 0 },
+	">":  func(cmp int) bool { return cmp > 0 },
+	">=": func(cmp int) bool { return cmp >= 0 },
+	"<":  func(cmp int) bool { return cmp < 0 },
+	"<=": func(cmp int) bool { return cmp <= 0 },
+}
+
+func (c OutputCondition) isTrue(parsed map[string]interface{}) bool {
+	op, ok := conditionOperators[c.Operator]
+	if !ok {
+		return false
+	}
+	value, found := LookupOutputField(parsed, c.Field)
+	if !found {
+		// a missing field is only unequal to everything
+		return c.Operator == "!="
+	}
+	return op(CompareOutputValues(fmt.Sprint(value), c.Value))
+}
+
+// LookupOutputField returns the value at the dot separated path of a parsed output.
+func LookupOutputField(parsed map[string]interface{}, field string) (interface{}, bool) {
+	var cur interface{} = parsed
+	for _, part := range strings.Split(field, ".") {
+		switch v := cur.(type) {
+		case map[string]interface{}:
+			next, ok := v[part]
+			if !ok {
+				return nil, false
+			}
+			cur = next
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			cur = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// CompareOutputValues compares two values of a parsed output, as numbers if both are numeric, otherwise as strings.
+func CompareOutputValues(a, b string) int {
+	fa, errA := strconv.ParseFloat(a, 64)
+	fb, errB := strconv.ParseFloat(b, 64)
+	if errA == nil && errB == nil {
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
+}
@@ -0,0 +1,151 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutputParserParse(t *testing.T) {
+	testCases := []struct {
+		Name       string
+		Parser     OutputParser
+		Output     string
+		WantParsed map[string]interface{}
+		WantError  string
+	}{
+		{
+			Name:       "json object",
+			Parser:     OutputParser{Type: OutputParserJSON},
+			Output:     `{"status":"ok","load":0.5}`,
+			WantParsed: map[string]interface{}{"status": "ok", "load": 0.5},
+		},
+		{
+			Name:       "json array",
+			Parser:     OutputParser{Type: OutputParserJSON},
+			Output:     `[{"name":"sda"}]`,
+			WantParsed: map[string]interface{}{"rows": []interface{}{map[string]interface{}{"name": "sda"}}},
+		},
+		{
+			Name:      "json scalar",
+			Parser:    OutputParser{Type: OutputParserJSON},
+			Output:    `42`,
+			WantError: "output must be a json object or array",
+		},
+		{
+			Name:       "key value",
+			Parser:     OutputParser{Type: OutputParserKeyValue},
+			Output:     "# comment\nstatus = ok\n\nversion=1.2=3\nno separator\n",
+			WantParsed: map[string]interface{}{"status": "ok", "version": "1.2=3"},
+		},
+		{
+			Name:       "key value with custom separator",
+			Parser:     OutputParser{Type: OutputParserKeyValue, Separator: ":"},
+			Output:     "Name: host1\r\nUptime: 3 days\r\n",
+			WantParsed: map[string]interface{}{"Name": "host1", "Uptime": "3 days"},
+		},
+		{
+			Name:   "regex table",
+			Parser: OutputParser{Type: OutputParserRegex, Pattern: `^(?P<fs>\S+)\s+(?P<used>\d+)%$`},
+			Output: "Filesystem Use%\n/dev/sda1 42%\n/dev/sdb1 97%\n",
+			WantParsed: map[string]interface{}{"rows": []interface{}{
+				map[string]interface{}{"fs": "/dev/sda1", "used": "42"},
+				map[string]interface{}{"fs": "/dev/sdb1", "used": "97"},
+			}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			parsed, err := tc.Parser.Parse(tc.Output)
+			if tc.WantError != "" {
+				assert.EqualError(t, err, tc.WantError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.WantParsed, parsed)
+		})
+	}
+}
+
+func TestOutputParserValidate(t *testing.T) {
+	testCases := []struct {
+		Name      string
+		Parser    OutputParser
+		WantError string
+	}{
+		{
+			Name:   "valid",
+			Parser: OutputParser{Type: OutputParserJSON, FailIf: []OutputCondition{{Field: "rows.0.status", Operator: "!=", Value: "ok"}}},
+		},
+		{
+			Name:      "unknown type",
+			Parser:    OutputParser{Type: "xml"},
+			WantError: `unknown output parser type "xml", supported are "json", "key_value" and "regex"`,
+		},
+		{
+			Name:      "regex without pattern",
+			Parser:    OutputParser{Type: OutputParserRegex},
+			WantError: "pattern is required for the regex output parser",
+		},
+		{
+			Name:      "regex without named group",
+			Parser:    OutputParser{Type: OutputParserRegex, Pattern: `(\d+)`},
+			WantError: `pattern must contain at least one named group, e.g. (?P<name>\w+)`,
+		},
+		{
+			Name:      "invalid field",
+			Parser:    OutputParser{Type: OutputParserJSON, FailIf: []OutputCondition{{Field: "a..b", Operator: "==", Value: "1"}}},
+			WantError: `invalid field "a..b" in fail_if condition`,
+		},
+		{
+			Name:      "invalid operator",
+			Parser:    OutputParser{Type: OutputParserJSON, FailIf: []OutputCondition{{Field: "a", Operator: "~", Value: "1"}}},
+			WantError: `invalid operator "~" in fail_if condition`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			err := tc.Parser.Validate()
+			if tc.WantError != "" {
+				assert.EqualError(t, err, tc.WantError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestOutputParserFailedCondition(t *testing.T) {
+	parsed := map[string]interface{}{
+		"status": "ok",
+		"load":   2.5,
+		"rows":   []interface{}{map[string]interface{}{"used": "97"}},
+	}
+	testCases := []struct {
+		Condition  OutputCondition
+		WantFailed bool
+	}{
+		{Condition: OutputCondition{Field: "status", Operator: "!=", Value: "ok"}, WantFailed: false},
+		{Condition: OutputCondition{Field: "status", Operator: "==", Value: "ok"}, WantFailed: true},
+		{Condition: OutputCondition{Field: "load", Operator: ">", Value: "10"}, WantFailed: false},
+		{Condition: OutputCondition{Field: "load", Operator: ">=", Value: "2.5"}, WantFailed: true},
+		{Condition: OutputCondition{Field: "rows.0.used", Operator: ">", Value: "90"}, WantFailed: true},
+		{Condition: OutputCondition{Field: "rows.1.used", Operator: ">", Value: "90"}, WantFailed: false},
+		{Condition: OutputCondition{Field: "missing", Operator: "!=", Value: "ok"}, WantFailed: true},
+		{Condition: OutputCondition{Field: "missing", Operator: "==", Value: "ok"}, WantFailed: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Condition.String(), func(t *testing.T) {
+			p := OutputParser{Type: OutputParserJSON, FailIf: []OutputCondition{tc.Condition}}
+			c, failed := p.FailedCondition(parsed)
+			assert.Equal(t, tc.WantFailed, failed)
+			if failed {
+				assert.Equal(t, tc.Condition, c)
+			}
+		})
+	}
+}
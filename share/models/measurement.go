@@ -19,4 +19,13 @@ type Measurement struct {
 	Mountpoints        string    `json:"mountpoints" db:"mountpoints"`
 	NetLan             *NetBytes `json:"net_lan" db:"net_lan"`
 	NetWan             *NetBytes `json:"net_wan" db:"net_wan"`
+	// Agent is the footprint of the client taking the measurement, it's not stored with the measurement
+	Agent *AgentFootprint `json:"agent,omitempty" db:"-"`
+}
+
+// BufferedMeasurement is a measurement a client took while the server was unreachable. Age is the time passed since
+// it was taken, so the server dates it back independently of the client's clock.
+type BufferedMeasurement struct {
+	Measurement
+	Age time.Duration `json:"age"`
 }
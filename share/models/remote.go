@@ -53,9 +53,45 @@ type Remote struct {
 	AuthUser           string        `json:"auth_user"`
 	AuthPassword       string        `json:"auth_password"`
 	TunnelURL          string        `json:"tunnel_url"`
+	// HTTPInspection logs the request lines and status codes of the requests to the tunnel proxy
+	HTTPInspection bool `json:"http_inspection,omitempty"`
+	// AccessOverride allows to use the tunnel outside the access schedule of a client group
+	AccessOverride *AccessOverride `json:"access_override,omitempty"`
+	// DeviceAuth logs the users of the tunnel proxy in to the device with a password from the vault
+	DeviceAuth *DeviceAuth `json:"device_auth,omitempty"`
+}
+
+// AccessOverride lifts the access schedule of a client group until it expires.
+type AccessOverride struct {
+	ClientGroupID string    `json:"client_group_id"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+const (
+	DeviceAuthBasic = "basic"
+	DeviceAuthForm  = "form"
+)
+
+// DeviceAuth tells how the tunnel proxy logs in to the device. Only the id of the vault value is kept, the password is
+// read from the vault on each login.
+type DeviceAuth struct {
+	// Type is DeviceAuthBasic to add http basic authentication to all requests, DeviceAuthForm to fill in the login form
+	Type         string `json:"type"`
+	Username     string `json:"username"`
+	VaultValueID int    `json:"vault_value_id"`
+	// LoginPath, UsernameField and PasswordField tell the login form for DeviceAuthForm
+	LoginPath     string `json:"login_path,omitempty"`
+	UsernameField string `json:"username_field,omitempty"`
+	PasswordField string `json:"password_field,omitempty"`
 }
 
 func NewRemote(s string) (*Remote, error) {
+	return NewRemoteWithDefaultLocalHost(s, ZeroHost)
+}
+
+// NewRemoteWithDefaultLocalHost parses the remote like NewRemote, a local port without a local host is opened on
+// the given host. IPv6 hosts are enclosed in brackets, e.g. [::1]:3000:[fd00::5]:80.
+func NewRemoteWithDefaultLocalHost(s, defaultLocalHost string) (*Remote, error) {
 	protocol := ProtocolTCP
 	matches := protocolRe.FindStringSubmatch(s)
 	if len(matches) >= 3 {
@@ -63,7 +99,7 @@ func NewRemote(s string) (*Remote, error) {
 		protocol = matches[2]
 	}
 
-	parts := strings.Split(s, ":")
+	parts := splitRemote(s)
 	if len(parts) <= 0 || len(parts) >= 5 {
 		return nil, errors.New("Invalid remote")
 	}
@@ -84,7 +120,12 @@ func NewRemote(s string) (*Remote, error) {
 		if r.RemotePort == "" && r.LocalPort == "" {
 			return nil, errors.New("Missing ports")
 		}
-		if !isHost(p) {
+		if strings.HasPrefix(p, "[") && strings.HasSuffix(p, "]") {
+			p = p[1 : len(p)-1]
+			if net.ParseIP(p) == nil {
+				return nil, errors.New("Invalid host")
+			}
+		} else if !isHost(p) {
 			return nil, errors.New("Invalid host")
 		}
 		if r.RemoteHost == "" {
@@ -94,7 +135,7 @@ func NewRemote(s string) (*Remote, error) {
 		}
 	}
 	if r.LocalHost == "" && r.LocalPort != "" {
-		r.LocalHost = ZeroHost
+		r.LocalHost = defaultLocalHost
 	}
 	if r.RemoteHost == "" {
 		r.RemoteHost = LocalHost
@@ -102,6 +143,26 @@ func NewRemote(s string) (*Remote, error) {
 	return r, nil
 }
 
+// splitRemote splits the remote at the colons outside of the brackets of IPv6 hosts.
+func splitRemote(s string) []string {
+	var parts []string
+	start, inBrackets := 0, false
+	for i, c := range s {
+		switch c {
+		case '[':
+			inBrackets = true
+		case ']':
+			inBrackets = false
+		case ':':
+			if !inBrackets {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
 var isPortRegExp = regexp.MustCompile(`^\d+$`)
 
 func isPort(s string) bool {
@@ -115,7 +176,7 @@ func isHost(s string) bool {
 
 // implement Stringer
 func (r Remote) String() string {
-	s := r.LocalHost + ":" + r.LocalPort + ":" + r.Remote()
+	s := net.JoinHostPort(r.LocalHost, r.LocalPort) + ":" + r.Remote()
 
 	if r.Protocol != ProtocolTCP {
 		s += "/" + r.Protocol
@@ -101,6 +101,20 @@ func TestDecodeRemote(t *testing.T) {
 			WantRemoteHost: "google.com",
 			WantRemotePort: "80",
 		},
+		{
+			Input:          "[::1]:80",
+			WantProtocol:   ProtocolTCP,
+			WantRemoteHost: "::1",
+			WantRemotePort: "80",
+		},
+		{
+			Input:          "[::1]:3000:[fd00::5]:80",
+			WantProtocol:   ProtocolTCP,
+			WantLocalHost:  "::1",
+			WantLocalPort:  "3000",
+			WantRemoteHost: "fd00::5",
+			WantRemotePort: "80",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -119,6 +133,17 @@ func TestDecodeRemote(t *testing.T) {
 	}
 }
 
+func TestNewRemoteInvalidIPv6Host(t *testing.T) {
+	_, err := NewRemote("3000:[fd00::zz]:80")
+	assert.EqualError(t, err, "Invalid host")
+}
+
+func TestRemoteStringIPv6(t *testing.T) {
+	remote, err := NewRemote("[::1]:3000:[fd00::5]:80")
+	require.NoError(t, err)
+	assert.Equal(t, "[::1]:3000:[fd00::5]:80", remote.String())
+}
+
 func TestIsProtocol(t *testing.T) {
 	testCases := []struct {
 		Protocol      string
@@ -36,6 +36,16 @@ type ConnectionRequest struct {
 	Labels                 map[string]string
 	Remotes                []*models.Remote
 	ClientConfiguration    *clientconfig.Config
+	JobDeliveryVersion     int
+	// Capabilities are the features the client supports, nil for clients older than the capability handshake.
+	Capabilities []string
+	// ProtocolRevision and FeatureLevels are 0 and nil for clients older than protocol revision 1.
+	ProtocolRevision int
+	FeatureLevels    models.FeatureLevels
+	// Simulated is set by the load generator, the server only accepts it with allow_simulated_clients.
+	Simulated bool
+	// Ephemeral clients, e.g. in containers or CI runners, are removed by the server shortly after they disconnect.
+	Ephemeral bool
 }
 
 func DecodeConnectionRequest(b []byte) (*ConnectionRequest, error) {
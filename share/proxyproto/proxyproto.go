@@ -0,0 +1,196 @@
+// Package proxyproto implements the server side of the PROXY protocol v1 and v2 of HAProxy, so listeners behind load
+// balancers see the address of the original client. See https://www.haproxy.org/download/2.8/doc/proxy-protocol.txt
+package proxyproto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HeaderTimeout limits the time to receive the header after the connection is accepted.
+const HeaderTimeout = 10 * time.Second
+
+const (
+	v1Prefix    = "PROXY "
+	v1MaxLength = 107
+)
+
+var v2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// Listener reads the PROXY protocol header of connections from trusted proxies. Headers of other peers are not read,
+// their connections are passed as they are. The header is optional, e.g. for health checks of the proxy.
+type Listener struct {
+	net.Listener
+	trusted []*net.IPNet
+}
+
+func NewListener(l net.Listener, trusted []*net.IPNet) *Listener {
+	return &Listener{
+		Listener: l,
+		trusted:  trusted,
+	}
+}
+
+func (l *Listener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	if !l.isTrusted(conn.RemoteAddr()) {
+		return conn, nil
+	}
+	return &Conn{Conn: conn, r: bufio.NewReader(conn)}, nil
+}
+
+func (l *Listener) isTrusted(addr net.Addr) bool {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+	for _, n := range l.trusted {
+		if n.Contains(tcpAddr.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// Conn reads the header on the first call of Read or RemoteAddr, so Accept doesn't block on slow peers.
+type Conn struct {
+	net.Conn
+	r          *bufio.Reader
+	once       sync.Once
+	remoteAddr net.Addr
+	err        error
+}
+
+func (c *Conn) Read(b []byte) (int, error) {
+	c.once.Do(c.readHeader)
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.r.Read(b)
+}
+
+// RemoteAddr returns the address of the original client, or the address of the proxy if it sent no address.
+func (c *Conn) RemoteAddr() net.Addr {
+	c.once.Do(c.readHeader)
+	if c.remoteAddr != nil {
+		return c.remoteAddr
+	}
+	return c.Conn.RemoteAddr()
+}
+
+func (c *Conn) readHeader() {
+	if err := c.Conn.SetReadDeadline(time.Now().Add(HeaderTimeout)); err != nil {
+		c.err = err
+		return
+	}
+	c.remoteAddr, c.err = ReadHeader(c.r)
+	if c.err != nil {
+		c.err = fmt.Errorf("invalid PROXY protocol header from %s: %w", c.Conn.RemoteAddr(), c.err)
+		_ = c.Conn.Close()
+		return
+	}
+	c.err = c.Conn.SetReadDeadline(time.Time{})
+}
+
+// ReadHeader reads a v1 or v2 header. It returns nil if there is no header or it has no address, e.g. for the
+// LOCAL command or UNKNOWN protocol.
+func ReadHeader(r *bufio.Reader) (net.Addr, error) {
+	sig, err := r.Peek(len(v2Signature))
+	if err != nil && len(sig) == 0 {
+		return nil, err
+	}
+	switch {
+	case bytes.Equal(sig, v2Signature):
+		return readV2(r)
+	case bytes.HasPrefix(sig, []byte(v1Prefix)):
+		return readV1(r)
+	}
+	return nil, nil
+}
+
+func readV1(r *bufio.Reader) (net.Addr, error) {
+	var line []byte
+	for len(line) < v1MaxLength {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+	}
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, errors.New("v1 header too long")
+	}
+
+	fields := strings.Split(strings.TrimSuffix(string(line), "\r\n"), " ")
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, fmt.Errorf("invalid v1 header %q", line)
+	}
+	ip := net.ParseIP(fields[2])
+	if ip == nil || (ip.To4() != nil) != (fields[1] == "TCP4") {
+		return nil, fmt.Errorf("invalid source address %q", fields[2])
+	}
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source port %q", fields[4])
+	}
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+func readV2(r *bufio.Reader) (net.Addr, error) {
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	if header[12]>>4 != 2 {
+		return nil, fmt.Errorf("unsupported version %d", header[12]>>4)
+	}
+	command := header[12] & 0x0f
+	family := header[13] >> 4
+	length := binary.BigEndian.Uint16(header[14:16])
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+
+	switch command {
+	case 0x0: // LOCAL, e.g. health checks of the proxy
+		return nil, nil
+	case 0x1: // PROXY
+	default:
+		return nil, fmt.Errorf("unsupported command %d", command)
+	}
+
+	switch family {
+	case 0x1: // AF_INET
+		if len(payload) < 12 {
+			return nil, errors.New("short IPv4 address block")
+		}
+		return &net.TCPAddr{IP: net.IP(payload[0:4]), Port: int(binary.BigEndian.Uint16(payload[8:10]))}, nil
+	case 0x2: // AF_INET6
+		if len(payload) < 36 {
+			return nil, errors.New("short IPv6 address block")
+		}
+		return &net.TCPAddr{IP: net.IP(payload[0:16]), Port: int(binary.BigEndian.Uint16(payload[32:34]))}, nil
+	}
+	// AF_UNSPEC and AF_UNIX carry no usable address
+	return nil, nil
+}
@@ -0,0 +1,143 @@
+package proxyproto
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadHeader(t *testing.T) {
+	v2 := func(command, family byte, addr []byte) string {
+		h := append([]byte{}, v2Signature...)
+		h = append(h, 0x20|command, family<<4|0x1, 0, byte(len(addr)))
+		return string(append(h, addr...))
+	}
+
+	testCases := []struct {
+		name         string
+		input        string
+		expectedAddr string
+		expectedErr  string
+	}{
+		{
+			name:         "v1 tcp4",
+			input:        "PROXY TCP4 192.0.2.10 10.0.0.1 51234 443\r\nGET / HTTP/1.1\r\n",
+			expectedAddr: "192.0.2.10:51234",
+		},
+		{
+			name:         "v1 tcp6",
+			input:        "PROXY TCP6 2001:db8::1 2001:db8::2 51234 443\r\nGET / HTTP/1.1\r\n",
+			expectedAddr: "[2001:db8::1]:51234",
+		},
+		{
+			name:  "v1 unknown",
+			input: "PROXY UNKNOWN\r\nGET / HTTP/1.1\r\n",
+		},
+		{
+			name:        "v1 invalid address",
+			input:       "PROXY TCP4 2001:db8::1 10.0.0.1 51234 443\r\n",
+			expectedErr: `invalid source address "2001:db8::1"`,
+		},
+		{
+			name:        "v1 too long",
+			input:       "PROXY TCP4 " + strings.Repeat("1", 200),
+			expectedErr: "v1 header too long",
+		},
+		{
+			name:         "v2 ipv4",
+			input:        v2(0x1, 0x1, []byte{192, 0, 2, 10, 10, 0, 0, 1, 0xc8, 0x22, 0x01, 0xbb}) + "GET / HTTP/1.1\r\n",
+			expectedAddr: "192.0.2.10:51234",
+		},
+		{
+			name:  "v2 local",
+			input: v2(0x0, 0x0, nil) + "GET / HTTP/1.1\r\n",
+		},
+		{
+			name:        "v2 short address",
+			input:       v2(0x1, 0x2, []byte{1, 2, 3, 4}),
+			expectedErr: "short IPv6 address block",
+		},
+		{
+			name:  "no header",
+			input: "GET / HTTP/1.1\r\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := bufio.NewReader(strings.NewReader(tc.input))
+			addr, err := ReadHeader(r)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			if tc.expectedAddr == "" {
+				assert.Nil(t, addr)
+			} else {
+				require.NotNil(t, addr)
+				assert.Equal(t, tc.expectedAddr, addr.String())
+			}
+			rest, err := io.ReadAll(r)
+			require.NoError(t, err)
+			assert.Equal(t, "GET / HTTP/1.1\r\n", string(rest))
+		})
+	}
+}
+
+func TestListener(t *testing.T) {
+	testCases := []struct {
+		name               string
+		trusted            string
+		expectedRemoteAddr string
+		expectedData       string
+	}{
+		{
+			name:               "trusted",
+			trusted:            "127.0.0.0/8",
+			expectedRemoteAddr: "192.0.2.10:51234",
+			expectedData:       "hello",
+		},
+		{
+			name:         "untrusted",
+			trusted:      "192.0.2.0/24",
+			expectedData: "PROXY TCP4 192.0.2.10 10.0.0.1 51234 443\r\nhello",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, trusted, err := net.ParseCIDR(tc.trusted)
+			require.NoError(t, err)
+			tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			l := NewListener(tcpListener, []*net.IPNet{trusted})
+			defer l.Close()
+
+			go func() {
+				conn, err := net.Dial("tcp", l.Addr().String())
+				if err != nil {
+					return
+				}
+				defer conn.Close()
+				_, _ = conn.Write([]byte("PROXY TCP4 192.0.2.10 10.0.0.1 51234 443\r\nhello"))
+			}()
+
+			conn, err := l.Accept()
+			require.NoError(t, err)
+			defer conn.Close()
+
+			if tc.expectedRemoteAddr != "" {
+				assert.Equal(t, tc.expectedRemoteAddr, conn.RemoteAddr().String())
+			} else {
+				assert.Contains(t, conn.RemoteAddr().String(), "127.0.0.1:")
+			}
+			data, err := io.ReadAll(conn)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedData, string(data))
+		})
+	}
+}
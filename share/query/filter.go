@@ -11,7 +11,7 @@ import (
 	errors2 "github.com/IOTech17/neo-rport/server/api/errors"
 )
 
-var filterRegex = regexp.MustCompile(`^filter\[([\w|*]+)](\[(\w+)])?`)
+var filterRegex = regexp.MustCompile(`^filter\[([\w|*.]+)](\[(\w+)])?`)
 var valuesLogicalOpsblock = regexp.MustCompile(`^(and|or){1}\((.+)\)`)
 
 type FilterOperatorType string
@@ -200,6 +200,18 @@ func TestParseFilterOptions(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name: "column with dots",
+			Query: map[string][]string{
+				"filter[parsed.rows.0.status]": {"ok"},
+			},
+			ExpectedFilterOptions: []FilterOption{
+				{
+					Column: []string{"parsed.rows.0.status"},
+					Values: []string{"ok"},
+				},
+			},
+		},
 		{
 			Name: "filter without fields, not ok",
 			Query: map[string][]string{
@@ -33,6 +33,32 @@ func (l *BanList) IsBanned(visitorKey string) bool {
 	return found && banExpiry.After(time.Now())
 }
 
+// List returns the ban expiry of the banned visitors.
+func (l *BanList) List() map[string]time.Time {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	now := time.Now()
+	res := make(map[string]time.Time)
+	for key, banExpiry := range l.visitors {
+		if banExpiry.After(now) {
+			res[key] = banExpiry
+		}
+	}
+	return res
+}
+
+// Remove lifts the ban of a visitor, it returns false if the visitor isn't banned.
+func (l *BanList) Remove(visitorKey string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	banExpiry, found := l.visitors[visitorKey]
+	delete(l.visitors, visitorKey)
+	return found && banExpiry.After(time.Now())
+}
+
+// BanHook is called when a visitor gets banned or the ban is lifted before it expires.
+type BanHook func(visitorKey string, banned bool, banExpiry time.Time)
+
 // MaxBadAttemptsBanList bans visitors by their keys after N failed consecutive attempts for Z period.
 type MaxBadAttemptsBanList struct {
 	banDuration    time.Duration
@@ -40,6 +66,7 @@ type MaxBadAttemptsBanList struct {
 	mu             sync.RWMutex
 	visitors       map[string]*visitor
 	logger         *logger.Logger
+	hook           BanHook
 }
 
 type visitor struct {
@@ -56,6 +83,13 @@ func NewMaxBadAttemptsBanList(maxBadAttempts int, banDuration time.Duration, log
 	}
 }
 
+// SetHook sets a hook to run on bans, it's run asynchronously.
+func (l *MaxBadAttemptsBanList) SetHook(hook BanHook) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hook = hook
+}
+
 // AddBadAttempt registers a bad attempt of a visitor.
 func (l *MaxBadAttemptsBanList) AddBadAttempt(visitorKey string) {
 	l.mu.Lock()
@@ -76,6 +110,9 @@ func (l *MaxBadAttemptsBanList) AddBadAttempt(visitorKey string) {
 		}
 		v.banTime = &t
 		v.badAttempts = 0
+		if l.hook != nil {
+			go l.hook(visitorKey, true, t)
+		}
 	}
 }
 
@@ -86,6 +123,9 @@ func (l *MaxBadAttemptsBanList) AddSuccessAttempt(visitorKey string) {
 
 	v, found := l.visitors[visitorKey]
 	if found {
+		if l.hook != nil && v.banTime != nil && v.banTime.After(time.Now()) {
+			go l.hook(visitorKey, false, *v.banTime)
+		}
 		v.badAttempts = 0
 		v.banTime = nil
 	}
@@ -98,3 +138,30 @@ func (l *MaxBadAttemptsBanList) IsBanned(visitorKey string) bool {
 	v, found := l.visitors[visitorKey]
 	return found && v.banTime != nil && v.banTime.After(time.Now())
 }
+
+// List returns the ban expiry of the banned visitors.
+func (l *MaxBadAttemptsBanList) List() map[string]time.Time {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	now := time.Now()
+	res := make(map[string]time.Time)
+	for key, v := range l.visitors {
+		if v.banTime != nil && v.banTime.After(now) {
+			res[key] = *v.banTime
+		}
+	}
+	return res
+}
+
+// Remove lifts the ban of a visitor and forgets its bad attempts, it returns false if the visitor isn't banned.
+func (l *MaxBadAttemptsBanList) Remove(visitorKey string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	v, found := l.visitors[visitorKey]
+	delete(l.visitors, visitorKey)
+	banned := found && v.banTime != nil && v.banTime.After(time.Now())
+	if banned && l.hook != nil {
+		go l.hook(visitorKey, false, *v.banTime)
+	}
+	return banned
+}
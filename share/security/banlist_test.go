@@ -0,0 +1,70 @@
+package security
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBanListRemove(t *testing.T) {
+	l := NewBanList(time.Hour)
+	l.Add("jane")
+
+	assert.Equal(t, []string{"jane"}, keys(l.List()))
+	assert.True(t, l.Remove("jane"))
+	assert.False(t, l.IsBanned("jane"))
+	assert.False(t, l.Remove("jane"))
+	assert.Empty(t, l.List())
+}
+
+func TestMaxBadAttemptsBanListRemove(t *testing.T) {
+	l := NewMaxBadAttemptsBanList(2, time.Hour, nil)
+	l.AddBadAttempt("192.0.2.1")
+	l.AddBadAttempt("192.0.2.2")
+	l.AddBadAttempt("192.0.2.2")
+
+	assert.Equal(t, []string{"192.0.2.2"}, keys(l.List()))
+	assert.False(t, l.Remove("192.0.2.1"))
+	assert.True(t, l.Remove("192.0.2.2"))
+	assert.False(t, l.IsBanned("192.0.2.2"))
+	assert.Empty(t, l.List())
+
+	// the bad attempts are forgotten
+	l.AddBadAttempt("192.0.2.2")
+	assert.False(t, l.IsBanned("192.0.2.2"))
+}
+
+func keys(m map[string]time.Time) []string {
+	var res []string
+	for k := range m {
+		res = append(res, k)
+	}
+	return res
+}
+
+func TestMaxBadAttemptsBanListHook(t *testing.T) {
+	type call struct {
+		key    string
+		banned bool
+	}
+	calls := make(chan call, 2)
+	l := NewMaxBadAttemptsBanList(1, time.Hour, nil)
+	l.SetHook(func(visitorKey string, banned bool, banExpiry time.Time) {
+		calls <- call{key: visitorKey, banned: banned}
+	})
+
+	l.AddBadAttempt("192.0.2.1")
+	assert.Equal(t, call{key: "192.0.2.1", banned: true}, <-calls)
+
+	l.Remove("192.0.2.1")
+	assert.Equal(t, call{key: "192.0.2.1", banned: false}, <-calls)
+
+	// not banned anymore
+	l.Remove("192.0.2.1")
+	select {
+	case c := <-calls:
+		t.Fatalf("unexpected hook call: %v", c)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
@@ -8,12 +8,13 @@ import (
 	chshare "github.com/IOTech17/neo-rport/share"
 )
 
-func RejectBannedIPs(bannedIPs *MaxBadAttemptsBanList) mux.MiddlewareFunc {
+// RejectBannedIPs rejects requests from banned IPs, except to the given paths.
+func RejectBannedIPs(bannedIPs *MaxBadAttemptsBanList, exceptPaths ...string) mux.MiddlewareFunc {
 	return func(f http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ip := chshare.RemoteIP(r)
 
-			if bannedIPs.IsBanned(ip) {
+			if bannedIPs.IsBanned(ip) && !isOneOf(r.URL.Path, exceptPaths) {
 				http.Error(w, "Too many bad attempts. Please try later.", http.StatusLocked)
 				return
 			}
@@ -22,3 +23,12 @@ func RejectBannedIPs(bannedIPs *MaxBadAttemptsBanList) mux.MiddlewareFunc {
 		})
 	}
 }
+
+func isOneOf(path string, paths []string) bool {
+	for _, p := range paths {
+		if path == p {
+			return true
+		}
+	}
+	return false
+}
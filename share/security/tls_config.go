@@ -1,6 +1,10 @@
 package security
 
-import "crypto/tls"
+import (
+	"crypto/tls"
+
+	"github.com/IOTech17/neo-rport/share/fips"
+)
 
 func TLSConfig(configTLSMin string) *tls.Config {
 	tlsMin := uint16(tls.VersionTLS13)
@@ -15,5 +19,5 @@ func TLSConfig(configTLSMin string) *tls.Config {
 		CurvePreferences:         []tls.CurveID{tls.CurveP521, tls.CurveP384, tls.CurveP256},
 		PreferServerCipherSuites: true,
 	}
-	return TLSConfig
+	return fips.ApplyTLSConfig(TLSConfig)
 }
@@ -0,0 +1,145 @@
+// Package sshpolicy configures the algorithms of the SSH transport between the client and the server.
+package sshpolicy
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/IOTech17/neo-rport/share/fips"
+)
+
+// DefaultKeyExchanges are used if no key exchanges are configured, the ones based on SHA-1 are left out.
+var DefaultKeyExchanges = []string{
+	"curve25519-sha256",
+	"curve25519-sha256@libssh.org",
+	"ecdh-sha2-nistp256",
+	"ecdh-sha2-nistp384",
+	"ecdh-sha2-nistp521",
+	"diffie-hellman-group14-sha256",
+}
+
+// DefaultCiphers are used if no ciphers are configured.
+var DefaultCiphers = []string{
+	"aes128-gcm@openssh.com",
+	"aes256-gcm@openssh.com",
+	"chacha20-poly1305@openssh.com",
+	"aes128-ctr",
+	"aes192-ctr",
+	"aes256-ctr",
+}
+
+// DefaultMACs are used if no MACs are configured, the ones based on SHA-1 are left out.
+var DefaultMACs = []string{
+	"hmac-sha2-256-etm@openssh.com",
+	"hmac-sha2-256",
+}
+
+// deprecated are supported but considered insecure, they can be enabled for old peers only.
+var deprecated = map[string]bool{
+	"diffie-hellman-group14-sha1": true,
+	"diffie-hellman-group1-sha1":  true,
+	"arcfour256":                  true,
+	"arcfour128":                  true,
+	"arcfour":                     true,
+	"aes128-cbc":                  true,
+	"3des-cbc":                    true,
+	"hmac-sha1":                   true,
+	"hmac-sha1-96":                true,
+}
+
+var supportedKeyExchanges = append(DefaultKeyExchanges[:len(DefaultKeyExchanges):len(DefaultKeyExchanges)],
+	"diffie-hellman-group14-sha1",
+	"diffie-hellman-group1-sha1",
+)
+
+var supportedCiphers = append(DefaultCiphers[:len(DefaultCiphers):len(DefaultCiphers)],
+	"arcfour256",
+	"arcfour128",
+	"arcfour",
+	"aes128-cbc",
+	"3des-cbc",
+)
+
+var supportedMACs = append(DefaultMACs[:len(DefaultMACs):len(DefaultMACs)],
+	"hmac-sha1",
+	"hmac-sha1-96",
+)
+
+// Policy restricts the SSH algorithms, an empty list means the defaults.
+type Policy struct {
+	KeyExchanges []string `json:"ssh_key_exchanges" mapstructure:"ssh_key_exchanges"`
+	Ciphers      []string `json:"ssh_ciphers" mapstructure:"ssh_ciphers"`
+	MACs         []string `json:"ssh_macs" mapstructure:"ssh_macs"`
+}
+
+// Validate returns an error if an algorithm is unknown or, in fips mode, not approved.
+func (p Policy) Validate(fipsMode bool) error {
+	for _, list := range p.lists() {
+		for _, algo := range list.configured {
+			if !contains(list.supported, algo) {
+				return fmt.Errorf("'%s': unsupported algorithm %q, supported are: %s", list.name, algo, strings.Join(list.supported, ", "))
+			}
+			if fipsMode && !contains(list.fips, algo) {
+				return fmt.Errorf("'%s': algorithm %q is not allowed in fips mode", list.name, algo)
+			}
+		}
+	}
+	return nil
+}
+
+// Deprecated returns a warning for each configured algorithm that is considered insecure.
+func (p Policy) Deprecated() []string {
+	var warnings []string
+	for _, list := range p.lists() {
+		for _, algo := range list.configured {
+			if deprecated[algo] {
+				warnings = append(warnings, fmt.Sprintf("'%s': algorithm %q is deprecated and insecure, it will be removed in a future version", list.name, algo))
+			}
+		}
+	}
+	return warnings
+}
+
+// Apply sets the configured algorithms or the defaults, which are the approved ones in fips mode.
+func (p Policy) Apply(c *ssh.Config) {
+	c.KeyExchanges = DefaultKeyExchanges
+	c.Ciphers = DefaultCiphers
+	c.MACs = DefaultMACs
+	fips.ApplySSHConfig(c)
+
+	if len(p.KeyExchanges) > 0 {
+		c.KeyExchanges = p.KeyExchanges
+	}
+	if len(p.Ciphers) > 0 {
+		c.Ciphers = p.Ciphers
+	}
+	if len(p.MACs) > 0 {
+		c.MACs = p.MACs
+	}
+}
+
+type algorithmList struct {
+	name       string
+	configured []string
+	supported  []string
+	fips       []string
+}
+
+func (p Policy) lists() []algorithmList {
+	return []algorithmList{
+		{name: "ssh_key_exchanges", configured: p.KeyExchanges, supported: supportedKeyExchanges, fips: fips.KeyExchanges},
+		{name: "ssh_ciphers", configured: p.Ciphers, supported: supportedCiphers, fips: fips.Ciphers},
+		{name: "ssh_macs", configured: p.MACs, supported: supportedMACs, fips: fips.MACs},
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package sshpolicy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/IOTech17/neo-rport/share/fips"
+)
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		policy      Policy
+		fipsMode    bool
+		expectedErr string
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name: "restricted",
+			policy: Policy{
+				KeyExchanges: []string{"curve25519-sha256"},
+				Ciphers:      []string{"aes256-gcm@openssh.com"},
+				MACs:         []string{"hmac-sha2-256-etm@openssh.com"},
+			},
+		},
+		{
+			name:   "deprecated",
+			policy: Policy{MACs: []string{"hmac-sha2-256", "hmac-sha1"}},
+		},
+		{
+			name:        "unknown",
+			policy:      Policy{Ciphers: []string{"blowfish-cbc"}},
+			expectedErr: `'ssh_ciphers': unsupported algorithm "blowfish-cbc", supported are: aes128-gcm@openssh.com, aes256-gcm@openssh.com, chacha20-poly1305@openssh.com, aes128-ctr, aes192-ctr, aes256-ctr, arcfour256, arcfour128, arcfour, aes128-cbc, 3des-cbc`,
+		},
+		{
+			name:        "not approved in fips mode",
+			policy:      Policy{KeyExchanges: []string{"curve25519-sha256"}},
+			fipsMode:    true,
+			expectedErr: `'ssh_key_exchanges': algorithm "curve25519-sha256" is not allowed in fips mode`,
+		},
+		{
+			name:     "approved in fips mode",
+			policy:   Policy{KeyExchanges: []string{"ecdh-sha2-nistp384"}},
+			fipsMode: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.policy.Validate(tc.fipsMode)
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDeprecated(t *testing.T) {
+	p := Policy{
+		KeyExchanges: []string{"curve25519-sha256", "diffie-hellman-group14-sha1"},
+		MACs:         []string{"hmac-sha1-96"},
+	}
+
+	assert.Equal(t, []string{
+		`'ssh_key_exchanges': algorithm "diffie-hellman-group14-sha1" is deprecated and insecure, it will be removed in a future version`,
+		`'ssh_macs': algorithm "hmac-sha1-96" is deprecated and insecure, it will be removed in a future version`,
+	}, p.Deprecated())
+	assert.Empty(t, Policy{}.Deprecated())
+}
+
+func TestApply(t *testing.T) {
+	if fips.Enabled() {
+		t.Skip("defaults are replaced by the approved algorithms in fips mode")
+	}
+	c := &ssh.Config{}
+	Policy{}.Apply(c)
+
+	assert.Equal(t, DefaultKeyExchanges, c.KeyExchanges)
+	assert.Equal(t, DefaultCiphers, c.Ciphers)
+	assert.Equal(t, DefaultMACs, c.MACs)
+
+	c = &ssh.Config{}
+	Policy{Ciphers: []string{"aes256-ctr"}}.Apply(c)
+
+	assert.Equal(t, DefaultKeyExchanges, c.KeyExchanges)
+	assert.Equal(t, []string{"aes256-ctr"}, c.Ciphers)
+	assert.Equal(t, DefaultMACs, c.MACs)
+}
@@ -1,11 +1,17 @@
 package chshare
 
+import "github.com/IOTech17/neo-rport/share/models"
+
 // ProtocolVersion of neo-rport. When backwards
 // incompatible changes are made, this will
 // be incremented to signify a protocol
 // mismatch.
 const ProtocolVersion = "rport-v1"
 
+// ProtocolRevision is raised for backwards compatible changes of the connection handshake within ProtocolVersion.
+// Revision 1 exchanges the feature levels, clients and servers older than that report 0.
+const ProtocolRevision = 1
+
 // BuildVersion represents a current build version. It can be overridden by CI workflow.
 var BuildVersion = "0.0.0-src"
 
@@ -13,7 +19,29 @@ var BuildVersion = "0.0.0-src"
 var SourceVersion = "0.0.0-src"
 
 // MonitoringVersion represents the current version of monitoring capability. 0 means no monitoring available.
-const MonitoringVersion = 1
+// Version 2 accepts measurements buffered by clients while the server was unreachable.
+const MonitoringVersion = 2
+
+// MonitoringBackfillVersion is the first monitoring version accepting buffered measurements.
+const MonitoringBackfillVersion = 2
 
 // IPAddressesVersion represents the current version of IPAddresses fetching. 0 means no IPAddress fetching available.
 const IPAddressesVersion = 1
+
+// JobResultsVersion represents the current version of receiving job results. Version 1 replies once a job result is
+// stored, so clients keep the results of jobs finished while the server was unreachable until they are confirmed.
+const JobResultsVersion = 1
+
+// JobDeliveryVersion represents the current version of receiving jobs. Version 1 runs a job at most once per job id,
+// so the server can send a job again if the connection was lost before the client replied.
+const JobDeliveryVersion = 1
+
+// FeatureLevels returns the levels of the features this build supports.
+func FeatureLevels() models.FeatureLevels {
+	return models.FeatureLevels{
+		models.FeatureJobDelivery: JobDeliveryVersion,
+		models.FeatureJobResults:  JobResultsVersion,
+		models.FeatureMonitoring:  MonitoringVersion,
+		models.FeatureIPAddresses: IPAddressesVersion,
+	}
+}
@@ -1,9 +1,11 @@
 package chshare
 
 import (
+	"bytes"
 	"encoding/csv"
 	"fmt"
 	"io"
+	"os"
 	"reflect"
 	"strings"
 
@@ -67,15 +69,27 @@ var decoderConfigOptions = []viper.DecoderConfigOption{viper.DecodeHook(decodeHo
 
 // DecodeViperConfig tries to load viper config from either a file or reader and env variables
 // then decodes all values into given cfg variable. cfg must be a pointer.
+// Files listed in the include option are merged in and ${ENV_VAR} references in values are expanded.
 func DecodeViperConfig(v *viper.Viper, cfg interface{}, cfgReader io.Reader) error {
 	if cfgReader == nil {
-		if err := v.ReadInConfig(); err != nil {
-			if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-				return fmt.Errorf("error reading config file: %s", err)
-			}
+		err := v.ReadInConfig()
+		if err == nil {
+			err = mergeConfigFile(v, v.ConfigFileUsed())
+		} else if _, ok := err.(viper.ConfigFileNotFoundError); ok {
+			err = nil
+		}
+		if err != nil {
+			return fmt.Errorf("error reading config file: %s", err)
 		}
 	} else {
-		if err := v.ReadConfig(cfgReader); err != nil {
+		content, err := io.ReadAll(cfgReader)
+		if err != nil {
+			return fmt.Errorf("error reading config contents: %w", err)
+		}
+		if err := v.ReadConfig(bytes.NewReader(content)); err != nil {
+			return fmt.Errorf("error reading config contents: %w", err)
+		}
+		if err := mergeConfig(v, content, ""); err != nil {
 			return fmt.Errorf("error reading config contents: %w", err)
 		}
 	}
@@ -94,6 +108,108 @@ func readEnvVars(v *viper.Viper) {
 	// here is workaround to make viper read them:
 	for _, key := range v.AllKeys() {
 		val := v.Get(key)
+		if val == nil {
+			// bound env variable which is not set
+			continue
+		}
 		v.Set(key, val)
 	}
 }
+
+// BindEnvVars makes every option of cfg configurable by an environment variable named after the option,
+// e.g. server.data_dir is read from RPORTD_SERVER_DATA_DIR for prefix RPORTD. If instead a variable with a _FILE
+// suffix is set, the value is read from the given file, so secrets mounted as files can be used.
+// Values read from files take precedence over all other sources.
+func BindEnvVars(v *viper.Viper, prefix string, cfg interface{}) error {
+	for _, key := range ConfigKeys(reflect.TypeOf(cfg)) {
+		envName := EnvVarName(prefix, key)
+		if err := v.BindEnv(key, envName); err != nil {
+			return err
+		}
+
+		filePath, ok := os.LookupEnv(envName + "_FILE")
+		if !ok {
+			continue
+		}
+		if _, ok := os.LookupEnv(envName); ok {
+			return fmt.Errorf("only one of %s and %s_FILE can be set", envName, envName)
+		}
+		content, err := os.ReadFile(filePath)
+		if err != nil {
+			return fmt.Errorf("failed to read %s_FILE: %v", envName, err)
+		}
+		v.Set(key, strings.TrimRight(string(content), "\r\n"))
+	}
+	return nil
+}
+
+// EnvVarName returns the name of the environment variable for the given config key.
+func EnvVarName(prefix, key string) string {
+	return strings.ToUpper(prefix + "_" + strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
+
+// ConfigKeys returns the viper keys of all options of the given config struct type derived from the mapstructure tags.
+func ConfigKeys(t reflect.Type) []string {
+	return configKeys(t, "")
+}
+
+func configKeys(t reflect.Type, parent string) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		tag, ok := field.Tag.Lookup("mapstructure")
+		if !ok || tag == "-" {
+			// fields without a tag are derived from other options
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if opts == "squash" {
+			keys = append(keys, configKeys(field.Type, parent)...)
+			continue
+		}
+		key := name
+		if parent != "" {
+			key = parent + "." + name
+		}
+
+		if isConfigSection(field.Type) {
+			keys = append(keys, configKeys(field.Type, key)...)
+		} else {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// isConfigSection is true for structs with options of their own, other structs like logger.LogOutput are
+// decoded from a single value.
+func isConfigSection(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if _, ok := t.Field(i).Tag.Lookup("mapstructure"); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func mergeConfigFile(v *viper.Viper, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return mergeConfig(v, content, path)
+}
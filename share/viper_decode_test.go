@@ -0,0 +1,81 @@
+package chshare
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/IOTech17/neo-rport/share/logger"
+)
+
+type testEnvSquashed struct {
+	Host string `mapstructure:"tunnel_host"`
+}
+
+type testEnvServer struct {
+	Tunnel    testEnvSquashed `mapstructure:",squash"`
+	DataDir   string          `mapstructure:"data_dir"`
+	UsedPorts []string        `mapstructure:"used_ports"`
+	Secret    string          `mapstructure:"secret"`
+	derived   string
+	Compiled  string
+}
+
+type testEnvPlugin struct {
+	Path string `mapstructure:"plugin_path"`
+}
+
+type testEnvConfig struct {
+	Server  testEnvServer    `mapstructure:"server"`
+	Logging logger.LogOutput `mapstructure:"log_file"`
+	Plugin  *testEnvPlugin   `mapstructure:"plus-plugin"`
+}
+
+func TestConfigKeys(t *testing.T) {
+	assert.Equal(t, []string{
+		"server.tunnel_host",
+		"server.data_dir",
+		"server.used_ports",
+		"server.secret",
+		"log_file",
+		"plus-plugin.plugin_path",
+	}, ConfigKeys(reflect.TypeOf(&testEnvConfig{})))
+}
+
+func TestBindEnvVars(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(secretFile, []byte("from-file\n"), 0600))
+
+	t.Setenv("TEST_SERVER_DATA_DIR", "/var/lib/test")
+	t.Setenv("TEST_SERVER_USED_PORTS", "10-20,30")
+	t.Setenv("TEST_SERVER_SECRET_FILE", secretFile)
+	t.Setenv("TEST_PLUS_PLUGIN_PLUGIN_PATH", "/usr/local/lib/plugin.so")
+
+	v := viper.New()
+	v.SetConfigType("toml")
+	cfg := &testEnvConfig{}
+	require.NoError(t, BindEnvVars(v, "TEST", cfg))
+	require.NoError(t, DecodeViperConfig(v, cfg, strings.NewReader("[server]\n  data_dir = \"/tmp\"\n  tunnel_host = \"example.com\"\n")))
+
+	assert.Equal(t, "/var/lib/test", cfg.Server.DataDir)
+	assert.Equal(t, "example.com", cfg.Server.Tunnel.Host)
+	assert.Equal(t, []string{"10-20", "30"}, cfg.Server.UsedPorts)
+	assert.Equal(t, "from-file", cfg.Server.Secret)
+	require.NotNil(t, cfg.Plugin)
+	assert.Equal(t, "/usr/local/lib/plugin.so", cfg.Plugin.Path)
+}
+
+func TestBindEnvVarsValueAndFile(t *testing.T) {
+	t.Setenv("TEST_SERVER_SECRET", "value")
+	t.Setenv("TEST_SERVER_SECRET_FILE", "/some/file")
+
+	err := BindEnvVars(viper.New(), "TEST", &testEnvConfig{})
+
+	assert.EqualError(t, err, "only one of TEST_SERVER_SECRET and TEST_SERVER_SECRET_FILE can be set")
+}